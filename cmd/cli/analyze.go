@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/models"
+)
+
+var (
+	analyzeAgents    []string
+	analyzeConsensus string
+	analyzeAPI       string
+	analyzeJSON      bool
+	analyzeTimeout   time.Duration
+)
+
+// analyzePollInterval is how often the workflow is polled when its progress
+// cannot be streamed
+const analyzePollInterval = 2 * time.Second
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze <symbol>",
+	Short: "Run the AI agents on a symbol and show their signals",
+	Long: `Start an AI analysis workflow for a symbol through the API, follow its
+progress step by step, and print each agent's signal and reasoning with the
+consensus they reach.
+
+The workflow runs on the server; interrupting the command stops following it
+but not the analysis. Progress is written to stderr, so --json output can be
+piped.`,
+	Example: `  hedge-fund analyze AAPL --agents warren_buffett,cathie_wood
+  hedge-fund analyze MSFT --consensus performance_weighted --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if analyzeAPI == "" {
+			analyzeAPI = config.Load().RiskServiceURL
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		ctx, cancel := context.WithTimeout(ctx, analyzeTimeout)
+		defer cancel()
+
+		client := &analysisClient{api: newAPIClient(analyzeAPI, "")}
+		status, err := client.start(ctx, models.AIAnalysisRequest{
+			Symbol:    args[0],
+			Agents:    analyzeAgents,
+			Consensus: analyzeConsensus,
+		})
+		if err != nil {
+			return err
+		}
+
+		progress := newProgressPrinter(cmd.ErrOrStderr())
+		progress.print(status)
+		requestID := status.RequestID
+		if status, err = client.follow(ctx, requestID, progress.print); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("stopped following workflow %s: %w", requestID, ctx.Err())
+			}
+			return err
+		}
+		if status.Status == models.JobStatusFailed {
+			return fmt.Errorf("analysis failed: %s", status.ErrorMessage)
+		}
+
+		out := cmd.OutOrStdout()
+		if analyzeJSON {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(status.Result)
+		}
+		printAnalysis(out, status.Result)
+		return nil
+	},
+}
+
+func init() {
+	flags := analyzeCmd.Flags()
+	flags.StringSliceVar(&analyzeAgents, "agents", nil, "Agents to run, comma-separated (all enabled agents when empty)")
+	flags.StringVar(&analyzeConsensus, "consensus", "", "Consensus strategy: majority, confidence_weighted or performance_weighted")
+	flags.StringVar(&analyzeAPI, "api", "", "Base URL of the risk service API (RISK_SERVICE_URL when empty)")
+	flags.BoolVar(&analyzeJSON, "json", false, "Print the analysis as JSON")
+	flags.DurationVar(&analyzeTimeout, "timeout", 10*time.Minute, "How long to wait for the analysis")
+}
+
+// analysisClient drives AI workflows through the HTTP API
+type analysisClient struct {
+	api *apiClient
+}
+
+func (c *analysisClient) start(ctx context.Context, req models.AIAnalysisRequest) (*models.WorkflowStatus, error) {
+	var status models.WorkflowStatus
+	if err := c.api.call(ctx, http.MethodPost, "/api/v1/ai/workflows", req, &status); err != nil {
+		return nil, fmt.Errorf("failed to start analysis: %w", err)
+	}
+	return &status, nil
+}
+
+func (c *analysisClient) get(ctx context.Context, requestID string) (*models.WorkflowStatus, error) {
+	var status models.WorkflowStatus
+	if err := c.api.call(ctx, http.MethodGet, "/api/v1/ai/workflows/"+requestID, nil, &status); err != nil {
+		return nil, fmt.Errorf("failed to get workflow: %w", err)
+	}
+	return &status, nil
+}
+
+// follow reports a workflow's status to fn on every change until it
+// finishes. Progress is streamed, falling back to polling when the stream is
+// unavailable or ends early.
+func (c *analysisClient) follow(ctx context.Context, requestID string, fn func(*models.WorkflowStatus)) (*models.WorkflowStatus, error) {
+	var last *models.WorkflowStatus
+	_ = c.api.events(ctx, "/api/v1/ai/workflows/"+requestID, func(_ string, data []byte) bool {
+		var status models.WorkflowStatus
+		if err := json.Unmarshal(data, &status); err != nil {
+			return false
+		}
+		last = &status
+		fn(last)
+		return !finished(last)
+	})
+	if last != nil && finished(last) {
+		return last, nil
+	}
+
+	ticker := time.NewTicker(analyzePollInterval)
+	defer ticker.Stop()
+	for {
+		status, err := c.get(ctx, requestID)
+		if err != nil {
+			return nil, err
+		}
+		fn(status)
+		if finished(status) {
+			return status, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func finished(status *models.WorkflowStatus) bool {
+	return status.Status == models.JobStatusCompleted || status.Status == models.JobStatusFailed
+}
+
+// progressPrinter writes a line whenever a workflow step changes status
+type progressPrinter struct {
+	out   io.Writer
+	steps map[string]string
+}
+
+func newProgressPrinter(out io.Writer) *progressPrinter {
+	return &progressPrinter{out: out, steps: make(map[string]string)}
+}
+
+func (p *progressPrinter) print(status *models.WorkflowStatus) {
+	for _, step := range status.Steps {
+		if p.steps[step.Name] == step.Status {
+			continue
+		}
+		p.steps[step.Name] = step.Status
+		if step.Status == models.JobStatusPending {
+			continue
+		}
+		line := fmt.Sprintf("[%3.0f%%] %-20s %s", status.Progress, step.Name, step.Status)
+		if step.Attempts > 1 {
+			line += fmt.Sprintf(" (attempt %d)", step.Attempts)
+		}
+		if step.Error != "" && step.Status != models.JobStatusCompleted {
+			line += ": " + step.Error
+		}
+		fmt.Fprintln(p.out, line)
+	}
+}
+
+// printAnalysis renders an analysis for the terminal
+func printAnalysis(out io.Writer, result *models.AIAnalysisResponse) {
+	if result == nil {
+		fmt.Fprintln(out, "No analysis result")
+		return
+	}
+
+	fmt.Fprintln(out)
+	title := result.Symbol
+	if result.Instrument != nil && result.Instrument.Name != "" {
+		title += " - " + result.Instrument.Name
+	}
+	fmt.Fprintln(out, title)
+	if result.MarketData != nil && result.MarketData.CurrentPrice > 0 {
+		fmt.Fprintf(out, "Price: %.2f\n", result.MarketData.CurrentPrice)
+	}
+	if result.RiskMetrics != nil {
+		fmt.Fprintf(out, "Volatility: %.1f%%  VaR 95%%: %.1f%%  Beta: %.2f\n",
+			result.RiskMetrics.Volatility*100, result.RiskMetrics.VaR95*100, result.RiskMetrics.Beta)
+	}
+	fmt.Fprintf(out, "\nConsensus: %s (%.1f%% confidence, %s)\n\n",
+		strings.ToUpper(result.ConsensusSignal), result.ConsensusConfidence, result.ConsensusStrategy)
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "AGENT\tSIGNAL\tCONFIDENCE\tPRICE")
+	for _, s := range result.Signals {
+		fmt.Fprintf(w, "%s\t%s\t%.1f%%\t%.2f\n", s.AgentName, strings.ToUpper(s.Signal), s.Confidence, s.Price)
+	}
+	w.Flush()
+
+	for _, s := range result.Signals {
+		if s.Reasoning == "" {
+			continue
+		}
+		fmt.Fprintf(out, "\n%s:\n", s.AgentName)
+		for _, line := range wrap(s.Reasoning, 76) {
+			fmt.Fprintln(out, "  "+line)
+		}
+	}
+}
+
+// wrap breaks text into lines of at most width characters at spaces,
+// keeping its paragraphs
+func wrap(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && len(line)+1+len(word) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
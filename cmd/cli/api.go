@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+// maxEventSize bounds one server-sent event; a completed workflow status
+// carries every agent's reasoning
+const maxEventSize = 4 * 1024 * 1024
+
+// apiClient calls a service's HTTP API, as the user of the login session
+// named by token when it is set
+type apiClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func newAPIClient(baseURL string, token string) *apiClient {
+	return &apiClient{baseURL: strings.TrimRight(baseURL, "/"), token: token, http: &http.Client{}}
+}
+
+func (c *apiClient) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set(requestctx.HeaderAuth, "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// call sends a JSON request and decodes a successful JSON response into v
+func (c *apiClient) call(ctx context.Context, method, path string, body, v interface{}) error {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// events calls fn with the name and data of each server-sent event read from
+// path until fn returns false. A stream ending before that is an error.
+func (c *apiClient) events(ctx context.Context, path string, fn func(event string, data []byte) bool) error {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return responseError(resp)
+	}
+
+	var event string
+	var data []byte
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxEventSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data != nil && !fn(event, data) {
+				return nil
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data != nil {
+				data = append(data, '\n')
+			}
+			data = append(data, strings.TrimSpace(strings.TrimPrefix(line, "data:"))...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// responseError describes an unexpected response by its error envelope
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var apiErr apierror.Response
+	if json.Unmarshal(body, &apiErr) != nil || apiErr.Error == "" {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	if apiErr.Details != "" {
+		return errors.New(apiErr.Error + ": " + apiErr.Details)
+	}
+	return errors.New(apiErr.Error)
+}
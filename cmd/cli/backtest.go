@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	airepo "hedge-fund/internal/ai/repository"
+	aiservice "hedge-fund/internal/ai/service"
+	"hedge-fund/internal/backtest/engine"
+	"hedge-fund/internal/backtest/repository"
+	"hedge-fund/internal/backtest/service"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/riskfree"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+var (
+	backtestStrategy     string
+	backtestSymbols      []string
+	backtestFrom         string
+	backtestTo           string
+	backtestLookback     int
+	backtestRebalance    int
+	backtestThreshold    float64
+	backtestPositionSize float64
+	backtestCash         float64
+	backtestCommission   float64
+	backtestRiskFree     float64
+	backtestEquityCurve  string
+	backtestTrades       string
+	backtestJSON         bool
+)
+
+var backtestCmd = &cobra.Command{
+	Use:   "backtest",
+	Short: "Backtest a strategy over stored price history",
+	Long: `Run the backtesting engine over the daily bars stored for the symbols
+between --from and --to, both inclusive, and print the run's summary
+statistics.
+
+The momentum strategy holds symbols whose return over --lookback bars beats
+--threshold. The agents strategy replays the signals the AI agents recorded
+over the period, trading each bar on their consensus.
+
+--equity-curve and --trades write the run's daily equity and its trades as
+CSV files.`,
+	Example: `  hedge-fund backtest --strategy agents --symbols AAPL,MSFT --from 2020-01-01 --to 2023-12-31
+  hedge-fund backtest --symbols SPY --from 2018-01-01 --to 2023-12-31 --lookback 50 --equity-curve equity.csv`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, err := time.Parse("2006-01-02", backtestFrom)
+		if err != nil {
+			return fmt.Errorf("invalid --from date: %w", err)
+		}
+		to, err := time.Parse("2006-01-02", backtestTo)
+		if err != nil {
+			return fmt.Errorf("invalid --to date: %w", err)
+		}
+
+		req := service.BacktestRequest{
+			Symbols:   symbols.NormalizeAll(backtestSymbols),
+			StartDate: from,
+			EndDate:   to.AddDate(0, 0, 1).Add(-time.Nanosecond),
+			Strategy:  backtestStrategy,
+			Params: engine.Params{
+				SignalThreshold: backtestThreshold,
+				Lookback:        backtestLookback,
+				RebalanceEvery:  backtestRebalance,
+				PositionSize:    backtestPositionSize,
+			},
+			Config: engine.Config{
+				InitialCash:    backtestCash,
+				CommissionRate: backtestCommission,
+			},
+		}
+		if cmd.Flags().Changed("risk-free") {
+			req.RiskFreeRate = &backtestRiskFree
+		}
+
+		svc, cleanup, err := newBacktestService(backtestStrategy == service.StrategyAgents)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		result, err := svc.RunBacktest(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		if backtestEquityCurve != "" {
+			if err := writeCSVFile(backtestEquityCurve, func(w *csv.Writer) error { return writeEquityCurve(w, result.EquityCurve) }); err != nil {
+				return err
+			}
+		}
+		if backtestTrades != "" {
+			if err := writeCSVFile(backtestTrades, func(w *csv.Writer) error { return writeBacktestTrades(w, result.Trades) }); err != nil {
+				return err
+			}
+		}
+
+		out := cmd.OutOrStdout()
+		if backtestJSON {
+			summary := *result
+			summary.EquityCurve, summary.Trades = nil, nil
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(summary)
+		}
+		printBacktest(out, backtestStrategy, result)
+		return nil
+	},
+}
+
+func init() {
+	defaults := engine.DefaultConfig()
+	flags := backtestCmd.Flags()
+	flags.StringVar(&backtestStrategy, "strategy", service.StrategyMomentum, "Strategy to backtest: momentum or agents")
+	flags.StringSliceVar(&backtestSymbols, "symbols", nil, "Symbols to trade, comma-separated")
+	flags.StringVar(&backtestFrom, "from", "", "First day of the backtest (YYYY-MM-DD)")
+	flags.StringVar(&backtestTo, "to", "", "Last day of the backtest (YYYY-MM-DD)")
+	flags.IntVar(&backtestLookback, "lookback", 20, "Bars of history the momentum signal uses")
+	flags.IntVar(&backtestRebalance, "rebalance", 5, "Bars between rebalances")
+	flags.Float64Var(&backtestThreshold, "threshold", 0, "Minimum lookback return (%) for momentum to hold a symbol")
+	flags.Float64Var(&backtestPositionSize, "position-size", 1, "Fraction of equity per held symbol (0-1]")
+	flags.Float64Var(&backtestCash, "cash", defaults.InitialCash, "Starting cash")
+	flags.Float64Var(&backtestCommission, "commission", defaults.CommissionRate, "Commission as a fraction of traded value")
+	flags.Float64Var(&backtestRiskFree, "risk-free", 0, "Annual risk-free rate for the Sharpe ratio (the configured source's rate when unset)")
+	flags.StringVar(&backtestEquityCurve, "equity-curve", "", "Write the equity curve to a CSV file")
+	flags.StringVar(&backtestTrades, "trades", "", "Write the trade log to a CSV file")
+	flags.BoolVar(&backtestJSON, "json", false, "Print the summary as JSON")
+	backtestCmd.MarkFlagRequired("symbols")
+	backtestCmd.MarkFlagRequired("from")
+	backtestCmd.MarkFlagRequired("to")
+}
+
+// newBacktestService builds a backtest service on the database, able to
+// replay the agents' signals when agents is set
+func newBacktestService(agents bool) (*service.BacktestService, func(), error) {
+	cfg := config.Load()
+	if err := logger.Init(cfg.LogLevel, cfg.Env); err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bars := repository.NewBarRepository(db, logger.Logger)
+	svc := service.NewBacktestService(bars, riskfree.NewSource(db, cfg), nil, nil, cfg.BacktestConcurrency, logger.Logger)
+	if agents {
+		svc.SetAgents(aiservice.NewAgentService(airepo.NewAgentRepository(db, logger.Logger), bars, cfg.AgentPerformanceLookback, logger.Logger))
+	}
+	return svc, func() {
+		db.Close()
+		logger.Sync()
+	}, nil
+}
+
+// printBacktest renders a backtest's summary statistics for the terminal
+func printBacktest(out io.Writer, strategy string, result *engine.Result) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Strategy\t%s\n", strategy)
+	fmt.Fprintf(w, "Period\t%s to %s (%d bars)\n", result.StartDate.Format("2006-01-02"), result.EndDate.Format("2006-01-02"), result.Bars)
+	fmt.Fprintf(w, "Initial cash\t%s\n", formatMoney(result.InitialCash))
+	fmt.Fprintf(w, "Final equity\t%s\n", formatMoney(result.FinalEquity))
+	fmt.Fprintf(w, "Total return\t%.2f%%\n", result.TotalReturn)
+	fmt.Fprintf(w, "Volatility\t%.2f%%\n", result.Volatility)
+	fmt.Fprintf(w, "Sharpe ratio\t%.2f\n", result.SharpeRatio)
+	fmt.Fprintf(w, "Max drawdown\t%.2f%%\n", result.MaxDrawdown)
+	fmt.Fprintf(w, "Trades\t%d\n", result.TradeCount)
+	w.Flush()
+}
+
+func writeEquityCurve(w *csv.Writer, curve []engine.EquityPoint) error {
+	w.Write([]string{"date", "equity"})
+	for _, point := range curve {
+		w.Write([]string{point.Timestamp.Format("2006-01-02"), strconv.FormatFloat(point.Equity, 'f', 2, 64)})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeBacktestTrades(w *csv.Writer, trades []engine.Trade) error {
+	w.Write([]string{"date", "symbol", "side", "shares", "price", "value", "commission"})
+	for _, t := range trades {
+		w.Write([]string{
+			t.Timestamp.Format("2006-01-02"), t.Symbol, t.Side,
+			strconv.FormatFloat(t.Shares, 'f', -1, 64),
+			strconv.FormatFloat(t.Price, 'f', 2, 64),
+			strconv.FormatFloat(t.Value, 'f', 2, 64),
+			strconv.FormatFloat(t.Commission, 'f', 2, 64),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeCSVFile creates path and writes it with write
+func writeCSVFile(path string, write func(*csv.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := write(csv.NewWriter(f)); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
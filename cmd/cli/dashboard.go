@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/models"
+)
+
+var (
+	dashboardPortfolio int
+	dashboardToken     string
+	dashboardAPI       string
+	dashboardMarketAPI string
+	dashboardRefresh   time.Duration
+	dashboardTrades    int
+)
+
+// dashboardRequestTimeout bounds each refresh of the portfolio
+const dashboardRequestTimeout = 10 * time.Second
+
+var dashboardCmd = &cobra.Command{
+	Use:   "dashboard",
+	Short: "Watch a portfolio live in the terminal",
+	Long: `Show a portfolio's value, positions and recent trades, refreshed in place.
+
+Positions are revalued as live prices stream in from the market data
+service, between refreshes of the portfolio itself. Gains are shown in green
+and losses in red. Press r to refresh now and q to quit.`,
+	Example: `  HEDGE_FUND_TOKEN=... hedge-fund dashboard --portfolio 12`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if dashboardAPI == "" || dashboardMarketAPI == "" {
+			cfg := config.Load()
+			if dashboardAPI == "" {
+				dashboardAPI = strings.TrimSpace(strings.Split(cfg.PortfolioServiceURL, ",")[0])
+			}
+			if dashboardMarketAPI == "" {
+				dashboardMarketAPI = cfg.MarketDataServiceURL
+			}
+		}
+		if dashboardToken == "" {
+			dashboardToken = os.Getenv("HEDGE_FUND_TOKEN")
+		}
+		if dashboardToken == "" {
+			return fmt.Errorf("--token or HEDGE_FUND_TOKEN is required")
+		}
+		if dashboardRefresh <= 0 {
+			return fmt.Errorf("--refresh must be positive")
+		}
+
+		model := newDashboard(newAPIClient(dashboardAPI, dashboardToken), newAPIClient(dashboardMarketAPI, ""),
+			dashboardPortfolio, dashboardRefresh, dashboardTrades)
+		defer model.stopPrices()
+
+		_, err := tea.NewProgram(model, tea.WithAltScreen()).Run()
+		return err
+	},
+}
+
+func init() {
+	flags := dashboardCmd.Flags()
+	flags.IntVar(&dashboardPortfolio, "portfolio", 0, "Portfolio to watch")
+	flags.StringVar(&dashboardToken, "token", "", "Session token of the portfolio's owner or an admin, from POST /api/v1/auth/login (HEDGE_FUND_TOKEN when empty)")
+	flags.StringVar(&dashboardAPI, "api", "", "Base URL of the portfolio service API (PORTFOLIO_SERVICE_URL when empty)")
+	flags.StringVar(&dashboardMarketAPI, "market-api", "", "Base URL of the market data service API (MARKET_DATA_SERVICE_URL when empty)")
+	flags.DurationVar(&dashboardRefresh, "refresh", 15*time.Second, "How often the portfolio is reloaded")
+	flags.IntVar(&dashboardTrades, "trades", 10, "Recent trades shown")
+	dashboardCmd.MarkFlagRequired("portfolio")
+}
+
+var (
+	dashboardTitle  = lipgloss.NewStyle().Bold(true)
+	dashboardHeader = lipgloss.NewStyle().Bold(true).Underline(true)
+	dashboardFaint  = lipgloss.NewStyle().Faint(true)
+	dashboardGain   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	dashboardLoss   = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	dashboardPlain  = lipgloss.NewStyle()
+)
+
+// Dashboard messages
+type (
+	dashboardLoaded struct {
+		portfolio *models.Portfolio
+		trades    []models.Trade
+		err       error
+	}
+	dashboardTick time.Time
+)
+
+// livePrice is a symbol's latest streamed price and the direction it moved
+type livePrice struct {
+	price float64
+	up    bool
+}
+
+// dashboard is the bubbletea model of the dashboard command
+type dashboard struct {
+	portfolioAPI *apiClient
+	marketAPI    *apiClient
+	portfolioID  int
+	refresh      time.Duration
+	tradeLimit   int
+
+	portfolio *models.Portfolio
+	trades    []models.Trade
+	updatedAt time.Time
+	err       error
+
+	prices        map[string]livePrice
+	priceUpdates  chan tea.Msg
+	streamSymbols string
+	stopStream    context.CancelFunc
+	streaming     bool
+	streamErr     error
+}
+
+func newDashboard(portfolioAPI, marketAPI *apiClient, portfolioID int, refresh time.Duration, tradeLimit int) *dashboard {
+	return &dashboard{
+		portfolioAPI: portfolioAPI,
+		marketAPI:    marketAPI,
+		portfolioID:  portfolioID,
+		refresh:      refresh,
+		tradeLimit:   tradeLimit,
+		prices:       make(map[string]livePrice),
+		priceUpdates: make(chan tea.Msg),
+	}
+}
+
+func (d *dashboard) Init() tea.Cmd {
+	return tea.Batch(d.load, d.tick(), d.waitForPrice)
+}
+
+func (d *dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			d.stopPrices()
+			return d, tea.Quit
+		case "r":
+			return d, d.load
+		}
+	case dashboardTick:
+		return d, tea.Batch(d.load, d.tick())
+	case dashboardLoaded:
+		d.err = msg.err
+		if msg.err == nil {
+			d.portfolio, d.trades, d.updatedAt = msg.portfolio, msg.trades, time.Now()
+			d.watchPrices()
+		}
+	case priceMsg:
+		previous, seen := d.prices[msg.Symbol]
+		d.prices[msg.Symbol] = livePrice{price: msg.Price, up: !seen || msg.Price >= previous.price}
+		d.streaming, d.streamErr = true, nil
+		return d, d.waitForPrice
+	case priceStreamDown:
+		d.streaming, d.streamErr = false, msg.err
+		return d, d.waitForPrice
+	}
+	return d, nil
+}
+
+func (d *dashboard) tick() tea.Cmd {
+	return tea.Tick(d.refresh, func(t time.Time) tea.Msg { return dashboardTick(t) })
+}
+
+// load fetches the portfolio with its positions and its recent trades
+func (d *dashboard) load() tea.Msg {
+	ctx, cancel := context.WithTimeout(context.Background(), dashboardRequestTimeout)
+	defer cancel()
+
+	var portfolio models.Portfolio
+	if err := d.portfolioAPI.call(ctx, http.MethodGet, fmt.Sprintf("/api/v1/portfolios/%d", d.portfolioID), nil, &portfolio); err != nil {
+		return dashboardLoaded{err: fmt.Errorf("failed to load portfolio: %w", err)}
+	}
+	var page struct {
+		Data []models.Trade `json:"data"`
+	}
+	path := fmt.Sprintf("/api/v1/portfolios/%d/trades?limit=%d", d.portfolioID, d.tradeLimit)
+	if err := d.portfolioAPI.call(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return dashboardLoaded{err: fmt.Errorf("failed to load trades: %w", err)}
+	}
+	return dashboardLoaded{portfolio: &portfolio, trades: page.Data}
+}
+
+func (d *dashboard) waitForPrice() tea.Msg {
+	return <-d.priceUpdates
+}
+
+// watchPrices streams the prices of the portfolio's positions, restarting
+// the stream when they change
+func (d *dashboard) watchPrices() {
+	symbols := make([]string, 0, len(d.portfolio.Positions))
+	for _, p := range d.portfolio.Positions {
+		symbols = append(symbols, p.Symbol)
+	}
+	sort.Strings(symbols)
+	joined := strings.Join(symbols, ",")
+	if joined == d.streamSymbols {
+		return
+	}
+
+	d.stopPrices()
+	d.streamSymbols = joined
+	if joined == "" {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d.stopStream = cancel
+	go streamPrices(ctx, d.marketAPI, symbols, d.priceUpdates)
+}
+
+func (d *dashboard) stopPrices() {
+	if d.stopStream != nil {
+		d.stopStream()
+		d.stopStream = nil
+	}
+}
+
+func (d *dashboard) View() string {
+	var b strings.Builder
+	if d.portfolio == nil {
+		if d.err != nil {
+			b.WriteString(dashboardLoss.Render(d.err.Error()) + "\n\n")
+		} else {
+			b.WriteString("Loading portfolio...\n\n")
+		}
+		b.WriteString(dashboardFaint.Render("q quit"))
+		return b.String()
+	}
+
+	p := d.portfolio
+	status := "prices as of refresh"
+	switch {
+	case d.streaming:
+		status = "live prices"
+	case d.streamErr != nil:
+		status = "price stream down: " + d.streamErr.Error()
+	}
+	fmt.Fprintf(&b, "%s  %s\n", dashboardTitle.Render(fmt.Sprintf("%s (portfolio %d)", p.Name, p.ID)),
+		dashboardFaint.Render(fmt.Sprintf("updated %s, %s", d.updatedAt.Format("15:04:05"), status)))
+	if d.err != nil {
+		b.WriteString(dashboardLoss.Render(d.err.Error()) + "\n")
+	}
+
+	// Live prices move the values last computed by the server
+	positions := append([]models.Position(nil), p.Positions...)
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Symbol < positions[j].Symbol })
+	var move float64
+	for i := range positions {
+		price := d.price(&positions[i])
+		move += direction(&positions[i]) * (positions[i].Value(price) - positions[i].Value(positions[i].CurrentPrice))
+	}
+
+	fmt.Fprintf(&b, "\nValue %s   Cash %s   Day %s   Unrealized %s   Realized %s\n",
+		dashboardTitle.Render(formatMoney(p.TotalValue+move)), formatMoney(p.Cash),
+		pnl(p.DayPnL+move), pnl(p.UnrealizedPnL+move), pnl(p.RealizedPnL))
+
+	b.WriteString("\n" + dashboardHeader.Render("Positions") + "\n")
+	b.WriteString(dashboardFaint.Render(row(
+		col{"SYMBOL", -10}, col{"SIDE", -6}, col{"QTY", 12}, col{"ENTRY", 12}, col{"PRICE", 12},
+		col{"VALUE", 14}, col{"UNREALIZED", 14}, col{"RETURN", 9},
+	)) + "\n")
+	if len(positions) == 0 {
+		b.WriteString(dashboardFaint.Render("No positions") + "\n")
+	}
+	for i := range positions {
+		pos := &positions[i]
+		price := d.price(pos)
+		cost := pos.Value(pos.EntryPrice)
+		unrealized := direction(pos) * (pos.Value(price) - cost)
+		ret := 0.0
+		if cost != 0 {
+			ret = unrealized / cost * 100
+		}
+
+		priceStyle := dashboardPlain
+		if live, ok := d.prices[pos.Symbol]; ok {
+			priceStyle = dashboardLoss
+			if live.up {
+				priceStyle = dashboardGain
+			}
+		}
+		b.WriteString(row(
+			col{pos.Symbol, -10}, col{pos.Side, -6}, col{formatQuantity(pos.Quantity), 12}, col{formatMoney(pos.EntryPrice), 12},
+		) + priceStyle.Render(row(col{formatMoney(price), 12})) + row(col{formatMoney(pos.Value(price)), 14}) +
+			gainStyle(unrealized).Render(row(col{fmt.Sprintf("%+.2f", unrealized), 14}, col{fmt.Sprintf("%+.2f%%", ret), 9})) + "\n")
+	}
+
+	b.WriteString("\n" + dashboardHeader.Render("Recent Trades") + "\n")
+	b.WriteString(dashboardFaint.Render(row(
+		col{"TIME", -12}, col{"SIDE", -5}, col{"SYMBOL", -10}, col{"QTY", 12}, col{"PRICE", 12}, col{"VALUE", 14}, col{"STATUS", -10},
+	)) + "\n")
+	if len(d.trades) == 0 {
+		b.WriteString(dashboardFaint.Render("No trades") + "\n")
+	}
+	for i := range d.trades {
+		t := &d.trades[i]
+		at := t.CreatedAt
+		if t.ExecutedAt != nil {
+			at = *t.ExecutedAt
+		}
+		sideStyle := dashboardGain
+		if t.Side == "sell" {
+			sideStyle = dashboardLoss
+		}
+		b.WriteString(row(col{at.Local().Format("01-02 15:04"), -12}) + sideStyle.Render(row(col{t.Side, -5})) +
+			row(col{t.Symbol, -10}, col{formatQuantity(t.Quantity), 12}, col{formatMoney(t.Price), 12},
+				col{formatMoney(t.Value(t.Price)), 14}, col{t.Status, -10}) + "\n")
+	}
+
+	b.WriteString("\n" + dashboardFaint.Render(fmt.Sprintf("r refresh  q quit  (reloads every %s)", d.refresh)))
+	return b.String()
+}
+
+// price is a position's live price, or the price of the last refresh
+func (d *dashboard) price(p *models.Position) float64 {
+	if live, ok := d.prices[p.Symbol]; ok {
+		return live.price
+	}
+	return p.CurrentPrice
+}
+
+// direction is 1 for long positions and -1 for short ones, which gain as
+// the price falls
+func direction(p *models.Position) float64 {
+	if p.Side == "short" {
+		return -1
+	}
+	return 1
+}
+
+// col is a table cell, left-aligned when width is negative
+type col struct {
+	text  string
+	width int
+}
+
+// row pads cells to their widths, separated by a space. Cells are padded
+// before styling, as escape codes would throw the alignment off.
+func row(cols ...col) string {
+	var b strings.Builder
+	for _, c := range cols {
+		fmt.Fprintf(&b, "%*s ", c.width, c.text)
+	}
+	return b.String()
+}
+
+func gainStyle(v float64) lipgloss.Style {
+	switch {
+	case v > 0:
+		return dashboardGain
+	case v < 0:
+		return dashboardLoss
+	}
+	return dashboardPlain
+}
+
+func pnl(v float64) string {
+	return gainStyle(v).Render(fmt.Sprintf("%+.2f", v))
+}
+
+func formatMoney(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+func formatQuantity(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
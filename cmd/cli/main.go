@@ -29,6 +29,13 @@ func main() {
 func init() {
 	// Add commands will be implemented later
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(provisionCmd)
+	rootCmd.AddCommand(analyzeCmd)
+	rootCmd.AddCommand(dashboardCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(backtestCmd)
+	rootCmd.AddCommand(serveCmd)
 }
 
 var versionCmd = &cobra.Command{
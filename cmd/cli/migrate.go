@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"hedge-fund/internal/migration/repository"
+	"hedge-fund/internal/migration/service"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/logger"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Run data migrations against the database",
+}
+
+var (
+	backfillDryRun bool
+	backfillReport string
+)
+
+var backfillPortfoliosCmd = &cobra.Command{
+	Use:   "backfill-portfolios",
+	Short: "Assign portfolio_id to positions and trades created before portfolios",
+	Long: `Assign portfolio_id to positions and trades that have none.
+
+Rows of users with a single portfolio go to that portfolio. Trades follow the
+position they belong to. For users with several portfolios, a row goes to the
+only portfolio that already holds or trades its symbol. Anything else is
+reported as unresolved and left untouched.
+
+An applied run is recorded and can be reverted with "migrate rollback".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, cleanup, err := newMigrationService()
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		result, err := svc.BackfillPortfolios(context.Background(), backfillDryRun, os.Getenv("USER"))
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if result.DryRun {
+			fmt.Fprintf(out, "Dry run: %d rows would be assigned\n", result.Changed)
+		} else if result.RunID == 0 {
+			fmt.Fprintln(out, "Nothing to assign")
+		} else {
+			fmt.Fprintf(out, "Run %d: assigned %d rows (%d skipped, scoped since planning)\n", result.RunID, result.Changed, result.Skipped)
+		}
+
+		rules := make([]string, 0, len(result.ByRule))
+		for rule := range result.ByRule {
+			rules = append(rules, rule)
+		}
+		sort.Strings(rules)
+		for _, rule := range rules {
+			fmt.Fprintf(out, "  %-18s %d\n", rule, result.ByRule[rule])
+		}
+
+		if n := len(result.Plan.Unresolved); n > 0 {
+			fmt.Fprintf(out, "\n%d rows need manual review:\n", n)
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "TABLE\tID\tUSER\tSYMBOL\tREASON\tCANDIDATES")
+			for _, u := range result.Plan.Unresolved {
+				fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n", u.Table, u.ID, u.UserID, u.Symbol, u.Reason, joinInts(u.Candidates))
+			}
+			w.Flush()
+		}
+
+		if backfillReport != "" {
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(backfillReport, data, 0o644); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+			fmt.Fprintf(out, "\nFull plan written to %s\n", backfillReport)
+		}
+		return nil
+	},
+}
+
+var normalizeDryRun bool
+
+var normalizeSymbolsCmd = &cobra.Command{
+	Use:   "normalize-symbols",
+	Short: "Rewrite stored symbols in their normalized form",
+	Long: `Rewrite symbols stored before input normalization ("brk-b", " aapl") in
+their normalized form ("BRK.B", "AAPL") across every table with a symbol.
+
+Positions of one portfolio that become duplicates are merged into the oldest,
+which takes their trades, combined quantity and realized P&L. Rows of market
+prices and instruments that collide with existing normalized rows are dropped.
+
+An applied run is recorded but cannot be rolled back.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, cleanup, err := newMigrationService()
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		result, err := svc.NormalizeSymbols(context.Background(), normalizeDryRun, os.Getenv("USER"))
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if result.DryRun {
+			fmt.Fprintf(out, "Dry run: %d rows would change\n", result.Changed)
+		} else if result.RunID == 0 {
+			fmt.Fprintln(out, "All symbols are already normalized")
+			return nil
+		} else {
+			fmt.Fprintf(out, "Run %d: changed %d rows\n", result.RunID, result.Changed)
+		}
+
+		if len(result.Merges) > 0 {
+			fmt.Fprintf(out, "\n%d duplicate positions merged:\n", len(result.Merges))
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "PORTFOLIO\tSYMBOL\tKEEP\tREMOVE\tQUANTITY")
+			for _, m := range result.Merges {
+				fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%v\n", m.PortfolioID, m.Symbol, m.KeepID, joinInts(m.RemoveIDs), m.Quantity)
+			}
+			w.Flush()
+		}
+		if len(result.Renames) > 0 {
+			fmt.Fprintf(out, "\n%d symbols renamed:\n", len(result.Renames))
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "TABLE\tFROM\tTO\tROWS")
+			for _, r := range result.Renames {
+				fmt.Fprintf(w, "%s\t%q\t%s\t%d\n", r.Table, r.From, r.To, r.Rows)
+			}
+			w.Flush()
+		}
+		return nil
+	},
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <run-id>",
+	Short: "Revert an applied data migration run",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid run ID: %s", args[0])
+		}
+
+		svc, cleanup, err := newMigrationService()
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		reverted, err := svc.Rollback(context.Background(), runID)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Run %d rolled back: %d rows reverted\n", runID, reverted)
+		return nil
+	},
+}
+
+var runsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "List recent data migration runs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, cleanup, err := newMigrationService()
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		runs, err := svc.GetRuns(context.Background(), 20)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tSTATUS\tCHANGED\tUNRESOLVED\tBY\tCREATED")
+		for _, run := range runs {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%s\t%s\n", run.ID, run.Name, run.Status, run.Changed, run.Unresolved,
+				run.AppliedBy, run.CreatedAt.Format("2006-01-02 15:04"))
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	backfillPortfoliosCmd.Flags().BoolVar(&backfillDryRun, "dry-run", false, "Plan the backfill without changing any rows")
+	backfillPortfoliosCmd.Flags().StringVar(&backfillReport, "report", "", "Write the full plan, including unresolved rows, to a JSON file")
+
+	normalizeSymbolsCmd.Flags().BoolVar(&normalizeDryRun, "dry-run", false, "Plan the normalization without changing any rows")
+
+	migrateCmd.AddCommand(backfillPortfoliosCmd, normalizeSymbolsCmd, rollbackCmd, runsCmd)
+}
+
+// newMigrationService connects to the database from the environment
+func newMigrationService() (*service.MigrationService, func(), error) {
+	cfg := config.Load()
+	if err := logger.Init(cfg.LogLevel, cfg.Env); err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	svc := service.NewMigrationService(repository.NewMigrationRepository(db, logger.Logger), logger.Logger)
+	return svc, func() {
+		db.Close()
+		logger.Sync()
+	}, nil
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
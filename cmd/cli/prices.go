@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"hedge-fund/pkg/shared/models"
+)
+
+// priceStreamRetry is how long to wait before reconnecting a price stream
+// that ended
+const priceStreamRetry = 5 * time.Second
+
+// Price stream messages
+type (
+	priceMsg        models.PriceSnapshotEvent
+	priceStreamDown struct{ err error }
+)
+
+// streamPrices forwards the market data service's live prices of symbols to
+// updates, with a priceStreamDown whenever the stream ends, reconnecting
+// until ctx is cancelled
+func streamPrices(ctx context.Context, api *apiClient, symbols []string, updates chan<- tea.Msg) {
+	path := "/api/v1/market/quotes/stream?symbols=" + url.QueryEscape(strings.Join(symbols, ","))
+	send := func(msg tea.Msg) bool {
+		select {
+		case updates <- msg:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		err := api.events(ctx, path, func(_ string, data []byte) bool {
+			var snapshot models.PriceSnapshotEvent
+			if err := json.Unmarshal(data, &snapshot); err != nil {
+				return true
+			}
+			return send(priceMsg(snapshot))
+		})
+		if ctx.Err() != nil || !send(priceStreamDown{err: err}) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(priceStreamRetry):
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/internal/portfolio/repository"
+	"hedge-fund/internal/portfolio/service"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/logger"
+)
+
+var (
+	provisionSpec          string
+	provisionCount         int
+	provisionPrefix        string
+	provisionCash          float64
+	provisionPassword      string
+	provisionRole          string
+	provisionPortfolioName string
+	provisionEmailDomain   string
+	provisionOut           string
+)
+
+var provisionCmd = &cobra.Command{
+	Use:   "provision",
+	Short: "Bulk-create users with seeded paper portfolios",
+	Long: `Create users for demos, load tests or classroom competitions, each with a
+paper portfolio holding starting cash and optional preset positions.
+
+Users are generated with --count, or read from a --spec file. A .json spec
+holds the same fields as POST /api/v1/admin/provision. A .csv spec has a
+username column and optional email, full_name, starting_cash, symbol,
+quantity and price columns; a username repeated on further rows gets more
+positions. Flags set batch defaults and override those of a JSON spec.
+
+Without --password each user gets a generated password. They are printed
+once, and written with the rest of the result to --out if given.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		spec, err := readProvisionSpec()
+		if err != nil {
+			return err
+		}
+		flags := cmd.Flags()
+		if flags.Changed("count") {
+			spec.Count = provisionCount
+		}
+		if flags.Changed("prefix") {
+			spec.UsernamePrefix = provisionPrefix
+		}
+		if flags.Changed("cash") {
+			spec.StartingCash = &provisionCash
+		}
+		if flags.Changed("password") {
+			spec.Password = provisionPassword
+		}
+		if flags.Changed("role") {
+			spec.Role = provisionRole
+		}
+		if flags.Changed("portfolio-name") {
+			spec.PortfolioName = provisionPortfolioName
+		}
+		if flags.Changed("email-domain") {
+			spec.EmailDomain = provisionEmailDomain
+		}
+
+		svc, cleanup, err := newPortfolioService()
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		result, err := svc.Provision(context.Background(), spec)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Provisioned %d users\n\n", len(result.Users))
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "USER\tUSERNAME\tEMAIL\tPORTFOLIO\tPASSWORD")
+		for _, u := range result.Users {
+			password := u.Password
+			if password == "" {
+				password = "(shared)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\n", u.UserID, u.Username, u.Email, u.PortfolioID, password)
+		}
+		w.Flush()
+
+		if provisionOut != "" {
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(provisionOut, data, 0o600); err != nil {
+				return fmt.Errorf("failed to write result: %w", err)
+			}
+			fmt.Fprintf(out, "\nCredentials written to %s\n", provisionOut)
+		}
+		return nil
+	},
+}
+
+func init() {
+	flags := provisionCmd.Flags()
+	flags.StringVar(&provisionSpec, "spec", "", "JSON or CSV spec of the users to create")
+	flags.IntVar(&provisionCount, "count", 0, "Number of users to generate")
+	flags.StringVar(&provisionPrefix, "prefix", domain.DefaultProvisionPrefix, "Username prefix of generated users")
+	flags.Float64Var(&provisionCash, "cash", domain.DefaultProvisionCash, "Starting cash of each portfolio")
+	flags.StringVar(&provisionPassword, "password", "", "Password shared by every user (generated per user when empty)")
+	flags.StringVar(&provisionRole, "role", domain.DefaultProvisionRole, "Role of the users: trader, viewer or admin")
+	flags.StringVar(&provisionPortfolioName, "portfolio-name", domain.DefaultProvisionName, "Name of each portfolio")
+	flags.StringVar(&provisionEmailDomain, "email-domain", domain.DefaultProvisionDomain, "Domain of generated emails")
+	flags.StringVar(&provisionOut, "out", "", "Write the created users and credentials to a JSON file")
+}
+
+func readProvisionSpec() (*domain.ProvisionSpec, error) {
+	if provisionSpec == "" {
+		return &domain.ProvisionSpec{}, nil
+	}
+
+	f, err := os.Open(provisionSpec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spec: %w", err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(provisionSpec)) {
+	case ".json":
+		return domain.ParseProvisionJSON(f)
+	case ".csv":
+		return domain.ParseProvisionCSV(f, nil)
+	default:
+		return nil, fmt.Errorf("spec must be a .json or .csv file: %s", provisionSpec)
+	}
+}
+
+// newPortfolioService connects to the database from the environment
+func newPortfolioService() (*service.PortfolioService, func(), error) {
+	cfg := config.Load()
+	if err := logger.Init(cfg.LogLevel, cfg.Env); err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	svc := service.NewPortfolioService(repository.NewPortfolioRepository(db, logger.Logger), domain.NewPortfolioService(), logger.Logger)
+	return svc, func() {
+		db.Close()
+		logger.Sync()
+	}, nil
+}
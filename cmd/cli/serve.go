@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	"hedge-fund/internal/services"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the platform's services from this binary",
+	Long: `Run one of the platform's services, or all of them in one process, with
+the same configuration and startup as their own binaries. Running everything
+from one binary is meant for local development; each service still listens on
+its configured ports.
+
+The AI agents, workflows and auto-trading are served by the risk service, so
+"serve ai" runs it.`,
+	Example: `  hedge-fund serve all
+  hedge-fund serve portfolio`,
+}
+
+func init() {
+	for _, s := range services.All {
+		s := s
+		cmd := &cobra.Command{
+			Use:   s.Name,
+			Short: "Run the " + s.Description,
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return services.Serve(s)
+			},
+		}
+		if s.Name == services.Risk.Name {
+			cmd.Aliases = []string{"ai"}
+		}
+		serveCmd.AddCommand(cmd)
+	}
+
+	serveCmd.AddCommand(&cobra.Command{
+		Use:   "all",
+		Short: "Run every service in one process",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return services.Serve(services.All...)
+		},
+	})
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+var (
+	watchMarketAPI string
+	watchRefresh   time.Duration
+	watchAbove     map[string]string
+	watchBelow     map[string]string
+	watchMove      float64
+	watchBell      bool
+)
+
+// watchRequestTimeout bounds each reload of the closes
+const watchRequestTimeout = 10 * time.Second
+
+var watchCmd = &cobra.Command{
+	Use:   "watch <symbol>...",
+	Short: "Stream live quotes for symbols",
+	Long: `Show the last price of each symbol with its change since the latest stored
+daily close, updated as prices stream in from the market data service. The
+closes are reloaded periodically. Press r to reload now and q to quit.
+
+--above and --below set price levels per symbol and --move a percent change
+for every symbol. When a price crosses one, an alert is printed above the
+quotes and the terminal bell rings unless --bell=false.`,
+	Example: `  hedge-fund watch AAPL MSFT
+  hedge-fund watch AAPL TSLA --above AAPL=200 --below TSLA=150,AAPL=180 --move 3`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if watchMarketAPI == "" {
+			watchMarketAPI = config.Load().MarketDataServiceURL
+		}
+		if watchRefresh <= 0 {
+			return fmt.Errorf("--refresh must be positive")
+		}
+		if watchMove < 0 {
+			return fmt.Errorf("--move cannot be negative")
+		}
+
+		list := symbols.NormalizeAll(args)
+		alerts, err := priceAlerts(list, watchAbove, watchBelow, watchMove)
+		if err != nil {
+			return err
+		}
+
+		api := newAPIClient(watchMarketAPI, "")
+		model := newWatch(api, list, watchRefresh, alerts, watchBell)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go streamPrices(ctx, api, list, model.priceUpdates)
+
+		_, err = tea.NewProgram(model).Run()
+		return err
+	},
+}
+
+func init() {
+	flags := watchCmd.Flags()
+	flags.StringVar(&watchMarketAPI, "market-api", "", "Base URL of the market data service API (MARKET_DATA_SERVICE_URL when empty)")
+	flags.DurationVar(&watchRefresh, "refresh", time.Minute, "How often the closes are reloaded")
+	flags.StringToStringVar(&watchAbove, "above", nil, "Alert when a symbol's price rises above a level, as SYMBOL=PRICE")
+	flags.StringToStringVar(&watchBelow, "below", nil, "Alert when a symbol's price falls below a level, as SYMBOL=PRICE")
+	flags.Float64Var(&watchMove, "move", 0, "Alert when a symbol moves this many percent from its close")
+	flags.BoolVar(&watchBell, "bell", true, "Ring the terminal bell on alerts")
+}
+
+// priceAlert fires when a symbol's price crosses a threshold. Only crossings
+// alert: a price already beyond the threshold when first seen does not, and
+// the alert rearms once the price comes back.
+type priceAlert struct {
+	symbol string
+	kind   string // "above", "below" or "move"
+	level  float64
+
+	seen   bool
+	beyond bool
+}
+
+// priceAlerts builds the alerts of the watched symbols from the flags
+func priceAlerts(watched []string, above, below map[string]string, move float64) ([]*priceAlert, error) {
+	isWatched := make(map[string]bool, len(watched))
+	for _, symbol := range watched {
+		isWatched[symbol] = true
+	}
+
+	var alerts []*priceAlert
+	for _, levels := range []struct {
+		kind   string
+		values map[string]string
+	}{{"above", above}, {"below", below}} {
+		for raw, value := range levels.values {
+			symbol := symbols.Normalize(raw)
+			if !isWatched[symbol] {
+				return nil, fmt.Errorf("--%s is set for %s, which is not watched", levels.kind, symbol)
+			}
+			level, err := strconv.ParseFloat(value, 64)
+			if err != nil || level <= 0 {
+				return nil, fmt.Errorf("invalid --%s level for %s: %s", levels.kind, symbol, value)
+			}
+			alerts = append(alerts, &priceAlert{symbol: symbol, kind: levels.kind, level: level})
+		}
+	}
+	if move > 0 {
+		for _, symbol := range watched {
+			alerts = append(alerts, &priceAlert{symbol: symbol, kind: "move", level: move})
+		}
+	}
+
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].symbol < alerts[j].symbol })
+	return alerts, nil
+}
+
+// check records the symbol's price and reports whether it crossed the
+// threshold. Moves are measured from the close and are not checked without
+// one.
+func (a *priceAlert) check(price, closePrice float64) bool {
+	var beyond bool
+	switch a.kind {
+	case "above":
+		beyond = price > a.level
+	case "below":
+		beyond = price < a.level
+	case "move":
+		if closePrice <= 0 {
+			return false
+		}
+		beyond = math.Abs(price/closePrice-1)*100 >= a.level
+	}
+
+	crossed := a.seen && beyond && !a.beyond
+	a.seen, a.beyond = true, beyond
+	return crossed
+}
+
+func (a *priceAlert) String() string {
+	switch a.kind {
+	case "above":
+		return ">" + formatMoney(a.level)
+	case "below":
+		return "<" + formatMoney(a.level)
+	}
+	return fmt.Sprintf("±%g%%", a.level)
+}
+
+// Watch messages
+type (
+	watchLoaded struct {
+		closes map[string]float64
+		err    error
+	}
+	watchTick time.Time
+)
+
+// watch is the bubbletea model of the watch command
+type watch struct {
+	marketAPI *apiClient
+	symbols   []string
+	refresh   time.Duration
+	alerts    []*priceAlert
+	bell      bool
+
+	closes    map[string]float64
+	updatedAt time.Time
+	err       error
+
+	prices       map[string]livePrice
+	priceUpdates chan tea.Msg
+	streaming    bool
+	streamErr    error
+}
+
+func newWatch(marketAPI *apiClient, symbols []string, refresh time.Duration, alerts []*priceAlert, bell bool) *watch {
+	return &watch{
+		marketAPI:    marketAPI,
+		symbols:      symbols,
+		refresh:      refresh,
+		alerts:       alerts,
+		bell:         bell,
+		closes:       make(map[string]float64),
+		prices:       make(map[string]livePrice),
+		priceUpdates: make(chan tea.Msg),
+	}
+}
+
+func (w *watch) Init() tea.Cmd {
+	return tea.Batch(w.load, w.tick(), w.waitForPrice)
+}
+
+func (w *watch) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return w, tea.Quit
+		case "r":
+			return w, w.load
+		}
+	case watchTick:
+		return w, tea.Batch(w.load, w.tick())
+	case watchLoaded:
+		w.err = msg.err
+		if msg.err != nil {
+			return w, nil
+		}
+		w.closes, w.updatedAt = msg.closes, time.Now()
+		var cmds []tea.Cmd
+		for _, symbol := range w.symbols {
+			cmds = append(cmds, w.checkAlerts(symbol)...)
+		}
+		if len(cmds) == 0 {
+			return w, nil
+		}
+		return w, tea.Sequence(cmds...)
+	case priceMsg:
+		previous, seen := w.prices[msg.Symbol]
+		w.prices[msg.Symbol] = livePrice{price: msg.Price, up: !seen || msg.Price >= previous.price}
+		w.streaming, w.streamErr = true, nil
+		return w, tea.Sequence(append(w.checkAlerts(msg.Symbol), w.waitForPrice)...)
+	case priceStreamDown:
+		w.streaming, w.streamErr = false, msg.err
+		return w, w.waitForPrice
+	}
+	return w, nil
+}
+
+func (w *watch) tick() tea.Cmd {
+	return tea.Tick(w.refresh, func(t time.Time) tea.Msg { return watchTick(t) })
+}
+
+// load fetches the latest stored close of each symbol
+func (w *watch) load() tea.Msg {
+	ctx, cancel := context.WithTimeout(context.Background(), watchRequestTimeout)
+	defer cancel()
+
+	var quotes struct {
+		Prices map[string]float64 `json:"prices"`
+	}
+	path := "/api/v1/market/quotes?symbols=" + url.QueryEscape(strings.Join(w.symbols, ","))
+	if err := w.marketAPI.call(ctx, http.MethodGet, path, nil, &quotes); err != nil {
+		return watchLoaded{err: fmt.Errorf("failed to load closes: %w", err)}
+	}
+	return watchLoaded{closes: quotes.Prices}
+}
+
+func (w *watch) waitForPrice() tea.Msg {
+	return <-w.priceUpdates
+}
+
+// checkAlerts checks symbol's alerts against its last price, printing those
+// it crossed above the quotes
+func (w *watch) checkAlerts(symbol string) []tea.Cmd {
+	last, ok := w.last(symbol)
+	if !ok {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, a := range w.alerts {
+		if a.symbol != symbol || !a.check(last, w.closes[symbol]) {
+			continue
+		}
+		bell := ""
+		if w.bell {
+			bell = "\a"
+		}
+		cmds = append(cmds, tea.Printf("%s%s %s crossed %s at %s", bell, time.Now().Format("15:04:05"),
+			symbol, a, formatMoney(last)))
+	}
+	return cmds
+}
+
+// last is a symbol's live price, or its close before any has streamed
+func (w *watch) last(symbol string) (float64, bool) {
+	if live, ok := w.prices[symbol]; ok {
+		return live.price, true
+	}
+	closePrice, ok := w.closes[symbol]
+	return closePrice, ok
+}
+
+func (w *watch) View() string {
+	var b strings.Builder
+	status := "closes only"
+	switch {
+	case w.streaming:
+		status = "live prices"
+	case w.streamErr != nil:
+		status = "price stream down: " + w.streamErr.Error()
+	}
+	updated := "loading"
+	if !w.updatedAt.IsZero() {
+		updated = "closes as of " + w.updatedAt.Format("15:04:05")
+	}
+	fmt.Fprintf(&b, "%s  %s\n", dashboardTitle.Render(fmt.Sprintf("Watching %d symbols", len(w.symbols))),
+		dashboardFaint.Render(updated+", "+status))
+	if w.err != nil {
+		b.WriteString(dashboardLoss.Render(w.err.Error()) + "\n")
+	}
+
+	b.WriteString("\n" + dashboardFaint.Render(row(
+		col{"SYMBOL", -10}, col{"LAST", 12}, col{"CHANGE", 10}, col{"CHANGE %", 9}, col{"ALERTS", -1},
+	)) + "\n")
+	for _, symbol := range w.symbols {
+		var alerts []string
+		for _, a := range w.alerts {
+			if a.symbol == symbol {
+				alerts = append(alerts, a.String())
+			}
+		}
+
+		last, ok := w.last(symbol)
+		if !ok {
+			b.WriteString(row(col{symbol, -10}, col{"-", 12}, col{"-", 10}, col{"-", 9}, col{strings.Join(alerts, " "), -1}) + "\n")
+			continue
+		}
+		priceStyle := dashboardPlain
+		if live, ok := w.prices[symbol]; ok {
+			priceStyle = dashboardLoss
+			if live.up {
+				priceStyle = dashboardGain
+			}
+		}
+		change, changePct := "-", "-"
+		var move float64
+		if closePrice := w.closes[symbol]; closePrice > 0 {
+			move = last - closePrice
+			change, changePct = fmt.Sprintf("%+.2f", move), fmt.Sprintf("%+.2f%%", move/closePrice*100)
+		}
+		b.WriteString(row(col{symbol, -10}) + priceStyle.Render(row(col{formatMoney(last), 12})) +
+			gainStyle(move).Render(row(col{change, 10}, col{changePct, 9})) + row(col{strings.Join(alerts, " "), -1}) + "\n")
+	}
+
+	b.WriteString("\n" + dashboardFaint.Render(fmt.Sprintf("r reload  q quit  (closes reload every %s)", w.refresh)))
+	return b.String()
+}
@@ -1,33 +1,15 @@
 package main
 
 import (
-	"log"
-	"net/http"
+	"fmt"
+	"os"
 
-	"github.com/gin-gonic/gin"
+	"hedge-fund/internal/services"
 )
 
 func main() {
-	r := gin.Default()
-
-	// Health check endpoint
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":  "ok",
-			"service": "api-gateway",
-		})
-	})
-
-	// API version endpoint
-	r.GET("/api/v1", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Hedge Fund API Gateway v1",
-			"version": "0.1.0",
-		})
-	})
-
-	log.Println("Starting API Gateway on :8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Failed to start server:", err)
+	if err := services.Serve(services.Gateway); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"hedge-fund/internal/services"
+)
+
+func main() {
+	if err := services.Serve(services.Notifications); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -8,19 +8,33 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"hedge-fund/internal/portfolio/domain"
 	"hedge-fund/internal/portfolio/handlers"
 	"hedge-fund/internal/portfolio/repository"
+	portfoliorpc "hedge-fund/internal/portfolio/rpc"
 	"hedge-fund/internal/portfolio/service"
+	portfoliopb "hedge-fund/pkg/proto/portfolio"
+	"hedge-fund/pkg/shared/auth"
 	"hedge-fund/pkg/shared/config"
 	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/jobledger"
 	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/models"
 	"hedge-fund/pkg/shared/redis"
+	"hedge-fund/pkg/shared/requestctx"
 )
 
 // PortfolioIntegrationTestSuite holds test dependencies
@@ -66,13 +80,14 @@ func (suite *PortfolioIntegrationTestSuite) SetupTest() {
 	// Clean database tables (in correct order due to foreign keys)
 	suite.cleanDatabase()
 
-	// Flush Redis cache
-	suite.redisClient.FlushCache(context.Background())
+	// Flush the test Redis database
+	suite.redisClient.FlushDB(context.Background())
 
 	// Setup dependencies
 	portfolioRepo := repository.NewPortfolioRepository(suite.db, logger.Logger)
 	domainService := domain.NewPortfolioService()
 	portfolioService := service.NewPortfolioService(portfolioRepo, domainService, logger.Logger)
+	portfolioService.SetJobLedger(jobledger.New(suite.db))
 	marketClient := handlers.NewMockMarketDataClient()
 	portfolioHandler := handlers.NewPortfolioHandler(portfolioService, marketClient, logger.Logger)
 
@@ -88,15 +103,28 @@ func (suite *PortfolioIntegrationTestSuite) SetupTest() {
 		v1.POST("/portfolios", portfolioHandler.CreatePortfolio)
 		v1.GET("/portfolios/:id", portfolioHandler.GetPortfolio)
 		v1.PUT("/portfolios/:id", portfolioHandler.UpdatePortfolio)
+		v1.PATCH("/portfolios/:id", portfolioHandler.PatchPortfolio)
 		v1.DELETE("/portfolios/:id", portfolioHandler.DeletePortfolio)
 		v1.GET("/portfolios/user/:user_id", portfolioHandler.ListUserPortfolios)
 		v1.GET("/portfolios/:id/positions", portfolioHandler.GetPositions)
+		v1.POST("/portfolios/:id/position-alerts", portfolioHandler.CreatePositionAlert)
+		v1.GET("/portfolios/:id/position-alerts", portfolioHandler.ListPositionAlerts)
+		v1.PUT("/portfolios/:id/position-alerts/:alert_id", portfolioHandler.UpdatePositionAlert)
 		v1.GET("/portfolios/:id/summary", portfolioHandler.GetSummary)
+		v1.GET("/users/:user_id/overview", portfolioHandler.GetUserOverview)
 		v1.GET("/portfolios/:id/allocation", portfolioHandler.GetAllocation)
 		v1.GET("/portfolios/:id/risk", portfolioHandler.GetRiskMetrics)
+		v1.GET("/portfolios/:id/performance", portfolioHandler.GetPerformance)
 		v1.POST("/portfolios/:id/trades", portfolioHandler.ExecuteTrade)
 		v1.GET("/portfolios/:id/trades", portfolioHandler.GetTradeHistory)
+		v1.GET("/portfolios/:id/trade-events", portfolioHandler.GetTradeEvents)
+		v1.GET("/portfolios/:id/trade-events/replay", portfolioHandler.ReplayTradeEvents)
+		v1.POST("/portfolios/:id/cash-transactions", portfolioHandler.CreateCashTransaction)
+		v1.GET("/portfolios/:id/cash-transactions", portfolioHandler.GetCashTransactions)
 		v1.POST("/portfolios/:id/rebalance", portfolioHandler.GetRebalanceRecommendations)
+		v1.GET("/portfolios/:id/audit", portfolioHandler.GetAuditTrail)
+		v1.GET("/portfolios/:id/settings", portfolioHandler.GetSettings)
+		v1.PATCH("/portfolios/:id/settings", portfolioHandler.UpdateSettings)
 	}
 
 	suite.router = router
@@ -120,11 +148,43 @@ func (suite *PortfolioIntegrationTestSuite) getTestUserID() int {
 
 func (suite *PortfolioIntegrationTestSuite) cleanDatabase() {
 	ctx := context.Background()
+	suite.db.ExecContext(ctx, "DELETE FROM processed_jobs")
 	suite.db.ExecContext(ctx, "DELETE FROM trades")
 	suite.db.ExecContext(ctx, "DELETE FROM positions")
 	suite.db.ExecContext(ctx, "DELETE FROM portfolios")
 }
 
+// rpcServer creates the portfolio gRPC server over the suite's service
+func (suite *PortfolioIntegrationTestSuite) rpcServer() *portfoliorpc.Server {
+	return portfoliorpc.NewServer(suite.service, handlers.NewMockMarketDataClient(), auth.NewAuthorizer(suite.db, logger.Logger), logger.Logger)
+}
+
+// createUser creates a user with role, or resets the role of an existing
+// one, and returns their ID
+func (suite *PortfolioIntegrationTestSuite) createUser(username, role string) int {
+	var userID int
+	err := suite.db.QueryRowContext(context.Background(), `
+		INSERT INTO users (username, email, password_hash, role)
+		VALUES ($1, $1 || '@example.com', 'unused', $2)
+		ON CONFLICT (username) DO UPDATE SET role = EXCLUDED.role, is_active = true
+		RETURNING id`, username, role).Scan(&userID)
+	suite.Require().NoError(err)
+	return userID
+}
+
+// actingAs returns the context of a gRPC call a service makes on behalf of
+// userID, as the service token interceptor leaves it
+func actingAs(userID int) context.Context {
+	return requestctx.WithActor(context.Background(), strconv.Itoa(userID))
+}
+
+// tradePage is the list envelope of the trade history endpoint
+type tradePage struct {
+	Data       []handlers.TradeResponse `json:"data"`
+	NextCursor string                   `json:"next_cursor"`
+	Total      int                      `json:"total"`
+}
+
 func (suite *PortfolioIntegrationTestSuite) makeRequest(method, path string, body interface{}) *httptest.ResponseRecorder {
 	var reqBody *bytes.Buffer
 	if body != nil {
@@ -165,9 +225,58 @@ func (suite *PortfolioIntegrationTestSuite) TestCreatePortfolio() {
 	assert.NotZero(suite.T(), response.ID)
 }
 
+func (suite *PortfolioIntegrationTestSuite) TestPatchPortfolio() {
+	portfolio, err := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{
+		Name:         "Draft",
+		BaseCurrency: "EUR",
+		StrategyTags: []string{"Value"},
+	}, 50000.00)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []string{"value"}, portfolio.StrategyTags)
+
+	name, description := "Dividend Growth", "Quality dividend payers"
+	path := fmt.Sprintf("/api/v1/portfolios/%d", portfolio.ID)
+	w := suite.makeRequest("PATCH", path, handlers.PatchPortfolioRequest{
+		Name:         &name,
+		Description:  &description,
+		StrategyTags: []string{"income", "dividends"},
+	})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handlers.PortfolioResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(suite.T(), "Dividend Growth", response.Name)
+	assert.Equal(suite.T(), "Quality dividend payers", response.Description)
+	assert.Equal(suite.T(), []string{"income", "dividends"}, response.StrategyTags)
+
+	// The base currency is kept as the portfolio's setting
+	settings, err := suite.service.GetSettings(context.Background(), portfolio.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "EUR", settings.BaseCurrency)
+
+	empty := " "
+	w = suite.makeRequest("PATCH", path, handlers.PatchPortfolioRequest{Name: &empty})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// Settings are patched alongside, on the condition of the version read
+	w = suite.makeRequest("PATCH", path, handlers.PatchPortfolioRequest{
+		Settings: map[string]json.RawMessage{"benchmark": json.RawMessage(`"QQQ"`)},
+		Version:  response.Version,
+	})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), fmt.Sprintf(`"%d"`, response.Version+1), w.Header().Get("ETag"))
+	settings, err = suite.service.GetSettings(context.Background(), portfolio.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "QQQ", settings.Benchmark)
+
+	// A patch based on the earlier version conflicts
+	w = suite.makeRequest("PATCH", path, handlers.PatchPortfolioRequest{Name: &name, Version: response.Version})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
 func (suite *PortfolioIntegrationTestSuite) TestGetPortfolio() {
 	// Create test portfolio
-	portfolio, err := suite.service.CreatePortfolio(context.Background(), suite.testUserID, "My Portfolio", 50000.00)
+	portfolio, err := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "My Portfolio"}, 50000.00)
 	suite.Require().NoError(err)
 
 	// Get portfolio
@@ -184,7 +293,7 @@ func (suite *PortfolioIntegrationTestSuite) TestGetPortfolio() {
 }
 
 func (suite *PortfolioIntegrationTestSuite) TestExecuteTradeBuy() {
-	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, "Trading Portfolio", 100000.00)
+	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Trading Portfolio"}, 100000.00)
 
 	tradeReq := handlers.TradeRequest{
 		Symbol:    "AAPL",
@@ -203,11 +312,11 @@ func (suite *PortfolioIntegrationTestSuite) TestExecuteTradeBuy() {
 	assert.Equal(suite.T(), "AAPL", response.Symbol)
 	assert.Equal(suite.T(), "filled", response.Status)
 	assert.NotZero(suite.T(), response.Price)
-	assert.Equal(suite.T(), int64(10), response.Quantity)
+	assert.Equal(suite.T(), 10.0, response.Quantity)
 }
 
 func (suite *PortfolioIntegrationTestSuite) TestExecuteTradeSell() {
-	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, "Trading Portfolio", 100000.00)
+	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Trading Portfolio"}, 100000.00)
 
 	// First buy shares
 	buyReq := handlers.TradeRequest{
@@ -233,11 +342,68 @@ func (suite *PortfolioIntegrationTestSuite) TestExecuteTradeSell() {
 	var response handlers.TradeResponse
 	json.Unmarshal(w.Body.Bytes(), &response)
 	assert.Equal(suite.T(), "sell", response.Side)
-	assert.Equal(suite.T(), int64(5), response.Quantity)
+	assert.Equal(suite.T(), 5.0, response.Quantity)
+}
+
+func (suite *PortfolioIntegrationTestSuite) TestExecuteTradeDryRun() {
+	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Dry Run Portfolio"}, 100000.00)
+
+	tradeReq := handlers.TradeRequest{
+		Symbol:    "AAPL",
+		Side:      "buy",
+		Quantity:  10,
+		OrderType: "market",
+	}
+
+	path := fmt.Sprintf("/api/v1/portfolios/%d/trades?dry_run=true", portfolio.ID)
+	w := suite.makeRequest("POST", path, tradeReq)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handlers.TradePreviewResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.True(suite.T(), response.DryRun)
+	assert.Zero(suite.T(), response.Trade.ID)
+	assert.NotZero(suite.T(), response.Trade.Fees)
+	assert.Equal(suite.T(), 100000.00, response.CashBefore)
+	assert.InDelta(suite.T(), 100000.00-10*response.Trade.Price-response.Trade.Fees, response.CashAfter, 0.01)
+	suite.Require().NotNil(response.Position)
+	assert.Equal(suite.T(), 10.0, response.Position.Quantity)
+
+	// Nothing was saved
+	saved, err := suite.service.GetPortfolio(context.Background(), portfolio.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 100000.00, saved.Cash)
+	assert.Empty(suite.T(), saved.Positions)
+}
+
+func (suite *PortfolioIntegrationTestSuite) TestSettingsFeeSchedule() {
+	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Settings Portfolio"}, 100000.00)
+	path := fmt.Sprintf("/api/v1/portfolios/%d/settings", portfolio.ID)
+
+	w := suite.makeRequest("PATCH", path, map[string]interface{}{"auto_trade_enabled": true})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code) // Manual strategy can't auto-trade
+
+	w = suite.makeRequest("PATCH", path, map[string]interface{}{"commission_rate": 0.01, "min_commission": 0})
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var settings handlers.SettingsResponse
+	json.Unmarshal(w.Body.Bytes(), &settings)
+	assert.Equal(suite.T(), 0.01, settings.CommissionRate)
+	assert.Equal(suite.T(), "portfolio", settings.Sources["commission_rate"])
+	assert.Equal(suite.T(), "default", settings.Sources["benchmark"])
+
+	tradeReq := handlers.TradeRequest{Symbol: "AAPL", Side: "buy", Quantity: 10, OrderType: "market"}
+	w = suite.makeRequest("POST", fmt.Sprintf("/api/v1/portfolios/%d/trades", portfolio.ID), tradeReq)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var trade handlers.TradeResponse
+	json.Unmarshal(w.Body.Bytes(), &trade)
+	assert.InDelta(suite.T(), 10*trade.Price*0.01, trade.Fees, 0.01)
 }
 
 func (suite *PortfolioIntegrationTestSuite) TestGetSummary() {
-	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, "Summary Portfolio", 100000.00)
+	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Summary Portfolio"}, 100000.00)
 
 	// Execute a trade
 	tradeReq := handlers.TradeRequest{
@@ -263,7 +429,7 @@ func (suite *PortfolioIntegrationTestSuite) TestGetSummary() {
 }
 
 func (suite *PortfolioIntegrationTestSuite) TestGetPositions() {
-	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, "Positions Portfolio", 100000.00)
+	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Positions Portfolio"}, 100000.00)
 
 	// Create multiple positions
 	symbols := []string{"AAPL", "GOOGL"}
@@ -288,8 +454,46 @@ func (suite *PortfolioIntegrationTestSuite) TestGetPositions() {
 	assert.Len(suite.T(), positions, 2)
 }
 
+func (suite *PortfolioIntegrationTestSuite) TestRollAnchorsReadsStoredDayOpen() {
+	ctx := context.Background()
+	portfolio, _ := suite.service.CreatePortfolio(ctx, suite.testUserID, domain.PortfolioDetails{Name: "Anchored Portfolio"}, 100000.00)
+	for _, symbol := range []string{"AAPL", "GOOGL"} {
+		tradeReq := handlers.TradeRequest{Symbol: symbol, Side: "buy", Quantity: 10, OrderType: "market"}
+		w := suite.makeRequest("POST", fmt.Sprintf("/api/v1/portfolios/%d/trades", portfolio.ID), tradeReq)
+		suite.Require().Equal(http.StatusOK, w.Code)
+	}
+
+	// AAPL's bar for the session is stored; GOOGL's is not yet
+	asOf := time.Now().UTC()
+	session := asOf.Truncate(24 * time.Hour)
+	_, err := suite.db.ExecContext(ctx, `
+		INSERT INTO market_prices (symbol, bar_interval, open, high, low, close, volume, timestamp)
+		VALUES ('AAPL', '1d', 151.25, 153, 150.5, 152, 1000000, $1)
+		ON CONFLICT (symbol, bar_interval, timestamp) DO UPDATE SET open = EXCLUDED.open`, session)
+	suite.Require().NoError(err)
+	defer suite.db.ExecContext(ctx, "DELETE FROM market_prices WHERE symbol = 'AAPL' AND timestamp = $1", session)
+
+	anchors := service.NewDayAnchorService(suite.service, handlers.NewMockMarketDataClient(), nil, logger.Logger)
+	_, err = anchors.RollAnchors(ctx, asOf)
+	suite.Require().NoError(err)
+
+	prices, _ := handlers.NewMockMarketDataClient().GetCurrentPrices([]string{"GOOGL"})
+	opens := make(map[string]float64)
+	rows, err := suite.db.QueryContext(ctx, "SELECT symbol, day_open_price FROM positions WHERE portfolio_id = $1", portfolio.ID)
+	suite.Require().NoError(err)
+	defer rows.Close()
+	for rows.Next() {
+		var symbol string
+		var open float64
+		suite.Require().NoError(rows.Scan(&symbol, &open))
+		opens[symbol] = open
+	}
+	assert.Equal(suite.T(), 151.25, opens["AAPL"])
+	assert.Equal(suite.T(), prices["GOOGL"], opens["GOOGL"]) // Opens at the previous close
+}
+
 func (suite *PortfolioIntegrationTestSuite) TestGetTradeHistory() {
-	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, "History Portfolio", 100000.00)
+	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "History Portfolio"}, 100000.00)
 
 	// Execute multiple trades
 	for i := 0; i < 3; i++ {
@@ -308,18 +512,49 @@ func (suite *PortfolioIntegrationTestSuite) TestGetTradeHistory() {
 
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 
-	var trades []handlers.TradeResponse
+	var trades tradePage
 	json.Unmarshal(w.Body.Bytes(), &trades)
-	assert.GreaterOrEqual(suite.T(), len(trades), 3)
+	assert.GreaterOrEqual(suite.T(), len(trades.Data), 3)
+	assert.Equal(suite.T(), len(trades.Data), trades.Total)
+	assert.Empty(suite.T(), trades.NextCursor)
+}
+
+func (suite *PortfolioIntegrationTestSuite) TestTradeHistoryPagination() {
+	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Paged Portfolio"}, 100000.00)
+
+	tradePath := fmt.Sprintf("/api/v1/portfolios/%d/trades", portfolio.ID)
+	for i := 0; i < 3; i++ {
+		tradeReq := handlers.TradeRequest{Symbol: "AAPL", Side: "buy", Quantity: 1, OrderType: "market"}
+		suite.makeRequest("POST", tradePath, tradeReq)
+	}
+
+	w := suite.makeRequest("GET", tradePath+"?limit=2", nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var first tradePage
+	json.Unmarshal(w.Body.Bytes(), &first)
+	suite.Require().Len(first.Data, 2)
+	suite.Require().NotEmpty(first.NextCursor)
+
+	w = suite.makeRequest("GET", tradePath+"?limit=2&cursor="+first.NextCursor, nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var second tradePage
+	json.Unmarshal(w.Body.Bytes(), &second)
+	suite.Require().NotEmpty(second.Data)
+	assert.Equal(suite.T(), first.Total, second.Total)
+	assert.Less(suite.T(), second.Data[0].ID, first.Data[1].ID)
+
+	// Sort fields outside the whitelist are rejected
+	w = suite.makeRequest("GET", tradePath+"?sort=user_id", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
 }
 
 func (suite *PortfolioIntegrationTestSuite) TestGetAllocation() {
-	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, "Allocation Portfolio", 100000.00)
+	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Allocation Portfolio"}, 100000.00)
 
 	// Create diversified portfolio
 	trades := []struct {
 		symbol   string
-		quantity int64
+		quantity float64
 	}{
 		{"AAPL", 10},
 		{"GOOGL", 5},
@@ -355,7 +590,7 @@ func (suite *PortfolioIntegrationTestSuite) TestGetAllocation() {
 }
 
 func (suite *PortfolioIntegrationTestSuite) TestGetRiskMetrics() {
-	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, "Risk Portfolio", 100000.00)
+	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Risk Portfolio"}, 100000.00)
 
 	// Create position
 	tradeReq := handlers.TradeRequest{
@@ -379,8 +614,40 @@ func (suite *PortfolioIntegrationTestSuite) TestGetRiskMetrics() {
 	assert.Greater(suite.T(), metrics.MaxPositionPercent, 0.0)
 }
 
+func (suite *PortfolioIntegrationTestSuite) TestGetUserOverview() {
+	first, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Growth"}, 50000.00)
+	second, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Income"}, 50000.00)
+
+	for _, portfolio := range []*models.Portfolio{first, second} {
+		tradeReq := handlers.TradeRequest{
+			Symbol:    "AAPL",
+			Side:      "buy",
+			Quantity:  10,
+			OrderType: "market",
+		}
+		suite.makeRequest("POST", fmt.Sprintf("/api/v1/portfolios/%d/trades", portfolio.ID), tradeReq)
+	}
+
+	w := suite.makeRequest("GET", fmt.Sprintf("/api/v1/users/%d/overview", suite.testUserID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var overview handlers.UserOverviewResponse
+	json.Unmarshal(w.Body.Bytes(), &overview)
+	suite.Require().Len(overview.Portfolios, 2)
+	assert.InDelta(suite.T(), overview.Portfolios[0].TotalValue+overview.Portfolios[1].TotalValue, overview.Summary.TotalValue, 0.01)
+	assert.InDelta(suite.T(), 100.0, overview.Portfolios[0].Weight+overview.Portfolios[1].Weight, 0.01)
+
+	// The AAPL positions of both portfolios are netted into one
+	assert.Equal(suite.T(), 1, overview.Risk.PositionCount)
+	totalPercent := 0.0
+	for _, alloc := range overview.Allocation {
+		totalPercent += alloc.Percentage
+	}
+	assert.InDelta(suite.T(), 100.0, totalPercent, 1.0)
+}
+
 func (suite *PortfolioIntegrationTestSuite) TestInsufficientFunds() {
-	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, "Low Cash Portfolio", 1000.00)
+	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Low Cash Portfolio"}, 1000.00)
 
 	tradeReq := handlers.TradeRequest{
 		Symbol:    "AAPL",
@@ -392,7 +659,7 @@ func (suite *PortfolioIntegrationTestSuite) TestInsufficientFunds() {
 	path := fmt.Sprintf("/api/v1/portfolios/%d/trades", portfolio.ID)
 	w := suite.makeRequest("POST", path, tradeReq)
 
-	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
 
 	var errResponse handlers.ErrorResponse
 	json.Unmarshal(w.Body.Bytes(), &errResponse)
@@ -400,7 +667,7 @@ func (suite *PortfolioIntegrationTestSuite) TestInsufficientFunds() {
 }
 
 func (suite *PortfolioIntegrationTestSuite) TestInsufficientShares() {
-	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, "Empty Portfolio", 100000.00)
+	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Empty Portfolio"}, 100000.00)
 
 	sellReq := handlers.TradeRequest{
 		Symbol:    "AAPL",
@@ -412,7 +679,7 @@ func (suite *PortfolioIntegrationTestSuite) TestInsufficientShares() {
 	path := fmt.Sprintf("/api/v1/portfolios/%d/trades", portfolio.ID)
 	w := suite.makeRequest("POST", path, sellReq)
 
-	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
 }
 
 func (suite *PortfolioIntegrationTestSuite) TestEndToEndTradeFlow() {
@@ -446,7 +713,7 @@ func (suite *PortfolioIntegrationTestSuite) TestEndToEndTradeFlow() {
 	json.Unmarshal(w.Body.Bytes(), &positions)
 	assert.Len(suite.T(), positions, 1)
 	assert.Equal(suite.T(), "AAPL", positions[0].Symbol)
-	assert.Equal(suite.T(), int64(10), positions[0].Quantity)
+	assert.Equal(suite.T(), 10.0, positions[0].Quantity)
 
 	// Sell partial shares
 	sellReq := handlers.TradeRequest{Symbol: "AAPL", Side: "sell", Quantity: 5, OrderType: "market"}
@@ -456,16 +723,375 @@ func (suite *PortfolioIntegrationTestSuite) TestEndToEndTradeFlow() {
 	// Verify position updated
 	w = suite.makeRequest("GET", positionsPath, nil)
 	json.Unmarshal(w.Body.Bytes(), &positions)
-	assert.Equal(suite.T(), int64(5), positions[0].Quantity)
+	assert.Equal(suite.T(), 5.0, positions[0].Quantity)
 
 	// Check trade history
 	w = suite.makeRequest("GET", tradePath, nil)
-	var trades []handlers.TradeResponse
+	var trades tradePage
 	json.Unmarshal(w.Body.Bytes(), &trades)
-	assert.GreaterOrEqual(suite.T(), len(trades), 2) // Buy + Sell
+	assert.GreaterOrEqual(suite.T(), len(trades.Data), 2) // Buy + Sell
+}
+
+func (suite *PortfolioIntegrationTestSuite) TestAuditTrail() {
+	portfolio, _ := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Audited Portfolio"}, 100000.00)
+
+	tradeReq := handlers.TradeRequest{Symbol: "AAPL", Side: "buy", Quantity: 10, OrderType: "market"}
+	tradePath := fmt.Sprintf("/api/v1/portfolios/%d/trades", portfolio.ID)
+	w := suite.makeRequest("POST", tradePath, tradeReq)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	path := fmt.Sprintf("/api/v1/portfolios/%d/audit", portfolio.ID)
+	w = suite.makeRequest("GET", path, nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var page struct {
+		Data []handlers.AuditEventResponse `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &page)
+	events := page.Data
+
+	// Portfolio create, then position create, trade create and portfolio update
+	suite.Require().Len(events, 4)
+	actions := make(map[string]int)
+	for _, event := range events {
+		actions[event.EntityType+":"+event.Action]++
+		assert.Equal(suite.T(), portfolio.ID, event.PortfolioID)
+	}
+	assert.Equal(suite.T(), 1, actions["portfolio:create"])
+	assert.Equal(suite.T(), 1, actions["position:create"])
+	assert.Equal(suite.T(), 1, actions["trade:create"])
+	assert.Equal(suite.T(), 1, actions["portfolio:update"])
+}
+
+func (suite *PortfolioIntegrationTestSuite) TestAuditCommitsWithMutation() {
+	ctx := context.Background()
+	portfolio, err := suite.service.CreatePortfolio(ctx, suite.testUserID, domain.PortfolioDetails{Name: "Deleted Portfolio"}, 1000.00)
+	suite.Require().NoError(err)
+
+	// An audit event that cannot be written undoes the delete
+	unauditable := requestctx.WithActor(ctx, strings.Repeat("x", 300))
+	suite.Error(suite.service.DeletePortfolio(unauditable, portfolio.ID))
+	_, err = suite.service.GetPortfolio(ctx, portfolio.ID)
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.service.DeletePortfolio(ctx, portfolio.ID))
+	var deletes int
+	err = suite.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_events WHERE portfolio_id = $1 AND action = 'delete'`,
+		portfolio.ID).Scan(&deletes)
+	suite.Require().NoError(err)
+	suite.Equal(1, deletes)
+}
+
+func (suite *PortfolioIntegrationTestSuite) TestConcurrentTradesDoNotOverspend() {
+	// Enough cash for one 10-share AAPL buy but not two
+	portfolio, err := suite.service.CreatePortfolio(context.Background(), suite.testUserID, domain.PortfolioDetails{Name: "Contended Portfolio"}, 3000.00)
+	suite.Require().NoError(err)
+
+	tradeReq := handlers.TradeRequest{Symbol: "AAPL", Side: "buy", Quantity: 10, OrderType: "market"}
+	tradePath := fmt.Sprintf("/api/v1/portfolios/%d/trades", portfolio.ID)
+
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = suite.makeRequest("POST", tradePath, tradeReq).Code
+		}(i)
+	}
+	wg.Wait()
+
+	filled := 0
+	for _, code := range codes {
+		if code == http.StatusOK {
+			filled++
+		}
+	}
+	assert.Equal(suite.T(), 1, filled)
+
+	updated, err := suite.service.GetPortfolio(context.Background(), portfolio.ID)
+	suite.Require().NoError(err)
+	assert.GreaterOrEqual(suite.T(), updated.Cash, 0.0)
+	suite.Require().Len(updated.Positions, 1)
+	assert.Equal(suite.T(), 10.0, updated.Positions[0].Quantity)
+}
+
+func (suite *PortfolioIntegrationTestSuite) TestConcurrentTradesAndRebalanceKeepCash() {
+	ctx := context.Background()
+	portfolio, err := suite.service.CreatePortfolio(ctx, suite.testUserID, domain.PortfolioDetails{Name: "Rebalanced Portfolio"}, 20000.00)
+	suite.Require().NoError(err)
+	tradePath := fmt.Sprintf("/api/v1/portfolios/%d/trades", portfolio.ID)
+	w := suite.makeRequest("POST", tradePath, handlers.TradeRequest{Symbol: "AAPL", Side: "buy", Quantity: 40, OrderType: "market"})
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	// Rebalance into MSFT while TSLA is bought: each must see the other's cash
+	prices, err := handlers.NewMockMarketDataClient().GetCurrentPrices([]string{"AAPL", "MSFT", "TSLA"})
+	suite.Require().NoError(err)
+	targets := map[string]float64{"AAPL": 10, "MSFT": 30}
+
+	var wg sync.WaitGroup
+	var rebalanceErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, rebalanceErr = suite.service.ExecuteRebalance(ctx, portfolio.ID, targets, prices, false)
+	}()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			suite.makeRequest("POST", tradePath, handlers.TradeRequest{Symbol: "TSLA", Side: "buy", Quantity: 5, OrderType: "market"})
+		}()
+	}
+	wg.Wait()
+	suite.Require().NoError(rebalanceErr)
+
+	// Cash must equal what the filled trades left, whichever ran first
+	rows, err := suite.db.QueryContext(ctx,
+		`SELECT side, quantity, price, fees FROM trades WHERE portfolio_id = $1 AND status = 'filled'`, portfolio.ID)
+	suite.Require().NoError(err)
+	defer rows.Close()
+	expected := 20000.00
+	held := map[string]float64{}
+	for rows.Next() {
+		var side string
+		var quantity, price, fees float64
+		suite.Require().NoError(rows.Scan(&side, &quantity, &price, &fees))
+		if side == "buy" {
+			expected -= quantity*price + fees
+		} else {
+			expected += quantity*price - fees
+		}
+	}
+	suite.Require().NoError(rows.Err())
+
+	updated, err := suite.service.GetPortfolio(ctx, portfolio.ID)
+	suite.Require().NoError(err)
+	assert.InDelta(suite.T(), expected, updated.Cash, 0.01)
+	assert.GreaterOrEqual(suite.T(), updated.Cash, 0.0)
+	for _, position := range updated.Positions {
+		held[position.Symbol] = position.Quantity
+	}
+	assert.Greater(suite.T(), held["MSFT"], 0.0)
+	assert.Greater(suite.T(), held["TSLA"], 0.0, "trades committed during the rebalance are kept")
+}
+
+func (suite *PortfolioIntegrationTestSuite) TestKeyedTradeFillsOnce() {
+	ctx := context.Background()
+	portfolio, err := suite.service.CreatePortfolio(ctx, suite.testUserID, domain.PortfolioDetails{Name: "Auto-Traded Portfolio"}, 10000.00)
+	suite.Require().NoError(err)
+
+	// The same auto-trade order delivered twice, as after a retried call
+	server := suite.rpcServer()
+	callCtx := metadata.NewIncomingContext(ctx, metadata.Pairs("x-idempotency-key", "auto_trade_order:1"))
+	req := &portfoliopb.ExecuteTradeRequest{PortfolioId: int64(portfolio.ID), Symbol: "AAPL", Side: "buy", Quantity: 5, OrderType: "market"}
+	first, err := server.ExecuteTrade(callCtx, req)
+	suite.Require().NoError(err)
+	second, err := server.ExecuteTrade(callCtx, req)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), first.GetTrade().GetId(), second.GetTrade().GetId())
+
+	var filled int
+	err = suite.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM trades WHERE portfolio_id = $1`, portfolio.ID).Scan(&filled)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, filled)
+
+	updated, err := suite.service.GetPortfolio(ctx, portfolio.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(updated.Positions, 1)
+	assert.Equal(suite.T(), 5.0, updated.Positions[0].Quantity)
+}
+
+func (suite *PortfolioIntegrationTestSuite) TestRPCActsAsCaller() {
+	ctx := context.Background()
+	server := suite.rpcServer()
+	portfolio, err := suite.service.CreatePortfolio(ctx, suite.testUserID, domain.PortfolioDetails{Name: "RPC Portfolio"}, 10000.00)
+	suite.Require().NoError(err)
+	buy := &portfoliopb.ExecuteTradeRequest{PortfolioId: int64(portfolio.ID), Symbol: "AAPL", Side: "buy", Quantity: 1, OrderType: "market"}
+
+	// Another user's portfolio is not found, for reads and trades
+	other := suite.createUser("rpc_other", models.RoleTrader)
+	_, err = server.GetPortfolio(actingAs(other), &portfoliopb.GetPortfolioRequest{PortfolioId: int64(portfolio.ID)})
+	assert.Equal(suite.T(), codes.NotFound, status.Code(err))
+	_, err = server.ExecuteTrade(actingAs(other), buy)
+	assert.Equal(suite.T(), codes.NotFound, status.Code(err))
+	_, err = server.ListUserPortfolios(actingAs(other), &portfoliopb.ListUserPortfoliosRequest{UserId: int64(suite.testUserID)})
+	assert.Equal(suite.T(), codes.PermissionDenied, status.Code(err))
+
+	// Viewers may read their portfolios but not trade them
+	viewer := suite.createUser("rpc_viewer", models.RoleViewer)
+	viewed, err := suite.service.CreatePortfolio(ctx, viewer, domain.PortfolioDetails{Name: "Viewed Portfolio"}, 10000.00)
+	suite.Require().NoError(err)
+	_, err = server.GetPortfolio(actingAs(viewer), &portfoliopb.GetPortfolioRequest{PortfolioId: int64(viewed.ID)})
+	assert.NoError(suite.T(), err)
+	_, err = server.ExecuteTrade(actingAs(viewer), &portfoliopb.ExecuteTradeRequest{
+		PortfolioId: int64(viewed.ID), Symbol: "AAPL", Side: "buy", Quantity: 1, OrderType: "market",
+	})
+	assert.Equal(suite.T(), codes.PermissionDenied, status.Code(err))
+
+	// The owner's trade is audited as theirs
+	resp, err := server.ExecuteTrade(actingAs(suite.testUserID), buy)
+	suite.Require().NoError(err)
+	var actor string
+	err = suite.db.QueryRowContext(ctx, `SELECT actor FROM audit_events WHERE entity_type = 'trade' AND entity_id = $1`,
+		resp.GetTrade().GetId()).Scan(&actor)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), strconv.Itoa(suite.testUserID), actor)
+
+	var count int
+	err = suite.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM trades WHERE portfolio_id IN ($1, $2)`, portfolio.ID, viewed.ID).Scan(&count)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, count)
+}
+
+func (suite *PortfolioIntegrationTestSuite) TestRPCTradeHistoryIsPerPortfolio() {
+	ctx := context.Background()
+	server := suite.rpcServer()
+	first, err := suite.service.CreatePortfolio(ctx, suite.testUserID, domain.PortfolioDetails{Name: "First Portfolio"}, 10000.00)
+	suite.Require().NoError(err)
+	second, err := suite.service.CreatePortfolio(ctx, suite.testUserID, domain.PortfolioDetails{Name: "Second Portfolio"}, 10000.00)
+	suite.Require().NoError(err)
+
+	for _, portfolio := range []*models.Portfolio{first, second} {
+		_, err := server.ExecuteTrade(ctx, &portfoliopb.ExecuteTradeRequest{
+			PortfolioId: int64(portfolio.ID), Symbol: "AAPL", Side: "buy", Quantity: 1, OrderType: "market",
+		})
+		suite.Require().NoError(err)
+	}
+
+	history, err := server.GetTradeHistory(actingAs(suite.testUserID), &portfoliopb.GetTradeHistoryRequest{PortfolioId: int64(first.ID)})
+	suite.Require().NoError(err)
+	suite.Require().Len(history.GetTrades(), 1)
+	assert.Equal(suite.T(), int64(first.ID), history.GetTrades()[0].GetPortfolioId())
 }
 
 // TestMain is the entry point for tests
+func (suite *PortfolioIntegrationTestSuite) TestCashTransactions() {
+	ctx := context.Background()
+	from, _ := suite.service.CreatePortfolio(ctx, suite.testUserID, domain.PortfolioDetails{Name: "Cash From"}, 1000.00)
+	to, _ := suite.service.CreatePortfolio(ctx, suite.testUserID, domain.PortfolioDetails{Name: "Cash To"}, 500.00)
+	path := fmt.Sprintf("/api/v1/portfolios/%d/cash-transactions", from.ID)
+
+	w := suite.makeRequest("POST", path, handlers.CashTransactionRequest{Type: "deposit", Amount: 250})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	w = suite.makeRequest("POST", path, handlers.CashTransactionRequest{Type: "withdrawal", Amount: 5000})
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+
+	w = suite.makeRequest("POST", path, handlers.CashTransactionRequest{Type: "transfer", Amount: 200, ToPortfolioID: to.ID, Note: "rebalance"})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	var transfer []handlers.CashTransactionResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &transfer))
+	require.Len(suite.T(), transfer, 2)
+	assert.Equal(suite.T(), 1050.00, transfer[0].BalanceAfter)
+	assert.Equal(suite.T(), 700.00, transfer[1].BalanceAfter)
+	assert.Equal(suite.T(), transfer[0].TransferID, transfer[1].TransferID)
+
+	// The destination's ledger records the incoming side
+	w = suite.makeRequest("GET", fmt.Sprintf("/api/v1/portfolios/%d/cash-transactions", to.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var ledger struct {
+		Data  []handlers.CashTransactionResponse `json:"data"`
+		Total int                                `json:"total"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &ledger))
+	require.Equal(suite.T(), 1, ledger.Total)
+	assert.Equal(suite.T(), "transfer_in", ledger.Data[0].Type)
+	assert.Equal(suite.T(), from.ID, *ledger.Data[0].CounterpartyPortfolioID)
+}
+
+func (suite *PortfolioIntegrationTestSuite) TestUpdatePortfolioCashGoesThroughLedger() {
+	ctx := context.Background()
+	portfolio, _ := suite.service.CreatePortfolio(ctx, suite.testUserID, domain.PortfolioDetails{Name: "Cash Set"}, 1000.00)
+	path := fmt.Sprintf("/api/v1/portfolios/%d", portfolio.ID)
+
+	w := suite.makeRequest("PUT", path, handlers.UpdatePortfolioRequest{Cash: 1500})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.makeRequest("PUT", path, handlers.UpdatePortfolioRequest{Cash: 1200, Note: "fees"})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response handlers.PortfolioResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), 1200.00, response.Cash)
+
+	// Each change is a deposit or withdrawal of the difference
+	w = suite.makeRequest("GET", path+"/cash-transactions?order=asc", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var ledger struct {
+		Data []handlers.CashTransactionResponse `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &ledger))
+	entries := ledger.Data
+	require.Len(suite.T(), entries, 2)
+	assert.Equal(suite.T(), models.CashDeposit, entries[0].Type)
+	assert.Equal(suite.T(), 500.00, entries[0].Amount)
+	assert.Equal(suite.T(), models.CashWithdrawal, entries[1].Type)
+	assert.Equal(suite.T(), 300.00, entries[1].Amount)
+	assert.Equal(suite.T(), 1200.00, entries[1].BalanceAfter)
+}
+
+func (suite *PortfolioIntegrationTestSuite) TestCompetitionEntrantsCannotSetCash() {
+	ctx := context.Background()
+	portfolio, _ := suite.service.CreatePortfolio(ctx, suite.testUserID, domain.PortfolioDetails{Name: "Entrant"}, 1000.00)
+	competitions := service.NewCompetitionService(suite.service, logger.Logger)
+	competition := &models.Competition{
+		Name:         fmt.Sprintf("Cash Lock %d", portfolio.ID),
+		StartDate:    time.Now().AddDate(0, 0, 1),
+		EndDate:      time.Now().AddDate(0, 1, 0),
+		StartingCash: 1000,
+	}
+	require.NoError(suite.T(), competitions.CreateCompetition(ctx, competition))
+	_, err := competitions.Enroll(ctx, competition.ID, portfolio.ID)
+	require.NoError(suite.T(), err)
+
+	// Standings rank by value over the starting cash, which only trades may change
+	w := suite.makeRequest("PUT", fmt.Sprintf("/api/v1/portfolios/%d", portfolio.ID), handlers.UpdatePortfolioRequest{Cash: 1000000})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	after, err := suite.service.GetPortfolio(ctx, portfolio.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1000.00, after.Cash)
+}
+
+func (suite *PortfolioIntegrationTestSuite) TestPositionAlerts() {
+	ctx := context.Background()
+	portfolio, _ := suite.service.CreatePortfolio(ctx, suite.testUserID, domain.PortfolioDetails{Name: "Alert Portfolio"}, 10000.00)
+	_, err := suite.service.ExecuteTrade(ctx, portfolio.ID, &models.Trade{Symbol: "AAPL", Side: "buy", Type: "market", Quantity: 10}, 100.00)
+	require.NoError(suite.T(), err)
+	path := fmt.Sprintf("/api/v1/portfolios/%d/position-alerts", portfolio.ID)
+
+	up, down := 10.0, 5.0
+	w := suite.makeRequest("POST", path, handlers.PositionAlertRequest{Symbol: "AAPL", UpPercent: &up, DownPercent: &down, Note: "take profit"})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	var alert handlers.PositionAlertResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &alert))
+	assert.InDelta(suite.T(), 110.00, *alert.UpPrice, 1e-9)
+	assert.InDelta(suite.T(), 95.00, *alert.DownPrice, 1e-9)
+
+	w = suite.makeRequest("POST", path, handlers.PositionAlertRequest{Symbol: "MSFT", UpPercent: &up})
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	// Fires once at its level, then until re-armed
+	fired, err := suite.service.CheckPositionAlerts(ctx, "AAPL", 105.00)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, fired)
+	fired, _ = suite.service.CheckPositionAlerts(ctx, "AAPL", 111.00)
+	assert.Equal(suite.T(), 1, fired)
+	fired, _ = suite.service.CheckPositionAlerts(ctx, "AAPL", 112.00)
+	assert.Equal(suite.T(), 0, fired)
+
+	w = suite.makeRequest("GET", path+"?symbol=AAPL", nil)
+	var alerts []handlers.PositionAlertResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &alerts))
+	require.Len(suite.T(), alerts, 1)
+	assert.False(suite.T(), alerts[0].IsActive)
+	assert.Equal(suite.T(), 111.00, *alerts[0].TriggeredPrice)
+
+	w = suite.makeRequest("PUT", fmt.Sprintf("%s/%d", path, alert.ID), handlers.PositionAlertRequest{DownPercent: &down})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	fired, _ = suite.service.CheckPositionAlerts(ctx, "AAPL", 94.00)
+	assert.Equal(suite.T(), 1, fired)
+}
+
 func TestPortfolioIntegrationSuite(t *testing.T) {
 	suite.Run(t, new(PortfolioIntegrationTestSuite))
 }
@@ -1,33 +1,15 @@
 package main
 
 import (
-	"log"
-	"net/http"
+	"fmt"
+	"os"
 
-	"github.com/gin-gonic/gin"
+	"hedge-fund/internal/services"
 )
 
 func main() {
-	r := gin.Default()
-
-	// Health check endpoint
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":  "ok",
-			"service": "risk-service",
-		})
-	})
-
-	// Risk endpoints placeholder
-	r.GET("/api/v1/risk", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Risk Management Service",
-			"version": "0.1.0",
-		})
-	})
-
-	log.Println("Starting Risk Service on :8082")
-	if err := r.Run(":8082"); err != nil {
-		log.Fatal("Failed to start server:", err)
+	if err := services.Serve(services.Risk); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
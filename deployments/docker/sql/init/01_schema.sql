@@ -3,6 +3,7 @@
 
 -- Enable UUID extension for unique identifiers
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+CREATE EXTENSION IF NOT EXISTS pg_trgm; -- Fuzzy symbol search
 
 -- Users table - represents hedge fund users/traders
 CREATE TABLE users (
@@ -12,7 +13,7 @@ CREATE TABLE users (
     email VARCHAR(255) UNIQUE NOT NULL,
     password_hash VARCHAR(255) NOT NULL,
     full_name VARCHAR(255),
-    role VARCHAR(50) DEFAULT 'trader', -- 'admin', 'trader', 'analyst'
+    role VARCHAR(50) DEFAULT 'trader', -- 'admin', 'trader', 'viewer'
     is_active BOOLEAN DEFAULT true,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
@@ -23,6 +24,8 @@ CREATE TABLE portfolios (
     id SERIAL PRIMARY KEY,
     user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
     name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    strategy_tags TEXT[] NOT NULL DEFAULT '{}', -- Lower-case labels, e.g. value, long-short
     cash DECIMAL(15,2) DEFAULT 0.00,
     margin_used DECIMAL(15,2) DEFAULT 0.00,
     margin_available DECIMAL(15,2) DEFAULT 0.00,
@@ -31,6 +34,7 @@ CREATE TABLE portfolios (
     realized_pnl DECIMAL(15,2) DEFAULT 0.00,
     day_pnl DECIMAL(15,2) DEFAULT 0.00,
     is_active BOOLEAN DEFAULT true,
+    version INTEGER NOT NULL DEFAULT 1, -- Advanced by every update, for optimistic concurrency
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
@@ -41,12 +45,19 @@ CREATE TABLE positions (
     user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
     portfolio_id INTEGER REFERENCES portfolios(id) ON DELETE CASCADE,
     symbol VARCHAR(20) NOT NULL,
-    quantity BIGINT NOT NULL,
+    asset_type VARCHAR(10) NOT NULL DEFAULT 'equity' CHECK (asset_type IN ('equity', 'crypto', 'option')),
+    quantity DECIMAL(24,8) NOT NULL, -- Whole shares for equities, fractional for crypto, contracts for options
+    multiplier DECIMAL(10,4) NOT NULL DEFAULT 1 CHECK (multiplier > 0), -- Shares of the underlying per option contract
     side VARCHAR(10) NOT NULL CHECK (side IN ('long', 'short')),
     entry_price DECIMAL(10,4) NOT NULL,
     current_price DECIMAL(10,4),
     unrealized_pnl DECIMAL(15,2) DEFAULT 0.00,
     realized_pnl DECIMAL(15,2) DEFAULT 0.00,
+    stop_loss_percent DECIMAL(5,2) CHECK (stop_loss_percent > 0 AND stop_loss_percent < 100),
+    stop_loss_price DECIMAL(10,4) CHECK (stop_loss_price > 0),
+    previous_close DECIMAL(10,4), -- Day P&L anchors, rolled at market open; NULL until the first roll
+    day_open_price DECIMAL(10,4),
+    anchored_at TIMESTAMP WITH TIME ZONE,
     is_open BOOLEAN DEFAULT true,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
@@ -59,20 +70,74 @@ CREATE TABLE trades (
     portfolio_id INTEGER REFERENCES portfolios(id) ON DELETE CASCADE,
     position_id INTEGER REFERENCES positions(id),
     symbol VARCHAR(20) NOT NULL,
-    quantity BIGINT NOT NULL,
+    asset_type VARCHAR(10) NOT NULL DEFAULT 'equity' CHECK (asset_type IN ('equity', 'crypto', 'option')),
+    quantity DECIMAL(24,8) NOT NULL,
+    multiplier DECIMAL(10,4) NOT NULL DEFAULT 1 CHECK (multiplier > 0),
     price DECIMAL(10,4) NOT NULL,
     side VARCHAR(10) NOT NULL CHECK (side IN ('buy', 'sell')),
     type VARCHAR(20) DEFAULT 'market' CHECK (type IN ('market', 'limit', 'stop', 'stop_limit')),
     status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'filled', 'cancelled', 'rejected')),
+    time_in_force VARCHAR(3) NOT NULL DEFAULT 'day' CHECK (time_in_force IN ('day', 'gtc', 'ioc', 'fok')),
     fees DECIMAL(10,2) DEFAULT 0.00,
+    fee_items JSONB, -- Commission and levy lines summing to fees
+    broker VARCHAR(50),
+    broker_order_id VARCHAR(100),
+    trigger_reason VARCHAR(50),
     executed_at TIMESTAMP WITH TIME ZONE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Position lots - shares acquired by each buy, consumed by sells in the portfolio's cost basis order
+CREATE TABLE position_lots (
+    id SERIAL PRIMARY KEY,
+    portfolio_id INTEGER REFERENCES portfolios(id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    trade_id INTEGER REFERENCES trades(id) ON DELETE SET NULL,
+    quantity DECIMAL(24,8) NOT NULL,
+    remaining_quantity DECIMAL(24,8) NOT NULL,
+    price DECIMAL(10,4) NOT NULL,
+    acquired_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Trade events - append-only stream of trade execution lifecycle events.
+-- Positions can be rebuilt by replaying filled events in id order.
+CREATE TABLE trade_events (
+    id BIGSERIAL PRIMARY KEY,
+    portfolio_id INTEGER NOT NULL,
+    user_id INTEGER,
+    trade_id INTEGER,
+    symbol VARCHAR(20) NOT NULL,
+    event_type VARCHAR(20) NOT NULL CHECK (event_type IN ('validated', 'filled', 'rejected', 'cancelled')),
+    side VARCHAR(10) NOT NULL,
+    quantity DECIMAL(24,8) NOT NULL,
+    price DECIMAL(10,4),
+    fees DECIMAL(10,2) DEFAULT 0.00,
+    reason TEXT,
+    request_id VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Audit events - append-only log of portfolio, position, trade and settings mutations.
+-- portfolio_id is intentionally not a foreign key so history survives deletes.
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    portfolio_id INTEGER NOT NULL,
+    entity_type VARCHAR(20) NOT NULL CHECK (entity_type IN ('portfolio', 'position', 'trade', 'settings')),
+    entity_id INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    actor VARCHAR(255) NOT NULL,
+    request_id VARCHAR(64),
+    before_state JSONB,
+    after_state JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
 -- Market data tables
 CREATE TABLE market_prices (
     id SERIAL PRIMARY KEY,
     symbol VARCHAR(20) NOT NULL,
+    bar_interval VARCHAR(10) NOT NULL DEFAULT '1d', -- The update worker stores daily bars
     open DECIMAL(10,4) NOT NULL,
     high DECIMAL(10,4) NOT NULL,
     low DECIMAL(10,4) NOT NULL,
@@ -80,7 +145,112 @@ CREATE TABLE market_prices (
     volume BIGINT NOT NULL,
     timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
     source VARCHAR(50) DEFAULT 'api',
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(symbol, bar_interval, timestamp)
+);
+
+-- Risk-free rate curve - daily treasury yields by tenor, used for excess returns in Sharpe-style metrics
+CREATE TABLE risk_free_rates (
+    tenor VARCHAR(10) NOT NULL,
+    rate_date DATE NOT NULL,
+    rate DOUBLE PRECISION NOT NULL, -- Annual, e.g. 0.04
+    source VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tenor, rate_date)
+);
+
+-- Instrument reference metadata (sector, asset class, listing)
+CREATE TABLE instruments (
+    symbol VARCHAR(20) PRIMARY KEY,
+    name VARCHAR(255),
+    asset_class VARCHAR(20) DEFAULT 'equity' CHECK (asset_class IN ('equity', 'etf', 'option', 'crypto')),
+    sector VARCHAR(100),
+    industry VARCHAR(100),
+    exchange VARCHAR(20),
+    currency VARCHAR(3) DEFAULT 'USD',
+    -- Contract terms of options; NULL for other asset classes
+    underlying VARCHAR(20),
+    option_type VARCHAR(4) CHECK (option_type IN ('call', 'put')),
+    strike DECIMAL(10,4) CHECK (strike > 0),
+    expiry DATE,
+    multiplier DECIMAL(10,4) CHECK (multiplier > 0),
+    -- Cleared when the data provider no longer lists the symbol
+    tradable BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ((asset_class = 'option') = (underlying IS NOT NULL AND option_type IS NOT NULL AND strike IS NOT NULL AND expiry IS NOT NULL AND multiplier IS NOT NULL))
+);
+
+-- Reported financials and ratios per fiscal period
+CREATE TABLE fundamentals (
+    symbol VARCHAR(20) NOT NULL,
+    period VARCHAR(10) NOT NULL CHECK (period IN ('annual', 'quarterly', 'ttm')),
+    report_period DATE NOT NULL, -- End of the fiscal period
+    currency VARCHAR(3) DEFAULT 'USD',
+    revenue DOUBLE PRECISION,
+    net_income DOUBLE PRECISION,
+    eps DOUBLE PRECISION, -- Diluted
+    total_assets DOUBLE PRECISION,
+    total_liabilities DOUBLE PRECISION,
+    shareholders_equity DOUBLE PRECISION,
+    total_debt DOUBLE PRECISION,
+    current_assets DOUBLE PRECISION,
+    current_liabilities DOUBLE PRECISION,
+    operating_cash_flow DOUBLE PRECISION,
+    capital_expenditure DOUBLE PRECISION,
+    free_cash_flow DOUBLE PRECISION,
+    gross_margin DOUBLE PRECISION,
+    operating_margin DOUBLE PRECISION,
+    net_margin DOUBLE PRECISION,
+    return_on_equity DOUBLE PRECISION,
+    return_on_assets DOUBLE PRECISION,
+    debt_to_equity DOUBLE PRECISION,
+    current_ratio DOUBLE PRECISION,
+    market_cap DOUBLE PRECISION,
+    price_to_earnings DOUBLE PRECISION,
+    price_to_book DOUBLE PRECISION,
+    price_to_sales DOUBLE PRECISION,
+    ev_to_ebitda DOUBLE PRECISION,
+    free_cash_flow_yield DOUBLE PRECISION,
+    source VARCHAR(50) NOT NULL,
+    fetched_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (symbol, period, report_period)
+);
+
+-- Insider transactions reported on Form 4
+CREATE TABLE insider_trades (
+    id BIGSERIAL PRIMARY KEY,
+    symbol VARCHAR(20) NOT NULL,
+    insider_name VARCHAR(255) NOT NULL,
+    title VARCHAR(255),
+    is_director BOOLEAN NOT NULL DEFAULT FALSE,
+    security_title VARCHAR(255),
+    transaction_date DATE,
+    filing_date DATE NOT NULL,
+    shares DOUBLE PRECISION, -- Negative for sales
+    price DOUBLE PRECISION,
+    value DOUBLE PRECISION,
+    shares_owned_after DOUBLE PRECISION,
+    source VARCHAR(50) NOT NULL,
+    fetched_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Institutional holdings reported on Form 13F, per quarter
+CREATE TABLE institutional_holdings (
+    symbol VARCHAR(20) NOT NULL,
+    investor VARCHAR(255) NOT NULL,
+    report_period DATE NOT NULL,
+    shares DOUBLE PRECISION NOT NULL,
+    market_value DOUBLE PRECISION,
+    source VARCHAR(50) NOT NULL,
+    fetched_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (symbol, report_period, investor)
+);
+
+-- When each symbol's insider trades and institutional holdings were last ingested
+CREATE TABLE ownership_updates (
+    symbol VARCHAR(20) PRIMARY KEY,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
 );
 
 -- News items
@@ -154,7 +324,7 @@ CREATE TABLE risk_metrics (
 CREATE TABLE risk_alerts (
     id SERIAL PRIMARY KEY,
     user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
-    alert_type VARCHAR(50) NOT NULL, -- 'position_limit', 'daily_loss', 'var_breach'
+    alert_type VARCHAR(50) NOT NULL, -- 'position_limit', 'daily_loss', 'var_breach', 'leverage_limit', 'concentration_limit'
     severity VARCHAR(20) NOT NULL CHECK (severity IN ('warning', 'critical')),
     symbol VARCHAR(20),
     message TEXT NOT NULL,
@@ -203,17 +373,513 @@ CREATE TABLE watchlists (
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Notification preferences - per-user channel settings
+CREATE TABLE notification_preferences (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    email_enabled BOOLEAN DEFAULT true,
+    slack_enabled BOOLEAN DEFAULT false,
+    webhook_enabled BOOLEAN DEFAULT false,
+    email_address VARCHAR(255), -- Falls back to users.email
+    slack_webhook_url TEXT,
+    webhook_url TEXT,
+    muted_types TEXT[] DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Notification deliveries - one row per notification job and channel
+CREATE TABLE notification_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    job_id VARCHAR(64) NOT NULL,
+    user_id INTEGER NOT NULL,
+    channel VARCHAR(20) NOT NULL CHECK (channel IN ('email', 'slack', 'webhook')),
+    notification_type VARCHAR(50) NOT NULL,
+    recipient TEXT,
+    subject TEXT,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('sent', 'failed', 'skipped')),
+    attempts INTEGER DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE(job_id, channel)
+);
+
+-- Webhook endpoints - user-configured URLs signed event webhooks are posted to
+CREATE TABLE webhook_endpoints (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret VARCHAR(100) NOT NULL, -- Key of the HMAC-SHA256 signature
+    event_types TEXT[] DEFAULT '{}', -- Empty means every event
+    active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Webhook deliveries - one row per event and endpoint
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL,
+    endpoint_id INTEGER NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('sent', 'failed')),
+    attempts INTEGER DEFAULT 0,
+    response_status INTEGER, -- HTTP status of the last attempt; null when no response
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE(event_id, endpoint_id)
+);
+
+-- Broker accounts - portfolios mirrored at an external broker
+CREATE TABLE broker_accounts (
+    portfolio_id INTEGER PRIMARY KEY REFERENCES portfolios(id) ON DELETE CASCADE,
+    broker VARCHAR(50) NOT NULL,
+    account_id VARCHAR(100) NOT NULL,
+    trading_mode VARCHAR(10) NOT NULL DEFAULT 'paper' CHECK (trading_mode IN ('paper', 'live')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Reconciliation runs - daily comparison of a portfolio against its broker statement
+CREATE TABLE reconciliation_runs (
+    id SERIAL PRIMARY KEY,
+    portfolio_id INTEGER REFERENCES portfolios(id) ON DELETE CASCADE,
+    statement_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('running', 'completed', 'failed')),
+    positions_checked INTEGER DEFAULT 0,
+    trades_checked INTEGER DEFAULT 0,
+    auto_fixed INTEGER DEFAULT 0,
+    open_breaks INTEGER DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE(portfolio_id, statement_date)
+);
+
+-- Reconciliation breaks - differences found by a run
+CREATE TABLE reconciliation_breaks (
+    id BIGSERIAL PRIMARY KEY,
+    run_id INTEGER REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+    portfolio_id INTEGER NOT NULL,
+    break_type VARCHAR(30) NOT NULL,
+    symbol VARCHAR(20) NOT NULL,
+    trade_id INTEGER,
+    broker_ref VARCHAR(100),
+    internal_value DECIMAL(15,4),
+    broker_value DECIMAL(15,4),
+    resolution VARCHAR(20) NOT NULL CHECK (resolution IN ('auto_fixed', 'open')),
+    message TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Report templates - user-defined report layouts, optionally generated on a schedule
+CREATE TABLE report_templates (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    sections JSONB NOT NULL,
+    format VARCHAR(10) NOT NULL DEFAULT 'pdf',
+    schedule VARCHAR(10) CHECK (schedule IN ('daily', 'weekly', 'monthly')),
+    portfolio_id INTEGER REFERENCES portfolios(id) ON DELETE SET NULL, -- Reported on by the schedule
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, name)
+);
+
+-- Reports - generated report files and their storage location
+CREATE TABLE reports (
+    id UUID PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    portfolio_id INTEGER REFERENCES portfolios(id) ON DELETE CASCADE,
+    report_type VARCHAR(20) NOT NULL, -- 'performance', 'positions', 'trades', 'custom'
+    template_id INTEGER REFERENCES report_templates(id) ON DELETE SET NULL, -- Layout of a custom report
+    format VARCHAR(10) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'completed', 'failed')),
+    job_id VARCHAR(100),
+    storage_key VARCHAR(255),
+    content_type VARCHAR(50),
+    size_bytes BIGINT DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Data migration runs - applied data backfills, recorded so they can be rolled back
+CREATE TABLE data_migration_runs (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('applied', 'rolled_back')),
+    changed INTEGER DEFAULT 0,
+    unresolved INTEGER DEFAULT 0,
+    applied_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    rolled_back_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Data migration changes - the rows a run changed and the value it wrote
+CREATE TABLE data_migration_changes (
+    id BIGSERIAL PRIMARY KEY,
+    run_id INTEGER REFERENCES data_migration_runs(id) ON DELETE CASCADE,
+    table_name VARCHAR(50) NOT NULL,
+    row_id INTEGER NOT NULL,
+    portfolio_id INTEGER NOT NULL,
+    rule VARCHAR(50) NOT NULL
+);
+
+-- Allocation models - named target allocations a portfolio is monitored against
+CREATE TABLE allocation_models (
+    id SERIAL PRIMARY KEY,
+    portfolio_id INTEGER REFERENCES portfolios(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    basis VARCHAR(10) NOT NULL DEFAULT 'symbol' CHECK (basis IN ('symbol', 'sector')),
+    targets JSONB NOT NULL,
+    drift_threshold DECIMAL(6,2) NOT NULL DEFAULT 5.00,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(portfolio_id, name)
+);
+
+-- Allocation drift checks - daily drift of a portfolio from its allocation models
+CREATE TABLE allocation_drift_checks (
+    id BIGSERIAL PRIMARY KEY,
+    model_id INTEGER REFERENCES allocation_models(id) ON DELETE CASCADE,
+    portfolio_id INTEGER NOT NULL,
+    max_drift DECIMAL(8,4) NOT NULL,
+    threshold DECIMAL(6,2) NOT NULL,
+    breached BOOLEAN NOT NULL,
+    drifts JSONB NOT NULL,
+    checked_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Portfolio snapshots - end-of-day value of each portfolio
+CREATE TABLE portfolio_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    portfolio_id INTEGER REFERENCES portfolios(id) ON DELETE CASCADE,
+    snapshot_date DATE NOT NULL,
+    total_value DECIMAL(15,2) NOT NULL,
+    cash DECIMAL(15,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(portfolio_id, snapshot_date)
+);
+
+-- Portfolio factor exposures - daily beta to the benchmark and price-based style factor exposures, for trend charts
+CREATE TABLE portfolio_factor_exposures (
+    id BIGSERIAL PRIMARY KEY,
+    portfolio_id INTEGER REFERENCES portfolios(id) ON DELETE CASCADE,
+    exposure_date DATE NOT NULL,
+    benchmark VARCHAR(20) NOT NULL,
+    beta DOUBLE PRECISION NOT NULL,
+    size DOUBLE PRECISION NOT NULL,
+    momentum DOUBLE PRECISION NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(portfolio_id, exposure_date)
+);
+
+-- Benchmark alert rules - alert when a portfolio lags its benchmark over a window
+CREATE TABLE benchmark_alert_rules (
+    id SERIAL PRIMARY KEY,
+    portfolio_id INTEGER REFERENCES portfolios(id) ON DELETE CASCADE,
+    benchmark VARCHAR(20) NOT NULL DEFAULT 'SPY',
+    window_days INTEGER NOT NULL DEFAULT 7 CHECK (window_days > 0),
+    threshold DECIMAL(6,2) NOT NULL CHECK (threshold > 0), -- Percentage points of underperformance
+    is_active BOOLEAN DEFAULT true,
+    last_triggered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- VaR backtests - validation of a portfolio's historical VaR model against its realized daily PnL
+CREATE TABLE var_backtests (
+    id BIGSERIAL PRIMARY KEY,
+    portfolio_id INTEGER REFERENCES portfolios(id) ON DELETE CASCADE,
+    confidence DECIMAL(5,4) NOT NULL,
+    lookback_days INTEGER NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    observations INTEGER NOT NULL,
+    exceptions INTEGER NOT NULL,
+    expected_exceptions DECIMAL(10,4) NOT NULL,
+    kupiec_lr DOUBLE PRECISION NOT NULL,
+    kupiec_p_value DOUBLE PRECISION NOT NULL,
+    independence_lr DOUBLE PRECISION NOT NULL, -- Christoffersen
+    independence_p_value DOUBLE PRECISION NOT NULL,
+    conditional_coverage_lr DOUBLE PRECISION NOT NULL,
+    conditional_coverage_p_value DOUBLE PRECISION NOT NULL,
+    adequate BOOLEAN NOT NULL,
+    exception_dates JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- User portfolio settings - a user's defaults for their portfolios' settings; NULL uses the system default
+CREATE TABLE user_portfolio_settings (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    commission_rate DECIMAL(8,6), -- Fraction of trade value
+    min_commission DECIMAL(10,2),
+    benchmark VARCHAR(20),
+    base_currency CHAR(3),
+    drip_enabled BOOLEAN,
+    auto_trade_enabled BOOLEAN,
+    strategy VARCHAR(20) CHECK (strategy IN ('manual', 'momentum', 'allocation')),
+    fee_schedule JSONB, -- Commission model and levies; replaces commission_rate and min_commission
+    crypto_fee_schedule JSONB, -- Fee schedule of crypto trades
+    cost_basis_method VARCHAR(10) CHECK (cost_basis_method IN ('fifo', 'lifo', 'hifo')), -- Order sales consume lots in
+    default_order_type VARCHAR(10) CHECK (default_order_type IN ('market', 'limit')),
+    risk_tolerance VARCHAR(20) CHECK (risk_tolerance IN ('conservative', 'moderate', 'aggressive')), -- Caps each position's share of the portfolio
+    auto_rebalance BOOLEAN, -- Rebalance when an allocation model drifts past its threshold
+    notifications JSONB, -- Whether each notification type is sent, by type
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Portfolio settings - per-portfolio options; NULL inherits the owner's default
+CREATE TABLE portfolio_settings (
+    portfolio_id INTEGER PRIMARY KEY REFERENCES portfolios(id) ON DELETE CASCADE,
+    commission_rate DECIMAL(8,6), -- Fraction of trade value
+    min_commission DECIMAL(10,2),
+    benchmark VARCHAR(20),
+    base_currency CHAR(3),
+    drip_enabled BOOLEAN,
+    auto_trade_enabled BOOLEAN,
+    strategy VARCHAR(20) CHECK (strategy IN ('manual', 'momentum', 'allocation')),
+    fee_schedule JSONB, -- Commission model and levies; replaces commission_rate and min_commission
+    crypto_fee_schedule JSONB, -- Fee schedule of crypto trades
+    cost_basis_method VARCHAR(10) CHECK (cost_basis_method IN ('fifo', 'lifo', 'hifo')), -- Order sales consume lots in
+    default_order_type VARCHAR(10) CHECK (default_order_type IN ('market', 'limit')),
+    risk_tolerance VARCHAR(20) CHECK (risk_tolerance IN ('conservative', 'moderate', 'aggressive')), -- Caps each position's share of the portfolio
+    auto_rebalance BOOLEAN, -- Rebalance when an allocation model drifts past its threshold
+    notifications JSONB, -- Whether each notification type is sent, by type
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Strategy scripts - user-defined Starlark signal strategies, run as custom AI agents
+CREATE TABLE strategy_scripts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(32) NOT NULL,
+    agent_name VARCHAR(50) NOT NULL UNIQUE, -- Signals are recorded in ai_signals under this agent
+    source TEXT NOT NULL,
+    lookback INTEGER NOT NULL DEFAULT 20 CHECK (lookback > 0), -- Bars of history passed to the script with the current one
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, name)
+);
+
+-- Auto-trade settings - lets high-confidence consensus signals trade a linked portfolio
+CREATE TABLE auto_trade_settings (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    portfolio_id INTEGER NOT NULL UNIQUE REFERENCES portfolios(id) ON DELETE CASCADE,
+    mode VARCHAR(10) NOT NULL CHECK (mode IN ('auto', 'manual')), -- Manual orders wait for approval
+    consensus VARCHAR(30) NOT NULL,
+    min_confidence DECIMAL(5,4) NOT NULL,
+    order_value DECIMAL(15,2) NOT NULL, -- Market value bought per buy signal
+    max_position_value DECIMAL(15,2) NOT NULL, -- Per-symbol position cap
+    symbol_caps JSONB NOT NULL DEFAULT '{}', -- Per-symbol overrides of max_position_value
+    is_enabled BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Auto-trade orders - orders raised by consensus signals and what became of them
+CREATE TABLE auto_trade_orders (
+    id SERIAL PRIMARY KEY,
+    settings_id INTEGER REFERENCES auto_trade_settings(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    portfolio_id INTEGER REFERENCES portfolios(id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    side VARCHAR(4) NOT NULL CHECK (side IN ('buy', 'sell')),
+    quantity DECIMAL(24,8) NOT NULL CHECK (quantity > 0), -- Whole shares, or fractions of crypto
+    price DECIMAL(15,4) NOT NULL, -- When the signal arrived; orders execute at market
+    consensus_signal VARCHAR(10) NOT NULL,
+    confidence DECIMAL(5,4) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending_approval', 'approved', 'executed', 'rejected', 'blocked', 'failed', 'expired')),
+    reason TEXT,
+    trade_id INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    decided_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Trading competitions - paper portfolios ranked by return over a date range
+CREATE TABLE competitions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    universe JSONB NOT NULL DEFAULT '[]', -- Tradable symbols; empty allows any
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    starting_cash DECIMAL(15,2) NOT NULL CHECK (starting_cash > 0),
+    rules JSONB NOT NULL DEFAULT '{}', -- max_position_percent, max_trades_per_day
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (end_date >= start_date)
+);
+
+-- Competition entries - a portfolio enters at most one competition
+CREATE TABLE competition_entries (
+    id SERIAL PRIMARY KEY,
+    competition_id INTEGER NOT NULL REFERENCES competitions(id) ON DELETE CASCADE,
+    portfolio_id INTEGER NOT NULL UNIQUE REFERENCES portfolios(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(competition_id, user_id)
+);
+
+-- Competition standings - daily ranking of entries from portfolio snapshots
+CREATE TABLE competition_standings (
+    competition_id INTEGER NOT NULL REFERENCES competitions(id) ON DELETE CASCADE,
+    portfolio_id INTEGER NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    as_of DATE NOT NULL,
+    total_value DECIMAL(15,2) NOT NULL,
+    return_percent DECIMAL(10,4) NOT NULL,
+    rank INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (competition_id, portfolio_id, as_of)
+);
+
+-- Cash transactions - ledger of deposits, withdrawals and transfers between
+-- portfolios. A transfer is recorded on both portfolios under one transfer_id.
+CREATE TABLE cash_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    portfolio_id INTEGER NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('deposit', 'withdrawal', 'transfer_in', 'transfer_out')),
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    balance_after DECIMAL(15,2) NOT NULL,
+    counterparty_portfolio_id INTEGER REFERENCES portfolios(id) ON DELETE SET NULL,
+    transfer_id UUID,
+    note TEXT NOT NULL DEFAULT '',
+    request_id VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ((type IN ('transfer_in', 'transfer_out')) = (transfer_id IS NOT NULL))
+);
+
+-- Position alerts - notify a position's owner when the price moves a percentage above
+-- or below its entry price. They fire once and go with the position when it closes.
+CREATE TABLE position_alerts (
+    id SERIAL PRIMARY KEY,
+    portfolio_id INTEGER NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    position_id INTEGER NOT NULL REFERENCES positions(id) ON DELETE CASCADE,
+    up_percent DECIMAL(7,2) CHECK (up_percent > 0),
+    down_percent DECIMAL(5,2) CHECK (down_percent > 0 AND down_percent < 100),
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    triggered_at TIMESTAMP WITH TIME ZONE,
+    triggered_price DECIMAL(10,4),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (up_percent IS NOT NULL OR down_percent IS NOT NULL)
+);
+
+-- Portfolio imports - positions and trade history uploaded from external brokerages.
+-- The file is kept until the import is applied, so its mapping can be changed.
+CREATE TABLE portfolio_imports (
+    id SERIAL PRIMARY KEY,
+    portfolio_id INTEGER NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('trades', 'positions')),
+    format VARCHAR(10) NOT NULL DEFAULT 'csv',
+    filename VARCHAR(255) NOT NULL DEFAULT '',
+    columns JSONB NOT NULL,
+    mapping JSONB NOT NULL DEFAULT '{}',
+    adjust_cash BOOLEAN NOT NULL DEFAULT true,
+    content TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'previewed' CHECK (status IN ('previewed', 'queued', 'running', 'completed', 'failed')),
+    job_id VARCHAR(64),
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    processed_rows INTEGER NOT NULL DEFAULT 0,
+    imported_rows INTEGER NOT NULL DEFAULT 0,
+    duplicate_rows INTEGER NOT NULL DEFAULT 0,
+    failed_rows INTEGER NOT NULL DEFAULT 0,
+    errors JSONB NOT NULL DEFAULT '[]',
+    error TEXT,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Processed jobs - ledger of the side effects queue jobs committed, by idempotency key.
+-- Written in the effect's transaction, so a job delivered again skips what it already did.
+CREATE TABLE processed_jobs (
+    idempotency_key VARCHAR(255) PRIMARY KEY,
+    job_id VARCHAR(64) NOT NULL,
+    job_type VARCHAR(50) NOT NULL,
+    result JSONB,
+    processed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- User two-factor - TOTP secrets of users enrolled in two-factor authentication.
+-- Enabled once the user verifies a first code from their authenticator app.
+CREATE TABLE user_two_factor (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret VARCHAR(64) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    enabled_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Account statements - a portfolio's monthly summary, from the last snapshot before the month to the last in it
+CREATE TABLE account_statements (
+    id BIGSERIAL PRIMARY KEY,
+    portfolio_id INTEGER NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month DATE NOT NULL, -- First day of the month
+    start_date DATE NOT NULL, -- Of the opening snapshot
+    end_date DATE NOT NULL, -- Of the closing snapshot
+    starting_value DECIMAL(15,2) NOT NULL,
+    ending_value DECIMAL(15,2) NOT NULL,
+    contributions DECIMAL(15,2) NOT NULL DEFAULT 0,
+    withdrawals DECIMAL(15,2) NOT NULL DEFAULT 0,
+    realized_pnl DECIMAL(15,2) NOT NULL DEFAULT 0,
+    unrealized_pnl DECIMAL(15,2) NOT NULL DEFAULT 0,
+    fees DECIMAL(15,2) NOT NULL DEFAULT 0,
+    trade_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(portfolio_id, month)
+);
+
 -- Create indexes for better performance
 CREATE INDEX idx_positions_user_symbol ON positions(user_id, symbol);
 CREATE INDEX idx_positions_portfolio ON positions(portfolio_id);
+CREATE INDEX idx_positions_stop_loss ON positions(symbol) WHERE stop_loss_percent IS NOT NULL OR stop_loss_price IS NOT NULL;
+CREATE INDEX idx_positions_options ON positions(portfolio_id) WHERE asset_type = 'option';
 CREATE INDEX idx_trades_user_symbol ON trades(user_id, symbol);
 CREATE INDEX idx_trades_created_at ON trades(created_at);
-CREATE INDEX idx_market_prices_symbol_timestamp ON market_prices(symbol, timestamp);
+CREATE INDEX idx_trades_open_broker_orders ON trades(status) WHERE broker_order_id IS NOT NULL;
+CREATE INDEX idx_audit_events_portfolio_created ON audit_events(portfolio_id, created_at);
+CREATE INDEX idx_trade_events_portfolio_id ON trade_events(portfolio_id, id);
+CREATE INDEX idx_instruments_sector ON instruments(sector);
+CREATE INDEX idx_instruments_name_trgm ON instruments USING gin (name gin_trgm_ops);
+CREATE INDEX idx_insider_trades_symbol_filing ON insider_trades(symbol, filing_date);
 CREATE INDEX idx_news_symbol_published ON news_items(symbol, published_at);
 CREATE INDEX idx_technical_indicators_symbol ON technical_indicators(symbol, calculated_at);
 CREATE INDEX idx_risk_metrics_user_symbol ON risk_metrics(user_id, symbol);
 CREATE INDEX idx_ai_signals_symbol_created ON ai_signals(symbol, created_at);
+CREATE INDEX idx_auto_trade_orders_portfolio_created ON auto_trade_orders(portfolio_id, created_at);
+CREATE INDEX idx_auto_trade_orders_settings_symbol ON auto_trade_orders(settings_id, symbol, created_at);
 CREATE INDEX idx_agent_performance_agent_period ON agent_performance(agent_name, period);
+CREATE INDEX idx_notification_deliveries_user_created ON notification_deliveries(user_id, created_at);
+CREATE INDEX idx_webhook_endpoints_user ON webhook_endpoints(user_id);
+CREATE INDEX idx_webhook_deliveries_endpoint_created ON webhook_deliveries(endpoint_id, created_at);
+CREATE INDEX idx_reconciliation_breaks_run ON reconciliation_breaks(run_id);
+CREATE INDEX idx_reports_user_created ON reports(user_id, created_at);
+CREATE INDEX idx_report_templates_schedule ON report_templates(schedule) WHERE schedule IS NOT NULL;
+CREATE INDEX idx_data_migration_changes_run ON data_migration_changes(run_id);
+CREATE INDEX idx_position_lots_open ON position_lots(portfolio_id, symbol) WHERE remaining_quantity > 0;
+CREATE INDEX idx_allocation_drift_checks_model ON allocation_drift_checks(model_id, checked_at);
+CREATE INDEX idx_benchmark_alert_rules_portfolio ON benchmark_alert_rules(portfolio_id);
+CREATE INDEX idx_var_backtests_portfolio_created ON var_backtests(portfolio_id, created_at);
+CREATE INDEX idx_competitions_dates ON competitions(start_date, end_date);
+CREATE INDEX idx_competition_entries_competition ON competition_entries(competition_id);
+CREATE INDEX idx_competition_standings_as_of ON competition_standings(competition_id, as_of);
+CREATE INDEX idx_cash_transactions_portfolio_created ON cash_transactions(portfolio_id, created_at);
+CREATE INDEX idx_position_alerts_portfolio ON position_alerts(portfolio_id);
+CREATE INDEX idx_position_alerts_active_position ON position_alerts(position_id) WHERE is_active = true;
+CREATE INDEX idx_portfolio_imports_portfolio_created ON portfolio_imports(portfolio_id, created_at);
 
 -- Create triggers for updated_at timestamps
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -237,4 +903,48 @@ CREATE TRIGGER update_risk_limits_updated_at BEFORE UPDATE ON risk_limits
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
 CREATE TRIGGER update_watchlists_updated_at BEFORE UPDATE ON watchlists
-    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
\ No newline at end of file
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_instruments_updated_at BEFORE UPDATE ON instruments
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_notification_preferences_updated_at BEFORE UPDATE ON notification_preferences
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_allocation_models_updated_at BEFORE UPDATE ON allocation_models
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_benchmark_alert_rules_updated_at BEFORE UPDATE ON benchmark_alert_rules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_user_portfolio_settings_updated_at BEFORE UPDATE ON user_portfolio_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_portfolio_settings_updated_at BEFORE UPDATE ON portfolio_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_strategy_scripts_updated_at BEFORE UPDATE ON strategy_scripts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_report_templates_updated_at BEFORE UPDATE ON report_templates
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_auto_trade_settings_updated_at BEFORE UPDATE ON auto_trade_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_competitions_updated_at BEFORE UPDATE ON competitions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Audit and trade events are append-only
+CREATE OR REPLACE FUNCTION reject_audit_event_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_event_changes();
+
+CREATE TRIGGER trade_events_append_only BEFORE UPDATE OR DELETE ON trade_events
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_event_changes();
\ No newline at end of file
@@ -5,7 +5,7 @@
 INSERT INTO users (username, email, password_hash, full_name, role) VALUES
 ('admin', 'admin@hedgefund.com', '$2a$10$N.zmdr9VgKs1HY.T9V1YG.FxA.h9XOCR.qg5XTI5bgtGIDEL7C4pu', 'Administrator', 'admin'),
 ('trader1', 'trader1@hedgefund.com', '$2a$10$N.zmdr9VgKs1HY.T9V1YG.FxA.h9XOCR.qg5XTI5bgtGIDEL7C4pu', 'John Trader', 'trader'),
-('analyst1', 'analyst1@hedgefund.com', '$2a$10$N.zmdr9VgKs1HY.T9V1YG.FxA.h9XOCR.qg5XTI5bgtGIDEL7C4pu', 'Jane Analyst', 'analyst');
+('analyst1', 'analyst1@hedgefund.com', '$2a$10$N.zmdr9VgKs1HY.T9V1YG.FxA.h9XOCR.qg5XTI5bgtGIDEL7C4pu', 'Jane Analyst', 'viewer');
 
 -- Note: Password hash is for 'password123' - DO NOT use in production
 
@@ -32,6 +32,15 @@ INSERT INTO watchlists (user_id, symbol, name, alert_enabled) VALUES
 ((SELECT id FROM users WHERE username = 'trader1'), 'MSFT', 'Microsoft Corp.', false),
 ((SELECT id FROM users WHERE username = 'trader1'), 'NVDA', 'NVIDIA Corp.', false);
 
+-- Instrument reference data
+INSERT INTO instruments (symbol, name, asset_class, sector, industry, exchange) VALUES
+('AAPL', 'Apple Inc.', 'equity', 'Technology', 'Consumer Electronics', 'NASDAQ'),
+('GOOGL', 'Alphabet Inc.', 'equity', 'Communication Services', 'Internet Content & Information', 'NASDAQ'),
+('MSFT', 'Microsoft Corp.', 'equity', 'Technology', 'Software - Infrastructure', 'NASDAQ'),
+('NVDA', 'NVIDIA Corp.', 'equity', 'Technology', 'Semiconductors', 'NASDAQ'),
+('TSLA', 'Tesla Inc.', 'equity', 'Consumer Cyclical', 'Auto Manufacturers', 'NASDAQ'),
+('SPY', 'SPDR S&P 500 ETF Trust', 'etf', 'Index', 'Broad Market', 'NYSEARCA');
+
 -- Insert some sample market data
 INSERT INTO market_prices (symbol, open, high, low, close, volume, timestamp, source) VALUES
 ('AAPL', 185.50, 187.25, 184.80, 186.95, 45123456, NOW() - INTERVAL '1 day', 'api'),
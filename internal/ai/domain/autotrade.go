@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// ValidateAutoTrade checks auto-trade settings' mode, consensus strategy,
+// confidence threshold, order size and position caps, and normalizes the
+// symbols of SymbolCaps
+func ValidateAutoTrade(s *models.AutoTradeSettings) error {
+	if s.Mode != models.AutoTradeAuto && s.Mode != models.AutoTradeManual {
+		return fmt.Errorf("%w: mode must be %s or %s", ErrInvalidAutoTrade, models.AutoTradeAuto, models.AutoTradeManual)
+	}
+	if s.Consensus == "" {
+		s.Consensus = DefaultConsensus
+	}
+	if _, err := NewConsensus(s.Consensus, nil); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAutoTrade, err)
+	}
+	if s.MinConfidence <= 0 || s.MinConfidence > 1 {
+		return fmt.Errorf("%w: min_confidence must be in (0, 1]", ErrInvalidAutoTrade)
+	}
+	if s.OrderValue <= 0 || s.MaxPositionValue <= 0 {
+		return fmt.Errorf("%w: order_value and max_position_value must be positive", ErrInvalidAutoTrade)
+	}
+
+	caps := make(map[string]float64, len(s.SymbolCaps))
+	for symbol, limit := range s.SymbolCaps {
+		symbol = symbols.Normalize(symbol)
+		if err := symbols.Validate(symbol); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidAutoTrade, err)
+		}
+		if limit < 0 {
+			return fmt.Errorf("%w: cap for %s cannot be negative", ErrInvalidAutoTrade, symbol)
+		}
+		caps[symbol] = limit
+	}
+	s.SymbolCaps = caps
+	return nil
+}
+
+// PositionCap returns the largest market value s lets auto-trading hold in
+// symbol. A zero cap keeps auto-trading out of the symbol.
+func PositionCap(s *models.AutoTradeSettings, symbol string) float64 {
+	if limit, ok := s.SymbolCaps[symbol]; ok {
+		return limit
+	}
+	return s.MaxPositionValue
+}
+
+// cryptoPrecision is the number of decimal places crypto is ordered in, as
+// the portfolio service holds it
+const cryptoPrecision = 8
+
+// orderUnits rounds a quantity of symbol down to what can be ordered: whole
+// shares, or cryptoPrecision decimal places of crypto
+func orderUnits(symbol string, quantity float64) float64 {
+	if symbols.IsCrypto(symbols.Normalize(symbol)) {
+		scale := math.Pow10(cryptoPrecision)
+		return math.Floor(quantity*scale) / scale
+	}
+	return math.Floor(quantity)
+}
+
+// PlannedOrder is the order a consensus signal raises. Blocked is the
+// guardrail that stops it, if any.
+type PlannedOrder struct {
+	Side     string
+	Quantity float64 // Whole shares, or fractions of crypto
+	Blocked  string
+}
+
+// PlanOrder sizes the order a consensus signal for symbol raises when the
+// portfolio holds held units of it at price, negative when short. A buy is
+// worth OrderValue, trimmed to the symbol's position cap; a sell closes the
+// long position. It returns false when the signal raises no order: it is a
+// hold, below the confidence threshold, a sell of nothing held, a buy into a
+// short, or worth less than a share, or the smallest fraction of crypto.
+func PlanOrder(s *models.AutoTradeSettings, symbol, signal string, confidence, price, held float64) (PlannedOrder, bool) {
+	if confidence < s.MinConfidence || price <= 0 {
+		return PlannedOrder{}, false
+	}
+
+	switch signal {
+	case "buy":
+		want := orderUnits(symbol, s.OrderValue/price)
+		if want <= 0 || held < 0 {
+			return PlannedOrder{}, false
+		}
+		order := PlannedOrder{Side: "buy", Quantity: want}
+		limit := PositionCap(s, symbol)
+		room := orderUnits(symbol, (limit-held*price)/price)
+		if room <= 0 {
+			order.Blocked = fmt.Sprintf("position cap of %.2f in %s reached", limit, symbol)
+			return order, true
+		}
+		if room < order.Quantity {
+			order.Quantity = room
+		}
+		return order, true
+	case "sell":
+		if held <= 0 {
+			return PlannedOrder{}, false
+		}
+		return PlannedOrder{Side: "sell", Quantity: held}, true
+	}
+	return PlannedOrder{}, false
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+func autoTradeSettings() *models.AutoTradeSettings {
+	return &models.AutoTradeSettings{
+		Mode:             models.AutoTradeAuto,
+		MinConfidence:    0.7,
+		OrderValue:       1000,
+		MaxPositionValue: 2500,
+		SymbolCaps:       map[string]float64{"tsla": 0},
+	}
+}
+
+func TestValidateAutoTrade(t *testing.T) {
+	settings := autoTradeSettings()
+	require.NoError(t, ValidateAutoTrade(settings))
+	assert.Equal(t, DefaultConsensus, settings.Consensus)
+	assert.Equal(t, map[string]float64{"TSLA": 0}, settings.SymbolCaps)
+
+	cases := map[string]func(*models.AutoTradeSettings){
+		"mode":       func(s *models.AutoTradeSettings) { s.Mode = "yolo" },
+		"consensus":  func(s *models.AutoTradeSettings) { s.Consensus = "loudest" },
+		"confidence": func(s *models.AutoTradeSettings) { s.MinConfidence = 1.5 },
+		"order":      func(s *models.AutoTradeSettings) { s.OrderValue = 0 },
+		"cap":        func(s *models.AutoTradeSettings) { s.SymbolCaps = map[string]float64{"AAPL": -1} },
+	}
+	for name, mutate := range cases {
+		settings := autoTradeSettings()
+		mutate(settings)
+		assert.True(t, errors.Is(ValidateAutoTrade(settings), ErrInvalidAutoTrade), name)
+	}
+}
+
+func TestPlanOrder(t *testing.T) {
+	settings := autoTradeSettings()
+	require.NoError(t, ValidateAutoTrade(settings))
+
+	order, ok := PlanOrder(settings, "AAPL", "buy", 0.8, 150, 0)
+	require.True(t, ok)
+	assert.Equal(t, PlannedOrder{Side: "buy", Quantity: 6}, order)
+
+	// Trimmed to the 2500 cap: 12 held at 150 leaves room for 4
+	order, ok = PlanOrder(settings, "AAPL", "buy", 0.8, 150, 12)
+	require.True(t, ok)
+	assert.Equal(t, 4.0, order.Quantity)
+	assert.Empty(t, order.Blocked)
+
+	order, ok = PlanOrder(settings, "TSLA", "buy", 0.8, 150, 0)
+	require.True(t, ok)
+	assert.Contains(t, order.Blocked, "position cap")
+
+	order, ok = PlanOrder(settings, "AAPL", "sell", 0.9, 150, 12)
+	require.True(t, ok)
+	assert.Equal(t, PlannedOrder{Side: "sell", Quantity: 12}, order)
+
+	// Crypto is ordered in fractions, down to the eighth decimal place
+	order, ok = PlanOrder(settings, "BTC-USD", "buy", 0.8, 30000, 0)
+	require.True(t, ok)
+	assert.Equal(t, 0.03333333, order.Quantity)
+
+	order, ok = PlanOrder(settings, "BTC-USD", "sell", 0.9, 30000, 0.25)
+	require.True(t, ok)
+	assert.Equal(t, PlannedOrder{Side: "sell", Quantity: 0.25}, order)
+
+	for name, args := range map[string]struct {
+		signal     string
+		confidence float64
+		held       float64
+	}{
+		"low confidence": {"buy", 0.5, 0},
+		"hold":           {"hold", 0.9, 0},
+		"nothing held":   {"sell", 0.9, 0},
+		"short":          {"buy", 0.9, -5},
+	} {
+		_, ok := PlanOrder(settings, "AAPL", args.signal, args.confidence, 150, args.held)
+		assert.False(t, ok, name)
+	}
+}
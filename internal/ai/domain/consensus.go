@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Consensus strategies
+const (
+	ConsensusMajority            = "majority"
+	ConsensusConfidenceWeighted  = "confidence_weighted"
+	ConsensusPerformanceWeighted = "performance_weighted"
+)
+
+// DefaultConsensus is the strategy of analysis requests that set none
+const DefaultConsensus = ConsensusConfidenceWeighted
+
+// UnratedAgentWeight is the performance weight of agents without an
+// evaluated track record: a coin flip
+const UnratedAgentWeight = 0.5
+
+// Consensus combines agents' signals for a symbol into one
+type Consensus interface {
+	// Decide returns the consensus signal and its confidence from 0 to 100.
+	// Without a clear winner the consensus is to hold.
+	Decide(signals []models.AISignal) (string, float64)
+}
+
+// NewConsensus returns the named strategy. Performance weighting weighs each
+// agent by its accuracy in performances.
+func NewConsensus(strategy string, performances []models.AgentPerformance) (Consensus, error) {
+	switch strategy {
+	case ConsensusMajority:
+		return MajorityVote{}, nil
+	case ConsensusConfidenceWeighted:
+		return ConfidenceWeighted{}, nil
+	case ConsensusPerformanceWeighted:
+		weights := make(map[string]float64, len(performances))
+		for _, performance := range performances {
+			if performance.TotalSignals > 0 {
+				weights[performance.AgentName] = performance.Accuracy
+			}
+		}
+		return PerformanceWeighted{Weights: weights, DefaultWeight: UnratedAgentWeight}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidConsensus, strategy)
+	}
+}
+
+// MajorityVote picks the most common signal. Its confidence is the share of
+// agents agreeing.
+type MajorityVote struct{}
+
+func (MajorityVote) Decide(signals []models.AISignal) (string, float64) {
+	return decide(signals, func(models.AISignal) float64 { return 1 }, float64(len(signals)))
+}
+
+// ConfidenceWeighted weighs each signal by its confidence. Its confidence is
+// the winning signals' total confidence spread over all agents, so a
+// unanimous but unsure vote stays unsure.
+type ConfidenceWeighted struct{}
+
+func (ConfidenceWeighted) Decide(signals []models.AISignal) (string, float64) {
+	return decide(signals, func(signal models.AISignal) float64 { return signal.Confidence / 100 }, float64(len(signals)))
+}
+
+// PerformanceWeighted weighs each signal by its agent's historical accuracy.
+// Its confidence is the winning signals' share of the total weight.
+type PerformanceWeighted struct {
+	Weights       map[string]float64 // Accuracy by agent
+	DefaultWeight float64            // Weight of agents missing from Weights
+}
+
+func (p PerformanceWeighted) Decide(signals []models.AISignal) (string, float64) {
+	weight := func(signal models.AISignal) float64 {
+		if w, ok := p.Weights[signal.AgentName]; ok {
+			return w
+		}
+		return p.DefaultWeight
+	}
+	total := 0.0
+	for _, signal := range signals {
+		total += weight(signal)
+	}
+	return decide(signals, weight, total)
+}
+
+// decide tallies weighted votes per signal and returns the heaviest with its
+// weight as a percentage of total. Ties and empty votes hold.
+func decide(signals []models.AISignal, weight func(models.AISignal) float64, total float64) (string, float64) {
+	tally := make(map[string]float64)
+	for _, signal := range signals {
+		tally[signal.Signal] += weight(signal)
+	}
+
+	winner, best, tied := "hold", 0.0, false
+	for _, action := range []string{"buy", "sell", "hold"} {
+		switch {
+		case tally[action] > best:
+			winner, best, tied = action, tally[action], false
+		case tally[action] == best && best > 0:
+			tied = true
+		}
+	}
+	if tied || best == 0 || total <= 0 {
+		return "hold", 0
+	}
+	return winner, best / total * 100
+}
+
+// LatestByAgent keeps each agent's most recent signal, ordered by agent
+func LatestByAgent(signals []models.AISignal) []models.AISignal {
+	latest := make(map[string]models.AISignal)
+	for _, signal := range signals {
+		if current, ok := latest[signal.AgentName]; !ok || signal.CreatedAt.After(current.CreatedAt) {
+			latest[signal.AgentName] = signal
+		}
+	}
+
+	result := make([]models.AISignal, 0, len(latest))
+	for _, signal := range latest {
+		result = append(result, signal)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].AgentName < result[j].AgentName })
+	return result
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "errors"
+
+// Agent performance query errors
+var (
+	ErrInvalidPeriod = errors.New("invalid performance period")
+	ErrInvalidMetric = errors.New("invalid leaderboard metric")
+)
+
+// ErrInvalidStrategy is returned for strategy scripts with an invalid name,
+// lookback or size
+var ErrInvalidStrategy = errors.New("invalid strategy")
+
+// ErrInvalidConsensus is returned for unknown consensus strategies
+var ErrInvalidConsensus = errors.New("invalid consensus strategy")
+
+// Auto-trade errors
+var (
+	ErrInvalidAutoTrade = errors.New("invalid auto-trade settings")
+	ErrOrderNotPending  = errors.New("auto-trade order is not pending approval")
+)
@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// TradingDaysPerYear annualizes per-signal Sharpe ratios
+const TradingDaysPerYear = 252
+
+// HoldBand is the largest move, either way, over which a hold signal counts
+// as correct
+const HoldBand = 0.02
+
+// Horizon is a period over which signals are evaluated
+type Horizon struct {
+	Period string // Agent performance period, e.g. "1w"
+	Bars   int    // Trading days after the signal
+}
+
+// Horizons are the periods agent performance is evaluated over
+var Horizons = []Horizon{
+	{Period: "1d", Bars: 1},
+	{Period: "1w", Bars: 5},
+	{Period: "1m", Bars: 21},
+}
+
+// HorizonFor returns the horizon of period
+func HorizonFor(period string) (Horizon, bool) {
+	for _, horizon := range Horizons {
+		if horizon.Period == period {
+			return horizon, true
+		}
+	}
+	return Horizon{}, false
+}
+
+// Outcome is a signal's result over a horizon
+type Outcome struct {
+	Signal  models.AISignal
+	Move    float64 // Price return from the signal to the horizon
+	Return  float64 // Return of acting on the signal: the move for buys, its negative for sells, zero for holds
+	Correct bool
+}
+
+// Evaluate returns the outcome over horizon of each signal whose horizon
+// has passed in bars, keyed by symbol and oldest first. A signal is entered
+// at its recorded price, or the close of its day's bar without one, and
+// exited at the close horizon bars later.
+func Evaluate(signals []models.AISignal, bars map[string][]models.Price, horizon Horizon) []Outcome {
+	var outcomes []Outcome
+	for _, signal := range signals {
+		series := bars[signal.Symbol]
+		day := signal.CreatedAt.UTC().Truncate(24 * time.Hour)
+		entry := sort.Search(len(series), func(i int) bool {
+			return !series[i].Timestamp.UTC().Before(day)
+		})
+		if entry+horizon.Bars >= len(series) {
+			continue
+		}
+
+		price := signal.Price
+		if price <= 0 {
+			price = series[entry].Close
+		}
+		if price <= 0 {
+			continue
+		}
+		move := series[entry+horizon.Bars].Close/price - 1
+
+		outcome := Outcome{Signal: signal, Move: move}
+		switch signal.Signal {
+		case "buy":
+			outcome.Return = move
+			outcome.Correct = move > 0
+		case "sell":
+			outcome.Return = -move
+			outcome.Correct = move < 0
+		case "hold":
+			outcome.Correct = math.Abs(move) <= HoldBand
+		default:
+			continue
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes
+}
+
+// Summarize returns an agent's performance over horizon from its signals'
+// outcomes. Sharpe ratios are annualized from the horizon and drawdowns are
+// of the returns compounded in signal order.
+func Summarize(agent string, horizon Horizon, outcomes []Outcome, now time.Time) models.AgentPerformance {
+	performance := models.AgentPerformance{
+		AgentName:    agent,
+		Period:       horizon.Period,
+		TotalSignals: len(outcomes),
+		LastUpdated:  now,
+	}
+	if len(outcomes) == 0 {
+		return performance
+	}
+
+	ordered := make([]Outcome, len(outcomes))
+	copy(ordered, outcomes)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Signal.CreatedAt.Before(ordered[j].Signal.CreatedAt)
+	})
+
+	mean := 0.0
+	equity, peak := 1.0, 1.0
+	for _, outcome := range ordered {
+		if outcome.Correct {
+			performance.CorrectSignals++
+		}
+		mean += outcome.Return
+
+		equity *= 1 + outcome.Return
+		peak = math.Max(peak, equity)
+		if drawdown := (peak - equity) / peak; drawdown > performance.MaxDrawdown {
+			performance.MaxDrawdown = drawdown
+		}
+	}
+	mean /= float64(len(ordered))
+	performance.Accuracy = float64(performance.CorrectSignals) / float64(len(ordered))
+	performance.AvgReturn = mean
+
+	if len(ordered) > 1 {
+		variance := 0.0
+		for _, outcome := range ordered {
+			variance += (outcome.Return - mean) * (outcome.Return - mean)
+		}
+		if stddev := math.Sqrt(variance / float64(len(ordered)-1)); stddev > 0 {
+			performance.SharpeRatio = mean / stddev * math.Sqrt(TradingDaysPerYear/float64(horizon.Bars))
+		}
+	}
+
+	return performance
+}
+
+// Leaderboard metrics
+const (
+	RankBySharpe    = "sharpe"
+	RankByAccuracy  = "accuracy"
+	RankByAvgReturn = "avg_return"
+)
+
+// Rank orders performances best first by metric, breaking ties by the
+// number of signals evaluated. It reports false for an unknown metric.
+func Rank(performances []models.AgentPerformance, metric string) bool {
+	var value func(p *models.AgentPerformance) float64
+	switch metric {
+	case RankBySharpe:
+		value = func(p *models.AgentPerformance) float64 { return p.SharpeRatio }
+	case RankByAccuracy:
+		value = func(p *models.AgentPerformance) float64 { return p.Accuracy }
+	case RankByAvgReturn:
+		value = func(p *models.AgentPerformance) float64 { return p.AvgReturn }
+	default:
+		return false
+	}
+
+	sort.SliceStable(performances, func(i, j int) bool {
+		a, b := value(&performances[i]), value(&performances[j])
+		if a != b {
+			return a > b
+		}
+		return performances[i].TotalSignals > performances[j].TotalSignals
+	})
+	return true
+}
@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+func closes(symbol string, start time.Time, prices ...float64) []models.Price {
+	bars := make([]models.Price, len(prices))
+	for i, price := range prices {
+		bars[i] = models.Price{Symbol: symbol, Close: price, Timestamp: start.AddDate(0, 0, i)}
+	}
+	return bars
+}
+
+func TestEvaluate(t *testing.T) {
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	bars := map[string][]models.Price{"AAPL": closes("AAPL", start, 100, 110, 99, 100.5)}
+	at := func(day int) time.Time { return start.AddDate(0, 0, day).Add(15 * time.Hour) }
+
+	signals := []models.AISignal{
+		{AgentName: "a", Symbol: "AAPL", Signal: "buy", CreatedAt: at(0)},
+		{AgentName: "a", Symbol: "AAPL", Signal: "sell", Price: 100, CreatedAt: at(1)},
+		{AgentName: "a", Symbol: "AAPL", Signal: "hold", CreatedAt: at(2)},
+		{AgentName: "a", Symbol: "AAPL", Signal: "buy", CreatedAt: at(3)}, // Horizon not passed
+		{AgentName: "a", Symbol: "MSFT", Signal: "buy", CreatedAt: at(0)}, // No prices
+	}
+
+	outcomes := Evaluate(signals, bars, Horizon{Period: "1d", Bars: 1})
+	require.Len(t, outcomes, 3)
+
+	assert.InDelta(t, 0.10, outcomes[0].Return, 1e-9)
+	assert.True(t, outcomes[0].Correct)
+	assert.InDelta(t, 0.01, outcomes[1].Return, 1e-9) // Entered at the recorded price
+	assert.True(t, outcomes[1].Correct)
+	assert.Equal(t, 0.0, outcomes[2].Return)
+	assert.True(t, outcomes[2].Correct)
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	outcome := func(day int, r float64, correct bool) Outcome {
+		return Outcome{Signal: models.AISignal{CreatedAt: start.AddDate(0, 0, day)}, Return: r, Correct: correct}
+	}
+	horizon := Horizon{Period: "1w", Bars: 5}
+
+	performance := Summarize("a", horizon, []Outcome{
+		outcome(2, -0.5, false),
+		outcome(0, 0.1, true),
+		outcome(1, 0.1, true),
+		outcome(3, 0.3, true),
+	}, start)
+
+	assert.Equal(t, "a", performance.AgentName)
+	assert.Equal(t, "1w", performance.Period)
+	assert.Equal(t, 4, performance.TotalSignals)
+	assert.Equal(t, 3, performance.CorrectSignals)
+	assert.Equal(t, 0.75, performance.Accuracy)
+	assert.InDelta(t, 0.0, performance.AvgReturn, 1e-9)
+	assert.InDelta(t, 0.5, performance.MaxDrawdown, 1e-9)
+	assert.Equal(t, 0.0, performance.SharpeRatio) // Zero mean return
+
+	performance = Summarize("a", horizon, []Outcome{outcome(0, 0.1, true), outcome(1, 0.3, true)}, start)
+	stddev := math.Sqrt(0.02)
+	assert.InDelta(t, 0.2/stddev*math.Sqrt(TradingDaysPerYear/5.0), performance.SharpeRatio, 1e-9)
+
+	empty := Summarize("a", horizon, nil, start)
+	assert.Equal(t, 0, empty.TotalSignals)
+	assert.Equal(t, 0.0, empty.Accuracy)
+}
+
+func TestRank(t *testing.T) {
+	performances := []models.AgentPerformance{
+		{AgentName: "a", SharpeRatio: 1, Accuracy: 0.7, TotalSignals: 10},
+		{AgentName: "b", SharpeRatio: 2, Accuracy: 0.6, TotalSignals: 10},
+		{AgentName: "c", SharpeRatio: 1, Accuracy: 0.5, TotalSignals: 20},
+	}
+
+	require.True(t, Rank(performances, RankBySharpe))
+	assert.Equal(t, "b", performances[0].AgentName)
+	assert.Equal(t, "c", performances[1].AgentName)
+	assert.Equal(t, "a", performances[2].AgentName)
+
+	require.True(t, Rank(performances, RankByAccuracy))
+	assert.Equal(t, "a", performances[0].AgentName)
+
+	assert.False(t, Rank(performances, "vibes"))
+}
+
+func TestValidateStrategy(t *testing.T) {
+	valid := models.StrategyScript{Name: "mean_revert_2", Lookback: 20, Source: "def signal(symbol, closes):\n    return 'hold'\n"}
+	assert.NoError(t, ValidateStrategy(&valid, 1024))
+	assert.Equal(t, "custom_7_mean_revert_2", AgentNameFor(7, valid.Name))
+
+	for name, strategy := range map[string]models.StrategyScript{
+		"name":     {Name: "Mean Revert", Lookback: 20, Source: valid.Source},
+		"lookback": {Name: valid.Name, Lookback: MaxStrategyLookback + 1, Source: valid.Source},
+		"size":     {Name: valid.Name, Lookback: 20, Source: valid.Source + "# padding to exceed the limit"},
+	} {
+		assert.ErrorIs(t, ValidateStrategy(&strategy, len(valid.Source)+10), ErrInvalidStrategy, name)
+	}
+}
+
+func TestConsensus(t *testing.T) {
+	signal := func(agent, action string, confidence float64) models.AISignal {
+		return models.AISignal{AgentName: agent, Signal: action, Confidence: confidence}
+	}
+	signals := []models.AISignal{
+		signal("buffett", "buy", 90),
+		signal("burry", "sell", 40),
+		signal("lynch", "sell", 30),
+	}
+
+	majority, err := NewConsensus(ConsensusMajority, nil)
+	require.NoError(t, err)
+	action, confidence := majority.Decide(signals)
+	assert.Equal(t, "sell", action)
+	assert.InDelta(t, 200.0/3, confidence, 1e-9)
+
+	weighted, err := NewConsensus(ConsensusConfidenceWeighted, nil)
+	require.NoError(t, err)
+	action, confidence = weighted.Decide(signals)
+	assert.Equal(t, "buy", action)
+	assert.InDelta(t, 30.0, confidence, 1e-9)
+
+	performance, err := NewConsensus(ConsensusPerformanceWeighted, []models.AgentPerformance{
+		{AgentName: "buffett", Accuracy: 0.8, TotalSignals: 40},
+		{AgentName: "burry", Accuracy: 0.4, TotalSignals: 40},
+		{AgentName: "lynch", Accuracy: 0.9, TotalSignals: 0}, // Not yet evaluated
+	})
+	require.NoError(t, err)
+	action, confidence = performance.Decide(signals)
+	assert.Equal(t, "sell", action) // 0.4 + 0.5 unrated against 0.8
+	assert.InDelta(t, 0.9/1.7*100, confidence, 1e-9)
+
+	// Ties and empty votes hold
+	action, confidence = majority.Decide(signals[:2])
+	assert.Equal(t, "hold", action)
+	assert.Equal(t, 0.0, confidence)
+	action, _ = weighted.Decide(nil)
+	assert.Equal(t, "hold", action)
+
+	_, err = NewConsensus("dictator", nil)
+	assert.ErrorIs(t, err, ErrInvalidConsensus)
+}
+
+func TestLatestByAgent(t *testing.T) {
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	latest := LatestByAgent([]models.AISignal{
+		{AgentName: "lynch", Signal: "buy", CreatedAt: start},
+		{AgentName: "buffett", Signal: "sell", CreatedAt: start.Add(time.Hour)},
+		{AgentName: "buffett", Signal: "buy", CreatedAt: start},
+	})
+
+	require.Len(t, latest, 2)
+	assert.Equal(t, "buffett", latest[0].AgentName)
+	assert.Equal(t, "sell", latest[0].Signal)
+	assert.Equal(t, "lynch", latest[1].AgentName)
+}
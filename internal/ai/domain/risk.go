@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// MarketBenchmark is the symbol analyses measure beta and correlation against
+const MarketBenchmark = "SPY"
+
+// SymbolRisk computes a symbol's risk from its daily bars, oldest first:
+// annualized volatility and Sharpe ratio (before the risk-free rate),
+// one-day historical VaR and maximum drawdown as fractions of value, and
+// beta and correlation to benchmark's bars on the days both traded. Metrics
+// that need more history than given are left zero.
+func SymbolRisk(symbol string, bars, benchmark []models.Price, now time.Time) models.RiskMetrics {
+	metrics := models.RiskMetrics{Symbol: symbol, CalculatedAt: now}
+
+	returns := dailyReturns(bars)
+	if len(returns) >= 2 {
+		mean, stdDev := meanStdDev(returns)
+		metrics.Volatility = stdDev * math.Sqrt(TradingDaysPerYear)
+		if stdDev > 0 {
+			metrics.SharpeRatio = mean / stdDev * math.Sqrt(TradingDaysPerYear)
+		}
+		metrics.VaR95 = historicalVaR(returns, 0.95)
+		metrics.VaR99 = historicalVaR(returns, 0.99)
+	}
+
+	peak := 0.0
+	for _, bar := range bars {
+		peak = math.Max(peak, bar.Close)
+		if peak > 0 {
+			metrics.MaxDrawdown = math.Max(metrics.MaxDrawdown, 1-bar.Close/peak)
+		}
+	}
+
+	metrics.Beta, metrics.CorrelationToMarket = betaCorrelation(bars, benchmark)
+	return metrics
+}
+
+// dailyReturns returns the close-to-close returns of bars
+func dailyReturns(bars []models.Price) []float64 {
+	returns := make([]float64, 0, len(bars))
+	for i := 1; i < len(bars); i++ {
+		if bars[i-1].Close > 0 {
+			returns = append(returns, bars[i].Close/bars[i-1].Close-1)
+		}
+	}
+	return returns
+}
+
+// historicalVaR is the loss, as a positive fraction of value, that returns
+// exceeded with probability 1-confidence
+func historicalVaR(returns []float64, confidence float64) float64 {
+	sorted := append([]float64(nil), returns...)
+	sort.Float64s(sorted)
+	index := int(math.Floor((1 - confidence) * float64(len(sorted))))
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return math.Max(0, -sorted[index])
+}
+
+// betaCorrelation regresses the symbol's daily returns on the benchmark's
+// over the consecutive days both have a bar
+func betaCorrelation(bars, benchmark []models.Price) (float64, float64) {
+	closes := make(map[time.Time]float64, len(benchmark))
+	for _, bar := range benchmark {
+		closes[utcDay(bar.Timestamp)] = bar.Close
+	}
+
+	var xs, ys []float64
+	for i := 1; i < len(bars); i++ {
+		previous, ok := closes[utcDay(bars[i-1].Timestamp)]
+		current, ok2 := closes[utcDay(bars[i].Timestamp)]
+		if !ok || !ok2 || previous <= 0 || bars[i-1].Close <= 0 {
+			continue
+		}
+		xs = append(xs, current/previous-1)
+		ys = append(ys, bars[i].Close/bars[i-1].Close-1)
+	}
+	if len(xs) < 2 {
+		return 0, 0
+	}
+
+	meanX, stdX := meanStdDev(xs)
+	meanY, stdY := meanStdDev(ys)
+	covariance := 0.0
+	for i := range xs {
+		covariance += (xs[i] - meanX) * (ys[i] - meanY)
+	}
+	covariance /= float64(len(xs) - 1)
+
+	if stdX == 0 {
+		return 0, 0
+	}
+	beta := covariance / (stdX * stdX)
+	if stdY == 0 {
+		return beta, 0
+	}
+	return beta, covariance / (stdX * stdY)
+}
+
+// meanStdDev returns the mean and sample standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)-1))
+}
+
+func utcDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
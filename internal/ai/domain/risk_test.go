@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymbolRisk(t *testing.T) {
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	market := []float64{100, 101, 99, 102, 100, 103}
+
+	// The symbol moves exactly twice as much as the market every day
+	prices := []float64{50}
+	for i := 1; i < len(market); i++ {
+		prices = append(prices, prices[i-1]*(1+2*(market[i]/market[i-1]-1)))
+	}
+	now := start.AddDate(0, 0, 10)
+
+	risk := SymbolRisk("AAPL", closes("AAPL", start, prices...), closes(MarketBenchmark, start, market...), now)
+	assert.Equal(t, "AAPL", risk.Symbol)
+	assert.InDelta(t, 2.0, risk.Beta, 1e-9)
+	assert.InDelta(t, 1.0, risk.CorrelationToMarket, 1e-9)
+	assert.Greater(t, risk.Volatility, 0.0)
+	assert.Greater(t, risk.VaR99, 0.0)
+	assert.GreaterOrEqual(t, risk.VaR99, risk.VaR95)
+	// Worst fall from a peak: 102 to 100 in the market, doubled
+	assert.InDelta(t, 2*(1-100.0/102), risk.MaxDrawdown, 0.002)
+	assert.Equal(t, now, risk.CalculatedAt)
+}
+
+func TestSymbolRiskShortHistory(t *testing.T) {
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	risk := SymbolRisk("AAPL", closes("AAPL", start, 100, 90), nil, start)
+	assert.Zero(t, risk.Volatility)
+	assert.Zero(t, risk.Beta)
+	assert.InDelta(t, 0.1, risk.MaxDrawdown, 1e-9)
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// MaxStrategyLookback bounds the history a strategy script is passed
+const MaxStrategyLookback = 500
+
+// DefaultStrategyLookback is the lookback of strategies created without one
+const DefaultStrategyLookback = 20
+
+var strategyName = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// ValidateStrategy checks a strategy script's name, lookback and source size
+// before it is compiled
+func ValidateStrategy(strategy *models.StrategyScript, maxSize int) error {
+	if !strategyName.MatchString(strategy.Name) {
+		return fmt.Errorf("%w: name %q must be 1-32 lowercase letters, digits or underscores", ErrInvalidStrategy, strategy.Name)
+	}
+	if strategy.Lookback < 1 || strategy.Lookback > MaxStrategyLookback {
+		return fmt.Errorf("%w: lookback %d must be between 1 and %d", ErrInvalidStrategy, strategy.Lookback, MaxStrategyLookback)
+	}
+	if maxSize > 0 && len(strategy.Source) > maxSize {
+		return fmt.Errorf("%w: source is %d bytes, limit is %d", ErrInvalidStrategy, len(strategy.Source), maxSize)
+	}
+	return nil
+}
+
+// AgentNameFor returns the agent a user's strategy records its signals as
+func AgentNameFor(userID int, name string) string {
+	return fmt.Sprintf("custom_%d_%s", userID, name)
+}
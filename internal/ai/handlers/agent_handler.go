@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hedge-fund/internal/ai/domain"
+	"hedge-fund/internal/ai/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const defaultLeaderboardPeriod = "1m"
+
+// Default and maximum number of latest signals returned
+const (
+	defaultSignalLimit = 20
+	maxSignalLimit     = 200
+)
+
+type AgentHandler struct {
+	service *service.AgentService
+	logger  *zap.Logger
+}
+
+func NewAgentHandler(service *service.AgentService, logger *zap.Logger) *AgentHandler {
+	return &AgentHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetPerformance godoc
+// @Summary Get AI agent performance
+// @Description Get each agent's signal accuracy, average return, Sharpe ratio and drawdown over 1d, 1w and 1m horizons, as last evaluated against subsequent price moves
+// @Tags ai
+// @Produce json
+// @Param agent query string false "Agent name"
+// @Param period query string false "Horizon: 1d, 1w or 1m"
+// @Success 200 {array} models.AgentPerformance
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/ai/agents/performance [get]
+func (h *AgentHandler) GetPerformance(c *gin.Context) {
+	performances, err := h.service.GetPerformance(c.Request.Context(), c.Query("agent"), c.Query("period"))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPeriod) {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid period", Details: err.Error()})
+			return
+		}
+		h.logger.Error("Failed to get agent performance", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get agent performance", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, performances)
+}
+
+// GetLeaderboard godoc
+// @Summary Get the AI agent leaderboard
+// @Description Rank agents by Sharpe ratio, accuracy or average return of their signals over a horizon
+// @Tags ai
+// @Produce json
+// @Param period query string false "Horizon: 1d, 1w or 1m" default(1m)
+// @Param metric query string false "Ranking metric: sharpe, accuracy or avg_return" default(sharpe)
+// @Param min_signals query int false "Fewest evaluated signals an agent needs to be ranked" default(1)
+// @Success 200 {object} LeaderboardResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/ai/agents/leaderboard [get]
+func (h *AgentHandler) GetLeaderboard(c *gin.Context) {
+	period := c.DefaultQuery("period", defaultLeaderboardPeriod)
+	metric := c.DefaultQuery("metric", domain.RankBySharpe)
+	minSignals, err := strconv.Atoi(c.DefaultQuery("min_signals", "1"))
+	if err != nil || minSignals < 0 {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid min_signals"})
+		return
+	}
+
+	performances, err := h.service.Leaderboard(c.Request.Context(), period, metric, minSignals)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPeriod) || errors.Is(err, domain.ErrInvalidMetric) {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid leaderboard query", Details: err.Error()})
+			return
+		}
+		h.logger.Error("Failed to get agent leaderboard", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get agent leaderboard", Details: err.Error()})
+		return
+	}
+
+	response := LeaderboardResponse{
+		Period:  period,
+		Metric:  metric,
+		Entries: make([]LeaderboardEntry, len(performances)),
+	}
+	for i, performance := range performances {
+		response.Entries[i] = LeaderboardEntry{
+			Rank:           i + 1,
+			AgentName:      performance.AgentName,
+			TotalSignals:   performance.TotalSignals,
+			CorrectSignals: performance.CorrectSignals,
+			Accuracy:       performance.Accuracy,
+			AvgReturn:      performance.AvgReturn,
+			SharpeRatio:    performance.SharpeRatio,
+			MaxDrawdown:    performance.MaxDrawdown,
+			LastUpdated:    performance.LastUpdated,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Analyze godoc
+// @Summary Compute a consensus signal
+// @Description Combine the latest signal of each agent for a symbol into a consensus by majority vote, confidence weighting or weighting by each agent's historical accuracy
+// @Tags ai
+// @Accept json
+// @Produce json
+// @Param request body models.AIAnalysisRequest true "Analysis request"
+// @Success 200 {object} models.AIAnalysisResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/ai/analysis [post]
+func (h *AgentHandler) Analyze(c *gin.Context) {
+	var req models.AIAnalysisRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+	if req.Symbol == "" {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: "symbol is required"})
+		return
+	}
+
+	response, err := h.service.Analyze(c.Request.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidConsensus), errors.Is(err, domain.ErrInvalidPeriod):
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid analysis request", Details: err.Error()})
+		case strings.Contains(err.Error(), "no signals found"):
+			apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "No signals found", Details: err.Error()})
+		default:
+			h.logger.Error("Failed to compute consensus", zap.Error(err))
+			apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to compute consensus", Details: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetLatestSignals godoc
+// @Summary Get the latest AI signals
+// @Description Get each agent's most recent signal per symbol, newest first
+// @Tags ai
+// @Produce json
+// @Param symbols query string false "Comma-separated symbols; all symbols when omitted"
+// @Param limit query int false "Maximum signals" default(20)
+// @Success 200 {array} models.AISignal
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/ai/signals/latest [get]
+func (h *AgentHandler) GetLatestSignals(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSignalLimit)))
+	if err != nil || limit <= 0 || limit > maxSignalLimit {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid limit"})
+		return
+	}
+
+	var list []string
+	if raw := c.Query("symbols"); raw != "" {
+		list = symbols.NormalizeAll(strings.Split(raw, ","))
+	}
+
+	signals, err := h.service.LatestSignals(c.Request.Context(), list, limit)
+	if err != nil {
+		h.logger.Error("Failed to get latest signals", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get latest signals", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, signals)
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hedge-fund/internal/ai/domain"
+	"hedge-fund/internal/ai/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/auth"
+	"hedge-fund/pkg/shared/flags"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/rpc"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultAutoTradeOrderLimit = 100
+
+type AutoTradeHandler struct {
+	service *service.AutoTradeService
+	logger  *zap.Logger
+}
+
+func NewAutoTradeHandler(service *service.AutoTradeService, logger *zap.Logger) *AutoTradeHandler {
+	return &AutoTradeHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// SaveSettings godoc
+// @Summary Link a portfolio to auto-trading
+// @Description Trade a portfolio on consensus signals at or above min_confidence. A buy signal buys order_value of the symbol, trimmed to its position cap; a sell signal closes the position. Buys that would breach the owner's critical risk limits are blocked. In manual mode orders wait for approval.
+// @Tags ai
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param request body AutoTradeSettingsRequest true "Auto-trade settings"
+// @Success 200 {object} models.AutoTradeSettings
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/ai/auto-trade/portfolios/{id} [put]
+func (h *AutoTradeHandler) SaveSettings(c *gin.Context) {
+	portfolioID, ok := h.id(c, "Invalid portfolio ID")
+	if !ok {
+		return
+	}
+
+	var req AutoTradeSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	settings := &models.AutoTradeSettings{
+		PortfolioID:      portfolioID,
+		Mode:             req.Mode,
+		Consensus:        req.Consensus,
+		MinConfidence:    req.MinConfidence,
+		OrderValue:       req.OrderValue,
+		MaxPositionValue: req.MaxPositionValue,
+		SymbolCaps:       req.SymbolCaps,
+		IsEnabled:        req.IsEnabled == nil || *req.IsEnabled,
+	}
+	if err := h.service.SaveSettings(c.Request.Context(), settings); err != nil {
+		h.writeError(c, err, "Failed to save auto-trade settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// GetSettings godoc
+// @Summary Get a portfolio's auto-trade settings
+// @Tags ai
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Success 200 {object} models.AutoTradeSettings
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/ai/auto-trade/portfolios/{id} [get]
+func (h *AutoTradeHandler) GetSettings(c *gin.Context) {
+	portfolioID, ok := h.id(c, "Invalid portfolio ID")
+	if !ok {
+		return
+	}
+
+	settings, err := h.service.GetSettings(c.Request.Context(), portfolioID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get auto-trade settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// DeleteSettings godoc
+// @Summary Unlink a portfolio from auto-trading
+// @Description Delete a portfolio's auto-trade settings along with the orders they raised
+// @Tags ai
+// @Param id path int true "Portfolio ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/ai/auto-trade/portfolios/{id} [delete]
+func (h *AutoTradeHandler) DeleteSettings(c *gin.Context) {
+	portfolioID, ok := h.id(c, "Invalid portfolio ID")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSettings(c.Request.Context(), portfolioID); err != nil {
+		h.writeError(c, err, "Failed to delete auto-trade settings")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListOrders godoc
+// @Summary List a portfolio's auto-trade orders
+// @Description List the latest orders raised by consensus signals, newest first
+// @Tags ai
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param status query string false "pending_approval, approved, executed, rejected, blocked, failed or expired"
+// @Param limit query int false "Maximum orders" default(100)
+// @Success 200 {array} models.AutoTradeOrder
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/ai/auto-trade/portfolios/{id}/orders [get]
+func (h *AutoTradeHandler) ListOrders(c *gin.Context) {
+	portfolioID, ok := h.id(c, "Invalid portfolio ID")
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultAutoTradeOrderLimit)))
+	if err != nil || limit <= 0 {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid limit"})
+		return
+	}
+
+	orders, err := h.service.ListOrders(c.Request.Context(), portfolioID, c.Query("status"), limit)
+	if err != nil {
+		h.writeError(c, err, "Failed to list auto-trade orders")
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// ApproveOrder godoc
+// @Summary Approve an auto-trade order
+// @Description Place an order waiting for approval. It expires instead if it has waited too long, and a buy is blocked if it would now breach a risk limit. Orders worth the two-factor trade threshold or more need the approving user's second factor; refused orders stay awaiting approval.
+// @Tags ai
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param X-2FA-Code header string false "Current two-factor code, for large orders"
+// @Param X-Trusted-Device header string false "Trusted-device token, in place of a code"
+// @Success 200 {object} models.AutoTradeOrder
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/ai/auto-trade/orders/{id}/approve [post]
+func (h *AutoTradeHandler) ApproveOrder(c *gin.Context) {
+	orderID, ok := h.id(c, "Invalid order ID")
+	if !ok {
+		return
+	}
+
+	// The portfolio service asks for the second factor on large orders
+	ctx := rpc.WithSecondFactor(c.Request.Context(),
+		c.GetHeader(auth.HeaderTwoFactorCode), c.GetHeader(auth.HeaderTrustedDevice))
+	order, err := h.service.Approve(ctx, orderID)
+	if err != nil {
+		h.writeError(c, err, "Failed to approve auto-trade order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// RejectOrder godoc
+// @Summary Reject an auto-trade order
+// @Tags ai
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param request body RejectOrderRequest false "Reason"
+// @Success 200 {object} models.AutoTradeOrder
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/ai/auto-trade/orders/{id}/reject [post]
+func (h *AutoTradeHandler) RejectOrder(c *gin.Context) {
+	orderID, ok := h.id(c, "Invalid order ID")
+	if !ok {
+		return
+	}
+
+	var req RejectOrderRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+			return
+		}
+	}
+
+	order, err := h.service.Reject(c.Request.Context(), orderID, req.Reason)
+	if err != nil {
+		h.writeError(c, err, "Failed to reject auto-trade order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// GetKillSwitch godoc
+// @Summary Get the auto-trade kill switch
+// @Tags ai
+// @Produce json
+// @Success 200 {object} models.KillSwitch
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/ai/auto-trade/kill-switch [get]
+func (h *AutoTradeHandler) GetKillSwitch(c *gin.Context) {
+	state, err := h.service.KillSwitch(c.Request.Context())
+	if err != nil {
+		h.writeError(c, err, "Failed to get kill switch")
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// EngageKillSwitch godoc
+// @Summary Engage the auto-trade kill switch
+// @Description Halt all auto-trading, including approval of queued orders, until the kill switch is released
+// @Tags ai
+// @Accept json
+// @Produce json
+// @Param request body KillSwitchRequest true "Reason"
+// @Success 200 {object} models.KillSwitch
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/ai/auto-trade/kill-switch [post]
+func (h *AutoTradeHandler) EngageKillSwitch(c *gin.Context) {
+	var req KillSwitchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	state, err := h.service.EngageKillSwitch(c.Request.Context(), req.Reason)
+	if err != nil {
+		h.writeError(c, err, "Failed to engage kill switch")
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// ReleaseKillSwitch godoc
+// @Summary Release the auto-trade kill switch
+// @Tags ai
+// @Success 204
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/ai/auto-trade/kill-switch [delete]
+func (h *AutoTradeHandler) ReleaseKillSwitch(c *gin.Context) {
+	if err := h.service.ReleaseKillSwitch(c.Request.Context()); err != nil {
+		h.writeError(c, err, "Failed to release kill switch")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AutoTradeHandler) id(c *gin.Context, message string) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: message})
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *AutoTradeHandler) writeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidAutoTrade):
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid auto-trade settings", Details: err.Error()})
+	case errors.Is(err, domain.ErrOrderNotPending):
+		apierror.Respond(c, http.StatusConflict, ErrorResponse{Code: apierror.CodeOrderNotPending, Error: "Order is not awaiting approval", Details: err.Error()})
+	case errors.Is(err, service.ErrKillSwitchEngaged):
+		apierror.Respond(c, http.StatusConflict, ErrorResponse{Code: apierror.CodeKillSwitchEngaged, Error: "Auto-trading is halted", Details: err.Error()})
+	case errors.Is(err, service.ErrOrderRefused) && status.Code(err) == codes.ResourceExhausted:
+		apierror.Respond(c, http.StatusTooManyRequests, ErrorResponse{Code: apierror.CodeRateLimited, Error: "Too many wrong two-factor codes", Details: err.Error()})
+	case errors.Is(err, service.ErrOrderRefused):
+		apierror.Respond(c, http.StatusForbidden, ErrorResponse{Error: "Order refused", Details: err.Error()})
+	case errors.Is(err, flags.ErrDisabled):
+		apierror.Respond(c, http.StatusUnprocessableEntity, ErrorResponse{Code: apierror.CodeFeatureDisabled, Error: "Auto-trading is not enabled for this user", Details: err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Not found", Details: err.Error()})
+	default:
+		h.logger.Error(message, zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: message, Details: err.Error()})
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"time"
+
+	"hedge-fund/pkg/shared/apierror"
+)
+
+// LeaderboardEntry is an agent's rank and performance over a period
+type LeaderboardEntry struct {
+	Rank           int       `json:"rank"`
+	AgentName      string    `json:"agent_name"`
+	TotalSignals   int       `json:"total_signals"`
+	CorrectSignals int       `json:"correct_signals"`
+	Accuracy       float64   `json:"accuracy"`
+	AvgReturn      float64   `json:"avg_return"`
+	SharpeRatio    float64   `json:"sharpe_ratio"`
+	MaxDrawdown    float64   `json:"max_drawdown"`
+	LastUpdated    time.Time `json:"last_updated"`
+}
+
+// LeaderboardResponse ranks agents by a metric over a period
+type LeaderboardResponse struct {
+	Period  string             `json:"period"`
+	Metric  string             `json:"metric"`
+	Entries []LeaderboardEntry `json:"entries"`
+}
+
+// CreateStrategyRequest uploads a strategy script
+type CreateStrategyRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Source   string `json:"source" binding:"required"`
+	Lookback int    `json:"lookback" binding:"omitempty,gt=0"` // Defaults to 20 bars
+}
+
+// UpdateStrategyRequest replaces a strategy script's source, lookback or
+// state. Omitted fields are kept.
+type UpdateStrategyRequest struct {
+	Source   *string `json:"source"`
+	Lookback *int    `json:"lookback" binding:"omitempty,gt=0"`
+	IsActive *bool   `json:"is_active"`
+}
+
+// RunSignalsRequest runs a strategy script on the latest prices of symbols
+type RunSignalsRequest struct {
+	Symbols []string `json:"symbols" binding:"required,min=1,max=50"`
+}
+
+// ErrorResponse is the error envelope shared by every service
+type ErrorResponse = apierror.Response
+
+// AutoTradeSettingsRequest links a portfolio to auto-trading
+type AutoTradeSettingsRequest struct {
+	Mode             string             `json:"mode" binding:"required"` // "auto" or "manual"
+	Consensus        string             `json:"consensus"`               // Defaults to confidence_weighted
+	MinConfidence    float64            `json:"min_confidence"`
+	OrderValue       float64            `json:"order_value"`
+	MaxPositionValue float64            `json:"max_position_value"`
+	SymbolCaps       map[string]float64 `json:"symbol_caps"`
+	IsEnabled        *bool              `json:"is_enabled"` // Defaults to true
+}
+
+// RejectOrderRequest declines an auto-trade order
+type RejectOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+// KillSwitchRequest engages the auto-trade kill switch
+type KillSwitchRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hedge-fund/internal/ai/domain"
+	"hedge-fund/internal/ai/script"
+	"hedge-fund/internal/ai/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type StrategyHandler struct {
+	service *service.StrategyService
+	logger  *zap.Logger
+}
+
+func NewStrategyHandler(service *service.StrategyService, logger *zap.Logger) *StrategyHandler {
+	return &StrategyHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateStrategy godoc
+// @Summary Upload a strategy script
+// @Description Upload a Starlark script defining signal(symbol, closes), which returns "buy", "sell" or "hold" and optionally a confidence. It runs sandboxed as a custom agent.
+// @Tags ai
+// @Accept json
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param request body CreateStrategyRequest true "Strategy script"
+// @Success 201 {object} models.StrategyScript
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/strategies [post]
+func (h *StrategyHandler) CreateStrategy(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	var req CreateStrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	strategy := &models.StrategyScript{
+		UserID:   userID,
+		Name:     req.Name,
+		Source:   req.Source,
+		Lookback: req.Lookback,
+		IsActive: true,
+	}
+	if strategy.Lookback == 0 {
+		strategy.Lookback = domain.DefaultStrategyLookback
+	}
+
+	if err := h.service.CreateStrategy(c.Request.Context(), strategy); err != nil {
+		h.writeError(c, err, "Failed to create strategy")
+		return
+	}
+
+	c.JSON(http.StatusCreated, strategy)
+}
+
+// ListStrategies godoc
+// @Summary List a user's strategy scripts
+// @Tags ai
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {array} models.StrategyScript
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/strategies [get]
+func (h *StrategyHandler) ListStrategies(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	strategies, err := h.service.ListStrategies(c.Request.Context(), userID)
+	if err != nil {
+		h.writeError(c, err, "Failed to list strategies")
+		return
+	}
+
+	c.JSON(http.StatusOK, strategies)
+}
+
+// GetStrategy godoc
+// @Summary Get a strategy script
+// @Tags ai
+// @Produce json
+// @Param id path int true "Strategy ID"
+// @Success 200 {object} models.StrategyScript
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/strategies/{id} [get]
+func (h *StrategyHandler) GetStrategy(c *gin.Context) {
+	strategyID, ok := h.strategyID(c)
+	if !ok {
+		return
+	}
+
+	strategy, err := h.service.GetStrategy(c.Request.Context(), strategyID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get strategy")
+		return
+	}
+
+	c.JSON(http.StatusOK, strategy)
+}
+
+// UpdateStrategy godoc
+// @Summary Update a strategy script
+// @Description Replace a strategy script's source, lookback or active state. The new source is compiled in the sandbox before it is saved.
+// @Tags ai
+// @Accept json
+// @Produce json
+// @Param id path int true "Strategy ID"
+// @Param request body UpdateStrategyRequest true "Strategy changes"
+// @Success 200 {object} models.StrategyScript
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/strategies/{id} [patch]
+func (h *StrategyHandler) UpdateStrategy(c *gin.Context) {
+	strategyID, ok := h.strategyID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateStrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	strategy, err := h.service.GetStrategy(c.Request.Context(), strategyID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get strategy")
+		return
+	}
+	if req.Source != nil {
+		strategy.Source = *req.Source
+	}
+	if req.Lookback != nil {
+		strategy.Lookback = *req.Lookback
+	}
+	if req.IsActive != nil {
+		strategy.IsActive = *req.IsActive
+	}
+
+	if err := h.service.UpdateStrategy(c.Request.Context(), strategy); err != nil {
+		h.writeError(c, err, "Failed to update strategy")
+		return
+	}
+
+	c.JSON(http.StatusOK, strategy)
+}
+
+// DeleteStrategy godoc
+// @Summary Delete a strategy script
+// @Description Delete a strategy script. Signals it recorded are kept.
+// @Tags ai
+// @Param id path int true "Strategy ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/strategies/{id} [delete]
+func (h *StrategyHandler) DeleteStrategy(c *gin.Context) {
+	strategyID, ok := h.strategyID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteStrategy(c.Request.Context(), strategyID); err != nil {
+		h.writeError(c, err, "Failed to delete strategy")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RunSignals godoc
+// @Summary Run a strategy script
+// @Description Run an active strategy script on the latest daily closes of each symbol and record its signals as its custom agent's
+// @Tags ai
+// @Accept json
+// @Produce json
+// @Param id path int true "Strategy ID"
+// @Param request body RunSignalsRequest true "Symbols"
+// @Success 200 {array} models.AISignal
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/strategies/{id}/signals [post]
+func (h *StrategyHandler) RunSignals(c *gin.Context) {
+	strategyID, ok := h.strategyID(c)
+	if !ok {
+		return
+	}
+
+	var req RunSignalsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	signals, err := h.service.RunSignals(c.Request.Context(), strategyID, req.Symbols)
+	if err != nil {
+		h.writeError(c, err, "Failed to run strategy")
+		return
+	}
+
+	c.JSON(http.StatusOK, signals)
+}
+
+func (h *StrategyHandler) strategyID(c *gin.Context) (int, bool) {
+	strategyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid strategy ID"})
+		return 0, false
+	}
+	return strategyID, true
+}
+
+func (h *StrategyHandler) writeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidStrategy), errors.Is(err, script.ErrInvalidScript):
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid strategy", Details: err.Error()})
+	case errors.Is(err, script.ErrScriptFailed):
+		apierror.Respond(c, http.StatusUnprocessableEntity, ErrorResponse{Error: "Strategy script failed", Details: err.Error()})
+	case strings.Contains(err.Error(), "already exists"):
+		apierror.Respond(c, http.StatusConflict, ErrorResponse{Error: "Strategy already exists", Details: err.Error()})
+	case strings.Contains(err.Error(), "not found"), strings.Contains(err.Error(), "no price history"):
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Not found", Details: err.Error()})
+	default:
+		h.logger.Error(message, zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: message, Details: err.Error()})
+	}
+}
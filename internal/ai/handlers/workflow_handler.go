@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"hedge-fund/internal/ai/workflow"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type WorkflowHandler struct {
+	engine *workflow.Engine
+	logger *zap.Logger
+}
+
+func NewWorkflowHandler(engine *workflow.Engine, logger *zap.Logger) *WorkflowHandler {
+	return &WorkflowHandler{
+		engine: engine,
+		logger: logger,
+	}
+}
+
+// StartWorkflow godoc
+// @Summary Start an AI analysis workflow
+// @Description Run a multi-step analysis of a symbol in the background: fetch market data, fetch fundamentals, run the agents, compute risk and build the consensus. Each step is retried with backoff before the workflow fails. Follow its progress at the status endpoint.
+// @Tags ai
+// @Accept json
+// @Produce json
+// @Param request body models.AIAnalysisRequest true "Analysis request"
+// @Success 202 {object} models.WorkflowStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/ai/workflows [post]
+func (h *WorkflowHandler) StartWorkflow(c *gin.Context) {
+	var req models.AIAnalysisRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	status, err := h.engine.Start(c.Request.Context(), req)
+	if err != nil {
+		h.writeError(c, err, "Failed to start workflow")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, status)
+}
+
+// GetWorkflowStatus godoc
+// @Summary Get an AI workflow's status
+// @Description Get a workflow's progress through its steps, with each step's attempts and latest error, and the analysis once it completes. With Accept text/event-stream the status is streamed as "progress" server-sent events on every change until the workflow finishes.
+// @Tags ai
+// @Produce json
+// @Produce text/event-stream
+// @Param id path string true "Workflow request ID"
+// @Success 200 {object} models.WorkflowStatus
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/ai/workflows/{id} [get]
+func (h *WorkflowHandler) GetWorkflowStatus(c *gin.Context) {
+	requestID := c.Param("id")
+
+	if !strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		status, err := h.engine.Get(c.Request.Context(), requestID)
+		if err != nil {
+			h.writeError(c, err, "Failed to get workflow")
+			return
+		}
+		c.JSON(http.StatusOK, status)
+		return
+	}
+
+	// The stream outlives the server's write timeout. Headers are sent with
+	// the first event, so a missing workflow can still be reported as such.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to lift write deadline for workflow stream", zap.Error(err))
+	}
+	started := false
+	err := h.engine.Watch(c.Request.Context(), requestID, func(status *models.WorkflowStatus) bool {
+		if !started {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			started = true
+		}
+		c.SSEvent("progress", status)
+		c.Writer.Flush()
+		return true
+	})
+	if err != nil && !started {
+		h.writeError(c, err, "Failed to stream workflow")
+		return
+	}
+	if err != nil {
+		h.logger.Warn("Workflow stream ended", zap.Error(err), zap.String("request_id", requestID))
+	}
+}
+
+func (h *WorkflowHandler) writeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, workflow.ErrInvalidWorkflow):
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid workflow request", Details: err.Error()})
+	case errors.Is(err, workflow.ErrWorkflowNotFound):
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Workflow not found", Details: err.Error()})
+	default:
+		h.logger.Error(message, zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: message, Details: err.Error()})
+	}
+}
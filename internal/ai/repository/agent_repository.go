@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+)
+
+type AgentRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewAgentRepository(db *database.DB, logger *zap.Logger) *AgentRepository {
+	return &AgentRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetSignalsSince retrieves every AI signal created at or after since, oldest first
+func (r *AgentRepository) GetSignalsSince(ctx context.Context, since time.Time) ([]models.AISignal, error) {
+	query := `
+		SELECT id, agent_name, symbol, signal, confidence, COALESCE(reasoning, ''), COALESCE(price, 0), created_at
+		FROM ai_signals
+		WHERE created_at >= $1
+		ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		r.logger.Error("Failed to get AI signals", zap.Error(err))
+		return nil, fmt.Errorf("failed to get AI signals: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanSignals(rows)
+}
+
+// GetSymbolSignals retrieves the AI signals for symbol created between
+// start and end, limited to agents when any are given, oldest first
+func (r *AgentRepository) GetSymbolSignals(ctx context.Context, symbol string, agents []string, start, end time.Time) ([]models.AISignal, error) {
+	query := `
+		SELECT id, agent_name, symbol, signal, confidence, COALESCE(reasoning, ''), COALESCE(price, 0), created_at
+		FROM ai_signals
+		WHERE symbol = $1 AND created_at >= $2 AND created_at <= $3
+		  AND (cardinality($4::text[]) = 0 OR agent_name = ANY($4))
+		ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, symbol, start, end, pq.Array(agents))
+	if err != nil {
+		r.logger.Error("Failed to get AI signals", zap.Error(err), zap.String("symbol", symbol))
+		return nil, fmt.Errorf("failed to get AI signals: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanSignals(rows)
+}
+
+// GetLatestSignals retrieves each agent's most recent AI signal per
+// symbol, limited to symbols when any are given, newest first
+func (r *AgentRepository) GetLatestSignals(ctx context.Context, symbols []string, limit int) ([]models.AISignal, error) {
+	query := `
+		SELECT id, agent_name, symbol, signal, confidence, reasoning, price, created_at
+		FROM (
+			SELECT DISTINCT ON (agent_name, symbol)
+			       id, agent_name, symbol, signal, confidence, COALESCE(reasoning, '') AS reasoning,
+			       COALESCE(price, 0) AS price, created_at
+			FROM ai_signals
+			WHERE cardinality($1::text[]) = 0 OR symbol = ANY($1)
+			ORDER BY agent_name, symbol, created_at DESC, id DESC
+		) latest
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(symbols), limit)
+	if err != nil {
+		r.logger.Error("Failed to get latest AI signals", zap.Error(err))
+		return nil, fmt.Errorf("failed to get latest AI signals: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanSignals(rows)
+}
+
+func (r *AgentRepository) scanSignals(rows *sql.Rows) ([]models.AISignal, error) {
+	var signals []models.AISignal
+	for rows.Next() {
+		signal := models.AISignal{}
+		err := rows.Scan(
+			&signal.ID,
+			&signal.AgentName,
+			&signal.Symbol,
+			&signal.Signal,
+			&signal.Confidence,
+			&signal.Reasoning,
+			&signal.Price,
+			&signal.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan AI signal", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan AI signal: %w", err)
+		}
+		signals = append(signals, signal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating AI signals: %w", err)
+	}
+
+	return signals, nil
+}
+
+// ReplaceAgentPerformance replaces every agent's overall performance, kept
+// without a symbol, with performances
+func (r *AgentRepository) ReplaceAgentPerformance(ctx context.Context, performances []models.AgentPerformance) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM agent_performance WHERE symbol IS NULL"); err != nil {
+		r.logger.Error("Failed to clear agent performance", zap.Error(err))
+		return fmt.Errorf("failed to clear agent performance: %w", err)
+	}
+
+	query := `
+		INSERT INTO agent_performance (agent_name, symbol, period, total_signals, correct_signals,
+		                               accuracy, avg_return, sharpe_ratio, max_drawdown, last_updated)
+		VALUES ($1, NULL, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+
+	for i := range performances {
+		performance := &performances[i]
+		err := tx.QueryRowContext(ctx, query,
+			performance.AgentName,
+			performance.Period,
+			performance.TotalSignals,
+			performance.CorrectSignals,
+			performance.Accuracy,
+			performance.AvgReturn,
+			performance.SharpeRatio,
+			performance.MaxDrawdown,
+			performance.LastUpdated,
+		).Scan(&performance.ID)
+		if err != nil {
+			r.logger.Error("Failed to insert agent performance", zap.Error(err), zap.String("agent", performance.AgentName))
+			return fmt.Errorf("failed to insert agent performance: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetAgentPerformance retrieves agents' overall performance, filtered by
+// agent and period when they are non-empty
+func (r *AgentRepository) GetAgentPerformance(ctx context.Context, agent, period string) ([]models.AgentPerformance, error) {
+	query := `
+		SELECT id, agent_name, period, total_signals, correct_signals, accuracy,
+		       avg_return, sharpe_ratio, max_drawdown, last_updated
+		FROM agent_performance
+		WHERE symbol IS NULL AND ($1 = '' OR agent_name = $1) AND ($2 = '' OR period = $2)
+		ORDER BY agent_name, period`
+
+	rows, err := r.db.QueryContext(ctx, query, agent, period)
+	if err != nil {
+		r.logger.Error("Failed to get agent performance", zap.Error(err))
+		return nil, fmt.Errorf("failed to get agent performance: %w", err)
+	}
+	defer rows.Close()
+
+	performances := []models.AgentPerformance{}
+	for rows.Next() {
+		performance := models.AgentPerformance{}
+		err := rows.Scan(
+			&performance.ID,
+			&performance.AgentName,
+			&performance.Period,
+			&performance.TotalSignals,
+			&performance.CorrectSignals,
+			&performance.Accuracy,
+			&performance.AvgReturn,
+			&performance.SharpeRatio,
+			&performance.MaxDrawdown,
+			&performance.LastUpdated,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan agent performance", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan agent performance: %w", err)
+		}
+		performances = append(performances, performance)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent performance: %w", err)
+	}
+
+	return performances, nil
+}
@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/ai/domain"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Auto-Trade Operations
+
+const autoTradeSettingsColumns = `
+	id, user_id, portfolio_id, mode, consensus, min_confidence, order_value, max_position_value,
+	symbol_caps, is_enabled, created_at, updated_at`
+
+const autoTradeOrderColumns = `
+	id, settings_id, user_id, portfolio_id, symbol, side, quantity, price, consensus_signal, confidence,
+	status, COALESCE(reason, ''), trade_id, created_at, decided_at`
+
+// SaveAutoTradeSettings creates or replaces a portfolio's auto-trade settings
+func (r *AgentRepository) SaveAutoTradeSettings(ctx context.Context, settings *models.AutoTradeSettings) error {
+	caps, err := json.Marshal(settings.SymbolCaps)
+	if err != nil {
+		return fmt.Errorf("failed to encode symbol caps: %w", err)
+	}
+
+	query := `
+		INSERT INTO auto_trade_settings (user_id, portfolio_id, mode, consensus, min_confidence, order_value,
+		                                 max_position_value, symbol_caps, is_enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (portfolio_id) DO UPDATE
+		SET mode = EXCLUDED.mode, consensus = EXCLUDED.consensus, min_confidence = EXCLUDED.min_confidence,
+		    order_value = EXCLUDED.order_value, max_position_value = EXCLUDED.max_position_value,
+		    symbol_caps = EXCLUDED.symbol_caps, is_enabled = EXCLUDED.is_enabled
+		RETURNING id, created_at, updated_at`
+
+	err = r.db.QueryRowContext(ctx, query, settings.UserID, settings.PortfolioID, settings.Mode, settings.Consensus,
+		settings.MinConfidence, settings.OrderValue, settings.MaxPositionValue, caps, settings.IsEnabled,
+	).Scan(&settings.ID, &settings.CreatedAt, &settings.UpdatedAt)
+	if err != nil {
+		r.logger.Error("Failed to save auto-trade settings", zap.Error(err), zap.Int("portfolio_id", settings.PortfolioID))
+		return fmt.Errorf("failed to save auto-trade settings: %w", err)
+	}
+
+	return nil
+}
+
+// GetAutoTradeSettings retrieves a portfolio's auto-trade settings
+func (r *AgentRepository) GetAutoTradeSettings(ctx context.Context, portfolioID int) (*models.AutoTradeSettings, error) {
+	query := `SELECT ` + autoTradeSettingsColumns + ` FROM auto_trade_settings WHERE portfolio_id = $1`
+
+	settings, err := scanAutoTradeSettings(r.db.QueryRowContext(ctx, query, portfolioID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("auto-trade settings not found for portfolio %d", portfolioID)
+		}
+		r.logger.Error("Failed to get auto-trade settings", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, fmt.Errorf("failed to get auto-trade settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// GetEnabledAutoTradeSettings retrieves every portfolio's enabled auto-trade
+// settings
+func (r *AgentRepository) GetEnabledAutoTradeSettings(ctx context.Context) ([]models.AutoTradeSettings, error) {
+	query := `SELECT ` + autoTradeSettingsColumns + ` FROM auto_trade_settings WHERE is_enabled = true ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to get auto-trade settings", zap.Error(err))
+		return nil, fmt.Errorf("failed to get auto-trade settings: %w", err)
+	}
+	defer rows.Close()
+
+	var all []models.AutoTradeSettings
+	for rows.Next() {
+		settings, err := scanAutoTradeSettings(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan auto-trade settings: %w", err)
+		}
+		all = append(all, *settings)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating auto-trade settings: %w", err)
+	}
+
+	return all, nil
+}
+
+// DeleteAutoTradeSettings deletes a portfolio's auto-trade settings and the
+// orders they raised
+func (r *AgentRepository) DeleteAutoTradeSettings(ctx context.Context, portfolioID int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM auto_trade_settings WHERE portfolio_id = $1", portfolioID)
+	if err != nil {
+		r.logger.Error("Failed to delete auto-trade settings", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return fmt.Errorf("failed to delete auto-trade settings: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("auto-trade settings not found for portfolio %d", portfolioID)
+	}
+
+	return nil
+}
+
+// CreateAutoTradeOrder records an order raised by a consensus signal
+func (r *AgentRepository) CreateAutoTradeOrder(ctx context.Context, order *models.AutoTradeOrder) error {
+	query := `
+		INSERT INTO auto_trade_orders (settings_id, user_id, portfolio_id, symbol, side, quantity, price,
+		                               consensus_signal, confidence, status, reason, decided_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query, order.SettingsID, order.UserID, order.PortfolioID, order.Symbol,
+		order.Side, order.Quantity, order.Price, order.ConsensusSignal, order.Confidence, order.Status,
+		order.Reason, order.DecidedAt,
+	).Scan(&order.ID, &order.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create auto-trade order", zap.Error(err), zap.Int("portfolio_id", order.PortfolioID))
+		return fmt.Errorf("failed to create auto-trade order: %w", err)
+	}
+
+	return nil
+}
+
+// GetAutoTradeOrder retrieves an auto-trade order by ID
+func (r *AgentRepository) GetAutoTradeOrder(ctx context.Context, orderID int) (*models.AutoTradeOrder, error) {
+	query := `SELECT ` + autoTradeOrderColumns + ` FROM auto_trade_orders WHERE id = $1`
+
+	order, err := scanAutoTradeOrder(r.db.QueryRowContext(ctx, query, orderID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("auto-trade order not found: %d", orderID)
+		}
+		r.logger.Error("Failed to get auto-trade order", zap.Error(err), zap.Int("order_id", orderID))
+		return nil, fmt.Errorf("failed to get auto-trade order: %w", err)
+	}
+
+	return order, nil
+}
+
+// GetAutoTradeOrders retrieves a portfolio's latest limit auto-trade orders,
+// newest first, filtered by status when it is non-empty
+func (r *AgentRepository) GetAutoTradeOrders(ctx context.Context, portfolioID int, status string, limit int) ([]models.AutoTradeOrder, error) {
+	query := `SELECT ` + autoTradeOrderColumns + `
+		FROM auto_trade_orders
+		WHERE portfolio_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, portfolioID, status, limit)
+	if err != nil {
+		r.logger.Error("Failed to get auto-trade orders", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, fmt.Errorf("failed to get auto-trade orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []models.AutoTradeOrder{}
+	for rows.Next() {
+		order, err := scanAutoTradeOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan auto-trade order: %w", err)
+		}
+		orders = append(orders, *order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating auto-trade orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// HasRecentAutoTradeOrder reports whether settings raised an order in symbol
+// at or after since, or one still awaits approval
+func (r *AgentRepository) HasRecentAutoTradeOrder(ctx context.Context, settingsID int, symbol string, since time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM auto_trade_orders
+			WHERE settings_id = $1 AND symbol = $2 AND (created_at >= $3 OR status = $4)
+		)`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, settingsID, symbol, since, models.AutoTradePendingApproval).Scan(&exists)
+	if err != nil {
+		r.logger.Error("Failed to check recent auto-trade orders", zap.Error(err), zap.Int("settings_id", settingsID))
+		return false, fmt.Errorf("failed to check recent auto-trade orders: %w", err)
+	}
+	return exists, nil
+}
+
+// TransitionAutoTradeOrder moves an order from status from to status to,
+// recording why and the trade it placed, if any. It returns
+// domain.ErrOrderNotPending when the order is no longer in status from, so
+// concurrent decisions on one order cannot both succeed.
+func (r *AgentRepository) TransitionAutoTradeOrder(ctx context.Context, order *models.AutoTradeOrder, from, to, reason string, tradeID *int) error {
+	query := `
+		UPDATE auto_trade_orders
+		SET status = $3, reason = COALESCE(NULLIF($4, ''), reason), trade_id = COALESCE($5, trade_id), decided_at = $6
+		WHERE id = $1 AND status = $2`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, order.ID, from, to, reason, tradeID, now)
+	if err != nil {
+		r.logger.Error("Failed to update auto-trade order", zap.Error(err), zap.Int("order_id", order.ID))
+		return fmt.Errorf("failed to update auto-trade order: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: order %d", domain.ErrOrderNotPending, order.ID)
+	}
+
+	order.Status = to
+	if reason != "" {
+		order.Reason = reason
+	}
+	if tradeID != nil {
+		order.TradeID = tradeID
+	}
+	order.DecidedAt = &now
+	return nil
+}
+
+func scanAutoTradeSettings(row rowScanner) (*models.AutoTradeSettings, error) {
+	settings := &models.AutoTradeSettings{}
+	var caps []byte
+	err := row.Scan(
+		&settings.ID,
+		&settings.UserID,
+		&settings.PortfolioID,
+		&settings.Mode,
+		&settings.Consensus,
+		&settings.MinConfidence,
+		&settings.OrderValue,
+		&settings.MaxPositionValue,
+		&caps,
+		&settings.IsEnabled,
+		&settings.CreatedAt,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(caps, &settings.SymbolCaps); err != nil {
+		return nil, fmt.Errorf("failed to decode symbol caps: %w", err)
+	}
+	return settings, nil
+}
+
+func scanAutoTradeOrder(row rowScanner) (*models.AutoTradeOrder, error) {
+	order := &models.AutoTradeOrder{}
+	err := row.Scan(
+		&order.ID,
+		&order.SettingsID,
+		&order.UserID,
+		&order.PortfolioID,
+		&order.Symbol,
+		&order.Side,
+		&order.Quantity,
+		&order.Price,
+		&order.ConsensusSignal,
+		&order.Confidence,
+		&order.Status,
+		&order.Reason,
+		&order.TradeID,
+		&order.CreatedAt,
+		&order.DecidedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Strategy Script Operations
+
+const strategyColumns = `id, user_id, name, agent_name, source, lookback, is_active, created_at, updated_at`
+
+// CreateStrategy saves a new strategy script
+func (r *AgentRepository) CreateStrategy(ctx context.Context, strategy *models.StrategyScript) error {
+	query := `
+		INSERT INTO strategy_scripts (user_id, name, agent_name, source, lookback, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query, strategy.UserID, strategy.Name, strategy.AgentName, strategy.Source,
+		strategy.Lookback, strategy.IsActive, now, now).Scan(&strategy.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("strategy %q already exists for user %d", strategy.Name, strategy.UserID)
+		}
+		r.logger.Error("Failed to create strategy", zap.Error(err), zap.Int("user_id", strategy.UserID))
+		return fmt.Errorf("failed to create strategy: %w", err)
+	}
+
+	strategy.CreatedAt = now
+	strategy.UpdatedAt = now
+	return nil
+}
+
+// GetStrategy retrieves a strategy script by ID
+func (r *AgentRepository) GetStrategy(ctx context.Context, strategyID int) (*models.StrategyScript, error) {
+	query := `SELECT ` + strategyColumns + ` FROM strategy_scripts WHERE id = $1`
+
+	strategy, err := scanStrategy(r.db.QueryRowContext(ctx, query, strategyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("strategy not found: %d", strategyID)
+		}
+		r.logger.Error("Failed to get strategy", zap.Error(err), zap.Int("strategy_id", strategyID))
+		return nil, fmt.Errorf("failed to get strategy: %w", err)
+	}
+
+	return strategy, nil
+}
+
+// GetStrategiesByUserID retrieves a user's strategy scripts by name
+func (r *AgentRepository) GetStrategiesByUserID(ctx context.Context, userID int) ([]models.StrategyScript, error) {
+	query := `SELECT ` + strategyColumns + ` FROM strategy_scripts WHERE user_id = $1 ORDER BY name`
+	return r.queryStrategies(ctx, query, userID)
+}
+
+// GetActiveStrategies retrieves every active strategy script by agent name,
+// or only those running as one of agents when it is non-empty
+func (r *AgentRepository) GetActiveStrategies(ctx context.Context, agents []string) ([]models.StrategyScript, error) {
+	query := `
+		SELECT ` + strategyColumns + ` FROM strategy_scripts
+		WHERE is_active AND (cardinality($1::text[]) = 0 OR agent_name = ANY($1))
+		ORDER BY agent_name`
+	if agents == nil {
+		agents = []string{} // A nil array is NULL
+	}
+	return r.queryStrategies(ctx, query, pq.Array(agents))
+}
+
+func (r *AgentRepository) queryStrategies(ctx context.Context, query string, args ...interface{}) ([]models.StrategyScript, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to get strategies", zap.Error(err))
+		return nil, fmt.Errorf("failed to get strategies: %w", err)
+	}
+	defer rows.Close()
+
+	strategies := []models.StrategyScript{}
+	for rows.Next() {
+		strategy, err := scanStrategy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan strategy: %w", err)
+		}
+		strategies = append(strategies, *strategy)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating strategies: %w", err)
+	}
+
+	return strategies, nil
+}
+
+// UpdateStrategy replaces a strategy script's source, lookback and state
+func (r *AgentRepository) UpdateStrategy(ctx context.Context, strategy *models.StrategyScript) error {
+	query := `
+		UPDATE strategy_scripts
+		SET source = $2, lookback = $3, is_active = $4, updated_at = $5
+		WHERE id = $1`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, strategy.ID, strategy.Source, strategy.Lookback, strategy.IsActive, now)
+	if err != nil {
+		r.logger.Error("Failed to update strategy", zap.Error(err), zap.Int("strategy_id", strategy.ID))
+		return fmt.Errorf("failed to update strategy: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("strategy not found: %d", strategy.ID)
+	}
+
+	strategy.UpdatedAt = now
+	return nil
+}
+
+// DeleteStrategy deletes a strategy script. The signals it recorded are kept.
+func (r *AgentRepository) DeleteStrategy(ctx context.Context, strategyID int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM strategy_scripts WHERE id = $1", strategyID)
+	if err != nil {
+		r.logger.Error("Failed to delete strategy", zap.Error(err), zap.Int("strategy_id", strategyID))
+		return fmt.Errorf("failed to delete strategy: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("strategy not found: %d", strategyID)
+	}
+
+	return nil
+}
+
+// CreateSignal records an AI signal
+func (r *AgentRepository) CreateSignal(ctx context.Context, signal *models.AISignal) error {
+	query := `
+		INSERT INTO ai_signals (agent_name, symbol, signal, confidence, reasoning, price, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	signal.CreatedAt = time.Now()
+	err := r.db.QueryRowContext(ctx, query, signal.AgentName, signal.Symbol, signal.Signal, signal.Confidence,
+		signal.Reasoning, signal.Price, signal.CreatedAt).Scan(&signal.ID)
+	if err != nil {
+		r.logger.Error("Failed to create AI signal", zap.Error(err), zap.String("agent", signal.AgentName))
+		return fmt.Errorf("failed to create AI signal: %w", err)
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanStrategy(row rowScanner) (*models.StrategyScript, error) {
+	strategy := &models.StrategyScript{}
+	err := row.Scan(
+		&strategy.ID,
+		&strategy.UserID,
+		&strategy.Name,
+		&strategy.AgentName,
+		&strategy.Source,
+		&strategy.Lookback,
+		&strategy.IsActive,
+		&strategy.CreatedAt,
+		&strategy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return strategy, nil
+}
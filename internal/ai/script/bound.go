@@ -0,0 +1,509 @@
+package script
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// Bounding values
+//
+// The memory limit is checked every few milliseconds across the process, so
+// one operation such as "a" * 1000000000 can allocate far past it before it
+// is noticed. The operations that can build a large value from small ones
+// are therefore checked before they run: the operators +, * and %, the
+// methods join, replace, format and extend, and the builtins that build
+// lists from ranges. Compile rewrites scripts to call the checks, under
+// names that are not identifiers so scripts cannot call or shadow them.
+
+// maxLen bounds each value a script builds: a string's or integer's bytes,
+// or a list's or tuple's elements
+const maxLen = 1 << 20
+
+// Names of the checks scripts are rewritten to call
+const (
+	binaryCheck = "*binary" // *binary(op, x, y) is x op y
+	updateCheck = "*update" // *update(op, x, y) is y, when x op y is in bounds
+	attrCheck   = "*attr"   // *attr(x, name) is x.name
+)
+
+// boundedOps are the operators checked, by their text in rewritten scripts
+var boundedOps = map[string]syntax.Token{
+	syntax.PLUS.String():    syntax.PLUS,
+	syntax.STAR.String():    syntax.STAR,
+	syntax.PERCENT.String(): syntax.PERCENT,
+}
+
+// updateOps maps the augmented assignments checked to their operators
+var updateOps = map[syntax.Token]syntax.Token{
+	syntax.PLUS_EQ:    syntax.PLUS,
+	syntax.STAR_EQ:    syntax.STAR,
+	syntax.PERCENT_EQ: syntax.PERCENT,
+}
+
+// checks are the predeclared names of bounded operations. The builtins
+// shadow the universal ones.
+var checks = starlark.StringDict{
+	binaryCheck: starlark.NewBuiltin(binaryCheck, checkedBinary),
+	updateCheck: starlark.NewBuiltin(updateCheck, checkedUpdate),
+	attrCheck:   starlark.NewBuiltin(attrCheck, checkedAttr),
+	"getattr":   starlark.NewBuiltin("getattr", checkedGetattr),
+	"enumerate": sized(starlark.Universe["enumerate"]),
+	"list":      sized(starlark.Universe["list"]),
+	"reversed":  sized(starlark.Universe["reversed"]),
+	"set":       sized(starlark.Universe["set"]),
+	"sorted":    sized(starlark.Universe["sorted"]),
+	"tuple":     sized(starlark.Universe["tuple"]),
+	"zip":       sized(starlark.Universe["zip"]),
+}
+
+// methodChecks check the arguments of methods that can build large values,
+// by receiver type and name
+var methodChecks = map[string]func(recv starlark.Value, args starlark.Tuple, kwargs []starlark.Tuple) error{
+	"string.join":    checkJoin,
+	"string.replace": checkReplace,
+	"string.format":  checkFormat,
+	"list.extend":    checkExtend,
+}
+
+// checkLen refuses values longer than maxLen
+func checkLen(n int) error {
+	if n > maxLen {
+		return fmt.Errorf("value of length %d exceeds the limit of %d", n, maxLen)
+	}
+	return nil
+}
+
+// checkBinary checks the size of x op y before it is computed
+func checkBinary(op syntax.Token, x, y starlark.Value) error {
+	switch op {
+	case syntax.PLUS:
+		if n, m := starlark.Len(x), starlark.Len(y); n >= 0 && m >= 0 {
+			return checkLen(n + m)
+		}
+	case syntax.STAR:
+		if n := starlark.Len(x); n >= 0 {
+			return checkRepeat(n, y)
+		}
+		if n := starlark.Len(y); n >= 0 {
+			return checkRepeat(n, x)
+		}
+		return checkProduct(x, y)
+	case syntax.PERCENT:
+		if format, ok := x.(starlark.String); ok {
+			return checkLen(formatLen(string(format), "%", formatArgs(y)))
+		}
+	}
+	return nil
+}
+
+// checkRepeat checks a sequence of n elements repeated count times
+func checkRepeat(n int, count starlark.Value) error {
+	i, ok := count.(starlark.Int)
+	if !ok {
+		return nil
+	}
+	k, ok := i.Int64()
+	if !ok {
+		return fmt.Errorf("repeat count %s exceeds the limit of %d", i, maxLen)
+	}
+	if n > 0 && k > 0 && int64(n) > int64(maxLen)/k {
+		return fmt.Errorf("repeating %d elements %d times exceeds the limit of %d", n, k, maxLen)
+	}
+	return nil
+}
+
+// checkProduct checks the bytes of a product of integers
+func checkProduct(x, y starlark.Value) error {
+	a, ok := x.(starlark.Int)
+	if !ok {
+		return nil
+	}
+	b, ok := y.(starlark.Int)
+	if !ok {
+		return nil
+	}
+	if _, ok := a.Int64(); ok {
+		if _, ok := b.Int64(); ok {
+			return nil
+		}
+	}
+	return checkLen((a.BigInt().BitLen() + b.BigInt().BitLen()) / 8)
+}
+
+// formatArgs returns the values a % format draws on
+func formatArgs(y starlark.Value) []starlark.Value {
+	switch y := y.(type) {
+	case starlark.Tuple:
+		return y
+	case *starlark.Dict:
+		values := make([]starlark.Value, 0, y.Len())
+		for _, item := range y.Items() {
+			values = append(values, item[1])
+		}
+		return values
+	default:
+		return []starlark.Value{y}
+	}
+}
+
+// formatLen bounds the length of format filled from args: each directive,
+// opened by open, takes at most the longest argument
+func formatLen(format, open string, args []starlark.Value) int {
+	longest := 0
+	for _, arg := range args {
+		longest = max(longest, len(arg.String()))
+	}
+	return len(format) + strings.Count(format, open)*longest
+}
+
+func checkJoin(recv starlark.Value, args starlark.Tuple, kwargs []starlark.Tuple) error {
+	if len(args) != 1 {
+		return nil
+	}
+	if err := checkLen(starlark.Len(args[0])); err != nil {
+		return err
+	}
+	iterable, ok := args[0].(starlark.Iterable)
+	if !ok {
+		return nil
+	}
+	iter := iterable.Iterate()
+	defer iter.Done()
+
+	n, count := 0, 0
+	var x starlark.Value
+	for iter.Next(&x) {
+		if s, ok := x.(starlark.String); ok {
+			n += len(s)
+		}
+		if count > 0 {
+			n += len(recv.(starlark.String))
+		}
+		count++
+		if err := checkLen(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkReplace(recv starlark.Value, args starlark.Tuple, kwargs []starlark.Tuple) error {
+	var old, repl string
+	count := -1
+	if err := starlark.UnpackPositionalArgs("replace", args, kwargs, 2, &old, &repl, &count); err != nil {
+		return nil // Left to the method to report
+	}
+	s := string(recv.(starlark.String))
+	n := strings.Count(s, old)
+	if count >= 0 && count < n {
+		n = count
+	}
+	return checkLen(len(s) + n*(len(repl)-len(old)))
+}
+
+func checkFormat(recv starlark.Value, args starlark.Tuple, kwargs []starlark.Tuple) error {
+	values := append([]starlark.Value(nil), args...)
+	for _, kwarg := range kwargs {
+		values = append(values, kwarg[1])
+	}
+	return checkLen(formatLen(string(recv.(starlark.String)), "{", values))
+}
+
+func checkExtend(recv starlark.Value, args starlark.Tuple, kwargs []starlark.Tuple) error {
+	if len(args) != 1 {
+		return nil
+	}
+	if n := starlark.Len(args[0]); n >= 0 {
+		return checkLen(starlark.Len(recv) + n)
+	}
+	return nil
+}
+
+// checkedBinary is *binary(op, x, y)
+func checkedBinary(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	op, x, y, err := unpackOp(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBinary(op, x, y); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return starlark.Binary(op, x, y)
+}
+
+// checkedUpdate is *update(op, x, y), which checks x op= y before it runs
+func checkedUpdate(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	op, x, y, err := unpackOp(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBinary(op, x, y); err != nil {
+		return nil, fmt.Errorf("%s=: %w", op, err)
+	}
+	return y, nil
+}
+
+func unpackOp(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (syntax.Token, starlark.Value, starlark.Value, error) {
+	var name string
+	var x, y starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 3, &name, &x, &y); err != nil {
+		return 0, nil, nil, err
+	}
+	op, ok := boundedOps[name]
+	if !ok {
+		return 0, nil, nil, fmt.Errorf("%s: unknown operator %s", b.Name(), name)
+	}
+	return op, x, y, nil
+}
+
+// checkedAttr is *attr(x, name)
+func checkedAttr(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x starlark.Value
+	var name string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &x, &name); err != nil {
+		return nil, err
+	}
+	attr, err := lookup(x, name)
+	if err != nil {
+		return nil, err
+	}
+	if attr == nil {
+		return nil, fmt.Errorf("%s has no .%s field or method", x.Type(), name)
+	}
+	return attr, nil
+}
+
+// checkedGetattr is getattr(x, name[, default]) with the checks of *attr
+func checkedGetattr(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x, dflt starlark.Value
+	var name string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &x, &name, &dflt); err != nil {
+		return nil, err
+	}
+	attr, err := lookup(x, name)
+	if err != nil {
+		return nil, err
+	}
+	if attr == nil {
+		if dflt != nil {
+			return dflt, nil
+		}
+		return nil, fmt.Errorf("getattr: %s has no .%s field or method", x.Type(), name)
+	}
+	return attr, nil
+}
+
+// lookup returns x.name, with methods that can build large values checking
+// their arguments first, or nil if x has no such attribute
+func lookup(x starlark.Value, name string) (starlark.Value, error) {
+	has, ok := x.(starlark.HasAttrs)
+	if !ok {
+		return nil, nil
+	}
+	attr, err := has.Attr(name)
+	if err != nil || attr == nil {
+		return nil, err
+	}
+	check := methodChecks[x.Type()+"."+name]
+	method, ok := attr.(*starlark.Builtin)
+	if check == nil || !ok {
+		return attr, nil
+	}
+	return starlark.NewBuiltin(method.Name(), func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := check(b.Receiver(), args, kwargs); err != nil {
+			return nil, fmt.Errorf("%s: %w", b.Name(), err)
+		}
+		return starlark.Call(thread, method, args, kwargs)
+	}).BindReceiver(x), nil
+}
+
+// sized wraps a builtin to refuse arguments longer than maxLen, such as
+// large ranges, which it would copy
+func sized(builtin starlark.Value) *starlark.Builtin {
+	b := builtin.(*starlark.Builtin)
+	return starlark.NewBuiltin(b.Name(), func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		for _, arg := range args {
+			if err := checkLen(starlark.Len(arg)); err != nil {
+				return nil, fmt.Errorf("%s: %w", b.Name(), err)
+			}
+		}
+		return starlark.Call(thread, b, args, kwargs)
+	})
+}
+
+// bound rewrites a parsed script to check the operations that can build
+// large values
+func bound(f *syntax.File) {
+	boundStmts(f.Stmts)
+}
+
+func boundStmts(stmts []syntax.Stmt) {
+	for _, stmt := range stmts {
+		boundStmt(stmt)
+	}
+}
+
+func boundStmt(stmt syntax.Stmt) {
+	switch stmt := stmt.(type) {
+	case *syntax.ExprStmt:
+		stmt.X = boundExpr(stmt.X)
+	case *syntax.IfStmt:
+		stmt.Cond = boundExpr(stmt.Cond)
+		boundStmts(stmt.True)
+		boundStmts(stmt.False)
+	case *syntax.AssignStmt:
+		boundTarget(stmt.LHS)
+		stmt.RHS = boundExpr(stmt.RHS)
+		if op, ok := updateOps[stmt.Op]; ok {
+			// The target is evaluated again to check it
+			stmt.RHS = call(updateCheck, stmt.OpPos, str(op.String(), stmt.OpPos), stmt.LHS, stmt.RHS)
+		}
+	case *syntax.DefStmt:
+		boundParams(stmt.Params)
+		boundStmts(stmt.Body)
+	case *syntax.ForStmt:
+		boundTarget(stmt.Vars)
+		stmt.X = boundExpr(stmt.X)
+		boundStmts(stmt.Body)
+	case *syntax.WhileStmt:
+		stmt.Cond = boundExpr(stmt.Cond)
+		boundStmts(stmt.Body)
+	case *syntax.ReturnStmt:
+		if stmt.Result != nil {
+			stmt.Result = boundExpr(stmt.Result)
+		}
+	}
+}
+
+// boundTarget bounds the expressions within an assignment target, leaving
+// it a target
+func boundTarget(e syntax.Expr) {
+	switch e := e.(type) {
+	case *syntax.IndexExpr:
+		e.X = boundExpr(e.X)
+		e.Y = boundExpr(e.Y)
+	case *syntax.DotExpr:
+		e.X = boundExpr(e.X)
+	case *syntax.ParenExpr:
+		boundTarget(e.X)
+	case *syntax.ListExpr:
+		for _, x := range e.List {
+			boundTarget(x)
+		}
+	case *syntax.TupleExpr:
+		for _, x := range e.List {
+			boundTarget(x)
+		}
+	}
+}
+
+// boundParams bounds parameters' default values
+func boundParams(params []syntax.Expr) {
+	for _, param := range params {
+		if binary, ok := param.(*syntax.BinaryExpr); ok && binary.Op == syntax.EQ {
+			binary.Y = boundExpr(binary.Y)
+		}
+	}
+}
+
+func boundExprs(list []syntax.Expr) {
+	for i := range list {
+		list[i] = boundExpr(list[i])
+	}
+}
+
+// boundExpr returns e rewritten to check its bounded operations
+func boundExpr(e syntax.Expr) syntax.Expr {
+	switch e := e.(type) {
+	case *syntax.BinaryExpr:
+		e.X = boundExpr(e.X)
+		e.Y = boundExpr(e.Y)
+		if _, ok := boundedOps[e.Op.String()]; ok {
+			return call(binaryCheck, e.OpPos, str(e.Op.String(), e.OpPos), e.X, e.Y)
+		}
+	case *syntax.DotExpr:
+		e.X = boundExpr(e.X)
+		return call(attrCheck, e.Dot, e.X, str(e.Name.Name, e.NamePos))
+	case *syntax.CallExpr:
+		e.Fn = boundExpr(e.Fn)
+		for i, arg := range e.Args {
+			switch arg := arg.(type) {
+			case *syntax.BinaryExpr:
+				if arg.Op == syntax.EQ { // Keyword argument
+					arg.Y = boundExpr(arg.Y)
+					continue
+				}
+			case *syntax.UnaryExpr:
+				if arg.Op == syntax.STAR || arg.Op == syntax.STARSTAR {
+					arg.X = boundExpr(arg.X)
+					continue
+				}
+			}
+			e.Args[i] = boundExpr(arg)
+		}
+	case *syntax.ParenExpr:
+		e.X = boundExpr(e.X)
+	case *syntax.UnaryExpr:
+		if e.X != nil {
+			e.X = boundExpr(e.X)
+		}
+	case *syntax.IndexExpr:
+		e.X = boundExpr(e.X)
+		e.Y = boundExpr(e.Y)
+	case *syntax.SliceExpr:
+		e.X = boundExpr(e.X)
+		for _, x := range []*syntax.Expr{&e.Lo, &e.Hi, &e.Step} {
+			if *x != nil {
+				*x = boundExpr(*x)
+			}
+		}
+	case *syntax.CondExpr:
+		e.Cond = boundExpr(e.Cond)
+		e.True = boundExpr(e.True)
+		e.False = boundExpr(e.False)
+	case *syntax.ListExpr:
+		boundExprs(e.List)
+	case *syntax.TupleExpr:
+		boundExprs(e.List)
+	case *syntax.DictExpr:
+		boundExprs(e.List)
+	case *syntax.DictEntry:
+		e.Key = boundExpr(e.Key)
+		e.Value = boundExpr(e.Value)
+	case *syntax.Comprehension:
+		e.Body = boundExpr(e.Body)
+		for _, clause := range e.Clauses {
+			switch clause := clause.(type) {
+			case *syntax.ForClause:
+				boundTarget(clause.Vars)
+				clause.X = boundExpr(clause.X)
+			case *syntax.IfClause:
+				clause.Cond = boundExpr(clause.Cond)
+			}
+		}
+	case *syntax.LambdaExpr:
+		boundParams(e.Params)
+		e.Body = boundExpr(e.Body)
+	}
+	return e
+}
+
+// call returns a call of a check at pos
+func call(name string, pos syntax.Position, args ...syntax.Expr) *syntax.CallExpr {
+	_, end := args[len(args)-1].Span()
+	return &syntax.CallExpr{
+		Fn:     &syntax.Ident{NamePos: pos, Name: name},
+		Lparen: pos,
+		Args:   args,
+		Rparen: end,
+	}
+}
+
+// str returns a string literal at pos
+func str(s string, pos syntax.Position) *syntax.Literal {
+	return &syntax.Literal{Token: syntax.STRING, TokenPos: pos, Raw: strconv.Quote(s), Value: s}
+}
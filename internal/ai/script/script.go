@@ -0,0 +1,225 @@
+// Package script runs user-defined signal strategies written in Starlark in
+// a sandbox. Scripts get the market data they are called with and a math
+// module, and nothing else: there is no load, file, network or clock
+// access, and each run is bounded in steps, wall time and allocation, and
+// each value it builds in size.
+package script
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/metrics"
+	"time"
+
+	starlarkmath "go.starlark.net/lib/math"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// EntryPoint is the function a script must define. It is called as
+// signal(symbol, closes) with closes oldest first and returns "buy", "sell"
+// or "hold", optionally paired with a confidence from 0 to 100.
+const EntryPoint = "signal"
+
+// DefaultConfidence is the confidence of signals returned without one
+const DefaultConfidence = 50.0
+
+// Signal actions
+const (
+	ActionBuy  = "buy"
+	ActionSell = "sell"
+	ActionHold = "hold"
+)
+
+// Script errors. Both are wrapped with the interpreter's error, so callers
+// should match them with errors.Is.
+var (
+	ErrInvalidScript = errors.New("invalid strategy script")
+	ErrScriptFailed  = errors.New("strategy script failed")
+)
+
+// Limits bound each run of a script: loading it and every call to its
+// entry point
+type Limits struct {
+	MaxSteps  uint64        // Interpreter steps
+	Timeout   time.Duration // Wall time
+	MaxMemory uint64        // Bytes allocated while the script runs
+}
+
+// Signal is a script's recommendation for a symbol
+type Signal struct {
+	Action     string  `json:"action"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Script is a compiled strategy. Its globals are frozen once loaded, so a
+// Script is safe for concurrent use.
+type Script struct {
+	name   string
+	fn     starlark.Callable
+	limits Limits
+}
+
+// options disallow while loops and recursion, so only the step limit can
+// stop a script that runs long
+var options = &syntax.FileOptions{Set: true}
+
+var predeclared = starlark.StringDict{
+	"math": starlarkmath.Module,
+}
+
+func init() {
+	for name, check := range checks {
+		predeclared[name] = check
+	}
+}
+
+// Compile loads source as the script name and checks that it defines the
+// entry point
+func Compile(ctx context.Context, name, source string, limits Limits) (*Script, error) {
+	f, err := options.Parse(name, source, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScript, err)
+	}
+	bound(f)
+	program, err := starlark.FileProgram(f, predeclared.Has)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScript, err)
+	}
+
+	var globals starlark.StringDict
+	err = run(ctx, name, limits, func(thread *starlark.Thread) error {
+		var err error
+		globals, err = program.Init(thread, predeclared)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScript, err)
+	}
+	globals.Freeze()
+
+	fn, ok := globals[EntryPoint].(*starlark.Function)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s must define a function %s(symbol, closes)", ErrInvalidScript, name, EntryPoint)
+	}
+	if fn.NumParams() != 2 {
+		return nil, fmt.Errorf("%w: %s must take 2 parameters, takes %d", ErrInvalidScript, EntryPoint, fn.NumParams())
+	}
+
+	return &Script{name: name, fn: fn, limits: limits}, nil
+}
+
+// Signal calls the script's entry point with symbol's closes, oldest first
+func (s *Script) Signal(ctx context.Context, symbol string, closes []float64) (Signal, error) {
+	values := make([]starlark.Value, len(closes))
+	for i, close := range closes {
+		values[i] = starlark.Float(close)
+	}
+	list := starlark.NewList(values)
+	list.Freeze()
+
+	var result starlark.Value
+	err := run(ctx, s.name, s.limits, func(thread *starlark.Thread) error {
+		var err error
+		result, err = starlark.Call(thread, s.fn, starlark.Tuple{starlark.String(symbol), list}, nil)
+		return err
+	})
+	if err != nil {
+		return Signal{}, fmt.Errorf("%w: %v", ErrScriptFailed, err)
+	}
+
+	signal, err := toSignal(result)
+	if err != nil {
+		return Signal{}, fmt.Errorf("%w: %s returned %v", ErrScriptFailed, EntryPoint, err)
+	}
+	return signal, nil
+}
+
+// toSignal converts an entry point's result, an action or an (action,
+// confidence) pair
+func toSignal(value starlark.Value) (Signal, error) {
+	signal := Signal{Confidence: DefaultConfidence}
+
+	action := value
+	if tuple, ok := value.(starlark.Tuple); ok {
+		if len(tuple) != 2 {
+			return Signal{}, fmt.Errorf("a tuple of %d values, want (action, confidence)", len(tuple))
+		}
+		action = tuple[0]
+		confidence, ok := starlark.AsFloat(tuple[1])
+		if !ok || confidence < 0 || confidence > 100 {
+			return Signal{}, fmt.Errorf("confidence %s, want a number from 0 to 100", tuple[1])
+		}
+		signal.Confidence = confidence
+	}
+
+	str, ok := starlark.AsString(action)
+	if !ok {
+		return Signal{}, fmt.Errorf("%s, want an action", action.Type())
+	}
+	switch str {
+	case ActionBuy, ActionSell, ActionHold:
+		signal.Action = str
+	default:
+		return Signal{}, fmt.Errorf("action %q, want buy, sell or hold", str)
+	}
+	return signal, nil
+}
+
+// allocsMetric is the process's cumulative heap allocation, which a run's
+// allocation is measured against. It includes allocation by other
+// goroutines, so the memory limit errs on the side of stopping scripts.
+const allocsMetric = "/gc/heap/allocs:bytes"
+
+// watchInterval is how often a running script's allocation is checked
+const watchInterval = 5 * time.Millisecond
+
+// run calls fn on a fresh thread bounded by limits, cancelling it when ctx
+// is done, the timeout passes or it allocates too much
+func run(ctx context.Context, name string, limits Limits, fn func(thread *starlark.Thread) error) error {
+	thread := &starlark.Thread{
+		Name:  name,
+		Print: func(*starlark.Thread, string) {}, // Scripts have no output
+	}
+	if limits.MaxSteps > 0 {
+		thread.SetMaxExecutionSteps(limits.MaxSteps)
+	}
+
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		sample := []metrics.Sample{{Name: allocsMetric}}
+		metrics.Read(sample)
+		start := sample[0].Value.Uint64()
+
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				thread.Cancel(ctx.Err().Error())
+				return
+			case <-ticker.C:
+				if limits.MaxMemory == 0 {
+					continue
+				}
+				metrics.Read(sample)
+				if sample[0].Value.Uint64()-start > limits.MaxMemory {
+					thread.Cancel("memory limit exceeded")
+					return
+				}
+			}
+		}
+	}()
+
+	return fn(thread)
+}
@@ -0,0 +1,146 @@
+package script
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLimits = Limits{MaxSteps: 100000, Timeout: time.Second, MaxMemory: 64 << 20}
+
+const momentum = `
+def signal(symbol, closes):
+    if len(closes) < 2:
+        return "hold"
+    change = closes[-1] / closes[0] - 1
+    if change > 0.05:
+        return ("buy", min(100, math.floor(change * 1000)))
+    if change < -0.05:
+        return "sell"
+    return "hold"
+`
+
+func TestSignal(t *testing.T) {
+	ctx := context.Background()
+	s, err := Compile(ctx, "momentum", momentum, testLimits)
+	require.NoError(t, err)
+
+	signal, err := s.Signal(ctx, "AAPL", []float64{100, 104, 110})
+	require.NoError(t, err)
+	assert.Equal(t, Signal{Action: ActionBuy, Confidence: 100}, signal)
+
+	signal, err = s.Signal(ctx, "AAPL", []float64{100, 90})
+	require.NoError(t, err)
+	assert.Equal(t, Signal{Action: ActionSell, Confidence: DefaultConfidence}, signal)
+
+	signal, err = s.Signal(ctx, "AAPL", []float64{100})
+	require.NoError(t, err)
+	assert.Equal(t, ActionHold, signal.Action)
+}
+
+func TestCompileInvalid(t *testing.T) {
+	ctx := context.Background()
+	for name, source := range map[string]string{
+		"syntax":      "def signal(symbol, closes)\n    return 'buy'",
+		"no entry":    "x = 1",
+		"arity":       "def signal(closes):\n    return 'buy'",
+		"load":        "load('os.star', 'system')\ndef signal(symbol, closes):\n    return 'buy'",
+		"while":       "def signal(symbol, closes):\n    while True:\n        pass",
+		"top level":   "x = [0]\nx.append(1)\n" + momentum + "\nfor i in range(1000000000):\n    pass",
+		"unknown var": "def signal(symbol, closes):\n    return open('/etc/passwd')",
+	} {
+		_, err := Compile(ctx, name, source, testLimits)
+		assert.ErrorIs(t, err, ErrInvalidScript, name)
+	}
+}
+
+func TestSignalLimits(t *testing.T) {
+	ctx := context.Background()
+
+	spin, err := Compile(ctx, "spin", `
+def signal(symbol, closes):
+    total = 0
+    for i in range(100000000):
+        total += i
+    return "buy"
+`, testLimits)
+	require.NoError(t, err)
+	_, err = spin.Signal(ctx, "AAPL", nil)
+	assert.ErrorIs(t, err, ErrScriptFailed)
+	assert.Contains(t, err.Error(), "too many steps")
+
+	slow, err := Compile(ctx, "slow", `
+def signal(symbol, closes):
+    for i in range(100000000):
+        pass
+    return "buy"
+`, Limits{Timeout: 20 * time.Millisecond})
+	require.NoError(t, err)
+	_, err = slow.Signal(ctx, "AAPL", nil)
+	assert.ErrorIs(t, err, ErrScriptFailed)
+	assert.Contains(t, err.Error(), "deadline exceeded")
+
+	hungry, err := Compile(ctx, "hungry", `
+def signal(symbol, closes):
+    chunks = []
+    for i in range(100000000):
+        chunks.append("x" * 1024)
+    return "buy"
+`, Limits{MaxMemory: 8 << 20})
+	require.NoError(t, err)
+	_, err = hungry.Signal(ctx, "AAPL", nil)
+	assert.ErrorIs(t, err, ErrScriptFailed)
+	assert.Contains(t, err.Error(), "memory limit exceeded")
+
+	bad, err := Compile(ctx, "bad", "def signal(symbol, closes):\n    return 'short'", testLimits)
+	require.NoError(t, err)
+	_, err = bad.Signal(ctx, "AAPL", nil)
+	assert.ErrorIs(t, err, ErrScriptFailed)
+}
+
+func TestSignalBoundsValues(t *testing.T) {
+	ctx := context.Background()
+	for name, body := range map[string]string{
+		"repeat":     `s = "a" * 2000000000`,
+		"concat":     "s = \"a\" * 1000000\n    s = s + s",
+		"augmented":  "s = \"a\" * 1000000\n    s += s",
+		"list":       `l = list(range(100000000))`,
+		"extend":     "l = []\n    l.extend(range(100000000))",
+		"join":       `s = "".join(["a" * 1000000] * 2)`,
+		"getattr":    "join = getattr(\"\", \"join\")\n    s = join([\"a\" * 1000000] * 2)",
+		"percent":    "s = \"a\" * 1000000\n    s = \"%s%s\" % (s, s)",
+		"format":     "s = \"a\" * 1000000\n    s = \"{}{}\".format(s, s)",
+		"replace":    "s = \"a\" * 1000000\n    s = s.replace(\"a\", \"aa\")",
+		"big number": "n = 1 << 500\n    for i in range(20):\n        n = n * n",
+	} {
+		s, err := Compile(ctx, name, "def signal(symbol, closes):\n    "+body+"\n    return \"buy\"", Limits{MaxSteps: 100000})
+		require.NoError(t, err, name)
+		_, err = s.Signal(ctx, "AAPL", nil)
+		assert.ErrorIs(t, err, ErrScriptFailed, name)
+		assert.ErrorContains(t, err, "exceeds the limit", name)
+	}
+}
+
+func TestBoundedScriptsKeepSemantics(t *testing.T) {
+	ctx := context.Background()
+	s, err := Compile(ctx, "semantics", `
+def signal(symbol, closes):
+    window = closes[-3:]
+    alias = window
+    alias += [4]
+    counts = {k: len(k) for k in ["a", "bb"]}
+    text = "%s:%d" % (symbol, len(window)) + "|" + "{}".format(counts["bb"])
+    ok = (len(window) == 4 and text == "AAPL:4|2" and 7 % 4 == 3 and 3 * "ab" == "ababab"
+          and sorted(window, reverse=True)[0] == 4 and getattr(text, "missing", None) == None
+          and ",".join([str(c) for c in closes]).count(",") == 2 and [0] * 2 == [0, 0])
+    return ("buy", 75) if ok else "hold"
+`, testLimits)
+	require.NoError(t, err)
+
+	signal, err := s.Signal(ctx, "AAPL", []float64{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, Signal{Action: ActionBuy, Confidence: 75}, signal)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/ai/domain"
+	"hedge-fund/internal/ai/repository"
+	backtestrepo "hedge-fund/internal/backtest/repository"
+	"hedge-fund/pkg/shared/models"
+)
+
+// AgentService evaluates AI agents' past signals against the price moves
+// that followed them
+type AgentService struct {
+	repo     *repository.AgentRepository
+	bars     *backtestrepo.BarRepository
+	lookback int // Days of signals evaluated
+	logger   *zap.Logger
+}
+
+func NewAgentService(repo *repository.AgentRepository, bars *backtestrepo.BarRepository, lookback int, logger *zap.Logger) *AgentService {
+	return &AgentService{
+		repo:     repo,
+		bars:     bars,
+		lookback: lookback,
+		logger:   logger,
+	}
+}
+
+// Evaluate recomputes every agent's performance over each horizon from its
+// signals in the lookback window and returns the performances stored
+func (s *AgentService) Evaluate(ctx context.Context) ([]models.AgentPerformance, error) {
+	now := time.Now()
+	signals, err := s.repo.GetSignalsSince(ctx, now.AddDate(0, 0, -s.lookback))
+	if err != nil {
+		return nil, err
+	}
+
+	byAgent := make(map[string][]models.AISignal)
+	var agents, symbols []string
+	held := make(map[string]bool)
+	for _, signal := range signals {
+		if _, ok := byAgent[signal.AgentName]; !ok {
+			agents = append(agents, signal.AgentName)
+		}
+		byAgent[signal.AgentName] = append(byAgent[signal.AgentName], signal)
+		if !held[signal.Symbol] {
+			held[signal.Symbol] = true
+			symbols = append(symbols, signal.Symbol)
+		}
+	}
+
+	var bars map[string][]models.Price
+	if len(signals) > 0 {
+		start := signals[0].CreatedAt.UTC().Truncate(24 * time.Hour)
+		if bars, err = s.bars.GetBars(ctx, symbols, start, now); err != nil {
+			return nil, err
+		}
+	}
+
+	performances := make([]models.AgentPerformance, 0, len(agents)*len(domain.Horizons))
+	for _, agent := range agents {
+		for _, horizon := range domain.Horizons {
+			outcomes := domain.Evaluate(byAgent[agent], bars, horizon)
+			performances = append(performances, domain.Summarize(agent, horizon, outcomes, now))
+		}
+	}
+
+	if err := s.repo.ReplaceAgentPerformance(ctx, performances); err != nil {
+		return nil, err
+	}
+	return performances, nil
+}
+
+// GetPerformance returns agents' latest evaluated performance, filtered by
+// agent and period when they are non-empty
+func (s *AgentService) GetPerformance(ctx context.Context, agent, period string) ([]models.AgentPerformance, error) {
+	if period != "" {
+		if _, ok := domain.HorizonFor(period); !ok {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInvalidPeriod, period)
+		}
+	}
+	return s.repo.GetAgentPerformance(ctx, agent, period)
+}
+
+// Leaderboard ranks agents by metric over period, best first. Agents with
+// fewer than minSignals evaluated signals are left out.
+func (s *AgentService) Leaderboard(ctx context.Context, period, metric string, minSignals int) ([]models.AgentPerformance, error) {
+	if _, ok := domain.HorizonFor(period); !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvalidPeriod, period)
+	}
+
+	performances, err := s.repo.GetAgentPerformance(ctx, "", period)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := performances[:0]
+	for _, performance := range performances {
+		if performance.TotalSignals > 0 && performance.TotalSignals >= minSignals {
+			ranked = append(ranked, performance)
+		}
+	}
+	if !domain.Rank(ranked, metric) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvalidMetric, metric)
+	}
+	return ranked, nil
+}
+
+// LatestSignals returns up to limit of the agents' most recent signals per
+// symbol, limited to symbols when any are given, newest first
+func (s *AgentService) LatestSignals(ctx context.Context, symbols []string, limit int) ([]models.AISignal, error) {
+	signals, err := s.repo.GetLatestSignals(ctx, symbols, limit)
+	if err != nil {
+		return nil, err
+	}
+	if signals == nil {
+		signals = []models.AISignal{}
+	}
+	return signals, nil
+}
+
+// RunDailySchedule evaluates agent performance at hour (UTC) every day
+// until ctx is cancelled
+func (s *AgentService) RunDailySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		performances, err := s.Evaluate(ctx)
+		if err != nil {
+			s.logger.Error("Failed to evaluate agent performance", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Agent performance evaluated", zap.Int("performances", len(performances)))
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"hedge-fund/internal/ai/domain"
+	"hedge-fund/internal/backtest/engine"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// DefaultSignalWindow is how far back an analysis looks for agents' signals
+// when the request sets no start date
+const DefaultSignalWindow = 24 * time.Hour
+
+// BacktestSignalWindow is how long an agent's signal counts toward the
+// consensus of a backtested bar
+const BacktestSignalWindow = 7 * 24 * time.Hour
+
+// DefaultPerformancePeriod is the agent performance period performance
+// weighting uses when the request sets none
+const DefaultPerformancePeriod = "1m"
+
+// Analyze combines the latest signal of each agent for the request's symbol
+// within its date range into a consensus, using the request's strategy
+func (s *AgentService) Analyze(ctx context.Context, req models.AIAnalysisRequest) (*models.AIAnalysisResponse, error) {
+	started := time.Now()
+
+	strategy := req.Consensus
+	if strategy == "" {
+		strategy = domain.DefaultConsensus
+	}
+	var performances []models.AgentPerformance
+	if strategy == domain.ConsensusPerformanceWeighted {
+		period := req.PerformancePeriod
+		if period == "" {
+			period = DefaultPerformancePeriod
+		}
+		var err error
+		if performances, err = s.GetPerformance(ctx, "", period); err != nil {
+			return nil, err
+		}
+	}
+	consensus, err := domain.NewConsensus(strategy, performances)
+	if err != nil {
+		return nil, err
+	}
+
+	end := started
+	if req.EndDate != nil {
+		end = *req.EndDate
+	}
+	start := end.Add(-DefaultSignalWindow)
+	if req.StartDate != nil {
+		start = *req.StartDate
+	}
+
+	symbol := symbols.Normalize(req.Symbol)
+	signals, err := s.repo.GetSymbolSignals(ctx, symbol, req.Agents, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(signals) == 0 {
+		return nil, fmt.Errorf("no signals found for %s between %s and %s", symbol, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	signals = domain.LatestByAgent(signals)
+
+	response := &models.AIAnalysisResponse{
+		RequestID:         uuid.New().String(),
+		Symbol:            symbol,
+		Signals:           signals,
+		ConsensusStrategy: strategy,
+	}
+	response.ConsensusSignal, response.ConsensusConfidence = consensus.Decide(signals)
+	response.CompletedAt = time.Now()
+	response.ProcessingTime = float64(response.CompletedAt.Sub(started).Microseconds()) / 1000
+
+	s.logger.Info("AI consensus computed",
+		zap.String("symbol", symbol),
+		zap.String("strategy", strategy),
+		zap.Int("agents", len(signals)),
+		zap.String("consensus", response.ConsensusSignal))
+
+	return response, nil
+}
+
+// AgentStrategy replays the signals the agents recorded for symbols between
+// start and end as a backtest signal. Each bar trades on the confidence
+// weighted consensus of every agent's latest signal within the week before
+// it, and holds when there is none.
+func (s *AgentService) AgentStrategy(ctx context.Context, syms []string, start, end time.Time) (engine.SignalFunc, error) {
+	history := make(map[string][]models.AISignal, len(syms))
+	recorded := 0
+	for _, symbol := range syms {
+		signals, err := s.repo.GetSymbolSignals(ctx, symbols.Normalize(symbol), nil, start.Add(-BacktestSignalWindow), end)
+		if err != nil {
+			return nil, err
+		}
+		history[symbol] = signals
+		recorded += len(signals)
+	}
+	if recorded == 0 {
+		return nil, fmt.Errorf("no agent signals recorded between %s and %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+
+	consensus := domain.ConfidenceWeighted{}
+	return func(symbol string, at time.Time, _ []float64) (string, error) {
+		var current []models.AISignal
+		for _, signal := range history[symbol] {
+			if signal.CreatedAt.After(at) {
+				break // Oldest first
+			}
+			if signal.CreatedAt.After(at.Add(-BacktestSignalWindow)) {
+				current = append(current, signal)
+			}
+		}
+		action, _ := consensus.Decide(domain.LatestByAgent(current))
+		return action, nil
+	}, nil
+}
@@ -0,0 +1,447 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"hedge-fund/internal/ai/domain"
+	"hedge-fund/internal/ai/repository"
+	riskdomain "hedge-fund/internal/risk/domain"
+	riskrepo "hedge-fund/internal/risk/repository"
+	"hedge-fund/pkg/shared/flags"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/redis"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// killSwitchKey holds the auto-trade kill switch while it is engaged
+const killSwitchKey = "autotrade:kill_switch"
+
+// ErrKillSwitchEngaged is returned for auto-trade orders placed while the
+// kill switch is engaged
+var ErrKillSwitchEngaged = errors.New("auto-trade kill switch is engaged")
+
+// ErrOrderRefused is returned for orders the portfolio service refused to
+// place for the user approving them, such as large trades approved without
+// a second factor. The order is left awaiting approval.
+var ErrOrderRefused = errors.New("portfolio service refused the order")
+
+// TradeExecutor places a market order in a portfolio, returning the trade's
+// ID. Orders placed again with the same idempotency key are filled once.
+type TradeExecutor interface {
+	ExecuteTrade(ctx context.Context, idempotencyKey string, portfolioID int, symbol, side string, quantity float64) (int, error)
+}
+
+// AutoTradeService turns high-confidence consensus signals into orders for
+// the portfolios linked to auto-trading. Orders are placed immediately in
+// auto mode or queued for approval in manual mode, and every buy must leave
+// the owner's risk limits unbreached.
+type AutoTradeService struct {
+	repo          *repository.AgentRepository
+	agents        *AgentService
+	risk          *riskrepo.RiskRepository
+	trader        TradeExecutor
+	redis         *redis.Client
+	notifications *queue.Manager
+	cooldown      time.Duration // Between orders in one symbol for one portfolio
+	approvalTTL   time.Duration // How long a manual order can wait for approval
+	flags         *flags.Manager
+	logger        *zap.Logger
+}
+
+func NewAutoTradeService(repo *repository.AgentRepository, agents *AgentService, risk *riskrepo.RiskRepository, trader TradeExecutor, redisClient *redis.Client, notifications *queue.Manager, cooldown, approvalTTL time.Duration, logger *zap.Logger) *AutoTradeService {
+	return &AutoTradeService{
+		repo:          repo,
+		agents:        agents,
+		risk:          risk,
+		trader:        trader,
+		redis:         redisClient,
+		notifications: notifications,
+		cooldown:      cooldown,
+		approvalTTL:   approvalTTL,
+		logger:        logger,
+	}
+}
+
+// SetFlags gates auto-trading behind the auto-trade feature flag of each
+// portfolio's owner. Without flags, it is on for everyone.
+func (s *AutoTradeService) SetFlags(manager *flags.Manager) {
+	s.flags = manager
+}
+
+// requireFlag returns flags.ErrDisabled unless auto-trading is on for the user
+func (s *AutoTradeService) requireFlag(ctx context.Context, userID int) error {
+	if s.flags == nil {
+		return nil
+	}
+	return s.flags.Require(ctx, flags.AutoTrade, userID)
+}
+
+// SaveSettings validates and saves a portfolio's auto-trade settings. They
+// belong to the portfolio's owner, who can only enable them with the
+// auto-trade flag on.
+func (s *AutoTradeService) SaveSettings(ctx context.Context, settings *models.AutoTradeSettings) error {
+	if err := domain.ValidateAutoTrade(settings); err != nil {
+		return err
+	}
+	book, err := s.risk.GetOpenBook(ctx, settings.PortfolioID)
+	if err != nil {
+		return err
+	}
+	settings.UserID = book.UserID
+	if settings.IsEnabled {
+		if err := s.requireFlag(ctx, settings.UserID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.SaveAutoTradeSettings(ctx, settings); err != nil {
+		return err
+	}
+
+	s.logger.Info("Auto-trade settings saved",
+		zap.Int("portfolio_id", settings.PortfolioID),
+		zap.String("mode", settings.Mode),
+		zap.Bool("enabled", settings.IsEnabled))
+	return nil
+}
+
+// GetSettings returns a portfolio's auto-trade settings
+func (s *AutoTradeService) GetSettings(ctx context.Context, portfolioID int) (*models.AutoTradeSettings, error) {
+	return s.repo.GetAutoTradeSettings(ctx, portfolioID)
+}
+
+// DeleteSettings unlinks a portfolio from auto-trading
+func (s *AutoTradeService) DeleteSettings(ctx context.Context, portfolioID int) error {
+	return s.repo.DeleteAutoTradeSettings(ctx, portfolioID)
+}
+
+// ListOrders returns a portfolio's latest auto-trade orders, filtered by
+// status when it is non-empty
+func (s *AutoTradeService) ListOrders(ctx context.Context, portfolioID int, status string, limit int) ([]models.AutoTradeOrder, error) {
+	return s.repo.GetAutoTradeOrders(ctx, portfolioID, status, limit)
+}
+
+// KillSwitch returns the kill switch's state
+func (s *AutoTradeService) KillSwitch(ctx context.Context) (models.KillSwitch, error) {
+	var state models.KillSwitch
+	data, err := s.redis.Get(ctx, killSwitchKey).Bytes()
+	if err == goredis.Nil {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read kill switch: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to decode kill switch: %w", err)
+	}
+	return state, nil
+}
+
+// EngageKillSwitch halts all auto-trading, including the approval of queued
+// orders, until it is released
+func (s *AutoTradeService) EngageKillSwitch(ctx context.Context, reason string) (models.KillSwitch, error) {
+	now := time.Now().UTC()
+	state := models.KillSwitch{Engaged: true, Reason: reason, EngagedAt: &now}
+	if err := s.redis.SetCache(ctx, killSwitchKey, state, 0); err != nil {
+		return models.KillSwitch{}, err
+	}
+	s.logger.Warn("Auto-trade kill switch engaged", zap.String("reason", reason))
+	return state, nil
+}
+
+// ReleaseKillSwitch resumes auto-trading
+func (s *AutoTradeService) ReleaseKillSwitch(ctx context.Context) error {
+	if err := s.redis.DeleteCache(ctx, killSwitchKey); err != nil {
+		return err
+	}
+	s.logger.Info("Auto-trade kill switch released")
+	return nil
+}
+
+// halted reports whether auto-trading must stop. An unreadable kill switch
+// halts it.
+func (s *AutoTradeService) halted(ctx context.Context) bool {
+	state, err := s.KillSwitch(ctx)
+	if err != nil {
+		s.logger.Error("Failed to check kill switch, halting auto-trading", zap.Error(err))
+		return true
+	}
+	return state.Engaged
+}
+
+// OnSignal reevaluates the consensus for an AI signal's symbol in every
+// linked portfolio and raises the order it calls for, unless one was raised
+// in the symbol within the cooldown or still awaits approval
+func (s *AutoTradeService) OnSignal(ctx context.Context, event models.AISignalEvent) {
+	if s.halted(ctx) {
+		return
+	}
+	symbol := symbols.Normalize(event.Symbol)
+
+	all, err := s.repo.GetEnabledAutoTradeSettings(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load auto-trade settings", zap.Error(err))
+		return
+	}
+	for i := range all {
+		if s.requireFlag(ctx, all[i].UserID) != nil {
+			continue
+		}
+		if err := s.evaluate(ctx, &all[i], symbol, event.Price); err != nil {
+			s.logger.Error("Failed to evaluate auto-trade signal", zap.Error(err),
+				zap.Int("portfolio_id", all[i].PortfolioID), zap.String("symbol", symbol))
+		}
+	}
+}
+
+func (s *AutoTradeService) evaluate(ctx context.Context, settings *models.AutoTradeSettings, symbol string, price float64) error {
+	recent, err := s.repo.HasRecentAutoTradeOrder(ctx, settings.ID, symbol, time.Now().Add(-s.cooldown))
+	if err != nil || recent {
+		return err
+	}
+
+	analysis, err := s.agents.Analyze(ctx, models.AIAnalysisRequest{Symbol: symbol, Consensus: settings.Consensus})
+	if err != nil {
+		return err
+	}
+	book, err := s.risk.GetOpenBook(ctx, settings.PortfolioID)
+	if err != nil {
+		return err
+	}
+
+	var held float64
+	for _, h := range book.Holdings {
+		if h.Symbol != symbol {
+			continue
+		}
+		held = h.Quantity
+		if h.Short {
+			held = -held
+		}
+		if price <= 0 {
+			price = h.Price
+		}
+	}
+
+	planned, ok := domain.PlanOrder(settings, symbol, analysis.ConsensusSignal, analysis.ConsensusConfidence, price, held)
+	if !ok {
+		return nil
+	}
+
+	order := &models.AutoTradeOrder{
+		SettingsID:      settings.ID,
+		UserID:          settings.UserID,
+		PortfolioID:     settings.PortfolioID,
+		Symbol:          symbol,
+		Side:            planned.Side,
+		Quantity:        planned.Quantity,
+		Price:           price,
+		ConsensusSignal: analysis.ConsensusSignal,
+		Confidence:      analysis.ConsensusConfidence,
+		Reason:          planned.Blocked,
+	}
+	if order.Reason == "" {
+		if order.Reason, err = s.checkRisk(ctx, book, order); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	switch {
+	case order.Reason != "":
+		order.Status = models.AutoTradeBlocked
+		order.DecidedAt = &now
+	case settings.Mode == models.AutoTradeManual:
+		order.Status = models.AutoTradePendingApproval
+	default:
+		order.Status = models.AutoTradeApproved
+		order.DecidedAt = &now
+	}
+	if err := s.repo.CreateAutoTradeOrder(ctx, order); err != nil {
+		return err
+	}
+
+	s.logger.Info("Auto-trade order raised",
+		zap.Int("order_id", order.ID),
+		zap.Int("portfolio_id", order.PortfolioID),
+		zap.String("symbol", symbol),
+		zap.String("side", order.Side),
+		zap.Float64("quantity", order.Quantity),
+		zap.String("status", order.Status),
+		zap.String("reason", order.Reason))
+
+	switch order.Status {
+	case models.AutoTradePendingApproval:
+		s.notify(order)
+	case models.AutoTradeApproved:
+		return s.place(ctx, order)
+	}
+	return nil
+}
+
+// checkRisk returns the risk limit a buy would breach, if any, by applying
+// it to the portfolio's book at the order's price. Sells only reduce risk
+// and are not checked. VaR is left to the risk monitor, which tracks each
+// holding's volatility.
+func (s *AutoTradeService) checkRisk(ctx context.Context, book *riskrepo.OpenBook, order *models.AutoTradeOrder) (string, error) {
+	if order.Side != "buy" {
+		return "", nil
+	}
+	rows, err := s.risk.GetUserRiskLimits(ctx, book.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	b := riskdomain.NewBook(book.PortfolioID, book.UserID, book.Cash, book.Holdings, riskdomain.LimitsFor(rows), time.Now())
+	b.Fill(order.Symbol, order.Quantity, order.Price)
+	for _, limit := range b.Exposure(0, 0, 0).Limits {
+		if limit.Level == models.RiskSeverityCritical {
+			return fmt.Sprintf("%s limit would be breached: %.4g against %.4g", limit.Limit, limit.Value, limit.Threshold), nil
+		}
+	}
+	return "", nil
+}
+
+// place executes an approved order and records the outcome. An order the
+// portfolio service refuses for the approving user goes back to awaiting
+// approval and ErrOrderRefused is returned; other failures fail the order.
+func (s *AutoTradeService) place(ctx context.Context, order *models.AutoTradeOrder) error {
+	// Keyed by order, so an order placed twice, by a retry or a second
+	// approval, is filled once
+	key := fmt.Sprintf("auto_trade_order:%d", order.ID)
+	tradeID, err := s.trader.ExecuteTrade(ctx, key, order.PortfolioID, order.Symbol, order.Side, order.Quantity)
+	if errors.Is(err, ErrOrderRefused) {
+		s.logger.Warn("Auto-trade order refused", zap.Error(err), zap.Int("order_id", order.ID))
+		if revertErr := s.repo.TransitionAutoTradeOrder(ctx, order, models.AutoTradeApproved, models.AutoTradePendingApproval, "", nil); revertErr != nil {
+			s.logger.Error("Failed to return refused auto-trade order to approval", zap.Error(revertErr), zap.Int("order_id", order.ID))
+		}
+		return err
+	}
+	if err != nil {
+		s.logger.Error("Failed to place auto-trade order", zap.Error(err), zap.Int("order_id", order.ID))
+		if err := s.repo.TransitionAutoTradeOrder(ctx, order, models.AutoTradeApproved, models.AutoTradeFailed, err.Error(), nil); err != nil {
+			s.logger.Error("Failed to record auto-trade failure", zap.Error(err), zap.Int("order_id", order.ID))
+		}
+		return nil
+	}
+
+	if err := s.repo.TransitionAutoTradeOrder(ctx, order, models.AutoTradeApproved, models.AutoTradeExecuted, "", &tradeID); err != nil {
+		s.logger.Error("Failed to record auto-trade execution", zap.Error(err), zap.Int("order_id", order.ID), zap.Int("trade_id", tradeID))
+		return nil
+	}
+	s.logger.Info("Auto-trade order executed", zap.Int("order_id", order.ID), zap.Int("trade_id", tradeID))
+	return nil
+}
+
+// Approve places an order queued for approval. An order left longer than
+// the approval window expires instead, and a buy that would now breach a
+// risk limit is blocked. ctx carries the second factor the approving user
+// gave, which large orders need.
+func (s *AutoTradeService) Approve(ctx context.Context, orderID int) (*models.AutoTradeOrder, error) {
+	order, err := s.repo.GetAutoTradeOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.AutoTradePendingApproval {
+		return nil, fmt.Errorf("%w: order %d is %s", domain.ErrOrderNotPending, orderID, order.Status)
+	}
+	if s.halted(ctx) {
+		return nil, ErrKillSwitchEngaged
+	}
+	if err := s.requireFlag(ctx, order.UserID); err != nil {
+		return nil, err
+	}
+
+	if time.Since(order.CreatedAt) > s.approvalTTL {
+		err := s.repo.TransitionAutoTradeOrder(ctx, order, models.AutoTradePendingApproval, models.AutoTradeExpired,
+			fmt.Sprintf("not approved within %s", s.approvalTTL), nil)
+		return order, err
+	}
+
+	book, err := s.risk.GetOpenBook(ctx, order.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+	reason, err := s.checkRisk(ctx, book, order)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		err := s.repo.TransitionAutoTradeOrder(ctx, order, models.AutoTradePendingApproval, models.AutoTradeBlocked, reason, nil)
+		return order, err
+	}
+
+	if err := s.repo.TransitionAutoTradeOrder(ctx, order, models.AutoTradePendingApproval, models.AutoTradeApproved, "", nil); err != nil {
+		return nil, err
+	}
+	if err := s.place(ctx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// Reject declines an order queued for approval
+func (s *AutoTradeService) Reject(ctx context.Context, orderID int, reason string) (*models.AutoTradeOrder, error) {
+	order, err := s.repo.GetAutoTradeOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		reason = "rejected by user"
+	}
+	if err := s.repo.TransitionAutoTradeOrder(ctx, order, models.AutoTradePendingApproval, models.AutoTradeRejected, reason, nil); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// notify asks the owner of a manual portfolio to approve an order. Failures
+// are logged.
+func (s *AutoTradeService) notify(order *models.AutoTradeOrder) {
+	data := map[string]interface{}{
+		"order_id":     order.ID,
+		"portfolio_id": order.PortfolioID,
+		"symbol":       order.Symbol,
+		"side":         order.Side,
+		"quantity":     order.Quantity,
+		"price":        order.Price,
+		"confidence":   order.Confidence,
+		"expires_in":   s.approvalTTL.String(),
+	}
+	if _, err := s.notifications.EnqueueNotification(order.UserID, "auto_trade_approval", "", "", data, nil); err != nil {
+		s.logger.Warn("Failed to enqueue auto-trade approval notification", zap.Error(err), zap.Int("order_id", order.ID))
+	}
+}
+
+// Run evaluates each AI signal published on the AI signal channel until ctx
+// is cancelled
+func (s *AutoTradeService) Run(ctx context.Context) {
+	pubsub := s.redis.SubscribeToEvents(ctx, models.ChannelAISignals)
+	defer pubsub.Close()
+
+	signals := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-signals:
+			if !ok {
+				return
+			}
+
+			var event models.AISignalEvent
+			if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+				s.logger.Warn("Failed to decode AI signal", zap.Error(err))
+				continue
+			}
+			s.OnSignal(ctx, event)
+		}
+	}
+}
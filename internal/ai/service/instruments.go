@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+
+	marketpb "hedge-fund/pkg/proto/market"
+	"hedge-fund/pkg/shared/models"
+)
+
+// MarketInstruments looks up instrument reference data in the market data
+// service
+type MarketInstruments struct {
+	client marketpb.MarketDataServiceClient
+}
+
+func NewMarketInstruments(client marketpb.MarketDataServiceClient) *MarketInstruments {
+	return &MarketInstruments{client: client}
+}
+
+// GetInstrument returns a symbol's instrument, or nil when it is unknown
+func (m *MarketInstruments) GetInstrument(ctx context.Context, symbol string) (*models.Instrument, error) {
+	resp, err := m.client.ListInstruments(ctx, &marketpb.ListInstrumentsRequest{Symbols: []string{symbol}})
+	if err != nil {
+		return nil, err
+	}
+	for _, instrument := range resp.GetInstruments() {
+		return &models.Instrument{
+			Symbol:     instrument.GetSymbol(),
+			Name:       instrument.GetName(),
+			AssetClass: instrument.GetAssetClass(),
+			Sector:     instrument.GetSector(),
+			Industry:   instrument.GetIndustry(),
+			Exchange:   instrument.GetExchange(),
+			Currency:   instrument.GetCurrency(),
+			CreatedAt:  instrument.GetCreatedAt().AsTime(),
+			UpdatedAt:  instrument.GetUpdatedAt().AsTime(),
+		}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/ai/domain"
+	"hedge-fund/internal/ai/repository"
+	"hedge-fund/internal/ai/script"
+	"hedge-fund/internal/backtest/engine"
+	backtestrepo "hedge-fund/internal/backtest/repository"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/redis"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// StrategyService manages user-defined strategy scripts and runs them as
+// custom agents
+type StrategyService struct {
+	repo    *repository.AgentRepository
+	bars    *backtestrepo.BarRepository
+	redis   *redis.Client
+	limits  script.Limits
+	maxSize int // Bytes of script source
+	logger  *zap.Logger
+}
+
+func NewStrategyService(repo *repository.AgentRepository, bars *backtestrepo.BarRepository, redisClient *redis.Client, limits script.Limits, maxSize int, logger *zap.Logger) *StrategyService {
+	return &StrategyService{
+		repo:    repo,
+		bars:    bars,
+		redis:   redisClient,
+		limits:  limits,
+		maxSize: maxSize,
+		logger:  logger,
+	}
+}
+
+// CreateStrategy validates and compiles a strategy script and registers it
+// as its user's custom agent
+func (s *StrategyService) CreateStrategy(ctx context.Context, strategy *models.StrategyScript) error {
+	if err := s.validate(ctx, strategy); err != nil {
+		return err
+	}
+	strategy.AgentName = domain.AgentNameFor(strategy.UserID, strategy.Name)
+
+	if err := s.repo.CreateStrategy(ctx, strategy); err != nil {
+		return err
+	}
+
+	s.logger.Info("Strategy script created",
+		zap.Int("strategy_id", strategy.ID),
+		zap.Int("user_id", strategy.UserID),
+		zap.String("agent", strategy.AgentName))
+	return nil
+}
+
+// GetStrategy returns a strategy script
+func (s *StrategyService) GetStrategy(ctx context.Context, strategyID int) (*models.StrategyScript, error) {
+	return s.repo.GetStrategy(ctx, strategyID)
+}
+
+// ListStrategies returns a user's strategy scripts
+func (s *StrategyService) ListStrategies(ctx context.Context, userID int) ([]models.StrategyScript, error) {
+	return s.repo.GetStrategiesByUserID(ctx, userID)
+}
+
+// UpdateStrategy validates and compiles a strategy script's new source and
+// replaces it. Its name, and so its agent, cannot change.
+func (s *StrategyService) UpdateStrategy(ctx context.Context, strategy *models.StrategyScript) error {
+	if err := s.validate(ctx, strategy); err != nil {
+		return err
+	}
+	return s.repo.UpdateStrategy(ctx, strategy)
+}
+
+// DeleteStrategy deletes a strategy script
+func (s *StrategyService) DeleteStrategy(ctx context.Context, strategyID int) error {
+	return s.repo.DeleteStrategy(ctx, strategyID)
+}
+
+func (s *StrategyService) validate(ctx context.Context, strategy *models.StrategyScript) error {
+	if err := domain.ValidateStrategy(strategy, s.maxSize); err != nil {
+		return err
+	}
+	_, err := script.Compile(ctx, strategy.Name, strategy.Source, s.limits)
+	return err
+}
+
+// RunSignals runs an active strategy on the latest stored daily closes of
+// each symbol and records the signals under its agent, where consensus and
+// agent performance pick them up
+func (s *StrategyService) RunSignals(ctx context.Context, strategyID int, syms []string) ([]models.AISignal, error) {
+	strategy, err := s.repo.GetStrategy(ctx, strategyID)
+	if err != nil {
+		return nil, err
+	}
+	if !strategy.IsActive {
+		return nil, fmt.Errorf("%w: strategy %d is inactive", domain.ErrInvalidStrategy, strategyID)
+	}
+	compiled, err := script.Compile(ctx, strategy.Name, strategy.Source, s.limits)
+	if err != nil {
+		return nil, err
+	}
+
+	syms = symbols.NormalizeAll(syms)
+	// Calendar days comfortably covering the trading days needed
+	end := time.Now()
+	bars, err := s.bars.GetBars(ctx, syms, end.AddDate(0, 0, -2*strategy.Lookback-7), end)
+	if err != nil {
+		return nil, err
+	}
+
+	signals := make([]models.AISignal, 0, len(syms))
+	for _, symbol := range syms {
+		series := bars[symbol]
+		if len(series) == 0 {
+			return nil, fmt.Errorf("no price history for %s", symbol)
+		}
+		if len(series) > strategy.Lookback+1 {
+			series = series[len(series)-strategy.Lookback-1:]
+		}
+		closes := make([]float64, len(series))
+		for i, bar := range series {
+			closes[i] = bar.Close
+		}
+
+		result, err := compiled.Signal(ctx, symbol, closes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+
+		signal := models.AISignal{
+			AgentName:  strategy.AgentName,
+			Symbol:     symbol,
+			Signal:     result.Action,
+			Confidence: result.Confidence,
+			Reasoning:  fmt.Sprintf("Strategy script %q on %d daily closes", strategy.Name, len(closes)),
+			Price:      closes[len(closes)-1],
+		}
+		if err := s.repo.CreateSignal(ctx, &signal); err != nil {
+			return nil, err
+		}
+		s.publish(ctx, &signal)
+		signals = append(signals, signal)
+	}
+
+	return signals, nil
+}
+
+// RunAgents runs the active strategies on a symbol's latest closes, or only
+// those running as one of agents when it is non-empty, and returns the
+// signals recorded. A strategy that fails is logged and skipped.
+func (s *StrategyService) RunAgents(ctx context.Context, symbol string, agents []string) ([]models.AISignal, error) {
+	strategies, err := s.repo.GetActiveStrategies(ctx, agents)
+	if err != nil {
+		return nil, err
+	}
+
+	signals := make([]models.AISignal, 0, len(strategies))
+	for _, strategy := range strategies {
+		run, err := s.RunSignals(ctx, strategy.ID, []string{symbol})
+		if err != nil {
+			s.logger.Warn("Strategy failed to run",
+				zap.Error(err),
+				zap.Int("strategy_id", strategy.ID),
+				zap.String("agent", strategy.AgentName),
+				zap.String("symbol", symbol))
+			continue
+		}
+		signals = append(signals, run...)
+	}
+	return signals, nil
+}
+
+// publish announces a recorded signal on the AI signal channel. Failures
+// are logged.
+func (s *StrategyService) publish(ctx context.Context, signal *models.AISignal) {
+	event := models.AISignalEvent{
+		Event: models.Event{
+			Type:      "ai_signal",
+			Source:    "risk-service",
+			Timestamp: signal.CreatedAt,
+		},
+		SignalID:   signal.ID,
+		AgentName:  signal.AgentName,
+		Symbol:     signal.Symbol,
+		Signal:     signal.Signal,
+		Confidence: signal.Confidence,
+		Price:      signal.Price,
+	}
+	if err := s.redis.PublishEvent(ctx, models.ChannelAISignals, event); err != nil {
+		s.logger.Warn("Failed to publish AI signal", zap.Error(err), zap.String("agent", signal.AgentName))
+	}
+}
+
+// Strategy returns an active strategy's signal for backtesting and its
+// lookback. Each call runs sandboxed under ctx.
+func (s *StrategyService) Strategy(ctx context.Context, strategyID int) (engine.SignalFunc, int, error) {
+	strategy, err := s.repo.GetStrategy(ctx, strategyID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !strategy.IsActive {
+		return nil, 0, fmt.Errorf("%w: strategy %d is inactive", domain.ErrInvalidStrategy, strategyID)
+	}
+	compiled, err := script.Compile(ctx, strategy.Name, strategy.Source, s.limits)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	signal := func(symbol string, _ time.Time, closes []float64) (string, error) {
+		result, err := compiled.Signal(ctx, symbol, closes)
+		if err != nil {
+			return "", err
+		}
+		return result.Action, nil
+	}
+	return signal, strategy.Lookback, nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	portfoliopb "hedge-fund/pkg/proto/portfolio"
+	sharedrpc "hedge-fund/pkg/shared/rpc"
+)
+
+// PortfolioTrader places auto-trade orders through the portfolio service
+type PortfolioTrader struct {
+	client portfoliopb.PortfolioServiceClient
+}
+
+func NewPortfolioTrader(client portfoliopb.PortfolioServiceClient) *PortfolioTrader {
+	return &PortfolioTrader{client: client}
+}
+
+// ExecuteTrade places a market order, returning the trade's ID. The
+// portfolio service fills an order once per idempotency key, and returns
+// the original trade when it is placed again. Orders it refuses the user
+// they are placed for, such as large trades without a second factor, are
+// returned as ErrOrderRefused.
+func (t *PortfolioTrader) ExecuteTrade(ctx context.Context, idempotencyKey string, portfolioID int, symbol, side string, quantity float64) (int, error) {
+	resp, err := t.client.ExecuteTrade(sharedrpc.WithIdempotencyKey(ctx, idempotencyKey), &portfoliopb.ExecuteTradeRequest{
+		PortfolioId: int64(portfolioID),
+		Symbol:      symbol,
+		Side:        side,
+		Quantity:    quantity,
+		OrderType:   "market",
+	})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.PermissionDenied, codes.ResourceExhausted:
+		return 0, fmt.Errorf("%w: %w", ErrOrderRefused, err)
+	default:
+		return 0, err
+	}
+	return int(resp.GetTrade().GetId()), nil
+}
@@ -0,0 +1,195 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hedge-fund/internal/ai/domain"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Steps of the analysis workflow, in order
+const (
+	StepFetchMarketData   = "fetch_market_data"
+	StepFetchFundamentals = "fetch_fundamentals"
+	StepRunAgents         = "run_agents"
+	StepComputeRisk       = "compute_risk"
+	StepBuildConsensus    = "build_consensus"
+)
+
+// HistoryDays is the calendar days of daily bars an analysis fetches
+const HistoryDays = 365
+
+// avgVolumeBars is the trading days average volume is taken over
+const avgVolumeBars = 20
+
+// State is what an analysis workflow's steps pass along
+type State struct {
+	RequestID string
+	Request   models.AIAnalysisRequest
+	StartedAt time.Time
+
+	Bars       []models.Price // Daily, oldest first
+	Benchmark  []models.Price // domain.MarketBenchmark's daily bars
+	Instrument *models.Instrument
+	MarketData *models.MarketData
+	Signals    []models.AISignal // Recorded by the agents run
+	Risk       *models.RiskMetrics
+	Result     *models.AIAnalysisResponse
+}
+
+// BarSource loads stored daily bars
+type BarSource interface {
+	GetBars(ctx context.Context, symbols []string, start, end time.Time) (map[string][]models.Price, error)
+}
+
+// InstrumentSource looks up reference data, returning nil for unknown symbols
+type InstrumentSource interface {
+	GetInstrument(ctx context.Context, symbol string) (*models.Instrument, error)
+}
+
+// MarketDataCache holds the latest quote and valuation data of symbols
+type MarketDataCache interface {
+	GetMarketData(ctx context.Context, symbol string, dest interface{}) error
+}
+
+// AgentRunner runs agents on a symbol, recording their signals. An empty
+// agents list runs every agent.
+type AgentRunner interface {
+	RunAgents(ctx context.Context, symbol string, agents []string) ([]models.AISignal, error)
+}
+
+// Analyzer combines agents' recorded signals into a consensus
+type Analyzer interface {
+	Analyze(ctx context.Context, req models.AIAnalysisRequest) (*models.AIAnalysisResponse, error)
+}
+
+// AnalysisSteps chains an analysis: fetch market data, fetch fundamentals,
+// run agents, compute risk and build the consensus
+func AnalysisSteps(bars BarSource, instruments InstrumentSource, cache MarketDataCache, agents AgentRunner, analyzer Analyzer) []Step {
+	return []Step{
+		{Name: StepFetchMarketData, Run: fetchMarketData(bars)},
+		{Name: StepFetchFundamentals, Run: fetchFundamentals(instruments, cache)},
+		{Name: StepRunAgents, Run: runAgents(agents)},
+		{Name: StepComputeRisk, Run: computeRisk},
+		{Name: StepBuildConsensus, Run: buildConsensus(analyzer)},
+	}
+}
+
+// fetchMarketData loads a year of daily bars of the symbol and the market
+// benchmark up to the request's end date
+func fetchMarketData(source BarSource) func(context.Context, *State) error {
+	return func(ctx context.Context, state *State) error {
+		end := state.StartedAt
+		if state.Request.EndDate != nil {
+			end = *state.Request.EndDate
+		}
+		symbol := state.Request.Symbol
+
+		bars, err := source.GetBars(ctx, []string{symbol, domain.MarketBenchmark}, end.AddDate(0, 0, -HistoryDays), end)
+		if err != nil {
+			return err
+		}
+		if len(bars[symbol]) == 0 {
+			return Permanent(fmt.Errorf("no price history for %s", symbol))
+		}
+		state.Bars = bars[symbol]
+		state.Benchmark = bars[domain.MarketBenchmark]
+		return nil
+	}
+}
+
+// fetchFundamentals looks up the symbol's instrument and cached valuation
+// data, completing the market data from the latest bars. Either may be
+// missing.
+func fetchFundamentals(instruments InstrumentSource, cache MarketDataCache) func(context.Context, *State) error {
+	return func(ctx context.Context, state *State) error {
+		symbol := state.Request.Symbol
+		instrument, err := instruments.GetInstrument(ctx, symbol)
+		if err != nil {
+			return err
+		}
+		state.Instrument = instrument
+
+		var data models.MarketData
+		if err := cache.GetMarketData(ctx, symbol, &data); err != nil {
+			data = models.MarketData{} // Not cached
+		}
+		data.Symbol = symbol
+
+		last := state.Bars[len(state.Bars)-1]
+		if data.CurrentPrice == 0 {
+			data.CurrentPrice = last.Close
+			data.LastUpdated = last.Timestamp
+		}
+		if data.DailyBar == nil {
+			data.DailyBar = &last
+		}
+		if data.Volume == 0 {
+			data.Volume = last.Volume
+		}
+		if data.AvgVolume == 0 {
+			recent := state.Bars
+			if len(recent) > avgVolumeBars {
+				recent = recent[len(recent)-avgVolumeBars:]
+			}
+			total := int64(0)
+			for _, bar := range recent {
+				total += bar.Volume
+			}
+			data.AvgVolume = total / int64(len(recent))
+		}
+		state.MarketData = &data
+		return nil
+	}
+}
+
+// runAgents runs the requested agents on the symbol, recording fresh signals
+// for the consensus
+func runAgents(runner AgentRunner) func(context.Context, *State) error {
+	return func(ctx context.Context, state *State) error {
+		signals, err := runner.RunAgents(ctx, state.Request.Symbol, state.Request.Agents)
+		if err != nil {
+			return err
+		}
+		state.Signals = signals
+		return nil
+	}
+}
+
+// computeRisk measures the symbol's risk from its bars against the market
+// benchmark
+func computeRisk(ctx context.Context, state *State) error {
+	risk := domain.SymbolRisk(state.Request.Symbol, state.Bars, state.Benchmark, time.Now())
+	state.Risk = &risk
+	if state.MarketData != nil && state.MarketData.Beta == 0 {
+		state.MarketData.Beta = risk.Beta
+	}
+	return nil
+}
+
+// buildConsensus combines the agents' latest signals and attaches the
+// market data and risk gathered by the earlier steps
+func buildConsensus(analyzer Analyzer) func(context.Context, *State) error {
+	return func(ctx context.Context, state *State) error {
+		result, err := analyzer.Analyze(ctx, state.Request)
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidConsensus) || errors.Is(err, domain.ErrInvalidPeriod) ||
+				strings.Contains(err.Error(), "no signals found") {
+				return Permanent(err)
+			}
+			return err
+		}
+
+		result.RequestID = state.RequestID
+		result.Instrument = state.Instrument
+		result.MarketData = state.MarketData
+		result.RiskMetrics = state.Risk
+		result.ProcessingTime = float64(result.CompletedAt.Sub(state.StartedAt).Microseconds()) / 1000
+		state.Result = result
+		return nil
+	}
+}
@@ -0,0 +1,369 @@
+// Package workflow orchestrates multi-step AI analyses. A workflow chains
+// steps that each feed the next through a shared State; progress is tracked
+// per step in Redis and published as it changes, so clients can poll or
+// stream it.
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"hedge-fund/internal/ai/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/redis"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+const (
+	workflowKeyPrefix = "ai:workflow:"
+	workflowTTL       = 24 * time.Hour
+
+	// EventWorkflowProgress is published on models.ChannelAIWorkflows
+	EventWorkflowProgress = "workflow_progress"
+)
+
+// Workflow and step statuses
+const (
+	StatusPending   = models.JobStatusPending
+	StatusRunning   = models.JobStatusRunning
+	StatusRetrying  = models.JobStatusRetrying
+	StatusCompleted = models.JobStatusCompleted
+	StatusFailed    = models.JobStatusFailed
+)
+
+var (
+	ErrInvalidWorkflow  = errors.New("invalid workflow request")
+	ErrWorkflowNotFound = errors.New("workflow not found")
+)
+
+// Step is one stage of a workflow. Run reads what earlier steps left in the
+// State and adds its own results.
+type Step struct {
+	Name string
+	Run  func(ctx context.Context, state *State) error
+}
+
+// permanentError marks a step failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the step that returned it fails without retries
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Engine runs workflows of a fixed chain of steps on the AI analysis queue.
+// A failing step is retried with exponential backoff before the workflow
+// fails. The State lives only in the worker running the workflow, so a
+// workflow redelivered after a crash starts over.
+type Engine struct {
+	steps   []Step
+	redis   *redis.Client
+	queue   *queue.Manager
+	retries int           // Extra attempts of a failing step
+	backoff time.Duration // Before a step's first retry, doubling after each
+	logger  *zap.Logger
+
+	// track persists and publishes a status change
+	track func(ctx context.Context, status *models.WorkflowStatus)
+}
+
+func NewEngine(steps []Step, redisClient *redis.Client, queueManager *queue.Manager, retries int, backoff time.Duration, logger *zap.Logger) *Engine {
+	e := &Engine{
+		steps:   steps,
+		redis:   redisClient,
+		queue:   queueManager,
+		retries: retries,
+		backoff: backoff,
+		logger:  logger,
+	}
+	e.track = e.save
+	return e
+}
+
+// Start validates an analysis request, stores its workflow as pending and
+// enqueues it for the AI analysis workers
+func (e *Engine) Start(ctx context.Context, req models.AIAnalysisRequest) (*models.WorkflowStatus, error) {
+	req.Symbol = symbols.Normalize(req.Symbol)
+	if err := symbols.Validate(req.Symbol); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+	}
+	if req.Consensus != "" {
+		if _, err := domain.NewConsensus(req.Consensus, nil); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+		}
+	}
+	if req.PerformancePeriod != "" {
+		if _, ok := domain.HorizonFor(req.PerformancePeriod); !ok {
+			return nil, fmt.Errorf("%w: unknown performance period %s", ErrInvalidWorkflow, req.PerformancePeriod)
+		}
+	}
+	if req.StartDate != nil && req.EndDate != nil && !req.EndDate.After(*req.StartDate) {
+		return nil, fmt.Errorf("%w: end_date must be after start_date", ErrInvalidWorkflow)
+	}
+
+	status := &models.WorkflowStatus{
+		RequestID:      uuid.New().String(),
+		Status:         StatusPending,
+		CompletedSteps: []string{},
+		Steps:          make([]models.WorkflowStep, len(e.steps)),
+		Request:        req,
+		StartedAt:      time.Now(),
+	}
+	for i, step := range e.steps {
+		status.Steps[i] = models.WorkflowStep{Name: step.Name, Status: StatusPending}
+	}
+
+	job := &models.Job{
+		ID:       uuid.New().String(),
+		Type:     models.JobTypeAIAnalysis,
+		Priority: 5,
+		Payload: map[string]interface{}{
+			"request_id": status.RequestID,
+		},
+	}
+	status.Metadata = map[string]interface{}{"job_id": job.ID}
+
+	if err := e.store(ctx, status); err != nil {
+		return nil, err
+	}
+	if err := e.queue.EnqueueJob(job); err != nil {
+		return nil, fmt.Errorf("failed to enqueue workflow: %w", err)
+	}
+
+	e.logger.Info("AI workflow enqueued",
+		zap.String("request_id", status.RequestID),
+		zap.String("symbol", req.Symbol),
+		zap.Int("steps", len(status.Steps)))
+	return status, nil
+}
+
+// Get returns a workflow's stored status
+func (e *Engine) Get(ctx context.Context, requestID string) (*models.WorkflowStatus, error) {
+	var status models.WorkflowStatus
+	if err := e.redis.GetCache(ctx, workflowKeyPrefix+requestID, &status); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWorkflowNotFound, requestID)
+	}
+	return &status, nil
+}
+
+// Watch calls fn with a workflow's current status and then with each change
+// to it, until the workflow finishes, fn returns false or ctx is done
+func (e *Engine) Watch(ctx context.Context, requestID string, fn func(*models.WorkflowStatus) bool) error {
+	// Subscribed before reading the current status, so no change is missed
+	pubsub := e.redis.SubscribeToEvents(ctx, models.ChannelAIWorkflows)
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to workflow progress: %w", err)
+	}
+
+	status, err := e.Get(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	if !fn(status) || Finished(status) {
+		return nil
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var event models.WorkflowEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				e.logger.Warn("Failed to decode workflow event", zap.Error(err))
+				continue
+			}
+			if event.Workflow.RequestID != requestID {
+				continue
+			}
+			if !fn(&event.Workflow) || Finished(&event.Workflow) {
+				return nil
+			}
+		}
+	}
+}
+
+// Finished reports whether a workflow has completed or failed
+func Finished(status *models.WorkflowStatus) bool {
+	return status.Status == StatusCompleted || status.Status == StatusFailed
+}
+
+// CanHandle implements queue.JobHandler
+func (e *Engine) CanHandle(jobType string) bool {
+	return jobType == models.JobTypeAIAnalysis
+}
+
+// Handle implements queue.JobHandler by running the workflow referenced by
+// the job. A failed workflow fails its job, which the queue does not retry:
+// its steps have already been retried.
+func (e *Engine) Handle(ctx context.Context, job *models.Job) error {
+	requestID, _ := job.Payload["request_id"].(string)
+	status, err := e.Get(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	if Finished(status) {
+		return nil // Redelivered after it settled
+	}
+
+	if err := e.run(ctx, status); err != nil {
+		e.logger.Warn("AI workflow failed",
+			zap.String("request_id", status.RequestID),
+			zap.String("step", status.CurrentStep),
+			zap.Error(err))
+		return fmt.Errorf("%s: %w", status.CurrentStep, err)
+	}
+
+	e.logger.Info("AI workflow completed",
+		zap.String("request_id", status.RequestID),
+		zap.String("symbol", status.Request.Symbol),
+		zap.Duration("duration", status.CompletedAt.Sub(status.StartedAt)))
+	return nil
+}
+
+// run executes every step in order, tracking each attempt, and records the
+// result or the error of the step that failed
+func (e *Engine) run(ctx context.Context, status *models.WorkflowStatus) error {
+	now := time.Now()
+	status.Status = StatusRunning
+	status.StartedAt = now
+	status.CompletedSteps = []string{}
+	status.Progress = 0
+	status.ErrorMessage = ""
+	for i := range status.Steps {
+		status.Steps[i] = models.WorkflowStep{Name: status.Steps[i].Name, Status: StatusPending}
+	}
+
+	state := &State{RequestID: status.RequestID, Request: status.Request, StartedAt: now}
+	for i, step := range e.steps {
+		if err := e.runStep(ctx, status, &status.Steps[i], step, state); err != nil {
+			completed := time.Now()
+			status.Status = StatusFailed
+			status.ErrorMessage = fmt.Sprintf("%s: %v", step.Name, err)
+			status.CompletedAt = &completed
+			e.track(ctx, status)
+			return err
+		}
+		status.CompletedSteps = append(status.CompletedSteps, step.Name)
+		status.Progress = float64(i+1) / float64(len(e.steps)) * 100
+	}
+
+	completed := time.Now()
+	status.Status = StatusCompleted
+	status.CurrentStep = ""
+	status.Result = state.Result
+	status.CompletedAt = &completed
+	e.track(ctx, status)
+	return nil
+}
+
+// runStep attempts a step until it succeeds, fails permanently or runs out
+// of retries
+func (e *Engine) runStep(ctx context.Context, status *models.WorkflowStatus, progress *models.WorkflowStep, step Step, state *State) error {
+	started := time.Now()
+	status.CurrentStep = step.Name
+	progress.StartedAt = &started
+
+	backoff := e.backoff
+	for {
+		progress.Attempts++
+		progress.Status = StatusRunning
+		e.track(ctx, status)
+
+		err := step.Run(ctx, state)
+		if err == nil {
+			completed := time.Now()
+			progress.Status = StatusCompleted
+			progress.Error = ""
+			progress.CompletedAt = &completed
+			return nil
+		}
+
+		progress.Error = err.Error()
+		var permanent *permanentError
+		if errors.As(err, &permanent) || progress.Attempts > e.retries || ctx.Err() != nil {
+			completed := time.Now()
+			progress.Status = StatusFailed
+			progress.CompletedAt = &completed
+			return err
+		}
+
+		progress.Status = StatusRetrying
+		e.track(ctx, status)
+		e.logger.Warn("Retrying AI workflow step",
+			zap.String("request_id", status.RequestID),
+			zap.String("step", step.Name),
+			zap.Int("attempt", progress.Attempts),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// save stores a workflow's status and publishes it. Failures are logged:
+// the workflow keeps running and a later change may still be recorded.
+func (e *Engine) save(ctx context.Context, status *models.WorkflowStatus) {
+	if err := e.store(ctx, status); err != nil {
+		e.logger.Warn("Failed to save workflow status", zap.Error(err), zap.String("request_id", status.RequestID))
+		return
+	}
+
+	event := models.WorkflowEvent{
+		Event: models.Event{
+			Type:      EventWorkflowProgress,
+			Source:    "risk-service",
+			Timestamp: time.Now(),
+		},
+		Workflow: *status,
+	}
+	if err := e.redis.PublishEvent(ctx, models.ChannelAIWorkflows, event); err != nil {
+		e.logger.Warn("Failed to publish workflow progress", zap.Error(err), zap.String("request_id", status.RequestID))
+	}
+
+	// Mirrored on the workflow's job while it runs; the worker settles the
+	// job once the workflow finishes
+	jobID, _ := status.Metadata["job_id"].(string)
+	if jobID == "" || Finished(status) {
+		return
+	}
+	message := fmt.Sprintf("Running %s", status.CurrentStep)
+	for _, step := range status.Steps {
+		if step.Name == status.CurrentStep && step.Status == StatusRetrying {
+			message = fmt.Sprintf("Retrying %s after attempt %d: %s", step.Name, step.Attempts, step.Error)
+		}
+	}
+	if err := e.queue.SetJobStatus(jobID, models.JobStatusRunning, message, status.Progress); err != nil {
+		e.logger.Warn("Failed to update job progress", zap.Error(err), zap.String("job_id", jobID))
+	}
+}
+
+func (e *Engine) store(ctx context.Context, status *models.WorkflowStatus) error {
+	if err := e.redis.SetCache(ctx, workflowKeyPrefix+status.RequestID, status, workflowTTL); err != nil {
+		return fmt.Errorf("failed to save workflow: %w", err)
+	}
+	return nil
+}
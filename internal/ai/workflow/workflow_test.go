@@ -0,0 +1,105 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+)
+
+// testEngine runs steps without Redis, recording every tracked status
+func testEngine(retries int, steps ...Step) (*Engine, *[]models.WorkflowStatus) {
+	e := &Engine{steps: steps, retries: retries, logger: zap.NewNop()}
+	var tracked []models.WorkflowStatus
+	e.track = func(ctx context.Context, status *models.WorkflowStatus) {
+		snapshot := *status
+		snapshot.Steps = append([]models.WorkflowStep(nil), status.Steps...)
+		tracked = append(tracked, snapshot)
+	}
+	return e, &tracked
+}
+
+func newStatus(e *Engine) *models.WorkflowStatus {
+	status := &models.WorkflowStatus{RequestID: "req", Status: StatusPending}
+	for _, step := range e.steps {
+		status.Steps = append(status.Steps, models.WorkflowStep{Name: step.Name, Status: StatusPending})
+	}
+	return status
+}
+
+func TestRunChainsStepsAndTracksProgress(t *testing.T) {
+	e, tracked := testEngine(0,
+		Step{Name: "first", Run: func(ctx context.Context, state *State) error {
+			state.Signals = []models.AISignal{{Signal: "buy"}}
+			return nil
+		}},
+		Step{Name: "second", Run: func(ctx context.Context, state *State) error {
+			state.Result = &models.AIAnalysisResponse{ConsensusSignal: state.Signals[0].Signal}
+			return nil
+		}},
+	)
+	status := newStatus(e)
+
+	require.NoError(t, e.run(context.Background(), status))
+	assert.Equal(t, StatusCompleted, status.Status)
+	assert.Equal(t, []string{"first", "second"}, status.CompletedSteps)
+	assert.Equal(t, 100.0, status.Progress)
+	assert.Equal(t, "buy", status.Result.ConsensusSignal)
+	assert.NotNil(t, status.CompletedAt)
+
+	// Each step is tracked as it starts, and the workflow once it finishes
+	require.Len(t, *tracked, 3)
+	assert.Equal(t, "second", (*tracked)[1].CurrentStep)
+	assert.Equal(t, 50.0, (*tracked)[1].Progress)
+	assert.Equal(t, StatusCompleted, (*tracked)[1].Steps[0].Status)
+}
+
+func TestRunRetriesFailingStep(t *testing.T) {
+	attempts := 0
+	e, _ := testEngine(2, Step{Name: "flaky", Run: func(ctx context.Context, state *State) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("temporarily unavailable")
+		}
+		return nil
+	}})
+	status := newStatus(e)
+
+	require.NoError(t, e.run(context.Background(), status))
+	assert.Equal(t, StatusCompleted, status.Status)
+	assert.Equal(t, 3, status.Steps[0].Attempts)
+	assert.Empty(t, status.Steps[0].Error)
+}
+
+func TestRunFailsAfterRetries(t *testing.T) {
+	ran := false
+	e, _ := testEngine(1,
+		Step{Name: "broken", Run: func(ctx context.Context, state *State) error { return errors.New("down") }},
+		Step{Name: "never", Run: func(ctx context.Context, state *State) error { ran = true; return nil }},
+	)
+	status := newStatus(e)
+
+	assert.Error(t, e.run(context.Background(), status))
+	assert.False(t, ran)
+	assert.Equal(t, StatusFailed, status.Status)
+	assert.Equal(t, "broken: down", status.ErrorMessage)
+	assert.Equal(t, 2, status.Steps[0].Attempts)
+	assert.Equal(t, StatusFailed, status.Steps[0].Status)
+	assert.Equal(t, StatusPending, status.Steps[1].Status)
+	assert.Zero(t, status.Progress)
+}
+
+func TestRunDoesNotRetryPermanentErrors(t *testing.T) {
+	e, _ := testEngine(3, Step{Name: "invalid", Run: func(ctx context.Context, state *State) error {
+		return Permanent(errors.New("no price history"))
+	}})
+	status := newStatus(e)
+
+	assert.Error(t, e.run(context.Background(), status))
+	assert.Equal(t, 1, status.Steps[0].Attempts)
+	assert.Equal(t, StatusFailed, status.Status)
+}
@@ -0,0 +1,274 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const tradingDaysPerYear = 252
+
+// Params are the tunable parameters of the momentum strategy
+type Params struct {
+	SignalThreshold float64 `json:"signal_threshold"` // Minimum lookback return (%) to hold a symbol
+	Lookback        int     `json:"lookback"`         // Bars used to compute the signal
+	RebalanceEvery  int     `json:"rebalance_every"`  // Bars between rebalances
+	PositionSize    float64 `json:"position_size"`    // Fraction of equity per held symbol (0-1]
+}
+
+// Validate checks that params are usable
+func (p Params) Validate() error {
+	if p.Lookback < 1 {
+		return fmt.Errorf("lookback must be at least 1")
+	}
+	if p.RebalanceEvery < 1 {
+		return fmt.Errorf("rebalance_every must be at least 1")
+	}
+	if p.PositionSize <= 0 || p.PositionSize > 1 {
+		return fmt.Errorf("position_size must be in (0, 1]")
+	}
+	return nil
+}
+
+// SignalFunc decides whether to hold symbol at the bar timestamped at given
+// its closes up to that bar, the last Lookback+1 of them, oldest first. It
+// returns "buy" to hold the symbol, "sell" to not hold it or "hold" to keep
+// the current holding.
+type SignalFunc func(symbol string, at time.Time, closes []float64) (string, error)
+
+// Config holds settings shared by every run
+type Config struct {
+	InitialCash    float64 `json:"initial_cash"`
+	CommissionRate float64 `json:"commission_rate"` // Fraction of traded value
+	RiskFreeRate   float64 `json:"risk_free_rate"`  // Annual, e.g. 0.04
+
+	// Signal replaces the momentum signal, leaving SignalThreshold unused
+	Signal SignalFunc `json:"-"`
+}
+
+// DefaultConfig returns the default backtest configuration
+func DefaultConfig() Config {
+	return Config{
+		InitialCash:    100000.0,
+		CommissionRate: 0.001,
+		RiskFreeRate:   0.0,
+	}
+}
+
+// EquityPoint is the portfolio value at a bar
+type EquityPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Equity    float64   `json:"equity"`
+}
+
+// Trade is a rebalancing order filled at a bar's close
+type Trade struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Symbol     string    `json:"symbol"`
+	Side       string    `json:"side"` // "buy" or "sell"
+	Shares     float64   `json:"shares"`
+	Price      float64   `json:"price"`
+	Value      float64   `json:"value"`
+	Commission float64   `json:"commission"`
+}
+
+// Result summarizes a backtest run
+type Result struct {
+	Params      Params        `json:"params"`
+	StartDate   time.Time     `json:"start_date"`
+	EndDate     time.Time     `json:"end_date"`
+	Bars        int           `json:"bars"`
+	InitialCash float64       `json:"initial_cash"`
+	FinalEquity float64       `json:"final_equity"`
+	TotalReturn float64       `json:"total_return"` // %
+	Volatility  float64       `json:"volatility"`   // Annualized, %
+	SharpeRatio float64       `json:"sharpe_ratio"`
+	MaxDrawdown float64       `json:"max_drawdown"` // %
+	TradeCount  int           `json:"trade_count"`
+	EquityCurve []EquityPoint `json:"equity_curve,omitempty"`
+	Trades      []Trade       `json:"trades,omitempty"`
+}
+
+// Run backtests params over the whole series
+func Run(series Series, params Params, cfg Config) (*Result, error) {
+	return RunRange(series, params, cfg, 0, series.Len())
+}
+
+// RunRange backtests params over bars [start, end). Bars before start are only
+// used as signal history, so evaluating an out-of-sample segment never trades
+// on data the segment doesn't have.
+func RunRange(series Series, params Params, cfg Config, start, end int) (*Result, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if start < 0 || end > series.Len() || end-start < 2 {
+		return nil, fmt.Errorf("backtest range [%d, %d) needs at least 2 of %d bars", start, end, series.Len())
+	}
+
+	symbols := series.Symbols()
+	cash := cfg.InitialCash
+	shares := make(map[string]float64, len(symbols))
+	curve := make([]EquityPoint, 0, end-start)
+	var trades []Trade
+
+	for i := start; i < end; i++ {
+		if i >= params.Lookback && (i-start)%params.RebalanceEvery == 0 {
+			targets, err := targetWeights(series, symbols, shares, params, cfg.Signal, i)
+			if err != nil {
+				return nil, err
+			}
+			equity := markToMarket(series, symbols, shares, cash, i)
+
+			for _, symbol := range symbols {
+				price := series.Closes[symbol][i]
+				if price <= 0 {
+					continue
+				}
+				targetShares := targets[symbol] * equity / price
+				delta := targetShares - shares[symbol]
+				if math.Abs(delta*price) < 1.0 {
+					continue // Ignore dust rebalances
+				}
+
+				tradeValue := delta * price
+				commission := math.Abs(tradeValue) * cfg.CommissionRate
+				cash -= tradeValue + commission
+				shares[symbol] = targetShares
+
+				side := "buy"
+				if delta < 0 {
+					side = "sell"
+				}
+				trades = append(trades, Trade{
+					Timestamp:  series.Timestamps[i],
+					Symbol:     symbol,
+					Side:       side,
+					Shares:     math.Abs(delta),
+					Price:      price,
+					Value:      math.Abs(tradeValue),
+					Commission: commission,
+				})
+			}
+		}
+
+		curve = append(curve, EquityPoint{
+			Timestamp: series.Timestamps[i],
+			Equity:    markToMarket(series, symbols, shares, cash, i),
+		})
+	}
+
+	result := &Result{
+		Params:      params,
+		StartDate:   series.Timestamps[start],
+		EndDate:     series.Timestamps[end-1],
+		Bars:        end - start,
+		InitialCash: cfg.InitialCash,
+		FinalEquity: curve[len(curve)-1].Equity,
+		TradeCount:  len(trades),
+		EquityCurve: curve,
+		Trades:      trades,
+	}
+	fillStatistics(result, cfg.RiskFreeRate)
+
+	return result, nil
+}
+
+// targetWeights returns the target portfolio weight of each symbol at bar
+// i, selecting symbols by signal or, without one, by momentum
+func targetWeights(series Series, symbols []string, shares map[string]float64, params Params, signal SignalFunc, i int) (map[string]float64, error) {
+	var selected []string
+	for _, symbol := range symbols {
+		if signal != nil {
+			action, err := signal(symbol, series.Timestamps[i], series.Closes[symbol][i-params.Lookback:i+1])
+			if err != nil {
+				return nil, fmt.Errorf("signal for %s on %s: %w", symbol, series.Timestamps[i].Format("2006-01-02"), err)
+			}
+			if action == "buy" || (action == "hold" && shares[symbol] > 0) {
+				selected = append(selected, symbol)
+			}
+			continue
+		}
+
+		past := series.Closes[symbol][i-params.Lookback]
+		if past <= 0 {
+			continue
+		}
+		momentum := (series.Closes[symbol][i]/past - 1) * 100
+		if momentum > params.SignalThreshold {
+			selected = append(selected, symbol)
+		}
+	}
+
+	weight := params.PositionSize
+	if total := weight * float64(len(selected)); total > 1 {
+		weight = 1 / float64(len(selected))
+	}
+
+	weights := make(map[string]float64, len(symbols))
+	for _, symbol := range selected {
+		weights[symbol] = weight
+	}
+	return weights, nil
+}
+
+func markToMarket(series Series, symbols []string, shares map[string]float64, cash float64, i int) float64 {
+	equity := cash
+	for _, symbol := range symbols {
+		equity += shares[symbol] * series.Closes[symbol][i]
+	}
+	return equity
+}
+
+// fillStatistics derives return, volatility, Sharpe and drawdown from the equity curve
+func fillStatistics(result *Result, riskFreeRate float64) {
+	curve := result.EquityCurve
+	if result.InitialCash > 0 {
+		result.TotalReturn = (result.FinalEquity/result.InitialCash - 1) * 100
+	}
+
+	returns := make([]float64, 0, len(curve)-1)
+	for i := 1; i < len(curve); i++ {
+		if curve[i-1].Equity > 0 {
+			returns = append(returns, curve[i].Equity/curve[i-1].Equity-1)
+		}
+	}
+
+	mean, stddev := meanStdDev(returns)
+	result.Volatility = stddev * math.Sqrt(tradingDaysPerYear) * 100
+	if stddev > 0 {
+		dailyRiskFree := riskFreeRate / tradingDaysPerYear
+		result.SharpeRatio = (mean - dailyRiskFree) / stddev * math.Sqrt(tradingDaysPerYear)
+	}
+
+	peak := 0.0
+	for _, point := range curve {
+		if point.Equity > peak {
+			peak = point.Equity
+		}
+		if peak > 0 {
+			if drawdown := (peak - point.Equity) / peak * 100; drawdown > result.MaxDrawdown {
+				result.MaxDrawdown = drawdown
+			}
+		}
+	}
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) < 2 {
+		return 0, 0
+	}
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values) - 1)
+
+	return mean, math.Sqrt(variance)
+}
@@ -0,0 +1,194 @@
+package engine
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+func trendingBars(symbol string, start float64, dailyReturn float64, days int) []models.Price {
+	base := time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC)
+	bars := make([]models.Price, days)
+	price := start
+	for i := 0; i < days; i++ {
+		bars[i] = models.Price{Symbol: symbol, Close: price, Timestamp: base.AddDate(0, 0, i)}
+		price *= 1 + dailyReturn
+	}
+	return bars
+}
+
+func TestAlignKeepsCommonDays(t *testing.T) {
+	series := Align(map[string][]models.Price{
+		"AAPL": trendingBars("AAPL", 100, 0.01, 10),
+		"MSFT": trendingBars("MSFT", 100, 0.01, 8),
+	})
+
+	assert.Equal(t, 8, series.Len())
+	assert.Equal(t, []string{"AAPL", "MSFT"}, series.Symbols())
+}
+
+func TestRunFollowsUptrend(t *testing.T) {
+	series := Align(map[string][]models.Price{
+		"AAPL": trendingBars("AAPL", 100, 0.01, 60),
+	})
+
+	result, err := Run(series, Params{SignalThreshold: 1, Lookback: 5, RebalanceEvery: 5, PositionSize: 1}, DefaultConfig())
+	require.NoError(t, err)
+
+	assert.Greater(t, result.TotalReturn, 0.0)
+	assert.Greater(t, result.TradeCount, 0)
+	assert.Len(t, result.EquityCurve, 60)
+}
+
+func TestRunStaysInCashInDowntrend(t *testing.T) {
+	series := Align(map[string][]models.Price{
+		"AAPL": trendingBars("AAPL", 100, -0.01, 60),
+	})
+
+	result, err := Run(series, Params{SignalThreshold: 0, Lookback: 5, RebalanceEvery: 1, PositionSize: 1}, DefaultConfig())
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, result.TradeCount)
+	assert.InDelta(t, 0.0, result.TotalReturn, 1e-9)
+}
+
+func TestRunWithSignal(t *testing.T) {
+	series := Align(map[string][]models.Price{
+		"AAPL": trendingBars("AAPL", 100, 0.01, 20),
+	})
+
+	// Buy once, then hold: one trade in, none out
+	var calls int
+	cfg := DefaultConfig()
+	cfg.Signal = func(symbol string, _ time.Time, closes []float64) (string, error) {
+		calls++
+		assert.Len(t, closes, 6)
+		if calls == 1 {
+			return "buy", nil
+		}
+		return "hold", nil
+	}
+
+	result, err := Run(series, Params{Lookback: 5, RebalanceEvery: 5, PositionSize: 0.5}, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 3, result.TradeCount) // Bought, then trimmed back to half of the grown equity twice
+	assert.Greater(t, result.TotalReturn, 0.0)
+	require.Len(t, result.Trades, 3)
+	for i, trade := range result.Trades {
+		side := "sell"
+		if i == 0 {
+			side = "buy"
+		}
+		assert.Equal(t, "AAPL", trade.Symbol)
+		assert.Equal(t, side, trade.Side)
+		assert.InDelta(t, trade.Shares*trade.Price, trade.Value, 1e-9)
+		assert.InDelta(t, trade.Value*cfg.CommissionRate, trade.Commission, 1e-9)
+	}
+	assert.True(t, result.Trades[0].Timestamp.Before(result.Trades[1].Timestamp))
+
+	cfg.Signal = func(string, time.Time, []float64) (string, error) { return "", assert.AnError }
+	_, err = Run(series, Params{Lookback: 5, RebalanceEvery: 5, PositionSize: 0.5}, cfg)
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestOptimizeRanksByOutOfSampleSharpe(t *testing.T) {
+	series := Align(map[string][]models.Price{
+		"AAPL": trendingBars("AAPL", 100, 0.005, 120),
+		"TSLA": trendingBars("TSLA", 100, -0.004, 120),
+	})
+	space := ParamSpace{
+		SignalThresholds: []float64{0, 2},
+		Lookbacks:        []int{5, 10},
+		RebalanceEvery:   []int{1, 5},
+		PositionSizes:    []float64{0.5, 1},
+	}
+
+	cfg := OptimizeConfig{Engine: DefaultConfig(), TrainRatio: 0.7, Concurrency: 3}
+	evaluations, err := Optimize(context.Background(), series, space.Grid(), cfg, nil)
+	require.NoError(t, err)
+	require.Len(t, evaluations, space.Size())
+
+	for i, evaluation := range evaluations {
+		assert.Equal(t, i+1, evaluation.Rank)
+		require.NotNil(t, evaluation.OutOfSample)
+		assert.Nil(t, evaluation.OutOfSample.EquityCurve)
+		assert.True(t, evaluation.InSample.EndDate.Before(evaluation.OutOfSample.StartDate))
+		if i > 0 {
+			assert.GreaterOrEqual(t, evaluations[i-1].OutOfSample.SharpeRatio, evaluation.OutOfSample.SharpeRatio)
+		}
+	}
+}
+
+func TestRandomSearchSamplesDistinctParams(t *testing.T) {
+	space := ParamSpace{
+		SignalThresholds: []float64{0, 1, 2},
+		Lookbacks:        []int{5, 10, 20},
+		RebalanceEvery:   []int{1, 5},
+		PositionSizes:    []float64{0.25, 0.5},
+	}
+
+	params := space.Random(10, rand.New(rand.NewSource(1)))
+	assert.Len(t, params, 10)
+
+	seen := make(map[Params]bool)
+	for _, p := range params {
+		assert.False(t, seen[p])
+		seen[p] = true
+	}
+
+	assert.Len(t, space.Random(100, rand.New(rand.NewSource(1))), space.Size())
+}
+
+func TestRandomSearchIgnoresRepeatedValues(t *testing.T) {
+	// Two distinct sets, though the lists multiply out to eight
+	space := ParamSpace{
+		SignalThresholds: []float64{1, 1},
+		Lookbacks:        []int{5, 10},
+		RebalanceEvery:   []int{1, 1},
+		PositionSizes:    []float64{0.5},
+	}
+	assert.Equal(t, 2, space.Size())
+	assert.Len(t, space.Grid(), 2)
+
+	done := make(chan []Params)
+	go func() { done <- space.Random(3, rand.New(rand.NewSource(1))) }()
+	select {
+	case params := <-done:
+		assert.ElementsMatch(t, space.Grid(), params)
+	case <-time.After(time.Second):
+		t.Fatal("random search did not return")
+	}
+}
+
+func TestWalkForwardStitchesOutOfSampleSegments(t *testing.T) {
+	series := Align(map[string][]models.Price{
+		"AAPL": trendingBars("AAPL", 100, 0.004, 130),
+		"MSFT": trendingBars("MSFT", 100, 0.002, 130),
+	})
+	space := ParamSpace{
+		SignalThresholds: []float64{0, 1},
+		Lookbacks:        []int{5, 10},
+		RebalanceEvery:   []int{5},
+		PositionSizes:    []float64{0.5},
+	}
+
+	cfg := WalkForwardConfig{Engine: DefaultConfig(), TrainBars: 40, TestBars: 20, Concurrency: 2}
+	result, err := WalkForward(context.Background(), series, space.Grid(), cfg, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result.Windows, 4)
+	assert.Len(t, result.Combined.EquityCurve, 80)
+	for i := 1; i < len(result.Windows); i++ {
+		assert.True(t, result.Windows[i-1].TestEnd.Before(result.Windows[i].TestStart))
+		assert.InDelta(t, result.Windows[i-1].OutOfSample.FinalEquity, result.Windows[i].OutOfSample.InitialCash, 1e-9)
+	}
+	assert.Equal(t, result.Combined.StartDate, result.FullPeriod.StartDate)
+	assert.Equal(t, result.Combined.EndDate, result.FullPeriod.EndDate)
+	assert.Greater(t, result.Combined.TotalReturn, 0.0)
+}
@@ -0,0 +1,223 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// ParamSpace lists the candidate values for each strategy parameter
+type ParamSpace struct {
+	SignalThresholds []float64 `json:"signal_thresholds"`
+	Lookbacks        []int     `json:"lookbacks"`
+	RebalanceEvery   []int     `json:"rebalance_every"`
+	PositionSizes    []float64 `json:"position_sizes"`
+}
+
+// Size returns the number of distinct parameter sets in the full grid
+func (s ParamSpace) Size() int {
+	s = s.distinct()
+	return len(s.SignalThresholds) * len(s.Lookbacks) * len(s.RebalanceEvery) * len(s.PositionSizes)
+}
+
+// distinct returns the space with repeated values dropped, so each
+// combination in it is a different parameter set
+func (s ParamSpace) distinct() ParamSpace {
+	return ParamSpace{
+		SignalThresholds: unique(s.SignalThresholds),
+		Lookbacks:        unique(s.Lookbacks),
+		RebalanceEvery:   unique(s.RebalanceEvery),
+		PositionSizes:    unique(s.PositionSizes),
+	}
+}
+
+// unique returns values without repeats, in their first order
+func unique[T comparable](values []T) []T {
+	seen := make(map[T]bool, len(values))
+	out := make([]T, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Grid enumerates every distinct combination in the space
+func (s ParamSpace) Grid() []Params {
+	s = s.distinct()
+	params := make([]Params, 0, s.Size())
+	for _, threshold := range s.SignalThresholds {
+		for _, lookback := range s.Lookbacks {
+			for _, rebalance := range s.RebalanceEvery {
+				for _, size := range s.PositionSizes {
+					params = append(params, Params{
+						SignalThreshold: threshold,
+						Lookback:        lookback,
+						RebalanceEvery:  rebalance,
+						PositionSize:    size,
+					})
+				}
+			}
+		}
+	}
+	return params
+}
+
+// Random samples up to n distinct combinations from the space
+func (s ParamSpace) Random(n int, rng *rand.Rand) []Params {
+	s = s.distinct()
+	if n >= s.Size() {
+		return s.Grid()
+	}
+
+	seen := make(map[Params]bool, n)
+	params := make([]Params, 0, n)
+	for len(params) < n {
+		p := Params{
+			SignalThreshold: s.SignalThresholds[rng.Intn(len(s.SignalThresholds))],
+			Lookback:        s.Lookbacks[rng.Intn(len(s.Lookbacks))],
+			RebalanceEvery:  s.RebalanceEvery[rng.Intn(len(s.RebalanceEvery))],
+			PositionSize:    s.PositionSizes[rng.Intn(len(s.PositionSizes))],
+		}
+		if !seen[p] {
+			seen[p] = true
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// OptimizeConfig controls a parameter sweep
+type OptimizeConfig struct {
+	Engine      Config
+	TrainRatio  float64 // Fraction of bars used in-sample, the rest is held out
+	Concurrency int     // Parameter sets evaluated in parallel
+	MinTestBars int     // Smallest acceptable out-of-sample segment
+}
+
+// Evaluation is the in-sample and out-of-sample result of one parameter set
+type Evaluation struct {
+	Rank        int     `json:"rank"`
+	Params      Params  `json:"params"`
+	InSample    *Result `json:"in_sample"`
+	OutOfSample *Result `json:"out_of_sample"`
+	Degradation float64 `json:"degradation"` // In-sample minus out-of-sample Sharpe
+	Overfit     bool    `json:"overfit"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// Optimize evaluates each candidate on a train/test split of the series and
+// ranks them by out-of-sample Sharpe ratio. Equity curves are dropped from
+// the evaluations to keep sweep results small.
+func Optimize(ctx context.Context, series Series, candidates []Params, cfg OptimizeConfig, progress func(done, total int)) ([]Evaluation, error) {
+	if cfg.TrainRatio <= 0 || cfg.TrainRatio >= 1 {
+		return nil, fmt.Errorf("train ratio must be between 0 and 1")
+	}
+
+	split := int(float64(series.Len()) * cfg.TrainRatio)
+	if split < 2 || series.Len()-split < cfg.MinTestBars || series.Len()-split < 2 {
+		return nil, fmt.Errorf("not enough bars (%d) for a %.0f%% train split", series.Len(), cfg.TrainRatio*100)
+	}
+
+	evaluations := make([]Evaluation, len(candidates))
+	var mu sync.Mutex
+	done := 0
+
+	err := parallel(ctx, len(candidates), cfg.Concurrency, func(i int) {
+		evaluations[i] = evaluate(series, candidates[i], cfg.Engine, split)
+
+		if progress != nil {
+			mu.Lock()
+			done++
+			progress(done, len(candidates))
+			mu.Unlock()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	Rank(evaluations)
+	return evaluations, nil
+}
+
+func evaluate(series Series, params Params, cfg Config, split int) Evaluation {
+	evaluation := Evaluation{Params: params}
+
+	inSample, err := RunRange(series, params, cfg, 0, split)
+	if err != nil {
+		evaluation.Error = err.Error()
+		return evaluation
+	}
+	outOfSample, err := RunRange(series, params, cfg, split, series.Len())
+	if err != nil {
+		evaluation.Error = err.Error()
+		return evaluation
+	}
+
+	inSample.EquityCurve, inSample.Trades = nil, nil
+	outOfSample.EquityCurve, outOfSample.Trades = nil, nil
+
+	evaluation.InSample = inSample
+	evaluation.OutOfSample = outOfSample
+	evaluation.Degradation = inSample.SharpeRatio - outOfSample.SharpeRatio
+	// A set that does well in-sample but loses more than half its Sharpe out
+	// of sample is most likely fitted to noise
+	evaluation.Overfit = inSample.SharpeRatio > 0 && outOfSample.SharpeRatio < inSample.SharpeRatio/2
+
+	return evaluation
+}
+
+// Rank sorts evaluations by out-of-sample Sharpe (failed runs last) and
+// assigns 1-based ranks
+func Rank(evaluations []Evaluation) {
+	sort.SliceStable(evaluations, func(i, j int) bool {
+		a, b := evaluations[i].OutOfSample, evaluations[j].OutOfSample
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.SharpeRatio > b.SharpeRatio
+	})
+	for i := range evaluations {
+		evaluations[i].Rank = i + 1
+	}
+}
+
+// parallel calls fn for every index in [0, n) on up to concurrency goroutines,
+// stopping early if ctx is cancelled
+func parallel(ctx context.Context, n, concurrency int, fn func(i int)) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		case indexes <- i:
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return err
+}
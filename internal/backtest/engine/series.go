@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Series holds close prices for several symbols aligned on common timestamps
+type Series struct {
+	Timestamps []time.Time
+	Closes     map[string][]float64
+}
+
+// Align builds a Series from per-symbol bars, keeping only timestamps (by
+// calendar day) for which every symbol has a bar
+func Align(bars map[string][]models.Price) Series {
+	counts := make(map[time.Time]int)
+	closes := make(map[string]map[time.Time]float64, len(bars))
+
+	for symbol, symbolBars := range bars {
+		closes[symbol] = make(map[time.Time]float64, len(symbolBars))
+		for _, bar := range symbolBars {
+			day := bar.Timestamp.UTC().Truncate(24 * time.Hour)
+			if _, seen := closes[symbol][day]; !seen {
+				counts[day]++
+			}
+			closes[symbol][day] = bar.Close
+		}
+	}
+
+	var timestamps []time.Time
+	for day, count := range counts {
+		if count == len(bars) {
+			timestamps = append(timestamps, day)
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i].Before(timestamps[j]) })
+
+	series := Series{
+		Timestamps: timestamps,
+		Closes:     make(map[string][]float64, len(bars)),
+	}
+	for symbol := range bars {
+		values := make([]float64, len(timestamps))
+		for i, day := range timestamps {
+			values[i] = closes[symbol][day]
+		}
+		series.Closes[symbol] = values
+	}
+
+	return series
+}
+
+// Len returns the number of aligned bars
+func (s Series) Len() int {
+	return len(s.Timestamps)
+}
+
+// Symbols returns the symbols in the series in sorted order
+func (s Series) Symbols() []string {
+	symbols := make([]string, 0, len(s.Closes))
+	for symbol := range s.Closes {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WalkForwardConfig controls a walk-forward analysis
+type WalkForwardConfig struct {
+	Engine      Config
+	TrainBars   int // In-sample bars used to pick parameters for each window
+	TestBars    int // Out-of-sample bars traded with those parameters
+	Concurrency int // Parameter sets evaluated in parallel per window
+}
+
+// WalkForwardWindow is one re-optimization step
+type WalkForwardWindow struct {
+	Index          int       `json:"index"`
+	TrainStart     time.Time `json:"train_start"`
+	TrainEnd       time.Time `json:"train_end"`
+	TestStart      time.Time `json:"test_start"`
+	TestEnd        time.Time `json:"test_end"`
+	Params         Params    `json:"params"`
+	InSampleSharpe float64   `json:"in_sample_sharpe"`
+	OutOfSample    *Result   `json:"out_of_sample"`
+}
+
+// WalkForwardResult stitches the out-of-sample segments of every window into
+// one continuous run and compares it with a standard full-period backtest
+type WalkForwardResult struct {
+	Windows    []WalkForwardWindow `json:"windows"`
+	Combined   *Result             `json:"combined"`    // Stitched out-of-sample segments
+	FullPeriod *Result             `json:"full_period"` // Best parameters picked with hindsight over the same period
+	Efficiency float64             `json:"efficiency"`  // Combined Sharpe / full-period Sharpe
+	ParamSets  int                 `json:"param_sets"`  // Distinct parameter sets chosen across windows
+}
+
+// WalkForward rolls a train window followed by a test window through the
+// series. For each window the candidate with the best in-sample Sharpe is
+// traded over the following test segment, starting from the equity the
+// previous segment ended with. Positions are closed between segments.
+func WalkForward(ctx context.Context, series Series, candidates []Params, cfg WalkForwardConfig, progress func(done, total int)) (*WalkForwardResult, error) {
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("at least one parameter set is required")
+	}
+	if cfg.TrainBars < 2 || cfg.TestBars < 2 {
+		return nil, fmt.Errorf("train and test windows need at least 2 bars each")
+	}
+
+	windowCount := (series.Len() - cfg.TrainBars) / cfg.TestBars
+	if windowCount < 1 {
+		return nil, fmt.Errorf("not enough bars (%d) for a %d-bar train and %d-bar test window", series.Len(), cfg.TrainBars, cfg.TestBars)
+	}
+
+	result := &WalkForwardResult{Windows: make([]WalkForwardWindow, 0, windowCount)}
+	segmentCfg := cfg.Engine
+	var curve []EquityPoint
+	var trades []Trade
+	chosen := make(map[Params]bool)
+
+	for w := 0; w < windowCount; w++ {
+		trainStart := w * cfg.TestBars
+		testStart := trainStart + cfg.TrainBars
+		testEnd := testStart + cfg.TestBars
+
+		best, bestSharpe, err := bestInSample(ctx, series, candidates, cfg.Engine, trainStart, testStart, cfg.Concurrency)
+		if err != nil {
+			return nil, fmt.Errorf("window %d: %w", w, err)
+		}
+
+		segment, err := RunRange(series, best, segmentCfg, testStart, testEnd)
+		if err != nil {
+			return nil, fmt.Errorf("window %d: %w", w, err)
+		}
+
+		curve = append(curve, segment.EquityCurve...)
+		trades = append(trades, segment.Trades...)
+		segmentCfg.InitialCash = segment.FinalEquity
+		segment.EquityCurve, segment.Trades = nil, nil
+		chosen[best] = true
+
+		result.Windows = append(result.Windows, WalkForwardWindow{
+			Index:          w,
+			TrainStart:     series.Timestamps[trainStart],
+			TrainEnd:       series.Timestamps[testStart-1],
+			TestStart:      series.Timestamps[testStart],
+			TestEnd:        series.Timestamps[testEnd-1],
+			Params:         best,
+			InSampleSharpe: bestSharpe,
+			OutOfSample:    segment,
+		})
+
+		if progress != nil {
+			progress(w+1, windowCount)
+		}
+	}
+
+	combined := &Result{
+		StartDate:   curve[0].Timestamp,
+		EndDate:     curve[len(curve)-1].Timestamp,
+		Bars:        len(curve),
+		InitialCash: cfg.Engine.InitialCash,
+		FinalEquity: curve[len(curve)-1].Equity,
+		TradeCount:  len(trades),
+		EquityCurve: curve,
+		Trades:      trades,
+	}
+	fillStatistics(combined, cfg.Engine.RiskFreeRate)
+	result.Combined = combined
+	result.ParamSets = len(chosen)
+
+	// The full-period comparison covers the same bars the walk-forward traded
+	fullStart := cfg.TrainBars
+	fullEnd := fullStart + windowCount*cfg.TestBars
+	best, _, err := bestInSample(ctx, series, candidates, cfg.Engine, fullStart, fullEnd, cfg.Concurrency)
+	if err != nil {
+		return nil, fmt.Errorf("full period: %w", err)
+	}
+	fullPeriod, err := RunRange(series, best, cfg.Engine, fullStart, fullEnd)
+	if err != nil {
+		return nil, fmt.Errorf("full period: %w", err)
+	}
+	result.FullPeriod = fullPeriod
+
+	if fullPeriod.SharpeRatio > 0 {
+		result.Efficiency = combined.SharpeRatio / fullPeriod.SharpeRatio
+	}
+
+	return result, nil
+}
+
+// bestInSample returns the candidate with the highest Sharpe over [start, end)
+func bestInSample(ctx context.Context, series Series, candidates []Params, cfg Config, start, end, concurrency int) (Params, float64, error) {
+	sharpes := make([]float64, len(candidates))
+	errs := make([]error, len(candidates))
+
+	err := parallel(ctx, len(candidates), concurrency, func(i int) {
+		result, err := RunRange(series, candidates[i], cfg, start, end)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		sharpes[i] = result.SharpeRatio
+	})
+	if err != nil {
+		return Params{}, 0, err
+	}
+
+	best := -1
+	for i := range candidates {
+		if errs[i] == nil && (best < 0 || sharpes[i] > sharpes[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return Params{}, 0, fmt.Errorf("no parameter set could be evaluated: %w", errs[0])
+	}
+
+	return candidates[best], sharpes[best], nil
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hedge-fund/internal/backtest/engine"
+	"hedge-fund/internal/backtest/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/symbols"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTrainRatio = 0.7
+	defaultTrainBars  = 126 // ~6 months of daily bars
+	defaultTestBars   = 21  // ~1 month of daily bars
+)
+
+type BacktestHandler struct {
+	service *service.BacktestService
+	logger  *zap.Logger
+}
+
+func NewBacktestHandler(service *service.BacktestService, logger *zap.Logger) *BacktestHandler {
+	return &BacktestHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RunBacktest godoc
+// @Summary Run a backtest
+// @Description Backtest one parameter set over stored daily price history
+// @Tags backtests
+// @Accept json
+// @Produce json
+// @Param request body RunBacktestRequest true "Backtest request"
+// @Success 200 {object} engine.Result
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/backtests [post]
+func (h *BacktestHandler) RunBacktest(c *gin.Context) {
+	var req RunBacktestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	result, err := h.service.RunBacktest(c.Request.Context(), service.BacktestRequest{
+		Symbols:      symbols.NormalizeAll(req.Symbols),
+		StartDate:    req.StartDate,
+		EndDate:      req.EndDate,
+		Params:       req.Params,
+		Config:       engineConfig(req.InitialCash, req.CommissionRate),
+		RiskFreeRate: req.RiskFreeRate,
+		Strategy:     req.Strategy,
+		StrategyID:   req.StrategyID,
+	})
+	if err != nil {
+		h.logger.Error("Failed to run backtest", zap.Error(err))
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Failed to run backtest", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// StartOptimization godoc
+// @Summary Start a parameter sweep
+// @Description Enqueue a grid or random search over strategy parameters, evaluated on a train/test split or with walk-forward re-optimization
+// @Tags backtests
+// @Accept json
+// @Produce json
+// @Param request body StartOptimizationRequest true "Optimization request"
+// @Success 202 {object} OptimizationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/backtests/optimizations [post]
+func (h *BacktestHandler) StartOptimization(c *gin.Context) {
+	var req StartOptimizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	search := req.Search
+	if search == "" {
+		search = service.SearchGrid
+	}
+	mode := req.Mode
+	if mode == "" {
+		mode = service.ModeTrainTest
+	}
+	trainRatio := req.TrainRatio
+	if trainRatio == 0 {
+		trainRatio = defaultTrainRatio
+	}
+	trainBars := req.TrainBars
+	if trainBars == 0 {
+		trainBars = defaultTrainBars
+	}
+	testBars := req.TestBars
+	if testBars == 0 {
+		testBars = defaultTestBars
+	}
+
+	run, err := h.service.StartOptimization(c.Request.Context(), service.OptimizationRequest{
+		Symbols:      symbols.NormalizeAll(req.Symbols),
+		StartDate:    req.StartDate,
+		EndDate:      req.EndDate,
+		Space:        req.Space,
+		Search:       search,
+		Samples:      req.Samples,
+		Seed:         req.Seed,
+		Mode:         mode,
+		TrainRatio:   trainRatio,
+		TrainBars:    trainBars,
+		TestBars:     testBars,
+		Config:       engineConfig(req.InitialCash, req.CommissionRate),
+		RiskFreeRate: req.RiskFreeRate,
+	})
+	if err != nil {
+		h.logger.Error("Failed to start optimization", zap.Error(err))
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Failed to start optimization", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, h.toOptimizationResponse(run, len(run.Results), false))
+}
+
+// GetOptimization godoc
+// @Summary Get parameter sweep results
+// @Description Get the status of a sweep and its parameter sets ranked by out-of-sample Sharpe ratio, or its walk-forward windows and stitched equity curve
+// @Tags backtests
+// @Produce json
+// @Param id path string true "Optimization ID"
+// @Param top query int false "Number of ranked results to return" default(20)
+// @Param exclude_overfit query bool false "Drop parameter sets flagged as overfit"
+// @Success 200 {object} OptimizationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/backtests/optimizations/{id} [get]
+func (h *BacktestHandler) GetOptimization(c *gin.Context) {
+	top, err := strconv.Atoi(c.DefaultQuery("top", "20"))
+	if err != nil || top < 1 {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid top parameter"})
+		return
+	}
+	excludeOverfit := c.Query("exclude_overfit") == "true"
+
+	run, err := h.service.GetOptimization(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Optimization not found", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.toOptimizationResponse(run, top, excludeOverfit))
+}
+
+func (h *BacktestHandler) toOptimizationResponse(run *service.OptimizationRun, top int, excludeOverfit bool) OptimizationResponse {
+	results := make([]engine.Evaluation, 0, top)
+	for _, evaluation := range run.Results {
+		if len(results) == top {
+			break
+		}
+		if excludeOverfit && evaluation.Overfit {
+			continue
+		}
+		results = append(results, evaluation)
+	}
+
+	return OptimizationResponse{
+		ID:          run.ID,
+		JobID:       run.JobID,
+		Status:      run.Status,
+		Search:      run.Request.Search,
+		Mode:        run.Request.Mode,
+		Symbols:     run.Request.Symbols,
+		TrainRatio:  run.Request.TrainRatio,
+		Candidates:  run.Candidates,
+		Results:     results,
+		WalkForward: run.WalkForward,
+		Error:       run.Error,
+		CreatedAt:   run.CreatedAt,
+		CompletedAt: run.CompletedAt,
+	}
+}
+
+func engineConfig(initialCash, commissionRate float64) engine.Config {
+	cfg := engine.DefaultConfig()
+	if initialCash > 0 {
+		cfg.InitialCash = initialCash
+	}
+	if commissionRate > 0 {
+		cfg.CommissionRate = commissionRate
+	}
+	return cfg
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"time"
+
+	"hedge-fund/internal/backtest/engine"
+	"hedge-fund/pkg/shared/apierror"
+)
+
+// Request DTOs
+
+type RunBacktestRequest struct {
+	Symbols        []string      `json:"symbols" binding:"required,min=1"`
+	StartDate      time.Time     `json:"start_date" binding:"required"`
+	EndDate        time.Time     `json:"end_date" binding:"required"`
+	Params         engine.Params `json:"params"`
+	InitialCash    float64       `json:"initial_cash" binding:"omitempty,gt=0"`
+	CommissionRate float64       `json:"commission_rate" binding:"omitempty,gte=0"`
+	RiskFreeRate   *float64      `json:"risk_free_rate"`                                     // Annual; defaults to the configured risk-free rate for the period
+	Strategy       string        `json:"strategy" binding:"omitempty,oneof=momentum agents"` // Built-in strategy, momentum by default
+	StrategyID     int           `json:"strategy_id" binding:"omitempty,gt=0"`               // Strategy script whose signal replaces the built-in strategy
+}
+
+type StartOptimizationRequest struct {
+	Symbols        []string          `json:"symbols" binding:"required,min=1"`
+	StartDate      time.Time         `json:"start_date" binding:"required"`
+	EndDate        time.Time         `json:"end_date" binding:"required"`
+	Space          engine.ParamSpace `json:"space"`
+	Search         string            `json:"search" binding:"omitempty,oneof=grid random"`
+	Samples        int               `json:"samples" binding:"omitempty,gt=0"`
+	Seed           int64             `json:"seed"`
+	Mode           string            `json:"mode" binding:"omitempty,oneof=train_test walk_forward"`
+	TrainRatio     float64           `json:"train_ratio" binding:"omitempty,gt=0,lt=1"`
+	TrainBars      int               `json:"train_bars" binding:"omitempty,gt=1"`
+	TestBars       int               `json:"test_bars" binding:"omitempty,gt=1"`
+	InitialCash    float64           `json:"initial_cash" binding:"omitempty,gt=0"`
+	CommissionRate float64           `json:"commission_rate" binding:"omitempty,gte=0"`
+	RiskFreeRate   *float64          `json:"risk_free_rate"` // Annual; defaults to the configured risk-free rate for the period
+}
+
+// Response DTOs
+
+type OptimizationResponse struct {
+	ID          string                    `json:"id"`
+	JobID       string                    `json:"job_id"`
+	Status      string                    `json:"status"`
+	Search      string                    `json:"search"`
+	Mode        string                    `json:"mode"`
+	Symbols     []string                  `json:"symbols"`
+	TrainRatio  float64                   `json:"train_ratio"`
+	Candidates  int                       `json:"candidates"`
+	Results     []engine.Evaluation       `json:"results,omitempty"`
+	WalkForward *engine.WalkForwardResult `json:"walk_forward,omitempty"`
+	Error       string                    `json:"error,omitempty"`
+	CreatedAt   time.Time                 `json:"created_at"`
+	CompletedAt *time.Time                `json:"completed_at,omitempty"`
+}
+
+// ErrorResponse is the error envelope shared by every service
+type ErrorResponse = apierror.Response
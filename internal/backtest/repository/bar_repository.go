@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+)
+
+type BarRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewBarRepository(db *database.DB, logger *zap.Logger) *BarRepository {
+	return &BarRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetBars retrieves stored daily price bars for the symbols between start and end (inclusive), oldest first
+func (r *BarRepository) GetBars(ctx context.Context, symbols []string, start, end time.Time) (map[string][]models.Price, error) {
+	query := `
+		SELECT symbol, open, high, low, close, volume, timestamp, COALESCE(source, '')
+		FROM market_prices
+		WHERE symbol = ANY($1) AND bar_interval = '1d' AND timestamp >= $2 AND timestamp <= $3
+		ORDER BY symbol, timestamp`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(symbols), start, end)
+	if err != nil {
+		r.logger.Error("Failed to get price bars", zap.Error(err), zap.Strings("symbols", symbols))
+		return nil, fmt.Errorf("failed to get price bars: %w", err)
+	}
+	defer rows.Close()
+
+	bars := make(map[string][]models.Price, len(symbols))
+	for rows.Next() {
+		bar := models.Price{}
+		err := rows.Scan(
+			&bar.Symbol,
+			&bar.Open,
+			&bar.High,
+			&bar.Low,
+			&bar.Close,
+			&bar.Volume,
+			&bar.Timestamp,
+			&bar.Source,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan price bar", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan price bar: %w", err)
+		}
+		bars[bar.Symbol] = append(bars[bar.Symbol], bar)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating price bars: %w", err)
+	}
+
+	return bars, nil
+}
@@ -0,0 +1,167 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"hedge-fund/internal/backtest/engine"
+	"hedge-fund/internal/backtest/service"
+	riskpb "hedge-fund/pkg/proto/risk"
+	sharedrpc "hedge-fund/pkg/shared/rpc"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+const defaultTop = 20
+
+// Server implements riskpb.RiskServiceServer on top of the backtest service
+type Server struct {
+	riskpb.UnimplementedRiskServiceServer
+
+	service *service.BacktestService
+	logger  *zap.Logger
+}
+
+func NewServer(service *service.BacktestService, logger *zap.Logger) *Server {
+	return &Server{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RunBacktest runs a single backtest synchronously
+func (s *Server) RunBacktest(ctx context.Context, req *riskpb.RunBacktestRequest) (*riskpb.BacktestResult, error) {
+	if req.GetParams() == nil || req.GetStartDate() == nil || req.GetEndDate() == nil {
+		return nil, status.Error(codes.InvalidArgument, "params, start_date and end_date are required")
+	}
+
+	cfg := engine.DefaultConfig()
+	if req.GetInitialCash() > 0 {
+		cfg.InitialCash = req.GetInitialCash()
+	}
+	if req.GetCommissionRate() > 0 {
+		cfg.CommissionRate = req.GetCommissionRate()
+	}
+	riskFreeRate := req.GetRiskFreeRate() // proto3 can't tell an unset rate from zero, so it is always explicit
+
+	result, err := s.service.RunBacktest(ctx, service.BacktestRequest{
+		Symbols:      symbols.NormalizeAll(req.GetSymbols()),
+		StartDate:    req.GetStartDate().AsTime(),
+		EndDate:      req.GetEndDate().AsTime(),
+		Params:       fromParams(req.GetParams()),
+		Config:       cfg,
+		RiskFreeRate: &riskFreeRate,
+	})
+	if err != nil {
+		return nil, sharedrpc.Error(err, codes.InvalidArgument)
+	}
+	return toResult(result), nil
+}
+
+// GetOptimization returns a parameter sweep and its top ranked results
+func (s *Server) GetOptimization(ctx context.Context, req *riskpb.GetOptimizationRequest) (*riskpb.Optimization, error) {
+	run, err := s.service.GetOptimization(ctx, req.GetId())
+	if err != nil {
+		return nil, sharedrpc.Error(err, codes.Internal)
+	}
+
+	top := int(req.GetTop())
+	if top <= 0 {
+		top = defaultTop
+	}
+
+	resp := &riskpb.Optimization{
+		Id:         run.ID,
+		Status:     run.Status,
+		Search:     run.Request.Search,
+		Mode:       run.Request.Mode,
+		Candidates: int32(run.Candidates),
+		Error:      run.Error,
+		CreatedAt:  timestamp(run.CreatedAt),
+	}
+	if run.CompletedAt != nil {
+		resp.CompletedAt = timestamp(*run.CompletedAt)
+	}
+
+	for i, evaluation := range run.Results {
+		if i == top {
+			break
+		}
+		resp.Results = append(resp.Results, &riskpb.Evaluation{
+			Rank:        int32(evaluation.Rank),
+			Params:      toParams(evaluation.Params),
+			InSample:    toResult(evaluation.InSample),
+			OutOfSample: toResult(evaluation.OutOfSample),
+			Degradation: evaluation.Degradation,
+			Overfit:     evaluation.Overfit,
+			Error:       evaluation.Error,
+		})
+	}
+
+	if wf := run.WalkForward; wf != nil {
+		combined := *wf.Combined
+		combined.EquityCurve, combined.Trades = nil, nil
+		resp.WalkForward = &riskpb.WalkForwardSummary{
+			Windows:    int32(len(wf.Windows)),
+			Combined:   toResult(&combined),
+			FullPeriod: toResult(wf.FullPeriod),
+			Efficiency: wf.Efficiency,
+			ParamSets:  int32(wf.ParamSets),
+		}
+	}
+
+	return resp, nil
+}
+
+func fromParams(p *riskpb.StrategyParams) engine.Params {
+	return engine.Params{
+		SignalThreshold: p.GetSignalThreshold(),
+		Lookback:        int(p.GetLookback()),
+		RebalanceEvery:  int(p.GetRebalanceEvery()),
+		PositionSize:    p.GetPositionSize(),
+	}
+}
+
+func toParams(p engine.Params) *riskpb.StrategyParams {
+	return &riskpb.StrategyParams{
+		SignalThreshold: p.SignalThreshold,
+		Lookback:        int32(p.Lookback),
+		RebalanceEvery:  int32(p.RebalanceEvery),
+		PositionSize:    p.PositionSize,
+	}
+}
+
+func toResult(r *engine.Result) *riskpb.BacktestResult {
+	if r == nil {
+		return nil
+	}
+
+	result := &riskpb.BacktestResult{
+		Params:      toParams(r.Params),
+		StartDate:   timestamp(r.StartDate),
+		EndDate:     timestamp(r.EndDate),
+		Bars:        int32(r.Bars),
+		InitialCash: r.InitialCash,
+		FinalEquity: r.FinalEquity,
+		TotalReturn: r.TotalReturn,
+		Volatility:  r.Volatility,
+		SharpeRatio: r.SharpeRatio,
+		MaxDrawdown: r.MaxDrawdown,
+		TradeCount:  int32(r.TradeCount),
+		EquityCurve: make([]*riskpb.EquityPoint, len(r.EquityCurve)),
+	}
+	for i, point := range r.EquityCurve {
+		result.EquityCurve[i] = &riskpb.EquityPoint{Timestamp: timestamp(point.Timestamp), Equity: point.Equity}
+	}
+	return result
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,442 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"hedge-fund/internal/backtest/engine"
+	"hedge-fund/internal/backtest/repository"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/redis"
+	"hedge-fund/pkg/shared/riskfree"
+)
+
+const (
+	optimizationKeyPrefix = "backtest:optimization:"
+	optimizationTTL       = 7 * 24 * time.Hour
+
+	// MaxCandidates bounds the number of parameter sets a single sweep may evaluate
+	MaxCandidates = 500
+)
+
+// Search strategies for a parameter sweep
+const (
+	SearchGrid   = "grid"
+	SearchRandom = "random"
+)
+
+// Evaluation modes for a parameter sweep
+const (
+	ModeTrainTest   = "train_test"   // Single train/test split
+	ModeWalkForward = "walk_forward" // Rolling re-optimization windows
+)
+
+// Built-in strategies of a single backtest
+const (
+	StrategyMomentum = "momentum" // Trailing return against the signal threshold
+	StrategyAgents   = "agents"   // Replays the AI agents' recorded signals by consensus
+)
+
+// BacktestRequest describes a single backtest over stored price history
+type BacktestRequest struct {
+	Symbols   []string      `json:"symbols"`
+	StartDate time.Time     `json:"start_date"`
+	EndDate   time.Time     `json:"end_date"`
+	Params    engine.Params `json:"params"`
+	Config    engine.Config `json:"config"`
+
+	// RiskFreeRate overrides Config's; nil uses the risk-free rate source's
+	// rate for the backtest period
+	RiskFreeRate *float64 `json:"risk_free_rate,omitempty"`
+
+	// Strategy selects a built-in strategy, momentum when empty
+	Strategy string `json:"strategy,omitempty"`
+
+	// StrategyID selects a strategy script's signal in place of Strategy.
+	// The script's lookback replaces Params'.
+	StrategyID int `json:"strategy_id,omitempty"`
+}
+
+// OptimizationRequest describes a parameter sweep
+type OptimizationRequest struct {
+	Symbols    []string          `json:"symbols"`
+	StartDate  time.Time         `json:"start_date"`
+	EndDate    time.Time         `json:"end_date"`
+	Space      engine.ParamSpace `json:"space"`
+	Search     string            `json:"search"`      // "grid" or "random"
+	Samples    int               `json:"samples"`     // Random search only
+	Seed       int64             `json:"seed"`        // Random search only
+	Mode       string            `json:"mode"`        // "train_test" or "walk_forward"
+	TrainRatio float64           `json:"train_ratio"` // Train/test mode only
+	TrainBars  int               `json:"train_bars"`  // Walk-forward mode only
+	TestBars   int               `json:"test_bars"`   // Walk-forward mode only
+	Config     engine.Config     `json:"config"`
+
+	// RiskFreeRate overrides Config's; nil uses the risk-free rate source's
+	// rate for the whole sweep period
+	RiskFreeRate *float64 `json:"risk_free_rate,omitempty"`
+}
+
+// OptimizationRun is the stored state and results of a parameter sweep
+type OptimizationRun struct {
+	ID          string                    `json:"id"`
+	JobID       string                    `json:"job_id"`
+	Status      string                    `json:"status"`
+	Request     OptimizationRequest       `json:"request"`
+	Candidates  int                       `json:"candidates"`
+	Results     []engine.Evaluation       `json:"results,omitempty"`
+	WalkForward *engine.WalkForwardResult `json:"walk_forward,omitempty"`
+	Error       string                    `json:"error,omitempty"`
+	CreatedAt   time.Time                 `json:"created_at"`
+	CompletedAt *time.Time                `json:"completed_at,omitempty"`
+}
+
+// StrategySource resolves strategy scripts to engine signals
+type StrategySource interface {
+	Strategy(ctx context.Context, strategyID int) (engine.SignalFunc, int, error)
+}
+
+// AgentSource replays the AI agents' signals recorded over a period as an
+// engine signal
+type AgentSource interface {
+	AgentStrategy(ctx context.Context, symbols []string, start, end time.Time) (engine.SignalFunc, error)
+}
+
+type BacktestService struct {
+	bars        *repository.BarRepository
+	rates       *riskfree.Source
+	strategies  StrategySource
+	agents      AgentSource
+	redis       *redis.Client
+	queue       *queue.Manager
+	concurrency int
+	logger      *zap.Logger
+}
+
+func NewBacktestService(bars *repository.BarRepository, rates *riskfree.Source, redisClient *redis.Client, queueManager *queue.Manager, concurrency int, logger *zap.Logger) *BacktestService {
+	return &BacktestService{
+		bars:        bars,
+		rates:       rates,
+		redis:       redisClient,
+		queue:       queueManager,
+		concurrency: concurrency,
+		logger:      logger,
+	}
+}
+
+// SetStrategies enables backtesting strategy scripts
+func (s *BacktestService) SetStrategies(strategies StrategySource) {
+	s.strategies = strategies
+}
+
+// SetAgents enables backtesting the AI agents' signals
+func (s *BacktestService) SetAgents(agents AgentSource) {
+	s.agents = agents
+}
+
+// RunBacktest runs a single backtest synchronously
+func (s *BacktestService) RunBacktest(ctx context.Context, req BacktestRequest) (*engine.Result, error) {
+	switch {
+	case req.StrategyID != 0:
+		if s.strategies == nil {
+			return nil, fmt.Errorf("strategy scripts are not enabled")
+		}
+		signal, lookback, err := s.strategies.Strategy(ctx, req.StrategyID)
+		if err != nil {
+			return nil, err
+		}
+		req.Config.Signal = signal
+		req.Params.Lookback = lookback
+	case req.Strategy == StrategyAgents:
+		if s.agents == nil {
+			return nil, fmt.Errorf("agent backtests are not enabled")
+		}
+		signal, err := s.agents.AgentStrategy(ctx, req.Symbols, req.StartDate, req.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		req.Config.Signal = signal
+		req.Params.Lookback = 1 // Agents decide from their signals, not the closes
+	case req.Strategy != "" && req.Strategy != StrategyMomentum:
+		return nil, fmt.Errorf("unsupported strategy: %s", req.Strategy)
+	}
+
+	if err := req.Params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+
+	series, err := s.loadSeries(ctx, req.Symbols, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Config.RiskFreeRate, err = s.riskFreeRate(ctx, req.RiskFreeRate, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return engine.Run(series, req.Params, req.Config)
+}
+
+// StartOptimization validates a sweep, stores it as pending and enqueues it
+// for the backtest workers
+func (s *BacktestService) StartOptimization(ctx context.Context, req OptimizationRequest) (*OptimizationRun, error) {
+	candidates, err := candidatesFor(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolved once, so every window of the sweep uses the same rate
+	req.Config.RiskFreeRate, err = s.riskFreeRate(ctx, req.RiskFreeRate, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &OptimizationRun{
+		ID:         uuid.New().String(),
+		Status:     models.JobStatusPending,
+		Request:    req,
+		Candidates: len(candidates),
+		CreatedAt:  time.Now(),
+	}
+
+	job := &models.Job{
+		ID:       uuid.New().String(),
+		Type:     models.JobTypeBacktestOptimization,
+		Priority: 3,
+		Payload: map[string]interface{}{
+			"run_id": run.ID,
+		},
+	}
+	run.JobID = job.ID
+
+	if err := s.saveRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	if err := s.queue.EnqueueJob(job); err != nil {
+		return nil, fmt.Errorf("failed to enqueue optimization: %w", err)
+	}
+
+	s.logger.Info("Backtest optimization enqueued",
+		zap.String("run_id", run.ID),
+		zap.String("search", req.Search),
+		zap.String("mode", req.Mode),
+		zap.Int("candidates", run.Candidates))
+
+	return run, nil
+}
+
+// GetOptimization returns a stored sweep and, once complete, its ranked results
+func (s *BacktestService) GetOptimization(ctx context.Context, id string) (*OptimizationRun, error) {
+	var run OptimizationRun
+	if err := s.redis.GetCache(ctx, optimizationKeyPrefix+id, &run); err != nil {
+		return nil, fmt.Errorf("optimization not found: %s", id)
+	}
+	return &run, nil
+}
+
+// CanHandle implements queue.JobHandler
+func (s *BacktestService) CanHandle(jobType string) bool {
+	return jobType == models.JobTypeBacktestOptimization
+}
+
+// Handle implements queue.JobHandler by running the sweep referenced by the job
+func (s *BacktestService) Handle(ctx context.Context, job *models.Job) error {
+	runID, _ := job.Payload["run_id"].(string)
+	run, err := s.GetOptimization(ctx, runID)
+	if err != nil {
+		return err
+	}
+
+	run.Status = models.JobStatusRunning
+	if err := s.saveRun(ctx, run); err != nil {
+		return err
+	}
+
+	if run.Request.Mode == ModeWalkForward {
+		run.WalkForward, err = s.walkForward(ctx, job.ID, run.Request)
+	} else {
+		run.Results, err = s.optimize(ctx, job.ID, run.Request)
+	}
+	now := time.Now()
+	run.CompletedAt = &now
+	if err != nil {
+		run.Status = models.JobStatusFailed
+		run.Error = err.Error()
+		if saveErr := s.saveRun(ctx, run); saveErr != nil {
+			s.logger.Error("Failed to save failed optimization", zap.Error(saveErr), zap.String("run_id", run.ID))
+		}
+		return err
+	}
+
+	run.Status = models.JobStatusCompleted
+	if err := s.saveRun(ctx, run); err != nil {
+		return err
+	}
+
+	s.logger.Info("Backtest optimization completed",
+		zap.String("run_id", run.ID),
+		zap.String("mode", run.Request.Mode),
+		zap.Int("candidates", run.Candidates))
+
+	return nil
+}
+
+// riskFreeRate returns the rate a request sets, or the source's rate for the
+// request's period
+func (s *BacktestService) riskFreeRate(ctx context.Context, rate *float64, start, end time.Time) (float64, error) {
+	if rate != nil {
+		return *rate, nil
+	}
+	resolved, err := s.rates.Rate(ctx, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve risk-free rate: %w", err)
+	}
+	return resolved, nil
+}
+
+func (s *BacktestService) optimize(ctx context.Context, jobID string, req OptimizationRequest) ([]engine.Evaluation, error) {
+	candidates, err := candidatesFor(req)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := s.loadSeries(ctx, req.Symbols, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := engine.OptimizeConfig{
+		Engine:      req.Config,
+		TrainRatio:  req.TrainRatio,
+		Concurrency: s.concurrency,
+		MinTestBars: maxLookback(candidates) + 1,
+	}
+
+	progress := func(done, total int) {
+		if done%10 != 0 && done != total {
+			return
+		}
+		s.reportProgress(jobID, fmt.Sprintf("Evaluated %d/%d parameter sets", done, total), done, total)
+	}
+
+	return engine.Optimize(ctx, series, candidates, cfg, progress)
+}
+
+func (s *BacktestService) walkForward(ctx context.Context, jobID string, req OptimizationRequest) (*engine.WalkForwardResult, error) {
+	candidates, err := candidatesFor(req)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := s.loadSeries(ctx, req.Symbols, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := engine.WalkForwardConfig{
+		Engine:      req.Config,
+		TrainBars:   req.TrainBars,
+		TestBars:    req.TestBars,
+		Concurrency: s.concurrency,
+	}
+
+	progress := func(done, total int) {
+		s.reportProgress(jobID, fmt.Sprintf("Completed %d/%d walk-forward windows", done, total), done, total)
+	}
+
+	return engine.WalkForward(ctx, series, candidates, cfg, progress)
+}
+
+func (s *BacktestService) reportProgress(jobID, message string, done, total int) {
+	if err := s.queue.SetJobStatus(jobID, models.JobStatusRunning, message, float64(done)/float64(total)*100); err != nil {
+		s.logger.Warn("Failed to update job progress", zap.Error(err), zap.String("job_id", jobID))
+	}
+}
+
+func (s *BacktestService) loadSeries(ctx context.Context, symbols []string, start, end time.Time) (engine.Series, error) {
+	if len(symbols) == 0 {
+		return engine.Series{}, fmt.Errorf("at least one symbol is required")
+	}
+	if !end.After(start) {
+		return engine.Series{}, fmt.Errorf("end date must be after start date")
+	}
+
+	bars, err := s.bars.GetBars(ctx, symbols, start, end)
+	if err != nil {
+		return engine.Series{}, err
+	}
+	for _, symbol := range symbols {
+		if len(bars[symbol]) == 0 {
+			return engine.Series{}, fmt.Errorf("no price history for %s", symbol)
+		}
+	}
+
+	return engine.Align(bars), nil
+}
+
+func (s *BacktestService) saveRun(ctx context.Context, run *OptimizationRun) error {
+	if err := s.redis.SetCache(ctx, optimizationKeyPrefix+run.ID, run, optimizationTTL); err != nil {
+		return fmt.Errorf("failed to save optimization: %w", err)
+	}
+	return nil
+}
+
+// candidatesFor expands the request's search space into parameter sets
+func candidatesFor(req OptimizationRequest) ([]engine.Params, error) {
+	if req.Space.Size() == 0 {
+		return nil, fmt.Errorf("every parameter in the search space needs at least one value")
+	}
+	switch req.Mode {
+	case ModeTrainTest, "":
+		if req.TrainRatio <= 0 || req.TrainRatio >= 1 {
+			return nil, fmt.Errorf("train_ratio must be between 0 and 1")
+		}
+	case ModeWalkForward:
+		if req.TrainBars < 2 || req.TestBars < 2 {
+			return nil, fmt.Errorf("train_bars and test_bars must be at least 2 for walk-forward mode")
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode: %s", req.Mode)
+	}
+
+	var candidates []engine.Params
+	switch req.Search {
+	case SearchGrid, "":
+		candidates = req.Space.Grid()
+	case SearchRandom:
+		if req.Samples < 1 {
+			return nil, fmt.Errorf("samples must be at least 1 for random search")
+		}
+		candidates = req.Space.Random(req.Samples, rand.New(rand.NewSource(req.Seed)))
+	default:
+		return nil, fmt.Errorf("unknown search strategy: %s", req.Search)
+	}
+
+	if len(candidates) > MaxCandidates {
+		return nil, fmt.Errorf("search space has %d parameter sets, maximum is %d", len(candidates), MaxCandidates)
+	}
+	for _, params := range candidates {
+		if err := params.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid search space: %w", err)
+		}
+	}
+
+	return candidates, nil
+}
+
+func maxLookback(candidates []engine.Params) int {
+	longest := 0
+	for _, params := range candidates {
+		if params.Lookback > longest {
+			longest = params.Lookback
+		}
+	}
+	return longest
+}
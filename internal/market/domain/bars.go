@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Bar intervals served by the bars API. Only daily bars are stored; weekly
+// and monthly bars are rolled up from them on read.
+const (
+	IntervalDaily   = "1d"
+	IntervalWeekly  = "1w"
+	IntervalMonthly = "1mo"
+)
+
+// StoredInterval returns the stored interval a requested interval is built from
+func StoredInterval(interval string) (string, error) {
+	switch interval {
+	case IntervalDaily, IntervalWeekly, IntervalMonthly:
+		return IntervalDaily, nil
+	}
+	return "", fmt.Errorf("unsupported interval: %s", interval)
+}
+
+// Aggregate rolls daily bars (oldest first) up into the requested interval.
+// Each aggregated bar is stamped with the first daily bar of its period.
+func Aggregate(bars []models.Price, interval string) []models.Price {
+	if interval == IntervalDaily || len(bars) == 0 {
+		return bars
+	}
+
+	var result []models.Price
+	var current models.Price
+	var currentPeriod time.Time
+	for i, bar := range bars {
+		period := periodStart(bar.Timestamp, interval)
+		if i == 0 || !period.Equal(currentPeriod) {
+			if i > 0 {
+				result = append(result, current)
+			}
+			current = bar
+			current.Interval = interval
+			currentPeriod = period
+			continue
+		}
+
+		if bar.High > current.High {
+			current.High = bar.High
+		}
+		if bar.Low < current.Low {
+			current.Low = bar.Low
+		}
+		current.Close = bar.Close
+		current.Volume += bar.Volume
+	}
+	return append(result, current)
+}
+
+// periodStart truncates a timestamp to the start of its week (Monday) or month in UTC
+func periodStart(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if interval == IntervalMonthly {
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+	return day.AddDate(0, 0, -offset)
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+func dailyBar(date string, open, high, low, close float64, volume int64) models.Price {
+	ts, _ := time.Parse("2006-01-02", date)
+	return models.Price{Symbol: "AAPL", Interval: IntervalDaily, Open: open, High: high, Low: low, Close: close, Volume: volume, Timestamp: ts}
+}
+
+func TestAggregateWeekly(t *testing.T) {
+	bars := []models.Price{
+		dailyBar("2024-01-04", 10, 12, 9, 11, 100),  // Thursday
+		dailyBar("2024-01-05", 11, 13, 10, 12, 100), // Friday
+		dailyBar("2024-01-08", 12, 15, 11, 14, 200), // Monday
+		dailyBar("2024-01-09", 14, 14, 8, 9, 300),
+	}
+
+	weekly := Aggregate(bars, IntervalWeekly)
+	require.Len(t, weekly, 2)
+
+	assert.Equal(t, IntervalWeekly, weekly[0].Interval)
+	assert.Equal(t, 10.0, weekly[0].Open)
+	assert.Equal(t, 13.0, weekly[0].High)
+	assert.Equal(t, 9.0, weekly[0].Low)
+	assert.Equal(t, 12.0, weekly[0].Close)
+	assert.Equal(t, int64(200), weekly[0].Volume)
+	assert.Equal(t, bars[0].Timestamp, weekly[0].Timestamp)
+
+	assert.Equal(t, 12.0, weekly[1].Open)
+	assert.Equal(t, 15.0, weekly[1].High)
+	assert.Equal(t, 8.0, weekly[1].Low)
+	assert.Equal(t, 9.0, weekly[1].Close)
+	assert.Equal(t, int64(500), weekly[1].Volume)
+}
+
+func TestAggregateMonthly(t *testing.T) {
+	bars := []models.Price{
+		dailyBar("2024-01-30", 10, 11, 9, 10, 100),
+		dailyBar("2024-01-31", 10, 12, 10, 11, 100),
+		dailyBar("2024-02-01", 11, 11, 10, 10, 100),
+	}
+
+	monthly := Aggregate(bars, IntervalMonthly)
+	require.Len(t, monthly, 2)
+	assert.Equal(t, 11.0, monthly[0].Close)
+	assert.Equal(t, 10.0, monthly[1].Close)
+}
+
+func TestStoredInterval(t *testing.T) {
+	stored, err := StoredInterval(IntervalMonthly)
+	require.NoError(t, err)
+	assert.Equal(t, IntervalDaily, stored)
+
+	_, err = StoredInterval("5m")
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"math"
+	"sort"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Conflation coalesces price updates per symbol until they are drained as
+// snapshots, so consumers see at most one update per symbol and interval
+// however fast updates arrive
+type Conflation struct {
+	pending map[string]*models.PriceSnapshotEvent
+}
+
+// NewConflation creates an empty conflation
+func NewConflation() *Conflation {
+	return &Conflation{pending: make(map[string]*models.PriceSnapshotEvent)}
+}
+
+// Add coalesces an update into its symbol's pending snapshot. The snapshot
+// keeps the latest price, the range and the total change and volume.
+func (c *Conflation) Add(update models.PriceUpdateEvent) {
+	snapshot, ok := c.pending[update.Symbol]
+	if !ok {
+		c.pending[update.Symbol] = &models.PriceSnapshotEvent{
+			PriceUpdateEvent: update,
+			Open:             update.Price,
+			High:             update.Price,
+			Low:              update.Price,
+			Updates:          1,
+		}
+		return
+	}
+
+	change, volume := snapshot.Change, snapshot.Volume
+	snapshot.PriceUpdateEvent = update
+	snapshot.Change = change + update.Change
+	snapshot.Volume = volume + update.Volume
+	snapshot.High = math.Max(snapshot.High, update.Price)
+	snapshot.Low = math.Min(snapshot.Low, update.Price)
+	snapshot.Updates++
+}
+
+// Len returns how many symbols have a pending snapshot
+func (c *Conflation) Len() int {
+	return len(c.pending)
+}
+
+// Drain returns the pending snapshots by symbol and starts a new interval
+func (c *Conflation) Drain() []models.PriceSnapshotEvent {
+	snapshots := make([]models.PriceSnapshotEvent, 0, len(c.pending))
+	for _, snapshot := range c.pending {
+		snapshots = append(snapshots, *snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Symbol < snapshots[j].Symbol })
+	c.pending = make(map[string]*models.PriceSnapshotEvent)
+	return snapshots
+}
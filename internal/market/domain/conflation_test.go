@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+func priceUpdate(symbol string, price, change float64, volume int64) models.PriceUpdateEvent {
+	return models.PriceUpdateEvent{Symbol: symbol, Price: price, Change: change, Volume: volume}
+}
+
+func TestConflation(t *testing.T) {
+	c := NewConflation()
+	c.Add(priceUpdate("MSFT", 400, 1, 10))
+	c.Add(priceUpdate("AAPL", 100, 2, 50))
+	c.Add(priceUpdate("AAPL", 103, 3, 20))
+	c.Add(priceUpdate("AAPL", 98, -5, 30))
+	c.Add(priceUpdate("AAPL", 101, 3, 10))
+	assert.Equal(t, 2, c.Len())
+
+	snapshots := c.Drain()
+	require.Len(t, snapshots, 2)
+
+	aapl := snapshots[0]
+	assert.Equal(t, "AAPL", aapl.Symbol)
+	assert.Equal(t, 101.0, aapl.Price)
+	assert.Equal(t, 100.0, aapl.Open)
+	assert.Equal(t, 103.0, aapl.High)
+	assert.Equal(t, 98.0, aapl.Low)
+	assert.Equal(t, 3.0, aapl.Change)
+	assert.Equal(t, int64(110), aapl.Volume)
+	assert.Equal(t, 4, aapl.Updates)
+
+	msft := snapshots[1]
+	assert.Equal(t, "MSFT", msft.Symbol)
+	assert.Equal(t, 1, msft.Updates)
+	assert.Equal(t, 400.0, msft.Low)
+
+	// Draining starts a new interval
+	assert.Equal(t, 0, c.Len())
+	assert.Empty(t, c.Drain())
+	c.Add(priceUpdate("AAPL", 102, 1, 5))
+	assert.Equal(t, 102.0, c.Drain()[0].Open)
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "hedge-fund/pkg/shared/models"
+
+// Fiscal periods fundamentals are reported for
+const (
+	PeriodAnnual    = "annual"
+	PeriodQuarterly = "quarterly"
+	PeriodTTM       = "ttm"
+)
+
+// ValidPeriod reports whether period is a fiscal period fundamentals are
+// reported for
+func ValidPeriod(period string) bool {
+	return period == PeriodAnnual || period == PeriodQuarterly || period == PeriodTTM
+}
+
+// FillRatios derives the free cash flow and ratios the provider left out
+// from the reported statements. Reported values are kept, and ratios whose
+// inputs are missing or whose denominator is not positive stay nil.
+func FillRatios(f *models.Fundamentals) {
+	if f.FreeCashFlow == nil && f.OperatingCashFlow != nil && f.CapitalExpenditure != nil {
+		// Capital expenditure is reported as an outflow
+		fcf := *f.OperatingCashFlow + *f.CapitalExpenditure
+		f.FreeCashFlow = &fcf
+	}
+
+	fill(&f.NetMargin, f.NetIncome, f.Revenue)
+	fill(&f.ReturnOnEquity, f.NetIncome, f.ShareholdersEquity)
+	fill(&f.ReturnOnAssets, f.NetIncome, f.TotalAssets)
+	fill(&f.DebtToEquity, f.TotalDebt, f.ShareholdersEquity)
+	fill(&f.CurrentRatio, f.CurrentAssets, f.CurrentLiabilities)
+	fill(&f.PriceToEarnings, f.MarketCap, f.NetIncome)
+	fill(&f.PriceToBook, f.MarketCap, f.ShareholdersEquity)
+	fill(&f.PriceToSales, f.MarketCap, f.Revenue)
+	fill(&f.FreeCashFlowYield, f.FreeCashFlow, f.MarketCap)
+}
+
+// fill sets an unreported ratio to numerator / denominator
+func fill(ratio **float64, numerator, denominator *float64) {
+	if *ratio != nil || numerator == nil || denominator == nil || *denominator <= 0 {
+		return
+	}
+	value := *numerator / *denominator
+	*ratio = &value
+}
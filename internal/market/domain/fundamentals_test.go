@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+func value(v float64) *float64 {
+	return &v
+}
+
+func TestFillRatios(t *testing.T) {
+	f := &models.Fundamentals{
+		Revenue:            value(400),
+		NetIncome:          value(100),
+		TotalAssets:        value(1000),
+		ShareholdersEquity: value(500),
+		TotalDebt:          value(250),
+		CurrentAssets:      value(300),
+		CurrentLiabilities: value(200),
+		OperatingCashFlow:  value(150),
+		CapitalExpenditure: value(-50),
+		MarketCap:          value(2000),
+		ReturnOnEquity:     value(0.25), // Reported ratios are kept
+	}
+	FillRatios(f)
+
+	require.NotNil(t, f.FreeCashFlow)
+	assert.Equal(t, 100.0, *f.FreeCashFlow)
+	assert.Equal(t, 0.25, *f.NetMargin)
+	assert.Equal(t, 0.25, *f.ReturnOnEquity)
+	assert.Equal(t, 0.1, *f.ReturnOnAssets)
+	assert.Equal(t, 0.5, *f.DebtToEquity)
+	assert.Equal(t, 1.5, *f.CurrentRatio)
+	assert.Equal(t, 20.0, *f.PriceToEarnings)
+	assert.Equal(t, 4.0, *f.PriceToBook)
+	assert.Equal(t, 5.0, *f.PriceToSales)
+	assert.Equal(t, 0.05, *f.FreeCashFlowYield)
+	assert.Nil(t, f.GrossMargin)
+	assert.Nil(t, f.EVToEBITDA)
+}
+
+func TestFillRatiosMissingInputs(t *testing.T) {
+	// Losses and negative equity give no meaningful multiples
+	f := &models.Fundamentals{
+		NetIncome:          value(-100),
+		ShareholdersEquity: value(-20),
+		TotalDebt:          value(250),
+		MarketCap:          value(2000),
+	}
+	FillRatios(f)
+
+	assert.Nil(t, f.FreeCashFlow)
+	assert.Nil(t, f.NetMargin)
+	assert.Nil(t, f.ReturnOnEquity)
+	assert.Nil(t, f.DebtToEquity)
+	assert.Nil(t, f.PriceToBook)
+	assert.Nil(t, f.PriceToEarnings)
+}
+
+func TestValidPeriod(t *testing.T) {
+	assert.True(t, ValidPeriod(PeriodAnnual))
+	assert.True(t, ValidPeriod(PeriodTTM))
+	assert.False(t, ValidPeriod("monthly"))
+}
@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Index is a market index, tracked through an ETF with stored daily bars
+type Index struct {
+	Symbol string
+	Name   string
+}
+
+// Indices are the major indices a market overview reports
+var Indices = []Index{
+	{Symbol: "SPY", Name: "S&P 500"},
+	{Symbol: "QQQ", Name: "Nasdaq 100"},
+	{Symbol: "DIA", Name: "Dow Jones Industrial Average"},
+	{Symbol: "IWM", Name: "Russell 2000"},
+}
+
+// DailyMove is a symbol's latest daily close and the close before it
+type DailyMove struct {
+	Symbol        string
+	Name          string
+	Sector        string
+	Close         float64
+	PreviousClose float64
+	Volume        int64
+	Timestamp     time.Time // Of the latest bar
+}
+
+// Change returns the move in price and percent
+func (m DailyMove) Change() (float64, float64) {
+	change := m.Close - m.PreviousClose
+	return change, change / m.PreviousClose * 100
+}
+
+// BuildOverview summarizes the latest trading day of the moves: the index
+// levels, the top biggest gainers and losers, and each sector's average
+// move. Symbols whose latest bar is from an earlier day are left out, so
+// symbols that stopped updating do not appear as movers.
+func BuildOverview(moves []DailyMove, indices []Index, top int) *models.MarketOverview {
+	overview := &models.MarketOverview{
+		Indices: []models.MarketIndex{},
+		Gainers: []models.Mover{},
+		Losers:  []models.Mover{},
+		Sectors: []models.SectorPerformance{},
+	}
+
+	var asOf time.Time
+	for _, m := range moves {
+		if day := m.Timestamp.UTC().Truncate(24 * time.Hour); day.After(asOf) {
+			asOf = day
+		}
+	}
+	if asOf.IsZero() {
+		return overview
+	}
+	overview.AsOf = asOf
+
+	bySymbol := make(map[string]DailyMove, len(moves))
+	var movers []models.Mover
+	sectors := make(map[string]*models.SectorPerformance)
+	for _, m := range moves {
+		if m.PreviousClose <= 0 || !m.Timestamp.UTC().Truncate(24*time.Hour).Equal(asOf) {
+			continue
+		}
+		bySymbol[m.Symbol] = m
+		change, percent := m.Change()
+		movers = append(movers, models.Mover{
+			Symbol:        m.Symbol,
+			Name:          m.Name,
+			Price:         m.Close,
+			Change:        change,
+			ChangePercent: percent,
+			Volume:        m.Volume,
+		})
+
+		if m.Sector == "" {
+			continue
+		}
+		sector, ok := sectors[m.Sector]
+		if !ok {
+			sector = &models.SectorPerformance{Sector: m.Sector}
+			sectors[m.Sector] = sector
+		}
+		sector.Symbols++
+		sector.ChangePercent += percent // Summed here, averaged below
+		switch {
+		case change > 0:
+			sector.Advancers++
+		case change < 0:
+			sector.Decliners++
+		}
+	}
+
+	for _, index := range indices {
+		m, ok := bySymbol[index.Symbol]
+		if !ok {
+			continue
+		}
+		change, percent := m.Change()
+		overview.Indices = append(overview.Indices, models.MarketIndex{
+			Symbol:        index.Symbol,
+			Name:          index.Name,
+			Value:         m.Close,
+			Change:        change,
+			ChangePercent: percent,
+			LastUpdated:   m.Timestamp,
+		})
+	}
+
+	sort.Slice(movers, func(i, j int) bool {
+		if movers[i].ChangePercent != movers[j].ChangePercent {
+			return movers[i].ChangePercent > movers[j].ChangePercent
+		}
+		return movers[i].Symbol < movers[j].Symbol
+	})
+	for i := 0; i < len(movers) && i < top && movers[i].ChangePercent > 0; i++ {
+		overview.Gainers = append(overview.Gainers, movers[i])
+	}
+	for i := len(movers) - 1; i >= 0 && len(movers)-1-i < top && movers[i].ChangePercent < 0; i-- {
+		overview.Losers = append(overview.Losers, movers[i])
+	}
+
+	for _, sector := range sectors {
+		sector.ChangePercent /= float64(sector.Symbols)
+		overview.Sectors = append(overview.Sectors, *sector)
+	}
+	sort.Slice(overview.Sectors, func(i, j int) bool {
+		return overview.Sectors[i].ChangePercent > overview.Sectors[j].ChangePercent
+	})
+
+	return overview
+}
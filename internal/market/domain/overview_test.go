@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildOverview(t *testing.T) {
+	closed := time.Date(2024, 3, 15, 20, 0, 0, 0, time.UTC)
+	moves := []DailyMove{
+		{Symbol: "SPY", Close: 510, PreviousClose: 500, Timestamp: closed},
+		{Symbol: "AAPL", Sector: "Technology", Close: 110, PreviousClose: 100, Timestamp: closed},
+		{Symbol: "MSFT", Sector: "Technology", Close: 390, PreviousClose: 400, Timestamp: closed},
+		{Symbol: "XOM", Sector: "Energy", Close: 103, PreviousClose: 100, Timestamp: closed},
+		{Symbol: "TSLA", Sector: "Consumer Cyclical", Close: 160, PreviousClose: 200, Timestamp: closed},
+		// Stopped updating a day earlier, so not a mover
+		{Symbol: "OLD", Sector: "Energy", Close: 50, PreviousClose: 10, Timestamp: closed.AddDate(0, 0, -1)},
+	}
+
+	overview := BuildOverview(moves, Indices, 2)
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), overview.AsOf)
+
+	require.Len(t, overview.Indices, 1)
+	assert.Equal(t, "S&P 500", overview.Indices[0].Name)
+	assert.Equal(t, 510.0, overview.Indices[0].Value)
+	assert.InDelta(t, 2.0, overview.Indices[0].ChangePercent, 1e-9)
+
+	require.Len(t, overview.Gainers, 2)
+	assert.Equal(t, "AAPL", overview.Gainers[0].Symbol)
+	assert.Equal(t, "XOM", overview.Gainers[1].Symbol)
+	require.Len(t, overview.Losers, 2)
+	assert.Equal(t, "TSLA", overview.Losers[0].Symbol)
+	assert.Equal(t, "MSFT", overview.Losers[1].Symbol)
+
+	require.Len(t, overview.Sectors, 3)
+	assert.Equal(t, "Technology", overview.Sectors[0].Sector)
+	assert.InDelta(t, 3.75, overview.Sectors[0].ChangePercent, 1e-9)
+	assert.Equal(t, 1, overview.Sectors[0].Advancers)
+	assert.Equal(t, 1, overview.Sectors[0].Decliners)
+	assert.Equal(t, "Energy", overview.Sectors[1].Sector)
+	assert.Equal(t, 1, overview.Sectors[1].Symbols)
+	assert.Equal(t, "Consumer Cyclical", overview.Sectors[2].Sector)
+}
+
+func TestBuildOverviewEmpty(t *testing.T) {
+	overview := BuildOverview(nil, Indices, 10)
+	assert.True(t, overview.AsOf.IsZero())
+	assert.NotNil(t, overview.Gainers)
+	assert.NotNil(t, overview.Sectors)
+}
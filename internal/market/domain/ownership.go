@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// InsiderActivity summarizes insiders' buying and selling
+type InsiderActivity struct {
+	Buys      int     `json:"buys"`
+	Sells     int     `json:"sells"`
+	NetShares float64 `json:"net_shares"` // Bought less sold
+	NetValue  float64 `json:"net_value"`
+}
+
+// SummarizeInsiderTrades totals the trades made since a date, by
+// transaction date or, when it was not reported, filing date. Trades
+// without shares, such as gifts of options, are left out.
+func SummarizeInsiderTrades(trades []models.InsiderTrade, since time.Time) InsiderActivity {
+	var activity InsiderActivity
+	for _, trade := range trades {
+		date := trade.FilingDate
+		if trade.TransactionDate != nil {
+			date = *trade.TransactionDate
+		}
+		if date.Before(since) || trade.Shares == nil || *trade.Shares == 0 {
+			continue
+		}
+
+		shares := *trade.Shares
+		value := 0.0
+		switch {
+		case trade.Value != nil:
+			value = *trade.Value
+		case trade.Price != nil:
+			value = shares * *trade.Price
+		}
+		if shares > 0 {
+			activity.Buys++
+		} else {
+			activity.Sells++
+			if value > 0 {
+				value = -value // Sale values are reported unsigned
+			}
+		}
+		activity.NetShares += shares
+		activity.NetValue += value
+	}
+	return activity
+}
+
+// InstitutionalOwnership summarizes institutions' holdings in the latest
+// report period, as far as the holdings given cover them
+type InstitutionalOwnership struct {
+	ReportPeriod *time.Time                    `json:"report_period"`
+	Holders      int                           `json:"holders"`
+	TotalShares  float64                       `json:"total_shares"`
+	TotalValue   float64                       `json:"total_value"`
+	SharesChange *float64                      `json:"shares_change"` // Since the previous period; nil without one
+	Holdings     []models.InstitutionalHolding `json:"holdings"`      // Largest first
+}
+
+// SummarizeHoldings totals the holdings of the latest report period and
+// compares their shares with the previous period's
+func SummarizeHoldings(holdings []models.InstitutionalHolding) InstitutionalOwnership {
+	ownership := InstitutionalOwnership{Holdings: []models.InstitutionalHolding{}}
+	var latest, previous time.Time
+	for _, h := range holdings {
+		switch {
+		case h.ReportPeriod.After(latest):
+			latest, previous = h.ReportPeriod, latest
+		case h.ReportPeriod.Before(latest) && h.ReportPeriod.After(previous):
+			previous = h.ReportPeriod
+		}
+	}
+	if latest.IsZero() {
+		return ownership
+	}
+	ownership.ReportPeriod = &latest
+
+	previousShares := 0.0
+	for _, h := range holdings {
+		switch {
+		case h.ReportPeriod.Equal(latest):
+			ownership.Holdings = append(ownership.Holdings, h)
+			ownership.TotalShares += h.Shares
+			if h.MarketValue != nil {
+				ownership.TotalValue += *h.MarketValue
+			}
+		case h.ReportPeriod.Equal(previous):
+			previousShares += h.Shares
+		}
+	}
+	ownership.Holders = len(ownership.Holdings)
+	if !previous.IsZero() {
+		change := ownership.TotalShares - previousShares
+		ownership.SharesChange = &change
+	}
+
+	sort.SliceStable(ownership.Holdings, func(i, j int) bool {
+		return ownership.Holdings[i].Shares > ownership.Holdings[j].Shares
+	})
+	return ownership
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestSummarizeInsiderTrades(t *testing.T) {
+	traded := date("2024-03-01")
+	trades := []models.InsiderTrade{
+		{FilingDate: date("2024-03-04"), TransactionDate: &traded, Shares: value(1000), Price: value(10)},
+		{FilingDate: date("2024-03-10"), Shares: value(-400), Value: value(4800)},
+		{FilingDate: date("2024-03-12"), Shares: value(0)},     // No shares changed hands
+		{FilingDate: date("2024-03-12"), Value: value(100)},    // Shares not reported
+		{FilingDate: date("2023-12-01"), Shares: value(-5000)}, // Before the window
+	}
+
+	activity := SummarizeInsiderTrades(trades, date("2024-01-01"))
+	assert.Equal(t, 1, activity.Buys)
+	assert.Equal(t, 1, activity.Sells)
+	assert.Equal(t, 600.0, activity.NetShares)
+	assert.Equal(t, 5200.0, activity.NetValue)
+}
+
+func TestSummarizeHoldings(t *testing.T) {
+	holdings := []models.InstitutionalHolding{
+		{Investor: "Vanguard", ReportPeriod: date("2023-12-31"), Shares: 900},
+		{Investor: "BlackRock", ReportPeriod: date("2024-03-31"), Shares: 500, MarketValue: value(5000)},
+		{Investor: "Vanguard", ReportPeriod: date("2024-03-31"), Shares: 1000, MarketValue: value(10000)},
+		{Investor: "BlackRock", ReportPeriod: date("2023-12-31"), Shares: 400},
+		{Investor: "State Street", ReportPeriod: date("2023-09-30"), Shares: 300},
+	}
+
+	ownership := SummarizeHoldings(holdings)
+	require.NotNil(t, ownership.ReportPeriod)
+	assert.Equal(t, date("2024-03-31"), *ownership.ReportPeriod)
+	assert.Equal(t, 2, ownership.Holders)
+	assert.Equal(t, 1500.0, ownership.TotalShares)
+	assert.Equal(t, 15000.0, ownership.TotalValue)
+	require.NotNil(t, ownership.SharesChange)
+	assert.Equal(t, 200.0, *ownership.SharesChange)
+	assert.Equal(t, "Vanguard", ownership.Holdings[0].Investor)
+
+	empty := SummarizeHoldings(nil)
+	assert.Nil(t, empty.ReportPeriod)
+	assert.Nil(t, empty.SharesChange)
+	assert.NotNil(t, empty.Holdings)
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// SymbolQuote is a symbol's latest stored daily close and the close before
+// it. There is no bid, ask or intraday last price: no configured provider
+// supplies live quotes.
+type SymbolQuote struct {
+	Symbol        string
+	Close         float64   // Latest stored daily close
+	PreviousClose float64   // Daily close before Close; zero with a single bar
+	Timestamp     time.Time // Of Close
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"time"
+
+	"hedge-fund/internal/market/domain"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Request DTOs
+
+type UpsertInstrumentRequest struct {
+	Name       string `json:"name" binding:"required"`
+	AssetClass string `json:"asset_class" binding:"required,oneof=equity etf option crypto"`
+	Sector     string `json:"sector"`
+	Industry   string `json:"industry"`
+	Exchange   string `json:"exchange"`
+	Currency   string `json:"currency"`
+	// Shares of the underlying per contract of an option, when not the
+	// standard 100; the other terms are read from the OCC symbol
+	Multiplier float64 `json:"multiplier" binding:"omitempty,gt=0"`
+	Tradable   *bool   `json:"tradable"` // Defaults to true
+}
+
+// Response DTOs
+
+type InstrumentResponse struct {
+	Symbol     string                  `json:"symbol"`
+	Name       string                  `json:"name"`
+	AssetClass string                  `json:"asset_class"`
+	Sector     string                  `json:"sector"`
+	Industry   string                  `json:"industry"`
+	Exchange   string                  `json:"exchange"`
+	Currency   string                  `json:"currency"`
+	Option     *OptionContractResponse `json:"option,omitempty"`
+	Tradable   bool                    `json:"tradable"`
+	CreatedAt  time.Time               `json:"created_at"`
+	UpdatedAt  time.Time               `json:"updated_at"`
+}
+
+type OptionContractResponse struct {
+	Underlying string  `json:"underlying"`
+	Type       string  `json:"option_type"`
+	Strike     float64 `json:"strike"`
+	Expiry     string  `json:"expiry"` // YYYY-MM-DD
+	Multiplier float64 `json:"multiplier"`
+}
+
+type BarResponse struct {
+	Timestamp time.Time `json:"timestamp"`
+	Open      float64   `json:"open"`
+	High      float64   `json:"high"`
+	Low       float64   `json:"low"`
+	Close     float64   `json:"close"`
+	Volume    int64     `json:"volume"`
+}
+
+type BarsResponse struct {
+	Symbol   string        `json:"symbol"`
+	Interval string        `json:"interval"`
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Bars     []BarResponse `json:"bars"`
+}
+
+type QuotesResponse struct {
+	Prices map[string]float64 `json:"prices"` // Latest daily close by symbol
+}
+
+// QuotesRequest is the payload of a bulk quote request
+type QuotesRequest struct {
+	Symbols []string `json:"symbols" binding:"required,min=1"`
+}
+
+// QuoteResponse is a symbol's latest price. Without a live quote provider
+// there is no bid or ask, and last is the latest daily close.
+type QuoteResponse struct {
+	Last          float64   `json:"last"`
+	Close         float64   `json:"close"`                    // Latest daily close
+	PreviousClose *float64  `json:"previous_close,omitempty"` // Null with a single stored bar
+	Timestamp     time.Time `json:"timestamp"`                // Of last
+}
+
+// BulkQuotesResponse holds the quotes of the requested symbols by
+// normalized symbol, and the symbols without stored bars
+type BulkQuotesResponse struct {
+	Quotes  map[string]QuoteResponse `json:"quotes"`
+	Missing []string                 `json:"missing"`
+}
+
+type RefreshBarsResponse struct {
+	JobID  string `json:"job_id"`
+	Status string `json:"status"`
+}
+
+type SyncInstrumentsResponse struct {
+	JobID  string `json:"job_id"`
+	Status string `json:"status"`
+}
+
+type FundamentalsResponse struct {
+	Symbol  string                `json:"symbol"`
+	Period  string                `json:"period"`
+	Periods []models.Fundamentals `json:"periods"` // Newest first
+}
+
+// FreshnessResponse tells how current ownership data is
+type FreshnessResponse struct {
+	UpdatedAt *time.Time `json:"updated_at"` // Last ingestion; null when never ingested
+	AsOf      *time.Time `json:"as_of"`      // Newest filing or report period stored
+	Stale     bool       `json:"stale"`      // Not ingested in the last two days
+}
+
+type InsiderTradesResponse struct {
+	Symbol    string                 `json:"symbol"`
+	Activity  domain.InsiderActivity `json:"activity_90d"` // Buying and selling over the last 90 days
+	Trades    []models.InsiderTrade  `json:"trades"`       // Newest filing first
+	Freshness FreshnessResponse      `json:"freshness"`
+}
+
+type InstitutionalOwnershipResponse struct {
+	Symbol string `json:"symbol"`
+	domain.InstitutionalOwnership
+	Freshness FreshnessResponse `json:"freshness"`
+}
+
+type RefreshOwnershipResponse struct {
+	JobID  string `json:"job_id"`
+	Status string `json:"status"`
+}
+
+type RiskFreeRateResponse struct {
+	Date   string  `json:"date"`
+	Rate   float64 `json:"rate"` // Annual, e.g. 0.04
+	Source string  `json:"source"`
+}
+
+type RiskFreeRatesResponse struct {
+	Tenor string                 `json:"tenor"`
+	Rates []RiskFreeRateResponse `json:"rates"`
+}
+
+type RefreshRiskFreeRatesResponse struct {
+	Updated map[string]int `json:"updated"` // Rates loaded by tenor
+}
+
+type WatchlistQuoteResponse struct {
+	Symbol        string   `json:"symbol"`
+	Name          string   `json:"name"`
+	Price         float64  `json:"price"` // Latest daily close, zero before any bars are stored
+	Change        float64  `json:"change"`
+	ChangePercent float64  `json:"change_percent"`
+	AlertPrice    *float64 `json:"alert_price,omitempty"`
+	AlertEnabled  bool     `json:"alert_enabled"`
+}
+
+type WatchlistResponse struct {
+	UserID int                      `json:"user_id"`
+	Quotes []WatchlistQuoteResponse `json:"quotes"`
+}
+
+// ErrorResponse is the error envelope shared by every service
+type ErrorResponse = apierror.Response
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hedge-fund/internal/market/domain"
+	"hedge-fund/internal/market/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/symbols"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxFundamentalsPeriods caps how many fiscal periods one request returns
+const maxFundamentalsPeriods = 40
+
+type FundamentalsHandler struct {
+	service *service.FundamentalsService
+	logger  *zap.Logger
+}
+
+func NewFundamentalsHandler(service *service.FundamentalsService, logger *zap.Logger) *FundamentalsHandler {
+	return &FundamentalsHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetFundamentals godoc
+// @Summary Get fundamentals
+// @Description Get a company's income statement, balance sheet and cash flow figures with its profitability, leverage and valuation ratios for its latest fiscal periods, newest first. Fundamentals are loaded from the data provider when not stored or more than a day old.
+// @Tags market
+// @Produce json
+// @Param symbol path string true "Symbol"
+// @Param period query string false "Fiscal period" Enums(annual, quarterly, ttm) default(annual)
+// @Param limit query int false "Fiscal periods to return (max 40)" default(4)
+// @Success 200 {object} FundamentalsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/{symbol}/fundamentals [get]
+func (h *FundamentalsHandler) GetFundamentals(c *gin.Context) {
+	symbol := symbols.Normalize(c.Param("symbol"))
+	if err := symbols.Validate(symbol); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid symbol", Details: err.Error()})
+		return
+	}
+
+	period := c.DefaultQuery("period", domain.PeriodAnnual)
+	if !domain.ValidPeriod(period) {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid period parameter", Details: "period must be annual, quarterly or ttm"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "4"))
+	if err != nil || limit < 1 || limit > maxFundamentalsPeriods {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid limit parameter", Details: "limit must be between 1 and 40"})
+		return
+	}
+
+	fundamentals, err := h.service.GetFundamentals(c.Request.Context(), symbol, period, limit)
+	if err != nil {
+		h.logger.Error("Failed to get fundamentals", zap.Error(err), zap.String("symbol", symbol))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get fundamentals", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, FundamentalsResponse{Symbol: symbol, Period: period, Periods: fundamentals})
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hedge-fund/internal/market/repository"
+	"hedge-fund/internal/market/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/symbols"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// assetClasses are the asset_class values instruments can have
+var assetClasses = map[string]bool{"equity": true, "etf": true, "option": true, "crypto": true}
+
+type InstrumentHandler struct {
+	repo    *repository.InstrumentRepository
+	service *service.InstrumentService
+	logger  *zap.Logger
+}
+
+func NewInstrumentHandler(repo *repository.InstrumentRepository, service *service.InstrumentService, logger *zap.Logger) *InstrumentHandler {
+	return &InstrumentHandler{
+		repo:    repo,
+		service: service,
+		logger:  logger,
+	}
+}
+
+// SearchSymbols godoc
+// @Summary Search symbols
+// @Description Search instruments by symbol prefix or by name, tolerating typos in names. By default the best matches come first: an exact symbol, then symbol prefixes, then names.
+// @Tags market
+// @Produce json
+// @Param query query string false "Symbol prefix or company name"
+// @Param asset_class query string false "Asset class" Enums(equity, etf, option, crypto)
+// @Param tradable query bool false "Only tradable (true) or untradable (false) instruments"
+// @Param limit query int false "Page size" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param sort query string false "Sort field" Enums(relevance, symbol, name) default(relevance)
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Success 200 {object} pagination.Page{data=[]InstrumentResponse}
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/symbols [get]
+func (h *InstrumentHandler) SearchSymbols(c *gin.Context) {
+	filter := repository.InstrumentFilter{
+		Query:      c.Query("query"),
+		AssetClass: c.Query("asset_class"),
+	}
+	if filter.AssetClass != "" && !assetClasses[filter.AssetClass] {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid asset_class parameter", Details: "asset_class must be equity, etf, option or crypto"})
+		return
+	}
+	if raw := c.Query("tradable"); raw != "" {
+		tradable, err := strconv.ParseBool(raw)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid tradable parameter", Details: err.Error()})
+			return
+		}
+		filter.Tradable = &tradable
+	}
+
+	page, err := pagination.Parse(c.Request.URL.Query(), repository.InstrumentSorts, 20)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid pagination parameters", Details: err.Error()})
+		return
+	}
+
+	instruments, result, err := h.service.Search(c.Request.Context(), filter, page)
+	if err != nil {
+		h.logger.Error("Failed to search symbols", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to search symbols", Details: err.Error()})
+		return
+	}
+
+	response := make([]InstrumentResponse, 0, len(instruments))
+	for i := range instruments {
+		response = append(response, h.toInstrumentResponse(&instruments[i]))
+	}
+
+	c.JSON(http.StatusOK, result.Page(response))
+}
+
+// SyncSymbols godoc
+// @Summary Sync instrument reference data
+// @Description Enqueue an immediate sync of instruments from the data provider: newly listed symbols are loaded, and equities and ETFs the provider no longer lists are flagged untradable
+// @Tags market
+// @Produce json
+// @Success 202 {object} SyncInstrumentsResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/symbols/sync [post]
+func (h *InstrumentHandler) SyncSymbols(c *gin.Context) {
+	jobID, err := h.service.RequestSync()
+	if err != nil {
+		h.logger.Error("Failed to enqueue instrument sync", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to enqueue instrument sync", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, SyncInstrumentsResponse{JobID: jobID, Status: models.JobStatusPending})
+}
+
+// ListInstruments godoc
+// @Summary List instruments
+// @Description Get reference metadata for a comma-separated list of symbols
+// @Tags market
+// @Produce json
+// @Param symbols query string true "Comma-separated symbols"
+// @Success 200 {array} InstrumentResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/instruments [get]
+func (h *InstrumentHandler) ListInstruments(c *gin.Context) {
+	symbols := parseSymbols(c.Query("symbols"))
+	if len(symbols) == 0 {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "At least one symbol is required"})
+		return
+	}
+
+	instruments, err := h.repo.GetInstrumentsBySymbols(c.Request.Context(), symbols)
+	if err != nil {
+		h.logger.Error("Failed to list instruments", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to list instruments", Details: err.Error()})
+		return
+	}
+
+	response := make([]InstrumentResponse, 0, len(instruments))
+	for _, symbol := range symbols {
+		if instrument, ok := instruments[symbol]; ok {
+			response = append(response, h.toInstrumentResponse(&instrument))
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetInstrument godoc
+// @Summary Get instrument
+// @Description Get reference metadata for a symbol
+// @Tags market
+// @Produce json
+// @Param symbol path string true "Symbol"
+// @Success 200 {object} InstrumentResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/market/instruments/{symbol} [get]
+func (h *InstrumentHandler) GetInstrument(c *gin.Context) {
+	symbol := symbols.Normalize(c.Param("symbol"))
+
+	instrument, err := h.repo.GetInstrument(c.Request.Context(), symbol)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Instrument not found", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.toInstrumentResponse(instrument))
+}
+
+// UpsertInstrument godoc
+// @Summary Create or update instrument
+// @Description Create or replace reference metadata for a symbol. Options must be listed under their OCC symbol (e.g. AAPL240119C00150000), from which the underlying, type, strike and expiry are read.
+// @Tags market
+// @Accept json
+// @Produce json
+// @Param symbol path string true "Symbol"
+// @Param request body UpsertInstrumentRequest true "Instrument"
+// @Success 200 {object} InstrumentResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/instruments/{symbol} [put]
+func (h *InstrumentHandler) UpsertInstrument(c *gin.Context) {
+	var req UpsertInstrumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+	tradable := req.Tradable == nil || *req.Tradable
+
+	instrument := &models.Instrument{
+		Symbol:     symbols.Normalize(c.Param("symbol")),
+		Name:       req.Name,
+		AssetClass: req.AssetClass,
+		Sector:     req.Sector,
+		Industry:   req.Industry,
+		Exchange:   req.Exchange,
+		Currency:   currency,
+		Tradable:   tradable,
+	}
+
+	if req.AssetClass == "option" {
+		contract, ok := symbols.ParseOption(instrument.Symbol)
+		if !ok {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: "options must be listed under their OCC symbol"})
+			return
+		}
+		if req.Multiplier > 0 {
+			contract.Multiplier = req.Multiplier
+		}
+		instrument.Option = contract
+	}
+
+	if err := h.repo.UpsertInstrument(c.Request.Context(), instrument); err != nil {
+		h.logger.Error("Failed to upsert instrument", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to save instrument", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.toInstrumentResponse(instrument))
+}
+
+// Helper functions
+
+func parseSymbols(raw string) []string {
+	return symbols.NormalizeAll(strings.Split(raw, ","))
+}
+
+func (h *InstrumentHandler) toInstrumentResponse(instrument *models.Instrument) InstrumentResponse {
+	response := InstrumentResponse{
+		Symbol:     instrument.Symbol,
+		Name:       instrument.Name,
+		AssetClass: instrument.AssetClass,
+		Sector:     instrument.Sector,
+		Industry:   instrument.Industry,
+		Exchange:   instrument.Exchange,
+		Currency:   instrument.Currency,
+		Tradable:   instrument.Tradable,
+		CreatedAt:  instrument.CreatedAt,
+		UpdatedAt:  instrument.UpdatedAt,
+	}
+	if o := instrument.Option; o != nil {
+		response.Option = &OptionContractResponse{
+			Underlying: o.Underlying,
+			Type:       o.Type,
+			Strike:     o.Strike,
+			Expiry:     o.Expiry.Format("2006-01-02"),
+			Multiplier: o.Multiplier,
+		}
+	}
+	return response
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"hedge-fund/internal/market/service"
+	"hedge-fund/pkg/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type OverviewHandler struct {
+	service *service.OverviewService
+	logger  *zap.Logger
+}
+
+func NewOverviewHandler(service *service.OverviewService, logger *zap.Logger) *OverviewHandler {
+	return &OverviewHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetOverview godoc
+// @Summary Get market overview
+// @Description Get the latest trading day's major index levels (through the ETFs tracking them), the top 10 gainers and losers among tracked symbols, and each sector's equal-weighted move. The overview is rebuilt from stored daily bars every MARKET_OVERVIEW_INTERVAL seconds.
+// @Tags market
+// @Produce json
+// @Success 200 {object} models.MarketOverview
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/overview [get]
+func (h *OverviewHandler) GetOverview(c *gin.Context) {
+	overview, err := h.service.GetOverview(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get market overview", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market overview", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
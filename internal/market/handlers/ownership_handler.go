@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hedge-fund/internal/market/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type OwnershipHandler struct {
+	service *service.OwnershipService
+	logger  *zap.Logger
+}
+
+func NewOwnershipHandler(service *service.OwnershipService, logger *zap.Logger) *OwnershipHandler {
+	return &OwnershipHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetInsiderTrades godoc
+// @Summary Get insider trades
+// @Description Get a company's latest insider transactions, newest filing first, with insiders' net buying over the last 90 days. Freshness tells when the trades were last ingested; stale data has not been ingested in two days.
+// @Tags market
+// @Produce json
+// @Param symbol path string true "Symbol"
+// @Param limit query int false "Trades to return (max 200)" default(50)
+// @Success 200 {object} InsiderTradesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/{symbol}/insider-trades [get]
+func (h *OwnershipHandler) GetInsiderTrades(c *gin.Context) {
+	symbol, limit, ok := h.parseRequest(c, 50, 200)
+	if !ok {
+		return
+	}
+
+	trades, activity, freshness, err := h.service.GetInsiderTrades(c.Request.Context(), symbol, limit)
+	if err != nil {
+		h.logger.Error("Failed to get insider trades", zap.Error(err), zap.String("symbol", symbol))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get insider trades", Details: err.Error()})
+		return
+	}
+
+	response := InsiderTradesResponse{
+		Symbol:    symbol,
+		Activity:  activity,
+		Trades:    trades,
+		Freshness: toFreshnessResponse(freshness),
+	}
+	if response.Trades == nil {
+		response.Trades = []models.InsiderTrade{}
+	}
+	if len(trades) > 0 {
+		response.Freshness.AsOf = &trades[0].FilingDate
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetInstitutionalOwnership godoc
+// @Summary Get institutional ownership
+// @Description Get the 13F holdings of a company in its latest report period, largest first, with their totals and the change in shares held since the previous period. Freshness tells when the holdings were last ingested; stale data has not been ingested in two days.
+// @Tags market
+// @Produce json
+// @Param symbol path string true "Symbol"
+// @Param limit query int false "Holdings to return (max 1000)" default(50)
+// @Success 200 {object} InstitutionalOwnershipResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/{symbol}/institutional-ownership [get]
+func (h *OwnershipHandler) GetInstitutionalOwnership(c *gin.Context) {
+	symbol, limit, ok := h.parseRequest(c, 50, 1000)
+	if !ok {
+		return
+	}
+
+	ownership, freshness, err := h.service.GetInstitutionalOwnership(c.Request.Context(), symbol)
+	if err != nil {
+		h.logger.Error("Failed to get institutional ownership", zap.Error(err), zap.String("symbol", symbol))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get institutional ownership", Details: err.Error()})
+		return
+	}
+	if len(ownership.Holdings) > limit {
+		ownership.Holdings = ownership.Holdings[:limit] // Totals still cover every holding
+	}
+
+	response := InstitutionalOwnershipResponse{
+		Symbol:                 symbol,
+		InstitutionalOwnership: ownership,
+		Freshness:              toFreshnessResponse(freshness),
+	}
+	response.Freshness.AsOf = ownership.ReportPeriod
+	c.JSON(http.StatusOK, response)
+}
+
+// RefreshOwnership godoc
+// @Summary Refresh ownership data
+// @Description Enqueue an immediate ingestion of a company's insider trades and institutional holdings from the data provider
+// @Tags market
+// @Produce json
+// @Param symbol path string true "Symbol"
+// @Success 202 {object} RefreshOwnershipResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/{symbol}/ownership/refresh [post]
+func (h *OwnershipHandler) RefreshOwnership(c *gin.Context) {
+	symbol := symbols.Normalize(c.Param("symbol"))
+	if err := symbols.Validate(symbol); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid symbol", Details: err.Error()})
+		return
+	}
+
+	jobID, err := h.service.RequestUpdate([]string{symbol})
+	if err != nil {
+		h.logger.Error("Failed to enqueue ownership update", zap.Error(err), zap.String("symbol", symbol))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to enqueue ownership update", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, RefreshOwnershipResponse{JobID: jobID, Status: models.JobStatusPending})
+}
+
+// parseRequest reads the symbol and limit of an ownership request,
+// responding with 400 when either is invalid
+func (h *OwnershipHandler) parseRequest(c *gin.Context, defaultLimit, maxLimit int) (string, int, bool) {
+	symbol := symbols.Normalize(c.Param("symbol"))
+	if err := symbols.Validate(symbol); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid symbol", Details: err.Error()})
+		return "", 0, false
+	}
+
+	limit := defaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxLimit {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid limit parameter", Details: "limit must be between 1 and " + strconv.Itoa(maxLimit)})
+			return "", 0, false
+		}
+		limit = parsed
+	}
+	return symbol, limit, true
+}
+
+func toFreshnessResponse(freshness service.Freshness) FreshnessResponse {
+	return FreshnessResponse{UpdatedAt: freshness.UpdatedAt, Stale: freshness.Stale}
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"hedge-fund/internal/market/domain"
+	"hedge-fund/internal/market/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultBarsLookback is the range served when no from date is given
+const defaultBarsLookback = 365 * 24 * time.Hour
+
+// maxQuoteSymbols bounds the symbols of one bulk quote request
+const maxQuoteSymbols = 500
+
+// maxStreamSymbols bounds the symbols one price stream watches
+const maxStreamSymbols = 100
+
+type PriceHandler struct {
+	service *service.PriceService
+	stream  *service.PriceStream
+	logger  *zap.Logger
+}
+
+func NewPriceHandler(service *service.PriceService, stream *service.PriceStream, logger *zap.Logger) *PriceHandler {
+	return &PriceHandler{
+		service: service,
+		stream:  stream,
+		logger:  logger,
+	}
+}
+
+// GetBars godoc
+// @Summary Get historical bars
+// @Description Get OHLCV bars for a symbol, oldest first. Weekly and monthly bars are rolled up from daily bars.
+// @Tags market
+// @Produce json
+// @Param symbol path string true "Symbol"
+// @Param interval query string false "Bar interval (1d, 1w, 1mo)" default(1d)
+// @Param from query string false "Start date (YYYY-MM-DD or RFC3339), defaults to one year before to"
+// @Param to query string false "End date (YYYY-MM-DD or RFC3339, inclusive), defaults to now"
+// @Success 200 {object} BarsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/{symbol}/bars [get]
+func (h *PriceHandler) GetBars(c *gin.Context) {
+	symbol := symbols.Normalize(c.Param("symbol"))
+	interval := c.DefaultQuery("interval", domain.IntervalDaily)
+
+	to := time.Now().UTC()
+	if raw := c.Query("to"); raw != "" {
+		parsed, dateOnly, err := parseBarTime(raw)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid to date", Details: err.Error()})
+			return
+		}
+		to = parsed
+		if dateOnly {
+			to = to.Add(24*time.Hour - time.Nanosecond) // Include the whole day
+		}
+	}
+
+	from := to.Add(-defaultBarsLookback)
+	if raw := c.Query("from"); raw != "" {
+		parsed, _, err := parseBarTime(raw)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid from date", Details: err.Error()})
+			return
+		}
+		from = parsed
+	}
+
+	if _, err := domain.StoredInterval(interval); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid interval", Details: err.Error()})
+		return
+	}
+	if to.Before(from) {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "from must not be after to"})
+		return
+	}
+
+	bars, err := h.service.GetBars(c.Request.Context(), symbol, interval, from, to)
+	if err != nil {
+		h.logger.Error("Failed to get bars", zap.Error(err), zap.String("symbol", symbol))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get bars", Details: err.Error()})
+		return
+	}
+
+	response := BarsResponse{
+		Symbol:   symbol,
+		Interval: interval,
+		From:     from,
+		To:       to,
+		Bars:     make([]BarResponse, len(bars)),
+	}
+	for i, bar := range bars {
+		response.Bars[i] = BarResponse{
+			Timestamp: bar.Timestamp,
+			Open:      bar.Open,
+			High:      bar.High,
+			Low:       bar.Low,
+			Close:     bar.Close,
+			Volume:    bar.Volume,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetQuotes godoc
+// @Summary Get latest closes
+// @Description Get the latest stored daily close of each symbol; symbols without stored bars are omitted
+// @Tags market
+// @Produce json
+// @Param symbols query string true "Comma-separated symbols"
+// @Success 200 {object} QuotesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/quotes [get]
+func (h *PriceHandler) GetQuotes(c *gin.Context) {
+	list := parseSymbols(c.Query("symbols"))
+	if len(list) == 0 {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "At least one symbol is required"})
+		return
+	}
+
+	closes, err := h.service.GetLatestCloses(c.Request.Context(), list)
+	if err != nil {
+		h.logger.Error("Failed to get quotes", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get quotes", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, QuotesResponse{Prices: closes})
+}
+
+// PostQuotes godoc
+// @Summary Get quotes in bulk
+// @Description Get the last price and previous close of up to 500 symbols in one call. The last price is the latest stored daily close; bid and ask are not supported, as no configured provider supplies live quotes.
+// @Tags market
+// @Accept json
+// @Produce json
+// @Param request body QuotesRequest true "Symbols"
+// @Success 200 {object} BulkQuotesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/quotes [post]
+func (h *PriceHandler) PostQuotes(c *gin.Context) {
+	var req QuotesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+	list := symbols.NormalizeAll(req.Symbols)
+	if len(list) == 0 {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "At least one symbol is required"})
+		return
+	}
+	if len(list) > maxQuoteSymbols {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("At most %d symbols can be quoted at once", maxQuoteSymbols)})
+		return
+	}
+
+	quotes, err := h.service.GetQuotes(c.Request.Context(), list)
+	if err != nil {
+		h.logger.Error("Failed to get quotes", zap.Error(err), zap.Int("symbols", len(list)))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get quotes", Details: err.Error()})
+		return
+	}
+
+	response := BulkQuotesResponse{
+		Quotes:  make(map[string]QuoteResponse, len(quotes)),
+		Missing: []string{},
+	}
+	for _, symbol := range list {
+		quote, ok := quotes[symbol]
+		if !ok {
+			response.Missing = append(response.Missing, symbol)
+			continue
+		}
+		item := QuoteResponse{
+			Last:      quote.Close,
+			Close:     quote.Close,
+			Timestamp: quote.Timestamp,
+		}
+		if quote.PreviousClose > 0 {
+			item.PreviousClose = &quote.PreviousClose
+		}
+		response.Quotes[symbol] = item
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// StreamQuotes godoc
+// @Summary Stream live prices
+// @Description Server-sent "price" events with each symbol's price updates, conflated per PRICE_CONFLATION_INTERVAL, until the client disconnects. Symbols without updates send nothing; use the quotes endpoint for their latest close.
+// @Tags market
+// @Produce text/event-stream
+// @Param symbols query string true "Comma-separated symbols"
+// @Success 200 {object} models.PriceSnapshotEvent
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/market/quotes/stream [get]
+func (h *PriceHandler) StreamQuotes(c *gin.Context) {
+	list := parseSymbols(c.Query("symbols"))
+	if len(list) == 0 || len(list) > maxStreamSymbols {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("Between 1 and %d symbols are required", maxStreamSymbols)})
+		return
+	}
+
+	// The stream outlives the server's write timeout. Headers are sent at
+	// once, as a quiet symbol may not update for a long time.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to lift write deadline for price stream", zap.Error(err))
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	err := h.stream.Watch(c.Request.Context(), list, func(snapshot *models.PriceSnapshotEvent) bool {
+		c.SSEvent("price", snapshot)
+		c.Writer.Flush()
+		return true
+	})
+	if err != nil {
+		h.logger.Warn("Price stream ended", zap.Error(err))
+	}
+}
+
+// RefreshBars godoc
+// @Summary Refresh historical bars
+// @Description Enqueue an immediate update of a symbol's daily bars from the market data provider
+// @Tags market
+// @Produce json
+// @Param symbol path string true "Symbol"
+// @Success 202 {object} RefreshBarsResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/{symbol}/bars/refresh [post]
+func (h *PriceHandler) RefreshBars(c *gin.Context) {
+	symbol := symbols.Normalize(c.Param("symbol"))
+
+	jobID, err := h.service.RequestUpdate([]string{symbol})
+	if err != nil {
+		h.logger.Error("Failed to enqueue price update", zap.Error(err), zap.String("symbol", symbol))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to enqueue price update", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, RefreshBarsResponse{JobID: jobID, Status: models.JobStatusPending})
+}
+
+// parseBarTime accepts a date or an RFC3339 timestamp and reports which it was
+func parseBarTime(raw string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	return t.UTC(), false, err
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"hedge-fund/internal/market/service"
+	"hedge-fund/pkg/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type RateHandler struct {
+	service      *service.RateService
+	defaultTenor string
+	logger       *zap.Logger
+}
+
+func NewRateHandler(service *service.RateService, defaultTenor string, logger *zap.Logger) *RateHandler {
+	return &RateHandler{
+		service:      service,
+		defaultTenor: defaultTenor,
+		logger:       logger,
+	}
+}
+
+// GetRiskFreeRates godoc
+// @Summary Get the risk-free rate curve
+// @Description Get a treasury tenor's stored daily yields, oldest first, as annual rates
+// @Tags market
+// @Produce json
+// @Param tenor query string false "Treasury tenor (1m, 3m, 6m, 1y, 2y, 5y, 10y), defaults to the configured tenor"
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to one year before to"
+// @Param to query string false "End date (YYYY-MM-DD, inclusive), defaults to today"
+// @Success 200 {object} RiskFreeRatesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/risk-free-rates [get]
+func (h *RateHandler) GetRiskFreeRates(c *gin.Context) {
+	tenor := c.DefaultQuery("tenor", h.defaultTenor)
+
+	to := time.Now().UTC()
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid to date", Details: err.Error()})
+			return
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultBarsLookback)
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid from date", Details: err.Error()})
+			return
+		}
+		from = parsed
+	}
+
+	rates, err := h.service.GetRates(c.Request.Context(), tenor, from, to)
+	if err != nil {
+		h.logger.Error("Failed to get risk-free rates", zap.Error(err), zap.String("tenor", tenor))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get risk-free rates", Details: err.Error()})
+		return
+	}
+
+	response := RiskFreeRatesResponse{
+		Tenor: tenor,
+		Rates: make([]RiskFreeRateResponse, len(rates)),
+	}
+	for i, rate := range rates {
+		response.Rates[i] = RiskFreeRateResponse{Date: rate.Date.Format("2006-01-02"), Rate: rate.Rate, Source: rate.Source}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RefreshRiskFreeRates godoc
+// @Summary Refresh the risk-free rate curve
+// @Description Load the latest treasury yields for every tenor from the rate provider
+// @Tags market
+// @Produce json
+// @Success 200 {object} RefreshRiskFreeRatesResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse "No rate provider configured"
+// @Router /api/v1/market/risk-free-rates/refresh [post]
+func (h *RateHandler) RefreshRiskFreeRates(c *gin.Context) {
+	updated, err := h.service.UpdateAll(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrRatesDisabled) {
+			apierror.Respond(c, http.StatusServiceUnavailable, ErrorResponse{Error: "Risk-free rate refresh unavailable", Details: err.Error()})
+			return
+		}
+		h.logger.Error("Failed to refresh risk-free rates", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to refresh risk-free rates", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, RefreshRiskFreeRatesResponse{Updated: updated})
+}
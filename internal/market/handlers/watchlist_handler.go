@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hedge-fund/internal/market/repository"
+	"hedge-fund/pkg/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type WatchlistHandler struct {
+	repo   *repository.WatchlistRepository
+	logger *zap.Logger
+}
+
+func NewWatchlistHandler(repo *repository.WatchlistRepository, logger *zap.Logger) *WatchlistHandler {
+	return &WatchlistHandler{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// GetWatchlist godoc
+// @Summary Get watchlist quotes
+// @Description Get a user's watchlist by symbol, quoted at the latest daily close with its change from the previous close
+// @Tags market
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {object} WatchlistResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/market/watchlists/{user_id} [get]
+func (h *WatchlistHandler) GetWatchlist(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	items, err := h.repo.GetWatchlistQuotes(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get watchlist", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get watchlist", Details: err.Error()})
+		return
+	}
+
+	response := WatchlistResponse{UserID: userID, Quotes: make([]WatchlistQuoteResponse, len(items))}
+	for i, item := range items {
+		response.Quotes[i] = WatchlistQuoteResponse{
+			Symbol:        item.Symbol,
+			Name:          item.Name,
+			Price:         item.CurrentPrice,
+			Change:        item.Change,
+			ChangePercent: item.ChangePercent,
+			AlertPrice:    item.AlertPrice,
+			AlertEnabled:  item.AlertEnabled,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
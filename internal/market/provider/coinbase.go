@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// SourceCoinbase identifies bars loaded from the Coinbase Exchange API
+const SourceCoinbase = "coinbase"
+
+// coinbaseMaxCandles is the most candles Coinbase returns for one request
+const coinbaseMaxCandles = 300
+
+// CoinbaseClient reads daily crypto bars from the public Coinbase Exchange
+// REST API. Crypto trades around the clock, so every calendar day has a bar.
+type CoinbaseClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewCoinbaseClient(baseURL string) *CoinbaseClient {
+	return &CoinbaseClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetDailyPrices implements PriceProvider for crypto pairs such as BTC-USD.
+// start and end are inclusive dates; ranges longer than one response are
+// read in windows.
+func (c *CoinbaseClient) GetDailyPrices(ctx context.Context, symbol string, start, end time.Time) ([]models.Price, error) {
+	start = start.UTC().Truncate(24 * time.Hour)
+	end = end.UTC().Truncate(24 * time.Hour)
+
+	var prices []models.Price
+	for from := start; !from.After(end); from = from.AddDate(0, 0, coinbaseMaxCandles) {
+		to := from.AddDate(0, 0, coinbaseMaxCandles-1)
+		if to.After(end) {
+			to = end
+		}
+		window, err := c.getCandles(ctx, symbol, from, to)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, window...)
+	}
+	return prices, nil
+}
+
+// getCandles reads the daily candles of one window of at most
+// coinbaseMaxCandles days
+func (c *CoinbaseClient) getCandles(ctx context.Context, symbol string, from, to time.Time) ([]models.Price, error) {
+	query := url.Values{
+		"granularity": {"86400"},
+		"start":       {from.Format(time.RFC3339)},
+		"end":         {to.Format(time.RFC3339)},
+	}
+	endpoint := c.baseURL + "/products/" + url.PathEscape(symbol) + "/candles?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch prices for %s: %w", symbol, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil // Unknown product
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("crypto price API returned %d for %s: %s", resp.StatusCode, symbol, strings.TrimSpace(string(body)))
+	}
+
+	// Each candle is [time, low, high, open, close, volume], newest first
+	var candles [][6]float64
+	if err := json.NewDecoder(resp.Body).Decode(&candles); err != nil {
+		return nil, fmt.Errorf("failed to decode prices for %s: %w", symbol, err)
+	}
+
+	prices := make([]models.Price, 0, len(candles))
+	for i := len(candles) - 1; i >= 0; i-- {
+		candle := candles[i]
+		prices = append(prices, models.Price{
+			Symbol:    symbol,
+			Interval:  "1d",
+			Open:      candle[3],
+			High:      candle[2],
+			Low:       candle[1],
+			Close:     candle[4],
+			Volume:    int64(candle[5]),
+			Timestamp: time.Unix(int64(candle[0]), 0).UTC(),
+			Source:    SourceCoinbase,
+		})
+	}
+	return prices, nil
+}
+
+// AssetRouter loads crypto pairs from one provider and every other symbol
+// from another
+type AssetRouter struct {
+	equities PriceProvider
+	crypto   PriceProvider
+}
+
+func NewAssetRouter(equities, crypto PriceProvider) *AssetRouter {
+	return &AssetRouter{equities: equities, crypto: crypto}
+}
+
+// GetDailyPrices implements PriceProvider
+func (r *AssetRouter) GetDailyPrices(ctx context.Context, symbol string, start, end time.Time) ([]models.Price, error) {
+	if symbols.IsCrypto(symbol) {
+		return r.crypto.GetDailyPrices(ctx, symbol, start, end)
+	}
+	return r.equities.GetDailyPrices(ctx, symbol, start, end)
+}
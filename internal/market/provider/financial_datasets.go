@@ -0,0 +1,441 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// SourceFinancialDatasets identifies bars loaded from financialdatasets.ai
+const SourceFinancialDatasets = "financial_datasets"
+
+// PriceProvider loads historical daily bars from an upstream data vendor
+type PriceProvider interface {
+	GetDailyPrices(ctx context.Context, symbol string, start, end time.Time) ([]models.Price, error)
+}
+
+// FinancialDatasetsClient reads prices from the financialdatasets.ai REST API
+type FinancialDatasetsClient struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func NewFinancialDatasetsClient(baseURL, apiKey string) *FinancialDatasetsClient {
+	return &FinancialDatasetsClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type financialDatasetsPrice struct {
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume float64 `json:"volume"`
+	Time   string  `json:"time"`
+}
+
+// GetDailyPrices implements PriceProvider. start and end are inclusive dates.
+func (c *FinancialDatasetsClient) GetDailyPrices(ctx context.Context, symbol string, start, end time.Time) ([]models.Price, error) {
+	query := url.Values{
+		"ticker":              {symbol},
+		"interval":            {"day"},
+		"interval_multiplier": {"1"},
+		"start_date":          {start.UTC().Format("2006-01-02")},
+		"end_date":            {end.UTC().Format("2006-01-02")},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/prices/?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-KEY", c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch prices for %s: %w", symbol, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil // Unknown ticker or no trading in range
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("price API returned %d for %s: %s", resp.StatusCode, symbol, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Prices []financialDatasetsPrice `json:"prices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode prices for %s: %w", symbol, err)
+	}
+
+	prices := make([]models.Price, 0, len(payload.Prices))
+	for _, p := range payload.Prices {
+		ts, err := time.Parse(time.RFC3339, p.Time)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bar time %q for %s: %w", p.Time, symbol, err)
+		}
+		prices = append(prices, models.Price{
+			Symbol:    symbol,
+			Interval:  "1d",
+			Open:      p.Open,
+			High:      p.High,
+			Low:       p.Low,
+			Close:     p.Close,
+			Volume:    int64(p.Volume),
+			Timestamp: ts.UTC(),
+			Source:    SourceFinancialDatasets,
+		})
+	}
+	return prices, nil
+}
+
+// InstrumentProvider lists the symbols an upstream data vendor covers and
+// loads their reference metadata
+type InstrumentProvider interface {
+	ListSymbols(ctx context.Context) ([]string, error)
+	GetInstrument(ctx context.Context, symbol string) (*models.Instrument, error)
+}
+
+type financialDatasetsCompanyFacts struct {
+	Ticker   string `json:"ticker"`
+	Name     string `json:"name"`
+	Sector   string `json:"sector"`
+	Industry string `json:"industry"`
+	Exchange string `json:"exchange"`
+	IsActive *bool  `json:"is_active"`
+}
+
+// ListSymbols implements InstrumentProvider with every ticker that has
+// company facts
+func (c *FinancialDatasetsClient) ListSymbols(ctx context.Context) ([]string, error) {
+	var payload struct {
+		Tickers []string `json:"tickers"`
+	}
+	if _, err := c.getJSON(ctx, "/company/facts/tickers/", nil, &payload); err != nil {
+		return nil, fmt.Errorf("failed to list tickers: %w", err)
+	}
+	return payload.Tickers, nil
+}
+
+// GetInstrument implements InstrumentProvider. Unknown tickers return nil.
+func (c *FinancialDatasetsClient) GetInstrument(ctx context.Context, symbol string) (*models.Instrument, error) {
+	var payload struct {
+		CompanyFacts financialDatasetsCompanyFacts `json:"company_facts"`
+	}
+	found, err := c.getJSON(ctx, "/company/facts/", url.Values{"ticker": {symbol}}, &payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch company facts for %s: %w", symbol, err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	facts := payload.CompanyFacts
+	return &models.Instrument{
+		Symbol:     symbol,
+		Name:       facts.Name,
+		AssetClass: "equity",
+		Sector:     facts.Sector,
+		Industry:   facts.Industry,
+		Exchange:   facts.Exchange,
+		Currency:   "USD",
+		Tradable:   facts.IsActive == nil || *facts.IsActive,
+	}, nil
+}
+
+// getJSON decodes the response to a GET request into out. It reports false
+// when the resource does not exist.
+func (c *FinancialDatasetsClient) getJSON(ctx context.Context, path string, query url.Values, out interface{}) (bool, error) {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-KEY", c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return false, fmt.Errorf("API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return true, nil
+}
+
+// FundamentalsProvider loads a company's reported financials for its latest
+// fiscal periods, newest first
+type FundamentalsProvider interface {
+	GetFundamentals(ctx context.Context, symbol, period string, limit int) ([]models.Fundamentals, error)
+}
+
+type financialDatasetsStatement struct {
+	ReportPeriod       string   `json:"report_period"`
+	Currency           string   `json:"currency"`
+	Revenue            *float64 `json:"revenue"`
+	NetIncome          *float64 `json:"net_income"`
+	EPSDiluted         *float64 `json:"earnings_per_share_diluted"`
+	TotalAssets        *float64 `json:"total_assets"`
+	TotalLiabilities   *float64 `json:"total_liabilities"`
+	ShareholdersEquity *float64 `json:"shareholders_equity"`
+	TotalDebt          *float64 `json:"total_debt"`
+	CurrentAssets      *float64 `json:"current_assets"`
+	CurrentLiabilities *float64 `json:"current_liabilities"`
+	OperatingCashFlow  *float64 `json:"net_cash_flow_from_operations"`
+	CapitalExpenditure *float64 `json:"capital_expenditure"`
+	FreeCashFlow       *float64 `json:"free_cash_flow"`
+}
+
+type financialDatasetsMetrics struct {
+	ReportPeriod      string   `json:"report_period"`
+	MarketCap         *float64 `json:"market_cap"`
+	PriceToEarnings   *float64 `json:"price_to_earnings_ratio"`
+	PriceToBook       *float64 `json:"price_to_book_ratio"`
+	PriceToSales      *float64 `json:"price_to_sales_ratio"`
+	EVToEBITDA        *float64 `json:"enterprise_value_to_ebitda_ratio"`
+	FreeCashFlowYield *float64 `json:"free_cash_flow_yield"`
+	GrossMargin       *float64 `json:"gross_margin"`
+	OperatingMargin   *float64 `json:"operating_margin"`
+	NetMargin         *float64 `json:"net_margin"`
+	ReturnOnEquity    *float64 `json:"return_on_equity"`
+	ReturnOnAssets    *float64 `json:"return_on_assets"`
+	DebtToEquity      *float64 `json:"debt_to_equity"`
+	CurrentRatio      *float64 `json:"current_ratio"`
+	EPS               *float64 `json:"earnings_per_share"`
+}
+
+// GetFundamentals implements FundamentalsProvider, joining the income
+// statement, balance sheet and cash flow statement of each period with its
+// financial metrics. Unknown tickers return nothing.
+func (c *FinancialDatasetsClient) GetFundamentals(ctx context.Context, symbol, period string, limit int) ([]models.Fundamentals, error) {
+	query := url.Values{
+		"ticker": {symbol},
+		"period": {period},
+		"limit":  {strconv.Itoa(limit)},
+	}
+
+	var financials struct {
+		Financials struct {
+			IncomeStatements   []financialDatasetsStatement `json:"income_statements"`
+			BalanceSheets      []financialDatasetsStatement `json:"balance_sheets"`
+			CashFlowStatements []financialDatasetsStatement `json:"cash_flow_statements"`
+		} `json:"financials"`
+	}
+	found, err := c.getJSON(ctx, "/financials/", query, &financials)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch financials for %s: %w", symbol, err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	var metrics struct {
+		FinancialMetrics []financialDatasetsMetrics `json:"financial_metrics"`
+	}
+	if _, err := c.getJSON(ctx, "/financial-metrics/", query, &metrics); err != nil {
+		return nil, fmt.Errorf("failed to fetch financial metrics for %s: %w", symbol, err)
+	}
+
+	byPeriod := make(map[string]*models.Fundamentals)
+	var order []string
+	periodOf := func(reportPeriod string) (*models.Fundamentals, error) {
+		if f, ok := byPeriod[reportPeriod]; ok {
+			return f, nil
+		}
+		date, err := time.Parse("2006-01-02", reportPeriod)
+		if err != nil {
+			return nil, fmt.Errorf("invalid report period %q for %s: %w", reportPeriod, symbol, err)
+		}
+		f := &models.Fundamentals{Symbol: symbol, Period: period, ReportPeriod: date, Currency: "USD", Source: SourceFinancialDatasets}
+		byPeriod[reportPeriod] = f
+		order = append(order, reportPeriod)
+		return f, nil
+	}
+
+	statements := append(append(financials.Financials.IncomeStatements, financials.Financials.BalanceSheets...),
+		financials.Financials.CashFlowStatements...)
+	for _, s := range statements {
+		f, err := periodOf(s.ReportPeriod)
+		if err != nil {
+			return nil, err
+		}
+		if s.Currency != "" {
+			f.Currency = s.Currency
+		}
+		merge(&f.Revenue, s.Revenue)
+		merge(&f.NetIncome, s.NetIncome)
+		merge(&f.EPS, s.EPSDiluted)
+		merge(&f.TotalAssets, s.TotalAssets)
+		merge(&f.TotalLiabilities, s.TotalLiabilities)
+		merge(&f.ShareholdersEquity, s.ShareholdersEquity)
+		merge(&f.TotalDebt, s.TotalDebt)
+		merge(&f.CurrentAssets, s.CurrentAssets)
+		merge(&f.CurrentLiabilities, s.CurrentLiabilities)
+		merge(&f.OperatingCashFlow, s.OperatingCashFlow)
+		merge(&f.CapitalExpenditure, s.CapitalExpenditure)
+		merge(&f.FreeCashFlow, s.FreeCashFlow)
+	}
+	for _, m := range metrics.FinancialMetrics {
+		f, err := periodOf(m.ReportPeriod)
+		if err != nil {
+			return nil, err
+		}
+		merge(&f.EPS, m.EPS)
+		merge(&f.MarketCap, m.MarketCap)
+		merge(&f.PriceToEarnings, m.PriceToEarnings)
+		merge(&f.PriceToBook, m.PriceToBook)
+		merge(&f.PriceToSales, m.PriceToSales)
+		merge(&f.EVToEBITDA, m.EVToEBITDA)
+		merge(&f.FreeCashFlowYield, m.FreeCashFlowYield)
+		merge(&f.GrossMargin, m.GrossMargin)
+		merge(&f.OperatingMargin, m.OperatingMargin)
+		merge(&f.NetMargin, m.NetMargin)
+		merge(&f.ReturnOnEquity, m.ReturnOnEquity)
+		merge(&f.ReturnOnAssets, m.ReturnOnAssets)
+		merge(&f.DebtToEquity, m.DebtToEquity)
+		merge(&f.CurrentRatio, m.CurrentRatio)
+	}
+
+	// Report periods are formatted YYYY-MM-DD, so they sort as dates
+	sort.Sort(sort.Reverse(sort.StringSlice(order)))
+	fundamentals := make([]models.Fundamentals, 0, len(order))
+	for _, reportPeriod := range order {
+		fundamentals = append(fundamentals, *byPeriod[reportPeriod])
+	}
+	return fundamentals, nil
+}
+
+// merge keeps the first reported value of a field
+func merge(field **float64, value *float64) {
+	if *field == nil {
+		*field = value
+	}
+}
+
+// OwnershipProvider loads a company's latest insider transactions and
+// institutional holdings
+type OwnershipProvider interface {
+	GetInsiderTrades(ctx context.Context, symbol string, limit int) ([]models.InsiderTrade, error)
+	GetInstitutionalHoldings(ctx context.Context, symbol string, limit int) ([]models.InstitutionalHolding, error)
+}
+
+type financialDatasetsInsiderTrade struct {
+	Name             string   `json:"name"`
+	Title            string   `json:"title"`
+	IsBoardDirector  bool     `json:"is_board_director"`
+	SecurityTitle    string   `json:"security_title"`
+	TransactionDate  string   `json:"transaction_date"`
+	FilingDate       string   `json:"filing_date"`
+	Shares           *float64 `json:"transaction_shares"`
+	Price            *float64 `json:"transaction_price_per_share"`
+	Value            *float64 `json:"transaction_value"`
+	SharesOwnedAfter *float64 `json:"shares_owned_after_transaction"`
+}
+
+type financialDatasetsHolding struct {
+	Investor     string   `json:"investor"`
+	ReportPeriod string   `json:"report_period"`
+	Shares       float64  `json:"shares"`
+	MarketValue  *float64 `json:"market_value"`
+}
+
+// GetInsiderTrades implements OwnershipProvider with the latest Form 4
+// transactions, newest filing first
+func (c *FinancialDatasetsClient) GetInsiderTrades(ctx context.Context, symbol string, limit int) ([]models.InsiderTrade, error) {
+	var payload struct {
+		InsiderTrades []financialDatasetsInsiderTrade `json:"insider_trades"`
+	}
+	query := url.Values{"ticker": {symbol}, "limit": {strconv.Itoa(limit)}}
+	if _, err := c.getJSON(ctx, "/insider-trades/", query, &payload); err != nil {
+		return nil, fmt.Errorf("failed to fetch insider trades for %s: %w", symbol, err)
+	}
+
+	trades := make([]models.InsiderTrade, 0, len(payload.InsiderTrades))
+	for _, t := range payload.InsiderTrades {
+		filed, err := time.Parse("2006-01-02", t.FilingDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filing date %q for %s: %w", t.FilingDate, symbol, err)
+		}
+		trade := models.InsiderTrade{
+			Symbol:           symbol,
+			InsiderName:      t.Name,
+			Title:            t.Title,
+			IsDirector:       t.IsBoardDirector,
+			SecurityTitle:    t.SecurityTitle,
+			FilingDate:       filed,
+			Shares:           t.Shares,
+			Price:            t.Price,
+			Value:            t.Value,
+			SharesOwnedAfter: t.SharesOwnedAfter,
+			Source:           SourceFinancialDatasets,
+		}
+		if traded, err := time.Parse("2006-01-02", t.TransactionDate); err == nil {
+			trade.TransactionDate = &traded
+		}
+		trades = append(trades, trade)
+	}
+	return trades, nil
+}
+
+// GetInstitutionalHoldings implements OwnershipProvider with the latest 13F
+// holdings, newest report period first
+func (c *FinancialDatasetsClient) GetInstitutionalHoldings(ctx context.Context, symbol string, limit int) ([]models.InstitutionalHolding, error) {
+	var payload struct {
+		InstitutionalOwnership []financialDatasetsHolding `json:"institutional_ownership"`
+	}
+	query := url.Values{"ticker": {symbol}, "limit": {strconv.Itoa(limit)}}
+	if _, err := c.getJSON(ctx, "/institutional-ownership/", query, &payload); err != nil {
+		return nil, fmt.Errorf("failed to fetch institutional ownership for %s: %w", symbol, err)
+	}
+
+	holdings := make([]models.InstitutionalHolding, 0, len(payload.InstitutionalOwnership))
+	for _, h := range payload.InstitutionalOwnership {
+		period, err := time.Parse("2006-01-02", h.ReportPeriod)
+		if err != nil {
+			return nil, fmt.Errorf("invalid report period %q for %s: %w", h.ReportPeriod, symbol, err)
+		}
+		holdings = append(holdings, models.InstitutionalHolding{
+			Symbol:       symbol,
+			Investor:     h.Investor,
+			ReportPeriod: period,
+			Shares:       h.Shares,
+			MarketValue:  h.MarketValue,
+			Source:       SourceFinancialDatasets,
+		})
+	}
+	return holdings, nil
+}
@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// SourceFRED identifies treasury yields loaded from the St. Louis Fed's FRED API
+const SourceFRED = "fred"
+
+// fredSeries maps treasury tenors to FRED's constant maturity yield series
+var fredSeries = map[string]string{
+	"1m":  "DGS1MO",
+	"3m":  "DGS3MO",
+	"6m":  "DGS6MO",
+	"1y":  "DGS1",
+	"2y":  "DGS2",
+	"5y":  "DGS5",
+	"10y": "DGS10",
+}
+
+// TreasuryTenors lists the tenors a RateProvider can load, shortest first
+var TreasuryTenors = []string{"1m", "3m", "6m", "1y", "2y", "5y", "10y"}
+
+// RateProvider loads historical daily treasury yields from an upstream source
+type RateProvider interface {
+	GetTreasuryYields(ctx context.Context, tenor string, start, end time.Time) ([]models.RiskFreeRate, error)
+}
+
+// FREDClient reads treasury yields from the FRED REST API
+type FREDClient struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func NewFREDClient(baseURL, apiKey string) *FREDClient {
+	return &FREDClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetTreasuryYields implements RateProvider. start and end are inclusive
+// dates; days FRED reports no yield for (market holidays) are skipped.
+func (c *FREDClient) GetTreasuryYields(ctx context.Context, tenor string, start, end time.Time) ([]models.RiskFreeRate, error) {
+	series, ok := fredSeries[tenor]
+	if !ok {
+		return nil, fmt.Errorf("unsupported treasury tenor %q", tenor)
+	}
+
+	query := url.Values{
+		"series_id":         {series},
+		"api_key":           {c.apiKey},
+		"file_type":         {"json"},
+		"observation_start": {start.UTC().Format("2006-01-02")},
+		"observation_end":   {end.UTC().Format("2006-01-02")},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/series/observations?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s treasury yields: %w", tenor, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("FRED API returned %d for %s: %s", resp.StatusCode, series, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Observations []struct {
+			Date  string `json:"date"`
+			Value string `json:"value"`
+		} `json:"observations"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode %s treasury yields: %w", tenor, err)
+	}
+
+	rates := make([]models.RiskFreeRate, 0, len(payload.Observations))
+	for _, o := range payload.Observations {
+		if o.Value == "." {
+			continue // No yield published that day
+		}
+		date, err := time.Parse("2006-01-02", o.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid observation date %q for %s: %w", o.Date, series, err)
+		}
+		percent, err := strconv.ParseFloat(o.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid yield %q on %s for %s: %w", o.Value, o.Date, series, err)
+		}
+		rates = append(rates, models.RiskFreeRate{
+			Tenor:  tenor,
+			Date:   date,
+			Rate:   percent / 100,
+			Source: SourceFRED,
+		})
+	}
+	return rates, nil
+}
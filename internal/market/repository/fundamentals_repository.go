@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+)
+
+type FundamentalsRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewFundamentalsRepository(db *database.DB, logger *zap.Logger) *FundamentalsRepository {
+	return &FundamentalsRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// UpsertFundamentals stores fundamentals in one transaction, replacing any
+// stored for the same symbol, period and report period
+func (r *FundamentalsRepository) UpsertFundamentals(ctx context.Context, fundamentals []models.Fundamentals) error {
+	if len(fundamentals) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO fundamentals (symbol, period, report_period, currency, revenue, net_income, eps,
+		                          total_assets, total_liabilities, shareholders_equity, total_debt,
+		                          current_assets, current_liabilities, operating_cash_flow,
+		                          capital_expenditure, free_cash_flow, gross_margin, operating_margin,
+		                          net_margin, return_on_equity, return_on_assets, debt_to_equity,
+		                          current_ratio, market_cap, price_to_earnings, price_to_book,
+		                          price_to_sales, ev_to_ebitda, free_cash_flow_yield, source, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
+		        $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, NOW())
+		ON CONFLICT (symbol, period, report_period) DO UPDATE
+		SET currency = EXCLUDED.currency, revenue = EXCLUDED.revenue, net_income = EXCLUDED.net_income,
+		    eps = EXCLUDED.eps, total_assets = EXCLUDED.total_assets,
+		    total_liabilities = EXCLUDED.total_liabilities, shareholders_equity = EXCLUDED.shareholders_equity,
+		    total_debt = EXCLUDED.total_debt, current_assets = EXCLUDED.current_assets,
+		    current_liabilities = EXCLUDED.current_liabilities, operating_cash_flow = EXCLUDED.operating_cash_flow,
+		    capital_expenditure = EXCLUDED.capital_expenditure, free_cash_flow = EXCLUDED.free_cash_flow,
+		    gross_margin = EXCLUDED.gross_margin, operating_margin = EXCLUDED.operating_margin,
+		    net_margin = EXCLUDED.net_margin, return_on_equity = EXCLUDED.return_on_equity,
+		    return_on_assets = EXCLUDED.return_on_assets, debt_to_equity = EXCLUDED.debt_to_equity,
+		    current_ratio = EXCLUDED.current_ratio, market_cap = EXCLUDED.market_cap,
+		    price_to_earnings = EXCLUDED.price_to_earnings, price_to_book = EXCLUDED.price_to_book,
+		    price_to_sales = EXCLUDED.price_to_sales, ev_to_ebitda = EXCLUDED.ev_to_ebitda,
+		    free_cash_flow_yield = EXCLUDED.free_cash_flow_yield, source = EXCLUDED.source,
+		    fetched_at = EXCLUDED.fetched_at`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare fundamentals upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, f := range fundamentals {
+		_, err := stmt.ExecContext(ctx, f.Symbol, f.Period, f.ReportPeriod, f.Currency, f.Revenue, f.NetIncome, f.EPS,
+			f.TotalAssets, f.TotalLiabilities, f.ShareholdersEquity, f.TotalDebt, f.CurrentAssets,
+			f.CurrentLiabilities, f.OperatingCashFlow, f.CapitalExpenditure, f.FreeCashFlow, f.GrossMargin,
+			f.OperatingMargin, f.NetMargin, f.ReturnOnEquity, f.ReturnOnAssets, f.DebtToEquity, f.CurrentRatio,
+			f.MarketCap, f.PriceToEarnings, f.PriceToBook, f.PriceToSales, f.EVToEBITDA, f.FreeCashFlowYield,
+			f.Source)
+		if err != nil {
+			r.logger.Error("Failed to upsert fundamentals", zap.Error(err),
+				zap.String("symbol", f.Symbol), zap.Time("report_period", f.ReportPeriod))
+			return fmt.Errorf("failed to upsert fundamentals: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit fundamentals: %w", err)
+	}
+	return nil
+}
+
+// GetFundamentals retrieves a symbol's stored fundamentals for its latest
+// limit fiscal periods of a kind, newest first
+func (r *FundamentalsRepository) GetFundamentals(ctx context.Context, symbol, period string, limit int) ([]models.Fundamentals, error) {
+	query := `
+		SELECT symbol, period, report_period, COALESCE(currency, 'USD'), revenue, net_income, eps,
+		       total_assets, total_liabilities, shareholders_equity, total_debt, current_assets,
+		       current_liabilities, operating_cash_flow, capital_expenditure, free_cash_flow, gross_margin,
+		       operating_margin, net_margin, return_on_equity, return_on_assets, debt_to_equity,
+		       current_ratio, market_cap, price_to_earnings, price_to_book, price_to_sales, ev_to_ebitda,
+		       free_cash_flow_yield, source, fetched_at
+		FROM fundamentals
+		WHERE symbol = $1 AND period = $2
+		ORDER BY report_period DESC
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, symbol, period, limit)
+	if err != nil {
+		r.logger.Error("Failed to get fundamentals", zap.Error(err), zap.String("symbol", symbol))
+		return nil, fmt.Errorf("failed to get fundamentals: %w", err)
+	}
+	defer rows.Close()
+
+	var fundamentals []models.Fundamentals
+	for rows.Next() {
+		var f models.Fundamentals
+		err := rows.Scan(&f.Symbol, &f.Period, &f.ReportPeriod, &f.Currency, &f.Revenue, &f.NetIncome, &f.EPS,
+			&f.TotalAssets, &f.TotalLiabilities, &f.ShareholdersEquity, &f.TotalDebt, &f.CurrentAssets,
+			&f.CurrentLiabilities, &f.OperatingCashFlow, &f.CapitalExpenditure, &f.FreeCashFlow, &f.GrossMargin,
+			&f.OperatingMargin, &f.NetMargin, &f.ReturnOnEquity, &f.ReturnOnAssets, &f.DebtToEquity,
+			&f.CurrentRatio, &f.MarketCap, &f.PriceToEarnings, &f.PriceToBook, &f.PriceToSales, &f.EVToEBITDA,
+			&f.FreeCashFlowYield, &f.Source, &f.FetchedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fundamentals: %w", err)
+		}
+		fundamentals = append(fundamentals, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating fundamentals: %w", err)
+	}
+
+	return fundamentals, nil
+}
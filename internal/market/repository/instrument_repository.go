@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// instrumentRelevance ranks an exact symbol match first, then symbol
+// prefixes, then names containing the search term, each ordered by how
+// similar the name is. It reads the search term from $1 and the LIKE
+// patterns from $2 and $3 of SearchInstruments' query.
+const instrumentRelevance = `(CASE WHEN symbol = upper($1) THEN 3 WHEN symbol LIKE $3 THEN 2 WHEN name ILIKE $2 THEN 1 ELSE 0 END
+	+ similarity(COALESCE(name, ''), $1))::double precision`
+
+// InstrumentSorts are the orders instrument searches can be listed in
+var InstrumentSorts = pagination.Sorts{
+	ID: pagination.Field{Column: "symbol", Type: "text"},
+	Fields: map[string]pagination.Field{
+		"relevance": {Column: instrumentRelevance, Type: "double precision"},
+		"symbol":    {Column: "symbol", Type: "text"},
+		"name":      {Column: "COALESCE(name, '')", Type: "text"},
+	},
+	Default: "relevance",
+	Desc:    true,
+}
+
+// likeEscaper escapes the LIKE wildcards in a search term
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// InstrumentFilter narrows an instrument search
+type InstrumentFilter struct {
+	Query      string // Symbol prefix or name, matched fuzzily; empty matches every instrument
+	AssetClass string
+	Tradable   *bool
+}
+
+type InstrumentRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewInstrumentRepository(db *database.DB, logger *zap.Logger) *InstrumentRepository {
+	return &InstrumentRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetInstrument retrieves reference metadata for a single symbol
+func (r *InstrumentRepository) GetInstrument(ctx context.Context, symbol string) (*models.Instrument, error) {
+	symbol = symbols.Normalize(symbol)
+
+	query := `
+		SELECT symbol, COALESCE(name, ''), asset_class, COALESCE(sector, ''), COALESCE(industry, ''),
+		       COALESCE(exchange, ''), currency, underlying, option_type, strike, expiry, multiplier,
+		       tradable, created_at, updated_at
+		FROM instruments
+		WHERE symbol = $1`
+
+	instrument, err := scanInstrument(r.db.QueryRowContext(ctx, query, symbol))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("instrument not found: %s", symbol)
+		}
+		r.logger.Error("Failed to get instrument", zap.Error(err), zap.String("symbol", symbol))
+		return nil, fmt.Errorf("failed to get instrument: %w", err)
+	}
+
+	return instrument, nil
+}
+
+// GetInstrumentsBySymbols retrieves reference metadata for a set of symbols.
+// Unknown symbols are omitted from the result.
+func (r *InstrumentRepository) GetInstrumentsBySymbols(ctx context.Context, symbols []string) (map[string]models.Instrument, error) {
+	query := `
+		SELECT symbol, COALESCE(name, ''), asset_class, COALESCE(sector, ''), COALESCE(industry, ''),
+		       COALESCE(exchange, ''), currency, underlying, option_type, strike, expiry, multiplier,
+		       tradable, created_at, updated_at
+		FROM instruments
+		WHERE symbol = ANY($1)`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(symbols))
+	if err != nil {
+		r.logger.Error("Failed to get instruments", zap.Error(err), zap.Strings("symbols", symbols))
+		return nil, fmt.Errorf("failed to get instruments: %w", err)
+	}
+	defer rows.Close()
+
+	instruments := make(map[string]models.Instrument)
+	for rows.Next() {
+		instrument, err := scanInstrument(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan instrument", zap.Error(err))
+			continue
+		}
+		instruments[instrument.Symbol] = *instrument
+	}
+
+	return instruments, nil
+}
+
+// UpsertInstrument creates or replaces reference metadata for a symbol
+func (r *InstrumentRepository) UpsertInstrument(ctx context.Context, instrument *models.Instrument) error {
+	instrument.Symbol = symbols.Normalize(instrument.Symbol)
+
+	query := `
+		INSERT INTO instruments (symbol, name, asset_class, sector, industry, exchange, currency,
+		                         underlying, option_type, strike, expiry, multiplier, tradable)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (symbol) DO UPDATE
+		SET name = EXCLUDED.name, asset_class = EXCLUDED.asset_class, sector = EXCLUDED.sector,
+		    industry = EXCLUDED.industry, exchange = EXCLUDED.exchange, currency = EXCLUDED.currency,
+		    underlying = EXCLUDED.underlying, option_type = EXCLUDED.option_type, strike = EXCLUDED.strike,
+		    expiry = EXCLUDED.expiry, multiplier = EXCLUDED.multiplier, tradable = EXCLUDED.tradable
+		RETURNING created_at, updated_at`
+
+	var underlying, optionType *string
+	var strike, multiplier *float64
+	var expiry *time.Time
+	if o := instrument.Option; o != nil {
+		underlying, optionType = &o.Underlying, &o.Type
+		strike, multiplier = &o.Strike, &o.Multiplier
+		expiry = &o.Expiry
+	}
+
+	err := r.db.QueryRowContext(ctx, query,
+		instrument.Symbol,
+		instrument.Name,
+		instrument.AssetClass,
+		instrument.Sector,
+		instrument.Industry,
+		instrument.Exchange,
+		instrument.Currency,
+		underlying,
+		optionType,
+		strike,
+		expiry,
+		multiplier,
+		instrument.Tradable,
+	).Scan(&instrument.CreatedAt, &instrument.UpdatedAt)
+
+	if err != nil {
+		r.logger.Error("Failed to upsert instrument", zap.Error(err), zap.String("symbol", instrument.Symbol))
+		return fmt.Errorf("failed to upsert instrument: %w", err)
+	}
+
+	r.logger.Info("Instrument upserted successfully", zap.String("symbol", instrument.Symbol))
+	return nil
+}
+
+// SearchInstruments retrieves a page of the instruments whose symbol starts
+// with the query or whose name contains it or resembles it
+func (r *InstrumentRepository) SearchInstruments(ctx context.Context, filter InstrumentFilter, page pagination.Request) ([]models.Instrument, pagination.Result, error) {
+	term := strings.TrimSpace(filter.Query)
+	args := []interface{}{
+		term,
+		"%" + likeEscaper.Replace(term) + "%",
+		likeEscaper.Replace(symbols.Normalize(term)) + "%",
+	}
+	where := `
+		WHERE ($1 = '' OR symbol LIKE $3 OR name ILIKE $2 OR name % $1)`
+	if filter.AssetClass != "" {
+		args = append(args, filter.AssetClass)
+		where += fmt.Sprintf(" AND asset_class = $%d", len(args))
+	}
+	if filter.Tradable != nil {
+		args = append(args, *filter.Tradable)
+		where += fmt.Sprintf(" AND tradable = $%d", len(args))
+	}
+
+	cond, after := page.After(len(args) + 1)
+	query := `
+		SELECT symbol, COALESCE(name, ''), asset_class, COALESCE(sector, ''), COALESCE(industry, ''),
+		       COALESCE(exchange, ''), currency, underlying, option_type, strike, expiry, multiplier,
+		       tradable, created_at, updated_at, ` + page.Key() + `
+		FROM instruments` + where + cond + page.OrderBy()
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, after...)...)
+	if err != nil {
+		r.logger.Error("Failed to search instruments", zap.Error(err), zap.String("query", term))
+		return nil, pagination.Result{}, fmt.Errorf("failed to search instruments: %w", err)
+	}
+	defer rows.Close()
+
+	var instruments []models.Instrument
+	var keys []string
+	for rows.Next() {
+		var key string
+		instrument, err := scanInstrument(rows, &key)
+		if err != nil {
+			return nil, pagination.Result{}, fmt.Errorf("failed to scan instrument: %w", err)
+		}
+		instruments = append(instruments, *instrument)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("error iterating instruments: %w", err)
+	}
+
+	var result pagination.Result
+	if page.More(len(instruments)) {
+		instruments = instruments[:page.Limit]
+		result.NextCursor = page.Cursor(keys[page.Limit-1], instruments[page.Limit-1].Symbol)
+	}
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM instruments`+where, args...).Scan(&result.Total); err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("failed to count instruments: %w", err)
+	}
+
+	return instruments, result, nil
+}
+
+// GetSymbols retrieves every symbol with reference metadata
+func (r *InstrumentRepository) GetSymbols(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT symbol FROM instruments`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get symbols: %w", err)
+	}
+	defer rows.Close()
+
+	var list []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan symbol: %w", err)
+		}
+		list = append(list, symbol)
+	}
+	return list, rows.Err()
+}
+
+// SetTradable flags the instruments of the given asset classes as tradable
+// exactly when their symbol is listed, and returns how many flags changed
+func (r *InstrumentRepository) SetTradable(ctx context.Context, assetClasses, listed []string) (int64, error) {
+	query := `
+		UPDATE instruments
+		SET tradable = (symbol = ANY($2))
+		WHERE asset_class = ANY($1) AND tradable <> (symbol = ANY($2))`
+
+	result, err := r.db.ExecContext(ctx, query, pq.Array(assetClasses), pq.Array(listed))
+	if err != nil {
+		r.logger.Error("Failed to update tradable instruments", zap.Error(err))
+		return 0, fmt.Errorf("failed to update tradable instruments: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanInstrument reads an instrument row, with the contract terms of options.
+// extra receives any columns selected after the instrument's.
+func scanInstrument(row rowScanner, extra ...interface{}) (*models.Instrument, error) {
+	instrument := &models.Instrument{}
+	var underlying, optionType sql.NullString
+	var strike, multiplier sql.NullFloat64
+	var expiry sql.NullTime
+	dest := []interface{}{
+		&instrument.Symbol,
+		&instrument.Name,
+		&instrument.AssetClass,
+		&instrument.Sector,
+		&instrument.Industry,
+		&instrument.Exchange,
+		&instrument.Currency,
+		&underlying,
+		&optionType,
+		&strike,
+		&expiry,
+		&multiplier,
+		&instrument.Tradable,
+		&instrument.CreatedAt,
+		&instrument.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if underlying.Valid {
+		instrument.Option = &models.OptionContract{
+			Underlying: underlying.String,
+			Type:       optionType.String,
+			Strike:     strike.Float64,
+			Expiry:     expiry.Time.UTC(),
+			Multiplier: multiplier.Float64,
+		}
+	}
+	return instrument, nil
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+)
+
+type OwnershipRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewOwnershipRepository(db *database.DB, logger *zap.Logger) *OwnershipRepository {
+	return &OwnershipRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveOwnership stores a symbol's ingested insider trades and institutional
+// holdings in one transaction and records when they were ingested. Insider
+// trades have no natural key, so the stored trades filed since the oldest
+// ingested filing are replaced.
+func (r *OwnershipRepository) SaveOwnership(ctx context.Context, symbol string, trades []models.InsiderTrade, holdings []models.InstitutionalHolding) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if len(trades) > 0 {
+		oldest := trades[0].FilingDate
+		for _, trade := range trades {
+			if trade.FilingDate.Before(oldest) {
+				oldest = trade.FilingDate
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM insider_trades WHERE symbol = $1 AND filing_date >= $2`, symbol, oldest); err != nil {
+			return fmt.Errorf("failed to replace insider trades: %w", err)
+		}
+
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO insider_trades (symbol, insider_name, title, is_director, security_title, transaction_date,
+			                            filing_date, shares, price, value, shares_owned_after, source)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare insider trade insert: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, t := range trades {
+			_, err := stmt.ExecContext(ctx, symbol, t.InsiderName, t.Title, t.IsDirector, t.SecurityTitle,
+				t.TransactionDate, t.FilingDate, t.Shares, t.Price, t.Value, t.SharesOwnedAfter, t.Source)
+			if err != nil {
+				r.logger.Error("Failed to insert insider trade", zap.Error(err), zap.String("symbol", symbol))
+				return fmt.Errorf("failed to insert insider trade: %w", err)
+			}
+		}
+	}
+
+	if len(holdings) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO institutional_holdings (symbol, investor, report_period, shares, market_value, source, fetched_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NOW())
+			ON CONFLICT (symbol, report_period, investor) DO UPDATE
+			SET shares = EXCLUDED.shares, market_value = EXCLUDED.market_value, source = EXCLUDED.source,
+			    fetched_at = EXCLUDED.fetched_at`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare institutional holding upsert: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, h := range holdings {
+			if _, err := stmt.ExecContext(ctx, symbol, h.Investor, h.ReportPeriod, h.Shares, h.MarketValue, h.Source); err != nil {
+				r.logger.Error("Failed to upsert institutional holding", zap.Error(err), zap.String("symbol", symbol))
+				return fmt.Errorf("failed to upsert institutional holding: %w", err)
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO ownership_updates (symbol, updated_at) VALUES ($1, NOW())
+		ON CONFLICT (symbol) DO UPDATE SET updated_at = EXCLUDED.updated_at`, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to record ownership update: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit ownership: %w", err)
+	}
+	return nil
+}
+
+// GetInsiderTrades retrieves a symbol's insider trades filed since a date,
+// newest filing first. A limit of 0 returns every trade.
+func (r *OwnershipRepository) GetInsiderTrades(ctx context.Context, symbol string, since time.Time, limit int) ([]models.InsiderTrade, error) {
+	query := `
+		SELECT symbol, insider_name, COALESCE(title, ''), is_director, COALESCE(security_title, ''),
+		       transaction_date, filing_date, shares, price, value, shares_owned_after, source
+		FROM insider_trades
+		WHERE symbol = $1 AND filing_date >= $2
+		ORDER BY filing_date DESC, transaction_date DESC NULLS LAST, id`
+	args := []interface{}{symbol, since}
+	if limit > 0 {
+		query += ` LIMIT $3`
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to get insider trades", zap.Error(err), zap.String("symbol", symbol))
+		return nil, fmt.Errorf("failed to get insider trades: %w", err)
+	}
+	defer rows.Close()
+
+	var trades []models.InsiderTrade
+	for rows.Next() {
+		var t models.InsiderTrade
+		var traded sql.NullTime
+		err := rows.Scan(&t.Symbol, &t.InsiderName, &t.Title, &t.IsDirector, &t.SecurityTitle, &traded,
+			&t.FilingDate, &t.Shares, &t.Price, &t.Value, &t.SharesOwnedAfter, &t.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan insider trade: %w", err)
+		}
+		if traded.Valid {
+			t.TransactionDate = &traded.Time
+		}
+		trades = append(trades, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating insider trades: %w", err)
+	}
+
+	return trades, nil
+}
+
+// GetInstitutionalHoldings retrieves a symbol's institutional holdings in
+// its latest periods report periods
+func (r *OwnershipRepository) GetInstitutionalHoldings(ctx context.Context, symbol string, periods int) ([]models.InstitutionalHolding, error) {
+	query := `
+		SELECT symbol, investor, report_period, shares, market_value, source
+		FROM institutional_holdings
+		WHERE symbol = $1 AND report_period IN (
+			SELECT DISTINCT report_period FROM institutional_holdings
+			WHERE symbol = $1
+			ORDER BY report_period DESC
+			LIMIT $2)
+		ORDER BY report_period DESC, shares DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, symbol, periods)
+	if err != nil {
+		r.logger.Error("Failed to get institutional holdings", zap.Error(err), zap.String("symbol", symbol))
+		return nil, fmt.Errorf("failed to get institutional holdings: %w", err)
+	}
+	defer rows.Close()
+
+	var holdings []models.InstitutionalHolding
+	for rows.Next() {
+		var h models.InstitutionalHolding
+		if err := rows.Scan(&h.Symbol, &h.Investor, &h.ReportPeriod, &h.Shares, &h.MarketValue, &h.Source); err != nil {
+			return nil, fmt.Errorf("failed to scan institutional holding: %w", err)
+		}
+		holdings = append(holdings, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating institutional holdings: %w", err)
+	}
+
+	return holdings, nil
+}
+
+// GetOwnershipUpdatedAt returns when a symbol's ownership data was last
+// ingested, or nil when it never was
+func (r *OwnershipRepository) GetOwnershipUpdatedAt(ctx context.Context, symbol string) (*time.Time, error) {
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, `SELECT updated_at FROM ownership_updates WHERE symbol = $1`, symbol).Scan(&updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ownership update time: %w", err)
+	}
+	return &updatedAt, nil
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/internal/market/domain"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+type PriceRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewPriceRepository(db *database.DB, logger *zap.Logger) *PriceRepository {
+	return &PriceRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// UpsertBars stores price bars in one transaction, replacing any bar already
+// stored for the same symbol, interval and timestamp
+func (r *PriceRepository) UpsertBars(ctx context.Context, bars []models.Price) error {
+	if len(bars) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO market_prices (symbol, bar_interval, open, high, low, close, volume, timestamp, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (symbol, bar_interval, timestamp) DO UPDATE
+		SET open = EXCLUDED.open, high = EXCLUDED.high, low = EXCLUDED.low, close = EXCLUDED.close,
+		    volume = EXCLUDED.volume, source = EXCLUDED.source`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare price bar upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, bar := range bars {
+		_, err := stmt.ExecContext(ctx, symbols.Normalize(bar.Symbol), bar.Interval, bar.Open, bar.High, bar.Low, bar.Close,
+			bar.Volume, bar.Timestamp, bar.Source)
+		if err != nil {
+			r.logger.Error("Failed to upsert price bar", zap.Error(err),
+				zap.String("symbol", bar.Symbol), zap.Time("timestamp", bar.Timestamp))
+			return fmt.Errorf("failed to upsert price bar: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit price bars: %w", err)
+	}
+	return nil
+}
+
+// GetBars retrieves stored bars for a symbol between from and to (inclusive), oldest first
+func (r *PriceRepository) GetBars(ctx context.Context, symbol, interval string, from, to time.Time) ([]models.Price, error) {
+	symbol = symbols.Normalize(symbol)
+
+	query := `
+		SELECT symbol, bar_interval, open, high, low, close, volume, timestamp, COALESCE(source, '')
+		FROM market_prices
+		WHERE symbol = $1 AND bar_interval = $2 AND timestamp >= $3 AND timestamp <= $4
+		ORDER BY timestamp`
+
+	rows, err := r.db.QueryContext(ctx, query, symbol, interval, from, to)
+	if err != nil {
+		r.logger.Error("Failed to get price bars", zap.Error(err), zap.String("symbol", symbol))
+		return nil, fmt.Errorf("failed to get price bars: %w", err)
+	}
+	defer rows.Close()
+
+	var bars []models.Price
+	for rows.Next() {
+		bar := models.Price{}
+		err := rows.Scan(
+			&bar.Symbol,
+			&bar.Interval,
+			&bar.Open,
+			&bar.High,
+			&bar.Low,
+			&bar.Close,
+			&bar.Volume,
+			&bar.Timestamp,
+			&bar.Source,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan price bar", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan price bar: %w", err)
+		}
+		bars = append(bars, bar)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating price bars: %w", err)
+	}
+
+	return bars, nil
+}
+
+// GetLatestCloses returns the latest stored daily close of each symbol.
+// Symbols without daily bars are omitted.
+func (r *PriceRepository) GetLatestCloses(ctx context.Context, list []string) (map[string]float64, error) {
+	query := `
+		SELECT DISTINCT ON (symbol) symbol, close
+		FROM market_prices
+		WHERE symbol = ANY($1) AND bar_interval = '1d'
+		ORDER BY symbol, timestamp DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(symbols.NormalizeAll(list)))
+	if err != nil {
+		r.logger.Error("Failed to get latest closes", zap.Error(err), zap.Strings("symbols", list))
+		return nil, fmt.Errorf("failed to get latest closes: %w", err)
+	}
+	defer rows.Close()
+
+	closes := make(map[string]float64, len(list))
+	for rows.Next() {
+		var symbol string
+		var price float64
+		if err := rows.Scan(&symbol, &price); err != nil {
+			return nil, fmt.Errorf("failed to scan latest close: %w", err)
+		}
+		closes[symbol] = price
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating latest closes: %w", err)
+	}
+
+	return closes, nil
+}
+
+// GetLatestQuotes returns the latest stored daily close of each symbol with
+// the close before it, by symbol. Symbols without stored bars are absent.
+func (r *PriceRepository) GetLatestQuotes(ctx context.Context, list []string) (map[string]domain.SymbolQuote, error) {
+	query := `
+		SELECT symbol, close, COALESCE(previous_close, 0), timestamp
+		FROM (
+			SELECT symbol, close, timestamp,
+			       LAG(close) OVER (PARTITION BY symbol ORDER BY timestamp) AS previous_close,
+			       ROW_NUMBER() OVER (PARTITION BY symbol ORDER BY timestamp DESC) AS recency
+			FROM market_prices
+			WHERE symbol = ANY($1) AND bar_interval = '1d'
+		) p
+		WHERE recency = 1`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(symbols.NormalizeAll(list)))
+	if err != nil {
+		r.logger.Error("Failed to get latest quotes", zap.Error(err), zap.Int("symbols", len(list)))
+		return nil, fmt.Errorf("failed to get latest quotes: %w", err)
+	}
+	defer rows.Close()
+
+	quotes := make(map[string]domain.SymbolQuote, len(list))
+	for rows.Next() {
+		var q domain.SymbolQuote
+		if err := rows.Scan(&q.Symbol, &q.Close, &q.PreviousClose, &q.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan latest quote: %w", err)
+		}
+		quotes[q.Symbol] = q
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating latest quotes: %w", err)
+	}
+
+	return quotes, nil
+}
+
+// GetLatestBarTime returns the timestamp of the newest stored bar, or nil when none is stored
+func (r *PriceRepository) GetLatestBarTime(ctx context.Context, symbol, interval string) (*time.Time, error) {
+	symbol = symbols.Normalize(symbol)
+
+	query := `SELECT MAX(timestamp) FROM market_prices WHERE symbol = $1 AND bar_interval = $2`
+
+	var latest sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, symbol, interval).Scan(&latest); err != nil {
+		r.logger.Error("Failed to get latest price bar", zap.Error(err), zap.String("symbol", symbol))
+		return nil, fmt.Errorf("failed to get latest price bar: %w", err)
+	}
+	if !latest.Valid {
+		return nil, nil
+	}
+	return &latest.Time, nil
+}
+
+// GetTrackedSymbols returns every symbol with reference data or stored bars
+func (r *PriceRepository) GetTrackedSymbols(ctx context.Context) ([]string, error) {
+	query := `
+		SELECT symbol FROM instruments
+		UNION
+		SELECT DISTINCT symbol FROM market_prices
+		ORDER BY symbol`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to get tracked symbols", zap.Error(err))
+		return nil, fmt.Errorf("failed to get tracked symbols: %w", err)
+	}
+	defer rows.Close()
+
+	var symbols []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan symbol: %w", err)
+		}
+		symbols = append(symbols, symbol)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating symbols: %w", err)
+	}
+
+	return symbols, nil
+}
+
+// GetDailyMoves returns the latest daily close of every symbol with bars
+// since a date, with the close before it and the symbol's name and sector.
+// Symbols with a single bar since then are omitted.
+func (r *PriceRepository) GetDailyMoves(ctx context.Context, since time.Time) ([]domain.DailyMove, error) {
+	query := `
+		SELECT p.symbol, COALESCE(i.name, ''), COALESCE(i.sector, ''), p.close, p.previous_close, p.volume, p.timestamp
+		FROM (
+			SELECT symbol, close, volume, timestamp,
+			       LAG(close) OVER (PARTITION BY symbol ORDER BY timestamp) AS previous_close,
+			       ROW_NUMBER() OVER (PARTITION BY symbol ORDER BY timestamp DESC) AS recency
+			FROM market_prices
+			WHERE bar_interval = '1d' AND timestamp >= $1
+		) p
+		LEFT JOIN instruments i ON i.symbol = p.symbol
+		WHERE p.recency = 1 AND p.previous_close IS NOT NULL`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		r.logger.Error("Failed to get daily moves", zap.Error(err))
+		return nil, fmt.Errorf("failed to get daily moves: %w", err)
+	}
+	defer rows.Close()
+
+	var moves []domain.DailyMove
+	for rows.Next() {
+		var m domain.DailyMove
+		if err := rows.Scan(&m.Symbol, &m.Name, &m.Sector, &m.Close, &m.PreviousClose, &m.Volume, &m.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan daily move: %w", err)
+		}
+		moves = append(moves, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating daily moves: %w", err)
+	}
+
+	return moves, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+)
+
+type RateRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewRateRepository(db *database.DB, logger *zap.Logger) *RateRepository {
+	return &RateRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// UpsertRates stores risk-free rates in one transaction, replacing any rate
+// already stored for the same tenor and date
+func (r *RateRepository) UpsertRates(ctx context.Context, rates []models.RiskFreeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO risk_free_rates (tenor, rate_date, rate, source)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenor, rate_date) DO UPDATE
+		SET rate = EXCLUDED.rate, source = EXCLUDED.source`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare risk-free rate upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.ExecContext(ctx, rate.Tenor, rate.Date, rate.Rate, rate.Source); err != nil {
+			r.logger.Error("Failed to upsert risk-free rate", zap.Error(err),
+				zap.String("tenor", rate.Tenor), zap.Time("date", rate.Date))
+			return fmt.Errorf("failed to upsert risk-free rate: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit risk-free rates: %w", err)
+	}
+	return nil
+}
+
+// GetRates retrieves the stored rates of a tenor between from and to (inclusive), oldest first
+func (r *RateRepository) GetRates(ctx context.Context, tenor string, from, to time.Time) ([]models.RiskFreeRate, error) {
+	query := `
+		SELECT tenor, rate_date, rate, source
+		FROM risk_free_rates
+		WHERE tenor = $1 AND rate_date >= $2 AND rate_date <= $3
+		ORDER BY rate_date`
+
+	rows, err := r.db.QueryContext(ctx, query, tenor, from, to)
+	if err != nil {
+		r.logger.Error("Failed to get risk-free rates", zap.Error(err), zap.String("tenor", tenor))
+		return nil, fmt.Errorf("failed to get risk-free rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []models.RiskFreeRate
+	for rows.Next() {
+		var rate models.RiskFreeRate
+		if err := rows.Scan(&rate.Tenor, &rate.Date, &rate.Rate, &rate.Source); err != nil {
+			return nil, fmt.Errorf("failed to scan risk-free rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating risk-free rates: %w", err)
+	}
+
+	return rates, nil
+}
+
+// GetLatestRateDate returns the date of the newest stored rate of a tenor, or nil when none is stored
+func (r *RateRepository) GetLatestRateDate(ctx context.Context, tenor string) (*time.Time, error) {
+	query := `SELECT MAX(rate_date) FROM risk_free_rates WHERE tenor = $1`
+
+	var latest sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, tenor).Scan(&latest); err != nil {
+		r.logger.Error("Failed to get latest risk-free rate", zap.Error(err), zap.String("tenor", tenor))
+		return nil, fmt.Errorf("failed to get latest risk-free rate: %w", err)
+	}
+	if !latest.Valid {
+		return nil, nil
+	}
+	return &latest.Time, nil
+}
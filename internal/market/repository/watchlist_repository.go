@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+)
+
+type WatchlistRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewWatchlistRepository(db *database.DB, logger *zap.Logger) *WatchlistRepository {
+	return &WatchlistRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetWatchlistQuotes retrieves a user's watchlist by symbol, quoted at the
+// latest stored daily close with its change from the close before it.
+// Symbols without stored bars are quoted at zero.
+func (r *WatchlistRepository) GetWatchlistQuotes(ctx context.Context, userID int) ([]models.WatchlistItem, error) {
+	query := `
+		SELECT w.id, w.user_id, w.symbol, COALESCE(w.name, ''), w.alert_price, w.alert_enabled,
+		       w.created_at, w.updated_at, COALESCE(p.close, 0), COALESCE(p.previous_close, 0)
+		FROM watchlists w
+		LEFT JOIN LATERAL (
+			SELECT close, LEAD(close) OVER (ORDER BY timestamp DESC) AS previous_close
+			FROM market_prices
+			WHERE symbol = w.symbol AND bar_interval = '1d'
+			ORDER BY timestamp DESC
+			LIMIT 1
+		) p ON true
+		WHERE w.user_id = $1
+		ORDER BY w.symbol`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("Failed to get watchlist", zap.Error(err), zap.Int("user_id", userID))
+		return nil, fmt.Errorf("failed to get watchlist: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.WatchlistItem{}
+	for rows.Next() {
+		var item models.WatchlistItem
+		var previousClose float64
+		err := rows.Scan(&item.ID, &item.UserID, &item.Symbol, &item.Name, &item.AlertPrice, &item.AlertEnabled,
+			&item.CreatedAt, &item.UpdatedAt, &item.CurrentPrice, &previousClose)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan watchlist item: %w", err)
+		}
+		if previousClose > 0 {
+			item.Change = item.CurrentPrice - previousClose
+			item.ChangePercent = item.Change / previousClose * 100
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating watchlist: %w", err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"hedge-fund/internal/market/repository"
+	marketpb "hedge-fund/pkg/proto/market"
+	"hedge-fund/pkg/shared/models"
+	sharedrpc "hedge-fund/pkg/shared/rpc"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Server implements marketpb.MarketDataServiceServer on top of the
+// instrument repository
+type Server struct {
+	marketpb.UnimplementedMarketDataServiceServer
+
+	repo   *repository.InstrumentRepository
+	logger *zap.Logger
+}
+
+func NewServer(repo *repository.InstrumentRepository, logger *zap.Logger) *Server {
+	return &Server{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// GetInstrument returns reference metadata for one symbol
+func (s *Server) GetInstrument(ctx context.Context, req *marketpb.GetInstrumentRequest) (*marketpb.Instrument, error) {
+	symbol := symbols.Normalize(req.GetSymbol())
+	if symbol == "" {
+		return nil, status.Error(codes.InvalidArgument, "symbol is required")
+	}
+
+	instrument, err := s.repo.GetInstrument(ctx, symbol)
+	if err != nil {
+		return nil, sharedrpc.Error(err, codes.Internal)
+	}
+	return toInstrument(instrument), nil
+}
+
+// ListInstruments returns reference metadata for the known symbols in the request
+func (s *Server) ListInstruments(ctx context.Context, req *marketpb.ListInstrumentsRequest) (*marketpb.ListInstrumentsResponse, error) {
+	requested := symbols.NormalizeAll(req.GetSymbols())
+	if len(requested) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one symbol is required")
+	}
+
+	instruments, err := s.repo.GetInstrumentsBySymbols(ctx, requested)
+	if err != nil {
+		return nil, sharedrpc.Error(err, codes.Internal)
+	}
+
+	resp := &marketpb.ListInstrumentsResponse{Instruments: make([]*marketpb.Instrument, 0, len(instruments))}
+	for _, symbol := range requested {
+		if instrument, ok := instruments[symbol]; ok {
+			resp.Instruments = append(resp.Instruments, toInstrument(&instrument))
+		}
+	}
+	return resp, nil
+}
+
+func toInstrument(i *models.Instrument) *marketpb.Instrument {
+	return &marketpb.Instrument{
+		Symbol:     i.Symbol,
+		Name:       i.Name,
+		AssetClass: i.AssetClass,
+		Sector:     i.Sector,
+		Industry:   i.Industry,
+		Exchange:   i.Exchange,
+		Currency:   i.Currency,
+		CreatedAt:  timestamp(i.CreatedAt),
+		UpdatedAt:  timestamp(i.UpdatedAt),
+	}
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
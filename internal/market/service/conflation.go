@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/market/domain"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Publisher publishes events on a pub/sub channel; redis.Client is one
+type Publisher interface {
+	PublishEvent(ctx context.Context, channel string, event interface{}) error
+}
+
+// Conflator publishes every price update on the raw channel and coalesces
+// them per symbol into snapshots published on the conflated channel once per
+// interval, so bursts of updates do not overwhelm downstream consumers
+type Conflator struct {
+	publisher Publisher
+	interval  time.Duration
+	logger    *zap.Logger
+
+	mu         sync.Mutex
+	conflation *domain.Conflation
+}
+
+// NewConflator creates a conflator publishing snapshots every interval. With
+// no interval every update is also published as its own snapshot.
+func NewConflator(publisher Publisher, interval time.Duration, logger *zap.Logger) *Conflator {
+	return &Conflator{
+		publisher:  publisher,
+		interval:   interval,
+		logger:     logger,
+		conflation: domain.NewConflation(),
+	}
+}
+
+// Publish publishes an update on the raw channel and adds it to the next
+// snapshot of its symbol
+func (c *Conflator) Publish(ctx context.Context, update models.PriceUpdateEvent) {
+	if err := c.publisher.PublishEvent(ctx, models.ChannelPriceUpdates, update); err != nil {
+		c.logger.Warn("Failed to publish price update", zap.Error(err), zap.String("symbol", update.Symbol))
+	}
+
+	c.mu.Lock()
+	c.conflation.Add(update)
+	c.mu.Unlock()
+	if c.interval <= 0 {
+		c.Flush(ctx)
+	}
+}
+
+// Flush publishes the pending snapshots on the conflated channel
+func (c *Conflator) Flush(ctx context.Context) {
+	c.mu.Lock()
+	snapshots := c.conflation.Drain()
+	c.mu.Unlock()
+
+	for _, snapshot := range snapshots {
+		snapshot.Type = "price_snapshot"
+		snapshot.Timestamp = time.Now()
+		if err := c.publisher.PublishEvent(ctx, models.ChannelPriceSnapshots, snapshot); err != nil {
+			c.logger.Warn("Failed to publish price snapshot", zap.Error(err), zap.String("symbol", snapshot.Symbol))
+		}
+	}
+}
+
+// Run flushes snapshots every interval until ctx is cancelled, then flushes
+// what is still pending
+func (c *Conflator) Run(ctx context.Context) {
+	if c.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.Flush(context.Background())
+			return
+		case <-ticker.C:
+			c.Flush(ctx)
+		}
+	}
+}
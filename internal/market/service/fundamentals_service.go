@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/market/domain"
+	"hedge-fund/internal/market/provider"
+	"hedge-fund/internal/market/repository"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/redis"
+)
+
+const (
+	// fundamentalsMaxAge is how long stored fundamentals are served before
+	// they are reloaded from the provider; filings change at most daily
+	fundamentalsMaxAge = 24 * time.Hour
+)
+
+// FundamentalsService serves companies' fundamentals from the cache, then
+// from the database, loading them from the provider when none are stored or
+// they are more than a day old
+type FundamentalsService struct {
+	repo     *repository.FundamentalsRepository
+	provider provider.FundamentalsProvider
+	redis    *redis.Client
+	cacheTTL time.Duration
+	logger   *zap.Logger
+}
+
+func NewFundamentalsService(repo *repository.FundamentalsRepository, fundamentalsProvider provider.FundamentalsProvider, redisClient *redis.Client, cacheTTL time.Duration, logger *zap.Logger) *FundamentalsService {
+	return &FundamentalsService{
+		repo:     repo,
+		provider: fundamentalsProvider,
+		redis:    redisClient,
+		cacheTTL: cacheTTL,
+		logger:   logger,
+	}
+}
+
+// GetFundamentals returns a symbol's fundamentals for its latest limit
+// fiscal periods of a kind, newest first. When the provider fails, stale
+// stored fundamentals are served rather than none.
+func (s *FundamentalsService) GetFundamentals(ctx context.Context, symbol, period string, limit int) ([]models.Fundamentals, error) {
+	if !domain.ValidPeriod(period) {
+		return nil, fmt.Errorf("unsupported period: %q", period)
+	}
+
+	key := redis.SymbolKey(symbol, "fundamentals", period, strconv.Itoa(limit))
+	var cached []models.Fundamentals
+	if err := s.redis.GetCache(ctx, key, &cached); err == nil {
+		return cached, nil
+	}
+
+	fundamentals, err := s.repo.GetFundamentals(ctx, symbol, period, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(fundamentals) == 0 || time.Since(fundamentals[0].FetchedAt) > fundamentalsMaxAge {
+		refreshed, err := s.Refresh(ctx, symbol, period, limit)
+		switch {
+		case err == nil:
+			fundamentals = refreshed
+		case len(fundamentals) == 0:
+			return nil, err
+		default:
+			s.logger.Warn("Failed to refresh fundamentals, serving stored", zap.Error(err), zap.String("symbol", symbol))
+		}
+	}
+
+	if fundamentals == nil {
+		fundamentals = []models.Fundamentals{}
+	}
+	if err := s.redis.SetCacheTagged(ctx, key, fundamentals, s.cacheTTL, redis.SymbolTag(symbol)); err != nil {
+		s.logger.Warn("Failed to cache fundamentals", zap.Error(err), zap.String("symbol", symbol))
+	}
+	return fundamentals, nil
+}
+
+// Refresh loads a symbol's fundamentals from the provider, derives the
+// ratios it left out and stores them
+func (s *FundamentalsService) Refresh(ctx context.Context, symbol, period string, limit int) ([]models.Fundamentals, error) {
+	fundamentals, err := s.provider.GetFundamentals(ctx, symbol, period, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range fundamentals {
+		domain.FillRatios(&fundamentals[i])
+	}
+	if err := s.repo.UpsertFundamentals(ctx, fundamentals); err != nil {
+		return nil, err
+	}
+	// Every cached period and limit of the symbol may now be outdated
+	if _, err := s.redis.InvalidateTags(ctx, redis.SymbolTag(symbol)); err != nil {
+		s.logger.Warn("Failed to invalidate cached fundamentals", zap.Error(err), zap.String("symbol", symbol))
+	}
+
+	s.logger.Info("Fundamentals refreshed", zap.String("symbol", symbol), zap.String("period", period),
+		zap.Int("periods", len(fundamentals)))
+	return s.repo.GetFundamentals(ctx, symbol, period, limit)
+}
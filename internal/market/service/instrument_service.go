@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/market/provider"
+	"hedge-fund/internal/market/repository"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// ErrEmptyListing is returned by syncs when the provider lists no symbols,
+// rather than flagging every instrument untradable
+var ErrEmptyListing = errors.New("instrument provider listed no symbols")
+
+// syncedAssetClasses are the asset classes the instrument provider lists.
+// Other instruments are maintained by hand and never flagged by a sync.
+var syncedAssetClasses = []string{"equity", "etf"}
+
+// InstrumentSyncResult summarizes an instrument sync
+type InstrumentSyncResult struct {
+	Listed  int   // Symbols the provider lists
+	Added   int   // New instruments loaded from the provider
+	Failed  int   // New symbols whose metadata could not be loaded
+	Flagged int64 // Instruments whose tradable flag changed
+}
+
+type InstrumentService struct {
+	repo     *repository.InstrumentRepository
+	provider provider.InstrumentProvider
+	queue    *queue.Manager
+	logger   *zap.Logger
+}
+
+func NewInstrumentService(repo *repository.InstrumentRepository, instrumentProvider provider.InstrumentProvider, queue *queue.Manager, logger *zap.Logger) *InstrumentService {
+	return &InstrumentService{
+		repo:     repo,
+		provider: instrumentProvider,
+		queue:    queue,
+		logger:   logger,
+	}
+}
+
+// Search returns a page of the instruments matching filter
+func (s *InstrumentService) Search(ctx context.Context, filter repository.InstrumentFilter, page pagination.Request) ([]models.Instrument, pagination.Result, error) {
+	return s.repo.SearchInstruments(ctx, filter, page)
+}
+
+// RequestSync enqueues an immediate instrument sync
+func (s *InstrumentService) RequestSync() (string, error) {
+	return s.queue.EnqueueMarketDataUpdate(nil, DataTypeInstruments, true)
+}
+
+// Sync loads the metadata of symbols the provider newly lists and flags
+// listed equities and ETFs tradable and the rest untradable. Symbols whose
+// metadata cannot be loaded are logged and retried on the next sync.
+func (s *InstrumentService) Sync(ctx context.Context) (*InstrumentSyncResult, error) {
+	listed, err := s.provider.ListSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	listed = symbols.NormalizeAll(listed)
+	if len(listed) == 0 {
+		return nil, ErrEmptyListing
+	}
+
+	stored, err := s.repo.GetSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(stored))
+	for _, symbol := range stored {
+		known[symbol] = true
+	}
+
+	result := &InstrumentSyncResult{Listed: len(listed)}
+	for _, symbol := range listed {
+		if known[symbol] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		instrument, err := s.provider.GetInstrument(ctx, symbol)
+		if err == nil && instrument != nil {
+			instrument.Symbol = symbol
+			err = s.repo.UpsertInstrument(ctx, instrument)
+		}
+		if err != nil || instrument == nil {
+			s.logger.Warn("Failed to load instrument", zap.Error(err), zap.String("symbol", symbol))
+			result.Failed++
+			continue
+		}
+		result.Added++
+	}
+
+	result.Flagged, err = s.repo.SetTradable(ctx, syncedAssetClasses, listed)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Instruments synced",
+		zap.Int("listed", result.Listed),
+		zap.Int("added", result.Added),
+		zap.Int("failed", result.Failed),
+		zap.Int64("flagged", result.Flagged),
+	)
+	return result, nil
+}
+
+// RunDailySchedule enqueues an instrument sync at hour (UTC) every day until
+// ctx is cancelled
+func (s *InstrumentService) RunDailySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		if _, err := s.queue.EnqueueMarketDataUpdate(nil, DataTypeInstruments, false); err != nil {
+			s.logger.Error("Failed to enqueue daily instrument sync", zap.Error(err))
+		}
+	}
+}
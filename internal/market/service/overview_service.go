@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/market/domain"
+	"hedge-fund/internal/market/repository"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// DataTypeOverview is the market data update job payload for a market
+// overview rebuild
+const DataTypeOverview = "overview"
+
+const (
+	overviewKey = "market:overview"
+	// overviewTTL keeps the last overview served while rebuilds fail
+	overviewTTL = 24 * time.Hour
+	// overviewMovers is how many gainers and losers an overview lists
+	overviewMovers = 10
+	// overviewLookbackDays bounds the bars scanned for each symbol's latest
+	// two closes, spanning long weekends and holidays
+	overviewLookbackDays = 10
+)
+
+// OverviewService builds the market overview from stored daily bars. It is
+// rebuilt on a schedule by the market data update worker and served from
+// the cache.
+type OverviewService struct {
+	repo   *repository.PriceRepository
+	redis  *redis.Client
+	queue  *queue.Manager
+	logger *zap.Logger
+}
+
+func NewOverviewService(repo *repository.PriceRepository, redisClient *redis.Client, queue *queue.Manager, logger *zap.Logger) *OverviewService {
+	return &OverviewService{
+		repo:   repo,
+		redis:  redisClient,
+		queue:  queue,
+		logger: logger,
+	}
+}
+
+// GetOverview returns the cached market overview, building it when none is
+// cached
+func (s *OverviewService) GetOverview(ctx context.Context) (*models.MarketOverview, error) {
+	var overview models.MarketOverview
+	if err := s.redis.GetCache(ctx, overviewKey, &overview); err == nil {
+		return &overview, nil
+	}
+	return s.Refresh(ctx)
+}
+
+// Refresh builds the market overview and caches it
+func (s *OverviewService) Refresh(ctx context.Context) (*models.MarketOverview, error) {
+	moves, err := s.repo.GetDailyMoves(ctx, time.Now().UTC().AddDate(0, 0, -overviewLookbackDays))
+	if err != nil {
+		return nil, err
+	}
+
+	overview := domain.BuildOverview(moves, domain.Indices, overviewMovers)
+	overview.GeneratedAt = time.Now().UTC()
+	if err := s.redis.SetCache(ctx, overviewKey, overview, overviewTTL); err != nil {
+		s.logger.Warn("Failed to cache market overview", zap.Error(err))
+	}
+	return overview, nil
+}
+
+// RunSchedule enqueues a market overview rebuild every interval until ctx
+// is cancelled
+func (s *OverviewService) RunSchedule(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.queue.EnqueueMarketDataUpdate(nil, DataTypeOverview, false); err != nil {
+				s.logger.Error("Failed to enqueue market overview rebuild", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/market/domain"
+	"hedge-fund/internal/market/provider"
+	"hedge-fund/internal/market/repository"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// DataTypeOwnership is the market data update job payload for insider
+// trades and institutional holdings
+const DataTypeOwnership = "ownership"
+
+const (
+	// ownershipMaxAge is how long after its last ingestion a symbol's
+	// ownership data is reported stale; ingestion runs daily
+	ownershipMaxAge = 48 * time.Hour
+	// insiderActivityDays is the window insider buying and selling is
+	// summarized over
+	insiderActivityDays = 90
+	// Rows requested from the provider per ingestion
+	insiderTradesFetchLimit   = 100
+	institutionalHoldingLimit = 1000
+)
+
+// TrackedSymbols lists the symbols scheduled ingestion covers
+type TrackedSymbols interface {
+	GetTrackedSymbols(ctx context.Context) ([]string, error)
+}
+
+// Freshness tells how current a symbol's stored ownership data is
+type Freshness struct {
+	UpdatedAt *time.Time // Last ingestion; nil when never ingested
+	Stale     bool       // Not ingested within ownershipMaxAge
+}
+
+type OwnershipService struct {
+	repo     *repository.OwnershipRepository
+	provider provider.OwnershipProvider
+	tracked  TrackedSymbols
+	queue    *queue.Manager
+	logger   *zap.Logger
+}
+
+func NewOwnershipService(repo *repository.OwnershipRepository, ownershipProvider provider.OwnershipProvider, tracked TrackedSymbols, queue *queue.Manager, logger *zap.Logger) *OwnershipService {
+	return &OwnershipService{
+		repo:     repo,
+		provider: ownershipProvider,
+		tracked:  tracked,
+		queue:    queue,
+		logger:   logger,
+	}
+}
+
+// GetInsiderTrades returns a symbol's latest limit insider trades, newest
+// filing first, with insiders' buying and selling over the last 90 days
+func (s *OwnershipService) GetInsiderTrades(ctx context.Context, symbol string, limit int) ([]models.InsiderTrade, domain.InsiderActivity, Freshness, error) {
+	freshness, err := s.freshness(ctx, symbol)
+	if err != nil {
+		return nil, domain.InsiderActivity{}, Freshness{}, err
+	}
+
+	trades, err := s.repo.GetInsiderTrades(ctx, symbol, time.Time{}, limit)
+	if err != nil {
+		return nil, domain.InsiderActivity{}, Freshness{}, err
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -insiderActivityDays)
+	recent, err := s.repo.GetInsiderTrades(ctx, symbol, since.AddDate(0, 0, -insiderActivityDays), 0)
+	if err != nil {
+		return nil, domain.InsiderActivity{}, Freshness{}, err
+	}
+
+	// Filings trail transactions, so trades filed before the window may
+	// still have been made in it
+	return trades, domain.SummarizeInsiderTrades(recent, since), freshness, nil
+}
+
+// GetInstitutionalOwnership returns the institutional holdings of a symbol
+// in its latest report period and how they changed from the previous one
+func (s *OwnershipService) GetInstitutionalOwnership(ctx context.Context, symbol string) (domain.InstitutionalOwnership, Freshness, error) {
+	freshness, err := s.freshness(ctx, symbol)
+	if err != nil {
+		return domain.InstitutionalOwnership{}, Freshness{}, err
+	}
+
+	holdings, err := s.repo.GetInstitutionalHoldings(ctx, symbol, 2)
+	if err != nil {
+		return domain.InstitutionalOwnership{}, Freshness{}, err
+	}
+	return domain.SummarizeHoldings(holdings), freshness, nil
+}
+
+// UpdateOwnership ingests a symbol's latest insider trades and institutional
+// holdings from the provider
+func (s *OwnershipService) UpdateOwnership(ctx context.Context, symbol string) error {
+	trades, err := s.provider.GetInsiderTrades(ctx, symbol, insiderTradesFetchLimit)
+	if err != nil {
+		return err
+	}
+	holdings, err := s.provider.GetInstitutionalHoldings(ctx, symbol, institutionalHoldingLimit)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SaveOwnership(ctx, symbol, trades, holdings); err != nil {
+		return err
+	}
+
+	s.logger.Info("Ownership updated", zap.String("symbol", symbol),
+		zap.Int("insider_trades", len(trades)), zap.Int("institutional_holdings", len(holdings)))
+	return nil
+}
+
+// UpdateSymbols ingests the ownership data of each listed symbol. Crypto
+// pairs and options have none and are skipped. It is the DataTypeHandler
+// for ownership jobs.
+func (s *OwnershipService) UpdateSymbols(ctx context.Context, list []string) error {
+	var failed []string
+	for _, symbol := range list {
+		if symbols.IsCrypto(symbol) || symbols.IsOption(symbol) {
+			continue
+		}
+		if err := s.UpdateOwnership(ctx, symbol); err != nil {
+			s.logger.Error("Failed to update ownership", zap.Error(err), zap.String("symbol", symbol))
+			failed = append(failed, symbol)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to update ownership for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// RequestUpdate enqueues an immediate ownership update for the symbols
+func (s *OwnershipService) RequestUpdate(list []string) (string, error) {
+	return s.queue.EnqueueMarketDataUpdate(list, DataTypeOwnership, true)
+}
+
+// EnqueueDailyUpdates enqueues ownership updates for every tracked symbol
+func (s *OwnershipService) EnqueueDailyUpdates(ctx context.Context) (int, error) {
+	tracked, err := s.tracked.GetTrackedSymbols(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	enqueued := 0
+	for start := 0; start < len(tracked); start += updateBatchSize {
+		end := start + updateBatchSize
+		if end > len(tracked) {
+			end = len(tracked)
+		}
+		if _, err := s.queue.EnqueueMarketDataUpdate(tracked[start:end], DataTypeOwnership, false); err != nil {
+			s.logger.Error("Failed to enqueue ownership update", zap.Error(err), zap.Strings("symbols", tracked[start:end]))
+			continue
+		}
+		enqueued += end - start
+	}
+
+	return enqueued, nil
+}
+
+// RunDailySchedule enqueues ownership updates for tracked symbols at hour
+// (UTC) every day until ctx is cancelled
+func (s *OwnershipService) RunDailySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		count, err := s.EnqueueDailyUpdates(ctx)
+		if err != nil {
+			s.logger.Error("Failed to enqueue daily ownership updates", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Daily ownership updates enqueued", zap.Int("symbols", count))
+	}
+}
+
+func (s *OwnershipService) freshness(ctx context.Context, symbol string) (Freshness, error) {
+	updatedAt, err := s.repo.GetOwnershipUpdatedAt(ctx, symbol)
+	if err != nil {
+		return Freshness{}, err
+	}
+	return Freshness{
+		UpdatedAt: updatedAt,
+		Stale:     updatedAt == nil || time.Since(*updatedAt) > ownershipMaxAge,
+	}, nil
+}
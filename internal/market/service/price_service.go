@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/market/domain"
+	"hedge-fund/internal/market/provider"
+	"hedge-fund/internal/market/repository"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+)
+
+// DataTypePrices is the market data update job payload for daily bars
+const DataTypePrices = "prices"
+
+// DataTypeInstruments is the market data update job payload for an
+// instrument reference data sync
+const DataTypeInstruments = "instruments"
+
+// updateBatchSize bounds how many symbols a scheduled update job carries
+const updateBatchSize = 50
+
+type PriceService struct {
+	repo         *repository.PriceRepository
+	provider     provider.PriceProvider
+	queue        *queue.Manager
+	updates      *Conflator
+	dataTypes    map[string]DataTypeHandler
+	backfillDays int
+	logger       *zap.Logger
+}
+
+// DataTypeHandler processes the symbols of a market data update job
+type DataTypeHandler func(ctx context.Context, symbols []string) error
+
+func NewPriceService(repo *repository.PriceRepository, provider provider.PriceProvider, queue *queue.Manager, backfillDays int, logger *zap.Logger) *PriceService {
+	s := &PriceService{
+		repo:         repo,
+		provider:     provider,
+		queue:        queue,
+		dataTypes:    make(map[string]DataTypeHandler),
+		backfillDays: backfillDays,
+		logger:       logger,
+	}
+	s.dataTypes[DataTypePrices] = s.updateSymbols
+	return s
+}
+
+// SetPriceUpdates publishes the latest close of each update through the
+// conflator, for consumers such as stop-loss automation. Without a
+// conflator nothing is published.
+func (s *PriceService) SetPriceUpdates(updates *Conflator) {
+	s.updates = updates
+}
+
+// HandleDataType routes market data update jobs of a data type other than
+// prices to handler. Jobs of unrouted data types fail permanently.
+func (s *PriceService) HandleDataType(dataType string, handler DataTypeHandler) {
+	s.dataTypes[dataType] = handler
+}
+
+// GetBars returns bars for a symbol between from and to (inclusive), oldest first
+func (s *PriceService) GetBars(ctx context.Context, symbol, interval string, from, to time.Time) ([]models.Price, error) {
+	stored, err := domain.StoredInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("from must not be after to")
+	}
+
+	bars, err := s.repo.GetBars(ctx, symbol, stored, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return domain.Aggregate(bars, interval), nil
+}
+
+// GetLatestCloses returns the latest stored daily close of each symbol
+// that has one
+func (s *PriceService) GetLatestCloses(ctx context.Context, symbols []string) (map[string]float64, error) {
+	return s.repo.GetLatestCloses(ctx, symbols)
+}
+
+// GetQuotes returns the latest quote of each symbol that has a stored
+// daily close, keyed by normalized symbol
+func (s *PriceService) GetQuotes(ctx context.Context, symbols []string) (map[string]domain.SymbolQuote, error) {
+	return s.repo.GetLatestQuotes(ctx, symbols)
+}
+
+// RequestUpdate enqueues an immediate price update for the symbols
+func (s *PriceService) RequestUpdate(symbols []string) (string, error) {
+	return s.queue.EnqueueMarketDataUpdate(symbols, DataTypePrices, true)
+}
+
+// UpdatePrices loads daily bars for a symbol from the provider, starting at the
+// newest stored bar (so late corrections are picked up) or backfillDays ago
+func (s *PriceService) UpdatePrices(ctx context.Context, symbol string) (int, error) {
+	end := time.Now().UTC()
+	start := end.AddDate(0, 0, -s.backfillDays)
+
+	latest, err := s.repo.GetLatestBarTime(ctx, symbol, domain.IntervalDaily)
+	if err != nil {
+		return 0, err
+	}
+	if latest != nil {
+		start = *latest
+	}
+
+	bars, err := s.provider.GetDailyPrices(ctx, symbol, start, end)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.repo.UpsertBars(ctx, bars); err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("Price bars updated", zap.String("symbol", symbol), zap.Int("bars", len(bars)))
+	s.publishUpdate(ctx, symbol, bars)
+	return len(bars), nil
+}
+
+// publishUpdate publishes the newest of bars as a price update
+func (s *PriceService) publishUpdate(ctx context.Context, symbol string, bars []models.Price) {
+	if s.updates == nil || len(bars) == 0 {
+		return
+	}
+
+	sorted := append([]models.Price(nil), bars...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+	latest := sorted[len(sorted)-1]
+
+	event := models.PriceUpdateEvent{
+		Event: models.Event{
+			Type:      "price_update",
+			Source:    "market",
+			Timestamp: time.Now(),
+		},
+		Symbol: symbol,
+		Price:  latest.Close,
+		Volume: latest.Volume,
+	}
+	if len(sorted) > 1 {
+		event.Change = latest.Close - sorted[len(sorted)-2].Close
+	}
+	s.updates.Publish(ctx, event)
+}
+
+// EnqueueDailyUpdates enqueues price updates for every tracked symbol
+func (s *PriceService) EnqueueDailyUpdates(ctx context.Context) (int, error) {
+	symbols, err := s.repo.GetTrackedSymbols(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	enqueued := 0
+	for start := 0; start < len(symbols); start += updateBatchSize {
+		end := start + updateBatchSize
+		if end > len(symbols) {
+			end = len(symbols)
+		}
+		if _, err := s.queue.EnqueueMarketDataUpdate(symbols[start:end], DataTypePrices, false); err != nil {
+			s.logger.Error("Failed to enqueue price update", zap.Error(err), zap.Strings("symbols", symbols[start:end]))
+			continue
+		}
+		enqueued += end - start
+	}
+
+	return enqueued, nil
+}
+
+// RunDailySchedule enqueues price updates for tracked symbols at hour (UTC)
+// every day until ctx is cancelled
+func (s *PriceService) RunDailySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		count, err := s.EnqueueDailyUpdates(ctx)
+		if err != nil {
+			s.logger.Error("Failed to enqueue daily price updates", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Daily price updates enqueued", zap.Int("symbols", count))
+	}
+}
+
+// CanHandle implements queue.JobHandler
+func (s *PriceService) CanHandle(jobType string) bool {
+	return jobType == models.JobTypeMarketDataUpdate
+}
+
+// Handle implements queue.JobHandler
+func (s *PriceService) Handle(ctx context.Context, job *models.Job) error {
+	dataType, _ := job.Payload["data_type"].(string)
+	handler, ok := s.dataTypes[dataType]
+	if !ok {
+		job.Retries = job.MaxRetries // Retrying cannot help
+		return fmt.Errorf("unsupported market data type: %q", dataType)
+	}
+
+	raw, _ := job.Payload["symbols"].([]interface{})
+	var list []string
+	for _, value := range raw {
+		if symbol, _ := value.(string); symbol != "" {
+			list = append(list, symbol)
+		}
+	}
+	return handler(ctx, list)
+}
+
+// updateSymbols updates the price bars of each symbol
+func (s *PriceService) updateSymbols(ctx context.Context, list []string) error {
+	var failed []string
+	for _, symbol := range list {
+		if _, err := s.UpdatePrices(ctx, symbol); err != nil {
+			s.logger.Error("Failed to update price bars", zap.Error(err), zap.String("symbol", symbol))
+			failed = append(failed, symbol)
+		}
+	}
+
+	// Upserts are idempotent, so a retry simply reloads every symbol in the job
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to update prices for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
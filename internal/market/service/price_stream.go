@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// PriceStream follows the price snapshots published by every market data
+// service instance, for clients watching prices live
+type PriceStream struct {
+	redis  *redis.Client
+	logger *zap.Logger
+}
+
+func NewPriceStream(redisClient *redis.Client, logger *zap.Logger) *PriceStream {
+	return &PriceStream{
+		redis:  redisClient,
+		logger: logger,
+	}
+}
+
+// Watch calls fn with each conflated snapshot of symbols until fn returns
+// false or ctx is done
+func (s *PriceStream) Watch(ctx context.Context, symbols []string, fn func(*models.PriceSnapshotEvent) bool) error {
+	watched := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		watched[symbol] = true
+	}
+
+	pubsub := s.redis.SubscribeToEvents(ctx, models.ChannelPriceSnapshots)
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to price snapshots: %w", err)
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var snapshot models.PriceSnapshotEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &snapshot); err != nil {
+				s.logger.Warn("Failed to decode price snapshot", zap.Error(err))
+				continue
+			}
+			if !watched[snapshot.Symbol] {
+				continue
+			}
+			if !fn(&snapshot) {
+				return nil
+			}
+		}
+	}
+}
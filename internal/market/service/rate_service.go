@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/market/provider"
+	"hedge-fund/internal/market/repository"
+	"hedge-fund/pkg/shared/models"
+)
+
+// ErrRatesDisabled is returned by updates when no rate provider is configured
+var ErrRatesDisabled = errors.New("treasury yield ingestion is disabled")
+
+type RateService struct {
+	repo         *repository.RateRepository
+	provider     provider.RateProvider
+	backfillDays int
+	logger       *zap.Logger
+}
+
+// NewRateService creates a risk-free rate service. rateProvider may be nil,
+// in which case stored rates are served but not updated.
+func NewRateService(repo *repository.RateRepository, rateProvider provider.RateProvider, backfillDays int, logger *zap.Logger) *RateService {
+	return &RateService{
+		repo:         repo,
+		provider:     rateProvider,
+		backfillDays: backfillDays,
+		logger:       logger,
+	}
+}
+
+// GetRates returns a tenor's stored rates between from and to (inclusive), oldest first
+func (s *RateService) GetRates(ctx context.Context, tenor string, from, to time.Time) ([]models.RiskFreeRate, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("from must not be after to")
+	}
+	return s.repo.GetRates(ctx, tenor, from, to)
+}
+
+// UpdateRates loads a tenor's daily yields from the provider, starting at the
+// newest stored rate (so late revisions are picked up) or backfillDays ago
+func (s *RateService) UpdateRates(ctx context.Context, tenor string) (int, error) {
+	if s.provider == nil {
+		return 0, ErrRatesDisabled
+	}
+
+	end := time.Now().UTC()
+	start := end.AddDate(0, 0, -s.backfillDays)
+
+	latest, err := s.repo.GetLatestRateDate(ctx, tenor)
+	if err != nil {
+		return 0, err
+	}
+	if latest != nil {
+		start = *latest
+	}
+
+	rates, err := s.provider.GetTreasuryYields(ctx, tenor, start, end)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.repo.UpsertRates(ctx, rates); err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("Risk-free rates updated", zap.String("tenor", tenor), zap.Int("rates", len(rates)))
+	return len(rates), nil
+}
+
+// UpdateAll updates every treasury tenor and returns how many rates were
+// loaded for each. Tenors that fail are logged and skipped.
+func (s *RateService) UpdateAll(ctx context.Context) (map[string]int, error) {
+	if s.provider == nil {
+		return nil, ErrRatesDisabled
+	}
+
+	updated := make(map[string]int)
+	for _, tenor := range provider.TreasuryTenors {
+		count, err := s.UpdateRates(ctx, tenor)
+		if err != nil {
+			s.logger.Error("Failed to update risk-free rates", zap.Error(err), zap.String("tenor", tenor))
+			continue
+		}
+		updated[tenor] = count
+	}
+	return updated, nil
+}
+
+// RunDailySchedule updates every treasury tenor at hour (UTC) every day until
+// ctx is cancelled
+func (s *RateService) RunDailySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		updated, err := s.UpdateAll(ctx)
+		if err != nil {
+			s.logger.Error("Failed to run daily risk-free rate update", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Daily risk-free rate update completed", zap.Int("tenors", len(updated)))
+	}
+}
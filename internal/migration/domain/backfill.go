@@ -0,0 +1,127 @@
+package domain
+
+import "sort"
+
+// Tables whose rows are backfilled
+const (
+	TablePositions = "positions"
+	TableTrades    = "trades"
+)
+
+// Rules explaining how a row's portfolio was chosen
+const (
+	RuleSinglePortfolio = "single_portfolio" // The user owns exactly one portfolio
+	RulePositionLink    = "position_link"    // A trade follows the position it belongs to
+	RuleSymbolHistory   = "symbol_history"   // Only one of the user's portfolios has scoped rows in the symbol
+)
+
+// Reasons a row could not be assigned
+const (
+	ReasonNoPortfolio = "user has no portfolio"
+	ReasonAmbiguous   = "user has several portfolios and the symbol does not identify one"
+)
+
+// Row is a position or trade without a portfolio
+type Row struct {
+	ID         int
+	UserID     int
+	Symbol     string
+	PositionID int // Trades only; 0 when the trade has no position
+}
+
+// Assignment sets a row's portfolio
+type Assignment struct {
+	Table       string `json:"table"`
+	ID          int    `json:"id"`
+	UserID      int    `json:"user_id"`
+	Symbol      string `json:"symbol"`
+	PortfolioID int    `json:"portfolio_id"`
+	Rule        string `json:"rule"`
+}
+
+// Unresolved is a row left for manual review
+type Unresolved struct {
+	Table      string `json:"table"`
+	ID         int    `json:"id"`
+	UserID     int    `json:"user_id"`
+	Symbol     string `json:"symbol"`
+	Reason     string `json:"reason"`
+	Candidates []int  `json:"candidates,omitempty"`
+}
+
+// BackfillInput is the current state of unscoped and scoped data
+type BackfillInput struct {
+	Portfolios        map[int][]int                   // User ID -> portfolio IDs
+	Positions         []Row                           // Positions without a portfolio
+	Trades            []Row                           // Trades without a portfolio
+	PositionPortfolio map[int]int                     // Scoped position ID -> portfolio ID
+	SymbolPortfolios  map[int]map[string]map[int]bool // User ID -> symbol -> portfolios with scoped rows in it
+}
+
+// BackfillPlan is the outcome of planning a backfill
+type BackfillPlan struct {
+	Assignments []Assignment `json:"assignments"`
+	Unresolved  []Unresolved `json:"unresolved"`
+}
+
+// PlanBackfill decides a portfolio for every unscoped position and trade.
+// Positions are planned first so trades can follow their position's assignment.
+func PlanBackfill(in BackfillInput) BackfillPlan {
+	var plan BackfillPlan
+	positionPortfolio := make(map[int]int, len(in.PositionPortfolio))
+	for id, portfolioID := range in.PositionPortfolio {
+		positionPortfolio[id] = portfolioID
+	}
+
+	for _, row := range in.Positions {
+		portfolioID, rule, unresolved := resolve(in, row)
+		if unresolved != nil {
+			unresolved.Table = TablePositions
+			plan.Unresolved = append(plan.Unresolved, *unresolved)
+			continue
+		}
+		positionPortfolio[row.ID] = portfolioID
+		plan.Assignments = append(plan.Assignments, assignment(TablePositions, row, portfolioID, rule))
+	}
+
+	for _, row := range in.Trades {
+		if portfolioID, ok := positionPortfolio[row.PositionID]; ok && row.PositionID != 0 {
+			plan.Assignments = append(plan.Assignments, assignment(TableTrades, row, portfolioID, RulePositionLink))
+			continue
+		}
+		portfolioID, rule, unresolved := resolve(in, row)
+		if unresolved != nil {
+			unresolved.Table = TableTrades
+			plan.Unresolved = append(plan.Unresolved, *unresolved)
+			continue
+		}
+		plan.Assignments = append(plan.Assignments, assignment(TableTrades, row, portfolioID, rule))
+	}
+
+	return plan
+}
+
+func resolve(in BackfillInput, row Row) (int, string, *Unresolved) {
+	portfolios := in.Portfolios[row.UserID]
+	switch len(portfolios) {
+	case 0:
+		return 0, "", &Unresolved{ID: row.ID, UserID: row.UserID, Symbol: row.Symbol, Reason: ReasonNoPortfolio}
+	case 1:
+		return portfolios[0], RuleSinglePortfolio, nil
+	}
+
+	held := in.SymbolPortfolios[row.UserID][row.Symbol]
+	if len(held) == 1 {
+		for portfolioID := range held {
+			return portfolioID, RuleSymbolHistory, nil
+		}
+	}
+
+	candidates := append([]int(nil), portfolios...)
+	sort.Ints(candidates)
+	return 0, "", &Unresolved{ID: row.ID, UserID: row.UserID, Symbol: row.Symbol, Reason: ReasonAmbiguous, Candidates: candidates}
+}
+
+func assignment(table string, row Row, portfolioID int, rule string) Assignment {
+	return Assignment{Table: table, ID: row.ID, UserID: row.UserID, Symbol: row.Symbol, PortfolioID: portfolioID, Rule: rule}
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanBackfill(t *testing.T) {
+	in := BackfillInput{
+		Portfolios: map[int][]int{
+			1: {10},     // Single portfolio
+			2: {20, 21}, // Several portfolios
+		},
+		Positions: []Row{
+			{ID: 100, UserID: 1, Symbol: "AAPL"},
+			{ID: 101, UserID: 2, Symbol: "MSFT"},
+			{ID: 102, UserID: 2, Symbol: "TSLA"},
+			{ID: 103, UserID: 3, Symbol: "NVDA"},
+		},
+		Trades: []Row{
+			{ID: 200, UserID: 2, Symbol: "MSFT", PositionID: 101},
+			{ID: 201, UserID: 2, Symbol: "TSLA", PositionID: 102},
+			{ID: 202, UserID: 2, Symbol: "AMZN", PositionID: 50},
+		},
+		PositionPortfolio: map[int]int{50: 21},
+		SymbolPortfolios: map[int]map[string]map[int]bool{
+			2: {"MSFT": {20: true}},
+		},
+	}
+
+	plan := PlanBackfill(in)
+
+	assigned := make(map[string]Assignment)
+	for _, a := range plan.Assignments {
+		assigned[fmt.Sprintf("%s:%d", a.Table, a.ID)] = a
+	}
+	require.Len(t, plan.Assignments, 4)
+	assert.Equal(t, Assignment{Table: TablePositions, ID: 100, UserID: 1, Symbol: "AAPL", PortfolioID: 10, Rule: RuleSinglePortfolio}, assigned["positions:100"])
+	assert.Equal(t, RuleSymbolHistory, assigned["positions:101"].Rule)
+	assert.Equal(t, 20, assigned["trades:200"].PortfolioID)
+	assert.Equal(t, RulePositionLink, assigned["trades:200"].Rule)
+	assert.Equal(t, 21, assigned["trades:202"].PortfolioID)
+
+	require.Len(t, plan.Unresolved, 3)
+	assert.Equal(t, Unresolved{Table: TablePositions, ID: 102, UserID: 2, Symbol: "TSLA", Reason: ReasonAmbiguous, Candidates: []int{20, 21}}, plan.Unresolved[0])
+	assert.Equal(t, ReasonNoPortfolio, plan.Unresolved[1].Reason)
+	assert.Equal(t, 201, plan.Unresolved[2].ID) // Its position is unresolved too
+}
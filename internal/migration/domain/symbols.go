@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"sort"
+
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// SymbolTables are the tables with a symbol column, in the order they are normalized
+var SymbolTables = []string{
+	TablePositions, TableTrades, "position_lots", "trade_events", "market_prices", "instruments",
+	"news_items", "technical_indicators", "risk_limits", "risk_metrics", "risk_alerts", "ai_signals",
+	"agent_performance", "watchlists", "reconciliation_breaks",
+}
+
+// SymbolRename rewrites every row of a table holding a symbol in its normalized form
+type SymbolRename struct {
+	Table string `json:"table"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Rows  int    `json:"rows"`
+}
+
+// SymbolPosition is a position considered for merging
+type SymbolPosition struct {
+	ID          int
+	UserID      int
+	PortfolioID int // 0 for positions without a portfolio
+	Symbol      string
+	Quantity    float64
+	EntryPrice  float64
+	RealizedPnL float64
+}
+
+// PositionMerge folds positions of one portfolio whose symbols normalize to
+// the same symbol into the oldest of them
+type PositionMerge struct {
+	KeepID      int     `json:"keep_id"`
+	RemoveIDs   []int   `json:"remove_ids"`
+	UserID      int     `json:"user_id"`
+	PortfolioID int     `json:"portfolio_id"`
+	Symbol      string  `json:"symbol"`
+	Quantity    float64 `json:"quantity"`
+	EntryPrice  float64 `json:"entry_price"` // Weighted by quantity
+	RealizedPnL float64 `json:"realized_pnl"`
+}
+
+// PlanSymbolRenames returns the renames needed for a table given its row
+// count per stored symbol, ordered by symbol
+func PlanSymbolRenames(table string, counts map[string]int) []SymbolRename {
+	var renames []SymbolRename
+	for symbol, rows := range counts {
+		if normalized := symbols.Normalize(symbol); normalized != symbol {
+			renames = append(renames, SymbolRename{Table: table, From: symbol, To: normalized, Rows: rows})
+		}
+	}
+	sort.Slice(renames, func(i, j int) bool { return renames[i].From < renames[j].From })
+	return renames
+}
+
+// PlanPositionMerges finds positions that would become duplicates once their
+// symbols are normalized. The position with the lowest ID is kept and takes
+// the combined quantity and realized P&L of the group.
+func PlanPositionMerges(positions []SymbolPosition) []PositionMerge {
+	type key struct {
+		userID, portfolioID int
+		symbol              string
+	}
+	groups := make(map[key][]SymbolPosition)
+	var order []key
+	for _, p := range positions {
+		k := key{p.UserID, p.PortfolioID, symbols.Normalize(p.Symbol)}
+		if groups[k] == nil {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], p)
+	}
+
+	var merges []PositionMerge
+	for _, k := range order {
+		group := groups[k]
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].ID < group[j].ID })
+
+		merge := PositionMerge{
+			KeepID:      group[0].ID,
+			UserID:      k.userID,
+			PortfolioID: k.portfolioID,
+			Symbol:      k.symbol,
+			EntryPrice:  group[0].EntryPrice,
+		}
+		var cost float64
+		var weight float64
+		for i, p := range group {
+			if i > 0 {
+				merge.RemoveIDs = append(merge.RemoveIDs, p.ID)
+			}
+			merge.Quantity += p.Quantity
+			merge.RealizedPnL += p.RealizedPnL
+			quantity := p.Quantity
+			if quantity < 0 {
+				quantity = -quantity
+			}
+			cost += quantity * p.EntryPrice
+			weight += quantity
+		}
+		if weight > 0 {
+			merge.EntryPrice = cost / weight
+		}
+		merges = append(merges, merge)
+	}
+	return merges
+}
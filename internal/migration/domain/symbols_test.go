@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanSymbolRenames(t *testing.T) {
+	renames := PlanSymbolRenames("trades", map[string]int{"AAPL": 5, "aapl": 2, "BRK-B": 1})
+
+	assert.Equal(t, []SymbolRename{
+		{Table: "trades", From: "BRK-B", To: "BRK.B", Rows: 1},
+		{Table: "trades", From: "aapl", To: "AAPL", Rows: 2},
+	}, renames)
+}
+
+func TestPlanPositionMerges(t *testing.T) {
+	merges := PlanPositionMerges([]SymbolPosition{
+		{ID: 3, UserID: 1, PortfolioID: 10, Symbol: " aapl", Quantity: 30, EntryPrice: 120, RealizedPnL: 5},
+		{ID: 1, UserID: 1, PortfolioID: 10, Symbol: "AAPL", Quantity: 10, EntryPrice: 100, RealizedPnL: 1},
+		{ID: 2, UserID: 1, PortfolioID: 11, Symbol: "aapl", Quantity: 10, EntryPrice: 100}, // Other portfolio
+		{ID: 4, UserID: 1, PortfolioID: 10, Symbol: "MSFT", Quantity: 5, EntryPrice: 300},
+	})
+
+	require.Len(t, merges, 1)
+	merge := merges[0]
+	assert.Equal(t, 1, merge.KeepID)
+	assert.Equal(t, []int{3}, merge.RemoveIDs)
+	assert.Equal(t, "AAPL", merge.Symbol)
+	assert.Equal(t, 40.0, merge.Quantity)
+	assert.InDelta(t, 115.0, merge.EntryPrice, 0.0001)
+	assert.InDelta(t, 6.0, merge.RealizedPnL, 0.0001)
+}
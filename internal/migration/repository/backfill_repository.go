@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/migration/domain"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+)
+
+type MigrationRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewMigrationRepository(db *database.DB, logger *zap.Logger) *MigrationRepository {
+	return &MigrationRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// BeginTx starts a new database transaction
+func (r *MigrationRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// Backfill Operations
+
+// LoadBackfillInput reads portfolios and the scoped and unscoped positions and trades
+func (r *MigrationRepository) LoadBackfillInput(ctx context.Context) (domain.BackfillInput, error) {
+	in := domain.BackfillInput{
+		Portfolios:        make(map[int][]int),
+		PositionPortfolio: make(map[int]int),
+		SymbolPortfolios:  make(map[int]map[string]map[int]bool),
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT id, user_id FROM portfolios WHERE is_active = true ORDER BY id`)
+	if err != nil {
+		r.logger.Error("Failed to load portfolios", zap.Error(err))
+		return in, fmt.Errorf("failed to load portfolios: %w", err)
+	}
+	for rows.Next() {
+		var id, userID int
+		if err := rows.Scan(&id, &userID); err != nil {
+			rows.Close()
+			return in, fmt.Errorf("failed to scan portfolio: %w", err)
+		}
+		in.Portfolios[userID] = append(in.Portfolios[userID], id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return in, fmt.Errorf("error iterating portfolios: %w", err)
+	}
+
+	// Scoped rows tell which portfolio a user already trades a symbol in
+	scoped := `
+		SELECT id, user_id, symbol, portfolio_id, 'positions' FROM positions WHERE portfolio_id IS NOT NULL AND user_id IS NOT NULL
+		UNION ALL
+		SELECT id, user_id, symbol, portfolio_id, 'trades' FROM trades WHERE portfolio_id IS NOT NULL AND user_id IS NOT NULL`
+	rows, err = r.db.QueryContext(ctx, scoped)
+	if err != nil {
+		r.logger.Error("Failed to load scoped rows", zap.Error(err))
+		return in, fmt.Errorf("failed to load scoped rows: %w", err)
+	}
+	for rows.Next() {
+		var id, userID, portfolioID int
+		var symbol, table string
+		if err := rows.Scan(&id, &userID, &symbol, &portfolioID, &table); err != nil {
+			rows.Close()
+			return in, fmt.Errorf("failed to scan scoped row: %w", err)
+		}
+		if table == domain.TablePositions {
+			in.PositionPortfolio[id] = portfolioID
+		}
+		if in.SymbolPortfolios[userID] == nil {
+			in.SymbolPortfolios[userID] = make(map[string]map[int]bool)
+		}
+		if in.SymbolPortfolios[userID][symbol] == nil {
+			in.SymbolPortfolios[userID][symbol] = make(map[int]bool)
+		}
+		in.SymbolPortfolios[userID][symbol][portfolioID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return in, fmt.Errorf("error iterating scoped rows: %w", err)
+	}
+
+	if in.Positions, err = r.loadUnscoped(ctx, `SELECT id, COALESCE(user_id, 0), symbol, 0 FROM positions WHERE portfolio_id IS NULL ORDER BY id`); err != nil {
+		return in, err
+	}
+	if in.Trades, err = r.loadUnscoped(ctx, `SELECT id, COALESCE(user_id, 0), symbol, COALESCE(position_id, 0) FROM trades WHERE portfolio_id IS NULL ORDER BY id`); err != nil {
+		return in, err
+	}
+
+	return in, nil
+}
+
+func (r *MigrationRepository) loadUnscoped(ctx context.Context, query string) ([]domain.Row, error) {
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to load unscoped rows", zap.Error(err))
+		return nil, fmt.Errorf("failed to load unscoped rows: %w", err)
+	}
+	defer rows.Close()
+
+	var result []domain.Row
+	for rows.Next() {
+		var row domain.Row
+		if err := rows.Scan(&row.ID, &row.UserID, &row.Symbol, &row.PositionID); err != nil {
+			return nil, fmt.Errorf("failed to scan unscoped row: %w", err)
+		}
+		result = append(result, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating unscoped rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// CreateRunTx records a new applied migration run
+func (r *MigrationRepository) CreateRunTx(ctx context.Context, tx *sql.Tx, run *models.DataMigrationRun) error {
+	query := `
+		INSERT INTO data_migration_runs (name, status, changed, unresolved, applied_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	err := tx.QueryRowContext(ctx, query, run.Name, run.Status, run.Changed, run.Unresolved, run.AppliedBy).
+		Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create migration run", zap.Error(err))
+		return fmt.Errorf("failed to create migration run: %w", err)
+	}
+	return nil
+}
+
+// ApplyAssignmentTx sets a row's portfolio and records the change. Rows that
+// were scoped since planning are left alone; it reports whether the row changed.
+func (r *MigrationRepository) ApplyAssignmentTx(ctx context.Context, tx *sql.Tx, runID int, a domain.Assignment) (bool, error) {
+	var update string
+	switch a.Table {
+	case domain.TablePositions:
+		update = `UPDATE positions SET portfolio_id = $1 WHERE id = $2 AND portfolio_id IS NULL`
+	case domain.TableTrades:
+		update = `UPDATE trades SET portfolio_id = $1 WHERE id = $2 AND portfolio_id IS NULL`
+	default:
+		return false, fmt.Errorf("unknown table: %s", a.Table)
+	}
+
+	result, err := tx.ExecContext(ctx, update, a.PortfolioID, a.ID)
+	if err != nil {
+		r.logger.Error("Failed to backfill portfolio", zap.Error(err), zap.String("table", a.Table), zap.Int("id", a.ID))
+		return false, fmt.Errorf("failed to update %s %d: %w", a.Table, a.ID, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO data_migration_changes (run_id, table_name, row_id, portfolio_id, rule)
+		VALUES ($1, $2, $3, $4, $5)`, runID, a.Table, a.ID, a.PortfolioID, a.Rule)
+	if err != nil {
+		r.logger.Error("Failed to record migration change", zap.Error(err), zap.Int("run_id", runID))
+		return false, fmt.Errorf("failed to record migration change: %w", err)
+	}
+	return true, nil
+}
+
+// SetRunChangedTx records how many rows a run changed
+func (r *MigrationRepository) SetRunChangedTx(ctx context.Context, tx *sql.Tx, runID int, changed int) error {
+	_, err := tx.ExecContext(ctx, `UPDATE data_migration_runs SET changed = $2 WHERE id = $1`, runID, changed)
+	if err != nil {
+		return fmt.Errorf("failed to update migration run: %w", err)
+	}
+	return nil
+}
+
+// RollbackRunTx clears the portfolios a run wrote, skipping rows changed since,
+// and returns how many rows were reverted
+func (r *MigrationRepository) RollbackRunTx(ctx context.Context, tx *sql.Tx, runID int) (int, error) {
+	var reverted int
+	for _, table := range []string{domain.TablePositions, domain.TableTrades} {
+		query := fmt.Sprintf(`
+			UPDATE %s t SET portfolio_id = NULL
+			FROM data_migration_changes c
+			WHERE c.run_id = $1 AND c.table_name = $2 AND t.id = c.row_id AND t.portfolio_id = c.portfolio_id`, table)
+		result, err := tx.ExecContext(ctx, query, runID, table)
+		if err != nil {
+			r.logger.Error("Failed to roll back migration", zap.Error(err), zap.Int("run_id", runID), zap.String("table", table))
+			return 0, fmt.Errorf("failed to roll back %s: %w", table, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		reverted += int(affected)
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE data_migration_runs SET status = $2, rolled_back_at = NOW() WHERE id = $1`,
+		runID, models.MigrationRolledBack)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update migration run: %w", err)
+	}
+	return reverted, nil
+}
+
+// GetRun retrieves a migration run by ID
+func (r *MigrationRepository) GetRun(ctx context.Context, runID int) (*models.DataMigrationRun, error) {
+	query := `
+		SELECT id, name, status, changed, unresolved, COALESCE(applied_by, ''), created_at, rolled_back_at
+		FROM data_migration_runs WHERE id = $1`
+
+	run := &models.DataMigrationRun{}
+	err := r.db.QueryRowContext(ctx, query, runID).Scan(
+		&run.ID, &run.Name, &run.Status, &run.Changed, &run.Unresolved, &run.AppliedBy, &run.CreatedAt, &run.RolledBackAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("migration run not found: %d", runID)
+		}
+		r.logger.Error("Failed to get migration run", zap.Error(err), zap.Int("run_id", runID))
+		return nil, fmt.Errorf("failed to get migration run: %w", err)
+	}
+	return run, nil
+}
+
+// GetRuns retrieves migration runs, newest first
+func (r *MigrationRepository) GetRuns(ctx context.Context, limit int) ([]models.DataMigrationRun, error) {
+	query := `
+		SELECT id, name, status, changed, unresolved, COALESCE(applied_by, ''), created_at, rolled_back_at
+		FROM data_migration_runs ORDER BY id DESC LIMIT $1`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		r.logger.Error("Failed to get migration runs", zap.Error(err))
+		return nil, fmt.Errorf("failed to get migration runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []models.DataMigrationRun
+	for rows.Next() {
+		run := models.DataMigrationRun{}
+		if err := rows.Scan(&run.ID, &run.Name, &run.Status, &run.Changed, &run.Unresolved, &run.AppliedBy, &run.CreatedAt, &run.RolledBackAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating migration runs: %w", err)
+	}
+
+	return runs, nil
+}
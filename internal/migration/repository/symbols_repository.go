@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/internal/migration/domain"
+)
+
+// Symbol Normalization Operations
+
+// CountSymbols returns the number of rows per stored symbol of a table
+func (r *MigrationRepository) CountSymbols(ctx context.Context, table string) (map[string]int, error) {
+	query := fmt.Sprintf(`SELECT symbol, COUNT(*) FROM %s WHERE symbol IS NOT NULL GROUP BY symbol`, pq.QuoteIdentifier(table))
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to count symbols", zap.Error(err), zap.String("table", table))
+		return nil, fmt.Errorf("failed to count symbols in %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var symbol string
+		var count int
+		if err := rows.Scan(&symbol, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan symbol count: %w", err)
+		}
+		counts[symbol] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating symbol counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// LoadSymbolPositions reads every position for duplicate detection
+func (r *MigrationRepository) LoadSymbolPositions(ctx context.Context) ([]domain.SymbolPosition, error) {
+	query := `
+		SELECT id, COALESCE(user_id, 0), COALESCE(portfolio_id, 0), symbol, quantity, entry_price, COALESCE(realized_pnl, 0)
+		FROM positions
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to load positions", zap.Error(err))
+		return nil, fmt.Errorf("failed to load positions: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []domain.SymbolPosition
+	for rows.Next() {
+		var p domain.SymbolPosition
+		if err := rows.Scan(&p.ID, &p.UserID, &p.PortfolioID, &p.Symbol, &p.Quantity, &p.EntryPrice, &p.RealizedPnL); err != nil {
+			return nil, fmt.Errorf("failed to scan position: %w", err)
+		}
+		positions = append(positions, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating positions: %w", err)
+	}
+
+	return positions, nil
+}
+
+// MergePositionsTx folds duplicate positions into the kept one, moving their
+// trades to it, within a transaction
+func (r *MigrationRepository) MergePositionsTx(ctx context.Context, tx *sql.Tx, merge domain.PositionMerge) error {
+	_, err := tx.ExecContext(ctx, `UPDATE trades SET position_id = $1 WHERE position_id = ANY($2)`,
+		merge.KeepID, pq.Array(merge.RemoveIDs))
+	if err != nil {
+		r.logger.Error("Failed to move trades of merged positions", zap.Error(err), zap.Int("keep_id", merge.KeepID))
+		return fmt.Errorf("failed to move trades of merged positions: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM positions WHERE id = ANY($1)`, pq.Array(merge.RemoveIDs)); err != nil {
+		r.logger.Error("Failed to delete merged positions", zap.Error(err), zap.Int("keep_id", merge.KeepID))
+		return fmt.Errorf("failed to delete merged positions: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE positions SET symbol = $2, quantity = $3, entry_price = $4, realized_pnl = $5
+		WHERE id = $1`, merge.KeepID, merge.Symbol, merge.Quantity, merge.EntryPrice, merge.RealizedPnL)
+	if err != nil {
+		r.logger.Error("Failed to update merged position", zap.Error(err), zap.Int("keep_id", merge.KeepID))
+		return fmt.Errorf("failed to update merged position %d: %w", merge.KeepID, err)
+	}
+	return nil
+}
+
+// RenameSymbolTx rewrites a symbol in a table within a transaction and returns
+// how many rows changed. In tables keyed by symbol, rows that would collide
+// with rows already under the normalized symbol are dropped in their favour.
+func (r *MigrationRepository) RenameSymbolTx(ctx context.Context, tx *sql.Tx, rename domain.SymbolRename) (int, error) {
+	var dedupe string
+	switch rename.Table {
+	case "instruments":
+		dedupe = `DELETE FROM instruments WHERE symbol = $1 AND EXISTS (SELECT 1 FROM instruments WHERE symbol = $2)`
+	case "market_prices":
+		dedupe = `
+			DELETE FROM market_prices a WHERE a.symbol = $1 AND EXISTS (
+				SELECT 1 FROM market_prices b
+				WHERE b.symbol = $2 AND b.bar_interval = a.bar_interval AND b.timestamp = a.timestamp)`
+	}
+	if dedupe != "" {
+		if _, err := tx.ExecContext(ctx, dedupe, rename.From, rename.To); err != nil {
+			r.logger.Error("Failed to drop colliding rows", zap.Error(err), zap.String("table", rename.Table))
+			return 0, fmt.Errorf("failed to drop colliding rows in %s: %w", rename.Table, err)
+		}
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET symbol = $2 WHERE symbol = $1`, pq.QuoteIdentifier(rename.Table))
+	result, err := tx.ExecContext(ctx, query, rename.From, rename.To)
+	if err != nil {
+		r.logger.Error("Failed to rename symbol", zap.Error(err),
+			zap.String("table", rename.Table), zap.String("from", rename.From))
+		return 0, fmt.Errorf("failed to rename %s in %s: %w", rename.From, rename.Table, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(affected), nil
+}
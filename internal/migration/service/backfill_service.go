@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/migration/domain"
+	"hedge-fund/internal/migration/repository"
+	"hedge-fund/pkg/shared/models"
+)
+
+// BackfillPortfoliosName names the portfolio_id backfill in data_migration_runs
+const BackfillPortfoliosName = "backfill_portfolio_id"
+
+// BackfillResult is the outcome of a backfill, or of its plan in a dry run
+type BackfillResult struct {
+	RunID   int                 `json:"run_id,omitempty"`
+	DryRun  bool                `json:"dry_run"`
+	Changed int                 `json:"changed"`
+	Skipped int                 `json:"skipped"` // Planned rows scoped by someone else before the run applied
+	ByRule  map[string]int      `json:"by_rule"`
+	Plan    domain.BackfillPlan `json:"plan"`
+}
+
+type MigrationService struct {
+	repo   *repository.MigrationRepository
+	logger *zap.Logger
+}
+
+func NewMigrationService(repo *repository.MigrationRepository, logger *zap.Logger) *MigrationService {
+	return &MigrationService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// BackfillPortfolios assigns a portfolio to every position and trade that can be
+// resolved unambiguously. A dry run only plans. An applied run changes all rows in
+// one transaction and records each change so the run can be rolled back.
+func (s *MigrationService) BackfillPortfolios(ctx context.Context, dryRun bool, appliedBy string) (*BackfillResult, error) {
+	in, err := s.repo.LoadBackfillInput(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := domain.PlanBackfill(in)
+	result := &BackfillResult{DryRun: dryRun, ByRule: make(map[string]int), Plan: plan}
+	for _, a := range plan.Assignments {
+		result.ByRule[a.Rule]++
+	}
+
+	if dryRun {
+		result.Changed = len(plan.Assignments)
+		return result, nil
+	}
+	if len(plan.Assignments) == 0 {
+		return result, nil
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	run := &models.DataMigrationRun{
+		Name:       BackfillPortfoliosName,
+		Status:     models.MigrationApplied,
+		Unresolved: len(plan.Unresolved),
+		AppliedBy:  appliedBy,
+	}
+	if err := s.repo.CreateRunTx(ctx, tx, run); err != nil {
+		return nil, err
+	}
+	result.RunID = run.ID
+
+	for _, a := range plan.Assignments {
+		changed, err := s.repo.ApplyAssignmentTx(ctx, tx, run.ID, a)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			result.Changed++
+		} else {
+			result.Skipped++
+		}
+	}
+
+	if err := s.repo.SetRunChangedTx(ctx, tx, run.ID, result.Changed); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit backfill: %w", err)
+	}
+
+	s.logger.Info("Portfolio backfill applied",
+		zap.Int("run_id", result.RunID),
+		zap.Int("changed", result.Changed),
+		zap.Int("skipped", result.Skipped),
+		zap.Int("unresolved", len(plan.Unresolved)))
+
+	return result, nil
+}
+
+// Rollback reverts an applied run and returns how many rows were reverted
+func (s *MigrationService) Rollback(ctx context.Context, runID int) (int, error) {
+	run, err := s.repo.GetRun(ctx, runID)
+	if err != nil {
+		return 0, err
+	}
+	if run.Name != BackfillPortfoliosName {
+		return 0, fmt.Errorf("migration run %d (%s) cannot be rolled back", runID, run.Name)
+	}
+	if run.Status != models.MigrationApplied {
+		return 0, fmt.Errorf("migration run %d is already %s", runID, run.Status)
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	reverted, err := s.repo.RollbackRunTx(ctx, tx, runID)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit rollback: %w", err)
+	}
+
+	s.logger.Info("Migration run rolled back", zap.Int("run_id", runID), zap.Int("reverted", reverted))
+	return reverted, nil
+}
+
+// GetRuns lists recent migration runs
+func (s *MigrationService) GetRuns(ctx context.Context, limit int) ([]models.DataMigrationRun, error) {
+	return s.repo.GetRuns(ctx, limit)
+}
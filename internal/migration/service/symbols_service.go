@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/migration/domain"
+	"hedge-fund/pkg/shared/models"
+)
+
+// NormalizeSymbolsName names the symbol normalization in data_migration_runs
+const NormalizeSymbolsName = "normalize_symbols"
+
+// NormalizeSymbolsResult is the outcome of a symbol normalization, or of its
+// plan in a dry run
+type NormalizeSymbolsResult struct {
+	RunID   int                    `json:"run_id,omitempty"`
+	DryRun  bool                   `json:"dry_run"`
+	Changed int                    `json:"changed"`
+	Merges  []domain.PositionMerge `json:"merges"`
+	Renames []domain.SymbolRename  `json:"renames"`
+}
+
+// NormalizeSymbols rewrites symbols stored before normalization in every
+// table, merging positions that become duplicates. An applied run changes all
+// rows in one transaction. It is recorded but, unlike the backfill, cannot be
+// rolled back since the original spellings carry no information.
+func (s *MigrationService) NormalizeSymbols(ctx context.Context, dryRun bool, appliedBy string) (*NormalizeSymbolsResult, error) {
+	positions, err := s.repo.LoadSymbolPositions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &NormalizeSymbolsResult{DryRun: dryRun, Merges: domain.PlanPositionMerges(positions)}
+
+	for _, table := range domain.SymbolTables {
+		counts, err := s.repo.CountSymbols(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		result.Renames = append(result.Renames, domain.PlanSymbolRenames(table, counts)...)
+	}
+
+	if dryRun {
+		for _, merge := range result.Merges {
+			result.Changed += len(merge.RemoveIDs)
+		}
+		for _, rename := range result.Renames {
+			result.Changed += rename.Rows
+		}
+		return result, nil
+	}
+	if len(result.Merges) == 0 && len(result.Renames) == 0 {
+		return result, nil
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	run := &models.DataMigrationRun{
+		Name:      NormalizeSymbolsName,
+		Status:    models.MigrationApplied,
+		AppliedBy: appliedBy,
+	}
+	if err := s.repo.CreateRunTx(ctx, tx, run); err != nil {
+		return nil, err
+	}
+	result.RunID = run.ID
+
+	// Merges go first so the renames never create duplicate positions
+	for _, merge := range result.Merges {
+		if err := s.repo.MergePositionsTx(ctx, tx, merge); err != nil {
+			return nil, err
+		}
+		result.Changed += len(merge.RemoveIDs)
+	}
+	for _, rename := range result.Renames {
+		changed, err := s.repo.RenameSymbolTx(ctx, tx, rename)
+		if err != nil {
+			return nil, err
+		}
+		result.Changed += changed
+	}
+
+	if err := s.repo.SetRunChangedTx(ctx, tx, run.ID, result.Changed); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit symbol normalization: %w", err)
+	}
+
+	s.logger.Info("Symbol normalization applied",
+		zap.Int("run_id", result.RunID),
+		zap.Int("changed", result.Changed),
+		zap.Int("merges", len(result.Merges)),
+		zap.Int("renames", len(result.Renames)))
+
+	return result, nil
+}
@@ -0,0 +1,127 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"hedge-fund/internal/notifications/domain"
+)
+
+// Sender delivers a rendered notification to a recipient on one channel
+type Sender interface {
+	Send(ctx context.Context, recipient string, msg domain.Rendered) error
+}
+
+// PermanentError marks a delivery failure that retrying will not fix, such as
+// a rejected address or a webhook answering 4xx
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// IsPermanent reports whether err should not be retried
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// postJSON posts body to url, treating 4xx responses other than 429 as permanent
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return &PermanentError{Err: fmt.Errorf("failed to encode payload: %w", err)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return &PermanentError{Err: fmt.Errorf("invalid url: %w", err)}
+	}
+	_, err = send(client, req)
+	return err
+}
+
+// send sends a JSON request, returning the response status, zero when there
+// was no response. 4xx responses other than 429 are permanent errors.
+func send(client *http.Client, req *http.Request) (int, error) {
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "hedge-fund-notifications/1.0")
+
+	resp, err := client.Do(req)
+	if errors.Is(err, domain.ErrPrivateAddress) {
+		return 0, &PermanentError{Err: fmt.Errorf("request failed: %w", err)}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, nil
+	}
+	err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return resp.StatusCode, &PermanentError{Err: err}
+	}
+	return resp.StatusCode, err
+}
+
+// newHTTPClient returns a client for user-configured URLs. It only connects
+// to public addresses, checked on each connection after the host is
+// resolved, so a host that resolves inside the network, or redirects there,
+// is refused however it resolved when registered.
+func newHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !domain.PublicIP(net.ParseIP(host)) {
+				return fmt.Errorf("%w: %s", domain.ErrPrivateAddress, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// ResolvePublic resolves a host, failing with domain.ErrPrivateAddress if
+// any of its addresses is not public
+func ResolvePublic(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !domain.PublicIP(ip) {
+			return domain.ErrPrivateAddress
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !domain.PublicIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", domain.ErrPrivateAddress, host, addr.IP)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"hedge-fund/internal/notifications/domain"
+)
+
+// EmailSender sends plain-text email through an SMTP relay
+type EmailSender struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from string
+}
+
+func NewEmailSender(host, port, username, password, from string) *EmailSender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &EmailSender{
+		addr: net.JoinHostPort(host, port),
+		host: host,
+		auth: auth,
+		from: from,
+	}
+}
+
+// Send implements Sender
+func (s *EmailSender) Send(ctx context.Context, recipient string, msg domain.Rendered) error {
+	if s.host == "" {
+		return &PermanentError{Err: fmt.Errorf("SMTP is not configured")}
+	}
+	if strings.ContainsAny(recipient, "\r\n") || !strings.Contains(recipient, "@") {
+		return &PermanentError{Err: fmt.Errorf("invalid email address: %q", recipient)}
+	}
+
+	message := strings.Join([]string{
+		"From: " + s.from,
+		"To: " + recipient,
+		"Subject: " + strings.NewReplacer("\r", " ", "\n", " ").Replace(msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		msg.Body,
+	}, "\r\n")
+
+	// net/smtp has no context support, so run the send and abandon it on cancellation
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(s.addr, s.auth, s.from, []string{recipient}, []byte(message))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return classifySMTPError(err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// classifySMTPError treats 5xx replies (e.g. unknown mailbox) as permanent
+func classifySMTPError(err error) error {
+	if strings.HasPrefix(err.Error(), "5") {
+		return &PermanentError{Err: fmt.Errorf("smtp rejected message: %w", err)}
+	}
+	return fmt.Errorf("smtp send failed: %w", err)
+}
@@ -0,0 +1,24 @@
+package channels
+
+import (
+	"context"
+	"net/http"
+
+	"hedge-fund/internal/notifications/domain"
+)
+
+// SlackSender posts to a Slack incoming webhook; the recipient is the webhook URL
+type SlackSender struct {
+	client *http.Client
+}
+
+func NewSlackSender() *SlackSender {
+	return &SlackSender{client: newHTTPClient()}
+}
+
+// Send implements Sender
+func (s *SlackSender) Send(ctx context.Context, recipient string, msg domain.Rendered) error {
+	return postJSON(ctx, s.client, recipient, map[string]string{
+		"text": "*" + msg.Subject + "*\n" + msg.Body,
+	})
+}
@@ -0,0 +1,76 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hedge-fund/internal/notifications/domain"
+	"hedge-fund/pkg/shared/models"
+)
+
+// WebhookSender posts the rendered notification and its data as JSON to a
+// user-configured URL
+type WebhookSender struct {
+	client *http.Client
+}
+
+func NewWebhookSender() *WebhookSender {
+	return &WebhookSender{client: newHTTPClient()}
+}
+
+// WebhookPayload is the body posted to generic webhooks
+type WebhookPayload struct {
+	Type    string                 `json:"type"`
+	Subject string                 `json:"subject"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+	SentAt  time.Time              `json:"sent_at"`
+}
+
+// Send implements Sender
+func (s *WebhookSender) Send(ctx context.Context, recipient string, msg domain.Rendered) error {
+	return postJSON(ctx, s.client, recipient, WebhookPayload{
+		Type:    msg.Type,
+		Subject: msg.Subject,
+		Message: msg.Body,
+		Data:    msg.Data,
+		SentAt:  time.Now(),
+	})
+}
+
+// SignedWebhookSender posts events to webhook endpoints, signed with each
+// endpoint's secret so receivers can verify them
+type SignedWebhookSender struct {
+	client *http.Client
+}
+
+func NewSignedWebhookSender() *SignedWebhookSender {
+	return &SignedWebhookSender{client: newHTTPClient()}
+}
+
+// Send posts event to endpoint, returning the response status, zero when
+// there was no response
+func (s *SignedWebhookSender) Send(ctx context.Context, endpoint models.WebhookEndpoint, event domain.WebhookEvent) (int, error) {
+	body, err := json.Marshal(event.Payload())
+	if err != nil {
+		return 0, &PermanentError{Err: fmt.Errorf("failed to encode payload: %w", err)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, &PermanentError{Err: fmt.Errorf("invalid url: %w", err)}
+	}
+
+	// Signed per attempt, so a retry carries a fresh timestamp
+	now := time.Now()
+	req.Header.Set(domain.WebhookIDHeader, event.ID)
+	req.Header.Set(domain.WebhookEventHeader, event.Type)
+	req.Header.Set(domain.WebhookTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(domain.WebhookSignatureHeader, domain.SignWebhook(endpoint.Secret, now, body))
+	return send(s.client, req)
+}
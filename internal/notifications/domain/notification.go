@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Notification is a notification job decoded from the queue payload
+type Notification struct {
+	JobID    string                 `json:"-"`
+	UserID   int                    `json:"user_id"`
+	Type     string                 `json:"notification_type"`
+	Subject  string                 `json:"subject"`
+	Message  string                 `json:"message"`
+	Data     map[string]interface{} `json:"data"`
+	Channels []string               `json:"channels"`
+}
+
+// Target is a channel and the address a notification is sent to on it
+type Target struct {
+	Channel   string
+	Recipient string
+}
+
+// Skip is a requested channel that will not be delivered, and why
+type Skip struct {
+	Channel string
+	Reason  string
+}
+
+// AllChannels lists the supported channels in delivery order
+var AllChannels = []string{
+	models.NotificationChannelEmail,
+	models.NotificationChannelSlack,
+	models.NotificationChannelWebhook,
+}
+
+// ResolveTargets applies a user's preferences to the requested channels. An
+// empty request means every channel the user has enabled.
+func ResolveTargets(prefs models.NotificationPreferences, notificationType string, requested []string) ([]Target, []Skip) {
+	channels := requested
+	if len(channels) == 0 {
+		channels = AllChannels
+	}
+
+	muted := false
+	for _, t := range prefs.MutedTypes {
+		if t == notificationType {
+			muted = true
+			break
+		}
+	}
+
+	var targets []Target
+	var skipped []Skip
+	seen := make(map[string]bool)
+	for _, channel := range channels {
+		if seen[channel] {
+			continue
+		}
+		seen[channel] = true
+
+		enabled, recipient, known := channelSettings(prefs, channel)
+		switch {
+		case !known:
+			skipped = append(skipped, Skip{Channel: channel, Reason: "unsupported channel"})
+		case muted:
+			skipped = append(skipped, Skip{Channel: channel, Reason: "notification type muted"})
+		case !enabled:
+			// Only report disabled channels the caller asked for explicitly
+			if len(requested) > 0 {
+				skipped = append(skipped, Skip{Channel: channel, Reason: "channel disabled"})
+			}
+		case recipient == "":
+			skipped = append(skipped, Skip{Channel: channel, Reason: "no recipient configured"})
+		default:
+			targets = append(targets, Target{Channel: channel, Recipient: recipient})
+		}
+	}
+
+	return targets, skipped
+}
+
+func channelSettings(prefs models.NotificationPreferences, channel string) (enabled bool, recipient string, known bool) {
+	switch channel {
+	case models.NotificationChannelEmail:
+		return prefs.EmailEnabled, prefs.EmailAddress, true
+	case models.NotificationChannelSlack:
+		return prefs.SlackEnabled, prefs.SlackWebhookURL, true
+	case models.NotificationChannelWebhook:
+		return prefs.WebhookEnabled, prefs.WebhookURL, true
+	}
+	return false, "", false
+}
+
+// Backoff returns the delay before retry number attempt (1-based), doubling
+// from base and capped at max
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+func TestRenderBuiltinTemplate(t *testing.T) {
+	r := NewRenderer()
+
+	rendered, err := r.Render(Notification{
+		Type: "trade_executed",
+		Data: map[string]interface{}{"side": "buy", "quantity": 10, "symbol": "AAPL", "price": 187.5, "portfolio_id": 3},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Trade executed: buy 10 AAPL", rendered.Subject)
+	assert.Equal(t, "Your buy order for 10 AAPL was filled at $187.50 in portfolio 3.", rendered.Body)
+}
+
+func TestRenderPositionAlert(t *testing.T) {
+	r := NewRenderer()
+	data := map[string]interface{}{
+		"alert_id": 4, "portfolio_id": 3, "symbol": "AAPL", "quantity": 10, "direction": "up", "percent": 10.0,
+		"level": 110.0, "entry_price": 100.0, "price": 111.0, "change_percent": 11.0, "note": "",
+	}
+
+	rendered, err := r.Render(Notification{Type: "position_alert", Data: data})
+	require.NoError(t, err)
+	assert.Equal(t, "Position alert: AAPL +11.00% from entry in portfolio 3", rendered.Subject)
+	assert.Equal(t, "AAPL is trading at $111.00, +11.00% from your entry price of $100.00 on 10 shares in portfolio 3. It reached your alert level of $110.00, 10.00% up from entry.", rendered.Body)
+
+	data["note"] = "take profit"
+	rendered, err = r.Render(Notification{Type: "position_alert", Data: data})
+	require.NoError(t, err)
+	assert.Contains(t, rendered.Body, "\nNote: take profit")
+}
+
+func TestRenderOptionExpired(t *testing.T) {
+	r := NewRenderer()
+	data := map[string]interface{}{
+		"portfolio_id": 3, "trade_id": 12, "symbol": "AAPL240119C00150000", "quantity": 2.0, "outcome": "exercised",
+		"underlying": "AAPL", "underlying_price": 160.0, "strike": 150.0, "price": 10.0, "value": 2000.0,
+	}
+
+	rendered, err := r.Render(Notification{Type: "option_expired", Data: data})
+	require.NoError(t, err)
+	assert.Equal(t, "Option exercised: AAPL240119C00150000 in portfolio 3", rendered.Subject)
+	assert.Equal(t, "2 AAPL240119C00150000 contract(s) expired with AAPL at $160.00 against a strike of $150.00. They were exercised and settled in cash at $10.00 per share, $2000.00 in total (trade 12).", rendered.Body)
+
+	data["outcome"], data["underlying_price"], data["price"], data["value"] = "expired worthless", 140.0, 0.0, 0.0
+	rendered, err = r.Render(Notification{Type: "option_expired", Data: data})
+	require.NoError(t, err)
+	assert.Contains(t, rendered.Body, "They expired worthless (trade 12).")
+}
+
+func TestRenderOverridesAndErrors(t *testing.T) {
+	r := NewRenderer()
+
+	rendered, err := r.Render(Notification{
+		Type:    "custom",
+		Subject: "Hello {{.name}}",
+		Message: "Welcome aboard",
+		Data:    map[string]interface{}{"name": "Ada"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Ada", rendered.Subject)
+	assert.Equal(t, "Welcome aboard", rendered.Body)
+
+	_, err = r.Render(Notification{Type: "custom", Subject: "No body"})
+	assert.Error(t, err)
+
+	_, err = r.Render(Notification{Type: "trade_executed", Data: map[string]interface{}{"side": "buy"}})
+	assert.Error(t, err, "missing template fields should fail rather than render <no value>")
+}
+
+func TestResolveTargets(t *testing.T) {
+	prefs := models.NotificationPreferences{
+		EmailEnabled:    true,
+		SlackEnabled:    true,
+		EmailAddress:    "ada@example.com",
+		SlackWebhookURL: "",
+		WebhookURL:      "https://example.com/hook",
+		MutedTypes:      []string{"price_alert"},
+	}
+
+	targets, skipped := ResolveTargets(prefs, "trade_executed", nil)
+	assert.Equal(t, []Target{{Channel: models.NotificationChannelEmail, Recipient: "ada@example.com"}}, targets)
+	assert.Equal(t, []Skip{{Channel: models.NotificationChannelSlack, Reason: "no recipient configured"}}, skipped)
+
+	targets, skipped = ResolveTargets(prefs, "trade_executed", []string{"webhook", "sms"})
+	assert.Empty(t, targets)
+	assert.Equal(t, []Skip{
+		{Channel: models.NotificationChannelWebhook, Reason: "channel disabled"},
+		{Channel: "sms", Reason: "unsupported channel"},
+	}, skipped)
+
+	targets, _ = ResolveTargets(prefs, "price_alert", nil)
+	assert.Empty(t, targets)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 100*time.Millisecond, Backoff(1, 100*time.Millisecond, time.Second))
+	assert.Equal(t, 400*time.Millisecond, Backoff(3, 100*time.Millisecond, time.Second))
+	assert.Equal(t, time.Second, Backoff(10, 100*time.Millisecond, time.Second))
+}
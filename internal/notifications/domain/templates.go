@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// Rendered is a notification ready to hand to a channel
+type Rendered struct {
+	Type    string                 `json:"type"`
+	Subject string                 `json:"subject"`
+	Body    string                 `json:"body"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// Built-in templates keyed by notification type. Fields come from the job's data.
+var builtinTemplates = map[string][2]string{
+	"trade_executed": {
+		"Trade executed: {{.side}} {{.quantity}} {{.symbol}}",
+		"Your {{.side}} order for {{.quantity}} {{.symbol}} was filled at ${{printf \"%.2f\" .price}} in portfolio {{.portfolio_id}}.",
+	},
+	"risk_alert": {
+		"[{{.severity}}] Risk alert: {{.alert_type}} on {{.symbol}}",
+		"{{.message}}\nCurrent value {{.value}} breached threshold {{.threshold}}.",
+	},
+	"price_alert": {
+		"Price alert: {{.symbol}} reached ${{printf \"%.2f\" .price}}",
+		"{{.symbol}} is now trading at ${{printf \"%.2f\" .price}}, crossing your alert level of ${{printf \"%.2f\" .alert_price}}.",
+	},
+	"position_alert": {
+		"Position alert: {{.symbol}} {{printf \"%+.2f\" .change_percent}}% from entry in portfolio {{.portfolio_id}}",
+		"{{.symbol}} is trading at ${{printf \"%.2f\" .price}}, {{printf \"%+.2f\" .change_percent}}% from your entry price of ${{printf \"%.2f\" .entry_price}} on {{.quantity}} shares in portfolio {{.portfolio_id}}. It reached your alert level of ${{printf \"%.2f\" .level}}, {{printf \"%.2f\" .percent}}% {{.direction}} from entry.{{if .note}}\nNote: {{.note}}{{end}}",
+	},
+	"benchmark_lagging": {
+		"You're lagging the market: portfolio {{.portfolio_id}} vs {{.benchmark}}",
+		"Over the last {{.window_days}} days portfolio {{.portfolio_id}} returned {{printf \"%.2f\" .portfolio_return}}% while {{.benchmark}} returned {{printf \"%.2f\" .benchmark_return}}%, trailing by more than your {{printf \"%.2f\" .threshold}} point threshold.",
+	},
+	"order_slippage_rejected": {
+		"Order rejected: {{.side}} {{.quantity}} {{.symbol}}",
+		"Your market {{.side}} order for {{.quantity}} {{.symbol}} quoted at ${{printf \"%.2f\" .quoted_price}} in portfolio {{.portfolio_id}} was rejected because the price moved more than {{.max_slippage}}% before it could fill.",
+	},
+	"stop_loss_triggered": {
+		"Stop-loss triggered: {{.symbol}} in portfolio {{.portfolio_id}}",
+		"{{.symbol}} fell to ${{printf \"%.2f\" .price}}, reaching your stop-loss at ${{printf \"%.2f\" .trigger_price}}. A market order to sell {{.quantity}} shares was submitted (trade {{.trade_id}}).",
+	},
+	"option_expired": {
+		"Option {{.outcome}}: {{.symbol}} in portfolio {{.portfolio_id}}",
+		"{{.quantity}} {{.symbol}} contract(s) expired with {{.underlying}} at ${{printf \"%.2f\" .underlying_price}} against a strike of ${{printf \"%.2f\" .strike}}. {{if .price}}They were {{.outcome}} and settled in cash at ${{printf \"%.2f\" .price}} per share, ${{printf \"%.2f\" .value}} in total{{else}}They expired worthless{{end}} (trade {{.trade_id}}).",
+	},
+	"reconciliation_break": {
+		"Reconciliation breaks in portfolio {{.portfolio_id}}",
+		"Reconciling {{.statement_date}} against the broker statement found {{.open_breaks}} unexplained break(s). See /api/v1/portfolios/{{.portfolio_id}}/reconciliations/{{.run_id}}.",
+	},
+	"allocation_drift": {
+		"Portfolio {{.portfolio_id}} has drifted from {{.model_name}}",
+		"{{.key}} is at {{printf \"%.2f\" .actual}}% against a target of {{printf \"%.2f\" .target}}%, a drift of {{printf \"%.2f\" .max_drift}} points against your threshold of {{printf \"%.2f\" .threshold}}.",
+	},
+	"account_statement": {
+		"Your {{.month}} statement for portfolio {{.portfolio_id}}",
+		"Portfolio {{.portfolio_id}} went from ${{printf \"%.2f\" .starting_value}} to ${{printf \"%.2f\" .ending_value}} in {{.month}}, with ${{printf \"%.2f\" .contributions}} in contributions, ${{printf \"%.2f\" .withdrawals}} in withdrawals, ${{printf \"%.2f\" .realized_pnl}} realized and ${{printf \"%.2f\" .unrealized_pnl}} unrealized PnL, and ${{printf \"%.2f\" .fees}} in fees over {{.trade_count}} trade(s). See /api/v1/portfolios/{{.portfolio_id}}/statements.",
+	},
+	"report_ready": {
+		"Your {{.report_type}} report is ready",
+		"The {{.report_type}} report for portfolio {{.portfolio_id}} is available at {{.url}}.",
+	},
+	"auto_trade_approval": {
+		"Approve auto-trade: {{.side}} {{.quantity}} {{.symbol}}",
+		"A consensus signal (confidence {{printf \"%.2f\" .confidence}}) calls for a {{.side}} of {{.quantity}} {{.symbol}} near ${{printf \"%.2f\" .price}} in portfolio {{.portfolio_id}}. Approve it within {{.expires_in}} at /api/v1/ai/auto-trade/orders/{{.order_id}}/approve.",
+	},
+}
+
+// Renderer renders notifications from built-in templates or from the subject
+// and message supplied on the job, which are themselves templates over the data
+type Renderer struct {
+	templates map[string]messageTemplate
+}
+
+func NewRenderer() *Renderer {
+	r := &Renderer{templates: make(map[string]messageTemplate)}
+	for name, t := range builtinTemplates {
+		r.templates[name] = messageTemplate{
+			subject: template.Must(parseTemplate(name+".subject", t[0])),
+			body:    template.Must(parseTemplate(name+".body", t[1])),
+		}
+	}
+	return r
+}
+
+// Render produces the subject and body for a notification. A subject or
+// message on the job overrides the corresponding part of the type's template.
+func (r *Renderer) Render(n Notification) (Rendered, error) {
+	data := n.Data
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	tmpl, builtin := r.templates[n.Type]
+	if !builtin && (n.Subject == "" || n.Message == "") {
+		return Rendered{}, fmt.Errorf("no template for notification type %q and no subject/message provided", n.Type)
+	}
+
+	subject, err := renderPart(n.Type+".subject", n.Subject, tmpl.subject, data)
+	if err != nil {
+		return Rendered{}, err
+	}
+	body, err := renderPart(n.Type+".body", n.Message, tmpl.body, data)
+	if err != nil {
+		return Rendered{}, err
+	}
+
+	return Rendered{Type: n.Type, Subject: subject, Body: body, Data: n.Data}, nil
+}
+
+func renderPart(name, override string, fallback *template.Template, data map[string]interface{}) (string, error) {
+	tmpl := fallback
+	if override != "" {
+		parsed, err := parseTemplate(name, override)
+		if err != nil {
+			return "", fmt.Errorf("invalid template %s: %w", name, err)
+		}
+		tmpl = parsed
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(text)
+}
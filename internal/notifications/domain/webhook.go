@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Webhook request headers
+const (
+	WebhookIDHeader        = "X-Webhook-Id"        // Event ID, the same on every retry
+	WebhookEventHeader     = "X-Webhook-Event"     // Event type
+	WebhookTimestampHeader = "X-Webhook-Timestamp" // Unix seconds the request was signed at
+	WebhookSignatureHeader = "X-Webhook-Signature" // "sha256=" and the hex HMAC of the timestamp and body
+)
+
+// WebhookEventTypes lists the events endpoints can subscribe to
+var WebhookEventTypes = []string{
+	models.WebhookEventTradeExecuted,
+}
+
+// WebhookEvent is a webhook job decoded from the queue payload
+type WebhookEvent struct {
+	ID        string                 `json:"-"`
+	UserID    int                    `json:"user_id"`
+	Type      string                 `json:"event_type"`
+	Data      map[string]interface{} `json:"data"`
+	CreatedAt time.Time              `json:"-"`
+}
+
+// WebhookPayload is the body posted to a webhook endpoint
+type WebhookPayload struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// Payload returns the body posted for the event
+func (e WebhookEvent) Payload() WebhookPayload {
+	return WebhookPayload{
+		ID:        e.ID,
+		Type:      e.Type,
+		CreatedAt: e.CreatedAt,
+		Data:      e.Data,
+	}
+}
+
+// Subscribed reports whether an active endpoint receives events of a type.
+// Endpoints without event types receive every event.
+func Subscribed(endpoint models.WebhookEndpoint, eventType string) bool {
+	if !endpoint.Active {
+		return false
+	}
+	if len(endpoint.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range endpoint.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// SignWebhook returns the signature header of a body posted at timestamp:
+// the HMAC-SHA256, keyed by the endpoint's secret, of the Unix timestamp, a
+// dot and the body. Receivers recompute it to verify the sender and reject
+// old timestamps to prevent replays.
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ErrPrivateAddress is returned for webhook URLs of hosts that are, or
+// resolve to, addresses inside the network
+var ErrPrivateAddress = errors.New("webhook url must be a public address")
+
+// nonPublicNetworks are the ranges, beyond loopback, private and link-local
+// ones, that webhooks may not be posted to
+var nonPublicNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),     // "This" network
+	mustParseCIDR("100.64.0.0/10"), // Carrier-grade NAT
+}
+
+// PublicIP reports whether ip is a public unicast address, rather than a
+// loopback, private, link-local (such as cloud metadata), multicast or
+// unspecified one
+func PublicIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateWebhookURL checks a webhook URL is an http or https URL of a host
+// that is not a non-public address literal or localhost. Host names must
+// also be resolved to public addresses, when registered and when posted to.
+func ValidateWebhookURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid webhook url: %s", raw)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("invalid webhook url: credentials are not allowed")
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return nil, ErrPrivateAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && !PublicIP(ip) {
+		return nil, ErrPrivateAddress
+	}
+	return u, nil
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
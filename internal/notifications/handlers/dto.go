@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"time"
+
+	"hedge-fund/pkg/shared/apierror"
+)
+
+// Request DTOs
+
+type SendNotificationRequest struct {
+	UserID   int                    `json:"user_id" binding:"required,gt=0"`
+	Type     string                 `json:"notification_type" binding:"required"`
+	Subject  string                 `json:"subject"` // Overrides the template subject
+	Message  string                 `json:"message"` // Overrides the template body
+	Data     map[string]interface{} `json:"data"`
+	Channels []string               `json:"channels" binding:"dive,oneof=email slack webhook"`
+}
+
+type UpdatePreferencesRequest struct {
+	EmailEnabled    bool     `json:"email_enabled"`
+	SlackEnabled    bool     `json:"slack_enabled"`
+	WebhookEnabled  bool     `json:"webhook_enabled"`
+	EmailAddress    string   `json:"email_address" binding:"omitempty,email"`
+	SlackWebhookURL string   `json:"slack_webhook_url" binding:"omitempty,url"`
+	WebhookURL      string   `json:"webhook_url" binding:"omitempty,url"`
+	MutedTypes      []string `json:"muted_types"`
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url"`
+	EventTypes []string `json:"event_types" binding:"dive,oneof=trade_executed"` // Empty means every event
+}
+
+// Response DTOs
+
+type SendNotificationResponse struct {
+	JobID  string `json:"job_id"`
+	Status string `json:"status"`
+}
+
+type PreferencesResponse struct {
+	UserID          int       `json:"user_id"`
+	EmailEnabled    bool      `json:"email_enabled"`
+	SlackEnabled    bool      `json:"slack_enabled"`
+	WebhookEnabled  bool      `json:"webhook_enabled"`
+	EmailAddress    string    `json:"email_address"`
+	SlackWebhookURL string    `json:"slack_webhook_url,omitempty"`
+	WebhookURL      string    `json:"webhook_url,omitempty"`
+	MutedTypes      []string  `json:"muted_types"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type DeliveryResponse struct {
+	ID               int64      `json:"id"`
+	JobID            string     `json:"job_id"`
+	UserID           int        `json:"user_id"`
+	Channel          string     `json:"channel"`
+	NotificationType string     `json:"notification_type"`
+	Recipient        string     `json:"recipient,omitempty"`
+	Subject          string     `json:"subject,omitempty"`
+	Status           string     `json:"status"`
+	Attempts         int        `json:"attempts"`
+	LastError        string     `json:"last_error,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	DeliveredAt      *time.Time `json:"delivered_at,omitempty"`
+}
+
+type WebhookEndpointResponse struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CreateWebhookResponse is a new endpoint with its signing secret, which is
+// only ever returned here
+type CreateWebhookResponse struct {
+	WebhookEndpointResponse
+	Secret string `json:"secret"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             int64      `json:"id"`
+	EventID        string     `json:"event_id"`
+	EndpointID     int        `json:"endpoint_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// ErrorResponse is the error envelope shared by every service
+type ErrorResponse = apierror.Response
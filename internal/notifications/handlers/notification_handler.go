@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hedge-fund/internal/notifications/repository"
+	"hedge-fund/internal/notifications/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type NotificationHandler struct {
+	service *service.NotificationService
+	logger  *zap.Logger
+}
+
+func NewNotificationHandler(service *service.NotificationService, logger *zap.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// SendNotification godoc
+// @Summary Send a notification
+// @Description Render a notification template and enqueue it for delivery on the user's enabled channels
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body SendNotificationRequest true "Notification"
+// @Success 202 {object} SendNotificationResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/notifications [post]
+func (h *NotificationHandler) SendNotification(c *gin.Context) {
+	var req SendNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	jobID, err := h.service.SendNotification(c.Request.Context(), service.SendRequest{
+		UserID:   req.UserID,
+		Type:     req.Type,
+		Subject:  req.Subject,
+		Message:  req.Message,
+		Data:     req.Data,
+		Channels: req.Channels,
+	})
+	if err != nil {
+		h.logger.Error("Failed to send notification", zap.Error(err), zap.Int("user_id", req.UserID))
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Failed to send notification", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, SendNotificationResponse{JobID: jobID, Status: models.JobStatusPending})
+}
+
+// GetDeliveries godoc
+// @Summary Get notification delivery status
+// @Description Get the per-channel delivery status of a notification job
+// @Tags notifications
+// @Produce json
+// @Param job_id path string true "Notification job ID"
+// @Success 200 {array} DeliveryResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/notifications/{job_id}/deliveries [get]
+func (h *NotificationHandler) GetDeliveries(c *gin.Context) {
+	deliveries, err := h.service.GetDeliveries(c.Request.Context(), c.Param("job_id"))
+	if err != nil {
+		h.logger.Error("Failed to get notification deliveries", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get deliveries", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryResponses(deliveries))
+}
+
+// GetUserDeliveries godoc
+// @Summary Get notification history
+// @Description Get a page of a user's notification deliveries, newest first by default
+// @Tags notifications
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param limit query int false "Limit" default(50)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Success 200 {object} pagination.Page{data=[]DeliveryResponse}
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/notifications [get]
+func (h *NotificationHandler) GetUserDeliveries(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	page, err := pagination.Parse(c.Request.URL.Query(), repository.DeliverySorts, 50)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid pagination parameters", Details: err.Error()})
+		return
+	}
+
+	deliveries, result, err := h.service.GetUserDeliveries(c.Request.Context(), userID, page)
+	if err != nil {
+		h.logger.Error("Failed to get notification history", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get notification history", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result.Page(toDeliveryResponses(deliveries)))
+}
+
+// GetPreferences godoc
+// @Summary Get notification preferences
+// @Description Get a user's notification channels and muted notification types
+// @Tags notifications
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {object} PreferencesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/notification-preferences [get]
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	prefs, err := h.service.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "User not found", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toPreferencesResponse(prefs))
+}
+
+// UpdatePreferences godoc
+// @Summary Update notification preferences
+// @Description Replace a user's notification channels and muted notification types
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param request body UpdatePreferencesRequest true "Preferences"
+// @Success 200 {object} PreferencesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/notification-preferences [put]
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	prefs := &models.NotificationPreferences{
+		UserID:          userID,
+		EmailEnabled:    req.EmailEnabled,
+		SlackEnabled:    req.SlackEnabled,
+		WebhookEnabled:  req.WebhookEnabled,
+		EmailAddress:    strings.TrimSpace(req.EmailAddress),
+		SlackWebhookURL: req.SlackWebhookURL,
+		WebhookURL:      req.WebhookURL,
+		MutedTypes:      req.MutedTypes,
+	}
+
+	if err := h.service.UpdatePreferences(c.Request.Context(), prefs); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "User not found", Details: err.Error()})
+			return
+		}
+		h.logger.Error("Failed to update notification preferences", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Failed to update preferences", Details: err.Error()})
+		return
+	}
+
+	// Re-read so an empty email address reflects the account default
+	updated, err := h.service.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get preferences", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toPreferencesResponse(updated))
+}
+
+func toPreferencesResponse(prefs *models.NotificationPreferences) PreferencesResponse {
+	mutedTypes := prefs.MutedTypes
+	if mutedTypes == nil {
+		mutedTypes = []string{}
+	}
+	return PreferencesResponse{
+		UserID:          prefs.UserID,
+		EmailEnabled:    prefs.EmailEnabled,
+		SlackEnabled:    prefs.SlackEnabled,
+		WebhookEnabled:  prefs.WebhookEnabled,
+		EmailAddress:    prefs.EmailAddress,
+		SlackWebhookURL: prefs.SlackWebhookURL,
+		WebhookURL:      prefs.WebhookURL,
+		MutedTypes:      mutedTypes,
+		UpdatedAt:       prefs.UpdatedAt,
+	}
+}
+
+func toDeliveryResponses(deliveries []models.NotificationDelivery) []DeliveryResponse {
+	response := make([]DeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		response[i] = DeliveryResponse{
+			ID:               delivery.ID,
+			JobID:            delivery.JobID,
+			UserID:           delivery.UserID,
+			Channel:          delivery.Channel,
+			NotificationType: delivery.NotificationType,
+			Recipient:        delivery.Recipient,
+			Subject:          delivery.Subject,
+			Status:           delivery.Status,
+			Attempts:         delivery.Attempts,
+			LastError:        delivery.LastError,
+			CreatedAt:        delivery.CreatedAt,
+			DeliveredAt:      delivery.DeliveredAt,
+		}
+	}
+	return response
+}
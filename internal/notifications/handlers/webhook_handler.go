@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hedge-fund/internal/notifications/repository"
+	"hedge-fund/internal/notifications/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type WebhookHandler struct {
+	service *service.WebhookService
+	logger  *zap.Logger
+}
+
+func NewWebhookHandler(service *service.WebhookService, logger *zap.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateWebhook godoc
+// @Summary Add a webhook endpoint
+// @Description Register a URL events such as trade_executed are posted to. Each request is signed: X-Webhook-Signature is "sha256=" and the hex HMAC-SHA256, keyed by the returned secret, of X-Webhook-Timestamp, a dot and the body. The secret is only returned here. URLs of hosts that are or resolve to loopback, private or link-local addresses are refused, at registration and at delivery.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param request body CreateWebhookRequest true "Endpoint"
+// @Success 201 {object} CreateWebhookResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	endpoint, secret, err := h.service.CreateEndpoint(c.Request.Context(), userID, req.URL, req.EventTypes)
+	if err != nil {
+		h.logger.Error("Failed to create webhook endpoint", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Failed to create webhook endpoint", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateWebhookResponse{
+		WebhookEndpointResponse: toWebhookEndpointResponse(*endpoint),
+		Secret:                  secret,
+	})
+}
+
+// GetWebhooks godoc
+// @Summary List webhook endpoints
+// @Description Get a user's webhook endpoints, without their secrets
+// @Tags notifications
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {array} WebhookEndpointResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/webhooks [get]
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	endpoints, err := h.service.GetEndpoints(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get webhook endpoints", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get webhook endpoints", Details: err.Error()})
+		return
+	}
+
+	response := make([]WebhookEndpointResponse, len(endpoints))
+	for i, endpoint := range endpoints {
+		response[i] = toWebhookEndpointResponse(endpoint)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook endpoint
+// @Description Stop posting events to an endpoint and delete its delivery log
+// @Tags notifications
+// @Param user_id path int true "User ID"
+// @Param id path int true "Endpoint ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userID, endpointID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteEndpoint(c.Request.Context(), userID, endpointID); err != nil {
+		h.respondEndpointError(c, err, "Failed to delete webhook endpoint")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary Get a webhook endpoint's delivery log
+// @Description Get a page of the events posted to an endpoint, with attempts, the last response status and error, newest first by default
+// @Tags notifications
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param id path int true "Endpoint ID"
+// @Param limit query int false "Limit" default(50)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Success 200 {object} pagination.Page{data=[]WebhookDeliveryResponse}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetWebhookDeliveries(c *gin.Context) {
+	userID, endpointID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	page, err := pagination.Parse(c.Request.URL.Query(), repository.WebhookDeliverySorts, 50)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid pagination parameters", Details: err.Error()})
+		return
+	}
+
+	deliveries, result, err := h.service.GetDeliveries(c.Request.Context(), userID, endpointID, page)
+	if err != nil {
+		h.respondEndpointError(c, err, "Failed to get webhook deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, result.Page(toWebhookDeliveryResponses(deliveries)))
+}
+
+// webhookParams parses the user and endpoint IDs of the request, responding
+// with an error when either is invalid
+func webhookParams(c *gin.Context) (int, int, bool) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return 0, 0, false
+	}
+	endpointID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid webhook endpoint ID"})
+		return 0, 0, false
+	}
+	return userID, endpointID, true
+}
+
+func (h *WebhookHandler) respondEndpointError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, repository.ErrWebhookEndpointNotFound) {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Webhook endpoint not found"})
+		return
+	}
+	h.logger.Error(msg, zap.Error(err))
+	apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: msg, Details: err.Error()})
+}
+
+func toWebhookEndpointResponse(endpoint models.WebhookEndpoint) WebhookEndpointResponse {
+	eventTypes := endpoint.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return WebhookEndpointResponse{
+		ID:         endpoint.ID,
+		UserID:     endpoint.UserID,
+		URL:        endpoint.URL,
+		EventTypes: eventTypes,
+		Active:     endpoint.Active,
+		CreatedAt:  endpoint.CreatedAt,
+	}
+}
+
+func toWebhookDeliveryResponses(deliveries []models.WebhookDelivery) []WebhookDeliveryResponse {
+	response := make([]WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		response[i] = WebhookDeliveryResponse{
+			ID:             delivery.ID,
+			EventID:        delivery.EventID,
+			EndpointID:     delivery.EndpointID,
+			EventType:      delivery.EventType,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			ResponseStatus: delivery.ResponseStatus,
+			LastError:      delivery.LastError,
+			CreatedAt:      delivery.CreatedAt,
+			DeliveredAt:    delivery.DeliveredAt,
+		}
+	}
+	return response
+}
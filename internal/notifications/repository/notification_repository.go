@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+)
+
+type NotificationRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewNotificationRepository(db *database.DB, logger *zap.Logger) *NotificationRepository {
+	return &NotificationRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Preference Operations
+
+// GetPreferences retrieves a user's notification preferences. Users without a
+// stored row get the defaults: email only, to the account address.
+func (r *NotificationRepository) GetPreferences(ctx context.Context, userID int) (*models.NotificationPreferences, error) {
+	query := `
+		SELECT u.id,
+		       COALESCE(p.email_enabled, true), COALESCE(p.slack_enabled, false), COALESCE(p.webhook_enabled, false),
+		       COALESCE(NULLIF(p.email_address, ''), u.email), COALESCE(p.slack_webhook_url, ''),
+		       COALESCE(p.webhook_url, ''), COALESCE(p.muted_types, '{}'), COALESCE(p.updated_at, u.updated_at)
+		FROM users u
+		LEFT JOIN notification_preferences p ON p.user_id = u.id
+		WHERE u.id = $1`
+
+	prefs := &models.NotificationPreferences{}
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&prefs.UserID,
+		&prefs.EmailEnabled,
+		&prefs.SlackEnabled,
+		&prefs.WebhookEnabled,
+		&prefs.EmailAddress,
+		&prefs.SlackWebhookURL,
+		&prefs.WebhookURL,
+		pq.Array(&prefs.MutedTypes),
+		&prefs.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found: %d", userID)
+		}
+		r.logger.Error("Failed to get notification preferences", zap.Error(err), zap.Int("user_id", userID))
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	return prefs, nil
+}
+
+// UpsertPreferences creates or replaces a user's notification preferences
+func (r *NotificationRepository) UpsertPreferences(ctx context.Context, prefs *models.NotificationPreferences) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, email_enabled, slack_enabled, webhook_enabled,
+		                                      email_address, slack_webhook_url, webhook_url, muted_types)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8)
+		ON CONFLICT (user_id) DO UPDATE SET
+			email_enabled = EXCLUDED.email_enabled,
+			slack_enabled = EXCLUDED.slack_enabled,
+			webhook_enabled = EXCLUDED.webhook_enabled,
+			email_address = EXCLUDED.email_address,
+			slack_webhook_url = EXCLUDED.slack_webhook_url,
+			webhook_url = EXCLUDED.webhook_url,
+			muted_types = EXCLUDED.muted_types
+		RETURNING updated_at`
+
+	mutedTypes := prefs.MutedTypes
+	if mutedTypes == nil {
+		mutedTypes = []string{}
+	}
+
+	err := r.db.QueryRowContext(ctx, query,
+		prefs.UserID,
+		prefs.EmailEnabled,
+		prefs.SlackEnabled,
+		prefs.WebhookEnabled,
+		prefs.EmailAddress,
+		prefs.SlackWebhookURL,
+		prefs.WebhookURL,
+		pq.Array(mutedTypes),
+	).Scan(&prefs.UpdatedAt)
+	if err != nil {
+		r.logger.Error("Failed to save notification preferences", zap.Error(err), zap.Int("user_id", prefs.UserID))
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+
+	return nil
+}
+
+// Delivery Operations
+
+// RecordDelivery stores the outcome of delivering a job on one channel. A job
+// retried by the queue updates its existing row for the channel.
+func (r *NotificationRepository) RecordDelivery(ctx context.Context, delivery *models.NotificationDelivery) error {
+	query := `
+		INSERT INTO notification_deliveries (job_id, user_id, channel, notification_type, recipient, subject,
+		                                     status, attempts, last_error, delivered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10)
+		ON CONFLICT (job_id, channel) DO UPDATE SET
+			recipient = EXCLUDED.recipient,
+			subject = EXCLUDED.subject,
+			status = EXCLUDED.status,
+			attempts = notification_deliveries.attempts + EXCLUDED.attempts,
+			last_error = EXCLUDED.last_error,
+			delivered_at = EXCLUDED.delivered_at
+		RETURNING id, attempts, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		delivery.JobID,
+		delivery.UserID,
+		delivery.Channel,
+		delivery.NotificationType,
+		delivery.Recipient,
+		delivery.Subject,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.LastError,
+		delivery.DeliveredAt,
+	).Scan(&delivery.ID, &delivery.Attempts, &delivery.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to record notification delivery", zap.Error(err),
+			zap.String("job_id", delivery.JobID), zap.String("channel", delivery.Channel))
+		return fmt.Errorf("failed to record notification delivery: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliveriesByJobID retrieves the per-channel delivery status of a notification
+func (r *NotificationRepository) GetDeliveriesByJobID(ctx context.Context, jobID string) ([]models.NotificationDelivery, error) {
+	query := deliveryColumns + `
+		WHERE job_id = $1
+		ORDER BY id`
+
+	return r.queryDeliveries(ctx, query, jobID)
+}
+
+// DeliverySorts whitelists the fields a user's delivery history can be sorted by
+var DeliverySorts = pagination.Sorts{
+	ID: pagination.Field{Column: "id", Type: "bigint"},
+	Fields: map[string]pagination.Field{
+		"created_at": {Column: "created_at", Type: "timestamptz"},
+	},
+	Default: "created_at",
+	Desc:    true,
+}
+
+// GetDeliveriesByUserID retrieves a page of a user's delivery history
+func (r *NotificationRepository) GetDeliveriesByUserID(ctx context.Context, userID int, page pagination.Request) ([]models.NotificationDelivery, pagination.Result, error) {
+	cond, args := page.After(2)
+	query := `
+		SELECT id, job_id, user_id, channel, notification_type, COALESCE(recipient, ''), COALESCE(subject, ''),
+		       status, attempts, COALESCE(last_error, ''), created_at, delivered_at, ` + page.Key() + `
+		FROM notification_deliveries
+		WHERE user_id = $1` + cond + page.OrderBy()
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{userID}, args...)...)
+	if err != nil {
+		r.logger.Error("Failed to get notification deliveries", zap.Error(err), zap.Int("user_id", userID))
+		return nil, pagination.Result{}, fmt.Errorf("failed to get notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.NotificationDelivery
+	var keys []string
+	for rows.Next() {
+		var key string
+		delivery, err := scanDelivery(rows, &key)
+		if err != nil {
+			return nil, pagination.Result{}, fmt.Errorf("failed to scan notification delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("error iterating notification deliveries: %w", err)
+	}
+
+	var result pagination.Result
+	if page.More(len(deliveries)) {
+		deliveries = deliveries[:page.Limit]
+		result.NextCursor = page.Cursor(keys[page.Limit-1], deliveries[page.Limit-1].ID)
+	}
+	err = r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notification_deliveries WHERE user_id = $1`, userID).Scan(&result.Total)
+	if err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("failed to count notification deliveries: %w", err)
+	}
+
+	return deliveries, result, nil
+}
+
+const deliveryColumns = `
+		SELECT id, job_id, user_id, channel, notification_type, COALESCE(recipient, ''), COALESCE(subject, ''),
+		       status, attempts, COALESCE(last_error, ''), created_at, delivered_at
+		FROM notification_deliveries`
+
+func (r *NotificationRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]models.NotificationDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to get notification deliveries", zap.Error(err))
+		return nil, fmt.Errorf("failed to get notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.NotificationDelivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan notification delivery", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan notification delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// scanDelivery scans a row of deliveryColumns followed by any extra columns
+func scanDelivery(rows *sql.Rows, extra ...interface{}) (models.NotificationDelivery, error) {
+	delivery := models.NotificationDelivery{}
+	dest := []interface{}{
+		&delivery.ID,
+		&delivery.JobID,
+		&delivery.UserID,
+		&delivery.Channel,
+		&delivery.NotificationType,
+		&delivery.Recipient,
+		&delivery.Subject,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	}
+	err := rows.Scan(append(dest, extra...)...)
+	return delivery, err
+}
@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+)
+
+// ErrWebhookEndpointNotFound is returned for an endpoint that does not exist
+// or belongs to another user
+var ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+
+type WebhookRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewWebhookRepository(db *database.DB, logger *zap.Logger) *WebhookRepository {
+	return &WebhookRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Endpoint Operations
+
+// CreateEndpoint stores a webhook endpoint, setting its ID and creation time
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	query := `
+		INSERT INTO webhook_endpoints (user_id, url, secret, event_types, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	eventTypes := endpoint.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	err := r.db.QueryRowContext(ctx, query,
+		endpoint.UserID,
+		endpoint.URL,
+		endpoint.Secret,
+		pq.Array(eventTypes),
+		endpoint.Active,
+	).Scan(&endpoint.ID, &endpoint.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create webhook endpoint", zap.Error(err), zap.Int("user_id", endpoint.UserID))
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	return nil
+}
+
+// GetEndpoints retrieves a user's webhook endpoints, oldest first
+func (r *WebhookRepository) GetEndpoints(ctx context.Context, userID int) ([]models.WebhookEndpoint, error) {
+	query := `
+		SELECT id, user_id, url, secret, COALESCE(event_types, '{}'), active, created_at
+		FROM webhook_endpoints
+		WHERE user_id = $1
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("Failed to get webhook endpoints", zap.Error(err), zap.Int("user_id", userID))
+		return nil, fmt.Errorf("failed to get webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []models.WebhookEndpoint
+	for rows.Next() {
+		var endpoint models.WebhookEndpoint
+		err := rows.Scan(
+			&endpoint.ID,
+			&endpoint.UserID,
+			&endpoint.URL,
+			&endpoint.Secret,
+			pq.Array(&endpoint.EventTypes),
+			&endpoint.Active,
+			&endpoint.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook endpoints: %w", err)
+	}
+
+	return endpoints, nil
+}
+
+// DeleteEndpoint deletes a user's webhook endpoint with its delivery log
+func (r *WebhookRepository) DeleteEndpoint(ctx context.Context, userID, endpointID int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE id = $1 AND user_id = $2`, endpointID, userID)
+	if err != nil {
+		r.logger.Error("Failed to delete webhook endpoint", zap.Error(err), zap.Int("endpoint_id", endpointID))
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+// EndpointExists reports whether a user has the webhook endpoint
+func (r *WebhookRepository) EndpointExists(ctx context.Context, userID, endpointID int) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM webhook_endpoints WHERE id = $1 AND user_id = $2)`
+	if err := r.db.QueryRowContext(ctx, query, endpointID, userID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check webhook endpoint: %w", err)
+	}
+	return exists, nil
+}
+
+// Delivery Operations
+
+// RecordDelivery stores the outcome of posting an event to an endpoint. An
+// event retried by the queue updates its existing row for the endpoint.
+func (r *WebhookRepository) RecordDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (event_id, endpoint_id, event_type, status, attempts, response_status,
+		                                last_error, delivered_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NULLIF($7, ''), $8)
+		ON CONFLICT (event_id, endpoint_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			attempts = webhook_deliveries.attempts + EXCLUDED.attempts,
+			response_status = EXCLUDED.response_status,
+			last_error = EXCLUDED.last_error,
+			delivered_at = EXCLUDED.delivered_at
+		RETURNING id, attempts, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		delivery.EventID,
+		delivery.EndpointID,
+		delivery.EventType,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.ResponseStatus,
+		delivery.LastError,
+		delivery.DeliveredAt,
+	).Scan(&delivery.ID, &delivery.Attempts, &delivery.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to record webhook delivery", zap.Error(err),
+			zap.String("event_id", delivery.EventID), zap.Int("endpoint_id", delivery.EndpointID))
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// GetSentEndpoints retrieves the IDs of the endpoints an event was delivered to
+func (r *WebhookRepository) GetSentEndpoints(ctx context.Context, eventID string) (map[int]bool, error) {
+	query := `SELECT endpoint_id FROM webhook_deliveries WHERE event_id = $1 AND status = 'sent'`
+
+	rows, err := r.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		r.logger.Error("Failed to get webhook deliveries", zap.Error(err), zap.String("event_id", eventID))
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	sent := make(map[int]bool)
+	for rows.Next() {
+		var endpointID int
+		if err := rows.Scan(&endpointID); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		sent[endpointID] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return sent, nil
+}
+
+// WebhookDeliverySorts whitelists the fields an endpoint's delivery log can
+// be sorted by
+var WebhookDeliverySorts = pagination.Sorts{
+	ID: pagination.Field{Column: "id", Type: "bigint"},
+	Fields: map[string]pagination.Field{
+		"created_at": {Column: "created_at", Type: "timestamptz"},
+	},
+	Default: "created_at",
+	Desc:    true,
+}
+
+// GetDeliveriesByEndpointID retrieves a page of an endpoint's delivery log
+func (r *WebhookRepository) GetDeliveriesByEndpointID(ctx context.Context, endpointID int, page pagination.Request) ([]models.WebhookDelivery, pagination.Result, error) {
+	cond, args := page.After(2)
+	query := `
+		SELECT id, event_id, endpoint_id, event_type, status, attempts, COALESCE(response_status, 0),
+		       COALESCE(last_error, ''), created_at, delivered_at, ` + page.Key() + `
+		FROM webhook_deliveries
+		WHERE endpoint_id = $1` + cond + page.OrderBy()
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{endpointID}, args...)...)
+	if err != nil {
+		r.logger.Error("Failed to get webhook deliveries", zap.Error(err), zap.Int("endpoint_id", endpointID))
+		return nil, pagination.Result{}, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	var keys []string
+	for rows.Next() {
+		var key string
+		delivery, err := scanWebhookDelivery(rows, &key)
+		if err != nil {
+			return nil, pagination.Result{}, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	var result pagination.Result
+	if page.More(len(deliveries)) {
+		deliveries = deliveries[:page.Limit]
+		result.NextCursor = page.Cursor(keys[page.Limit-1], deliveries[page.Limit-1].ID)
+	}
+	err = r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhook_deliveries WHERE endpoint_id = $1`, endpointID).Scan(&result.Total)
+	if err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	return deliveries, result, nil
+}
+
+func scanWebhookDelivery(rows *sql.Rows, extra ...interface{}) (models.WebhookDelivery, error) {
+	delivery := models.WebhookDelivery{}
+	dest := []interface{}{
+		&delivery.ID,
+		&delivery.EventID,
+		&delivery.EndpointID,
+		&delivery.EventType,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.ResponseStatus,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	}
+	err := rows.Scan(append(dest, extra...)...)
+	return delivery, err
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/notifications/channels"
+	"hedge-fund/internal/notifications/domain"
+	"hedge-fund/internal/notifications/repository"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/queue"
+)
+
+const maxBackoff = 30 * time.Second
+
+// SendRequest describes a notification to enqueue
+type SendRequest struct {
+	UserID   int
+	Type     string
+	Subject  string
+	Message  string
+	Data     map[string]interface{}
+	Channels []string
+}
+
+type NotificationService struct {
+	repo        *repository.NotificationRepository
+	queue       *queue.Manager
+	renderer    *domain.Renderer
+	senders     map[string]channels.Sender
+	maxAttempts int
+	backoff     time.Duration
+	logger      *zap.Logger
+}
+
+func NewNotificationService(repo *repository.NotificationRepository, queueManager *queue.Manager, senders map[string]channels.Sender, maxAttempts int, backoff time.Duration, logger *zap.Logger) *NotificationService {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &NotificationService{
+		repo:        repo,
+		queue:       queueManager,
+		renderer:    domain.NewRenderer(),
+		senders:     senders,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		logger:      logger,
+	}
+}
+
+// SendNotification validates and enqueues a notification, returning the job ID
+func (s *NotificationService) SendNotification(ctx context.Context, req SendRequest) (string, error) {
+	for _, channel := range req.Channels {
+		if _, ok := s.senders[channel]; !ok {
+			return "", fmt.Errorf("unsupported channel: %s", channel)
+		}
+	}
+
+	// Render once up front so template errors are reported to the caller
+	// rather than failing in the worker
+	if _, err := s.renderer.Render(domain.Notification{
+		Type:    req.Type,
+		Subject: req.Subject,
+		Message: req.Message,
+		Data:    req.Data,
+	}); err != nil {
+		return "", err
+	}
+
+	return s.queue.EnqueueNotification(req.UserID, req.Type, req.Subject, req.Message, req.Data, req.Channels)
+}
+
+// GetPreferences returns a user's notification preferences
+func (s *NotificationService) GetPreferences(ctx context.Context, userID int) (*models.NotificationPreferences, error) {
+	return s.repo.GetPreferences(ctx, userID)
+}
+
+// UpdatePreferences replaces a user's notification preferences
+func (s *NotificationService) UpdatePreferences(ctx context.Context, prefs *models.NotificationPreferences) error {
+	if prefs.SlackEnabled && prefs.SlackWebhookURL == "" {
+		return fmt.Errorf("slack_webhook_url is required when slack is enabled")
+	}
+	if prefs.WebhookEnabled && prefs.WebhookURL == "" {
+		return fmt.Errorf("webhook_url is required when webhook is enabled")
+	}
+	for _, url := range []string{prefs.SlackWebhookURL, prefs.WebhookURL} {
+		if url != "" && !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+			return fmt.Errorf("invalid webhook url: %s", url)
+		}
+	}
+
+	if _, err := s.repo.GetPreferences(ctx, prefs.UserID); err != nil {
+		return err
+	}
+	return s.repo.UpsertPreferences(ctx, prefs)
+}
+
+// GetDeliveries returns the per-channel delivery status of a notification job
+func (s *NotificationService) GetDeliveries(ctx context.Context, jobID string) ([]models.NotificationDelivery, error) {
+	return s.repo.GetDeliveriesByJobID(ctx, jobID)
+}
+
+// GetUserDeliveries returns a page of a user's delivery history
+func (s *NotificationService) GetUserDeliveries(ctx context.Context, userID int, page pagination.Request) ([]models.NotificationDelivery, pagination.Result, error) {
+	return s.repo.GetDeliveriesByUserID(ctx, userID, page)
+}
+
+// CanHandle implements queue.JobHandler
+func (s *NotificationService) CanHandle(jobType string) bool {
+	return jobType == models.JobTypeNotification
+}
+
+// Handle implements queue.JobHandler. Each channel is retried with exponential
+// backoff; if a channel still fails with a retryable error the job is returned
+// to the queue, and channels already sent are skipped on the next attempt.
+func (s *NotificationService) Handle(ctx context.Context, job *models.Job) error {
+	notification, err := decodeNotification(job)
+	if err != nil {
+		job.Retries = job.MaxRetries // A malformed payload will not improve on retry
+		return err
+	}
+
+	prefs, err := s.repo.GetPreferences(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+
+	targets, skipped := domain.ResolveTargets(*prefs, notification.Type, notification.Channels)
+	for _, skip := range skipped {
+		if _, ok := s.senders[skip.Channel]; !ok {
+			s.logger.Warn("Ignoring unsupported notification channel",
+				zap.String("job_id", job.ID), zap.String("channel", skip.Channel))
+			continue
+		}
+		s.record(ctx, notification, domain.Target{Channel: skip.Channel}, "", models.DeliveryStatusSkipped, 0, skip.Reason)
+	}
+	if len(targets) == 0 {
+		s.logger.Info("Notification has no deliverable channels",
+			zap.String("job_id", job.ID), zap.Int("user_id", notification.UserID))
+		return nil
+	}
+
+	rendered, err := s.renderer.Render(notification)
+	if err != nil {
+		for _, target := range targets {
+			s.record(ctx, notification, target, "", models.DeliveryStatusFailed, 0, err.Error())
+		}
+		job.Retries = job.MaxRetries
+		return err
+	}
+
+	sent, err := s.sentChannels(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+
+	var retryable []string
+	for _, target := range targets {
+		if sent[target.Channel] {
+			continue
+		}
+
+		attempts, err := s.deliver(ctx, target, rendered)
+		if err != nil {
+			s.logger.Warn("Notification delivery failed",
+				zap.String("job_id", job.ID),
+				zap.String("channel", target.Channel),
+				zap.Int("attempts", attempts),
+				zap.Error(err))
+			s.record(ctx, notification, target, rendered.Subject, models.DeliveryStatusFailed, attempts, err.Error())
+			if !channels.IsPermanent(err) {
+				retryable = append(retryable, target.Channel)
+			}
+			continue
+		}
+
+		s.record(ctx, notification, target, rendered.Subject, models.DeliveryStatusSent, attempts, "")
+	}
+
+	if len(retryable) > 0 {
+		return fmt.Errorf("delivery failed on %s", strings.Join(retryable, ", "))
+	}
+
+	s.logger.Info("Notification processed",
+		zap.String("job_id", job.ID),
+		zap.Int("user_id", notification.UserID),
+		zap.String("type", notification.Type))
+
+	return nil
+}
+
+// deliver sends on one channel, retrying transient failures with exponential
+// backoff. It returns the number of attempts made.
+func (s *NotificationService) deliver(ctx context.Context, target domain.Target, msg domain.Rendered) (int, error) {
+	sender, ok := s.senders[target.Channel]
+	if !ok {
+		return 0, &channels.PermanentError{Err: fmt.Errorf("no sender for channel %s", target.Channel)}
+	}
+
+	var err error
+	for attempt := 1; attempt <= s.maxAttempts; attempt++ {
+		if err = sender.Send(ctx, target.Recipient, msg); err == nil || channels.IsPermanent(err) {
+			return attempt, err
+		}
+		if attempt == s.maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(domain.Backoff(attempt, s.backoff, maxBackoff)):
+		}
+	}
+	return s.maxAttempts, err
+}
+
+func (s *NotificationService) sentChannels(ctx context.Context, jobID string) (map[string]bool, error) {
+	deliveries, err := s.repo.GetDeliveriesByJobID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	sent := make(map[string]bool)
+	for _, delivery := range deliveries {
+		if delivery.Status == models.DeliveryStatusSent {
+			sent[delivery.Channel] = true
+		}
+	}
+	return sent, nil
+}
+
+// record stores a delivery outcome. Failures are logged rather than returned
+// so a database hiccup does not cause an already-sent notification to repeat.
+func (s *NotificationService) record(ctx context.Context, n domain.Notification, target domain.Target, subject, status string, attempts int, reason string) {
+	delivery := &models.NotificationDelivery{
+		JobID:            n.JobID,
+		UserID:           n.UserID,
+		Channel:          target.Channel,
+		NotificationType: n.Type,
+		Recipient:        target.Recipient,
+		Subject:          subject,
+		Status:           status,
+		Attempts:         attempts,
+		LastError:        reason,
+	}
+	if status == models.DeliveryStatusSent {
+		now := time.Now()
+		delivery.DeliveredAt = &now
+	}
+
+	if err := s.repo.RecordDelivery(ctx, delivery); err != nil {
+		s.logger.Error("Failed to record notification delivery", zap.Error(err),
+			zap.String("job_id", n.JobID), zap.String("channel", target.Channel))
+	}
+}
+
+func decodeNotification(job *models.Job) (domain.Notification, error) {
+	var notification domain.Notification
+	payload, err := json.Marshal(job.Payload)
+	if err != nil {
+		return notification, fmt.Errorf("invalid notification payload: %w", err)
+	}
+	if err := json.Unmarshal(payload, &notification); err != nil {
+		return notification, fmt.Errorf("invalid notification payload: %w", err)
+	}
+	if notification.UserID <= 0 {
+		return notification, fmt.Errorf("notification payload is missing user_id")
+	}
+
+	notification.JobID = job.ID
+	return notification, nil
+}
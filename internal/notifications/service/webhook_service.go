@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/notifications/channels"
+	"hedge-fund/internal/notifications/domain"
+	"hedge-fund/internal/notifications/repository"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+)
+
+// WebhookService manages users' webhook endpoints and posts queued events
+// to them
+type WebhookService struct {
+	repo        *repository.WebhookRepository
+	sender      *channels.SignedWebhookSender
+	maxAttempts int
+	backoff     time.Duration
+	logger      *zap.Logger
+}
+
+// NewWebhookService creates a webhook service. Each endpoint is tried up to
+// maxAttempts times per queue attempt, backing off exponentially from backoff.
+func NewWebhookService(repo *repository.WebhookRepository, sender *channels.SignedWebhookSender, maxAttempts int, backoff time.Duration, logger *zap.Logger) *WebhookService {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &WebhookService{
+		repo:        repo,
+		sender:      sender,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		logger:      logger,
+	}
+}
+
+// CreateEndpoint stores a webhook endpoint for a user with a new signing
+// secret, which is returned as the endpoint's secret is never shown again.
+// URLs of hosts inside the network are refused; deliveries check the
+// address again as they connect.
+func (s *WebhookService) CreateEndpoint(ctx context.Context, userID int, url string, eventTypes []string) (*models.WebhookEndpoint, string, error) {
+	parsed, err := domain.ValidateWebhookURL(url)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := channels.ResolvePublic(ctx, parsed.Hostname()); err != nil {
+		return nil, "", err
+	}
+	for _, eventType := range eventTypes {
+		if !knownWebhookEvent(eventType) {
+			return nil, "", fmt.Errorf("unsupported event type: %s", eventType)
+		}
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	endpoint := &models.WebhookEndpoint{
+		UserID:     userID,
+		URL:        url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		Active:     true,
+	}
+	if err := s.repo.CreateEndpoint(ctx, endpoint); err != nil {
+		return nil, "", err
+	}
+	return endpoint, secret, nil
+}
+
+// GetEndpoints returns a user's webhook endpoints
+func (s *WebhookService) GetEndpoints(ctx context.Context, userID int) ([]models.WebhookEndpoint, error) {
+	return s.repo.GetEndpoints(ctx, userID)
+}
+
+// DeleteEndpoint deletes a user's webhook endpoint
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, userID, endpointID int) error {
+	return s.repo.DeleteEndpoint(ctx, userID, endpointID)
+}
+
+// GetDeliveries returns a page of the delivery log of a user's endpoint
+func (s *WebhookService) GetDeliveries(ctx context.Context, userID, endpointID int, page pagination.Request) ([]models.WebhookDelivery, pagination.Result, error) {
+	exists, err := s.repo.EndpointExists(ctx, userID, endpointID)
+	if err != nil {
+		return nil, pagination.Result{}, err
+	}
+	if !exists {
+		return nil, pagination.Result{}, repository.ErrWebhookEndpointNotFound
+	}
+	return s.repo.GetDeliveriesByEndpointID(ctx, endpointID, page)
+}
+
+// CanHandle implements queue.JobHandler
+func (s *WebhookService) CanHandle(jobType string) bool {
+	return jobType == models.JobTypeWebhook
+}
+
+// Handle implements queue.JobHandler. The event is posted to each of the
+// user's subscribed endpoints, retrying with exponential backoff; if an
+// endpoint still fails with a retryable error the job is returned to the
+// queue, and endpoints already sent are skipped on the next attempt.
+func (s *WebhookService) Handle(ctx context.Context, job *models.Job) error {
+	event, err := decodeWebhookEvent(job)
+	if err != nil {
+		job.Retries = job.MaxRetries // A malformed payload will not improve on retry
+		return err
+	}
+
+	endpoints, err := s.repo.GetEndpoints(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+	sent, err := s.repo.GetSentEndpoints(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+
+	var retryable []string
+	for _, endpoint := range endpoints {
+		if !domain.Subscribed(endpoint, event.Type) || sent[endpoint.ID] {
+			continue
+		}
+
+		attempts, status, err := s.deliver(ctx, endpoint, event)
+		if err != nil {
+			s.logger.Warn("Webhook delivery failed",
+				zap.String("event_id", event.ID),
+				zap.Int("endpoint_id", endpoint.ID),
+				zap.Int("attempts", attempts),
+				zap.Error(err))
+			s.record(ctx, event, endpoint, models.DeliveryStatusFailed, attempts, status, err.Error())
+			if !channels.IsPermanent(err) {
+				retryable = append(retryable, fmt.Sprint(endpoint.ID))
+			}
+			continue
+		}
+
+		s.record(ctx, event, endpoint, models.DeliveryStatusSent, attempts, status, "")
+	}
+
+	if len(retryable) > 0 {
+		return fmt.Errorf("delivery failed to endpoints %s", strings.Join(retryable, ", "))
+	}
+
+	s.logger.Info("Webhook event processed",
+		zap.String("event_id", event.ID),
+		zap.Int("user_id", event.UserID),
+		zap.String("event_type", event.Type))
+
+	return nil
+}
+
+// deliver posts to one endpoint, retrying transient failures with
+// exponential backoff. It returns the number of attempts made and the last
+// response status.
+func (s *WebhookService) deliver(ctx context.Context, endpoint models.WebhookEndpoint, event domain.WebhookEvent) (int, int, error) {
+	var status int
+	var err error
+	for attempt := 1; attempt <= s.maxAttempts; attempt++ {
+		if status, err = s.sender.Send(ctx, endpoint, event); err == nil || channels.IsPermanent(err) {
+			return attempt, status, err
+		}
+		if attempt == s.maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, status, ctx.Err()
+		case <-time.After(domain.Backoff(attempt, s.backoff, maxBackoff)):
+		}
+	}
+	return s.maxAttempts, status, err
+}
+
+// record stores a delivery outcome. Failures are logged rather than returned
+// so a database hiccup does not cause an already-sent event to repeat.
+func (s *WebhookService) record(ctx context.Context, event domain.WebhookEvent, endpoint models.WebhookEndpoint, status string, attempts, responseStatus int, reason string) {
+	delivery := &models.WebhookDelivery{
+		EventID:        event.ID,
+		EndpointID:     endpoint.ID,
+		EventType:      event.Type,
+		Status:         status,
+		Attempts:       attempts,
+		ResponseStatus: responseStatus,
+		LastError:      reason,
+	}
+	if status == models.DeliveryStatusSent {
+		now := time.Now()
+		delivery.DeliveredAt = &now
+	}
+
+	if err := s.repo.RecordDelivery(ctx, delivery); err != nil {
+		s.logger.Error("Failed to record webhook delivery", zap.Error(err),
+			zap.String("event_id", event.ID), zap.Int("endpoint_id", endpoint.ID))
+	}
+}
+
+func knownWebhookEvent(eventType string) bool {
+	for _, known := range domain.WebhookEventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// newWebhookSecret returns a random signing secret
+func newWebhookSecret() (string, error) {
+	key := make([]byte, 24)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(key), nil
+}
+
+func decodeWebhookEvent(job *models.Job) (domain.WebhookEvent, error) {
+	var event domain.WebhookEvent
+	payload, err := json.Marshal(job.Payload)
+	if err != nil {
+		return event, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return event, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	if event.UserID <= 0 || event.Type == "" {
+		return event, fmt.Errorf("webhook payload is missing user_id or event_type")
+	}
+
+	event.ID = job.ID
+	event.CreatedAt = job.CreatedAt
+	return event, nil
+}
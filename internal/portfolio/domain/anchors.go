@@ -0,0 +1,32 @@
+package domain
+
+import "hedge-fund/pkg/shared/models"
+
+// DayAnchors returns the day P&L anchors of the symbols with an official
+// close. opens holds the open of each symbol's stored daily bar for the
+// session; without one the session is taken to open at the previous close.
+func DayAnchors(held []string, closes, opens map[string]float64) []models.DayAnchor {
+	var anchors []models.DayAnchor
+	for _, symbol := range held {
+		previousClose, ok := closes[symbol]
+		if !ok || previousClose <= 0 {
+			continue
+		}
+		anchor := models.DayAnchor{Symbol: symbol, PreviousClose: previousClose, DayOpen: previousClose}
+		if open := opens[symbol]; open > 0 {
+			anchor.DayOpen = open
+		}
+		anchors = append(anchors, anchor)
+	}
+	return anchors
+}
+
+// dayAnchorPrice returns the price a position's day P&L is measured from:
+// its rolled previous close, or else the caller's previous day price
+func dayAnchorPrice(position *models.Position, previousDayPrices map[string]float64) (float64, bool) {
+	if position.PreviousClose != nil && *position.PreviousClose > 0 {
+		return *position.PreviousClose, true
+	}
+	price, ok := previousDayPrices[position.Symbol]
+	return price, ok
+}
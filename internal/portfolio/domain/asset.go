@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// QuantityPrecision is the number of decimal places a crypto quantity is
+// held to, as stored by the quantity columns
+const QuantityPrecision = 8
+
+var quantityScale = math.Pow10(QuantityPrecision)
+
+// AssetType returns the asset type traded under a symbol. Crypto pairs
+// (BTC-USD) are crypto, OCC symbols (AAPL240119C00150000) options and
+// everything else an equity.
+func AssetType(symbol string) string {
+	symbol = symbols.Normalize(symbol)
+	switch {
+	case symbols.IsCrypto(symbol):
+		return models.AssetTypeCrypto
+	case symbols.IsOption(symbol):
+		return models.AssetTypeOption
+	}
+	return models.AssetTypeEquity
+}
+
+// Multiplier returns the units of the underlying one unit of symbol is
+// worth: the contract multiplier of an option, 1 for everything else
+func Multiplier(symbol string) float64 {
+	if contract, ok := symbols.ParseOption(symbols.Normalize(symbol)); ok {
+		return contract.Multiplier
+	}
+	return 1
+}
+
+// classifyTrade fills in the asset type and multiplier of a trade from its
+// symbol where they were not set
+func classifyTrade(trade *models.Trade) {
+	if trade.AssetType == "" {
+		trade.AssetType = AssetType(trade.Symbol)
+	}
+	if trade.Multiplier == 0 {
+		trade.Multiplier = Multiplier(trade.Symbol)
+	}
+}
+
+// RoundQuantity rounds a quantity to QuantityPrecision decimal places, so
+// positions bought and sold in fractions close at exactly zero
+func RoundQuantity(quantity float64) float64 {
+	return math.Round(quantity*quantityScale) / quantityScale
+}
+
+// ValidateQuantity checks a quantity is positive and tradable for its asset
+// type: whole shares for equities and contracts for options, at most
+// QuantityPrecision decimal places for crypto
+func (ps *PortfolioService) ValidateQuantity(assetType string, quantity float64) error {
+	if math.IsNaN(quantity) || math.IsInf(quantity, 0) || RoundQuantity(quantity) <= 0 {
+		return ErrInvalidQuantity
+	}
+	switch assetType {
+	case models.AssetTypeCrypto:
+		if scaled := quantity * quantityScale; math.Abs(scaled-math.Round(scaled)) > 1e-3 {
+			return fmt.Errorf("%w: %v has more than %d decimal places", ErrInvalidQuantity, quantity, QuantityPrecision)
+		}
+	default:
+		if quantity != math.Trunc(quantity) {
+			return fmt.Errorf("%w: %v is not a whole number of shares", ErrInvalidQuantity, quantity)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"hedge-fund/pkg/shared/cashflow"
+	"hedge-fund/pkg/shared/models"
+)
+
+// ValidateBenchmarkRule checks a rule's window and threshold
+func (ps *PortfolioService) ValidateBenchmarkRule(rule *models.BenchmarkRule) error {
+	if rule.Benchmark == "" {
+		return fmt.Errorf("%w: no benchmark", ErrInvalidBenchmarkRule)
+	}
+	if rule.WindowDays <= 0 || rule.WindowDays > 365 {
+		return fmt.Errorf("%w: window of %d days must be in [1, 365]", ErrInvalidBenchmarkRule, rule.WindowDays)
+	}
+	if rule.Threshold <= 0 || rule.Threshold > 100 {
+		return fmt.Errorf("%w: threshold %.2f must be in (0, 100]", ErrInvalidBenchmarkRule, rule.Threshold)
+	}
+	return nil
+}
+
+// CompareToBenchmark compares the portfolio's time-weighted return over its
+// snapshots, net of the cash transactions made between them, with the
+// benchmark's return between two closes. Snapshots and transactions must be
+// oldest first. The portfolio is lagging when it trails the benchmark by
+// more than the rule's threshold.
+func (ps *PortfolioService) CompareToBenchmark(rule *models.BenchmarkRule, snapshots []models.PortfolioSnapshot, cash []models.CashTransaction, benchmarkStart, benchmarkEnd float64) (models.BenchmarkComparison, error) {
+	if len(snapshots) == 0 || snapshots[0].TotalValue <= 0 || benchmarkStart <= 0 {
+		return models.BenchmarkComparison{}, fmt.Errorf("%w: no starting value", ErrInsufficientHistory)
+	}
+	returns, err := cashflow.Returns(snapshots, cash)
+	if err != nil {
+		return models.BenchmarkComparison{}, fmt.Errorf("%w: %v", ErrInsufficientHistory, err)
+	}
+	index := cashflow.Index(returns)
+	start, end := snapshots[0], snapshots[len(snapshots)-1]
+
+	comparison := models.BenchmarkComparison{
+		RuleID:          rule.ID,
+		PortfolioID:     rule.PortfolioID,
+		Benchmark:       rule.Benchmark,
+		StartDate:       start.SnapshotDate,
+		EndDate:         end.SnapshotDate,
+		PortfolioReturn: (index[len(index)-1] - 1) * 100,
+		BenchmarkReturn: (benchmarkEnd - benchmarkStart) / benchmarkStart * 100,
+		Threshold:       rule.Threshold,
+	}
+	comparison.RelativeReturn = comparison.PortfolioReturn - comparison.BenchmarkReturn
+	comparison.Lagging = comparison.RelativeReturn < -rule.Threshold
+	return comparison, nil
+}
+
+// SimulateBenchmark values a portfolio at each of its snapshots alongside a
+// counterfactual that put the same money into benchmark. The counterfactual
+// starts with the first snapshot's cash and the rest of its value in the
+// benchmark. Cash then moves as it did in the portfolio, fees included: buys
+// spend their value on the benchmark, and sales sell benchmark worth their
+// proceeds, or all of it when it is worth less. Cash transactions and trades
+// count from the first snapshot until the last, and are traded at the
+// benchmark's close on their day. Snapshots and closes must be oldest first.
+func (ps *PortfolioService) SimulateBenchmark(portfolioID int, benchmark string, snapshots []models.PortfolioSnapshot, cash []models.CashTransaction, trades []models.Trade, closes []models.Price) (models.BenchmarkSimulation, error) {
+	if len(snapshots) == 0 {
+		return models.BenchmarkSimulation{}, fmt.Errorf("%w: portfolio %d has no snapshots", ErrInsufficientHistory, portfolioID)
+	}
+	first, last := snapshots[0], snapshots[len(snapshots)-1]
+
+	// Days are only ever looked up in order, so the latest close on or
+	// before one is found by walking forward
+	next := 0
+	closeOn := func(t time.Time) float64 {
+		limit := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+		for next < len(closes) && closes[next].Timestamp.Before(limit) {
+			next++
+		}
+		if next == 0 {
+			return 0
+		}
+		return closes[next-1].Close
+	}
+
+	price := closeOn(first.SnapshotDate)
+	if price <= 0 {
+		return models.BenchmarkSimulation{}, fmt.Errorf("%w: no %s close on or before %s",
+			ErrInsufficientHistory, benchmark, first.SnapshotDate.Format("2006-01-02"))
+	}
+
+	type flow struct {
+		at    time.Time
+		cash  *models.CashTransaction
+		trade *models.Trade
+	}
+	var flows []flow
+	during := func(t time.Time) bool {
+		return !t.Before(first.CreatedAt) && t.Before(last.CreatedAt)
+	}
+	for i := range cash {
+		if during(cash[i].CreatedAt) {
+			flows = append(flows, flow{at: cash[i].CreatedAt, cash: &cash[i]})
+		}
+	}
+	for i := range trades {
+		if trades[i].ExecutedAt != nil && during(*trades[i].ExecutedAt) {
+			flows = append(flows, flow{at: *trades[i].ExecutedAt, trade: &trades[i]})
+		}
+	}
+	sort.SliceStable(flows, func(i, j int) bool { return flows[i].at.Before(flows[j].at) })
+
+	simulation := models.BenchmarkSimulation{
+		PortfolioID: portfolioID,
+		Benchmark:   benchmark,
+		StartDate:   first.SnapshotDate,
+		EndDate:     last.SnapshotDate,
+		Points:      make([]models.BenchmarkSimulationPoint, 0, len(snapshots)),
+	}
+	balance := first.Cash
+	units := (first.TotalValue - first.Cash) / price
+
+	applied := 0
+	for _, snapshot := range snapshots {
+		for ; applied < len(flows) && flows[applied].at.Before(snapshot.CreatedAt); applied++ {
+			f := flows[applied]
+			price := closeOn(f.at)
+			if entry := f.cash; entry != nil {
+				switch entry.Type {
+				case models.CashDeposit, models.CashTransferIn:
+					balance += entry.Amount
+					simulation.Contributions += entry.Amount
+				case models.CashWithdrawal, models.CashTransferOut:
+					balance -= entry.Amount
+					simulation.Withdrawals += entry.Amount
+				}
+				continue
+			}
+
+			value := f.trade.Value(f.trade.Price)
+			switch f.trade.Side {
+			case "buy":
+				balance -= value + f.trade.Fees
+				units += value / price
+			case "sell":
+				sold := math.Min(value/price, units)
+				units -= sold
+				balance += sold*price - f.trade.Fees
+			}
+		}
+
+		price := closeOn(snapshot.SnapshotDate)
+		simulation.Points = append(simulation.Points, models.BenchmarkSimulationPoint{
+			Date:           snapshot.SnapshotDate,
+			PortfolioValue: snapshot.TotalValue,
+			BenchmarkValue: balance + units*price,
+			BenchmarkClose: price,
+		})
+	}
+
+	end := simulation.Points[len(simulation.Points)-1]
+	simulation.PortfolioValue = end.PortfolioValue
+	simulation.BenchmarkValue = end.BenchmarkValue
+	simulation.Difference = end.PortfolioValue - end.BenchmarkValue
+	return simulation, nil
+}
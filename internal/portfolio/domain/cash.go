@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Cash transaction limits
+const (
+	MaxCashTransactionAmount = 1e12
+	MaxCashNoteLength        = 500
+)
+
+// AvailableCash is the cash that can leave a portfolio: its balance less the
+// cost of its pending buy orders and the margin it has in use
+func (ps *PortfolioService) AvailableCash(portfolio *models.Portfolio, pendingBuys float64) float64 {
+	return math.Max(portfolio.Cash-pendingBuys-portfolio.MarginUsed, 0)
+}
+
+// ApplyCashTransaction checks a deposit, withdrawal or side of a transfer
+// and applies it to the portfolio's cash, returning its ledger entry.
+// Withdrawals and outgoing transfers are limited to the available cash.
+func (ps *PortfolioService) ApplyCashTransaction(portfolio *models.Portfolio, txType string, amount, pendingBuys float64, note string) (*models.CashTransaction, error) {
+	if math.IsNaN(amount) || amount <= 0 || amount > MaxCashTransactionAmount {
+		return nil, fmt.Errorf("%w: amount must be positive and at most %.0f", ErrInvalidCashTransaction, float64(MaxCashTransactionAmount))
+	}
+	if math.Abs(amount*100-math.Round(amount*100)) > 1e-6 {
+		return nil, fmt.Errorf("%w: amount %v has more than 2 decimal places", ErrInvalidCashTransaction, amount)
+	}
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > MaxCashNoteLength {
+		return nil, fmt.Errorf("%w: note is longer than %d characters", ErrInvalidCashTransaction, MaxCashNoteLength)
+	}
+
+	switch txType {
+	case models.CashDeposit, models.CashTransferIn:
+		portfolio.Cash += amount
+		portfolio.TotalValue += amount
+	case models.CashWithdrawal, models.CashTransferOut:
+		if available := ps.AvailableCash(portfolio, pendingBuys); amount > available+1e-9 {
+			return nil, fmt.Errorf("%w: %.2f requested, %.2f available (%.2f reserved for pending buys, %.2f margin used)",
+				ErrInsufficientCash, amount, available, pendingBuys, portfolio.MarginUsed)
+		}
+		portfolio.Cash -= amount
+		portfolio.TotalValue -= amount
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidCashTransaction, txType)
+	}
+
+	return &models.CashTransaction{
+		PortfolioID:  portfolio.ID,
+		Type:         txType,
+		Amount:       amount,
+		BalanceAfter: portfolio.Cash,
+		Note:         note,
+	}, nil
+}
@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Competition errors
+var (
+	ErrInvalidCompetition = newError(ErrValidation, "invalid competition")
+	ErrInvalidEntry       = newError(ErrRejected, "portfolio cannot enter competition")
+	ErrCompetitionRule    = newError(ErrRejected, "trade breaks competition rules")
+)
+
+const maxCompetitionName = 100
+
+// ValidateCompetition checks a competition's definition, normalizing its
+// universe and truncating its dates to UTC days
+func ValidateCompetition(c *models.Competition) error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" || len(c.Name) > maxCompetitionName {
+		return fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidCompetition, maxCompetitionName)
+	}
+
+	c.StartDate = utcDay(c.StartDate)
+	c.EndDate = utcDay(c.EndDate)
+	if c.StartDate.IsZero() || c.EndDate.Before(c.StartDate) {
+		return fmt.Errorf("%w: end_date must not be before start_date", ErrInvalidCompetition)
+	}
+	if c.StartingCash <= 0 {
+		return fmt.Errorf("%w: starting_cash must be positive", ErrInvalidCompetition)
+	}
+	if c.Rules.MaxPositionPercent < 0 || c.Rules.MaxPositionPercent > 100 {
+		return fmt.Errorf("%w: max_position_percent must be between 0 and 100", ErrInvalidCompetition)
+	}
+	if c.Rules.MaxTradesPerDay < 0 {
+		return fmt.Errorf("%w: max_trades_per_day cannot be negative", ErrInvalidCompetition)
+	}
+
+	universe := symbols.NormalizeAll(c.Universe)
+	for _, symbol := range universe {
+		if err := symbols.Validate(symbol); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCompetition, err)
+		}
+	}
+	c.Universe = universe
+	return nil
+}
+
+// CompetitionStatus returns whether a competition is upcoming, active or
+// finished on the UTC day of now
+func CompetitionStatus(c *models.Competition, now time.Time) string {
+	today := utcDay(now)
+	switch {
+	case today.Before(c.StartDate):
+		return models.CompetitionUpcoming
+	case today.After(c.EndDate):
+		return models.CompetitionFinished
+	default:
+		return models.CompetitionActive
+	}
+}
+
+// CheckEntry reports whether a portfolio can enter a competition on the day
+// of now. Enrollment closes once the start date has passed, and entrants
+// start level: with no positions and exactly the starting cash.
+func CheckEntry(c *models.Competition, portfolio *models.Portfolio, now time.Time) error {
+	if utcDay(now).After(c.StartDate) {
+		return fmt.Errorf("%w: enrollment closed on %s", ErrInvalidEntry, c.StartDate.Format("2006-01-02"))
+	}
+	if len(portfolio.Positions) > 0 {
+		return fmt.Errorf("%w: portfolio %d holds positions", ErrInvalidEntry, portfolio.ID)
+	}
+	if math.Abs(portfolio.Cash-c.StartingCash) >= 0.005 {
+		return fmt.Errorf("%w: portfolio cash %.2f must equal the starting cash of %.2f", ErrInvalidEntry, portfolio.Cash, c.StartingCash)
+	}
+	return nil
+}
+
+// CheckCompetitionTrade enforces a competition's rules on a trade by an
+// enrolled portfolio. Nothing may be traded before the start date; after the
+// end date the portfolio trades freely. tradesToday counts the portfolio's
+// trades so far on the day of now.
+func CheckCompetitionTrade(c *models.Competition, portfolio *models.Portfolio, trade *models.Trade, price float64, tradesToday int, now time.Time) error {
+	switch CompetitionStatus(c, now) {
+	case models.CompetitionUpcoming:
+		return fmt.Errorf("%w: %s starts on %s", ErrCompetitionRule, c.Name, c.StartDate.Format("2006-01-02"))
+	case models.CompetitionFinished:
+		return nil
+	}
+
+	symbol := symbols.Normalize(trade.Symbol)
+	if len(c.Universe) > 0 && !contains(c.Universe, symbol) {
+		return fmt.Errorf("%w: %s is not in the universe of %s", ErrCompetitionRule, symbol, c.Name)
+	}
+	if c.Rules.MaxTradesPerDay > 0 && tradesToday >= c.Rules.MaxTradesPerDay {
+		return fmt.Errorf("%w: at most %d trades per day", ErrCompetitionRule, c.Rules.MaxTradesPerDay)
+	}
+
+	if trade.Side != "buy" || c.Rules.MaxPositionPercent == 0 {
+		return nil
+	}
+	if percent := positionPercentAfterBuy(portfolio, symbol, trade.Quantity, price); percent > c.Rules.MaxPositionPercent {
+		return fmt.Errorf("%w: %s would be %.2f%% of the portfolio, above the %.2f%% limit",
+			ErrCompetitionRule, symbol, percent, c.Rules.MaxPositionPercent)
+	}
+	return nil
+}
+
+// RankStandings orders standings by return, best first, and ranks them.
+// Equal returns share a rank, and the next rank skips past them.
+func RankStandings(standings []models.CompetitionStanding) {
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].ReturnPercent != standings[j].ReturnPercent {
+			return standings[i].ReturnPercent > standings[j].ReturnPercent
+		}
+		return standings[i].PortfolioID < standings[j].PortfolioID
+	})
+	for i := range standings {
+		if i > 0 && standings[i].ReturnPercent == standings[i-1].ReturnPercent {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+}
+
+func utcDay(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Portfolio detail limits
+const (
+	MaxPortfolioNameLength        = 255
+	MaxPortfolioDescriptionLength = 2000
+	MaxStrategyTags               = 10
+)
+
+// strategyTagPattern is a normalized strategy tag, e.g. "value" or "long-short"
+var strategyTagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// PortfolioDetails describe a portfolio to its owner. The base currency is
+// one of the portfolio's settings; empty inherits the owner's default.
+type PortfolioDetails struct {
+	Name         string
+	Description  string
+	BaseCurrency string
+	StrategyTags []string
+}
+
+// PortfolioPatch changes some of a portfolio's details and settings. Nil
+// fields are left as they are; empty StrategyTags clears them.
+type PortfolioPatch struct {
+	Name         *string
+	Description  *string
+	BaseCurrency *string
+	StrategyTags []string
+	Settings     map[string]json.RawMessage // JSON merge patch of the portfolio's settings
+	Version      int                        // Version the patch applies to; zero applies it to any
+}
+
+// NormalizeDetails trims the name and description, upper-cases the base
+// currency and lower-cases and de-duplicates the strategy tags, then checks
+// them
+func (ps *PortfolioService) NormalizeDetails(details *PortfolioDetails) error {
+	details.Name = strings.TrimSpace(details.Name)
+	if details.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPortfolioDetails)
+	}
+	if utf8.RuneCountInString(details.Name) > MaxPortfolioNameLength {
+		return fmt.Errorf("%w: name is longer than %d characters", ErrInvalidPortfolioDetails, MaxPortfolioNameLength)
+	}
+
+	details.Description = strings.TrimSpace(details.Description)
+	if utf8.RuneCountInString(details.Description) > MaxPortfolioDescriptionLength {
+		return fmt.Errorf("%w: description is longer than %d characters", ErrInvalidPortfolioDetails, MaxPortfolioDescriptionLength)
+	}
+
+	details.BaseCurrency = strings.ToUpper(strings.TrimSpace(details.BaseCurrency))
+	if details.BaseCurrency != "" && !SupportedCurrencies[details.BaseCurrency] {
+		return fmt.Errorf("%w: unsupported base_currency %q", ErrInvalidPortfolioDetails, details.BaseCurrency)
+	}
+
+	tags := make([]string, 0, len(details.StrategyTags))
+	seen := make(map[string]bool, len(details.StrategyTags))
+	for _, tag := range details.StrategyTags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !strategyTagPattern.MatchString(tag) {
+			return fmt.Errorf("%w: strategy tag %q must be 1-32 letters, digits, '-' or '_'", ErrInvalidPortfolioDetails, tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > MaxStrategyTags {
+		return fmt.Errorf("%w: at most %d strategy tags", ErrInvalidPortfolioDetails, MaxStrategyTags)
+	}
+	details.StrategyTags = tags
+	return nil
+}
+
+// ApplyDetailsPatch applies patch's details to details and normalizes the
+// result. Setting the base currency both directly and in the settings is
+// ambiguous and rejected.
+func (ps *PortfolioService) ApplyDetailsPatch(details *PortfolioDetails, patch PortfolioPatch) error {
+	if _, ok := patch.Settings["base_currency"]; ok && patch.BaseCurrency != nil {
+		return fmt.Errorf("%w: base_currency is set both directly and in settings", ErrInvalidPortfolioDetails)
+	}
+	if patch.Name != nil {
+		details.Name = *patch.Name
+	}
+	if patch.Description != nil {
+		details.Description = *patch.Description
+	}
+	if patch.BaseCurrency != nil {
+		details.BaseCurrency = *patch.BaseCurrency
+	}
+	if patch.StrategyTags != nil {
+		details.StrategyTags = patch.StrategyTags
+	}
+	return ps.NormalizeDetails(details)
+}
+
+// CheckVersion checks a portfolio is at the version an update was based on.
+// A zero version matches any.
+func (ps *PortfolioService) CheckVersion(portfolio *models.Portfolio, version int) error {
+	if version != 0 && portfolio.Version != version {
+		return fmt.Errorf("%w: portfolio %d is at version %d, not %d", ErrVersionConflict, portfolio.ID, portfolio.Version, version)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// DefaultDriftThreshold is the drift, in percentage points, that raises an
+// alert when an allocation model does not set its own
+const DefaultDriftThreshold = 5.0
+
+// ValidateAllocationModel checks a model's basis and that its targets are
+// percentages adding up to no more than 100
+func (ps *PortfolioService) ValidateAllocationModel(model *models.AllocationModel) error {
+	if model.Basis != models.AllocationBasisSymbol && model.Basis != models.AllocationBasisSector {
+		return fmt.Errorf("%w: unknown basis %q", ErrInvalidAllocation, model.Basis)
+	}
+	if len(model.Targets) == 0 {
+		return fmt.Errorf("%w: no targets", ErrInvalidAllocation)
+	}
+	if model.DriftThreshold <= 0 || model.DriftThreshold > 100 {
+		return fmt.Errorf("%w: drift threshold %.2f must be in (0, 100]", ErrInvalidAllocation, model.DriftThreshold)
+	}
+
+	total := 0.0
+	for key, target := range model.Targets {
+		if target < 0 || target > 100 {
+			return fmt.Errorf("%w: target for %s is %.2f%%", ErrInvalidAllocation, key, target)
+		}
+		total += target
+	}
+	if total > 100.0001 {
+		return fmt.Errorf("%w: targets add up to %.2f%%", ErrInvalidAllocation, total)
+	}
+	return nil
+}
+
+// CalculateDrift compares a portfolio's current allocation with a model's
+// targets. Holdings without a target count as drift away from a 0% target;
+// cash is only compared when the model targets CASH. For sector models,
+// sectors maps symbols to sectors and unmapped symbols fall under "Unknown".
+// Drifts are ordered largest first.
+func (ps *PortfolioService) CalculateDrift(portfolio *models.Portfolio, model *models.AllocationModel, currentPrices map[string]float64, sectors map[string]string) []models.AllocationDrift {
+	actual := ps.CalculatePortfolioAllocation(portfolio, currentPrices)
+	cash, hasCash := actual["CASH"]
+	delete(actual, "CASH")
+
+	if model.Basis == models.AllocationBasisSector {
+		bySector := make(map[string]float64)
+		for symbol, percent := range actual {
+			sector := sectors[symbol]
+			if sector == "" {
+				sector = "Unknown"
+			}
+			bySector[sector] += percent
+		}
+		actual = bySector
+	}
+	if _, targeted := model.Targets["CASH"]; targeted && hasCash {
+		actual["CASH"] = cash
+	}
+
+	drifts := make([]models.AllocationDrift, 0, len(actual)+len(model.Targets))
+	for key, target := range model.Targets {
+		drifts = append(drifts, models.AllocationDrift{Key: key, Target: target, Actual: actual[key], Drift: actual[key] - target})
+	}
+	for key, percent := range actual {
+		if _, targeted := model.Targets[key]; !targeted {
+			drifts = append(drifts, models.AllocationDrift{Key: key, Actual: percent, Drift: percent})
+		}
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		if abs(drifts[i].Drift) != abs(drifts[j].Drift) {
+			return abs(drifts[i].Drift) > abs(drifts[j].Drift)
+		}
+		return drifts[i].Key < drifts[j].Key
+	})
+	return drifts
+}
+
+// MaxDrift returns the largest absolute drift
+func MaxDrift(drifts []models.AllocationDrift) float64 {
+	max := 0.0
+	for _, d := range drifts {
+		if abs(d.Drift) > max {
+			max = abs(d.Drift)
+		}
+	}
+	return max
+}
@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error categories. Every domain error belongs to one, so callers can handle
+// a whole category, such as choosing an HTTP status, with errors.Is.
+var (
+	// ErrNotFound is the category of errors for missing portfolios,
+	// positions and other records
+	ErrNotFound = errors.New("not found")
+
+	// ErrValidation is the category of errors for malformed requests
+	ErrValidation = errors.New("validation failed")
+
+	// ErrInsufficientFunds is the category of errors for trades and
+	// transfers the portfolio holds too little cash or too few shares for
+	ErrInsufficientFunds = errors.New("insufficient funds")
+
+	// ErrConflict is the category of errors for requests that clash with a
+	// concurrent change or an existing record
+	ErrConflict = errors.New("conflict")
+
+	// ErrRejected is the category of errors for well-formed requests the
+	// portfolio's state or rules do not allow
+	ErrRejected = errors.New("rejected")
+)
+
+// categorizedError is a domain error belonging to a category
+type categorizedError struct {
+	msg      string
+	category error
+}
+
+func (e *categorizedError) Error() string { return e.msg }
+
+func (e *categorizedError) Unwrap() error { return e.category }
+
+func newError(category error, msg string) error {
+	return &categorizedError{msg: msg, category: category}
+}
+
+// NotFoundf returns an error in the ErrNotFound category
+func NotFoundf(format string, args ...interface{}) error {
+	return newError(ErrNotFound, fmt.Sprintf(format, args...))
+}
+
+// Conflictf returns an error in the ErrConflict category
+func Conflictf(format string, args ...interface{}) error {
+	return newError(ErrConflict, fmt.Sprintf(format, args...))
+}
+
+// ErrPortfolioNotFound is returned for portfolio IDs with no portfolio
+var ErrPortfolioNotFound = newError(ErrNotFound, "portfolio not found")
+
+// Trade validation errors. They are wrapped with the offending values, so
+// callers should match them with errors.Is.
+var (
+	ErrInvalidQuantity    = newError(ErrValidation, "quantity must be positive")
+	ErrInvalidPrice       = newError(ErrValidation, "invalid current price")
+	ErrInvalidSide        = newError(ErrValidation, "invalid order side")
+	ErrInsufficientCash   = newError(ErrInsufficientFunds, "insufficient cash balance")
+	ErrInsufficientShares = newError(ErrInsufficientFunds, "insufficient shares")
+	ErrPositionNotFound   = newError(ErrNotFound, "position not found")
+	ErrOptionExpired      = newError(ErrValidation, "option contract has expired")
+	ErrInvalidTimeInForce = newError(ErrValidation, "invalid time in force")
+)
+
+// IsInvalidOrder reports whether err is caused by a malformed order rather
+// than by the state of the portfolio
+func IsInvalidOrder(err error) bool {
+	return errors.Is(err, ErrInvalidQuantity) || errors.Is(err, ErrInvalidPrice) || errors.Is(err, ErrInvalidSide) ||
+		errors.Is(err, ErrOptionExpired) || errors.Is(err, ErrInvalidTimeInForce)
+}
+
+// ErrLiveRebalance is returned when a rebalance is executed on a portfolio
+// trading through a live broker, whose fills cannot be applied atomically
+var ErrLiveRebalance = newError(ErrRejected, "rebalance execution is only supported for paper portfolios")
+
+// ErrFeatureDisabled is returned for operations gated behind a feature flag
+// that is off for the portfolio's owner
+var ErrFeatureDisabled = newError(ErrRejected, "feature is not enabled")
+
+// ErrInvalidAllocation is returned for allocation models with invalid targets
+var ErrInvalidAllocation = newError(ErrValidation, "invalid allocation model")
+
+// ErrSlippageExceeded is returned when a market order is re-quoted before
+// filling and the price has moved against it by more than the tolerance
+var ErrSlippageExceeded = newError(ErrConflict, "price moved beyond slippage tolerance")
+
+// Benchmark alerting errors
+var (
+	ErrInvalidBenchmark     = newError(ErrValidation, "invalid benchmark")
+	ErrInvalidBenchmarkRule = newError(ErrValidation, "invalid benchmark rule")
+	ErrInsufficientHistory  = newError(ErrRejected, "not enough history")
+)
+
+// ErrInvalidSettings is returned for portfolio settings with invalid options
+// or options that cannot be combined
+var ErrInvalidSettings = newError(ErrValidation, "invalid portfolio settings")
+
+// ErrPositionLimit is returned for buys that would take a position past the
+// share of the portfolio its risk tolerance allows
+var ErrPositionLimit = newError(ErrRejected, "position above risk tolerance limit")
+
+// ErrOrderNotFilled is returned when the venue cancels an IOC or FOK order
+// without filling any of it
+var ErrOrderNotFilled = newError(ErrRejected, "order not filled")
+
+// ErrInvalidVaRBacktest is returned for VaR backtests with invalid parameters
+var ErrInvalidVaRBacktest = newError(ErrValidation, "invalid VaR backtest")
+
+// ErrInvalidStopLoss is returned for stop-losses that cannot be set on a
+// position
+var ErrInvalidStopLoss = newError(ErrValidation, "invalid stop-loss")
+
+// ErrInvalidPortfolioDetails is returned for portfolio names, descriptions,
+// base currencies or strategy tags that cannot be stored
+var ErrInvalidPortfolioDetails = newError(ErrValidation, "invalid portfolio details")
+
+// ErrVersionConflict is returned when a portfolio is updated on the
+// condition it is at a version it has since moved on from
+var ErrVersionConflict = newError(ErrConflict, "portfolio was modified concurrently")
+
+// ErrInvalidCashTransaction is returned for deposits, withdrawals and
+// transfers with invalid amounts or portfolios
+var ErrInvalidCashTransaction = newError(ErrValidation, "invalid cash transaction")
+
+// ErrInvalidPositionAlert is returned for position alerts without a level
+// or with levels out of range
+var ErrInvalidPositionAlert = newError(ErrValidation, "invalid position alert")
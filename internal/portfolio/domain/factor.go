@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// FactorLookbackDays is the daily bars beta and factor exposures are
+// estimated from
+const FactorLookbackDays = 252
+
+const (
+	// momentumSkipDays leaves the most recent month out of momentum, which
+	// tends to reverse over short horizons
+	momentumSkipDays = 21
+	// minBetaReturns is the fewest daily returns a position must share with
+	// the benchmark for its beta to be estimated
+	minBetaReturns = 20
+)
+
+// factorStats are a symbol's price-based factor proxies
+type factorStats struct {
+	dollarVolume float64 // Average daily close times volume
+	momentum     float64 // Return from the first bar to a month before the last
+	discount     float64 // Fraction below the highest close
+}
+
+// CalculateFactorExposure estimates a portfolio's beta to benchmark and its
+// size, momentum and value exposures from daily bars per symbol, oldest
+// first. There are no fundamentals, so the factors are price-based proxies:
+// average dollar volume for size, 12-1 month return for momentum and the
+// discount to the 52-week high for value. Options are measured by their
+// underlying's bars at their own value, not their delta-adjusted exposure.
+// Positions without enough history contribute zero.
+func (ps *PortfolioService) CalculateFactorExposure(portfolio *models.Portfolio, currentPrices map[string]float64, bars map[string][]models.Price, benchmark string, now time.Time) models.FactorExposure {
+	exposure := models.FactorExposure{
+		PortfolioID:  portfolio.ID,
+		Benchmark:    benchmark,
+		ExposureDate: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		Positions:    make([]models.PositionFactorExposure, 0, len(portfolio.Positions)),
+	}
+	totalValue := ps.CalculatePortfolioValue(portfolio, currentPrices)
+	if totalValue <= 0 {
+		return exposure
+	}
+
+	benchmarkBars := recentBars(bars[benchmark])
+	market := statsOf(benchmarkBars)
+	for _, position := range portfolio.Positions {
+		price, ok := currentPrices[position.Symbol]
+		if !ok {
+			continue
+		}
+		symbol := position.Symbol
+		if contract, ok := symbols.ParseOption(symbol); ok {
+			symbol = contract.Underlying
+		}
+		series := recentBars(bars[symbol])
+		stats := statsOf(series)
+
+		factors := models.PositionFactorExposure{
+			Symbol: position.Symbol,
+			Weight: position.Value(price) / totalValue,
+			Beta:   betaTo(series, benchmarkBars),
+		}
+		if stats.dollarVolume > 0 && market.dollarVolume > 0 {
+			factors.Size = math.Log(stats.dollarVolume / market.dollarVolume)
+		}
+		if len(series) > momentumSkipDays+1 {
+			factors.Momentum = stats.momentum - market.momentum
+			factors.Value = stats.discount - market.discount
+		}
+
+		exposure.Beta += factors.Weight * factors.Beta
+		exposure.Size += factors.Weight * factors.Size
+		exposure.Momentum += factors.Weight * factors.Momentum
+		exposure.Value += factors.Weight * factors.Value
+		exposure.Positions = append(exposure.Positions, factors)
+	}
+	return exposure
+}
+
+// recentBars returns the last FactorLookbackDays returns' worth of bars
+func recentBars(bars []models.Price) []models.Price {
+	if len(bars) > FactorLookbackDays+1 {
+		return bars[len(bars)-FactorLookbackDays-1:]
+	}
+	return bars
+}
+
+func statsOf(bars []models.Price) factorStats {
+	var stats factorStats
+	if len(bars) == 0 {
+		return stats
+	}
+
+	high := 0.0
+	for _, bar := range bars {
+		stats.dollarVolume += bar.Close * float64(bar.Volume)
+		high = math.Max(high, bar.Close)
+	}
+	stats.dollarVolume /= float64(len(bars))
+
+	last := bars[len(bars)-1].Close
+	if high > 0 {
+		stats.discount = 1 - last/high
+	}
+	if len(bars) > momentumSkipDays+1 && bars[0].Close > 0 {
+		stats.momentum = bars[len(bars)-1-momentumSkipDays].Close/bars[0].Close - 1
+	}
+	return stats
+}
+
+// betaTo regresses the daily returns of bars on the benchmark's over the
+// consecutive days both have a bar, or returns zero with too few of them
+func betaTo(bars, benchmark []models.Price) float64 {
+	closes := make(map[string]float64, len(benchmark))
+	for _, bar := range benchmark {
+		closes[bar.Timestamp.UTC().Format("2006-01-02")] = bar.Close
+	}
+
+	var xs, ys []float64
+	for i := 1; i < len(bars); i++ {
+		previous, ok := closes[bars[i-1].Timestamp.UTC().Format("2006-01-02")]
+		current, ok2 := closes[bars[i].Timestamp.UTC().Format("2006-01-02")]
+		if !ok || !ok2 || previous <= 0 || bars[i-1].Close <= 0 {
+			continue
+		}
+		xs = append(xs, current/previous-1)
+		ys = append(ys, bars[i].Close/bars[i-1].Close-1)
+	}
+	if len(xs) < minBetaReturns {
+		return 0
+	}
+
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(len(xs))
+	meanY /= float64(len(ys))
+
+	var covariance, variance float64
+	for i := range xs {
+		covariance += (xs[i] - meanX) * (ys[i] - meanY)
+		variance += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if variance == 0 {
+		return 0
+	}
+	return covariance / variance
+}
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// MaxFeeLevies caps the exchange and regulatory levies of a fee schedule
+const MaxFeeLevies = 10
+
+// CalculateFees returns the fees charged by schedule on a trade of quantity
+// shares or coins worth value: the commission, then each levy that applies, and
+// their total
+func (ps *PortfolioService) CalculateFees(schedule models.FeeSchedule, side string, quantity, value float64) (float64, []models.FeeItem) {
+	var commission float64
+	switch schedule.Model {
+	case models.FeeModelFlat:
+		commission = schedule.Flat
+	case models.FeeModelPerShare:
+		commission = quantity * schedule.PerShare
+	case models.FeeModelTiered:
+		floor := 0.0
+		for _, tier := range schedule.Tiers {
+			if tier.UpTo == 0 || value <= tier.UpTo {
+				commission += (value - floor) * tier.Rate
+				break
+			}
+			commission += (tier.UpTo - floor) * tier.Rate
+			floor = tier.UpTo
+		}
+	default:
+		commission = value * schedule.Rate
+	}
+	if commission < schedule.Minimum {
+		commission = schedule.Minimum
+	}
+	if schedule.Maximum > 0 && commission > schedule.Maximum {
+		commission = schedule.Maximum
+	}
+
+	items := []models.FeeItem{{Name: models.FeeCommission, Amount: commission}}
+	total := commission
+	for _, levy := range schedule.Levies {
+		if levy.Side != "" && levy.Side != side {
+			continue
+		}
+		amount := value*levy.Rate + quantity*levy.PerShare
+		if levy.Maximum > 0 && amount > levy.Maximum {
+			amount = levy.Maximum
+		}
+		if amount == 0 {
+			continue
+		}
+		items = append(items, models.FeeItem{Name: levy.Name, Amount: amount})
+		total += amount
+	}
+	return total, items
+}
+
+// ValidateFeeSchedule checks a fee schedule's model has what it needs and
+// its amounts and rates are in range
+func (ps *PortfolioService) ValidateFeeSchedule(schedule models.FeeSchedule) error {
+	return ps.validateFeeSchedule("fee_schedule", schedule)
+}
+
+// validateFeeSchedule validates the fee schedule set as option name
+func (ps *PortfolioService) validateFeeSchedule(name string, schedule models.FeeSchedule) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidSettings, name, fmt.Sprintf(format, args...))
+	}
+	validRate := func(rate float64) bool { return rate >= 0 && rate <= MaxCommissionRate }
+
+	switch schedule.Model {
+	case "", models.FeeModelPercentage:
+		if !validRate(schedule.Rate) {
+			return invalid("rate %.4f must be in [0, %.2f]", schedule.Rate, MaxCommissionRate)
+		}
+	case models.FeeModelFlat:
+		if schedule.Flat < 0 {
+			return invalid("flat must not be negative")
+		}
+	case models.FeeModelPerShare:
+		if schedule.PerShare < 0 {
+			return invalid("per_share must not be negative")
+		}
+	case models.FeeModelTiered:
+		if len(schedule.Tiers) == 0 {
+			return invalid("tiered model needs tiers")
+		}
+		floor := 0.0
+		for i, tier := range schedule.Tiers {
+			if !validRate(tier.Rate) {
+				return invalid("tier %d rate %.4f must be in [0, %.2f]", i+1, tier.Rate, MaxCommissionRate)
+			}
+			last := i == len(schedule.Tiers)-1
+			if last != (tier.UpTo == 0) {
+				return invalid("every tier but the last needs up_to, and the last must not have one")
+			}
+			if !last && tier.UpTo <= floor {
+				return invalid("tier up_to values must increase")
+			}
+			floor = tier.UpTo
+		}
+	default:
+		return invalid("unknown model %q", schedule.Model)
+	}
+	if schedule.Minimum < 0 || schedule.Maximum < 0 {
+		return invalid("minimum and maximum must not be negative")
+	}
+	if schedule.Maximum > 0 && schedule.Maximum < schedule.Minimum {
+		return invalid("maximum %.2f is below minimum %.2f", schedule.Maximum, schedule.Minimum)
+	}
+
+	if len(schedule.Levies) > MaxFeeLevies {
+		return invalid("at most %d levies", MaxFeeLevies)
+	}
+	names := make(map[string]bool, len(schedule.Levies))
+	for _, levy := range schedule.Levies {
+		name := levy.Name
+		if name == "" || name != strings.TrimSpace(name) || len(name) > 32 || name == models.FeeCommission {
+			return invalid("levy name %q must be 1-32 characters other than %q", levy.Name, models.FeeCommission)
+		}
+		if names[name] {
+			return invalid("levy %q is listed twice", name)
+		}
+		names[name] = true
+		if !validRate(levy.Rate) || levy.PerShare < 0 || levy.Maximum < 0 {
+			return invalid("levy %q has a negative amount or a rate above %.2f", name, MaxCommissionRate)
+		}
+		if levy.Side != "" && levy.Side != "buy" && levy.Side != "sell" {
+			return invalid("levy %q side must be buy or sell", name)
+		}
+	}
+	return nil
+}
+
+// ParseBrokerFeeSchedules reads the fee schedules of execution venues from
+// a JSON object keyed by venue name. Empty configures none.
+func (ps *PortfolioService) ParseBrokerFeeSchedules(raw string) (map[string]models.FeeSchedule, error) {
+	schedules := make(map[string]models.FeeSchedule)
+	if strings.TrimSpace(raw) == "" {
+		return schedules, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &schedules); err != nil {
+		return nil, fmt.Errorf("invalid broker fee schedules: %w", err)
+	}
+	for name, schedule := range schedules {
+		if err := ps.ValidateFeeSchedule(schedule); err != nil {
+			return nil, fmt.Errorf("broker %s: %w", name, err)
+		}
+	}
+	return schedules, nil
+}
@@ -0,0 +1,428 @@
+package domain
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// ErrInvalidImport is returned for imports that cannot be read or mapped
+var ErrInvalidImport = newError(ErrValidation, "invalid import")
+
+// Import limits
+const (
+	MaxImportSize = 5 << 20 // Bytes of an uploaded file
+	MaxImportRows = 20000
+)
+
+// Fields an import's columns are mapped to
+const (
+	ImportFieldDate     = "date" // Execution date of a trade, acquisition date of a position
+	ImportFieldSymbol   = "symbol"
+	ImportFieldSide     = "side" // Optional; without it a negative quantity is a sell
+	ImportFieldQuantity = "quantity"
+	ImportFieldPrice    = "price" // Execution price of a trade, cost per share of a position
+	ImportFieldFees     = "fees"
+	ImportFieldTradeID  = "trade_id" // The broker's ID of a trade, which identifies duplicates
+)
+
+// importFields are the fields of each import kind, required ones first
+var importFields = map[string]struct{ required, optional []string }{
+	models.ImportKindTrades: {
+		required: []string{ImportFieldDate, ImportFieldSymbol, ImportFieldQuantity, ImportFieldPrice},
+		optional: []string{ImportFieldSide, ImportFieldFees, ImportFieldTradeID},
+	},
+	models.ImportKindPositions: {
+		required: []string{ImportFieldSymbol, ImportFieldQuantity, ImportFieldPrice},
+		optional: []string{ImportFieldDate},
+	},
+}
+
+// importAliases are the column headers brokerages commonly export for each
+// field, normalized by normalizeHeader
+var importAliases = map[string][]string{
+	ImportFieldDate:     {"date", "trade date", "transaction date", "execution date", "activity date", "run date", "date acquired", "acquired", "open date"},
+	ImportFieldSymbol:   {"symbol", "ticker", "instrument", "security symbol"},
+	ImportFieldSide:     {"side", "action", "buy sell", "transaction type", "type"},
+	ImportFieldQuantity: {"quantity", "qty", "shares", "units", "amount of shares"},
+	ImportFieldPrice:    {"price", "execution price", "trade price", "price per share", "unit price", "average cost", "avg cost", "cost per share", "cost basis per share", "unit cost"},
+	ImportFieldFees:     {"fees", "fee", "commission", "commissions", "commissions and fees", "fees and commissions"},
+	ImportFieldTradeID:  {"trade id", "transaction id", "order id", "execution id", "reference", "confirmation number"},
+}
+
+// importDateLayouts are the date formats imports are read in, tried in order
+var importDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"01/02/2006 15:04:05",
+	"01/02/2006",
+	"1/2/2006",
+	"01/02/06",
+	"02-Jan-2006",
+	"Jan 2, 2006",
+}
+
+var headerSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ImportRow is one row of an import as mapped to its fields. Rows with an
+// Error or that are Duplicates are not imported.
+type ImportRow struct {
+	Line      int       `json:"line"` // In the file, counting the header as line 1
+	Date      time.Time `json:"date"`
+	Symbol    string    `json:"symbol"`
+	Side      string    `json:"side"` // "buy" or "sell"; positions are imported as buys
+	Quantity  float64   `json:"quantity"`
+	Price     float64   `json:"price"`
+	Fees      float64   `json:"fees"`
+	TradeID   string    `json:"trade_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Duplicate bool      `json:"duplicate,omitempty"`
+}
+
+// Importable reports whether the row is imported
+func (r ImportRow) Importable() bool {
+	return r.Error == "" && !r.Duplicate
+}
+
+// ValidateImportKind checks an import's kind and file format
+func ValidateImportKind(kind, format string) error {
+	if _, ok := importFields[kind]; !ok {
+		return fmt.Errorf("%w: kind must be %s or %s", ErrInvalidImport, models.ImportKindTrades, models.ImportKindPositions)
+	}
+	switch format {
+	case models.ImportFormatCSV:
+		return nil
+	case models.ImportFormatOFX:
+		return fmt.Errorf("%w: OFX files are not supported yet", ErrInvalidImport)
+	}
+	return fmt.Errorf("%w: unknown format %q", ErrInvalidImport, format)
+}
+
+// ReadImportCSV reads the header and records of a CSV import. Blank lines
+// are skipped and records may have fewer or more fields than the header.
+func ReadImportCSV(r io.Reader) ([]string, [][]string, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("%w: file is empty", ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: failed to read header: %v", ErrInvalidImport, err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		if len(records) == MaxImportRows {
+			return nil, nil, fmt.Errorf("%w: at most %d rows per import", ErrInvalidImport, MaxImportRows)
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("%w: file has no rows", ErrInvalidImport)
+	}
+	return header, records, nil
+}
+
+// normalizeHeader lowercases a column header and reduces its punctuation to
+// single spaces, so "Commissions & Fees" matches "commissions and fees"
+func normalizeHeader(header string) string {
+	header = strings.ReplaceAll(strings.ToLower(header), "&", " and ")
+	return strings.TrimSpace(headerSeparators.ReplaceAllString(header, " "))
+}
+
+// SuggestImportMapping maps each field of the kind to the first column whose
+// header is a known alias of it. Fields without such a column are left out.
+func SuggestImportMapping(kind string, columns []string) map[string]string {
+	fields := importFields[kind]
+	mapping := make(map[string]string)
+	taken := make(map[string]bool)
+	for _, field := range append(append([]string{}, fields.required...), fields.optional...) {
+		for _, alias := range importAliases[field] {
+			for _, column := range columns {
+				if !taken[column] && normalizeHeader(column) == alias {
+					mapping[field] = column
+					taken[column] = true
+					break
+				}
+			}
+			if _, ok := mapping[field]; ok {
+				break
+			}
+		}
+	}
+	return mapping
+}
+
+// ValidateImportMapping checks a mapping names only fields of the kind and
+// columns of the file, and maps every required field
+func ValidateImportMapping(kind string, columns []string, mapping map[string]string) error {
+	fields := importFields[kind]
+	known := make(map[string]bool)
+	for _, field := range append(append([]string{}, fields.required...), fields.optional...) {
+		known[field] = true
+	}
+	present := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		present[column] = true
+	}
+
+	for field, column := range mapping {
+		if !known[field] {
+			return fmt.Errorf("%w: %s imports have no %q field", ErrInvalidImport, kind, field)
+		}
+		if !present[column] {
+			return fmt.Errorf("%w: field %s is mapped to %q, which is not a column of the file", ErrInvalidImport, field, column)
+		}
+	}
+	for _, field := range fields.required {
+		if mapping[field] == "" {
+			return fmt.Errorf("%w: field %s must be mapped to a column", ErrInvalidImport, field)
+		}
+	}
+	return nil
+}
+
+// ParseImportRows maps the records of an import to rows. Records that fail
+// validation are returned with their Error set; the mapping must be valid.
+// Positions are read as buys of their quantity at their cost, acquired on
+// their date or now.
+func (ps *PortfolioService) ParseImportRows(kind string, columns []string, records [][]string, mapping map[string]string, now time.Time) ([]ImportRow, error) {
+	if err := ValidateImportMapping(kind, columns, mapping); err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(mapping))
+	for field, column := range mapping {
+		for i, c := range columns {
+			if c == column {
+				index[field] = i
+				break
+			}
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := index[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := make([]ImportRow, 0, len(records))
+	for i, record := range records {
+		row := ImportRow{Line: i + 2, Side: "buy"}
+		if err := ps.parseImportRow(&row, kind, func(name string) string { return field(record, name) }, now); err != nil {
+			row.Error = err.Error()
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (ps *PortfolioService) parseImportRow(row *ImportRow, kind string, field func(string) string, now time.Time) error {
+	row.Symbol = symbols.Normalize(field(ImportFieldSymbol))
+	if err := symbols.Validate(row.Symbol); err != nil {
+		return fmt.Errorf("invalid symbol %q", field(ImportFieldSymbol))
+	}
+
+	row.Date = now
+	if value := field(ImportFieldDate); value != "" || kind == models.ImportKindTrades {
+		date, err := parseImportDate(value)
+		if err != nil {
+			return err
+		}
+		if date.After(now) {
+			return fmt.Errorf("date %s is in the future", value)
+		}
+		row.Date = date
+	}
+
+	quantity, err := parseImportNumber(field(ImportFieldQuantity))
+	if err != nil {
+		return fmt.Errorf("invalid quantity %q", field(ImportFieldQuantity))
+	}
+	if kind == models.ImportKindTrades {
+		side, err := parseImportSide(field(ImportFieldSide), quantity)
+		if err != nil {
+			return err
+		}
+		row.Side = side
+	} else if quantity < 0 {
+		return fmt.Errorf("short positions cannot be imported")
+	}
+	row.Quantity = RoundQuantity(math.Abs(quantity))
+	if err := ps.ValidateQuantity(AssetType(row.Symbol), row.Quantity); err != nil {
+		return err
+	}
+
+	row.Price, err = parseImportNumber(field(ImportFieldPrice))
+	if err != nil || row.Price <= 0 {
+		return fmt.Errorf("invalid price %q", field(ImportFieldPrice))
+	}
+	if value := field(ImportFieldFees); value != "" {
+		fees, err := parseImportNumber(value)
+		if err != nil {
+			return fmt.Errorf("invalid fees %q", value)
+		}
+		row.Fees = math.Abs(fees) // Some brokers export fees as a debit
+	}
+	row.TradeID = field(ImportFieldTradeID)
+	return nil
+}
+
+// parseImportSide reads a trade's side, or takes it from the sign of its
+// quantity when the side column is not mapped or empty
+func parseImportSide(value string, quantity float64) (string, error) {
+	switch strings.ToLower(value) {
+	case "":
+		if quantity < 0 {
+			return "sell", nil
+		}
+		return "buy", nil
+	case "buy", "b", "bought", "buy to open", "buy to cover", "you bought":
+		return "buy", nil
+	case "sell", "s", "sold", "sell to close", "sell short", "you sold":
+		return "sell", nil
+	}
+	return "", fmt.Errorf("unknown side %q", value)
+}
+
+// parseImportNumber reads an amount as brokerages export it, allowing
+// currency symbols, thousands separators and accounting negatives "(1.50)"
+func parseImportNumber(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")")
+	value = strings.Trim(value, "()")
+	value = strings.NewReplacer("$", "", ",", "", " ", "").Replace(value)
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	if negative {
+		number = -math.Abs(number)
+	}
+	return number, nil
+}
+
+func parseImportDate(value string) (time.Time, error) {
+	for _, layout := range importDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// MarkDuplicateTrades flags rows already recorded as trades of the
+// portfolio: those with the trade ID of an existing trade, and those matching
+// an existing trade's symbol, side, quantity, price and day. Identical rows
+// only match as many existing trades as there are, so repeated fills in the
+// file are kept.
+func MarkDuplicateTrades(rows []ImportRow, existing []models.Trade) {
+	ids := make(map[string]bool)
+	counts := make(map[string]int)
+	for _, trade := range existing {
+		if trade.BrokerOrderID != "" {
+			ids[trade.BrokerOrderID] = true
+		}
+		if trade.ExecutedAt != nil {
+			counts[tradeFingerprint(trade.Symbol, trade.Side, trade.Quantity, trade.Price, *trade.ExecutedAt)]++
+		}
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		if row.Error != "" {
+			continue
+		}
+		if row.TradeID != "" {
+			if ids[row.TradeID] {
+				row.Duplicate = true
+			}
+			ids[row.TradeID] = true
+			if row.Duplicate {
+				continue
+			}
+		}
+		key := tradeFingerprint(row.Symbol, row.Side, row.Quantity, row.Price, row.Date)
+		if counts[key] > 0 {
+			counts[key]--
+			row.Duplicate = true
+		}
+	}
+}
+
+// MarkDuplicatePositions flags position rows of symbols the portfolio
+// already holds or an earlier row lists
+func MarkDuplicatePositions(rows []ImportRow, held []models.Position) {
+	seen := make(map[string]bool, len(held))
+	for _, position := range held {
+		seen[symbols.Normalize(position.Symbol)] = true
+	}
+	for i := range rows {
+		row := &rows[i]
+		if row.Error != "" {
+			continue
+		}
+		row.Duplicate = seen[row.Symbol]
+		seen[row.Symbol] = true
+	}
+}
+
+func tradeFingerprint(symbol, side string, quantity, price float64, executedAt time.Time) string {
+	return fmt.Sprintf("%s|%s|%.8f|%.4f|%s", symbols.Normalize(symbol), side, quantity, price, executedAt.UTC().Format("2006-01-02"))
+}
+
+// OrderImportRows sorts rows in the order they are applied: by date, then
+// buys before sells, so a round trip exported without times never sells
+// before it buys, then by line
+func OrderImportRows(rows []ImportRow) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if !rows[i].Date.Equal(rows[j].Date) {
+			return rows[i].Date.Before(rows[j].Date)
+		}
+		if rows[i].Side != rows[j].Side {
+			return rows[i].Side == "buy"
+		}
+		return rows[i].Line < rows[j].Line
+	})
+}
+
+// ImportSpan returns the first and last dates of the rows without errors
+func ImportSpan(rows []ImportRow) (time.Time, time.Time) {
+	var first, last time.Time
+	for _, row := range rows {
+		if row.Error != "" {
+			continue
+		}
+		if first.IsZero() || row.Date.Before(first) {
+			first = row.Date
+		}
+		if row.Date.After(last) {
+			last = row.Date
+		}
+	}
+	return first, last
+}
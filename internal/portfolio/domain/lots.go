@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+const day = 24 * time.Hour
+
+// NewLot opens a lot for the quantity acquired by a filled buy
+func NewLot(portfolioID int, trade *models.Trade) models.PositionLot {
+	acquiredAt := time.Now()
+	if trade.ExecutedAt != nil {
+		acquiredAt = *trade.ExecutedAt
+	}
+	return models.PositionLot{
+		PortfolioID:       portfolioID,
+		Symbol:            trade.Symbol,
+		TradeID:           trade.ID,
+		Quantity:          trade.Quantity,
+		RemainingQuantity: trade.Quantity,
+		Price:             trade.Price,
+		AcquiredAt:        acquiredAt,
+	}
+}
+
+// OrderLots returns open lots in the order a sale consumes them under a cost
+// basis method: oldest first for FIFO, newest first for LIFO and
+// highest-priced first for HIFO (oldest first among equal prices). Unknown
+// methods are FIFO.
+func OrderLots(lots []models.PositionLot, method string) []models.PositionLot {
+	ordered := append([]models.PositionLot(nil), lots...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		switch method {
+		case models.CostBasisLIFO:
+			return a.AcquiredAt.After(b.AcquiredAt)
+		case models.CostBasisHIFO:
+			if a.Price != b.Price {
+				return a.Price > b.Price
+			}
+		}
+		return a.AcquiredAt.Before(b.AcquiredAt)
+	})
+	return ordered
+}
+
+// ConsumeLots reduces open lots, in the order given (see OrderLots), by the
+// quantity sold and returns the lots that changed. Lots emptied by the sale are closed at soldAt.
+// Quantity beyond the open lots (positions predating lot tracking) is ignored.
+func ConsumeLots(lots []models.PositionLot, quantity float64, soldAt time.Time) []models.PositionLot {
+	var changed []models.PositionLot
+	for _, lot := range lots {
+		if quantity <= 0 {
+			break
+		}
+		if lot.RemainingQuantity <= 0 {
+			continue
+		}
+
+		used := lot.RemainingQuantity
+		if used > quantity {
+			used = quantity
+		}
+		lot.RemainingQuantity = RoundQuantity(lot.RemainingQuantity - used)
+		quantity = RoundQuantity(quantity - used)
+		if lot.RemainingQuantity == 0 {
+			closedAt := soldAt
+			lot.ClosedAt = &closedAt
+		}
+		changed = append(changed, lot)
+	}
+	return changed
+}
+
+// BreakDownLots shows how a position's average entry price was formed from
+// its lots, oldest first. Lots closed before the position was opened belong
+// to an earlier position in the symbol and are left out.
+func BreakDownLots(position *models.Position, lots []models.PositionLot, costBasisMethod string) models.LotBreakdown {
+	breakdown := models.LotBreakdown{
+		Symbol:          position.Symbol,
+		Quantity:        position.Quantity,
+		AveragePrice:    position.EntryPrice,
+		CostBasisMethod: costBasisMethod,
+		Lots:            []models.LotHistory{},
+	}
+
+	var cost float64
+	for _, lot := range lots {
+		if lot.ClosedAt != nil && lot.ClosedAt.Before(position.CreatedAt) {
+			continue
+		}
+		breakdown.Lots = append(breakdown.Lots, models.LotHistory{PositionLot: lot})
+		breakdown.LotQuantity += lot.RemainingQuantity
+		cost += lot.RemainingQuantity * lot.Price
+	}
+	sort.SliceStable(breakdown.Lots, func(i, j int) bool {
+		return breakdown.Lots[i].AcquiredAt.Before(breakdown.Lots[j].AcquiredAt)
+	})
+
+	breakdown.LotQuantity = RoundQuantity(breakdown.LotQuantity)
+	if breakdown.LotQuantity > 0 {
+		breakdown.LotAveragePrice = cost / breakdown.LotQuantity
+	}
+	if cost > 0 {
+		for i := range breakdown.Lots {
+			lot := &breakdown.Lots[i]
+			lot.Weight = lot.RemainingQuantity * lot.Price / cost * 100
+		}
+	}
+	if untracked := RoundQuantity(abs(position.Quantity) - breakdown.LotQuantity); untracked > 0 {
+		breakdown.UntrackedQuantity = untracked
+	}
+	return breakdown
+}
+
+// HoldingPeriod returns the quantity-weighted average acquisition date of a
+// position's open lots, the days since its oldest lot was acquired, and the
+// weighted average age of its lots in days. Quantity not covered by lots
+// (positions opened before lot tracking) is treated as acquired when the
+// position was opened.
+func HoldingPeriod(position *models.Position, lots []models.PositionLot, asOf time.Time) (time.Time, int, float64) {
+	var oldest time.Time
+	var weightedSeconds float64
+	var total float64
+	add := func(quantity float64, acquiredAt time.Time) {
+		weightedSeconds += quantity * float64(acquiredAt.Unix())
+		total += quantity
+		if oldest.IsZero() || acquiredAt.Before(oldest) {
+			oldest = acquiredAt
+		}
+	}
+
+	for _, lot := range lots {
+		if lot.RemainingQuantity > 0 {
+			add(lot.RemainingQuantity, lot.AcquiredAt)
+		}
+	}
+	if uncovered := RoundQuantity(abs(position.Quantity) - total); uncovered > 0 {
+		add(uncovered, position.CreatedAt)
+	}
+	if total == 0 {
+		return position.CreatedAt, 0, 0
+	}
+
+	entryDate := time.Unix(int64(weightedSeconds/total), 0).In(asOf.Location())
+	daysHeld := int(asOf.Sub(oldest) / day)
+	avgDays := asOf.Sub(entryDate).Hours() / 24
+	if avgDays < 0 {
+		avgDays = 0
+	}
+	return entryDate, daysHeld, avgDays
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// SettleExpiredOption settles a portfolio's position in an option contract
+// that has expired, given the underlying's price at expiry. Contracts in the
+// money are exercised (long) or assigned (short) and settled in cash at their
+// intrinsic value, without delivering shares of the underlying; those out of
+// the money expire worthless. No fees are charged. The position is removed
+// from the portfolio and the settling trade returned.
+func (ps *PortfolioService) SettleExpiredOption(portfolio *models.Portfolio, symbol string, underlyingPrice float64, now time.Time) (*models.Trade, error) {
+	contract, ok := symbols.ParseOption(symbol)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an option contract", symbol)
+	}
+	if !contract.Expired(now) {
+		return nil, fmt.Errorf("%s expires on %s", symbol, contract.Expiry.Format("2006-01-02"))
+	}
+	if underlyingPrice <= 0 {
+		return nil, fmt.Errorf("%w: %.4f", ErrInvalidPrice, underlyingPrice)
+	}
+	index := ps.findPositionByIndex(portfolio.Positions, symbol)
+	if index == -1 {
+		return nil, fmt.Errorf("%w for symbol %s", ErrPositionNotFound, symbol)
+	}
+	position := portfolio.Positions[index]
+
+	// Shorts carry a negative quantity and are closed by a buy
+	short := position.Quantity < 0
+	trade := &models.Trade{
+		UserID:      position.UserID,
+		PortfolioID: portfolio.ID,
+		PositionID:  position.ID,
+		Symbol:      position.Symbol,
+		AssetType:   models.AssetTypeOption,
+		Quantity:    abs(position.Quantity),
+		Multiplier:  position.Multiplier,
+		Price:       contract.IntrinsicValue(underlyingPrice),
+		Side:        "sell",
+		Type:        "market",
+		Status:      "filled",
+		FeeItems:    []models.FeeItem{},
+		ExecutedAt:  &now,
+	}
+	if short {
+		trade.Side = "buy"
+	}
+
+	switch {
+	case trade.Price == 0:
+		trade.TriggerReason = models.TradeTriggerOptionExpiry
+	case short:
+		trade.TriggerReason = models.TradeTriggerOptionAssignment
+	default:
+		trade.TriggerReason = models.TradeTriggerOptionExercise
+	}
+
+	// A long position receives the intrinsic value, a short one pays it
+	portfolio.Cash += position.Value(trade.Price)
+	portfolio.Positions = append(portfolio.Positions[:index], portfolio.Positions[index+1:]...)
+	return trade, nil
+}
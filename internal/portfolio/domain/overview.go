@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"sort"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// ConsolidatePortfolios combines portfolios into one for allocation and risk
+// analysis: cash and realized PnL are summed and positions are netted by
+// symbol, with the entry price weighted by quantity. Symbols whose positions
+// net to zero carry no exposure and are left out.
+func (ps *PortfolioService) ConsolidatePortfolios(userID int, portfolios []models.Portfolio) *models.Portfolio {
+	consolidated := &models.Portfolio{UserID: userID, Positions: []models.Position{}}
+	quantities := make(map[string]float64)
+	costs := make(map[string]float64)
+	for _, portfolio := range portfolios {
+		consolidated.Cash += portfolio.Cash
+		consolidated.RealizedPnL += portfolio.RealizedPnL
+		for _, position := range portfolio.Positions {
+			quantities[position.Symbol] = RoundQuantity(quantities[position.Symbol] + position.Quantity)
+			costs[position.Symbol] += position.Quantity * position.EntryPrice
+		}
+	}
+
+	held := make([]string, 0, len(quantities))
+	for symbol, quantity := range quantities {
+		if quantity != 0 {
+			held = append(held, symbol)
+		}
+	}
+	sort.Strings(held)
+
+	for _, symbol := range held {
+		quantity := quantities[symbol]
+		side := "long"
+		if quantity < 0 {
+			side = "short"
+		}
+		consolidated.Positions = append(consolidated.Positions, models.Position{
+			UserID:     userID,
+			Symbol:     symbol,
+			AssetType:  AssetType(symbol),
+			Quantity:   quantity,
+			Multiplier: Multiplier(symbol),
+			Side:       side,
+			EntryPrice: costs[symbol] / quantity,
+		})
+	}
+
+	return consolidated
+}
+
+// CombineSummaries adds up portfolio summaries, recalculating the returns
+// against the combined values
+func (ps *PortfolioService) CombineSummaries(summaries []models.PortfolioSummary) models.PortfolioSummary {
+	var combined models.PortfolioSummary
+	for _, summary := range summaries {
+		combined.TotalValue += summary.TotalValue
+		combined.Cash += summary.Cash
+		combined.PositionsValue += summary.PositionsValue
+		combined.UnrealizedPnL += summary.UnrealizedPnL
+		combined.RealizedPnL += summary.RealizedPnL
+		combined.DayPnL += summary.DayPnL
+		combined.PositionCount += summary.PositionCount
+	}
+
+	// Same bases as CalculatePortfolioSummary
+	if combined.TotalValue > 0 {
+		combined.DayReturn = (combined.DayPnL / combined.TotalValue) * 100
+	}
+	if combined.PositionsValue > 0 {
+		combined.TotalReturn = (combined.UnrealizedPnL / combined.PositionsValue) * 100
+	}
+	return combined
+}
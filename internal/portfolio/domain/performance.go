@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+
+	"hedge-fund/pkg/shared/cashflow"
+	"hedge-fund/pkg/shared/models"
+)
+
+// TradingDaysPerYear annualizes daily snapshot returns
+const TradingDaysPerYear = 252
+
+// MinPerformanceObservations is the fewest daily returns a performance
+// calculation needs for a volatility
+const MinPerformanceObservations = 2
+
+// CalculatePerformance derives the annualized return, volatility, Sharpe and
+// Sortino ratios and maximum drawdown of a portfolio from its snapshots, in
+// date order. Returns are time-weighted: the cash transactions made between
+// the snapshots, oldest first, are netted out of each day's return. Excess
+// returns are taken over riskFreeRate, an annual rate.
+func (ps *PortfolioService) CalculatePerformance(snapshots []models.PortfolioSnapshot, cash []models.CashTransaction, riskFreeRate float64) (models.PortfolioPerformance, error) {
+	if len(snapshots)-1 < MinPerformanceObservations {
+		return models.PortfolioPerformance{}, fmt.Errorf("%w: %d snapshots, need %d", ErrInsufficientHistory, len(snapshots), MinPerformanceObservations+1)
+	}
+
+	returns, err := cashflow.Returns(snapshots, cash)
+	if err != nil {
+		return models.PortfolioPerformance{}, fmt.Errorf("%w: %v", ErrInsufficientHistory, err)
+	}
+	index := cashflow.Index(returns)
+	growth := index[len(index)-1]
+
+	first, last := snapshots[0], snapshots[len(snapshots)-1]
+	performance := models.PortfolioPerformance{
+		PortfolioID:  first.PortfolioID,
+		StartDate:    first.SnapshotDate,
+		EndDate:      last.SnapshotDate,
+		Observations: len(returns),
+		TotalReturn:  (growth - 1) * 100,
+		RiskFreeRate: riskFreeRate,
+	}
+	if growth > 0 {
+		years := float64(len(returns)) / TradingDaysPerYear
+		performance.AnnualizedReturn = (math.Pow(growth, 1/years) - 1) * 100
+	}
+
+	dailyRiskFree := riskFreeRate / TradingDaysPerYear
+	mean, downside := 0.0, 0.0
+	for _, r := range returns {
+		mean += r
+		if excess := r - dailyRiskFree; excess < 0 {
+			downside += excess * excess
+		}
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(returns)-1))
+	downsideDeviation := math.Sqrt(downside / float64(len(returns)))
+
+	performance.Volatility = stddev * math.Sqrt(TradingDaysPerYear) * 100
+	if stddev > 0 {
+		performance.SharpeRatio = (mean - dailyRiskFree) / stddev * math.Sqrt(TradingDaysPerYear)
+	}
+	if downsideDeviation > 0 {
+		performance.SortinoRatio = (mean - dailyRiskFree) / downsideDeviation * math.Sqrt(TradingDaysPerYear)
+	}
+
+	peak := 0.0
+	for _, value := range index {
+		peak = math.Max(peak, value)
+		if drawdown := (peak - value) / peak * 100; drawdown > performance.MaxDrawdown {
+			performance.MaxDrawdown = drawdown
+		}
+	}
+
+	return performance, nil
+}
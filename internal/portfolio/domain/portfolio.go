@@ -2,9 +2,11 @@ package domain
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
 )
 
 type PortfolioService struct{}
@@ -19,7 +21,7 @@ func (ps *PortfolioService) CalculatePortfolioValue(portfolio *models.Portfolio,
 
 	for _, position := range portfolio.Positions {
 		if currentPrice, exists := currentPrices[position.Symbol]; exists {
-			totalValue += float64(position.Quantity) * currentPrice
+			totalValue += position.Value(currentPrice)
 		}
 	}
 
@@ -32,7 +34,7 @@ func (ps *PortfolioService) CalculateUnrealizedPnL(positions []models.Position,
 
 	for _, position := range positions {
 		if currentPrice, exists := currentPrices[position.Symbol]; exists {
-			unrealizedPnL := (currentPrice - position.EntryPrice) * float64(position.Quantity)
+			unrealizedPnL := position.Value(currentPrice - position.EntryPrice)
 			totalPnL += unrealizedPnL
 		}
 	}
@@ -41,58 +43,93 @@ func (ps *PortfolioService) CalculateUnrealizedPnL(positions []models.Position,
 }
 
 // CalculatePositionSummary calculates detailed metrics for a specific position
-func (ps *PortfolioService) CalculatePositionSummary(position *models.Position, currentPrice float64) models.PositionSummary {
-	marketValue := float64(position.Quantity) * currentPrice
-	unrealizedPnL := (currentPrice - position.EntryPrice) * float64(position.Quantity)
+// and its holding period from the position's open lots
+func (ps *PortfolioService) CalculatePositionSummary(position *models.Position, lots []models.PositionLot, currentPrice float64) models.PositionSummary {
+	marketValue := position.Value(currentPrice)
+	unrealizedPnL := position.Value(currentPrice - position.EntryPrice)
 	unrealizedReturn := 0.0
-	if position.EntryPrice > 0 {
-		unrealizedReturn = (unrealizedPnL / (position.EntryPrice * float64(position.Quantity))) * 100
+	if position.EntryPrice > 0 && position.Quantity != 0 {
+		unrealizedReturn = (unrealizedPnL / abs(position.Value(position.EntryPrice))) * 100
+	}
+
+	entryDate, daysHeld, avgHoldingDays := HoldingPeriod(position, lots, time.Now())
+
+	// Shorts carry a negative quantity
+	longQuantity, shortQuantity := position.Quantity, 0.0
+	if position.Quantity < 0 {
+		longQuantity, shortQuantity = 0, -position.Quantity
 	}
 
 	return models.PositionSummary{
 		Symbol:           position.Symbol,
 		NetQuantity:      position.Quantity,
-		LongQuantity:     position.Quantity, // Assuming long positions for now
-		ShortQuantity:    0,
+		LongQuantity:     longQuantity,
+		ShortQuantity:    shortQuantity,
 		AveragePrice:     position.EntryPrice,
 		CurrentPrice:     currentPrice,
 		MarketValue:      marketValue,
 		UnrealizedPnL:    unrealizedPnL,
 		UnrealizedReturn: unrealizedReturn,
+		EntryDate:        entryDate,
+		DaysHeld:         daysHeld,
+		AvgHoldingDays:   avgHoldingDays,
+		Lots:             lots,
 	}
 }
 
 // ValidateTradeOrder validates a trade order before execution
 func (ps *PortfolioService) ValidateTradeOrder(trade *models.Trade, portfolio *models.Portfolio, currentPrice float64) error {
-	if trade.Quantity <= 0 {
-		return fmt.Errorf("quantity must be positive")
+	classifyTrade(trade)
+	if err := ps.ValidateQuantity(trade.AssetType, trade.Quantity); err != nil {
+		return err
+	}
+	trade.Quantity = RoundQuantity(trade.Quantity)
+
+	switch trade.TimeInForce {
+	case "":
+		trade.TimeInForce = models.TimeInForceDay
+	case models.TimeInForceDay, models.TimeInForceGTC, models.TimeInForceIOC, models.TimeInForceFOK:
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidTimeInForce, trade.TimeInForce)
+	}
+
+	if contract, ok := symbols.ParseOption(trade.Symbol); ok && contract.Expired(time.Now()) {
+		return fmt.Errorf("%w: %s expired on %s", ErrOptionExpired, trade.Symbol, contract.Expiry.Format("2006-01-02"))
 	}
 
 	if currentPrice <= 0 {
-		return fmt.Errorf("invalid current price: %.4f", currentPrice)
+		return fmt.Errorf("%w: %.4f", ErrInvalidPrice, currentPrice)
 	}
 
 	if trade.Side == "buy" {
 		// Check if sufficient cash for buy order
-		orderValue := float64(trade.Quantity) * currentPrice
-		fees := ps.calculateCommission(orderValue)
+		orderValue := trade.Value(currentPrice)
+		fees, _ := ps.calculateFees(portfolio, trade, orderValue)
 		totalCost := orderValue + fees
 
 		if portfolio.Cash < totalCost {
-			return fmt.Errorf("insufficient cash balance: need %.2f, have %.2f", totalCost, portfolio.Cash)
+			return fmt.Errorf("%w: need %.2f, have %.2f", ErrInsufficientCash, totalCost, portfolio.Cash)
+		}
+
+		// Positions are capped by the portfolio's risk tolerance
+		if limit := portfolio.MaxPosition; limit > 0 {
+			symbol := symbols.Normalize(trade.Symbol)
+			if percent := positionPercentAfterBuy(portfolio, symbol, trade.Quantity, currentPrice); percent > limit {
+				return fmt.Errorf("%w: %s would be %.2f%% of the portfolio, above the %.2f%% limit", ErrPositionLimit, symbol, percent, limit)
+			}
 		}
 	} else if trade.Side == "sell" {
 		// Check if sufficient shares for sell order
 		position := ps.findPosition(portfolio.Positions, trade.Symbol)
 		if position == nil || position.Quantity < trade.Quantity {
-			availableQuantity := int64(0)
+			availableQuantity := 0.0
 			if position != nil {
 				availableQuantity = position.Quantity
 			}
-			return fmt.Errorf("insufficient shares: need %d, have %d", trade.Quantity, availableQuantity)
+			return fmt.Errorf("%w: need %v, have %v", ErrInsufficientShares, trade.Quantity, availableQuantity)
 		}
 	} else {
-		return fmt.Errorf("invalid order side: %s", trade.Side)
+		return fmt.Errorf("%w: %s", ErrInvalidSide, trade.Side)
 	}
 
 	return nil
@@ -101,12 +138,13 @@ func (ps *PortfolioService) ValidateTradeOrder(trade *models.Trade, portfolio *m
 // ExecuteTradeOrder executes a validated trade order and updates portfolio state
 func (ps *PortfolioService) ExecuteTradeOrder(trade *models.Trade, portfolio *models.Portfolio, currentPrice float64) (*models.Position, error) {
 	trade.Price = currentPrice
-	trade.Fees = ps.calculateCommission(float64(trade.Quantity) * currentPrice)
+	classifyTrade(trade)
+	tradeValue := trade.Value(currentPrice)
+	trade.Fees, trade.FeeItems = ps.calculateFees(portfolio, trade, tradeValue)
 	trade.Status = "filled"
 	executedAt := time.Now()
 	trade.ExecutedAt = &executedAt
 
-	tradeValue := float64(trade.Quantity) * currentPrice
 	position := ps.findPositionByIndex(portfolio.Positions, trade.Symbol)
 
 	if trade.Side == "buy" {
@@ -119,7 +157,9 @@ func (ps *PortfolioService) ExecuteTradeOrder(trade *models.Trade, portfolio *mo
 			newPosition := models.Position{
 				UserID:        trade.UserID,
 				Symbol:        trade.Symbol,
+				AssetType:     trade.AssetType,
 				Quantity:      trade.Quantity,
+				Multiplier:    trade.Multiplier,
 				Side:          "long",
 				EntryPrice:    currentPrice,
 				CurrentPrice:  currentPrice,
@@ -132,18 +172,18 @@ func (ps *PortfolioService) ExecuteTradeOrder(trade *models.Trade, portfolio *mo
 		} else {
 			// Update existing position with weighted average cost
 			pos := &portfolio.Positions[position]
-			totalCost := (pos.EntryPrice * float64(pos.Quantity)) + tradeValue
-			totalQuantity := pos.Quantity + trade.Quantity
-			pos.EntryPrice = totalCost / float64(totalQuantity)
+			totalCost := pos.Value(pos.EntryPrice) + tradeValue
+			totalQuantity := RoundQuantity(pos.Quantity + trade.Quantity)
 			pos.Quantity = totalQuantity
+			pos.EntryPrice = totalCost / pos.Value(1)
 			pos.CurrentPrice = currentPrice
-			pos.UnrealizedPnL = (currentPrice - pos.EntryPrice) * float64(totalQuantity)
+			pos.UnrealizedPnL = pos.Value(currentPrice - pos.EntryPrice)
 			pos.UpdatedAt = time.Now()
 			return pos, nil
 		}
 	} else { // sell
 		if position == -1 {
-			return nil, fmt.Errorf("position not found for symbol %s", trade.Symbol)
+			return nil, fmt.Errorf("%w for symbol %s", ErrPositionNotFound, trade.Symbol)
 		}
 
 		// Update cash balance
@@ -151,7 +191,7 @@ func (ps *PortfolioService) ExecuteTradeOrder(trade *models.Trade, portfolio *mo
 
 		// Update position
 		pos := &portfolio.Positions[position]
-		pos.Quantity -= trade.Quantity
+		pos.Quantity = RoundQuantity(pos.Quantity - trade.Quantity)
 		pos.CurrentPrice = currentPrice
 
 		if pos.Quantity == 0 {
@@ -160,13 +200,32 @@ func (ps *PortfolioService) ExecuteTradeOrder(trade *models.Trade, portfolio *mo
 			return nil, nil
 		} else {
 			// Partial sale - entry price remains the same
-			pos.UnrealizedPnL = (currentPrice - pos.EntryPrice) * float64(pos.Quantity)
+			pos.UnrealizedPnL = pos.Value(currentPrice - pos.EntryPrice)
 			pos.UpdatedAt = time.Now()
 			return pos, nil
 		}
 	}
 }
 
+// ExecuteFill applies a fill reported by an execution venue. It behaves like
+// ExecuteTradeOrder, but fees reported by the venue replace the modelled
+// ones, as a single commission line, and the trade takes the venue's
+// execution time.
+func (ps *PortfolioService) ExecuteFill(trade *models.Trade, portfolio *models.Portfolio, price float64, fees *float64, executedAt time.Time) (*models.Position, error) {
+	position, err := ps.ExecuteTradeOrder(trade, portfolio, price)
+	if err != nil {
+		return nil, err
+	}
+
+	if fees != nil {
+		portfolio.Cash += trade.Fees - *fees
+		trade.Fees = *fees
+		trade.FeeItems = []models.FeeItem{{Name: models.FeeCommission, Amount: *fees}}
+	}
+	trade.ExecutedAt = &executedAt
+	return position, nil
+}
+
 // CalculatePortfolioAllocation calculates allocation percentages for each position
 func (ps *PortfolioService) CalculatePortfolioAllocation(portfolio *models.Portfolio, currentPrices map[string]float64) map[string]float64 {
 	totalValue := ps.CalculatePortfolioValue(portfolio, currentPrices)
@@ -180,7 +239,7 @@ func (ps *PortfolioService) CalculatePortfolioAllocation(portfolio *models.Portf
 	// Position allocations
 	for _, position := range portfolio.Positions {
 		if currentPrice, exists := currentPrices[position.Symbol]; exists {
-			positionValue := float64(position.Quantity) * currentPrice
+			positionValue := position.Value(currentPrice)
 			if totalValue > 0 {
 				allocations[position.Symbol] = (positionValue / totalValue) * 100
 			}
@@ -190,18 +249,21 @@ func (ps *PortfolioService) CalculatePortfolioAllocation(portfolio *models.Portf
 	return allocations
 }
 
-// CalculatePortfolioSummary generates a comprehensive portfolio summary
+// CalculatePortfolioSummary generates a comprehensive portfolio summary. Day
+// P&L is measured from each position's rolled previous close, falling back
+// to previousDayPrices for positions not yet anchored.
 func (ps *PortfolioService) CalculatePortfolioSummary(portfolio *models.Portfolio, currentPrices map[string]float64, previousDayPrices map[string]float64) models.PortfolioSummary {
 	totalValue := ps.CalculatePortfolioValue(portfolio, currentPrices)
 	positionsValue := totalValue - portfolio.Cash
 	unrealizedPnL := ps.CalculateUnrealizedPnL(portfolio.Positions, currentPrices)
 
-	// Calculate day PnL based on price changes
+	// Calculate day PnL from each position's previous close
 	dayPnL := 0.0
-	for _, position := range portfolio.Positions {
+	for i := range portfolio.Positions {
+		position := &portfolio.Positions[i]
 		if currentPrice, exists := currentPrices[position.Symbol]; exists {
-			if previousPrice, prevExists := previousDayPrices[position.Symbol]; prevExists {
-				dayChange := (currentPrice - previousPrice) * float64(position.Quantity)
+			if previousPrice, prevExists := dayAnchorPrice(position, previousDayPrices); prevExists {
+				dayChange := position.Value(currentPrice - previousPrice)
 				dayPnL += dayChange
 			}
 		}
@@ -240,11 +302,11 @@ func (ps *PortfolioService) UpdatePortfolioWithMarketData(portfolio *models.Port
 		position := &portfolio.Positions[i]
 		if currentPrice, exists := currentPrices[position.Symbol]; exists {
 			position.CurrentPrice = currentPrice
-			position.UnrealizedPnL = (currentPrice - position.EntryPrice) * float64(position.Quantity)
+			position.UnrealizedPnL = position.Value(currentPrice - position.EntryPrice)
 			position.UpdatedAt = time.Now()
 
 			totalUnrealizedPnL += position.UnrealizedPnL
-			totalValue += float64(position.Quantity) * currentPrice
+			totalValue += position.Value(currentPrice)
 		}
 	}
 
@@ -253,8 +315,10 @@ func (ps *PortfolioService) UpdatePortfolioWithMarketData(portfolio *models.Port
 	portfolio.UpdatedAt = time.Now()
 }
 
-// CalculateRiskMetrics calculates basic risk metrics for positions
-func (ps *PortfolioService) CalculateRiskMetrics(portfolio *models.Portfolio, currentPrices map[string]float64) map[string]interface{} {
+// CalculateRiskMetrics calculates basic risk metrics for positions.
+// Short positions are represented by a negative quantity; sectors maps
+// symbols to their sector for the exposure breakdown and may be nil.
+func (ps *PortfolioService) CalculateRiskMetrics(portfolio *models.Portfolio, currentPrices map[string]float64, sectors map[string]string) map[string]interface{} {
 	totalValue := ps.CalculatePortfolioValue(portfolio, currentPrices)
 	metrics := make(map[string]interface{})
 
@@ -262,24 +326,65 @@ func (ps *PortfolioService) CalculateRiskMetrics(portfolio *models.Portfolio, cu
 	maxPositionPercent := 0.0
 	positionCount := len(portfolio.Positions)
 
+	longExposure := 0.0
+	shortExposure := 0.0
+	sectorValues := make(map[string]float64)
+
 	for _, position := range portfolio.Positions {
 		if currentPrice, exists := currentPrices[position.Symbol]; exists {
-			positionValue := float64(position.Quantity) * currentPrice
-			positionPercent := (positionValue / totalValue) * 100
-			if positionPercent > maxPositionPercent {
-				maxPositionPercent = positionPercent
+			positionValue := position.Value(currentPrice)
+			if positionValue >= 0 {
+				longExposure += positionValue
+			} else {
+				shortExposure += -positionValue
+			}
+
+			sector := sectors[position.Symbol]
+			if sector == "" {
+				sector = "Unknown"
+			}
+			sectorValues[sector] += positionValue
+
+			if totalValue > 0 {
+				positionPercent := (abs(positionValue) / totalValue) * 100
+				if positionPercent > maxPositionPercent {
+					maxPositionPercent = positionPercent
+				}
 			}
 		}
 	}
 
 	// Cash percentage
-	cashPercent := (portfolio.Cash / totalValue) * 100
+	cashPercent := 0.0
+	if totalValue > 0 {
+		cashPercent = (portfolio.Cash / totalValue) * 100
+	}
+
+	// Long/short ratio is undefined without shorts, report 0 in that case
+	longShortRatio := 0.0
+	if shortExposure > 0 {
+		longShortRatio = longExposure / shortExposure
+	}
+
+	// Net sector exposure as a percentage of total portfolio value
+	sectorExposure := make(map[string]float64)
+	for sector, value := range sectorValues {
+		if totalValue > 0 {
+			sectorExposure[sector] = (value / totalValue) * 100
+		}
+	}
 
 	metrics["total_value"] = totalValue
 	metrics["position_count"] = positionCount
 	metrics["max_position_percent"] = maxPositionPercent
 	metrics["cash_percent"] = cashPercent
 	metrics["diversification_score"] = ps.calculateDiversificationScore(portfolio.Positions, totalValue, currentPrices)
+	metrics["long_exposure"] = longExposure
+	metrics["short_exposure"] = shortExposure
+	metrics["gross_exposure"] = longExposure + shortExposure
+	metrics["net_exposure"] = longExposure - shortExposure
+	metrics["long_short_ratio"] = longShortRatio
+	metrics["sector_exposure"] = sectorExposure
 
 	return metrics
 }
@@ -311,7 +416,7 @@ func (ps *PortfolioService) RebalanceRecommendations(portfolio *models.Portfolio
 					"target_value":     targetValue,
 					"current_value":    currentValue,
 					"action":           ps.getRebalanceAction(diff),
-					"estimated_shares": int64((targetValue - currentValue) / currentPrice),
+					"estimated_shares": ps.tradableQuantity(symbol, (targetValue-currentValue)/(currentPrice*Multiplier(symbol))),
 				}
 				recommendations = append(recommendations, recommendation)
 			}
@@ -323,13 +428,19 @@ func (ps *PortfolioService) RebalanceRecommendations(portfolio *models.Portfolio
 
 // Helper functions
 
-func (ps *PortfolioService) calculateCommission(tradeValue float64) float64 {
-	// Simple commission structure: $1 minimum, 0.1% of trade value
-	commission := tradeValue * 0.001
-	if commission < 1.0 {
-		commission = 1.0
+// calculateFees charges the portfolio's fee schedule for the trade's asset
+// type, or the default one when its settings were not loaded
+func (ps *PortfolioService) calculateFees(portfolio *models.Portfolio, trade *models.Trade, tradeValue float64) (float64, []models.FeeItem) {
+	fees := DefaultFees
+	if trade.AssetType == models.AssetTypeCrypto {
+		fees = DefaultCryptoFees
+		if portfolio.CryptoFees != nil {
+			fees = *portfolio.CryptoFees
+		}
+	} else if portfolio.Fees != nil {
+		fees = *portfolio.Fees
 	}
-	return commission
+	return ps.CalculateFees(fees, trade.Side, trade.Quantity, tradeValue)
 }
 
 func (ps *PortfolioService) findPosition(positions []models.Position, symbol string) *models.Position {
@@ -351,7 +462,7 @@ func (ps *PortfolioService) findPositionByIndex(positions []models.Position, sym
 }
 
 func (ps *PortfolioService) calculateDiversificationScore(positions []models.Position, totalValue float64, currentPrices map[string]float64) float64 {
-	if len(positions) <= 1 {
+	if len(positions) <= 1 || totalValue <= 0 {
 		return 0.0
 	}
 
@@ -359,7 +470,7 @@ func (ps *PortfolioService) calculateDiversificationScore(positions []models.Pos
 	sum := 0.0
 	for _, position := range positions {
 		if currentPrice, exists := currentPrices[position.Symbol]; exists {
-			positionValue := float64(position.Quantity) * currentPrice
+			positionValue := abs(position.Value(currentPrice))
 			weight := positionValue / totalValue
 			sum += weight * weight
 		}
@@ -378,6 +489,42 @@ func (ps *PortfolioService) getRebalanceAction(diff float64) string {
 	return "hold"
 }
 
+// tradableQuantity truncates a quantity of symbol to one that can be traded:
+// whole shares of an equity or contracts of an option, or
+// positionPercentAfterBuy returns the percent of the portfolio's value the
+// position in symbol would be after buying quantity at price, or 0 for a
+// portfolio without value. Positions are valued at their last known price
+// and the bought symbol at price; a buy moves cash into the position,
+// leaving the total unchanged.
+func positionPercentAfterBuy(portfolio *models.Portfolio, symbol string, quantity, price float64) float64 {
+	total := portfolio.Cash
+	held := 0.0
+	for _, position := range portfolio.Positions {
+		if position.Symbol == symbol {
+			held = position.Quantity
+			total += position.Value(price)
+			continue
+		}
+		positionPrice := position.CurrentPrice
+		if positionPrice <= 0 {
+			positionPrice = position.EntryPrice
+		}
+		total += position.Value(positionPrice)
+	}
+	if total <= 0 {
+		return 0
+	}
+	return (held + quantity) * price * Multiplier(symbol) / total * 100
+}
+
+// QuantityPrecision decimal places of crypto
+func (ps *PortfolioService) tradableQuantity(symbol string, quantity float64) float64 {
+	if AssetType(symbol) == models.AssetTypeCrypto {
+		return math.Trunc(quantity*quantityScale) / quantityScale
+	}
+	return math.Trunc(quantity)
+}
+
 func abs(x float64) float64 {
 	if x < 0 {
 		return -x
@@ -0,0 +1,1544 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+func TestCalculateRiskMetricsLongShortExposure(t *testing.T) {
+	ps := NewPortfolioService()
+
+	portfolio := &models.Portfolio{
+		Cash: 10000.0,
+		Positions: []models.Position{
+			{Symbol: "AAPL", Quantity: 100, Side: "long", EntryPrice: 150.0},
+			{Symbol: "MSFT", Quantity: 20, Side: "long", EntryPrice: 300.0},
+			{Symbol: "TSLA", Quantity: -40, Side: "short", EntryPrice: 250.0},
+		},
+	}
+	prices := map[string]float64{"AAPL": 200.0, "MSFT": 400.0, "TSLA": 250.0}
+	sectors := map[string]string{"AAPL": "Technology", "MSFT": "Technology"}
+
+	metrics := ps.CalculateRiskMetrics(portfolio, prices, sectors)
+
+	// 10000 cash + 20000 AAPL + 8000 MSFT - 10000 TSLA
+	assert.InDelta(t, 28000.0, metrics["total_value"].(float64), 0.001)
+	assert.InDelta(t, 28000.0, metrics["long_exposure"].(float64), 0.001)
+	assert.InDelta(t, 10000.0, metrics["short_exposure"].(float64), 0.001)
+	assert.InDelta(t, 38000.0, metrics["gross_exposure"].(float64), 0.001)
+	assert.InDelta(t, 18000.0, metrics["net_exposure"].(float64), 0.001)
+	assert.InDelta(t, 2.8, metrics["long_short_ratio"].(float64), 0.001)
+
+	sectorExposure := metrics["sector_exposure"].(map[string]float64)
+	assert.InDelta(t, 100.0, sectorExposure["Technology"], 0.001)
+	assert.InDelta(t, -35.714, sectorExposure["Unknown"], 0.001)
+}
+
+func TestCalculateRiskMetricsEmptyPortfolio(t *testing.T) {
+	ps := NewPortfolioService()
+
+	metrics := ps.CalculateRiskMetrics(&models.Portfolio{}, map[string]float64{}, nil)
+
+	assert.Equal(t, 0.0, metrics["cash_percent"])
+	assert.Equal(t, 0.0, metrics["long_short_ratio"])
+	assert.Empty(t, metrics["sector_exposure"])
+}
+
+func TestCalculatePositionSummaryShort(t *testing.T) {
+	ps := NewPortfolioService()
+
+	position := &models.Position{Symbol: "TSLA", Quantity: -10, Side: "short", EntryPrice: 200.0}
+	summary := ps.CalculatePositionSummary(position, nil, 180.0)
+
+	assert.Equal(t, 0.0, summary.LongQuantity)
+	assert.Equal(t, 10.0, summary.ShortQuantity)
+	assert.InDelta(t, 200.0, summary.UnrealizedPnL, 0.001)
+	assert.InDelta(t, 10.0, summary.UnrealizedReturn, 0.001)
+}
+
+func TestCalculatePortfolioSummaryDayPnLFromAnchors(t *testing.T) {
+	ps := NewPortfolioService()
+	previousClose := 100.0
+	portfolio := &models.Portfolio{
+		Cash: 1000,
+		Positions: []models.Position{
+			{Symbol: "AAPL", Quantity: 10, Multiplier: 1, EntryPrice: 90, PreviousClose: &previousClose},
+			{Symbol: "MSFT", Quantity: 5, Multiplier: 1, EntryPrice: 200},
+		},
+	}
+	current := map[string]float64{"AAPL": 104, "MSFT": 210}
+
+	// The caller's previous price is only used for positions without an anchor
+	summary := ps.CalculatePortfolioSummary(portfolio, current, map[string]float64{"AAPL": 50, "MSFT": 208})
+	assert.InDelta(t, 10*4+5*2, summary.DayPnL, 1e-9)
+
+	summary = ps.CalculatePortfolioSummary(portfolio, current, map[string]float64{})
+	assert.InDelta(t, 40.0, summary.DayPnL, 1e-9)
+}
+
+func TestDayAnchors(t *testing.T) {
+	// MSFT has no bar for the session yet; TSLA has no close
+	opens := map[string]float64{"AAPL": 101, "TSLA": 180}
+
+	anchors := DayAnchors([]string{"AAPL", "MSFT", "TSLA"}, map[string]float64{"AAPL": 100, "MSFT": 400}, opens)
+
+	assert.Equal(t, []models.DayAnchor{
+		{Symbol: "AAPL", PreviousClose: 100, DayOpen: 101},
+		{Symbol: "MSFT", PreviousClose: 400, DayOpen: 400},
+	}, anchors)
+}
+
+func TestReplayTradeEvents(t *testing.T) {
+	ps := NewPortfolioService()
+
+	events := []models.TradeEvent{
+		{ID: 1, Symbol: "AAPL", EventType: models.TradeEventValidated, Side: "buy", Quantity: 10, Price: 100.0},
+		{ID: 2, Symbol: "AAPL", EventType: models.TradeEventFilled, Side: "buy", Quantity: 10, Price: 100.0},
+		{ID: 3, Symbol: "AAPL", EventType: models.TradeEventFilled, Side: "buy", Quantity: 10, Price: 120.0},
+		{ID: 4, Symbol: "MSFT", EventType: models.TradeEventRejected, Side: "sell", Quantity: 5, Price: 300.0},
+		{ID: 5, Symbol: "AAPL", EventType: models.TradeEventFilled, Side: "sell", Quantity: 5, Price: 130.0},
+		{ID: 6, Symbol: "TSLA", EventType: models.TradeEventFilled, Side: "buy", Quantity: 3, Price: 200.0},
+		{ID: 7, Symbol: "TSLA", EventType: models.TradeEventFilled, Side: "sell", Quantity: 3, Price: 210.0},
+	}
+
+	positions, err := ps.ReplayTradeEvents(events)
+	assert.NoError(t, err)
+	assert.Len(t, positions, 1)
+
+	aapl := positions[0]
+	assert.Equal(t, "AAPL", aapl.Symbol)
+	assert.Equal(t, 15.0, aapl.Quantity)
+	assert.InDelta(t, 110.0, aapl.EntryPrice, 0.001)
+	assert.InDelta(t, 100.0, aapl.RealizedPnL, 0.001)
+
+	_, err = ps.ReplayTradeEvents([]models.TradeEvent{
+		{ID: 1, Symbol: "AAPL", EventType: models.TradeEventFilled, Side: "sell", Quantity: 1, Price: 100.0},
+	})
+	assert.Error(t, err)
+}
+
+func TestReconcile(t *testing.T) {
+	ps := NewPortfolioService()
+
+	input := ReconcileInput{
+		Positions: []models.Position{
+			{Symbol: "AAPL", Quantity: 10},
+			{Symbol: "MSFT", Quantity: 5},
+		},
+		Trades: []models.Trade{
+			{ID: 1, Symbol: "AAPL", Side: "buy", Quantity: 10, Price: 150.004, Fees: 1.50},
+			{ID: 2, Symbol: "MSFT", Side: "buy", Quantity: 5, Price: 300.00, Fees: 1.50},
+			{ID: 3, Symbol: "TSLA", Side: "sell", Quantity: 2, Price: 200.00},
+		},
+		BrokerPositions: []models.BrokerPosition{
+			{Symbol: "AAPL", Quantity: 10},
+			{Symbol: "MSFT", Quantity: 5},
+			{Symbol: "NVDA", Quantity: 3},
+		},
+		BrokerTransactions: []models.BrokerTransaction{
+			{ID: "b-1", ClientOrderID: "1", Symbol: "AAPL", Side: "buy", Quantity: 10, Price: 150.00, Fees: 1.00},
+			{ID: "b-2", Symbol: "MSFT", Side: "buy", Quantity: 5, Price: 302.00, Fees: 1.50},
+			{ID: "b-3", Symbol: "NVDA", Side: "buy", Quantity: 3, Price: 450.00},
+		},
+	}
+
+	breaks := ps.Reconcile(input, DefaultReconcileTolerance())
+
+	resolutions := make(map[string]string)
+	for _, b := range breaks {
+		resolutions[b.BreakType+":"+b.Symbol] = b.Resolution
+	}
+	assert.Equal(t, map[string]string{
+		"price_rounding:AAPL":         models.ResolutionAutoFixed,
+		"fee_mismatch:AAPL":           models.ResolutionAutoFixed,
+		"price_mismatch:MSFT":         models.ResolutionOpen,
+		"missing_broker_trade:TSLA":   models.ResolutionOpen,
+		"missing_internal_trade:NVDA": models.ResolutionOpen,
+		"position_mismatch:NVDA":      models.ResolutionOpen,
+	}, resolutions)
+}
+
+func TestExecuteFillUsesVenueCommission(t *testing.T) {
+	ps := NewPortfolioService()
+	portfolio := &models.Portfolio{Cash: 10000.0}
+	trade := &models.Trade{Symbol: "AAPL", Side: "buy", Quantity: 10}
+	filledAt := time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)
+	fees := 0.0
+
+	position, err := ps.ExecuteFill(trade, portfolio, 101.25, &fees, filledAt)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, position.Quantity)
+	assert.Equal(t, 0.0, trade.Fees)
+	assert.Equal(t, filledAt, *trade.ExecutedAt)
+	assert.InDelta(t, 10000.0-1012.5, portfolio.Cash, 1e-9)
+}
+
+func TestValidateTradeOrderErrors(t *testing.T) {
+	ps := NewPortfolioService()
+	portfolio := &models.Portfolio{
+		Cash:      1000.0,
+		Positions: []models.Position{{Symbol: "AAPL", Quantity: 5}},
+	}
+
+	tests := []struct {
+		name    string
+		trade   models.Trade
+		price   float64
+		want    error
+		invalid bool
+	}{
+		{"zero quantity", models.Trade{Symbol: "AAPL", Side: "buy", Quantity: 0}, 100.0, ErrInvalidQuantity, true},
+		{"zero price", models.Trade{Symbol: "AAPL", Side: "buy", Quantity: 1}, 0, ErrInvalidPrice, true},
+		{"unknown side", models.Trade{Symbol: "AAPL", Side: "hold", Quantity: 1}, 100.0, ErrInvalidSide, true},
+		{"cash", models.Trade{Symbol: "AAPL", Side: "buy", Quantity: 20}, 100.0, ErrInsufficientCash, false},
+		{"shares", models.Trade{Symbol: "AAPL", Side: "sell", Quantity: 6}, 100.0, ErrInsufficientShares, false},
+		{"no position", models.Trade{Symbol: "MSFT", Side: "sell", Quantity: 1}, 100.0, ErrInsufficientShares, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ps.ValidateTradeOrder(&tt.trade, portfolio, tt.price)
+			assert.ErrorIs(t, err, tt.want)
+			assert.Equal(t, tt.invalid, IsInvalidOrder(err))
+		})
+	}
+}
+
+func TestLotsHoldingPeriod(t *testing.T) {
+	opened := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lots := []models.PositionLot{
+		{ID: 1, Quantity: 10, RemainingQuantity: 10, AcquiredAt: opened},
+		{ID: 2, Quantity: 10, RemainingQuantity: 10, AcquiredAt: opened.AddDate(0, 0, 10)},
+	}
+
+	changed := ConsumeLots(lots, 15, opened.AddDate(0, 0, 20))
+	assert.Len(t, changed, 2)
+	assert.Equal(t, 0.0, changed[0].RemainingQuantity)
+	assert.NotNil(t, changed[0].ClosedAt)
+	assert.Equal(t, 5.0, changed[1].RemainingQuantity)
+	assert.Nil(t, changed[1].ClosedAt)
+
+	// 5 shares from the second lot plus 5 legacy shares opened with the position
+	position := &models.Position{Symbol: "AAPL", Quantity: 10, CreatedAt: opened}
+	entryDate, daysHeld, avgDays := HoldingPeriod(position, changed, opened.AddDate(0, 0, 30))
+
+	assert.Equal(t, opened.AddDate(0, 0, 5), entryDate)
+	assert.Equal(t, 30, daysHeld)
+	assert.InDelta(t, 25.0, avgDays, 1e-9)
+}
+
+func TestOrderLotsByCostBasisMethod(t *testing.T) {
+	opened := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lots := []models.PositionLot{
+		{ID: 1, RemainingQuantity: 10, Price: 100, AcquiredAt: opened},
+		{ID: 2, RemainingQuantity: 10, Price: 120, AcquiredAt: opened.AddDate(0, 0, 10)},
+		{ID: 3, RemainingQuantity: 10, Price: 90, AcquiredAt: opened.AddDate(0, 0, 20)},
+	}
+	ids := func(lots []models.PositionLot) []int {
+		var ids []int
+		for _, lot := range lots {
+			ids = append(ids, lot.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []int{1, 2, 3}, ids(OrderLots(lots, models.CostBasisFIFO)))
+	assert.Equal(t, []int{3, 2, 1}, ids(OrderLots(lots, models.CostBasisLIFO)))
+	assert.Equal(t, []int{2, 1, 3}, ids(OrderLots(lots, models.CostBasisHIFO)))
+	assert.Equal(t, []int{1, 2, 3}, ids(lots))
+
+	changed := ConsumeLots(OrderLots(lots, models.CostBasisHIFO), 15, opened.AddDate(0, 0, 30))
+	assert.Equal(t, []int{2, 1}, ids(changed))
+	assert.Equal(t, 5.0, changed[1].RemainingQuantity)
+}
+
+func TestBreakDownLots(t *testing.T) {
+	opened := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	earlierSale := opened.AddDate(0, 0, -5)
+	laterSale := opened.AddDate(0, 0, 15)
+	position := &models.Position{Symbol: "AAPL", Quantity: 25, EntryPrice: 104, CreatedAt: opened}
+	lots := []models.PositionLot{
+		{ID: 1, Quantity: 10, RemainingQuantity: 0, Price: 80, AcquiredAt: opened.AddDate(0, -1, 0), ClosedAt: &earlierSale},
+		{ID: 3, Quantity: 10, RemainingQuantity: 10, Price: 120, AcquiredAt: opened.AddDate(0, 0, 10)},
+		{ID: 2, Quantity: 10, RemainingQuantity: 0, Price: 100, AcquiredAt: opened, ClosedAt: &laterSale},
+		{ID: 4, Quantity: 10, RemainingQuantity: 10, Price: 80, AcquiredAt: opened.AddDate(0, 0, 20)},
+	}
+
+	breakdown := BreakDownLots(position, lots, models.CostBasisFIFO)
+
+	require.Len(t, breakdown.Lots, 3)
+	assert.Equal(t, 2, breakdown.Lots[0].ID)
+	assert.Equal(t, 3, breakdown.Lots[1].ID)
+	assert.Equal(t, 4, breakdown.Lots[2].ID)
+	assert.Equal(t, 20.0, breakdown.LotQuantity)
+	assert.Equal(t, 100.0, breakdown.LotAveragePrice)
+	assert.Equal(t, 5.0, breakdown.UntrackedQuantity)
+	assert.Zero(t, breakdown.Lots[0].Weight)
+	assert.InDelta(t, 60.0, breakdown.Lots[1].Weight, 1e-9)
+	assert.InDelta(t, 40.0, breakdown.Lots[2].Weight, 1e-9)
+}
+
+func TestValidateTradeOrderPositionLimit(t *testing.T) {
+	ps := NewPortfolioService()
+	portfolio := &models.Portfolio{
+		Cash:        9000,
+		MaxPosition: RiskToleranceLimits[models.RiskConservative],
+		Positions:   []models.Position{{Symbol: "AAPL", Quantity: 5, CurrentPrice: 200, Multiplier: 1}},
+	}
+
+	// 10,000 in total; 5 more shares would make AAPL 20% of it
+	err := ps.ValidateTradeOrder(&models.Trade{Symbol: "AAPL", Side: "buy", Quantity: 5}, portfolio, 200)
+	assert.ErrorIs(t, err, ErrPositionLimit)
+	assert.ErrorIs(t, err, ErrRejected)
+	assert.NoError(t, ps.ValidateTradeOrder(&models.Trade{Symbol: "MSFT", Side: "buy", Quantity: 2}, portfolio, 400))
+
+	portfolio.MaxPosition = RiskToleranceLimits[models.RiskAggressive]
+	assert.NoError(t, ps.ValidateTradeOrder(&models.Trade{Symbol: "AAPL", Side: "buy", Quantity: 5}, portfolio, 200))
+}
+
+func TestValidateTradeOrderTimeInForce(t *testing.T) {
+	ps := NewPortfolioService()
+	portfolio := &models.Portfolio{Cash: 10000}
+
+	trade := &models.Trade{Symbol: "AAPL", Side: "buy", Quantity: 1}
+	require.NoError(t, ps.ValidateTradeOrder(trade, portfolio, 100))
+	assert.Equal(t, models.TimeInForceDay, trade.TimeInForce)
+
+	trade = &models.Trade{Symbol: "AAPL", Side: "buy", Quantity: 1, TimeInForce: models.TimeInForceFOK}
+	require.NoError(t, ps.ValidateTradeOrder(trade, portfolio, 100))
+	assert.Equal(t, models.TimeInForceFOK, trade.TimeInForce)
+
+	err := ps.ValidateTradeOrder(&models.Trade{Symbol: "AAPL", Side: "buy", Quantity: 1, TimeInForce: "opg"}, portfolio, 100)
+	assert.ErrorIs(t, err, ErrInvalidTimeInForce)
+	assert.True(t, IsInvalidOrder(err))
+}
+
+func TestPlanRebalanceSellsFirst(t *testing.T) {
+	ps := NewPortfolioService()
+
+	portfolio := &models.Portfolio{
+		Cash:      1000.0,
+		Positions: []models.Position{{Symbol: "AAPL", Quantity: 10, Side: "long", EntryPrice: 90.0}},
+	}
+	prices := map[string]float64{"AAPL": 100.0, "MSFT": 200.0}
+
+	// 2000 total value: AAPL goes from 50% to 20%, MSFT from 0% to 50%
+	orders := ps.PlanRebalance(portfolio, map[string]float64{"MSFT": 50, "AAPL": 20}, prices)
+
+	assert.Equal(t, []RebalanceOrder{
+		{Symbol: "AAPL", Side: "sell", Quantity: 6, Price: 100.0},
+		{Symbol: "MSFT", Side: "buy", Quantity: 5, Price: 200.0},
+	}, orders)
+}
+
+func TestCalculateDriftBySector(t *testing.T) {
+	ps := NewPortfolioService()
+
+	portfolio := &models.Portfolio{
+		Cash: 2000.0,
+		Positions: []models.Position{
+			{Symbol: "AAPL", Quantity: 40, Side: "long"},
+			{Symbol: "MSFT", Quantity: 20, Side: "long"},
+			{Symbol: "XOM", Quantity: 20, Side: "long"},
+		},
+	}
+	prices := map[string]float64{"AAPL": 100.0, "MSFT": 200.0, "XOM": 100.0}
+	sectors := map[string]string{"AAPL": "Technology", "MSFT": "Technology"}
+	model := &models.AllocationModel{
+		Basis:   models.AllocationBasisSector,
+		Targets: map[string]float64{"Technology": 60, "Energy": 10, "CASH": 30},
+	}
+
+	// 12000 total: Technology 8000 (66.7%), XOM unmapped 2000 (16.7%), cash 2000 (16.7%)
+	drifts := ps.CalculateDrift(portfolio, model, prices, sectors)
+
+	assert.Len(t, drifts, 4)
+	assert.Equal(t, "Unknown", drifts[0].Key)
+	assert.InDelta(t, 16.67, drifts[0].Drift, 0.01)
+	assert.Equal(t, "CASH", drifts[1].Key)
+	assert.InDelta(t, -13.33, drifts[1].Drift, 0.01)
+	assert.Equal(t, "Energy", drifts[2].Key)
+	assert.InDelta(t, -10.0, drifts[2].Drift, 0.01)
+	assert.InDelta(t, 16.67, MaxDrift(drifts), 0.01)
+
+	assert.NoError(t, ps.ValidateAllocationModel(&models.AllocationModel{
+		Basis: models.AllocationBasisSymbol, Targets: map[string]float64{"SPY": 60, "AGG": 40}, DriftThreshold: 5,
+	}))
+	assert.ErrorIs(t, ps.ValidateAllocationModel(&models.AllocationModel{
+		Basis: models.AllocationBasisSymbol, Targets: map[string]float64{"SPY": 80, "AGG": 40}, DriftThreshold: 5,
+	}), ErrInvalidAllocation)
+}
+
+func TestCheckSlippageRejectsAdverseMoves(t *testing.T) {
+	ps := NewPortfolioService()
+
+	// Buys are hurt by a rising price, sells by a falling one
+	assert.NoError(t, ps.CheckSlippage("buy", 100.0, 100.9, 1.0))
+	assert.ErrorIs(t, ps.CheckSlippage("buy", 100.0, 101.5, 1.0), ErrSlippageExceeded)
+	assert.NoError(t, ps.CheckSlippage("buy", 100.0, 90.0, 1.0))
+
+	assert.NoError(t, ps.CheckSlippage("sell", 100.0, 110.0, 1.0))
+	assert.ErrorIs(t, ps.CheckSlippage("sell", 100.0, 98.0, 1.0), ErrSlippageExceeded)
+	assert.InDelta(t, 2.0, Slippage("sell", 100.0, 98.0), 1e-9)
+}
+
+func TestCompareToBenchmarkFlagsLagging(t *testing.T) {
+	ps := NewPortfolioService()
+	rule := &models.BenchmarkRule{ID: 1, PortfolioID: 7, Benchmark: "SPY", WindowDays: 7, Threshold: 2.0}
+	start := models.PortfolioSnapshot{TotalValue: 100000.0}
+
+	// Portfolio -1% while SPY +2%: 3 points behind
+	comparison, err := ps.CompareToBenchmark(rule, []models.PortfolioSnapshot{start, {TotalValue: 99000.0}}, nil, 500.0, 510.0)
+	assert.NoError(t, err)
+	assert.InDelta(t, -1.0, comparison.PortfolioReturn, 1e-9)
+	assert.InDelta(t, 2.0, comparison.BenchmarkReturn, 1e-9)
+	assert.InDelta(t, -3.0, comparison.RelativeReturn, 1e-9)
+	assert.True(t, comparison.Lagging)
+
+	// 1.5 points behind is within the threshold
+	comparison, err = ps.CompareToBenchmark(rule, []models.PortfolioSnapshot{start, {TotalValue: 100500.0}}, nil, 500.0, 510.0)
+	assert.NoError(t, err)
+	assert.False(t, comparison.Lagging)
+
+	_, err = ps.CompareToBenchmark(rule, []models.PortfolioSnapshot{{}, start}, nil, 500.0, 510.0)
+	assert.ErrorIs(t, err, ErrInsufficientHistory)
+
+	// A withdrawal of 10,000 is not a 10% loss: the portfolio made 1%
+	taken := time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC)
+	snapshots := []models.PortfolioSnapshot{
+		{TotalValue: 100000.0, CreatedAt: taken},
+		{TotalValue: 91000.0, CreatedAt: taken.AddDate(0, 0, 1)},
+	}
+	withdrawal := []models.CashTransaction{{Type: models.CashWithdrawal, Amount: 10000, CreatedAt: taken.AddDate(0, 0, 1).Add(-time.Minute)}}
+	comparison, err = ps.CompareToBenchmark(rule, snapshots, withdrawal, 500.0, 505.0)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.0, comparison.PortfolioReturn, 0.01)
+	assert.False(t, comparison.Lagging)
+}
+
+func TestResolveSettingsInheritsFromUser(t *testing.T) {
+	ps := NewPortfolioService()
+	rate, strategy, benchmark := 0.002, models.StrategyMomentum, "QQQ"
+
+	settings := ps.ResolveSettings(7,
+		models.SettingsOverrides{Benchmark: &benchmark},
+		models.SettingsOverrides{CommissionRate: &rate, Strategy: &strategy})
+
+	assert.Equal(t, 0.002, settings.CommissionRate)
+	assert.Equal(t, DefaultFees.Minimum, settings.MinCommission)
+	assert.Equal(t, "QQQ", settings.Benchmark)
+	assert.Equal(t, models.StrategyMomentum, settings.Strategy)
+	assert.Equal(t, models.SettingsLevelUser, settings.Sources["commission_rate"])
+	assert.Equal(t, models.SettingsLevelDefault, settings.Sources["min_commission"])
+	assert.Equal(t, models.SettingsLevelPortfolio, settings.Sources["benchmark"])
+}
+
+func TestValidateSettingsRejectsCombinations(t *testing.T) {
+	ps := NewPortfolioService()
+	settings := ps.ResolveSettings(1, models.SettingsOverrides{}, models.SettingsOverrides{})
+	assert.NoError(t, ps.ValidateSettings(settings))
+
+	autoTrade := settings
+	autoTrade.AutoTradeEnabled = true
+	assert.ErrorIs(t, ps.ValidateSettings(autoTrade), ErrInvalidSettings)
+	autoTrade.Strategy = models.StrategyAllocation
+	assert.NoError(t, ps.ValidateSettings(autoTrade))
+
+	currency := settings
+	currency.BaseCurrency = "XYZ"
+	assert.ErrorIs(t, ps.ValidateSettings(currency), ErrInvalidSettings)
+
+	fees := settings
+	fees.CommissionRate = 0.5
+	assert.ErrorIs(t, ps.ValidateSettings(fees), ErrInvalidSettings)
+}
+
+func TestApplySettingsPatch(t *testing.T) {
+	ps := NewPortfolioService()
+	rate, benchmark := 0.002, "QQQ"
+	overrides := models.SettingsOverrides{CommissionRate: &rate, Benchmark: &benchmark}
+
+	err := ps.ApplySettingsPatch(&overrides, map[string]json.RawMessage{
+		"commission_rate": json.RawMessage(`null`),
+		"base_currency":   json.RawMessage(`"eur"`),
+		"drip_enabled":    json.RawMessage(`true`),
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, overrides.CommissionRate)
+	assert.Equal(t, "QQQ", *overrides.Benchmark)
+	assert.Equal(t, "EUR", *overrides.BaseCurrency)
+	assert.True(t, *overrides.DRIPEnabled)
+
+	err = ps.ApplySettingsPatch(&overrides, map[string]json.RawMessage{"leverage": json.RawMessage(`2`)})
+	assert.ErrorIs(t, err, ErrInvalidSettings)
+	err = ps.ApplySettingsPatch(&overrides, map[string]json.RawMessage{"drip_enabled": json.RawMessage(`"yes"`)})
+	assert.ErrorIs(t, err, ErrInvalidSettings)
+}
+
+func TestResolveSettingsPreferences(t *testing.T) {
+	ps := NewPortfolioService()
+	lifo, risk, autoRebalance := models.CostBasisLIFO, models.RiskModerate, true
+
+	settings := ps.ResolveSettings(7,
+		models.SettingsOverrides{RiskTolerance: &risk, Notifications: map[string]bool{"stop_loss_triggered": true}},
+		models.SettingsOverrides{CostBasisMethod: &lifo, AutoRebalance: &autoRebalance,
+			Notifications: map[string]bool{"stop_loss_triggered": false, "position_alert": false}})
+
+	assert.Equal(t, models.CostBasisLIFO, settings.CostBasisMethod)
+	assert.Equal(t, DefaultSettingsOrderType, settings.DefaultOrderType)
+	assert.Equal(t, models.RiskModerate, settings.RiskTolerance)
+	assert.True(t, settings.AutoRebalance)
+	assert.True(t, settings.Notifies("stop_loss_triggered"))
+	assert.False(t, settings.Notifies("position_alert"))
+	assert.True(t, settings.Notifies("allocation_drift"))
+	assert.Equal(t, models.SettingsLevelPortfolio, settings.Sources["notifications"])
+	assert.NoError(t, ps.ValidateSettings(settings))
+
+	for _, invalid := range []func(*models.PortfolioSettings){
+		func(s *models.PortfolioSettings) { s.CostBasisMethod = "average" },
+		func(s *models.PortfolioSettings) { s.DefaultOrderType = "stop" },
+		func(s *models.PortfolioSettings) { s.RiskTolerance = "reckless" },
+		func(s *models.PortfolioSettings) { s.Notifications = map[string]bool{"newsletter": false} },
+	} {
+		changed := settings
+		invalid(&changed)
+		assert.ErrorIs(t, ps.ValidateSettings(changed), ErrInvalidSettings)
+	}
+}
+
+func TestApplySettingsPatchNotifications(t *testing.T) {
+	ps := NewPortfolioService()
+	overrides := models.SettingsOverrides{Notifications: map[string]bool{"position_alert": false, "option_expired": false}}
+
+	err := ps.ApplySettingsPatch(&overrides, map[string]json.RawMessage{
+		"notifications":     json.RawMessage(`{"position_alert": null, "stop_loss_triggered": false}`),
+		"cost_basis_method": json.RawMessage(`"HIFO"`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"option_expired": false, "stop_loss_triggered": false}, overrides.Notifications)
+	assert.Equal(t, models.CostBasisHIFO, *overrides.CostBasisMethod)
+
+	err = ps.ApplySettingsPatch(&overrides, map[string]json.RawMessage{"notifications": json.RawMessage(`null`)})
+	assert.NoError(t, err)
+	assert.Nil(t, overrides.Notifications)
+}
+
+func TestFeeScheduleSetsCommission(t *testing.T) {
+	ps := NewPortfolioService()
+	portfolio := &models.Portfolio{Cash: 10000, Fees: &models.FeeSchedule{Rate: 0.01, Minimum: 5}}
+	trade := &models.Trade{Symbol: "AAPL", Side: "buy", Quantity: 10}
+
+	_, err := ps.ExecuteTradeOrder(trade, portfolio, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, trade.Fees)
+	assert.Equal(t, 8990.0, portfolio.Cash)
+}
+
+func TestCalculateFeesByModel(t *testing.T) {
+	ps := NewPortfolioService()
+
+	// 200 shares worth 30,000
+	for _, tc := range []struct {
+		schedule models.FeeSchedule
+		want     float64
+	}{
+		{models.FeeSchedule{Rate: 0.001, Minimum: 1}, 30},
+		{models.FeeSchedule{Model: models.FeeModelFlat, Flat: 4.95}, 4.95},
+		{models.FeeSchedule{Model: models.FeeModelPerShare, PerShare: 0.005, Minimum: 1, Maximum: 0.5}, 0.5},
+		{models.FeeSchedule{Model: models.FeeModelPerShare, PerShare: 0.005, Minimum: 1.5}, 1.5},
+		// 10,000 at 0.2%, 15,000 at 0.1% and 5,000 at 0.05%
+		{models.FeeSchedule{Model: models.FeeModelTiered, Tiers: []models.FeeTier{{UpTo: 10000, Rate: 0.002}, {UpTo: 25000, Rate: 0.001}, {Rate: 0.0005}}}, 37.5},
+	} {
+		total, items := ps.CalculateFees(tc.schedule, "buy", 200, 30000)
+		assert.InDelta(t, tc.want, total, 1e-9, "%+v", tc.schedule)
+		assert.Equal(t, []models.FeeItem{{Name: models.FeeCommission, Amount: total}}, items)
+	}
+}
+
+func TestCalculateFeesItemizesLevies(t *testing.T) {
+	ps := NewPortfolioService()
+	schedule := models.FeeSchedule{
+		Model: models.FeeModelFlat,
+		Flat:  1,
+		Levies: []models.FeeLevy{
+			{Name: "sec", Rate: 0.0000278, Side: "sell"},
+			{Name: "taf", PerShare: 0.000166, Maximum: 8.30, Side: "sell"},
+			{Name: "exchange", PerShare: 0.003},
+		},
+	}
+
+	total, items := ps.CalculateFees(schedule, "sell", 100000, 1000000)
+	require.Len(t, items, 4)
+	assert.InDelta(t, 27.8, items[1].Amount, 1e-9)
+	assert.Equal(t, models.FeeItem{Name: "taf", Amount: 8.30}, items[2])
+	assert.InDelta(t, 300, items[3].Amount, 1e-9)
+	assert.InDelta(t, 1+27.8+8.30+300, total, 1e-9)
+
+	// Sell-side levies are not charged on buys
+	_, items = ps.CalculateFees(schedule, "buy", 100000, 1000000)
+	assert.Equal(t, []string{models.FeeCommission, "exchange"}, []string{items[0].Name, items[1].Name})
+}
+
+func TestExecuteTradeOrderRecordsFeeItems(t *testing.T) {
+	ps := NewPortfolioService()
+	portfolio := &models.Portfolio{Cash: 10000, Fees: &models.FeeSchedule{
+		Model:    models.FeeModelPerShare,
+		PerShare: 0.01,
+		Minimum:  1,
+		Levies:   []models.FeeLevy{{Name: "exchange", PerShare: 0.05}},
+	}}
+	trade := &models.Trade{Symbol: "AAPL", Side: "buy", Quantity: 10}
+
+	// 1 of commission and 0.50 of exchange fees are part of the cost checked
+	assert.ErrorIs(t, ps.ValidateTradeOrder(trade, portfolio, 999.9), ErrInsufficientCash)
+	_, err := ps.ExecuteTradeOrder(trade, portfolio, 100)
+	require.NoError(t, err)
+	assert.InDelta(t, 1.5, trade.Fees, 1e-9)
+	assert.Equal(t, []models.FeeItem{{Name: models.FeeCommission, Amount: 1}, {Name: "exchange", Amount: 0.5}}, trade.FeeItems)
+	assert.InDelta(t, 8998.5, portfolio.Cash, 1e-9)
+}
+
+func TestValidateQuantity(t *testing.T) {
+	ps := NewPortfolioService()
+	assert.Equal(t, models.AssetTypeCrypto, AssetType("btc-usd"))
+	assert.Equal(t, models.AssetTypeEquity, AssetType("AAPL"))
+
+	assert.NoError(t, ps.ValidateQuantity(models.AssetTypeEquity, 10))
+	assert.ErrorIs(t, ps.ValidateQuantity(models.AssetTypeEquity, 1.5), ErrInvalidQuantity)
+	assert.NoError(t, ps.ValidateQuantity(models.AssetTypeCrypto, 0.12345678))
+	assert.ErrorIs(t, ps.ValidateQuantity(models.AssetTypeCrypto, 0.123456789), ErrInvalidQuantity)
+	assert.ErrorIs(t, ps.ValidateQuantity(models.AssetTypeCrypto, 0.000000001), ErrInvalidQuantity)
+	assert.ErrorIs(t, ps.ValidateQuantity(models.AssetTypeCrypto, math.NaN()), ErrInvalidQuantity)
+}
+
+func TestExecuteTradeOrderFractionalCrypto(t *testing.T) {
+	ps := NewPortfolioService()
+	portfolio := &models.Portfolio{Cash: 10000, Fees: &models.FeeSchedule{Rate: 0.001, Minimum: 1}}
+
+	buy := &models.Trade{Symbol: "BTC-USD", Side: "buy", Quantity: 0.1}
+	require.NoError(t, ps.ValidateTradeOrder(buy, portfolio, 30000))
+	position, err := ps.ExecuteTradeOrder(buy, portfolio, 30000)
+	require.NoError(t, err)
+	assert.Equal(t, models.AssetTypeCrypto, buy.AssetType)
+	assert.Equal(t, models.AssetTypeCrypto, position.AssetType)
+	assert.InDelta(t, 7.5, buy.Fees, 1e-9) // DefaultCryptoFees, not the equity schedule
+	assert.InDelta(t, 6992.5, portfolio.Cash, 1e-9)
+
+	// Fractions sold off in pieces close the position at exactly zero
+	for _, quantity := range []float64{0.07, 0.03} {
+		sell := &models.Trade{Symbol: "BTC-USD", Side: "sell", Quantity: quantity}
+		require.NoError(t, ps.ValidateTradeOrder(sell, portfolio, 30000))
+		_, err := ps.ExecuteTradeOrder(sell, portfolio, 30000)
+		require.NoError(t, err)
+	}
+	assert.Empty(t, portfolio.Positions)
+
+	assert.ErrorIs(t, ps.ValidateTradeOrder(&models.Trade{Symbol: "AAPL", Side: "buy", Quantity: 0.5}, portfolio, 100), ErrInvalidQuantity)
+}
+
+func TestExecuteTradeOrderOptions(t *testing.T) {
+	ps := NewPortfolioService()
+	portfolio := &models.Portfolio{Cash: 10000, Fees: &models.FeeSchedule{Model: models.FeeModelFlat, Flat: 1}}
+	symbol := symbols.OptionSymbol(&models.OptionContract{
+		Underlying: "AAPL", Type: models.OptionCall, Strike: 150, Expiry: time.Now().UTC().AddDate(0, 1, 0).Truncate(24 * time.Hour),
+	})
+
+	// Option prices are per share; each contract covers 100
+	buy := &models.Trade{Symbol: symbol, Side: "buy", Quantity: 2}
+	require.NoError(t, ps.ValidateTradeOrder(buy, portfolio, 5))
+	position, err := ps.ExecuteTradeOrder(buy, portfolio, 5)
+	require.NoError(t, err)
+	assert.Equal(t, models.AssetTypeOption, position.AssetType)
+	assert.Equal(t, 100.0, position.Multiplier)
+	assert.InDelta(t, 8999, portfolio.Cash, 1e-9)
+
+	ps.UpdatePortfolioWithMarketData(portfolio, map[string]float64{symbol: 6})
+	assert.InDelta(t, 200, portfolio.UnrealizedPnL, 1e-9)
+	assert.InDelta(t, 10199, portfolio.TotalValue, 1e-9)
+
+	assert.ErrorIs(t, ps.ValidateTradeOrder(&models.Trade{Symbol: symbol, Side: "buy", Quantity: 0.5}, portfolio, 5), ErrInvalidQuantity)
+	assert.ErrorIs(t, ps.ValidateTradeOrder(&models.Trade{Symbol: symbol, Side: "buy", Quantity: 100}, portfolio, 5), ErrInsufficientCash)
+	assert.ErrorIs(t, ps.ValidateTradeOrder(&models.Trade{Symbol: "AAPL240119C00150000", Side: "buy", Quantity: 1}, portfolio, 5), ErrOptionExpired)
+}
+
+func TestSettleExpiredOption(t *testing.T) {
+	ps := NewPortfolioService()
+	now := time.Date(2024, 1, 20, 1, 0, 0, 0, time.UTC)
+	newPortfolio := func(quantity float64) *models.Portfolio {
+		return &models.Portfolio{ID: 1, Cash: 1000, Positions: []models.Position{
+			{ID: 7, Symbol: "AAPL240119C00150000", AssetType: models.AssetTypeOption, Quantity: quantity, Multiplier: 100},
+		}}
+	}
+
+	long := newPortfolio(2)
+	trade, err := ps.SettleExpiredOption(long, "AAPL240119C00150000", 160, now)
+	require.NoError(t, err)
+	assert.Equal(t, "sell", trade.Side)
+	assert.Equal(t, models.TradeTriggerOptionExercise, trade.TriggerReason)
+	assert.Equal(t, 10.0, trade.Price)
+	assert.Equal(t, 7, trade.PositionID)
+	assert.Zero(t, trade.Fees)
+	assert.InDelta(t, 3000, long.Cash, 1e-9)
+	assert.Empty(t, long.Positions)
+
+	short := newPortfolio(-1)
+	trade, err = ps.SettleExpiredOption(short, "AAPL240119C00150000", 160, now)
+	require.NoError(t, err)
+	assert.Equal(t, "buy", trade.Side)
+	assert.Equal(t, 1.0, trade.Quantity)
+	assert.Equal(t, models.TradeTriggerOptionAssignment, trade.TriggerReason)
+	assert.InDelta(t, 0, short.Cash, 1e-9)
+
+	worthless := newPortfolio(2)
+	trade, err = ps.SettleExpiredOption(worthless, "AAPL240119C00150000", 140, now)
+	require.NoError(t, err)
+	assert.Equal(t, models.TradeTriggerOptionExpiry, trade.TriggerReason)
+	assert.Zero(t, trade.Price)
+	assert.InDelta(t, 1000, worthless.Cash, 1e-9)
+
+	// Not expired until the end of its last trading day
+	_, err = ps.SettleExpiredOption(newPortfolio(2), "AAPL240119C00150000", 160, time.Date(2024, 1, 19, 20, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
+
+func TestValidateFeeSchedule(t *testing.T) {
+	ps := NewPortfolioService()
+	assert.NoError(t, ps.ValidateFeeSchedule(models.FeeSchedule{Model: models.FeeModelTiered, Tiers: []models.FeeTier{{UpTo: 1000, Rate: 0.002}, {Rate: 0.001}}}))
+
+	for _, invalid := range []models.FeeSchedule{
+		{Model: "monthly"},
+		{Rate: 0.2},
+		{Model: models.FeeModelFlat, Flat: -1},
+		{Model: models.FeeModelTiered},
+		{Model: models.FeeModelTiered, Tiers: []models.FeeTier{{UpTo: 1000, Rate: 0.002}, {UpTo: 500, Rate: 0.001}, {Rate: 0}}},
+		{Model: models.FeeModelTiered, Tiers: []models.FeeTier{{UpTo: 1000, Rate: 0.002}}},
+		{Minimum: 5, Maximum: 2},
+		{Levies: []models.FeeLevy{{Name: models.FeeCommission, Rate: 0.001}}},
+		{Levies: []models.FeeLevy{{Name: "sec", Side: "short"}}},
+		{Levies: []models.FeeLevy{{Name: "sec"}, {Name: "sec"}}},
+	} {
+		assert.ErrorIs(t, ps.ValidateFeeSchedule(invalid), ErrInvalidSettings, "%+v", invalid)
+	}
+}
+
+func TestFeeScheduleSettingPrecedence(t *testing.T) {
+	ps := NewPortfolioService()
+	rate := 0.002
+	flat := &models.FeeSchedule{Model: models.FeeModelFlat, Flat: 2}
+
+	// Set by the owner, the schedule replaces the default commission options
+	settings := ps.ResolveSettings(1, models.SettingsOverrides{}, models.SettingsOverrides{FeeSchedule: flat})
+	assert.Equal(t, *flat, settings.Fees())
+	assert.True(t, settings.SetsFees())
+
+	// A commission rate set on the portfolio is more specific
+	settings = ps.ResolveSettings(1, models.SettingsOverrides{CommissionRate: &rate}, models.SettingsOverrides{FeeSchedule: flat})
+	assert.Equal(t, models.FeeSchedule{Rate: 0.002, Minimum: DefaultFees.Minimum}, settings.Fees())
+
+	settings = ps.ResolveSettings(1, models.SettingsOverrides{}, models.SettingsOverrides{})
+	assert.False(t, settings.SetsFees())
+
+	overrides := models.SettingsOverrides{}
+	require.NoError(t, ps.ApplySettingsPatch(&overrides, map[string]json.RawMessage{
+		"fee_schedule": json.RawMessage(`{"model": "per_share", "per_share": 0.005, "minimum": 1}`),
+	}))
+	assert.Equal(t, &models.FeeSchedule{Model: models.FeeModelPerShare, PerShare: 0.005, Minimum: 1}, overrides.FeeSchedule)
+}
+
+func TestParseBrokerFeeSchedules(t *testing.T) {
+	ps := NewPortfolioService()
+
+	schedules, err := ps.ParseBrokerFeeSchedules(`{"alpaca": {"model": "flat", "flat": 0, "levies": [{"name": "sec", "rate": 0.0000278, "side": "sell"}]}}`)
+	require.NoError(t, err)
+	assert.Equal(t, models.FeeModelFlat, schedules["alpaca"].Model)
+	assert.Len(t, schedules["alpaca"].Levies, 1)
+
+	schedules, err = ps.ParseBrokerFeeSchedules("")
+	assert.NoError(t, err)
+	assert.Empty(t, schedules)
+
+	_, err = ps.ParseBrokerFeeSchedules(`{"alpaca": {"model": "monthly"}}`)
+	assert.ErrorIs(t, err, ErrInvalidSettings)
+	_, err = ps.ParseBrokerFeeSchedules(`[1]`)
+	assert.Error(t, err)
+}
+
+func TestKupiecLR(t *testing.T) {
+	// An observed exception rate equal to p is perfectly calibrated
+	assert.InDelta(t, 0, KupiecLR(250, 25, 0.1), 1e-9)
+	assert.InDelta(t, 1, chiSquaredSurvival(KupiecLR(250, 25, 0.1), 1), 1e-9)
+
+	// 10 exceptions in 250 days at 99% is far too many
+	lr := KupiecLR(250, 10, 0.01)
+	assert.InDelta(t, 12.96, lr, 0.01)
+	assert.Less(t, chiSquaredSurvival(lr, 1), VaRSignificance)
+}
+
+func TestChristoffersenLRDetectsClustering(t *testing.T) {
+	spread := make([]bool, 100)
+	clustered := make([]bool, 100)
+	for i := 0; i < 5; i++ {
+		spread[i*20+10] = true
+		clustered[50+i] = true
+	}
+
+	assert.Less(t, ChristoffersenLR(spread), 1.0)
+	assert.Greater(t, ChristoffersenLR(clustered), 3.84) // 5% critical value
+}
+
+func TestBacktestVaRCountsExceptions(t *testing.T) {
+	ps := NewPortfolioService()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Alternating gains and shrinking losses, so no loss exceeds those before
+	// it, except a 5% loss on the 40th forecast day
+	snapshots := make([]models.PortfolioSnapshot, 81)
+	value := 100000.0
+	for i := range snapshots {
+		if i > 0 {
+			switch {
+			case i == 60:
+				value *= 0.95
+			case i%2 == 0:
+				value *= 1.01
+			default:
+				value *= 0.99 + 0.00001*float64(i)
+			}
+		}
+		snapshots[i] = models.PortfolioSnapshot{PortfolioID: 1, SnapshotDate: start.AddDate(0, 0, i), TotalValue: value}
+	}
+
+	result, err := ps.BacktestVaR(snapshots, nil, 0.99, 20, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 60, result.Observations)
+	assert.Equal(t, 1, result.Exceptions)
+	assert.Equal(t, []time.Time{start.AddDate(0, 0, 60)}, result.ExceptionDates)
+	assert.Equal(t, start.AddDate(0, 0, 21), result.StartDate)
+	assert.True(t, result.Adequate)
+
+	windowed, err := ps.BacktestVaR(snapshots, nil, 0.99, 20, 40)
+	assert.NoError(t, err)
+	assert.Equal(t, 40, windowed.Observations)
+	assert.Equal(t, start.AddDate(0, 0, 41), windowed.StartDate)
+
+	_, err = ps.BacktestVaR(snapshots[:40], nil, 0.99, 20, 0)
+	assert.ErrorIs(t, err, ErrInsufficientHistory)
+	_, err = ps.BacktestVaR(snapshots, nil, 1.5, 20, 0)
+	assert.ErrorIs(t, err, ErrInvalidVaRBacktest)
+}
+
+func TestConsolidatePortfoliosNetsPositions(t *testing.T) {
+	ps := NewPortfolioService()
+	portfolios := []models.Portfolio{
+		{ID: 1, Cash: 1000, RealizedPnL: 50, Positions: []models.Position{
+			{Symbol: "AAPL", Quantity: 10, Side: "long", EntryPrice: 100},
+			{Symbol: "MSFT", Quantity: 5, Side: "long", EntryPrice: 300},
+		}},
+		{ID: 2, Cash: 500, RealizedPnL: -20, Positions: []models.Position{
+			{Symbol: "AAPL", Quantity: 30, Side: "long", EntryPrice: 120},
+			{Symbol: "MSFT", Quantity: -5, Side: "short", EntryPrice: 310},
+		}},
+	}
+
+	consolidated := ps.ConsolidatePortfolios(7, portfolios)
+	assert.Equal(t, 7, consolidated.UserID)
+	assert.Equal(t, 1500.0, consolidated.Cash)
+	assert.Equal(t, 30.0, consolidated.RealizedPnL)
+	assert.Len(t, consolidated.Positions, 1) // MSFT nets to zero
+	assert.Equal(t, "AAPL", consolidated.Positions[0].Symbol)
+	assert.Equal(t, 40.0, consolidated.Positions[0].Quantity)
+	assert.Equal(t, 115.0, consolidated.Positions[0].EntryPrice)
+
+	allocation := ps.CalculatePortfolioAllocation(consolidated, map[string]float64{"AAPL": 125})
+	assert.InDelta(t, 5000.0/6500*100, allocation["AAPL"], 1e-9)
+}
+
+func TestCombineSummaries(t *testing.T) {
+	ps := NewPortfolioService()
+	combined := ps.CombineSummaries([]models.PortfolioSummary{
+		{TotalValue: 6000, Cash: 1000, PositionsValue: 5000, UnrealizedPnL: 500, DayPnL: 60, PositionCount: 2},
+		{TotalValue: 4000, Cash: 2000, PositionsValue: 2000, UnrealizedPnL: -100, DayPnL: 40, PositionCount: 1},
+	})
+
+	assert.Equal(t, 10000.0, combined.TotalValue)
+	assert.Equal(t, 7000.0, combined.PositionsValue)
+	assert.Equal(t, 400.0, combined.UnrealizedPnL)
+	assert.Equal(t, 3, combined.PositionCount)
+	assert.InDelta(t, 1.0, combined.DayReturn, 1e-9)
+	assert.InDelta(t, 400.0/7000*100, combined.TotalReturn, 1e-9)
+}
+
+func TestCalculatePerformance(t *testing.T) {
+	ps := NewPortfolioService()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	values := []float64{100, 110, 99, 108.9, 119.79}
+	snapshots := make([]models.PortfolioSnapshot, len(values))
+	for i, value := range values {
+		snapshots[i] = models.PortfolioSnapshot{PortfolioID: 1, SnapshotDate: start.AddDate(0, 0, i), TotalValue: value}
+	}
+
+	performance, err := ps.CalculatePerformance(snapshots, nil, 0.0252)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, performance.Observations)
+	assert.InDelta(t, 19.79, performance.TotalReturn, 1e-9)
+	assert.InDelta(t, 10.0, performance.MaxDrawdown, 1e-9)
+	assert.Equal(t, 0.0252, performance.RiskFreeRate)
+
+	// Daily returns of +10%, -10%, +10%, +10% over a 0.01% daily risk-free rate
+	// have a sample standard deviation of 10%
+	excess := 0.05 - 0.0001
+	assert.InDelta(t, 0.1*math.Sqrt(252)*100, performance.Volatility, 1e-9)
+	assert.InDelta(t, excess/0.1*math.Sqrt(252), performance.SharpeRatio, 1e-9)
+	assert.InDelta(t, excess/(0.1001/2)*math.Sqrt(252), performance.SortinoRatio, 1e-9)
+	assert.Greater(t, performance.AnnualizedReturn, performance.TotalReturn)
+
+	lower, err := ps.CalculatePerformance(snapshots, nil, 0.05)
+	assert.NoError(t, err)
+	assert.Less(t, lower.SharpeRatio, performance.SharpeRatio)
+
+	_, err = ps.CalculatePerformance(snapshots[:2], nil, 0)
+	assert.ErrorIs(t, err, ErrInsufficientHistory)
+}
+
+func TestCalculatePerformanceNetsCashFlows(t *testing.T) {
+	ps := NewPortfolioService()
+	start := time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC)
+
+	// Flat investments, with 50 withdrawn before day 2 and 100 deposited
+	// before day 4
+	values := []float64{100, 50, 50, 150, 150}
+	snapshots := make([]models.PortfolioSnapshot, len(values))
+	for i, value := range values {
+		snapshots[i] = models.PortfolioSnapshot{PortfolioID: 1, SnapshotDate: start.AddDate(0, 0, i), TotalValue: value, CreatedAt: start.AddDate(0, 0, i)}
+	}
+	cash := []models.CashTransaction{
+		{Type: models.CashWithdrawal, Amount: 50, CreatedAt: start.Add(time.Hour)},
+		{Type: models.CashDeposit, Amount: 100, CreatedAt: start.AddDate(0, 0, 2).Add(time.Hour)},
+	}
+
+	performance, err := ps.CalculatePerformance(snapshots, cash, 0)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.0, performance.TotalReturn, 1e-9)
+	assert.InDelta(t, 0.0, performance.MaxDrawdown, 1e-9)
+	assert.InDelta(t, 0.0, performance.Volatility, 1e-9)
+
+	// Counted as the portfolio's own, the withdrawal is a 50% loss
+	raw, err := ps.CalculatePerformance(snapshots, nil, 0)
+	assert.NoError(t, err)
+	assert.InDelta(t, 50.0, raw.MaxDrawdown, 1e-9)
+}
+
+func TestStopLossOrder(t *testing.T) {
+	ps := NewPortfolioService()
+	percent := 10.0
+	position := &models.Position{ID: 7, UserID: 1, PortfolioID: 2, Symbol: "AAPL", Quantity: 50, Side: "long", EntryPrice: 200, StopLossPercent: &percent}
+
+	trigger, ok := position.StopLossTrigger()
+	assert.True(t, ok)
+	assert.InDelta(t, 180.0, trigger, 1e-9)
+
+	assert.Nil(t, ps.StopLossOrder(position, 180.01))
+	order := ps.StopLossOrder(position, 180)
+	if assert.NotNil(t, order) {
+		assert.Equal(t, "sell", order.Side)
+		assert.Equal(t, "market", order.Type)
+		assert.Equal(t, 50.0, order.Quantity)
+		assert.Equal(t, models.TradeTriggerStopLoss, order.TriggerReason)
+	}
+
+	// An absolute price takes precedence
+	price := 150.0
+	position.StopLossPrice = &price
+	assert.Nil(t, ps.StopLossOrder(position, 170))
+	assert.NotNil(t, ps.StopLossOrder(position, 149))
+
+	position.StopLossPercent, position.StopLossPrice = nil, nil
+	assert.Nil(t, ps.StopLossOrder(position, 1))
+}
+
+func TestValidateStopLoss(t *testing.T) {
+	ps := NewPortfolioService()
+	long := &models.Position{Symbol: "AAPL", Side: "long"}
+	short := &models.Position{Symbol: "AAPL", Side: "short"}
+	percent, price := 5.0, 100.0
+	invalid := 100.0
+
+	assert.NoError(t, ps.ValidateStopLoss(long, nil, nil))
+	assert.NoError(t, ps.ValidateStopLoss(short, nil, nil))
+	assert.NoError(t, ps.ValidateStopLoss(long, &percent, nil))
+	assert.NoError(t, ps.ValidateStopLoss(long, nil, &price))
+	assert.ErrorIs(t, ps.ValidateStopLoss(long, &percent, &price), ErrInvalidStopLoss)
+	assert.ErrorIs(t, ps.ValidateStopLoss(long, &invalid, nil), ErrInvalidStopLoss)
+	assert.ErrorIs(t, ps.ValidateStopLoss(short, &percent, nil), ErrInvalidStopLoss)
+}
+
+func TestValidatePositionAlert(t *testing.T) {
+	ps := NewPortfolioService()
+	up, down := 10.0, 5.0
+	tooFar := 100.0
+
+	assert.NoError(t, ps.ValidatePositionAlert(&models.PositionAlert{UpPercent: &up}))
+	assert.NoError(t, ps.ValidatePositionAlert(&models.PositionAlert{UpPercent: &up, DownPercent: &down}))
+	assert.ErrorIs(t, ps.ValidatePositionAlert(&models.PositionAlert{}), ErrInvalidPositionAlert)
+	assert.ErrorIs(t, ps.ValidatePositionAlert(&models.PositionAlert{DownPercent: &tooFar}), ErrInvalidPositionAlert)
+	assert.ErrorIs(t, ps.ValidatePositionAlert(&models.PositionAlert{UpPercent: &up, Note: strings.Repeat("x", MaxPositionAlertNoteLength+1)}), ErrInvalidPositionAlert)
+}
+
+func TestPositionAlertCrossed(t *testing.T) {
+	ps := NewPortfolioService()
+	up, down := 10.0, 5.0
+	alert := &models.PositionAlert{UpPercent: &up, DownPercent: &down, EntryPrice: 200, IsActive: true}
+
+	direction, level := ps.PositionAlertCrossed(alert, 220)
+	assert.Equal(t, models.PositionAlertUp, direction)
+	assert.InDelta(t, 220, level, 1e-9)
+
+	direction, level = ps.PositionAlertCrossed(alert, 185)
+	assert.Equal(t, models.PositionAlertDown, direction)
+	assert.InDelta(t, 190, level, 1e-9)
+
+	direction, _ = ps.PositionAlertCrossed(alert, 215)
+	assert.Empty(t, direction)
+
+	// Only the watched direction fires, and only while active
+	alert.UpPercent = nil
+	direction, _ = ps.PositionAlertCrossed(alert, 300)
+	assert.Empty(t, direction)
+	alert.IsActive = false
+	direction, _ = ps.PositionAlertCrossed(alert, 150)
+	assert.Empty(t, direction)
+}
+
+func TestExpandProvisionSpecGeneratesUsers(t *testing.T) {
+	cash := 5000.0
+	users, err := ExpandProvisionSpec(&ProvisionSpec{
+		Count:          12,
+		UsernamePrefix: "Class",
+		StartingCash:   &cash,
+		Positions: []PresetPosition{
+			{Symbol: "aapl", Quantity: 10, Price: 100},
+			{Symbol: "AAPL", Quantity: 30, Price: 200},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, users, 12)
+
+	assert.Equal(t, "class-001", users[0].Username)
+	assert.Equal(t, "class-012", users[11].Username)
+	assert.Equal(t, "class-001@example.com", users[0].Email)
+	assert.Equal(t, 5000.0, *users[0].StartingCash)
+
+	// Repeated symbols merge at their average price
+	assert.Equal(t, []PresetPosition{{Symbol: "AAPL", Quantity: 40, Price: 175}}, users[0].Positions)
+}
+
+func TestExpandProvisionSpecRejectsInvalid(t *testing.T) {
+	negative := -1.0
+	specs := map[string]*ProvisionSpec{
+		"no users":           {},
+		"count and users":    {Count: 1, Users: []ProvisionUser{{Username: "a"}}},
+		"too many":           {Count: MaxProvisionUsers + 1},
+		"unknown role":       {Count: 1, Role: "root"},
+		"short password":     {Count: 1, Password: "abc"},
+		"negative cash":      {Count: 1, StartingCash: &negative},
+		"duplicate username": {Users: []ProvisionUser{{Username: "a"}, {Username: "A"}}},
+		"duplicate email":    {Users: []ProvisionUser{{Username: "a", Email: "x@y.z"}, {Username: "b", Email: "x@y.z"}}},
+		"invalid username":   {Users: []ProvisionUser{{Username: "a b"}}},
+		"invalid position":   {Count: 1, Positions: []PresetPosition{{Symbol: "AAPL", Quantity: 0, Price: 1}}},
+	}
+	for name, spec := range specs {
+		_, err := ExpandProvisionSpec(spec)
+		assert.ErrorIs(t, err, ErrInvalidProvisionSpec, name)
+	}
+}
+
+func TestParseProvisionCSV(t *testing.T) {
+	input := "username,email,starting_cash,symbol,quantity,price\n" +
+		"alice,alice@school.edu,2500,AAPL,10,150\n" +
+		"alice,,,MSFT,5,300\n" +
+		"bob,,,,,\n"
+
+	spec, err := ParseProvisionCSV(strings.NewReader(input), &ProvisionSpec{Password: "classroom"})
+	assert.NoError(t, err)
+	assert.Equal(t, "classroom", spec.Password)
+	assert.Len(t, spec.Users, 2)
+
+	alice := spec.Users[0]
+	assert.Equal(t, "alice@school.edu", alice.Email)
+	assert.Equal(t, 2500.0, *alice.StartingCash)
+	assert.Len(t, alice.Positions, 2)
+	assert.Equal(t, "bob", spec.Users[1].Username)
+	assert.Empty(t, spec.Users[1].Positions)
+
+	_, err = ParseProvisionCSV(strings.NewReader("email\nx@y.z\n"), nil)
+	assert.ErrorIs(t, err, ErrInvalidProvisionSpec)
+	_, err = ParseProvisionCSV(strings.NewReader("username,symbol,quantity,price\na,AAPL,ten,1\n"), nil)
+	assert.ErrorIs(t, err, ErrInvalidProvisionSpec)
+}
+
+func TestSuggestImportMapping(t *testing.T) {
+	columns := []string{"Run Date", "Action", "Symbol", "Quantity", "Price ($)", "Commissions & Fees", "Order ID"}
+
+	mapping := SuggestImportMapping(models.ImportKindTrades, columns)
+	assert.Equal(t, map[string]string{
+		ImportFieldDate:     "Run Date",
+		ImportFieldSide:     "Action",
+		ImportFieldSymbol:   "Symbol",
+		ImportFieldQuantity: "Quantity",
+		ImportFieldPrice:    "Price ($)",
+		ImportFieldFees:     "Commissions & Fees",
+		ImportFieldTradeID:  "Order ID",
+	}, mapping)
+	assert.NoError(t, ValidateImportMapping(models.ImportKindTrades, columns, mapping))
+
+	positions := SuggestImportMapping(models.ImportKindPositions, []string{"Ticker", "Shares", "Avg Cost"})
+	assert.NoError(t, ValidateImportMapping(models.ImportKindPositions, []string{"Ticker", "Shares", "Avg Cost"}, positions))
+
+	err := ValidateImportMapping(models.ImportKindTrades, columns, map[string]string{ImportFieldSymbol: "Symbol"})
+	assert.ErrorIs(t, err, ErrInvalidImport)
+	err = ValidateImportMapping(models.ImportKindTrades, columns, map[string]string{ImportFieldSymbol: "Ticker"})
+	assert.ErrorIs(t, err, ErrInvalidImport)
+}
+
+func TestParseImportRows(t *testing.T) {
+	ps := NewPortfolioService()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	input := "\ufeffDate,Symbol,Side,Quantity,Price,Fees\n" +
+		"2024-03-01,aapl,Bought,10,\"$1,150.25\",(1.00)\n" +
+		"03/04/2024,MSFT,,-5,300,\n" +
+		"2024-03-05,AAPL,hold,1,150,\n" +
+		"2024-03-06,AAPL,buy,1.5,150,\n" +
+		"2024-07-01,AAPL,buy,1,150,\n"
+
+	columns, records, err := ReadImportCSV(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, "Date", columns[0])
+
+	rows, err := ps.ParseImportRows(models.ImportKindTrades, columns, records, SuggestImportMapping(models.ImportKindTrades, columns), now)
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+
+	assert.Equal(t, ImportRow{Line: 2, Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Symbol: "AAPL", Side: "buy",
+		Quantity: 10, Price: 1150.25, Fees: 1}, rows[0])
+	assert.Equal(t, "sell", rows[1].Side)
+	assert.Equal(t, 5.0, rows[1].Quantity)
+	assert.Contains(t, rows[2].Error, "unknown side")
+	assert.NotEmpty(t, rows[3].Error, "fractional equity quantity")
+	assert.Contains(t, rows[4].Error, "future")
+
+	_, _, err = ReadImportCSV(strings.NewReader("Date,Symbol\n"))
+	assert.ErrorIs(t, err, ErrInvalidImport)
+	assert.ErrorIs(t, ValidateImportKind(models.ImportKindTrades, models.ImportFormatOFX), ErrInvalidImport)
+}
+
+func TestMarkDuplicateTrades(t *testing.T) {
+	day := time.Date(2024, 3, 1, 14, 30, 0, 0, time.UTC)
+	existing := []models.Trade{
+		{Symbol: "AAPL", Side: "buy", Quantity: 10, Price: 150, ExecutedAt: &day},
+		{Symbol: "MSFT", Side: "buy", Quantity: 1, Price: 300, BrokerOrderID: "X1", ExecutedAt: &day},
+	}
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	rows := []ImportRow{
+		{Line: 2, Date: date, Symbol: "AAPL", Side: "buy", Quantity: 10, Price: 150},
+		{Line: 3, Date: date, Symbol: "AAPL", Side: "buy", Quantity: 10, Price: 150}, // A second, new fill
+		{Line: 4, Date: date, Symbol: "MSFT", Side: "buy", Quantity: 2, Price: 310, TradeID: "X1"},
+		{Line: 5, Date: date, Symbol: "MSFT", Side: "sell", Quantity: 1, Price: 320, TradeID: "X2"},
+		{Line: 6, Date: date, Symbol: "MSFT", Side: "sell", Quantity: 1, Price: 320, TradeID: "X2"},
+		{Line: 7, Error: "invalid price"},
+	}
+
+	MarkDuplicateTrades(rows, existing)
+	var duplicates []int
+	for _, row := range rows {
+		if row.Duplicate {
+			duplicates = append(duplicates, row.Line)
+		}
+	}
+	assert.Equal(t, []int{2, 4, 6}, duplicates)
+
+	positions := []ImportRow{{Line: 2, Symbol: "AAPL"}, {Line: 3, Symbol: "MSFT"}, {Line: 4, Symbol: "MSFT"}}
+	MarkDuplicatePositions(positions, []models.Position{{Symbol: "aapl"}})
+	assert.True(t, positions[0].Duplicate)
+	assert.False(t, positions[1].Duplicate)
+	assert.True(t, positions[2].Duplicate)
+}
+
+func TestOrderImportRows(t *testing.T) {
+	first := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	second := first.AddDate(0, 0, 1)
+	rows := []ImportRow{
+		{Line: 2, Date: second, Side: "buy"},
+		{Line: 3, Date: first, Side: "sell"},
+		{Line: 4, Date: first, Side: "buy"},
+		{Line: 5, Date: first, Side: "buy"},
+	}
+
+	OrderImportRows(rows)
+	lines := make([]int, len(rows))
+	for i, row := range rows {
+		lines[i] = row.Line
+	}
+	assert.Equal(t, []int{4, 5, 3, 2}, lines)
+
+	start, end := ImportSpan(append(rows, ImportRow{Error: "invalid date"}))
+	assert.Equal(t, first, start)
+	assert.Equal(t, second, end)
+}
+
+func testCompetition() *models.Competition {
+	return &models.Competition{
+		Name:         "Spring Cup",
+		Universe:     []string{"AAPL", "MSFT"},
+		StartDate:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:      time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		StartingCash: 10000,
+		Rules:        models.CompetitionRules{MaxPositionPercent: 25, MaxTradesPerDay: 3},
+	}
+}
+
+func TestValidateCompetition(t *testing.T) {
+	c := testCompetition()
+	c.Universe = []string{" aapl ", "MSFT", "AAPL"}
+	c.StartDate = time.Date(2024, 3, 1, 15, 30, 0, 0, time.UTC)
+	assert.NoError(t, ValidateCompetition(c))
+	assert.Equal(t, []string{"AAPL", "MSFT"}, c.Universe)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), c.StartDate)
+
+	invalid := map[string]func(*models.Competition){
+		"no name":          func(c *models.Competition) { c.Name = " " },
+		"ends before":      func(c *models.Competition) { c.EndDate = c.StartDate.AddDate(0, 0, -1) },
+		"no cash":          func(c *models.Competition) { c.StartingCash = 0 },
+		"position percent": func(c *models.Competition) { c.Rules.MaxPositionPercent = 150 },
+		"negative trades":  func(c *models.Competition) { c.Rules.MaxTradesPerDay = -1 },
+	}
+	for name, mutate := range invalid {
+		c := testCompetition()
+		mutate(c)
+		assert.ErrorIs(t, ValidateCompetition(c), ErrInvalidCompetition, name)
+	}
+}
+
+func TestCheckEntry(t *testing.T) {
+	c := testCompetition()
+	before := time.Date(2024, 2, 20, 12, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, CheckEntry(c, &models.Portfolio{Cash: 10000}, before))
+	assert.NoError(t, CheckEntry(c, &models.Portfolio{Cash: 10000}, c.StartDate.Add(20*time.Hour)))
+	assert.ErrorIs(t, CheckEntry(c, &models.Portfolio{Cash: 10000}, c.StartDate.AddDate(0, 0, 1)), ErrInvalidEntry)
+	assert.ErrorIs(t, CheckEntry(c, &models.Portfolio{Cash: 12000}, before), ErrInvalidEntry)
+	assert.ErrorIs(t, CheckEntry(c, &models.Portfolio{
+		Cash:      10000,
+		Positions: []models.Position{{Symbol: "AAPL", Quantity: 1}},
+	}, before), ErrInvalidEntry)
+}
+
+func TestCheckCompetitionTrade(t *testing.T) {
+	c := testCompetition()
+	during := time.Date(2024, 3, 10, 14, 0, 0, 0, time.UTC)
+	portfolio := &models.Portfolio{
+		Cash:      8000,
+		Positions: []models.Position{{Symbol: "AAPL", Quantity: 10, EntryPrice: 150, CurrentPrice: 200}},
+	}
+	buy := func(symbol string, quantity float64) *models.Trade {
+		return &models.Trade{Symbol: symbol, Side: "buy", Quantity: quantity}
+	}
+
+	// Portfolio worth 10000; AAPL at 200 is 20% of it
+	assert.NoError(t, CheckCompetitionTrade(c, portfolio, buy("AAPL", 2), 200, 0, during))
+	assert.ErrorIs(t, CheckCompetitionTrade(c, portfolio, buy("AAPL", 3), 200, 0, during), ErrCompetitionRule)
+	assert.NoError(t, CheckCompetitionTrade(c, portfolio, &models.Trade{Symbol: "AAPL", Side: "sell", Quantity: 10}, 200, 0, during))
+
+	assert.ErrorIs(t, CheckCompetitionTrade(c, portfolio, buy("TSLA", 1), 100, 0, during), ErrCompetitionRule)
+	assert.ErrorIs(t, CheckCompetitionTrade(c, portfolio, buy("MSFT", 1), 100, 3, during), ErrCompetitionRule)
+
+	assert.ErrorIs(t, CheckCompetitionTrade(c, portfolio, buy("MSFT", 1), 100, 0, c.StartDate.Add(-time.Hour)), ErrCompetitionRule)
+	assert.NoError(t, CheckCompetitionTrade(c, portfolio, buy("TSLA", 100), 100, 10, c.EndDate.AddDate(0, 0, 1)))
+}
+
+func TestRankStandingsSharesTies(t *testing.T) {
+	standings := []models.CompetitionStanding{
+		{PortfolioID: 1, ReturnPercent: 2.5},
+		{PortfolioID: 2, ReturnPercent: 7.1},
+		{PortfolioID: 3, ReturnPercent: 2.5},
+		{PortfolioID: 4, ReturnPercent: -1},
+	}
+	RankStandings(standings)
+
+	ids := make([]int, len(standings))
+	ranks := make([]int, len(standings))
+	for i, s := range standings {
+		ids[i], ranks[i] = s.PortfolioID, s.Rank
+	}
+	assert.Equal(t, []int{2, 1, 3, 4}, ids)
+	assert.Equal(t, []int{1, 2, 2, 4}, ranks)
+}
+
+func TestNormalizeDetails(t *testing.T) {
+	ps := NewPortfolioService()
+
+	details := PortfolioDetails{
+		Name:         "  Growth  ",
+		Description:  " Large caps ",
+		BaseCurrency: "eur",
+		StrategyTags: []string{"Value", " long-short", "value"},
+	}
+	assert.NoError(t, ps.NormalizeDetails(&details))
+	assert.Equal(t, PortfolioDetails{
+		Name:         "Growth",
+		Description:  "Large caps",
+		BaseCurrency: "EUR",
+		StrategyTags: []string{"value", "long-short"},
+	}, details)
+
+	for _, invalid := range []PortfolioDetails{
+		{Name: "  "},
+		{Name: strings.Repeat("x", MaxPortfolioNameLength+1)},
+		{Name: "Growth", BaseCurrency: "XYZ"},
+		{Name: "Growth", StrategyTags: []string{"not a tag"}},
+		{Name: "Growth", StrategyTags: strings.Fields("a b c d e f g h i j k")},
+	} {
+		assert.ErrorIs(t, ps.NormalizeDetails(&invalid), ErrInvalidPortfolioDetails)
+	}
+}
+
+func TestApplyDetailsPatch(t *testing.T) {
+	ps := NewPortfolioService()
+	details := PortfolioDetails{Name: "Growth", Description: "Large caps", BaseCurrency: "EUR", StrategyTags: []string{"value"}}
+
+	name, currency := "Income", ""
+	assert.NoError(t, ps.ApplyDetailsPatch(&details, PortfolioPatch{Name: &name, BaseCurrency: &currency, StrategyTags: []string{}}))
+	assert.Equal(t, PortfolioDetails{Name: "Income", Description: "Large caps", StrategyTags: []string{}}, details)
+}
+
+func TestApplyDetailsPatchRejectsAmbiguousBaseCurrency(t *testing.T) {
+	ps := NewPortfolioService()
+	details := PortfolioDetails{Name: "Growth"}
+
+	currency := "GBP"
+	patch := PortfolioPatch{BaseCurrency: &currency, Settings: map[string]json.RawMessage{"base_currency": json.RawMessage(`"EUR"`)}}
+	assert.ErrorIs(t, ps.ApplyDetailsPatch(&details, patch), ErrInvalidPortfolioDetails)
+}
+
+func TestCheckVersion(t *testing.T) {
+	ps := NewPortfolioService()
+	portfolio := &models.Portfolio{ID: 1, Version: 3}
+
+	assert.NoError(t, ps.CheckVersion(portfolio, 0))
+	assert.NoError(t, ps.CheckVersion(portfolio, 3))
+	assert.ErrorIs(t, ps.CheckVersion(portfolio, 2), ErrVersionConflict)
+}
+
+func TestApplyCashTransaction(t *testing.T) {
+	ps := NewPortfolioService()
+	portfolio := &models.Portfolio{ID: 1, Cash: 1000, TotalValue: 1500, MarginUsed: 100}
+
+	entry, err := ps.ApplyCashTransaction(portfolio, models.CashDeposit, 250.5, 0, " payroll ")
+	require.NoError(t, err)
+	assert.Equal(t, 1250.5, entry.BalanceAfter)
+	assert.Equal(t, 1750.5, portfolio.TotalValue)
+	assert.Equal(t, "payroll", entry.Note)
+
+	// 300 of the cash is reserved for a pending buy and 100 by margin
+	assert.Equal(t, 850.5, ps.AvailableCash(portfolio, 300))
+	_, err = ps.ApplyCashTransaction(portfolio, models.CashWithdrawal, 900, 300, "")
+	assert.ErrorIs(t, err, ErrInsufficientCash)
+	assert.Equal(t, 1250.5, portfolio.Cash)
+
+	entry, err = ps.ApplyCashTransaction(portfolio, models.CashTransferOut, 850.5, 300, "")
+	require.NoError(t, err)
+	assert.Equal(t, 400.0, entry.BalanceAfter)
+}
+
+func TestApplyCashTransactionRejectsInvalidAmounts(t *testing.T) {
+	ps := NewPortfolioService()
+	portfolio := &models.Portfolio{ID: 1, Cash: 1000}
+
+	for _, amount := range []float64{0, -5, 10.001, math.NaN(), MaxCashTransactionAmount * 2} {
+		_, err := ps.ApplyCashTransaction(portfolio, models.CashDeposit, amount, 0, "")
+		assert.ErrorIs(t, err, ErrInvalidCashTransaction, "amount %v", amount)
+	}
+	_, err := ps.ApplyCashTransaction(portfolio, "dividend", 10, 0, "")
+	assert.ErrorIs(t, err, ErrInvalidCashTransaction)
+	assert.Equal(t, 1000.0, portfolio.Cash)
+}
+
+// barsFrom builds daily bars from returns, starting at 100 on start
+func barsFrom(symbol string, start time.Time, returns []float64, volume int64) []models.Price {
+	price := 100.0
+	bars := []models.Price{{Symbol: symbol, Close: price, Volume: volume, Timestamp: start}}
+	for i, r := range returns {
+		price *= 1 + r
+		bars = append(bars, models.Price{Symbol: symbol, Close: price, Volume: volume, Timestamp: start.AddDate(0, 0, i+1)})
+	}
+	return bars
+}
+
+func TestCalculateFactorExposure(t *testing.T) {
+	ps := NewPortfolioService()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	market := make([]float64, 100)
+	levered := make([]float64, len(market))
+	for i := range market {
+		market[i] = 0.01 * math.Sin(float64(i))
+		levered[i] = 2 * market[i]
+	}
+	bars := map[string][]models.Price{
+		"SPY":  barsFrom("SPY", start, market, 1000),
+		"AAPL": barsFrom("AAPL", start, levered, 10),
+		"NEW":  barsFrom("NEW", start, levered[:5], 10), // Too little history
+	}
+
+	portfolio := &models.Portfolio{
+		ID:   1,
+		Cash: 5000,
+		Positions: []models.Position{
+			{Symbol: "AAPL", Quantity: 50, Side: "long"},
+			{Symbol: "NEW", Quantity: 10, Side: "long"},
+			{Symbol: "AAPL240119C00150000", Quantity: 1, Multiplier: 100, Side: "long"},
+		},
+	}
+	prices := map[string]float64{"AAPL": 100, "NEW": 100, "AAPL240119C00150000": 10}
+
+	exposure := ps.CalculateFactorExposure(portfolio, prices, bars, "SPY", start)
+	require.Len(t, exposure.Positions, 3)
+
+	// 5,000 cash + 5,000 AAPL + 1,000 NEW + 1,000 of calls on AAPL
+	stock, fresh, option := exposure.Positions[0], exposure.Positions[1], exposure.Positions[2]
+	assert.InDelta(t, 5000.0/12000, stock.Weight, 1e-9)
+	assert.InDelta(t, 2, stock.Beta, 1e-6)
+	assert.Equal(t, 0.0, fresh.Beta)
+	assert.Equal(t, 0.0, fresh.Momentum)
+	assert.InDelta(t, 2, option.Beta, 1e-6)
+
+	// Smaller dollar volume than the benchmark is a negative size exposure
+	assert.Less(t, stock.Size, 0.0)
+	assert.InDelta(t, 2*6000.0/12000, exposure.Beta, 1e-6)
+	assert.Equal(t, "SPY", exposure.Benchmark)
+}
+
+func TestErrorCategories(t *testing.T) {
+	ps := NewPortfolioService()
+	portfolio := &models.Portfolio{Cash: 100}
+
+	err := ps.ValidateTradeOrder(&models.Trade{Symbol: "AAPL", Quantity: 10, Side: "buy"}, portfolio, 50)
+	assert.ErrorIs(t, err, ErrInsufficientCash)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	assert.NotErrorIs(t, err, ErrValidation)
+
+	err = ps.ValidateTradeOrder(&models.Trade{Symbol: "AAPL", Quantity: 0, Side: "buy"}, portfolio, 50)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	// Wrapping keeps the message and both the sentinel and its category
+	err = fmt.Errorf("%w: %d", ErrPortfolioNotFound, 7)
+	assert.EqualError(t, err, "portfolio not found: 7")
+	assert.ErrorIs(t, err, ErrPortfolioNotFound)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	err = Conflictf("competition %q already exists", "Spring")
+	assert.EqualError(t, err, `competition "Spring" already exists`)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
+
+func TestCompileStatement(t *testing.T) {
+	ps := NewPortfolioService()
+	at := func(month time.Month, day, hour int) *time.Time {
+		ts := time.Date(2024, month, day, hour, 0, 0, 0, time.UTC)
+		return &ts
+	}
+	portfolio := &models.Portfolio{ID: 7, UserID: 3}
+	opening := models.PortfolioSnapshot{SnapshotDate: *at(time.February, 29, 0), TotalValue: 10000, CreatedAt: *at(time.February, 29, 22)}
+	closing := models.PortfolioSnapshot{SnapshotDate: *at(time.March, 31, 0), TotalValue: 11000, CreatedAt: *at(time.March, 31, 22)}
+
+	cash := []models.CashTransaction{
+		{Type: models.CashTransferIn, Amount: 100, CreatedAt: *at(time.February, 20, 12)}, // Before the opening snapshot
+		{Type: models.CashDeposit, Amount: 500, CreatedAt: *at(time.March, 3, 12)},
+		{Type: models.CashWithdrawal, Amount: 200, CreatedAt: *at(time.March, 20, 12)},
+	}
+	trades := []models.Trade{
+		{Symbol: "AAPL", Side: "buy", Quantity: 10, Price: 100, Fees: 1, ExecutedAt: at(time.February, 10, 15)},
+		{Symbol: "MSFT", Side: "buy", Quantity: 1, Price: 50, Fees: 0.5, ExecutedAt: at(time.February, 29, 23)},
+		{Symbol: "AAPL", Side: "buy", Quantity: 10, Price: 120, Fees: 2, ExecutedAt: at(time.March, 5, 15)},
+		{Symbol: "AAPL", Side: "sell", Quantity: 5, Price: 130, Fees: 1.5, ExecutedAt: at(time.March, 10, 15)},
+		{Symbol: "AAPL", Side: "sell", Quantity: 15, Price: 90, Fees: 3, ExecutedAt: at(time.April, 1, 15)}, // After the closing snapshot
+	}
+
+	statement := ps.CompileStatement(portfolio, *at(time.March, 15, 0), opening, closing, cash, trades)
+	assert.Equal(t, 7, statement.PortfolioID)
+	assert.Equal(t, 3, statement.UserID)
+	assert.Equal(t, *at(time.March, 1, 0), statement.Month)
+	assert.Equal(t, 500.0, statement.Contributions)
+	assert.Equal(t, 200.0, statement.Withdrawals)
+	assert.Equal(t, 3, statement.TradeCount)
+	assert.InDelta(t, 4.0, statement.Fees, 1e-9)
+	assert.InDelta(t, 100.0, statement.RealizedPnL, 1e-9, "5 sold at 130 against an average cost of 110")
+
+	// The statement reconciles
+	assert.InDelta(t, 604.0, statement.UnrealizedPnL, 1e-9)
+	assert.InDelta(t, statement.EndingValue, statement.StartingValue+statement.Contributions-statement.Withdrawals+
+		statement.RealizedPnL-statement.Fees+statement.UnrealizedPnL, 1e-9)
+}
+
+func TestSimulateBenchmark(t *testing.T) {
+	ps := NewPortfolioService()
+	at := func(day, hour int) time.Time {
+		return time.Date(2024, time.March, day, hour, 0, 0, 0, time.UTC)
+	}
+	executed := func(day, hour int) *time.Time {
+		ts := at(day, hour)
+		return &ts
+	}
+	snapshots := []models.PortfolioSnapshot{
+		{SnapshotDate: at(1, 0), TotalValue: 1000, Cash: 1000, CreatedAt: at(1, 22)},
+		{SnapshotDate: at(2, 0), TotalValue: 1100, CreatedAt: at(2, 22)},
+		{SnapshotDate: at(3, 0), TotalValue: 1600, CreatedAt: at(3, 22)},
+	}
+	closes := []models.Price{
+		{Symbol: "SPY", Close: 100, Timestamp: at(1, 0).AddDate(0, 0, -1)}, // No bar on the first day
+		{Symbol: "SPY", Close: 110, Timestamp: at(2, 0)},
+		{Symbol: "SPY", Close: 121, Timestamp: at(3, 0)},
+	}
+	cash := []models.CashTransaction{
+		{Type: models.CashDeposit, Amount: 500, CreatedAt: at(3, 9)},
+		{Type: models.CashWithdrawal, Amount: 50, CreatedAt: at(3, 23)}, // After the last snapshot
+	}
+	trades := []models.Trade{
+		{Symbol: "AAPL", Side: "buy", Quantity: 1, Price: 100, ExecutedAt: executed(1, 10)}, // Before the first snapshot
+		{Symbol: "AAPL", Side: "buy", Quantity: 5, Price: 100, Fees: 1, ExecutedAt: executed(2, 10)},
+		{Symbol: "AAPL", Side: "sell", Quantity: 2, Price: 110, Fees: 1, ExecutedAt: executed(3, 12)},
+	}
+
+	simulation, err := ps.SimulateBenchmark(4, "SPY", snapshots, cash, trades, closes)
+	require.NoError(t, err)
+	require.Len(t, simulation.Points, 3)
+
+	// The counterfactual starts at the portfolio's value
+	assert.InDelta(t, 1000.0, simulation.Points[0].BenchmarkValue, 1e-9)
+	assert.Equal(t, 100.0, simulation.Points[0].BenchmarkClose)
+	// 500 of SPY bought at 110, with the fee paid from cash
+	assert.InDelta(t, 999.0, simulation.Points[1].BenchmarkValue, 1e-9)
+	// 500 deposited and 220 of SPY sold at 121, less the fee
+	assert.InDelta(t, 1548.0, simulation.Points[2].BenchmarkValue, 1e-9)
+	assert.Equal(t, 1600.0, simulation.Points[2].PortfolioValue)
+
+	assert.Equal(t, 500.0, simulation.Contributions)
+	assert.Zero(t, simulation.Withdrawals)
+	assert.InDelta(t, 52.0, simulation.Difference, 1e-9)
+	assert.Equal(t, at(1, 0), simulation.StartDate)
+	assert.Equal(t, at(3, 0), simulation.EndDate)
+
+	_, err = ps.SimulateBenchmark(4, "SPY", snapshots, cash, trades, closes[1:])
+	assert.ErrorIs(t, err, ErrInsufficientHistory)
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Position alert limits
+const (
+	MaxPositionAlertUpPercent  = 1000
+	MaxPositionAlertNoteLength = 500
+)
+
+// ValidatePositionAlert checks a position alert watches at least one
+// direction, with levels a price can reach
+func (ps *PortfolioService) ValidatePositionAlert(alert *models.PositionAlert) error {
+	if alert.UpPercent == nil && alert.DownPercent == nil {
+		return fmt.Errorf("%w: set up_percent, down_percent or both", ErrInvalidPositionAlert)
+	}
+	if alert.UpPercent != nil && (*alert.UpPercent <= 0 || *alert.UpPercent > MaxPositionAlertUpPercent) {
+		return fmt.Errorf("%w: up_percent %.2f must be in (0, %d]", ErrInvalidPositionAlert, *alert.UpPercent, MaxPositionAlertUpPercent)
+	}
+	if alert.DownPercent != nil && (*alert.DownPercent <= 0 || *alert.DownPercent >= 100) {
+		return fmt.Errorf("%w: down_percent %.2f must be in (0, 100)", ErrInvalidPositionAlert, *alert.DownPercent)
+	}
+	alert.Note = strings.TrimSpace(alert.Note)
+	if utf8.RuneCountInString(alert.Note) > MaxPositionAlertNoteLength {
+		return fmt.Errorf("%w: note is longer than %d characters", ErrInvalidPositionAlert, MaxPositionAlertNoteLength)
+	}
+	return nil
+}
+
+// PositionAlertCrossed returns the direction of the level an active alert's
+// price has reached and the level, or "" when it has reached neither
+func (ps *PortfolioService) PositionAlertCrossed(alert *models.PositionAlert, price float64) (string, float64) {
+	if !alert.IsActive || alert.EntryPrice <= 0 || price <= 0 {
+		return "", 0
+	}
+	// Levels are compared to a tolerance, so a price quoted at one reaches it
+	const epsilon = 1e-9
+	up, down := alert.Levels()
+	switch {
+	case alert.UpPercent != nil && price >= up-epsilon:
+		return models.PositionAlertUp, up
+	case alert.DownPercent != nil && price <= down+epsilon:
+		return models.PositionAlertDown, down
+	}
+	return "", 0
+}
@@ -0,0 +1,303 @@
+package domain
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// ErrInvalidProvisionSpec is returned for provisioning specs that cannot be
+// applied
+var ErrInvalidProvisionSpec = newError(ErrValidation, "invalid provisioning spec")
+
+// Provisioning limits and defaults
+const (
+	MaxProvisionUsers       = 500
+	DefaultProvisionPrefix  = "demo"
+	DefaultProvisionDomain  = "example.com"
+	DefaultProvisionRole    = models.RoleTrader
+	DefaultProvisionName    = "Paper Portfolio"
+	DefaultProvisionCash    = 100000.0
+	maxProvisionUsernameLen = 50
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+	provisionRoles  = map[string]bool{models.RoleTrader: true, models.RoleViewer: true, models.RoleAdmin: true}
+)
+
+// PresetPosition is a long position granted to a provisioned portfolio at
+// its cost price, on top of the starting cash
+type PresetPosition struct {
+	Symbol   string  `json:"symbol"`
+	Quantity int64   `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+// ProvisionUser is one user to create. Omitted fields take the spec's
+// defaults.
+type ProvisionUser struct {
+	Username     string           `json:"username"`
+	Email        string           `json:"email,omitempty"`
+	FullName     string           `json:"full_name,omitempty"`
+	StartingCash *float64         `json:"starting_cash,omitempty"`
+	Positions    []PresetPosition `json:"positions,omitempty"`
+}
+
+// ProvisionSpec describes a batch of users, each with one seeded paper
+// portfolio. Users are listed explicitly or generated as Count users named
+// UsernamePrefix-001, UsernamePrefix-002 and so on.
+type ProvisionSpec struct {
+	Count          int              `json:"count,omitempty"`
+	UsernamePrefix string           `json:"username_prefix,omitempty"`
+	EmailDomain    string           `json:"email_domain,omitempty"`
+	Password       string           `json:"password,omitempty"` // Shared by every user; generated per user when empty
+	Role           string           `json:"role,omitempty"`
+	PortfolioName  string           `json:"portfolio_name,omitempty"`
+	StartingCash   *float64         `json:"starting_cash,omitempty"`
+	Positions      []PresetPosition `json:"positions,omitempty"` // Granted to users that list none
+	Users          []ProvisionUser  `json:"users,omitempty"`
+}
+
+// ProvisionedUser is a created user and the credentials to hand out
+type ProvisionedUser struct {
+	UserID      int    `json:"user_id"`
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	Password    string `json:"password,omitempty"` // Only when generated
+	PortfolioID int    `json:"portfolio_id"`
+}
+
+// ProvisionResult lists the users a spec created
+type ProvisionResult struct {
+	Users []ProvisionedUser `json:"users"`
+}
+
+// ParseProvisionJSON reads a JSON provisioning spec
+func ParseProvisionJSON(r io.Reader) (*ProvisionSpec, error) {
+	var spec ProvisionSpec
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProvisionSpec, err)
+	}
+	return &spec, nil
+}
+
+// ParseProvisionCSV reads users from CSV with a header row. Recognized
+// columns are username (required), email, full_name, starting_cash, symbol,
+// quantity and price. Repeating a username on further rows grants that user
+// more positions. Defaults for the batch come from spec, which may be nil.
+func ParseProvisionCSV(r io.Reader, spec *ProvisionSpec) (*ProvisionSpec, error) {
+	if spec == nil {
+		spec = &ProvisionSpec{}
+	}
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read header: %v", ErrInvalidProvisionSpec, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, fmt.Errorf("%w: missing username column", ErrInvalidProvisionSpec)
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	index := make(map[string]int)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidProvisionSpec, err)
+		}
+
+		username := strings.ToLower(field(record, "username"))
+		if username == "" {
+			return nil, fmt.Errorf("%w: line %d: username is required", ErrInvalidProvisionSpec, line)
+		}
+		i, seen := index[username]
+		if !seen {
+			i = len(spec.Users)
+			index[username] = i
+			spec.Users = append(spec.Users, ProvisionUser{Username: username})
+		}
+		user := &spec.Users[i]
+
+		if email := field(record, "email"); email != "" {
+			user.Email = email
+		}
+		if name := field(record, "full_name"); name != "" {
+			user.FullName = name
+		}
+		if value := field(record, "starting_cash"); value != "" {
+			cash, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: invalid starting_cash %q", ErrInvalidProvisionSpec, line, value)
+			}
+			user.StartingCash = &cash
+		}
+
+		symbol := field(record, "symbol")
+		if symbol == "" {
+			continue
+		}
+		quantity, err := strconv.ParseInt(field(record, "quantity"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: invalid quantity %q", ErrInvalidProvisionSpec, line, field(record, "quantity"))
+		}
+		price, err := strconv.ParseFloat(field(record, "price"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: invalid price %q", ErrInvalidProvisionSpec, line, field(record, "price"))
+		}
+		user.Positions = append(user.Positions, PresetPosition{Symbol: symbol, Quantity: quantity, Price: price})
+	}
+
+	return spec, nil
+}
+
+// ExpandProvisionSpec validates a spec and returns the users it describes
+// with every default applied
+func ExpandProvisionSpec(spec *ProvisionSpec) ([]ProvisionUser, error) {
+	if spec.Role == "" {
+		spec.Role = DefaultProvisionRole
+	}
+	if !provisionRoles[spec.Role] {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidProvisionSpec, spec.Role)
+	}
+	if spec.PortfolioName == "" {
+		spec.PortfolioName = DefaultProvisionName
+	}
+	if spec.EmailDomain == "" {
+		spec.EmailDomain = DefaultProvisionDomain
+	}
+	if spec.Password != "" && len(spec.Password) < 8 {
+		return nil, fmt.Errorf("%w: password must be at least 8 characters", ErrInvalidProvisionSpec)
+	}
+	cash := DefaultProvisionCash
+	if spec.StartingCash != nil {
+		cash = *spec.StartingCash
+	}
+
+	users := spec.Users
+	switch {
+	case len(users) > 0 && spec.Count > 0:
+		return nil, fmt.Errorf("%w: set either count or users, not both", ErrInvalidProvisionSpec)
+	case len(users) == 0:
+		if spec.Count <= 0 {
+			return nil, fmt.Errorf("%w: count must be positive", ErrInvalidProvisionSpec)
+		}
+		prefix := strings.ToLower(spec.UsernamePrefix)
+		if prefix == "" {
+			prefix = DefaultProvisionPrefix
+		}
+		width := len(strconv.Itoa(spec.Count))
+		if width < 3 {
+			width = 3
+		}
+		users = make([]ProvisionUser, spec.Count)
+		for i := range users {
+			users[i].Username = fmt.Sprintf("%s-%0*d", prefix, width, i+1)
+		}
+	}
+	if len(users) > MaxProvisionUsers {
+		return nil, fmt.Errorf("%w: at most %d users per batch", ErrInvalidProvisionSpec, MaxProvisionUsers)
+	}
+
+	expanded := make([]ProvisionUser, len(users))
+	usernames := make(map[string]bool, len(users))
+	emails := make(map[string]bool, len(users))
+	for i, user := range users {
+		user.Username = strings.ToLower(strings.TrimSpace(user.Username))
+		if len(user.Username) > maxProvisionUsernameLen || !usernamePattern.MatchString(user.Username) {
+			return nil, fmt.Errorf("%w: invalid username %q", ErrInvalidProvisionSpec, user.Username)
+		}
+		if usernames[user.Username] {
+			return nil, fmt.Errorf("%w: duplicate username %q", ErrInvalidProvisionSpec, user.Username)
+		}
+		usernames[user.Username] = true
+
+		if user.Email == "" {
+			user.Email = user.Username + "@" + spec.EmailDomain
+		}
+		user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+		if at := strings.Index(user.Email, "@"); at <= 0 || at == len(user.Email)-1 {
+			return nil, fmt.Errorf("%w: invalid email %q", ErrInvalidProvisionSpec, user.Email)
+		}
+		if emails[user.Email] {
+			return nil, fmt.Errorf("%w: duplicate email %q", ErrInvalidProvisionSpec, user.Email)
+		}
+		emails[user.Email] = true
+
+		if user.StartingCash == nil {
+			userCash := cash
+			user.StartingCash = &userCash
+		}
+		if *user.StartingCash < 0 {
+			return nil, fmt.Errorf("%w: starting cash of %s cannot be negative", ErrInvalidProvisionSpec, user.Username)
+		}
+
+		if user.Positions == nil {
+			user.Positions = spec.Positions
+		}
+		positions, err := normalizePresets(user.Positions)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidProvisionSpec, user.Username, err)
+		}
+		user.Positions = positions
+
+		expanded[i] = user
+	}
+	return expanded, nil
+}
+
+// normalizePresets validates preset positions, merging repeated symbols at
+// their average price
+func normalizePresets(presets []PresetPosition) ([]PresetPosition, error) {
+	merged := make([]PresetPosition, 0, len(presets))
+	index := make(map[string]int, len(presets))
+	for _, p := range presets {
+		p.Symbol = symbols.Normalize(p.Symbol)
+		if err := symbols.Validate(p.Symbol); err != nil {
+			return nil, err
+		}
+		if p.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity of %s must be positive", p.Symbol)
+		}
+		if p.Price <= 0 {
+			return nil, fmt.Errorf("price of %s must be positive", p.Symbol)
+		}
+
+		i, ok := index[p.Symbol]
+		if !ok {
+			index[p.Symbol] = len(merged)
+			merged = append(merged, p)
+			continue
+		}
+		total := merged[i].Quantity + p.Quantity
+		merged[i].Price = (merged[i].Price*float64(merged[i].Quantity) + p.Price*float64(p.Quantity)) / float64(total)
+		merged[i].Quantity = total
+	}
+	return merged, nil
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"sort"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// RebalanceOrder is one market order of a rebalance plan
+type RebalanceOrder struct {
+	Symbol   string
+	Side     string
+	Quantity float64
+	Price    float64 // Current price the order is expected to fill at
+}
+
+// PlanRebalance turns the rebalance recommendations for targetAllocations
+// into market orders. Sells come first so their proceeds fund the buys; each
+// side is ordered by symbol. Sells never exceed the shares held, and symbols
+// without a target are left untouched, as in RebalanceRecommendations.
+func (ps *PortfolioService) PlanRebalance(portfolio *models.Portfolio, targetAllocations map[string]float64, currentPrices map[string]float64) []RebalanceOrder {
+	var sells, buys []RebalanceOrder
+	for _, rec := range ps.RebalanceRecommendations(portfolio, targetAllocations, currentPrices) {
+		symbol := rec["symbol"].(string)
+		shares := rec["estimated_shares"].(float64)
+		order := RebalanceOrder{Symbol: symbol, Price: currentPrices[symbol]}
+
+		switch {
+		case shares > 0:
+			order.Side = "buy"
+			order.Quantity = shares
+			buys = append(buys, order)
+		case shares < 0:
+			held := 0.0
+			if position := ps.findPosition(portfolio.Positions, symbol); position != nil {
+				held = position.Quantity
+			}
+			order.Side = "sell"
+			order.Quantity = -shares
+			if order.Quantity > held {
+				order.Quantity = held
+			}
+			if order.Quantity > 0 {
+				sells = append(sells, order)
+			}
+		}
+	}
+
+	sort.Slice(sells, func(i, j int) bool { return sells[i].Symbol < sells[j].Symbol })
+	sort.Slice(buys, func(i, j int) bool { return buys[i].Symbol < buys[j].Symbol })
+	return append(sells, buys...)
+}
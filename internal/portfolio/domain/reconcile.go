@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// ReconcileTolerance decides which differences are benign enough to fix
+// automatically
+type ReconcileTolerance struct {
+	PriceRounding    float64 // Max per-share price difference treated as rounding
+	MaxFeeAdjustment float64 // Max fee difference corrected without review
+}
+
+// DefaultReconcileTolerance allows a cent of price rounding and fee corrections up to $25
+func DefaultReconcileTolerance() ReconcileTolerance {
+	return ReconcileTolerance{PriceRounding: 0.01, MaxFeeAdjustment: 25.00}
+}
+
+// ReconcileInput is one day's internal records and broker statement
+type ReconcileInput struct {
+	Positions          []models.Position
+	Trades             []models.Trade
+	BrokerPositions    []models.BrokerPosition
+	BrokerTransactions []models.BrokerTransaction
+}
+
+// Reconcile diffs internal trades and positions against a broker statement.
+// Trades are matched on client order ID, falling back to symbol, side and
+// quantity. Fee differences and sub-tolerance price differences on matched
+// trades are marked auto_fixed, with the broker's value as the correction;
+// everything else is left open for review.
+func (ps *PortfolioService) Reconcile(input ReconcileInput, tol ReconcileTolerance) []models.ReconciliationBreak {
+	var breaks []models.ReconciliationBreak
+
+	matched := make(map[int]bool) // Broker transaction index -> matched
+	byClientOrderID := make(map[string]int)
+	for i, txn := range input.BrokerTransactions {
+		if txn.ClientOrderID != "" {
+			byClientOrderID[txn.ClientOrderID] = i
+		}
+	}
+
+	for _, trade := range input.Trades {
+		idx, ok := byClientOrderID[strconv.Itoa(trade.ID)]
+		if !ok || matched[idx] {
+			idx, ok = findTransaction(input.BrokerTransactions, matched, trade)
+		}
+		if !ok {
+			breaks = append(breaks, models.ReconciliationBreak{
+				BreakType:     models.BreakMissingBrokerTrade,
+				Symbol:        trade.Symbol,
+				TradeID:       trade.ID,
+				InternalValue: trade.Quantity,
+				Resolution:    models.ResolutionOpen,
+				Message:       fmt.Sprintf("%s %v %s not on broker statement", trade.Side, trade.Quantity, trade.Symbol),
+			})
+			continue
+		}
+		matched[idx] = true
+		breaks = append(breaks, compareTrade(trade, input.BrokerTransactions[idx], tol)...)
+	}
+
+	for i, txn := range input.BrokerTransactions {
+		if matched[i] {
+			continue
+		}
+		breaks = append(breaks, models.ReconciliationBreak{
+			BreakType:   models.BreakMissingInternalTrade,
+			Symbol:      txn.Symbol,
+			BrokerRef:   txn.ID,
+			BrokerValue: txn.Quantity,
+			Resolution:  models.ResolutionOpen,
+			Message:     fmt.Sprintf("Broker %s %v %s has no internal trade", txn.Side, txn.Quantity, txn.Symbol),
+		})
+	}
+
+	return append(breaks, comparePositionQuantities(input.Positions, input.BrokerPositions)...)
+}
+
+// findTransaction returns the first unmatched broker transaction with the
+// trade's symbol, side and quantity
+func findTransaction(txns []models.BrokerTransaction, matched map[int]bool, trade models.Trade) (int, bool) {
+	for i, txn := range txns {
+		if !matched[i] && txn.Symbol == trade.Symbol && txn.Side == trade.Side && RoundQuantity(txn.Quantity) == trade.Quantity {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func compareTrade(trade models.Trade, txn models.BrokerTransaction, tol ReconcileTolerance) []models.ReconciliationBreak {
+	var breaks []models.ReconciliationBreak
+	base := models.ReconciliationBreak{Symbol: trade.Symbol, TradeID: trade.ID, BrokerRef: txn.ID}
+
+	if trade.Quantity != RoundQuantity(txn.Quantity) {
+		b := base
+		b.BreakType = models.BreakQuantityMismatch
+		b.InternalValue = trade.Quantity
+		b.BrokerValue = txn.Quantity
+		b.Resolution = models.ResolutionOpen
+		b.Message = fmt.Sprintf("Trade %d filled %v, broker reports %v", trade.ID, trade.Quantity, txn.Quantity)
+		breaks = append(breaks, b)
+	}
+
+	if priceDiff := math.Abs(trade.Price - txn.Price); priceDiff > 1e-9 {
+		b := base
+		b.InternalValue = trade.Price
+		b.BrokerValue = txn.Price
+		if priceDiff <= tol.PriceRounding+1e-9 {
+			b.BreakType = models.BreakPriceRounding
+			b.Resolution = models.ResolutionAutoFixed
+		} else {
+			b.BreakType = models.BreakPriceMismatch
+			b.Resolution = models.ResolutionOpen
+		}
+		b.Message = fmt.Sprintf("Trade %d price %.4f, broker reports %.4f", trade.ID, trade.Price, txn.Price)
+		breaks = append(breaks, b)
+	}
+
+	if feeDiff := math.Abs(trade.Fees - txn.Fees); feeDiff >= 0.005 {
+		b := base
+		b.BreakType = models.BreakFeeMismatch
+		b.InternalValue = trade.Fees
+		b.BrokerValue = txn.Fees
+		b.Resolution = models.ResolutionOpen
+		if feeDiff <= tol.MaxFeeAdjustment {
+			b.Resolution = models.ResolutionAutoFixed
+		}
+		b.Message = fmt.Sprintf("Trade %d fees %.2f, broker charged %.2f", trade.ID, trade.Fees, txn.Fees)
+		breaks = append(breaks, b)
+	}
+
+	return breaks
+}
+
+// comparePositionQuantities reports symbols whose net quantity differs,
+// sorted by symbol
+func comparePositionQuantities(positions []models.Position, brokerPositions []models.BrokerPosition) []models.ReconciliationBreak {
+	internal := make(map[string]float64)
+	for _, position := range positions {
+		internal[position.Symbol] += position.Quantity
+	}
+	broker := make(map[string]float64)
+	for _, position := range brokerPositions {
+		broker[position.Symbol] += position.Quantity
+	}
+
+	symbols := make([]string, 0, len(internal)+len(broker))
+	for symbol := range internal {
+		symbols = append(symbols, symbol)
+	}
+	for symbol := range broker {
+		if _, ok := internal[symbol]; !ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+
+	var breaks []models.ReconciliationBreak
+	for _, symbol := range symbols {
+		if RoundQuantity(internal[symbol]) == RoundQuantity(broker[symbol]) {
+			continue
+		}
+		breaks = append(breaks, models.ReconciliationBreak{
+			BreakType:     models.BreakPositionMismatch,
+			Symbol:        symbol,
+			InternalValue: internal[symbol],
+			BrokerValue:   broker[symbol],
+			Resolution:    models.ResolutionOpen,
+			Message:       fmt.Sprintf("%s position is %v internally, %v at broker", symbol, internal[symbol], broker[symbol]),
+		})
+	}
+	return breaks
+}
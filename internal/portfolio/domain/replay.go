@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// ReplayTradeEvents rebuilds positions by applying filled trade events in
+// order, using the same weighted-average cost rules as ExecuteTradeOrder.
+// Events other than filled are ignored. Positions are returned sorted by
+// symbol; fully closed positions are omitted.
+func (ps *PortfolioService) ReplayTradeEvents(events []models.TradeEvent) ([]models.Position, error) {
+	positions := make(map[string]*models.Position)
+
+	for _, event := range events {
+		if event.EventType != models.TradeEventFilled {
+			continue
+		}
+
+		pos, exists := positions[event.Symbol]
+		switch event.Side {
+		case "buy":
+			if !exists {
+				positions[event.Symbol] = &models.Position{
+					UserID:       event.UserID,
+					PortfolioID:  event.PortfolioID,
+					Symbol:       event.Symbol,
+					AssetType:    AssetType(event.Symbol),
+					Quantity:     event.Quantity,
+					Multiplier:   Multiplier(event.Symbol),
+					Side:         "long",
+					EntryPrice:   event.Price,
+					CurrentPrice: event.Price,
+					CreatedAt:    event.CreatedAt,
+					UpdatedAt:    event.CreatedAt,
+				}
+				continue
+			}
+
+			totalCost := pos.EntryPrice*pos.Quantity + event.Price*event.Quantity
+			pos.Quantity = RoundQuantity(pos.Quantity + event.Quantity)
+			pos.EntryPrice = totalCost / pos.Quantity
+		case "sell":
+			if !exists || pos.Quantity < event.Quantity {
+				return nil, fmt.Errorf("event %d sells %v %s but only %v held", event.ID, event.Quantity, event.Symbol, heldQuantity(pos))
+			}
+
+			pos.RealizedPnL += (event.Price - pos.EntryPrice) * event.Quantity * Multiplier(event.Symbol)
+			pos.Quantity = RoundQuantity(pos.Quantity - event.Quantity)
+			if pos.Quantity == 0 {
+				delete(positions, event.Symbol)
+				continue
+			}
+		default:
+			return nil, fmt.Errorf("event %d has invalid side: %s", event.ID, event.Side)
+		}
+
+		pos = positions[event.Symbol]
+		pos.CurrentPrice = event.Price
+		pos.UnrealizedPnL = pos.Value(event.Price - pos.EntryPrice)
+		pos.UpdatedAt = event.CreatedAt
+	}
+
+	result := make([]models.Position, 0, len(positions))
+	for _, pos := range positions {
+		result = append(result, *pos)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Symbol < result[j].Symbol })
+
+	return result, nil
+}
+
+func heldQuantity(pos *models.Position) float64 {
+	if pos == nil {
+		return 0
+	}
+	return pos.Quantity
+}
@@ -0,0 +1,300 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// DefaultFees is the commission charged when neither a portfolio, its owner
+// nor its execution venue sets a fee schedule: 0.1% of trade value, $1
+// minimum
+var DefaultFees = models.FeeSchedule{Rate: 0.001, Minimum: 1.0}
+
+// DefaultCryptoFees is the fee charged on crypto trades when neither a
+// portfolio nor its owner sets crypto_fee_schedule: 0.25% of trade value,
+// without a minimum, since fractional trades can be small
+var DefaultCryptoFees = models.FeeSchedule{Rate: 0.0025}
+
+// Settings defaults for options no level sets
+const (
+	DefaultSettingsBenchmark    = "SPY"
+	DefaultSettingsBaseCurrency = "USD"
+	DefaultSettingsStrategy     = models.StrategyManual
+	DefaultSettingsCostBasis    = models.CostBasisFIFO
+	DefaultSettingsOrderType    = "market"
+	DefaultSettingsRisk         = models.RiskAggressive
+
+	// MaxCommissionRate caps a fee schedule's rate at 5% of trade value
+	MaxCommissionRate = 0.05
+)
+
+// SupportedCurrencies are the base currencies a portfolio can report in
+var SupportedCurrencies = map[string]bool{"USD": true, "EUR": true, "GBP": true, "CAD": true, "JPY": true, "CHF": true}
+
+// RiskToleranceLimits are the most percent of a portfolio's value one
+// position may reach, by risk tolerance. Aggressive portfolios are uncapped.
+var RiskToleranceLimits = map[string]float64{
+	models.RiskConservative: 10,
+	models.RiskModerate:     25,
+	models.RiskAggressive:   0,
+}
+
+// NotificationTypes are the notifications about a portfolio its owner can
+// turn off. All are sent by default.
+var NotificationTypes = []string{
+	"account_statement",
+	"allocation_drift",
+	"benchmark_lagging",
+	"option_expired",
+	"order_slippage_rejected",
+	"position_alert",
+	"reconciliation_break",
+	"stop_loss_triggered",
+}
+
+// ResolveSettings applies a portfolio's overrides over its owner's, and the
+// owner's over the defaults. Sources records the level each option came from.
+func (ps *PortfolioService) ResolveSettings(portfolioID int, portfolio, user models.SettingsOverrides) models.PortfolioSettings {
+	settings := models.PortfolioSettings{
+		PortfolioID:       portfolioID,
+		CommissionRate:    DefaultFees.Rate,
+		MinCommission:     DefaultFees.Minimum,
+		Benchmark:         DefaultSettingsBenchmark,
+		BaseCurrency:      DefaultSettingsBaseCurrency,
+		DRIPEnabled:       false,
+		AutoTradeEnabled:  false,
+		Strategy:          DefaultSettingsStrategy,
+		CryptoFeeSchedule: DefaultCryptoFees,
+		CostBasisMethod:   DefaultSettingsCostBasis,
+		DefaultOrderType:  DefaultSettingsOrderType,
+		RiskTolerance:     DefaultSettingsRisk,
+		AutoRebalance:     false,
+		Notifications:     map[string]bool{},
+		Sources:           map[string]string{},
+	}
+	for _, notificationType := range NotificationTypes {
+		settings.Notifications[notificationType] = true
+	}
+	for _, name := range settingNames {
+		settings.Sources[name] = models.SettingsLevelDefault
+	}
+
+	for _, level := range []struct {
+		name      string
+		overrides models.SettingsOverrides
+	}{{models.SettingsLevelUser, user}, {models.SettingsLevelPortfolio, portfolio}} {
+		o := level.overrides
+		if o.CommissionRate != nil {
+			settings.CommissionRate = *o.CommissionRate
+			settings.Sources["commission_rate"] = level.name
+		}
+		if o.MinCommission != nil {
+			settings.MinCommission = *o.MinCommission
+			settings.Sources["min_commission"] = level.name
+		}
+		if o.Benchmark != nil {
+			settings.Benchmark = *o.Benchmark
+			settings.Sources["benchmark"] = level.name
+		}
+		if o.BaseCurrency != nil {
+			settings.BaseCurrency = *o.BaseCurrency
+			settings.Sources["base_currency"] = level.name
+		}
+		if o.DRIPEnabled != nil {
+			settings.DRIPEnabled = *o.DRIPEnabled
+			settings.Sources["drip_enabled"] = level.name
+		}
+		if o.AutoTradeEnabled != nil {
+			settings.AutoTradeEnabled = *o.AutoTradeEnabled
+			settings.Sources["auto_trade_enabled"] = level.name
+		}
+		if o.Strategy != nil {
+			settings.Strategy = *o.Strategy
+			settings.Sources["strategy"] = level.name
+		}
+		if o.FeeSchedule != nil {
+			settings.FeeSchedule = o.FeeSchedule
+			settings.Sources["fee_schedule"] = level.name
+		}
+		if o.CryptoFeeSchedule != nil {
+			settings.CryptoFeeSchedule = *o.CryptoFeeSchedule
+			settings.Sources["crypto_fee_schedule"] = level.name
+		}
+		if o.CostBasisMethod != nil {
+			settings.CostBasisMethod = *o.CostBasisMethod
+			settings.Sources["cost_basis_method"] = level.name
+		}
+		if o.DefaultOrderType != nil {
+			settings.DefaultOrderType = *o.DefaultOrderType
+			settings.Sources["default_order_type"] = level.name
+		}
+		if o.RiskTolerance != nil {
+			settings.RiskTolerance = *o.RiskTolerance
+			settings.Sources["risk_tolerance"] = level.name
+		}
+		if o.AutoRebalance != nil {
+			settings.AutoRebalance = *o.AutoRebalance
+			settings.Sources["auto_rebalance"] = level.name
+		}
+		// Notification types are inherited one by one
+		if len(o.Notifications) > 0 {
+			for notificationType, on := range o.Notifications {
+				settings.Notifications[notificationType] = on
+			}
+			settings.Sources["notifications"] = level.name
+		}
+	}
+
+	return settings
+}
+
+// ValidateSettings checks each effective option and the combinations that
+// cannot work together
+func (ps *PortfolioService) ValidateSettings(settings models.PortfolioSettings) error {
+	if settings.CommissionRate < 0 || settings.CommissionRate > MaxCommissionRate {
+		return fmt.Errorf("%w: commission_rate %.4f must be in [0, %.2f]", ErrInvalidSettings, settings.CommissionRate, MaxCommissionRate)
+	}
+	if settings.MinCommission < 0 {
+		return fmt.Errorf("%w: min_commission %.2f must not be negative", ErrInvalidSettings, settings.MinCommission)
+	}
+	if settings.FeeSchedule != nil {
+		if err := ps.ValidateFeeSchedule(*settings.FeeSchedule); err != nil {
+			return err
+		}
+	}
+	if err := ps.validateFeeSchedule("crypto_fee_schedule", settings.CryptoFeeSchedule); err != nil {
+		return err
+	}
+	if settings.Benchmark == "" {
+		return fmt.Errorf("%w: no benchmark", ErrInvalidSettings)
+	}
+	if !SupportedCurrencies[settings.BaseCurrency] {
+		return fmt.Errorf("%w: unsupported base_currency %q", ErrInvalidSettings, settings.BaseCurrency)
+	}
+
+	switch settings.Strategy {
+	case models.StrategyManual, models.StrategyMomentum, models.StrategyAllocation:
+	default:
+		return fmt.Errorf("%w: unknown strategy %q", ErrInvalidSettings, settings.Strategy)
+	}
+	if settings.AutoTradeEnabled && settings.Strategy == models.StrategyManual {
+		return fmt.Errorf("%w: auto_trade_enabled requires a strategy other than %s", ErrInvalidSettings, models.StrategyManual)
+	}
+
+	switch settings.CostBasisMethod {
+	case models.CostBasisFIFO, models.CostBasisLIFO, models.CostBasisHIFO:
+	default:
+		return fmt.Errorf("%w: unknown cost_basis_method %q", ErrInvalidSettings, settings.CostBasisMethod)
+	}
+	if settings.DefaultOrderType != "market" && settings.DefaultOrderType != "limit" {
+		return fmt.Errorf("%w: default_order_type %q must be market or limit", ErrInvalidSettings, settings.DefaultOrderType)
+	}
+	if _, ok := RiskToleranceLimits[settings.RiskTolerance]; !ok {
+		return fmt.Errorf("%w: unknown risk_tolerance %q", ErrInvalidSettings, settings.RiskTolerance)
+	}
+	for notificationType := range settings.Notifications {
+		if !contains(NotificationTypes, notificationType) {
+			return fmt.Errorf("%w: unknown notification type %q", ErrInvalidSettings, notificationType)
+		}
+	}
+	return nil
+}
+
+// ApplySettingsPatch merges a JSON merge patch into overrides: options set
+// to null are cleared, so they are inherited again, and options not in the
+// patch are left as they are
+func (ps *PortfolioService) ApplySettingsPatch(overrides *models.SettingsOverrides, patch map[string]json.RawMessage) error {
+	fields := map[string]interface{}{
+		"commission_rate":     &overrides.CommissionRate,
+		"min_commission":      &overrides.MinCommission,
+		"benchmark":           &overrides.Benchmark,
+		"base_currency":       &overrides.BaseCurrency,
+		"drip_enabled":        &overrides.DRIPEnabled,
+		"auto_trade_enabled":  &overrides.AutoTradeEnabled,
+		"strategy":            &overrides.Strategy,
+		"fee_schedule":        &overrides.FeeSchedule,
+		"crypto_fee_schedule": &overrides.CryptoFeeSchedule,
+		"cost_basis_method":   &overrides.CostBasisMethod,
+		"default_order_type":  &overrides.DefaultOrderType,
+		"risk_tolerance":      &overrides.RiskTolerance,
+		"auto_rebalance":      &overrides.AutoRebalance,
+	}
+
+	names := make([]string, 0, len(patch))
+	for name := range patch {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "notifications" {
+			if err := mergeNotifications(overrides, patch[name]); err != nil {
+				return err
+			}
+			continue
+		}
+		field, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("%w: unknown option %q", ErrInvalidSettings, name)
+		}
+		// null unmarshals into a pointer by setting it to nil
+		if err := json.Unmarshal(patch[name], field); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidSettings, name, err)
+		}
+	}
+
+	if overrides.BaseCurrency != nil {
+		currency := strings.ToUpper(*overrides.BaseCurrency)
+		overrides.BaseCurrency = &currency
+	}
+	overrides.CostBasisMethod = lowerOption(overrides.CostBasisMethod)
+	overrides.DefaultOrderType = lowerOption(overrides.DefaultOrderType)
+	overrides.RiskTolerance = lowerOption(overrides.RiskTolerance)
+	return nil
+}
+
+func lowerOption(option *string) *string {
+	if option == nil {
+		return nil
+	}
+	value := strings.ToLower(*option)
+	return &value
+}
+
+// mergeNotifications merges a patch of notification types into overrides,
+// itself a merge patch: types set to null are inherited again, and null
+// clears them all
+func mergeNotifications(overrides *models.SettingsOverrides, patch json.RawMessage) error {
+	var types map[string]*bool
+	if err := json.Unmarshal(patch, &types); err != nil {
+		return fmt.Errorf("%w: notifications: %v", ErrInvalidSettings, err)
+	}
+	if types == nil {
+		overrides.Notifications = nil
+		return nil
+	}
+
+	merged := make(map[string]bool, len(overrides.Notifications)+len(types))
+	for notificationType, on := range overrides.Notifications {
+		merged[notificationType] = on
+	}
+	for notificationType, on := range types {
+		if on == nil {
+			delete(merged, notificationType)
+		} else {
+			merged[notificationType] = *on
+		}
+	}
+	overrides.Notifications = merged
+	if len(merged) == 0 {
+		overrides.Notifications = nil
+	}
+	return nil
+}
+
+var settingNames = []string{"commission_rate", "min_commission", "benchmark", "base_currency", "drip_enabled", "auto_trade_enabled", "strategy", "fee_schedule", "crypto_fee_schedule",
+	"cost_basis_method", "default_order_type", "risk_tolerance", "auto_rebalance", "notifications"}
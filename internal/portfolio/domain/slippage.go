@@ -0,0 +1,27 @@
+package domain
+
+import "fmt"
+
+// Slippage returns how far, in percent of quotedPrice, currentPrice has moved
+// against an order: up for buys, down for sells. Favorable moves are negative.
+func Slippage(side string, quotedPrice, currentPrice float64) float64 {
+	if quotedPrice <= 0 {
+		return 0
+	}
+	move := (currentPrice - quotedPrice) / quotedPrice * 100
+	if side == "sell" {
+		return -move
+	}
+	return move
+}
+
+// CheckSlippage rejects an order whose price has moved against it by more
+// than maxSlippage percent since it was quoted
+func (ps *PortfolioService) CheckSlippage(side string, quotedPrice, currentPrice, maxSlippage float64) error {
+	slippage := Slippage(side, quotedPrice, currentPrice)
+	if slippage > maxSlippage {
+		return fmt.Errorf("%w: quoted %.2f, now %.2f (%.2f%% > %.2f%%)",
+			ErrSlippageExceeded, quotedPrice, currentPrice, slippage, maxSlippage)
+	}
+	return nil
+}
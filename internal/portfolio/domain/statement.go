@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// StatementMonth returns the first day (UTC) of the month containing t
+func StatementMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// CompileStatement summarizes a portfolio's month between its opening and
+// closing snapshots. Cash transactions and trades count when made from the
+// opening snapshot until the closing one; trades must be the portfolio's
+// filled trades up to the closing snapshot, oldest first, as earlier ones
+// set the cost of positions sold during the month. Realized PnL uses the
+// same weighted-average cost as ExecuteTradeOrder, and unrealized PnL is the
+// change in value the cash flows, realized PnL and fees leave unexplained.
+func (ps *PortfolioService) CompileStatement(portfolio *models.Portfolio, month time.Time, opening, closing models.PortfolioSnapshot, cash []models.CashTransaction, trades []models.Trade) models.AccountStatement {
+	statement := models.AccountStatement{
+		PortfolioID:   portfolio.ID,
+		UserID:        portfolio.UserID,
+		Month:         StatementMonth(month),
+		StartDate:     opening.SnapshotDate,
+		EndDate:       closing.SnapshotDate,
+		StartingValue: opening.TotalValue,
+		EndingValue:   closing.TotalValue,
+	}
+	during := func(t time.Time) bool {
+		return !t.Before(opening.CreatedAt) && t.Before(closing.CreatedAt)
+	}
+
+	for _, entry := range cash {
+		if !during(entry.CreatedAt) {
+			continue
+		}
+		switch entry.Type {
+		case models.CashDeposit, models.CashTransferIn:
+			statement.Contributions += entry.Amount
+		case models.CashWithdrawal, models.CashTransferOut:
+			statement.Withdrawals += entry.Amount
+		}
+	}
+
+	// Quantity held and its total cost, by symbol
+	type holding struct{ quantity, cost float64 }
+	holdings := make(map[string]*holding)
+	for i := range trades {
+		trade := &trades[i]
+		if trade.ExecutedAt == nil || !trade.ExecutedAt.Before(closing.CreatedAt) {
+			continue
+		}
+		inMonth := during(*trade.ExecutedAt)
+		h, ok := holdings[trade.Symbol]
+		if !ok {
+			h = &holding{}
+			holdings[trade.Symbol] = h
+		}
+
+		switch trade.Side {
+		case "buy":
+			h.quantity = RoundQuantity(h.quantity + trade.Quantity)
+			h.cost += trade.Value(trade.Price)
+		case "sell":
+			// Quantity beyond what the trades bought (positions predating
+			// the history) has no known cost and realizes nothing
+			sold := math.Min(trade.Quantity, h.quantity)
+			if sold > 0 {
+				basis := h.cost * sold / h.quantity
+				if inMonth {
+					statement.RealizedPnL += trade.Value(trade.Price)*sold/trade.Quantity - basis
+				}
+				h.quantity = RoundQuantity(h.quantity - sold)
+				h.cost -= basis
+				if h.quantity == 0 {
+					h.cost = 0
+				}
+			}
+		}
+
+		if inMonth {
+			statement.TradeCount++
+			statement.Fees += trade.Fees
+		}
+	}
+
+	statement.UnrealizedPnL = statement.EndingValue - statement.StartingValue -
+		(statement.Contributions - statement.Withdrawals) - statement.RealizedPnL + statement.Fees
+	return statement
+}
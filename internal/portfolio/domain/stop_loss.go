@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"fmt"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// ValidateStopLoss checks a stop-loss for a position. A stop is either a
+// percentage below the entry price or an absolute price, and only long
+// positions can have one, since trades can only close those.
+func (ps *PortfolioService) ValidateStopLoss(position *models.Position, percent, price *float64) error {
+	if percent == nil && price == nil {
+		return nil
+	}
+	if percent != nil && price != nil {
+		return fmt.Errorf("%w: set either a percentage or a price, not both", ErrInvalidStopLoss)
+	}
+	if position.Side != "long" {
+		return fmt.Errorf("%w: %s position in %s", ErrInvalidStopLoss, position.Side, position.Symbol)
+	}
+	if percent != nil && (*percent <= 0 || *percent >= 100) {
+		return fmt.Errorf("%w: percentage %.2f must be in (0, 100)", ErrInvalidStopLoss, *percent)
+	}
+	if price != nil && *price <= 0 {
+		return fmt.Errorf("%w: price %.4f must be positive", ErrInvalidStopLoss, *price)
+	}
+	return nil
+}
+
+// StopLossOrder returns the market order that closes a position whose
+// stop-loss is hit at price, or nil when it isn't
+func (ps *PortfolioService) StopLossOrder(position *models.Position, price float64) *models.Trade {
+	trigger, ok := position.StopLossTrigger()
+	if !ok || position.Side != "long" || position.Quantity <= 0 || price <= 0 || price > trigger {
+		return nil
+	}
+	return &models.Trade{
+		UserID:        position.UserID,
+		PortfolioID:   position.PortfolioID,
+		PositionID:    position.ID,
+		Symbol:        position.Symbol,
+		Quantity:      position.Quantity,
+		Side:          "sell",
+		Type:          "market",
+		Status:        "pending",
+		TriggerReason: models.TradeTriggerStopLoss,
+	}
+}
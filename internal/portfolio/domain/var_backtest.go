@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"hedge-fund/pkg/shared/cashflow"
+	"hedge-fund/pkg/shared/models"
+)
+
+// VaR backtesting limits
+const (
+	MinVaRLookback             = 20 // Returns a historical VaR forecast needs
+	MinVaRBacktestObservations = 30 // Forecasts a backtest needs to be meaningful
+
+	// VaRSignificance is the test size below which a p-value rejects the model
+	VaRSignificance = 0.05
+)
+
+// HistoricalVaR is the loss, as a positive fraction of value, that returns
+// exceeded with probability 1-confidence
+func HistoricalVaR(returns []float64, confidence float64) float64 {
+	if len(returns) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), returns...)
+	sort.Float64s(sorted)
+
+	index := int(math.Floor((1 - confidence) * float64(len(sorted))))
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return math.Max(0, -sorted[index])
+}
+
+// BacktestVaR forecasts a historical-simulation VaR for each day from the
+// lookback returns before it and counts the days whose loss exceeded the
+// forecast. Returns are net of the cash transactions made between the
+// snapshots, so moving cash is neither a loss nor a gain. Snapshots and
+// transactions must be oldest first; the last window of the snapshots are
+// tested.
+func (ps *PortfolioService) BacktestVaR(snapshots []models.PortfolioSnapshot, cash []models.CashTransaction, confidence float64, lookback, window int) (models.VaRBacktest, error) {
+	if confidence <= 0 || confidence >= 1 {
+		return models.VaRBacktest{}, fmt.Errorf("%w: confidence %.4f must be in (0, 1)", ErrInvalidVaRBacktest, confidence)
+	}
+	if lookback < MinVaRLookback {
+		return models.VaRBacktest{}, fmt.Errorf("%w: lookback of %d days must be at least %d", ErrInvalidVaRBacktest, lookback, MinVaRLookback)
+	}
+
+	daily, err := cashflow.Returns(snapshots, cash)
+	if err != nil {
+		return models.VaRBacktest{}, fmt.Errorf("%w: %v", ErrInsufficientHistory, err)
+	}
+	// returns[i] is day i's return; the first day has none
+	returns := append([]float64{0}, daily...)
+
+	// Day i can be forecast once lookback returns (days 1..i-1) precede it
+	first := lookback + 1
+	if window > 0 && len(snapshots)-window > first {
+		first = len(snapshots) - window
+	}
+	observations := len(snapshots) - first
+	if observations < MinVaRBacktestObservations {
+		return models.VaRBacktest{}, fmt.Errorf("%w: %d VaR forecasts to test, need %d", ErrInsufficientHistory, max(observations, 0), MinVaRBacktestObservations)
+	}
+
+	result := models.VaRBacktest{
+		PortfolioID:        snapshots[0].PortfolioID,
+		Confidence:         confidence,
+		LookbackDays:       lookback,
+		StartDate:          snapshots[first].SnapshotDate,
+		EndDate:            snapshots[len(snapshots)-1].SnapshotDate,
+		Observations:       observations,
+		ExpectedExceptions: float64(observations) * (1 - confidence),
+		ExceptionDates:     []time.Time{},
+	}
+	hits := make([]bool, 0, observations)
+	for i := first; i < len(snapshots); i++ {
+		hit := -returns[i] > HistoricalVaR(returns[i-lookback:i], confidence)
+		if hit {
+			result.Exceptions++
+			result.ExceptionDates = append(result.ExceptionDates, snapshots[i].SnapshotDate)
+		}
+		hits = append(hits, hit)
+	}
+
+	result.KupiecLR = KupiecLR(observations, result.Exceptions, 1-confidence)
+	result.KupiecPValue = chiSquaredSurvival(result.KupiecLR, 1)
+	result.IndependenceLR = ChristoffersenLR(hits)
+	result.IndependencePValue = chiSquaredSurvival(result.IndependenceLR, 1)
+	result.ConditionalCoverageLR = result.KupiecLR + result.IndependenceLR
+	result.ConditionalCoveragePValue = chiSquaredSurvival(result.ConditionalCoverageLR, 2)
+	result.Adequate = result.KupiecPValue >= VaRSignificance && result.IndependencePValue >= VaRSignificance
+	return result, nil
+}
+
+// KupiecLR is the likelihood ratio of Kupiec's proportion of failures test:
+// whether exceptions out of observations is consistent with an exception
+// probability p. It is chi-squared with one degree of freedom.
+func KupiecLR(observations, exceptions int, p float64) float64 {
+	n, x := float64(observations), float64(exceptions)
+	if observations == 0 {
+		return 0
+	}
+	observed := x / n
+	lr := -2 * (xlogy(n-x, 1-p) + xlogy(x, p) - xlogy(n-x, 1-observed) - xlogy(x, observed))
+	return math.Max(0, lr)
+}
+
+// ChristoffersenLR is the likelihood ratio of Christoffersen's independence
+// test: whether an exception makes one the next day more likely. It is
+// chi-squared with one degree of freedom.
+func ChristoffersenLR(hits []bool) float64 {
+	var n00, n01, n10, n11 float64
+	for i := 1; i < len(hits); i++ {
+		switch {
+		case !hits[i-1] && !hits[i]:
+			n00++
+		case !hits[i-1] && hits[i]:
+			n01++
+		case hits[i-1] && !hits[i]:
+			n10++
+		default:
+			n11++
+		}
+	}
+	if n00+n01 == 0 || n10+n11 == 0 {
+		return 0 // No transitions out of one of the states to compare
+	}
+
+	pi0 := n01 / (n00 + n01)
+	pi1 := n11 / (n10 + n11)
+	pi := (n01 + n11) / (n00 + n01 + n10 + n11)
+	restricted := xlogy(n00+n10, 1-pi) + xlogy(n01+n11, pi)
+	unrestricted := xlogy(n00, 1-pi0) + xlogy(n01, pi0) + xlogy(n10, 1-pi1) + xlogy(n11, pi1)
+	return math.Max(0, -2*(restricted-unrestricted))
+}
+
+// xlogy is x*log(y), taken as 0 when x is 0
+func xlogy(x, y float64) float64 {
+	if x == 0 {
+		return 0
+	}
+	return x * math.Log(y)
+}
+
+// chiSquaredSurvival is P(X > x) for X chi-squared with 1 or 2 degrees of
+// freedom, the only ones the VaR tests need
+func chiSquaredSurvival(x float64, degrees int) float64 {
+	if x <= 0 {
+		return 1
+	}
+	if degrees == 1 {
+		return math.Erfc(math.Sqrt(x / 2))
+	}
+	return math.Exp(-x / 2)
+}
@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hedge-fund/internal/portfolio/repository"
+	"hedge-fund/internal/portfolio/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AllocationHandler struct {
+	service *service.AllocationService
+	logger  *zap.Logger
+}
+
+func NewAllocationHandler(service *service.AllocationService, logger *zap.Logger) *AllocationHandler {
+	return &AllocationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateAllocationModel godoc
+// @Summary Create an allocation model
+// @Description Save a named target allocation by symbol or sector that the portfolio's drift is checked against daily
+// @Tags allocation
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param request body AllocationModelRequest true "Allocation model"
+// @Success 201 {object} AllocationModelResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/allocation-models [post]
+func (h *AllocationHandler) CreateAllocationModel(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	var req AllocationModelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	model := req.toModel()
+	model.PortfolioID = portfolioID
+	if err := h.service.CreateModel(c.Request.Context(), model); err != nil {
+		writeError(c, h.logger, err, "Failed to create allocation model")
+		return
+	}
+
+	c.JSON(http.StatusCreated, toAllocationModelResponse(model))
+}
+
+// ListAllocationModels godoc
+// @Summary List allocation models
+// @Description Get a portfolio's allocation models by name
+// @Tags allocation
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Success 200 {array} AllocationModelResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/allocation-models [get]
+func (h *AllocationHandler) ListAllocationModels(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	allocationModels, err := h.service.GetModels(c.Request.Context(), portfolioID)
+	if err != nil {
+		h.logger.Error("Failed to list allocation models", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to list allocation models", Details: err.Error()})
+		return
+	}
+
+	response := make([]AllocationModelResponse, len(allocationModels))
+	for i := range allocationModels {
+		response[i] = toAllocationModelResponse(&allocationModels[i])
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAllocationModel godoc
+// @Summary Get an allocation model
+// @Tags allocation
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param model_id path int true "Allocation model ID"
+// @Success 200 {object} AllocationModelResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/allocation-models/{model_id} [get]
+func (h *AllocationHandler) GetAllocationModel(c *gin.Context) {
+	model, ok := h.loadModel(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, toAllocationModelResponse(model))
+}
+
+// UpdateAllocationModel godoc
+// @Summary Update an allocation model
+// @Description Replace an allocation model's name, targets, drift threshold and active state
+// @Tags allocation
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param model_id path int true "Allocation model ID"
+// @Param request body AllocationModelRequest true "Allocation model"
+// @Success 200 {object} AllocationModelResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/allocation-models/{model_id} [put]
+func (h *AllocationHandler) UpdateAllocationModel(c *gin.Context) {
+	existing, ok := h.loadModel(c)
+	if !ok {
+		return
+	}
+
+	var req AllocationModelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	model := req.toModel()
+	model.ID = existing.ID
+	model.PortfolioID = existing.PortfolioID
+	model.CreatedAt = existing.CreatedAt
+	if err := h.service.UpdateModel(c.Request.Context(), model); err != nil {
+		writeError(c, h.logger, err, "Failed to update allocation model")
+		return
+	}
+
+	c.JSON(http.StatusOK, toAllocationModelResponse(model))
+}
+
+// DeleteAllocationModel godoc
+// @Summary Delete an allocation model
+// @Description Delete an allocation model and its drift history
+// @Tags allocation
+// @Param id path int true "Portfolio ID"
+// @Param model_id path int true "Allocation model ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/allocation-models/{model_id} [delete]
+func (h *AllocationHandler) DeleteAllocationModel(c *gin.Context) {
+	model, ok := h.loadModel(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteModel(c.Request.Context(), model.ID); err != nil {
+		writeError(c, h.logger, err, "Failed to delete allocation model")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CheckDrift godoc
+// @Summary Check allocation drift
+// @Description Measure the portfolio's drift from an allocation model at current prices, record it and alert when it exceeds the model's threshold
+// @Tags allocation
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param model_id path int true "Allocation model ID"
+// @Success 200 {object} DriftCheckResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/allocation-models/{model_id}/drift [post]
+func (h *AllocationHandler) CheckDrift(c *gin.Context) {
+	model, ok := h.loadModel(c)
+	if !ok {
+		return
+	}
+
+	check, err := h.service.CheckDrift(c.Request.Context(), model.ID)
+	if err != nil {
+		h.logger.Error("Failed to check allocation drift", zap.Error(err), zap.Int("model_id", model.ID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to check allocation drift", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toDriftCheckResponse(check))
+}
+
+// ListDriftChecks godoc
+// @Summary List drift checks
+// @Description Get a page of an allocation model's recorded drift checks, newest first by default
+// @Tags allocation
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param model_id path int true "Allocation model ID"
+// @Param limit query int false "Limit" default(30)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field" Enums(checked_at, max_drift) default(checked_at)
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Success 200 {object} pagination.Page{data=[]DriftCheckResponse}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/allocation-models/{model_id}/drift [get]
+func (h *AllocationHandler) ListDriftChecks(c *gin.Context) {
+	model, ok := h.loadModel(c)
+	if !ok {
+		return
+	}
+
+	page, ok := parsePage(c, repository.DriftCheckSorts, 30)
+	if !ok {
+		return
+	}
+
+	checks, result, err := h.service.GetDriftChecks(c.Request.Context(), model.ID, page)
+	if err != nil {
+		h.logger.Error("Failed to list drift checks", zap.Error(err), zap.Int("model_id", model.ID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to list drift checks", Details: err.Error()})
+		return
+	}
+
+	response := make([]DriftCheckResponse, len(checks))
+	for i := range checks {
+		response[i] = toDriftCheckResponse(&checks[i])
+	}
+
+	c.JSON(http.StatusOK, result.Page(response))
+}
+
+// loadModel resolves the model in the path, responding with an error unless
+// it belongs to the portfolio in the path
+func (h *AllocationHandler) loadModel(c *gin.Context) (*models.AllocationModel, bool) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return nil, false
+	}
+	modelID, err := strconv.Atoi(c.Param("model_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid model ID"})
+		return nil, false
+	}
+
+	model, err := h.service.GetModel(c.Request.Context(), modelID)
+	if err != nil || model.PortfolioID != portfolioID {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Allocation model not found"})
+		return nil, false
+	}
+	return model, true
+}
+
+func (req *AllocationModelRequest) toModel() *models.AllocationModel {
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	return &models.AllocationModel{
+		Name:           req.Name,
+		Basis:          req.Basis,
+		Targets:        req.Targets,
+		DriftThreshold: req.DriftThreshold,
+		IsActive:       isActive,
+	}
+}
+
+func toAllocationModelResponse(model *models.AllocationModel) AllocationModelResponse {
+	return AllocationModelResponse{
+		ID:             model.ID,
+		PortfolioID:    model.PortfolioID,
+		Name:           model.Name,
+		Basis:          model.Basis,
+		Targets:        model.Targets,
+		DriftThreshold: model.DriftThreshold,
+		IsActive:       model.IsActive,
+		CreatedAt:      model.CreatedAt,
+		UpdatedAt:      model.UpdatedAt,
+	}
+}
+
+func toDriftCheckResponse(check *models.DriftCheck) DriftCheckResponse {
+	response := DriftCheckResponse{
+		ID:          check.ID,
+		ModelID:     check.ModelID,
+		PortfolioID: check.PortfolioID,
+		MaxDrift:    check.MaxDrift,
+		Threshold:   check.Threshold,
+		Breached:    check.Breached,
+		Drifts:      make([]AllocationDriftResponse, len(check.Drifts)),
+		CheckedAt:   check.CheckedAt,
+	}
+	for i, d := range check.Drifts {
+		response.Drifts[i] = AllocationDriftResponse{Key: d.Key, Target: d.Target, Actual: d.Actual, Drift: d.Drift}
+	}
+	return response
+}
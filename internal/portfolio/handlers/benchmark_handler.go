@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hedge-fund/internal/portfolio/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type BenchmarkHandler struct {
+	service *service.BenchmarkService
+	logger  *zap.Logger
+}
+
+func NewBenchmarkHandler(service *service.BenchmarkService, logger *zap.Logger) *BenchmarkHandler {
+	return &BenchmarkHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateBenchmarkRule godoc
+// @Summary Create a benchmark alert rule
+// @Description Alert the portfolio owner when the portfolio trails a benchmark by more than a threshold over a window, checked daily
+// @Tags benchmark
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param request body BenchmarkRuleRequest true "Benchmark rule"
+// @Success 201 {object} BenchmarkRuleResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/benchmark-rules [post]
+func (h *BenchmarkHandler) CreateBenchmarkRule(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	var req BenchmarkRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	rule := &models.BenchmarkRule{
+		PortfolioID: portfolioID,
+		Benchmark:   req.Benchmark,
+		WindowDays:  req.WindowDays,
+		Threshold:   req.Threshold,
+		IsActive:    true,
+	}
+	if err := h.service.CreateRule(c.Request.Context(), rule); err != nil {
+		writeError(c, h.logger, err, "Failed to create benchmark rule", zap.Int("portfolio_id", portfolioID))
+		return
+	}
+
+	c.JSON(http.StatusCreated, toBenchmarkRuleResponse(rule))
+}
+
+// ListBenchmarkRules godoc
+// @Summary List benchmark alert rules
+// @Tags benchmark
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Success 200 {array} BenchmarkRuleResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/benchmark-rules [get]
+func (h *BenchmarkHandler) ListBenchmarkRules(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	rules, err := h.service.GetRules(c.Request.Context(), portfolioID)
+	if err != nil {
+		h.logger.Error("Failed to list benchmark rules", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to list benchmark rules", Details: err.Error()})
+		return
+	}
+
+	response := make([]BenchmarkRuleResponse, len(rules))
+	for i := range rules {
+		response[i] = toBenchmarkRuleResponse(&rules[i])
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteBenchmarkRule godoc
+// @Summary Delete a benchmark alert rule
+// @Tags benchmark
+// @Param id path int true "Portfolio ID"
+// @Param rule_id path int true "Benchmark rule ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/benchmark-rules/{rule_id} [delete]
+func (h *BenchmarkHandler) DeleteBenchmarkRule(c *gin.Context) {
+	rule, ok := h.loadRule(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRule(c.Request.Context(), rule.ID); err != nil {
+		h.logger.Error("Failed to delete benchmark rule", zap.Error(err), zap.Int("rule_id", rule.ID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete benchmark rule", Details: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CompareToBenchmark godoc
+// @Summary Compare with the benchmark
+// @Description Compare the portfolio's time-weighted return over a rule's window, from its daily snapshots net of deposits, withdrawals and transfers, with the benchmark's return
+// @Tags benchmark
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param rule_id path int true "Benchmark rule ID"
+// @Success 200 {object} BenchmarkComparisonResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse "Not enough snapshot or benchmark history"
+// @Router /api/v1/portfolios/{id}/benchmark-rules/{rule_id}/comparison [get]
+func (h *BenchmarkHandler) CompareToBenchmark(c *gin.Context) {
+	rule, ok := h.loadRule(c)
+	if !ok {
+		return
+	}
+
+	comparison, err := h.service.Evaluate(c.Request.Context(), rule.ID)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to compare with benchmark", zap.Int("rule_id", rule.ID))
+		return
+	}
+
+	c.JSON(http.StatusOK, BenchmarkComparisonResponse{
+		RuleID:          comparison.RuleID,
+		PortfolioID:     comparison.PortfolioID,
+		Benchmark:       comparison.Benchmark,
+		StartDate:       comparison.StartDate.Format("2006-01-02"),
+		EndDate:         comparison.EndDate.Format("2006-01-02"),
+		PortfolioReturn: comparison.PortfolioReturn,
+		BenchmarkReturn: comparison.BenchmarkReturn,
+		RelativeReturn:  comparison.RelativeReturn,
+		Threshold:       comparison.Threshold,
+		Lagging:         comparison.Lagging,
+	})
+}
+
+// SimulateBenchmark godoc
+// @Summary Simulate investing in the benchmark
+// @Description Compare the portfolio's value at its daily snapshots with what it would have been worth had the same money gone into a benchmark at the same times. The simulation starts with the first snapshot's cash and the rest of its value in the benchmark; afterwards cash moves as it did in the portfolio, but buys and sales trade the benchmark at its close that day.
+// @Tags benchmark
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param benchmark query string false "Benchmark symbol; the portfolio's benchmark setting by default"
+// @Param days query int false "Number of daily snapshots after the first" default(252)
+// @Success 200 {object} BenchmarkSimulationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse "Not enough snapshot or benchmark history"
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/benchmark-simulation [get]
+func (h *BenchmarkHandler) SimulateBenchmark(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	days := service.DefaultPerformanceDays
+	if d := c.Query("days"); d != "" {
+		days, err = strconv.Atoi(d)
+		if err != nil || days <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid days"})
+			return
+		}
+	}
+
+	simulation, err := h.service.Simulate(c.Request.Context(), portfolioID, c.Query("benchmark"), days)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to simulate benchmark", zap.Int("portfolio_id", portfolioID))
+		return
+	}
+
+	response := BenchmarkSimulationResponse{
+		PortfolioID:    simulation.PortfolioID,
+		Benchmark:      simulation.Benchmark,
+		StartDate:      simulation.StartDate.Format("2006-01-02"),
+		EndDate:        simulation.EndDate.Format("2006-01-02"),
+		Contributions:  simulation.Contributions,
+		Withdrawals:    simulation.Withdrawals,
+		PortfolioValue: simulation.PortfolioValue,
+		BenchmarkValue: simulation.BenchmarkValue,
+		Difference:     simulation.Difference,
+		Points:         make([]BenchmarkSimulationPoint, len(simulation.Points)),
+	}
+	for i, point := range simulation.Points {
+		response.Points[i] = BenchmarkSimulationPoint{
+			Date:           point.Date.Format("2006-01-02"),
+			PortfolioValue: point.PortfolioValue,
+			BenchmarkValue: point.BenchmarkValue,
+			BenchmarkClose: point.BenchmarkClose,
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// loadRule resolves the rule in the path, responding with an error unless
+// it belongs to the portfolio in the path
+func (h *BenchmarkHandler) loadRule(c *gin.Context) (*models.BenchmarkRule, bool) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return nil, false
+	}
+	ruleID, err := strconv.Atoi(c.Param("rule_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid rule ID"})
+		return nil, false
+	}
+
+	rule, err := h.service.GetRule(c.Request.Context(), ruleID)
+	if err != nil || rule.PortfolioID != portfolioID {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Benchmark rule not found"})
+		return nil, false
+	}
+	return rule, true
+}
+
+func toBenchmarkRuleResponse(rule *models.BenchmarkRule) BenchmarkRuleResponse {
+	return BenchmarkRuleResponse{
+		ID:              rule.ID,
+		PortfolioID:     rule.PortfolioID,
+		Benchmark:       rule.Benchmark,
+		WindowDays:      rule.WindowDays,
+		Threshold:       rule.Threshold,
+		IsActive:        rule.IsActive,
+		LastTriggeredAt: rule.LastTriggeredAt,
+		CreatedAt:       rule.CreatedAt,
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hedge-fund/internal/portfolio/repository"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCashTransaction godoc
+// @Summary Deposit, withdraw or transfer cash
+// @Description Deposit cash into a portfolio, withdraw it, or transfer it to another portfolio of the same owner and base currency. Withdrawals and transfers are limited to the cash not reserved for pending buy orders or margin in use. Each movement is recorded in the portfolio's cash ledger; a transfer is recorded in both, and both entries are returned. Portfolios entered in a competition that hasn't finished cannot move cash.
+// @Tags portfolios
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param request body CashTransactionRequest true "Cash transaction"
+// @Success 201 {array} CashTransactionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "A withdrawal or transfer without X-2FA-Code or X-Trusted-Device"
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse "Insufficient available cash"
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/cash-transactions [post]
+func (h *PortfolioHandler) CreateCashTransaction(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	var req CashTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	// Both take cash out of the portfolio
+	if (req.Type == models.CashWithdrawal || req.Type == "transfer") && !h.requireSecondFactor(c) {
+		return
+	}
+
+	var entries []models.CashTransaction
+	if req.Type == "transfer" {
+		if req.ToPortfolioID <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: "to_portfolio_id is required for transfers"})
+			return
+		}
+		entries, err = h.service.TransferCash(c.Request.Context(), portfolioID, req.ToPortfolioID, req.Amount, req.Note)
+	} else {
+		var entry *models.CashTransaction
+		if entry, err = h.service.RecordCashTransaction(c.Request.Context(), portfolioID, req.Type, req.Amount, req.Note); err == nil {
+			entries = []models.CashTransaction{*entry}
+		}
+	}
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to handle cash transaction")
+		return
+	}
+
+	response := make([]CashTransactionResponse, len(entries))
+	for i := range entries {
+		response[i] = toCashTransactionResponse(&entries[i])
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetCashTransactions godoc
+// @Summary Get a portfolio's cash ledger
+// @Description Get the deposits, withdrawals and transfers of a portfolio's cash, with the balance after each
+// @Tags portfolios
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param limit query int false "Limit" default(100)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field" Enums(created_at, amount) default(created_at)
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Success 200 {object} pagination.Page{data=[]CashTransactionResponse}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/cash-transactions [get]
+func (h *PortfolioHandler) GetCashTransactions(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	page, ok := parsePage(c, repository.CashTransactionSorts, 100)
+	if !ok {
+		return
+	}
+
+	entries, result, err := h.service.GetCashTransactions(c.Request.Context(), portfolioID, page)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to handle cash transaction")
+		return
+	}
+
+	response := make([]CashTransactionResponse, len(entries))
+	for i := range entries {
+		response[i] = toCashTransactionResponse(&entries[i])
+	}
+	c.JSON(http.StatusOK, result.Page(response))
+}
+
+func toCashTransactionResponse(entry *models.CashTransaction) CashTransactionResponse {
+	return CashTransactionResponse{
+		ID:                      entry.ID,
+		PortfolioID:             entry.PortfolioID,
+		Type:                    entry.Type,
+		Amount:                  entry.Amount,
+		BalanceAfter:            entry.BalanceAfter,
+		CounterpartyPortfolioID: entry.CounterpartyPortfolioID,
+		TransferID:              entry.TransferID,
+		Note:                    entry.Note,
+		RequestID:               entry.RequestID,
+		CreatedAt:               entry.CreatedAt,
+	}
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"hedge-fund/internal/portfolio/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type CompetitionHandler struct {
+	service *service.CompetitionService
+	logger  *zap.Logger
+}
+
+func NewCompetitionHandler(service *service.CompetitionService, logger *zap.Logger) *CompetitionHandler {
+	return &CompetitionHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateCompetition godoc
+// @Summary Create a trading competition
+// @Description Define a competition between paper portfolios: the symbols they may trade, the UTC days it runs, the cash every entrant starts with, and optional limits on position size and trades per day
+// @Tags competitions
+// @Accept json
+// @Produce json
+// @Param request body CreateCompetitionRequest true "Competition definition"
+// @Success 201 {object} models.Competition
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/competitions [post]
+func (h *CompetitionHandler) CreateCompetition(c *gin.Context) {
+	var req CreateCompetitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid start_date, expected YYYY-MM-DD"})
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid end_date, expected YYYY-MM-DD"})
+		return
+	}
+
+	competition := &models.Competition{
+		Name:         req.Name,
+		Description:  req.Description,
+		Universe:     req.Universe,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		StartingCash: req.StartingCash,
+		Rules: models.CompetitionRules{
+			MaxPositionPercent: req.MaxPositionPercent,
+			MaxTradesPerDay:    req.MaxTradesPerDay,
+		},
+	}
+	if err := h.service.CreateCompetition(c.Request.Context(), competition); err != nil {
+		writeError(c, h.logger, err, "Failed to create competition")
+		return
+	}
+
+	c.JSON(http.StatusCreated, competition)
+}
+
+// ListCompetitions godoc
+// @Summary List trading competitions
+// @Description Get every competition with its status and number of entries, latest start first
+// @Tags competitions
+// @Produce json
+// @Success 200 {array} models.Competition
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/competitions [get]
+func (h *CompetitionHandler) ListCompetitions(c *gin.Context) {
+	competitions, err := h.service.ListCompetitions(c.Request.Context())
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to list competitions")
+		return
+	}
+
+	c.JSON(http.StatusOK, competitions)
+}
+
+// GetCompetition godoc
+// @Summary Get a trading competition
+// @Description Get a competition's definition, status and number of entries
+// @Tags competitions
+// @Produce json
+// @Param id path int true "Competition ID"
+// @Success 200 {object} models.Competition
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/competitions/{id} [get]
+func (h *CompetitionHandler) GetCompetition(c *gin.Context) {
+	competitionID, ok := competitionIDParam(c)
+	if !ok {
+		return
+	}
+
+	competition, err := h.service.GetCompetition(c.Request.Context(), competitionID)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to get competition")
+		return
+	}
+
+	c.JSON(http.StatusOK, competition)
+}
+
+// Enroll godoc
+// @Summary Enter a portfolio in a competition
+// @Description Enroll a paper portfolio until the competition's start date. It must hold no positions and exactly the starting cash, and can be entered in one competition only. Its trades are checked against the competition's rules while it runs.
+// @Tags competitions
+// @Accept json
+// @Produce json
+// @Param id path int true "Competition ID"
+// @Param request body EnrollRequest true "Portfolio to enroll"
+// @Success 201 {object} models.CompetitionEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/competitions/{id}/entries [post]
+func (h *CompetitionHandler) Enroll(c *gin.Context) {
+	competitionID, ok := competitionIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req EnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	entry, err := h.service.Enroll(c.Request.Context(), competitionID, req.PortfolioID)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to enroll portfolio")
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// Withdraw godoc
+// @Summary Withdraw a portfolio from a competition
+// @Description Remove a portfolio's entry before the competition starts
+// @Tags competitions
+// @Param id path int true "Competition ID"
+// @Param portfolio_id path int true "Portfolio ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/competitions/{id}/entries/{portfolio_id} [delete]
+func (h *CompetitionHandler) Withdraw(c *gin.Context) {
+	competitionID, ok := competitionIDParam(c)
+	if !ok {
+		return
+	}
+	portfolioID, err := strconv.Atoi(c.Param("portfolio_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	if err := h.service.Withdraw(c.Request.Context(), competitionID, portfolioID); err != nil {
+		writeError(c, h.logger, err, "Failed to withdraw portfolio")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Leaderboard godoc
+// @Summary Get a competition's leaderboard
+// @Description Public standings of a competition, best return first, as of its latest daily scoring. Equal returns share a rank. Standings are empty until the competition is first scored.
+// @Tags competitions
+// @Produce json
+// @Param id path int true "Competition ID"
+// @Success 200 {object} LeaderboardResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/competitions/{id}/leaderboard [get]
+func (h *CompetitionHandler) Leaderboard(c *gin.Context) {
+	competitionID, ok := competitionIDParam(c)
+	if !ok {
+		return
+	}
+
+	competition, standings, err := h.service.Leaderboard(c.Request.Context(), competitionID)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to get leaderboard")
+		return
+	}
+
+	response := LeaderboardResponse{
+		Competition: competition,
+		Standings:   standings,
+	}
+	if len(standings) > 0 {
+		response.AsOf = standings[0].AsOf.Format("2006-01-02")
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func competitionIDParam(c *gin.Context) (int, bool) {
+	competitionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid competition ID"})
+		return 0, false
+	}
+	return competitionID, true
+}
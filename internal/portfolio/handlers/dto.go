@@ -1,37 +1,145 @@
 package handlers
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+)
 
 // Request DTOs
 
 type CreatePortfolioRequest struct {
-	UserID      int     `json:"user_id" binding:"required"`
-	Name        string  `json:"name" binding:"required"`
-	InitialCash float64 `json:"initial_cash" binding:"required,gt=0"`
+	UserID       int      `json:"user_id" binding:"required"`
+	Name         string   `json:"name" binding:"required"`
+	Description  string   `json:"description"`
+	BaseCurrency string   `json:"base_currency"` // USD, EUR, GBP, CAD, JPY or CHF; empty inherits the owner's default
+	StrategyTags []string `json:"strategy_tags"` // e.g. value, long-short
+	InitialCash  float64  `json:"initial_cash" binding:"required,gt=0"`
 }
 
+// UpdatePortfolioRequest sets a portfolio's cash. The difference is
+// deposited or withdrawn through the cash ledger.
 type UpdatePortfolioRequest struct {
 	Cash float64 `json:"cash" binding:"gte=0"`
+	Note string  `json:"note"` // Of the ledger entry
+}
+
+// PatchPortfolioRequest changes some of a portfolio's fields. Fields left
+// out are unchanged.
+type PatchPortfolioRequest struct {
+	Name         *string                    `json:"name"`
+	Description  *string                    `json:"description"`
+	BaseCurrency *string                    `json:"base_currency"` // Empty inherits the owner's default again
+	StrategyTags []string                   `json:"strategy_tags"` // Replaces the tags; [] clears them
+	Settings     map[string]json.RawMessage `json:"settings" swaggertype:"object"` // Merge patch as for the settings endpoint
+	Version      int                        `json:"version"`                       // Rejects the patch if the portfolio has moved on; If-Match takes precedence
 }
 
 type TradeRequest struct {
-	Symbol    string `json:"symbol" binding:"required"`
-	Side      string `json:"side" binding:"required,oneof=buy sell"`
-	Quantity  int64  `json:"quantity" binding:"required,gt=0"`
-	OrderType string `json:"order_type" binding:"required,oneof=market limit"`
-	Price     float64 `json:"price"` // Only for limit orders
+	Symbol      string  `json:"symbol" binding:"required"`
+	Side        string  `json:"side" binding:"required,oneof=buy sell"`
+	Quantity    float64 `json:"quantity" binding:"required,gt=0"` // Whole shares, or up to 8 decimal places for crypto
+	OrderType   string  `json:"order_type" binding:"omitempty,oneof=market limit"` // Empty uses the portfolio's default_order_type
+	Price       float64 `json:"price"` // Only for limit orders
+	TimeInForce string  `json:"time_in_force" binding:"omitempty,oneof=day gtc ioc fok DAY GTC IOC FOK"` // Empty is day
 }
 
 type RebalanceRequest struct {
 	TargetAllocations map[string]float64 `json:"target_allocations" binding:"required"`
 }
 
+type ExecuteRebalanceRequest struct {
+	TargetAllocations map[string]float64 `json:"target_allocations" binding:"required"`
+	DryRun            bool               `json:"dry_run"` // Validate and simulate without trading
+}
+
+type LinkBrokerAccountRequest struct {
+	Broker      string `json:"broker" binding:"required"`
+	AccountID   string `json:"account_id" binding:"required"`
+	TradingMode string `json:"trading_mode" binding:"omitempty,oneof=paper live"` // Defaults to paper
+}
+
+type AllocationModelRequest struct {
+	Name           string             `json:"name" binding:"required"`
+	Basis          string             `json:"basis" binding:"omitempty,oneof=symbol sector"` // Defaults to symbol
+	Targets        map[string]float64 `json:"targets" binding:"required"`                    // % of total value; CASH targets cash
+	DriftThreshold float64            `json:"drift_threshold" binding:"gte=0,lte=100"`       // Percentage points, defaults to 5
+	IsActive       *bool              `json:"is_active"`                                     // Defaults to true
+}
+
+type BenchmarkRuleRequest struct {
+	Benchmark  string  `json:"benchmark"`                                     // Defaults to the portfolio's benchmark setting
+	WindowDays int     `json:"window_days" binding:"omitempty,gte=1,lte=365"` // Defaults to 7
+	Threshold  float64 `json:"threshold" binding:"required,gt=0,lte=100"`     // Percentage points of underperformance
+}
+
+// CashTransactionRequest deposits, withdraws or transfers cash
+type CashTransactionRequest struct {
+	Type          string  `json:"type" binding:"required,oneof=deposit withdrawal transfer"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"` // At most 2 decimal places
+	ToPortfolioID int     `json:"to_portfolio_id"`                // Destination of a transfer
+	Note          string  `json:"note" binding:"max=500"`
+}
+
+// SettingsRequest is a JSON merge patch of portfolio settings: options left
+// out are unchanged and options set to null are inherited again
+type SettingsRequest struct {
+	CommissionRate    *float64            `json:"commission_rate"`     // Fraction of trade value, at most 0.05
+	MinCommission     *float64            `json:"min_commission"`      // Minimum commission per trade
+	Benchmark         *string             `json:"benchmark"`           // Benchmark symbol, e.g. SPY
+	BaseCurrency      *string             `json:"base_currency"`       // USD, EUR, GBP, CAD, JPY or CHF
+	DRIPEnabled       *bool               `json:"drip_enabled"`        // Reinvest dividends
+	AutoTradeEnabled  *bool               `json:"auto_trade_enabled"`  // Requires a strategy other than manual
+	Strategy          *string             `json:"strategy"`            // manual, momentum or allocation
+	FeeSchedule       *models.FeeSchedule `json:"fee_schedule"`        // percentage, flat, per_share or tiered commission with levies; replaces commission_rate and min_commission
+	CryptoFeeSchedule *models.FeeSchedule `json:"crypto_fee_schedule"` // Charged on crypto trades instead; defaults to 0.25% of trade value
+	CostBasisMethod   *string             `json:"cost_basis_method"`   // fifo, lifo or hifo: the lots a sale consumes first
+	DefaultOrderType  *string             `json:"default_order_type"`  // market or limit, for trades placed without an order type
+	RiskTolerance     *string             `json:"risk_tolerance"`      // conservative (positions up to 10%), moderate (25%) or aggressive (uncapped)
+	AutoRebalance     *bool               `json:"auto_rebalance"`      // Rebalance when a symbol allocation model drifts past its threshold
+	Notifications     map[string]*bool    `json:"notifications"`       // On or off by notification type; null inherits a type again
+}
+
+// VaRBacktestRequest selects the VaR model to backtest. Fields left out use
+// the service's defaults.
+type VaRBacktestRequest struct {
+	Confidence   float64 `json:"confidence" binding:"omitempty,gt=0,lt=1"`          // e.g. 0.99
+	LookbackDays int     `json:"lookback_days" binding:"omitempty,gte=20,lte=1000"` // Daily returns each forecast is simulated from
+	WindowDays   int     `json:"window_days" binding:"omitempty,gte=30,lte=1000"`   // Most recent forecasts tested
+}
+
+type CreateCompetitionRequest struct {
+	Name               string   `json:"name" binding:"required"`
+	Description        string   `json:"description"`
+	Universe           []string `json:"universe"`                              // Tradable symbols; empty allows any
+	StartDate          string   `json:"start_date" binding:"required"`         // YYYY-MM-DD, UTC
+	EndDate            string   `json:"end_date" binding:"required"`           // YYYY-MM-DD, UTC, inclusive
+	StartingCash       float64  `json:"starting_cash" binding:"required,gt=0"` // Cash every entrant must start with
+	MaxPositionPercent float64  `json:"max_position_percent" binding:"omitempty,gt=0,lte=100"`
+	MaxTradesPerDay    int      `json:"max_trades_per_day" binding:"omitempty,gt=0"`
+}
+
+type EnrollRequest struct {
+	PortfolioID int `json:"portfolio_id" binding:"required"`
+}
+
+type UpdateImportMappingRequest struct {
+	Mapping    map[string]string `json:"mapping" binding:"required"` // Column of each import field
+	AdjustCash *bool             `json:"adjust_cash"`                // Unchanged when omitted
+}
+
 // Response DTOs
 
 type PortfolioResponse struct {
 	ID               int                `json:"id"`
 	UserID           int                `json:"user_id"`
 	Name             string             `json:"name"`
+	Description      string             `json:"description"`
+	StrategyTags     []string           `json:"strategy_tags"`
+	Version          int                `json:"version"` // Also sent as the ETag
 	Cash             float64            `json:"cash"`
 	MarginUsed       float64            `json:"margin_used"`
 	MarginAvailable  float64            `json:"margin_available"`
@@ -45,32 +153,87 @@ type PortfolioResponse struct {
 }
 
 type PositionResponse struct {
-	ID            int       `json:"id"`
-	PortfolioID   int       `json:"portfolio_id"`
-	Symbol        string    `json:"symbol"`
-	Quantity      int64     `json:"quantity"`
-	Side          string    `json:"side"`
-	EntryPrice    float64   `json:"entry_price"`
-	CurrentPrice  float64   `json:"current_price"`
-	UnrealizedPnL float64   `json:"unrealized_pnl"`
-	RealizedPnL   float64   `json:"realized_pnl"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            int               `json:"id"`
+	PortfolioID   int               `json:"portfolio_id"`
+	Symbol        string            `json:"symbol"`
+	AssetType     string            `json:"asset_type"` // "equity", "crypto" or "option"
+	Quantity      float64           `json:"quantity"`
+	Multiplier    float64           `json:"multiplier"` // Shares of the underlying per option contract; prices are per share
+	Side          string            `json:"side"`
+	EntryPrice    float64           `json:"entry_price"`
+	CurrentPrice  float64           `json:"current_price"`
+	UnrealizedPnL float64           `json:"unrealized_pnl"`
+	RealizedPnL   float64           `json:"realized_pnl"`
+	StopLoss      *StopLossResponse `json:"stop_loss,omitempty"`
+	PreviousClose *float64          `json:"previous_close,omitempty"` // Day P&L is measured from it; set at market open
+	DayOpenPrice  *float64          `json:"day_open_price,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// StopLossResponse is a position's stop-loss and the price it triggers at
+type StopLossResponse struct {
+	Percent      *float64 `json:"percent,omitempty"` // Below entry price
+	Price        *float64 `json:"price,omitempty"`
+	TriggerPrice float64  `json:"trigger_price"`
 }
 
+// SetStopLossRequest sets a position's stop-loss as a percentage below its
+// entry price or an absolute price. Leaving out both clears it.
+type SetStopLossRequest struct {
+	Percent *float64 `json:"percent" binding:"omitempty,gt=0,lt=100"`
+	Price   *float64 `json:"price" binding:"omitempty,gt=0"`
+}
+
+// PositionAlertRequest sets the price moves from a position's entry price to
+// be notified of. Symbol picks the position and is only read on create.
+type PositionAlertRequest struct {
+	Symbol      string   `json:"symbol"`
+	UpPercent   *float64 `json:"up_percent" binding:"omitempty,gt=0"`          // Above entry price
+	DownPercent *float64 `json:"down_percent" binding:"omitempty,gt=0,lt=100"` // Below entry price
+	Note        string   `json:"note" binding:"max=500"`                       // Included in the notification
+	IsActive    *bool    `json:"is_active"`                                    // Defaults to true, re-arming an alert that fired
+}
+
+type PositionAlertResponse struct {
+	ID             int        `json:"id"`
+	PortfolioID    int        `json:"portfolio_id"`
+	PositionID     int        `json:"position_id"`
+	Symbol         string     `json:"symbol"`
+	UpPercent      *float64   `json:"up_percent,omitempty"`
+	DownPercent    *float64   `json:"down_percent,omitempty"`
+	EntryPrice     float64    `json:"entry_price"`
+	UpPrice        *float64   `json:"up_price,omitempty"`   // Fires at or above
+	DownPrice      *float64   `json:"down_price,omitempty"` // Fires at or below
+	Note           string     `json:"note"`
+	IsActive       bool       `json:"is_active"`
+	TriggeredAt    *time.Time `json:"triggered_at,omitempty"`
+	TriggeredPrice *float64   `json:"triggered_price,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+
 type TradeResponse struct {
-	ID          int        `json:"id"`
-	PortfolioID int        `json:"portfolio_id"`
-	PositionID  int        `json:"position_id"`
-	Symbol      string     `json:"symbol"`
-	Quantity    int64      `json:"quantity"`
-	Price       float64    `json:"price"`
-	Side        string     `json:"side"`
-	Type        string     `json:"type"`
-	Status      string     `json:"status"`
-	Fees        float64    `json:"fees"`
-	ExecutedAt  *time.Time `json:"executed_at"`
-	CreatedAt   time.Time  `json:"created_at"`
+	ID            int              `json:"id"`
+	PortfolioID   int              `json:"portfolio_id"`
+	PositionID    int              `json:"position_id"`
+	Symbol        string           `json:"symbol"`
+	AssetType     string           `json:"asset_type"`
+	Quantity      float64          `json:"quantity"`
+	Multiplier    float64          `json:"multiplier"`
+	Price         float64          `json:"price"`
+	Side          string           `json:"side"`
+	Type          string           `json:"type"`
+	Status        string           `json:"status"`
+	TimeInForce   string           `json:"time_in_force"`
+	Fees          float64          `json:"fees"`
+	FeeItems      []models.FeeItem `json:"fee_items,omitempty"` // Commission and levies making up fees
+	Broker        string           `json:"broker,omitempty"`
+	BrokerOrderID string           `json:"broker_order_id,omitempty"`
+	TriggerReason string           `json:"trigger_reason,omitempty"` // Set on automated trades, e.g. "stop_loss"
+	ExecutedAt    *time.Time       `json:"executed_at"`
+	CreatedAt     time.Time        `json:"created_at"`
 }
 
 type SummaryResponse struct {
@@ -85,6 +248,73 @@ type SummaryResponse struct {
 	PositionCount  int     `json:"position_count"`
 }
 
+type UserSummariesResponse struct {
+	UserID     int                     `json:"user_id"`
+	TotalValue float64                 `json:"total_value"`
+	Portfolios []PortfolioSummaryEntry `json:"portfolios"`
+}
+
+type PortfolioSummaryEntry struct {
+	PortfolioID int    `json:"portfolio_id"`
+	Name        string `json:"name"`
+	SummaryResponse
+}
+
+type UserOverviewResponse struct {
+	UserID     int                      `json:"user_id"`
+	Summary    SummaryResponse          `json:"summary"`    // Totals across all portfolios
+	Allocation []AllocationResponse     `json:"allocation"` // Positions netted by symbol across portfolios
+	Risk       RiskMetricsResponse      `json:"risk"`
+	Portfolios []PortfolioOverviewEntry `json:"portfolios"`
+}
+
+type PortfolioOverviewEntry struct {
+	PortfolioID int     `json:"portfolio_id"`
+	Name        string  `json:"name"`
+	Weight      float64 `json:"weight"` // Percent of the combined total value
+	SummaryResponse
+}
+
+type PositionSummaryResponse struct {
+	Symbol             string        `json:"symbol"`
+	Quantity           float64       `json:"quantity"`
+	AveragePrice       float64       `json:"average_price"`
+	CurrentPrice       float64       `json:"current_price"`
+	MarketValue        float64       `json:"market_value"`
+	UnrealizedPnL      float64       `json:"unrealized_pnl"`
+	UnrealizedReturn   float64       `json:"unrealized_return"`
+	EntryDate          time.Time     `json:"entry_date"`
+	DaysHeld           int           `json:"days_held"`
+	AverageHoldingDays float64       `json:"average_holding_days"`
+	Lots               []LotResponse `json:"lots"`
+}
+
+type LotResponse struct {
+	ID                int       `json:"id"`
+	TradeID           int       `json:"trade_id,omitempty"`
+	Quantity          float64   `json:"quantity"`
+	RemainingQuantity float64   `json:"remaining_quantity"`
+	Price             float64   `json:"price"`
+	AcquiredAt        time.Time `json:"acquired_at"`
+}
+
+type LotBreakdownResponse struct {
+	Symbol            string               `json:"symbol"`
+	Quantity          float64              `json:"quantity"`
+	AveragePrice      float64              `json:"average_price"`
+	LotQuantity       float64              `json:"lot_quantity"` // Remaining in open lots
+	LotAveragePrice   float64              `json:"lot_average_price"` // Of the quantity remaining in open lots
+	UntrackedQuantity float64              `json:"untracked_quantity"` // Held without lots, e.g. bought before lot tracking
+	CostBasisMethod   string               `json:"cost_basis_method"` // The order sales consume open lots in
+	Lots              []LotHistoryResponse `json:"lots"`
+}
+
+type LotHistoryResponse struct {
+	LotResponse
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
+	Weight   float64    `json:"weight"` // Percent of the open lots' cost; 0 once closed
+}
+
 type AllocationResponse struct {
 	Symbol     string  `json:"symbol"`
 	Percentage float64 `json:"percentage"`
@@ -92,11 +322,70 @@ type AllocationResponse struct {
 }
 
 type RiskMetricsResponse struct {
-	TotalValue            float64 `json:"total_value"`
-	PositionCount         int     `json:"position_count"`
-	MaxPositionPercent    float64 `json:"max_position_percent"`
-	CashPercent           float64 `json:"cash_percent"`
-	DiversificationScore  float64 `json:"diversification_score"`
+	TotalValue           float64                  `json:"total_value"`
+	PositionCount        int                      `json:"position_count"`
+	MaxPositionPercent   float64                  `json:"max_position_percent"`
+	CashPercent          float64                  `json:"cash_percent"`
+	DiversificationScore float64                  `json:"diversification_score"`
+	LongExposure         float64                  `json:"long_exposure"`
+	ShortExposure        float64                  `json:"short_exposure"`
+	GrossExposure        float64                  `json:"gross_exposure"`
+	NetExposure          float64                  `json:"net_exposure"`
+	LongShortRatio       float64                  `json:"long_short_ratio"`
+	SectorExposure       map[string]float64       `json:"sector_exposure"`     // Net % of total value per sector
+	Benchmark            string                   `json:"benchmark,omitempty"` // What Beta and the factor exposures are measured against
+	Beta                 float64                  `json:"beta"`
+	Factors              FactorExposureResponse   `json:"factors"`
+	PositionFactors      []PositionFactorResponse `json:"position_factors"`
+}
+
+// FactorExposureResponse is a portfolio's exposure to price-based style
+// factors, relative to its benchmark
+type FactorExposureResponse struct {
+	Size     float64 `json:"size"`     // Log of average dollar volume relative to the benchmark's
+	Momentum float64 `json:"momentum"` // 12-1 month return less the benchmark's
+	Value    float64 `json:"value"`    // Discount to the 52-week high less the benchmark's
+}
+
+type PositionFactorResponse struct {
+	Symbol   string  `json:"symbol"`
+	Weight   float64 `json:"weight"` // Signed share of total value
+	Beta     float64 `json:"beta"`
+	Size     float64 `json:"size"`
+	Momentum float64 `json:"momentum"`
+	Value    float64 `json:"value"`
+}
+
+// FactorHistoryResponse is a portfolio's recorded daily beta and factor
+// exposures, oldest first
+type FactorHistoryResponse struct {
+	PortfolioID int                  `json:"portfolio_id"`
+	Exposures   []FactorHistoryPoint `json:"exposures"`
+}
+
+type FactorHistoryPoint struct {
+	Date      time.Time `json:"date"`
+	Benchmark string    `json:"benchmark"`
+	Beta      float64   `json:"beta"`
+	Size      float64   `json:"size"`
+	Momentum  float64   `json:"momentum"`
+	Value     float64   `json:"value"`
+}
+
+// PerformanceResponse is a portfolio's risk-adjusted return from its daily
+// snapshots. Returns, volatility and drawdown are percentages.
+type PerformanceResponse struct {
+	PortfolioID      int       `json:"portfolio_id"`
+	StartDate        time.Time `json:"start_date"`
+	EndDate          time.Time `json:"end_date"`
+	Observations     int       `json:"observations"`
+	TotalReturn      float64   `json:"total_return"`
+	AnnualizedReturn float64   `json:"annualized_return"`
+	Volatility       float64   `json:"volatility"`
+	RiskFreeRate     float64   `json:"risk_free_rate"` // Annual rate for the period
+	SharpeRatio      float64   `json:"sharpe_ratio"`
+	SortinoRatio     float64   `json:"sortino_ratio"`
+	MaxDrawdown      float64   `json:"max_drawdown"`
 }
 
 type RebalanceRecommendation struct {
@@ -107,10 +396,288 @@ type RebalanceRecommendation struct {
 	TargetValue     float64 `json:"target_value"`
 	CurrentValue    float64 `json:"current_value"`
 	Action          string  `json:"action"` // "buy", "sell", "hold"
-	EstimatedShares int64   `json:"estimated_shares"`
+	EstimatedShares float64 `json:"estimated_shares"`
+}
+
+type TradePreviewResponse struct {
+	DryRun     bool              `json:"dry_run"`
+	Trade      TradeResponse     `json:"trade"`              // Price and fees the trade would fill at
+	Position   *PositionResponse `json:"position,omitempty"` // Omitted when the trade closes the position
+	Venue      string            `json:"venue"`              // Live venues may fill at a different price
+	CashBefore float64           `json:"cash_before"`
+	CashAfter  float64           `json:"cash_after"`
+}
+
+type CashUpdatePreviewResponse struct {
+	DryRun     bool              `json:"dry_run"`
+	CashBefore float64           `json:"cash_before"`
+	CashAfter  float64           `json:"cash_after"`
+	Portfolio  PortfolioResponse `json:"portfolio"`
+}
+
+
+type SettingsResponse struct {
+	PortfolioID       int                 `json:"portfolio_id,omitempty"`
+	UserID            int                 `json:"user_id,omitempty"`
+	CommissionRate    float64             `json:"commission_rate"`
+	MinCommission     float64             `json:"min_commission"`
+	Benchmark         string              `json:"benchmark"`
+	BaseCurrency      string              `json:"base_currency"`
+	DRIPEnabled       bool                `json:"drip_enabled"`
+	AutoTradeEnabled  bool                `json:"auto_trade_enabled"`
+	Strategy          string              `json:"strategy"`
+	FeeSchedule       *models.FeeSchedule `json:"fee_schedule,omitempty"`
+	CryptoFeeSchedule models.FeeSchedule  `json:"crypto_fee_schedule"`
+	CostBasisMethod   string              `json:"cost_basis_method"`
+	DefaultOrderType  string              `json:"default_order_type"`
+	RiskTolerance     string              `json:"risk_tolerance"`
+	AutoRebalance     bool                `json:"auto_rebalance"`
+	Notifications     map[string]bool     `json:"notifications"`
+	Sources           map[string]string   `json:"sources"` // portfolio, user or default, by option
+}
+
+type RebalanceExecutionResponse struct {
+	PortfolioID int                    `json:"portfolio_id"`
+	DryRun      bool                   `json:"dry_run"`
+	Applied     bool                   `json:"applied"`
+	CashBefore  float64                `json:"cash_before"`
+	CashAfter   float64                `json:"cash_after"`
+	Trades      []RebalanceTradeStatus `json:"trades"`
+}
+
+type RebalanceTradeStatus struct {
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"`
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price,omitempty"`
+	Fees     float64 `json:"fees,omitempty"`
+	TradeID  int     `json:"trade_id,omitempty"`
+	Status   string  `json:"status"` // filled, simulated, failed, rolled_back, skipped
+	Error    string  `json:"error,omitempty"`
+}
+
+type CashTransactionResponse struct {
+	ID                      int64     `json:"id"`
+	PortfolioID             int       `json:"portfolio_id"`
+	Type                    string    `json:"type"`
+	Amount                  float64   `json:"amount"`
+	BalanceAfter            float64   `json:"balance_after"`
+	CounterpartyPortfolioID *int      `json:"counterparty_portfolio_id,omitempty"`
+	TransferID              string    `json:"transfer_id,omitempty"`
+	Note                    string    `json:"note,omitempty"`
+	RequestID               string    `json:"request_id,omitempty"`
+	CreatedAt               time.Time `json:"created_at"`
+}
+
+type AuditEventResponse struct {
+	ID          int64           `json:"id"`
+	PortfolioID int             `json:"portfolio_id"`
+	EntityType  string          `json:"entity_type"`
+	EntityID    int             `json:"entity_id"`
+	Action      string          `json:"action"`
+	Actor       string          `json:"actor"`
+	RequestID   string          `json:"request_id,omitempty"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type TradeEventResponse struct {
+	ID          int64     `json:"id"`
+	PortfolioID int       `json:"portfolio_id"`
+	TradeID     int       `json:"trade_id,omitempty"`
+	Symbol      string    `json:"symbol"`
+	EventType   string    `json:"event_type"`
+	Side        string    `json:"side"`
+	Quantity    float64   `json:"quantity"`
+	Price       float64   `json:"price"`
+	Fees        float64   `json:"fees"`
+	Reason      string    `json:"reason,omitempty"`
+	RequestID   string    `json:"request_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type TradeEventsResponse struct {
+	Events     []TradeEventResponse `json:"events"`
+	NextCursor int64                `json:"next_cursor"` // Pass as after_id to continue reading
+}
+
+type ReplayResponse struct {
+	PortfolioID   int                `json:"portfolio_id"`
+	EventsApplied int                `json:"events_applied"`
+	LastEventID   int64              `json:"last_event_id"`
+	Rebuilt       []PositionResponse `json:"rebuilt"`
+	Stored        []PositionResponse `json:"stored"`
+	Consistent    bool               `json:"consistent"`
+	Discrepancies []string           `json:"discrepancies"`
+}
+
+type BrokerAccountResponse struct {
+	PortfolioID int       `json:"portfolio_id"`
+	Broker      string    `json:"broker"`
+	AccountID   string    `json:"account_id"`
+	TradingMode string    `json:"trading_mode"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ReconciliationBreakResponse struct {
+	ID            int64   `json:"id"`
+	BreakType     string  `json:"break_type"`
+	Symbol        string  `json:"symbol"`
+	TradeID       int     `json:"trade_id,omitempty"`
+	BrokerRef     string  `json:"broker_ref,omitempty"`
+	InternalValue float64 `json:"internal_value"`
+	BrokerValue   float64 `json:"broker_value"`
+	Resolution    string  `json:"resolution"`
+	Message       string  `json:"message"`
 }
 
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Details string `json:"details,omitempty"`
+type ReconciliationRunResponse struct {
+	ID               int                           `json:"id"`
+	PortfolioID      int                           `json:"portfolio_id"`
+	StatementDate    string                        `json:"statement_date"`
+	Status           string                        `json:"status"`
+	PositionsChecked int                           `json:"positions_checked"`
+	TradesChecked    int                           `json:"trades_checked"`
+	AutoFixed        int                           `json:"auto_fixed"`
+	OpenBreaks       int                           `json:"open_breaks"`
+	Error            string                        `json:"error,omitempty"`
+	StartedAt        time.Time                     `json:"started_at"`
+	CompletedAt      *time.Time                    `json:"completed_at,omitempty"`
+	Breaks           []ReconciliationBreakResponse `json:"breaks,omitempty"`
 }
+
+type AllocationModelResponse struct {
+	ID             int                `json:"id"`
+	PortfolioID    int                `json:"portfolio_id"`
+	Name           string             `json:"name"`
+	Basis          string             `json:"basis"`
+	Targets        map[string]float64 `json:"targets"`
+	DriftThreshold float64            `json:"drift_threshold"`
+	IsActive       bool               `json:"is_active"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+type AllocationDriftResponse struct {
+	Key    string  `json:"key"` // Symbol, sector or CASH
+	Target float64 `json:"target"`
+	Actual float64 `json:"actual"`
+	Drift  float64 `json:"drift"` // Actual minus target, in percentage points
+}
+
+type DriftCheckResponse struct {
+	ID          int64                     `json:"id"`
+	ModelID     int                       `json:"model_id"`
+	PortfolioID int                       `json:"portfolio_id"`
+	MaxDrift    float64                   `json:"max_drift"`
+	Threshold   float64                   `json:"threshold"`
+	Breached    bool                      `json:"breached"`
+	Drifts      []AllocationDriftResponse `json:"drifts"` // Largest drift first
+	CheckedAt   time.Time                 `json:"checked_at"`
+}
+
+type BenchmarkRuleResponse struct {
+	ID              int        `json:"id"`
+	PortfolioID     int        `json:"portfolio_id"`
+	Benchmark       string     `json:"benchmark"`
+	WindowDays      int        `json:"window_days"`
+	Threshold       float64    `json:"threshold"`
+	IsActive        bool       `json:"is_active"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type BenchmarkComparisonResponse struct {
+	RuleID          int     `json:"rule_id"`
+	PortfolioID     int     `json:"portfolio_id"`
+	Benchmark       string  `json:"benchmark"`
+	StartDate       string  `json:"start_date"`
+	EndDate         string  `json:"end_date"`
+	PortfolioReturn float64 `json:"portfolio_return"` // Percent
+	BenchmarkReturn float64 `json:"benchmark_return"` // Percent
+	RelativeReturn  float64 `json:"relative_return"`  // Portfolio minus benchmark, in percentage points
+	Threshold       float64 `json:"threshold"`
+	Lagging         bool    `json:"lagging"`
+}
+
+type BenchmarkSimulationResponse struct {
+	PortfolioID    int                        `json:"portfolio_id"`
+	Benchmark      string                     `json:"benchmark"`
+	StartDate      string                     `json:"start_date"`
+	EndDate        string                     `json:"end_date"`
+	Contributions  float64                    `json:"contributions"`
+	Withdrawals    float64                    `json:"withdrawals"`
+	PortfolioValue float64                    `json:"portfolio_value"`
+	BenchmarkValue float64                    `json:"benchmark_value"` // Of the counterfactual
+	Difference     float64                    `json:"difference"`      // Portfolio minus counterfactual value
+	Points         []BenchmarkSimulationPoint `json:"points"`          // Equity curves, oldest first
+}
+
+type BenchmarkSimulationPoint struct {
+	Date           string  `json:"date"`
+	PortfolioValue float64 `json:"portfolio_value"`
+	BenchmarkValue float64 `json:"benchmark_value"`
+	BenchmarkClose float64 `json:"benchmark_close"`
+}
+
+type VaRBacktestResponse struct {
+	ID                        int64     `json:"id"`
+	PortfolioID               int       `json:"portfolio_id"`
+	Confidence                float64   `json:"confidence"`
+	LookbackDays              int       `json:"lookback_days"`
+	StartDate                 string    `json:"start_date"`
+	EndDate                   string    `json:"end_date"`
+	Observations              int       `json:"observations"`
+	Exceptions                int       `json:"exceptions"` // Days whose loss exceeded the VaR forecast
+	ExpectedExceptions        float64   `json:"expected_exceptions"`
+	KupiecLR                  float64   `json:"kupiec_lr"`
+	KupiecPValue              float64   `json:"kupiec_p_value"`
+	IndependenceLR            float64   `json:"independence_lr"` // Christoffersen
+	IndependencePValue        float64   `json:"independence_p_value"`
+	ConditionalCoverageLR     float64   `json:"conditional_coverage_lr"`
+	ConditionalCoveragePValue float64   `json:"conditional_coverage_p_value"`
+	Adequate                  bool      `json:"adequate"`
+	ExceptionDates            []string  `json:"exception_dates"`
+	CreatedAt                 time.Time `json:"created_at"`
+}
+
+type LeaderboardResponse struct {
+	Competition *models.Competition          `json:"competition"`
+	AsOf        string                       `json:"as_of,omitempty"` // Day of the latest standings; empty until first scored
+	Standings   []models.CompetitionStanding `json:"standings"`
+}
+
+type ImportPreviewResponse struct {
+	MappingError  string               `json:"mapping_error,omitempty"` // Why no rows could be read with the mapping
+	ValidRows     int                  `json:"valid_rows"`
+	InvalidRows   int                  `json:"invalid_rows"`
+	DuplicateRows int                  `json:"duplicate_rows"`
+	Rows          []domain.ImportRow   `json:"rows"` // The first rows of the file
+	Errors        []models.ImportError `json:"errors"`
+}
+
+type ImportResponse struct {
+	Import  *models.PortfolioImport `json:"import"`
+	Preview *ImportPreviewResponse  `json:"preview,omitempty"` // Until the import is started
+}
+
+type StatementResponse struct {
+	ID            int64     `json:"id"`
+	PortfolioID   int       `json:"portfolio_id"`
+	Month         string    `json:"month"`      // YYYY-MM
+	StartDate     string    `json:"start_date"` // Of the opening snapshot
+	EndDate       string    `json:"end_date"`   // Of the closing snapshot
+	StartingValue float64   `json:"starting_value"`
+	EndingValue   float64   `json:"ending_value"`
+	Contributions float64   `json:"contributions"`
+	Withdrawals   float64   `json:"withdrawals"`
+	RealizedPnL   float64   `json:"realized_pnl"`
+	UnrealizedPnL float64   `json:"unrealized_pnl"` // Change over the month
+	Fees          float64   `json:"fees"`
+	TradeCount    int       `json:"trade_count"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ErrorResponse is the error envelope shared by every service
+type ErrorResponse = apierror.Response
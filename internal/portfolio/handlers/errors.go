@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/apierror"
+)
+
+// specificErrors are the domain errors answered with their own code or
+// summary. An empty code is the category's; an empty summary keeps the
+// handler's message.
+var specificErrors = []struct {
+	err     error
+	code    string
+	summary string
+}{
+	{domain.ErrPortfolioNotFound, apierror.CodePortfolioNotFound, "Portfolio not found"},
+	{domain.ErrPositionNotFound, apierror.CodePositionNotFound, "Position not found"},
+	{domain.ErrInvalidQuantity, apierror.CodeInvalidOrder, ""},
+	{domain.ErrInvalidPrice, apierror.CodeInvalidOrder, ""},
+	{domain.ErrInvalidSide, apierror.CodeInvalidOrder, ""},
+	{domain.ErrOptionExpired, apierror.CodeInvalidOrder, ""},
+	{domain.ErrInvalidTimeInForce, apierror.CodeInvalidOrder, ""},
+	{domain.ErrInsufficientCash, apierror.CodeInsufficientFunds, ""},
+	{domain.ErrInsufficientShares, apierror.CodeInsufficientShares, ""},
+	{domain.ErrInsufficientHistory, apierror.CodeInsufficientHistory, "Not enough history"},
+	{domain.ErrSlippageExceeded, apierror.CodeSlippageExceeded, ""},
+	{domain.ErrVersionConflict, apierror.CodeVersionConflict, "Portfolio was modified"},
+	{domain.ErrCompetitionRule, apierror.CodeCompetitionRule, ""},
+	{domain.ErrPositionLimit, apierror.CodePositionLimit, ""},
+	{domain.ErrOrderNotFilled, apierror.CodeOrderNotFilled, ""},
+	{domain.ErrLiveRebalance, apierror.CodeLiveRebalance, ""},
+	{domain.ErrFeatureDisabled, apierror.CodeFeatureDisabled, "Feature not enabled"},
+	{domain.ErrInvalidAllocation, "", "Invalid allocation model"},
+	{domain.ErrInvalidBenchmarkRule, "", "Invalid benchmark rule"},
+	{domain.ErrInvalidSettings, "", "Invalid settings"},
+	{domain.ErrInvalidVaRBacktest, "", "Invalid VaR backtest"},
+	{domain.ErrInvalidStopLoss, "", "Invalid stop-loss"},
+	{domain.ErrInvalidPortfolioDetails, "", "Invalid portfolio details"},
+	{domain.ErrInvalidCashTransaction, "", "Invalid cash transaction"},
+	{domain.ErrInvalidPositionAlert, "", "Invalid position alert"},
+	{domain.ErrInvalidProvisionSpec, "", "Invalid provisioning spec"},
+	{domain.ErrInvalidImport, "", "Invalid import"},
+	{domain.ErrInvalidCompetition, "", "Invalid competition"},
+	{domain.ErrInvalidEntry, "", "Invalid entry"},
+}
+
+// errorStatus maps a domain error's category to an HTTP status. Errors
+// outside every category are unexpected and map to 500.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrInsufficientFunds), errors.Is(err, domain.ErrRejected):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError answers a failed request with the status of err's category.
+// message summarizes the failure when err has no summary of its own;
+// unexpected errors are also logged under it, with fields.
+func writeError(c *gin.Context, logger *zap.Logger, err error, message string, fields ...zap.Field) {
+	status := errorStatus(err)
+	resp := ErrorResponse{Error: message, Details: err.Error()}
+	if status == http.StatusNotFound {
+		resp.Error = "Not found"
+	}
+	for _, specific := range specificErrors {
+		if errors.Is(err, specific.err) {
+			resp.Code = specific.code
+			if specific.summary != "" {
+				resp.Error = specific.summary
+			}
+			break
+		}
+	}
+	if status == http.StatusInternalServerError {
+		logger.Error(message, append(fields, zap.Error(err))...)
+	}
+	apierror.Respond(c, status, resp)
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Export formats
+const (
+	exportCSV  = "csv"
+	exportJSON = "json"
+)
+
+// exportFlushRows is how many records are written between flushes to the client
+const exportFlushRows = 500
+
+var tradeExportColumns = []string{
+	"trade_id", "executed_at", "symbol", "asset_type", "side", "quantity", "multiplier", "price", "value", "fees",
+	"type", "trigger_reason", "broker", "broker_order_id",
+}
+
+var positionExportColumns = []string{
+	"symbol", "asset_type", "side", "quantity", "multiplier", "entry_price", "cost_basis", "current_price",
+	"unrealized_pnl", "realized_pnl", "opened_at",
+}
+
+// ExportTrades godoc
+// @Summary Export trades
+// @Description Download a portfolio's filled trades, oldest first, for tax preparation or analysis. Trades are streamed as they are read, so exports of any size start at once; a download that ends early is incomplete.
+// @Tags portfolios
+// @Produce text/csv
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param format query string false "File format" Enums(csv, json) default(csv)
+// @Param from query string false "First execution date (YYYY-MM-DD, UTC)"
+// @Param to query string false "Last execution date (YYYY-MM-DD, UTC), inclusive"
+// @Success 200 {array} TradeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/trades/export [get]
+func (h *PortfolioHandler) ExportTrades(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	var from, to time.Time
+	if value := c.Query("from"); value != "" {
+		if from, err = time.Parse("2006-01-02", value); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = time.Parse("2006-01-02", value); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "from must not be after to"})
+		return
+	}
+
+	stream := newExportStream(c, format, fmt.Sprintf("portfolio-%d-trades", portfolioID), tradeExportColumns)
+	err = h.service.ExportTrades(c.Request.Context(), portfolioID, from, to, func(trade *models.Trade) error {
+		return stream.write(tradeExportRecord(trade), h.toTradeResponse(trade, nil))
+	})
+	h.finishExport(c, stream, err, "Failed to export trades", zap.Int("portfolio_id", portfolioID))
+}
+
+// ExportPositions godoc
+// @Summary Export positions
+// @Description Download a portfolio's positions, by symbol, with their cost basis
+// @Tags portfolios
+// @Produce text/csv
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param format query string false "File format" Enums(csv, json) default(csv)
+// @Success 200 {array} PositionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/positions/export [get]
+func (h *PortfolioHandler) ExportPositions(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	stream := newExportStream(c, format, fmt.Sprintf("portfolio-%d-positions", portfolioID), positionExportColumns)
+	err = h.service.ExportPositions(c.Request.Context(), portfolioID, func(position *models.Position) error {
+		return stream.write(positionExportRecord(position), h.toPositionResponse(position))
+	})
+	h.finishExport(c, stream, err, "Failed to export positions", zap.Int("portfolio_id", portfolioID))
+}
+
+// finishExport ends an export. Failures before the first record are answered
+// with an error; later ones can only cut the download short.
+func (h *PortfolioHandler) finishExport(c *gin.Context, stream *exportStream, err error, message string, fields ...zap.Field) {
+	if err != nil && !stream.started {
+		writeError(c, h.logger, err, message, fields...)
+		return
+	}
+	if err != nil {
+		h.logger.Warn("Export interrupted", append(fields, zap.Error(err), zap.Int("records", stream.records))...)
+		return
+	}
+	if err := stream.finish(); err != nil {
+		h.logger.Warn("Export interrupted", append(fields, zap.Error(err))...)
+	}
+}
+
+func exportFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", exportCSV)
+	if format != exportCSV && format != exportJSON {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid format", Details: "format must be csv or json"})
+		return "", false
+	}
+	return format, true
+}
+
+// exportStream writes records to the client as CSV rows or elements of a JSON
+// array as they are produced. Headers are sent with the first record, so a
+// failure before it can still be answered with an error status.
+type exportStream struct {
+	c        *gin.Context
+	format   string
+	filename string
+	columns  []string
+	csv      *csv.Writer
+	started  bool
+	records  int
+}
+
+func newExportStream(c *gin.Context, format, filename string, columns []string) *exportStream {
+	return &exportStream{c: c, format: format, filename: filename, columns: columns}
+}
+
+func (s *exportStream) start() error {
+	s.started = true
+	// Large exports outlive the server's write timeout
+	_ = http.NewResponseController(s.c.Writer).SetWriteDeadline(time.Time{})
+
+	s.c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, s.filename, s.format))
+	s.c.Header("Cache-Control", "no-store")
+	if s.format == exportJSON {
+		s.c.Header("Content-Type", "application/json")
+		s.c.Status(http.StatusOK)
+		_, err := s.c.Writer.WriteString("[")
+		return err
+	}
+	s.c.Header("Content-Type", "text/csv; charset=utf-8")
+	s.c.Status(http.StatusOK)
+	s.csv = csv.NewWriter(s.c.Writer)
+	return s.csv.Write(s.columns)
+}
+
+// write sends one record, as its CSV fields or as value in JSON
+func (s *exportStream) write(record []string, value interface{}) error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+
+	if s.format == exportJSON {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if s.records > 0 {
+			data = append([]byte(","), data...)
+		}
+		if _, err := s.c.Writer.Write(data); err != nil {
+			return err
+		}
+	} else if err := s.csv.Write(record); err != nil {
+		return err
+	}
+
+	s.records++
+	if s.records%exportFlushRows == 0 {
+		return s.flush()
+	}
+	return nil
+}
+
+// finish ends the export, sending headers first when there were no records
+func (s *exportStream) finish() error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+	if s.format == exportJSON {
+		if _, err := s.c.Writer.WriteString("]"); err != nil {
+			return err
+		}
+	}
+	return s.flush()
+}
+
+func (s *exportStream) flush() error {
+	if s.csv != nil {
+		s.csv.Flush()
+		if err := s.csv.Error(); err != nil {
+			return err
+		}
+	}
+	s.c.Writer.Flush()
+	return nil
+}
+
+func tradeExportRecord(trade *models.Trade) []string {
+	executedAt := ""
+	if trade.ExecutedAt != nil {
+		executedAt = trade.ExecutedAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		strconv.Itoa(trade.ID),
+		executedAt,
+		trade.Symbol,
+		trade.AssetType,
+		trade.Side,
+		formatExportNumber(trade.Quantity),
+		formatExportNumber(trade.Multiplier),
+		formatExportNumber(trade.Price),
+		formatExportNumber(trade.Value(trade.Price)),
+		formatExportNumber(trade.Fees),
+		trade.Type,
+		trade.TriggerReason,
+		trade.Broker,
+		trade.BrokerOrderID,
+	}
+}
+
+func positionExportRecord(position *models.Position) []string {
+	return []string{
+		position.Symbol,
+		position.AssetType,
+		position.Side,
+		formatExportNumber(position.Quantity),
+		formatExportNumber(position.Multiplier),
+		formatExportNumber(position.EntryPrice),
+		formatExportNumber(position.Value(position.EntryPrice)),
+		formatExportNumber(position.CurrentPrice),
+		formatExportNumber(position.UnrealizedPnL),
+		formatExportNumber(position.RealizedPnL),
+		position.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func formatExportNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
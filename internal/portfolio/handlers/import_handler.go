@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/internal/portfolio/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ImportHandler struct {
+	service *service.ImportService
+	logger  *zap.Logger
+}
+
+func NewImportHandler(service *service.ImportService, logger *zap.Logger) *ImportHandler {
+	return &ImportHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateImport godoc
+// @Summary Upload brokerage history for import
+// @Description Upload trade history or positions exported by another brokerage, as a CSV body (Content-Type text/csv) or a multipart "file" field, and get a preview of the import: its columns, the suggested mapping of them to fields, validation errors and duplicates of trades already recorded. Nothing is applied until the import is started. Trade imports need date, symbol, quantity and price columns and may map side, fees and trade_id; position imports need symbol, quantity and price (cost per share) and may map date. OFX files are not supported yet.
+// @Tags imports
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param kind query string false "What the file holds" Enums(trades, positions) default(trades)
+// @Param format query string false "File format; OFX is inferred from .ofx and .qfx file names" Enums(csv, ofx) default(csv)
+// @Param adjust_cash query bool false "Whether imported trades move the portfolio's cash; positions never do" default(true)
+// @Param file formData file false "File to import, when uploading a form"
+// @Success 201 {object} ImportResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/import [post]
+func (h *ImportHandler) CreateImport(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+	adjustCash := true
+	if value := c.Query("adjust_cash"); value != "" {
+		if adjustCash, err = strconv.ParseBool(value); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid adjust_cash"})
+			return
+		}
+	}
+
+	filename, content, err := readImportFile(c)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apierror.Respond(c, http.StatusRequestEntityTooLarge, ErrorResponse{
+				Error: "File too large", Details: fmt.Sprintf("imports are limited to %d bytes", domain.MaxImportSize)})
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid upload", Details: err.Error()})
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = models.ImportFormatCSV
+		if ext := strings.ToLower(filepath.Ext(filename)); ext == ".ofx" || ext == ".qfx" {
+			format = models.ImportFormatOFX
+		}
+	}
+
+	imp, preview, err := h.service.CreateImport(c.Request.Context(), portfolioID, c.DefaultQuery("kind", models.ImportKindTrades),
+		format, filename, content, adjustCash, nil)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to create import", zap.Int("portfolio_id", portfolioID))
+		return
+	}
+
+	c.JSON(http.StatusCreated, ImportResponse{Import: imp, Preview: toImportPreviewResponse(preview)})
+}
+
+// GetImport godoc
+// @Summary Get an import
+// @Description Get an import with its status and, once started, how many rows have been processed, imported, skipped as duplicates or failed
+// @Tags imports
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param import_id path int true "Import ID"
+// @Success 200 {object} ImportResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/import/{import_id} [get]
+func (h *ImportHandler) GetImport(c *gin.Context) {
+	portfolioID, importID, ok := importIDs(c)
+	if !ok {
+		return
+	}
+
+	imp, err := h.service.GetImport(c.Request.Context(), portfolioID, importID)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to get import", zap.Int("import_id", importID))
+		return
+	}
+
+	c.JSON(http.StatusOK, ImportResponse{Import: imp})
+}
+
+// UpdateImportMapping godoc
+// @Summary Remap an import's columns
+// @Description Replace the mapping of an import's columns to fields before it is started, and get a fresh preview
+// @Tags imports
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param import_id path int true "Import ID"
+// @Param request body UpdateImportMappingRequest true "Column of each field"
+// @Success 200 {object} ImportResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/import/{import_id}/mapping [put]
+func (h *ImportHandler) UpdateImportMapping(c *gin.Context) {
+	portfolioID, importID, ok := importIDs(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateImportMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	imp, preview, err := h.service.UpdateMapping(c.Request.Context(), portfolioID, importID, req.Mapping, req.AdjustCash)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to update import mapping", zap.Int("import_id", importID))
+		return
+	}
+
+	c.JSON(http.StatusOK, ImportResponse{Import: imp, Preview: toImportPreviewResponse(preview)})
+}
+
+// StartImport godoc
+// @Summary Start an import
+// @Description Confirm an import and apply it in the background. Rows are applied in date order as filled trades, positions as buys at their cost; invalid rows and duplicates are skipped. Poll the import, or its job, for progress. Portfolios entered in a competition that hasn't finished cannot import.
+// @Tags imports
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param import_id path int true "Import ID"
+// @Success 202 {object} ImportResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse "Portfolio entered in a competition"
+// @Router /api/v1/portfolios/{id}/import/{import_id}/start [post]
+func (h *ImportHandler) StartImport(c *gin.Context) {
+	portfolioID, importID, ok := importIDs(c)
+	if !ok {
+		return
+	}
+
+	imp, err := h.service.StartImport(c.Request.Context(), portfolioID, importID)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to start import", zap.Int("import_id", importID))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, ImportResponse{Import: imp})
+}
+
+// readImportFile reads an uploaded file from a multipart form's "file" field
+// or, for other content types, the request body
+func readImportFile(c *gin.Context) (string, string, error) {
+	// Room for the form's own fields around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, domain.MaxImportSize+64<<10)
+
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		content, err := io.ReadAll(c.Request.Body)
+		if err == nil && len(content) > domain.MaxImportSize {
+			err = &http.MaxBytesError{Limit: domain.MaxImportSize}
+		}
+		return "", string(content), err
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return "", "", err
+	}
+	if header.Size > domain.MaxImportSize {
+		return "", "", &http.MaxBytesError{Limit: domain.MaxImportSize}
+	}
+	file, err := header.Open()
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	return header.Filename, string(content), err
+}
+
+// importIDs parses the portfolio and import IDs of the path, answering 400
+// when either is invalid
+func importIDs(c *gin.Context) (int, int, bool) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return 0, 0, false
+	}
+	importID, err := strconv.Atoi(c.Param("import_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid import ID"})
+		return 0, 0, false
+	}
+	return portfolioID, importID, true
+}
+
+func toImportPreviewResponse(preview *service.ImportPreview) *ImportPreviewResponse {
+	response := &ImportPreviewResponse{
+		MappingError:  preview.MappingError,
+		ValidRows:     preview.ValidRows,
+		InvalidRows:   preview.InvalidRows,
+		DuplicateRows: preview.DuplicateRows,
+		Rows:          preview.Rows,
+		Errors:        preview.Errors,
+	}
+	if response.Rows == nil {
+		response.Rows = []domain.ImportRow{}
+	}
+	if response.Errors == nil {
+		response.Errors = []models.ImportError{}
+	}
+	return response
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"hedge-fund/pkg/shared/httpclient"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/requestctx"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// MockMarketDataClient serves static prices and instrument metadata until the
+// Portfolio Service is wired to the Market Data Service
+type MockMarketDataClient struct {
+	prices      map[string]float64
+	instruments map[string]models.Instrument
+}
+
+func NewMockMarketDataClient() *MockMarketDataClient {
+	return &MockMarketDataClient{
+		prices: map[string]float64{
+			"AAPL":  188.25,
+			"GOOGL": 147.90,
+			"MSFT":  382.30,
+			"NVDA":  748.40,
+			"TSLA":  256.70,
+			"AMZN":  178.50,
+			"META":  485.20,
+			"SPY":   512.40,
+		},
+		instruments: map[string]models.Instrument{
+			"AAPL":  {Symbol: "AAPL", Name: "Apple Inc.", AssetClass: "equity", Sector: "Technology"},
+			"GOOGL": {Symbol: "GOOGL", Name: "Alphabet Inc.", AssetClass: "equity", Sector: "Communication Services"},
+			"MSFT":  {Symbol: "MSFT", Name: "Microsoft Corp.", AssetClass: "equity", Sector: "Technology"},
+			"NVDA":  {Symbol: "NVDA", Name: "NVIDIA Corp.", AssetClass: "equity", Sector: "Technology"},
+			"TSLA":  {Symbol: "TSLA", Name: "Tesla Inc.", AssetClass: "equity", Sector: "Consumer Cyclical"},
+			"AMZN":  {Symbol: "AMZN", Name: "Amazon.com Inc.", AssetClass: "equity", Sector: "Consumer Cyclical"},
+			"META":  {Symbol: "META", Name: "Meta Platforms Inc.", AssetClass: "equity", Sector: "Communication Services"},
+			"SPY":   {Symbol: "SPY", Name: "SPDR S&P 500 ETF Trust", AssetClass: "etf", Sector: "Index"},
+		},
+	}
+}
+
+// GetCurrentPrice returns the static price for a symbol
+func (m *MockMarketDataClient) GetCurrentPrice(symbol string) (float64, error) {
+	price, ok := m.prices[symbols.Normalize(symbol)]
+	if !ok {
+		return 0, fmt.Errorf("no price available for symbol: %s", symbol)
+	}
+	return price, nil
+}
+
+// GetCurrentPrices returns static prices for all known symbols in the list
+func (m *MockMarketDataClient) GetCurrentPrices(list []string) (map[string]float64, error) {
+	prices := make(map[string]float64, len(list))
+	for _, symbol := range list {
+		if price, ok := m.prices[symbols.Normalize(symbol)]; ok {
+			prices[symbol] = price
+		}
+	}
+	return prices, nil
+}
+
+// GetInstruments returns static metadata for all known symbols in the list
+func (m *MockMarketDataClient) GetInstruments(list []string) (map[string]models.Instrument, error) {
+	instruments := make(map[string]models.Instrument, len(list))
+	for _, symbol := range list {
+		if instrument, ok := m.instruments[symbols.Normalize(symbol)]; ok {
+			instruments[symbol] = instrument
+		}
+	}
+	return instruments, nil
+}
+
+// marketQuoteBatchSize is the most symbols quoted by one request, the Market
+// Data Service's bulk quote limit
+const marketQuoteBatchSize = 500
+
+// marketRequestTimeout bounds a request to the Market Data Service, retries
+// included; the client interface carries no request context
+const marketRequestTimeout = 10 * time.Second
+
+// HTTPMarketDataClient reads prices and instrument metadata from the Market
+// Data Service through a resilient HTTP client. Prices are the latest
+// stored daily closes.
+type HTTPMarketDataClient struct {
+	baseURL string
+	client  *httpclient.Client
+}
+
+func NewHTTPMarketDataClient(baseURL string, client *httpclient.Client) *HTTPMarketDataClient {
+	return &HTTPMarketDataClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}
+}
+
+// GetCurrentPrice returns the latest close of a symbol
+func (m *HTTPMarketDataClient) GetCurrentPrice(symbol string) (float64, error) {
+	prices, err := m.GetCurrentPrices([]string{symbol})
+	if err != nil {
+		return 0, err
+	}
+	price, ok := prices[symbol]
+	if !ok {
+		return 0, fmt.Errorf("no price available for symbol: %s", symbol)
+	}
+	return price, nil
+}
+
+// GetCurrentPrices returns the latest closes of the symbols in the list
+// that have one, quoting them in bulk a batch per request
+func (m *HTTPMarketDataClient) GetCurrentPrices(list []string) (map[string]float64, error) {
+	prices := make(map[string]float64, len(list))
+	for start := 0; start < len(list); start += marketQuoteBatchSize {
+		batch := list[start:min(start+marketQuoteBatchSize, len(list))]
+
+		var response struct {
+			Quotes map[string]struct {
+				Close float64 `json:"close"`
+			} `json:"quotes"`
+		}
+		if err := m.post("/api/v1/market/quotes", map[string][]string{"symbols": batch}, &response); err != nil {
+			return nil, err
+		}
+		for _, symbol := range batch {
+			if quote, ok := response.Quotes[symbols.Normalize(symbol)]; ok {
+				prices[symbol] = quote.Close
+			}
+		}
+	}
+	return prices, nil
+}
+
+// GetInstruments returns metadata for the symbols in the list the Market
+// Data Service knows
+func (m *HTTPMarketDataClient) GetInstruments(list []string) (map[string]models.Instrument, error) {
+	instruments := make(map[string]models.Instrument, len(list))
+	if len(list) == 0 {
+		return instruments, nil
+	}
+
+	var response []struct {
+		models.Instrument
+		Option *struct {
+			Underlying string  `json:"underlying"`
+			Type       string  `json:"option_type"`
+			Strike     float64 `json:"strike"`
+			Expiry     string  `json:"expiry"` // YYYY-MM-DD
+			Multiplier float64 `json:"multiplier"`
+		} `json:"option,omitempty"`
+	}
+	if err := m.get("/api/v1/market/instruments", list, &response); err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]models.Instrument, len(response))
+	for _, item := range response {
+		instrument := item.Instrument
+		if item.Option != nil {
+			expiry, err := time.Parse("2006-01-02", item.Option.Expiry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid expiry of %s: %w", instrument.Symbol, err)
+			}
+			instrument.Option = &models.OptionContract{
+				Underlying: item.Option.Underlying,
+				Type:       item.Option.Type,
+				Strike:     item.Option.Strike,
+				Expiry:     expiry,
+				Multiplier: item.Option.Multiplier,
+			}
+		}
+		known[instrument.Symbol] = instrument
+	}
+	for _, symbol := range list {
+		if instrument, ok := known[symbols.Normalize(symbol)]; ok {
+			instruments[symbol] = instrument
+		}
+	}
+	return instruments, nil
+}
+
+// get requests path for the symbols and decodes the JSON response into dest
+func (m *HTTPMarketDataClient) get(path string, list []string, dest interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), marketRequestTimeout)
+	defer cancel()
+
+	query := url.Values{"symbols": {strings.Join(list, ",")}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create market data request: %w", err)
+	}
+	return m.do(req, dest)
+}
+
+// post sends body as JSON to path and decodes the JSON response into dest.
+// It must only read, as it is retried like a GET.
+func (m *HTTPMarketDataClient) post(path string, body, dest interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), marketRequestTimeout)
+	defer cancel()
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode market data request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create market data request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(httpclient.IdempotencyKeyHeader, uuid.NewString())
+	return m.do(req, dest)
+}
+
+// do sends req and decodes the JSON response into dest
+func (m *HTTPMarketDataClient) do(req *http.Request, dest interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	requestctx.SetHeaders(req.Context(), req.Header)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("market data request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("market data service returned status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		return fmt.Errorf("failed to decode market data response: %w", err)
+	}
+	return nil
+}
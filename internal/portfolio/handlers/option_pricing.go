@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"fmt"
+
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// OptionPricingClient prices option contracts the upstream client has no
+// quote for at their intrinsic value, from the price of the underlying. The
+// time value of those contracts is not modelled. Instruments of options the
+// upstream client doesn't know are described from their OCC symbol.
+type OptionPricingClient struct {
+	upstream MarketDataClient
+}
+
+func NewOptionPricingClient(upstream MarketDataClient) *OptionPricingClient {
+	return &OptionPricingClient{upstream: upstream}
+}
+
+// GetCurrentPrice returns the quoted or intrinsic price of a symbol
+func (c *OptionPricingClient) GetCurrentPrice(symbol string) (float64, error) {
+	prices, err := c.GetCurrentPrices([]string{symbol})
+	if err != nil {
+		return 0, err
+	}
+	price, ok := prices[symbol]
+	if !ok {
+		return 0, fmt.Errorf("no price available for symbol: %s", symbol)
+	}
+	return price, nil
+}
+
+// GetCurrentPrices returns upstream prices for the symbols in the list, and
+// intrinsic prices for the options among them without a quote whose
+// underlying has one
+func (c *OptionPricingClient) GetCurrentPrices(list []string) (map[string]float64, error) {
+	prices, err := c.upstream.GetCurrentPrices(list)
+	if err != nil {
+		return nil, err
+	}
+
+	contracts := make(map[string]*models.OptionContract)
+	var underlyings []string
+	for _, symbol := range list {
+		if _, ok := prices[symbol]; ok {
+			continue
+		}
+		if contract, ok := symbols.ParseOption(symbols.Normalize(symbol)); ok {
+			contracts[symbol] = contract
+			underlyings = append(underlyings, contract.Underlying)
+		}
+	}
+	if len(contracts) == 0 {
+		return prices, nil
+	}
+
+	underlyingPrices, err := c.upstream.GetCurrentPrices(symbols.NormalizeAll(underlyings))
+	if err != nil {
+		return nil, err
+	}
+	for symbol, contract := range contracts {
+		if price, ok := underlyingPrices[contract.Underlying]; ok {
+			prices[symbol] = contract.IntrinsicValue(price)
+		}
+	}
+	return prices, nil
+}
+
+// GetInstruments returns upstream metadata for the symbols in the list, and
+// describes the options among them it doesn't know
+func (c *OptionPricingClient) GetInstruments(list []string) (map[string]models.Instrument, error) {
+	instruments, err := c.upstream.GetInstruments(list)
+	if err != nil {
+		return nil, err
+	}
+	for _, symbol := range list {
+		if _, ok := instruments[symbol]; ok {
+			continue
+		}
+		if contract, ok := symbols.ParseOption(symbols.Normalize(symbol)); ok {
+			instruments[symbol] = models.Instrument{
+				Symbol:     symbols.Normalize(symbol),
+				AssetClass: models.AssetTypeOption,
+				Option:     contract,
+			}
+		}
+	}
+	return instruments, nil
+}
@@ -1,58 +1,107 @@
 package handlers
 
 import (
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/internal/portfolio/repository"
 	"hedge-fund/internal/portfolio/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/auth"
 	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/symbols"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 type PortfolioHandler struct {
-	service      *service.PortfolioService
-	marketClient MarketDataClient
-	logger       *zap.Logger
+	service        *service.PortfolioService
+	marketClient   MarketDataClient
+	prices         MarketDataClient // Valuation prices for summaries, allocation and risk
+	secondFactor   SecondFactorCheck
+	tradeThreshold float64
+	logger         *zap.Logger
 }
 
-// MarketDataClient interface for getting market prices
+// SecondFactorCheck reports whether a request carries the second factor a
+// sensitive action needs, responding with an error when it does not
+type SecondFactorCheck func(c *gin.Context) bool
+
+// MarketDataClient interface for getting market prices and instrument metadata
 type MarketDataClient interface {
 	GetCurrentPrice(symbol string) (float64, error)
 	GetCurrentPrices(symbols []string) (map[string]float64, error)
+	GetInstruments(symbols []string) (map[string]models.Instrument, error)
 }
 
 func NewPortfolioHandler(service *service.PortfolioService, marketClient MarketDataClient, logger *zap.Logger) *PortfolioHandler {
 	return &PortfolioHandler{
 		service:      service,
 		marketClient: marketClient,
+		prices:       marketClient,
 		logger:       logger,
 	}
 }
 
+// SetPriceCache serves the prices read-only position, summary, allocation
+// and risk requests are valued at from cache. Trades and rebalances keep using the
+// market client directly.
+func (h *PortfolioHandler) SetPriceCache(cache *CachedMarketDataClient) {
+	h.prices = cache
+}
+
+// SetSecondFactor asks for a second factor on sensitive actions: trades
+// worth tradeThreshold or more, alone or in a rebalance, cash withdrawals
+// and transfers, cash lowered by an update, and settings that uncap risk
+// tolerance
+func (h *PortfolioHandler) SetSecondFactor(check SecondFactorCheck, tradeThreshold float64) {
+	h.secondFactor = check
+	h.tradeThreshold = tradeThreshold
+}
+
+// requireSecondFactor reports whether a sensitive action may go ahead
+func (h *PortfolioHandler) requireSecondFactor(c *gin.Context) bool {
+	return h.secondFactor == nil || h.secondFactor(c)
+}
+
 // CreatePortfolio godoc
 // @Summary Create a new portfolio
-// @Description Create a new portfolio for a user with initial cash
+// @Description Create a new portfolio for a user with initial cash, a description and strategy tags. A base currency is set as the portfolio's setting.
 // @Tags portfolios
 // @Accept json
 // @Produce json
 // @Param request body CreatePortfolioRequest true "Create Portfolio Request"
 // @Success 201 {object} PortfolioResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/portfolios [post]
 func (h *PortfolioHandler) CreatePortfolio(c *gin.Context) {
 	var req CreatePortfolioRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+	if !auth.CanAccess(c.Request.Context(), req.UserID) {
+		apierror.Respond(c, http.StatusForbidden, ErrorResponse{Error: "Forbidden", Details: "cannot create a portfolio for another user"})
 		return
 	}
 
-	portfolio, err := h.service.CreatePortfolio(c.Request.Context(), req.UserID, req.Name, req.InitialCash)
+	details := domain.PortfolioDetails{
+		Name:         req.Name,
+		Description:  req.Description,
+		BaseCurrency: req.BaseCurrency,
+		StrategyTags: req.StrategyTags,
+	}
+	portfolio, err := h.service.CreatePortfolio(c.Request.Context(), req.UserID, details, req.InitialCash)
 	if err != nil {
-		h.logger.Error("Failed to create portfolio", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create portfolio", Details: err.Error()})
+		writeError(c, h.logger, err, "Failed to create portfolio")
 		return
 	}
 
@@ -61,7 +110,7 @@ func (h *PortfolioHandler) CreatePortfolio(c *gin.Context) {
 
 // GetPortfolio godoc
 // @Summary Get portfolio by ID
-// @Description Get portfolio details including positions
+// @Description Get portfolio details including positions. The ETag header carries the portfolio's version for conditional updates.
 // @Tags portfolios
 // @Produce json
 // @Param id path int true "Portfolio ID"
@@ -72,61 +121,165 @@ func (h *PortfolioHandler) CreatePortfolio(c *gin.Context) {
 func (h *PortfolioHandler) GetPortfolio(c *gin.Context) {
 	portfolioID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
 		return
 	}
 
 	portfolio, err := h.service.GetPortfolio(c.Request.Context(), portfolioID)
 	if err != nil {
 		h.logger.Error("Failed to get portfolio", zap.Error(err), zap.Int("portfolio_id", portfolioID))
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Portfolio not found", Details: err.Error()})
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePortfolioNotFound, Error: "Portfolio not found", Details: err.Error()})
 		return
 	}
 
+	c.Header("ETag", portfolioETag(portfolio))
 	c.JSON(http.StatusOK, h.toPortfolioResponse(portfolio))
 }
 
 // UpdatePortfolio godoc
 // @Summary Update portfolio
-// @Description Update portfolio cash balance
+// @Description Set a portfolio's cash balance. The difference is deposited or withdrawn through the cash ledger, with the same checks as a cash transaction: a lower balance needs a second factor and may not dip into cash reserved for pending buys or margin, and portfolios entered in a competition that hasn't finished cannot change their cash. With dry_run=true the resulting balances are returned and nothing is saved.
 // @Tags portfolios
 // @Accept json
 // @Produce json
 // @Param id path int true "Portfolio ID"
+// @Param dry_run query bool false "Validate and project the update without saving it"
 // @Param request body UpdatePortfolioRequest true "Update Portfolio Request"
 // @Success 200 {object} PortfolioResponse
+// @Success 200 {object} CashUpdatePreviewResponse "Dry run"
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "A lower balance without X-2FA-Code or X-Trusted-Device"
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse "Insufficient available cash"
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/portfolios/{id} [put]
 func (h *PortfolioHandler) UpdatePortfolio(c *gin.Context) {
 	portfolioID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	dryRun, ok := parseDryRun(c)
+	if !ok {
 		return
 	}
 
 	var req UpdatePortfolioRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
 		return
 	}
 
 	portfolio, err := h.service.GetPortfolio(c.Request.Context(), portfolioID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Portfolio not found"})
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePortfolioNotFound, Error: "Portfolio not found"})
+		return
+	}
+
+	cashBefore := portfolio.Cash
+	if dryRun {
+		portfolio.Cash = req.Cash
+		c.JSON(http.StatusOK, CashUpdatePreviewResponse{
+			DryRun:     true,
+			CashBefore: cashBefore,
+			CashAfter:  portfolio.Cash,
+			Portfolio:  h.toPortfolioResponse(portfolio),
+		})
+		return
+	}
+
+	// Lowering the balance withdraws the difference
+	if req.Cash < cashBefore && !h.requireSecondFactor(c) {
+		return
+	}
+	if _, err := h.service.AdjustCash(c.Request.Context(), portfolioID, req.Cash, req.Note); err != nil {
+		writeError(c, h.logger, err, "Failed to update portfolio")
+		return
+	}
+
+	portfolio, err = h.service.GetPortfolio(c.Request.Context(), portfolioID)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to update portfolio")
+		return
+	}
+	c.JSON(http.StatusOK, h.toPortfolioResponse(portfolio))
+}
+
+// PatchPortfolio godoc
+// @Summary Partially update a portfolio
+// @Description Rename a portfolio or change its description, base currency, strategy tags or settings. Fields left out are unchanged; an empty base_currency inherits the owner's default again. With an If-Match header of the portfolio's ETag, or a version in the body, the patch is rejected with 409 if the portfolio has been updated since.
+// @Tags portfolios
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param If-Match header string false "ETag of the portfolio the patch is based on"
+// @Param request body PatchPortfolioRequest true "Portfolio patch"
+// @Success 200 {object} PortfolioResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "Aggressive risk tolerance without X-2FA-Code or X-Trusted-Device"
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id} [patch]
+func (h *PortfolioHandler) PatchPortfolio(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	var req PatchPortfolioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+	version := req.Version
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		if version, err = parseETag(ifMatch); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid If-Match header", Details: err.Error()})
+			return
+		}
+	}
+	if uncapsRisk(req.Settings) && !h.requireSecondFactor(c) {
 		return
 	}
 
-	portfolio.Cash = req.Cash
-	if err := h.service.UpdatePortfolio(c.Request.Context(), portfolio); err != nil {
-		h.logger.Error("Failed to update portfolio", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update portfolio", Details: err.Error()})
+	portfolio, err := h.service.PatchPortfolio(c.Request.Context(), portfolioID, domain.PortfolioPatch{
+		Name:         req.Name,
+		Description:  req.Description,
+		BaseCurrency: req.BaseCurrency,
+		StrategyTags: req.StrategyTags,
+		Settings:     req.Settings,
+		Version:      version,
+	})
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to update portfolio")
 		return
 	}
 
+	c.Header("ETag", portfolioETag(portfolio))
 	c.JSON(http.StatusOK, h.toPortfolioResponse(portfolio))
 }
 
+// portfolioETag is the entity tag of a portfolio's version
+func portfolioETag(portfolio *models.Portfolio) string {
+	return strconv.Quote(strconv.Itoa(portfolio.Version))
+}
+
+// parseETag reads the portfolio version of an If-Match header
+func parseETag(header string) (int, error) {
+	tag := strings.TrimPrefix(strings.TrimSpace(header), "W/")
+	if unquoted, err := strconv.Unquote(tag); err == nil {
+		tag = unquoted
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("%q is not a portfolio ETag", header)
+	}
+	return version, nil
+}
+
 // DeletePortfolio godoc
 // @Summary Delete portfolio
 // @Description Delete a portfolio and all its positions
@@ -139,13 +292,13 @@ func (h *PortfolioHandler) UpdatePortfolio(c *gin.Context) {
 func (h *PortfolioHandler) DeletePortfolio(c *gin.Context) {
 	portfolioID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
 		return
 	}
 
 	if err := h.service.DeletePortfolio(c.Request.Context(), portfolioID); err != nil {
 		h.logger.Error("Failed to delete portfolio", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete portfolio", Details: err.Error()})
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete portfolio", Details: err.Error()})
 		return
 	}
 
@@ -154,25 +307,34 @@ func (h *PortfolioHandler) DeletePortfolio(c *gin.Context) {
 
 // ListUserPortfolios godoc
 // @Summary List user portfolios
-// @Description Get all portfolios for a user
+// @Description Get a page of a user's portfolios, newest first by default
 // @Tags portfolios
 // @Produce json
 // @Param user_id path int true "User ID"
-// @Success 200 {array} PortfolioResponse
+// @Param limit query int false "Limit" default(50)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field" Enums(created_at, name, total_value) default(created_at)
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Success 200 {object} pagination.Page{data=[]PortfolioResponse}
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/portfolios/user/{user_id} [get]
 func (h *PortfolioHandler) ListUserPortfolios(c *gin.Context) {
 	userID, err := strconv.Atoi(c.Param("user_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
 		return
 	}
 
-	portfolios, err := h.service.GetUserPortfolios(c.Request.Context(), userID)
+	page, ok := parsePage(c, repository.PortfolioSorts, 50)
+	if !ok {
+		return
+	}
+
+	portfolios, result, err := h.service.ListUserPortfolios(c.Request.Context(), userID, page)
 	if err != nil {
 		h.logger.Error("Failed to list portfolios", zap.Error(err), zap.Int("user_id", userID))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list portfolios", Details: err.Error()})
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to list portfolios", Details: err.Error()})
 		return
 	}
 
@@ -181,7 +343,7 @@ func (h *PortfolioHandler) ListUserPortfolios(c *gin.Context) {
 		response[i] = h.toPortfolioResponse(&portfolio)
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, result.Page(response))
 }
 
 // GetPositions godoc
@@ -197,14 +359,14 @@ func (h *PortfolioHandler) ListUserPortfolios(c *gin.Context) {
 func (h *PortfolioHandler) GetPositions(c *gin.Context) {
 	portfolioID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
 		return
 	}
 
 	positions, err := h.service.GetPositions(c.Request.Context(), portfolioID)
 	if err != nil {
 		h.logger.Error("Failed to get positions", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get positions", Details: err.Error()})
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get positions", Details: err.Error()})
 		return
 	}
 
@@ -216,114 +378,406 @@ func (h *PortfolioHandler) GetPositions(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetPositionSummary godoc
+// @Summary Get position summary
+// @Description Get a position's market value, P&L and holding period with its open lots
+// @Tags portfolios
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param symbol path string true "Symbol"
+// @Success 200 {object} PositionSummaryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/positions/{symbol} [get]
+func (h *PortfolioHandler) GetPositionSummary(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+	symbol := symbols.Normalize(c.Param("symbol"))
+
+	portfolio, err := h.service.GetPortfolio(c.Request.Context(), portfolioID)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePortfolioNotFound, Error: "Portfolio not found"})
+		return
+	}
+
+	position, err := h.service.GetPosition(c.Request.Context(), portfolio.UserID, portfolioID, symbol)
+	if err != nil {
+		h.logger.Error("Failed to get position", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get position", Details: err.Error()})
+		return
+	}
+	if position == nil {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePositionNotFound, Error: "Position not found"})
+		return
+	}
+
+	currentPrice, err := h.prices.GetCurrentPrice(symbol)
+	if err != nil {
+		h.logger.Error("Failed to get current price", zap.Error(err), zap.String("symbol", symbol))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market price", Details: err.Error()})
+		return
+	}
+
+	summary, err := h.service.GetPositionSummary(c.Request.Context(), position.ID, currentPrice)
+	if err != nil {
+		h.logger.Error("Failed to calculate position summary", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to calculate position summary", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toPositionSummaryResponse(summary))
+}
+
+// GetPositionLots godoc
+// @Summary Get position lots
+// @Description Get the lots of the buys averaged into a position, oldest first, with the quantity each has left and its weight in the average entry price. Lots since sold are included.
+// @Tags portfolios
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param symbol path string true "Symbol"
+// @Success 200 {object} LotBreakdownResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/positions/{symbol}/lots [get]
+func (h *PortfolioHandler) GetPositionLots(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+	symbol := symbols.Normalize(c.Param("symbol"))
+
+	portfolio, err := h.service.GetPortfolio(c.Request.Context(), portfolioID)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePortfolioNotFound, Error: "Portfolio not found"})
+		return
+	}
+
+	position, err := h.service.GetPosition(c.Request.Context(), portfolio.UserID, portfolioID, symbol)
+	if err != nil {
+		h.logger.Error("Failed to get position", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get position", Details: err.Error()})
+		return
+	}
+	if position == nil {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePositionNotFound, Error: "Position not found"})
+		return
+	}
+
+	breakdown, err := h.service.GetPositionLots(c.Request.Context(), position.ID)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to get position lots")
+		return
+	}
+
+	c.JSON(http.StatusOK, toLotBreakdownResponse(breakdown))
+}
+
 // GetSummary godoc
 // @Summary Get portfolio summary
-// @Description Get portfolio summary with current market prices
+// @Description Get portfolio summary with current market prices. Summaries are cached until the portfolio trades or a holding's price moves materially; the ETag header identifies the summary's content, and a request whose If-None-Match matches it gets 304.
 // @Tags portfolios
 // @Produce json
 // @Param id path int true "Portfolio ID"
+// @Param If-None-Match header string false "ETag of a summary already held"
 // @Success 200 {object} SummaryResponse
+// @Success 304 "Summary unchanged"
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/portfolios/{id}/summary [get]
 func (h *PortfolioHandler) GetSummary(c *gin.Context) {
 	portfolioID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
 		return
 	}
 
-	// Get portfolio
-	portfolio, err := h.service.GetPortfolio(c.Request.Context(), portfolioID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Portfolio not found"})
+	cached := h.service.GetCachedSummary(c.Request.Context(), portfolioID)
+	if cached == nil {
+		// Get portfolio
+		portfolio, err := h.service.GetPortfolioForRead(c.Request.Context(), portfolioID)
+		if err != nil {
+			apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePortfolioNotFound, Error: "Portfolio not found"})
+			return
+		}
+
+		// Get current prices for all positions
+		symbols := make([]string, len(portfolio.Positions))
+		for i, pos := range portfolio.Positions {
+			symbols[i] = pos.Symbol
+		}
+
+		currentPrices, err := h.prices.GetCurrentPrices(symbols)
+		if err != nil {
+			h.logger.Error("Failed to get current prices", zap.Error(err))
+			apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market prices"})
+			return
+		}
+
+		// For now, use empty previous day prices (will be implemented with Market Data Service)
+		previousDayPrices := make(map[string]float64)
+
+		cached = h.service.SummarizePortfolio(c.Request.Context(), portfolio, currentPrices, previousDayPrices)
+	}
+
+	// Clients revalidate every time, so a trade is never hidden behind a stale copy
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("ETag", cached.ETag)
+	if etagMatches(c.GetHeader("If-None-Match"), cached.ETag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
+	c.JSON(http.StatusOK, h.toSummaryResponse(&cached.Summary))
+}
 
-	// Get current prices for all positions
-	symbols := make([]string, len(portfolio.Positions))
-	for i, pos := range portfolio.Positions {
-		symbols[i] = pos.Symbol
+// etagMatches reports whether an If-None-Match header lists etag, comparing
+// weakly as the header requires
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetUserSummaries godoc
+// @Summary Get summaries of all user portfolios
+// @Description Get headline summaries for every portfolio of a user in one call. Prices are fetched once for the union of symbols held across the portfolios.
+// @Tags portfolios
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {object} UserSummariesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/portfolios/summary [get]
+func (h *PortfolioHandler) GetUserSummaries(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
 	}
 
-	currentPrices, err := h.marketClient.GetCurrentPrices(symbols)
+	portfolios, err := h.service.GetUserPortfolios(c.Request.Context(), userID)
 	if err != nil {
-		h.logger.Error("Failed to get current prices", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market prices"})
+		h.logger.Error("Failed to list portfolios", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to list portfolios", Details: err.Error()})
 		return
 	}
 
-	// For now, use empty previous day prices (will be implemented with Market Data Service)
+	// One price lookup for every symbol held in any of the portfolios
+	symbols := heldSymbols(portfolios)
+	currentPrices := make(map[string]float64)
+	if len(symbols) > 0 {
+		currentPrices, err = h.prices.GetCurrentPrices(symbols)
+		if err != nil {
+			h.logger.Error("Failed to get current prices", zap.Error(err))
+			apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market prices"})
+			return
+		}
+	}
+
+	// Previous day prices are not available yet, as in GetSummary
 	previousDayPrices := make(map[string]float64)
 
-	summary, err := h.service.CalculatePortfolioSummary(c.Request.Context(), portfolioID, currentPrices, previousDayPrices)
+	summaries := h.service.SummarizePortfolios(portfolios, currentPrices, previousDayPrices)
+
+	response := UserSummariesResponse{
+		UserID:     userID,
+		Portfolios: make([]PortfolioSummaryEntry, len(portfolios)),
+	}
+	for i, portfolio := range portfolios {
+		response.Portfolios[i] = PortfolioSummaryEntry{
+			PortfolioID:     portfolio.ID,
+			Name:            portfolio.Name,
+			SummaryResponse: h.toSummaryResponse(&summaries[i]),
+		}
+		response.TotalValue += summaries[i].TotalValue
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetUserOverview godoc
+// @Summary Get a consolidated overview of all user portfolios
+// @Description Aggregate total value, cash and PnL across every portfolio of a user, with the combined allocation and risk metrics of their positions netted by symbol, and a per-portfolio breakdown
+// @Tags portfolios
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {object} UserOverviewResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/overview [get]
+func (h *PortfolioHandler) GetUserOverview(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	portfolios, err := h.service.GetUserPortfolios(c.Request.Context(), userID)
 	if err != nil {
-		h.logger.Error("Failed to calculate summary", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to calculate summary", Details: err.Error()})
+		h.logger.Error("Failed to list portfolios", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to list portfolios", Details: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, h.toSummaryResponse(summary))
+	symbols := heldSymbols(portfolios)
+	currentPrices := make(map[string]float64)
+	sectors := make(map[string]string)
+	if len(symbols) > 0 {
+		currentPrices, err = h.prices.GetCurrentPrices(symbols)
+		if err != nil {
+			h.logger.Error("Failed to get current prices", zap.Error(err))
+			apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market prices"})
+			return
+		}
+
+		// Sector metadata is best-effort, as in GetRiskMetrics
+		instruments, err := h.marketClient.GetInstruments(symbols)
+		if err != nil {
+			h.logger.Warn("Failed to get instrument metadata", zap.Error(err))
+		}
+		for symbol, instrument := range instruments {
+			sectors[symbol] = instrument.Sector
+		}
+	}
+
+	// Previous day prices are not available yet, as in GetSummary
+	previousDayPrices := make(map[string]float64)
+
+	overview := h.service.OverviewPortfolios(userID, portfolios, currentPrices, previousDayPrices, sectors)
+
+	response := UserOverviewResponse{
+		UserID:     userID,
+		Summary:    h.toSummaryResponse(&overview.Summary),
+		Allocation: toAllocationResponse(overview.Allocation, overview.Summary.TotalValue),
+		Risk:       toRiskMetricsResponse(overview.Risk),
+		Portfolios: make([]PortfolioOverviewEntry, len(overview.Portfolios)),
+	}
+	for i, entry := range overview.Portfolios {
+		response.Portfolios[i] = PortfolioOverviewEntry{
+			PortfolioID:     entry.Portfolio.ID,
+			Name:            entry.Portfolio.Name,
+			Weight:          entry.Weight,
+			SummaryResponse: h.toSummaryResponse(&entry.Summary),
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // ExecuteTrade godoc
 // @Summary Execute trade
-// @Description Execute a buy or sell trade order
+// @Description Execute a buy or sell trade order. time_in_force is day (the default), gtc, ioc or fok; IOC orders may fill in part and FOK orders fill in full or not at all. With dry_run=true the order is validated and its fees and resulting balances are returned without trading.
 // @Tags portfolios
 // @Accept json
 // @Produce json
 // @Param id path int true "Portfolio ID"
+// @Param dry_run query bool false "Validate and project the trade without executing it"
 // @Param request body TradeRequest true "Trade Request"
 // @Success 200 {object} TradeResponse
+// @Success 200 {object} TradePreviewResponse "Dry run"
+// @Success 202 {object} TradeResponse "Live order routed to the broker, fill pending"
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "A trade from the two-factor threshold without X-2FA-Code or X-Trusted-Device"
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Market price moved beyond the slippage tolerance"
+// @Failure 422 {object} ErrorResponse "Insufficient cash or shares, a position above the risk tolerance limit, or an IOC or FOK order left unfilled"
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/portfolios/{id}/trades [post]
 func (h *PortfolioHandler) ExecuteTrade(c *gin.Context) {
 	portfolioID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	dryRun, ok := parseDryRun(c)
+	if !ok {
 		return
 	}
 
 	var req TradeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+	req.Symbol = symbols.Normalize(req.Symbol)
+	if err := symbols.Validate(req.Symbol); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
 		return
 	}
 
 	// Get portfolio to get user_id
 	portfolio, err := h.service.GetPortfolio(c.Request.Context(), portfolioID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Portfolio not found"})
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePortfolioNotFound, Error: "Portfolio not found"})
 		return
 	}
 
+	if req.OrderType == "" {
+		settings, err := h.service.GetSettings(c.Request.Context(), portfolioID)
+		if err != nil {
+			writeError(c, h.logger, err, "Failed to get default order type")
+			return
+		}
+		req.OrderType = settings.DefaultOrderType
+	}
+
 	// Get current price from market data
 	currentPrice := req.Price
 	if req.OrderType == "market" {
 		currentPrice, err = h.marketClient.GetCurrentPrice(req.Symbol)
 		if err != nil {
 			h.logger.Error("Failed to get current price", zap.Error(err), zap.String("symbol", req.Symbol))
-			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market price", Details: err.Error()})
+			apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market price", Details: err.Error()})
 			return
 		}
 	}
 
 	// Create trade object
 	trade := &models.Trade{
-		UserID:   portfolio.UserID,
-		Symbol:   req.Symbol,
-		Quantity: req.Quantity,
-		Side:     req.Side,
-		Type:     req.OrderType,
-		Status:   "pending",
+		UserID:      portfolio.UserID,
+		Symbol:      req.Symbol,
+		Quantity:    req.Quantity,
+		Side:        req.Side,
+		Type:        req.OrderType,
+		Status:      "pending",
+		TimeInForce: strings.ToLower(req.TimeInForce),
+	}
+
+	if dryRun {
+		preview, err := h.service.PreviewTrade(c.Request.Context(), portfolioID, trade, currentPrice)
+		if err != nil {
+			writeError(c, h.logger, err, "Trade would be rejected")
+			return
+		}
+		c.JSON(http.StatusOK, h.toTradePreviewResponse(preview))
+		return
+	}
+
+	// Option prices are per share; a contract is worth its multiplier in shares
+	if req.Quantity*currentPrice*domain.Multiplier(req.Symbol) >= h.tradeThreshold && !h.requireSecondFactor(c) {
+		return
 	}
 
 	// Execute trade
 	position, err := h.service.ExecuteTrade(c.Request.Context(), portfolioID, trade, currentPrice)
 	if err != nil {
-		h.logger.Error("Failed to execute trade", zap.Error(err))
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Failed to execute trade", Details: err.Error()})
+		writeError(c, h.logger, err, "Failed to execute trade")
+		return
+	}
+
+	// Live orders are accepted here and filled asynchronously by the broker
+	if trade.Status == "pending" {
+		c.JSON(http.StatusAccepted, h.toTradeResponse(trade, position))
 		return
 	}
 
@@ -331,8 +785,8 @@ func (h *PortfolioHandler) ExecuteTrade(c *gin.Context) {
 		zap.Int("portfolio_id", portfolioID),
 		zap.String("symbol", req.Symbol),
 		zap.String("side", req.Side),
-		zap.Int64("quantity", req.Quantity),
-		zap.Float64("price", currentPrice))
+		zap.Float64("quantity", req.Quantity),
+		zap.Float64("price", trade.Price))
 
 	c.JSON(http.StatusOK, h.toTradeResponse(trade, position))
 }
@@ -344,39 +798,34 @@ func (h *PortfolioHandler) ExecuteTrade(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Portfolio ID"
 // @Param limit query int false "Limit" default(50)
-// @Param offset query int false "Offset" default(0)
-// @Success 200 {array} TradeResponse
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field" Enums(created_at, symbol, quantity, price) default(created_at)
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Success 200 {object} pagination.Page{data=[]TradeResponse}
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/portfolios/{id}/trades [get]
 func (h *PortfolioHandler) GetTradeHistory(c *gin.Context) {
 	portfolioID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
 		return
 	}
 
-	// Get portfolio to get user_id
-	portfolio, err := h.service.GetPortfolio(c.Request.Context(), portfolioID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Portfolio not found"})
+	if _, err := h.service.GetPortfolio(c.Request.Context(), portfolioID); err != nil {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePortfolioNotFound, Error: "Portfolio not found"})
 		return
 	}
 
-	limit := 50
-	if l := c.Query("limit"); l != "" {
-		limit, _ = strconv.Atoi(l)
-	}
-
-	offset := 0
-	if o := c.Query("offset"); o != "" {
-		offset, _ = strconv.Atoi(o)
+	page, ok := parsePage(c, repository.TradeSorts, 50)
+	if !ok {
+		return
 	}
 
-	trades, err := h.service.GetTradeHistory(c.Request.Context(), portfolio.UserID, limit, offset)
+	trades, result, err := h.service.ListTradeHistory(c.Request.Context(), portfolioID, page)
 	if err != nil {
 		h.logger.Error("Failed to get trade history", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get trade history", Details: err.Error()})
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get trade history", Details: err.Error()})
 		return
 	}
 
@@ -385,7 +834,7 @@ func (h *PortfolioHandler) GetTradeHistory(c *gin.Context) {
 		response[i] = h.toTradeResponse(&trade, nil)
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, result.Page(response))
 }
 
 // GetAllocation godoc
@@ -401,14 +850,14 @@ func (h *PortfolioHandler) GetTradeHistory(c *gin.Context) {
 func (h *PortfolioHandler) GetAllocation(c *gin.Context) {
 	portfolioID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
 		return
 	}
 
 	// Get portfolio
-	portfolio, err := h.service.GetPortfolio(c.Request.Context(), portfolioID)
+	portfolio, err := h.service.GetPortfolioForRead(c.Request.Context(), portfolioID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Portfolio not found"})
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePortfolioNotFound, Error: "Portfolio not found"})
 		return
 	}
 
@@ -418,17 +867,17 @@ func (h *PortfolioHandler) GetAllocation(c *gin.Context) {
 		symbols[i] = pos.Symbol
 	}
 
-	currentPrices, err := h.marketClient.GetCurrentPrices(symbols)
+	currentPrices, err := h.prices.GetCurrentPrices(symbols)
 	if err != nil {
 		h.logger.Error("Failed to get current prices", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market prices"})
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market prices"})
 		return
 	}
 
 	allocations, err := h.service.GetPortfolioAllocation(c.Request.Context(), portfolioID, currentPrices)
 	if err != nil {
 		h.logger.Error("Failed to get allocation", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get allocation", Details: err.Error()})
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get allocation", Details: err.Error()})
 		return
 	}
 
@@ -436,27 +885,16 @@ func (h *PortfolioHandler) GetAllocation(c *gin.Context) {
 	totalValue := portfolio.Cash
 	for _, pos := range portfolio.Positions {
 		if price, ok := currentPrices[pos.Symbol]; ok {
-			totalValue += float64(pos.Quantity) * price
+			totalValue += pos.Value(price)
 		}
 	}
 
-	// Convert to response
-	response := make([]AllocationResponse, 0, len(allocations))
-	for symbol, percentage := range allocations {
-		value := (percentage / 100) * totalValue
-		response = append(response, AllocationResponse{
-			Symbol:     symbol,
-			Percentage: percentage,
-			Value:      value,
-		})
-	}
-
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, toAllocationResponse(allocations, totalValue))
 }
 
 // GetRiskMetrics godoc
 // @Summary Get risk metrics
-// @Description Get portfolio risk metrics
+// @Description Get portfolio risk metrics, including beta to the portfolio's benchmark and size, momentum and value exposures estimated from stored daily bars
 // @Tags portfolios
 // @Produce json
 // @Param id path int true "Portfolio ID"
@@ -467,14 +905,14 @@ func (h *PortfolioHandler) GetAllocation(c *gin.Context) {
 func (h *PortfolioHandler) GetRiskMetrics(c *gin.Context) {
 	portfolioID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
 		return
 	}
 
 	// Get portfolio
 	portfolio, err := h.service.GetPortfolio(c.Request.Context(), portfolioID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Portfolio not found"})
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePortfolioNotFound, Error: "Portfolio not found"})
 		return
 	}
 
@@ -484,31 +922,208 @@ func (h *PortfolioHandler) GetRiskMetrics(c *gin.Context) {
 		symbols[i] = pos.Symbol
 	}
 
-	currentPrices, err := h.marketClient.GetCurrentPrices(symbols)
+	currentPrices, err := h.prices.GetCurrentPrices(symbols)
 	if err != nil {
 		h.logger.Error("Failed to get current prices", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market prices"})
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market prices"})
 		return
 	}
 
-	metrics, err := h.service.GetRiskMetrics(c.Request.Context(), portfolioID, currentPrices)
+	// Sector metadata is best-effort; positions without it are reported as "Unknown"
+	sectors := make(map[string]string)
+	instruments, err := h.marketClient.GetInstruments(symbols)
+	if err != nil {
+		h.logger.Warn("Failed to get instrument metadata", zap.Error(err))
+	}
+	for symbol, instrument := range instruments {
+		sectors[symbol] = instrument.Sector
+	}
+
+	metrics, err := h.service.GetRiskMetrics(c.Request.Context(), portfolioID, currentPrices, sectors)
 	if err != nil {
 		h.logger.Error("Failed to get risk metrics", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get risk metrics", Details: err.Error()})
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get risk metrics", Details: err.Error()})
 		return
 	}
 
-	response := RiskMetricsResponse{
-		TotalValue:           metrics["total_value"].(float64),
-		PositionCount:        metrics["position_count"].(int),
-		MaxPositionPercent:   metrics["max_position_percent"].(float64),
-		CashPercent:          metrics["cash_percent"].(float64),
-		DiversificationScore: metrics["diversification_score"].(float64),
-	}
+	c.JSON(http.StatusOK, toRiskMetricsResponse(metrics))
+}
+
+// GetFactorHistory godoc
+// @Summary Get a portfolio's factor exposure history
+// @Description Get a portfolio's beta to its benchmark and its size, momentum and value exposures as recorded each day with its snapshot, for trend charts
+// @Tags portfolios
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param days query int false "Days of history" default(90)
+// @Success 200 {object} FactorHistoryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/risk/history [get]
+func (h *PortfolioHandler) GetFactorHistory(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	days := service.DefaultFactorHistoryDays
+	if d := c.Query("days"); d != "" {
+		days, err = strconv.Atoi(d)
+		if err != nil || days <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid days"})
+			return
+		}
+	}
+
+	exposures, err := h.service.GetFactorHistory(c.Request.Context(), portfolioID, days)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to get factor history", zap.Int("portfolio_id", portfolioID))
+		return
+	}
 
+	response := FactorHistoryResponse{
+		PortfolioID: portfolioID,
+		Exposures:   make([]FactorHistoryPoint, 0, len(exposures)),
+	}
+	for _, exposure := range exposures {
+		response.Exposures = append(response.Exposures, FactorHistoryPoint{
+			Date:      exposure.ExposureDate,
+			Benchmark: exposure.Benchmark,
+			Beta:      exposure.Beta,
+			Size:      exposure.Size,
+			Momentum:  exposure.Momentum,
+			Value:     exposure.Value,
+		})
+	}
 	c.JSON(http.StatusOK, response)
 }
 
+// SetStopLoss godoc
+// @Summary Set position stop-loss
+// @Description Set a long position's stop-loss as a percentage below its entry price or an absolute price. When a price update reaches it, the position is closed with a market order whose trigger_reason is stop_loss. Leaving out both clears the stop-loss.
+// @Tags portfolios
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param symbol path string true "Symbol"
+// @Param request body SetStopLossRequest true "Stop-loss"
+// @Success 200 {object} PositionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/positions/{symbol}/stop-loss [put]
+func (h *PortfolioHandler) SetStopLoss(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	var req SetStopLossRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	position, err := h.service.SetStopLoss(c.Request.Context(), portfolioID, c.Param("symbol"), req.Percent, req.Price)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to set stop-loss", zap.Int("portfolio_id", portfolioID))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.toPositionResponse(position))
+}
+
+// GetPerformance godoc
+// @Summary Get performance
+// @Description Get the portfolio's annualized return, volatility, Sharpe and Sortino ratios and maximum drawdown from its daily snapshots. Returns are time-weighted, so deposits, withdrawals and transfers are neither gains nor losses. Excess returns are taken over the configured risk-free rate for the period.
+// @Tags portfolios
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param days query int false "Number of daily returns" default(252)
+// @Success 200 {object} PerformanceResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/performance [get]
+func (h *PortfolioHandler) GetPerformance(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	days := service.DefaultPerformanceDays
+	if d := c.Query("days"); d != "" {
+		days, err = strconv.Atoi(d)
+		if err != nil || days <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid days"})
+			return
+		}
+	}
+
+	performance, err := h.service.GetPerformance(c.Request.Context(), portfolioID, days)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to get performance", zap.Int("portfolio_id", portfolioID))
+		return
+	}
+
+	c.JSON(http.StatusOK, PerformanceResponse{
+		PortfolioID:      performance.PortfolioID,
+		StartDate:        performance.StartDate,
+		EndDate:          performance.EndDate,
+		Observations:     performance.Observations,
+		TotalReturn:      performance.TotalReturn,
+		AnnualizedReturn: performance.AnnualizedReturn,
+		Volatility:       performance.Volatility,
+		RiskFreeRate:     performance.RiskFreeRate,
+		SharpeRatio:      performance.SharpeRatio,
+		SortinoRatio:     performance.SortinoRatio,
+		MaxDrawdown:      performance.MaxDrawdown,
+	})
+}
+
+// GetDrawdown godoc
+// @Summary Get drawdowns
+// @Description Get the portfolio's maximum drawdown with its peak, trough and recovery dates, its drawdown periods and durations, and the underwater curve of its daily snapshots for charting. Drawdowns are measured on time-weighted returns, so deposits, withdrawals and transfers neither open nor hide them.
+// @Tags portfolios
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param days query int false "Number of daily snapshots after the first" default(252)
+// @Success 200 {object} models.DrawdownAnalysis
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/drawdown [get]
+func (h *PortfolioHandler) GetDrawdown(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	days := service.DefaultPerformanceDays
+	if d := c.Query("days"); d != "" {
+		days, err = strconv.Atoi(d)
+		if err != nil || days <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid days"})
+			return
+		}
+	}
+
+	analysis, err := h.service.GetDrawdown(c.Request.Context(), portfolioID, days)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to get drawdown", zap.Int("portfolio_id", portfolioID))
+		return
+	}
+
+	c.JSON(http.StatusOK, analysis)
+}
+
 // GetRebalanceRecommendations godoc
 // @Summary Get rebalancing recommendations
 // @Description Get recommendations for rebalancing portfolio
@@ -524,31 +1139,194 @@ func (h *PortfolioHandler) GetRiskMetrics(c *gin.Context) {
 func (h *PortfolioHandler) GetRebalanceRecommendations(c *gin.Context) {
 	portfolioID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
 		return
 	}
 
 	var req RebalanceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+	if req.TargetAllocations, err = normalizeAllocations(req.TargetAllocations); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
 		return
 	}
 
 	// Get portfolio
 	portfolio, err := h.service.GetPortfolio(c.Request.Context(), portfolioID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Portfolio not found"})
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePortfolioNotFound, Error: "Portfolio not found"})
 		return
 	}
 
 	// Get current prices
+	currentPrices, err := h.marketClient.GetCurrentPrices(rebalanceSymbols(portfolio, req.TargetAllocations))
+	if err != nil {
+		h.logger.Error("Failed to get current prices", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market prices"})
+		return
+	}
+
+	recommendations, err := h.service.GetRebalanceRecommendations(c.Request.Context(), portfolioID, req.TargetAllocations, currentPrices)
+	if err != nil {
+		h.logger.Error("Failed to get rebalance recommendations", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get recommendations", Details: err.Error()})
+		return
+	}
+
+	// Convert to response
+	response := make([]RebalanceRecommendation, len(recommendations))
+	for i, rec := range recommendations {
+		response[i] = RebalanceRecommendation{
+			Symbol:          rec["symbol"].(string),
+			CurrentPercent:  rec["current_percent"].(float64),
+			TargetPercent:   rec["target_percent"].(float64),
+			Difference:      rec["difference"].(float64),
+			TargetValue:     rec["target_value"].(float64),
+			CurrentValue:    rec["current_value"].(float64),
+			Action:          rec["action"].(string),
+			EstimatedShares: rec["estimated_shares"].(float64),
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ExecuteRebalance godoc
+// @Summary Execute rebalancing
+// @Description Execute the rebalancing recommendations as market orders, sells first to free cash. The trades are applied all-or-nothing and the status of each is reported. With dry_run the trades are only simulated. A rebalance with a trade worth the two-factor threshold or more needs a second factor.
+// @Tags portfolios
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param dry_run query bool false "Validate and simulate without trading; same as dry_run in the body"
+// @Param request body ExecuteRebalanceRequest true "Rebalance Request"
+// @Success 200 {object} RebalanceExecutionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "A trade from the threshold without X-2FA-Code or X-Trusted-Device"
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} RebalanceExecutionResponse "A trade was rejected and nothing was applied"
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/rebalance/execute [post]
+func (h *PortfolioHandler) ExecuteRebalance(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	var req ExecuteRebalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+	req.DryRun = req.DryRun || dryRun
+	if req.TargetAllocations, err = normalizeAllocations(req.TargetAllocations); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	portfolio, err := h.service.GetPortfolio(c.Request.Context(), portfolioID)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePortfolioNotFound, Error: "Portfolio not found"})
+		return
+	}
+
+	currentPrices, err := h.marketClient.GetCurrentPrices(rebalanceSymbols(portfolio, req.TargetAllocations))
+	if err != nil {
+		h.logger.Error("Failed to get current prices", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market prices"})
+		return
+	}
+
+	// Large trades need a second factor however they are placed
+	if !req.DryRun && h.secondFactor != nil {
+		preview, err := h.service.ExecuteRebalance(c.Request.Context(), portfolioID, req.TargetAllocations, currentPrices, true)
+		if err != nil {
+			writeError(c, h.logger, err, "Failed to execute rebalance", zap.Int("portfolio_id", portfolioID))
+			return
+		}
+		if largestRebalanceTrade(preview, currentPrices) >= h.tradeThreshold && !h.requireSecondFactor(c) {
+			return
+		}
+	}
+
+	result, err := h.service.ExecuteRebalance(c.Request.Context(), portfolioID, req.TargetAllocations, currentPrices, req.DryRun)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to execute rebalance", zap.Int("portfolio_id", portfolioID))
+		return
+	}
+
+	response := RebalanceExecutionResponse{
+		PortfolioID: result.PortfolioID,
+		DryRun:      result.DryRun,
+		Applied:     result.Applied,
+		CashBefore:  result.CashBefore,
+		CashAfter:   result.CashAfter,
+		Trades:      make([]RebalanceTradeStatus, len(result.Trades)),
+	}
+	rejected := false
+	for i, t := range result.Trades {
+		response.Trades[i] = RebalanceTradeStatus{
+			Symbol:   t.Trade.Symbol,
+			Side:     t.Trade.Side,
+			Quantity: t.Trade.Quantity,
+			Price:    t.Trade.Price,
+			Fees:     t.Trade.Fees,
+			TradeID:  t.Trade.ID,
+			Status:   t.Status,
+			Error:    t.Error,
+		}
+		if t.Status == service.RebalanceTradeFailed {
+			rejected = true
+		}
+	}
+
+	if rejected {
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// largestRebalanceTrade returns the value of a rebalance's largest trade
+func largestRebalanceTrade(result *service.RebalanceResult, currentPrices map[string]float64) float64 {
+	largest := 0.0
+	for _, t := range result.Trades {
+		largest = math.Max(largest, t.Trade.Quantity*currentPrices[t.Trade.Symbol]*domain.Multiplier(t.Trade.Symbol))
+	}
+	return largest
+}
+
+// normalizeAllocations normalizes the symbols of target allocations, adding
+// up the targets of spellings of the same symbol
+func normalizeAllocations(targetAllocations map[string]float64) (map[string]float64, error) {
+	normalized := make(map[string]float64, len(targetAllocations))
+	for symbol, percent := range targetAllocations {
+		symbol = symbols.Normalize(symbol)
+		if err := symbols.Validate(symbol); err != nil {
+			return nil, err
+		}
+		normalized[symbol] += percent
+	}
+	return normalized, nil
+}
+
+// rebalanceSymbols returns the symbols held by a portfolio plus those of the
+// target allocations it does not hold yet
+func rebalanceSymbols(portfolio *models.Portfolio, targetAllocations map[string]float64) []string {
 	symbols := make([]string, len(portfolio.Positions))
 	for i, pos := range portfolio.Positions {
 		symbols[i] = pos.Symbol
 	}
 
-	// Add symbols from target allocations that might not be in portfolio
-	for symbol := range req.TargetAllocations {
+	for symbol := range targetAllocations {
 		found := false
 		for _, s := range symbols {
 			if s == symbol {
@@ -560,51 +1338,284 @@ func (h *PortfolioHandler) GetRebalanceRecommendations(c *gin.Context) {
 			symbols = append(symbols, symbol)
 		}
 	}
+	return symbols
+}
 
-	currentPrices, err := h.marketClient.GetCurrentPrices(symbols)
+// GetAuditTrail godoc
+// @Summary Get portfolio audit trail
+// @Description Get the append-only log of mutations to a portfolio, its positions and trades
+// @Tags portfolios
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param limit query int false "Limit" default(100)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Success 200 {object} pagination.Page{data=[]AuditEventResponse}
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/audit [get]
+func (h *PortfolioHandler) GetAuditTrail(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		h.logger.Error("Failed to get current prices", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get market prices"})
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
 		return
 	}
 
-	recommendations, err := h.service.GetRebalanceRecommendations(c.Request.Context(), portfolioID, req.TargetAllocations, currentPrices)
+	page, ok := parsePage(c, repository.AuditSorts, 100)
+	if !ok {
+		return
+	}
+
+	// Audit history outlives the portfolio, so a missing portfolio is not an error
+	events, result, err := h.service.GetAuditTrail(c.Request.Context(), portfolioID, page)
 	if err != nil {
-		h.logger.Error("Failed to get rebalance recommendations", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get recommendations", Details: err.Error()})
+		h.logger.Error("Failed to get audit trail", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get audit trail", Details: err.Error()})
 		return
 	}
 
-	// Convert to response
-	response := make([]RebalanceRecommendation, len(recommendations))
-	for i, rec := range recommendations {
-		response[i] = RebalanceRecommendation{
-			Symbol:          rec["symbol"].(string),
-			CurrentPercent:  rec["current_percent"].(float64),
-			TargetPercent:   rec["target_percent"].(float64),
-			Difference:      rec["difference"].(float64),
-			TargetValue:     rec["target_value"].(float64),
-			CurrentValue:    rec["current_value"].(float64),
-			Action:          rec["action"].(string),
-			EstimatedShares: rec["estimated_shares"].(int64),
+	response := make([]AuditEventResponse, len(events))
+	for i, event := range events {
+		response[i] = AuditEventResponse{
+			ID:          event.ID,
+			PortfolioID: event.PortfolioID,
+			EntityType:  event.EntityType,
+			EntityID:    event.EntityID,
+			Action:      event.Action,
+			Actor:       event.Actor,
+			RequestID:   event.RequestID,
+			Before:      event.Before,
+			After:       event.After,
+			CreatedAt:   event.CreatedAt,
 		}
 	}
 
+	c.JSON(http.StatusOK, result.Page(response))
+}
+
+// GetTradeEvents godoc
+// @Summary Get trade event stream
+// @Description Get trade execution events (validated, filled, rejected, cancelled) oldest first, starting after a cursor
+// @Tags portfolios
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param after_id query int false "Return events with id greater than this cursor" default(0)
+// @Param limit query int false "Limit" default(100)
+// @Success 200 {object} TradeEventsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/trade-events [get]
+func (h *PortfolioHandler) GetTradeEvents(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	var afterID int64
+	if a := c.Query("after_id"); a != "" {
+		afterID, err = strconv.ParseInt(a, 10, 64)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid after_id"})
+			return
+		}
+	}
+
+	limit := 100
+	if l := c.Query("limit"); l != "" {
+		limit, _ = strconv.Atoi(l)
+	}
+
+	events, err := h.service.GetTradeEvents(c.Request.Context(), portfolioID, afterID, limit)
+	if err != nil {
+		h.logger.Error("Failed to get trade events", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get trade events", Details: err.Error()})
+		return
+	}
+
+	response := TradeEventsResponse{
+		Events:     make([]TradeEventResponse, len(events)),
+		NextCursor: afterID,
+	}
+	for i, event := range events {
+		response.Events[i] = TradeEventResponse{
+			ID:          event.ID,
+			PortfolioID: event.PortfolioID,
+			TradeID:     event.TradeID,
+			Symbol:      event.Symbol,
+			EventType:   event.EventType,
+			Side:        event.Side,
+			Quantity:    event.Quantity,
+			Price:       event.Price,
+			Fees:        event.Fees,
+			Reason:      event.Reason,
+			RequestID:   event.RequestID,
+			CreatedAt:   event.CreatedAt,
+		}
+		response.NextCursor = event.ID
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
+// ReplayTradeEvents godoc
+// @Summary Replay trade events
+// @Description Rebuild positions from the trade event stream and compare them with stored positions
+// @Tags portfolios
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Success 200 {object} ReplayResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/trade-events/replay [get]
+func (h *PortfolioHandler) ReplayTradeEvents(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	result, err := h.service.ReplayPositions(c.Request.Context(), portfolioID)
+	if err != nil {
+		h.logger.Error("Failed to replay trade events", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to replay trade events", Details: err.Error()})
+		return
+	}
+
+	response := ReplayResponse{
+		PortfolioID:   result.PortfolioID,
+		EventsApplied: result.EventsApplied,
+		LastEventID:   result.LastEventID,
+		Rebuilt:       make([]PositionResponse, len(result.Rebuilt)),
+		Stored:        make([]PositionResponse, len(result.Stored)),
+		Consistent:    len(result.Discrepancies) == 0,
+		Discrepancies: result.Discrepancies,
+	}
+	for i := range result.Rebuilt {
+		response.Rebuilt[i] = h.toPositionResponse(&result.Rebuilt[i])
+	}
+	for i := range result.Stored {
+		response.Stored[i] = h.toPositionResponse(&result.Stored[i])
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// parsePage reads a list endpoint's pagination parameters, responding with
+// 400 when they are invalid
+func parsePage(c *gin.Context, sorts pagination.Sorts, defaultLimit int) (pagination.Request, bool) {
+	page, err := pagination.Parse(c.Request.URL.Query(), sorts, defaultLimit)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid pagination parameters", Details: err.Error()})
+		return pagination.Request{}, false
+	}
+	return page, true
+}
+
+// parseDryRun reads the dry_run query parameter, responding with 400 when it
+// is not a boolean
+func parseDryRun(c *gin.Context) (bool, bool) {
+	value := c.Query("dry_run")
+	if value == "" {
+		return false, true
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid dry_run parameter", Details: err.Error()})
+		return false, false
+	}
+	return dryRun, true
+}
+
+// heldSymbols lists every symbol held in any of the portfolios once
+func heldSymbols(portfolios []models.Portfolio) []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, portfolio := range portfolios {
+		for _, pos := range portfolio.Positions {
+			if !seen[pos.Symbol] {
+				seen[pos.Symbol] = true
+				symbols = append(symbols, pos.Symbol)
+			}
+		}
+	}
+	return symbols
+}
+
 // Helper functions to convert domain models to response DTOs
 
+func toPositionSummaryResponse(summary *models.PositionSummary) PositionSummaryResponse {
+	lots := make([]LotResponse, len(summary.Lots))
+	for i, lot := range summary.Lots {
+		lots[i] = toLotResponse(lot)
+	}
+
+	return PositionSummaryResponse{
+		Symbol:             summary.Symbol,
+		Quantity:           summary.NetQuantity,
+		AveragePrice:       summary.AveragePrice,
+		CurrentPrice:       summary.CurrentPrice,
+		MarketValue:        summary.MarketValue,
+		UnrealizedPnL:      summary.UnrealizedPnL,
+		UnrealizedReturn:   summary.UnrealizedReturn,
+		EntryDate:          summary.EntryDate,
+		DaysHeld:           summary.DaysHeld,
+		AverageHoldingDays: summary.AvgHoldingDays,
+		Lots:               lots,
+	}
+}
+
+func toLotResponse(lot models.PositionLot) LotResponse {
+	return LotResponse{
+		ID:                lot.ID,
+		TradeID:           lot.TradeID,
+		Quantity:          lot.Quantity,
+		RemainingQuantity: lot.RemainingQuantity,
+		Price:             lot.Price,
+		AcquiredAt:        lot.AcquiredAt,
+	}
+}
+
+func toLotBreakdownResponse(breakdown *models.LotBreakdown) LotBreakdownResponse {
+	lots := make([]LotHistoryResponse, len(breakdown.Lots))
+	for i, lot := range breakdown.Lots {
+		lots[i] = LotHistoryResponse{
+			LotResponse: toLotResponse(lot.PositionLot),
+			ClosedAt:    lot.ClosedAt,
+			Weight:      lot.Weight,
+		}
+	}
+
+	return LotBreakdownResponse{
+		Symbol:            breakdown.Symbol,
+		Quantity:          breakdown.Quantity,
+		AveragePrice:      breakdown.AveragePrice,
+		LotQuantity:       breakdown.LotQuantity,
+		LotAveragePrice:   breakdown.LotAveragePrice,
+		UntrackedQuantity: breakdown.UntrackedQuantity,
+		CostBasisMethod:   breakdown.CostBasisMethod,
+		Lots:              lots,
+	}
+}
+
 func (h *PortfolioHandler) toPortfolioResponse(portfolio *models.Portfolio) PortfolioResponse {
 	positions := make([]PositionResponse, len(portfolio.Positions))
 	for i, pos := range portfolio.Positions {
 		positions[i] = h.toPositionResponse(&pos)
 	}
 
+	strategyTags := portfolio.StrategyTags
+	if strategyTags == nil {
+		strategyTags = []string{}
+	}
+
 	return PortfolioResponse{
 		ID:              portfolio.ID,
 		UserID:          portfolio.UserID,
 		Name:            portfolio.Name,
+		Description:     portfolio.Description,
+		StrategyTags:    strategyTags,
+		Version:         portfolio.Version,
 		Cash:            portfolio.Cash,
 		MarginUsed:      portfolio.MarginUsed,
 		MarginAvailable: portfolio.MarginAvailable,
@@ -623,34 +1634,73 @@ func (h *PortfolioHandler) toPositionResponse(position *models.Position) Positio
 		ID:            position.ID,
 		PortfolioID:   position.PortfolioID,
 		Symbol:        position.Symbol,
+		AssetType:     position.AssetType,
 		Quantity:      position.Quantity,
+		Multiplier:    position.Multiplier,
 		Side:          position.Side,
 		EntryPrice:    position.EntryPrice,
 		CurrentPrice:  position.CurrentPrice,
 		UnrealizedPnL: position.UnrealizedPnL,
 		RealizedPnL:   position.RealizedPnL,
+		StopLoss:      toStopLossResponse(position),
+		PreviousClose: position.PreviousClose,
+		DayOpenPrice:  position.DayOpenPrice,
 		CreatedAt:     position.CreatedAt,
 		UpdatedAt:     position.UpdatedAt,
 	}
 }
 
+func toStopLossResponse(position *models.Position) *StopLossResponse {
+	trigger, ok := position.StopLossTrigger()
+	if !ok {
+		return nil
+	}
+	return &StopLossResponse{
+		Percent:      position.StopLossPercent,
+		Price:        position.StopLossPrice,
+		TriggerPrice: trigger,
+	}
+}
+
 func (h *PortfolioHandler) toTradeResponse(trade *models.Trade, position *models.Position) TradeResponse {
 	return TradeResponse{
-		ID:          trade.ID,
-		PortfolioID: trade.PortfolioID,
-		PositionID:  trade.PositionID,
-		Symbol:      trade.Symbol,
-		Quantity:    trade.Quantity,
-		Price:       trade.Price,
-		Side:        trade.Side,
-		Type:        trade.Type,
-		Status:      trade.Status,
-		Fees:        trade.Fees,
-		ExecutedAt:  trade.ExecutedAt,
-		CreatedAt:   trade.CreatedAt,
+		ID:            trade.ID,
+		PortfolioID:   trade.PortfolioID,
+		PositionID:    trade.PositionID,
+		Symbol:        trade.Symbol,
+		AssetType:     trade.AssetType,
+		Quantity:      trade.Quantity,
+		Multiplier:    trade.Multiplier,
+		Price:         trade.Price,
+		Side:          trade.Side,
+		Type:          trade.Type,
+		Status:        trade.Status,
+		TimeInForce:   trade.TimeInForce,
+		Fees:          trade.Fees,
+		FeeItems:      trade.FeeItems,
+		Broker:        trade.Broker,
+		BrokerOrderID: trade.BrokerOrderID,
+		TriggerReason: trade.TriggerReason,
+		ExecutedAt:    trade.ExecutedAt,
+		CreatedAt:     trade.CreatedAt,
 	}
 }
 
+func (h *PortfolioHandler) toTradePreviewResponse(preview *service.TradePreview) TradePreviewResponse {
+	response := TradePreviewResponse{
+		DryRun:     true,
+		Trade:      h.toTradeResponse(preview.Trade, preview.Position),
+		Venue:      preview.Venue,
+		CashBefore: preview.CashBefore,
+		CashAfter:  preview.CashAfter,
+	}
+	if preview.Position != nil {
+		position := h.toPositionResponse(preview.Position)
+		response.Position = &position
+	}
+	return response
+}
+
 func (h *PortfolioHandler) toSummaryResponse(summary *models.PortfolioSummary) SummaryResponse {
 	return SummaryResponse{
 		TotalValue:     summary.TotalValue,
@@ -664,3 +1714,52 @@ func (h *PortfolioHandler) toSummaryResponse(summary *models.PortfolioSummary) S
 		PositionCount:  summary.PositionCount,
 	}
 }
+
+func toAllocationResponse(allocations map[string]float64, totalValue float64) []AllocationResponse {
+	response := make([]AllocationResponse, 0, len(allocations))
+	for symbol, percentage := range allocations {
+		response = append(response, AllocationResponse{
+			Symbol:     symbol,
+			Percentage: percentage,
+			Value:      (percentage / 100) * totalValue,
+		})
+	}
+	return response
+}
+
+func toRiskMetricsResponse(metrics map[string]interface{}) RiskMetricsResponse {
+	response := RiskMetricsResponse{
+		TotalValue:           metrics["total_value"].(float64),
+		PositionCount:        metrics["position_count"].(int),
+		MaxPositionPercent:   metrics["max_position_percent"].(float64),
+		CashPercent:          metrics["cash_percent"].(float64),
+		DiversificationScore: metrics["diversification_score"].(float64),
+		LongExposure:         metrics["long_exposure"].(float64),
+		ShortExposure:        metrics["short_exposure"].(float64),
+		GrossExposure:        metrics["gross_exposure"].(float64),
+		NetExposure:          metrics["net_exposure"].(float64),
+		LongShortRatio:       metrics["long_short_ratio"].(float64),
+		SectorExposure:       metrics["sector_exposure"].(map[string]float64),
+		PositionFactors:      []PositionFactorResponse{},
+	}
+
+	// Factor exposure is left out when the bars it needs can't be read
+	exposure, ok := metrics["factor_exposure"].(*models.FactorExposure)
+	if !ok {
+		return response
+	}
+	response.Benchmark = exposure.Benchmark
+	response.Beta = exposure.Beta
+	response.Factors = FactorExposureResponse{Size: exposure.Size, Momentum: exposure.Momentum, Value: exposure.Value}
+	for _, position := range exposure.Positions {
+		response.PositionFactors = append(response.PositionFactors, PositionFactorResponse{
+			Symbol:   position.Symbol,
+			Weight:   position.Weight,
+			Beta:     position.Beta,
+			Size:     position.Size,
+			Momentum: position.Momentum,
+			Value:    position.Value,
+		})
+	}
+	return response
+}
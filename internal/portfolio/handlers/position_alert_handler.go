@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreatePositionAlert godoc
+// @Summary Create a position alert
+// @Description Notify the portfolio owner when the price of a position's symbol moves up_percent above or down_percent below the position's entry price. The levels follow the entry price as the position is added to. An alert fires once, through the notification service with the position and levels in the message, and is deleted with the position when it closes.
+// @Tags portfolios
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param request body PositionAlertRequest true "Position alert"
+// @Success 201 {object} PositionAlertResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/position-alerts [post]
+func (h *PortfolioHandler) CreatePositionAlert(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	var req PositionAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+	if req.Symbol == "" {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: "symbol is required"})
+		return
+	}
+
+	alert := &models.PositionAlert{
+		UpPercent:   req.UpPercent,
+		DownPercent: req.DownPercent,
+		Note:        req.Note,
+	}
+	if err := h.service.CreatePositionAlert(c.Request.Context(), portfolioID, req.Symbol, alert); err != nil {
+		writeError(c, h.logger, err, "Failed to create position alert")
+		return
+	}
+
+	c.JSON(http.StatusCreated, toPositionAlertResponse(alert))
+}
+
+// ListPositionAlerts godoc
+// @Summary List position alerts
+// @Description List a portfolio's position alerts, those that have fired included
+// @Tags portfolios
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param symbol query string false "Only alerts on this symbol's position"
+// @Success 200 {array} PositionAlertResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/position-alerts [get]
+func (h *PortfolioHandler) ListPositionAlerts(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	alerts, err := h.service.GetPositionAlerts(c.Request.Context(), portfolioID, c.Query("symbol"))
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to list position alerts")
+		return
+	}
+
+	response := make([]PositionAlertResponse, len(alerts))
+	for i := range alerts {
+		response[i] = toPositionAlertResponse(&alerts[i])
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetPositionAlert godoc
+// @Summary Get a position alert
+// @Tags portfolios
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param alert_id path int true "Position alert ID"
+// @Success 200 {object} PositionAlertResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/position-alerts/{alert_id} [get]
+func (h *PortfolioHandler) GetPositionAlert(c *gin.Context) {
+	alert, ok := h.loadPositionAlert(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, toPositionAlertResponse(alert))
+}
+
+// UpdatePositionAlert godoc
+// @Summary Update a position alert
+// @Description Replace a position alert's levels, note and active state. Leaving it active re-arms an alert that has fired.
+// @Tags portfolios
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param alert_id path int true "Position alert ID"
+// @Param request body PositionAlertRequest true "Position alert"
+// @Success 200 {object} PositionAlertResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/position-alerts/{alert_id} [put]
+func (h *PortfolioHandler) UpdatePositionAlert(c *gin.Context) {
+	alert, ok := h.loadPositionAlert(c)
+	if !ok {
+		return
+	}
+
+	var req PositionAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	alert.UpPercent = req.UpPercent
+	alert.DownPercent = req.DownPercent
+	alert.Note = req.Note
+	alert.IsActive = req.IsActive == nil || *req.IsActive
+	if err := h.service.UpdatePositionAlert(c.Request.Context(), alert); err != nil {
+		writeError(c, h.logger, err, "Failed to update position alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, toPositionAlertResponse(alert))
+}
+
+// DeletePositionAlert godoc
+// @Summary Delete a position alert
+// @Tags portfolios
+// @Param id path int true "Portfolio ID"
+// @Param alert_id path int true "Position alert ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/position-alerts/{alert_id} [delete]
+func (h *PortfolioHandler) DeletePositionAlert(c *gin.Context) {
+	alert, ok := h.loadPositionAlert(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeletePositionAlert(c.Request.Context(), alert.ID); err != nil {
+		writeError(c, h.logger, err, "Failed to delete position alert")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// loadPositionAlert resolves the alert in the path, responding with an
+// error unless it belongs to the portfolio in the path
+func (h *PortfolioHandler) loadPositionAlert(c *gin.Context) (*models.PositionAlert, bool) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return nil, false
+	}
+	alertID, err := strconv.Atoi(c.Param("alert_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid alert ID"})
+		return nil, false
+	}
+
+	alert, err := h.service.GetPositionAlert(c.Request.Context(), alertID)
+	if err != nil || alert.PortfolioID != portfolioID {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Position alert not found"})
+		return nil, false
+	}
+	return alert, true
+}
+
+func toPositionAlertResponse(alert *models.PositionAlert) PositionAlertResponse {
+	response := PositionAlertResponse{
+		ID:             alert.ID,
+		PortfolioID:    alert.PortfolioID,
+		PositionID:     alert.PositionID,
+		Symbol:         alert.Symbol,
+		UpPercent:      alert.UpPercent,
+		DownPercent:    alert.DownPercent,
+		EntryPrice:     alert.EntryPrice,
+		Note:           alert.Note,
+		IsActive:       alert.IsActive,
+		TriggeredAt:    alert.TriggeredAt,
+		TriggeredPrice: alert.TriggeredPrice,
+		CreatedAt:      alert.CreatedAt,
+		UpdatedAt:      alert.UpdatedAt,
+	}
+	up, down := alert.Levels()
+	if alert.UpPercent != nil {
+		response.UpPrice = &up
+	}
+	if alert.DownPercent != nil {
+		response.DownPrice = &down
+	}
+	return response
+}
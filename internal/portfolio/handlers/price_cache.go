@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// priceFetchTimeout bounds cache reads and background refreshes, which are
+// not tied to a request
+const priceFetchTimeout = 5 * time.Second
+
+// pricePart narrows a symbol's cache namespace to the price cached here. It
+// is a key of its own, so the shared market:{symbol} entry is never
+// overwritten with a price alone.
+const pricePart = "portfolio_price"
+
+// priceTTL bounds how long a cached price can be served stale
+const priceTTL = time.Minute
+
+// cachedPrice is a symbol's price as it is cached
+type cachedPrice struct {
+	Price     float64   `json:"price"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CachedMarketDataClient serves prices from a Redis cache shared by every
+// Portfolio Service instance. Prices updated within freshFor are served as
+// is. Older prices still in the cache are served stale while one background
+// fetch refreshes them.
+// Symbols missing from the cache are fetched upstream in a single batched
+// call per request. Instruments are passed through to the upstream client.
+type CachedMarketDataClient struct {
+	upstream MarketDataClient
+	redis    *redis.Client
+	freshFor time.Duration
+	logger   *zap.Logger
+
+	mu         sync.Mutex
+	refreshing map[string]bool
+}
+
+func NewCachedMarketDataClient(upstream MarketDataClient, redisClient *redis.Client, freshFor time.Duration, logger *zap.Logger) *CachedMarketDataClient {
+	return &CachedMarketDataClient{
+		upstream:   upstream,
+		redis:      redisClient,
+		freshFor:   freshFor,
+		logger:     logger,
+		refreshing: make(map[string]bool),
+	}
+}
+
+// GetCurrentPrice returns the cached price for a symbol
+func (c *CachedMarketDataClient) GetCurrentPrice(symbol string) (float64, error) {
+	prices, err := c.GetCurrentPrices([]string{symbol})
+	if err != nil {
+		return 0, err
+	}
+	price, ok := prices[symbol]
+	if !ok {
+		return 0, fmt.Errorf("no price available for symbol: %s", symbol)
+	}
+	return price, nil
+}
+
+// GetCurrentPrices returns prices for all symbols in the list that are
+// cached or available upstream. A cache outage falls back to upstream.
+func (c *CachedMarketDataClient) GetCurrentPrices(list []string) (map[string]float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), priceFetchTimeout)
+	defer cancel()
+
+	cached, err := c.load(ctx, list)
+	if err != nil {
+		c.logger.Warn("Price cache unavailable, fetching upstream", zap.Error(err))
+		cached = map[string]cachedPrice{}
+	}
+
+	prices := make(map[string]float64, len(list))
+	var missing, stale []string
+	now := time.Now()
+	for _, symbol := range list {
+		entry, ok := cached[symbol]
+		if !ok || entry.Price <= 0 {
+			missing = append(missing, symbol)
+			continue
+		}
+		prices[symbol] = entry.Price
+		if now.Sub(entry.UpdatedAt) > c.freshFor {
+			stale = append(stale, symbol)
+		}
+	}
+
+	if len(missing) > 0 {
+		fetched, err := c.upstream.GetCurrentPrices(missing)
+		if err != nil {
+			return nil, err
+		}
+		c.store(ctx, fetched)
+		for symbol, price := range fetched {
+			prices[symbol] = price
+		}
+	}
+
+	if len(stale) > 0 {
+		c.revalidate(stale)
+	}
+
+	return prices, nil
+}
+
+// GetInstruments returns instrument metadata from the upstream client
+func (c *CachedMarketDataClient) GetInstruments(list []string) (map[string]models.Instrument, error) {
+	return c.upstream.GetInstruments(list)
+}
+
+// revalidate refreshes stale symbols in the background with one upstream
+// call, skipping symbols a previous refresh is still fetching
+func (c *CachedMarketDataClient) revalidate(stale []string) {
+	c.mu.Lock()
+	var list []string
+	for _, symbol := range stale {
+		if !c.refreshing[symbol] {
+			c.refreshing[symbol] = true
+			list = append(list, symbol)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(list) == 0 {
+		return
+	}
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			for _, symbol := range list {
+				delete(c.refreshing, symbol)
+			}
+			c.mu.Unlock()
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), priceFetchTimeout)
+		defer cancel()
+
+		fetched, err := c.upstream.GetCurrentPrices(list)
+		if err != nil {
+			c.logger.Warn("Failed to refresh stale prices", zap.Error(err), zap.Strings("symbols", list))
+			return
+		}
+		c.store(ctx, fetched)
+	}()
+}
+
+// load reads the cached prices of the symbols in one round trip, keyed by
+// the symbols as given. Symbols that are not cached are left out.
+func (c *CachedMarketDataClient) load(ctx context.Context, list []string) (map[string]cachedPrice, error) {
+	found := make(map[string]cachedPrice, len(list))
+	if len(list) == 0 {
+		return found, nil
+	}
+
+	keys := make([]string, len(list))
+	for i, symbol := range list {
+		keys[i] = redis.SymbolKey(symbol, pricePart)
+	}
+	values, err := c.redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached prices: %w", err)
+	}
+
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue // Not cached
+		}
+		var entry cachedPrice
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			c.logger.Warn("Discarding unreadable cached price", zap.String("key", keys[i]), zap.Error(err))
+			continue
+		}
+		found[list[i]] = entry
+	}
+	return found, nil
+}
+
+// store writes fetched prices to the cache for every instance reading it
+func (c *CachedMarketDataClient) store(ctx context.Context, prices map[string]float64) {
+	now := time.Now()
+	for symbol, price := range prices {
+		entry := cachedPrice{Price: price, UpdatedAt: now}
+		if err := c.redis.SetCache(ctx, redis.SymbolKey(symbol, pricePart), entry, priceTTL); err != nil {
+			c.logger.Warn("Failed to cache price", zap.Error(err), zap.String("symbol", symbol))
+		}
+	}
+}
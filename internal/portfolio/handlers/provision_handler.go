@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProvisionUsers godoc
+// @Summary Bulk-create users with seeded paper portfolios
+// @Description Create users for demos, load tests or classroom competitions, each with a paper portfolio holding starting cash and optional preset positions. Send a JSON spec, or CSV (Content-Type text/csv) with a username column and optional email, full_name, starting_cash, symbol, quantity and price columns; a username repeated on further rows gets more positions. Batch defaults for CSV are taken from the query. Generated passwords are returned once and never stored in clear.
+// @Tags admin
+// @Accept json
+// @Accept text/csv
+// @Produce json
+// @Param request body domain.ProvisionSpec false "Provisioning spec"
+// @Param starting_cash query number false "Default starting cash (CSV)"
+// @Param password query string false "Shared password (CSV); generated per user when omitted"
+// @Param role query string false "trader, viewer or admin (CSV)" default(trader)
+// @Param portfolio_name query string false "Portfolio name (CSV)"
+// @Param email_domain query string false "Domain of generated emails (CSV)"
+// @Success 201 {object} domain.ProvisionResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/provision [post]
+func (h *PortfolioHandler) ProvisionUsers(c *gin.Context) {
+	var (
+		spec *domain.ProvisionSpec
+		err  error
+	)
+	if strings.HasPrefix(c.ContentType(), "text/csv") {
+		defaults := &domain.ProvisionSpec{
+			Password:      c.Query("password"),
+			Role:          c.Query("role"),
+			PortfolioName: c.Query("portfolio_name"),
+			EmailDomain:   c.Query("email_domain"),
+		}
+		if value := c.Query("starting_cash"); value != "" {
+			cash, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid starting_cash"})
+				return
+			}
+			defaults.StartingCash = &cash
+		}
+		spec, err = domain.ParseProvisionCSV(c.Request.Body, defaults)
+	} else {
+		spec, err = domain.ParseProvisionJSON(c.Request.Body)
+	}
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to provision users")
+		return
+	}
+
+	result, err := h.service.Provision(c.Request.Context(), spec)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to provision users")
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/internal/portfolio/repository"
+	"hedge-fund/internal/portfolio/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ReconciliationHandler struct {
+	service *service.ReconciliationService
+	logger  *zap.Logger
+}
+
+func NewReconciliationHandler(service *service.ReconciliationService, logger *zap.Logger) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// LinkBrokerAccount godoc
+// @Summary Link a broker account
+// @Description Mark a portfolio as mirrored at a broker account so it is reconciled daily. In live mode its orders are routed to the broker.
+// @Tags reconciliation
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param request body LinkBrokerAccountRequest true "Broker account"
+// @Success 200 {object} BrokerAccountResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "Without X-2FA-Code or X-Trusted-Device"
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/broker-account [put]
+func (h *ReconciliationHandler) LinkBrokerAccount(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	var req LinkBrokerAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	tradingMode := req.TradingMode
+	if tradingMode == "" {
+		tradingMode = models.TradingModePaper
+	}
+
+	account, err := h.service.LinkBrokerAccount(c.Request.Context(), portfolioID, strings.TrimSpace(req.Broker), strings.TrimSpace(req.AccountID), tradingMode)
+	if err != nil {
+		if strings.Contains(err.Error(), "not configured") {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Broker not available for live trading", Details: err.Error()})
+			return
+		}
+		writeError(c, h.logger, err, "Failed to link broker account", zap.Int("portfolio_id", portfolioID))
+		return
+	}
+
+	c.JSON(http.StatusOK, BrokerAccountResponse{
+		PortfolioID: account.PortfolioID,
+		Broker:      account.Broker,
+		AccountID:   account.AccountID,
+		TradingMode: account.TradingMode,
+		CreatedAt:   account.CreatedAt,
+	})
+}
+
+// RunReconciliation godoc
+// @Summary Reconcile against the broker
+// @Description Compare a day's trades and current positions with the broker statement, auto-fixing fee and rounding differences
+// @Tags reconciliation
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param date query string false "Statement date (YYYY-MM-DD), defaults to today (UTC)"
+// @Success 200 {object} ReconciliationRunResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/reconciliations [post]
+func (h *ReconciliationHandler) RunReconciliation(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	statementDate := time.Now().UTC()
+	if d := c.Query("date"); d != "" {
+		statementDate, err = time.Parse("2006-01-02", d)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+
+	run, err := h.service.Reconcile(c.Request.Context(), portfolioID, statementDate)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Portfolio has no linked broker account", Details: err.Error()})
+		case errors.Is(err, domain.ErrConflict):
+			apierror.Respond(c, http.StatusConflict, ErrorResponse{Error: "Reconciliation already running", Details: err.Error()})
+		default:
+			h.logger.Error("Failed to reconcile portfolio", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+			apierror.Respond(c, http.StatusBadGateway, ErrorResponse{Error: "Failed to reconcile portfolio", Details: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, toReconciliationRunResponse(run))
+}
+
+// ListReconciliations godoc
+// @Summary List reconciliation runs
+// @Description Get a page of a portfolio's reconciliation runs, newest statement first by default
+// @Tags reconciliation
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param limit query int false "Limit" default(30)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Success 200 {object} pagination.Page{data=[]ReconciliationRunResponse}
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/reconciliations [get]
+func (h *ReconciliationHandler) ListReconciliations(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	page, ok := parsePage(c, repository.ReconciliationSorts, 30)
+	if !ok {
+		return
+	}
+
+	runs, result, err := h.service.GetRuns(c.Request.Context(), portfolioID, page)
+	if err != nil {
+		h.logger.Error("Failed to list reconciliations", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to list reconciliations", Details: err.Error()})
+		return
+	}
+
+	response := make([]ReconciliationRunResponse, len(runs))
+	for i := range runs {
+		response[i] = toReconciliationRunResponse(&runs[i])
+	}
+
+	c.JSON(http.StatusOK, result.Page(response))
+}
+
+// GetReconciliationReport godoc
+// @Summary Get a reconciliation report
+// @Description Get a reconciliation run with every break found, auto-fixed or open
+// @Tags reconciliation
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param run_id path int true "Reconciliation run ID"
+// @Success 200 {object} ReconciliationRunResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/reconciliations/{run_id} [get]
+func (h *ReconciliationHandler) GetReconciliationReport(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+	runID, err := strconv.Atoi(c.Param("run_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid run ID"})
+		return
+	}
+
+	run, err := h.service.GetRun(c.Request.Context(), runID)
+	if err != nil || run.PortfolioID != portfolioID {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Reconciliation run not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toReconciliationRunResponse(run))
+}
+
+func toReconciliationRunResponse(run *models.ReconciliationRun) ReconciliationRunResponse {
+	response := ReconciliationRunResponse{
+		ID:               run.ID,
+		PortfolioID:      run.PortfolioID,
+		StatementDate:    run.StatementDate.Format("2006-01-02"),
+		Status:           run.Status,
+		PositionsChecked: run.PositionsChecked,
+		TradesChecked:    run.TradesChecked,
+		AutoFixed:        run.AutoFixed,
+		OpenBreaks:       run.OpenBreaks,
+		Error:            run.Error,
+		StartedAt:        run.StartedAt,
+		CompletedAt:      run.CompletedAt,
+	}
+	for _, b := range run.Breaks {
+		response.Breaks = append(response.Breaks, ReconciliationBreakResponse{
+			ID:            b.ID,
+			BreakType:     b.BreakType,
+			Symbol:        b.Symbol,
+			TradeID:       b.TradeID,
+			BrokerRef:     b.BrokerRef,
+			InternalValue: b.InternalValue,
+			BrokerValue:   b.BrokerValue,
+			Resolution:    b.Resolution,
+			Message:       b.Message,
+		})
+	}
+	return response
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSettings godoc
+// @Summary Get portfolio settings
+// @Description Get a portfolio's effective settings. Options the portfolio doesn't set are inherited from its owner's defaults, then from the system defaults; sources gives the level each option came from.
+// @Tags settings
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Success 200 {object} SettingsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/settings [get]
+func (h *PortfolioHandler) GetSettings(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	settings, err := h.service.GetSettings(c.Request.Context(), portfolioID)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to handle settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, toSettingsResponse(settings))
+}
+
+// UpdateSettings godoc
+// @Summary Update portfolio settings
+// @Description Change the options set on a portfolio. Options left out are unchanged and options set to null are inherited again. Rejected when the resulting settings cannot be combined. A fee_schedule (flat, per_share, tiered or percentage commission, with exchange and regulatory levies) replaces commission_rate and min_commission set at the same or a less specific level. When no fee option is set, trades routed to a broker are charged its configured schedule. Crypto trades are charged crypto_fee_schedule instead.
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param request body SettingsRequest true "Settings patch"
+// @Success 200 {object} SettingsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "Aggressive risk tolerance without X-2FA-Code or X-Trusted-Device"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/settings [patch]
+func (h *PortfolioHandler) UpdateSettings(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	var patch map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+	if uncapsRisk(patch) && !h.requireSecondFactor(c) {
+		return
+	}
+
+	settings, err := h.service.UpdateSettings(c.Request.Context(), portfolioID, patch)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to handle settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, toSettingsResponse(settings))
+}
+
+// ReplaceSettings godoc
+// @Summary Replace portfolio settings
+// @Description Replace the options set on a portfolio with those given. Options left out are inherited from the owner's defaults again. Rejected when the resulting settings cannot be combined.
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param request body SettingsRequest true "Settings"
+// @Success 200 {object} SettingsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "Aggressive risk tolerance without X-2FA-Code or X-Trusted-Device"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/settings [put]
+func (h *PortfolioHandler) ReplaceSettings(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	var options map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&options); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+	if uncapsRisk(options) && !h.requireSecondFactor(c) {
+		return
+	}
+
+	settings, err := h.service.ReplaceSettings(c.Request.Context(), portfolioID, options)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to handle settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, toSettingsResponse(settings))
+}
+
+// GetUserSettings godoc
+// @Summary Get a user's portfolio defaults
+// @Description Get the settings a user's portfolios inherit when they don't set an option
+// @Tags settings
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {object} SettingsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/portfolio-settings [get]
+func (h *PortfolioHandler) GetUserSettings(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	settings, err := h.service.GetUserSettings(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to handle settings")
+		return
+	}
+
+	response := toSettingsResponse(settings)
+	response.UserID = userID
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateUserSettings godoc
+// @Summary Update a user's portfolio defaults
+// @Description Change the settings a user's portfolios inherit. Rejected when the defaults, or the settings any of the user's portfolios would end up with, cannot be combined.
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param request body SettingsRequest true "Settings patch"
+// @Success 200 {object} SettingsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "Aggressive risk tolerance without X-2FA-Code or X-Trusted-Device"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/portfolio-settings [patch]
+func (h *PortfolioHandler) UpdateUserSettings(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	var patch map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+	if uncapsRisk(patch) && !h.requireSecondFactor(c) {
+		return
+	}
+
+	settings, err := h.service.UpdateUserSettings(c.Request.Context(), userID, patch)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to handle settings")
+		return
+	}
+
+	response := toSettingsResponse(settings)
+	response.UserID = userID
+	c.JSON(http.StatusOK, response)
+}
+
+// uncapsRisk reports whether settings set the aggressive risk tolerance,
+// which lifts the cap on a position's share of the portfolio
+func uncapsRisk(settings map[string]json.RawMessage) bool {
+	var tolerance string
+	return json.Unmarshal(settings["risk_tolerance"], &tolerance) == nil && tolerance == models.RiskAggressive
+}
+
+func toSettingsResponse(settings *models.PortfolioSettings) SettingsResponse {
+	return SettingsResponse{
+		PortfolioID:       settings.PortfolioID,
+		CommissionRate:    settings.CommissionRate,
+		MinCommission:     settings.MinCommission,
+		Benchmark:         settings.Benchmark,
+		BaseCurrency:      settings.BaseCurrency,
+		DRIPEnabled:       settings.DRIPEnabled,
+		AutoTradeEnabled:  settings.AutoTradeEnabled,
+		Strategy:          settings.Strategy,
+		FeeSchedule:       settings.FeeSchedule,
+		CryptoFeeSchedule: settings.CryptoFeeSchedule,
+		CostBasisMethod:   settings.CostBasisMethod,
+		DefaultOrderType:  settings.DefaultOrderType,
+		RiskTolerance:     settings.RiskTolerance,
+		AutoRebalance:     settings.AutoRebalance,
+		Notifications:     settings.Notifications,
+		Sources:           settings.Sources,
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hedge-fund/internal/portfolio/repository"
+	"hedge-fund/internal/portfolio/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type StatementHandler struct {
+	service *service.StatementService
+	logger  *zap.Logger
+}
+
+func NewStatementHandler(service *service.StatementService, logger *zap.Logger) *StatementHandler {
+	return &StatementHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListStatements godoc
+// @Summary List account statements
+// @Description Get a page of a portfolio's monthly statements, newest first by default. Each runs from the last snapshot before the month (or the first in it, for a portfolio opened that month) to the last in it, so starting value + contributions - withdrawals + realized PnL - fees + unrealized PnL = ending value. Statements are compiled on the first of each month.
+// @Tags portfolios
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param limit query int false "Limit" default(12)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field" Enums(month) default(month)
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Success 200 {object} pagination.Page{data=[]StatementResponse}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/statements [get]
+func (h *StatementHandler) ListStatements(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	page, ok := parsePage(c, repository.StatementSorts, 12)
+	if !ok {
+		return
+	}
+
+	statements, result, err := h.service.GetStatements(c.Request.Context(), portfolioID, page)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to list account statements", zap.Int("portfolio_id", portfolioID))
+		return
+	}
+
+	response := make([]StatementResponse, len(statements))
+	for i := range statements {
+		response[i] = toStatementResponse(&statements[i])
+	}
+
+	c.JSON(http.StatusOK, result.Page(response))
+}
+
+func toStatementResponse(statement *models.AccountStatement) StatementResponse {
+	return StatementResponse{
+		ID:            statement.ID,
+		PortfolioID:   statement.PortfolioID,
+		Month:         statement.Month.Format("2006-01"),
+		StartDate:     statement.StartDate.Format("2006-01-02"),
+		EndDate:       statement.EndDate.Format("2006-01-02"),
+		StartingValue: statement.StartingValue,
+		EndingValue:   statement.EndingValue,
+		Contributions: statement.Contributions,
+		Withdrawals:   statement.Withdrawals,
+		RealizedPnL:   statement.RealizedPnL,
+		UnrealizedPnL: statement.UnrealizedPnL,
+		Fees:          statement.Fees,
+		TradeCount:    statement.TradeCount,
+		CreatedAt:     statement.CreatedAt,
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hedge-fund/internal/portfolio/repository"
+	"hedge-fund/internal/portfolio/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VaRBacktestHandler struct {
+	service *service.VaRBacktestService
+	logger  *zap.Logger
+}
+
+func NewVaRBacktestHandler(service *service.VaRBacktestService, logger *zap.Logger) *VaRBacktestHandler {
+	return &VaRBacktestHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RunVaRBacktest godoc
+// @Summary Backtest the VaR model
+// @Description Compare historical-simulation VaR forecasts with the portfolio's realized daily returns from its snapshots, net of deposits, withdrawals and transfers, and test the exceptions with Kupiec's proportion of failures and Christoffersen's independence tests. The body is optional; fields left out use the service defaults.
+// @Tags risk
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param request body VaRBacktestRequest false "VaR model and test window"
+// @Success 201 {object} VaRBacktestResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/var-backtests [post]
+func (h *VaRBacktestHandler) RunVaRBacktest(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	var req VaRBacktestRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+			return
+		}
+	}
+
+	config := h.service.Defaults()
+	if req.Confidence != 0 {
+		config.Confidence = req.Confidence
+	}
+	if req.LookbackDays != 0 {
+		config.Lookback = req.LookbackDays
+	}
+	if req.WindowDays != 0 {
+		config.Window = req.WindowDays
+	}
+
+	backtest, err := h.service.Run(c.Request.Context(), portfolioID, config)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to backtest VaR", zap.Int("portfolio_id", portfolioID))
+		return
+	}
+
+	c.JSON(http.StatusCreated, toVaRBacktestResponse(backtest))
+}
+
+// ListVaRBacktests godoc
+// @Summary List VaR backtests
+// @Description Get a page of a portfolio's VaR backtests, newest first by default
+// @Tags risk
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param limit query int false "Limit" default(30)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field" Enums(created_at, end_date) default(created_at)
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Success 200 {object} pagination.Page{data=[]VaRBacktestResponse}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/var-backtests [get]
+func (h *VaRBacktestHandler) ListVaRBacktests(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	page, ok := parsePage(c, repository.VaRBacktestSorts, 30)
+	if !ok {
+		return
+	}
+
+	backtests, result, err := h.service.GetBacktests(c.Request.Context(), portfolioID, page)
+	if err != nil {
+		writeError(c, h.logger, err, "Failed to list VaR backtests", zap.Int("portfolio_id", portfolioID))
+		return
+	}
+
+	response := make([]VaRBacktestResponse, len(backtests))
+	for i := range backtests {
+		response[i] = toVaRBacktestResponse(&backtests[i])
+	}
+
+	c.JSON(http.StatusOK, result.Page(response))
+}
+
+func toVaRBacktestResponse(backtest *models.VaRBacktest) VaRBacktestResponse {
+	response := VaRBacktestResponse{
+		ID:                        backtest.ID,
+		PortfolioID:               backtest.PortfolioID,
+		Confidence:                backtest.Confidence,
+		LookbackDays:              backtest.LookbackDays,
+		StartDate:                 backtest.StartDate.Format("2006-01-02"),
+		EndDate:                   backtest.EndDate.Format("2006-01-02"),
+		Observations:              backtest.Observations,
+		Exceptions:                backtest.Exceptions,
+		ExpectedExceptions:        backtest.ExpectedExceptions,
+		KupiecLR:                  backtest.KupiecLR,
+		KupiecPValue:              backtest.KupiecPValue,
+		IndependenceLR:            backtest.IndependenceLR,
+		IndependencePValue:        backtest.IndependencePValue,
+		ConditionalCoverageLR:     backtest.ConditionalCoverageLR,
+		ConditionalCoveragePValue: backtest.ConditionalCoveragePValue,
+		Adequate:                  backtest.Adequate,
+		ExceptionDates:            make([]string, len(backtest.ExceptionDates)),
+		CreatedAt:                 backtest.CreatedAt,
+	}
+	for i, date := range backtest.ExceptionDates {
+		response.ExceptionDates[i] = date.Format("2006-01-02")
+	}
+	return response
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+)
+
+// Allocation Model Operations
+
+const allocationModelColumns = `id, portfolio_id, name, basis, targets, drift_threshold, is_active, created_at, updated_at`
+
+// CreateAllocationModel saves a new allocation model
+func (r *PortfolioRepository) CreateAllocationModel(ctx context.Context, model *models.AllocationModel) error {
+	targets, err := json.Marshal(model.Targets)
+	if err != nil {
+		return fmt.Errorf("failed to encode targets: %w", err)
+	}
+
+	query := `
+		INSERT INTO allocation_models (portfolio_id, name, basis, targets, drift_threshold, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	now := time.Now()
+	err = r.db.QueryRowContext(ctx, query, model.PortfolioID, model.Name, model.Basis, targets,
+		model.DriftThreshold, model.IsActive, now, now).Scan(&model.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return domain.Conflictf("allocation model %q already exists for portfolio %d", model.Name, model.PortfolioID)
+		}
+		r.logger.Error("Failed to create allocation model", zap.Error(err), zap.Int("portfolio_id", model.PortfolioID))
+		return fmt.Errorf("failed to create allocation model: %w", err)
+	}
+
+	model.CreatedAt = now
+	model.UpdatedAt = now
+	return nil
+}
+
+// GetAllocationModel retrieves an allocation model by ID
+func (r *PortfolioRepository) GetAllocationModel(ctx context.Context, modelID int) (*models.AllocationModel, error) {
+	query := `SELECT ` + allocationModelColumns + ` FROM allocation_models WHERE id = $1`
+
+	model, err := scanAllocationModel(r.db.QueryRowContext(ctx, query, modelID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundf("allocation model not found: %d", modelID)
+		}
+		r.logger.Error("Failed to get allocation model", zap.Error(err), zap.Int("model_id", modelID))
+		return nil, fmt.Errorf("failed to get allocation model: %w", err)
+	}
+
+	return model, nil
+}
+
+// GetAllocationModelsByPortfolioID retrieves a portfolio's allocation models by name
+func (r *PortfolioRepository) GetAllocationModelsByPortfolioID(ctx context.Context, portfolioID int) ([]models.AllocationModel, error) {
+	query := `SELECT ` + allocationModelColumns + ` FROM allocation_models WHERE portfolio_id = $1 ORDER BY name`
+	return r.queryAllocationModels(ctx, query, portfolioID)
+}
+
+// ListActiveAllocationModels retrieves the active models of active portfolios
+func (r *PortfolioRepository) ListActiveAllocationModels(ctx context.Context) ([]models.AllocationModel, error) {
+	query := `
+		SELECT m.id, m.portfolio_id, m.name, m.basis, m.targets, m.drift_threshold, m.is_active, m.created_at, m.updated_at
+		FROM allocation_models m
+		JOIN portfolios p ON p.id = m.portfolio_id
+		WHERE m.is_active = true AND p.is_active = true
+		ORDER BY m.portfolio_id, m.id`
+	return r.queryAllocationModels(ctx, query)
+}
+
+func (r *PortfolioRepository) queryAllocationModels(ctx context.Context, query string, args ...interface{}) ([]models.AllocationModel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to get allocation models", zap.Error(err))
+		return nil, fmt.Errorf("failed to get allocation models: %w", err)
+	}
+	defer rows.Close()
+
+	var allocationModels []models.AllocationModel
+	for rows.Next() {
+		model, err := scanAllocationModel(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan allocation model: %w", err)
+		}
+		allocationModels = append(allocationModels, *model)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating allocation models: %w", err)
+	}
+
+	return allocationModels, nil
+}
+
+// UpdateAllocationModel replaces an allocation model's name, targets, threshold and state
+func (r *PortfolioRepository) UpdateAllocationModel(ctx context.Context, model *models.AllocationModel) error {
+	targets, err := json.Marshal(model.Targets)
+	if err != nil {
+		return fmt.Errorf("failed to encode targets: %w", err)
+	}
+
+	query := `
+		UPDATE allocation_models
+		SET name = $2, basis = $3, targets = $4, drift_threshold = $5, is_active = $6, updated_at = $7
+		WHERE id = $1`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, model.ID, model.Name, model.Basis, targets, model.DriftThreshold, model.IsActive, now)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return domain.Conflictf("allocation model %q already exists for portfolio %d", model.Name, model.PortfolioID)
+		}
+		r.logger.Error("Failed to update allocation model", zap.Error(err), zap.Int("model_id", model.ID))
+		return fmt.Errorf("failed to update allocation model: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.NotFoundf("allocation model not found: %d", model.ID)
+	}
+
+	model.UpdatedAt = now
+	return nil
+}
+
+// DeleteAllocationModel deletes an allocation model and its drift history
+func (r *PortfolioRepository) DeleteAllocationModel(ctx context.Context, modelID int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM allocation_models WHERE id = $1`, modelID)
+	if err != nil {
+		r.logger.Error("Failed to delete allocation model", zap.Error(err), zap.Int("model_id", modelID))
+		return fmt.Errorf("failed to delete allocation model: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.NotFoundf("allocation model not found: %d", modelID)
+	}
+
+	return nil
+}
+
+// Drift Check Operations
+
+// CreateDriftCheck records the result of a drift check
+func (r *PortfolioRepository) CreateDriftCheck(ctx context.Context, check *models.DriftCheck) error {
+	drifts, err := json.Marshal(check.Drifts)
+	if err != nil {
+		return fmt.Errorf("failed to encode drifts: %w", err)
+	}
+
+	query := `
+		INSERT INTO allocation_drift_checks (model_id, portfolio_id, max_drift, threshold, breached, drifts, checked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	now := time.Now()
+	err = r.db.QueryRowContext(ctx, query, check.ModelID, check.PortfolioID, check.MaxDrift, check.Threshold,
+		check.Breached, drifts, now).Scan(&check.ID)
+	if err != nil {
+		r.logger.Error("Failed to create drift check", zap.Error(err), zap.Int("model_id", check.ModelID))
+		return fmt.Errorf("failed to create drift check: %w", err)
+	}
+
+	check.CheckedAt = now
+	return nil
+}
+
+// GetDriftChecks retrieves a page of a model's drift checks
+func (r *PortfolioRepository) GetDriftChecks(ctx context.Context, modelID int, page pagination.Request) ([]models.DriftCheck, pagination.Result, error) {
+	cond, args := page.After(2)
+	query := `
+		SELECT id, model_id, portfolio_id, max_drift, threshold, breached, drifts, checked_at, ` + page.Key() + `
+		FROM allocation_drift_checks
+		WHERE model_id = $1` + cond + page.OrderBy()
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{modelID}, args...)...)
+	if err != nil {
+		r.logger.Error("Failed to get drift checks", zap.Error(err), zap.Int("model_id", modelID))
+		return nil, pagination.Result{}, fmt.Errorf("failed to get drift checks: %w", err)
+	}
+	defer rows.Close()
+
+	var checks []models.DriftCheck
+	var keys []string
+	for rows.Next() {
+		var check models.DriftCheck
+		var drifts []byte
+		var key string
+		err := rows.Scan(&check.ID, &check.ModelID, &check.PortfolioID, &check.MaxDrift, &check.Threshold,
+			&check.Breached, &drifts, &check.CheckedAt, &key)
+		if err != nil {
+			return nil, pagination.Result{}, fmt.Errorf("failed to scan drift check: %w", err)
+		}
+		if err := json.Unmarshal(drifts, &check.Drifts); err != nil {
+			return nil, pagination.Result{}, fmt.Errorf("failed to decode drifts of check %d: %w", check.ID, err)
+		}
+		checks = append(checks, check)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("error iterating drift checks: %w", err)
+	}
+
+	var result pagination.Result
+	if page.More(len(checks)) {
+		checks = checks[:page.Limit]
+		result.NextCursor = page.Cursor(keys[page.Limit-1], checks[page.Limit-1].ID)
+	}
+	result.Total, err = r.count(ctx, `SELECT COUNT(*) FROM allocation_drift_checks WHERE model_id = $1`, modelID)
+	if err != nil {
+		return nil, pagination.Result{}, err
+	}
+
+	return checks, result, nil
+}
+
+func scanAllocationModel(row rowScanner) (*models.AllocationModel, error) {
+	model := &models.AllocationModel{}
+	var targets []byte
+	err := row.Scan(&model.ID, &model.PortfolioID, &model.Name, &model.Basis, &targets, &model.DriftThreshold,
+		&model.IsActive, &model.CreatedAt, &model.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(targets, &model.Targets); err != nil {
+		return nil, fmt.Errorf("failed to decode targets of model %d: %w", model.ID, err)
+	}
+	return model, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Day Anchor Operations
+
+// GetHeldSymbols retrieves every symbol held in a position, in order
+func (r *PortfolioRepository) GetHeldSymbols(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT symbol FROM positions ORDER BY symbol`)
+	if err != nil {
+		r.logger.Error("Failed to get held symbols", zap.Error(err))
+		return nil, fmt.Errorf("failed to get held symbols: %w", err)
+	}
+	defer rows.Close()
+
+	var held []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan symbol: %w", err)
+		}
+		held = append(held, symbol)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating symbols: %w", err)
+	}
+
+	return held, nil
+}
+
+// GetDayOpens retrieves the open of each symbol's stored daily bar for the
+// session (UTC day) of asOf, by symbol. Symbols without one are absent.
+func (r *PortfolioRepository) GetDayOpens(ctx context.Context, list []string, asOf time.Time) (map[string]float64, error) {
+	query := `
+		SELECT symbol, open
+		FROM market_prices
+		WHERE symbol = ANY($1) AND bar_interval = '1d' AND timestamp >= $2 AND timestamp < $3`
+
+	// Bars are stamped at the start of their day
+	session := asOf.UTC().Truncate(24 * time.Hour)
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(symbols.NormalizeAll(list)), session, session.AddDate(0, 0, 1))
+	if err != nil {
+		r.logger.Error("Failed to get day opens", zap.Error(err), zap.Int("symbols", len(list)))
+		return nil, fmt.Errorf("failed to get day opens: %w", err)
+	}
+	defer rows.Close()
+
+	opens := make(map[string]float64, len(list))
+	for rows.Next() {
+		var symbol string
+		var open float64
+		if err := rows.Scan(&symbol, &open); err != nil {
+			return nil, fmt.Errorf("failed to scan day open: %w", err)
+		}
+		opens[symbol] = open
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating day opens: %w", err)
+	}
+
+	return opens, nil
+}
+
+// RollDayAnchors sets the previous close and day open of every position in
+// the symbols anchored, returning how many positions were rolled
+func (r *PortfolioRepository) RollDayAnchors(ctx context.Context, anchors []models.DayAnchor, asOf time.Time) (int64, error) {
+	if len(anchors) == 0 {
+		return 0, nil
+	}
+
+	symbols := make([]string, len(anchors))
+	closes := make([]float64, len(anchors))
+	opens := make([]float64, len(anchors))
+	for i, anchor := range anchors {
+		symbols[i] = anchor.Symbol
+		closes[i] = anchor.PreviousClose
+		opens[i] = anchor.DayOpen
+	}
+
+	query := `
+		UPDATE positions AS p
+		SET previous_close = a.previous_close, day_open_price = a.day_open, anchored_at = $4
+		FROM unnest($1::text[], $2::numeric[], $3::numeric[]) AS a(symbol, previous_close, day_open)
+		WHERE p.symbol = a.symbol`
+
+	result, err := r.db.ExecContext(ctx, query, pq.Array(symbols), pq.Array(closes), pq.Array(opens), asOf)
+	if err != nil {
+		r.logger.Error("Failed to roll day anchors", zap.Error(err), zap.Int("symbols", len(anchors)))
+		return 0, fmt.Errorf("failed to roll day anchors: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected, nil
+}
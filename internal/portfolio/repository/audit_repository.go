@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+)
+
+// Audit Operations
+
+const insertAuditEventQuery = `
+	INSERT INTO audit_events (portfolio_id, entity_type, entity_id, action, actor, request_id,
+	                          before_state, after_state, created_at)
+	VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9)
+	RETURNING id`
+
+// CreateAuditEventTx appends an audit event within a transaction
+func (r *PortfolioRepository) CreateAuditEventTx(ctx context.Context, tx *sql.Tx, event *models.AuditEvent) error {
+	now := time.Now()
+	err := tx.QueryRowContext(ctx, insertAuditEventQuery, auditEventArgs(event, now)...).Scan(&event.ID)
+	if err != nil {
+		r.logger.Error("Failed to create audit event in transaction", zap.Error(err),
+			zap.Int("portfolio_id", event.PortfolioID), zap.String("entity_type", event.EntityType))
+		return fmt.Errorf("failed to create audit event: %w", err)
+	}
+
+	event.CreatedAt = now
+	return nil
+}
+
+// GetAuditEventsByPortfolioID retrieves a page of the audit trail for a portfolio
+func (r *PortfolioRepository) GetAuditEventsByPortfolioID(ctx context.Context, portfolioID int, page pagination.Request) ([]models.AuditEvent, pagination.Result, error) {
+	cond, args := page.After(2)
+	query := `
+		SELECT id, portfolio_id, entity_type, entity_id, action, actor, COALESCE(request_id, ''),
+		       before_state, after_state, created_at, ` + page.Key() + `
+		FROM audit_events
+		WHERE portfolio_id = $1` + cond + page.OrderBy()
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{portfolioID}, args...)...)
+	if err != nil {
+		r.logger.Error("Failed to get audit events", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, pagination.Result{}, fmt.Errorf("failed to get audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	var keys []string
+	for rows.Next() {
+		event := models.AuditEvent{}
+		var before, after []byte
+		var key string
+		err := rows.Scan(
+			&event.ID,
+			&event.PortfolioID,
+			&event.EntityType,
+			&event.EntityID,
+			&event.Action,
+			&event.Actor,
+			&event.RequestID,
+			&before,
+			&after,
+			&event.CreatedAt,
+			&key,
+		)
+		if err != nil {
+			return nil, pagination.Result{}, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		event.Before = before
+		event.After = after
+		events = append(events, event)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("error iterating audit events: %w", err)
+	}
+
+	var result pagination.Result
+	if page.More(len(events)) {
+		events = events[:page.Limit]
+		result.NextCursor = page.Cursor(keys[page.Limit-1], events[page.Limit-1].ID)
+	}
+	result.Total, err = r.count(ctx, `SELECT COUNT(*) FROM audit_events WHERE portfolio_id = $1`, portfolioID)
+	if err != nil {
+		return nil, pagination.Result{}, err
+	}
+
+	return events, result, nil
+}
+
+func auditEventArgs(event *models.AuditEvent, now time.Time) []interface{} {
+	return []interface{}{
+		event.PortfolioID,
+		event.EntityType,
+		event.EntityID,
+		event.Action,
+		event.Actor,
+		event.RequestID,
+		nullableJSON(event.Before),
+		nullableJSON(event.After),
+		now,
+	}
+}
+
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Portfolio Snapshot Operations
+
+// ListActivePortfolioIDs retrieves the IDs of all active portfolios
+func (r *PortfolioRepository) ListActivePortfolioIDs(ctx context.Context) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id FROM portfolios WHERE is_active = true ORDER BY id`)
+	if err != nil {
+		r.logger.Error("Failed to list active portfolios", zap.Error(err))
+		return nil, fmt.Errorf("failed to list active portfolios: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan portfolio ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating portfolios: %w", err)
+	}
+
+	return ids, nil
+}
+
+// UpsertSnapshot records a portfolio's value for a day, replacing any
+// snapshot already taken that day
+func (r *PortfolioRepository) UpsertSnapshot(ctx context.Context, snapshot *models.PortfolioSnapshot) error {
+	query := `
+		INSERT INTO portfolio_snapshots (portfolio_id, snapshot_date, total_value, cash, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (portfolio_id, snapshot_date) DO UPDATE
+		SET total_value = EXCLUDED.total_value, cash = EXCLUDED.cash, created_at = EXCLUDED.created_at
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query, snapshot.PortfolioID, snapshot.SnapshotDate, snapshot.TotalValue,
+		snapshot.Cash, now).Scan(&snapshot.ID)
+	if err != nil {
+		r.logger.Error("Failed to save portfolio snapshot", zap.Error(err), zap.Int("portfolio_id", snapshot.PortfolioID))
+		return fmt.Errorf("failed to save portfolio snapshot: %w", err)
+	}
+
+	snapshot.CreatedAt = now
+	return nil
+}
+
+// GetSnapshotOnOrBefore retrieves a portfolio's latest snapshot taken on or
+// before date, or nil when there is none
+func (r *PortfolioRepository) GetSnapshotOnOrBefore(ctx context.Context, portfolioID int, date time.Time) (*models.PortfolioSnapshot, error) {
+	query := `
+		SELECT id, portfolio_id, snapshot_date, total_value, cash, created_at
+		FROM portfolio_snapshots
+		WHERE portfolio_id = $1 AND snapshot_date <= $2
+		ORDER BY snapshot_date DESC
+		LIMIT 1`
+
+	snapshot := &models.PortfolioSnapshot{}
+	err := r.reader().QueryRowContext(ctx, query, portfolioID, date).Scan(&snapshot.ID, &snapshot.PortfolioID,
+		&snapshot.SnapshotDate, &snapshot.TotalValue, &snapshot.Cash, &snapshot.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get portfolio snapshot", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, fmt.Errorf("failed to get portfolio snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// GetSnapshotsBetween retrieves a portfolio's snapshots taken in
+// [start, end), oldest first
+func (r *PortfolioRepository) GetSnapshotsBetween(ctx context.Context, portfolioID int, start, end time.Time) ([]models.PortfolioSnapshot, error) {
+	query := `
+		SELECT id, portfolio_id, snapshot_date, total_value, cash, created_at
+		FROM portfolio_snapshots
+		WHERE portfolio_id = $1 AND snapshot_date >= $2 AND snapshot_date < $3
+		ORDER BY snapshot_date`
+
+	rows, err := r.reader().QueryContext(ctx, query, portfolioID, start, end)
+	if err != nil {
+		r.logger.Error("Failed to get portfolio snapshots", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, fmt.Errorf("failed to get portfolio snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []models.PortfolioSnapshot
+	for rows.Next() {
+		var snapshot models.PortfolioSnapshot
+		err := rows.Scan(&snapshot.ID, &snapshot.PortfolioID, &snapshot.SnapshotDate, &snapshot.TotalValue,
+			&snapshot.Cash, &snapshot.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan portfolio snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating portfolio snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// GetCloseOnOrBefore retrieves a symbol's latest daily close on or before
+// date from the stored market prices, or 0 when there is none
+func (r *PortfolioRepository) GetCloseOnOrBefore(ctx context.Context, symbol string, date time.Time) (float64, error) {
+	query := `
+		SELECT close
+		FROM market_prices
+		WHERE symbol = $1 AND bar_interval = '1d' AND timestamp < $2
+		ORDER BY timestamp DESC
+		LIMIT 1`
+
+	var close float64
+	// Bars are stamped at the start of their day, so the whole of date counts
+	err := r.db.QueryRowContext(ctx, query, symbols.Normalize(symbol), date.AddDate(0, 0, 1)).Scan(&close)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		r.logger.Error("Failed to get close", zap.Error(err), zap.String("symbol", symbol))
+		return 0, fmt.Errorf("failed to get close: %w", err)
+	}
+
+	return close, nil
+}
+
+// Benchmark Rule Operations
+
+const benchmarkRuleColumns = `id, portfolio_id, benchmark, window_days, threshold, is_active, last_triggered_at, created_at, updated_at`
+
+// CreateBenchmarkRule saves a new benchmark alert rule
+func (r *PortfolioRepository) CreateBenchmarkRule(ctx context.Context, rule *models.BenchmarkRule) error {
+	query := `
+		INSERT INTO benchmark_alert_rules (portfolio_id, benchmark, window_days, threshold, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query, rule.PortfolioID, rule.Benchmark, rule.WindowDays, rule.Threshold,
+		rule.IsActive, now, now).Scan(&rule.ID)
+	if err != nil {
+		r.logger.Error("Failed to create benchmark rule", zap.Error(err), zap.Int("portfolio_id", rule.PortfolioID))
+		return fmt.Errorf("failed to create benchmark rule: %w", err)
+	}
+
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	return nil
+}
+
+// GetBenchmarkRule retrieves a benchmark alert rule by ID
+func (r *PortfolioRepository) GetBenchmarkRule(ctx context.Context, ruleID int) (*models.BenchmarkRule, error) {
+	query := `SELECT ` + benchmarkRuleColumns + ` FROM benchmark_alert_rules WHERE id = $1`
+
+	rule, err := scanBenchmarkRule(r.db.QueryRowContext(ctx, query, ruleID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundf("benchmark rule not found: %d", ruleID)
+		}
+		r.logger.Error("Failed to get benchmark rule", zap.Error(err), zap.Int("rule_id", ruleID))
+		return nil, fmt.Errorf("failed to get benchmark rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// GetBenchmarkRulesByPortfolioID retrieves a portfolio's benchmark alert rules
+func (r *PortfolioRepository) GetBenchmarkRulesByPortfolioID(ctx context.Context, portfolioID int) ([]models.BenchmarkRule, error) {
+	query := `SELECT ` + benchmarkRuleColumns + ` FROM benchmark_alert_rules WHERE portfolio_id = $1 ORDER BY id`
+	return r.queryBenchmarkRules(ctx, query, portfolioID)
+}
+
+// ListActiveBenchmarkRules retrieves the active rules of active portfolios
+func (r *PortfolioRepository) ListActiveBenchmarkRules(ctx context.Context) ([]models.BenchmarkRule, error) {
+	query := `
+		SELECT b.id, b.portfolio_id, b.benchmark, b.window_days, b.threshold, b.is_active, b.last_triggered_at, b.created_at, b.updated_at
+		FROM benchmark_alert_rules b
+		JOIN portfolios p ON p.id = b.portfolio_id
+		WHERE b.is_active = true AND p.is_active = true
+		ORDER BY b.portfolio_id, b.id`
+	return r.queryBenchmarkRules(ctx, query)
+}
+
+func (r *PortfolioRepository) queryBenchmarkRules(ctx context.Context, query string, args ...interface{}) ([]models.BenchmarkRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to get benchmark rules", zap.Error(err))
+		return nil, fmt.Errorf("failed to get benchmark rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []models.BenchmarkRule
+	for rows.Next() {
+		rule, err := scanBenchmarkRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan benchmark rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating benchmark rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// MarkBenchmarkRuleTriggered records when a rule last raised an alert
+func (r *PortfolioRepository) MarkBenchmarkRuleTriggered(ctx context.Context, ruleID int, triggeredAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE benchmark_alert_rules SET last_triggered_at = $2 WHERE id = $1`, ruleID, triggeredAt)
+	if err != nil {
+		r.logger.Error("Failed to mark benchmark rule triggered", zap.Error(err), zap.Int("rule_id", ruleID))
+		return fmt.Errorf("failed to mark benchmark rule triggered: %w", err)
+	}
+	return nil
+}
+
+// DeleteBenchmarkRule deletes a benchmark alert rule
+func (r *PortfolioRepository) DeleteBenchmarkRule(ctx context.Context, ruleID int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM benchmark_alert_rules WHERE id = $1`, ruleID)
+	if err != nil {
+		r.logger.Error("Failed to delete benchmark rule", zap.Error(err), zap.Int("rule_id", ruleID))
+		return fmt.Errorf("failed to delete benchmark rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.NotFoundf("benchmark rule not found: %d", ruleID)
+	}
+
+	return nil
+}
+
+func scanBenchmarkRule(row rowScanner) (*models.BenchmarkRule, error) {
+	rule := &models.BenchmarkRule{}
+	var lastTriggeredAt sql.NullTime
+	err := row.Scan(&rule.ID, &rule.PortfolioID, &rule.Benchmark, &rule.WindowDays, &rule.Threshold,
+		&rule.IsActive, &lastTriggeredAt, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if lastTriggeredAt.Valid {
+		rule.LastTriggeredAt = &lastTriggeredAt.Time
+	}
+	return rule, nil
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Bulk Operations
+
+// bulkBatchSize is the most rows written by one statement, keeping the
+// widest batch well under Postgres' 65535 parameters
+const bulkBatchSize = 500
+
+const positionColumns = `id, user_id, portfolio_id, symbol, asset_type, quantity, multiplier, side, entry_price, current_price,
+		       unrealized_pnl, realized_pnl, stop_loss_percent, stop_loss_price, previous_close, day_open_price, anchored_at,
+		       created_at, updated_at`
+
+// GetPositionsByPortfolioIDs retrieves the positions of several portfolios in
+// one query, by portfolio ID. Portfolios without positions are absent.
+func (r *PortfolioRepository) GetPositionsByPortfolioIDs(ctx context.Context, portfolioIDs []int) (map[int][]models.Position, error) {
+	positions := make(map[int][]models.Position, len(portfolioIDs))
+	if len(portfolioIDs) == 0 {
+		return positions, nil
+	}
+
+	query := `
+		SELECT ` + positionColumns + `
+		FROM positions
+		WHERE portfolio_id = ANY($1)
+		ORDER BY portfolio_id, created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(portfolioIDs))
+	if err != nil {
+		r.logger.Error("Failed to get positions for portfolios", zap.Error(err), zap.Ints("portfolio_ids", portfolioIDs))
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		position, err := scanPosition(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan position", zap.Error(err))
+			continue
+		}
+		positions[position.PortfolioID] = append(positions[position.PortfolioID], position)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating positions: %w", err)
+	}
+
+	return positions, nil
+}
+
+// GetPositionsForUpdateTx retrieves a portfolio's positions, locking them
+// until the transaction ends
+func (r *PortfolioRepository) GetPositionsForUpdateTx(ctx context.Context, tx *sql.Tx, portfolioID int) ([]models.Position, error) {
+	return r.getPositions(ctx, tx, portfolioID, "FOR NO KEY UPDATE")
+}
+
+// CreatePositionsTx creates positions within a transaction, a batch per
+// statement, setting their IDs
+func (r *PortfolioRepository) CreatePositionsTx(ctx context.Context, tx *sql.Tx, positions []*models.Position) error {
+	now := time.Now()
+	for start := 0; start < len(positions); start += bulkBatchSize {
+		batch := positions[start:min(start+bulkBatchSize, len(positions))]
+
+		// Postgres returns the rows of a multi-row insert in the order given
+		query := `
+			INSERT INTO positions (user_id, portfolio_id, symbol, asset_type, quantity, multiplier, side, entry_price, current_price,
+			                      unrealized_pnl, realized_pnl, created_at, updated_at)
+			VALUES ` + valueRows(len(batch), `(%s, %s, %s, COALESCE(NULLIF(%s, ''), 'equity'), %s, COALESCE(NULLIF(%s, 0), 1), %s, %s, %s, %s, %s, %s, %s)`) + `
+			RETURNING id`
+
+		args := make([]interface{}, 0, len(batch)*13)
+		for _, position := range batch {
+			position.Symbol = symbols.Normalize(position.Symbol)
+			args = append(args,
+				position.UserID,
+				position.PortfolioID,
+				position.Symbol,
+				position.AssetType,
+				position.Quantity,
+				position.Multiplier,
+				position.Side,
+				position.EntryPrice,
+				position.CurrentPrice,
+				position.UnrealizedPnL,
+				position.RealizedPnL,
+				now,
+				now,
+			)
+		}
+
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			r.logger.Error("Failed to create positions in transaction", zap.Error(err), zap.Int("positions", len(batch)))
+			return fmt.Errorf("failed to create positions: %w", err)
+		}
+		err = scanIDs(rows, len(batch), func(i, id int) {
+			batch[i].ID = id
+			batch[i].CreatedAt = now
+			batch[i].UpdatedAt = now
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create positions: %w", err)
+		}
+	}
+
+	if len(positions) > 0 {
+		r.logger.Info("Positions created successfully in transaction", zap.Int("positions", len(positions)))
+	}
+	return nil
+}
+
+// UpdatePositionsTx updates positions within a transaction, a batch per
+// statement, like UpdatePositionTx
+func (r *PortfolioRepository) UpdatePositionsTx(ctx context.Context, tx *sql.Tx, positions []*models.Position) error {
+	now := time.Now()
+	for start := 0; start < len(positions); start += bulkBatchSize {
+		batch := positions[start:min(start+bulkBatchSize, len(positions))]
+
+		// Values are cast as the statement gives Postgres nothing to infer their types from
+		query := `
+			UPDATE positions AS p
+			SET portfolio_id = v.portfolio_id, quantity = v.quantity, side = v.side, entry_price = v.entry_price,
+			    current_price = v.current_price, unrealized_pnl = v.unrealized_pnl, realized_pnl = v.realized_pnl,
+			    updated_at = v.updated_at
+			FROM (VALUES ` + valueRows(len(batch), `(%s::integer, %s::integer, %s::numeric, %s::text, %s::numeric, %s::numeric, %s::numeric, %s::numeric, %s::timestamptz)`) + `)
+			     AS v(id, portfolio_id, quantity, side, entry_price, current_price, unrealized_pnl, realized_pnl, updated_at)
+			WHERE p.id = v.id`
+
+		args := make([]interface{}, 0, len(batch)*9)
+		for _, position := range batch {
+			args = append(args,
+				position.ID,
+				position.PortfolioID,
+				position.Quantity,
+				position.Side,
+				position.EntryPrice,
+				position.CurrentPrice,
+				position.UnrealizedPnL,
+				position.RealizedPnL,
+				now,
+			)
+		}
+
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			r.logger.Error("Failed to update positions in transaction", zap.Error(err), zap.Int("positions", len(batch)))
+			return fmt.Errorf("failed to update positions: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if int(rowsAffected) != len(batch) {
+			return fmt.Errorf("%w: %d of %d positions updated", domain.ErrPositionNotFound, rowsAffected, len(batch))
+		}
+		for _, position := range batch {
+			position.UpdatedAt = now
+		}
+	}
+
+	if len(positions) > 0 {
+		r.logger.Info("Positions updated successfully in transaction", zap.Int("positions", len(positions)))
+	}
+	return nil
+}
+
+// CreateTradesTx creates trade records within a transaction, a batch per
+// statement, setting their IDs
+func (r *PortfolioRepository) CreateTradesTx(ctx context.Context, tx *sql.Tx, trades []*models.Trade) error {
+	now := time.Now()
+	for start := 0; start < len(trades); start += bulkBatchSize {
+		batch := trades[start:min(start+bulkBatchSize, len(trades))]
+
+		// Postgres returns the rows of a multi-row insert in the order given
+		query := `
+			INSERT INTO trades (user_id, portfolio_id, position_id, symbol, asset_type, quantity, multiplier, price, side, type, status,
+			                   time_in_force, fees, fee_items, trigger_reason, executed_at, created_at)
+			VALUES ` + valueRows(len(batch), `(%s, %s, NULLIF(%s, 0), %s, COALESCE(NULLIF(%s, ''), 'equity'), %s, COALESCE(NULLIF(%s, 0), 1), %s, %s, %s, %s, COALESCE(NULLIF(%s, ''), 'day'), %s, %s, NULLIF(%s, ''), %s, %s)`) + `
+			RETURNING id`
+
+		args := make([]interface{}, 0, len(batch)*17)
+		for _, trade := range batch {
+			trade.Symbol = symbols.Normalize(trade.Symbol)
+			args = append(args,
+				trade.UserID,
+				trade.PortfolioID,
+				trade.PositionID,
+				trade.Symbol,
+				trade.AssetType,
+				trade.Quantity,
+				trade.Multiplier,
+				trade.Price,
+				trade.Side,
+				trade.Type,
+				trade.Status,
+				trade.TimeInForce,
+				trade.Fees,
+				feeItems(trade),
+				trade.TriggerReason,
+				trade.ExecutedAt,
+				now,
+			)
+		}
+
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			r.logger.Error("Failed to create trades in transaction", zap.Error(err), zap.Int("trades", len(batch)))
+			return fmt.Errorf("failed to create trades: %w", err)
+		}
+		err = scanIDs(rows, len(batch), func(i, id int) {
+			batch[i].ID = id
+			batch[i].CreatedAt = now
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create trades: %w", err)
+		}
+	}
+
+	if len(trades) > 0 {
+		r.logger.Info("Trades created successfully in transaction", zap.Int("trades", len(trades)))
+	}
+	return nil
+}
+
+// Helper functions
+
+// valueRows formats n rows of a multi-row statement from format, one %s per
+// value, numbering the placeholders on from row to row
+func valueRows(n int, format string) string {
+	width := strings.Count(format, "%s")
+	rows := make([]string, n)
+	placeholders := make([]interface{}, width)
+	for i := range rows {
+		for j := range placeholders {
+			placeholders[j] = "$" + strconv.Itoa(i*width+j+1)
+		}
+		rows[i] = fmt.Sprintf(format, placeholders...)
+	}
+	return strings.Join(rows, ", ")
+}
+
+// scanIDs reads the n IDs returned by a multi-row insert, calling set with
+// each and its row's index, and closes rows
+func scanIDs(rows *sql.Rows, n int, set func(i, id int)) error {
+	defer rows.Close()
+
+	i := 0
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		if i < n {
+			set(i, id)
+		}
+		i++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if i != n {
+		return fmt.Errorf("inserted %d rows, expected %d", i, n)
+	}
+	return nil
+}
+
+func scanPosition(row rowScanner) (models.Position, error) {
+	position := models.Position{}
+	err := row.Scan(
+		&position.ID,
+		&position.UserID,
+		&position.PortfolioID,
+		&position.Symbol,
+		&position.AssetType,
+		&position.Quantity,
+		&position.Multiplier,
+		&position.Side,
+		&position.EntryPrice,
+		&position.CurrentPrice,
+		&position.UnrealizedPnL,
+		&position.RealizedPnL,
+		&position.StopLossPercent,
+		&position.StopLossPrice,
+		&position.PreviousClose,
+		&position.DayOpenPrice,
+		&position.AnchoredAt,
+		&position.CreatedAt,
+		&position.UpdatedAt,
+	)
+	return position, err
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+)
+
+// Cash Ledger Operations
+
+// CreateCashTransactionTx appends an entry to a portfolio's cash ledger
+// within a transaction
+func (r *PortfolioRepository) CreateCashTransactionTx(ctx context.Context, tx *sql.Tx, entry *models.CashTransaction) error {
+	query := `
+		INSERT INTO cash_transactions (portfolio_id, type, amount, balance_after, counterparty_portfolio_id,
+		                               transfer_id, note, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, $7, NULLIF($8, ''), $9)
+		RETURNING id`
+
+	now := time.Now()
+	err := tx.QueryRowContext(ctx, query,
+		entry.PortfolioID,
+		entry.Type,
+		entry.Amount,
+		entry.BalanceAfter,
+		entry.CounterpartyPortfolioID,
+		entry.TransferID,
+		entry.Note,
+		entry.RequestID,
+		now,
+	).Scan(&entry.ID)
+	if err != nil {
+		r.logger.Error("Failed to create cash transaction", zap.Error(err),
+			zap.Int("portfolio_id", entry.PortfolioID), zap.String("type", entry.Type))
+		return fmt.Errorf("failed to create cash transaction: %w", err)
+	}
+
+	entry.CreatedAt = now
+	return nil
+}
+
+// GetCashTransactions retrieves a page of a portfolio's cash ledger
+func (r *PortfolioRepository) GetCashTransactions(ctx context.Context, portfolioID int, page pagination.Request) ([]models.CashTransaction, pagination.Result, error) {
+	cond, args := page.After(2)
+	query := `
+		SELECT id, portfolio_id, type, amount, balance_after, counterparty_portfolio_id,
+		       COALESCE(transfer_id::text, ''), note, COALESCE(request_id, ''), created_at, ` + page.Key() + `
+		FROM cash_transactions
+		WHERE portfolio_id = $1` + cond + page.OrderBy()
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{portfolioID}, args...)...)
+	if err != nil {
+		r.logger.Error("Failed to get cash transactions", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, pagination.Result{}, fmt.Errorf("failed to get cash transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.CashTransaction
+	var keys []string
+	for rows.Next() {
+		entry := models.CashTransaction{}
+		var counterparty sql.NullInt64
+		var key string
+		err := rows.Scan(
+			&entry.ID,
+			&entry.PortfolioID,
+			&entry.Type,
+			&entry.Amount,
+			&entry.BalanceAfter,
+			&counterparty,
+			&entry.TransferID,
+			&entry.Note,
+			&entry.RequestID,
+			&entry.CreatedAt,
+			&key,
+		)
+		if err != nil {
+			return nil, pagination.Result{}, fmt.Errorf("failed to scan cash transaction: %w", err)
+		}
+		if counterparty.Valid {
+			id := int(counterparty.Int64)
+			entry.CounterpartyPortfolioID = &id
+		}
+		entries = append(entries, entry)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("error iterating cash transactions: %w", err)
+	}
+
+	var result pagination.Result
+	if page.More(len(entries)) {
+		entries = entries[:page.Limit]
+		result.NextCursor = page.Cursor(keys[page.Limit-1], entries[page.Limit-1].ID)
+	}
+	result.Total, err = r.count(ctx, `SELECT COUNT(*) FROM cash_transactions WHERE portfolio_id = $1`, portfolioID)
+	if err != nil {
+		return nil, pagination.Result{}, err
+	}
+
+	return entries, result, nil
+}
+
+// GetPendingBuyValueTx sums the cost, fees included, of a portfolio's buy
+// orders still pending at a broker, within a transaction
+func (r *PortfolioRepository) GetPendingBuyValueTx(ctx context.Context, tx *sql.Tx, portfolioID int) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(quantity * multiplier * price + COALESCE(fees, 0)), 0)
+		FROM trades
+		WHERE portfolio_id = $1 AND side = 'buy' AND status = 'pending'`
+
+	var value float64
+	if err := tx.QueryRowContext(ctx, query, portfolioID).Scan(&value); err != nil {
+		r.logger.Error("Failed to get pending buy value", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return 0, fmt.Errorf("failed to get pending buy value: %w", err)
+	}
+	return value, nil
+}
@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Competition Operations
+
+const competitionColumns = `
+	c.id, c.name, COALESCE(c.description, ''), c.universe, c.start_date, c.end_date, c.starting_cash, c.rules,
+	(SELECT COUNT(*) FROM competition_entries e WHERE e.competition_id = c.id), c.created_at, c.updated_at`
+
+// CreateCompetition saves a new competition
+func (r *PortfolioRepository) CreateCompetition(ctx context.Context, competition *models.Competition) error {
+	universe, err := json.Marshal(competition.Universe)
+	if err != nil {
+		return fmt.Errorf("failed to encode universe: %w", err)
+	}
+	rules, err := json.Marshal(competition.Rules)
+	if err != nil {
+		return fmt.Errorf("failed to encode rules: %w", err)
+	}
+
+	query := `
+		INSERT INTO competitions (name, description, universe, start_date, end_date, starting_cash, rules,
+		                          created_at, updated_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+
+	now := time.Now()
+	err = r.db.QueryRowContext(ctx, query, competition.Name, competition.Description, universe,
+		competition.StartDate, competition.EndDate, competition.StartingCash, rules, now, now).Scan(&competition.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return domain.Conflictf("competition %q already exists", competition.Name)
+		}
+		r.logger.Error("Failed to create competition", zap.Error(err), zap.String("name", competition.Name))
+		return fmt.Errorf("failed to create competition: %w", err)
+	}
+
+	competition.CreatedAt = now
+	competition.UpdatedAt = now
+	return nil
+}
+
+// GetCompetition retrieves a competition by ID
+func (r *PortfolioRepository) GetCompetition(ctx context.Context, competitionID int) (*models.Competition, error) {
+	query := `SELECT ` + competitionColumns + ` FROM competitions c WHERE c.id = $1`
+
+	competition, err := scanCompetition(r.db.QueryRowContext(ctx, query, competitionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundf("competition not found: %d", competitionID)
+		}
+		r.logger.Error("Failed to get competition", zap.Error(err), zap.Int("competition_id", competitionID))
+		return nil, fmt.Errorf("failed to get competition: %w", err)
+	}
+
+	return competition, nil
+}
+
+// GetCompetitionByPortfolioID retrieves the competition a portfolio is
+// entered in, or nil when it is in none
+func (r *PortfolioRepository) GetCompetitionByPortfolioID(ctx context.Context, portfolioID int) (*models.Competition, error) {
+	query := `
+		SELECT ` + competitionColumns + `
+		FROM competitions c
+		JOIN competition_entries e ON e.competition_id = c.id
+		WHERE e.portfolio_id = $1`
+
+	competition, err := scanCompetition(r.db.QueryRowContext(ctx, query, portfolioID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get portfolio's competition", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, fmt.Errorf("failed to get competition: %w", err)
+	}
+
+	return competition, nil
+}
+
+// ListCompetitions retrieves every competition, latest start first
+func (r *PortfolioRepository) ListCompetitions(ctx context.Context) ([]models.Competition, error) {
+	query := `SELECT ` + competitionColumns + ` FROM competitions c ORDER BY c.start_date DESC, c.id DESC`
+	return r.queryCompetitions(ctx, query)
+}
+
+// GetCompetitionsToScore retrieves the competitions running on asOf, and
+// finished ones without standings for their end date
+func (r *PortfolioRepository) GetCompetitionsToScore(ctx context.Context, asOf time.Time) ([]models.Competition, error) {
+	query := `
+		SELECT ` + competitionColumns + `
+		FROM competitions c
+		WHERE c.start_date <= $1
+		  AND (c.end_date >= $1 OR NOT EXISTS (
+		      SELECT 1 FROM competition_standings s WHERE s.competition_id = c.id AND s.as_of = c.end_date))
+		ORDER BY c.id`
+	return r.queryCompetitions(ctx, query, asOf)
+}
+
+func (r *PortfolioRepository) queryCompetitions(ctx context.Context, query string, args ...interface{}) ([]models.Competition, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to get competitions", zap.Error(err))
+		return nil, fmt.Errorf("failed to get competitions: %w", err)
+	}
+	defer rows.Close()
+
+	competitions := []models.Competition{}
+	for rows.Next() {
+		competition, err := scanCompetition(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan competition: %w", err)
+		}
+		competitions = append(competitions, *competition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating competitions: %w", err)
+	}
+
+	return competitions, nil
+}
+
+// CreateCompetitionEntry enters a portfolio in a competition
+func (r *PortfolioRepository) CreateCompetitionEntry(ctx context.Context, entry *models.CompetitionEntry) error {
+	query := `
+		INSERT INTO competition_entries (competition_id, portfolio_id, user_id, joined_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query, entry.CompetitionID, entry.PortfolioID, entry.UserID, now).Scan(&entry.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return domain.Conflictf("competition entry already exists for portfolio %d or user %d", entry.PortfolioID, entry.UserID)
+		}
+		r.logger.Error("Failed to create competition entry", zap.Error(err), zap.Int("portfolio_id", entry.PortfolioID))
+		return fmt.Errorf("failed to create competition entry: %w", err)
+	}
+
+	entry.JoinedAt = now
+	return nil
+}
+
+// GetCompetitionEntries retrieves a competition's entries in joining order
+func (r *PortfolioRepository) GetCompetitionEntries(ctx context.Context, competitionID int) ([]models.CompetitionEntry, error) {
+	query := `
+		SELECT id, competition_id, portfolio_id, user_id, joined_at
+		FROM competition_entries
+		WHERE competition_id = $1
+		ORDER BY joined_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, competitionID)
+	if err != nil {
+		r.logger.Error("Failed to get competition entries", zap.Error(err), zap.Int("competition_id", competitionID))
+		return nil, fmt.Errorf("failed to get competition entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.CompetitionEntry{}
+	for rows.Next() {
+		var entry models.CompetitionEntry
+		if err := rows.Scan(&entry.ID, &entry.CompetitionID, &entry.PortfolioID, &entry.UserID, &entry.JoinedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan competition entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating competition entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// DeleteCompetitionEntry withdraws a portfolio from a competition
+func (r *PortfolioRepository) DeleteCompetitionEntry(ctx context.Context, competitionID, portfolioID int) error {
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM competition_entries WHERE competition_id = $1 AND portfolio_id = $2", competitionID, portfolioID)
+	if err != nil {
+		r.logger.Error("Failed to delete competition entry", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return fmt.Errorf("failed to delete competition entry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.NotFoundf("competition entry not found for portfolio %d", portfolioID)
+	}
+
+	return nil
+}
+
+// CountTradesSince counts a portfolio's trades placed at or after since
+func (r *PortfolioRepository) CountTradesSince(ctx context.Context, portfolioID int, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM trades WHERE portfolio_id = $1 AND created_at >= $2`, portfolioID, since).Scan(&count)
+	if err != nil {
+		r.logger.Error("Failed to count trades", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return 0, fmt.Errorf("failed to count trades: %w", err)
+	}
+	return count, nil
+}
+
+// SaveStandings records a competition's standings for a day, replacing any
+// already recorded for it
+func (r *PortfolioRepository) SaveStandings(ctx context.Context, standings []models.CompetitionStanding) error {
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO competition_standings (competition_id, portfolio_id, as_of, total_value, return_percent, rank)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (competition_id, portfolio_id, as_of) DO UPDATE
+		SET total_value = EXCLUDED.total_value, return_percent = EXCLUDED.return_percent, rank = EXCLUDED.rank`
+
+	for _, s := range standings {
+		_, err := tx.ExecContext(ctx, query, s.CompetitionID, s.PortfolioID, s.AsOf, s.TotalValue, s.ReturnPercent, s.Rank)
+		if err != nil {
+			r.logger.Error("Failed to save competition standing", zap.Error(err),
+				zap.Int("competition_id", s.CompetitionID), zap.Int("portfolio_id", s.PortfolioID))
+			return fmt.Errorf("failed to save competition standing: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit competition standings: %w", err)
+	}
+	return nil
+}
+
+// GetLatestStandings retrieves a competition's most recent standings by rank
+func (r *PortfolioRepository) GetLatestStandings(ctx context.Context, competitionID int) ([]models.CompetitionStanding, error) {
+	query := `
+		SELECT s.competition_id, s.portfolio_id, u.username, s.as_of, s.total_value, s.return_percent, s.rank
+		FROM competition_standings s
+		JOIN portfolios p ON p.id = s.portfolio_id
+		JOIN users u ON u.id = p.user_id
+		WHERE s.competition_id = $1
+		  AND s.as_of = (SELECT MAX(as_of) FROM competition_standings WHERE competition_id = $1)
+		ORDER BY s.rank, s.portfolio_id`
+
+	rows, err := r.db.QueryContext(ctx, query, competitionID)
+	if err != nil {
+		r.logger.Error("Failed to get competition standings", zap.Error(err), zap.Int("competition_id", competitionID))
+		return nil, fmt.Errorf("failed to get competition standings: %w", err)
+	}
+	defer rows.Close()
+
+	standings := []models.CompetitionStanding{}
+	for rows.Next() {
+		var s models.CompetitionStanding
+		err := rows.Scan(&s.CompetitionID, &s.PortfolioID, &s.Username, &s.AsOf, &s.TotalValue, &s.ReturnPercent, &s.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan competition standing: %w", err)
+		}
+		standings = append(standings, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating competition standings: %w", err)
+	}
+
+	return standings, nil
+}
+
+func scanCompetition(row rowScanner) (*models.Competition, error) {
+	competition := &models.Competition{}
+	var universe, rules []byte
+	err := row.Scan(
+		&competition.ID,
+		&competition.Name,
+		&competition.Description,
+		&universe,
+		&competition.StartDate,
+		&competition.EndDate,
+		&competition.StartingCash,
+		&rules,
+		&competition.Entries,
+		&competition.CreatedAt,
+		&competition.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(universe, &competition.Universe); err != nil {
+		return nil, fmt.Errorf("failed to decode universe: %w", err)
+	}
+	if err := json.Unmarshal(rules, &competition.Rules); err != nil {
+		return nil, fmt.Errorf("failed to decode rules: %w", err)
+	}
+	return competition, nil
+}
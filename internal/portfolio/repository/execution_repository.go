@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Order Execution Operations
+
+// SetTradeBrokerOrder records the venue and venue order ID of a live order
+func (r *PortfolioRepository) SetTradeBrokerOrder(ctx context.Context, tradeID int, broker, brokerOrderID string) error {
+	query := `UPDATE trades SET broker = $2, broker_order_id = $3 WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, tradeID, broker, brokerOrderID); err != nil {
+		r.logger.Error("Failed to set broker order", zap.Error(err), zap.Int("trade_id", tradeID))
+		return fmt.Errorf("failed to update trade: %w", err)
+	}
+	return nil
+}
+
+// UpdateTradeStatus moves a pending trade to a final status without a fill
+func (r *PortfolioRepository) UpdateTradeStatus(ctx context.Context, tradeID int, status string) error {
+	query := `UPDATE trades SET status = $2 WHERE id = $1 AND status = 'pending'`
+
+	result, err := r.db.ExecContext(ctx, query, tradeID, status)
+	if err != nil {
+		r.logger.Error("Failed to update trade status", zap.Error(err), zap.Int("trade_id", tradeID))
+		return fmt.Errorf("failed to update trade: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.NotFoundf("pending trade not found: %d", tradeID)
+	}
+
+	return nil
+}
+
+// GetTradeByID retrieves a trade of a portfolio
+func (r *PortfolioRepository) GetTradeByID(ctx context.Context, portfolioID, tradeID int) (*models.Trade, error) {
+	query := `
+		SELECT id, user_id, portfolio_id, COALESCE(position_id, 0), symbol, asset_type, quantity, multiplier, price, side, type, status,
+		       time_in_force, fees, COALESCE(broker, ''), COALESCE(broker_order_id, ''), executed_at, created_at
+		FROM trades
+		WHERE id = $1 AND portfolio_id = $2`
+
+	trade := &models.Trade{}
+	err := r.db.QueryRowContext(ctx, query, tradeID, portfolioID).Scan(
+		&trade.ID,
+		&trade.UserID,
+		&trade.PortfolioID,
+		&trade.PositionID,
+		&trade.Symbol,
+		&trade.AssetType,
+		&trade.Quantity,
+		&trade.Multiplier,
+		&trade.Price,
+		&trade.Side,
+		&trade.Type,
+		&trade.Status,
+		&trade.TimeInForce,
+		&trade.Fees,
+		&trade.Broker,
+		&trade.BrokerOrderID,
+		&trade.ExecutedAt,
+		&trade.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.NotFoundf("trade not found: %d", tradeID)
+	}
+	if err != nil {
+		r.logger.Error("Failed to get trade", zap.Error(err), zap.Int("trade_id", tradeID))
+		return nil, fmt.Errorf("failed to get trade: %w", err)
+	}
+	return trade, nil
+}
+
+// GetOpenBrokerTrades retrieves live orders still waiting for the venue, oldest first
+func (r *PortfolioRepository) GetOpenBrokerTrades(ctx context.Context, limit int) ([]models.Trade, error) {
+	return r.getOpenBrokerTrades(ctx, "", limit)
+}
+
+// GetOpenDayTrades retrieves live DAY orders placed before a time and still
+// waiting for the venue, oldest first
+func (r *PortfolioRepository) GetOpenDayTrades(ctx context.Context, before time.Time, limit int) ([]models.Trade, error) {
+	return r.getOpenBrokerTrades(ctx, ` AND time_in_force = 'day' AND created_at < $2`, limit, before)
+}
+
+func (r *PortfolioRepository) getOpenBrokerTrades(ctx context.Context, cond string, limit int, args ...interface{}) ([]models.Trade, error) {
+	query := `
+		SELECT id, user_id, portfolio_id, COALESCE(position_id, 0), symbol, asset_type, quantity, multiplier, price, side, type, status,
+		       time_in_force, fees, broker, broker_order_id, executed_at, created_at
+		FROM trades
+		WHERE status = 'pending' AND broker_order_id IS NOT NULL` + cond + `
+		ORDER BY id
+		LIMIT $1`
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{limit}, args...)...)
+	if err != nil {
+		r.logger.Error("Failed to get open broker trades", zap.Error(err))
+		return nil, fmt.Errorf("failed to get trades: %w", err)
+	}
+	defer rows.Close()
+
+	var trades []models.Trade
+	for rows.Next() {
+		trade := models.Trade{}
+		err := rows.Scan(
+			&trade.ID,
+			&trade.UserID,
+			&trade.PortfolioID,
+			&trade.PositionID,
+			&trade.Symbol,
+			&trade.AssetType,
+			&trade.Quantity,
+			&trade.Multiplier,
+			&trade.Price,
+			&trade.Side,
+			&trade.Type,
+			&trade.Status,
+			&trade.TimeInForce,
+			&trade.Fees,
+			&trade.Broker,
+			&trade.BrokerOrderID,
+			&trade.ExecutedAt,
+			&trade.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trade: %w", err)
+		}
+		trades = append(trades, trade)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trades: %w", err)
+	}
+
+	return trades, nil
+}
+
+// FillTradeTx records the fill of a pending trade within a transaction. It
+// fails if the trade is no longer pending, so a fill is applied only once.
+func (r *PortfolioRepository) FillTradeTx(ctx context.Context, tx *sql.Tx, trade *models.Trade) error {
+	query := `
+		UPDATE trades
+		SET quantity = $2, price = $3, fees = $4, fee_items = $5, status = $6, executed_at = $7, position_id = NULLIF($8, 0)
+		WHERE id = $1 AND status = 'pending'`
+
+	result, err := tx.ExecContext(ctx, query,
+		trade.ID,
+		trade.Quantity,
+		trade.Price,
+		trade.Fees,
+		feeItems(trade),
+		trade.Status,
+		trade.ExecutedAt,
+		trade.PositionID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to fill trade", zap.Error(err), zap.Int("trade_id", trade.ID))
+		return fmt.Errorf("failed to update trade: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.NotFoundf("pending trade not found: %d", trade.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Export Operations
+
+// StreamTrades calls fn with each filled trade of a portfolio executed in
+// [from, to), oldest first, as it is read from the database, so exports never
+// hold the whole history in memory. A zero from or to leaves that end of the
+// range open. An error from fn stops the stream and is returned.
+func (r *PortfolioRepository) StreamTrades(ctx context.Context, portfolioID int, from, to time.Time, fn func(*models.Trade) error) error {
+	query := `
+		SELECT id, user_id, portfolio_id, COALESCE(position_id, 0), symbol, asset_type, quantity, multiplier, price, side,
+		       type, status, fees, fee_items, COALESCE(broker, ''), COALESCE(broker_order_id, ''),
+		       COALESCE(trigger_reason, ''), executed_at, created_at
+		FROM trades
+		WHERE portfolio_id = $1 AND status = 'filled'`
+	args := []interface{}{portfolioID}
+	if !from.IsZero() {
+		args = append(args, from)
+		query += ` AND executed_at >= $` + strconv.Itoa(len(args))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		query += ` AND executed_at < $` + strconv.Itoa(len(args))
+	}
+	query += ` ORDER BY executed_at, id`
+
+	rows, err := r.reader().QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to export trades", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return fmt.Errorf("failed to get trades: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		trade := models.Trade{}
+		var items []byte
+		err := rows.Scan(
+			&trade.ID,
+			&trade.UserID,
+			&trade.PortfolioID,
+			&trade.PositionID,
+			&trade.Symbol,
+			&trade.AssetType,
+			&trade.Quantity,
+			&trade.Multiplier,
+			&trade.Price,
+			&trade.Side,
+			&trade.Type,
+			&trade.Status,
+			&trade.Fees,
+			&items,
+			&trade.Broker,
+			&trade.BrokerOrderID,
+			&trade.TriggerReason,
+			&trade.ExecutedAt,
+			&trade.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan trade: %w", err)
+		}
+		if trade.FeeItems, err = decodeFeeItems(items); err != nil {
+			return err
+		}
+		if err := fn(&trade); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating trades: %w", err)
+	}
+	return nil
+}
+
+// StreamPositions calls fn with each position of a portfolio, by symbol, as
+// it is read from the database. An error from fn stops the stream and is
+// returned.
+func (r *PortfolioRepository) StreamPositions(ctx context.Context, portfolioID int, fn func(*models.Position) error) error {
+	query := `
+		SELECT id, user_id, portfolio_id, symbol, asset_type, quantity, multiplier, side, entry_price, current_price,
+		       unrealized_pnl, realized_pnl, stop_loss_percent, stop_loss_price, created_at, updated_at
+		FROM positions
+		WHERE portfolio_id = $1
+		ORDER BY symbol`
+
+	rows, err := r.reader().QueryContext(ctx, query, portfolioID)
+	if err != nil {
+		r.logger.Error("Failed to export positions", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return fmt.Errorf("failed to get positions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		position := models.Position{}
+		err := rows.Scan(
+			&position.ID,
+			&position.UserID,
+			&position.PortfolioID,
+			&position.Symbol,
+			&position.AssetType,
+			&position.Quantity,
+			&position.Multiplier,
+			&position.Side,
+			&position.EntryPrice,
+			&position.CurrentPrice,
+			&position.UnrealizedPnL,
+			&position.RealizedPnL,
+			&position.StopLossPercent,
+			&position.StopLossPrice,
+			&position.CreatedAt,
+			&position.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan position: %w", err)
+		}
+		if err := fn(&position); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating positions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Factor Exposure Operations
+
+// GetDailyBars retrieves the stored daily bars of symbols between start and
+// end (inclusive), oldest first
+func (r *PortfolioRepository) GetDailyBars(ctx context.Context, symbols []string, start, end time.Time) (map[string][]models.Price, error) {
+	query := `
+		SELECT symbol, close, volume, timestamp
+		FROM market_prices
+		WHERE symbol = ANY($1) AND bar_interval = '1d' AND timestamp >= $2 AND timestamp <= $3
+		ORDER BY symbol, timestamp`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(symbols), start, end)
+	if err != nil {
+		r.logger.Error("Failed to get daily bars", zap.Error(err), zap.Strings("symbols", symbols))
+		return nil, fmt.Errorf("failed to get daily bars: %w", err)
+	}
+	defer rows.Close()
+
+	bars := make(map[string][]models.Price, len(symbols))
+	for rows.Next() {
+		bar := models.Price{Interval: "1d"}
+		if err := rows.Scan(&bar.Symbol, &bar.Close, &bar.Volume, &bar.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan daily bar: %w", err)
+		}
+		bars[bar.Symbol] = append(bars[bar.Symbol], bar)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating daily bars: %w", err)
+	}
+
+	return bars, nil
+}
+
+// UpsertFactorExposure records a portfolio's factor exposure for a day,
+// replacing any already recorded that day
+func (r *PortfolioRepository) UpsertFactorExposure(ctx context.Context, exposure *models.FactorExposure) error {
+	query := `
+		INSERT INTO portfolio_factor_exposures (portfolio_id, exposure_date, benchmark, beta, size, momentum, value, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (portfolio_id, exposure_date) DO UPDATE
+		SET benchmark = EXCLUDED.benchmark, beta = EXCLUDED.beta, size = EXCLUDED.size,
+		    momentum = EXCLUDED.momentum, value = EXCLUDED.value, created_at = EXCLUDED.created_at
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query, exposure.PortfolioID, exposure.ExposureDate, exposure.Benchmark,
+		exposure.Beta, exposure.Size, exposure.Momentum, exposure.Value, now).Scan(&exposure.ID)
+	if err != nil {
+		r.logger.Error("Failed to save factor exposure", zap.Error(err), zap.Int("portfolio_id", exposure.PortfolioID))
+		return fmt.Errorf("failed to save factor exposure: %w", err)
+	}
+
+	exposure.CreatedAt = now
+	return nil
+}
+
+// GetFactorExposuresSince retrieves a portfolio's factor exposures recorded
+// on or after start, oldest first
+func (r *PortfolioRepository) GetFactorExposuresSince(ctx context.Context, portfolioID int, start time.Time) ([]models.FactorExposure, error) {
+	query := `
+		SELECT id, portfolio_id, exposure_date, benchmark, beta, size, momentum, value, created_at
+		FROM portfolio_factor_exposures
+		WHERE portfolio_id = $1 AND exposure_date >= $2
+		ORDER BY exposure_date`
+
+	rows, err := r.db.QueryContext(ctx, query, portfolioID, start)
+	if err != nil {
+		r.logger.Error("Failed to get factor exposures", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, fmt.Errorf("failed to get factor exposures: %w", err)
+	}
+	defer rows.Close()
+
+	exposures := []models.FactorExposure{}
+	for rows.Next() {
+		var exposure models.FactorExposure
+		err := rows.Scan(&exposure.ID, &exposure.PortfolioID, &exposure.ExposureDate, &exposure.Benchmark,
+			&exposure.Beta, &exposure.Size, &exposure.Momentum, &exposure.Value, &exposure.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan factor exposure: %w", err)
+		}
+		exposures = append(exposures, exposure)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating factor exposures: %w", err)
+	}
+
+	return exposures, nil
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Portfolio Import Operations
+
+const importColumns = `
+	id, portfolio_id, kind, format, filename, columns, mapping, adjust_cash, content, status, COALESCE(job_id, ''),
+	total_rows, processed_rows, imported_rows, duplicate_rows, failed_rows, errors, COALESCE(error, ''),
+	created_by, created_at, started_at, completed_at`
+
+// CreateImport stores an uploaded import awaiting confirmation
+func (r *PortfolioRepository) CreateImport(ctx context.Context, imp *models.PortfolioImport) error {
+	columns, _ := json.Marshal(imp.Columns) // Plain values always marshal
+	mapping, _ := json.Marshal(imp.Mapping)
+
+	query := `
+		INSERT INTO portfolio_imports (portfolio_id, kind, format, filename, columns, mapping, adjust_cash, content,
+		                               status, total_rows, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at`
+
+	imp.Status = models.ImportPreviewed
+	err := r.db.QueryRowContext(ctx, query, imp.PortfolioID, imp.Kind, imp.Format, imp.Filename, columns, mapping,
+		imp.AdjustCash, imp.Content, imp.Status, imp.TotalRows, imp.CreatedBy,
+	).Scan(&imp.ID, &imp.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create import", zap.Error(err), zap.Int("portfolio_id", imp.PortfolioID))
+		return fmt.Errorf("failed to create import: %w", err)
+	}
+	return nil
+}
+
+// GetImport retrieves an import with its file
+func (r *PortfolioRepository) GetImport(ctx context.Context, importID int) (*models.PortfolioImport, error) {
+	query := `SELECT ` + importColumns + ` FROM portfolio_imports WHERE id = $1`
+
+	imp, err := scanImport(r.db.QueryRowContext(ctx, query, importID))
+	if err == sql.ErrNoRows {
+		return nil, domain.NotFoundf("import not found: %d", importID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import: %w", err)
+	}
+	return imp, nil
+}
+
+// UpdateImportMapping replaces the mapping of an import awaiting confirmation
+func (r *PortfolioRepository) UpdateImportMapping(ctx context.Context, importID int, mapping map[string]string, adjustCash bool) error {
+	data, _ := json.Marshal(mapping)
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE portfolio_imports SET mapping = $2, adjust_cash = $3
+		WHERE id = $1 AND status = 'previewed'`, importID, data, adjustCash)
+	if err != nil {
+		return fmt.Errorf("failed to update import mapping: %w", err)
+	}
+	return r.expectPreviewed(ctx, result, importID)
+}
+
+// QueueImport records the job applying an import awaiting confirmation
+func (r *PortfolioRepository) QueueImport(ctx context.Context, importID int, jobID string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE portfolio_imports SET status = 'queued', job_id = $2
+		WHERE id = $1 AND status = 'previewed'`, importID, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to queue import: %w", err)
+	}
+	return r.expectPreviewed(ctx, result, importID)
+}
+
+// expectPreviewed tells why an update of an import awaiting confirmation
+// changed no row
+func (r *PortfolioRepository) expectPreviewed(ctx context.Context, result sql.Result, importID int) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+	if _, err := r.GetImport(ctx, importID); err != nil {
+		return err
+	}
+	return domain.Conflictf("import %d has already been started", importID)
+}
+
+// StartImport marks a queued import running, or a failed or interrupted one
+// running again, and resets its progress
+func (r *PortfolioRepository) StartImport(ctx context.Context, importID, totalRows int) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE portfolio_imports
+		SET status = 'running', total_rows = $2, processed_rows = 0, imported_rows = 0, duplicate_rows = 0,
+		    failed_rows = 0, errors = '[]', error = NULL, started_at = NOW(), completed_at = NULL
+		WHERE id = $1 AND status <> 'completed' AND status <> 'previewed'`, importID, totalRows)
+	if err != nil {
+		return fmt.Errorf("failed to start import: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.Conflictf("import %d is not queued", importID)
+	}
+	return nil
+}
+
+// UpdateImportProgress records how far a running import has got
+func (r *PortfolioRepository) UpdateImportProgress(ctx context.Context, imp *models.PortfolioImport) error {
+	errors, _ := json.Marshal(imp.Errors)
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE portfolio_imports
+		SET processed_rows = $2, imported_rows = $3, duplicate_rows = $4, failed_rows = $5, errors = $6
+		WHERE id = $1`,
+		imp.ID, imp.ProcessedRows, imp.ImportedRows, imp.DuplicateRows, imp.FailedRows, errors)
+	if err != nil {
+		r.logger.Error("Failed to update import progress", zap.Error(err), zap.Int("import_id", imp.ID))
+		return fmt.Errorf("failed to update import progress: %w", err)
+	}
+	return nil
+}
+
+// FinishImport records an import's final progress and status, and drops its
+// file once it has been applied
+func (r *PortfolioRepository) FinishImport(ctx context.Context, imp *models.PortfolioImport) error {
+	errors, _ := json.Marshal(imp.Errors)
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE portfolio_imports
+		SET status = $2, processed_rows = $3, imported_rows = $4, duplicate_rows = $5, failed_rows = $6, errors = $7,
+		    error = NULLIF($8, ''), completed_at = $9,
+		    content = CASE WHEN $2 = 'completed' THEN '' ELSE content END
+		WHERE id = $1`,
+		imp.ID, imp.Status, imp.ProcessedRows, imp.ImportedRows, imp.DuplicateRows, imp.FailedRows, errors,
+		imp.Error, now)
+	if err != nil {
+		r.logger.Error("Failed to finish import", zap.Error(err), zap.Int("import_id", imp.ID))
+		return fmt.Errorf("failed to finish import: %w", err)
+	}
+	imp.CompletedAt = &now
+	return nil
+}
+
+// GetTradesForImport retrieves the filled trades of a portfolio executed in
+// [start, end), with the broker IDs imports are matched on
+func (r *PortfolioRepository) GetTradesForImport(ctx context.Context, portfolioID int, start, end time.Time) ([]models.Trade, error) {
+	query := `
+		SELECT symbol, side, quantity, price, COALESCE(broker_order_id, ''), executed_at
+		FROM trades
+		WHERE portfolio_id = $1 AND status = 'filled' AND executed_at >= $2 AND executed_at < $3`
+
+	rows, err := r.db.QueryContext(ctx, query, portfolioID, start, end)
+	if err != nil {
+		r.logger.Error("Failed to get trades for import", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, fmt.Errorf("failed to get trades: %w", err)
+	}
+	defer rows.Close()
+
+	var trades []models.Trade
+	for rows.Next() {
+		trade := models.Trade{PortfolioID: portfolioID}
+		if err := rows.Scan(&trade.Symbol, &trade.Side, &trade.Quantity, &trade.Price, &trade.BrokerOrderID, &trade.ExecutedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan trade: %w", err)
+		}
+		trades = append(trades, trade)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trades: %w", err)
+	}
+
+	return trades, nil
+}
+
+func scanImport(row rowScanner) (*models.PortfolioImport, error) {
+	var imp models.PortfolioImport
+	var columns, mapping, errors []byte
+	err := row.Scan(&imp.ID, &imp.PortfolioID, &imp.Kind, &imp.Format, &imp.Filename, &columns, &mapping,
+		&imp.AdjustCash, &imp.Content, &imp.Status, &imp.JobID, &imp.TotalRows, &imp.ProcessedRows, &imp.ImportedRows,
+		&imp.DuplicateRows, &imp.FailedRows, &errors, &imp.Error, &imp.CreatedBy, &imp.CreatedAt, &imp.StartedAt,
+		&imp.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(columns, &imp.Columns); err != nil {
+		return nil, fmt.Errorf("failed to decode import columns: %w", err)
+	}
+	if err := json.Unmarshal(mapping, &imp.Mapping); err != nil {
+		return nil, fmt.Errorf("failed to decode import mapping: %w", err)
+	}
+	if err := json.Unmarshal(errors, &imp.Errors); err != nil {
+		return nil, fmt.Errorf("failed to decode import errors: %w", err)
+	}
+	return &imp, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Position Lot Operations
+
+const lotColumns = `id, portfolio_id, symbol, COALESCE(trade_id, 0), quantity, remaining_quantity, price, acquired_at, closed_at`
+
+// CreateLotTx records a lot acquired by a buy within a transaction
+func (r *PortfolioRepository) CreateLotTx(ctx context.Context, tx *sql.Tx, lot *models.PositionLot) error {
+	lot.Symbol = symbols.Normalize(lot.Symbol)
+
+	query := `
+		INSERT INTO position_lots (portfolio_id, symbol, trade_id, quantity, remaining_quantity, price, acquired_at)
+		VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7)
+		RETURNING id`
+
+	err := tx.QueryRowContext(ctx, query,
+		lot.PortfolioID,
+		lot.Symbol,
+		lot.TradeID,
+		lot.Quantity,
+		lot.RemainingQuantity,
+		lot.Price,
+		lot.AcquiredAt,
+	).Scan(&lot.ID)
+	if err != nil {
+		r.logger.Error("Failed to create position lot", zap.Error(err),
+			zap.Int("portfolio_id", lot.PortfolioID), zap.String("symbol", lot.Symbol))
+		return fmt.Errorf("failed to create position lot: %w", err)
+	}
+
+	return nil
+}
+
+// GetOpenLotsForUpdateTx retrieves a symbol's open lots oldest first, locking
+// them until the transaction ends so concurrent sells consume each share once
+func (r *PortfolioRepository) GetOpenLotsForUpdateTx(ctx context.Context, tx *sql.Tx, portfolioID int, symbol string) ([]models.PositionLot, error) {
+	query := `
+		SELECT ` + lotColumns + `
+		FROM position_lots
+		WHERE portfolio_id = $1 AND symbol = $2 AND remaining_quantity > 0
+		ORDER BY acquired_at, id
+		FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, query, portfolioID, symbol)
+	if err != nil {
+		r.logger.Error("Failed to get open lots", zap.Error(err),
+			zap.Int("portfolio_id", portfolioID), zap.String("symbol", symbol))
+		return nil, fmt.Errorf("failed to get open lots: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLots(rows)
+}
+
+// GetOpenLots retrieves a symbol's open lots oldest first
+func (r *PortfolioRepository) GetOpenLots(ctx context.Context, portfolioID int, symbol string) ([]models.PositionLot, error) {
+	query := `
+		SELECT ` + lotColumns + `
+		FROM position_lots
+		WHERE portfolio_id = $1 AND symbol = $2 AND remaining_quantity > 0
+		ORDER BY acquired_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, portfolioID, symbol)
+	if err != nil {
+		r.logger.Error("Failed to get open lots", zap.Error(err),
+			zap.Int("portfolio_id", portfolioID), zap.String("symbol", symbol))
+		return nil, fmt.Errorf("failed to get open lots: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLots(rows)
+}
+
+// GetLots retrieves a symbol's lots, open and closed, oldest first
+func (r *PortfolioRepository) GetLots(ctx context.Context, portfolioID int, symbol string) ([]models.PositionLot, error) {
+	query := `
+		SELECT ` + lotColumns + `
+		FROM position_lots
+		WHERE portfolio_id = $1 AND symbol = $2
+		ORDER BY acquired_at, id`
+
+	rows, err := r.reader().QueryContext(ctx, query, portfolioID, symbol)
+	if err != nil {
+		r.logger.Error("Failed to get lots", zap.Error(err),
+			zap.Int("portfolio_id", portfolioID), zap.String("symbol", symbol))
+		return nil, fmt.Errorf("failed to get lots: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLots(rows)
+}
+
+// UpdateLotTx saves the remaining quantity of a lot reduced by a sell
+func (r *PortfolioRepository) UpdateLotTx(ctx context.Context, tx *sql.Tx, lot *models.PositionLot) error {
+	query := `UPDATE position_lots SET remaining_quantity = $2, closed_at = $3 WHERE id = $1`
+
+	if _, err := tx.ExecContext(ctx, query, lot.ID, lot.RemainingQuantity, lot.ClosedAt); err != nil {
+		r.logger.Error("Failed to update position lot", zap.Error(err), zap.Int("lot_id", lot.ID))
+		return fmt.Errorf("failed to update position lot: %w", err)
+	}
+	return nil
+}
+
+func scanLots(rows *sql.Rows) ([]models.PositionLot, error) {
+	var lots []models.PositionLot
+	for rows.Next() {
+		lot := models.PositionLot{}
+		err := rows.Scan(
+			&lot.ID,
+			&lot.PortfolioID,
+			&lot.Symbol,
+			&lot.TradeID,
+			&lot.Quantity,
+			&lot.RemainingQuantity,
+			&lot.Price,
+			&lot.AcquiredAt,
+			&lot.ClosedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan position lot: %w", err)
+		}
+		lots = append(lots, lot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating position lots: %w", err)
+	}
+
+	return lots, nil
+}
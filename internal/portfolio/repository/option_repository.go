@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Option Operations
+
+// GetOptionPositions retrieves every open position in an option contract
+func (r *PortfolioRepository) GetOptionPositions(ctx context.Context) ([]models.Position, error) {
+	query := `
+		SELECT id, user_id, portfolio_id, symbol, asset_type, quantity, multiplier, side, entry_price, current_price,
+		       unrealized_pnl, realized_pnl, stop_loss_percent, stop_loss_price, created_at, updated_at
+		FROM positions
+		WHERE asset_type = 'option'
+		ORDER BY portfolio_id, id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to get option positions", zap.Error(err))
+		return nil, fmt.Errorf("failed to get option positions: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []models.Position
+	for rows.Next() {
+		position := models.Position{}
+		err := rows.Scan(
+			&position.ID,
+			&position.UserID,
+			&position.PortfolioID,
+			&position.Symbol,
+			&position.AssetType,
+			&position.Quantity,
+			&position.Multiplier,
+			&position.Side,
+			&position.EntryPrice,
+			&position.CurrentPrice,
+			&position.UnrealizedPnL,
+			&position.RealizedPnL,
+			&position.StopLossPercent,
+			&position.StopLossPrice,
+			&position.CreatedAt,
+			&position.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan position: %w", err)
+		}
+		positions = append(positions, position)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating positions: %w", err)
+	}
+
+	return positions, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"hedge-fund/pkg/shared/pagination"
+)
+
+// Sort whitelists for paginated lists
+
+var (
+	PortfolioSorts = pagination.Sorts{
+		ID: pagination.Field{Column: "id", Type: "integer"},
+		Fields: map[string]pagination.Field{
+			"created_at":  {Column: "created_at", Type: "timestamptz"},
+			"name":        {Column: "name", Type: "text"},
+			"total_value": {Column: "total_value", Type: "numeric"},
+		},
+		Default: "created_at",
+		Desc:    true,
+	}
+
+	TradeSorts = pagination.Sorts{
+		ID: pagination.Field{Column: "id", Type: "integer"},
+		Fields: map[string]pagination.Field{
+			"created_at": {Column: "created_at", Type: "timestamptz"},
+			"symbol":     {Column: "symbol", Type: "text"},
+			"quantity":   {Column: "quantity", Type: "numeric"},
+			"price":      {Column: "price", Type: "numeric"},
+		},
+		Default: "created_at",
+		Desc:    true,
+	}
+
+	AuditSorts = pagination.Sorts{
+		ID: pagination.Field{Column: "id", Type: "bigint"},
+		Fields: map[string]pagination.Field{
+			"created_at": {Column: "created_at", Type: "timestamptz"},
+		},
+		Default: "created_at",
+		Desc:    true,
+	}
+
+	CashTransactionSorts = pagination.Sorts{
+		ID: pagination.Field{Column: "id", Type: "bigint"},
+		Fields: map[string]pagination.Field{
+			"created_at": {Column: "created_at", Type: "timestamptz"},
+			"amount":     {Column: "amount", Type: "numeric"},
+		},
+		Default: "created_at",
+		Desc:    true,
+	}
+
+	DriftCheckSorts = pagination.Sorts{
+		ID: pagination.Field{Column: "id", Type: "bigint"},
+		Fields: map[string]pagination.Field{
+			"checked_at": {Column: "checked_at", Type: "timestamptz"},
+			"max_drift":  {Column: "max_drift", Type: "numeric"},
+		},
+		Default: "checked_at",
+		Desc:    true,
+	}
+
+	ReconciliationSorts = pagination.Sorts{
+		ID: pagination.Field{Column: "id", Type: "integer"},
+		Fields: map[string]pagination.Field{
+			"statement_date": {Column: "statement_date", Type: "date"},
+		},
+		Default: "statement_date",
+		Desc:    true,
+	}
+
+	VaRBacktestSorts = pagination.Sorts{
+		ID: pagination.Field{Column: "id", Type: "bigint"},
+		Fields: map[string]pagination.Field{
+			"created_at": {Column: "created_at", Type: "timestamptz"},
+			"end_date":   {Column: "end_date", Type: "date"},
+		},
+		Default: "created_at",
+		Desc:    true,
+	}
+
+	StatementSorts = pagination.Sorts{
+		ID: pagination.Field{Column: "id", Type: "bigint"},
+		Fields: map[string]pagination.Field{
+			"month": {Column: "month", Type: "date"},
+		},
+		Default: "month",
+		Desc:    true,
+	}
+)
+
+// keyedRow appends a page's sort key to the columns a row scan reads, so
+// shared scan helpers can be used for paginated queries
+type keyedRow struct {
+	row rowScanner
+	key *string
+}
+
+func (k keyedRow) Scan(dest ...interface{}) error {
+	return k.row.Scan(append(dest, k.key)...)
+}
+
+// count runs a COUNT(*) query for a paginated list's total
+func (r *PortfolioRepository) count(ctx context.Context, query string, args ...interface{}) (int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count rows: %w", err)
+	}
+	return total, nil
+}
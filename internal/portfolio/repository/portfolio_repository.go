@@ -3,11 +3,16 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
+	"hedge-fund/internal/portfolio/domain"
 	"hedge-fund/pkg/shared/database"
 	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/symbols"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -28,15 +33,17 @@ func NewPortfolioRepository(db *database.DB, logger *zap.Logger) *PortfolioRepos
 // CreatePortfolio creates a new portfolio
 func (r *PortfolioRepository) CreatePortfolio(ctx context.Context, portfolio *models.Portfolio) error {
 	query := `
-		INSERT INTO portfolios (user_id, name, cash, margin_used, margin_available, total_value,
-		                       unrealized_pnl, realized_pnl, day_pnl, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		RETURNING id`
+		INSERT INTO portfolios (user_id, name, description, strategy_tags, cash, margin_used, margin_available,
+		                       total_value, unrealized_pnl, realized_pnl, day_pnl, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, version`
 
 	now := time.Now()
 	err := r.db.QueryRowContext(ctx, query,
 		portfolio.UserID,
 		portfolio.Name,
+		portfolio.Description,
+		strategyTags(portfolio),
 		portfolio.Cash,
 		portfolio.MarginUsed,
 		portfolio.MarginAvailable,
@@ -46,7 +53,7 @@ func (r *PortfolioRepository) CreatePortfolio(ctx context.Context, portfolio *mo
 		portfolio.DayPnL,
 		now,
 		now,
-	).Scan(&portfolio.ID)
+	).Scan(&portfolio.ID, &portfolio.Version)
 
 	if err != nil {
 		r.logger.Error("Failed to create portfolio", zap.Error(err), zap.Int("user_id", portfolio.UserID))
@@ -65,17 +72,55 @@ func (r *PortfolioRepository) CreatePortfolio(ctx context.Context, portfolio *mo
 
 // GetPortfolioByID retrieves a portfolio by ID with all positions
 func (r *PortfolioRepository) GetPortfolioByID(ctx context.Context, portfolioID int) (*models.Portfolio, error) {
+	return r.getPortfolio(ctx, r.db, portfolioID, "")
+}
+
+// GetPortfolioForRead retrieves a portfolio with all positions from the read
+// replica, for summaries and analysis that tolerate replication lag
+func (r *PortfolioRepository) GetPortfolioForRead(ctx context.Context, portfolioID int) (*models.Portfolio, error) {
+	return r.getPortfolio(ctx, r.reader(), portfolioID, "")
+}
+
+// GetPortfolioForUpdateTx retrieves a portfolio with all positions, locking
+// the portfolio and position rows until the transaction ends so concurrent
+// trades are applied one after another against current cash and holdings.
+// NO KEY UPDATE still lets other connections insert rows referencing them.
+func (r *PortfolioRepository) GetPortfolioForUpdateTx(ctx context.Context, tx *sql.Tx, portfolioID int) (*models.Portfolio, error) {
+	return r.getPortfolio(ctx, tx, portfolioID, "FOR NO KEY UPDATE")
+}
+
+// queryer is satisfied by both the database and a transaction
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// reader is for read-only queries that tolerate replication lag: history,
+// summaries and snapshots. It is the read replica when one is configured.
+func (r *PortfolioRepository) reader() queryer {
+	return r.db.Reader()
+}
+
+// execer is satisfied by both the database and a transaction
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (r *PortfolioRepository) getPortfolio(ctx context.Context, q queryer, portfolioID int, lock string) (*models.Portfolio, error) {
 	query := `
-		SELECT id, user_id, name, cash, margin_used, margin_available, total_value,
-		       unrealized_pnl, realized_pnl, day_pnl, created_at, updated_at
+		SELECT id, user_id, name, description, strategy_tags, cash, margin_used, margin_available, total_value,
+		       unrealized_pnl, realized_pnl, day_pnl, version, created_at, updated_at
 		FROM portfolios
-		WHERE id = $1`
+		WHERE id = $1
+		` + lock
 
 	portfolio := &models.Portfolio{}
-	err := r.db.QueryRowContext(ctx, query, portfolioID).Scan(
+	err := q.QueryRowContext(ctx, query, portfolioID).Scan(
 		&portfolio.ID,
 		&portfolio.UserID,
 		&portfolio.Name,
+		&portfolio.Description,
+		pq.Array(&portfolio.StrategyTags),
 		&portfolio.Cash,
 		&portfolio.MarginUsed,
 		&portfolio.MarginAvailable,
@@ -83,20 +128,21 @@ func (r *PortfolioRepository) GetPortfolioByID(ctx context.Context, portfolioID
 		&portfolio.UnrealizedPnL,
 		&portfolio.RealizedPnL,
 		&portfolio.DayPnL,
+		&portfolio.Version,
 		&portfolio.CreatedAt,
 		&portfolio.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("portfolio not found: %d", portfolioID)
+			return nil, fmt.Errorf("%w: %d", domain.ErrPortfolioNotFound, portfolioID)
 		}
 		r.logger.Error("Failed to get portfolio", zap.Error(err), zap.Int("portfolio_id", portfolioID))
 		return nil, fmt.Errorf("failed to get portfolio: %w", err)
 	}
 
 	// Load positions
-	positions, err := r.GetPositionsByPortfolioID(ctx, portfolioID)
+	positions, err := r.getPositions(ctx, q, portfolioID, lock)
 	if err != nil {
 		r.logger.Error("Failed to load positions for portfolio", zap.Error(err), zap.Int("portfolio_id", portfolioID))
 		return nil, fmt.Errorf("failed to load positions: %w", err)
@@ -109,8 +155,8 @@ func (r *PortfolioRepository) GetPortfolioByID(ctx context.Context, portfolioID
 // GetPortfoliosByUserID retrieves all portfolios for a user
 func (r *PortfolioRepository) GetPortfoliosByUserID(ctx context.Context, userID int) ([]models.Portfolio, error) {
 	query := `
-		SELECT id, user_id, name, cash, margin_used, margin_available, total_value,
-		       unrealized_pnl, realized_pnl, day_pnl, created_at, updated_at
+		SELECT id, user_id, name, description, strategy_tags, cash, margin_used, margin_available, total_value,
+		       unrealized_pnl, realized_pnl, day_pnl, version, created_at, updated_at
 		FROM portfolios
 		WHERE user_id = $1
 		ORDER BY created_at DESC`
@@ -129,6 +175,8 @@ func (r *PortfolioRepository) GetPortfoliosByUserID(ctx context.Context, userID
 			&portfolio.ID,
 			&portfolio.UserID,
 			&portfolio.Name,
+			&portfolio.Description,
+			pq.Array(&portfolio.StrategyTags),
 			&portfolio.Cash,
 			&portfolio.MarginUsed,
 			&portfolio.MarginAvailable,
@@ -136,6 +184,7 @@ func (r *PortfolioRepository) GetPortfoliosByUserID(ctx context.Context, userID
 			&portfolio.UnrealizedPnL,
 			&portfolio.RealizedPnL,
 			&portfolio.DayPnL,
+			&portfolio.Version,
 			&portfolio.CreatedAt,
 			&portfolio.UpdatedAt,
 		)
@@ -143,31 +192,115 @@ func (r *PortfolioRepository) GetPortfoliosByUserID(ctx context.Context, userID
 			r.logger.Error("Failed to scan portfolio", zap.Error(err))
 			continue
 		}
+		portfolios = append(portfolios, portfolio)
+	}
+	rows.Close()
+
+	// Load positions for all portfolios in one query
+	if err := r.loadPositions(ctx, portfolios); err != nil {
+		r.logger.Error("Failed to load positions", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	return portfolios, nil
+}
+
+// ListPortfoliosByUserID retrieves a page of a user's portfolios with their positions
+func (r *PortfolioRepository) ListPortfoliosByUserID(ctx context.Context, userID int, page pagination.Request) ([]models.Portfolio, pagination.Result, error) {
+	cond, args := page.After(2)
+	query := `
+		SELECT id, user_id, name, description, strategy_tags, cash, margin_used, margin_available, total_value,
+		       unrealized_pnl, realized_pnl, day_pnl, version, created_at, updated_at, ` + page.Key() + `
+		FROM portfolios
+		WHERE user_id = $1` + cond + page.OrderBy()
 
-		// Load positions for each portfolio
-		positions, err := r.GetPositionsByPortfolioID(ctx, portfolio.ID)
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{userID}, args...)...)
+	if err != nil {
+		r.logger.Error("Failed to get portfolios for user", zap.Error(err), zap.Int("user_id", userID))
+		return nil, pagination.Result{}, fmt.Errorf("failed to get portfolios: %w", err)
+	}
+	defer rows.Close()
+
+	var portfolios []models.Portfolio
+	var keys []string
+	for rows.Next() {
+		portfolio := models.Portfolio{}
+		var key string
+		err := rows.Scan(
+			&portfolio.ID,
+			&portfolio.UserID,
+			&portfolio.Name,
+			&portfolio.Description,
+			pq.Array(&portfolio.StrategyTags),
+			&portfolio.Cash,
+			&portfolio.MarginUsed,
+			&portfolio.MarginAvailable,
+			&portfolio.TotalValue,
+			&portfolio.UnrealizedPnL,
+			&portfolio.RealizedPnL,
+			&portfolio.DayPnL,
+			&portfolio.Version,
+			&portfolio.CreatedAt,
+			&portfolio.UpdatedAt,
+			&key,
+		)
 		if err != nil {
-			r.logger.Error("Failed to load positions", zap.Error(err), zap.Int("portfolio_id", portfolio.ID))
-			continue
+			return nil, pagination.Result{}, fmt.Errorf("failed to scan portfolio: %w", err)
 		}
-		portfolio.Positions = positions
-
 		portfolios = append(portfolios, portfolio)
+		keys = append(keys, key)
 	}
 
-	return portfolios, nil
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("error iterating portfolios: %w", err)
+	}
+	rows.Close()
+
+	var result pagination.Result
+	if page.More(len(portfolios)) {
+		portfolios = portfolios[:page.Limit]
+		result.NextCursor = page.Cursor(keys[page.Limit-1], portfolios[page.Limit-1].ID)
+	}
+	result.Total, err = r.count(ctx, `SELECT COUNT(*) FROM portfolios WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, pagination.Result{}, err
+	}
+
+	if err := r.loadPositions(ctx, portfolios); err != nil {
+		return nil, pagination.Result{}, err
+	}
+
+	return portfolios, result, nil
 }
 
-// UpdatePortfolio updates an existing portfolio
+// loadPositions sets the positions of portfolios, loaded in one query
+func (r *PortfolioRepository) loadPositions(ctx context.Context, portfolios []models.Portfolio) error {
+	ids := make([]int, len(portfolios))
+	for i := range portfolios {
+		ids[i] = portfolios[i].ID
+	}
+
+	positions, err := r.GetPositionsByPortfolioIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load positions: %w", err)
+	}
+	for i := range portfolios {
+		portfolios[i].Positions = positions[portfolios[i].ID]
+	}
+	return nil
+}
+
+// UpdatePortfolio updates an existing portfolio, advancing its version
 func (r *PortfolioRepository) UpdatePortfolio(ctx context.Context, portfolio *models.Portfolio) error {
 	query := `
 		UPDATE portfolios
 		SET cash = $2, margin_used = $3, margin_available = $4, total_value = $5,
-		    unrealized_pnl = $6, realized_pnl = $7, day_pnl = $8, updated_at = $9
-		WHERE id = $1`
+		    unrealized_pnl = $6, realized_pnl = $7, day_pnl = $8, updated_at = $9, version = version + 1
+		WHERE id = $1
+		RETURNING version`
 
 	now := time.Now()
-	result, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query,
 		portfolio.ID,
 		portfolio.Cash,
 		portfolio.MarginUsed,
@@ -177,38 +310,82 @@ func (r *PortfolioRepository) UpdatePortfolio(ctx context.Context, portfolio *mo
 		portfolio.RealizedPnL,
 		portfolio.DayPnL,
 		now,
-	)
+	).Scan(&portfolio.Version)
 
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %d", domain.ErrPortfolioNotFound, portfolio.ID)
+	}
 	if err != nil {
 		r.logger.Error("Failed to update portfolio", zap.Error(err), zap.Int("portfolio_id", portfolio.ID))
 		return fmt.Errorf("failed to update portfolio: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("portfolio not found: %d", portfolio.ID)
-	}
-
 	portfolio.UpdatedAt = now
 
 	r.logger.Info("Portfolio updated successfully", zap.Int("portfolio_id", portfolio.ID))
 	return nil
 }
 
-// DeletePortfolio deletes a portfolio and all its positions
-func (r *PortfolioRepository) DeletePortfolio(ctx context.Context, portfolioID int) error {
-	tx, err := r.db.BeginTx(ctx, nil)
+// UpdatePortfolioDetailsTx updates a portfolio's name, description and
+// strategy tags within a transaction, advancing its version
+func (r *PortfolioRepository) UpdatePortfolioDetailsTx(ctx context.Context, tx *sql.Tx, portfolio *models.Portfolio) error {
+	query := `
+		UPDATE portfolios
+		SET name = $2, description = $3, strategy_tags = $4, updated_at = $5, version = version + 1
+		WHERE id = $1
+		RETURNING version`
+
+	now := time.Now()
+	err := tx.QueryRowContext(ctx, query, portfolio.ID, portfolio.Name, portfolio.Description, strategyTags(portfolio), now).
+		Scan(&portfolio.Version)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %d", domain.ErrPortfolioNotFound, portfolio.ID)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		r.logger.Error("Failed to update portfolio details", zap.Error(err), zap.Int("portfolio_id", portfolio.ID))
+		return fmt.Errorf("failed to update portfolio details: %w", err)
 	}
-	defer tx.Rollback()
 
+	portfolio.UpdatedAt = now
+	return nil
+}
+
+// strategyTags binds a portfolio's strategy tags, storing none as an empty
+// array
+func strategyTags(portfolio *models.Portfolio) interface{} {
+	if portfolio.StrategyTags == nil {
+		return pq.Array([]string{})
+	}
+	return pq.Array(portfolio.StrategyTags)
+}
+
+// feeItems binds a trade's fee lines as JSON, storing none as NULL
+func feeItems(trade *models.Trade) interface{} {
+	if trade.FeeItems == nil {
+		return nil
+	}
+	data, _ := json.Marshal(trade.FeeItems) // Plain structs always marshal
+	return string(data)
+}
+
+// decodeFeeItems decodes a trade's fee lines, which are NULL for trades
+// recorded before fees were itemized
+func decodeFeeItems(data []byte) ([]models.FeeItem, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var items []models.FeeItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode fee items: %w", err)
+	}
+	return items, nil
+}
+
+// DeletePortfolioTx deletes a portfolio and all its positions within a
+// transaction
+func (r *PortfolioRepository) DeletePortfolioTx(ctx context.Context, tx *sql.Tx, portfolioID int) error {
 	// Delete positions first (foreign key constraint)
-	_, err = tx.ExecContext(ctx, "DELETE FROM positions WHERE user_id = (SELECT user_id FROM portfolios WHERE id = $1)", portfolioID)
+	_, err := tx.ExecContext(ctx, "DELETE FROM positions WHERE user_id = (SELECT user_id FROM portfolios WHERE id = $1)", portfolioID)
 	if err != nil {
 		r.logger.Error("Failed to delete positions", zap.Error(err), zap.Int("portfolio_id", portfolioID))
 		return fmt.Errorf("failed to delete positions: %w", err)
@@ -227,11 +404,7 @@ func (r *PortfolioRepository) DeletePortfolio(ctx context.Context, portfolioID i
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("portfolio not found: %d", portfolioID)
-	}
-
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return fmt.Errorf("%w: %d", domain.ErrPortfolioNotFound, portfolioID)
 	}
 
 	r.logger.Info("Portfolio deleted successfully", zap.Int("portfolio_id", portfolioID))
@@ -242,10 +415,12 @@ func (r *PortfolioRepository) DeletePortfolio(ctx context.Context, portfolioID i
 
 // CreatePosition creates a new position
 func (r *PortfolioRepository) CreatePosition(ctx context.Context, position *models.Position) error {
+	position.Symbol = symbols.Normalize(position.Symbol)
+
 	query := `
-		INSERT INTO positions (user_id, portfolio_id, symbol, quantity, side, entry_price, current_price,
+		INSERT INTO positions (user_id, portfolio_id, symbol, asset_type, quantity, multiplier, side, entry_price, current_price,
 		                      unrealized_pnl, realized_pnl, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'equity'), $5, COALESCE(NULLIF($6, 0), 1), $7, $8, $9, $10, $11, $12, $13)
 		RETURNING id`
 
 	now := time.Now()
@@ -253,7 +428,9 @@ func (r *PortfolioRepository) CreatePosition(ctx context.Context, position *mode
 		position.UserID,
 		position.PortfolioID,
 		position.Symbol,
+		position.AssetType,
 		position.Quantity,
+		position.Multiplier,
 		position.Side,
 		position.EntryPrice,
 		position.CurrentPrice,
@@ -275,7 +452,7 @@ func (r *PortfolioRepository) CreatePosition(ctx context.Context, position *mode
 	r.logger.Info("Position created successfully",
 		zap.Int("position_id", position.ID),
 		zap.String("symbol", position.Symbol),
-		zap.Int64("quantity", position.Quantity))
+		zap.Float64("quantity", position.Quantity))
 
 	return nil
 }
@@ -283,48 +460,36 @@ func (r *PortfolioRepository) CreatePosition(ctx context.Context, position *mode
 // GetPositionByID retrieves a position by ID
 func (r *PortfolioRepository) GetPositionByID(ctx context.Context, positionID int) (*models.Position, error) {
 	query := `
-		SELECT id, user_id, portfolio_id, symbol, quantity, side, entry_price, current_price,
-		       unrealized_pnl, realized_pnl, created_at, updated_at
+		SELECT ` + positionColumns + `
 		FROM positions
 		WHERE id = $1`
 
-	position := &models.Position{}
-	err := r.db.QueryRowContext(ctx, query, positionID).Scan(
-		&position.ID,
-		&position.UserID,
-		&position.PortfolioID,
-		&position.Symbol,
-		&position.Quantity,
-		&position.Side,
-		&position.EntryPrice,
-		&position.CurrentPrice,
-		&position.UnrealizedPnL,
-		&position.RealizedPnL,
-		&position.CreatedAt,
-		&position.UpdatedAt,
-	)
-
+	position, err := scanPosition(r.db.QueryRowContext(ctx, query, positionID))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("position not found: %d", positionID)
+			return nil, fmt.Errorf("%w: %d", domain.ErrPositionNotFound, positionID)
 		}
 		r.logger.Error("Failed to get position", zap.Error(err), zap.Int("position_id", positionID))
 		return nil, fmt.Errorf("failed to get position: %w", err)
 	}
 
-	return position, nil
+	return &position, nil
 }
 
 // GetPositionsByPortfolioID retrieves all positions for a portfolio
 func (r *PortfolioRepository) GetPositionsByPortfolioID(ctx context.Context, portfolioID int) ([]models.Position, error) {
+	return r.getPositions(ctx, r.db, portfolioID, "")
+}
+
+func (r *PortfolioRepository) getPositions(ctx context.Context, q queryer, portfolioID int, lock string) ([]models.Position, error) {
 	query := `
-		SELECT id, user_id, portfolio_id, symbol, quantity, side, entry_price, current_price,
-		       unrealized_pnl, realized_pnl, created_at, updated_at
+		SELECT ` + positionColumns + `
 		FROM positions
 		WHERE portfolio_id = $1
-		ORDER BY created_at DESC`
+		ORDER BY created_at DESC
+		` + lock
 
-	rows, err := r.db.QueryContext(ctx, query, portfolioID)
+	rows, err := q.QueryContext(ctx, query, portfolioID)
 	if err != nil {
 		r.logger.Error("Failed to get positions for portfolio", zap.Error(err), zap.Int("portfolio_id", portfolioID))
 		return nil, fmt.Errorf("failed to get positions: %w", err)
@@ -333,21 +498,7 @@ func (r *PortfolioRepository) GetPositionsByPortfolioID(ctx context.Context, por
 
 	var positions []models.Position
 	for rows.Next() {
-		position := models.Position{}
-		err := rows.Scan(
-			&position.ID,
-			&position.UserID,
-			&position.PortfolioID,
-			&position.Symbol,
-			&position.Quantity,
-			&position.Side,
-			&position.EntryPrice,
-			&position.CurrentPrice,
-			&position.UnrealizedPnL,
-			&position.RealizedPnL,
-			&position.CreatedAt,
-			&position.UpdatedAt,
-		)
+		position, err := scanPosition(rows)
 		if err != nil {
 			r.logger.Error("Failed to scan position", zap.Error(err))
 			continue
@@ -360,28 +511,23 @@ func (r *PortfolioRepository) GetPositionsByPortfolioID(ctx context.Context, por
 
 // GetPositionByUserAndSymbol retrieves a specific position by user and symbol
 func (r *PortfolioRepository) GetPositionByUserAndSymbol(ctx context.Context, userID int, portfolioID int, symbol string) (*models.Position, error) {
+	return r.getPositionByUserAndSymbol(ctx, r.db, userID, portfolioID, symbol)
+}
+
+// GetPositionByUserAndSymbolTx retrieves a specific position by user and symbol within a transaction
+func (r *PortfolioRepository) GetPositionByUserAndSymbolTx(ctx context.Context, tx *sql.Tx, userID int, portfolioID int, symbol string) (*models.Position, error) {
+	return r.getPositionByUserAndSymbol(ctx, tx, userID, portfolioID, symbol)
+}
+
+func (r *PortfolioRepository) getPositionByUserAndSymbol(ctx context.Context, q queryer, userID int, portfolioID int, symbol string) (*models.Position, error) {
+	symbol = symbols.Normalize(symbol)
+
 	query := `
-		SELECT id, user_id, portfolio_id, symbol, quantity, side, entry_price, current_price,
-		       unrealized_pnl, realized_pnl, created_at, updated_at
+		SELECT ` + positionColumns + `
 		FROM positions
 		WHERE user_id = $1 AND portfolio_id = $2 AND symbol = $3`
 
-	position := &models.Position{}
-	err := r.db.QueryRowContext(ctx, query, userID, portfolioID, symbol).Scan(
-		&position.ID,
-		&position.UserID,
-		&position.PortfolioID,
-		&position.Symbol,
-		&position.Quantity,
-		&position.Side,
-		&position.EntryPrice,
-		&position.CurrentPrice,
-		&position.UnrealizedPnL,
-		&position.RealizedPnL,
-		&position.CreatedAt,
-		&position.UpdatedAt,
-	)
-
+	position, err := scanPosition(q.QueryRowContext(ctx, query, userID, portfolioID, symbol))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Position doesn't exist, which is valid
@@ -391,7 +537,7 @@ func (r *PortfolioRepository) GetPositionByUserAndSymbol(ctx context.Context, us
 		return nil, fmt.Errorf("failed to get position: %w", err)
 	}
 
-	return position, nil
+	return &position, nil
 }
 
 // UpdatePosition updates an existing position
@@ -426,7 +572,7 @@ func (r *PortfolioRepository) UpdatePosition(ctx context.Context, position *mode
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("position not found: %d", position.ID)
+		return fmt.Errorf("%w: %d", domain.ErrPositionNotFound, position.ID)
 	}
 
 	position.UpdatedAt = now
@@ -450,7 +596,7 @@ func (r *PortfolioRepository) DeletePosition(ctx context.Context, positionID int
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("position not found: %d", positionID)
+		return fmt.Errorf("%w: %d", domain.ErrPositionNotFound, positionID)
 	}
 
 	r.logger.Info("Position deleted successfully", zap.Int("position_id", positionID))
@@ -461,10 +607,12 @@ func (r *PortfolioRepository) DeletePosition(ctx context.Context, positionID int
 
 // CreateTrade creates a new trade record
 func (r *PortfolioRepository) CreateTrade(ctx context.Context, trade *models.Trade) error {
+	trade.Symbol = symbols.Normalize(trade.Symbol)
+
 	query := `
-		INSERT INTO trades (user_id, portfolio_id, position_id, symbol, quantity, price, side, type, status,
-		                   fees, executed_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO trades (user_id, portfolio_id, position_id, symbol, asset_type, quantity, multiplier, price, side, type, status,
+		                   time_in_force, fees, fee_items, trigger_reason, broker_order_id, executed_at, created_at)
+		VALUES ($1, $2, NULLIF($3, 0), $4, COALESCE(NULLIF($5, ''), 'equity'), $6, COALESCE(NULLIF($7, 0), 1), $8, $9, $10, $11, COALESCE(NULLIF($12, ''), 'day'), $13, $14, NULLIF($15, ''), NULLIF($16, ''), $17, $18)
 		RETURNING id`
 
 	now := time.Now()
@@ -473,12 +621,18 @@ func (r *PortfolioRepository) CreateTrade(ctx context.Context, trade *models.Tra
 		trade.PortfolioID,
 		trade.PositionID,
 		trade.Symbol,
+		trade.AssetType,
 		trade.Quantity,
+		trade.Multiplier,
 		trade.Price,
 		trade.Side,
 		trade.Type,
 		trade.Status,
+		trade.TimeInForce,
 		trade.Fees,
+		feeItems(trade),
+		trade.TriggerReason,
+		trade.BrokerOrderID,
 		trade.ExecutedAt,
 		now,
 	).Scan(&trade.ID)
@@ -495,25 +649,25 @@ func (r *PortfolioRepository) CreateTrade(ctx context.Context, trade *models.Tra
 		zap.Int("trade_id", trade.ID),
 		zap.String("symbol", trade.Symbol),
 		zap.String("side", trade.Side),
-		zap.Int64("quantity", trade.Quantity),
+		zap.Float64("quantity", trade.Quantity),
 		zap.Float64("price", trade.Price))
 
 	return nil
 }
 
-// GetTradesByUserID retrieves all trades for a user
-func (r *PortfolioRepository) GetTradesByUserID(ctx context.Context, userID int, limit int, offset int) ([]models.Trade, error) {
+// GetTradesByPortfolioID retrieves a portfolio's trades, newest first
+func (r *PortfolioRepository) GetTradesByPortfolioID(ctx context.Context, portfolioID int, limit int, offset int) ([]models.Trade, error) {
 	query := `
-		SELECT id, user_id, position_id, symbol, quantity, price, side, type, status,
-		       fees, executed_at, created_at
+		SELECT id, user_id, portfolio_id, position_id, symbol, asset_type, quantity, multiplier, price, side, type, status, time_in_force,
+		       fees, fee_items, COALESCE(trigger_reason, ''), executed_at, created_at
 		FROM trades
-		WHERE user_id = $1
+		WHERE portfolio_id = $1
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3`
 
-	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	rows, err := r.reader().QueryContext(ctx, query, portfolioID, limit, offset)
 	if err != nil {
-		r.logger.Error("Failed to get trades for user", zap.Error(err), zap.Int("user_id", userID))
+		r.logger.Error("Failed to get trades for portfolio", zap.Error(err), zap.Int("portfolio_id", portfolioID))
 		return nil, fmt.Errorf("failed to get trades: %w", err)
 	}
 	defer rows.Close()
@@ -521,20 +675,30 @@ func (r *PortfolioRepository) GetTradesByUserID(ctx context.Context, userID int,
 	var trades []models.Trade
 	for rows.Next() {
 		trade := models.Trade{}
+		var items []byte
 		err := rows.Scan(
 			&trade.ID,
 			&trade.UserID,
+			&trade.PortfolioID,
 			&trade.PositionID,
 			&trade.Symbol,
+			&trade.AssetType,
 			&trade.Quantity,
+			&trade.Multiplier,
 			&trade.Price,
 			&trade.Side,
 			&trade.Type,
 			&trade.Status,
+			&trade.TimeInForce,
 			&trade.Fees,
+			&items,
+			&trade.TriggerReason,
 			&trade.ExecutedAt,
 			&trade.CreatedAt,
 		)
+		if err == nil {
+			trade.FeeItems, err = decodeFeeItems(items)
+		}
 		if err != nil {
 			r.logger.Error("Failed to scan trade", zap.Error(err))
 			continue
@@ -545,17 +709,89 @@ func (r *PortfolioRepository) GetTradesByUserID(ctx context.Context, userID int,
 	return trades, nil
 }
 
+// ListTradesByPortfolioID retrieves a page of a portfolio's trades
+func (r *PortfolioRepository) ListTradesByPortfolioID(ctx context.Context, portfolioID int, page pagination.Request) ([]models.Trade, pagination.Result, error) {
+	cond, args := page.After(2)
+	query := `
+		SELECT id, user_id, portfolio_id, position_id, symbol, asset_type, quantity, multiplier, price, side, type, status, time_in_force,
+		       fees, fee_items, COALESCE(trigger_reason, ''), executed_at, created_at, ` + page.Key() + `
+		FROM trades
+		WHERE portfolio_id = $1` + cond + page.OrderBy()
+
+	rows, err := r.reader().QueryContext(ctx, query, append([]interface{}{portfolioID}, args...)...)
+	if err != nil {
+		r.logger.Error("Failed to get trades for portfolio", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, pagination.Result{}, fmt.Errorf("failed to get trades: %w", err)
+	}
+	defer rows.Close()
+
+	var trades []models.Trade
+	var keys []string
+	for rows.Next() {
+		trade := models.Trade{}
+		var items []byte
+		var key string
+		err := rows.Scan(
+			&trade.ID,
+			&trade.UserID,
+			&trade.PortfolioID,
+			&trade.PositionID,
+			&trade.Symbol,
+			&trade.AssetType,
+			&trade.Quantity,
+			&trade.Multiplier,
+			&trade.Price,
+			&trade.Side,
+			&trade.Type,
+			&trade.Status,
+			&trade.TimeInForce,
+			&trade.Fees,
+			&items,
+			&trade.TriggerReason,
+			&trade.ExecutedAt,
+			&trade.CreatedAt,
+			&key,
+		)
+		if err != nil {
+			return nil, pagination.Result{}, fmt.Errorf("failed to scan trade: %w", err)
+		}
+		if trade.FeeItems, err = decodeFeeItems(items); err != nil {
+			return nil, pagination.Result{}, err
+		}
+		trades = append(trades, trade)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("error iterating trades: %w", err)
+	}
+
+	var result pagination.Result
+	if page.More(len(trades)) {
+		trades = trades[:page.Limit]
+		result.NextCursor = page.Cursor(keys[page.Limit-1], trades[page.Limit-1].ID)
+	}
+	result.Total, err = r.count(ctx, `SELECT COUNT(*) FROM trades WHERE portfolio_id = $1`, portfolioID)
+	if err != nil {
+		return nil, pagination.Result{}, err
+	}
+
+	return trades, result, nil
+}
+
 // GetTradesBySymbol retrieves all trades for a specific symbol
 func (r *PortfolioRepository) GetTradesBySymbol(ctx context.Context, userID int, symbol string, limit int, offset int) ([]models.Trade, error) {
+	symbol = symbols.Normalize(symbol)
+
 	query := `
-		SELECT id, user_id, position_id, symbol, quantity, price, side, type, status,
-		       fees, executed_at, created_at
+		SELECT id, user_id, position_id, symbol, asset_type, quantity, multiplier, price, side, type, status, time_in_force,
+		       fees, fee_items, COALESCE(trigger_reason, ''), executed_at, created_at
 		FROM trades
 		WHERE user_id = $1 AND symbol = $2
 		ORDER BY created_at DESC
 		LIMIT $3 OFFSET $4`
 
-	rows, err := r.db.QueryContext(ctx, query, userID, symbol, limit, offset)
+	rows, err := r.reader().QueryContext(ctx, query, userID, symbol, limit, offset)
 	if err != nil {
 		r.logger.Error("Failed to get trades for symbol", zap.Error(err),
 			zap.Int("user_id", userID), zap.String("symbol", symbol))
@@ -566,20 +802,29 @@ func (r *PortfolioRepository) GetTradesBySymbol(ctx context.Context, userID int,
 	var trades []models.Trade
 	for rows.Next() {
 		trade := models.Trade{}
+		var items []byte
 		err := rows.Scan(
 			&trade.ID,
 			&trade.UserID,
 			&trade.PositionID,
 			&trade.Symbol,
+			&trade.AssetType,
 			&trade.Quantity,
+			&trade.Multiplier,
 			&trade.Price,
 			&trade.Side,
 			&trade.Type,
 			&trade.Status,
+			&trade.TimeInForce,
 			&trade.Fees,
+			&items,
+			&trade.TriggerReason,
 			&trade.ExecutedAt,
 			&trade.CreatedAt,
 		)
+		if err == nil {
+			trade.FeeItems, err = decodeFeeItems(items)
+		}
 		if err != nil {
 			r.logger.Error("Failed to scan trade", zap.Error(err))
 			continue
@@ -604,10 +849,12 @@ func (r *PortfolioRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
 
 // CreatePositionTx creates a new position within a transaction
 func (r *PortfolioRepository) CreatePositionTx(ctx context.Context, tx *sql.Tx, position *models.Position) error {
+	position.Symbol = symbols.Normalize(position.Symbol)
+
 	query := `
-		INSERT INTO positions (user_id, portfolio_id, symbol, quantity, side, entry_price, current_price,
+		INSERT INTO positions (user_id, portfolio_id, symbol, asset_type, quantity, multiplier, side, entry_price, current_price,
 		                      unrealized_pnl, realized_pnl, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'equity'), $5, COALESCE(NULLIF($6, 0), 1), $7, $8, $9, $10, $11, $12, $13)
 		RETURNING id`
 
 	now := time.Now()
@@ -615,7 +862,9 @@ func (r *PortfolioRepository) CreatePositionTx(ctx context.Context, tx *sql.Tx,
 		position.UserID,
 		position.PortfolioID,
 		position.Symbol,
+		position.AssetType,
 		position.Quantity,
+		position.Multiplier,
 		position.Side,
 		position.EntryPrice,
 		position.CurrentPrice,
@@ -637,7 +886,7 @@ func (r *PortfolioRepository) CreatePositionTx(ctx context.Context, tx *sql.Tx,
 	r.logger.Info("Position created successfully in transaction",
 		zap.Int("position_id", position.ID),
 		zap.String("symbol", position.Symbol),
-		zap.Int64("quantity", position.Quantity))
+		zap.Float64("quantity", position.Quantity))
 
 	return nil
 }
@@ -674,7 +923,7 @@ func (r *PortfolioRepository) UpdatePositionTx(ctx context.Context, tx *sql.Tx,
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("position not found: %d", position.ID)
+		return fmt.Errorf("%w: %d", domain.ErrPositionNotFound, position.ID)
 	}
 
 	position.UpdatedAt = now
@@ -698,7 +947,7 @@ func (r *PortfolioRepository) DeletePositionTx(ctx context.Context, tx *sql.Tx,
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("position not found: %d", positionID)
+		return fmt.Errorf("%w: %d", domain.ErrPositionNotFound, positionID)
 	}
 
 	r.logger.Info("Position deleted successfully in transaction", zap.Int("position_id", positionID))
@@ -707,10 +956,12 @@ func (r *PortfolioRepository) DeletePositionTx(ctx context.Context, tx *sql.Tx,
 
 // CreateTradeTx creates a new trade record within a transaction
 func (r *PortfolioRepository) CreateTradeTx(ctx context.Context, tx *sql.Tx, trade *models.Trade) error {
+	trade.Symbol = symbols.Normalize(trade.Symbol)
+
 	query := `
-		INSERT INTO trades (user_id, portfolio_id, position_id, symbol, quantity, price, side, type, status,
-		                   fees, executed_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO trades (user_id, portfolio_id, position_id, symbol, asset_type, quantity, multiplier, price, side, type, status,
+		                   time_in_force, fees, fee_items, trigger_reason, executed_at, created_at)
+		VALUES ($1, $2, NULLIF($3, 0), $4, COALESCE(NULLIF($5, ''), 'equity'), $6, COALESCE(NULLIF($7, 0), 1), $8, $9, $10, $11, COALESCE(NULLIF($12, ''), 'day'), $13, $14, NULLIF($15, ''), $16, $17)
 		RETURNING id`
 
 	now := time.Now()
@@ -719,12 +970,17 @@ func (r *PortfolioRepository) CreateTradeTx(ctx context.Context, tx *sql.Tx, tra
 		trade.PortfolioID,
 		trade.PositionID,
 		trade.Symbol,
+		trade.AssetType,
 		trade.Quantity,
+		trade.Multiplier,
 		trade.Price,
 		trade.Side,
 		trade.Type,
 		trade.Status,
+		trade.TimeInForce,
 		trade.Fees,
+		feeItems(trade),
+		trade.TriggerReason,
 		trade.ExecutedAt,
 		now,
 	).Scan(&trade.ID)
@@ -741,22 +997,24 @@ func (r *PortfolioRepository) CreateTradeTx(ctx context.Context, tx *sql.Tx, tra
 		zap.Int("trade_id", trade.ID),
 		zap.String("symbol", trade.Symbol),
 		zap.String("side", trade.Side),
-		zap.Int64("quantity", trade.Quantity),
+		zap.Float64("quantity", trade.Quantity),
 		zap.Float64("price", trade.Price))
 
 	return nil
 }
 
-// UpdatePortfolioTx updates an existing portfolio within a transaction
+// UpdatePortfolioTx updates an existing portfolio within a transaction,
+// advancing its version
 func (r *PortfolioRepository) UpdatePortfolioTx(ctx context.Context, tx *sql.Tx, portfolio *models.Portfolio) error {
 	query := `
 		UPDATE portfolios
 		SET cash = $2, margin_used = $3, margin_available = $4, total_value = $5,
-		    unrealized_pnl = $6, realized_pnl = $7, day_pnl = $8, updated_at = $9
-		WHERE id = $1`
+		    unrealized_pnl = $6, realized_pnl = $7, day_pnl = $8, updated_at = $9, version = version + 1
+		WHERE id = $1
+		RETURNING version`
 
 	now := time.Now()
-	result, err := tx.ExecContext(ctx, query,
+	err := tx.QueryRowContext(ctx, query,
 		portfolio.ID,
 		portfolio.Cash,
 		portfolio.MarginUsed,
@@ -766,22 +1024,16 @@ func (r *PortfolioRepository) UpdatePortfolioTx(ctx context.Context, tx *sql.Tx,
 		portfolio.RealizedPnL,
 		portfolio.DayPnL,
 		now,
-	)
+	).Scan(&portfolio.Version)
 
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %d", domain.ErrPortfolioNotFound, portfolio.ID)
+	}
 	if err != nil {
 		r.logger.Error("Failed to update portfolio in transaction", zap.Error(err), zap.Int("portfolio_id", portfolio.ID))
 		return fmt.Errorf("failed to update portfolio: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("portfolio not found: %d", portfolio.ID)
-	}
-
 	portfolio.UpdatedAt = now
 
 	r.logger.Info("Portfolio updated successfully in transaction", zap.Int("portfolio_id", portfolio.ID))
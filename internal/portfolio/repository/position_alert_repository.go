@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Position Alert Operations
+
+const positionAlertSelect = `
+		SELECT a.id, a.portfolio_id, a.position_id, p.user_id, p.symbol, a.up_percent, a.down_percent, a.note,
+		       a.is_active, a.triggered_at, a.triggered_price, p.entry_price, p.quantity, a.created_at, a.updated_at
+		FROM position_alerts a
+		JOIN positions p ON p.id = a.position_id`
+
+// CreatePositionAlert saves a new alert on a position
+func (r *PortfolioRepository) CreatePositionAlert(ctx context.Context, alert *models.PositionAlert) error {
+	query := `
+		INSERT INTO position_alerts (portfolio_id, position_id, up_percent, down_percent, note, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query, alert.PortfolioID, alert.PositionID, alert.UpPercent, alert.DownPercent,
+		alert.Note, alert.IsActive, now, now).Scan(&alert.ID)
+	if err != nil {
+		r.logger.Error("Failed to create position alert", zap.Error(err), zap.Int("position_id", alert.PositionID))
+		return fmt.Errorf("failed to create position alert: %w", err)
+	}
+
+	alert.CreatedAt = now
+	alert.UpdatedAt = now
+	return nil
+}
+
+// GetPositionAlert retrieves a position alert by ID
+func (r *PortfolioRepository) GetPositionAlert(ctx context.Context, alertID int) (*models.PositionAlert, error) {
+	alert, err := scanPositionAlert(r.db.QueryRowContext(ctx, positionAlertSelect+` WHERE a.id = $1`, alertID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundf("position alert not found: %d", alertID)
+		}
+		r.logger.Error("Failed to get position alert", zap.Error(err), zap.Int("alert_id", alertID))
+		return nil, fmt.Errorf("failed to get position alert: %w", err)
+	}
+
+	return alert, nil
+}
+
+// GetPositionAlertsByPortfolioID retrieves a portfolio's position alerts,
+// only those on symbol unless it is empty
+func (r *PortfolioRepository) GetPositionAlertsByPortfolioID(ctx context.Context, portfolioID int, symbol string) ([]models.PositionAlert, error) {
+	if symbol == "" {
+		return r.queryPositionAlerts(ctx, positionAlertSelect+` WHERE a.portfolio_id = $1 ORDER BY p.symbol, a.id`, portfolioID)
+	}
+	query := positionAlertSelect + ` WHERE a.portfolio_id = $1 AND p.symbol = $2 ORDER BY a.id`
+	return r.queryPositionAlerts(ctx, query, portfolioID, symbols.Normalize(symbol))
+}
+
+// GetActivePositionAlerts retrieves the alerts still to fire on positions
+// in a symbol
+func (r *PortfolioRepository) GetActivePositionAlerts(ctx context.Context, symbol string) ([]models.PositionAlert, error) {
+	query := positionAlertSelect + ` WHERE p.symbol = $1 AND a.is_active = true ORDER BY a.id`
+	return r.queryPositionAlerts(ctx, query, symbols.Normalize(symbol))
+}
+
+// UpdatePositionAlert replaces a position alert's levels, note, active state
+// and trigger
+func (r *PortfolioRepository) UpdatePositionAlert(ctx context.Context, alert *models.PositionAlert) error {
+	query := `
+		UPDATE position_alerts
+		SET up_percent = $2, down_percent = $3, note = $4, is_active = $5, triggered_at = $6, triggered_price = $7, updated_at = $8
+		WHERE id = $1`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, alert.ID, alert.UpPercent, alert.DownPercent, alert.Note,
+		alert.IsActive, alert.TriggeredAt, alert.TriggeredPrice, now)
+	if err != nil {
+		r.logger.Error("Failed to update position alert", zap.Error(err), zap.Int("alert_id", alert.ID))
+		return fmt.Errorf("failed to update position alert: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.NotFoundf("position alert not found: %d", alert.ID)
+	}
+
+	alert.UpdatedAt = now
+	return nil
+}
+
+// ClaimPositionAlert deactivates an alert, recording the price that fired
+// it, and reports whether it was still active, so an alert reached by
+// concurrent price updates is sent only once
+func (r *PortfolioRepository) ClaimPositionAlert(ctx context.Context, alertID int, price float64, triggeredAt time.Time) (bool, error) {
+	query := `
+		UPDATE position_alerts
+		SET is_active = false, triggered_at = $2, triggered_price = $3, updated_at = $2
+		WHERE id = $1 AND is_active = true`
+
+	result, err := r.db.ExecContext(ctx, query, alertID, triggeredAt, price)
+	if err != nil {
+		r.logger.Error("Failed to claim position alert", zap.Error(err), zap.Int("alert_id", alertID))
+		return false, fmt.Errorf("failed to claim position alert: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
+
+// DeletePositionAlert deletes a position alert
+func (r *PortfolioRepository) DeletePositionAlert(ctx context.Context, alertID int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM position_alerts WHERE id = $1`, alertID)
+	if err != nil {
+		r.logger.Error("Failed to delete position alert", zap.Error(err), zap.Int("alert_id", alertID))
+		return fmt.Errorf("failed to delete position alert: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.NotFoundf("position alert not found: %d", alertID)
+	}
+
+	return nil
+}
+
+func (r *PortfolioRepository) queryPositionAlerts(ctx context.Context, query string, args ...interface{}) ([]models.PositionAlert, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to get position alerts", zap.Error(err))
+		return nil, fmt.Errorf("failed to get position alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []models.PositionAlert
+	for rows.Next() {
+		alert, err := scanPositionAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan position alert: %w", err)
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating position alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+func scanPositionAlert(row rowScanner) (*models.PositionAlert, error) {
+	alert := &models.PositionAlert{}
+	err := row.Scan(&alert.ID, &alert.PortfolioID, &alert.PositionID, &alert.UserID, &alert.Symbol,
+		&alert.UpPercent, &alert.DownPercent, &alert.Note, &alert.IsActive, &alert.TriggeredAt,
+		&alert.TriggeredPrice, &alert.EntryPrice, &alert.Quantity, &alert.CreatedAt, &alert.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Provisioning Operations
+
+// CreateUserTx creates a user within a transaction
+func (r *PortfolioRepository) CreateUserTx(ctx context.Context, tx *sql.Tx, user *models.User) error {
+	query := `
+		INSERT INTO users (username, email, password_hash, full_name, role, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, true, $6, $7)
+		RETURNING id`
+
+	now := time.Now()
+	err := tx.QueryRowContext(ctx, query, user.Username, user.Email, user.PasswordHash, user.FullName, user.Role,
+		now, now).Scan(&user.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return domain.Conflictf("user %q or email %q already exists", user.Username, user.Email)
+		}
+		r.logger.Error("Failed to create user", zap.Error(err), zap.String("username", user.Username))
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	user.IsActive = true
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	return nil
+}
+
+// CreatePortfolioTx creates a portfolio within a transaction
+func (r *PortfolioRepository) CreatePortfolioTx(ctx context.Context, tx *sql.Tx, portfolio *models.Portfolio) error {
+	query := `
+		INSERT INTO portfolios (user_id, name, description, strategy_tags, cash, margin_used, margin_available,
+		                       total_value, unrealized_pnl, realized_pnl, day_pnl, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, version`
+
+	now := time.Now()
+	err := tx.QueryRowContext(ctx, query,
+		portfolio.UserID,
+		portfolio.Name,
+		portfolio.Description,
+		strategyTags(portfolio),
+		portfolio.Cash,
+		portfolio.MarginUsed,
+		portfolio.MarginAvailable,
+		portfolio.TotalValue,
+		portfolio.UnrealizedPnL,
+		portfolio.RealizedPnL,
+		portfolio.DayPnL,
+		now,
+		now,
+	).Scan(&portfolio.ID, &portfolio.Version)
+	if err != nil {
+		r.logger.Error("Failed to create portfolio in transaction", zap.Error(err), zap.Int("user_id", portfolio.UserID))
+		return fmt.Errorf("failed to create portfolio: %w", err)
+	}
+
+	portfolio.CreatedAt = now
+	portfolio.UpdatedAt = now
+	return nil
+}
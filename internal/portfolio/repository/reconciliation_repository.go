@@ -0,0 +1,381 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Broker Account Operations
+
+// UpsertBrokerAccount links a portfolio to a broker account, replacing any existing link
+func (r *PortfolioRepository) UpsertBrokerAccount(ctx context.Context, account *models.BrokerAccount) error {
+	query := `
+		INSERT INTO broker_accounts (portfolio_id, broker, account_id, trading_mode)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (portfolio_id) DO UPDATE
+		SET broker = EXCLUDED.broker, account_id = EXCLUDED.account_id, trading_mode = EXCLUDED.trading_mode
+		RETURNING created_at`
+
+	err := r.db.QueryRowContext(ctx, query, account.PortfolioID, account.Broker, account.AccountID, account.TradingMode).Scan(&account.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to save broker account", zap.Error(err), zap.Int("portfolio_id", account.PortfolioID))
+		return fmt.Errorf("failed to save broker account: %w", err)
+	}
+
+	return nil
+}
+
+// GetBrokerAccount retrieves the broker account linked to a portfolio
+func (r *PortfolioRepository) GetBrokerAccount(ctx context.Context, portfolioID int) (*models.BrokerAccount, error) {
+	query := `
+		SELECT portfolio_id, broker, account_id, trading_mode, created_at
+		FROM broker_accounts
+		WHERE portfolio_id = $1`
+
+	account := &models.BrokerAccount{}
+	err := r.db.QueryRowContext(ctx, query, portfolioID).Scan(
+		&account.PortfolioID,
+		&account.Broker,
+		&account.AccountID,
+		&account.TradingMode,
+		&account.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundf("broker account not found for portfolio: %d", portfolioID)
+		}
+		r.logger.Error("Failed to get broker account", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, fmt.Errorf("failed to get broker account: %w", err)
+	}
+
+	return account, nil
+}
+
+// ListBrokerAccounts retrieves every broker-linked active portfolio
+func (r *PortfolioRepository) ListBrokerAccounts(ctx context.Context) ([]models.BrokerAccount, error) {
+	query := `
+		SELECT b.portfolio_id, b.broker, b.account_id, b.trading_mode, b.created_at
+		FROM broker_accounts b
+		JOIN portfolios p ON p.id = b.portfolio_id
+		WHERE p.is_active = true
+		ORDER BY b.portfolio_id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to list broker accounts", zap.Error(err))
+		return nil, fmt.Errorf("failed to list broker accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []models.BrokerAccount
+	for rows.Next() {
+		account := models.BrokerAccount{}
+		if err := rows.Scan(&account.PortfolioID, &account.Broker, &account.AccountID, &account.TradingMode, &account.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan broker account: %w", err)
+		}
+		accounts = append(accounts, account)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating broker accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// Reconciliation Operations
+
+// GetFilledTradesByPortfolioID retrieves trades filled in [start, end)
+func (r *PortfolioRepository) GetFilledTradesByPortfolioID(ctx context.Context, portfolioID int, start, end time.Time) ([]models.Trade, error) {
+	query := `
+		SELECT id, user_id, portfolio_id, COALESCE(position_id, 0), symbol, asset_type, quantity, multiplier, price, side, type, status,
+		       fees, executed_at, created_at
+		FROM trades
+		WHERE portfolio_id = $1 AND status = 'filled' AND executed_at >= $2 AND executed_at < $3
+		ORDER BY executed_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, portfolioID, start, end)
+	if err != nil {
+		r.logger.Error("Failed to get filled trades", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, fmt.Errorf("failed to get trades: %w", err)
+	}
+	defer rows.Close()
+
+	var trades []models.Trade
+	for rows.Next() {
+		trade := models.Trade{}
+		err := rows.Scan(
+			&trade.ID,
+			&trade.UserID,
+			&trade.PortfolioID,
+			&trade.PositionID,
+			&trade.Symbol,
+			&trade.AssetType,
+			&trade.Quantity,
+			&trade.Multiplier,
+			&trade.Price,
+			&trade.Side,
+			&trade.Type,
+			&trade.Status,
+			&trade.Fees,
+			&trade.ExecutedAt,
+			&trade.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trade: %w", err)
+		}
+		trades = append(trades, trade)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trades: %w", err)
+	}
+
+	return trades, nil
+}
+
+// UpdateTradeExecutionTx corrects the fill price and fees of a trade within a transaction
+func (r *PortfolioRepository) UpdateTradeExecutionTx(ctx context.Context, tx *sql.Tx, tradeID int, price, fees float64) error {
+	query := `UPDATE trades SET price = $2, fees = $3 WHERE id = $1`
+
+	result, err := tx.ExecContext(ctx, query, tradeID, price, fees)
+	if err != nil {
+		r.logger.Error("Failed to update trade execution", zap.Error(err), zap.Int("trade_id", tradeID))
+		return fmt.Errorf("failed to update trade: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.NotFoundf("trade not found: %d", tradeID)
+	}
+
+	return nil
+}
+
+// StartReconciliationRun creates the run for a portfolio and statement date,
+// or resets a finished one for a re-run. It fails if the run is in progress.
+func (r *PortfolioRepository) StartReconciliationRun(ctx context.Context, portfolioID int, statementDate time.Time) (*models.ReconciliationRun, error) {
+	query := `
+		INSERT INTO reconciliation_runs (portfolio_id, statement_date, status, started_at)
+		VALUES ($1, $2, 'running', NOW())
+		ON CONFLICT (portfolio_id, statement_date) DO UPDATE SET
+			status = 'running', positions_checked = 0, trades_checked = 0, auto_fixed = 0, open_breaks = 0,
+			error = NULL, started_at = NOW(), completed_at = NULL
+		WHERE reconciliation_runs.status <> 'running'
+		RETURNING id, started_at`
+
+	run := &models.ReconciliationRun{
+		PortfolioID:   portfolioID,
+		StatementDate: statementDate,
+		Status:        models.ReconciliationRunning,
+	}
+	err := r.db.QueryRowContext(ctx, query, portfolioID, statementDate).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.Conflictf("reconciliation already running for portfolio %d on %s", portfolioID, statementDate.Format("2006-01-02"))
+		}
+		r.logger.Error("Failed to start reconciliation run", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, fmt.Errorf("failed to start reconciliation run: %w", err)
+	}
+
+	return run, nil
+}
+
+// CompleteReconciliationRunTx replaces a run's breaks and records its totals within a transaction
+func (r *PortfolioRepository) CompleteReconciliationRunTx(ctx context.Context, tx *sql.Tx, run *models.ReconciliationRun) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM reconciliation_breaks WHERE run_id = $1`, run.ID); err != nil {
+		return fmt.Errorf("failed to clear reconciliation breaks: %w", err)
+	}
+
+	insertQuery := `
+		INSERT INTO reconciliation_breaks (run_id, portfolio_id, break_type, symbol, trade_id, broker_ref,
+		                                   internal_value, broker_value, resolution, message)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), NULLIF($6, ''), $7, $8, $9, $10)
+		RETURNING id, created_at`
+
+	for i := range run.Breaks {
+		b := &run.Breaks[i]
+		b.Symbol = symbols.Normalize(b.Symbol)
+		b.RunID = run.ID
+		b.PortfolioID = run.PortfolioID
+		err := tx.QueryRowContext(ctx, insertQuery,
+			b.RunID, b.PortfolioID, b.BreakType, b.Symbol, b.TradeID, b.BrokerRef,
+			b.InternalValue, b.BrokerValue, b.Resolution, b.Message,
+		).Scan(&b.ID, &b.CreatedAt)
+		if err != nil {
+			r.logger.Error("Failed to create reconciliation break", zap.Error(err), zap.Int("run_id", run.ID))
+			return fmt.Errorf("failed to create reconciliation break: %w", err)
+		}
+	}
+
+	updateQuery := `
+		UPDATE reconciliation_runs
+		SET status = $2, positions_checked = $3, trades_checked = $4, auto_fixed = $5, open_breaks = $6,
+		    completed_at = $7
+		WHERE id = $1`
+
+	now := time.Now()
+	_, err := tx.ExecContext(ctx, updateQuery,
+		run.ID, models.ReconciliationCompleted, run.PositionsChecked, run.TradesChecked, run.AutoFixed, run.OpenBreaks, now)
+	if err != nil {
+		r.logger.Error("Failed to complete reconciliation run", zap.Error(err), zap.Int("run_id", run.ID))
+		return fmt.Errorf("failed to complete reconciliation run: %w", err)
+	}
+
+	run.Status = models.ReconciliationCompleted
+	run.CompletedAt = &now
+	return nil
+}
+
+// FailReconciliationRun marks a run as failed
+func (r *PortfolioRepository) FailReconciliationRun(ctx context.Context, runID int, reason string) error {
+	query := `UPDATE reconciliation_runs SET status = 'failed', error = $2, completed_at = NOW() WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, runID, reason); err != nil {
+		r.logger.Error("Failed to mark reconciliation run failed", zap.Error(err), zap.Int("run_id", runID))
+		return fmt.Errorf("failed to update reconciliation run: %w", err)
+	}
+	return nil
+}
+
+const reconciliationRunColumns = `
+		SELECT id, portfolio_id, statement_date, status, positions_checked, trades_checked, auto_fixed,
+		       open_breaks, COALESCE(error, ''), started_at, completed_at
+		FROM reconciliation_runs`
+
+// GetReconciliationRuns retrieves a page of a portfolio's reconciliation runs
+func (r *PortfolioRepository) GetReconciliationRuns(ctx context.Context, portfolioID int, page pagination.Request) ([]models.ReconciliationRun, pagination.Result, error) {
+	cond, args := page.After(2)
+	query := `
+		SELECT id, portfolio_id, statement_date, status, positions_checked, trades_checked, auto_fixed,
+		       open_breaks, COALESCE(error, ''), started_at, completed_at, ` + page.Key() + `
+		FROM reconciliation_runs
+		WHERE portfolio_id = $1` + cond + page.OrderBy()
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{portfolioID}, args...)...)
+	if err != nil {
+		r.logger.Error("Failed to get reconciliation runs", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, pagination.Result{}, fmt.Errorf("failed to get reconciliation runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []models.ReconciliationRun
+	var keys []string
+	for rows.Next() {
+		var key string
+		run, err := scanReconciliationRun(keyedRow{row: rows, key: &key})
+		if err != nil {
+			return nil, pagination.Result{}, err
+		}
+		runs = append(runs, *run)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("error iterating reconciliation runs: %w", err)
+	}
+
+	var result pagination.Result
+	if page.More(len(runs)) {
+		runs = runs[:page.Limit]
+		result.NextCursor = page.Cursor(keys[page.Limit-1], runs[page.Limit-1].ID)
+	}
+	result.Total, err = r.count(ctx, `SELECT COUNT(*) FROM reconciliation_runs WHERE portfolio_id = $1`, portfolioID)
+	if err != nil {
+		return nil, pagination.Result{}, err
+	}
+
+	return runs, result, nil
+}
+
+// GetReconciliationRun retrieves a run with its breaks
+func (r *PortfolioRepository) GetReconciliationRun(ctx context.Context, runID int) (*models.ReconciliationRun, error) {
+	run, err := scanReconciliationRun(r.db.QueryRowContext(ctx, reconciliationRunColumns+` WHERE id = $1`, runID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundf("reconciliation run not found: %d", runID)
+		}
+		return nil, err
+	}
+
+	query := `
+		SELECT id, run_id, portfolio_id, break_type, symbol, COALESCE(trade_id, 0), COALESCE(broker_ref, ''),
+		       COALESCE(internal_value, 0), COALESCE(broker_value, 0), resolution, COALESCE(message, ''), created_at
+		FROM reconciliation_breaks
+		WHERE run_id = $1
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, runID)
+	if err != nil {
+		r.logger.Error("Failed to get reconciliation breaks", zap.Error(err), zap.Int("run_id", runID))
+		return nil, fmt.Errorf("failed to get reconciliation breaks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		b := models.ReconciliationBreak{}
+		err := rows.Scan(
+			&b.ID,
+			&b.RunID,
+			&b.PortfolioID,
+			&b.BreakType,
+			&b.Symbol,
+			&b.TradeID,
+			&b.BrokerRef,
+			&b.InternalValue,
+			&b.BrokerValue,
+			&b.Resolution,
+			&b.Message,
+			&b.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reconciliation break: %w", err)
+		}
+		run.Breaks = append(run.Breaks, b)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reconciliation breaks: %w", err)
+	}
+
+	return run, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReconciliationRun(row rowScanner) (*models.ReconciliationRun, error) {
+	run := &models.ReconciliationRun{}
+	err := row.Scan(
+		&run.ID,
+		&run.PortfolioID,
+		&run.StatementDate,
+		&run.Status,
+		&run.PositionsChecked,
+		&run.TradesChecked,
+		&run.AutoFixed,
+		&run.OpenBreaks,
+		&run.Error,
+		&run.StartedAt,
+		&run.CompletedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan reconciliation run: %w", err)
+	}
+	return run, nil
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Settings Operations
+
+const settingsColumns = `commission_rate, min_commission, benchmark, base_currency, drip_enabled, auto_trade_enabled, strategy, fee_schedule, crypto_fee_schedule,
+	cost_basis_method, default_order_type, risk_tolerance, auto_rebalance, notifications, updated_at`
+
+// GetPortfolioSettings retrieves the options set on a portfolio. A portfolio
+// without settings has no overrides.
+func (r *PortfolioRepository) GetPortfolioSettings(ctx context.Context, portfolioID int) (*models.SettingsOverrides, error) {
+	query := `SELECT ` + settingsColumns + ` FROM portfolio_settings WHERE portfolio_id = $1`
+	return r.getSettings(ctx, query, portfolioID)
+}
+
+// SavePortfolioSettings replaces the options set on a portfolio
+func (r *PortfolioRepository) SavePortfolioSettings(ctx context.Context, portfolioID int, settings *models.SettingsOverrides) error {
+	return r.saveSettings(ctx, r.db, "portfolio_settings", "portfolio_id", portfolioID, settings)
+}
+
+// SavePortfolioSettingsTx replaces the options set on a portfolio within a
+// transaction
+func (r *PortfolioRepository) SavePortfolioSettingsTx(ctx context.Context, tx *sql.Tx, portfolioID int, settings *models.SettingsOverrides) error {
+	return r.saveSettings(ctx, tx, "portfolio_settings", "portfolio_id", portfolioID, settings)
+}
+
+// GetUserPortfolioSettings retrieves a user's defaults for their portfolios'
+// settings
+func (r *PortfolioRepository) GetUserPortfolioSettings(ctx context.Context, userID int) (*models.SettingsOverrides, error) {
+	query := `SELECT ` + settingsColumns + ` FROM user_portfolio_settings WHERE user_id = $1`
+	return r.getSettings(ctx, query, userID)
+}
+
+// SaveUserPortfolioSettings replaces a user's defaults for their portfolios'
+// settings
+func (r *PortfolioRepository) SaveUserPortfolioSettings(ctx context.Context, userID int, settings *models.SettingsOverrides) error {
+	return r.saveSettings(ctx, r.db, "user_portfolio_settings", "user_id", userID, settings)
+}
+
+// GetPortfolioSettingsByUserID retrieves the options set on each of a user's
+// portfolios, by portfolio ID
+func (r *PortfolioRepository) GetPortfolioSettingsByUserID(ctx context.Context, userID int) (map[int]models.SettingsOverrides, error) {
+	query := `
+		SELECT p.id, s.commission_rate, s.min_commission, s.benchmark, s.base_currency, s.drip_enabled,
+		       s.auto_trade_enabled, s.strategy, s.fee_schedule, s.crypto_fee_schedule, s.cost_basis_method,
+		       s.default_order_type, s.risk_tolerance, s.auto_rebalance, s.notifications, s.updated_at
+		FROM portfolios p
+		LEFT JOIN portfolio_settings s ON s.portfolio_id = p.id
+		WHERE p.user_id = $1`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("Failed to get portfolio settings", zap.Error(err), zap.Int("user_id", userID))
+		return nil, fmt.Errorf("failed to get portfolio settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[int]models.SettingsOverrides)
+	for rows.Next() {
+		var portfolioID int
+		overrides, err := scanSettings(idRow{rows, &portfolioID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan portfolio settings: %w", err)
+		}
+		settings[portfolioID] = *overrides
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating portfolio settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+func (r *PortfolioRepository) getSettings(ctx context.Context, query string, id int) (*models.SettingsOverrides, error) {
+	settings, err := scanSettings(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &models.SettingsOverrides{}, nil
+		}
+		r.logger.Error("Failed to get settings", zap.Error(err), zap.Int("id", id))
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (r *PortfolioRepository) saveSettings(ctx context.Context, db execer, table, keyColumn string, id int, settings *models.SettingsOverrides) error {
+	query := `
+		INSERT INTO ` + table + ` (` + keyColumn + `, ` + settingsColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (` + keyColumn + `) DO UPDATE
+		SET commission_rate = EXCLUDED.commission_rate, min_commission = EXCLUDED.min_commission,
+		    benchmark = EXCLUDED.benchmark, base_currency = EXCLUDED.base_currency,
+		    drip_enabled = EXCLUDED.drip_enabled, auto_trade_enabled = EXCLUDED.auto_trade_enabled,
+		    strategy = EXCLUDED.strategy, fee_schedule = EXCLUDED.fee_schedule,
+		    crypto_fee_schedule = EXCLUDED.crypto_fee_schedule, cost_basis_method = EXCLUDED.cost_basis_method,
+		    default_order_type = EXCLUDED.default_order_type, risk_tolerance = EXCLUDED.risk_tolerance,
+		    auto_rebalance = EXCLUDED.auto_rebalance, notifications = EXCLUDED.notifications,
+		    updated_at = EXCLUDED.updated_at`
+
+	feeSchedule, err := feeScheduleValue(settings.FeeSchedule)
+	if err != nil {
+		return err
+	}
+	cryptoFeeSchedule, err := feeScheduleValue(settings.CryptoFeeSchedule)
+	if err != nil {
+		return err
+	}
+	var notifications interface{}
+	if settings.Notifications != nil {
+		data, err := json.Marshal(settings.Notifications)
+		if err != nil {
+			return fmt.Errorf("failed to marshal notifications: %w", err)
+		}
+		notifications = string(data)
+	}
+
+	now := time.Now()
+	_, err = db.ExecContext(ctx, query, id, settings.CommissionRate, settings.MinCommission, settings.Benchmark,
+		settings.BaseCurrency, settings.DRIPEnabled, settings.AutoTradeEnabled, settings.Strategy, feeSchedule,
+		cryptoFeeSchedule, settings.CostBasisMethod, settings.DefaultOrderType, settings.RiskTolerance,
+		settings.AutoRebalance, notifications, now)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return domain.NotFoundf("%s not found: %d", strings.TrimSuffix(keyColumn, "_id"), id)
+		}
+		r.logger.Error("Failed to save settings", zap.Error(err), zap.String("table", table), zap.Int("id", id))
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	settings.UpdatedAt = &now
+	return nil
+}
+
+// feeScheduleValue returns a fee schedule as a JSONB column value, NULL when
+// not set
+func feeScheduleValue(schedule *models.FeeSchedule) (interface{}, error) {
+	if schedule == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fee schedule: %w", err)
+	}
+	return string(data), nil
+}
+
+// idRow prepends an ID to the columns a row scan reads
+type idRow struct {
+	row rowScanner
+	id  *int
+}
+
+func (i idRow) Scan(dest ...interface{}) error {
+	return i.row.Scan(append([]interface{}{i.id}, dest...)...)
+}
+
+func scanSettings(row rowScanner) (*models.SettingsOverrides, error) {
+	var (
+		commissionRate, minCommission                 sql.NullFloat64
+		benchmark, baseCurrency, strategy             sql.NullString
+		costBasis, defaultOrderType, riskTolerance    sql.NullString
+		dripEnabled, autoTradeEnabled, autoRebalance  sql.NullBool
+		feeSchedule, cryptoFeeSchedule, notifications []byte
+		updatedAt                                     sql.NullTime
+	)
+	err := row.Scan(&commissionRate, &minCommission, &benchmark, &baseCurrency, &dripEnabled,
+		&autoTradeEnabled, &strategy, &feeSchedule, &cryptoFeeSchedule, &costBasis, &defaultOrderType,
+		&riskTolerance, &autoRebalance, &notifications, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &models.SettingsOverrides{}
+	if commissionRate.Valid {
+		settings.CommissionRate = &commissionRate.Float64
+	}
+	if minCommission.Valid {
+		settings.MinCommission = &minCommission.Float64
+	}
+	if benchmark.Valid {
+		settings.Benchmark = &benchmark.String
+	}
+	if baseCurrency.Valid {
+		settings.BaseCurrency = &baseCurrency.String
+	}
+	if dripEnabled.Valid {
+		settings.DRIPEnabled = &dripEnabled.Bool
+	}
+	if autoTradeEnabled.Valid {
+		settings.AutoTradeEnabled = &autoTradeEnabled.Bool
+	}
+	if strategy.Valid {
+		settings.Strategy = &strategy.String
+	}
+	if feeSchedule != nil {
+		settings.FeeSchedule = &models.FeeSchedule{}
+		if err := json.Unmarshal(feeSchedule, settings.FeeSchedule); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fee schedule: %w", err)
+		}
+	}
+	if cryptoFeeSchedule != nil {
+		settings.CryptoFeeSchedule = &models.FeeSchedule{}
+		if err := json.Unmarshal(cryptoFeeSchedule, settings.CryptoFeeSchedule); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal crypto fee schedule: %w", err)
+		}
+	}
+	if costBasis.Valid {
+		settings.CostBasisMethod = &costBasis.String
+	}
+	if defaultOrderType.Valid {
+		settings.DefaultOrderType = &defaultOrderType.String
+	}
+	if riskTolerance.Valid {
+		settings.RiskTolerance = &riskTolerance.String
+	}
+	if autoRebalance.Valid {
+		settings.AutoRebalance = &autoRebalance.Bool
+	}
+	if notifications != nil {
+		if err := json.Unmarshal(notifications, &settings.Notifications); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal notifications: %w", err)
+		}
+	}
+	if updatedAt.Valid {
+		settings.UpdatedAt = &updatedAt.Time
+	}
+	return settings, nil
+}
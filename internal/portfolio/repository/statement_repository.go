@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+)
+
+// Account Statement Operations
+
+// GetCashTransactionsBetween retrieves a portfolio's cash ledger entries made
+// in [start, end), oldest first, without their transfer details
+func (r *PortfolioRepository) GetCashTransactionsBetween(ctx context.Context, portfolioID int, start, end time.Time) ([]models.CashTransaction, error) {
+	query := `
+		SELECT id, portfolio_id, type, amount, balance_after, created_at
+		FROM cash_transactions
+		WHERE portfolio_id = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY created_at, id`
+
+	rows, err := r.reader().QueryContext(ctx, query, portfolioID, start, end)
+	if err != nil {
+		r.logger.Error("Failed to get cash transactions", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, fmt.Errorf("failed to get cash transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.CashTransaction
+	for rows.Next() {
+		var entry models.CashTransaction
+		err := rows.Scan(&entry.ID, &entry.PortfolioID, &entry.Type, &entry.Amount, &entry.BalanceAfter, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cash transaction: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cash transactions: %w", err)
+	}
+
+	return entries, nil
+}
+
+// CreateAccountStatement saves a portfolio's statement for a month. It
+// returns false, saving nothing, when the month already has one.
+func (r *PortfolioRepository) CreateAccountStatement(ctx context.Context, statement *models.AccountStatement) (bool, error) {
+	query := `
+		INSERT INTO account_statements (portfolio_id, user_id, month, start_date, end_date, starting_value, ending_value,
+			contributions, withdrawals, realized_pnl, unrealized_pnl, fees, trade_count, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (portfolio_id, month) DO NOTHING
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query, statement.PortfolioID, statement.UserID, statement.Month,
+		statement.StartDate, statement.EndDate, statement.StartingValue, statement.EndingValue,
+		statement.Contributions, statement.Withdrawals, statement.RealizedPnL, statement.UnrealizedPnL,
+		statement.Fees, statement.TradeCount, now).Scan(&statement.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		r.logger.Error("Failed to create account statement", zap.Error(err), zap.Int("portfolio_id", statement.PortfolioID))
+		return false, fmt.Errorf("failed to create account statement: %w", err)
+	}
+
+	statement.CreatedAt = now
+	return true, nil
+}
+
+// GetAccountStatements retrieves a page of a portfolio's account statements
+func (r *PortfolioRepository) GetAccountStatements(ctx context.Context, portfolioID int, page pagination.Request) ([]models.AccountStatement, pagination.Result, error) {
+	cond, args := page.After(2)
+	query := `
+		SELECT id, portfolio_id, user_id, month, start_date, end_date, starting_value, ending_value, contributions,
+		       withdrawals, realized_pnl, unrealized_pnl, fees, trade_count, created_at, ` + page.Key() + `
+		FROM account_statements
+		WHERE portfolio_id = $1` + cond + page.OrderBy()
+
+	rows, err := r.reader().QueryContext(ctx, query, append([]interface{}{portfolioID}, args...)...)
+	if err != nil {
+		r.logger.Error("Failed to get account statements", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, pagination.Result{}, fmt.Errorf("failed to get account statements: %w", err)
+	}
+	defer rows.Close()
+
+	var statements []models.AccountStatement
+	var keys []string
+	for rows.Next() {
+		var s models.AccountStatement
+		var key string
+		err := rows.Scan(&s.ID, &s.PortfolioID, &s.UserID, &s.Month, &s.StartDate, &s.EndDate, &s.StartingValue,
+			&s.EndingValue, &s.Contributions, &s.Withdrawals, &s.RealizedPnL, &s.UnrealizedPnL, &s.Fees,
+			&s.TradeCount, &s.CreatedAt, &key)
+		if err != nil {
+			return nil, pagination.Result{}, fmt.Errorf("failed to scan account statement: %w", err)
+		}
+		statements = append(statements, s)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("error iterating account statements: %w", err)
+	}
+
+	var result pagination.Result
+	if page.More(len(statements)) {
+		statements = statements[:page.Limit]
+		result.NextCursor = page.Cursor(keys[page.Limit-1], statements[page.Limit-1].ID)
+	}
+	result.Total, err = r.count(ctx, `SELECT COUNT(*) FROM account_statements WHERE portfolio_id = $1`, portfolioID)
+	if err != nil {
+		return nil, pagination.Result{}, err
+	}
+
+	return statements, result, nil
+}
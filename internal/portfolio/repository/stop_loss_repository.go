@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Stop-Loss Operations
+
+// SetPositionStopLoss replaces a position's stop-loss. Nil percent and price
+// clear it.
+func (r *PortfolioRepository) SetPositionStopLoss(ctx context.Context, positionID int, percent, price *float64) error {
+	return r.setPositionStopLoss(ctx, r.db, positionID, percent, price)
+}
+
+// SetPositionStopLossTx replaces a position's stop-loss within a transaction
+func (r *PortfolioRepository) SetPositionStopLossTx(ctx context.Context, tx *sql.Tx, positionID int, percent, price *float64) error {
+	return r.setPositionStopLoss(ctx, tx, positionID, percent, price)
+}
+
+func (r *PortfolioRepository) setPositionStopLoss(ctx context.Context, db execer, positionID int, percent, price *float64) error {
+	query := `
+		UPDATE positions
+		SET stop_loss_percent = $2, stop_loss_price = $3, updated_at = $4
+		WHERE id = $1`
+
+	result, err := db.ExecContext(ctx, query, positionID, percent, price, time.Now())
+	if err != nil {
+		r.logger.Error("Failed to set stop-loss", zap.Error(err), zap.Int("position_id", positionID))
+		return fmt.Errorf("failed to set stop-loss: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %d", domain.ErrPositionNotFound, positionID)
+	}
+	return nil
+}
+
+// ClaimStopLoss clears a position's stop-loss and reports whether it still
+// had one, so a stop hit by concurrent price updates is acted on only once
+func (r *PortfolioRepository) ClaimStopLoss(ctx context.Context, positionID int) (bool, error) {
+	query := `
+		UPDATE positions
+		SET stop_loss_percent = NULL, stop_loss_price = NULL, updated_at = $2
+		WHERE id = $1 AND (stop_loss_percent IS NOT NULL OR stop_loss_price IS NOT NULL)`
+
+	result, err := r.db.ExecContext(ctx, query, positionID, time.Now())
+	if err != nil {
+		r.logger.Error("Failed to claim stop-loss", zap.Error(err), zap.Int("position_id", positionID))
+		return false, fmt.Errorf("failed to claim stop-loss: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
+
+// GetStopLossPositions retrieves the positions in a symbol that have a
+// stop-loss
+func (r *PortfolioRepository) GetStopLossPositions(ctx context.Context, symbol string) ([]models.Position, error) {
+	query := `
+		SELECT id, user_id, portfolio_id, symbol, asset_type, quantity, multiplier, side, entry_price, current_price,
+		       unrealized_pnl, realized_pnl, stop_loss_percent, stop_loss_price, created_at, updated_at
+		FROM positions
+		WHERE symbol = $1 AND (stop_loss_percent IS NOT NULL OR stop_loss_price IS NOT NULL)
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, symbols.Normalize(symbol))
+	if err != nil {
+		r.logger.Error("Failed to get stop-loss positions", zap.Error(err), zap.String("symbol", symbol))
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []models.Position
+	for rows.Next() {
+		position := models.Position{}
+		err := rows.Scan(
+			&position.ID,
+			&position.UserID,
+			&position.PortfolioID,
+			&position.Symbol,
+			&position.AssetType,
+			&position.Quantity,
+			&position.Multiplier,
+			&position.Side,
+			&position.EntryPrice,
+			&position.CurrentPrice,
+			&position.UnrealizedPnL,
+			&position.RealizedPnL,
+			&position.StopLossPercent,
+			&position.StopLossPrice,
+			&position.CreatedAt,
+			&position.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan position: %w", err)
+		}
+		positions = append(positions, position)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating positions: %w", err)
+	}
+
+	return positions, nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Trade Event Operations
+
+const insertTradeEventQuery = `
+	INSERT INTO trade_events (portfolio_id, user_id, trade_id, symbol, event_type, side, quantity,
+	                          price, fees, reason, request_id, created_at)
+	VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, ''), $12)
+	RETURNING id`
+
+// CreateTradeEvent appends a trade event
+func (r *PortfolioRepository) CreateTradeEvent(ctx context.Context, event *models.TradeEvent) error {
+	event.Symbol = symbols.Normalize(event.Symbol)
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, insertTradeEventQuery, tradeEventArgs(event, now)...).Scan(&event.ID)
+	if err != nil {
+		r.logger.Error("Failed to create trade event", zap.Error(err),
+			zap.Int("portfolio_id", event.PortfolioID), zap.String("event_type", event.EventType))
+		return fmt.Errorf("failed to create trade event: %w", err)
+	}
+
+	event.CreatedAt = now
+	return nil
+}
+
+// CreateTradeEventTx appends a trade event within a transaction
+func (r *PortfolioRepository) CreateTradeEventTx(ctx context.Context, tx *sql.Tx, event *models.TradeEvent) error {
+	event.Symbol = symbols.Normalize(event.Symbol)
+
+	now := time.Now()
+	err := tx.QueryRowContext(ctx, insertTradeEventQuery, tradeEventArgs(event, now)...).Scan(&event.ID)
+	if err != nil {
+		r.logger.Error("Failed to create trade event in transaction", zap.Error(err),
+			zap.Int("portfolio_id", event.PortfolioID), zap.String("event_type", event.EventType))
+		return fmt.Errorf("failed to create trade event: %w", err)
+	}
+
+	event.CreatedAt = now
+	return nil
+}
+
+// GetTradeEvents retrieves trade events for a portfolio with id greater than
+// afterID, oldest first, so consumers can resume reading from a cursor
+func (r *PortfolioRepository) GetTradeEvents(ctx context.Context, portfolioID int, afterID int64, limit int) ([]models.TradeEvent, error) {
+	query := `
+		SELECT id, portfolio_id, COALESCE(user_id, 0), COALESCE(trade_id, 0), symbol, event_type, side,
+		       quantity, COALESCE(price, 0), COALESCE(fees, 0), COALESCE(reason, ''),
+		       COALESCE(request_id, ''), created_at
+		FROM trade_events
+		WHERE portfolio_id = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, portfolioID, afterID, limit)
+	if err != nil {
+		r.logger.Error("Failed to get trade events", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, fmt.Errorf("failed to get trade events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.TradeEvent
+	for rows.Next() {
+		event := models.TradeEvent{}
+		err := rows.Scan(
+			&event.ID,
+			&event.PortfolioID,
+			&event.UserID,
+			&event.TradeID,
+			&event.Symbol,
+			&event.EventType,
+			&event.Side,
+			&event.Quantity,
+			&event.Price,
+			&event.Fees,
+			&event.Reason,
+			&event.RequestID,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan trade event", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan trade event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trade events: %w", err)
+	}
+
+	return events, nil
+}
+
+func tradeEventArgs(event *models.TradeEvent, now time.Time) []interface{} {
+	return []interface{}{
+		event.PortfolioID,
+		event.UserID,
+		event.TradeID,
+		event.Symbol,
+		event.EventType,
+		event.Side,
+		event.Quantity,
+		event.Price,
+		event.Fees,
+		event.Reason,
+		event.RequestID,
+		now,
+	}
+}
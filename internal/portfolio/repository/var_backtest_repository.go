@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+)
+
+// VaR Backtest Operations
+
+// GetRecentSnapshots retrieves a portfolio's latest limit snapshots, oldest
+// first
+func (r *PortfolioRepository) GetRecentSnapshots(ctx context.Context, portfolioID, limit int) ([]models.PortfolioSnapshot, error) {
+	query := `
+		SELECT id, portfolio_id, snapshot_date, total_value, cash, created_at
+		FROM (
+			SELECT id, portfolio_id, snapshot_date, total_value, cash, created_at
+			FROM portfolio_snapshots
+			WHERE portfolio_id = $1
+			ORDER BY snapshot_date DESC
+			LIMIT $2
+		) recent
+		ORDER BY snapshot_date`
+
+	rows, err := r.db.QueryContext(ctx, query, portfolioID, limit)
+	if err != nil {
+		r.logger.Error("Failed to get portfolio snapshots", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, fmt.Errorf("failed to get portfolio snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []models.PortfolioSnapshot
+	for rows.Next() {
+		var snapshot models.PortfolioSnapshot
+		err := rows.Scan(&snapshot.ID, &snapshot.PortfolioID, &snapshot.SnapshotDate, &snapshot.TotalValue,
+			&snapshot.Cash, &snapshot.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan portfolio snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating portfolio snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// CreateVaRBacktest saves the result of a VaR backtest
+func (r *PortfolioRepository) CreateVaRBacktest(ctx context.Context, backtest *models.VaRBacktest) error {
+	exceptionDates, err := json.Marshal(backtest.ExceptionDates)
+	if err != nil {
+		return fmt.Errorf("failed to encode exception dates: %w", err)
+	}
+
+	query := `
+		INSERT INTO var_backtests (portfolio_id, confidence, lookback_days, start_date, end_date, observations,
+			exceptions, expected_exceptions, kupiec_lr, kupiec_p_value, independence_lr, independence_p_value,
+			conditional_coverage_lr, conditional_coverage_p_value, adequate, exception_dates, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING id`
+
+	now := time.Now()
+	err = r.db.QueryRowContext(ctx, query, backtest.PortfolioID, backtest.Confidence, backtest.LookbackDays,
+		backtest.StartDate, backtest.EndDate, backtest.Observations, backtest.Exceptions, backtest.ExpectedExceptions,
+		backtest.KupiecLR, backtest.KupiecPValue, backtest.IndependenceLR, backtest.IndependencePValue,
+		backtest.ConditionalCoverageLR, backtest.ConditionalCoveragePValue, backtest.Adequate, exceptionDates, now).Scan(&backtest.ID)
+	if err != nil {
+		r.logger.Error("Failed to create VaR backtest", zap.Error(err), zap.Int("portfolio_id", backtest.PortfolioID))
+		return fmt.Errorf("failed to create VaR backtest: %w", err)
+	}
+
+	backtest.CreatedAt = now
+	return nil
+}
+
+// GetVaRBacktests retrieves a page of a portfolio's VaR backtests
+func (r *PortfolioRepository) GetVaRBacktests(ctx context.Context, portfolioID int, page pagination.Request) ([]models.VaRBacktest, pagination.Result, error) {
+	cond, args := page.After(2)
+	query := `
+		SELECT id, portfolio_id, confidence, lookback_days, start_date, end_date, observations, exceptions,
+		       expected_exceptions, kupiec_lr, kupiec_p_value, independence_lr, independence_p_value,
+		       conditional_coverage_lr, conditional_coverage_p_value, adequate, exception_dates, created_at, ` + page.Key() + `
+		FROM var_backtests
+		WHERE portfolio_id = $1` + cond + page.OrderBy()
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{portfolioID}, args...)...)
+	if err != nil {
+		r.logger.Error("Failed to get VaR backtests", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return nil, pagination.Result{}, fmt.Errorf("failed to get VaR backtests: %w", err)
+	}
+	defer rows.Close()
+
+	var backtests []models.VaRBacktest
+	var keys []string
+	for rows.Next() {
+		var b models.VaRBacktest
+		var exceptionDates []byte
+		var key string
+		err := rows.Scan(&b.ID, &b.PortfolioID, &b.Confidence, &b.LookbackDays, &b.StartDate, &b.EndDate,
+			&b.Observations, &b.Exceptions, &b.ExpectedExceptions, &b.KupiecLR, &b.KupiecPValue, &b.IndependenceLR,
+			&b.IndependencePValue, &b.ConditionalCoverageLR, &b.ConditionalCoveragePValue, &b.Adequate,
+			&exceptionDates, &b.CreatedAt, &key)
+		if err != nil {
+			return nil, pagination.Result{}, fmt.Errorf("failed to scan VaR backtest: %w", err)
+		}
+		if err := json.Unmarshal(exceptionDates, &b.ExceptionDates); err != nil {
+			return nil, pagination.Result{}, fmt.Errorf("failed to decode exception dates of VaR backtest %d: %w", b.ID, err)
+		}
+		backtests = append(backtests, b)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("error iterating VaR backtests: %w", err)
+	}
+
+	var result pagination.Result
+	if page.More(len(backtests)) {
+		backtests = backtests[:page.Limit]
+		result.NextCursor = page.Cursor(keys[page.Limit-1], backtests[page.Limit-1].ID)
+	}
+	result.Total, err = r.count(ctx, `SELECT COUNT(*) FROM var_backtests WHERE portfolio_id = $1`, portfolioID)
+	if err != nil {
+		return nil, pagination.Result{}, err
+	}
+
+	return backtests, result, nil
+}
@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"hedge-fund/pkg/shared/auth"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/requestctx"
+	sharedrpc "hedge-fund/pkg/shared/rpc"
+)
+
+// Identities resolves the users calls act as and checks the second factors
+// they give
+type Identities interface {
+	Identify(ctx context.Context, userID int) (auth.Identity, error)
+	VerifySecondFactor(ctx context.Context, identity auth.Identity, code, deviceToken string) error
+}
+
+// caller resolves the user a call acts as, named by the calling service, and
+// returns ctx carrying their identity. Calls naming no user are the
+// services' own, such as auto-trading, and are trusted: acting is then false.
+func (s *Server) caller(ctx context.Context) (_ context.Context, identity auth.Identity, acting bool, err error) {
+	actor := requestctx.Actor(ctx)
+	if actor == requestctx.SystemActor {
+		return ctx, auth.Identity{}, false, nil
+	}
+	userID, err := strconv.Atoi(actor)
+	if err != nil || userID <= 0 {
+		return ctx, auth.Identity{}, false, status.Errorf(codes.Unauthenticated, "invalid acting user %q", actor)
+	}
+
+	identity, err = s.identities.Identify(ctx, userID)
+	switch {
+	case errors.Is(err, auth.ErrUnknownUser):
+		return ctx, auth.Identity{}, false, status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, auth.ErrInactiveUser):
+		return ctx, auth.Identity{}, false, status.Error(codes.PermissionDenied, err.Error())
+	case err != nil:
+		return ctx, auth.Identity{}, false, status.Error(codes.Internal, err.Error())
+	}
+	return auth.WithIdentity(ctx, identity), identity, true, nil
+}
+
+// portfolio returns a portfolio the caller may access, and ctx carrying the
+// caller's identity. Other users' portfolios are answered as not found, as
+// over HTTP. Changing a portfolio also takes a role that may trade.
+func (s *Server) portfolio(ctx context.Context, portfolioID int, change bool) (context.Context, *models.Portfolio, error) {
+	ctx, identity, acting, err := s.caller(ctx)
+	if err != nil {
+		return ctx, nil, err
+	}
+	portfolio, err := s.service.GetPortfolio(ctx, portfolioID)
+	if err != nil {
+		return ctx, nil, sharedrpc.Error(err, codes.Internal)
+	}
+	if !acting {
+		return ctx, portfolio, nil
+	}
+	if !identity.CanActFor(portfolio.UserID) {
+		return ctx, nil, status.Errorf(codes.NotFound, "portfolio not found: %d", portfolioID)
+	}
+	if change && !identity.CanTrade() {
+		return ctx, nil, status.Errorf(codes.PermissionDenied, "requires role %s or %s", models.RoleTrader, models.RoleAdmin)
+	}
+	return ctx, portfolio, nil
+}
+
+// checkSecondFactor checks the second factor a user gave for a sensitive call,
+// mapping refusals to the codes the HTTP API answers with
+func (s *Server) checkSecondFactor(ctx context.Context, identity auth.Identity) error {
+	code, deviceToken := sharedrpc.SecondFactor(ctx)
+	err := s.identities.VerifySecondFactor(ctx, identity, code, deviceToken)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, auth.ErrTooManyAttempts):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, auth.ErrTwoFactorNotEnabled):
+		return status.Error(codes.PermissionDenied, "enroll in two-factor authentication to take this action")
+	case errors.Is(err, auth.ErrSecondFactorRequired), errors.Is(err, auth.ErrInvalidCode):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		s.logger.Error("Failed to check second factor", zap.Error(err), zap.Int("user_id", identity.UserID))
+		return status.Error(codes.Internal, "failed to authorize call")
+	}
+}
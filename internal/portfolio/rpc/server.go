@@ -0,0 +1,324 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/internal/portfolio/handlers"
+	"hedge-fund/internal/portfolio/service"
+	portfoliopb "hedge-fund/pkg/proto/portfolio"
+	"hedge-fund/pkg/shared/auth"
+	"hedge-fund/pkg/shared/jobledger"
+	"hedge-fund/pkg/shared/models"
+	sharedrpc "hedge-fund/pkg/shared/rpc"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Server implements portfoliopb.PortfolioServiceServer on top of the
+// portfolio service layer. Calls acting as a user, named by the calling
+// service, are held to that user's portfolios and role, as over HTTP.
+type Server struct {
+	portfoliopb.UnimplementedPortfolioServiceServer
+
+	service        *service.PortfolioService
+	marketClient   handlers.MarketDataClient
+	identities     Identities
+	secondFactor   bool
+	tradeThreshold float64
+	logger         *zap.Logger
+}
+
+func NewServer(service *service.PortfolioService, marketClient handlers.MarketDataClient, identities Identities, logger *zap.Logger) *Server {
+	return &Server{
+		service:      service,
+		marketClient: marketClient,
+		identities:   identities,
+		logger:       logger,
+	}
+}
+
+// SetSecondFactor asks users for a second factor on trades worth
+// tradeThreshold or more, as the HTTP API does. Calls the services make on
+// their own behalf are not asked.
+func (s *Server) SetSecondFactor(tradeThreshold float64) {
+	s.secondFactor = true
+	s.tradeThreshold = tradeThreshold
+}
+
+// GetPortfolio returns a portfolio with its positions
+func (s *Server) GetPortfolio(ctx context.Context, req *portfoliopb.GetPortfolioRequest) (*portfoliopb.Portfolio, error) {
+	_, portfolio, err := s.portfolio(ctx, int(req.GetPortfolioId()), false)
+	if err != nil {
+		return nil, err
+	}
+	return toPortfolio(portfolio), nil
+}
+
+// ListUserPortfolios returns every portfolio owned by a user
+func (s *Server) ListUserPortfolios(ctx context.Context, req *portfoliopb.ListUserPortfoliosRequest) (*portfoliopb.ListUserPortfoliosResponse, error) {
+	userID := int(req.GetUserId())
+	ctx, identity, acting, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if acting && !identity.CanActFor(userID) {
+		return nil, status.Error(codes.PermissionDenied, "cannot access another user's resources")
+	}
+
+	portfolios, err := s.service.GetUserPortfolios(ctx, userID)
+	if err != nil {
+		return nil, sharedrpc.Error(err, codes.Internal)
+	}
+
+	resp := &portfoliopb.ListUserPortfoliosResponse{Portfolios: make([]*portfoliopb.Portfolio, len(portfolios))}
+	for i := range portfolios {
+		resp.Portfolios[i] = toPortfolio(&portfolios[i])
+	}
+	return resp, nil
+}
+
+// GetPositions returns the open positions of a portfolio
+func (s *Server) GetPositions(ctx context.Context, req *portfoliopb.GetPositionsRequest) (*portfoliopb.GetPositionsResponse, error) {
+	ctx, _, err := s.portfolio(ctx, int(req.GetPortfolioId()), false)
+	if err != nil {
+		return nil, err
+	}
+
+	positions, err := s.service.GetPositions(ctx, int(req.GetPortfolioId()))
+	if err != nil {
+		return nil, sharedrpc.Error(err, codes.Internal)
+	}
+
+	resp := &portfoliopb.GetPositionsResponse{Positions: make([]*portfoliopb.Position, len(positions))}
+	for i := range positions {
+		resp.Positions[i] = toPosition(&positions[i])
+	}
+	return resp, nil
+}
+
+// maxIdempotencyKey is the longest idempotency key a call may be sent with,
+// the width of processed_jobs.job_id
+const maxIdempotencyKey = 64
+
+// ExecuteTrade executes a trade with the same validation as the HTTP API.
+// A trade sent with an idempotency key is made once: sent again, the
+// original trade is returned. Large trades acting as a user need the second
+// factor they gave, sent with rpc.WithSecondFactor.
+func (s *Server) ExecuteTrade(ctx context.Context, req *portfoliopb.ExecuteTradeRequest) (*portfoliopb.ExecuteTradeResponse, error) {
+	if key := sharedrpc.IdempotencyKey(ctx); key != "" {
+		if len(key) > maxIdempotencyKey {
+			return nil, status.Errorf(codes.InvalidArgument, "idempotency key must be at most %d characters", maxIdempotencyKey)
+		}
+		ctx = jobledger.WithKey(ctx, key, "rpc_trade")
+	}
+
+	symbol := symbols.Normalize(req.GetSymbol())
+	if symbol == "" || req.GetQuantity() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "symbol and a positive quantity are required")
+	}
+	if err := symbols.Validate(symbol); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.GetSide() != "buy" && req.GetSide() != "sell" {
+		return nil, status.Error(codes.InvalidArgument, "side must be buy or sell")
+	}
+	if req.GetOrderType() != "market" && req.GetOrderType() != "limit" {
+		return nil, status.Error(codes.InvalidArgument, "order_type must be market or limit")
+	}
+
+	portfolioID := int(req.GetPortfolioId())
+	ctx, portfolio, err := s.portfolio(ctx, portfolioID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	currentPrice := req.GetPrice()
+	if req.GetOrderType() == "market" {
+		currentPrice, err = s.marketClient.GetCurrentPrice(symbol)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "failed to get market price: %v", err)
+		}
+	}
+
+	notional := req.GetQuantity() * currentPrice * domain.Multiplier(symbol)
+	if identity, acting := auth.FromContext(ctx); acting && s.secondFactor && notional >= s.tradeThreshold {
+		if err := s.checkSecondFactor(ctx, identity); err != nil {
+			return nil, err
+		}
+	}
+
+	trade := &models.Trade{
+		UserID:   portfolio.UserID,
+		Symbol:   symbol,
+		Quantity: req.GetQuantity(),
+		Side:     req.GetSide(),
+		Type:     req.GetOrderType(),
+		Status:   "pending",
+	}
+
+	position, err := s.service.ExecuteTrade(ctx, portfolioID, trade, currentPrice)
+	if errors.Is(err, jobledger.ErrProcessed) {
+		original, err := s.service.ProcessedTrade(ctx, portfolioID, trade)
+		if err != nil {
+			return nil, sharedrpc.Error(err, codes.Internal)
+		}
+		return &portfoliopb.ExecuteTradeResponse{Trade: toTrade(original)}, nil
+	}
+	if err != nil {
+		if domain.IsInvalidOrder(err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, sharedrpc.Error(err, codes.FailedPrecondition)
+	}
+
+	s.logger.Info("Trade executed via gRPC",
+		zap.Int("portfolio_id", portfolioID),
+		zap.String("symbol", trade.Symbol),
+		zap.String("side", trade.Side),
+		zap.Float64("quantity", trade.Quantity),
+		zap.Float64("price", currentPrice))
+
+	resp := &portfoliopb.ExecuteTradeResponse{Trade: toTrade(trade)}
+	if position != nil {
+		resp.Position = toPosition(position)
+	}
+	return resp, nil
+}
+
+// GetTradeHistory returns a portfolio's trades, newest first
+func (s *Server) GetTradeHistory(ctx context.Context, req *portfoliopb.GetTradeHistoryRequest) (*portfoliopb.GetTradeHistoryResponse, error) {
+	portfolioID := int(req.GetPortfolioId())
+	ctx, _, err := s.portfolio(ctx, portfolioID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = 50
+	}
+
+	trades, err := s.service.GetTradeHistory(ctx, portfolioID, limit, int(req.GetOffset()))
+	if err != nil {
+		return nil, sharedrpc.Error(err, codes.Internal)
+	}
+
+	resp := &portfoliopb.GetTradeHistoryResponse{Trades: make([]*portfoliopb.Trade, len(trades))}
+	for i := range trades {
+		resp.Trades[i] = toTrade(&trades[i])
+	}
+	return resp, nil
+}
+
+// ListTradeEvents reads the trade event stream after a cursor so consumers
+// such as the risk service can follow executions
+func (s *Server) ListTradeEvents(ctx context.Context, req *portfoliopb.ListTradeEventsRequest) (*portfoliopb.ListTradeEventsResponse, error) {
+	ctx, _, err := s.portfolio(ctx, int(req.GetPortfolioId()), false)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = 100
+	}
+
+	events, err := s.service.GetTradeEvents(ctx, int(req.GetPortfolioId()), req.GetAfterId(), limit)
+	if err != nil {
+		return nil, sharedrpc.Error(err, codes.Internal)
+	}
+
+	resp := &portfoliopb.ListTradeEventsResponse{
+		Events:     make([]*portfoliopb.TradeEvent, len(events)),
+		NextCursor: req.GetAfterId(),
+	}
+	for i, event := range events {
+		resp.Events[i] = &portfoliopb.TradeEvent{
+			Id:          event.ID,
+			PortfolioId: int64(event.PortfolioID),
+			TradeId:     int64(event.TradeID),
+			Symbol:      event.Symbol,
+			EventType:   event.EventType,
+			Side:        event.Side,
+			Quantity:    event.Quantity,
+			Price:       event.Price,
+			Fees:        event.Fees,
+			Reason:      event.Reason,
+			RequestId:   event.RequestID,
+			CreatedAt:   timestamp(event.CreatedAt),
+		}
+		resp.NextCursor = event.ID
+	}
+	return resp, nil
+}
+
+func toPortfolio(p *models.Portfolio) *portfoliopb.Portfolio {
+	portfolio := &portfoliopb.Portfolio{
+		Id:              int64(p.ID),
+		UserId:          int64(p.UserID),
+		Name:            p.Name,
+		Cash:            p.Cash,
+		MarginUsed:      p.MarginUsed,
+		MarginAvailable: p.MarginAvailable,
+		TotalValue:      p.TotalValue,
+		UnrealizedPnl:   p.UnrealizedPnL,
+		RealizedPnl:     p.RealizedPnL,
+		DayPnl:          p.DayPnL,
+		Positions:       make([]*portfoliopb.Position, len(p.Positions)),
+		CreatedAt:       timestamp(p.CreatedAt),
+		UpdatedAt:       timestamp(p.UpdatedAt),
+	}
+	for i := range p.Positions {
+		portfolio.Positions[i] = toPosition(&p.Positions[i])
+	}
+	return portfolio
+}
+
+func toPosition(p *models.Position) *portfoliopb.Position {
+	return &portfoliopb.Position{
+		Id:            int64(p.ID),
+		PortfolioId:   int64(p.PortfolioID),
+		Symbol:        p.Symbol,
+		Quantity:      p.Quantity,
+		Side:          p.Side,
+		EntryPrice:    p.EntryPrice,
+		CurrentPrice:  p.CurrentPrice,
+		UnrealizedPnl: p.UnrealizedPnL,
+		RealizedPnl:   p.RealizedPnL,
+		CreatedAt:     timestamp(p.CreatedAt),
+		UpdatedAt:     timestamp(p.UpdatedAt),
+	}
+}
+
+func toTrade(t *models.Trade) *portfoliopb.Trade {
+	trade := &portfoliopb.Trade{
+		Id:          int64(t.ID),
+		PortfolioId: int64(t.PortfolioID),
+		PositionId:  int64(t.PositionID),
+		Symbol:      t.Symbol,
+		Quantity:    t.Quantity,
+		Price:       t.Price,
+		Side:        t.Side,
+		Type:        t.Type,
+		Status:      t.Status,
+		Fees:        t.Fees,
+		CreatedAt:   timestamp(t.CreatedAt),
+	}
+	if t.ExecutedAt != nil {
+		trade.ExecutedAt = timestamp(*t.ExecutedAt)
+	}
+	return trade
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
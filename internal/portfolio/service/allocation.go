@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/jobledger"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/redis"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// MarketData supplies the prices and sectors drift is measured with
+type MarketData interface {
+	GetCurrentPrices(list []string) (map[string]float64, error)
+	GetInstruments(list []string) (map[string]models.Instrument, error)
+}
+
+// AllocationService manages a portfolio's target allocation models and
+// monitors the portfolio's drift from them
+type AllocationService struct {
+	portfolios *PortfolioService
+	market     MarketData
+	queue      *queue.Manager
+	redis      *redis.Client
+	logger     *zap.Logger
+}
+
+// NewAllocationService creates an allocation service. queueManager and
+// redisClient may be nil, in which case breaches are only logged.
+func NewAllocationService(portfolios *PortfolioService, market MarketData, queueManager *queue.Manager, redisClient *redis.Client, logger *zap.Logger) *AllocationService {
+	return &AllocationService{
+		portfolios: portfolios,
+		market:     market,
+		queue:      queueManager,
+		redis:      redisClient,
+		logger:     logger,
+	}
+}
+
+// CreateModel validates and saves an allocation model for a portfolio
+func (s *AllocationService) CreateModel(ctx context.Context, model *models.AllocationModel) error {
+	if _, err := s.portfolios.repo.GetPortfolioByID(ctx, model.PortfolioID); err != nil {
+		return err
+	}
+	if err := s.prepare(model); err != nil {
+		return err
+	}
+	if err := s.portfolios.repo.CreateAllocationModel(ctx, model); err != nil {
+		return err
+	}
+
+	s.logger.Info("Allocation model created",
+		zap.Int("model_id", model.ID),
+		zap.Int("portfolio_id", model.PortfolioID),
+		zap.String("name", model.Name))
+	return nil
+}
+
+// UpdateModel validates and replaces an allocation model
+func (s *AllocationService) UpdateModel(ctx context.Context, model *models.AllocationModel) error {
+	if err := s.prepare(model); err != nil {
+		return err
+	}
+	return s.portfolios.repo.UpdateAllocationModel(ctx, model)
+}
+
+// prepare normalizes a model's target keys and fills in defaults before validation
+func (s *AllocationService) prepare(model *models.AllocationModel) error {
+	model.Name = strings.TrimSpace(model.Name)
+	if model.Basis == "" {
+		model.Basis = models.AllocationBasisSymbol
+	}
+	if model.DriftThreshold == 0 {
+		model.DriftThreshold = domain.DefaultDriftThreshold
+	}
+
+	// Symbols are normalized like any other input; sector names are kept as given
+	if model.Basis == models.AllocationBasisSymbol {
+		targets := make(map[string]float64, len(model.Targets))
+		for key, target := range model.Targets {
+			symbol := symbols.Normalize(key)
+			if err := symbols.Validate(symbol); err != nil {
+				return fmt.Errorf("%w: %v", domain.ErrInvalidAllocation, err)
+			}
+			targets[symbol] += target
+		}
+		model.Targets = targets
+	}
+
+	return s.portfolios.domain.ValidateAllocationModel(model)
+}
+
+// GetModel returns an allocation model
+func (s *AllocationService) GetModel(ctx context.Context, modelID int) (*models.AllocationModel, error) {
+	return s.portfolios.repo.GetAllocationModel(ctx, modelID)
+}
+
+// GetModels returns a portfolio's allocation models
+func (s *AllocationService) GetModels(ctx context.Context, portfolioID int) ([]models.AllocationModel, error) {
+	return s.portfolios.repo.GetAllocationModelsByPortfolioID(ctx, portfolioID)
+}
+
+// DeleteModel deletes an allocation model and its drift history
+func (s *AllocationService) DeleteModel(ctx context.Context, modelID int) error {
+	return s.portfolios.repo.DeleteAllocationModel(ctx, modelID)
+}
+
+// GetDriftChecks returns a page of a model's recorded drift checks
+func (s *AllocationService) GetDriftChecks(ctx context.Context, modelID int, page pagination.Request) ([]models.DriftCheck, pagination.Result, error) {
+	return s.portfolios.repo.GetDriftChecks(ctx, modelID, page)
+}
+
+// CheckDrift measures a portfolio's drift from an allocation model at current
+// prices and records the result, alerting the owner when the largest drift
+// exceeds the model's threshold
+func (s *AllocationService) CheckDrift(ctx context.Context, modelID int) (*models.DriftCheck, error) {
+	model, err := s.portfolios.repo.GetAllocationModel(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	return s.checkDrift(ctx, model)
+}
+
+func (s *AllocationService) checkDrift(ctx context.Context, model *models.AllocationModel) (*models.DriftCheck, error) {
+	portfolio, err := s.portfolios.repo.GetPortfolioByID(ctx, model.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	held := make([]string, 0, len(portfolio.Positions))
+	for _, position := range portfolio.Positions {
+		held = append(held, position.Symbol)
+	}
+	prices, err := s.market.GetCurrentPrices(held)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current prices: %w", err)
+	}
+
+	var sectors map[string]string
+	if model.Basis == models.AllocationBasisSector {
+		instruments, err := s.market.GetInstruments(held)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get instruments: %w", err)
+		}
+		sectors = make(map[string]string, len(instruments))
+		for symbol, instrument := range instruments {
+			sectors[symbol] = instrument.Sector
+		}
+	}
+
+	drifts := s.portfolios.domain.CalculateDrift(portfolio, model, prices, sectors)
+	check := &models.DriftCheck{
+		ModelID:     model.ID,
+		PortfolioID: model.PortfolioID,
+		MaxDrift:    domain.MaxDrift(drifts),
+		Threshold:   model.DriftThreshold,
+		Drifts:      drifts,
+	}
+	check.Breached = check.MaxDrift > check.Threshold
+
+	if err := s.portfolios.repo.CreateDriftCheck(ctx, check); err != nil {
+		return nil, err
+	}
+
+	if check.Breached {
+		s.alert(ctx, portfolio.UserID, model, check)
+		s.autoRebalance(ctx, portfolio, model)
+	}
+	return check, nil
+}
+
+// autoRebalance rebalances a portfolio towards the targets of a model that
+// drifted past its threshold, when the portfolio's settings turn
+// auto_rebalance on, at most once a day. Sector models name no symbols to
+// trade, so are left to the owner, and none trade during maintenance.
+func (s *AllocationService) autoRebalance(ctx context.Context, portfolio *models.Portfolio, model *models.AllocationModel) {
+	if model.Basis == models.AllocationBasisSector {
+		return
+	}
+	if s.portfolios.inMaintenance(ctx) {
+		s.logger.Info("Skipped auto-rebalance during maintenance", zap.Int("portfolio_id", portfolio.ID))
+		return
+	}
+	settings, err := s.portfolios.settingsFor(ctx, portfolio)
+	if err != nil {
+		s.logger.Warn("Failed to get settings for auto-rebalance", zap.Error(err), zap.Int("portfolio_id", portfolio.ID))
+		return
+	}
+	if !settings.AutoRebalance {
+		return
+	}
+
+	// Cash is whatever the symbol targets leave over
+	targets := make(map[string]float64, len(model.Targets))
+	priced := make([]string, 0, len(portfolio.Positions)+len(model.Targets))
+	for _, position := range portfolio.Positions {
+		priced = append(priced, position.Symbol)
+	}
+	for key, percent := range model.Targets {
+		if key == "CASH" {
+			continue
+		}
+		targets[key] = percent
+		priced = append(priced, key)
+	}
+	prices, err := s.market.GetCurrentPrices(priced)
+	if err != nil {
+		s.logger.Warn("Failed to get prices for auto-rebalance", zap.Error(err), zap.Int("portfolio_id", portfolio.ID))
+		return
+	}
+
+	// One auto-rebalance per model a day, however many leaders or checks run
+	key := fmt.Sprintf("auto_rebalance:%d:%s", model.ID, time.Now().UTC().Format("2006-01-02"))
+	result, err := s.portfolios.ExecuteRebalance(jobledger.WithKey(ctx, key, "auto_rebalance"), portfolio.ID, targets, prices, false)
+	if errors.Is(err, jobledger.ErrProcessed) {
+		s.logger.Debug("Portfolio already auto-rebalanced today", zap.Int("portfolio_id", portfolio.ID), zap.Int("model_id", model.ID))
+		return
+	}
+	if err != nil {
+		s.logger.Warn("Auto-rebalance failed", zap.Error(err), zap.Int("portfolio_id", portfolio.ID), zap.Int("model_id", model.ID))
+		return
+	}
+	s.logger.Info("Portfolio auto-rebalanced",
+		zap.Int("portfolio_id", portfolio.ID),
+		zap.Int("model_id", model.ID),
+		zap.Bool("applied", result.Applied),
+		zap.Int("trades", len(result.Trades)))
+}
+
+// alert notifies the portfolio owner and publishes a risk alert event for a
+// breached drift threshold. Failures are logged; the check has been stored.
+func (s *AllocationService) alert(ctx context.Context, userID int, model *models.AllocationModel, check *models.DriftCheck) {
+	worst := check.Drifts[0]
+	data := map[string]interface{}{
+		"portfolio_id": model.PortfolioID,
+		"model_id":     model.ID,
+		"model_name":   model.Name,
+		"key":          worst.Key,
+		"target":       worst.Target,
+		"actual":       worst.Actual,
+		"max_drift":    check.MaxDrift,
+		"threshold":    check.Threshold,
+	}
+
+	s.logger.Warn("Allocation drift above threshold",
+		zap.Int("portfolio_id", model.PortfolioID),
+		zap.Int("model_id", model.ID),
+		zap.String("key", worst.Key),
+		zap.Float64("max_drift", check.MaxDrift),
+		zap.Float64("threshold", check.Threshold))
+
+	if s.redis != nil {
+		event := models.Event{
+			Type:      "allocation_drift",
+			Source:    "portfolio-service",
+			Data:      data,
+			Timestamp: time.Now(),
+		}
+		if err := s.redis.PublishEvent(ctx, models.ChannelRiskAlerts, event); err != nil {
+			s.logger.Warn("Failed to publish drift alert", zap.Error(err))
+		}
+	}
+
+	if s.queue != nil && s.portfolios.notifies(ctx, userID, model.PortfolioID, "allocation_drift") {
+		if _, err := s.queue.EnqueueNotification(userID, "allocation_drift", "", "", data, nil); err != nil {
+			s.logger.Warn("Failed to enqueue drift alert", zap.Error(err))
+		}
+	}
+}
+
+// CheckAllDrift checks every active model of every active portfolio and
+// returns how many were checked and how many breached their threshold. A
+// model that fails to check is logged and skipped.
+func (s *AllocationService) CheckAllDrift(ctx context.Context) (int, int, error) {
+	allocationModels, err := s.portfolios.repo.ListActiveAllocationModels(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	checked, breached := 0, 0
+	for i := range allocationModels {
+		check, err := s.checkDrift(ctx, &allocationModels[i])
+		if err != nil {
+			s.logger.Error("Failed to check allocation drift", zap.Error(err), zap.Int("model_id", allocationModels[i].ID))
+			continue
+		}
+		checked++
+		if check.Breached {
+			breached++
+		}
+	}
+
+	return checked, breached, nil
+}
+
+// RunDailySchedule checks drift of all active models at hour (UTC) every day
+// until ctx is cancelled
+func (s *AllocationService) RunDailySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		checked, breached, err := s.CheckAllDrift(ctx)
+		if err != nil {
+			s.logger.Error("Failed to run daily drift checks", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Daily drift checks completed", zap.Int("models", checked), zap.Int("breached", breached))
+	}
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+
+	"hedge-fund/pkg/shared/analytics"
+	"hedge-fund/pkg/shared/models"
+)
+
+// SetAnalytics sets the tracker business events are emitted to. Without one
+// no events are emitted.
+func (s *PortfolioService) SetAnalytics(tracker *analytics.Tracker) {
+	s.analytics = tracker
+}
+
+// trackTrade emits trade_executed for a filled trade
+func (s *PortfolioService) trackTrade(ctx context.Context, userID int, trade *models.Trade, venue string) {
+	s.analytics.Track(ctx, analytics.EventTradeExecuted, userID, "trading", map[string]interface{}{
+		"portfolio_id": trade.PortfolioID,
+		"trade_id":     trade.ID,
+		"symbol":       trade.Symbol,
+		"side":         trade.Side,
+		"order_type":   trade.Type,
+		"quantity":     trade.Quantity,
+		"notional":     trade.Value(trade.Price),
+		"venue":        venue,
+	})
+}
+
+// trackAnalysis emits analysis_requested for an analysis feature run on a portfolio
+func (s *PortfolioService) trackAnalysis(ctx context.Context, portfolio *models.Portfolio, feature string) {
+	s.analytics.Track(ctx, analytics.EventAnalysisRequested, portfolio.UserID, feature, map[string]interface{}{
+		"portfolio_id":   portfolio.ID,
+		"position_count": len(portfolio.Positions),
+	})
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+// Audit Operations
+
+// GetAuditTrail retrieves the audit events recorded for a portfolio
+func (s *PortfolioService) GetAuditTrail(ctx context.Context, portfolioID int, page pagination.Request) ([]models.AuditEvent, pagination.Result, error) {
+	return s.repo.GetAuditEventsByPortfolioID(ctx, portfolioID, page)
+}
+
+// newAuditEvent builds an audit event with the actor and request ID from ctx.
+// before and after are snapshotted immediately so later in-memory mutations
+// don't leak into the record.
+func newAuditEvent(ctx context.Context, portfolioID int, entityType string, entityID int, action string, before, after interface{}) *models.AuditEvent {
+	return &models.AuditEvent{
+		PortfolioID: portfolioID,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Action:      action,
+		Actor:       requestctx.Actor(ctx),
+		RequestID:   requestctx.RequestID(ctx),
+		Before:      snapshot(before),
+		After:       snapshot(after),
+	}
+}
+
+// recordAudit appends an audit event within tx, the transaction of the
+// mutation it records, so each is committed only with the other
+func (s *PortfolioService) recordAudit(ctx context.Context, tx *sql.Tx, event *models.AuditEvent) error {
+	if err := s.repo.CreateAuditEventTx(ctx, tx, event); err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// snapshot serializes v to JSON; portfolios are stored without positions since
+// position changes are audited separately
+func snapshot(v interface{}) json.RawMessage {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case json.RawMessage:
+		return value
+	case *models.Portfolio:
+		if value == nil {
+			return nil
+		}
+		copied := *value
+		copied.Positions = nil
+		v = copied
+	case *models.Position:
+		if value == nil {
+			return nil
+		}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
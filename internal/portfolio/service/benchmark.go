@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/redis"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// DefaultBenchmarkWindowDays is the window of rules that don't set one. Rules
+// without a benchmark use the portfolio's benchmark setting.
+const DefaultBenchmarkWindowDays = 7
+
+// BenchmarkService takes daily snapshots of portfolio values and alerts
+// owners whose portfolios lag their benchmark by more than a rule allows
+type BenchmarkService struct {
+	portfolios *PortfolioService
+	market     MarketData
+	queue      *queue.Manager
+	redis      *redis.Client
+	logger     *zap.Logger
+}
+
+// NewBenchmarkService creates a benchmark service. queueManager and
+// redisClient may be nil, in which case lagging portfolios are only logged.
+func NewBenchmarkService(portfolios *PortfolioService, market MarketData, queueManager *queue.Manager, redisClient *redis.Client, logger *zap.Logger) *BenchmarkService {
+	return &BenchmarkService{
+		portfolios: portfolios,
+		market:     market,
+		queue:      queueManager,
+		redis:      redisClient,
+		logger:     logger,
+	}
+}
+
+// CreateRule validates and saves a benchmark alert rule for a portfolio
+func (s *BenchmarkService) CreateRule(ctx context.Context, rule *models.BenchmarkRule) error {
+	portfolio, err := s.portfolios.repo.GetPortfolioByID(ctx, rule.PortfolioID)
+	if err != nil {
+		return err
+	}
+
+	if rule.Benchmark == "" {
+		settings, err := s.portfolios.settingsFor(ctx, portfolio)
+		if err != nil {
+			return err
+		}
+		rule.Benchmark = settings.Benchmark
+	}
+	rule.Benchmark = symbols.Normalize(rule.Benchmark)
+	if err := symbols.Validate(rule.Benchmark); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidBenchmarkRule, err)
+	}
+	if rule.WindowDays == 0 {
+		rule.WindowDays = DefaultBenchmarkWindowDays
+	}
+	if err := s.portfolios.domain.ValidateBenchmarkRule(rule); err != nil {
+		return err
+	}
+
+	if err := s.portfolios.repo.CreateBenchmarkRule(ctx, rule); err != nil {
+		return err
+	}
+
+	s.logger.Info("Benchmark rule created",
+		zap.Int("rule_id", rule.ID),
+		zap.Int("portfolio_id", rule.PortfolioID),
+		zap.String("benchmark", rule.Benchmark))
+	return nil
+}
+
+// GetRule returns a benchmark alert rule
+func (s *BenchmarkService) GetRule(ctx context.Context, ruleID int) (*models.BenchmarkRule, error) {
+	return s.portfolios.repo.GetBenchmarkRule(ctx, ruleID)
+}
+
+// GetRules returns a portfolio's benchmark alert rules
+func (s *BenchmarkService) GetRules(ctx context.Context, portfolioID int) ([]models.BenchmarkRule, error) {
+	return s.portfolios.repo.GetBenchmarkRulesByPortfolioID(ctx, portfolioID)
+}
+
+// DeleteRule deletes a benchmark alert rule
+func (s *BenchmarkService) DeleteRule(ctx context.Context, ruleID int) error {
+	return s.portfolios.repo.DeleteBenchmarkRule(ctx, ruleID)
+}
+
+// Snapshot records a portfolio's value and factor exposure at current prices
+// for today (UTC)
+func (s *BenchmarkService) Snapshot(ctx context.Context, portfolioID int) (*models.PortfolioSnapshot, error) {
+	portfolio, err := s.portfolios.repo.GetPortfolioByID(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	held := make([]string, 0, len(portfolio.Positions))
+	for _, position := range portfolio.Positions {
+		held = append(held, position.Symbol)
+	}
+	prices, err := s.market.GetCurrentPrices(held)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current prices: %w", err)
+	}
+
+	now := time.Now().UTC()
+	snapshot := &models.PortfolioSnapshot{
+		PortfolioID:  portfolioID,
+		SnapshotDate: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		TotalValue:   s.portfolios.domain.CalculatePortfolioValue(portfolio, prices),
+		Cash:         portfolio.Cash,
+	}
+	if err := s.portfolios.repo.UpsertSnapshot(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	// Recorded alongside for trend charts; a failure doesn't void the snapshot
+	if _, err := s.portfolios.RecordFactorExposure(ctx, portfolio, prices); err != nil {
+		s.logger.Warn("Failed to record factor exposure", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+	}
+	return snapshot, nil
+}
+
+// Evaluate compares a portfolio's return over a rule's window, from its
+// snapshots, with the benchmark's return from stored daily closes. It does
+// not alert; see EvaluateAll.
+func (s *BenchmarkService) Evaluate(ctx context.Context, ruleID int) (*models.BenchmarkComparison, error) {
+	rule, err := s.portfolios.repo.GetBenchmarkRule(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	return s.evaluate(ctx, rule, time.Now().UTC())
+}
+
+func (s *BenchmarkService) evaluate(ctx context.Context, rule *models.BenchmarkRule, asOf time.Time) (*models.BenchmarkComparison, error) {
+	end, err := s.portfolios.repo.GetSnapshotOnOrBefore(ctx, rule.PortfolioID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	if end == nil {
+		return nil, fmt.Errorf("%w: portfolio %d has no snapshots", domain.ErrInsufficientHistory, rule.PortfolioID)
+	}
+	start, err := s.portfolios.repo.GetSnapshotOnOrBefore(ctx, rule.PortfolioID, end.SnapshotDate.AddDate(0, 0, -rule.WindowDays))
+	if err != nil {
+		return nil, err
+	}
+	if start == nil {
+		return nil, fmt.Errorf("%w: portfolio %d has no snapshot %d days back", domain.ErrInsufficientHistory, rule.PortfolioID, rule.WindowDays)
+	}
+
+	benchmarkStart, err := s.portfolios.repo.GetCloseOnOrBefore(ctx, rule.Benchmark, start.SnapshotDate)
+	if err != nil {
+		return nil, err
+	}
+	benchmarkEnd, err := s.portfolios.repo.GetCloseOnOrBefore(ctx, rule.Benchmark, end.SnapshotDate)
+	if err != nil {
+		return nil, err
+	}
+	if benchmarkEnd <= 0 {
+		return nil, fmt.Errorf("%w: no %s closes", domain.ErrInsufficientHistory, rule.Benchmark)
+	}
+
+	// The snapshots in between chain the return, net of the cash moved
+	snapshots, err := s.portfolios.repo.GetSnapshotsBetween(ctx, rule.PortfolioID, start.SnapshotDate, end.SnapshotDate.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	cash, err := s.portfolios.repo.GetCashTransactionsBetween(ctx, rule.PortfolioID, start.CreatedAt, end.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison, err := s.portfolios.domain.CompareToBenchmark(rule, snapshots, cash, benchmarkStart, benchmarkEnd)
+	if err != nil {
+		return nil, err
+	}
+	return &comparison, nil
+}
+
+// Simulate compares a portfolio's value over its last days daily snapshots
+// with what it would have been worth had its cash flows and trades gone into
+// a benchmark instead. An empty benchmark uses the portfolio's benchmark
+// setting.
+func (s *BenchmarkService) Simulate(ctx context.Context, portfolioID int, benchmark string, days int) (*models.BenchmarkSimulation, error) {
+	portfolio, err := s.portfolios.repo.GetPortfolioByID(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	if benchmark == "" {
+		settings, err := s.portfolios.settingsFor(ctx, portfolio)
+		if err != nil {
+			return nil, err
+		}
+		benchmark = settings.Benchmark
+	}
+	benchmark = symbols.Normalize(benchmark)
+	if err := symbols.Validate(benchmark); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidBenchmark, err)
+	}
+
+	snapshots, err := s.portfolios.repo.GetRecentSnapshots(ctx, portfolioID, days+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("%w: portfolio %d has no snapshots", domain.ErrInsufficientHistory, portfolioID)
+	}
+	first, last := snapshots[0], snapshots[len(snapshots)-1]
+
+	cash, err := s.portfolios.repo.GetCashTransactionsBetween(ctx, portfolioID, first.CreatedAt, last.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	trades, err := s.portfolios.repo.GetFilledTradesByPortfolioID(ctx, portfolioID, first.CreatedAt, last.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	// From a week back, for a close on or before the first snapshot's day
+	bars, err := s.portfolios.repo.GetDailyBars(ctx, []string{benchmark}, first.SnapshotDate.AddDate(0, 0, -7), last.SnapshotDate)
+	if err != nil {
+		return nil, err
+	}
+
+	simulation, err := s.portfolios.domain.SimulateBenchmark(portfolioID, benchmark, snapshots, cash, trades, bars[benchmark])
+	if err != nil {
+		return nil, err
+	}
+	return &simulation, nil
+}
+
+// EvaluateAll snapshots every active portfolio, then evaluates every active
+// rule, alerting for lagging portfolios at most once per rule window. It
+// returns how many rules were evaluated and how many alerted. Portfolios and
+// rules that fail are logged and skipped.
+func (s *BenchmarkService) EvaluateAll(ctx context.Context) (int, int, error) {
+	portfolioIDs, err := s.portfolios.repo.ListActivePortfolioIDs(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, portfolioID := range portfolioIDs {
+		if _, err := s.Snapshot(ctx, portfolioID); err != nil {
+			s.logger.Error("Failed to snapshot portfolio", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		}
+	}
+
+	rules, err := s.portfolios.repo.ListActiveBenchmarkRules(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	now := time.Now().UTC()
+	evaluated, alerted := 0, 0
+	for i := range rules {
+		rule := &rules[i]
+		comparison, err := s.evaluate(ctx, rule, now)
+		if err != nil {
+			if errors.Is(err, domain.ErrInsufficientHistory) {
+				s.logger.Debug("Skipping benchmark rule", zap.Error(err), zap.Int("rule_id", rule.ID))
+			} else {
+				s.logger.Error("Failed to evaluate benchmark rule", zap.Error(err), zap.Int("rule_id", rule.ID))
+			}
+			continue
+		}
+		evaluated++
+
+		// One alert per window, so a lagging portfolio is not reported daily
+		if !comparison.Lagging || (rule.LastTriggeredAt != nil && now.Sub(*rule.LastTriggeredAt) < time.Duration(rule.WindowDays)*24*time.Hour) {
+			continue
+		}
+		if err := s.portfolios.repo.MarkBenchmarkRuleTriggered(ctx, rule.ID, now); err != nil {
+			continue
+		}
+		s.alert(ctx, rule, comparison)
+		alerted++
+	}
+
+	return evaluated, alerted, nil
+}
+
+// alert notifies the portfolio owner and publishes a risk alert event for a
+// portfolio lagging its benchmark. Failures are logged.
+func (s *BenchmarkService) alert(ctx context.Context, rule *models.BenchmarkRule, comparison *models.BenchmarkComparison) {
+	data := map[string]interface{}{
+		"portfolio_id":     rule.PortfolioID,
+		"rule_id":          rule.ID,
+		"benchmark":        rule.Benchmark,
+		"window_days":      rule.WindowDays,
+		"portfolio_return": comparison.PortfolioReturn,
+		"benchmark_return": comparison.BenchmarkReturn,
+		"relative_return":  comparison.RelativeReturn,
+		"threshold":        rule.Threshold,
+	}
+
+	s.logger.Warn("Portfolio lagging its benchmark",
+		zap.Int("portfolio_id", rule.PortfolioID),
+		zap.Int("rule_id", rule.ID),
+		zap.String("benchmark", rule.Benchmark),
+		zap.Float64("relative_return", comparison.RelativeReturn),
+		zap.Float64("threshold", rule.Threshold))
+
+	if s.redis != nil {
+		event := models.Event{
+			Type:      "benchmark_lagging",
+			Source:    "portfolio-service",
+			Data:      data,
+			Timestamp: time.Now(),
+		}
+		if err := s.redis.PublishEvent(ctx, models.ChannelRiskAlerts, event); err != nil {
+			s.logger.Warn("Failed to publish benchmark alert", zap.Error(err))
+		}
+	}
+
+	if s.queue != nil {
+		portfolio, err := s.portfolios.repo.GetPortfolioByID(ctx, rule.PortfolioID)
+		if err != nil {
+			s.logger.Warn("Failed to get portfolio owner for benchmark alert", zap.Error(err))
+			return
+		}
+		if !s.portfolios.notifies(ctx, portfolio.UserID, portfolio.ID, "benchmark_lagging") {
+			return
+		}
+		if _, err := s.queue.EnqueueNotification(portfolio.UserID, "benchmark_lagging", "", "", data, nil); err != nil {
+			s.logger.Warn("Failed to enqueue benchmark alert", zap.Error(err))
+		}
+	}
+}
+
+// RunDailySchedule snapshots portfolios and evaluates benchmark rules at
+// hour (UTC) every day until ctx is cancelled
+func (s *BenchmarkService) RunDailySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		evaluated, alerted, err := s.EvaluateAll(ctx)
+		if err != nil {
+			s.logger.Error("Failed to run daily benchmark checks", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Daily benchmark checks completed", zap.Int("rules", evaluated), zap.Int("alerted", alerted))
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// summaryTag narrows a holding's symbol tag to the portfolio summaries
+// valued at its price
+const summaryTag = "summary"
+
+// CachedSummary is a portfolio summary with the entity tag of its content
+type CachedSummary struct {
+	Summary models.PortfolioSummary `json:"summary"`
+	ETag    string                  `json:"etag"`
+}
+
+// SetCache sets the Redis client whose caches derived from a portfolio are
+// invalidated when the portfolio changes, and caches portfolio summaries in
+// it for summaryTTL. Without a client nothing is cached or invalidated.
+func (s *PortfolioService) SetCache(client *redis.Client, summaryTTL time.Duration) {
+	s.cache = client
+	s.summaryTTL = summaryTTL
+}
+
+// GetCachedSummary returns a portfolio's cached summary, or nil when it is
+// not cached
+func (s *PortfolioService) GetCachedSummary(ctx context.Context, portfolioID int) *CachedSummary {
+	if s.cache == nil || s.summaryTTL <= 0 {
+		return nil
+	}
+	var cached CachedSummary
+	if err := s.cache.GetCache(ctx, redis.PortfolioKey(portfolioID, summaryTag), &cached); err != nil {
+		return nil
+	}
+	return &cached
+}
+
+// SummarizePortfolio summarizes a loaded portfolio at the prices given and
+// caches the summary. It stays cached until the portfolio trades, a holding's
+// price moves materially (see SummaryInvalidator) or it expires.
+func (s *PortfolioService) SummarizePortfolio(ctx context.Context, portfolio *models.Portfolio, currentPrices, previousDayPrices map[string]float64) *CachedSummary {
+	summary := s.domain.CalculatePortfolioSummary(portfolio, currentPrices, previousDayPrices)
+	data, _ := json.Marshal(summary) // Plain numbers always marshal
+	hash := sha256.Sum256(data)
+	cached := &CachedSummary{Summary: summary, ETag: strconv.Quote(hex.EncodeToString(hash[:8]))}
+
+	if s.cache == nil || s.summaryTTL <= 0 {
+		return cached
+	}
+	tags := []string{redis.PortfolioTag(portfolio.ID), redis.UserTag(portfolio.UserID)}
+	for _, position := range portfolio.Positions {
+		tags = append(tags, redis.SymbolTag(position.Symbol, summaryTag))
+	}
+	if err := s.cache.SetCacheTagged(ctx, redis.PortfolioKey(portfolio.ID, summaryTag), cached, s.summaryTTL, tags...); err != nil {
+		s.logger.Warn("Failed to cache portfolio summary", zap.Error(err), zap.Int("portfolio_id", portfolio.ID))
+	}
+	return cached
+}
+
+// invalidateCaches deletes every cache tagged with the portfolio, such as
+// its summary and risk exposure. Failures are logged; the caches expire
+// regardless.
+func (s *PortfolioService) invalidateCaches(ctx context.Context, portfolioID int) {
+	if s.cache == nil {
+		return
+	}
+	deleted, err := s.cache.InvalidateTags(ctx, redis.PortfolioTag(portfolioID))
+	if err != nil {
+		s.logger.Warn("Failed to invalidate portfolio caches", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return
+	}
+	if deleted > 0 {
+		s.logger.Debug("Portfolio caches invalidated", zap.Int("portfolio_id", portfolioID), zap.Int64("keys", deleted))
+	}
+}
+
+// SummaryInvalidator invalidates the cached summaries of the portfolios
+// holding a symbol once its price has moved materially since it last did
+type SummaryInvalidator struct {
+	redis   *redis.Client
+	minMove float64
+	logger  *zap.Logger
+
+	reference map[string]float64 // Price of each symbol at its last invalidation
+}
+
+// NewSummaryInvalidator creates a summary invalidator. Price moves smaller
+// than minMove, a fraction of the price at the last invalidation, don't
+// invalidate.
+func NewSummaryInvalidator(redisClient *redis.Client, minMove float64, logger *zap.Logger) *SummaryInvalidator {
+	return &SummaryInvalidator{
+		redis:     redisClient,
+		minMove:   minMove,
+		logger:    logger,
+		reference: make(map[string]float64),
+	}
+}
+
+// Run applies each price snapshot published on the conflated price channel
+// until ctx is cancelled
+func (i *SummaryInvalidator) Run(ctx context.Context) {
+	pubsub := i.redis.SubscribeToEvents(ctx, models.ChannelPriceSnapshots)
+	defer pubsub.Close()
+
+	updates := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-updates:
+			if !ok {
+				return
+			}
+
+			var update models.PriceUpdateEvent
+			if err := json.Unmarshal([]byte(message.Payload), &update); err != nil {
+				i.logger.Warn("Failed to decode price update", zap.Error(err))
+				continue
+			}
+			i.apply(ctx, update.Symbol, update.Price)
+		}
+	}
+}
+
+// apply invalidates the summaries holding symbol when price has moved
+// materially. The first price seen of a symbol becomes its reference, since
+// summaries cached earlier expire soon enough.
+func (i *SummaryInvalidator) apply(ctx context.Context, symbol string, price float64) {
+	reference, ok := i.reference[symbol]
+	if !ok || reference <= 0 {
+		i.reference[symbol] = price
+		return
+	}
+	if math.Abs(price-reference)/reference < i.minMove {
+		return
+	}
+
+	deleted, err := i.redis.InvalidateTags(ctx, redis.SymbolTag(symbol, summaryTag))
+	if err != nil {
+		i.logger.Warn("Failed to invalidate portfolio summaries", zap.Error(err), zap.String("symbol", symbol))
+		return
+	}
+	i.reference[symbol] = price
+	if deleted > 0 {
+		i.logger.Debug("Portfolio summaries invalidated on price move", zap.String("symbol", symbol),
+			zap.Float64("price", price), zap.Int64("summaries", deleted))
+	}
+}
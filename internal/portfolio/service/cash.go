@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+// Cash Management Operations
+
+// RecordCashTransaction deposits cash into or withdraws it from a portfolio
+// and appends the entry to its cash ledger. Withdrawals are limited to the
+// cash not reserved for pending buys or margin.
+func (s *PortfolioService) RecordCashTransaction(ctx context.Context, portfolioID int, txType string, amount float64, note string) (*models.CashTransaction, error) {
+	if txType != models.CashDeposit && txType != models.CashWithdrawal {
+		return nil, fmt.Errorf("%w: type must be %s or %s", domain.ErrInvalidCashTransaction, models.CashDeposit, models.CashWithdrawal)
+	}
+	if err := s.checkCashMovable(ctx, portfolioID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	portfolio, err := s.repo.GetPortfolioForUpdateTx(ctx, tx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.applyCashTransaction(ctx, tx, portfolio, txType, amount, note, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit cash transaction: %w", err)
+	}
+	s.invalidateCaches(ctx, portfolioID)
+
+	s.logger.Info("Cash transaction recorded",
+		zap.Int("portfolio_id", portfolioID),
+		zap.String("type", txType),
+		zap.Float64("amount", amount),
+		zap.Float64("balance_after", entry.BalanceAfter))
+	return entry, nil
+}
+
+// AdjustCash sets a portfolio's cash to cash, to the cent, by depositing or
+// withdrawing the difference as RecordCashTransaction would. It returns the
+// ledger entry, or nil when the cash is already cash.
+func (s *PortfolioService) AdjustCash(ctx context.Context, portfolioID int, cash float64, note string) (*models.CashTransaction, error) {
+	if err := s.checkCashMovable(ctx, portfolioID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	portfolio, err := s.repo.GetPortfolioForUpdateTx(ctx, tx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	txType, amount := models.CashDeposit, math.Round((cash-portfolio.Cash)*100)/100
+	if amount < 0 {
+		txType, amount = models.CashWithdrawal, -amount
+	}
+	if amount == 0 {
+		return nil, nil
+	}
+	entry, err := s.applyCashTransaction(ctx, tx, portfolio, txType, amount, note, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit cash adjustment: %w", err)
+	}
+	s.invalidateCaches(ctx, portfolioID)
+
+	s.logger.Info("Cash adjusted",
+		zap.Int("portfolio_id", portfolioID),
+		zap.String("type", txType),
+		zap.Float64("amount", amount),
+		zap.Float64("balance_after", entry.BalanceAfter))
+	return entry, nil
+}
+
+// TransferCash moves cash between two portfolios of the same owner and base
+// currency, recording it in both ledgers under one transfer ID. It returns
+// the outgoing entry, then the incoming one.
+func (s *PortfolioService) TransferCash(ctx context.Context, fromID, toID int, amount float64, note string) ([]models.CashTransaction, error) {
+	if fromID == toID {
+		return nil, fmt.Errorf("%w: cannot transfer to the same portfolio", domain.ErrInvalidCashTransaction)
+	}
+	for _, portfolioID := range []int{fromID, toID} {
+		if err := s.checkCashMovable(ctx, portfolioID); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock both portfolios in ID order, so opposing transfers can't deadlock
+	locked := make(map[int]*models.Portfolio, 2)
+	first, second := fromID, toID
+	if first > second {
+		first, second = second, first
+	}
+	for _, portfolioID := range []int{first, second} {
+		portfolio, err := s.repo.GetPortfolioForUpdateTx(ctx, tx, portfolioID)
+		if err != nil {
+			return nil, err
+		}
+		locked[portfolioID] = portfolio
+	}
+	from, to := locked[fromID], locked[toID]
+
+	if from.UserID != to.UserID {
+		return nil, fmt.Errorf("%w: portfolios %d and %d belong to different users", domain.ErrInvalidCashTransaction, fromID, toID)
+	}
+	fromSettings, err := s.settingsFor(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	toSettings, err := s.settingsFor(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	if fromSettings.BaseCurrency != toSettings.BaseCurrency {
+		return nil, fmt.Errorf("%w: portfolio %d is in %s and portfolio %d in %s", domain.ErrInvalidCashTransaction,
+			fromID, fromSettings.BaseCurrency, toID, toSettings.BaseCurrency)
+	}
+
+	transferID := uuid.New().String()
+	out, err := s.applyCashTransaction(ctx, tx, from, models.CashTransferOut, amount, note, &toID, transferID)
+	if err != nil {
+		return nil, err
+	}
+	in, err := s.applyCashTransaction(ctx, tx, to, models.CashTransferIn, amount, note, &fromID, transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit cash transfer: %w", err)
+	}
+	s.invalidateCaches(ctx, fromID)
+	s.invalidateCaches(ctx, toID)
+
+	s.logger.Info("Cash transferred",
+		zap.Int("from_portfolio_id", fromID),
+		zap.Int("to_portfolio_id", toID),
+		zap.Float64("amount", amount),
+		zap.String("transfer_id", transferID))
+	return []models.CashTransaction{*out, *in}, nil
+}
+
+// GetCashTransactions retrieves a page of a portfolio's cash ledger
+func (s *PortfolioService) GetCashTransactions(ctx context.Context, portfolioID int, page pagination.Request) ([]models.CashTransaction, pagination.Result, error) {
+	if _, err := s.repo.GetPortfolioByID(ctx, portfolioID); err != nil {
+		return nil, pagination.Result{}, err
+	}
+	return s.repo.GetCashTransactions(ctx, portfolioID, page)
+}
+
+// checkCashMovable rejects cash moving in or out of a portfolio entered in a
+// competition that hasn't finished, whose entrants trade from the same
+// starting cash
+func (s *PortfolioService) checkCashMovable(ctx context.Context, portfolioID int) error {
+	competition, err := s.competing(ctx, portfolioID)
+	if err != nil {
+		return err
+	}
+	if competition != nil {
+		return fmt.Errorf("%w: portfolio %d is entered in competition %q", domain.ErrInvalidCashTransaction, portfolioID, competition.Name)
+	}
+	return nil
+}
+
+// applyCashTransaction applies a ledger entry to a portfolio locked in tx,
+// saving the new balance, the entry and an audit event
+func (s *PortfolioService) applyCashTransaction(ctx context.Context, tx *sql.Tx, portfolio *models.Portfolio, txType string, amount float64, note string, counterpartyID *int, transferID string) (*models.CashTransaction, error) {
+	pendingBuys, err := s.repo.GetPendingBuyValueTx(ctx, tx, portfolio.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *portfolio
+	entry, err := s.domain.ApplyCashTransaction(portfolio, txType, amount, pendingBuys, note)
+	if err != nil {
+		return nil, err
+	}
+	entry.CounterpartyPortfolioID = counterpartyID
+	entry.TransferID = transferID
+	entry.RequestID = requestctx.RequestID(ctx)
+
+	if err := s.repo.UpdatePortfolioTx(ctx, tx, portfolio); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateCashTransactionTx(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+	if err := s.recordAudit(ctx, tx, newAuditEvent(ctx, portfolio.ID, models.AuditEntityPortfolio, portfolio.ID, models.AuditActionUpdate, &before, portfolio)); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/broker"
+	"hedge-fund/pkg/shared/auth"
+	"hedge-fund/pkg/shared/models"
+)
+
+// CompetitionService runs trading competitions between paper portfolios.
+// Standings are scored daily from the portfolio snapshots taken by the
+// benchmark checks, so scoring should run after them.
+type CompetitionService struct {
+	portfolios *PortfolioService
+	logger     *zap.Logger
+}
+
+func NewCompetitionService(portfolios *PortfolioService, logger *zap.Logger) *CompetitionService {
+	return &CompetitionService{
+		portfolios: portfolios,
+		logger:     logger,
+	}
+}
+
+// CreateCompetition validates and saves a competition
+func (s *CompetitionService) CreateCompetition(ctx context.Context, competition *models.Competition) error {
+	if err := domain.ValidateCompetition(competition); err != nil {
+		return err
+	}
+	if err := s.portfolios.repo.CreateCompetition(ctx, competition); err != nil {
+		return err
+	}
+	competition.Status = domain.CompetitionStatus(competition, time.Now())
+
+	s.logger.Info("Competition created",
+		zap.Int("competition_id", competition.ID),
+		zap.String("name", competition.Name),
+		zap.Time("start_date", competition.StartDate),
+		zap.Time("end_date", competition.EndDate))
+	return nil
+}
+
+// GetCompetition returns a competition
+func (s *CompetitionService) GetCompetition(ctx context.Context, competitionID int) (*models.Competition, error) {
+	competition, err := s.portfolios.repo.GetCompetition(ctx, competitionID)
+	if err != nil {
+		return nil, err
+	}
+	competition.Status = domain.CompetitionStatus(competition, time.Now())
+	return competition, nil
+}
+
+// ListCompetitions returns every competition, latest start first
+func (s *CompetitionService) ListCompetitions(ctx context.Context) ([]models.Competition, error) {
+	competitions, err := s.portfolios.repo.ListCompetitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range competitions {
+		competitions[i].Status = domain.CompetitionStatus(&competitions[i], now)
+	}
+	return competitions, nil
+}
+
+// Enroll enters a paper portfolio in a competition. It must hold no
+// positions and exactly the starting cash, and enrollment closes after the
+// start date.
+func (s *CompetitionService) Enroll(ctx context.Context, competitionID, portfolioID int) (*models.CompetitionEntry, error) {
+	competition, err := s.portfolios.repo.GetCompetition(ctx, competitionID)
+	if err != nil {
+		return nil, err
+	}
+	portfolio, err := s.portfolios.repo.GetPortfolioByID(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.CanAccess(ctx, portfolio.UserID) {
+		return nil, fmt.Errorf("%w: %d", domain.ErrPortfolioNotFound, portfolioID)
+	}
+
+	venue, _, err := s.portfolios.venueFor(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if venue.Name() != broker.Paper {
+		return nil, fmt.Errorf("%w: portfolio %d trades through a live broker", domain.ErrInvalidEntry, portfolioID)
+	}
+	if err := domain.CheckEntry(competition, portfolio, time.Now()); err != nil {
+		return nil, err
+	}
+
+	entry := &models.CompetitionEntry{
+		CompetitionID: competitionID,
+		PortfolioID:   portfolioID,
+		UserID:        portfolio.UserID,
+	}
+	if err := s.portfolios.repo.CreateCompetitionEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Portfolio entered competition",
+		zap.Int("competition_id", competitionID),
+		zap.Int("portfolio_id", portfolioID),
+		zap.Int("user_id", portfolio.UserID))
+	return entry, nil
+}
+
+// Withdraw removes a portfolio from a competition that has not started
+func (s *CompetitionService) Withdraw(ctx context.Context, competitionID, portfolioID int) error {
+	competition, err := s.portfolios.repo.GetCompetition(ctx, competitionID)
+	if err != nil {
+		return err
+	}
+	if domain.CompetitionStatus(competition, time.Now()) != models.CompetitionUpcoming {
+		return fmt.Errorf("%w: %s has already started", domain.ErrInvalidEntry, competition.Name)
+	}
+	return s.portfolios.repo.DeleteCompetitionEntry(ctx, competitionID, portfolioID)
+}
+
+// Leaderboard returns a competition and its latest standings, best first.
+// Standings are empty until the competition is first scored.
+func (s *CompetitionService) Leaderboard(ctx context.Context, competitionID int) (*models.Competition, []models.CompetitionStanding, error) {
+	competition, err := s.GetCompetition(ctx, competitionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	standings, err := s.portfolios.repo.GetLatestStandings(ctx, competitionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return competition, standings, nil
+}
+
+// Score records the standings on asOf (UTC day) of every running
+// competition, and the final standings of competitions that ended without
+// them, returning how many competitions were scored. Each entry is valued at
+// its latest snapshot on or before the day; entries without one are still
+// worth the starting cash.
+func (s *CompetitionService) Score(ctx context.Context, asOf time.Time) (int, error) {
+	asOf = asOf.UTC()
+	asOf = time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+
+	competitions, err := s.portfolios.repo.GetCompetitionsToScore(ctx, asOf)
+	if err != nil {
+		return 0, err
+	}
+
+	scored := 0
+	for i := range competitions {
+		competition := &competitions[i]
+		if err := s.score(ctx, competition, asOf); err != nil {
+			s.logger.Error("Failed to score competition", zap.Error(err), zap.Int("competition_id", competition.ID))
+			continue
+		}
+		scored++
+	}
+	return scored, nil
+}
+
+func (s *CompetitionService) score(ctx context.Context, competition *models.Competition, asOf time.Time) error {
+	day := asOf
+	if day.After(competition.EndDate) {
+		day = competition.EndDate
+	}
+
+	entries, err := s.portfolios.repo.GetCompetitionEntries(ctx, competition.ID)
+	if err != nil {
+		return err
+	}
+
+	standings := make([]models.CompetitionStanding, len(entries))
+	for i, entry := range entries {
+		value := competition.StartingCash
+		snapshot, err := s.portfolios.repo.GetSnapshotOnOrBefore(ctx, entry.PortfolioID, day)
+		if err != nil {
+			return err
+		}
+		if snapshot != nil && !snapshot.SnapshotDate.Before(competition.StartDate) {
+			value = snapshot.TotalValue
+		}
+
+		standings[i] = models.CompetitionStanding{
+			CompetitionID: competition.ID,
+			PortfolioID:   entry.PortfolioID,
+			AsOf:          day,
+			TotalValue:    value,
+			// Rounded as stored, so equal returns rank equally
+			ReturnPercent: math.Round((value/competition.StartingCash-1)*100*1e4) / 1e4,
+		}
+	}
+	domain.RankStandings(standings)
+
+	if err := s.portfolios.repo.SaveStandings(ctx, standings); err != nil {
+		return err
+	}
+
+	s.logger.Info("Competition scored",
+		zap.Int("competition_id", competition.ID),
+		zap.Time("as_of", day),
+		zap.Int("entries", len(standings)))
+	return nil
+}
+
+// RunDailySchedule scores competitions at hour (UTC) every day until ctx is
+// cancelled
+func (s *CompetitionService) RunDailySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		scored, err := s.Score(ctx, time.Now())
+		if err != nil {
+			s.logger.Error("Failed to score competitions", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Daily competition scoring completed", zap.Int("competitions", scored))
+	}
+}
+
+// checkCompetition enforces the rules of the competition a portfolio is
+// entered in, if any, on a trade at price
+func (s *PortfolioService) checkCompetition(ctx context.Context, portfolio *models.Portfolio, trade *models.Trade, price float64) error {
+	competition, tradesToday, err := s.competitionFor(ctx, portfolio.ID)
+	if err != nil || competition == nil {
+		return err
+	}
+	return domain.CheckCompetitionTrade(competition, portfolio, trade, price, tradesToday, time.Now())
+}
+
+// competing returns the competition a portfolio is entered in if it hasn't
+// finished, or nil. Entrants' standings must come from their trades alone.
+func (s *PortfolioService) competing(ctx context.Context, portfolioID int) (*models.Competition, error) {
+	competition, err := s.repo.GetCompetitionByPortfolioID(ctx, portfolioID)
+	if err != nil || competition == nil {
+		return nil, err
+	}
+	if domain.CompetitionStatus(competition, time.Now()) == models.CompetitionFinished {
+		return nil, nil
+	}
+	return competition, nil
+}
+
+// competitionFor returns the competition a portfolio is entered in, or nil,
+// and how many trades the portfolio has placed today (UTC) when the
+// competition limits them
+func (s *PortfolioService) competitionFor(ctx context.Context, portfolioID int) (*models.Competition, int, error) {
+	competition, err := s.repo.GetCompetitionByPortfolioID(ctx, portfolioID)
+	if err != nil || competition == nil || competition.Rules.MaxTradesPerDay == 0 {
+		return competition, 0, err
+	}
+
+	now := time.Now().UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	tradesToday, err := s.repo.CountTradesSince(ctx, portfolioID, midnight)
+	if err != nil {
+		return nil, 0, err
+	}
+	return competition, tradesToday, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// SetTradeConfirmations announces every filled trade: a TradeExecutedEvent is
+// published through publisher and a trade confirmation webhook is enqueued
+// on webhooks for the user's endpoints. Either may be nil.
+func (s *PortfolioService) SetTradeConfirmations(publisher *redis.Client, webhooks *queue.Manager) {
+	s.publisher = publisher
+	s.webhooks = webhooks
+}
+
+// confirmTrade announces a filled trade. Failures are logged, as the trade
+// stands whether or not it is announced.
+func (s *PortfolioService) confirmTrade(ctx context.Context, userID int, trade *models.Trade) {
+	executedAt := trade.CreatedAt
+	if trade.ExecutedAt != nil {
+		executedAt = *trade.ExecutedAt
+	}
+	data := map[string]interface{}{
+		"trade_id":        trade.ID,
+		"portfolio_id":    trade.PortfolioID,
+		"position_id":     trade.PositionID,
+		"symbol":          trade.Symbol,
+		"asset_type":      trade.AssetType,
+		"side":            trade.Side,
+		"order_type":      trade.Type,
+		"time_in_force":   trade.TimeInForce,
+		"quantity":        trade.Quantity,
+		"multiplier":      trade.Multiplier,
+		"price":           trade.Price,
+		"notional":        trade.Value(trade.Price),
+		"fees":            trade.Fees,
+		"fee_items":       trade.FeeItems,
+		"broker":          trade.Broker,
+		"broker_order_id": trade.BrokerOrderID,
+		"trigger_reason":  trade.TriggerReason,
+		"executed_at":     executedAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	if s.publisher != nil {
+		event := models.TradeExecutedEvent{
+			Event: models.Event{
+				Type:      models.WebhookEventTradeExecuted,
+				Source:    "portfolio-service",
+				Data:      data,
+				Timestamp: time.Now(),
+			},
+			TradeID:  trade.ID,
+			UserID:   userID,
+			Symbol:   trade.Symbol,
+			Quantity: trade.Quantity,
+			Price:    trade.Price,
+			Side:     trade.Side,
+		}
+		if err := s.publisher.PublishEvent(ctx, models.ChannelTradeEvents, event); err != nil {
+			s.logger.Warn("Failed to publish trade event", zap.Error(err), zap.Int("trade_id", trade.ID))
+		}
+	}
+
+	if s.webhooks != nil {
+		if _, err := s.webhooks.EnqueueWebhook(userID, models.WebhookEventTradeExecuted, data); err != nil {
+			s.logger.Warn("Failed to enqueue trade webhook", zap.Error(err), zap.Int("trade_id", trade.ID))
+		}
+	}
+}
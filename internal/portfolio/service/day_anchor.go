@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// DayAnchorService rolls the prices positions' day P&L is measured from at
+// each market open
+type DayAnchorService struct {
+	portfolios *PortfolioService
+	market     MarketData
+	redis      *redis.Client
+	logger     *zap.Logger
+}
+
+// NewDayAnchorService creates a day anchor service. Official closes are read
+// from market and day opens from the stored daily bars. Cached summaries are
+// invalidated in redisClient, which may be nil.
+func NewDayAnchorService(portfolios *PortfolioService, market MarketData, redisClient *redis.Client, logger *zap.Logger) *DayAnchorService {
+	return &DayAnchorService{
+		portfolios: portfolios,
+		market:     market,
+		redis:      redisClient,
+		logger:     logger,
+	}
+}
+
+// RollAnchors sets every position's previous close to its symbol's latest
+// official close and its day open to the session's open at asOf, returning
+// how many positions were rolled. Symbols without a close keep their anchors.
+func (s *DayAnchorService) RollAnchors(ctx context.Context, asOf time.Time) (int64, error) {
+	held, err := s.portfolios.repo.GetHeldSymbols(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(held) == 0 {
+		return 0, nil
+	}
+
+	closes, err := s.market.GetCurrentPrices(held)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get closing prices: %w", err)
+	}
+	opens, err := s.portfolios.repo.GetDayOpens(ctx, held, asOf)
+	if err != nil {
+		return 0, err
+	}
+
+	anchors := domain.DayAnchors(held, closes, opens)
+	if missing := len(held) - len(anchors); missing > 0 {
+		s.logger.Warn("Symbols without a close keep their day anchors", zap.Int("symbols", missing))
+	}
+	rolled, err := s.portfolios.repo.RollDayAnchors(ctx, anchors, asOf)
+	if err != nil {
+		return 0, err
+	}
+
+	// Cached summaries hold day P&L measured from the old anchors
+	if s.redis != nil {
+		tags := make([]string, len(anchors))
+		for i, anchor := range anchors {
+			tags[i] = redis.SymbolTag(anchor.Symbol, summaryTag)
+		}
+		if _, err := s.redis.InvalidateTags(ctx, tags...); err != nil {
+			s.logger.Warn("Failed to invalidate cached summaries", zap.Error(err))
+		}
+	}
+
+	return rolled, nil
+}
+
+// RunDailySchedule rolls the day anchors every day at hour (UTC) until ctx is
+// cancelled. The hour should fall just after the market opens.
+func (s *DayAnchorService) RunDailySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		rolled, err := s.RollAnchors(ctx, time.Now())
+		if err != nil {
+			s.logger.Error("Failed to roll day anchors", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Day anchors rolled", zap.Int64("positions", rolled))
+	}
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/broker"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Order Execution Operations
+
+const openOrderBatchSize = 200
+
+// SetBrokers replaces the execution venues orders can be routed to. The
+// registry should include the paper broker, which portfolios use by default.
+func (s *PortfolioService) SetBrokers(brokers *broker.Registry) {
+	s.brokers = brokers
+}
+
+// SetBrokerFees sets the fee schedules charged by execution venues, by
+// venue name. A venue's schedule applies to the portfolios routed to it
+// whose settings set no fees of their own.
+func (s *PortfolioService) SetBrokerFees(fees map[string]models.FeeSchedule) {
+	s.brokerFees = fees
+}
+
+// venueFor returns the venue a portfolio's orders are routed to: its linked
+// broker when the account is in live mode, otherwise the paper broker
+func (s *PortfolioService) venueFor(ctx context.Context, portfolioID int) (broker.Broker, string, error) {
+	account, err := s.repo.GetBrokerAccount(ctx, portfolioID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			venue, err := s.brokers.Get(broker.Paper)
+			return venue, "", err
+		}
+		return nil, "", err
+	}
+
+	name := broker.Paper
+	if account.TradingMode == models.TradingModeLive {
+		name = account.Broker
+	}
+	venue, err := s.brokers.Get(name)
+	if err != nil {
+		return nil, "", err
+	}
+	return venue, account.AccountID, nil
+}
+
+func newOrder(trade *models.Trade, accountID string, currentPrice float64) broker.Order {
+	order := broker.Order{
+		AccountID:      accountID,
+		Symbol:         trade.Symbol,
+		Side:           trade.Side,
+		Quantity:       trade.Quantity,
+		Type:           trade.Type,
+		ReferencePrice: currentPrice,
+		TimeInForce:    trade.TimeInForce,
+	}
+	if trade.ID != 0 {
+		order.ClientOrderID = strconv.Itoa(trade.ID)
+	}
+	if trade.Type == "limit" {
+		order.LimitPrice = currentPrice
+	}
+	return order
+}
+
+// submitLiveOrder records the trade as pending and sends it to the venue. The
+// trade ID is the client order ID, so broker statements match it back.
+func (s *PortfolioService) submitLiveOrder(ctx context.Context, portfolioID int, trade *models.Trade, currentPrice float64, venue broker.Broker, accountID string) error {
+	trade.PortfolioID = portfolioID
+	trade.Price = currentPrice
+	trade.Status = "pending"
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = s.repo.CreateTradeTx(ctx, tx, trade); err != nil {
+		return fmt.Errorf("failed to create trade record: %w", err)
+	}
+	err = s.recordAudit(ctx, tx, newAuditEvent(ctx, portfolioID, models.AuditEntityTrade, trade.ID, models.AuditActionCreate, nil, trade))
+	if err != nil {
+		return err
+	}
+	if err = s.recordJobStep(ctx, tx, tradeStep(portfolioID, trade), trade.ID); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	status, err := venue.SubmitOrder(ctx, newOrder(trade, accountID, currentPrice))
+	if err != nil {
+		s.logger.Warn("Order rejected by venue",
+			zap.Error(err),
+			zap.String("broker", venue.Name()),
+			zap.Int("trade_id", trade.ID))
+		trade.Status = "rejected"
+		if updateErr := s.repo.UpdateTradeStatus(ctx, trade.ID, trade.Status); updateErr != nil {
+			s.logger.Error("Failed to reject trade", zap.Error(updateErr), zap.Int("trade_id", trade.ID))
+		}
+		s.recordTradeEvent(ctx, nil, newTradeEvent(ctx, portfolioID, trade, models.TradeEventRejected, currentPrice, err))
+		return fmt.Errorf("order rejected by %s: %w", venue.Name(), err)
+	}
+
+	trade.Broker = venue.Name()
+	trade.BrokerOrderID = status.BrokerOrderID
+	if err := s.repo.SetTradeBrokerOrder(ctx, trade.ID, trade.Broker, trade.BrokerOrderID); err != nil {
+		// The order is live at the venue; without its ID the fill can only be matched on the statement
+		s.logger.Error("Failed to record broker order", zap.Error(err),
+			zap.Int("trade_id", trade.ID), zap.String("broker_order_id", status.BrokerOrderID))
+	}
+
+	s.logger.Info("Order routed to venue",
+		zap.Int("trade_id", trade.ID),
+		zap.Int("portfolio_id", portfolioID),
+		zap.String("broker", venue.Name()),
+		zap.String("broker_order_id", status.BrokerOrderID),
+		zap.String("symbol", trade.Symbol),
+		zap.String("side", trade.Side),
+		zap.Float64("quantity", trade.Quantity))
+
+	if status.Terminal() {
+		return s.applyOrderStatus(ctx, trade, status)
+	}
+	return nil
+}
+
+// SyncOpenOrders polls the venues of pending live orders and applies fills,
+// cancellations and rejections. It returns how many orders were settled.
+func (s *PortfolioService) SyncOpenOrders(ctx context.Context) (int, error) {
+	trades, err := s.repo.GetOpenBrokerTrades(ctx, openOrderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for i := range trades {
+		trade := &trades[i]
+		venue, err := s.brokers.Get(trade.Broker)
+		if err != nil {
+			s.logger.Warn("Cannot sync order", zap.Error(err), zap.Int("trade_id", trade.ID))
+			continue
+		}
+
+		status, err := venue.GetOrder(ctx, trade.BrokerOrderID)
+		if err != nil {
+			s.logger.Warn("Failed to get order status", zap.Error(err),
+				zap.Int("trade_id", trade.ID), zap.String("broker", trade.Broker))
+			continue
+		}
+		if !status.Terminal() {
+			continue
+		}
+
+		if err := s.applyOrderStatus(ctx, trade, status); err != nil {
+			s.logger.Error("Failed to apply order status", zap.Error(err), zap.Int("trade_id", trade.ID))
+			continue
+		}
+		settled++
+	}
+
+	return settled, nil
+}
+
+// RunOrderSync calls SyncOpenOrders every interval until ctx is cancelled
+func (s *PortfolioService) RunOrderSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if settled, err := s.SyncOpenOrders(ctx); err != nil {
+				s.logger.Error("Failed to sync open orders", zap.Error(err))
+			} else if settled > 0 {
+				s.logger.Info("Synced open orders", zap.Int("settled", settled))
+			}
+		}
+	}
+}
+
+// ExpireDayOrders cancels the live DAY orders placed before asOf that are
+// still open, settling any partial fill. An order the venue has not yet
+// confirmed cancelled is left to order sync. It returns how many orders were
+// settled.
+func (s *PortfolioService) ExpireDayOrders(ctx context.Context, asOf time.Time) (int, error) {
+	trades, err := s.repo.GetOpenDayTrades(ctx, asOf, openOrderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for i := range trades {
+		trade := &trades[i]
+		venue, err := s.brokers.Get(trade.Broker)
+		if err != nil {
+			s.logger.Warn("Cannot expire order", zap.Error(err), zap.Int("trade_id", trade.ID))
+			continue
+		}
+
+		if err := venue.CancelOrder(ctx, trade.BrokerOrderID); err != nil {
+			// The order may have filled since the last sync; its status says
+			s.logger.Warn("Failed to cancel day order", zap.Error(err),
+				zap.Int("trade_id", trade.ID), zap.String("broker", trade.Broker))
+		}
+		status, err := venue.GetOrder(ctx, trade.BrokerOrderID)
+		if err != nil {
+			s.logger.Warn("Failed to get order status", zap.Error(err),
+				zap.Int("trade_id", trade.ID), zap.String("broker", trade.Broker))
+			continue
+		}
+		if !status.Terminal() {
+			continue
+		}
+		if status.Status == broker.StatusCancelled && status.Reason == "" {
+			status.Reason = "day order expired at market close"
+		}
+
+		if err := s.applyOrderStatus(ctx, trade, status); err != nil {
+			s.logger.Error("Failed to apply order status", zap.Error(err), zap.Int("trade_id", trade.ID))
+			continue
+		}
+		settled++
+	}
+
+	return settled, nil
+}
+
+// RunDayOrderExpiry expires open DAY orders every day at hour (UTC) until
+// ctx is cancelled. The hour should fall after the market close.
+func (s *PortfolioService) RunDayOrderExpiry(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		if s.inMaintenance(ctx) {
+			s.logger.Warn("Skipped day order expiry during maintenance; the next run expires them")
+			continue
+		}
+		expired, err := s.ExpireDayOrders(ctx, time.Now())
+		if err != nil {
+			s.logger.Error("Failed to expire day orders", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Day orders expired", zap.Int("settled", expired))
+	}
+}
+
+// applyOrderStatus settles a pending trade from a terminal venue status. An
+// order cancelled after a partial fill is settled for the filled quantity.
+func (s *PortfolioService) applyOrderStatus(ctx context.Context, trade *models.Trade, status *broker.OrderStatus) error {
+	if status.FilledQuantity > 0 {
+		filledAt := time.Now()
+		if status.FilledAt != nil {
+			filledAt = *status.FilledAt
+		}
+		return s.fillTrade(ctx, trade, status.FilledQuantity, status.FilledPrice, status.Fees, filledAt)
+	}
+
+	final, eventType := "cancelled", models.TradeEventCancelled
+	if status.Status == broker.StatusRejected {
+		final, eventType = "rejected", models.TradeEventRejected
+	}
+	if err := s.repo.UpdateTradeStatus(ctx, trade.ID, final); err != nil {
+		return err
+	}
+	trade.Status = final
+
+	var reason error
+	if status.Reason != "" {
+		reason = fmt.Errorf("%s", status.Reason)
+	}
+	s.recordTradeEvent(ctx, nil, newTradeEvent(ctx, trade.PortfolioID, trade, eventType, trade.Price, reason))
+	return nil
+}
+
+// fillTrade applies a venue fill to the portfolio, its position and the pending trade
+func (s *PortfolioService) fillTrade(ctx context.Context, trade *models.Trade, quantity, price float64, fees *float64, filledAt time.Time) error {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locked like ExecuteTrade, so a fill never overwrites a concurrent trade's cash
+	portfolio, err := s.repo.GetPortfolioForUpdateTx(ctx, tx, trade.PortfolioID)
+	if err != nil {
+		return fmt.Errorf("failed to get portfolio: %w", err)
+	}
+	if err := s.loadSettings(ctx, portfolio); err != nil {
+		return err
+	}
+	portfolioBefore := snapshot(portfolio)
+	tradeBefore := snapshot(trade)
+
+	trade.Quantity = domain.RoundQuantity(quantity)
+	position, err := s.domain.ExecuteFill(trade, portfolio, price, fees, filledAt)
+	if err != nil {
+		return fmt.Errorf("failed to apply fill: %w", err)
+	}
+
+	if _, err = s.savePositionTx(ctx, tx, trade.PortfolioID, trade, position); err != nil {
+		return err
+	}
+	if err = s.repo.FillTradeTx(ctx, tx, trade); err != nil {
+		return err
+	}
+	if err = s.saveLotsTx(ctx, tx, trade.PortfolioID, trade); err != nil {
+		return err
+	}
+	err = s.recordAudit(ctx, tx, newAuditEvent(ctx, trade.PortfolioID, models.AuditEntityTrade, trade.ID, models.AuditActionUpdate, tradeBefore, trade))
+	if err != nil {
+		return err
+	}
+	if err = s.recordTradeEvent(ctx, tx, newTradeEvent(ctx, trade.PortfolioID, trade, models.TradeEventFilled, trade.Price, nil)); err != nil {
+		return err
+	}
+	if err = s.repo.UpdatePortfolioTx(ctx, tx, portfolio); err != nil {
+		return fmt.Errorf("failed to update portfolio: %w", err)
+	}
+	err = s.recordAudit(ctx, tx, newAuditEvent(ctx, trade.PortfolioID, models.AuditEntityPortfolio, trade.PortfolioID, models.AuditActionUpdate, portfolioBefore, portfolio))
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.Info("Live order filled",
+		zap.Int("trade_id", trade.ID),
+		zap.Int("portfolio_id", trade.PortfolioID),
+		zap.String("broker", trade.Broker),
+		zap.String("symbol", trade.Symbol),
+		zap.Float64("quantity", trade.Quantity),
+		zap.Float64("price", trade.Price),
+		zap.Float64("fees", trade.Fees))
+
+	s.invalidateCaches(ctx, trade.PortfolioID)
+	s.trackTrade(ctx, portfolio.UserID, trade, trade.Broker)
+	s.confirmTrade(ctx, portfolio.UserID, trade)
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Export Operations
+
+// ExportTrades streams a portfolio's filled trades executed in [from, to) to
+// fn, oldest first. A zero from or to leaves that end of the range open.
+func (s *PortfolioService) ExportTrades(ctx context.Context, portfolioID int, from, to time.Time, fn func(*models.Trade) error) error {
+	if _, err := s.repo.GetPortfolioByID(ctx, portfolioID); err != nil {
+		return err
+	}
+	return s.repo.StreamTrades(ctx, portfolioID, from, to, fn)
+}
+
+// ExportPositions streams a portfolio's positions to fn, by symbol
+func (s *PortfolioService) ExportPositions(ctx context.Context, portfolioID int, fn func(*models.Position) error) error {
+	if _, err := s.repo.GetPortfolioByID(ctx, portfolioID); err != nil {
+		return err
+	}
+	return s.repo.StreamPositions(ctx, portfolioID, fn)
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Factor Exposure Operations
+
+// DefaultFactorHistoryDays is the period of recorded factor exposures
+// returned when a request doesn't set one
+const DefaultFactorHistoryDays = 90
+
+// GetFactorExposure estimates a portfolio's beta to its benchmark and its
+// factor exposures at currentPrices, from the stored daily bars of its
+// holdings and the benchmark
+func (s *PortfolioService) GetFactorExposure(ctx context.Context, portfolio *models.Portfolio, currentPrices map[string]float64) (*models.FactorExposure, error) {
+	settings, err := s.settingsFor(ctx, portfolio)
+	if err != nil {
+		return nil, err
+	}
+	benchmark := symbols.Normalize(settings.Benchmark)
+
+	held := []string{benchmark}
+	for _, position := range portfolio.Positions {
+		symbol := position.Symbol
+		if contract, ok := symbols.ParseOption(symbol); ok {
+			symbol = contract.Underlying
+		}
+		held = append(held, symbol)
+	}
+
+	// Calendar days comfortably covering the trading days needed
+	now := time.Now().UTC()
+	start := now.AddDate(0, 0, -domain.FactorLookbackDays*3/2-14)
+	bars, err := s.repo.GetDailyBars(ctx, symbols.NormalizeAll(held), start, now)
+	if err != nil {
+		return nil, err
+	}
+
+	exposure := s.domain.CalculateFactorExposure(portfolio, currentPrices, bars, benchmark, now)
+	return &exposure, nil
+}
+
+// RecordFactorExposure estimates a portfolio's factor exposure at
+// currentPrices and records it for today (UTC)
+func (s *PortfolioService) RecordFactorExposure(ctx context.Context, portfolio *models.Portfolio, currentPrices map[string]float64) (*models.FactorExposure, error) {
+	exposure, err := s.GetFactorExposure(ctx, portfolio, currentPrices)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpsertFactorExposure(ctx, exposure); err != nil {
+		return nil, err
+	}
+	return exposure, nil
+}
+
+// GetFactorHistory returns a portfolio's factor exposures recorded over its
+// last days days, oldest first
+func (s *PortfolioService) GetFactorHistory(ctx context.Context, portfolioID, days int) ([]models.FactorExposure, error) {
+	if _, err := s.repo.GetPortfolioByID(ctx, portfolioID); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -days)
+	return s.repo.GetFactorExposuresSince(ctx, portfolioID, start)
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+
+	"hedge-fund/pkg/shared/flags"
+)
+
+// SetFlags gates live order routing and market order re-quoting behind
+// their feature flags. Without flags, both are always on.
+func (s *PortfolioService) SetFlags(manager *flags.Manager) {
+	s.flags = manager
+}
+
+// featureOn reports whether a gated feature is on for the user
+func (s *PortfolioService) featureOn(ctx context.Context, name string, userID int) bool {
+	return s.flags == nil || s.flags.Enabled(ctx, name, userID)
+}
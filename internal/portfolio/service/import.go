@@ -0,0 +1,471 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+const (
+	importPreviewRows      = 20  // Rows shown in a preview
+	importProgressInterval = 100 // Rows applied between progress updates
+)
+
+// ImportService imports positions and trade history exported by external
+// brokerages. An upload is previewed with a suggested column mapping, which
+// the user may correct, then applied row by row by a job.
+type ImportService struct {
+	portfolios *PortfolioService
+	queue      *queue.Manager
+	logger     *zap.Logger
+}
+
+// NewImportService creates an import service
+func NewImportService(portfolios *PortfolioService, queueManager *queue.Manager, logger *zap.Logger) *ImportService {
+	return &ImportService{
+		portfolios: portfolios,
+		queue:      queueManager,
+		logger:     logger,
+	}
+}
+
+// ImportPreview is what applying an import would do with its current mapping.
+// When the mapping is incomplete MappingError says why and no rows are read.
+type ImportPreview struct {
+	MappingError  string
+	ValidRows     int // Rows that would be imported
+	InvalidRows   int
+	DuplicateRows int
+	Rows          []domain.ImportRow   // The first rows of the file
+	Errors        []models.ImportError // Of invalid rows, the first MaxImportErrors
+}
+
+// CreateImport stores an uploaded file awaiting confirmation and previews it.
+// Without a mapping, columns are mapped by their headers.
+func (s *ImportService) CreateImport(ctx context.Context, portfolioID int, kind, format, filename, content string, adjustCash bool, mapping map[string]string) (*models.PortfolioImport, *ImportPreview, error) {
+	if err := domain.ValidateImportKind(kind, format); err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.portfolios.repo.GetPortfolioByID(ctx, portfolioID); err != nil {
+		return nil, nil, err
+	}
+
+	columns, records, err := domain.ReadImportCSV(strings.NewReader(content))
+	if err != nil {
+		return nil, nil, err
+	}
+	if mapping == nil {
+		mapping = domain.SuggestImportMapping(kind, columns)
+	}
+
+	imp := &models.PortfolioImport{
+		PortfolioID: portfolioID,
+		Kind:        kind,
+		Format:      format,
+		Filename:    filename,
+		Columns:     columns,
+		Mapping:     mapping,
+		AdjustCash:  adjustCash && kind == models.ImportKindTrades,
+		Content:     content,
+		TotalRows:   len(records),
+		CreatedBy:   requestctx.Actor(ctx),
+	}
+	preview, err := s.preview(ctx, imp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.portfolios.repo.CreateImport(ctx, imp); err != nil {
+		return nil, nil, err
+	}
+
+	s.logger.Info("Import uploaded",
+		zap.Int("import_id", imp.ID),
+		zap.Int("portfolio_id", portfolioID),
+		zap.String("kind", kind),
+		zap.Int("rows", imp.TotalRows))
+
+	return imp, preview, nil
+}
+
+// GetImport returns a portfolio's import with its progress
+func (s *ImportService) GetImport(ctx context.Context, portfolioID, importID int) (*models.PortfolioImport, error) {
+	imp, err := s.portfolios.repo.GetImport(ctx, importID)
+	if err != nil {
+		return nil, err
+	}
+	if imp.PortfolioID != portfolioID {
+		return nil, domain.NotFoundf("import not found: %d", importID)
+	}
+	return imp, nil
+}
+
+// UpdateMapping remaps the columns of an import awaiting confirmation and
+// previews it again
+func (s *ImportService) UpdateMapping(ctx context.Context, portfolioID, importID int, mapping map[string]string, adjustCash *bool) (*models.PortfolioImport, *ImportPreview, error) {
+	imp, err := s.GetImport(ctx, portfolioID, importID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if imp.Status != models.ImportPreviewed {
+		return nil, nil, domain.Conflictf("import %d has already been started", importID)
+	}
+
+	imp.Mapping = mapping
+	if imp.Mapping == nil {
+		imp.Mapping = map[string]string{}
+	}
+	if adjustCash != nil {
+		imp.AdjustCash = *adjustCash && imp.Kind == models.ImportKindTrades
+	}
+	preview, err := s.preview(ctx, imp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.portfolios.repo.UpdateImportMapping(ctx, importID, imp.Mapping, imp.AdjustCash); err != nil {
+		return nil, nil, err
+	}
+	return imp, preview, nil
+}
+
+// StartImport confirms an import and enqueues the job applying it. Its
+// mapping must be complete.
+func (s *ImportService) StartImport(ctx context.Context, portfolioID, importID int) (*models.PortfolioImport, error) {
+	imp, err := s.GetImport(ctx, portfolioID, importID)
+	if err != nil {
+		return nil, err
+	}
+	if imp.Status != models.ImportPreviewed {
+		return nil, domain.Conflictf("import %d has already been started", importID)
+	}
+	if err := domain.ValidateImportMapping(imp.Kind, imp.Columns, imp.Mapping); err != nil {
+		return nil, err
+	}
+	// Imported fills and positions would bypass the competition's rules
+	competition, err := s.portfolios.competing(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if competition != nil {
+		return nil, fmt.Errorf("%w: portfolio %d is entered in competition %q and cannot import", domain.ErrCompetitionRule, portfolioID, competition.Name)
+	}
+
+	job := &models.Job{
+		ID:         uuid.New().String(),
+		Type:       models.JobTypePortfolioImport,
+		Priority:   4,
+		MaxRetries: 3,
+		Payload: map[string]interface{}{
+			"import_id": imp.ID,
+		},
+	}
+	// Claimed before enqueueing, so concurrent confirmations start one job
+	if err := s.portfolios.repo.QueueImport(ctx, imp.ID, job.ID); err != nil {
+		return nil, err
+	}
+	imp.Status, imp.JobID = models.ImportQueued, job.ID
+
+	if err := s.queue.EnqueueJob(job); err != nil {
+		imp.Status, imp.Error = models.ImportFailed, "failed to enqueue import"
+		if finishErr := s.portfolios.repo.FinishImport(ctx, imp); finishErr != nil {
+			s.logger.Error("Failed to record import failure", zap.Error(finishErr), zap.Int("import_id", imp.ID))
+		}
+		return nil, err
+	}
+	return imp, nil
+}
+
+// preview reads an import's rows with its mapping and counts what applying
+// them would do. An incomplete mapping is reported in the preview rather
+// than as an error.
+func (s *ImportService) preview(ctx context.Context, imp *models.PortfolioImport) (*ImportPreview, error) {
+	preview := &ImportPreview{}
+	rows, err := s.readRows(ctx, imp)
+	if errors.Is(err, domain.ErrInvalidImport) {
+		preview.MappingError = err.Error()
+		return preview, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		switch {
+		case row.Error != "":
+			preview.InvalidRows++
+			if len(preview.Errors) < models.MaxImportErrors {
+				preview.Errors = append(preview.Errors, models.ImportError{Line: row.Line, Message: row.Error})
+			}
+		case row.Duplicate:
+			preview.DuplicateRows++
+		default:
+			preview.ValidRows++
+		}
+	}
+	if len(rows) > importPreviewRows {
+		rows = rows[:importPreviewRows]
+	}
+	preview.Rows = rows
+	return preview, nil
+}
+
+// readRows parses an import's rows in file order and flags those already in
+// the portfolio, so an import applied again, or retried, skips them
+func (s *ImportService) readRows(ctx context.Context, imp *models.PortfolioImport) ([]domain.ImportRow, error) {
+	_, records, err := domain.ReadImportCSV(strings.NewReader(imp.Content))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.portfolios.domain.ParseImportRows(imp.Kind, imp.Columns, records, imp.Mapping, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	if imp.Kind == models.ImportKindPositions {
+		positions, err := s.portfolios.repo.GetPositionsByPortfolioID(ctx, imp.PortfolioID)
+		if err != nil {
+			return nil, err
+		}
+		domain.MarkDuplicatePositions(rows, positions)
+		return rows, nil
+	}
+
+	first, last := domain.ImportSpan(rows)
+	if first.IsZero() {
+		return rows, nil
+	}
+	start := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	trades, err := s.portfolios.repo.GetTradesForImport(ctx, imp.PortfolioID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	domain.MarkDuplicateTrades(rows, trades)
+	return rows, nil
+}
+
+// CanHandle implements queue.JobHandler
+func (s *ImportService) CanHandle(jobType string) bool {
+	return jobType == models.JobTypePortfolioImport
+}
+
+// Handle implements queue.JobHandler
+func (s *ImportService) Handle(ctx context.Context, job *models.Job) error {
+	importID, _ := job.Payload["import_id"].(float64)
+	if importID <= 0 {
+		job.Retries = job.MaxRetries // A malformed payload will not improve on retry
+		return fmt.Errorf("invalid import payload: import_id=%v", job.Payload["import_id"])
+	}
+
+	err := s.Run(ctx, int(importID), job.ID)
+	if errors.Is(err, domain.ErrValidation) || errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrConflict) {
+		job.Retries = job.MaxRetries // Neither the file nor the import's state will change
+	}
+	return err
+}
+
+// Run applies an import's rows in date order, each in its own transaction,
+// as filled trades of the portfolio. Positions are bought at their cost
+// without moving cash. Rows that fail are recorded and skipped; the import
+// fails as a whole only when it cannot proceed, and running it again skips
+// the rows already applied.
+func (s *ImportService) Run(ctx context.Context, importID int, jobID string) error {
+	imp, err := s.portfolios.repo.GetImport(ctx, importID)
+	if err != nil {
+		return err
+	}
+	if imp.Status == models.ImportCompleted {
+		return nil // Redelivered after completing
+	}
+	ctx = requestctx.WithActor(ctx, imp.CreatedBy)
+
+	rows, err := s.readRows(ctx, imp)
+	if err != nil {
+		s.fail(ctx, imp, err)
+		return err
+	}
+	if err := s.portfolios.repo.StartImport(ctx, imp.ID, len(rows)); err != nil {
+		return err
+	}
+	imp.Status, imp.TotalRows, imp.Errors = models.ImportRunning, len(rows), nil
+	imp.ProcessedRows, imp.ImportedRows, imp.DuplicateRows, imp.FailedRows = 0, 0, 0, 0
+
+	domain.OrderImportRows(rows)
+	for _, row := range rows {
+		if err := ctx.Err(); err != nil {
+			s.fail(ctx, imp, err)
+			return err
+		}
+
+		switch {
+		case row.Error != "":
+			s.rowFailed(imp, row.Line, row.Error)
+		case row.Duplicate:
+			imp.DuplicateRows++
+		default:
+			err := s.applyRow(ctx, imp, row)
+			if err != nil && !isRowError(err) {
+				s.fail(ctx, imp, err)
+				return err
+			}
+			if err != nil {
+				s.rowFailed(imp, row.Line, err.Error())
+			} else {
+				imp.ImportedRows++
+			}
+		}
+
+		imp.ProcessedRows++
+		if imp.ProcessedRows%importProgressInterval == 0 {
+			s.reportProgress(ctx, imp, jobID)
+		}
+	}
+
+	imp.Status = models.ImportCompleted
+	if err := s.portfolios.repo.FinishImport(ctx, imp); err != nil {
+		return err
+	}
+	s.portfolios.invalidateCaches(ctx, imp.PortfolioID)
+
+	s.logger.Info("Import completed",
+		zap.Int("import_id", imp.ID),
+		zap.Int("portfolio_id", imp.PortfolioID),
+		zap.Int("imported", imp.ImportedRows),
+		zap.Int("duplicates", imp.DuplicateRows),
+		zap.Int("failed", imp.FailedRows))
+
+	return nil
+}
+
+// applyRow records a row as a filled trade, like ExecuteTrade but at the
+// row's price, fees and date, and without routing it to a venue
+func (s *ImportService) applyRow(ctx context.Context, imp *models.PortfolioImport, row domain.ImportRow) error {
+	repo := s.portfolios.repo
+	tx, err := repo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	portfolio, err := repo.GetPortfolioForUpdateTx(ctx, tx, imp.PortfolioID)
+	if err != nil {
+		return fmt.Errorf("failed to get portfolio: %w", err)
+	}
+	portfolioBefore := snapshot(portfolio)
+
+	if row.Side == "sell" {
+		held := 0.0
+		for _, position := range portfolio.Positions {
+			if position.Symbol == row.Symbol {
+				held = position.Quantity
+			}
+		}
+		if held > 0 && row.Quantity > held {
+			return fmt.Errorf("%w: selling %v %s, holding %v", domain.ErrInsufficientShares, row.Quantity, row.Symbol, held)
+		}
+	}
+
+	trade := &models.Trade{
+		UserID:        portfolio.UserID,
+		PortfolioID:   imp.PortfolioID,
+		Symbol:        row.Symbol,
+		Quantity:      row.Quantity,
+		Side:          row.Side,
+		Type:          "market",
+		BrokerOrderID: row.TradeID,
+		TriggerReason: models.TradeTriggerImport,
+	}
+	// Executing the fill changes the portfolio's positions in place
+	existing := append([]models.Position(nil), portfolio.Positions...)
+	cash := portfolio.Cash
+	position, err := s.portfolios.domain.ExecuteFill(trade, portfolio, row.Price, &row.Fees, row.Date)
+	if err != nil {
+		return err
+	}
+	if !imp.AdjustCash {
+		portfolio.Cash = cash
+	} else if portfolio.Cash < 0 {
+		return fmt.Errorf("%w: import with adjust_cash=false to leave cash unchanged", domain.ErrInsufficientCash)
+	}
+
+	// The positions are already locked, so they are not looked up again
+	err = s.portfolios.savePositionsTx(ctx, tx, imp.PortfolioID, existing, []*models.Trade{trade}, []*models.Position{position})
+	if err != nil {
+		return err
+	}
+	if err = repo.CreateTradeTx(ctx, tx, trade); err != nil {
+		return fmt.Errorf("failed to create trade record: %w", err)
+	}
+	if err = s.portfolios.saveLotsTx(ctx, tx, imp.PortfolioID, trade); err != nil {
+		return err
+	}
+	err = s.portfolios.recordAudit(ctx, tx, newAuditEvent(ctx, imp.PortfolioID, models.AuditEntityTrade, trade.ID, models.AuditActionCreate, nil, trade))
+	if err != nil {
+		return err
+	}
+	if err = s.portfolios.recordTradeEvent(ctx, tx, newTradeEvent(ctx, imp.PortfolioID, trade, models.TradeEventFilled, trade.Price, nil)); err != nil {
+		return err
+	}
+	if err = repo.UpdatePortfolioTx(ctx, tx, portfolio); err != nil {
+		return fmt.Errorf("failed to update portfolio: %w", err)
+	}
+	err = s.portfolios.recordAudit(ctx, tx, newAuditEvent(ctx, imp.PortfolioID, models.AuditEntityPortfolio, imp.PortfolioID, models.AuditActionUpdate, portfolioBefore, portfolio))
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isRowError reports whether err is a rule the row broke, rather than a
+// failure that would fail every row
+func isRowError(err error) bool {
+	return errors.Is(err, domain.ErrValidation) || errors.Is(err, domain.ErrInsufficientFunds) ||
+		errors.Is(err, domain.ErrRejected) || errors.Is(err, domain.ErrPositionNotFound)
+}
+
+func (s *ImportService) rowFailed(imp *models.PortfolioImport, line int, message string) {
+	imp.FailedRows++
+	if len(imp.Errors) < models.MaxImportErrors {
+		imp.Errors = append(imp.Errors, models.ImportError{Line: line, Message: message})
+	}
+}
+
+// reportProgress records a running import's progress and mirrors it on its
+// job's status. Failures are logged; the import carries on.
+func (s *ImportService) reportProgress(ctx context.Context, imp *models.PortfolioImport, jobID string) {
+	if err := s.portfolios.repo.UpdateImportProgress(ctx, imp); err != nil {
+		s.logger.Warn("Failed to record import progress", zap.Error(err), zap.Int("import_id", imp.ID))
+	}
+	if s.queue == nil || jobID == "" {
+		return
+	}
+	message := fmt.Sprintf("Imported %d of %d rows", imp.ProcessedRows, imp.TotalRows)
+	progress := float64(imp.ProcessedRows) / float64(imp.TotalRows) * 100
+	if err := s.queue.SetJobStatus(jobID, models.JobStatusRunning, message, progress); err != nil {
+		s.logger.Warn("Failed to update import job status", zap.Error(err), zap.String("job_id", jobID))
+	}
+}
+
+// fail records why an import stopped, with the rows applied so far
+func (s *ImportService) fail(ctx context.Context, imp *models.PortfolioImport, cause error) {
+	imp.Status, imp.Error = models.ImportFailed, cause.Error()
+	// Recorded even when the job's context was cancelled
+	if err := s.portfolios.repo.FinishImport(context.WithoutCancel(ctx), imp); err != nil {
+		s.logger.Error("Failed to record import failure", zap.Error(err), zap.Int("import_id", imp.ID))
+	}
+	if imp.ImportedRows > 0 {
+		s.portfolios.invalidateCaches(context.WithoutCancel(ctx), imp.PortfolioID)
+	}
+	s.logger.Warn("Import failed", zap.Error(cause), zap.Int("import_id", imp.ID))
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/jobledger"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+)
+
+// SetJobLedger records the trades and rebalances of queue jobs, keyed RPCs
+// and auto-rebalances so each commits once per key, however often it runs
+func (s *PortfolioService) SetJobLedger(ledger *jobledger.Ledger) {
+	s.ledger = ledger
+}
+
+// tradeStep names a trade in the job ledger. A job placing the same order
+// on a portfolio twice places it once.
+func tradeStep(portfolioID int, trade *models.Trade) string {
+	return fmt.Sprintf("trade:%d:%s:%s", portfolioID, trade.Symbol, trade.Side)
+}
+
+// rebalanceStep names a portfolio's rebalance in the job ledger
+func rebalanceStep(portfolioID int) string {
+	return fmt.Sprintf("rebalance:%d", portfolioID)
+}
+
+// ProcessedTrade returns the trade the job carried by ctx already made for
+// an order, after ExecuteTrade failed with jobledger.ErrProcessed, so a
+// redelivered order can be answered with its original trade
+func (s *PortfolioService) ProcessedTrade(ctx context.Context, portfolioID int, trade *models.Trade) (*models.Trade, error) {
+	job := queue.JobFromContext(ctx)
+	if s.ledger == nil || job == nil {
+		return nil, fmt.Errorf("no job ledger for trade on portfolio %d", portfolioID)
+	}
+	entry, err := s.ledger.Get(ctx, jobledger.Key(job, tradeStep(portfolioID, trade)))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, domain.NotFoundf("processed trade not found: %s", tradeStep(portfolioID, trade))
+	}
+
+	var tradeID int
+	if err := json.Unmarshal(entry.Result, &tradeID); err != nil {
+		return nil, fmt.Errorf("failed to decode processed trade: %w", err)
+	}
+	return s.repo.GetTradeByID(ctx, portfolioID, tradeID)
+}
+
+// recordJobStep records a step made for the queue job carried by ctx in tx,
+// the transaction making it. Outside a job it does nothing.
+func (s *PortfolioService) recordJobStep(ctx context.Context, tx *sql.Tx, step string, result interface{}) error {
+	job := queue.JobFromContext(ctx)
+	if s.ledger == nil || job == nil {
+		return nil
+	}
+	return s.ledger.RecordTx(ctx, tx, job, step, result)
+}
@@ -0,0 +1,15 @@
+package service
+
+import "context"
+
+// SetReadOnly sets the switch that holds back the trades scheduled work
+// places on its own, such as stop-loss sells and auto-rebalances, during
+// maintenance. Without one they always run.
+func (s *PortfolioService) SetReadOnly(readOnly func(ctx context.Context) bool) {
+	s.readOnly = readOnly
+}
+
+// inMaintenance reports whether the system is read-only for maintenance
+func (s *PortfolioService) inMaintenance(ctx context.Context) bool {
+	return s.readOnly != nil && s.readOnly(ctx)
+}
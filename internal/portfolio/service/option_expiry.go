@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// OptionExpiryService settles positions in option contracts once they have
+// expired, at the last price of their underlying
+type OptionExpiryService struct {
+	portfolios *PortfolioService
+	market     MarketData
+	logger     *zap.Logger
+}
+
+func NewOptionExpiryService(portfolios *PortfolioService, market MarketData, logger *zap.Logger) *OptionExpiryService {
+	return &OptionExpiryService{
+		portfolios: portfolios,
+		market:     market,
+		logger:     logger,
+	}
+}
+
+// ProcessExpiries settles every option position expired at now and returns
+// how many were settled. Positions whose underlying has no price, or that
+// fail to settle, are logged and left for the next run.
+func (s *OptionExpiryService) ProcessExpiries(ctx context.Context, now time.Time) (int, error) {
+	positions, err := s.portfolios.repo.GetOptionPositions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := make(map[string]*models.OptionContract)
+	var underlyings []string
+	for _, position := range positions {
+		contract, ok := symbols.ParseOption(position.Symbol)
+		if !ok || !contract.Expired(now) {
+			continue
+		}
+		expired[position.Symbol] = contract
+		underlyings = append(underlyings, contract.Underlying)
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	prices, err := s.market.GetCurrentPrices(symbols.NormalizeAll(underlyings))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get underlying prices: %w", err)
+	}
+
+	settled := 0
+	for i := range positions {
+		position := &positions[i]
+		contract, ok := expired[position.Symbol]
+		if !ok {
+			continue
+		}
+		price, ok := prices[contract.Underlying]
+		if !ok {
+			s.logger.Warn("No price for the underlying of an expired option",
+				zap.Int("portfolio_id", position.PortfolioID),
+				zap.String("symbol", position.Symbol),
+				zap.String("underlying", contract.Underlying))
+			continue
+		}
+
+		trade, err := s.settle(ctx, position, price, now)
+		if err != nil {
+			s.logger.Error("Failed to settle expired option", zap.Error(err),
+				zap.Int("portfolio_id", position.PortfolioID),
+				zap.Int("position_id", position.ID),
+				zap.String("symbol", position.Symbol))
+			continue
+		}
+		if trade == nil {
+			continue // Closed since it was loaded
+		}
+		settled++
+
+		s.logger.Info("Expired option settled",
+			zap.Int("portfolio_id", position.PortfolioID),
+			zap.Int("trade_id", trade.ID),
+			zap.String("symbol", position.Symbol),
+			zap.String("outcome", trade.TriggerReason),
+			zap.Float64("underlying_price", price),
+			zap.Float64("price", trade.Price))
+		s.notify(ctx, position, contract, trade, price)
+	}
+	return settled, nil
+}
+
+// settle closes an expired option position in one transaction with the
+// portfolio locked. It returns nil when the position is no longer held.
+func (s *OptionExpiryService) settle(ctx context.Context, position *models.Position, underlyingPrice float64, now time.Time) (*models.Trade, error) {
+	p := s.portfolios
+	tx, err := p.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	portfolio, err := p.repo.GetPortfolioForUpdateTx(ctx, tx, position.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if !holds(portfolio, position.ID) {
+		return nil, nil
+	}
+
+	before := snapshot(portfolio)
+	trade, err := p.domain.SettleExpiredOption(portfolio, position.Symbol, underlyingPrice, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.savePositionTx(ctx, tx, portfolio.ID, trade, nil); err != nil {
+		return nil, err
+	}
+	if err := p.repo.CreateTradeTx(ctx, tx, trade); err != nil {
+		return nil, fmt.Errorf("failed to create trade record: %w", err)
+	}
+	// Shorts have no lots to close
+	if trade.Side == "sell" {
+		if err := p.saveLotsTx(ctx, tx, portfolio.ID, trade); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.recordAudit(ctx, tx, newAuditEvent(ctx, portfolio.ID, models.AuditEntityTrade, trade.ID, models.AuditActionCreate, nil, trade)); err != nil {
+		return nil, err
+	}
+	if err := p.recordTradeEvent(ctx, tx, newTradeEvent(ctx, portfolio.ID, trade, models.TradeEventFilled, trade.Price, nil)); err != nil {
+		return nil, err
+	}
+	if err := p.repo.UpdatePortfolioTx(ctx, tx, portfolio); err != nil {
+		return nil, fmt.Errorf("failed to update portfolio: %w", err)
+	}
+	if err := p.recordAudit(ctx, tx, newAuditEvent(ctx, portfolio.ID, models.AuditEntityPortfolio, portfolio.ID, models.AuditActionUpdate, before, portfolio)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit option settlement: %w", err)
+	}
+	p.invalidateCaches(ctx, portfolio.ID)
+	p.confirmTrade(ctx, portfolio.UserID, trade)
+	return trade, nil
+}
+
+func holds(portfolio *models.Portfolio, positionID int) bool {
+	for _, position := range portfolio.Positions {
+		if position.ID == positionID {
+			return true
+		}
+	}
+	return false
+}
+
+// notify tells the owner how an option position was settled
+func (s *OptionExpiryService) notify(ctx context.Context, position *models.Position, contract *models.OptionContract, trade *models.Trade, underlyingPrice float64) {
+	if s.portfolios.notifications == nil || !s.portfolios.notifies(ctx, position.UserID, position.PortfolioID, "option_expired") {
+		return
+	}
+
+	outcome := "expired worthless"
+	switch trade.TriggerReason {
+	case models.TradeTriggerOptionExercise:
+		outcome = "exercised"
+	case models.TradeTriggerOptionAssignment:
+		outcome = "assigned"
+	}
+	data := map[string]interface{}{
+		"portfolio_id":     position.PortfolioID,
+		"trade_id":         trade.ID,
+		"symbol":           position.Symbol,
+		"quantity":         trade.Quantity,
+		"outcome":          outcome,
+		"underlying":       contract.Underlying,
+		"underlying_price": underlyingPrice,
+		"strike":           contract.Strike,
+		"price":            trade.Price,
+		"value":            trade.Value(trade.Price),
+	}
+	if _, err := s.portfolios.notifications.EnqueueNotification(position.UserID, "option_expired", "", "", data, nil); err != nil {
+		s.logger.Warn("Failed to enqueue option expiry notification", zap.Error(err), zap.Int("portfolio_id", position.PortfolioID))
+	}
+}
+
+// RunDailySchedule settles expired option positions every day at hour (UTC)
+// until ctx is cancelled. Contracts expire at the end of their last trading
+// day, so the hour should fall after it, once the underlying has closed.
+func (s *OptionExpiryService) RunDailySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		if s.portfolios.inMaintenance(ctx) {
+			s.logger.Warn("Skipped option expiries during maintenance; the next run settles them")
+			continue
+		}
+		settled, err := s.ProcessExpiries(ctx, time.Now())
+		if err != nil {
+			s.logger.Error("Failed to process option expiries", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Option expiries processed", zap.Int("settled", settled))
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import "hedge-fund/pkg/shared/models"
+
+// Overview Operations
+
+// UserOverview is the consolidated view of all of a user's portfolios
+type UserOverview struct {
+	UserID     int
+	Summary    models.PortfolioSummary
+	Allocation map[string]float64     // Percent of combined value by symbol, and CASH
+	Risk       map[string]interface{} // As CalculateRiskMetrics, over the netted positions
+	Portfolios []PortfolioOverview
+}
+
+// PortfolioOverview is one portfolio's share of a user overview
+type PortfolioOverview struct {
+	Portfolio *models.Portfolio
+	Summary   models.PortfolioSummary
+	Weight    float64 // Percent of the combined total value
+}
+
+// OverviewPortfolios consolidates already loaded portfolios of a user from
+// one shared set of prices. Totals add up the portfolios' summaries;
+// allocation and risk are calculated over their positions netted by symbol.
+func (s *PortfolioService) OverviewPortfolios(userID int, portfolios []models.Portfolio, currentPrices, previousDayPrices map[string]float64, sectors map[string]string) *UserOverview {
+	summaries := s.SummarizePortfolios(portfolios, currentPrices, previousDayPrices)
+	consolidated := s.domain.ConsolidatePortfolios(userID, portfolios)
+
+	overview := &UserOverview{
+		UserID:     userID,
+		Summary:    s.domain.CombineSummaries(summaries),
+		Allocation: s.domain.CalculatePortfolioAllocation(consolidated, currentPrices),
+		Risk:       s.domain.CalculateRiskMetrics(consolidated, currentPrices, sectors),
+		Portfolios: make([]PortfolioOverview, len(portfolios)),
+	}
+	for i := range portfolios {
+		overview.Portfolios[i] = PortfolioOverview{Portfolio: &portfolios[i], Summary: summaries[i]}
+		if overview.Summary.TotalValue > 0 {
+			overview.Portfolios[i].Weight = (summaries[i].TotalValue / overview.Summary.TotalValue) * 100
+		}
+	}
+	return overview
+}
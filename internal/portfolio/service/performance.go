@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/drawdown"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/riskfree"
+)
+
+// Performance Operations
+
+// DefaultPerformanceDays is the period performance is calculated over when a
+// request doesn't set one
+const DefaultPerformanceDays = 252
+
+// SetRiskFreeRates sets the source of the risk-free rate risk-adjusted
+// returns are taken over. Without one the rate is zero.
+func (s *PortfolioService) SetRiskFreeRates(rates *riskfree.Source) {
+	s.rates = rates
+}
+
+// GetPerformance calculates a portfolio's risk-adjusted return over its last
+// days daily snapshots, at the risk-free rate for that period
+func (s *PortfolioService) GetPerformance(ctx context.Context, portfolioID, days int) (*models.PortfolioPerformance, error) {
+	portfolio, err := s.repo.GetPortfolioByID(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := s.repo.GetRecentSnapshots(ctx, portfolioID, days+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("%w: portfolio %d has no snapshots", domain.ErrInsufficientHistory, portfolioID)
+	}
+
+	riskFreeRate := 0.0
+	if s.rates != nil {
+		riskFreeRate, err = s.rates.Rate(ctx, snapshots[0].SnapshotDate, snapshots[len(snapshots)-1].SnapshotDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve risk-free rate: %w", err)
+		}
+	}
+
+	first, last := snapshots[0], snapshots[len(snapshots)-1]
+	cash, err := s.repo.GetCashTransactionsBetween(ctx, portfolioID, first.CreatedAt, last.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	performance, err := s.domain.CalculatePerformance(snapshots, cash, riskFreeRate)
+	if err != nil {
+		return nil, err
+	}
+	s.trackAnalysis(ctx, portfolio, "performance")
+	return &performance, nil
+}
+
+// GetDrawdown analyzes a portfolio's drawdowns and underwater curve over its
+// last days daily snapshots, net of its cash flows
+func (s *PortfolioService) GetDrawdown(ctx context.Context, portfolioID, days int) (*models.DrawdownAnalysis, error) {
+	portfolio, err := s.repo.GetPortfolioByID(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := s.repo.GetRecentSnapshots(ctx, portfolioID, days+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("%w: portfolio %d has no snapshots", domain.ErrInsufficientHistory, portfolioID)
+	}
+
+	cash, err := s.repo.GetCashTransactionsBetween(ctx, portfolioID, snapshots[0].CreatedAt, snapshots[len(snapshots)-1].CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	analysis, err := drawdown.Analyze(snapshots, cash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInsufficientHistory, err)
+	}
+	s.trackAnalysis(ctx, portfolio, "drawdown")
+	return &analysis, nil
+}
@@ -2,35 +2,68 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"time"
 
 	"hedge-fund/internal/portfolio/domain"
 	"hedge-fund/internal/portfolio/repository"
+	"hedge-fund/pkg/broker"
+	"hedge-fund/pkg/shared/analytics"
+	"hedge-fund/pkg/shared/flags"
+	"hedge-fund/pkg/shared/jobledger"
 	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/redis"
+	"hedge-fund/pkg/shared/riskfree"
+	"hedge-fund/pkg/shared/symbols"
 	"go.uber.org/zap"
 )
 
 type PortfolioService struct {
-	repo   *repository.PortfolioRepository
-	domain *domain.PortfolioService
-	logger *zap.Logger
+	repo          *repository.PortfolioRepository
+	domain        *domain.PortfolioService
+	brokers       *broker.Registry
+	brokerFees    map[string]models.FeeSchedule
+	analytics     *analytics.Tracker
+	quotes        Quoter
+	maxSlippage   float64
+	notifications *queue.Manager
+	rates         *riskfree.Source
+	cache         *redis.Client
+	summaryTTL    time.Duration
+	flags         *flags.Manager
+	publisher     *redis.Client
+	webhooks      *queue.Manager
+	ledger        *jobledger.Ledger
+	readOnly      func(ctx context.Context) bool
+	logger        *zap.Logger
 }
 
 func NewPortfolioService(repo *repository.PortfolioRepository, domain *domain.PortfolioService, logger *zap.Logger) *PortfolioService {
 	return &PortfolioService{
-		repo:   repo,
-		domain: domain,
-		logger: logger,
+		repo:    repo,
+		domain:  domain,
+		brokers: broker.NewRegistry(broker.NewPaperBroker()),
+		logger:  logger,
 	}
 }
 
 // Portfolio Operations
 
-// CreatePortfolio creates a new portfolio with initial cash
-func (s *PortfolioService) CreatePortfolio(ctx context.Context, userID int, name string, initialCash float64) (*models.Portfolio, error) {
+// CreatePortfolio creates a new portfolio with initial cash. A base
+// currency in details is set as the portfolio's setting.
+func (s *PortfolioService) CreatePortfolio(ctx context.Context, userID int, details domain.PortfolioDetails, initialCash float64) (*models.Portfolio, error) {
+	if err := s.domain.NormalizeDetails(&details); err != nil {
+		return nil, err
+	}
+
 	portfolio := &models.Portfolio{
 		UserID:           userID,
-		Name:             name,
+		Name:             details.Name,
+		Description:      details.Description,
+		StrategyTags:     details.StrategyTags,
 		Cash:             initialCash,
 		MarginUsed:       0.0,
 		MarginAvailable:  initialCash * 0.5, // 50% margin
@@ -41,16 +74,39 @@ func (s *PortfolioService) CreatePortfolio(ctx context.Context, userID int, name
 		Positions:        []models.Position{},
 	}
 
-	err := s.repo.CreatePortfolio(ctx, portfolio)
+	tx, err := s.repo.BeginTx(ctx)
 	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.CreatePortfolioTx(ctx, tx, portfolio); err != nil {
 		s.logger.Error("Failed to create portfolio", zap.Error(err), zap.Int("user_id", userID))
 		return nil, fmt.Errorf("failed to create portfolio: %w", err)
 	}
+	if details.BaseCurrency != "" {
+		settings := &models.SettingsOverrides{BaseCurrency: &details.BaseCurrency}
+		if err := s.repo.SavePortfolioSettingsTx(ctx, tx, portfolio.ID, settings); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.recordAudit(ctx, tx, newAuditEvent(ctx, portfolio.ID, models.AuditEntityPortfolio, portfolio.ID, models.AuditActionCreate, nil, portfolio)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit portfolio: %w", err)
+	}
+
+	s.analytics.Track(ctx, analytics.EventPortfolioCreated, userID, "portfolios", map[string]interface{}{
+		"portfolio_id": portfolio.ID,
+		"initial_cash": initialCash,
+	})
 
 	s.logger.Info("Portfolio created successfully",
 		zap.Int("portfolio_id", portfolio.ID),
 		zap.Int("user_id", userID),
-		zap.String("name", name),
+		zap.String("name", portfolio.Name),
 		zap.Float64("initial_cash", initialCash))
 
 	return portfolio, nil
@@ -61,38 +117,64 @@ func (s *PortfolioService) GetPortfolio(ctx context.Context, portfolioID int) (*
 	return s.repo.GetPortfolioByID(ctx, portfolioID)
 }
 
+// GetPortfolioForRead retrieves a portfolio for display, from the read
+// replica when one is configured. It may not yet reflect the latest trades.
+func (s *PortfolioService) GetPortfolioForRead(ctx context.Context, portfolioID int) (*models.Portfolio, error) {
+	return s.repo.GetPortfolioForRead(ctx, portfolioID)
+}
+
 // GetUserPortfolios retrieves all portfolios for a user
 func (s *PortfolioService) GetUserPortfolios(ctx context.Context, userID int) ([]models.Portfolio, error) {
 	return s.repo.GetPortfoliosByUserID(ctx, userID)
 }
 
-// CalculatePortfolioSummary generates a comprehensive portfolio summary with current market data
-func (s *PortfolioService) CalculatePortfolioSummary(ctx context.Context, portfolioID int, currentPrices map[string]float64, previousDayPrices map[string]float64) (*models.PortfolioSummary, error) {
-	portfolio, err := s.repo.GetPortfolioByID(ctx, portfolioID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get portfolio: %w", err)
-	}
+// ListUserPortfolios retrieves a page of a user's portfolios
+func (s *PortfolioService) ListUserPortfolios(ctx context.Context, userID int, page pagination.Request) ([]models.Portfolio, pagination.Result, error) {
+	return s.repo.ListPortfoliosByUserID(ctx, userID, page)
+}
 
-	summary := s.domain.CalculatePortfolioSummary(portfolio, currentPrices, previousDayPrices)
-	return &summary, nil
+// SummarizePortfolios generates summaries for already loaded portfolios from
+// one shared set of prices, in the order the portfolios were given
+func (s *PortfolioService) SummarizePortfolios(portfolios []models.Portfolio, currentPrices map[string]float64, previousDayPrices map[string]float64) []models.PortfolioSummary {
+	summaries := make([]models.PortfolioSummary, len(portfolios))
+	for i := range portfolios {
+		summaries[i] = s.domain.CalculatePortfolioSummary(&portfolios[i], currentPrices, previousDayPrices)
+	}
+	return summaries
 }
 
 // UpdatePortfolioWithMarketData updates portfolio positions with current market prices
 func (s *PortfolioService) UpdatePortfolioWithMarketData(ctx context.Context, portfolioID int, currentPrices map[string]float64) error {
-	portfolio, err := s.repo.GetPortfolioByID(ctx, portfolioID)
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	portfolio, err := s.repo.GetPortfolioForUpdateTx(ctx, tx, portfolioID)
 	if err != nil {
 		return fmt.Errorf("failed to get portfolio: %w", err)
 	}
 
+	before := snapshot(portfolio)
+
 	// Update portfolio with market data using domain logic
 	s.domain.UpdatePortfolioWithMarketData(portfolio, currentPrices)
 
 	// Save updated portfolio to database
-	err = s.repo.UpdatePortfolio(ctx, portfolio)
+	err = s.repo.UpdatePortfolioTx(ctx, tx, portfolio)
 	if err != nil {
 		return fmt.Errorf("failed to update portfolio: %w", err)
 	}
 
+	if err := s.recordAudit(ctx, tx, newAuditEvent(ctx, portfolioID, models.AuditEntityPortfolio, portfolioID, models.AuditActionUpdate, before, portfolio)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit portfolio update: %w", err)
+	}
+	s.invalidateCaches(ctx, portfolioID)
+
 	s.logger.Info("Portfolio updated with market data",
 		zap.Int("portfolio_id", portfolioID),
 		zap.Float64("total_value", portfolio.TotalValue),
@@ -103,28 +185,119 @@ func (s *PortfolioService) UpdatePortfolioWithMarketData(ctx context.Context, po
 
 // Trading Operations
 
-// ExecuteTrade executes a trade order and updates portfolio state
-func (s *PortfolioService) ExecuteTrade(ctx context.Context, portfolioID int, trade *models.Trade, currentPrice float64) (*models.Position, error) {
-	// Get portfolio
-	portfolio, err := s.repo.GetPortfolioByID(ctx, portfolioID)
+// ExecuteTrade executes a trade order and updates portfolio state. Each step
+// of the order lifecycle is appended to the trade event stream. The portfolio
+// and its positions stay locked from validation to commit, so simultaneous
+// trades on one portfolio cannot both spend the same cash.
+func (s *PortfolioService) ExecuteTrade(ctx context.Context, portfolioID int, trade *models.Trade, currentPrice float64) (_ *models.Position, err error) {
+	// Begin database transaction
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// A trade made for a queue job is made once, however often the job runs
+	if _, err := s.ledger.Step(ctx, tradeStep(portfolioID, trade)); err != nil {
+		return nil, err
+	}
+
+	// Get and lock portfolio
+	portfolio, err := s.repo.GetPortfolioForUpdateTx(ctx, tx, portfolioID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get portfolio: %w", err)
 	}
+	if err := s.loadSettings(ctx, portfolio); err != nil {
+		return nil, err
+	}
+
+	// Snapshot before the domain logic mutates the portfolio in-memory
+	portfolioBefore := snapshot(portfolio)
+
+	// Re-quote market orders now that the lock is held; the price the order
+	// was placed at may be stale after waiting on retries, queues or the lock
+	quotedPrice := currentPrice
+	currentPrice, err = s.requote(ctx, portfolio.UserID, trade, quotedPrice)
+	if err != nil {
+		s.logger.Warn("Market order re-quote failed",
+			zap.Error(err),
+			zap.Int("portfolio_id", portfolioID),
+			zap.String("symbol", trade.Symbol),
+			zap.Float64("quoted_price", quotedPrice))
+		s.recordTradeEvent(ctx, nil, newTradeEvent(ctx, portfolioID, trade, models.TradeEventRejected, quotedPrice, err))
+		s.notifySlippage(ctx, portfolio.UserID, portfolioID, trade, quotedPrice, err)
+		return nil, fmt.Errorf("trade rejected: %w", err)
+	}
 
 	// Validate trade using domain logic
 	err = s.domain.ValidateTradeOrder(trade, portfolio, currentPrice)
+	if err == nil {
+		err = s.checkCompetition(ctx, portfolio, trade, currentPrice)
+	}
 	if err != nil {
 		s.logger.Warn("Trade validation failed",
 			zap.Error(err),
 			zap.Int("portfolio_id", portfolioID),
 			zap.String("symbol", trade.Symbol),
 			zap.String("side", trade.Side),
-			zap.Int64("quantity", trade.Quantity))
+			zap.Float64("quantity", trade.Quantity))
+		s.recordTradeEvent(ctx, nil, newTradeEvent(ctx, portfolioID, trade, models.TradeEventRejected, currentPrice, err))
 		return nil, fmt.Errorf("trade validation failed: %w", err)
 	}
 
+	s.recordTradeEvent(ctx, nil, newTradeEvent(ctx, portfolioID, trade, models.TradeEventValidated, currentPrice, nil))
+
+	// Route the order to the portfolio's execution venue. Live orders stay
+	// pending until the venue reports a fill (see SyncOpenOrders).
+	venue, accountID, err := s.venueFor(ctx, portfolioID)
+	if err != nil {
+		s.recordTradeEvent(ctx, nil, newTradeEvent(ctx, portfolioID, trade, models.TradeEventRejected, currentPrice, err))
+		return nil, err
+	}
+	if venue.Name() != broker.Paper {
+		if !s.featureOn(ctx, flags.LiveExecution, portfolio.UserID) {
+			err := fmt.Errorf("%w: orders to %s", domain.ErrFeatureDisabled, venue.Name())
+			s.recordTradeEvent(ctx, nil, newTradeEvent(ctx, portfolioID, trade, models.TradeEventRejected, currentPrice, err))
+			return nil, err
+		}
+
+		// Live orders only touch cash once filled, so don't hold the locks
+		// while calling out to the venue
+		tx.Rollback()
+		return nil, s.submitLiveOrder(ctx, portfolioID, trade, currentPrice, venue, accountID)
+	}
+
+	// Anything failing from here on aborts an order that already passed validation
+	defer func() {
+		if err != nil {
+			event := newTradeEvent(ctx, portfolioID, trade, models.TradeEventCancelled, currentPrice, err)
+			event.TradeID = 0 // The trade row was rolled back
+			s.recordTradeEvent(ctx, nil, event)
+		}
+	}()
+
+	fill, err := venue.SubmitOrder(ctx, newOrder(trade, accountID, currentPrice))
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit order: %w", err)
+	}
+
+	// IOC and FOK orders may come back cancelled for want of liquidity
+	if fill.FilledQuantity <= 0 {
+		err = fmt.Errorf("%w: %s", domain.ErrOrderNotFilled, fill.Reason)
+		return nil, err
+	}
+	if fill.FilledQuantity < trade.Quantity {
+		s.logger.Info("Order partially filled",
+			zap.Int("portfolio_id", portfolioID),
+			zap.String("symbol", trade.Symbol),
+			zap.String("time_in_force", trade.TimeInForce),
+			zap.Float64("quantity", trade.Quantity),
+			zap.Float64("filled_quantity", fill.FilledQuantity))
+		trade.Quantity = domain.RoundQuantity(fill.FilledQuantity)
+	}
+
 	// Execute trade using domain logic (updates portfolio state in-memory)
-	position, err := s.domain.ExecuteTradeOrder(trade, portfolio, currentPrice)
+	position, err := s.domain.ExecuteTradeOrder(trade, portfolio, fill.FilledPrice)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute trade: %w", err)
 	}
@@ -132,21 +305,79 @@ func (s *PortfolioService) ExecuteTrade(ctx context.Context, portfolioID int, tr
 	// Set portfolio_id on trade
 	trade.PortfolioID = portfolioID
 
-	// Begin database transaction
-	tx, err := s.repo.BeginTx(ctx)
+	// Handle position operations FIRST (so we get the position ID)
+	finalPosition, err := s.savePositionTx(ctx, tx, portfolioID, trade, position)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, err
 	}
-	defer tx.Rollback()
 
-	// Handle position operations FIRST (so we get the position ID)
+	// Create trade record (position_id is now set)
+	err = s.repo.CreateTradeTx(ctx, tx, trade)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trade record: %w", err)
+	}
+
+	if err = s.saveLotsTx(ctx, tx, portfolioID, trade); err != nil {
+		return nil, err
+	}
+
+	err = s.recordAudit(ctx, tx, newAuditEvent(ctx, portfolioID, models.AuditEntityTrade, trade.ID, models.AuditActionCreate, nil, trade))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.recordTradeEvent(ctx, tx, newTradeEvent(ctx, portfolioID, trade, models.TradeEventFilled, trade.Price, nil))
+	if err != nil {
+		return nil, err
+	}
+
+	// Update portfolio
+	err = s.repo.UpdatePortfolioTx(ctx, tx, portfolio)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update portfolio: %w", err)
+	}
+
+	err = s.recordAudit(ctx, tx, newAuditEvent(ctx, portfolioID, models.AuditEntityPortfolio, portfolioID, models.AuditActionUpdate, portfolioBefore, portfolio))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.recordJobStep(ctx, tx, tradeStep(portfolioID, trade), trade.ID); err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.Info("Trade executed successfully",
+		zap.Int("trade_id", trade.ID),
+		zap.Int("portfolio_id", portfolioID),
+		zap.String("symbol", trade.Symbol),
+		zap.String("side", trade.Side),
+		zap.Float64("quantity", trade.Quantity),
+		zap.Float64("price", trade.Price),
+		zap.Float64("fees", trade.Fees))
+
+	s.invalidateCaches(ctx, portfolioID)
+	s.trackTrade(ctx, portfolio.UserID, trade, broker.Paper)
+	s.confirmTrade(ctx, portfolio.UserID, trade)
+
+	return finalPosition, nil
+}
+
+// savePositionTx persists the position produced by executing trade: it is
+// created, updated, or deleted when the trade closed it. The trade's
+// position_id is set to the position's ID.
+func (s *PortfolioService) savePositionTx(ctx context.Context, tx *sql.Tx, portfolioID int, trade *models.Trade, position *models.Position) (*models.Position, error) {
 	var finalPosition *models.Position
 	if position != nil {
 		// Set portfolio_id on position
 		position.PortfolioID = portfolioID
 
 		// Check if position already exists
-		existingPosition, err := s.repo.GetPositionByUserAndSymbol(ctx, trade.UserID, portfolioID, trade.Symbol)
+		existingPosition, err := s.repo.GetPositionByUserAndSymbolTx(ctx, tx, trade.UserID, portfolioID, trade.Symbol)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check existing position: %w", err)
 		}
@@ -158,6 +389,11 @@ func (s *PortfolioService) ExecuteTrade(ctx context.Context, portfolioID int, tr
 				return nil, fmt.Errorf("failed to create position: %w", err)
 			}
 			finalPosition = position
+
+			event := newAuditEvent(ctx, portfolioID, models.AuditEntityPosition, position.ID, models.AuditActionCreate, nil, position)
+			if err = s.recordAudit(ctx, tx, event); err != nil {
+				return nil, err
+			}
 		} else {
 			// Update existing position in transaction
 			position.ID = existingPosition.ID
@@ -166,13 +402,18 @@ func (s *PortfolioService) ExecuteTrade(ctx context.Context, portfolioID int, tr
 				return nil, fmt.Errorf("failed to update position: %w", err)
 			}
 			finalPosition = position
+
+			event := newAuditEvent(ctx, portfolioID, models.AuditEntityPosition, position.ID, models.AuditActionUpdate, existingPosition, position)
+			if err = s.recordAudit(ctx, tx, event); err != nil {
+				return nil, err
+			}
 		}
 
 		// Set position_id on trade (now we have the position ID)
 		trade.PositionID = finalPosition.ID
 	} else {
 		// Position was closed, need to get existing position for trade record
-		existingPosition, err := s.repo.GetPositionByUserAndSymbol(ctx, trade.UserID, portfolioID, trade.Symbol)
+		existingPosition, err := s.repo.GetPositionByUserAndSymbolTx(ctx, tx, trade.UserID, portfolioID, trade.Symbol)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check existing position: %w", err)
 		}
@@ -186,41 +427,107 @@ func (s *PortfolioService) ExecuteTrade(ctx context.Context, portfolioID int, tr
 			if err != nil {
 				return nil, fmt.Errorf("failed to delete position: %w", err)
 			}
+
+			event := newAuditEvent(ctx, portfolioID, models.AuditEntityPosition, existingPosition.ID, models.AuditActionDelete, existingPosition, nil)
+			if err = s.recordAudit(ctx, tx, event); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	// Create trade record (position_id is now set)
-	err = s.repo.CreateTradeTx(ctx, tx, trade)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create trade record: %w", err)
+	return finalPosition, nil
+}
+
+// savePositionsTx persists the positions produced by executing trades, as
+// savePositionTx does for one, writing new and changed positions in bulk.
+// existing are the portfolio's positions before the trades, and each symbol
+// is traded at most once.
+func (s *PortfolioService) savePositionsTx(ctx context.Context, tx *sql.Tx, portfolioID int, existing []models.Position, trades []*models.Trade, positions []*models.Position) error {
+	bySymbol := make(map[string]*models.Position, len(existing))
+	for i := range existing {
+		bySymbol[existing[i].Symbol] = &existing[i]
 	}
 
-	// Update portfolio
-	err = s.repo.UpdatePortfolioTx(ctx, tx, portfolio)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update portfolio: %w", err)
+	var created, updated []*models.Position
+	for i, trade := range trades {
+		position, current := positions[i], bySymbol[symbols.Normalize(trade.Symbol)]
+		switch {
+		case position != nil && current == nil:
+			position.PortfolioID = portfolioID
+			created = append(created, position)
+		case position != nil:
+			position.PortfolioID = portfolioID
+			position.ID = current.ID
+			updated = append(updated, position)
+		case current != nil:
+			// Position was closed
+			if err := s.repo.DeletePositionTx(ctx, tx, current.ID); err != nil {
+				return fmt.Errorf("failed to delete position: %w", err)
+			}
+		}
 	}
 
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	if err := s.repo.CreatePositionsTx(ctx, tx, created); err != nil {
+		return err
+	}
+	if err := s.repo.UpdatePositionsTx(ctx, tx, updated); err != nil {
+		return err
 	}
 
-	s.logger.Info("Trade executed successfully",
-		zap.Int("trade_id", trade.ID),
-		zap.Int("portfolio_id", portfolioID),
-		zap.String("symbol", trade.Symbol),
-		zap.String("side", trade.Side),
-		zap.Int64("quantity", trade.Quantity),
-		zap.Float64("price", trade.Price),
-		zap.Float64("fees", trade.Fees))
+	for i, trade := range trades {
+		position, current := positions[i], bySymbol[symbols.Normalize(trade.Symbol)]
+		var event *models.AuditEvent
+		switch {
+		case position != nil && current == nil:
+			event = newAuditEvent(ctx, portfolioID, models.AuditEntityPosition, position.ID, models.AuditActionCreate, nil, position)
+		case position != nil:
+			event = newAuditEvent(ctx, portfolioID, models.AuditEntityPosition, position.ID, models.AuditActionUpdate, current, position)
+		case current != nil:
+			event = newAuditEvent(ctx, portfolioID, models.AuditEntityPosition, current.ID, models.AuditActionDelete, current, nil)
+		default:
+			continue
+		}
+		trade.PositionID = event.EntityID
+		if err := s.recordAudit(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	return finalPosition, nil
+// saveLotsTx opens a lot for a filled buy, or reduces the open lots by the
+// quantity of a filled sell, in the order of the portfolio's cost basis method
+func (s *PortfolioService) saveLotsTx(ctx context.Context, tx *sql.Tx, portfolioID int, trade *models.Trade) error {
+	if trade.Side == "buy" {
+		lot := domain.NewLot(portfolioID, trade)
+		return s.repo.CreateLotTx(ctx, tx, &lot)
+	}
+
+	lots, err := s.repo.GetOpenLotsForUpdateTx(ctx, tx, portfolioID, trade.Symbol)
+	if err != nil {
+		return err
+	}
+	settings, err := s.settingsFor(ctx, &models.Portfolio{ID: portfolioID, UserID: trade.UserID})
+	if err != nil {
+		return fmt.Errorf("failed to get portfolio settings: %w", err)
+	}
+	lots = domain.OrderLots(lots, settings.CostBasisMethod)
+	for _, lot := range domain.ConsumeLots(lots, trade.Quantity, *trade.ExecutedAt) {
+		if err := s.repo.UpdateLotTx(ctx, tx, &lot); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // GetTradeHistory retrieves trade history for a portfolio
-func (s *PortfolioService) GetTradeHistory(ctx context.Context, userID int, limit, offset int) ([]models.Trade, error) {
-	return s.repo.GetTradesByUserID(ctx, userID, limit, offset)
+func (s *PortfolioService) GetTradeHistory(ctx context.Context, portfolioID int, limit, offset int) ([]models.Trade, error) {
+	return s.repo.GetTradesByPortfolioID(ctx, portfolioID, limit, offset)
+}
+
+// ListTradeHistory retrieves a page of a portfolio's trades
+func (s *PortfolioService) ListTradeHistory(ctx context.Context, portfolioID int, page pagination.Request) ([]models.Trade, pagination.Result, error) {
+	return s.repo.ListTradesByPortfolioID(ctx, portfolioID, page)
 }
 
 // GetSymbolTrades retrieves trades for a specific symbol
@@ -240,37 +547,76 @@ func (s *PortfolioService) GetPosition(ctx context.Context, userID int, portfoli
 	return s.repo.GetPositionByUserAndSymbol(ctx, userID, portfolioID, symbol)
 }
 
-// GetPositionSummary calculates detailed metrics for a specific position
+// GetPositionSummary calculates detailed metrics and the holding period for a specific position
 func (s *PortfolioService) GetPositionSummary(ctx context.Context, positionID int, currentPrice float64) (*models.PositionSummary, error) {
 	position, err := s.repo.GetPositionByID(ctx, positionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get position: %w", err)
 	}
 
-	summary := s.domain.CalculatePositionSummary(position, currentPrice)
+	lots, err := s.repo.GetOpenLots(ctx, position.PortfolioID, position.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := s.domain.CalculatePositionSummary(position, lots, currentPrice)
 	return &summary, nil
 }
 
+// GetPositionLots breaks a position's average entry price down into the lots
+// averaged into it, including those since sold
+func (s *PortfolioService) GetPositionLots(ctx context.Context, positionID int) (*models.LotBreakdown, error) {
+	position, err := s.repo.GetPositionByID(ctx, positionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position: %w", err)
+	}
+
+	lots, err := s.repo.GetLots(ctx, position.PortfolioID, position.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.settingsFor(ctx, &models.Portfolio{ID: position.PortfolioID, UserID: position.UserID})
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := domain.BreakDownLots(position, lots, settings.CostBasisMethod)
+	return &breakdown, nil
+}
+
 // Analysis Operations
 
 // GetPortfolioAllocation calculates allocation percentages for each position
 func (s *PortfolioService) GetPortfolioAllocation(ctx context.Context, portfolioID int, currentPrices map[string]float64) (map[string]float64, error) {
-	portfolio, err := s.repo.GetPortfolioByID(ctx, portfolioID)
+	portfolio, err := s.repo.GetPortfolioForRead(ctx, portfolioID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get portfolio: %w", err)
 	}
 
+	s.trackAnalysis(ctx, portfolio, "allocation")
+
 	return s.domain.CalculatePortfolioAllocation(portfolio, currentPrices), nil
 }
 
-// GetRiskMetrics calculates basic risk metrics for the portfolio
-func (s *PortfolioService) GetRiskMetrics(ctx context.Context, portfolioID int, currentPrices map[string]float64) (map[string]interface{}, error) {
+// GetRiskMetrics calculates risk and exposure metrics for the portfolio
+func (s *PortfolioService) GetRiskMetrics(ctx context.Context, portfolioID int, currentPrices map[string]float64, sectors map[string]string) (map[string]interface{}, error) {
 	portfolio, err := s.repo.GetPortfolioByID(ctx, portfolioID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get portfolio: %w", err)
 	}
 
-	return s.domain.CalculateRiskMetrics(portfolio, currentPrices), nil
+	s.trackAnalysis(ctx, portfolio, "risk")
+
+	metrics := s.domain.CalculateRiskMetrics(portfolio, currentPrices, sectors)
+
+	// Beta and factor exposures are best-effort, like sector metadata
+	exposure, err := s.GetFactorExposure(ctx, portfolio, currentPrices)
+	if err != nil {
+		s.logger.Warn("Failed to get factor exposure", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+	} else {
+		metrics["factor_exposure"] = exposure
+	}
+	return metrics, nil
 }
 
 // GetRebalanceRecommendations suggests portfolio rebalancing based on target allocations
@@ -280,33 +626,122 @@ func (s *PortfolioService) GetRebalanceRecommendations(ctx context.Context, port
 		return nil, fmt.Errorf("failed to get portfolio: %w", err)
 	}
 
+	s.trackAnalysis(ctx, portfolio, "rebalance")
+
 	return s.domain.RebalanceRecommendations(portfolio, targetAllocations, currentPrices), nil
 }
 
 // Portfolio Management
 
-// UpdatePortfolio updates portfolio information
-func (s *PortfolioService) UpdatePortfolio(ctx context.Context, portfolio *models.Portfolio) error {
-	err := s.repo.UpdatePortfolio(ctx, portfolio)
+// PatchPortfolio renames a portfolio or changes its description, strategy
+// tags or settings, leaving the fields the patch doesn't set as they are.
+// An empty base currency inherits the owner's default again. A patch based
+// on an earlier version of the portfolio is rejected with
+// domain.ErrVersionConflict.
+func (s *PortfolioService) PatchPortfolio(ctx context.Context, portfolioID int, patch domain.PortfolioPatch) (*models.Portfolio, error) {
+	tx, err := s.repo.BeginTx(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to update portfolio: %w", err)
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	s.logger.Info("Portfolio updated",
-		zap.Int("portfolio_id", portfolio.ID),
-		zap.Float64("cash", portfolio.Cash),
-		zap.Float64("total_value", portfolio.TotalValue))
+	portfolio, err := s.repo.GetPortfolioForUpdateTx(ctx, tx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.domain.CheckVersion(portfolio, patch.Version); err != nil {
+		return nil, err
+	}
+	overrides, err := s.repo.GetPortfolioSettings(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	details := domain.PortfolioDetails{
+		Name:         portfolio.Name,
+		Description:  portfolio.Description,
+		StrategyTags: portfolio.StrategyTags,
+	}
+	if overrides.BaseCurrency != nil {
+		details.BaseCurrency = *overrides.BaseCurrency
+	}
+	if err := s.domain.ApplyDetailsPatch(&details, patch); err != nil {
+		return nil, err
+	}
+
+	before := *portfolio
+	portfolio.Name = details.Name
+	portfolio.Description = details.Description
+	portfolio.StrategyTags = details.StrategyTags
+	if err := s.repo.UpdatePortfolioDetailsTx(ctx, tx, portfolio); err != nil {
+		return nil, err
+	}
+	if err := s.recordAudit(ctx, tx, newAuditEvent(ctx, portfolioID, models.AuditEntityPortfolio, portfolioID, models.AuditActionUpdate, &before, portfolio)); err != nil {
+		return nil, err
+	}
+
+	if patch.BaseCurrency != nil || patch.Settings != nil {
+		settingsBefore := snapshot(overrides)
+		if patch.BaseCurrency != nil {
+			overrides.BaseCurrency = nil
+			if details.BaseCurrency != "" {
+				overrides.BaseCurrency = &details.BaseCurrency
+			}
+		}
+		if err := s.applySettingsPatch(overrides, patch.Settings); err != nil {
+			return nil, err
+		}
+		userOverrides, err := s.repo.GetUserPortfolioSettings(ctx, portfolio.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.domain.ValidateSettings(s.domain.ResolveSettings(portfolioID, *overrides, *userOverrides)); err != nil {
+			return nil, err
+		}
+
+		if err := s.repo.SavePortfolioSettingsTx(ctx, tx, portfolioID, overrides); err != nil {
+			return nil, err
+		}
+		if err := s.recordAudit(ctx, tx, newAuditEvent(ctx, portfolioID, models.AuditEntitySettings, portfolioID, models.AuditActionUpdate, settingsBefore, overrides)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit portfolio patch: %w", err)
+	}
+	s.invalidateCaches(ctx, portfolioID)
+
+	s.logger.Info("Portfolio patched", zap.Int("portfolio_id", portfolioID), zap.Int("version", portfolio.Version))
+	return portfolio, nil
 }
 
 // DeletePortfolio deletes a portfolio and all its positions
 func (s *PortfolioService) DeletePortfolio(ctx context.Context, portfolioID int) error {
-	err := s.repo.DeletePortfolio(ctx, portfolioID)
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := s.repo.GetPortfolioForUpdateTx(ctx, tx, portfolioID)
+	if err != nil {
+		return fmt.Errorf("failed to get portfolio: %w", err)
+	}
+
+	err = s.repo.DeletePortfolioTx(ctx, tx, portfolioID)
 	if err != nil {
 		return fmt.Errorf("failed to delete portfolio: %w", err)
 	}
 
+	if err := s.recordAudit(ctx, tx, newAuditEvent(ctx, portfolioID, models.AuditEntityPortfolio, portfolioID, models.AuditActionDelete, before, nil)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit portfolio delete: %w", err)
+	}
+	s.invalidateCaches(ctx, portfolioID)
+
 	s.logger.Info("Portfolio deleted", zap.Int("portfolio_id", portfolioID))
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Position Alert Operations
+
+// CreatePositionAlert validates and saves an alert on a portfolio's
+// position in symbol
+func (s *PortfolioService) CreatePositionAlert(ctx context.Context, portfolioID int, symbol string, alert *models.PositionAlert) error {
+	portfolio, err := s.repo.GetPortfolioByID(ctx, portfolioID)
+	if err != nil {
+		return err
+	}
+	position, err := s.repo.GetPositionByUserAndSymbol(ctx, portfolio.UserID, portfolioID, symbol)
+	if err != nil {
+		return err
+	}
+	if position == nil {
+		return fmt.Errorf("%w: %s", domain.ErrPositionNotFound, symbols.Normalize(symbol))
+	}
+
+	alert.PortfolioID = portfolioID
+	alert.PositionID = position.ID
+	alert.UserID = position.UserID
+	alert.Symbol = position.Symbol
+	alert.EntryPrice = position.EntryPrice
+	alert.Quantity = position.Quantity
+	alert.IsActive = true
+	if err := s.domain.ValidatePositionAlert(alert); err != nil {
+		return err
+	}
+	if err := s.repo.CreatePositionAlert(ctx, alert); err != nil {
+		return err
+	}
+
+	s.logger.Info("Position alert created",
+		zap.Int("alert_id", alert.ID),
+		zap.Int("portfolio_id", portfolioID),
+		zap.String("symbol", alert.Symbol))
+	return nil
+}
+
+// GetPositionAlert returns a position alert
+func (s *PortfolioService) GetPositionAlert(ctx context.Context, alertID int) (*models.PositionAlert, error) {
+	return s.repo.GetPositionAlert(ctx, alertID)
+}
+
+// GetPositionAlerts returns a portfolio's position alerts, only those on
+// symbol unless it is empty
+func (s *PortfolioService) GetPositionAlerts(ctx context.Context, portfolioID int, symbol string) ([]models.PositionAlert, error) {
+	if _, err := s.repo.GetPortfolioByID(ctx, portfolioID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPositionAlertsByPortfolioID(ctx, portfolioID, symbol)
+}
+
+// UpdatePositionAlert validates and saves new levels, note and active state
+// for an alert. Activating it re-arms an alert that has fired.
+func (s *PortfolioService) UpdatePositionAlert(ctx context.Context, alert *models.PositionAlert) error {
+	if err := s.domain.ValidatePositionAlert(alert); err != nil {
+		return err
+	}
+	if alert.IsActive {
+		alert.TriggeredAt = nil
+		alert.TriggeredPrice = nil
+	}
+	return s.repo.UpdatePositionAlert(ctx, alert)
+}
+
+// DeletePositionAlert deletes a position alert
+func (s *PortfolioService) DeletePositionAlert(ctx context.Context, alertID int) error {
+	return s.repo.DeletePositionAlert(ctx, alertID)
+}
+
+// CheckPositionAlerts fires the alerts on positions in symbol whose level
+// is reached at price and returns how many fired
+func (s *PortfolioService) CheckPositionAlerts(ctx context.Context, symbol string, price float64) (int, error) {
+	alerts, err := s.repo.GetActivePositionAlerts(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	fired := 0
+	for i := range alerts {
+		alert := &alerts[i]
+		direction, level := s.domain.PositionAlertCrossed(alert, price)
+		if direction == "" {
+			continue
+		}
+
+		claimed, err := s.repo.ClaimPositionAlert(ctx, alert.ID, price, time.Now())
+		if err != nil {
+			s.logger.Error("Failed to fire position alert", zap.Error(err),
+				zap.Int("alert_id", alert.ID), zap.String("symbol", alert.Symbol))
+			continue
+		}
+		if !claimed {
+			continue // Fired or deactivated since it was loaded
+		}
+
+		s.logger.Info("Position alert fired",
+			zap.Int("alert_id", alert.ID),
+			zap.Int("portfolio_id", alert.PortfolioID),
+			zap.String("symbol", alert.Symbol),
+			zap.String("direction", direction),
+			zap.Float64("level", level),
+			zap.Float64("price", price))
+		s.notifyPositionAlert(ctx, alert, direction, level, price)
+		fired++
+	}
+	return fired, nil
+}
+
+// notifyPositionAlert tells the owner a position alert fired, with the
+// position and level it was set on
+func (s *PortfolioService) notifyPositionAlert(ctx context.Context, alert *models.PositionAlert, direction string, level, price float64) {
+	if s.notifications == nil || !s.notifies(ctx, alert.UserID, alert.PortfolioID, "position_alert") {
+		return
+	}
+
+	percent := alert.UpPercent
+	if direction == models.PositionAlertDown {
+		percent = alert.DownPercent
+	}
+	data := map[string]interface{}{
+		"alert_id":       alert.ID,
+		"portfolio_id":   alert.PortfolioID,
+		"symbol":         alert.Symbol,
+		"quantity":       alert.Quantity,
+		"direction":      direction,
+		"percent":        *percent,
+		"level":          level,
+		"entry_price":    alert.EntryPrice,
+		"price":          price,
+		"change_percent": (price/alert.EntryPrice - 1) * 100,
+		"note":           alert.Note,
+	}
+	if _, err := s.notifications.EnqueueNotification(alert.UserID, "position_alert", "", "", data, nil); err != nil {
+		s.logger.Warn("Failed to enqueue position alert notification", zap.Error(err), zap.Int("alert_id", alert.ID))
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Dry Run Operations
+
+// TradePreview is the projected outcome of a trade that was validated but not
+// executed. Position is nil when the trade would close the position.
+type TradePreview struct {
+	Trade      *models.Trade
+	Position   *models.Position
+	Venue      string // Live venues may fill at a different price
+	CashBefore float64
+	CashAfter  float64
+}
+
+// PreviewTrade runs the same re-quote and validation as ExecuteTrade and
+// applies the trade to an in-memory copy of the portfolio at the price it
+// would fill at. Nothing is locked, submitted, saved or recorded in the
+// trade event stream.
+func (s *PortfolioService) PreviewTrade(ctx context.Context, portfolioID int, trade *models.Trade, currentPrice float64) (*TradePreview, error) {
+	portfolio, err := s.repo.GetPortfolioByID(ctx, portfolioID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get portfolio: %w", err)
+	}
+	if err := s.loadSettings(ctx, portfolio); err != nil {
+		return nil, err
+	}
+
+	currentPrice, err = s.requote(ctx, portfolio.UserID, trade, currentPrice)
+	if err != nil {
+		return nil, fmt.Errorf("trade rejected: %w", err)
+	}
+
+	if err := s.domain.ValidateTradeOrder(trade, portfolio, currentPrice); err != nil {
+		return nil, fmt.Errorf("trade validation failed: %w", err)
+	}
+
+	venue, _, err := s.venueFor(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &TradePreview{Trade: trade, Venue: venue.Name(), CashBefore: portfolio.Cash}
+	trade.PortfolioID = portfolioID
+	preview.Position, err = s.domain.ExecuteTradeOrder(trade, portfolio, currentPrice)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute trade: %w", err)
+	}
+	if preview.Position != nil {
+		preview.Position.PortfolioID = portfolioID
+	}
+	trade.Status = RebalanceTradeSimulated
+	trade.ExecutedAt = nil
+	preview.CashAfter = portfolio.Cash
+
+	return preview, nil
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+)
+
+// generatedPasswordAlphabet leaves out characters that are easily confused
+// when read off a handout
+const generatedPasswordAlphabet = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const generatedPasswordLength = 12
+
+// Provision creates the users a spec describes, each with a paper portfolio
+// holding the starting cash and preset positions. The batch is created in
+// one transaction, so a conflicting username or email creates nothing.
+func (s *PortfolioService) Provision(ctx context.Context, spec *domain.ProvisionSpec) (*domain.ProvisionResult, error) {
+	users, err := domain.ExpandProvisionSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var sharedHash string
+	if spec.Password != "" {
+		if sharedHash, err = hashPassword(spec.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result := &domain.ProvisionResult{Users: make([]domain.ProvisionedUser, 0, len(users))}
+	for _, u := range users {
+		provisioned := domain.ProvisionedUser{Username: u.Username, Email: u.Email}
+
+		hash := sharedHash
+		if hash == "" {
+			if provisioned.Password, err = generatePassword(); err != nil {
+				return nil, err
+			}
+			if hash, err = hashPassword(provisioned.Password); err != nil {
+				return nil, err
+			}
+		}
+
+		user := &models.User{
+			Username:     u.Username,
+			Email:        u.Email,
+			PasswordHash: hash,
+			FullName:     u.FullName,
+			Role:         spec.Role,
+		}
+		if err := s.repo.CreateUserTx(ctx, tx, user); err != nil {
+			return nil, err
+		}
+		provisioned.UserID = user.ID
+
+		portfolio, err := s.seedPortfolio(ctx, tx, user.ID, spec.PortfolioName, u, now)
+		if err != nil {
+			return nil, err
+		}
+		provisioned.PortfolioID = portfolio.ID
+
+		result.Users = append(result.Users, provisioned)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit provisioning: %w", err)
+	}
+
+	s.logger.Info("Users provisioned", zap.Int("users", len(result.Users)))
+	return result, nil
+}
+
+// seedPortfolio creates a provisioned user's paper portfolio. Preset
+// positions are granted at their price, each as one lot acquired now.
+func (s *PortfolioService) seedPortfolio(ctx context.Context, tx *sql.Tx, userID int, name string, u domain.ProvisionUser, now time.Time) (*models.Portfolio, error) {
+	cash := *u.StartingCash
+	portfolio := &models.Portfolio{
+		UserID:          userID,
+		Name:            name,
+		Cash:            cash,
+		MarginAvailable: cash * 0.5, // 50% margin
+		TotalValue:      cash,
+	}
+	for _, p := range u.Positions {
+		portfolio.TotalValue += float64(p.Quantity) * p.Price * domain.Multiplier(p.Symbol)
+	}
+	if err := s.repo.CreatePortfolioTx(ctx, tx, portfolio); err != nil {
+		return nil, err
+	}
+
+	for _, p := range u.Positions {
+		position := &models.Position{
+			UserID:       userID,
+			PortfolioID:  portfolio.ID,
+			Symbol:       p.Symbol,
+			AssetType:    domain.AssetType(p.Symbol),
+			Quantity:     float64(p.Quantity),
+			Multiplier:   domain.Multiplier(p.Symbol),
+			Side:         "long",
+			EntryPrice:   p.Price,
+			CurrentPrice: p.Price,
+		}
+		if err := s.repo.CreatePositionTx(ctx, tx, position); err != nil {
+			return nil, err
+		}
+		portfolio.Positions = append(portfolio.Positions, *position)
+
+		lot := &models.PositionLot{
+			PortfolioID:       portfolio.ID,
+			Symbol:            p.Symbol,
+			Quantity:          float64(p.Quantity),
+			RemainingQuantity: float64(p.Quantity),
+			Price:             p.Price,
+			AcquiredAt:        now,
+		}
+		if err := s.repo.CreateLotTx(ctx, tx, lot); err != nil {
+			return nil, err
+		}
+	}
+	return portfolio, nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+func generatePassword() (string, error) {
+	max := big.NewInt(int64(len(generatedPasswordAlphabet)))
+	password := make([]byte, generatedPasswordLength)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		password[i] = generatedPasswordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/broker"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Rebalance Operations
+
+// Statuses of the trades of a rebalance
+const (
+	RebalanceTradeFilled     = "filled"      // Executed and committed
+	RebalanceTradeSimulated  = "simulated"   // Would execute; dry run
+	RebalanceTradeFailed     = "failed"      // Rejected; the rebalance was not applied
+	RebalanceTradeRolledBack = "rolled_back" // Valid, but discarded because another trade failed
+	RebalanceTradeSkipped    = "skipped"     // Not attempted after an earlier failure
+)
+
+// RebalanceTradeResult reports the outcome of one trade of a rebalance
+type RebalanceTradeResult struct {
+	Trade  *models.Trade
+	Status string
+	Error  string
+}
+
+// RebalanceResult is the outcome of executing a rebalance. Applied is false
+// for dry runs and when any trade failed, in which case nothing was changed.
+type RebalanceResult struct {
+	PortfolioID int
+	DryRun      bool
+	Applied     bool
+	CashBefore  float64
+	CashAfter   float64
+	Trades      []RebalanceTradeResult
+}
+
+// ExecuteRebalance moves a portfolio towards targetAllocations by executing
+// the planned orders (sells first) as market orders at currentPrices. The
+// trades are applied all-or-nothing: if any is rejected none are committed.
+// A dry run validates and simulates the same trades without saving anything.
+// A rebalance is planned against the portfolio locked as trades are, so
+// trades committed while it runs are neither lost nor overdrawn.
+func (s *PortfolioService) ExecuteRebalance(ctx context.Context, portfolioID int, targetAllocations map[string]float64, currentPrices map[string]float64, dryRun bool) (*RebalanceResult, error) {
+	// Live fills arrive asynchronously, so only paper portfolios can rebalance atomically
+	venue, _, err := s.venueFor(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if venue.Name() != broker.Paper {
+		return nil, domain.ErrLiveRebalance
+	}
+
+	var tx *sql.Tx
+	var portfolio *models.Portfolio
+	if dryRun {
+		portfolio, err = s.repo.GetPortfolioByID(ctx, portfolioID)
+	} else {
+		tx, err = s.repo.BeginTx(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		// A rebalance made for a queue job is made once, however often the job runs
+		if _, err := s.ledger.Step(ctx, rebalanceStep(portfolioID)); err != nil {
+			return nil, err
+		}
+		portfolio, err = s.repo.GetPortfolioForUpdateTx(ctx, tx, portfolioID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get portfolio: %w", err)
+	}
+	if err := s.loadSettings(ctx, portfolio); err != nil {
+		return nil, err
+	}
+	portfolioBefore := snapshot(portfolio)
+
+	competition, tradesToday, err := s.competitionFor(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := s.domain.PlanRebalance(portfolio, targetAllocations, currentPrices)
+	result := &RebalanceResult{
+		PortfolioID: portfolioID,
+		DryRun:      dryRun,
+		CashBefore:  portfolio.Cash,
+		Trades:      make([]RebalanceTradeResult, len(orders)),
+	}
+
+	// Apply the trades in memory; positions are copied as each symbol is traded once
+	positions := make([]*models.Position, len(orders))
+	failed := -1
+	for i, order := range orders {
+		trade := &models.Trade{
+			UserID:      portfolio.UserID,
+			PortfolioID: portfolioID,
+			Symbol:      order.Symbol,
+			Quantity:    order.Quantity,
+			Side:        order.Side,
+			Type:        "market",
+			Status:      "pending",
+		}
+		result.Trades[i].Trade = trade
+
+		if failed >= 0 {
+			result.Trades[i].Status = RebalanceTradeSkipped
+			continue
+		}
+
+		err := s.domain.ValidateTradeOrder(trade, portfolio, order.Price)
+		if err == nil && competition != nil {
+			err = domain.CheckCompetitionTrade(competition, portfolio, trade, order.Price, tradesToday+i, time.Now())
+		}
+		var position *models.Position
+		if err == nil {
+			position, err = s.domain.ExecuteTradeOrder(trade, portfolio, order.Price)
+		}
+		if err != nil {
+			failed = i
+			result.Trades[i].Status = RebalanceTradeFailed
+			result.Trades[i].Error = err.Error()
+			if !dryRun {
+				s.recordTradeEvent(ctx, nil, newTradeEvent(ctx, portfolioID, trade, models.TradeEventRejected, order.Price, err))
+			}
+			continue
+		}
+		if position != nil {
+			saved := *position
+			positions[i] = &saved
+		}
+		result.Trades[i].Status = RebalanceTradeSimulated
+	}
+
+	if failed >= 0 {
+		for i := 0; i < failed; i++ {
+			result.Trades[i].Status = RebalanceTradeRolledBack
+		}
+		result.CashAfter = result.CashBefore
+		return result, nil
+	}
+
+	result.CashAfter = portfolio.Cash
+	if dryRun || len(orders) == 0 {
+		return result, nil
+	}
+
+	if err := s.saveRebalance(ctx, tx, portfolio, portfolioBefore, result.Trades, positions); err != nil {
+		return nil, err
+	}
+
+	for i := range result.Trades {
+		result.Trades[i].Status = RebalanceTradeFilled
+		s.trackTrade(ctx, portfolio.UserID, result.Trades[i].Trade, broker.Paper)
+		s.confirmTrade(ctx, portfolio.UserID, result.Trades[i].Trade)
+	}
+	result.Applied = true
+
+	s.logger.Info("Portfolio rebalanced",
+		zap.Int("portfolio_id", portfolioID),
+		zap.Int("trades", len(orders)),
+		zap.Float64("cash_before", result.CashBefore),
+		zap.Float64("cash_after", result.CashAfter))
+
+	return result, nil
+}
+
+// saveRebalance persists the trades of a rebalance and the resulting
+// portfolio, and commits tx, in which the portfolio was locked. Positions and
+// trades are written in bulk rather than a few statements per trade.
+func (s *PortfolioService) saveRebalance(ctx context.Context, tx *sql.Tx, portfolio *models.Portfolio, portfolioBefore json.RawMessage, trades []RebalanceTradeResult, positions []*models.Position) error {
+	existing, err := s.repo.GetPositionsForUpdateTx(ctx, tx, portfolio.ID)
+	if err != nil {
+		return err
+	}
+	saved := make([]*models.Trade, len(trades))
+	for i := range trades {
+		saved[i] = trades[i].Trade
+	}
+	if err = s.savePositionsTx(ctx, tx, portfolio.ID, existing, saved, positions); err != nil {
+		return err
+	}
+	if err = s.repo.CreateTradesTx(ctx, tx, saved); err != nil {
+		return fmt.Errorf("failed to create trade records: %w", err)
+	}
+
+	for _, trade := range saved {
+		if err = s.saveLotsTx(ctx, tx, portfolio.ID, trade); err != nil {
+			return err
+		}
+		err = s.recordAudit(ctx, tx, newAuditEvent(ctx, portfolio.ID, models.AuditEntityTrade, trade.ID, models.AuditActionCreate, nil, trade))
+		if err != nil {
+			return err
+		}
+		if err = s.recordTradeEvent(ctx, tx, newTradeEvent(ctx, portfolio.ID, trade, models.TradeEventFilled, trade.Price, nil)); err != nil {
+			return err
+		}
+	}
+
+	if err = s.repo.UpdatePortfolioTx(ctx, tx, portfolio); err != nil {
+		return fmt.Errorf("failed to update portfolio: %w", err)
+	}
+	err = s.recordAudit(ctx, tx, newAuditEvent(ctx, portfolio.ID, models.AuditEntityPortfolio, portfolio.ID, models.AuditActionUpdate, portfolioBefore, portfolio))
+	if err != nil {
+		return err
+	}
+
+	tradeIDs := make([]int, len(saved))
+	for i, trade := range saved {
+		tradeIDs[i] = trade.ID
+	}
+	if err = s.recordJobStep(ctx, tx, rebalanceStep(portfolio.ID), tradeIDs); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	s.invalidateCaches(ctx, portfolio.ID)
+	return nil
+}
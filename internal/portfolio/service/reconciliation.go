@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/broker"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/redis"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+// ReconciliationActor is recorded on audit events for automatic corrections
+const ReconciliationActor = "reconciliation"
+
+const statementDateLayout = "2006-01-02"
+
+// ReconciliationService compares broker-linked portfolios against their broker
+// statements, applies benign corrections and alerts on unexplained breaks
+type ReconciliationService struct {
+	portfolios *PortfolioService
+	statements broker.StatementClient
+	queue      *queue.Manager
+	redis      *redis.Client
+	tolerance  domain.ReconcileTolerance
+	logger     *zap.Logger
+}
+
+// NewReconciliationService creates a reconciliation service. statements may be
+// nil when no broker is configured, in which case runs fail with an error.
+func NewReconciliationService(portfolios *PortfolioService, statements broker.StatementClient, queueManager *queue.Manager, redisClient *redis.Client, tolerance domain.ReconcileTolerance, logger *zap.Logger) *ReconciliationService {
+	return &ReconciliationService{
+		portfolios: portfolios,
+		statements: statements,
+		queue:      queueManager,
+		redis:      redisClient,
+		tolerance:  tolerance,
+		logger:     logger,
+	}
+}
+
+// LinkBrokerAccount marks a portfolio as mirrored at a broker account
+func (s *ReconciliationService) LinkBrokerAccount(ctx context.Context, portfolioID int, brokerName, accountID, tradingMode string) (*models.BrokerAccount, error) {
+	if _, err := s.portfolios.repo.GetPortfolioByID(ctx, portfolioID); err != nil {
+		return nil, err
+	}
+	if tradingMode == models.TradingModeLive {
+		if _, err := s.portfolios.brokers.Get(brokerName); err != nil {
+			return nil, err
+		}
+	}
+
+	account := &models.BrokerAccount{PortfolioID: portfolioID, Broker: brokerName, AccountID: accountID, TradingMode: tradingMode}
+	if err := s.portfolios.repo.UpsertBrokerAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// GetRuns returns a page of a portfolio's reconciliation runs
+func (s *ReconciliationService) GetRuns(ctx context.Context, portfolioID int, page pagination.Request) ([]models.ReconciliationRun, pagination.Result, error) {
+	return s.portfolios.repo.GetReconciliationRuns(ctx, portfolioID, page)
+}
+
+// GetRun returns a reconciliation run with its breaks
+func (s *ReconciliationService) GetRun(ctx context.Context, runID int) (*models.ReconciliationRun, error) {
+	return s.portfolios.repo.GetReconciliationRun(ctx, runID)
+}
+
+// Reconcile compares a portfolio's trades for the statement date, and its
+// current positions, against the broker. Re-running a date replaces its breaks.
+func (s *ReconciliationService) Reconcile(ctx context.Context, portfolioID int, statementDate time.Time) (*models.ReconciliationRun, error) {
+	if s.statements == nil {
+		return nil, fmt.Errorf("broker reconciliation is not configured")
+	}
+
+	account, err := s.portfolios.repo.GetBrokerAccount(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	day := time.Date(statementDate.Year(), statementDate.Month(), statementDate.Day(), 0, 0, 0, 0, time.UTC)
+	run, err := s.portfolios.repo.StartReconciliationRun(ctx, portfolioID, day)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.reconcile(ctx, account, run); err != nil {
+		if failErr := s.portfolios.repo.FailReconciliationRun(ctx, run.ID, err.Error()); failErr != nil {
+			s.logger.Error("Failed to record reconciliation failure", zap.Error(failErr), zap.Int("run_id", run.ID))
+		}
+		return nil, err
+	}
+
+	s.logger.Info("Reconciliation completed",
+		zap.Int("portfolio_id", portfolioID),
+		zap.String("statement_date", day.Format(statementDateLayout)),
+		zap.Int("auto_fixed", run.AutoFixed),
+		zap.Int("open_breaks", run.OpenBreaks))
+
+	return run, nil
+}
+
+func (s *ReconciliationService) reconcile(ctx context.Context, account *models.BrokerAccount, run *models.ReconciliationRun) error {
+	start, end := run.StatementDate, run.StatementDate.AddDate(0, 0, 1)
+
+	brokerPositions, err := s.statements.GetPositions(ctx, account.AccountID)
+	if err != nil {
+		return err
+	}
+	brokerTransactions, err := s.statements.GetTransactions(ctx, account.AccountID, start, end)
+	if err != nil {
+		return err
+	}
+	positions, err := s.portfolios.repo.GetPositionsByPortfolioID(ctx, run.PortfolioID)
+	if err != nil {
+		return err
+	}
+	trades, err := s.portfolios.repo.GetFilledTradesByPortfolioID(ctx, run.PortfolioID, start, end)
+	if err != nil {
+		return err
+	}
+
+	run.Breaks = s.portfolios.domain.Reconcile(domain.ReconcileInput{
+		Positions:          positions,
+		Trades:             trades,
+		BrokerPositions:    brokerPositions,
+		BrokerTransactions: brokerTransactions,
+	}, s.tolerance)
+	run.PositionsChecked = len(positions)
+	run.TradesChecked = len(trades)
+	for _, b := range run.Breaks {
+		if b.Resolution == models.ResolutionAutoFixed {
+			run.AutoFixed++
+		} else {
+			run.OpenBreaks++
+		}
+	}
+
+	tx, err := s.portfolios.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if run.AutoFixed > 0 {
+		if err := s.applyFixes(ctx, tx, run, trades); err != nil {
+			return err
+		}
+	}
+
+	if err := s.portfolios.repo.CompleteReconciliationRunTx(ctx, tx, run); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reconciliation: %w", err)
+	}
+	if run.AutoFixed > 0 {
+		s.portfolios.invalidateCaches(ctx, run.PortfolioID)
+	}
+
+	if run.OpenBreaks > 0 {
+		s.alert(ctx, run)
+	}
+	return nil
+}
+
+// applyFixes corrects trades to the broker's fees and price for auto-fixed
+// breaks and moves the difference into portfolio cash
+func (s *ReconciliationService) applyFixes(ctx context.Context, tx *sql.Tx, run *models.ReconciliationRun, trades []models.Trade) error {
+	byID := make(map[int]*models.Trade, len(trades))
+	for i := range trades {
+		byID[trades[i].ID] = &trades[i]
+	}
+
+	corrected := make(map[int]models.Trade)
+	var order []int
+	for _, b := range run.Breaks {
+		if b.Resolution != models.ResolutionAutoFixed {
+			continue
+		}
+		trade, ok := byID[b.TradeID]
+		if !ok {
+			continue
+		}
+		fixed, seen := corrected[trade.ID]
+		if !seen {
+			fixed = *trade
+			order = append(order, trade.ID)
+		}
+		switch b.BreakType {
+		case models.BreakFeeMismatch:
+			fixed.Fees = b.BrokerValue
+		case models.BreakPriceRounding:
+			fixed.Price = b.BrokerValue
+		}
+		corrected[trade.ID] = fixed
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	portfolio, err := s.portfolios.repo.GetPortfolioByID(ctx, run.PortfolioID)
+	if err != nil {
+		return err
+	}
+	beforePortfolio := *portfolio
+
+	ctx = requestctx.WithActor(ctx, ReconciliationActor)
+	for _, id := range order {
+		original, fixed := byID[id], corrected[id]
+		if err := s.portfolios.repo.UpdateTradeExecutionTx(ctx, tx, id, fixed.Price, fixed.Fees); err != nil {
+			return err
+		}
+
+		// A buy paid price*qty + fees, a sell received price*qty - fees
+		notional := fixed.Value(original.Price - fixed.Price)
+		if fixed.Side == "sell" {
+			notional = -notional
+		}
+		portfolio.Cash += notional + original.Fees - fixed.Fees
+
+		audit := newAuditEvent(ctx, run.PortfolioID, models.AuditEntityTrade, id, models.AuditActionUpdate, original, &fixed)
+		if err := s.portfolios.recordAudit(ctx, tx, audit); err != nil {
+			return err
+		}
+	}
+
+	portfolio.Cash = math.Round(portfolio.Cash*100) / 100
+	if portfolio.Cash == beforePortfolio.Cash {
+		return nil
+	}
+	if err := s.portfolios.repo.UpdatePortfolioTx(ctx, tx, portfolio); err != nil {
+		return err
+	}
+	audit := newAuditEvent(ctx, run.PortfolioID, models.AuditEntityPortfolio, run.PortfolioID, models.AuditActionUpdate, &beforePortfolio, portfolio)
+	return s.portfolios.recordAudit(ctx, tx, audit)
+}
+
+// alert notifies the portfolio owner and publishes a risk alert event for
+// unexplained breaks. Failures are logged; the run itself has been stored.
+func (s *ReconciliationService) alert(ctx context.Context, run *models.ReconciliationRun) {
+	data := map[string]interface{}{
+		"portfolio_id":   run.PortfolioID,
+		"run_id":         run.ID,
+		"statement_date": run.StatementDate.Format(statementDateLayout),
+		"open_breaks":    run.OpenBreaks,
+	}
+
+	s.logger.Warn("Unexplained reconciliation breaks",
+		zap.Int("portfolio_id", run.PortfolioID),
+		zap.Int("run_id", run.ID),
+		zap.Int("open_breaks", run.OpenBreaks))
+
+	if s.redis != nil {
+		event := models.Event{
+			Type:      "reconciliation_break",
+			Source:    "portfolio-service",
+			Data:      data,
+			Timestamp: time.Now(),
+		}
+		if err := s.redis.PublishEvent(ctx, models.ChannelRiskAlerts, event); err != nil {
+			s.logger.Warn("Failed to publish reconciliation alert", zap.Error(err))
+		}
+	}
+
+	if s.queue != nil {
+		portfolio, err := s.portfolios.repo.GetPortfolioByID(ctx, run.PortfolioID)
+		if err != nil {
+			s.logger.Warn("Failed to load portfolio for reconciliation alert", zap.Error(err))
+			return
+		}
+		if !s.portfolios.notifies(ctx, portfolio.UserID, portfolio.ID, "reconciliation_break") {
+			return
+		}
+		if _, err := s.queue.EnqueueNotification(portfolio.UserID, "reconciliation_break", "", "", data, nil); err != nil {
+			s.logger.Warn("Failed to enqueue reconciliation alert", zap.Error(err))
+		}
+	}
+}
+
+// EnqueueDailyRuns enqueues a reconciliation job for every broker-linked portfolio
+func (s *ReconciliationService) EnqueueDailyRuns(ctx context.Context, statementDate time.Time) (int, error) {
+	accounts, err := s.portfolios.repo.ListBrokerAccounts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	enqueued := 0
+	for _, account := range accounts {
+		job := &models.Job{
+			ID:         uuid.New().String(),
+			Type:       models.JobTypeReconciliation,
+			Priority:   4,
+			MaxRetries: 3,
+			Payload: map[string]interface{}{
+				"portfolio_id":   account.PortfolioID,
+				"statement_date": statementDate.Format(statementDateLayout),
+			},
+		}
+		if err := s.queue.EnqueueJob(job); err != nil {
+			s.logger.Error("Failed to enqueue reconciliation", zap.Error(err), zap.Int("portfolio_id", account.PortfolioID))
+			continue
+		}
+		enqueued++
+	}
+
+	return enqueued, nil
+}
+
+// RunDailySchedule enqueues the day's reconciliation runs at hour (UTC) every
+// day until ctx is cancelled
+func (s *ReconciliationService) RunDailySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		count, err := s.EnqueueDailyRuns(ctx, next)
+		if err != nil {
+			s.logger.Error("Failed to enqueue daily reconciliation", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Daily reconciliation enqueued", zap.Int("portfolios", count))
+	}
+}
+
+// CanHandle implements queue.JobHandler
+func (s *ReconciliationService) CanHandle(jobType string) bool {
+	return jobType == models.JobTypeReconciliation
+}
+
+// Handle implements queue.JobHandler
+func (s *ReconciliationService) Handle(ctx context.Context, job *models.Job) error {
+	portfolioID, _ := job.Payload["portfolio_id"].(float64)
+	dateStr, _ := job.Payload["statement_date"].(string)
+	statementDate, err := time.Parse(statementDateLayout, dateStr)
+	if err != nil || portfolioID <= 0 {
+		job.Retries = job.MaxRetries // A malformed payload will not improve on retry
+		return fmt.Errorf("invalid reconciliation payload: portfolio_id=%v statement_date=%q", job.Payload["portfolio_id"], dateStr)
+	}
+
+	ctx = requestctx.WithActor(ctx, ReconciliationActor)
+	_, err = s.Reconcile(ctx, int(portfolioID), statementDate)
+	return err
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/flags"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+)
+
+// Quoter supplies the latest price market orders are re-quoted at
+type Quoter interface {
+	GetCurrentPrice(symbol string) (float64, error)
+}
+
+// SetRepricing re-quotes market orders just before they fill and rejects any
+// whose price has moved against them by more than maxSlippage percent of the
+// quoted price. Rejections are notified through notifications when it is set.
+// Without a quoter orders fill at the price they were quoted at.
+func (s *PortfolioService) SetRepricing(quotes Quoter, maxSlippage float64, notifications *queue.Manager) {
+	s.quotes = quotes
+	s.maxSlippage = maxSlippage
+	s.notifications = notifications
+}
+
+// requote returns the current price for a market order quoted at
+// quotedPrice, or ErrSlippageExceeded when it has moved too far. Orders of
+// users without the order engine flag fill at the quoted price.
+func (s *PortfolioService) requote(ctx context.Context, userID int, trade *models.Trade, quotedPrice float64) (float64, error) {
+	if s.quotes == nil || trade.Type != "market" || !s.featureOn(ctx, flags.OrderEngine, userID) {
+		return quotedPrice, nil
+	}
+
+	currentPrice, err := s.quotes.GetCurrentPrice(trade.Symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to re-quote %s: %w", trade.Symbol, err)
+	}
+	if err := s.domain.CheckSlippage(trade.Side, quotedPrice, currentPrice, s.maxSlippage); err != nil {
+		return 0, err
+	}
+
+	if currentPrice != quotedPrice {
+		s.logger.Debug("Market order re-quoted",
+			zap.String("symbol", trade.Symbol),
+			zap.Float64("quoted_price", quotedPrice),
+			zap.Float64("price", currentPrice))
+	}
+	return currentPrice, nil
+}
+
+// notifySlippage tells the user a market order was rejected because its
+// price moved beyond the tolerance
+func (s *PortfolioService) notifySlippage(ctx context.Context, userID, portfolioID int, trade *models.Trade, quotedPrice float64, err error) {
+	if s.notifications == nil || !errors.Is(err, domain.ErrSlippageExceeded) || !s.notifies(ctx, userID, portfolioID, "order_slippage_rejected") {
+		return
+	}
+
+	data := map[string]interface{}{
+		"portfolio_id": portfolioID,
+		"symbol":       trade.Symbol,
+		"side":         trade.Side,
+		"quantity":     trade.Quantity,
+		"quoted_price": quotedPrice,
+		"max_slippage": s.maxSlippage,
+		"reason":       err.Error(),
+	}
+	if _, qErr := s.notifications.EnqueueNotification(userID, "order_slippage_rejected", "", "", data, nil); qErr != nil {
+		s.logger.Warn("Failed to enqueue slippage notification", zap.Error(qErr), zap.Int("portfolio_id", portfolioID))
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Settings Operations
+
+// GetSettings returns a portfolio's effective settings, inherited from its
+// owner's defaults where the portfolio doesn't set them
+func (s *PortfolioService) GetSettings(ctx context.Context, portfolioID int) (*models.PortfolioSettings, error) {
+	portfolio, err := s.repo.GetPortfolioByID(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	return s.settingsFor(ctx, portfolio)
+}
+
+// UpdateSettings applies a JSON merge patch to the options set on a
+// portfolio and returns its effective settings. The patch is rejected when
+// the options it results in cannot be combined.
+func (s *PortfolioService) UpdateSettings(ctx context.Context, portfolioID int, patch map[string]json.RawMessage) (*models.PortfolioSettings, error) {
+	return s.saveSettings(ctx, portfolioID, patch, false)
+}
+
+// ReplaceSettings replaces the options set on a portfolio with those given;
+// options left out are inherited again
+func (s *PortfolioService) ReplaceSettings(ctx context.Context, portfolioID int, options map[string]json.RawMessage) (*models.PortfolioSettings, error) {
+	return s.saveSettings(ctx, portfolioID, options, true)
+}
+
+// saveSettings applies patch to the options set on a portfolio, or to none
+// when replacing them, and saves them unless the result cannot be combined
+func (s *PortfolioService) saveSettings(ctx context.Context, portfolioID int, patch map[string]json.RawMessage, replace bool) (*models.PortfolioSettings, error) {
+	portfolio, err := s.repo.GetPortfolioByID(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.repo.GetPortfolioSettings(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	userOverrides, err := s.repo.GetUserPortfolioSettings(ctx, portfolio.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(overrides)
+	if replace {
+		overrides = &models.SettingsOverrides{}
+	}
+	if err := s.applySettingsPatch(overrides, patch); err != nil {
+		return nil, err
+	}
+	settings := s.domain.ResolveSettings(portfolioID, *overrides, *userOverrides)
+	if err := s.domain.ValidateSettings(settings); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.SavePortfolioSettingsTx(ctx, tx, portfolioID, overrides); err != nil {
+		return nil, err
+	}
+	if err := s.recordAudit(ctx, tx, newAuditEvent(ctx, portfolioID, models.AuditEntitySettings, portfolioID, models.AuditActionUpdate, before, overrides)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit portfolio settings: %w", err)
+	}
+	s.invalidateCaches(ctx, portfolioID)
+
+	s.logger.Info("Portfolio settings updated", zap.Int("portfolio_id", portfolioID))
+	return &settings, nil
+}
+
+// GetUserSettings returns the defaults a user's portfolios inherit
+func (s *PortfolioService) GetUserSettings(ctx context.Context, userID int) (*models.PortfolioSettings, error) {
+	overrides, err := s.repo.GetUserPortfolioSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings := s.domain.ResolveSettings(0, models.SettingsOverrides{}, *overrides)
+	return &settings, nil
+}
+
+// UpdateUserSettings applies a JSON merge patch to a user's defaults. The
+// patch is rejected when the defaults, or the settings any of the user's
+// portfolios would inherit, cannot be combined.
+func (s *PortfolioService) UpdateUserSettings(ctx context.Context, userID int, patch map[string]json.RawMessage) (*models.PortfolioSettings, error) {
+	overrides, err := s.repo.GetUserPortfolioSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applySettingsPatch(overrides, patch); err != nil {
+		return nil, err
+	}
+
+	settings := s.domain.ResolveSettings(0, models.SettingsOverrides{}, *overrides)
+	if err := s.domain.ValidateSettings(settings); err != nil {
+		return nil, err
+	}
+	portfolios, err := s.repo.GetPortfolioSettingsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for portfolioID, portfolioOverrides := range portfolios {
+		if err := s.domain.ValidateSettings(s.domain.ResolveSettings(portfolioID, portfolioOverrides, *overrides)); err != nil {
+			return nil, fmt.Errorf("%w (inherited by portfolio %d)", err, portfolioID)
+		}
+	}
+
+	if err := s.repo.SaveUserPortfolioSettings(ctx, userID, overrides); err != nil {
+		return nil, err
+	}
+	// Every portfolio of the user inherits the defaults
+	for portfolioID := range portfolios {
+		s.invalidateCaches(ctx, portfolioID)
+	}
+
+	s.logger.Info("User portfolio settings updated", zap.Int("user_id", userID))
+	return &settings, nil
+}
+
+// settingsFor resolves a portfolio's effective settings
+func (s *PortfolioService) settingsFor(ctx context.Context, portfolio *models.Portfolio) (*models.PortfolioSettings, error) {
+	overrides, err := s.repo.GetPortfolioSettings(ctx, portfolio.ID)
+	if err != nil {
+		return nil, err
+	}
+	userOverrides, err := s.repo.GetUserPortfolioSettings(ctx, portfolio.UserID)
+	if err != nil {
+		return nil, err
+	}
+	settings := s.domain.ResolveSettings(portfolio.ID, *overrides, *userOverrides)
+	return &settings, nil
+}
+
+// loadSettings sets the portfolio's fee schedules from its settings, or its
+// equity fees from its execution venue when the settings set no fees, and
+// its position limit from its risk tolerance, so trades executed against it
+// are charged and checked accordingly
+func (s *PortfolioService) loadSettings(ctx context.Context, portfolio *models.Portfolio) error {
+	settings, err := s.settingsFor(ctx, portfolio)
+	if err != nil {
+		return fmt.Errorf("failed to get portfolio settings: %w", err)
+	}
+	fees := settings.Fees()
+
+	if len(s.brokerFees) > 0 && !settings.SetsFees() {
+		venue, _, err := s.venueFor(ctx, portfolio.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get execution venue: %w", err)
+		}
+		if venueFees, ok := s.brokerFees[venue.Name()]; ok {
+			fees = venueFees
+		}
+	}
+	portfolio.Fees = &fees
+	portfolio.CryptoFees = &settings.CryptoFeeSchedule
+	portfolio.MaxPosition = domain.RiskToleranceLimits[settings.RiskTolerance]
+	return nil
+}
+
+// notifies reports whether a user is sent notifications of a type about
+// their portfolio. Preferences that cannot be read don't hold notifications
+// back.
+func (s *PortfolioService) notifies(ctx context.Context, userID, portfolioID int, notificationType string) bool {
+	settings, err := s.settingsFor(ctx, &models.Portfolio{ID: portfolioID, UserID: userID})
+	if err != nil {
+		s.logger.Warn("Failed to get notification preferences", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		return true
+	}
+	return settings.Notifies(notificationType)
+}
+
+// applySettingsPatch merges patch into overrides and normalizes the
+// benchmark symbol it sets
+func (s *PortfolioService) applySettingsPatch(overrides *models.SettingsOverrides, patch map[string]json.RawMessage) error {
+	if err := s.domain.ApplySettingsPatch(overrides, patch); err != nil {
+		return err
+	}
+	if overrides.Benchmark != nil {
+		benchmark := symbols.Normalize(*overrides.Benchmark)
+		if err := symbols.Validate(benchmark); err != nil {
+			return fmt.Errorf("%w: benchmark: %v", domain.ErrInvalidSettings, err)
+		}
+		overrides.Benchmark = &benchmark
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/queue"
+)
+
+// StatementService compiles monthly account statements of portfolios from
+// their daily snapshots, cash ledger and trades, and notifies their owners
+type StatementService struct {
+	portfolios *PortfolioService
+	queue      *queue.Manager
+	logger     *zap.Logger
+}
+
+// NewStatementService creates a statement service. queueManager may be nil,
+// in which case owners are not notified of new statements.
+func NewStatementService(portfolios *PortfolioService, queueManager *queue.Manager, logger *zap.Logger) *StatementService {
+	return &StatementService{
+		portfolios: portfolios,
+		queue:      queueManager,
+		logger:     logger,
+	}
+}
+
+// Compile compiles and saves a portfolio's statement for the month
+// containing month. It returns false, with the statement compiled but not
+// saved, when the month already has one.
+func (s *StatementService) Compile(ctx context.Context, portfolioID int, month time.Time) (*models.AccountStatement, bool, error) {
+	portfolio, err := s.portfolios.repo.GetPortfolioByID(ctx, portfolioID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	month = domain.StatementMonth(month)
+	next := month.AddDate(0, 1, 0)
+	closing, err := s.portfolios.repo.GetSnapshotOnOrBefore(ctx, portfolioID, next.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, false, err
+	}
+	if closing == nil || closing.SnapshotDate.Before(month) {
+		return nil, false, fmt.Errorf("%w: portfolio %d has no snapshots in %s", domain.ErrInsufficientHistory, portfolioID, month.Format("2006-01"))
+	}
+
+	// Portfolios opened during the month start from their first snapshot
+	opening, err := s.portfolios.repo.GetSnapshotOnOrBefore(ctx, portfolioID, month.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, false, err
+	}
+	if opening == nil {
+		snapshots, err := s.portfolios.repo.GetSnapshotsBetween(ctx, portfolioID, month, next)
+		if err != nil {
+			return nil, false, err
+		}
+		opening = &snapshots[0]
+	}
+	if opening.ID == closing.ID {
+		return nil, false, fmt.Errorf("%w: portfolio %d has one snapshot in %s", domain.ErrInsufficientHistory, portfolioID, month.Format("2006-01"))
+	}
+
+	cash, err := s.portfolios.repo.GetCashTransactionsBetween(ctx, portfolioID, opening.CreatedAt, closing.CreatedAt)
+	if err != nil {
+		return nil, false, err
+	}
+	// Earlier trades set the cost of positions sold during the month
+	trades, err := s.portfolios.repo.GetFilledTradesByPortfolioID(ctx, portfolioID, time.Time{}, closing.CreatedAt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	statement := s.portfolios.domain.CompileStatement(portfolio, month, *opening, *closing, cash, trades)
+	created, err := s.portfolios.repo.CreateAccountStatement(ctx, &statement)
+	if err != nil {
+		return nil, false, err
+	}
+	return &statement, created, nil
+}
+
+// GetStatements returns a page of a portfolio's account statements
+func (s *StatementService) GetStatements(ctx context.Context, portfolioID int, page pagination.Request) ([]models.AccountStatement, pagination.Result, error) {
+	if _, err := s.portfolios.repo.GetPortfolioByID(ctx, portfolioID); err != nil {
+		return nil, pagination.Result{}, err
+	}
+	return s.portfolios.repo.GetAccountStatements(ctx, portfolioID, page)
+}
+
+// CompileAll compiles every active portfolio's statement for the month
+// containing month and notifies the owners of new ones. It returns how many
+// statements were saved. Portfolios that already have one, lack the
+// snapshots, or fail are logged and skipped, so a run can be repeated.
+func (s *StatementService) CompileAll(ctx context.Context, month time.Time) (int, error) {
+	portfolioIDs, err := s.portfolios.repo.ListActivePortfolioIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	compiled := 0
+	for _, portfolioID := range portfolioIDs {
+		statement, created, err := s.Compile(ctx, portfolioID, month)
+		if err != nil {
+			if errors.Is(err, domain.ErrInsufficientHistory) {
+				s.logger.Debug("Skipping account statement", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+			} else {
+				s.logger.Error("Failed to compile account statement", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+			}
+			continue
+		}
+		if !created {
+			continue
+		}
+		compiled++
+		s.notify(ctx, statement)
+	}
+
+	return compiled, nil
+}
+
+// notify tells a portfolio's owner their statement is ready. Failures are
+// logged.
+func (s *StatementService) notify(ctx context.Context, statement *models.AccountStatement) {
+	if s.queue == nil || !s.portfolios.notifies(ctx, statement.UserID, statement.PortfolioID, "account_statement") {
+		return
+	}
+
+	data := map[string]interface{}{
+		"portfolio_id":   statement.PortfolioID,
+		"statement_id":   statement.ID,
+		"month":          statement.Month.Format("January 2006"),
+		"starting_value": statement.StartingValue,
+		"ending_value":   statement.EndingValue,
+		"contributions":  statement.Contributions,
+		"withdrawals":    statement.Withdrawals,
+		"realized_pnl":   statement.RealizedPnL,
+		"unrealized_pnl": statement.UnrealizedPnL,
+		"fees":           statement.Fees,
+		"trade_count":    statement.TradeCount,
+	}
+	if _, err := s.queue.EnqueueNotification(statement.UserID, "account_statement", "", "", data, nil); err != nil {
+		s.logger.Warn("Failed to enqueue account statement notification", zap.Error(err),
+			zap.Int("portfolio_id", statement.PortfolioID))
+	}
+}
+
+// RunMonthlySchedule compiles the previous month's statements at hour (UTC)
+// on the first of every month until ctx is cancelled
+func (s *StatementService) RunMonthlySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := domain.StatementMonth(now).Add(time.Duration(hour) * time.Hour)
+		if !next.After(now) {
+			next = next.AddDate(0, 1, 0)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		month := domain.StatementMonth(next).AddDate(0, -1, 0)
+		compiled, err := s.CompileAll(ctx, month)
+		if err != nil {
+			s.logger.Error("Failed to compile monthly statements", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Monthly statements compiled", zap.String("month", month.Format("2006-01")), zap.Int("statements", compiled))
+	}
+}
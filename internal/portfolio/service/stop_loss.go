@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/redis"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Stop-Loss Operations
+
+// SetStopLoss replaces the stop-loss of a portfolio's position in symbol,
+// as a percentage below its entry price or an absolute price. Nil percent
+// and price clear it.
+func (s *PortfolioService) SetStopLoss(ctx context.Context, portfolioID int, symbol string, percent, price *float64) (*models.Position, error) {
+	portfolio, err := s.repo.GetPortfolioByID(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	position, err := s.repo.GetPositionByUserAndSymbol(ctx, portfolio.UserID, portfolioID, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if position == nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPositionNotFound, symbols.Normalize(symbol))
+	}
+
+	if err := s.domain.ValidateStopLoss(position, percent, price); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before := snapshot(position)
+	if err := s.repo.SetPositionStopLossTx(ctx, tx, position.ID, percent, price); err != nil {
+		return nil, err
+	}
+	position.StopLossPercent = percent
+	position.StopLossPrice = price
+	if err := s.recordAudit(ctx, tx, newAuditEvent(ctx, portfolioID, models.AuditEntityPosition, position.ID, models.AuditActionUpdate, before, position)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit stop-loss: %w", err)
+	}
+	s.invalidateCaches(ctx, portfolioID)
+
+	s.logger.Info("Stop-loss updated",
+		zap.Int("portfolio_id", portfolioID),
+		zap.Int("position_id", position.ID),
+		zap.String("symbol", position.Symbol))
+	return position, nil
+}
+
+// StopLossService closes positions whose stop-loss is hit and fires position
+// alerts, as price snapshots arrive on the conflated price channel
+type StopLossService struct {
+	portfolios *PortfolioService
+	redis      *redis.Client
+	logger     *zap.Logger
+}
+
+func NewStopLossService(portfolios *PortfolioService, redisClient *redis.Client, logger *zap.Logger) *StopLossService {
+	return &StopLossService{
+		portfolios: portfolios,
+		redis:      redisClient,
+		logger:     logger,
+	}
+}
+
+// Check closes the positions in symbol whose stop-loss is hit at price and
+// returns how many were closed. None are closed during maintenance; stops
+// still hit afterwards close on the next snapshot.
+func (s *StopLossService) Check(ctx context.Context, symbol string, price float64) (int, error) {
+	if s.portfolios.inMaintenance(ctx) {
+		return 0, nil
+	}
+
+	positions, err := s.portfolios.repo.GetStopLossPositions(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for i := range positions {
+		position := &positions[i]
+		order := s.portfolios.domain.StopLossOrder(position, price)
+		if order == nil {
+			continue
+		}
+		if err := s.trigger(ctx, position, order, price); err != nil {
+			s.logger.Error("Failed to close position at stop-loss", zap.Error(err),
+				zap.Int("portfolio_id", position.PortfolioID),
+				zap.Int("position_id", position.ID),
+				zap.String("symbol", position.Symbol))
+			continue
+		}
+		closed++
+	}
+	return closed, nil
+}
+
+// trigger submits the closing order of a position whose stop-loss was hit.
+// The stop is cleared first, so later price updates don't submit it again,
+// and restored if the order fails.
+func (s *StopLossService) trigger(ctx context.Context, position *models.Position, order *models.Trade, price float64) error {
+	claimed, err := s.portfolios.repo.ClaimStopLoss(ctx, position.ID)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return nil // Cleared or triggered since it was loaded
+	}
+
+	trigger, _ := position.StopLossTrigger()
+	if _, err := s.portfolios.ExecuteTrade(ctx, position.PortfolioID, order, price); err != nil {
+		if restoreErr := s.portfolios.repo.SetPositionStopLoss(ctx, position.ID, position.StopLossPercent, position.StopLossPrice); restoreErr != nil {
+			s.logger.Error("Failed to restore stop-loss", zap.Error(restoreErr), zap.Int("position_id", position.ID))
+		}
+		return err
+	}
+
+	s.logger.Info("Stop-loss triggered",
+		zap.Int("portfolio_id", position.PortfolioID),
+		zap.Int("position_id", position.ID),
+		zap.Int("trade_id", order.ID),
+		zap.String("symbol", position.Symbol),
+		zap.Float64("trigger_price", trigger),
+		zap.Float64("price", price))
+	s.notify(ctx, position, order, trigger, price)
+	return nil
+}
+
+// notify tells the owner a position was closed at its stop-loss
+func (s *StopLossService) notify(ctx context.Context, position *models.Position, order *models.Trade, trigger, price float64) {
+	if s.portfolios.notifications == nil || !s.portfolios.notifies(ctx, position.UserID, position.PortfolioID, "stop_loss_triggered") {
+		return
+	}
+
+	data := map[string]interface{}{
+		"portfolio_id":  position.PortfolioID,
+		"trade_id":      order.ID,
+		"symbol":        position.Symbol,
+		"quantity":      order.Quantity,
+		"entry_price":   position.EntryPrice,
+		"trigger_price": trigger,
+		"price":         price,
+	}
+	if _, err := s.portfolios.notifications.EnqueueNotification(position.UserID, "stop_loss_triggered", "", "", data, nil); err != nil {
+		s.logger.Warn("Failed to enqueue stop-loss notification", zap.Error(err), zap.Int("portfolio_id", position.PortfolioID))
+	}
+}
+
+// Run checks position alerts and stop-losses against each price snapshot
+// published on the conflated price channel until ctx is cancelled. Alerts are
+// checked first, since a position closed at its stop takes its alerts with it.
+// Snapshots carry the latest price of their interval, so a price that crosses
+// a level and returns within one interval is not acted on.
+func (s *StopLossService) Run(ctx context.Context) {
+	pubsub := s.redis.SubscribeToEvents(ctx, models.ChannelPriceSnapshots)
+	defer pubsub.Close()
+
+	updates := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-updates:
+			if !ok {
+				return
+			}
+
+			var update models.PriceUpdateEvent
+			if err := json.Unmarshal([]byte(message.Payload), &update); err != nil {
+				s.logger.Warn("Failed to decode price update", zap.Error(err))
+				continue
+			}
+
+			fired, err := s.portfolios.CheckPositionAlerts(ctx, update.Symbol, update.Price)
+			if err != nil {
+				s.logger.Error("Failed to check position alerts", zap.Error(err), zap.String("symbol", update.Symbol))
+			} else if fired > 0 {
+				s.logger.Info("Position alerts fired", zap.String("symbol", update.Symbol), zap.Int("alerts", fired))
+			}
+
+			closed, err := s.Check(ctx, update.Symbol, update.Price)
+			if err != nil {
+				s.logger.Error("Failed to check stop-losses", zap.Error(err), zap.String("symbol", update.Symbol))
+				continue
+			}
+			if closed > 0 {
+				s.logger.Info("Positions closed at stop-loss", zap.String("symbol", update.Symbol), zap.Int("positions", closed))
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+// Trade Event Operations
+
+const replayPageSize = 1000
+
+// ReplayResult compares positions rebuilt from the trade event stream with
+// the positions currently stored for a portfolio
+type ReplayResult struct {
+	PortfolioID   int               `json:"portfolio_id"`
+	EventsApplied int               `json:"events_applied"`
+	LastEventID   int64             `json:"last_event_id"`
+	Rebuilt       []models.Position `json:"rebuilt"`
+	Stored        []models.Position `json:"stored"`
+	Discrepancies []string          `json:"discrepancies"`
+}
+
+// GetTradeEvents retrieves trade events for a portfolio after the given cursor
+func (s *PortfolioService) GetTradeEvents(ctx context.Context, portfolioID int, afterID int64, limit int) ([]models.TradeEvent, error) {
+	return s.repo.GetTradeEvents(ctx, portfolioID, afterID, limit)
+}
+
+// ReplayPositions rebuilds a portfolio's positions from its full trade event
+// stream and reports any difference from the stored positions
+func (s *PortfolioService) ReplayPositions(ctx context.Context, portfolioID int) (*ReplayResult, error) {
+	var events []models.TradeEvent
+	var afterID int64
+	for {
+		page, err := s.repo.GetTradeEvents(ctx, portfolioID, afterID, replayPageSize)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, page...)
+		if len(page) < replayPageSize {
+			break
+		}
+		afterID = page[len(page)-1].ID
+	}
+
+	rebuilt, err := s.domain.ReplayTradeEvents(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay trade events: %w", err)
+	}
+
+	stored, err := s.repo.GetPositionsByPortfolioID(ctx, portfolioID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+
+	result := &ReplayResult{
+		PortfolioID:   portfolioID,
+		Rebuilt:       rebuilt,
+		Stored:        stored,
+		Discrepancies: comparePositions(rebuilt, stored),
+	}
+	for _, event := range events {
+		if event.EventType == models.TradeEventFilled {
+			result.EventsApplied++
+		}
+	}
+	if len(events) > 0 {
+		result.LastEventID = events[len(events)-1].ID
+	}
+
+	return result, nil
+}
+
+// newTradeEvent builds a trade event for the order with the request ID from ctx
+func newTradeEvent(ctx context.Context, portfolioID int, trade *models.Trade, eventType string, price float64, reason error) *models.TradeEvent {
+	event := &models.TradeEvent{
+		PortfolioID: portfolioID,
+		UserID:      trade.UserID,
+		TradeID:     trade.ID,
+		Symbol:      trade.Symbol,
+		EventType:   eventType,
+		Side:        trade.Side,
+		Quantity:    trade.Quantity,
+		Price:       price,
+		Fees:        trade.Fees,
+		RequestID:   requestctx.RequestID(ctx),
+	}
+	if reason != nil {
+		event.Reason = reason.Error()
+	}
+	return event
+}
+
+// recordTradeEvent appends a trade event, within tx when one is given.
+// Outside a transaction failures are logged rather than returned so a broken
+// event stream never blocks trading.
+func (s *PortfolioService) recordTradeEvent(ctx context.Context, tx *sql.Tx, event *models.TradeEvent) error {
+	if tx != nil {
+		if err := s.repo.CreateTradeEventTx(ctx, tx, event); err != nil {
+			return fmt.Errorf("failed to record trade event: %w", err)
+		}
+		return nil
+	}
+
+	if err := s.repo.CreateTradeEvent(ctx, event); err != nil {
+		s.logger.Error("Failed to record trade event",
+			zap.Error(err),
+			zap.Int("portfolio_id", event.PortfolioID),
+			zap.String("symbol", event.Symbol),
+			zap.String("event_type", event.EventType))
+	}
+	return nil
+}
+
+// comparePositions describes every symbol whose quantity or entry price
+// differs between the rebuilt and stored positions
+func comparePositions(rebuilt, stored []models.Position) []string {
+	bySymbol := make(map[string]models.Position, len(stored))
+	for _, pos := range stored {
+		bySymbol[pos.Symbol] = pos
+	}
+
+	discrepancies := []string{}
+	for _, pos := range rebuilt {
+		current, ok := bySymbol[pos.Symbol]
+		delete(bySymbol, pos.Symbol)
+		if !ok {
+			discrepancies = append(discrepancies, fmt.Sprintf("%s: rebuilt %v shares but no stored position", pos.Symbol, pos.Quantity))
+			continue
+		}
+		if current.Quantity != pos.Quantity {
+			discrepancies = append(discrepancies, fmt.Sprintf("%s: rebuilt quantity %v, stored %v", pos.Symbol, pos.Quantity, current.Quantity))
+		}
+		if diff := current.EntryPrice - pos.EntryPrice; diff > 0.0001 || diff < -0.0001 {
+			discrepancies = append(discrepancies, fmt.Sprintf("%s: rebuilt entry price %.4f, stored %.4f", pos.Symbol, pos.EntryPrice, current.EntryPrice))
+		}
+	}
+	for _, pos := range stored {
+		if _, unmatched := bySymbol[pos.Symbol]; unmatched {
+			discrepancies = append(discrepancies, fmt.Sprintf("%s: stored %v shares but no filled events", pos.Symbol, pos.Quantity))
+		}
+	}
+
+	return discrepancies
+}
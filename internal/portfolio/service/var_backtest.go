@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// VaRBacktestConfig is the VaR model a backtest validates and the window it
+// is tested over
+type VaRBacktestConfig struct {
+	Confidence float64 // e.g. 0.99
+	Lookback   int     // Daily returns each forecast is simulated from
+	Window     int     // Most recent forecasts tested
+}
+
+// VaRBacktestService validates the historical VaR model against realized
+// portfolio PnL and alerts when it is not adequately calibrated
+type VaRBacktestService struct {
+	portfolios *PortfolioService
+	redis      *redis.Client
+	defaults   VaRBacktestConfig
+	logger     *zap.Logger
+}
+
+// NewVaRBacktestService creates a VaR backtest service. redisClient may be
+// nil, in which case inadequate models are only logged.
+func NewVaRBacktestService(portfolios *PortfolioService, redisClient *redis.Client, defaults VaRBacktestConfig, logger *zap.Logger) *VaRBacktestService {
+	return &VaRBacktestService{
+		portfolios: portfolios,
+		redis:      redisClient,
+		defaults:   defaults,
+		logger:     logger,
+	}
+}
+
+// Defaults returns the model and window backtests use when a request does
+// not set them
+func (s *VaRBacktestService) Defaults() VaRBacktestConfig {
+	return s.defaults
+}
+
+// Run backtests the VaR model over a portfolio's daily snapshots and saves
+// the result
+func (s *VaRBacktestService) Run(ctx context.Context, portfolioID int, config VaRBacktestConfig) (*models.VaRBacktest, error) {
+	if _, err := s.portfolios.repo.GetPortfolioByID(ctx, portfolioID); err != nil {
+		return nil, err
+	}
+
+	// The window's first forecast needs lookback returns, so lookback+1
+	// snapshots, before it
+	snapshots, err := s.portfolios.repo.GetRecentSnapshots(ctx, portfolioID, config.Window+config.Lookback+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("%w: portfolio %d has no snapshots", domain.ErrInsufficientHistory, portfolioID)
+	}
+
+	cash, err := s.portfolios.repo.GetCashTransactionsBetween(ctx, portfolioID, snapshots[0].CreatedAt, snapshots[len(snapshots)-1].CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	backtest, err := s.portfolios.domain.BacktestVaR(snapshots, cash, config.Confidence, config.Lookback, config.Window)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.portfolios.repo.CreateVaRBacktest(ctx, &backtest); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("VaR backtest completed",
+		zap.Int("portfolio_id", portfolioID),
+		zap.Int("observations", backtest.Observations),
+		zap.Int("exceptions", backtest.Exceptions),
+		zap.Bool("adequate", backtest.Adequate))
+	return &backtest, nil
+}
+
+// GetBacktests returns a page of a portfolio's VaR backtests
+func (s *VaRBacktestService) GetBacktests(ctx context.Context, portfolioID int, page pagination.Request) ([]models.VaRBacktest, pagination.Result, error) {
+	if _, err := s.portfolios.repo.GetPortfolioByID(ctx, portfolioID); err != nil {
+		return nil, pagination.Result{}, err
+	}
+	return s.portfolios.repo.GetVaRBacktests(ctx, portfolioID, page)
+}
+
+// RunAll backtests every active portfolio with the default model, alerting
+// for those whose model is inadequate. It returns how many portfolios were
+// tested and how many were inadequate. Portfolios without enough history, or
+// that fail, are logged and skipped.
+func (s *VaRBacktestService) RunAll(ctx context.Context) (int, int, error) {
+	portfolioIDs, err := s.portfolios.repo.ListActivePortfolioIDs(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tested, inadequate := 0, 0
+	for _, portfolioID := range portfolioIDs {
+		backtest, err := s.Run(ctx, portfolioID, s.defaults)
+		if err != nil {
+			if errors.Is(err, domain.ErrInsufficientHistory) {
+				s.logger.Debug("Skipping VaR backtest", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+			} else {
+				s.logger.Error("Failed to backtest VaR", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+			}
+			continue
+		}
+		tested++
+
+		if !backtest.Adequate {
+			s.alert(ctx, backtest)
+			inadequate++
+		}
+	}
+
+	return tested, inadequate, nil
+}
+
+// alert publishes a risk alert event for an inadequately calibrated VaR
+// model. Failures are logged.
+func (s *VaRBacktestService) alert(ctx context.Context, backtest *models.VaRBacktest) {
+	s.logger.Warn("VaR model inadequately calibrated",
+		zap.Int("portfolio_id", backtest.PortfolioID),
+		zap.Int("exceptions", backtest.Exceptions),
+		zap.Float64("expected_exceptions", backtest.ExpectedExceptions),
+		zap.Float64("kupiec_p_value", backtest.KupiecPValue),
+		zap.Float64("independence_p_value", backtest.IndependencePValue))
+
+	if s.redis == nil {
+		return
+	}
+	event := models.Event{
+		Type:   "var_model_inadequate",
+		Source: "portfolio-service",
+		Data: map[string]interface{}{
+			"portfolio_id":         backtest.PortfolioID,
+			"backtest_id":          backtest.ID,
+			"confidence":           backtest.Confidence,
+			"observations":         backtest.Observations,
+			"exceptions":           backtest.Exceptions,
+			"expected_exceptions":  backtest.ExpectedExceptions,
+			"kupiec_p_value":       backtest.KupiecPValue,
+			"independence_p_value": backtest.IndependencePValue,
+		},
+		Timestamp: time.Now(),
+	}
+	if err := s.redis.PublishEvent(ctx, models.ChannelRiskAlerts, event); err != nil {
+		s.logger.Warn("Failed to publish VaR model alert", zap.Error(err))
+	}
+}
+
+// RunDailySchedule backtests every active portfolio's VaR model at hour (UTC)
+// every day until ctx is cancelled
+func (s *VaRBacktestService) RunDailySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		tested, inadequate, err := s.RunAll(ctx)
+		if err != nil {
+			s.logger.Error("Failed to run daily VaR backtests", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Daily VaR backtests completed", zap.Int("portfolios", tested), zap.Int("inadequate", inadequate))
+	}
+}
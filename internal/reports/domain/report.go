@@ -0,0 +1,331 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"hedge-fund/pkg/shared/drawdown"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Report types
+const (
+	TypePerformance  = "performance"
+	TypePositions    = "positions"
+	TypeTradeHistory = "trades"
+	TypeTax          = "tax"    // Realized gains with wash sales, for tax preparation
+	TypeCustom       = "custom" // Laid out by a report template
+)
+
+// Output formats
+const (
+	FormatPDF  = "pdf"
+	FormatHTML = "html"
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Report is a format-independent report made of titled tables
+type Report struct {
+	Title       string    `json:"title"`
+	Subtitle    string    `json:"subtitle"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Sections    []Section `json:"sections"`
+}
+
+// Section is one table of a report, optionally drawn as a chart by formats
+// that support one
+type Section struct {
+	Title   string     `json:"title"`
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+	Chart   *Chart     `json:"chart,omitempty"`
+}
+
+// Chart is the data of a section's chart
+type Chart struct {
+	Type   string    `json:"type"` // "pie"
+	Labels []string  `json:"labels"`
+	Values []float64 `json:"values"`
+}
+
+// ValidType reports whether t is a supported report type
+func ValidType(t string) bool {
+	return t == TypePerformance || t == TypePositions || t == TypeTradeHistory || t == TypeTax || t == TypeCustom
+}
+
+// ValidFormat reports whether f is a supported output format
+func ValidFormat(f string) bool {
+	return f == FormatPDF || f == FormatHTML || f == FormatCSV || f == FormatJSON
+}
+
+// BuildPositions reports a portfolio's current positions
+func BuildPositions(portfolio *models.Portfolio, positions []models.Position, now time.Time) Report {
+	rows := make([][]string, 0, len(positions))
+	var marketValue, unrealized float64
+	sorted := append([]models.Position(nil), positions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Symbol < sorted[j].Symbol })
+	for _, p := range sorted {
+		value := p.Value(p.CurrentPrice)
+		marketValue += value
+		unrealized += p.UnrealizedPnL
+		rows = append(rows, []string{
+			p.Symbol, p.Side, quantity(p.Quantity), money(p.EntryPrice), money(p.CurrentPrice),
+			money(value), money(p.UnrealizedPnL), money(p.RealizedPnL),
+		})
+	}
+
+	return Report{
+		Title:       "Positions Report",
+		Subtitle:    fmt.Sprintf("%s (portfolio %d) as of %s", portfolio.Name, portfolio.ID, now.Format("2006-01-02")),
+		GeneratedAt: now,
+		Sections: []Section{
+			{
+				Title:   "Summary",
+				Columns: []string{"Cash", "Market Value", "Unrealized P&L", "Positions"},
+				Rows:    [][]string{{money(portfolio.Cash), money(marketValue), money(unrealized), fmt.Sprintf("%d", len(positions))}},
+			},
+			{
+				Title:   "Positions",
+				Columns: []string{"Symbol", "Side", "Quantity", "Entry", "Price", "Market Value", "Unrealized P&L", "Realized P&L"},
+				Rows:    rows,
+			},
+		},
+	}
+}
+
+// BuildTradeHistory reports the trades executed in [start, end)
+func BuildTradeHistory(portfolio *models.Portfolio, trades []models.Trade, start, end, now time.Time) Report {
+	rows := make([][]string, 0, len(trades))
+	for _, t := range inPeriod(trades, start, end) {
+		rows = append(rows, []string{
+			tradeTime(t).Format("2006-01-02 15:04"), t.Symbol, t.Side, quantity(t.Quantity),
+			money(t.Price), money(t.Value(t.Price)), money(t.Fees), t.Status,
+		})
+	}
+
+	return Report{
+		Title:       "Trade History",
+		Subtitle:    fmt.Sprintf("%s (portfolio %d), %s", portfolio.Name, portfolio.ID, period(start, end)),
+		GeneratedAt: now,
+		Sections: []Section{{
+			Title:   "Trades",
+			Columns: []string{"Executed", "Symbol", "Side", "Quantity", "Price", "Value", "Fees", "Status"},
+			Rows:    rows,
+		}},
+	}
+}
+
+// BuildPerformance reports trading activity and realized P&L for [start, end).
+// trades must include every filled trade before end, oldest first, so the
+// average cost of positions opened before the period is known. Drawdowns are
+// measured over the period's daily snapshots, in date order, net of the cash
+// moved in or out between them.
+func BuildPerformance(portfolio *models.Portfolio, trades []models.Trade, snapshots []models.PortfolioSnapshot, cash []models.CashTransaction, start, end, now time.Time) Report {
+	type symbolStats struct {
+		trades           int
+		bought, sold     float64
+		buyValue         float64
+		sellValue        float64
+		fees             float64
+		realized         float64
+		wins, closingTxn int
+	}
+
+	stats := make(map[string]*symbolStats)
+	var total symbolStats
+
+	pnl := realizedPnL(trades)
+	for i, t := range trades {
+		ts := tradeTime(t)
+		if !ts.Before(end) {
+			break
+		}
+		if ts.Before(start) {
+			continue
+		}
+		realized := pnl[i]
+
+		s, ok := stats[t.Symbol]
+		if !ok {
+			s = &symbolStats{}
+			stats[t.Symbol] = s
+		}
+		for _, agg := range []*symbolStats{s, &total} {
+			agg.trades++
+			agg.fees += t.Fees
+			if t.Side == "buy" {
+				agg.bought += t.Quantity
+				agg.buyValue += t.Value(t.Price)
+			} else {
+				agg.sold += t.Quantity
+				agg.sellValue += t.Value(t.Price)
+				agg.realized += realized
+				agg.closingTxn++
+				if realized > 0 {
+					agg.wins++
+				}
+			}
+		}
+	}
+
+	symbols := make([]string, 0, len(stats))
+	for symbol := range stats {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	rows := make([][]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		s := stats[symbol]
+		rows = append(rows, []string{
+			symbol, fmt.Sprintf("%d", s.trades), quantity(s.bought), quantity(s.sold),
+			money(s.buyValue), money(s.sellValue), money(s.fees), money(s.realized),
+		})
+	}
+
+	winRate := "n/a"
+	if total.closingTxn > 0 {
+		winRate = fmt.Sprintf("%.1f%%", float64(total.wins)/float64(total.closingTxn)*100)
+	}
+
+	// Without a value to measure from there are no drawdowns to report
+	drawdowns, _ := drawdown.Analyze(snapshots, cash)
+	return Report{
+		Title:       "Performance Report",
+		Subtitle:    fmt.Sprintf("%s (portfolio %d), %s", portfolio.Name, portfolio.ID, period(start, end)),
+		GeneratedAt: now,
+		Sections: []Section{
+			{
+				Title:   "Portfolio",
+				Columns: []string{"Cash", "Total Value", "Unrealized P&L", "Realized P&L (lifetime)"},
+				Rows:    [][]string{{money(portfolio.Cash), money(portfolio.TotalValue), money(portfolio.UnrealizedPnL), money(portfolio.RealizedPnL)}},
+			},
+			{
+				Title:   "Period Activity",
+				Columns: []string{"Trades", "Bought", "Sold", "Fees", "Realized P&L", "Win Rate"},
+				Rows: [][]string{{
+					fmt.Sprintf("%d", total.trades), money(total.buyValue), money(total.sellValue),
+					money(total.fees), money(total.realized), winRate,
+				}},
+			},
+			{
+				Title:   "By Symbol",
+				Columns: []string{"Symbol", "Trades", "Bought Qty", "Sold Qty", "Buy Value", "Sell Value", "Fees", "Realized P&L"},
+				Rows:    rows,
+			},
+			drawdownSection(drawdowns),
+			drawdownPeriodsSection(drawdowns),
+		},
+	}
+}
+
+// maxDrawdownPeriods is how many of the deepest drawdowns a performance
+// report lists
+const maxDrawdownPeriods = 5
+
+func drawdownSection(analysis models.DrawdownAnalysis) Section {
+	section := Section{
+		Title:   "Drawdown",
+		Columns: []string{"Max Drawdown", "Peak", "Trough", "Recovery", "Duration (days)", "Longest (days)", "Current Drawdown"},
+	}
+	if len(analysis.Underwater) == 0 {
+		section.Rows = [][]string{{"n/a", "", "", "", "", "", "n/a"}}
+		return section
+	}
+
+	peak, trough, recovery := "", "", ""
+	if analysis.MaxDrawdownPeak != nil {
+		peak = analysis.MaxDrawdownPeak.Format("2006-01-02")
+		trough = analysis.MaxDrawdownTrough.Format("2006-01-02")
+		recovery = "not recovered"
+	}
+	if analysis.MaxDrawdownRecovery != nil {
+		recovery = analysis.MaxDrawdownRecovery.Format("2006-01-02")
+	}
+	section.Rows = [][]string{{
+		fmt.Sprintf("%.1f%%", analysis.MaxDrawdown), peak, trough, recovery,
+		strconv.Itoa(analysis.MaxDrawdownDays), strconv.Itoa(analysis.LongestDrawdownDays),
+		fmt.Sprintf("%.1f%%", analysis.CurrentDrawdown),
+	}}
+	return section
+}
+
+func drawdownPeriodsSection(analysis models.DrawdownAnalysis) Section {
+	periods := analysis.Periods
+	if len(periods) > maxDrawdownPeriods {
+		periods = periods[:maxDrawdownPeriods]
+	}
+
+	rows := make([][]string, 0, len(periods))
+	for _, p := range periods {
+		recovery := "not recovered"
+		if p.Recovery != nil {
+			recovery = p.Recovery.Format("2006-01-02")
+		}
+		rows = append(rows, []string{
+			fmt.Sprintf("%.1f%%", p.Depth), p.Peak.Format("2006-01-02"), p.Trough.Format("2006-01-02"),
+			recovery, strconv.Itoa(p.Days),
+		})
+	}
+	return Section{
+		Title:   "Deepest Drawdowns",
+		Columns: []string{"Depth", "Peak", "Trough", "Recovery", "Days"},
+		Rows:    rows,
+	}
+}
+
+// realizedPnL returns the P&L each sell in trades realized against the
+// average cost of the shares held before it, net of fees, and zero for each
+// buy. trades must be oldest first.
+func realizedPnL(trades []models.Trade) []float64 {
+	held := make(map[string]float64)
+	cost := make(map[string]float64) // Average cost per share
+	pnl := make([]float64, len(trades))
+
+	for i, t := range trades {
+		switch t.Side {
+		case "buy":
+			newQty := held[t.Symbol] + t.Quantity
+			if newQty != 0 {
+				cost[t.Symbol] = (cost[t.Symbol]*held[t.Symbol] + t.Price*t.Quantity) / newQty
+			}
+			held[t.Symbol] = newQty
+		case "sell":
+			pnl[i] = t.Value(t.Price-cost[t.Symbol]) - t.Fees
+			held[t.Symbol] -= t.Quantity
+		}
+	}
+	return pnl
+}
+
+func inPeriod(trades []models.Trade, start, end time.Time) []models.Trade {
+	var filtered []models.Trade
+	for _, t := range trades {
+		if ts := tradeTime(t); !ts.Before(start) && ts.Before(end) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+func tradeTime(t models.Trade) time.Time {
+	if t.ExecutedAt != nil {
+		return *t.ExecutedAt
+	}
+	return t.CreatedAt
+}
+
+func period(start, end time.Time) string {
+	return fmt.Sprintf("%s to %s", start.Format("2006-01-02"), end.AddDate(0, 0, -1).Format("2006-01-02"))
+}
+
+func money(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+func quantity(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
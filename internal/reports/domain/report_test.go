@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+func filledTrade(symbol, side string, qty, price float64, at time.Time) models.Trade {
+	return models.Trade{Symbol: symbol, Side: side, Quantity: qty, Price: price, Status: "filled", ExecutedAt: &at}
+}
+
+func TestBuildPerformanceUsesCostBasisFromBeforePeriod(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 15, 0, 0, 0, time.UTC) }
+	portfolio := &models.Portfolio{ID: 7, Name: "Growth"}
+	trades := []models.Trade{
+		filledTrade("AAPL", "buy", 10, 100, day(1)),
+		filledTrade("AAPL", "buy", 10, 120, day(4)),
+		filledTrade("AAPL", "sell", 5, 130, day(10)),
+		filledTrade("MSFT", "buy", 2, 300, day(12)),
+		filledTrade("MSFT", "sell", 2, 290, day(20)), // After the period
+	}
+
+	start := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	report := BuildPerformance(portfolio, trades, nil, nil, start, end, end)
+
+	require.Len(t, report.Sections, 5)
+	activity := report.Sections[1].Rows[0]
+	assert.Equal(t, "3", activity[0])      // The day-1 buy is before the period
+	assert.Equal(t, "100.00", activity[4]) // (130 - 110) * 5
+	assert.Equal(t, "100.0%", activity[5])
+
+	bySymbol := report.Sections[2].Rows
+	require.Len(t, bySymbol, 2)
+	assert.Equal(t, "AAPL", bySymbol[0][0])
+	assert.Equal(t, "MSFT", bySymbol[1][0])
+	assert.Equal(t, "0.00", bySymbol[1][7])
+	assert.Contains(t, report.Subtitle, "2024-03-03 to 2024-03-14")
+	assert.Equal(t, "n/a", report.Sections[3].Rows[0][0])
+	assert.Empty(t, report.Sections[4].Rows)
+}
+
+func TestBuildPerformanceReportsDrawdowns(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	var snapshots []models.PortfolioSnapshot
+	for i, value := range []float64{100, 80, 100, 120, 108} {
+		snapshots = append(snapshots, models.PortfolioSnapshot{PortfolioID: 7, SnapshotDate: day(i + 1), TotalValue: value})
+	}
+
+	report := BuildPerformance(&models.Portfolio{ID: 7}, nil, snapshots, nil, day(1), day(6), day(6))
+
+	require.Len(t, report.Sections, 5)
+	summary := report.Sections[3]
+	assert.Equal(t, "Drawdown", summary.Title)
+	assert.Equal(t, []string{"20.0%", "2024-03-01", "2024-03-02", "2024-03-03", "2", "2", "10.0%"}, summary.Rows[0])
+
+	periods := report.Sections[4].Rows
+	require.Len(t, periods, 2)
+	assert.Equal(t, "20.0%", periods[0][0])
+	assert.Equal(t, []string{"10.0%", "2024-03-04", "2024-03-05", "not recovered", "1"}, periods[1])
+}
+
+func TestBuildTradeHistoryFiltersPeriod(t *testing.T) {
+	at := func(d int) time.Time { return time.Date(2024, 3, d, 10, 0, 0, 0, time.UTC) }
+	trades := []models.Trade{
+		filledTrade("AAPL", "buy", 1, 100, at(1)),
+		filledTrade("AAPL", "sell", 1, 110, at(5)),
+	}
+
+	report := BuildTradeHistory(&models.Portfolio{ID: 1}, trades, at(2), at(6), at(6))
+
+	require.Len(t, report.Sections[0].Rows, 1)
+	assert.Equal(t, "sell", report.Sections[0].Rows[0][2])
+}
+
+func TestDispositionsClassifiesTermsFirstInFirstOut(t *testing.T) {
+	buyOld := filledTrade("AAPL", "buy", 10, 100, time.Date(2022, 1, 10, 15, 0, 0, 0, time.UTC))
+	buyOld.Fees = 10
+	buyNew := filledTrade("AAPL", "buy", 10, 150, time.Date(2023, 6, 1, 15, 0, 0, 0, time.UTC))
+	sell := filledTrade("AAPL", "sell", 15, 200, time.Date(2023, 8, 1, 15, 0, 0, 0, time.UTC))
+	sell.Fees = 15
+
+	dispositions, washSales := Dispositions([]models.Trade{sell, buyOld, buyNew}, models.CostBasisFIFO)
+
+	assert.Empty(t, washSales)
+	require.Len(t, dispositions, 2)
+	assert.True(t, dispositions[0].LongTerm())
+	assert.Equal(t, 10.0, dispositions[0].Quantity)
+	assert.InDelta(t, 1990.0, dispositions[0].Proceeds, 1e-6) // 10 of 15 shares sold for 2985 net
+	assert.InDelta(t, 1010.0, dispositions[0].CostBasis, 1e-6)
+	assert.False(t, dispositions[1].LongTerm())
+	assert.InDelta(t, 995-750, dispositions[1].Gain(), 1e-6)
+}
+
+func TestDispositionsFollowCostBasisMethod(t *testing.T) {
+	day := func(m time.Month) time.Time { return time.Date(2024, m, 1, 15, 0, 0, 0, time.UTC) }
+	trades := []models.Trade{
+		filledTrade("AAPL", "buy", 10, 100, day(1)),
+		filledTrade("AAPL", "buy", 10, 150, day(2)),
+		filledTrade("AAPL", "buy", 10, 120, day(3)),
+		filledTrade("AAPL", "sell", 15, 200, day(7)),
+	}
+
+	for method, costs := range map[string][]float64{
+		models.CostBasisFIFO: {1000, 750},
+		models.CostBasisLIFO: {1200, 750},
+		models.CostBasisHIFO: {1500, 600},
+	} {
+		dispositions, _ := Dispositions(trades, method)
+		require.Len(t, dispositions, 2, method)
+		assert.InDelta(t, costs[0], dispositions[0].CostBasis, 1e-6, method)
+		assert.InDelta(t, costs[1], dispositions[1].CostBasis, 1e-6, method)
+	}
+}
+
+func TestDispositionsDisallowWashSaleLosses(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 15, 0, 0, 0, time.UTC) }
+	trades := []models.Trade{
+		filledTrade("TSLA", "buy", 10, 200, day(1, 2)),
+		filledTrade("TSLA", "sell", 10, 150, day(3, 1)), // 500 loss
+		filledTrade("TSLA", "buy", 4, 160, day(3, 20)),  // Replaces 4 of the 10 shares
+		filledTrade("TSLA", "sell", 4, 170, day(9, 1)),
+		filledTrade("MSFT", "buy", 1, 300, day(3, 5)),
+	}
+
+	dispositions, washSales := Dispositions(trades, models.CostBasisFIFO)
+
+	require.Len(t, washSales, 1)
+	assert.Equal(t, 4.0, washSales[0].Quantity)
+	assert.InDelta(t, 200.0, washSales[0].Disallowed, 1e-6)
+	require.Len(t, dispositions, 2)
+	assert.InDelta(t, -300.0, dispositions[0].Gain(), 1e-6)
+	// The replacement carries the disallowed loss and the sold shares' holding period
+	assert.InDelta(t, 840.0, dispositions[1].CostBasis, 1e-6)
+	assert.InDelta(t, -160.0, dispositions[1].Gain(), 1e-6)
+	assert.Equal(t, day(1, 2).Add(day(3, 20).Sub(day(3, 1))), dispositions[1].AcquiredAt)
+
+	report := BuildTax(&models.Portfolio{ID: 3, Name: "Taxable"}, trades, models.CostBasisFIFO, day(1, 1), day(12, 31), day(12, 31))
+	require.Len(t, report.Sections, 3)
+	assert.Equal(t, []string{"Total", "2180.00", "2840.00", "200.00", "-460.00"}, report.Sections[0].Rows[2])
+	assert.Len(t, report.Sections[1].Rows, 2)
+	assert.Equal(t, []string{"TSLA", "2024-03-01", "4", "2024-03-20", "200.00"}, report.Sections[2].Rows[0])
+}
+
+func TestDispositionsCountHeldEarlierBuysAsReplacements(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 15, 0, 0, 0, time.UTC) }
+	trades := []models.Trade{
+		filledTrade("NVDA", "buy", 5, 100, day(1)),
+		filledTrade("NVDA", "buy", 5, 90, day(10)),
+		filledTrade("NVDA", "sell", 5, 80, day(15)), // Sells the day-1 lot at a 100 loss
+		filledTrade("NVDA", "sell", 5, 95, day(20)),
+	}
+
+	dispositions, washSales := Dispositions(trades, models.CostBasisFIFO)
+
+	require.Len(t, washSales, 1)
+	assert.Equal(t, day(10), washSales[0].ReplacementAt)
+	require.Len(t, dispositions, 2)
+	assert.InDelta(t, 0.0, dispositions[0].Gain(), 1e-6)
+	assert.InDelta(t, 550.0, dispositions[1].CostBasis, 1e-6)
+	assert.InDelta(t, -75.0, dispositions[1].Gain(), 1e-6)
+}
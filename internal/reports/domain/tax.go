@@ -0,0 +1,349 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// washSaleWindow is how long before or after a loss sale buying the same
+// security makes it a wash sale
+const washSaleWindow = 30 * 24 * time.Hour
+
+// quantityEpsilon absorbs float error when lots are split and consumed
+const quantityEpsilon = 1e-9
+
+// Disposition is the sale of the shares of one tax lot
+type Disposition struct {
+	Symbol     string
+	Quantity   float64
+	AcquiredAt time.Time // Zero for shares sold beyond the trade history
+	SoldAt     time.Time
+	Proceeds   float64 // Net of the sale's fees
+	CostBasis  float64 // Including purchase fees and losses deferred into the lot
+	Disallowed float64 // Loss disallowed as a wash sale
+}
+
+// Gain is the reportable gain, or loss when negative. A disallowed loss is
+// not reported until the replacement shares are sold.
+func (d Disposition) Gain() float64 {
+	return d.Proceeds - d.CostBasis + d.Disallowed
+}
+
+// LongTerm reports whether the shares were held for more than a year
+func (d Disposition) LongTerm() bool {
+	return !d.AcquiredAt.IsZero() && d.SoldAt.After(d.AcquiredAt.AddDate(1, 0, 0))
+}
+
+// WashSale is a loss disallowed because the same security was bought within
+// 30 days of the sale. The loss is added to the replacement shares' basis.
+type WashSale struct {
+	Symbol        string
+	SoldAt        time.Time
+	Quantity      float64 // Of the replacement shares
+	ReplacementAt time.Time
+	Disallowed    float64
+}
+
+// taxLot is shares bought together, or the part of them given a wash-sale
+// adjustment
+type taxLot struct {
+	buy        int // Index of the buying trade
+	acquiredAt time.Time
+	quantity   float64
+	unitCost   float64 // Per unit of quantity, so it already carries the multiplier
+}
+
+// basisAdjustment defers a disallowed loss into shares of a later buy
+type basisAdjustment struct {
+	quantity   float64
+	disallowed float64
+	held       time.Duration // Holding period carried over from the sold shares
+}
+
+// Dispositions matches each sell in trades to the lots it closed, in the
+// order the portfolio's cost basis method consumes its position lots, and
+// applies the wash sale rule to losses. trades must include every filled
+// trade of the portfolio, oldest first, and reach 30 days past the last sale
+// reported so replacements are seen.
+func Dispositions(trades []models.Trade, method string) ([]Disposition, []WashSale) {
+	trades = filledOldestFirst(trades)
+	open := make(map[string][]*taxLot)
+	pending := make(map[int][]basisAdjustment)
+	replaced := make(map[int]float64) // Quantity of each buy already replacing a wash sale
+
+	var dispositions []Disposition
+	var washSales []WashSale
+	for i, t := range trades {
+		if t.Quantity <= 0 {
+			continue
+		}
+		at := tradeTime(t)
+
+		if t.Side == "buy" {
+			unitCost := (t.Value(t.Price) + t.Fees) / t.Quantity
+			remaining := t.Quantity
+			for _, adj := range pending[i] {
+				open[t.Symbol] = append(open[t.Symbol], &taxLot{
+					buy:        i,
+					acquiredAt: at.Add(-adj.held),
+					quantity:   adj.quantity,
+					unitCost:   unitCost + adj.disallowed/adj.quantity,
+				})
+				remaining -= adj.quantity
+			}
+			if remaining > quantityEpsilon {
+				open[t.Symbol] = append(open[t.Symbol], &taxLot{buy: i, acquiredAt: at, quantity: remaining, unitCost: unitCost})
+			}
+			continue
+		}
+
+		unitProceeds := (t.Value(t.Price) - t.Fees) / t.Quantity
+		sold := make(map[int]bool)
+		remaining := t.Quantity
+		var sale []Disposition
+		for remaining > quantityEpsilon && len(open[t.Symbol]) > 0 {
+			next := nextLot(open[t.Symbol], trades, method)
+			lot := open[t.Symbol][next]
+			used := lot.quantity
+			if used > remaining {
+				used = remaining
+			}
+			sale = append(sale, Disposition{
+				Symbol:     t.Symbol,
+				Quantity:   used,
+				AcquiredAt: lot.acquiredAt,
+				SoldAt:     at,
+				Proceeds:   used * unitProceeds,
+				CostBasis:  used * lot.unitCost,
+			})
+			sold[lot.buy] = true
+			lot.quantity -= used
+			remaining -= used
+			if lot.quantity <= quantityEpsilon {
+				open[t.Symbol] = append(open[t.Symbol][:next], open[t.Symbol][next+1:]...)
+			}
+		}
+		if remaining > quantityEpsilon {
+			// Bought before the trade history began; the basis is unknown
+			sale = append(sale, Disposition{Symbol: t.Symbol, Quantity: remaining, SoldAt: at, Proceeds: remaining * unitProceeds})
+		}
+
+		for j := range sale {
+			d := &sale[j]
+			if d.AcquiredAt.IsZero() || d.Gain() >= 0 {
+				continue
+			}
+			loss := -d.Gain()
+			for _, r := range replacements(trades, i, d.Quantity, sold, replaced, open) {
+				disallowed := loss * r.quantity / d.Quantity
+				d.Disallowed += disallowed
+				replaced[r.buy] += r.quantity
+				adj := basisAdjustment{quantity: r.quantity, disallowed: disallowed, held: at.Sub(d.AcquiredAt)}
+				if r.buy > i {
+					pending[r.buy] = append(pending[r.buy], adj)
+				} else {
+					adjustOpenLots(open, trades[r.buy], r.buy, adj)
+				}
+				washSales = append(washSales, WashSale{
+					Symbol:        t.Symbol,
+					SoldAt:        at,
+					Quantity:      r.quantity,
+					ReplacementAt: tradeTime(trades[r.buy]),
+					Disallowed:    disallowed,
+				})
+			}
+		}
+		dispositions = append(dispositions, sale...)
+	}
+	return dispositions, washSales
+}
+
+// nextLot returns the index of the open lot a sale closes next under a cost
+// basis method, ordered like position lots: oldest purchase first for FIFO,
+// newest first for LIFO and highest purchase price first for HIFO (oldest
+// first among equal prices). Unknown methods are FIFO.
+func nextLot(lots []*taxLot, trades []models.Trade, method string) int {
+	next := 0
+	for k := 1; k < len(lots); k++ {
+		a, b := lots[k], lots[next]
+		switch method {
+		case models.CostBasisLIFO:
+			if a.buy > b.buy {
+				next = k
+			}
+			continue
+		case models.CostBasisHIFO:
+			if price, nextPrice := trades[a.buy].Price, trades[b.buy].Price; price != nextPrice {
+				if price > nextPrice {
+					next = k
+				}
+				continue
+			}
+		}
+		if a.buy < b.buy {
+			next = k
+		}
+	}
+	return next
+}
+
+type replacement struct {
+	buy      int
+	quantity float64
+}
+
+// replacements finds the buys, earliest first, that replace up to quantity
+// shares of the loss sale trades[sale]: buys of the same symbol within 30
+// days of it, other than those whose shares it sold. Buys before the sale
+// only count with shares still held.
+func replacements(trades []models.Trade, sale int, quantity float64, sold map[int]bool, replaced map[int]float64, open map[string][]*taxLot) []replacement {
+	t := trades[sale]
+	at := tradeTime(t)
+	var found []replacement
+	for j, buy := range trades {
+		if quantity <= quantityEpsilon {
+			break
+		}
+		bought := tradeTime(buy)
+		if buy.Side != "buy" || buy.Symbol != t.Symbol || sold[j] || j == sale {
+			continue
+		}
+		if bought.Before(at.Add(-washSaleWindow)) {
+			continue
+		}
+		if bought.After(at.Add(washSaleWindow)) {
+			break
+		}
+
+		available := buy.Quantity - replaced[j]
+		if j < sale {
+			held := 0.0
+			for _, lot := range open[t.Symbol] {
+				if lot.buy == j {
+					held += lot.quantity
+				}
+			}
+			if held < available {
+				available = held
+			}
+		}
+		if available <= quantityEpsilon {
+			continue
+		}
+		if available > quantity {
+			available = quantity
+		}
+		found = append(found, replacement{buy: j, quantity: available})
+		quantity -= available
+	}
+	return found
+}
+
+// adjustOpenLots defers a disallowed loss into shares of a buy still held,
+// splitting a lot when only part of it replaces the sold shares
+func adjustOpenLots(open map[string][]*taxLot, buy models.Trade, index int, adj basisAdjustment) {
+	lots := open[buy.Symbol]
+	remaining := adj.quantity
+	for k := 0; k < len(lots) && remaining > quantityEpsilon; k++ {
+		lot := lots[k]
+		if lot.buy != index {
+			continue
+		}
+		if lot.quantity > remaining+quantityEpsilon {
+			rest := *lot
+			rest.quantity = lot.quantity - remaining
+			lot.quantity = remaining
+			lots = append(lots[:k+1], append([]*taxLot{&rest}, lots[k+1:]...)...)
+		}
+		lot.unitCost += adj.disallowed / adj.quantity
+		lot.acquiredAt = lot.acquiredAt.Add(-adj.held)
+		remaining -= lot.quantity
+	}
+	open[buy.Symbol] = lots
+}
+
+func filledOldestFirst(trades []models.Trade) []models.Trade {
+	filled := make([]models.Trade, 0, len(trades))
+	for _, t := range trades {
+		if t.Status == "" || t.Status == "filled" {
+			filled = append(filled, t)
+		}
+	}
+	sort.SliceStable(filled, func(i, j int) bool { return tradeTime(filled[i]).Before(tradeTime(filled[j])) })
+	return filled
+}
+
+// BuildTax reports the gains realized in [start, end), split into short and
+// long term, with the losses disallowed as wash sales. trades must include
+// every filled trade before end, and those up to 30 days after it. Sales are
+// matched to lots by the portfolio's cost basis method.
+func BuildTax(portfolio *models.Portfolio, trades []models.Trade, method string, start, end, now time.Time) Report {
+	dispositions, washSales := Dispositions(trades, method)
+
+	type totals struct{ proceeds, cost, disallowed, gain float64 }
+	var short, long, all totals
+	rows := [][]string{}
+	for _, d := range dispositions {
+		if d.SoldAt.Before(start) || !d.SoldAt.Before(end) {
+			continue
+		}
+		term, bucket := "Short", &short
+		if d.LongTerm() {
+			term, bucket = "Long", &long
+		}
+		for _, agg := range []*totals{bucket, &all} {
+			agg.proceeds += d.Proceeds
+			agg.cost += d.CostBasis
+			agg.disallowed += d.Disallowed
+			agg.gain += d.Gain()
+		}
+
+		acquired := "Unknown"
+		if !d.AcquiredAt.IsZero() {
+			acquired = d.AcquiredAt.Format("2006-01-02")
+		}
+		rows = append(rows, []string{
+			d.Symbol, quantity(d.Quantity), acquired, d.SoldAt.Format("2006-01-02"), money(d.Proceeds),
+			money(d.CostBasis), money(d.Disallowed), money(d.Gain()), term,
+		})
+	}
+
+	washRows := [][]string{}
+	for _, w := range washSales {
+		if w.SoldAt.Before(start) || !w.SoldAt.Before(end) {
+			continue
+		}
+		washRows = append(washRows, []string{
+			w.Symbol, w.SoldAt.Format("2006-01-02"), quantity(w.Quantity), w.ReplacementAt.Format("2006-01-02"), money(w.Disallowed),
+		})
+	}
+
+	summary := func(label string, t totals) []string {
+		return []string{label, money(t.proceeds), money(t.cost), money(t.disallowed), money(t.gain)}
+	}
+	return Report{
+		Title:       "Realized Gains Tax Report",
+		Subtitle:    fmt.Sprintf("%s (portfolio %d), %s", portfolio.Name, portfolio.ID, period(start, end)),
+		GeneratedAt: now,
+		Sections: []Section{
+			{
+				Title:   "Summary",
+				Columns: []string{"Term", "Proceeds", "Cost Basis", "Wash Sale Adjustment", "Gain/Loss"},
+				Rows:    [][]string{summary("Short-term", short), summary("Long-term", long), summary("Total", all)},
+			},
+			{
+				Title:   "Realized Gains",
+				Columns: []string{"Symbol", "Quantity", "Acquired", "Sold", "Proceeds", "Cost Basis", "Wash Sale Adjustment", "Gain/Loss", "Term"},
+				Rows:    rows,
+			},
+			{
+				Title:   "Wash Sales",
+				Columns: []string{"Symbol", "Sold", "Quantity", "Replacement Bought", "Loss Disallowed"},
+				Rows:    washRows,
+			},
+		},
+	}
+}
@@ -0,0 +1,419 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"hedge-fund/pkg/shared/cashflow"
+	"hedge-fund/pkg/shared/drawdown"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Template section types
+const (
+	SectionSummary    = "summary"
+	SectionAllocation = "allocation"
+	SectionTopTrades  = "top_trades"
+	SectionRisk       = "risk"
+	SectionMetrics    = "metrics"
+)
+
+// Template schedules
+const (
+	ScheduleDaily   = "daily"
+	ScheduleWeekly  = "weekly"
+	ScheduleMonthly = "monthly"
+)
+
+// Template limits
+const (
+	MaxTemplateSections = 20
+	MaxTemplateName     = 100
+	DefaultTopTrades    = 10
+	MaxTopTrades        = 100
+)
+
+// ErrInvalidTemplate is returned for a report template that cannot be saved
+var ErrInvalidTemplate = errors.New("invalid report template")
+
+// Data is what a template's sections are built from
+type Data struct {
+	Portfolio *models.Portfolio
+	Positions []models.Position
+	Trades    []models.Trade             // Every filled trade before End, oldest first
+	Snapshots []models.PortfolioSnapshot // Daily snapshots within the period, oldest first
+	Cash      []models.CashTransaction   // Made between the first and last snapshots, oldest first
+	Start     time.Time
+	End       time.Time
+}
+
+// Metric is a single figure a metrics section can show
+type Metric struct {
+	Label string
+	Value func(d *Data) string
+}
+
+// Metrics are the figures available to metrics sections, by name
+var Metrics = map[string]Metric{
+	"total_value":           {"Total Value", func(d *Data) string { return money(d.Portfolio.TotalValue) }},
+	"cash":                  {"Cash", func(d *Data) string { return money(d.Portfolio.Cash) }},
+	"cash_weight":           {"Cash Weight", func(d *Data) string { return d.weight(d.Portfolio.Cash) }},
+	"market_value":          {"Market Value", func(d *Data) string { return money(d.marketValue()) }},
+	"gross_exposure":        {"Gross Exposure", func(d *Data) string { return d.weight(d.marketValue()) }},
+	"positions":             {"Positions", func(d *Data) string { return fmt.Sprintf("%d", len(d.Positions)) }},
+	"unrealized_pnl":        {"Unrealized P&L", func(d *Data) string { return money(d.unrealized()) }},
+	"realized_pnl":          {"Realized P&L", func(d *Data) string { return money(d.activity().realized) }},
+	"trades":                {"Trades", func(d *Data) string { return fmt.Sprintf("%d", d.activity().trades) }},
+	"turnover":              {"Turnover", func(d *Data) string { return money(d.activity().turnover) }},
+	"fees":                  {"Fees", func(d *Data) string { return money(d.activity().fees) }},
+	"win_rate":              {"Win Rate", func(d *Data) string { return d.activity().winRate() }},
+	"period_return":         {"Period Return", func(d *Data) string { return d.periodReturn() }},
+	"volatility":            {"Annualized Volatility", func(d *Data) string { return d.volatility() }},
+	"max_drawdown":          {"Max Drawdown", func(d *Data) string { return d.maxDrawdown() }},
+	"largest_weight":        {"Largest Position Weight", func(d *Data) string { return d.concentration(1) }},
+	"top5_weight":           {"Top 5 Concentration", func(d *Data) string { return d.concentration(5) }},
+	"unprotected_positions": {"Positions Without Stop-Loss", func(d *Data) string { return fmt.Sprintf("%d", d.unprotected()) }},
+}
+
+// summaryMetrics and riskMetrics are the fixed contents of the summary and
+// risk sections
+var (
+	summaryMetrics = []string{"total_value", "cash", "market_value", "unrealized_pnl", "realized_pnl", "trades"}
+	riskMetrics    = []string{"volatility", "max_drawdown", "largest_weight", "top5_weight", "gross_exposure", "cash_weight", "unprotected_positions"}
+)
+
+var sectionTitles = map[string]string{
+	SectionSummary:    "Summary",
+	SectionAllocation: "Allocation",
+	SectionTopTrades:  "Top Trades",
+	SectionRisk:       "Risk",
+	SectionMetrics:    "Metrics",
+}
+
+// ValidateTemplate checks a template's name, format, schedule and sections
+func ValidateTemplate(t *models.ReportTemplate) error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" || len(t.Name) > MaxTemplateName {
+		return fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidTemplate, MaxTemplateName)
+	}
+	if !ValidFormat(t.Format) {
+		return fmt.Errorf("%w: unsupported format %q", ErrInvalidTemplate, t.Format)
+	}
+	switch t.Schedule {
+	case "":
+	case ScheduleDaily, ScheduleWeekly, ScheduleMonthly:
+		if t.PortfolioID == nil {
+			return fmt.Errorf("%w: a scheduled template needs a portfolio_id", ErrInvalidTemplate)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported schedule %q", ErrInvalidTemplate, t.Schedule)
+	}
+
+	if len(t.Sections) == 0 || len(t.Sections) > MaxTemplateSections {
+		return fmt.Errorf("%w: a template needs 1 to %d sections", ErrInvalidTemplate, MaxTemplateSections)
+	}
+	for i, section := range t.Sections {
+		if _, ok := sectionTitles[section.Type]; !ok {
+			return fmt.Errorf("%w: section %d has unsupported type %q", ErrInvalidTemplate, i+1, section.Type)
+		}
+		if len(section.Title) > MaxTemplateName {
+			return fmt.Errorf("%w: section %d title exceeds %d characters", ErrInvalidTemplate, i+1, MaxTemplateName)
+		}
+		if section.Limit < 0 || section.Limit > MaxTopTrades {
+			return fmt.Errorf("%w: section %d limit must be 0 to %d", ErrInvalidTemplate, i+1, MaxTopTrades)
+		}
+		if section.Type != SectionMetrics {
+			if len(section.Metrics) > 0 {
+				return fmt.Errorf("%w: section %d: metrics apply only to metrics sections", ErrInvalidTemplate, i+1)
+			}
+			continue
+		}
+		if len(section.Metrics) == 0 {
+			return fmt.Errorf("%w: section %d lists no metrics", ErrInvalidTemplate, i+1)
+		}
+		for _, name := range section.Metrics {
+			if _, ok := Metrics[name]; !ok {
+				return fmt.Errorf("%w: section %d has unknown metric %q", ErrInvalidTemplate, i+1, name)
+			}
+		}
+	}
+	return nil
+}
+
+// SchedulePeriod reports whether a template on schedule is due on the day of
+// now, and the period [start, end) it then covers: the previous day, the
+// previous seven days on Mondays, or the previous month on the 1st
+func SchedulePeriod(schedule string, now time.Time) (start, end time.Time, due bool) {
+	end = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch schedule {
+	case ScheduleDaily:
+		return end.AddDate(0, 0, -1), end, true
+	case ScheduleWeekly:
+		return end.AddDate(0, 0, -7), end, now.Weekday() == time.Monday
+	case ScheduleMonthly:
+		return end.AddDate(0, -1, 0), end, now.Day() == 1
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// BuildTemplate lays a report out section by section as template describes
+func BuildTemplate(template *models.ReportTemplate, d *Data, now time.Time) Report {
+	report := Report{
+		Title:       template.Name,
+		Subtitle:    fmt.Sprintf("%s (portfolio %d), %s", d.Portfolio.Name, d.Portfolio.ID, period(d.Start, d.End)),
+		GeneratedAt: now,
+		Sections:    make([]Section, 0, len(template.Sections)),
+	}
+
+	for _, ts := range template.Sections {
+		var section Section
+		switch ts.Type {
+		case SectionSummary:
+			section = metricRow(d, summaryMetrics)
+		case SectionAllocation:
+			section = allocation(d)
+		case SectionTopTrades:
+			limit := ts.Limit
+			if limit == 0 {
+				limit = DefaultTopTrades
+			}
+			section = topTrades(d, limit)
+		case SectionRisk:
+			section = metricTable(d, riskMetrics)
+		case SectionMetrics:
+			section = metricTable(d, ts.Metrics)
+		}
+		section.Title = sectionTitles[ts.Type]
+		if ts.Title != "" {
+			section.Title = ts.Title
+		}
+		report.Sections = append(report.Sections, section)
+	}
+	return report
+}
+
+// metricRow shows metrics as the columns of a single row
+func metricRow(d *Data, names []string) Section {
+	section := Section{Rows: [][]string{make([]string, 0, len(names))}}
+	for _, name := range names {
+		section.Columns = append(section.Columns, Metrics[name].Label)
+		section.Rows[0] = append(section.Rows[0], Metrics[name].Value(d))
+	}
+	return section
+}
+
+// metricTable shows metrics one per row
+func metricTable(d *Data, names []string) Section {
+	section := Section{Columns: []string{"Metric", "Value"}, Rows: make([][]string, 0, len(names))}
+	for _, name := range names {
+		section.Rows = append(section.Rows, []string{Metrics[name].Label, Metrics[name].Value(d)})
+	}
+	return section
+}
+
+// allocation breaks the portfolio's value down by position, largest first,
+// then cash, with the same split as pie chart data
+func allocation(d *Data) Section {
+	positions := append([]models.Position(nil), d.Positions...)
+	sort.SliceStable(positions, func(i, j int) bool {
+		return positionValue(positions[i]) > positionValue(positions[j])
+	})
+
+	chart := &Chart{Type: "pie"}
+	for _, p := range positions {
+		chart.Labels = append(chart.Labels, p.Symbol)
+		chart.Values = append(chart.Values, positionValue(p))
+	}
+	chart.Labels = append(chart.Labels, "Cash")
+	chart.Values = append(chart.Values, d.Portfolio.Cash)
+
+	var total float64
+	for _, v := range chart.Values {
+		total += v
+	}
+	section := Section{Columns: []string{"Holding", "Market Value", "Weight"}, Chart: chart}
+	for i, label := range chart.Labels {
+		weight := "n/a"
+		if total > 0 {
+			weight = percent(chart.Values[i] / total)
+		}
+		section.Rows = append(section.Rows, []string{label, money(chart.Values[i]), weight})
+	}
+	return section
+}
+
+// topTrades lists the period's largest trades by value
+func topTrades(d *Data, limit int) Section {
+	type ranked struct {
+		trade    models.Trade
+		realized float64
+	}
+	pnl := realizedPnL(d.Trades)
+	var trades []ranked
+	for i, t := range d.Trades {
+		if ts := tradeTime(t); !ts.Before(d.Start) && ts.Before(d.End) {
+			trades = append(trades, ranked{t, pnl[i]})
+		}
+	}
+	sort.SliceStable(trades, func(i, j int) bool {
+		return tradeValue(trades[i].trade) > tradeValue(trades[j].trade)
+	})
+	if len(trades) > limit {
+		trades = trades[:limit]
+	}
+
+	section := Section{
+		Columns: []string{"Executed", "Symbol", "Side", "Quantity", "Price", "Value", "Realized P&L"},
+		Rows:    make([][]string, 0, len(trades)),
+	}
+	for _, r := range trades {
+		realized := ""
+		if r.trade.Side == "sell" {
+			realized = money(r.realized)
+		}
+		section.Rows = append(section.Rows, []string{
+			tradeTime(r.trade).Format("2006-01-02 15:04"), r.trade.Symbol, r.trade.Side, quantity(r.trade.Quantity),
+			money(r.trade.Price), money(tradeValue(r.trade)), realized,
+		})
+	}
+	return section
+}
+
+type periodActivity struct {
+	trades          int
+	turnover, fees  float64
+	realized        float64
+	wins, closingTx int
+}
+
+func (a periodActivity) winRate() string {
+	if a.closingTx == 0 {
+		return "n/a"
+	}
+	return percent(float64(a.wins) / float64(a.closingTx))
+}
+
+func (d *Data) activity() periodActivity {
+	var a periodActivity
+	pnl := realizedPnL(d.Trades)
+	for i, t := range d.Trades {
+		if ts := tradeTime(t); ts.Before(d.Start) || !ts.Before(d.End) {
+			continue
+		}
+		a.trades++
+		a.turnover += tradeValue(t)
+		a.fees += t.Fees
+		if t.Side == "sell" {
+			a.realized += pnl[i]
+			a.closingTx++
+			if pnl[i] > 0 {
+				a.wins++
+			}
+		}
+	}
+	return a
+}
+
+func (d *Data) marketValue() float64 {
+	var total float64
+	for _, p := range d.Positions {
+		total += positionValue(p)
+	}
+	return total
+}
+
+func (d *Data) unrealized() float64 {
+	var total float64
+	for _, p := range d.Positions {
+		total += p.UnrealizedPnL
+	}
+	return total
+}
+
+// weight is v as a share of the portfolio's total value
+func (d *Data) weight(v float64) string {
+	if d.Portfolio.TotalValue <= 0 {
+		return "n/a"
+	}
+	return percent(v / d.Portfolio.TotalValue)
+}
+
+// concentration is the combined weight of the n largest positions
+func (d *Data) concentration(n int) string {
+	values := make([]float64, 0, len(d.Positions))
+	for _, p := range d.Positions {
+		values = append(values, positionValue(p))
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(values)))
+
+	var top float64
+	for i := 0; i < n && i < len(values); i++ {
+		top += values[i]
+	}
+	return d.weight(top)
+}
+
+func (d *Data) unprotected() int {
+	count := 0
+	for i := range d.Positions {
+		if _, ok := d.Positions[i].StopLossTrigger(); !ok {
+			count++
+		}
+	}
+	return count
+}
+
+// periodReturn is the time-weighted return over the snapshots, so cash
+// moved in or out is neither a gain nor a loss
+func (d *Data) periodReturn() string {
+	returns, err := cashflow.Returns(d.Snapshots, d.Cash)
+	if err != nil || len(returns) == 0 {
+		return "n/a"
+	}
+	index := cashflow.Index(returns)
+	return percent(index[len(index)-1] - 1)
+}
+
+// volatility annualizes the standard deviation of daily snapshot returns,
+// net of cash flows
+func (d *Data) volatility() string {
+	returns, err := cashflow.Returns(d.Snapshots, d.Cash)
+	if err != nil || len(returns) < 2 {
+		return "n/a"
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	return percent(math.Sqrt(variance/float64(len(returns)-1)) * math.Sqrt(252))
+}
+
+func (d *Data) maxDrawdown() string {
+	analysis, err := drawdown.Analyze(d.Snapshots, d.Cash)
+	if err != nil || len(analysis.Underwater) == 0 {
+		return "n/a"
+	}
+	return percent(analysis.MaxDrawdown / 100)
+}
+
+func positionValue(p models.Position) float64 {
+	return p.Value(p.CurrentPrice)
+}
+
+func tradeValue(t models.Trade) float64 {
+	return t.Value(t.Price)
+}
+
+func percent(v float64) string {
+	return fmt.Sprintf("%.1f%%", v*100)
+}
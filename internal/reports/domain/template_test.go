@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+func templateData() *Data {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 15, 0, 0, 0, time.UTC) }
+	stop := 90.0
+	return &Data{
+		Portfolio: &models.Portfolio{ID: 7, Name: "Growth", Cash: 500, TotalValue: 2000},
+		Positions: []models.Position{
+			{Symbol: "MSFT", Quantity: 1, CurrentPrice: 500},
+			{Symbol: "AAPL", Quantity: 10, CurrentPrice: 100, StopLossPrice: &stop},
+		},
+		Trades: []models.Trade{
+			filledTrade("AAPL", "buy", 10, 100, day(1)),
+			filledTrade("AAPL", "buy", 10, 120, day(4)),
+			filledTrade("AAPL", "sell", 5, 130, day(10)),
+			filledTrade("MSFT", "buy", 1, 480, day(12)),
+		},
+		Snapshots: []models.PortfolioSnapshot{
+			{TotalValue: 1000}, {TotalValue: 1100}, {TotalValue: 990}, {TotalValue: 1200},
+		},
+		Start: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestBuildTemplateLaysOutSectionsInOrder(t *testing.T) {
+	template := &models.ReportTemplate{
+		Name: "Weekly Review",
+		Sections: []models.TemplateSection{
+			{Type: SectionAllocation},
+			{Type: SectionTopTrades, Limit: 2},
+			{Type: SectionMetrics, Title: "Extras", Metrics: []string{"win_rate", "max_drawdown", "top5_weight"}},
+			{Type: SectionSummary},
+		},
+	}
+	report := BuildTemplate(template, templateData(), time.Time{})
+
+	assert.Equal(t, "Weekly Review", report.Title)
+	require.Len(t, report.Sections, 4)
+
+	allocation := report.Sections[0]
+	assert.Equal(t, "Allocation", allocation.Title)
+	require.NotNil(t, allocation.Chart)
+	assert.Equal(t, []string{"AAPL", "MSFT", "Cash"}, allocation.Chart.Labels)
+	assert.Equal(t, []float64{1000, 500, 500}, allocation.Chart.Values)
+	assert.Equal(t, []string{"AAPL", "1000.00", "50.0%"}, allocation.Rows[0])
+
+	trades := report.Sections[1]
+	require.Len(t, trades.Rows, 2) // The day-1 buy is before the period
+	assert.Equal(t, "AAPL", trades.Rows[0][1])
+	assert.Equal(t, "1200.00", trades.Rows[0][5])
+	assert.Equal(t, "", trades.Rows[0][6])
+	assert.Equal(t, "650.00", trades.Rows[1][5])
+	assert.Equal(t, "100.00", trades.Rows[1][6]) // (130 - 110) * 5
+
+	metrics := report.Sections[2]
+	assert.Equal(t, "Extras", metrics.Title)
+	assert.Equal(t, [][]string{
+		{"Win Rate", "100.0%"},
+		{"Max Drawdown", "10.0%"},
+		{"Top 5 Concentration", "75.0%"},
+	}, metrics.Rows)
+
+	summary := report.Sections[3]
+	require.Len(t, summary.Rows, 1)
+	assert.Equal(t, len(summary.Columns), len(summary.Rows[0]))
+}
+
+func TestValidateTemplate(t *testing.T) {
+	portfolioID := 7
+	valid := func() *models.ReportTemplate {
+		return &models.ReportTemplate{
+			Name:     " Monthly ",
+			Format:   FormatHTML,
+			Sections: []models.TemplateSection{{Type: SectionSummary}, {Type: SectionMetrics, Metrics: []string{"fees"}}},
+		}
+	}
+
+	template := valid()
+	require.NoError(t, ValidateTemplate(template))
+	assert.Equal(t, "Monthly", template.Name)
+
+	cases := map[string]func(*models.ReportTemplate){
+		"no sections":        func(t *models.ReportTemplate) { t.Sections = nil },
+		"unknown section":    func(t *models.ReportTemplate) { t.Sections[0].Type = "chart" },
+		"unknown metric":     func(t *models.ReportTemplate) { t.Sections[1].Metrics = []string{"alpha"} },
+		"empty metrics":      func(t *models.ReportTemplate) { t.Sections[1].Metrics = nil },
+		"misplaced metrics":  func(t *models.ReportTemplate) { t.Sections[0].Metrics = []string{"fees"} },
+		"unscheduled target": func(t *models.ReportTemplate) { t.Schedule = ScheduleWeekly },
+		"bad schedule":       func(t *models.ReportTemplate) { t.Schedule = "hourly"; t.PortfolioID = &portfolioID },
+		"bad format":         func(t *models.ReportTemplate) { t.Format = "xlsx" },
+	}
+	for name, mutate := range cases {
+		template := valid()
+		mutate(template)
+		assert.True(t, errors.Is(ValidateTemplate(template), ErrInvalidTemplate), name)
+	}
+}
+
+func TestSchedulePeriod(t *testing.T) {
+	monday := time.Date(2024, 4, 1, 6, 0, 0, 0, time.UTC)
+	today := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	start, end, due := SchedulePeriod(ScheduleDaily, monday)
+	assert.True(t, due)
+	assert.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, today, end)
+
+	start, _, due = SchedulePeriod(ScheduleWeekly, monday)
+	assert.True(t, due)
+	assert.Equal(t, time.Date(2024, 3, 25, 0, 0, 0, 0, time.UTC), start)
+
+	start, _, due = SchedulePeriod(ScheduleMonthly, monday)
+	assert.True(t, due)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), start)
+
+	_, _, due = SchedulePeriod(ScheduleWeekly, monday.AddDate(0, 0, 1))
+	assert.False(t, due)
+	_, _, due = SchedulePeriod(ScheduleMonthly, monday.AddDate(0, 0, 1))
+	assert.False(t, due)
+}
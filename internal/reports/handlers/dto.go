@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"time"
+
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Request DTOs
+
+type GenerateReportRequest struct {
+	UserID     int    `json:"user_id"`
+	ReportType string `json:"report_type" binding:"required,oneof=performance positions trades tax custom"`
+	TemplateID *int   `json:"template_id"`                                        // Required for custom reports
+	Format     string `json:"format" binding:"omitempty,oneof=pdf html csv json"` // Defaults to the template's for custom reports
+	StartDate  string `json:"start_date" binding:"required"`                      // YYYY-MM-DD
+	EndDate    string `json:"end_date" binding:"required"`                        // YYYY-MM-DD, inclusive
+}
+
+// TaxReportRequest asks for the tax report of a calendar year
+type TaxReportRequest struct {
+	UserID int    `json:"user_id"`
+	Year   int    `json:"year" binding:"required"`
+	Format string `json:"format" binding:"omitempty,oneof=pdf html csv json"` // Defaults to pdf
+}
+
+// ReportTemplateRequest creates or replaces a report template
+type ReportTemplateRequest struct {
+	Name        string                   `json:"name" binding:"required"`
+	Sections    []models.TemplateSection `json:"sections" binding:"required,min=1"`
+	Format      string                   `json:"format" binding:"omitempty,oneof=pdf html csv json"` // Defaults to pdf
+	Schedule    string                   `json:"schedule" binding:"omitempty,oneof=daily weekly monthly"`
+	PortfolioID *int                     `json:"portfolio_id"` // Required with a schedule
+}
+
+// Response DTOs
+
+type ReportResponse struct {
+	ID          string     `json:"id"`
+	UserID      int        `json:"user_id"`
+	PortfolioID int        `json:"portfolio_id"`
+	ReportType  string     `json:"report_type"`
+	TemplateID  *int       `json:"template_id,omitempty"`
+	Format      string     `json:"format"`
+	StartDate   string     `json:"start_date"`
+	EndDate     string     `json:"end_date"`
+	Status      string     `json:"status"`
+	JobID       string     `json:"job_id,omitempty"`
+	SizeBytes   int64      `json:"size_bytes,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// ErrorResponse is the error envelope shared by every service
+type ErrorResponse = apierror.Response
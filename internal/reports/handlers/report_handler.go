@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hedge-fund/internal/reports/domain"
+	"hedge-fund/internal/reports/repository"
+	"hedge-fund/internal/reports/service"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const dateLayout = "2006-01-02"
+
+type ReportHandler struct {
+	service *service.ReportService
+	logger  *zap.Logger
+}
+
+func NewReportHandler(service *service.ReportService, logger *zap.Logger) *ReportHandler {
+	return &ReportHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GenerateReport godoc
+// @Summary Generate a portfolio report
+// @Description Enqueue a performance, positions, trade-history, tax or template-driven custom report over a date range, rendered as PDF, HTML, CSV or JSON
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param request body GenerateReportRequest true "Report request"
+// @Success 202 {object} ReportResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/reports [post]
+func (h *ReportHandler) GenerateReport(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID", Details: err.Error()})
+		return
+	}
+
+	var req GenerateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	start, err := time.Parse(dateLayout, req.StartDate)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid start_date, expected YYYY-MM-DD", Details: err.Error()})
+		return
+	}
+	end, err := time.Parse(dateLayout, req.EndDate)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid end_date, expected YYYY-MM-DD", Details: err.Error()})
+		return
+	}
+
+	report, err := h.service.RequestReport(c.Request.Context(), service.ReportRequest{
+		UserID:      req.UserID,
+		PortfolioID: portfolioID,
+		ReportType:  req.ReportType,
+		TemplateID:  req.TemplateID,
+		Format:      req.Format,
+		StartDate:   start,
+		EndDate:     end.AddDate(0, 0, 1), // end_date is inclusive
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Portfolio or template not found", Details: err.Error()})
+			return
+		}
+		h.logger.Error("Failed to request report", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Failed to request report", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, toReportResponse(report))
+}
+
+// GenerateTaxReport godoc
+// @Summary Generate a tax report
+// @Description Enqueue a calendar year's realized gains report: each lot sold with its cost basis and short or long-term holding period, and the losses disallowed as wash sales
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param request body TaxReportRequest true "Tax report request"
+// @Success 202 {object} ReportResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/portfolios/{id}/tax-reports [post]
+func (h *ReportHandler) GenerateTaxReport(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID", Details: err.Error()})
+		return
+	}
+
+	var req TaxReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+	if req.Format == "" {
+		req.Format = domain.FormatPDF
+	}
+
+	report, err := h.service.RequestTaxReport(c.Request.Context(), req.UserID, portfolioID, req.Year, req.Format)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Portfolio not found", Details: err.Error()})
+			return
+		}
+		h.logger.Error("Failed to request tax report", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Failed to request tax report", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, toReportResponse(report))
+}
+
+// GetReport godoc
+// @Summary Get report status
+// @Description Get a report's generation status and, once completed, its download URL
+// @Tags reports
+// @Produce json
+// @Param id path string true "Report ID"
+// @Success 200 {object} ReportResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/reports/{id} [get]
+func (h *ReportHandler) GetReport(c *gin.Context) {
+	report, err := h.service.GetReport(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Report not found", Details: err.Error()})
+			return
+		}
+		h.logger.Error("Failed to get report", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get report", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toReportResponse(report))
+}
+
+// DownloadReport godoc
+// @Summary Download a report
+// @Description Download a completed report file
+// @Tags reports
+// @Produce application/pdf
+// @Produce text/html
+// @Produce text/csv
+// @Produce json
+// @Param id path string true "Report ID"
+// @Success 200 {file} file
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/reports/{id}/download [get]
+func (h *ReportHandler) DownloadReport(c *gin.Context) {
+	report, body, err := h.service.Download(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Report not found", Details: err.Error()})
+		case strings.Contains(err.Error(), "not ready"):
+			apierror.Respond(c, http.StatusConflict, ErrorResponse{Code: apierror.CodeReportNotReady, Error: "Report not ready", Details: err.Error()})
+		default:
+			h.logger.Error("Failed to download report", zap.Error(err))
+			apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to download report", Details: err.Error()})
+		}
+		return
+	}
+	defer body.Close()
+
+	filename := fmt.Sprintf("%s-%d-%s.%s", report.ReportType, report.PortfolioID,
+		report.StartDate.Format(dateLayout), report.Format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Type", report.ContentType)
+	if report.SizeBytes > 0 {
+		c.Header("Content-Length", strconv.FormatInt(report.SizeBytes, 10))
+	}
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, body); err != nil {
+		h.logger.Warn("Failed to stream report", zap.Error(err), zap.String("report_id", report.ID))
+	}
+}
+
+// ListUserReports godoc
+// @Summary List a user's reports
+// @Description List a page of reports requested for a user's portfolios, newest first by default
+// @Tags reports
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param limit query int false "Number of reports to return" default(50)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Success 200 {object} pagination.Page{data=[]ReportResponse}
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/reports [get]
+func (h *ReportHandler) ListUserReports(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID", Details: err.Error()})
+		return
+	}
+	page, err := pagination.Parse(c.Request.URL.Query(), repository.ReportSorts, 50)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid pagination parameters", Details: err.Error()})
+		return
+	}
+
+	reports, result, err := h.service.GetUserReports(c.Request.Context(), userID, page)
+	if err != nil {
+		h.logger.Error("Failed to list reports", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to list reports", Details: err.Error()})
+		return
+	}
+
+	responses := make([]ReportResponse, len(reports))
+	for i := range reports {
+		responses[i] = toReportResponse(&reports[i])
+	}
+	c.JSON(http.StatusOK, result.Page(responses))
+}
+
+func toReportResponse(report *models.Report) ReportResponse {
+	response := ReportResponse{
+		ID:          report.ID,
+		UserID:      report.UserID,
+		PortfolioID: report.PortfolioID,
+		ReportType:  report.ReportType,
+		TemplateID:  report.TemplateID,
+		Format:      report.Format,
+		StartDate:   report.StartDate.Format(dateLayout),
+		EndDate:     report.EndDate.AddDate(0, 0, -1).Format(dateLayout),
+		Status:      report.Status,
+		JobID:       report.JobID,
+		SizeBytes:   report.SizeBytes,
+		Error:       report.Error,
+		CreatedAt:   report.CreatedAt,
+		CompletedAt: report.CompletedAt,
+	}
+	if report.Status == models.ReportCompleted {
+		response.DownloadURL = service.DownloadPath(report.ID)
+	}
+	return response
+}
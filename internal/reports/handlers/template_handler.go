@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hedge-fund/internal/reports/domain"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreateTemplate godoc
+// @Summary Create a report template
+// @Description Define a custom report layout from summary, allocation, top_trades, risk and metrics sections. A template with a schedule is generated daily, weekly (Mondays) or monthly (the 1st) for its portfolio.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param request body ReportTemplateRequest true "Report template"
+// @Success 201 {object} models.ReportTemplate
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/report-templates [post]
+func (h *ReportHandler) CreateTemplate(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID", Details: err.Error()})
+		return
+	}
+
+	var req ReportTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	template := &models.ReportTemplate{UserID: userID}
+	req.apply(template)
+	if err := h.service.CreateTemplate(c.Request.Context(), template); err != nil {
+		h.writeTemplateError(c, err, "Failed to create report template")
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// ListTemplates godoc
+// @Summary List a user's report templates
+// @Description List a user's report templates by name
+// @Tags reports
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {array} models.ReportTemplate
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/{user_id}/report-templates [get]
+func (h *ReportHandler) ListTemplates(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID", Details: err.Error()})
+		return
+	}
+
+	templates, err := h.service.ListTemplates(c.Request.Context(), userID)
+	if err != nil {
+		h.writeTemplateError(c, err, "Failed to list report templates")
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetTemplate godoc
+// @Summary Get a report template
+// @Tags reports
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.ReportTemplate
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/report-templates/{id} [get]
+func (h *ReportHandler) GetTemplate(c *gin.Context) {
+	templateID, ok := h.templateID(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.service.GetTemplate(c.Request.Context(), templateID)
+	if err != nil {
+		h.writeTemplateError(c, err, "Failed to get report template")
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// UpdateTemplate godoc
+// @Summary Replace a report template
+// @Description Replace a report template's name, sections, format and schedule
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param request body ReportTemplateRequest true "Report template"
+// @Success 200 {object} models.ReportTemplate
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/report-templates/{id} [put]
+func (h *ReportHandler) UpdateTemplate(c *gin.Context) {
+	templateID, ok := h.templateID(c)
+	if !ok {
+		return
+	}
+
+	var req ReportTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	template, err := h.service.GetTemplate(c.Request.Context(), templateID)
+	if err != nil {
+		h.writeTemplateError(c, err, "Failed to get report template")
+		return
+	}
+	req.apply(template)
+	if err := h.service.UpdateTemplate(c.Request.Context(), template); err != nil {
+		h.writeTemplateError(c, err, "Failed to update report template")
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteTemplate godoc
+// @Summary Delete a report template
+// @Description Delete a report template. Reports already generated from it are kept.
+// @Tags reports
+// @Param id path int true "Template ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/report-templates/{id} [delete]
+func (h *ReportHandler) DeleteTemplate(c *gin.Context) {
+	templateID, ok := h.templateID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteTemplate(c.Request.Context(), templateID); err != nil {
+		h.writeTemplateError(c, err, "Failed to delete report template")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (req *ReportTemplateRequest) apply(template *models.ReportTemplate) {
+	template.Name = req.Name
+	template.Sections = req.Sections
+	template.Format = req.Format
+	if template.Format == "" {
+		template.Format = domain.FormatPDF
+	}
+	template.Schedule = req.Schedule
+	template.PortfolioID = req.PortfolioID
+}
+
+func (h *ReportHandler) templateID(c *gin.Context) (int, bool) {
+	templateID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid template ID", Details: err.Error()})
+		return 0, false
+	}
+	return templateID, true
+}
+
+func (h *ReportHandler) writeTemplateError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidTemplate):
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid report template", Details: err.Error()})
+	case strings.Contains(err.Error(), "already exists"):
+		apierror.Respond(c, http.StatusConflict, ErrorResponse{Error: "Report template already exists", Details: err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Not found", Details: err.Error()})
+	default:
+		h.logger.Error(message, zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: message, Details: err.Error()})
+	}
+}
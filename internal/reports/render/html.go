@@ -0,0 +1,142 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+
+	"hedge-fund/internal/reports/domain"
+)
+
+// pieRadius is the radius in pixels of a section's pie chart
+const pieRadius = 80
+
+// pieColors are cycled through for the slices of a pie chart
+var pieColors = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac"}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.subtitle, .generated { color: #666; margin: 0.2em 0; }
+table { border-collapse: collapse; margin: 0.5em 0 1.5em; font-size: 0.9em; }
+th, td { border-bottom: 1px solid #ddd; padding: 4px 10px; text-align: left; }
+th { background: #f4f4f4; }
+.chart { display: flex; align-items: center; gap: 2em; }
+.swatch { display: inline-block; width: 10px; height: 10px; margin-right: 6px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Subtitle}}<p class="subtitle">{{.Subtitle}}</p>{{end}}
+<p class="generated">Generated {{.Generated}}</p>
+{{range .Sections}}
+<h2>{{.Title}}</h2>
+<div class="chart">
+{{if .Slices}}<svg width="{{.Size}}" height="{{.Size}}" viewBox="0 0 {{.Size}} {{.Size}}" role="img">
+{{range .Slices}}<path d="{{.Path}}" fill="{{.Color}}"><title>{{.Label}}</title></path>
+{{end}}</svg>{{end}}
+<table>
+<thead><tr>{{if .Slices}}<th></th>{{end}}{{range .Columns}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{- $slices := .Slices}}
+{{range $i, $row := .Rows}}<tr>{{if $slices}}<td>{{with index $slices $i}}<span class="swatch" style="background: {{.Color}}"></span>{{end}}</td>{{end}}{{range $row}}<td>{{.}}</td>{{end}}</tr>
+{{else}}<tr><td colspan="{{len .Columns}}">(none)</td></tr>
+{{end}}</tbody>
+</table>
+</div>
+{{end}}
+</body>
+</html>
+`))
+
+type htmlReport struct {
+	Title     string
+	Subtitle  string
+	Generated string
+	Sections  []htmlSection
+}
+
+type htmlSection struct {
+	domain.Section
+	Size   int
+	Slices []pieSlice
+}
+
+type pieSlice struct {
+	Label string
+	Color template.CSS
+	Path  string
+}
+
+// HTML renders a report as a standalone page, drawing pie charts as inline
+// SVG beside their tables
+func HTML(report domain.Report) ([]byte, error) {
+	page := htmlReport{
+		Title:     report.Title,
+		Subtitle:  report.Subtitle,
+		Generated: report.GeneratedAt.UTC().Format("2006-01-02 15:04 MST"),
+	}
+	for _, section := range report.Sections {
+		s := htmlSection{Section: section, Size: 2 * pieRadius}
+		if section.Chart != nil && section.Chart.Type == "pie" {
+			s.Slices = pieSlices(section.Chart)
+		}
+		page.Sections = append(page.Sections, s)
+	}
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, page); err != nil {
+		return nil, fmt.Errorf("failed to render html: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pieSlices draws each positive value as a wedge of a circle. Slices line up
+// with the chart's labels, and so with its section's rows; those with no
+// share are left undrawn.
+func pieSlices(chart *domain.Chart) []pieSlice {
+	var total float64
+	for _, v := range chart.Values {
+		if v > 0 {
+			total += v
+		}
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	slices := make([]pieSlice, len(chart.Labels))
+	angle := -math.Pi / 2 // Start at 12 o'clock
+	for i, label := range chart.Labels {
+		slices[i] = pieSlice{Label: label, Color: template.CSS(pieColors[i%len(pieColors)])}
+		if i >= len(chart.Values) || chart.Values[i] <= 0 {
+			continue
+		}
+
+		share := chart.Values[i] / total
+		if share >= 1 {
+			// A single full-circle arc has coincident end points, so draw two halves
+			slices[i].Path = fmt.Sprintf("M %d %d m -%d 0 a %d %d 0 1 0 %d 0 a %d %d 0 1 0 -%d 0",
+				pieRadius, pieRadius, pieRadius, pieRadius, pieRadius, 2*pieRadius, pieRadius, pieRadius, 2*pieRadius)
+			continue
+		}
+		end := angle + share*2*math.Pi
+		largeArc := 0
+		if share > 0.5 {
+			largeArc = 1
+		}
+		slices[i].Path = fmt.Sprintf("M %d %d L %.2f %.2f A %d %d 0 %d 1 %.2f %.2f Z",
+			pieRadius, pieRadius,
+			pieRadius+pieRadius*math.Cos(angle), pieRadius+pieRadius*math.Sin(angle),
+			pieRadius, pieRadius, largeArc,
+			pieRadius+pieRadius*math.Cos(end), pieRadius+pieRadius*math.Sin(end))
+		angle = end
+	}
+	return slices
+}
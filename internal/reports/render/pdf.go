@@ -0,0 +1,217 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"hedge-fund/internal/reports/domain"
+)
+
+// Page layout in points (US Letter, landscape so wide tables fit)
+const (
+	pageWidth    = 792
+	pageHeight   = 612
+	margin       = 40
+	bodySize     = 8
+	lineHeight   = 11
+	titleSize    = 16
+	headingSize  = 11
+	charWidth    = 0.6 * bodySize // Courier advance width
+	columnGutter = 2
+)
+
+// PDF lays a report out as monospaced tables using the standard PDF fonts, so
+// no font embedding or external library is needed
+func PDF(report domain.Report) []byte {
+	l := &pdfLayout{}
+	l.newPage()
+
+	l.text("Helvetica-Bold", titleSize, report.Title)
+	l.advance(titleSize + 4)
+	if report.Subtitle != "" {
+		l.text("Helvetica", headingSize, report.Subtitle)
+		l.advance(lineHeight + 2)
+	}
+	l.text("Helvetica", bodySize, "Generated "+report.GeneratedAt.UTC().Format("2006-01-02 15:04 MST"))
+	l.advance(lineHeight * 2)
+
+	for _, section := range report.Sections {
+		l.ensure(lineHeight * 4)
+		l.text("Helvetica-Bold", headingSize, section.Title)
+		l.advance(lineHeight + 4)
+
+		widths := columnWidths(section)
+		header := formatRow(section.Columns, widths)
+		l.text("Courier-Bold", bodySize, header)
+		l.advance(lineHeight)
+		l.text("Courier", bodySize, strings.Repeat("-", len(header)))
+		l.advance(lineHeight)
+
+		if len(section.Rows) == 0 {
+			l.text("Courier", bodySize, "(none)")
+			l.advance(lineHeight)
+		}
+		for _, row := range section.Rows {
+			if l.ensure(lineHeight) {
+				l.text("Courier-Bold", bodySize, header)
+				l.advance(lineHeight)
+			}
+			l.text("Courier", bodySize, formatRow(row, widths))
+			l.advance(lineHeight)
+		}
+		l.advance(lineHeight)
+	}
+
+	return l.bytes()
+}
+
+// columnWidths sizes columns to their widest cell, shrinking the widest
+// columns until the table fits the page
+func columnWidths(section domain.Section) []int {
+	widths := make([]int, len(section.Columns))
+	for i, col := range section.Columns {
+		widths[i] = len(col)
+	}
+	for _, row := range section.Rows {
+		for i, cell := range row {
+			if i < len(widths) && len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	usable := float64(pageWidth - 2*margin)
+	maxChars := int(usable / charWidth)
+	for {
+		total := 0
+		widest := 0
+		for i, w := range widths {
+			total += w + columnGutter
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if total <= maxChars || widths[widest] <= 4 {
+			return widths
+		}
+		widths[widest]--
+	}
+}
+
+func formatRow(cells []string, widths []int) string {
+	var b strings.Builder
+	for i, w := range widths {
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		if len(cell) > w {
+			cell = cell[:w-1] + "~"
+		}
+		b.WriteString(fmt.Sprintf("%-*s", w+columnGutter, cell))
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+type pdfLayout struct {
+	pages   []*bytes.Buffer
+	current *bytes.Buffer
+	y       float64
+}
+
+func (l *pdfLayout) newPage() {
+	l.current = &bytes.Buffer{}
+	l.pages = append(l.pages, l.current)
+	l.y = pageHeight - margin
+}
+
+// ensure starts a new page if fewer than height points remain, reporting whether it did
+func (l *pdfLayout) ensure(height float64) bool {
+	if l.y-height < margin {
+		l.newPage()
+		return true
+	}
+	return false
+}
+
+func (l *pdfLayout) advance(height float64) {
+	l.y -= height
+}
+
+func (l *pdfLayout) text(font string, size float64, s string) {
+	fmt.Fprintf(l.current, "BT /%s %g Tf %d %.2f Td (%s) Tj ET\n", fontResource(font), size, margin, l.y-size, escapePDF(s))
+}
+
+var fonts = []string{"Helvetica", "Helvetica-Bold", "Courier", "Courier-Bold"}
+
+func fontResource(font string) string {
+	for i, f := range fonts {
+		if f == font {
+			return fmt.Sprintf("F%d", i+1)
+		}
+	}
+	return "F1"
+}
+
+// escapePDF escapes string delimiters and replaces characters outside the
+// standard fonts' ASCII range
+func escapePDF(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// bytes assembles the document: catalog, page tree, fonts, then a page and
+// content stream per page, followed by the cross-reference table
+func (l *pdfLayout) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	fontBase := 3
+	pageBase := fontBase + len(fonts)
+	kids := make([]string, len(l.pages))
+	for i := range l.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageBase+2*i)
+	}
+	fontRefs := make([]string, len(fonts))
+	for i := range fonts {
+		fontRefs[i] = fmt.Sprintf("/F%d %d 0 R", i+1, fontBase+i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(l.pages)))
+	for _, font := range fonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font))
+	}
+	for i, page := range l.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, strings.Join(fontRefs, " "), pageBase+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
@@ -0,0 +1,55 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+
+	"hedge-fund/internal/reports/domain"
+)
+
+// Render encodes a report in the given format, returning the bytes and content type
+func Render(report domain.Report, format string) ([]byte, string, error) {
+	switch format {
+	case domain.FormatCSV:
+		data, err := CSV(report)
+		return data, "text/csv", err
+	case domain.FormatPDF:
+		return PDF(report), "application/pdf", nil
+	case domain.FormatHTML:
+		data, err := HTML(report)
+		return data, "text/html; charset=utf-8", err
+	case domain.FormatJSON:
+		data, err := json.MarshalIndent(report, "", "  ")
+		return data, "application/json", err
+	}
+	return nil, "", fmt.Errorf("unsupported report format: %s", format)
+}
+
+// CSV writes each section as a table. A single-section report is written as a
+// plain table; otherwise each table is preceded by its title and followed by
+// a blank line.
+func CSV(report domain.Report) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	for i, section := range report.Sections {
+		if len(report.Sections) > 1 {
+			if i > 0 {
+				w.Write(nil)
+			}
+			w.Write([]string{section.Title})
+		}
+		w.Write(section.Columns)
+		for _, row := range section.Rows {
+			w.Write(row)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
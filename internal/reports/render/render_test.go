@@ -0,0 +1,64 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/internal/reports/domain"
+)
+
+func sampleReport(rows int) domain.Report {
+	section := domain.Section{Title: "Trades", Columns: []string{"Symbol", "Quantity"}}
+	for i := 0; i < rows; i++ {
+		section.Rows = append(section.Rows, []string{"AAPL", "10"})
+	}
+	return domain.Report{
+		Title:       "Trade History",
+		GeneratedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Sections:    []domain.Section{section},
+	}
+}
+
+func TestCSVSingleSectionIsPlainTable(t *testing.T) {
+	data, contentType, err := Render(sampleReport(2), domain.FormatCSV)
+	require.NoError(t, err)
+
+	assert.Equal(t, "text/csv", contentType)
+	assert.Equal(t, "Symbol,Quantity\nAAPL,10\nAAPL,10\n", string(data))
+}
+
+func TestPDFPaginatesLongTables(t *testing.T) {
+	data, contentType, err := Render(sampleReport(200), domain.FormatPDF)
+	require.NoError(t, err)
+
+	assert.Equal(t, "application/pdf", contentType)
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF-")))
+	assert.True(t, bytes.HasSuffix(bytes.TrimSpace(data), []byte("%%EOF")))
+	assert.Greater(t, strings.Count(string(data), "/Type /Page "), 1)
+}
+
+func TestHTMLEscapesCellsAndDrawsPieCharts(t *testing.T) {
+	report := sampleReport(1)
+	report.Sections[0].Rows[0][0] = "<script>"
+	report.Sections = append(report.Sections, domain.Section{
+		Title:   "Allocation",
+		Columns: []string{"Holding", "Weight"},
+		Rows:    [][]string{{"AAPL", "75.0%"}, {"Cash", "25.0%"}},
+		Chart:   &domain.Chart{Type: "pie", Labels: []string{"AAPL", "Cash"}, Values: []float64{300, 100}},
+	})
+
+	data, contentType, err := Render(report, domain.FormatHTML)
+	require.NoError(t, err)
+
+	assert.Equal(t, "text/html; charset=utf-8", contentType)
+	html := string(data)
+	assert.NotContains(t, html, "<script>")
+	assert.Contains(t, html, "&lt;script&gt;")
+	assert.Equal(t, 1, strings.Count(html, "<svg"))
+	assert.Equal(t, 2, strings.Count(html, "<path"))
+	assert.Contains(t, html, "A 80 80 0 1 1") // AAPL's wedge is more than half the pie
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+)
+
+type ReportRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewReportRepository(db *database.DB, logger *zap.Logger) *ReportRepository {
+	return &ReportRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const reportColumns = `
+	id, user_id, portfolio_id, report_type, template_id, format, start_date, end_date, status, COALESCE(job_id, ''),
+	COALESCE(storage_key, ''), COALESCE(content_type, ''), size_bytes, COALESCE(error, ''), created_at, completed_at`
+
+// CreateReport inserts a pending report
+func (r *ReportRepository) CreateReport(ctx context.Context, report *models.Report) error {
+	query := `
+		INSERT INTO reports (id, user_id, portfolio_id, report_type, template_id, format, start_date, end_date, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		report.ID,
+		report.UserID,
+		report.PortfolioID,
+		report.ReportType,
+		report.TemplateID,
+		report.Format,
+		report.StartDate,
+		report.EndDate,
+		report.Status,
+	).Scan(&report.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create report", zap.Error(err), zap.Int("portfolio_id", report.PortfolioID))
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+
+	return nil
+}
+
+// SetJobID records the queue job building a report
+func (r *ReportRepository) SetJobID(ctx context.Context, reportID, jobID string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE reports SET job_id = $2 WHERE id = $1`, reportID, jobID)
+	if err != nil {
+		r.logger.Error("Failed to set report job", zap.Error(err), zap.String("report_id", reportID))
+		return fmt.Errorf("failed to update report: %w", err)
+	}
+	return nil
+}
+
+// GetReport retrieves a report by ID
+func (r *ReportRepository) GetReport(ctx context.Context, reportID string) (*models.Report, error) {
+	query := `SELECT ` + reportColumns + ` FROM reports WHERE id = $1`
+
+	report, err := scanReport(r.db.QueryRowContext(ctx, query, reportID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("report not found: %s", reportID)
+		}
+		r.logger.Error("Failed to get report", zap.Error(err), zap.String("report_id", reportID))
+		return nil, fmt.Errorf("failed to get report: %w", err)
+	}
+
+	return report, nil
+}
+
+// ReportSorts whitelists the fields a user's reports can be sorted by
+var ReportSorts = pagination.Sorts{
+	ID: pagination.Field{Column: "id", Type: "uuid"},
+	Fields: map[string]pagination.Field{
+		"created_at": {Column: "created_at", Type: "timestamptz"},
+	},
+	Default: "created_at",
+	Desc:    true,
+}
+
+// GetReportsByUserID retrieves a page of a user's reports
+func (r *ReportRepository) GetReportsByUserID(ctx context.Context, userID int, page pagination.Request) ([]models.Report, pagination.Result, error) {
+	cond, args := page.After(2)
+	query := `SELECT ` + reportColumns + `, ` + page.Key() + `
+		FROM reports
+		WHERE user_id = $1` + cond + page.OrderBy()
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{userID}, args...)...)
+	if err != nil {
+		r.logger.Error("Failed to get reports", zap.Error(err), zap.Int("user_id", userID))
+		return nil, pagination.Result{}, fmt.Errorf("failed to get reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []models.Report
+	var keys []string
+	for rows.Next() {
+		var key string
+		report, err := scanReport(keyedRow{row: rows, key: &key})
+		if err != nil {
+			return nil, pagination.Result{}, fmt.Errorf("failed to scan report: %w", err)
+		}
+		reports = append(reports, *report)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("error iterating reports: %w", err)
+	}
+
+	var result pagination.Result
+	if page.More(len(reports)) {
+		reports = reports[:page.Limit]
+		result.NextCursor = page.Cursor(keys[page.Limit-1], reports[page.Limit-1].ID)
+	}
+	err = r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM reports WHERE user_id = $1`, userID).Scan(&result.Total)
+	if err != nil {
+		return nil, pagination.Result{}, fmt.Errorf("failed to count reports: %w", err)
+	}
+
+	return reports, result, nil
+}
+
+// CompleteReport marks a report as stored and ready for download
+func (r *ReportRepository) CompleteReport(ctx context.Context, reportID, storageKey, contentType string, size int64) error {
+	query := `
+		UPDATE reports
+		SET status = $2, storage_key = $3, content_type = $4, size_bytes = $5, error = NULL, completed_at = $6
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, reportID, models.ReportCompleted, storageKey, contentType, size, time.Now())
+	if err != nil {
+		r.logger.Error("Failed to complete report", zap.Error(err), zap.String("report_id", reportID))
+		return fmt.Errorf("failed to update report: %w", err)
+	}
+	return nil
+}
+
+// FailReport marks a report as failed with the reason
+func (r *ReportRepository) FailReport(ctx context.Context, reportID, reason string) error {
+	query := `UPDATE reports SET status = $2, error = $3, completed_at = $4 WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, reportID, models.ReportFailed, reason, time.Now())
+	if err != nil {
+		r.logger.Error("Failed to mark report failed", zap.Error(err), zap.String("report_id", reportID))
+		return fmt.Errorf("failed to update report: %w", err)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// keyedRow appends a page's sort key to the columns scanReport reads
+type keyedRow struct {
+	row rowScanner
+	key *string
+}
+
+func (k keyedRow) Scan(dest ...interface{}) error {
+	return k.row.Scan(append(dest, k.key)...)
+}
+
+func scanReport(row rowScanner) (*models.Report, error) {
+	report := &models.Report{}
+	err := row.Scan(
+		&report.ID,
+		&report.UserID,
+		&report.PortfolioID,
+		&report.ReportType,
+		&report.TemplateID,
+		&report.Format,
+		&report.StartDate,
+		&report.EndDate,
+		&report.Status,
+		&report.JobID,
+		&report.StorageKey,
+		&report.ContentType,
+		&report.SizeBytes,
+		&report.Error,
+		&report.CreatedAt,
+		&report.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
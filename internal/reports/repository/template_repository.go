@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Report Template Operations
+
+const templateColumns = `
+	id, user_id, name, sections, format, COALESCE(schedule, ''), portfolio_id, last_run_at, created_at, updated_at`
+
+// CreateTemplate saves a new report template
+func (r *ReportRepository) CreateTemplate(ctx context.Context, template *models.ReportTemplate) error {
+	sections, err := json.Marshal(template.Sections)
+	if err != nil {
+		return fmt.Errorf("failed to encode template sections: %w", err)
+	}
+
+	query := `
+		INSERT INTO report_templates (user_id, name, sections, format, schedule, portfolio_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8)
+		RETURNING id`
+
+	now := time.Now()
+	err = r.db.QueryRowContext(ctx, query, template.UserID, template.Name, sections, template.Format,
+		template.Schedule, template.PortfolioID, now, now).Scan(&template.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("report template %q already exists for user %d", template.Name, template.UserID)
+		}
+		r.logger.Error("Failed to create report template", zap.Error(err), zap.Int("user_id", template.UserID))
+		return fmt.Errorf("failed to create report template: %w", err)
+	}
+
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	return nil
+}
+
+// GetTemplate retrieves a report template by ID
+func (r *ReportRepository) GetTemplate(ctx context.Context, templateID int) (*models.ReportTemplate, error) {
+	query := `SELECT ` + templateColumns + ` FROM report_templates WHERE id = $1`
+
+	template, err := scanTemplate(r.db.QueryRowContext(ctx, query, templateID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("report template not found: %d", templateID)
+		}
+		r.logger.Error("Failed to get report template", zap.Error(err), zap.Int("template_id", templateID))
+		return nil, fmt.Errorf("failed to get report template: %w", err)
+	}
+
+	return template, nil
+}
+
+// GetTemplatesByUserID retrieves a user's report templates by name
+func (r *ReportRepository) GetTemplatesByUserID(ctx context.Context, userID int) ([]models.ReportTemplate, error) {
+	query := `SELECT ` + templateColumns + ` FROM report_templates WHERE user_id = $1 ORDER BY name`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("Failed to get report templates", zap.Error(err), zap.Int("user_id", userID))
+		return nil, fmt.Errorf("failed to get report templates: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTemplates(rows)
+}
+
+// GetScheduledTemplates retrieves every template with a schedule and a
+// portfolio to report on
+func (r *ReportRepository) GetScheduledTemplates(ctx context.Context) ([]models.ReportTemplate, error) {
+	query := `SELECT ` + templateColumns + `
+		FROM report_templates
+		WHERE schedule IS NOT NULL AND portfolio_id IS NOT NULL
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to get scheduled report templates", zap.Error(err))
+		return nil, fmt.Errorf("failed to get report templates: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTemplates(rows)
+}
+
+// UpdateTemplate replaces a report template's name, sections, format and schedule
+func (r *ReportRepository) UpdateTemplate(ctx context.Context, template *models.ReportTemplate) error {
+	sections, err := json.Marshal(template.Sections)
+	if err != nil {
+		return fmt.Errorf("failed to encode template sections: %w", err)
+	}
+
+	query := `
+		UPDATE report_templates
+		SET name = $2, sections = $3, format = $4, schedule = NULLIF($5, ''), portfolio_id = $6, updated_at = $7
+		WHERE id = $1`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, template.ID, template.Name, sections, template.Format,
+		template.Schedule, template.PortfolioID, now)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("report template %q already exists for user %d", template.Name, template.UserID)
+		}
+		r.logger.Error("Failed to update report template", zap.Error(err), zap.Int("template_id", template.ID))
+		return fmt.Errorf("failed to update report template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("report template not found: %d", template.ID)
+	}
+
+	template.UpdatedAt = now
+	return nil
+}
+
+// SetTemplateRun records when a scheduled template was last generated
+func (r *ReportRepository) SetTemplateRun(ctx context.Context, templateID int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE report_templates SET last_run_at = $2 WHERE id = $1`, templateID, at)
+	if err != nil {
+		r.logger.Error("Failed to record report template run", zap.Error(err), zap.Int("template_id", templateID))
+		return fmt.Errorf("failed to update report template: %w", err)
+	}
+	return nil
+}
+
+// DeleteTemplate deletes a report template. Reports generated from it are kept.
+func (r *ReportRepository) DeleteTemplate(ctx context.Context, templateID int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM report_templates WHERE id = $1", templateID)
+	if err != nil {
+		r.logger.Error("Failed to delete report template", zap.Error(err), zap.Int("template_id", templateID))
+		return fmt.Errorf("failed to delete report template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("report template not found: %d", templateID)
+	}
+
+	return nil
+}
+
+func scanTemplates(rows *sql.Rows) ([]models.ReportTemplate, error) {
+	templates := []models.ReportTemplate{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan report template: %w", err)
+		}
+		templates = append(templates, *template)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating report templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+func scanTemplate(row rowScanner) (*models.ReportTemplate, error) {
+	template := &models.ReportTemplate{}
+	var sections []byte
+	err := row.Scan(
+		&template.ID,
+		&template.UserID,
+		&template.Name,
+		&sections,
+		&template.Format,
+		&template.Schedule,
+		&template.PortfolioID,
+		&template.LastRunAt,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(sections, &template.Sections); err != nil {
+		return nil, fmt.Errorf("failed to decode template sections: %w", err)
+	}
+	return template, nil
+}
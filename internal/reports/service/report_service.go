@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"hedge-fund/internal/reports/domain"
+	"hedge-fund/internal/reports/render"
+	"hedge-fund/internal/reports/repository"
+	"hedge-fund/internal/reports/storage"
+	"hedge-fund/pkg/shared/auth"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/pagination"
+	"hedge-fund/pkg/shared/queue"
+)
+
+// maxReportPeriod bounds the date range of a single report
+const maxReportPeriod = 5 * 366 * 24 * time.Hour
+
+// PortfolioReader is the portfolio data a report is built from
+type PortfolioReader interface {
+	GetPortfolioByID(ctx context.Context, portfolioID int) (*models.Portfolio, error)
+	GetPositionsByPortfolioID(ctx context.Context, portfolioID int) ([]models.Position, error)
+	GetFilledTradesByPortfolioID(ctx context.Context, portfolioID int, start, end time.Time) ([]models.Trade, error)
+	GetSnapshotsBetween(ctx context.Context, portfolioID int, start, end time.Time) ([]models.PortfolioSnapshot, error)
+	GetCashTransactionsBetween(ctx context.Context, portfolioID int, start, end time.Time) ([]models.CashTransaction, error)
+}
+
+// SettingsReader resolves a portfolio's effective settings
+type SettingsReader interface {
+	GetSettings(ctx context.Context, portfolioID int) (*models.PortfolioSettings, error)
+}
+
+// ReportRequest asks for a report over [StartDate, EndDate). A custom report
+// is laid out by TemplateID and defaults to its format.
+type ReportRequest struct {
+	UserID      int
+	PortfolioID int
+	ReportType  string
+	TemplateID  *int
+	Format      string
+	StartDate   time.Time
+	EndDate     time.Time
+}
+
+type ReportService struct {
+	repo       *repository.ReportRepository
+	portfolios PortfolioReader
+	settings   SettingsReader
+	store      storage.Store
+	queue      *queue.Manager
+	logger     *zap.Logger
+}
+
+func NewReportService(repo *repository.ReportRepository, portfolios PortfolioReader, store storage.Store, queue *queue.Manager, logger *zap.Logger) *ReportService {
+	return &ReportService{
+		repo:       repo,
+		portfolios: portfolios,
+		store:      store,
+		queue:      queue,
+		logger:     logger,
+	}
+}
+
+// SetSettings sets where portfolios' settings are resolved, so tax reports
+// match sales to lots by each portfolio's cost basis method. Without it they
+// are matched first in first out.
+func (s *ReportService) SetSettings(settings SettingsReader) {
+	s.settings = settings
+}
+
+// RequestReport records a pending report and enqueues the job that builds it
+func (s *ReportService) RequestReport(ctx context.Context, req ReportRequest) (*models.Report, error) {
+	if !domain.ValidType(req.ReportType) {
+		return nil, fmt.Errorf("unsupported report type: %s", req.ReportType)
+	}
+	if (req.ReportType == domain.TypeCustom) != (req.TemplateID != nil) {
+		return nil, fmt.Errorf("a template_id is required for custom reports and only for them")
+	}
+	var template *models.ReportTemplate
+	if req.TemplateID != nil {
+		var err error
+		if template, err = s.GetTemplate(ctx, *req.TemplateID); err != nil {
+			return nil, err
+		}
+		if req.Format == "" {
+			req.Format = template.Format
+		}
+	}
+	if !domain.ValidFormat(req.Format) {
+		return nil, fmt.Errorf("unsupported report format: %s", req.Format)
+	}
+	if !req.EndDate.After(req.StartDate) {
+		return nil, fmt.Errorf("end date must be after start date")
+	}
+	if req.EndDate.Sub(req.StartDate) > maxReportPeriod {
+		return nil, fmt.Errorf("report period cannot exceed 5 years")
+	}
+
+	portfolio, err := s.portfolios.GetPortfolioByID(ctx, req.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if req.UserID != 0 && req.UserID != portfolio.UserID {
+		return nil, fmt.Errorf("portfolio not found: %d", req.PortfolioID)
+	}
+	if template != nil && template.UserID != portfolio.UserID {
+		return nil, fmt.Errorf("report template not found: %d", template.ID)
+	}
+
+	report := &models.Report{
+		ID:          uuid.New().String(),
+		UserID:      portfolio.UserID,
+		PortfolioID: portfolio.ID,
+		ReportType:  req.ReportType,
+		TemplateID:  req.TemplateID,
+		Format:      req.Format,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		Status:      models.ReportPending,
+	}
+	if err := s.repo.CreateReport(ctx, report); err != nil {
+		return nil, err
+	}
+
+	jobID, err := s.queue.EnqueueReportGeneration(report.ID, report.UserID, report.PortfolioID,
+		report.ReportType, report.Format, report.StartDate, report.EndDate)
+	if err != nil {
+		s.repo.FailReport(ctx, report.ID, err.Error())
+		return nil, fmt.Errorf("failed to enqueue report: %w", err)
+	}
+	if err := s.repo.SetJobID(ctx, report.ID, jobID); err != nil {
+		s.logger.Warn("Failed to record report job", zap.Error(err), zap.String("report_id", report.ID))
+	}
+	report.JobID = jobID
+
+	s.logger.Info("Report requested",
+		zap.String("report_id", report.ID),
+		zap.Int("portfolio_id", report.PortfolioID),
+		zap.String("report_type", report.ReportType),
+		zap.String("format", report.Format))
+
+	return report, nil
+}
+
+// RequestTaxReport requests the tax report of a calendar year
+func (s *ReportService) RequestTaxReport(ctx context.Context, userID, portfolioID, year int, format string) (*models.Report, error) {
+	if year < 1970 || year > time.Now().UTC().Year() {
+		return nil, fmt.Errorf("unsupported tax year: %d", year)
+	}
+	return s.RequestReport(ctx, ReportRequest{
+		UserID:      userID,
+		PortfolioID: portfolioID,
+		ReportType:  domain.TypeTax,
+		Format:      format,
+		StartDate:   time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:     time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+}
+
+// GetReport retrieves a report's metadata
+func (s *ReportService) GetReport(ctx context.Context, reportID string) (*models.Report, error) {
+	report, err := s.repo.GetReport(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.CanAccess(ctx, report.UserID) {
+		return nil, fmt.Errorf("report not found: %s", reportID)
+	}
+	return report, nil
+}
+
+// GetUserReports retrieves a page of a user's reports
+func (s *ReportService) GetUserReports(ctx context.Context, userID int, page pagination.Request) ([]models.Report, pagination.Result, error) {
+	return s.repo.GetReportsByUserID(ctx, userID, page)
+}
+
+// Download opens a completed report's file. The caller must close the reader.
+func (s *ReportService) Download(ctx context.Context, reportID string) (*models.Report, io.ReadCloser, error) {
+	report, err := s.GetReport(ctx, reportID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if report.Status != models.ReportCompleted {
+		return report, nil, fmt.Errorf("report is not ready: status %s", report.Status)
+	}
+
+	body, err := s.store.Get(ctx, report.StorageKey)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return report, nil, fmt.Errorf("report file not found: %s", reportID)
+		}
+		return report, nil, err
+	}
+	return report, body, nil
+}
+
+// Generate builds, renders and stores a report, then notifies its owner
+func (s *ReportService) Generate(ctx context.Context, reportID string) error {
+	report, err := s.repo.GetReport(ctx, reportID)
+	if err != nil {
+		return err
+	}
+	if report.Status == models.ReportCompleted {
+		return nil // Already built by an earlier delivery of the job
+	}
+
+	built, err := s.build(ctx, report)
+	if err != nil {
+		return err
+	}
+
+	data, contentType, err := render.Render(built, report.Format)
+	if err != nil {
+		return err
+	}
+
+	key := StorageKey(report)
+	if err := s.store.Put(ctx, key, data, contentType); err != nil {
+		return err
+	}
+	if err := s.repo.CompleteReport(ctx, report.ID, key, contentType, int64(len(data))); err != nil {
+		return err
+	}
+
+	s.logger.Info("Report generated",
+		zap.String("report_id", report.ID),
+		zap.String("storage_key", key),
+		zap.Int("size_bytes", len(data)))
+
+	if _, err := s.queue.EnqueueNotification(report.UserID, "report_ready", "", "", map[string]interface{}{
+		"report_type":  report.ReportType,
+		"portfolio_id": report.PortfolioID,
+		"url":          DownloadPath(report.ID),
+	}, nil); err != nil {
+		s.logger.Warn("Failed to enqueue report notification", zap.Error(err), zap.String("report_id", report.ID))
+	}
+
+	return nil
+}
+
+func (s *ReportService) build(ctx context.Context, report *models.Report) (domain.Report, error) {
+	portfolio, err := s.portfolios.GetPortfolioByID(ctx, report.PortfolioID)
+	if err != nil {
+		return domain.Report{}, err
+	}
+	now := time.Now().UTC()
+
+	switch report.ReportType {
+	case domain.TypePositions:
+		positions, err := s.portfolios.GetPositionsByPortfolioID(ctx, report.PortfolioID)
+		if err != nil {
+			return domain.Report{}, err
+		}
+		return domain.BuildPositions(portfolio, positions, now), nil
+	case domain.TypeTradeHistory:
+		trades, err := s.portfolios.GetFilledTradesByPortfolioID(ctx, report.PortfolioID, report.StartDate, report.EndDate)
+		if err != nil {
+			return domain.Report{}, err
+		}
+		return domain.BuildTradeHistory(portfolio, trades, report.StartDate, report.EndDate, now), nil
+	case domain.TypePerformance:
+		// Trades before the period establish the cost basis of positions sold within it
+		trades, err := s.portfolios.GetFilledTradesByPortfolioID(ctx, report.PortfolioID, time.Time{}, report.EndDate)
+		if err != nil {
+			return domain.Report{}, err
+		}
+		snapshots, err := s.portfolios.GetSnapshotsBetween(ctx, report.PortfolioID, report.StartDate, report.EndDate)
+		if err != nil {
+			return domain.Report{}, err
+		}
+		cash, err := s.snapshotCash(ctx, report.PortfolioID, snapshots)
+		if err != nil {
+			return domain.Report{}, err
+		}
+		return domain.BuildPerformance(portfolio, trades, snapshots, cash, report.StartDate, report.EndDate, now), nil
+	case domain.TypeTax:
+		// Trades before the period establish cost basis; those in the 30 days
+		// after it can make losses within it wash sales
+		trades, err := s.portfolios.GetFilledTradesByPortfolioID(ctx, report.PortfolioID, time.Time{}, report.EndDate.AddDate(0, 0, 31))
+		if err != nil {
+			return domain.Report{}, err
+		}
+		method := models.CostBasisFIFO
+		if s.settings != nil {
+			settings, err := s.settings.GetSettings(ctx, report.PortfolioID)
+			if err != nil {
+				return domain.Report{}, err
+			}
+			method = settings.CostBasisMethod
+		}
+		return domain.BuildTax(portfolio, trades, method, report.StartDate, report.EndDate, now), nil
+	case domain.TypeCustom:
+		return s.buildCustom(ctx, report, portfolio, now)
+	}
+	return domain.Report{}, fmt.Errorf("unsupported report type: %s", report.ReportType)
+}
+
+// CanHandle implements queue.JobHandler
+func (s *ReportService) CanHandle(jobType string) bool {
+	return jobType == models.JobTypeReportGeneration
+}
+
+// Handle implements queue.JobHandler
+func (s *ReportService) Handle(ctx context.Context, job *models.Job) error {
+	reportID, _ := job.Payload["report_id"].(string)
+	if reportID == "" {
+		job.Retries = job.MaxRetries // A malformed payload will not improve on retry
+		return fmt.Errorf("invalid report payload: report_id missing")
+	}
+
+	err := s.Generate(ctx, reportID)
+	if err == nil {
+		return nil
+	}
+
+	permanent := strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "unsupported")
+	if permanent {
+		job.Retries = job.MaxRetries
+	}
+	if job.Retries >= job.MaxRetries {
+		if failErr := s.repo.FailReport(ctx, reportID, err.Error()); failErr != nil {
+			s.logger.Error("Failed to record report failure", zap.Error(failErr), zap.String("report_id", reportID))
+		}
+	}
+	return err
+}
+
+// StorageKey is where a report's file is stored
+func StorageKey(report *models.Report) string {
+	return fmt.Sprintf("reports/%d/%s.%s", report.PortfolioID, report.ID, report.Format)
+}
+
+// DownloadPath is the API path serving a report's file
+func DownloadPath(reportID string) string {
+	return "/api/v1/reports/" + reportID + "/download"
+}
+
+// snapshotCash returns the cash moved in or out of a portfolio between the
+// first and last of its snapshots, which returns over them are net of
+func (s *ReportService) snapshotCash(ctx context.Context, portfolioID int, snapshots []models.PortfolioSnapshot) ([]models.CashTransaction, error) {
+	if len(snapshots) < 2 {
+		return nil, nil
+	}
+	return s.portfolios.GetCashTransactionsBetween(ctx, portfolioID, snapshots[0].CreatedAt, snapshots[len(snapshots)-1].CreatedAt)
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/reports/domain"
+	"hedge-fund/pkg/shared/auth"
+	"hedge-fund/pkg/shared/models"
+)
+
+// CreateTemplate validates and saves a user's report template
+func (s *ReportService) CreateTemplate(ctx context.Context, template *models.ReportTemplate) error {
+	if err := s.checkTemplate(ctx, template); err != nil {
+		return err
+	}
+	if err := s.repo.CreateTemplate(ctx, template); err != nil {
+		return err
+	}
+
+	s.logger.Info("Report template created",
+		zap.Int("template_id", template.ID),
+		zap.Int("user_id", template.UserID),
+		zap.String("schedule", template.Schedule))
+	return nil
+}
+
+// GetTemplate retrieves a report template
+func (s *ReportService) GetTemplate(ctx context.Context, templateID int) (*models.ReportTemplate, error) {
+	template, err := s.repo.GetTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.CanAccess(ctx, template.UserID) {
+		return nil, fmt.Errorf("report template not found: %d", templateID)
+	}
+	return template, nil
+}
+
+// ListTemplates retrieves a user's report templates
+func (s *ReportService) ListTemplates(ctx context.Context, userID int) ([]models.ReportTemplate, error) {
+	return s.repo.GetTemplatesByUserID(ctx, userID)
+}
+
+// UpdateTemplate validates and saves changes to a report template
+func (s *ReportService) UpdateTemplate(ctx context.Context, template *models.ReportTemplate) error {
+	if err := s.checkTemplate(ctx, template); err != nil {
+		return err
+	}
+	return s.repo.UpdateTemplate(ctx, template)
+}
+
+// DeleteTemplate deletes a report template
+func (s *ReportService) DeleteTemplate(ctx context.Context, templateID int) error {
+	if _, err := s.GetTemplate(ctx, templateID); err != nil {
+		return err
+	}
+	return s.repo.DeleteTemplate(ctx, templateID)
+}
+
+// checkTemplate validates a template and that the portfolio it is scheduled
+// for belongs to its owner
+func (s *ReportService) checkTemplate(ctx context.Context, template *models.ReportTemplate) error {
+	if err := domain.ValidateTemplate(template); err != nil {
+		return err
+	}
+	if template.PortfolioID == nil {
+		return nil
+	}
+	portfolio, err := s.portfolios.GetPortfolioByID(ctx, *template.PortfolioID)
+	if err != nil {
+		return err
+	}
+	if portfolio.UserID != template.UserID {
+		return fmt.Errorf("portfolio not found: %d", *template.PortfolioID)
+	}
+	return nil
+}
+
+// buildCustom lays a report out with the template it was requested from
+func (s *ReportService) buildCustom(ctx context.Context, report *models.Report, portfolio *models.Portfolio, now time.Time) (domain.Report, error) {
+	if report.TemplateID == nil {
+		return domain.Report{}, fmt.Errorf("report template not found: template was deleted")
+	}
+	template, err := s.repo.GetTemplate(ctx, *report.TemplateID)
+	if err != nil {
+		return domain.Report{}, err
+	}
+
+	positions, err := s.portfolios.GetPositionsByPortfolioID(ctx, report.PortfolioID)
+	if err != nil {
+		return domain.Report{}, err
+	}
+	// Trades before the period establish the cost basis of positions sold within it
+	trades, err := s.portfolios.GetFilledTradesByPortfolioID(ctx, report.PortfolioID, time.Time{}, report.EndDate)
+	if err != nil {
+		return domain.Report{}, err
+	}
+	snapshots, err := s.portfolios.GetSnapshotsBetween(ctx, report.PortfolioID, report.StartDate, report.EndDate)
+	if err != nil {
+		return domain.Report{}, err
+	}
+	cash, err := s.snapshotCash(ctx, report.PortfolioID, snapshots)
+	if err != nil {
+		return domain.Report{}, err
+	}
+
+	return domain.BuildTemplate(template, &domain.Data{
+		Portfolio: portfolio,
+		Positions: positions,
+		Trades:    trades,
+		Snapshots: snapshots,
+		Cash:      cash,
+		Start:     report.StartDate,
+		End:       report.EndDate,
+	}, now), nil
+}
+
+// RunScheduled requests a report for every scheduled template due on the
+// day of now that has not already run for it, returning how many it
+// requested
+func (s *ReportService) RunScheduled(ctx context.Context, now time.Time) (int, error) {
+	templates, err := s.repo.GetScheduledTemplates(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	requested := 0
+	for i := range templates {
+		template := &templates[i]
+		start, end, due := domain.SchedulePeriod(template.Schedule, now)
+		if !due || (template.LastRunAt != nil && !template.LastRunAt.Before(end)) {
+			continue
+		}
+
+		templateID := template.ID
+		_, err := s.RequestReport(ctx, ReportRequest{
+			UserID:      template.UserID,
+			PortfolioID: *template.PortfolioID,
+			ReportType:  domain.TypeCustom,
+			TemplateID:  &templateID,
+			StartDate:   start,
+			EndDate:     end,
+		})
+		if err != nil {
+			s.logger.Warn("Failed to request scheduled report", zap.Error(err), zap.Int("template_id", template.ID))
+			continue
+		}
+		if err := s.repo.SetTemplateRun(ctx, template.ID, now); err != nil {
+			s.logger.Warn("Failed to record scheduled report", zap.Error(err), zap.Int("template_id", template.ID))
+		}
+		requested++
+	}
+	return requested, nil
+}
+
+// RunDailySchedule requests the reports of scheduled templates at hour (UTC)
+// every day until ctx is cancelled
+func (s *ReportService) RunDailySchedule(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		requested, err := s.RunScheduled(ctx, time.Now().UTC())
+		if err != nil {
+			s.logger.Error("Failed to run scheduled reports", zap.Error(err))
+			continue
+		}
+		s.logger.Info("Scheduled reports requested", zap.Int("reports", requested))
+	}
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"math"
+	"sort"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// minCorrelationReturns is the fewest daily returns two symbols must share
+// for their correlation to be estimated
+const minCorrelationReturns = 20
+
+// Correlations are the daily return statistics of a set of symbols
+type Correlations struct {
+	Symbols      []string    `json:"symbols"`
+	Matrix       [][]float64 `json:"matrix"`       // Pairwise correlation of daily returns, in Symbols order
+	Volatilities []float64   `json:"volatilities"` // Daily standard deviation of returns, in Symbols order
+}
+
+// ComputeCorrelations estimates the pairwise correlation of the daily
+// returns of symbols from their bars, oldest first. Each pair is compared
+// over the days both have a return; pairs sharing fewer than
+// minCorrelationReturns days, or with a constant price, are uncorrelated.
+func ComputeCorrelations(symbols []string, bars map[string][]models.Price) *Correlations {
+	returns := make([]map[string]float64, len(symbols))
+	days := make([][]string, len(symbols)) // Days with a return, oldest first
+	c := &Correlations{
+		Symbols:      symbols,
+		Matrix:       make([][]float64, len(symbols)),
+		Volatilities: make([]float64, len(symbols)),
+	}
+	for i, symbol := range symbols {
+		series := bars[symbol]
+		returns[i] = make(map[string]float64, len(series))
+		closes := make([]float64, len(series))
+		for j, bar := range series {
+			closes[j] = bar.Close
+			if j > 0 && series[j-1].Close > 0 {
+				day := bar.Timestamp.UTC().Format("2006-01-02")
+				returns[i][day] = bar.Close/series[j-1].Close - 1
+				days[i] = append(days[i], day)
+			}
+		}
+		c.Volatilities[i] = DailyVolatility(closes)
+		c.Matrix[i] = make([]float64, len(symbols))
+		c.Matrix[i][i] = 1
+	}
+
+	for i := range symbols {
+		for j := i + 1; j < len(symbols); j++ {
+			var x, y []float64
+			for _, day := range days[i] {
+				if other, ok := returns[j][day]; ok {
+					x = append(x, returns[i][day])
+					y = append(y, other)
+				}
+			}
+			rho := correlation(x, y)
+			c.Matrix[i][j] = rho
+			c.Matrix[j][i] = rho
+		}
+	}
+	return c
+}
+
+// correlation returns the Pearson correlation of x and y, or zero when it
+// cannot be estimated
+func correlation(x, y []float64) float64 {
+	n := len(x)
+	if n < minCorrelationReturns {
+		return 0
+	}
+
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var cov, varX, varY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// CorrelatedPairs returns the pairs of symbols whose correlation is at
+// least threshold in absolute value, most correlated first
+func (c *Correlations) CorrelatedPairs(threshold float64) []models.CorrelationWarning {
+	var pairs []models.CorrelationWarning
+	for i := range c.Symbols {
+		for j := i + 1; j < len(c.Symbols); j++ {
+			if math.Abs(c.Matrix[i][j]) >= threshold {
+				pairs = append(pairs, models.CorrelationWarning{
+					SymbolA:     c.Symbols[i],
+					SymbolB:     c.Symbols[j],
+					Correlation: c.Matrix[i][j],
+				})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool {
+		return math.Abs(pairs[a].Correlation) > math.Abs(pairs[b].Correlation)
+	})
+	return pairs
+}
+
+// PortfolioVolatility returns the daily standard deviation of the value of
+// a book with the given signed market values per symbol, in Symbols order,
+// accounting for the correlation between them
+func (c *Correlations) PortfolioVolatility(values []float64) float64 {
+	variance := 0.0
+	for i := range values {
+		for j := range values {
+			variance += values[i] * values[j] * c.Volatilities[i] * c.Volatilities[j] * c.Matrix[i][j]
+		}
+	}
+	return math.Sqrt(math.Max(variance, 0))
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+// seriesFrom builds daily bars from returns, starting at 100 on start
+func seriesFrom(symbol string, start time.Time, returns []float64) []models.Price {
+	price := 100.0
+	series := []models.Price{{Symbol: symbol, Close: price, Timestamp: start}}
+	for i, r := range returns {
+		price *= 1 + r
+		series = append(series, models.Price{Symbol: symbol, Close: price, Timestamp: start.AddDate(0, 0, i+1)})
+	}
+	return series
+}
+
+func TestComputeCorrelations(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	returns := make([]float64, 40)
+	for i := range returns {
+		returns[i] = 0.01 * math.Sin(float64(i))
+	}
+	inverse := make([]float64, len(returns))
+	for i, r := range returns {
+		inverse[i] = -r
+	}
+
+	bars := map[string][]models.Price{
+		"AAA": seriesFrom("AAA", start, returns),
+		"BBB": seriesFrom("BBB", start, returns),
+		"CCC": seriesFrom("CCC", start, inverse),
+		"DDD": seriesFrom("DDD", start, returns[:10]), // Too little history
+	}
+	c := ComputeCorrelations([]string{"AAA", "BBB", "CCC", "DDD"}, bars)
+
+	require.Len(t, c.Matrix, 4)
+	for i := range c.Matrix {
+		assert.Equal(t, 1.0, c.Matrix[i][i])
+	}
+	assert.InDelta(t, 1, c.Matrix[0][1], 1e-9)
+	assert.InDelta(t, -1, c.Matrix[0][2], 1e-3)
+	assert.Equal(t, c.Matrix[0][2], c.Matrix[2][0])
+	assert.Equal(t, 0.0, c.Matrix[0][3])
+	assert.Greater(t, c.Volatilities[0], 0.0)
+
+	pairs := c.CorrelatedPairs(0.8)
+	flagged := make([]string, len(pairs))
+	for i, pair := range pairs {
+		flagged[i] = pair.SymbolA + "/" + pair.SymbolB
+	}
+	assert.ElementsMatch(t, []string{"AAA/BBB", "AAA/CCC", "BBB/CCC"}, flagged)
+}
+
+func TestPortfolioVolatilityDiversifies(t *testing.T) {
+	c := &Correlations{
+		Symbols:      []string{"AAA", "BBB"},
+		Matrix:       [][]float64{{1, 0}, {0, 1}},
+		Volatilities: []float64{0.02, 0.02},
+	}
+
+	// Uncorrelated: sqrt(2) * 0.02 * 1,000
+	assert.InDelta(t, math.Sqrt2*20, c.PortfolioVolatility([]float64{1000, 1000}), 1e-9)
+
+	// Perfectly correlated long and short positions hedge each other
+	c.Matrix = [][]float64{{1, 1}, {1, 1}}
+	assert.InDelta(t, 40, c.PortfolioVolatility([]float64{1000, 1000}), 1e-9)
+	assert.InDelta(t, 0, c.PortfolioVolatility([]float64{1000, -1000}), 1e-9)
+}
@@ -0,0 +1,365 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Holding is a position tracked by the risk monitor
+type Holding struct {
+	Symbol     string
+	Quantity   float64
+	Short      bool
+	Price      float64 // Last price the holding was valued at
+	Volatility float64 // Daily standard deviation of returns
+
+	evaluatedPrice float64 // Price when the book was last evaluated
+}
+
+// Value returns the holding's market value
+func (h *Holding) Value() float64 {
+	return h.Quantity * h.Price
+}
+
+// Limits are the risk limits a book is checked against. Zero disables a
+// limit.
+type Limits struct {
+	MaxPositionSize  float64            // Market value of any one position
+	PositionSizes    map[string]float64 // Per-symbol overrides of MaxPositionSize
+	MaxDailyLoss     float64
+	MaxPortfolioRisk float64 // VaR as a fraction of equity
+	MaxLeverage      float64 // Gross exposure / equity
+	MaxConcentration float64 // Largest position as a fraction of equity
+}
+
+// LimitsFor builds a user's limits from their active risk limit rows. Rows
+// without a symbol set the portfolio-level limits; rows with one set the
+// maximum position size in that symbol.
+func LimitsFor(rows []models.RiskLimit) Limits {
+	limits := Limits{PositionSizes: make(map[string]float64)}
+	for _, row := range rows {
+		if row.Symbol != "" {
+			if row.MaxPositionSize > 0 {
+				limits.PositionSizes[row.Symbol] = row.MaxPositionSize
+			}
+			continue
+		}
+		limits.MaxPositionSize = row.MaxPositionSize
+		limits.MaxDailyLoss = row.MaxDailyLoss
+		limits.MaxPortfolioRisk = row.MaxPortfolioRisk
+		limits.MaxLeverage = row.MaxLeverage
+		limits.MaxConcentration = row.MaxConcentration
+	}
+	return limits
+}
+
+// Book is a portfolio's risk state as maintained by the risk monitor. Its
+// exposures and VaR approximation are updated by the change in value of the
+// repriced holding, not recomputed from every position.
+type Book struct {
+	PortfolioID int
+	UserID      int
+	Cash        float64
+	Limits      Limits
+
+	holdings    map[string]*Holding
+	long        float64 // Market value of long holdings
+	short       float64 // Market value of short holdings
+	riskSum     float64 // Sum of each holding's value times its volatility
+	day         time.Time
+	startEquity float64
+	levels      map[string]string // Breach level last alerted, by limit key
+	updatedAt   time.Time
+	evaluated   *models.RiskExposure // Exposure at the last evaluation
+}
+
+// NewBook creates a portfolio's book, valuing each holding at its price.
+// Daily PnL is measured from its equity at now.
+func NewBook(portfolioID, userID int, cash float64, holdings []Holding, limits Limits, now time.Time) *Book {
+	b := &Book{
+		PortfolioID: portfolioID,
+		UserID:      userID,
+		Cash:        cash,
+		Limits:      limits,
+		holdings:    make(map[string]*Holding, len(holdings)),
+		levels:      make(map[string]string),
+		updatedAt:   now,
+	}
+	for i := range holdings {
+		h := holdings[i]
+		b.holdings[h.Symbol] = &h
+		if h.Short {
+			b.short += h.Value()
+		} else {
+			b.long += h.Value()
+		}
+		b.riskSum += h.Value() * h.Volatility
+	}
+	b.day = utcDay(now)
+	b.startEquity = b.Equity()
+	return b
+}
+
+// Carry keeps the day's starting equity and the alerted breach levels of
+// the book a reload replaces, so reloading neither resets daily PnL nor
+// repeats alerts
+func (b *Book) Carry(previous *Book) {
+	if previous == nil {
+		return
+	}
+	if previous.day.Equal(b.day) {
+		b.startEquity = previous.startEquity
+	}
+	for key, level := range previous.levels {
+		b.levels[key] = level
+	}
+	b.evaluated = previous.evaluated
+}
+
+// Symbols returns the symbols the book holds
+func (b *Book) Symbols() []string {
+	symbols := make([]string, 0, len(b.holdings))
+	for symbol := range b.holdings {
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// Equity returns the book's cash plus long less short market value
+func (b *Book) Equity() float64 {
+	return b.Cash + b.long - b.short
+}
+
+// Apply reprices the holding in symbol and updates the book by the change
+// in its value. It reports whether the book holds symbol. The first update
+// of a UTC day starts that day's PnL from the equity before it.
+func (b *Book) Apply(symbol string, price float64, now time.Time) bool {
+	h, ok := b.holdings[symbol]
+	if !ok || price <= 0 {
+		return false
+	}
+	if day := utcDay(now); day.After(b.day) {
+		b.day = day
+		b.startEquity = b.Equity()
+	}
+
+	delta := h.Quantity * (price - h.Price)
+	if h.Short {
+		b.short += delta
+	} else {
+		b.long += delta
+	}
+	b.riskSum += delta * h.Volatility
+	h.Price = price
+	b.updatedAt = now
+	return true
+}
+
+// Moved reports whether the price of symbol has moved by at least minMove,
+// as a fraction, since the book was last evaluated. Holdings never
+// evaluated have always moved.
+func (b *Book) Moved(symbol string, minMove float64) bool {
+	h, ok := b.holdings[symbol]
+	if !ok {
+		return false
+	}
+	if h.evaluatedPrice <= 0 {
+		return true
+	}
+	return math.Abs(h.Price/h.evaluatedPrice-1) >= minMove
+}
+
+// Evaluate returns the book's exposure, as Exposure does, with its change
+// since the previous evaluation, and marks the book's prices evaluated
+func (b *Book) Evaluate(z, confidence, warningLevel float64) models.RiskExposure {
+	exposure := b.Exposure(z, confidence, warningLevel)
+	if previous := b.evaluated; previous != nil {
+		exposure.Change = &models.ExposureChange{
+			Equity:        exposure.Equity - previous.Equity,
+			GrossExposure: exposure.GrossExposure - previous.GrossExposure,
+			NetExposure:   exposure.NetExposure - previous.NetExposure,
+			VaR:           exposure.VaR - previous.VaR,
+			Concentration: exposure.Concentration - previous.Concentration,
+			Since:         previous.UpdatedAt,
+		}
+	}
+
+	evaluated := exposure
+	evaluated.Change = nil
+	b.evaluated = &evaluated
+	for _, h := range b.holdings {
+		h.evaluatedPrice = h.Price
+	}
+	return exposure
+}
+
+// Fill applies a fill of quantity shares of symbol at price to the book,
+// positive to buy and negative to sell, repricing the holding to price
+// first. Buying covers a short holding. It lets limits be checked against
+// the book a trade would leave before placing it.
+func (b *Book) Fill(symbol string, quantity, price float64) {
+	h, ok := b.holdings[symbol]
+	if !ok {
+		h = &Holding{Symbol: symbol}
+		b.holdings[symbol] = h
+	}
+
+	before := h.Value()
+	h.Price = price
+	if h.Short {
+		h.Quantity -= quantity
+	} else {
+		h.Quantity += quantity
+	}
+	delta := h.Value() - before
+	if h.Short {
+		b.short += delta
+	} else {
+		b.long += delta
+	}
+	b.riskSum += delta * h.Volatility
+	b.Cash -= quantity * price
+}
+
+// Exposure returns the book's exposures, one-day VaR at z standard
+// deviations and the utilization of each limit that is set. VaR is
+// undiversified: positions are assumed perfectly correlated, which bounds
+// the diversified figure from above.
+func (b *Book) Exposure(z, confidence, warningLevel float64) models.RiskExposure {
+	exposure := models.RiskExposure{
+		PortfolioID:   b.PortfolioID,
+		UserID:        b.UserID,
+		Equity:        b.Equity(),
+		LongExposure:  b.long,
+		ShortExposure: b.short,
+		GrossExposure: b.long + b.short,
+		NetExposure:   b.long - b.short,
+		DailyPnL:      b.Equity() - b.startEquity,
+		VaR:           z * b.riskSum,
+		Confidence:    confidence,
+		Limits:        []models.LimitUtilization{},
+		UpdatedAt:     b.updatedAt,
+	}
+
+	add := func(limit, symbol string, value, threshold float64) {
+		if threshold <= 0 {
+			return
+		}
+		utilization := models.LimitUtilization{
+			Limit:       limit,
+			Symbol:      symbol,
+			Value:       value,
+			Threshold:   threshold,
+			Utilization: value / threshold,
+		}
+		switch {
+		case utilization.Utilization >= 1:
+			utilization.Level = models.RiskSeverityCritical
+		case warningLevel > 0 && utilization.Utilization >= warningLevel:
+			utilization.Level = models.RiskSeverityWarning
+		}
+		exposure.Limits = append(exposure.Limits, utilization)
+	}
+
+	largest := 0.0
+	for _, symbol := range sortedKeys(b.holdings) {
+		value := b.holdings[symbol].Value()
+		largest = math.Max(largest, value)
+		threshold, ok := b.Limits.PositionSizes[symbol]
+		if !ok {
+			threshold = b.Limits.MaxPositionSize
+		}
+		add(models.RiskAlertPositionLimit, symbol, value, threshold)
+	}
+	add(models.RiskAlertDailyLoss, "", math.Max(0, -exposure.DailyPnL), b.Limits.MaxDailyLoss)
+
+	// Ratios to equity are undefined once there is no equity left, which
+	// the daily loss limit covers
+	if exposure.Equity > 0 {
+		exposure.Concentration = largest / exposure.Equity
+		add(models.RiskAlertVaRBreach, "", exposure.VaR/exposure.Equity, b.Limits.MaxPortfolioRisk)
+		add(models.RiskAlertLeverage, "", exposure.GrossExposure/exposure.Equity, b.Limits.MaxLeverage)
+		add(models.RiskAlertConcentration, "", largest/exposure.Equity, b.Limits.MaxConcentration)
+	}
+
+	return exposure
+}
+
+// Breaches returns the limits of exposure whose level rose since they were
+// last alerted, and records the new levels. A limit that falls back below
+// the warning level is alerted again when it next rises.
+func (b *Book) Breaches(exposure models.RiskExposure) []models.LimitUtilization {
+	var breaches []models.LimitUtilization
+	for _, limit := range exposure.Limits {
+		key := limit.Limit + ":" + limit.Symbol
+		previous := b.levels[key]
+		if limit.Level == "" {
+			delete(b.levels, key)
+			continue
+		}
+		if severity(limit.Level) > severity(previous) {
+			breaches = append(breaches, limit)
+		}
+		b.levels[key] = limit.Level
+	}
+	return breaches
+}
+
+// ZScore returns the standard normal quantile of a one-sided confidence
+// level, e.g. 2.326 for 0.99
+func ZScore(confidence float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*confidence-1)
+}
+
+// DailyVolatility returns the sample standard deviation of the daily
+// returns of closes, oldest first, or zero without two returns
+func DailyVolatility(closes []float64) float64 {
+	returns := make([]float64, 0, len(closes))
+	for i := 1; i < len(closes); i++ {
+		if closes[i-1] > 0 {
+			returns = append(returns, closes[i]/closes[i-1]-1)
+		}
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	return math.Sqrt(variance / float64(len(returns)-1))
+}
+
+func severity(level string) int {
+	switch level {
+	case models.RiskSeverityCritical:
+		return 2
+	case models.RiskSeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func utcDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+func sortedKeys(holdings map[string]*Holding) []string {
+	keys := make([]string, 0, len(holdings))
+	for key := range holdings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+func limitFor(exposure models.RiskExposure, limit, symbol string) *models.LimitUtilization {
+	for i := range exposure.Limits {
+		if exposure.Limits[i].Limit == limit && exposure.Limits[i].Symbol == symbol {
+			return &exposure.Limits[i]
+		}
+	}
+	return nil
+}
+
+func TestBookApply(t *testing.T) {
+	now := time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC)
+	book := NewBook(1, 7, 1000, []Holding{
+		{Symbol: "AAPL", Quantity: 10, Price: 100, Volatility: 0.02},
+		{Symbol: "TSLA", Quantity: 5, Short: true, Price: 200, Volatility: 0.04},
+	}, Limits{}, now)
+
+	assert.Equal(t, 1000.0, book.Equity())
+	assert.False(t, book.Apply("MSFT", 50, now))
+
+	require.True(t, book.Apply("AAPL", 110, now.Add(time.Minute)))
+	require.True(t, book.Apply("TSLA", 180, now.Add(2*time.Minute)))
+
+	exposure := book.Exposure(2, 0.98, 0.9)
+	assert.Equal(t, 1100.0, exposure.LongExposure)
+	assert.Equal(t, 900.0, exposure.ShortExposure)
+	assert.Equal(t, 2000.0, exposure.GrossExposure)
+	assert.Equal(t, 200.0, exposure.NetExposure)
+	assert.Equal(t, 1200.0, exposure.Equity)
+	assert.Equal(t, 200.0, exposure.DailyPnL)
+	assert.InDelta(t, 2*(1100*0.02+900*0.04), exposure.VaR, 1e-9)
+
+	// A new UTC day measures PnL from the equity before its first update
+	require.True(t, book.Apply("AAPL", 100, now.Add(24*time.Hour)))
+	assert.Equal(t, -100.0, book.Exposure(2, 0.98, 0.9).DailyPnL)
+}
+
+func TestBookBreaches(t *testing.T) {
+	now := time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC)
+	limits := Limits{
+		MaxPositionSize: 2000,
+		PositionSizes:   map[string]float64{"AAPL": 1050},
+		MaxDailyLoss:    100,
+	}
+	book := NewBook(1, 7, 0, []Holding{{Symbol: "AAPL", Quantity: 10, Price: 100}}, limits, now)
+
+	exposure := book.Exposure(2, 0.98, 0.9)
+	position := limitFor(exposure, models.RiskAlertPositionLimit, "AAPL")
+	require.NotNil(t, position)
+	assert.Equal(t, 1050.0, position.Threshold)
+	assert.Equal(t, models.RiskSeverityWarning, position.Level)
+	assert.Nil(t, limitFor(exposure, models.RiskAlertLeverage, ""))
+
+	breaches := book.Breaches(exposure)
+	require.Len(t, breaches, 1)
+	assert.Equal(t, models.RiskAlertPositionLimit, breaches[0].Limit)
+
+	// Staying at a level does not alert again, escalating does
+	assert.Empty(t, book.Breaches(book.Exposure(2, 0.98, 0.9)))
+	book.Apply("AAPL", 106, now)
+	breaches = book.Breaches(book.Exposure(2, 0.98, 0.9))
+	require.Len(t, breaches, 1)
+	assert.Equal(t, models.RiskSeverityCritical, breaches[0].Level)
+
+	// Recovering and breaching again alerts again
+	book.Apply("AAPL", 80, now)
+	breaches = book.Breaches(book.Exposure(2, 0.98, 0.9))
+	require.Len(t, breaches, 1)
+	assert.Equal(t, models.RiskAlertDailyLoss, breaches[0].Limit)
+	book.Apply("AAPL", 106, now)
+	breaches = book.Breaches(book.Exposure(2, 0.98, 0.9))
+	require.Len(t, breaches, 1)
+	assert.Equal(t, models.RiskAlertPositionLimit, breaches[0].Limit)
+
+	// A reloaded book keeps the day's PnL and the alerted levels
+	reloaded := NewBook(1, 7, 0, []Holding{{Symbol: "AAPL", Quantity: 10, Price: 106}}, limits, now)
+	reloaded.Carry(book)
+	assert.Equal(t, 60.0, reloaded.Exposure(2, 0.98, 0.9).DailyPnL)
+	assert.Empty(t, reloaded.Breaches(reloaded.Exposure(2, 0.98, 0.9)))
+}
+
+func TestZScoreAndVolatility(t *testing.T) {
+	assert.InDelta(t, 2.326, ZScore(0.99), 1e-3)
+	assert.InDelta(t, 1.645, ZScore(0.95), 1e-3)
+
+	assert.Equal(t, 0.0, DailyVolatility([]float64{100, 101}))
+	// Returns of +10%, -10%, +10%
+	assert.InDelta(t, math.Sqrt(0.04/3), DailyVolatility([]float64{100, 110, 99, 108.9}), 1e-9)
+}
+
+func TestBookFill(t *testing.T) {
+	now := time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC)
+	book := NewBook(1, 7, 1000, []Holding{
+		{Symbol: "AAPL", Quantity: 10, Price: 100},
+		{Symbol: "TSLA", Quantity: 5, Short: true, Price: 200},
+	}, Limits{MaxConcentration: 0.5}, now)
+
+	book.Fill("MSFT", 2, 250)
+	book.Fill("AAPL", -4, 100)
+	book.Fill("TSLA", 5, 200) // Covers the short
+
+	exposure := book.Exposure(2, 0.98, 0.9)
+	assert.Equal(t, 1100.0, exposure.LongExposure)
+	assert.Equal(t, 0.0, exposure.ShortExposure)
+	assert.Equal(t, 1000.0, exposure.Equity)
+	assert.Equal(t, 0.0, exposure.DailyPnL)
+
+	book.Fill("MSFT", 2, 250)
+	concentration := limitFor(book.Exposure(2, 0.98, 0.9), models.RiskAlertConcentration, "")
+	require.NotNil(t, concentration)
+	assert.Equal(t, models.RiskSeverityCritical, concentration.Level)
+}
+
+func TestBookEvaluateOnMaterialMoves(t *testing.T) {
+	now := time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC)
+	book := NewBook(1, 7, 1000, []Holding{
+		{Symbol: "AAPL", Quantity: 10, Price: 100, Volatility: 0.02},
+	}, Limits{}, now)
+
+	// Never evaluated, so any price has moved
+	assert.True(t, book.Moved("AAPL", 0.01))
+	first := book.Evaluate(2, 0.98, 0.9)
+	assert.Nil(t, first.Change)
+	assert.InDelta(t, 0.5, first.Concentration, 1e-9)
+
+	// Small moves accumulate against the evaluated price
+	require.True(t, book.Apply("AAPL", 100.5, now.Add(time.Second)))
+	assert.False(t, book.Moved("AAPL", 0.01))
+	require.True(t, book.Apply("AAPL", 101, now.Add(2*time.Second)))
+	assert.True(t, book.Moved("AAPL", 0.01))
+	assert.False(t, book.Moved("MSFT", 0.01))
+
+	second := book.Evaluate(2, 0.98, 0.9)
+	require.NotNil(t, second.Change)
+	assert.InDelta(t, 10, second.Change.Equity, 1e-9)
+	assert.InDelta(t, 10, second.Change.GrossExposure, 1e-9)
+	assert.InDelta(t, 2*10*0.02, second.Change.VaR, 1e-9)
+	assert.InDelta(t, 1010.0/2010-0.5, second.Change.Concentration, 1e-9)
+	assert.Equal(t, now, second.Change.Since)
+	assert.False(t, book.Moved("AAPL", 0.01))
+
+	// A reload carries the last evaluation, so changes span it
+	reloaded := NewBook(1, 7, 1000, []Holding{{Symbol: "AAPL", Quantity: 10, Price: 102}}, Limits{}, now.Add(time.Minute))
+	reloaded.Carry(book)
+	third := reloaded.Evaluate(2, 0.98, 0.9)
+	require.NotNil(t, third.Change)
+	assert.InDelta(t, 10, third.Change.Equity, 1e-9)
+}
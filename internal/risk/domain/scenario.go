@@ -0,0 +1,280 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// ErrInvalidScenario is returned for unknown historical scenarios and
+// malformed custom shocks
+var ErrInvalidScenario = errors.New("invalid scenario")
+
+// SectorCrypto is the sector crypto pairs are shocked under when their
+// instrument has none
+const SectorCrypto = "Crypto"
+
+// Scenario is a set of shocks applied to a portfolio's positions. Shocks
+// are percentage moves in price; a position takes the shock of its symbol,
+// else of its sector, else the market's, plus the move its sector is
+// expected to make on RatesBP basis points of change in interest rates.
+type Scenario struct {
+	ID          string
+	Name        string
+	Description string
+	Market      float64
+	Sectors     map[string]float64
+	Symbols     map[string]float64
+	RatesBP     float64
+}
+
+// historicalScenarios are approximate peak-to-trough moves of the S&P 500
+// and its sectors through past crises
+var historicalScenarios = []Scenario{
+	{
+		ID:          "2008",
+		Name:        "2008 financial crisis",
+		Description: "Peak-to-trough moves from October 2007 to March 2009",
+		Market:      -55,
+		Sectors: map[string]float64{
+			"Financial Services":     -80,
+			"Real Estate":            -70,
+			"Consumer Cyclical":      -60,
+			"Industrials":            -62,
+			"Basic Materials":        -60,
+			"Technology":             -52,
+			"Energy":                 -52,
+			"Communication Services": -50,
+			"Healthcare":             -40,
+			"Consumer Defensive":     -35,
+			"Utilities":              -45,
+		},
+	},
+	{
+		ID:          "2020",
+		Name:        "2020 COVID crash",
+		Description: "Peak-to-trough moves from February to March 2020",
+		Market:      -34,
+		Sectors: map[string]float64{
+			"Energy":                 -60,
+			"Financial Services":     -43,
+			"Industrials":            -42,
+			"Real Estate":            -40,
+			"Basic Materials":        -37,
+			"Consumer Cyclical":      -35,
+			"Utilities":              -36,
+			"Communication Services": -30,
+			"Technology":             -30,
+			"Healthcare":             -28,
+			"Consumer Defensive":     -25,
+			SectorCrypto:             -50,
+		},
+	},
+}
+
+// HistoricalScenarioIDs returns the IDs of the historical scenarios
+func HistoricalScenarioIDs() []string {
+	ids := make([]string, len(historicalScenarios))
+	for i, scenario := range historicalScenarios {
+		ids[i] = scenario.ID
+	}
+	return ids
+}
+
+// HistoricalScenario returns the historical scenario with id
+func HistoricalScenario(id string) (*Scenario, error) {
+	for _, scenario := range historicalScenarios {
+		if scenario.ID == strings.TrimSpace(id) {
+			s := scenario
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown historical scenario %q", ErrInvalidScenario, id)
+}
+
+// rateSensitivity is the rough percentage move of each sector on a rise in
+// interest rates of 100 basis points
+var rateSensitivity = map[string]float64{
+	"Utilities":              -4,
+	"Real Estate":            -5,
+	"Technology":             -3,
+	"Communication Services": -2,
+	"Consumer Cyclical":      -2,
+	"Consumer Defensive":     -2,
+	"Healthcare":             -1.5,
+	"Industrials":            -1.5,
+	"Basic Materials":        -1,
+	"Energy":                 0,
+	"Financial Services":     2,
+	SectorCrypto:             0,
+}
+
+// defaultRateSensitivity applies to sectors without a rateSensitivity
+const defaultRateSensitivity = -2
+
+// sectorAliases maps the names custom shocks may use to instrument sectors
+var sectorAliases = map[string]string{
+	"tech":          "Technology",
+	"technology":    "Technology",
+	"financials":    "Financial Services",
+	"financial":     "Financial Services",
+	"banks":         "Financial Services",
+	"energy":        "Energy",
+	"healthcare":    "Healthcare",
+	"health care":   "Healthcare",
+	"utilities":     "Utilities",
+	"real estate":   "Real Estate",
+	"reits":         "Real Estate",
+	"industrials":   "Industrials",
+	"materials":     "Basic Materials",
+	"discretionary": "Consumer Cyclical",
+	"staples":       "Consumer Defensive",
+	"communication": "Communication Services",
+	"crypto":        SectorCrypto,
+}
+
+// shockTerm matches one term of a custom scenario: a target and a signed
+// move in percent or basis points, e.g. "tech -20%" or "rates +200bp"
+var shockTerm = regexp.MustCompile(`^(.+?)\s+([+-]?\d+(?:\.\d+)?)\s*(%|bp)$`)
+
+// ParseScenario builds a custom scenario from a comma-separated list of
+// shocks such as "tech -20%, AAPL -35%, rates +200bp". Targets are "market",
+// "rates", a sector or a symbol; rates move in basis points and everything
+// else in percent.
+func ParseScenario(name, expr string) (*Scenario, error) {
+	scenario := &Scenario{
+		ID:          "custom",
+		Name:        strings.TrimSpace(name),
+		Description: strings.TrimSpace(expr),
+		Sectors:     make(map[string]float64),
+		Symbols:     make(map[string]float64),
+	}
+	if scenario.Name == "" {
+		scenario.Name = scenario.Description
+	}
+	if scenario.Description == "" {
+		return nil, fmt.Errorf("%w: no shocks given", ErrInvalidScenario)
+	}
+
+	for _, term := range strings.Split(expr, ",") {
+		term = strings.TrimSpace(term)
+		match := shockTerm.FindStringSubmatch(term)
+		if match == nil {
+			return nil, fmt.Errorf("%w: cannot parse shock %q", ErrInvalidScenario, term)
+		}
+		target, unit := strings.ToLower(strings.TrimSpace(match[1])), match[3]
+		move, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: cannot parse shock %q", ErrInvalidScenario, term)
+		}
+
+		if target == "rates" || target == "rate" {
+			if unit != "bp" {
+				return nil, fmt.Errorf("%w: rate shocks are in basis points: %q", ErrInvalidScenario, term)
+			}
+			scenario.RatesBP = move
+			continue
+		}
+		if unit != "%" {
+			return nil, fmt.Errorf("%w: price shocks are in percent: %q", ErrInvalidScenario, term)
+		}
+		if move < -100 {
+			return nil, fmt.Errorf("%w: prices cannot fall more than 100%%: %q", ErrInvalidScenario, term)
+		}
+
+		switch {
+		case target == "market" || target == "all":
+			scenario.Market = move
+		case sectorAliases[target] != "":
+			scenario.Sectors[sectorAliases[target]] = move
+		default:
+			symbol := symbols.Normalize(match[1])
+			if err := symbols.Validate(symbol); err != nil {
+				return nil, fmt.Errorf("%w: unknown shock target %q", ErrInvalidScenario, match[1])
+			}
+			scenario.Symbols[symbol] = move
+		}
+	}
+	return scenario, nil
+}
+
+// Shock returns the percentage price move of a symbol in sector under the
+// scenario. Moves are floored at -100%.
+func (s *Scenario) Shock(symbol, sector string) float64 {
+	shock, ok := s.Symbols[symbol]
+	if !ok {
+		shock, ok = s.Sectors[sector]
+	}
+	if !ok {
+		shock = s.Market
+	}
+
+	if s.RatesBP != 0 {
+		sensitivity, ok := rateSensitivity[sector]
+		if !ok {
+			sensitivity = defaultRateSensitivity
+		}
+		shock += sensitivity * s.RatesBP / 100
+	}
+	return math.Max(shock, -100)
+}
+
+// RunScenario projects the PnL of a book with cash and holdings under a
+// scenario. sectors maps symbols to their instrument's sector. Options are
+// shocked by the move of their underlying, as though their price moved
+// with it one for one; their leverage and convexity are not modelled.
+func RunScenario(s *Scenario, cash float64, holdings []Holding, sectors map[string]string) models.ScenarioResult {
+	result := models.ScenarioResult{
+		Scenario:    s.Name,
+		Description: s.Description,
+		Equity:      cash,
+		Positions:   make([]models.ScenarioPositionImpact, 0, len(holdings)),
+	}
+
+	for _, holding := range holdings {
+		symbol := holding.Symbol
+		if contract, ok := symbols.ParseOption(symbol); ok {
+			symbol = contract.Underlying
+		}
+		sector := sectors[symbol]
+		if sector == "" && symbols.IsCrypto(symbol) {
+			sector = SectorCrypto
+		}
+
+		value := holding.Value()
+		shock := s.Shock(symbol, sector)
+		pnl := value * shock / 100
+		side := "long"
+		if holding.Short {
+			side = "short"
+			value = -value
+			pnl = -pnl
+		}
+		result.Equity += value
+		result.ProjectedPnL += pnl
+		result.Positions = append(result.Positions, models.ScenarioPositionImpact{
+			Symbol:       holding.Symbol,
+			Sector:       sector,
+			Side:         side,
+			MarketValue:  value,
+			ShockPercent: shock,
+			ProjectedPnL: pnl,
+		})
+	}
+
+	if result.Equity > 0 {
+		result.ProjectedReturn = result.ProjectedPnL / result.Equity
+	}
+	// Largest losses first
+	sort.SliceStable(result.Positions, func(i, j int) bool {
+		return result.Positions[i].ProjectedPnL < result.Positions[j].ProjectedPnL
+	})
+	return result
+}
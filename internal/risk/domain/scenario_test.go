@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScenario(t *testing.T) {
+	scenario, err := ParseScenario("Tech selloff", "tech -20%, aapl -35%, market -5.5%, rates +200bp")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Tech selloff", scenario.Name)
+	assert.Equal(t, -5.5, scenario.Market)
+	assert.Equal(t, -20.0, scenario.Sectors["Technology"])
+	assert.Equal(t, -35.0, scenario.Symbols["AAPL"])
+	assert.Equal(t, 200.0, scenario.RatesBP)
+}
+
+func TestParseScenarioRejectsMalformedShocks(t *testing.T) {
+	for _, expr := range []string{"", "tech", "tech -20", "rates +2%", "tech +50bp", "tech -120%", "$$$ -10%"} {
+		_, err := ParseScenario("", expr)
+		assert.True(t, errors.Is(err, ErrInvalidScenario), expr)
+	}
+}
+
+func TestHistoricalScenario(t *testing.T) {
+	for _, id := range HistoricalScenarioIDs() {
+		scenario, err := HistoricalScenario(id)
+		require.NoError(t, err)
+		assert.Less(t, scenario.Market, 0.0)
+	}
+
+	_, err := HistoricalScenario("1987")
+	assert.True(t, errors.Is(err, ErrInvalidScenario))
+}
+
+func TestScenarioShockPrecedence(t *testing.T) {
+	scenario, err := ParseScenario("", "market -10%, tech -20%, NVDA -40%, rates +100bp")
+	require.NoError(t, err)
+
+	// Symbol over sector over market, plus the sector's rate sensitivity
+	assert.InDelta(t, -43, scenario.Shock("NVDA", "Technology"), 1e-9)
+	assert.InDelta(t, -23, scenario.Shock("MSFT", "Technology"), 1e-9)
+	assert.InDelta(t, -8, scenario.Shock("JPM", "Financial Services"), 1e-9)
+	assert.InDelta(t, -12, scenario.Shock("XYZ", ""), 1e-9)
+
+	crash, err := ParseScenario("", "NVDA -100%, rates +100bp")
+	require.NoError(t, err)
+	assert.Equal(t, -100.0, crash.Shock("NVDA", "Technology"))
+}
+
+func TestRunScenario(t *testing.T) {
+	scenario, err := ParseScenario("", "tech -20%, energy +10%, crypto -50%")
+	require.NoError(t, err)
+
+	holdings := []Holding{
+		{Symbol: "AAPL", Quantity: 100, Price: 100},              // 10,000 long tech
+		{Symbol: "XOM", Quantity: 50, Price: 100, Short: true},   // 5,000 short energy
+		{Symbol: "BTC-USD", Quantity: 0.1, Price: 20000},         // 2,000 long crypto
+		{Symbol: "AAPL240119C00150000", Quantity: 200, Price: 5}, // 1,000 of calls on tech
+	}
+	sectors := map[string]string{"AAPL": "Technology", "XOM": "Energy"}
+
+	result := RunScenario(scenario, 10000, holdings, sectors)
+
+	// 10,000 cash + 10,000 + 2,000 + 1,000 long - 5,000 short
+	assert.InDelta(t, 18000, result.Equity, 1e-9)
+	// -2,000 tech, -500 on the short, -1,000 crypto, -200 calls
+	assert.InDelta(t, -3700, result.ProjectedPnL, 1e-9)
+	assert.InDelta(t, -3700.0/18000, result.ProjectedReturn, 1e-9)
+
+	require.Len(t, result.Positions, 4)
+	assert.Equal(t, "AAPL", result.Positions[0].Symbol)
+	assert.Equal(t, "Crypto", result.Positions[1].Sector)
+
+	short := result.Positions[2]
+	assert.Equal(t, "XOM", short.Symbol)
+	assert.Equal(t, "short", short.Side)
+	assert.InDelta(t, -5000, short.MarketValue, 1e-9)
+	assert.InDelta(t, -500, short.ProjectedPnL, 1e-9)
+
+	option := result.Positions[3]
+	assert.Equal(t, "Technology", option.Sector)
+	assert.InDelta(t, -20, option.ShockPercent, 1e-9)
+}
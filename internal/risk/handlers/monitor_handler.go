@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hedge-fund/internal/risk/service"
+	"hedge-fund/pkg/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type MonitorHandler struct {
+	monitor *service.RiskMonitor
+	logger  *zap.Logger
+}
+
+func NewMonitorHandler(monitor *service.RiskMonitor, logger *zap.Logger) *MonitorHandler {
+	return &MonitorHandler{
+		monitor: monitor,
+		logger:  logger,
+	}
+}
+
+// ErrorResponse is the error envelope shared by every service
+type ErrorResponse = apierror.Response
+
+// GetExposure godoc
+// @Summary Get a portfolio's intraday risk exposure
+// @Description Get a portfolio's exposure, VaR approximation and limit utilization as last updated by the intraday risk monitor
+// @Tags risk
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Success 200 {object} models.RiskExposure
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/risk/portfolios/{id}/exposure [get]
+func (h *MonitorHandler) GetExposure(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	exposure, err := h.monitor.GetExposure(c.Request.Context(), portfolioID)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Error: "Exposure not found", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, exposure)
+}
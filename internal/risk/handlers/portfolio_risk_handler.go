@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hedge-fund/internal/risk/service"
+	"hedge-fund/pkg/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type PortfolioRiskHandler struct {
+	risk   *service.PortfolioRiskService
+	logger *zap.Logger
+}
+
+func NewPortfolioRiskHandler(risk *service.PortfolioRiskService, logger *zap.Logger) *PortfolioRiskHandler {
+	return &PortfolioRiskHandler{
+		risk:   risk,
+		logger: logger,
+	}
+}
+
+// GetPortfolioRisk godoc
+// @Summary Get a portfolio's risk
+// @Description Get the pairwise correlation of a portfolio's holdings' daily returns, warnings for highly correlated pairs, and the volatility and VaR of its holdings alone and diversified. Correlations are computed from stored daily bars once per day.
+// @Tags risk
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Success 200 {object} models.PortfolioRisk
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/risk/portfolios/{id} [get]
+func (h *PortfolioRiskHandler) GetPortfolioRisk(c *gin.Context) {
+	portfolioID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	risk, err := h.risk.GetPortfolioRisk(c.Request.Context(), portfolioID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePortfolioNotFound, Error: "Portfolio not found", Details: err.Error()})
+			return
+		}
+		h.logger.Error("Failed to get portfolio risk", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to get portfolio risk", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, risk)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"hedge-fund/internal/risk/domain"
+	"hedge-fund/internal/risk/service"
+	"hedge-fund/pkg/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ScenarioHandler struct {
+	scenarios *service.ScenarioService
+	logger    *zap.Logger
+}
+
+func NewScenarioHandler(scenarios *service.ScenarioService, logger *zap.Logger) *ScenarioHandler {
+	return &ScenarioHandler{
+		scenarios: scenarios,
+		logger:    logger,
+	}
+}
+
+// RunScenariosRequest represents a request to stress test a portfolio.
+// Every historical scenario is run when none are named.
+type RunScenariosRequest struct {
+	PortfolioID int                     `json:"portfolio_id" binding:"required,min=1"`
+	Historical  []string                `json:"historical,omitempty" example:"2008,2020"`
+	Custom      []CustomScenarioRequest `json:"custom,omitempty"`
+}
+
+// CustomScenarioRequest represents a user-defined scenario: a
+// comma-separated list of shocks to the market, a sector, a symbol or
+// interest rates
+type CustomScenarioRequest struct {
+	Name   string `json:"name,omitempty" example:"Tech selloff"`
+	Shocks string `json:"shocks" binding:"required" example:"tech -20%, rates +200bp"`
+}
+
+// RunScenarios godoc
+// @Summary Stress test a portfolio
+// @Description Project a portfolio's PnL under historical scenarios (2008, 2020) and user-defined shocks such as "tech -20%, rates +200bp", from its open positions at their last known prices. Options are shocked by the move of their underlying.
+// @Tags risk
+// @Accept json
+// @Produce json
+// @Param request body RunScenariosRequest true "Scenarios to run"
+// @Success 200 {object} models.ScenarioReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/risk/scenarios [post]
+func (h *ScenarioHandler) RunScenarios(c *gin.Context) {
+	var req RunScenariosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	historical := req.Historical
+	if len(historical) == 0 && len(req.Custom) == 0 {
+		historical = domain.HistoricalScenarioIDs()
+	}
+
+	var scenarios []*domain.Scenario
+	for _, id := range historical {
+		scenario, err := domain.HistoricalScenario(id)
+		if err != nil {
+			h.writeError(c, err)
+			return
+		}
+		scenarios = append(scenarios, scenario)
+	}
+	for _, custom := range req.Custom {
+		scenario, err := domain.ParseScenario(custom.Name, custom.Shocks)
+		if err != nil {
+			h.writeError(c, err)
+			return
+		}
+		scenarios = append(scenarios, scenario)
+	}
+
+	report, err := h.scenarios.Run(c.Request.Context(), req.PortfolioID, scenarios)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *ScenarioHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidScenario):
+		apierror.Respond(c, http.StatusBadRequest, ErrorResponse{Error: "Invalid scenario", Details: err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		apierror.Respond(c, http.StatusNotFound, ErrorResponse{Code: apierror.CodePortfolioNotFound, Error: "Portfolio not found", Details: err.Error()})
+	default:
+		h.logger.Error("Failed to run scenarios", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, ErrorResponse{Error: "Failed to run scenarios", Details: err.Error()})
+	}
+}
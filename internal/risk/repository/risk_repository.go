@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"hedge-fund/internal/risk/domain"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+)
+
+type RiskRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewRiskRepository(db *database.DB, logger *zap.Logger) *RiskRepository {
+	return &RiskRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// OpenBook is an active portfolio's cash and open positions, valued at
+// their last known prices
+type OpenBook struct {
+	PortfolioID int
+	UserID      int
+	Cash        float64
+	Holdings    []domain.Holding
+}
+
+// GetOpenBooks retrieves every active portfolio with its open positions
+func (r *RiskRepository) GetOpenBooks(ctx context.Context) ([]OpenBook, error) {
+	return r.getOpenBooks(ctx, 0)
+}
+
+// GetOpenBook retrieves an active portfolio with its open positions
+func (r *RiskRepository) GetOpenBook(ctx context.Context, portfolioID int) (*OpenBook, error) {
+	books, err := r.getOpenBooks(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if len(books) == 0 {
+		return nil, fmt.Errorf("portfolio not found: %d", portfolioID)
+	}
+	return &books[0], nil
+}
+
+// getOpenBooks retrieves the active portfolio with portfolioID, or every
+// active portfolio when it is zero. Option quantities are scaled by their
+// multiplier, so holdings are valued at quantity times the per-share price.
+func (r *RiskRepository) getOpenBooks(ctx context.Context, portfolioID int) ([]OpenBook, error) {
+	query := `
+		SELECT p.id, p.user_id, p.cash, pos.symbol, pos.quantity * pos.multiplier, pos.side,
+		       COALESCE(pos.current_price, pos.entry_price)
+		FROM portfolios p
+		LEFT JOIN positions pos ON pos.portfolio_id = p.id AND pos.is_open = true
+		WHERE p.is_active = true AND ($1 = 0 OR p.id = $1)
+		ORDER BY p.id`
+
+	rows, err := r.db.QueryContext(ctx, query, portfolioID)
+	if err != nil {
+		r.logger.Error("Failed to get open books", zap.Error(err))
+		return nil, fmt.Errorf("failed to get open books: %w", err)
+	}
+	defer rows.Close()
+
+	var books []OpenBook
+	for rows.Next() {
+		var (
+			portfolioID, userID int
+			cash                float64
+			symbol, side        *string
+			quantity            *float64
+			price               *float64
+		)
+		if err := rows.Scan(&portfolioID, &userID, &cash, &symbol, &quantity, &side, &price); err != nil {
+			r.logger.Error("Failed to scan open book", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan open book: %w", err)
+		}
+
+		if len(books) == 0 || books[len(books)-1].PortfolioID != portfolioID {
+			books = append(books, OpenBook{PortfolioID: portfolioID, UserID: userID, Cash: cash})
+		}
+		if symbol == nil {
+			continue
+		}
+		book := &books[len(books)-1]
+		book.Holdings = append(book.Holdings, domain.Holding{
+			Symbol:   *symbol,
+			Quantity: *quantity,
+			Short:    *side == "short",
+			Price:    *price,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating open books: %w", err)
+	}
+
+	return books, nil
+}
+
+// GetSectors retrieves the sectors of the instruments with the given
+// symbols. Symbols without an instrument or a sector are left out.
+func (r *RiskRepository) GetSectors(ctx context.Context, symbols []string) (map[string]string, error) {
+	query := `
+		SELECT symbol, sector
+		FROM instruments
+		WHERE symbol = ANY($1) AND COALESCE(sector, '') <> ''`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(symbols))
+	if err != nil {
+		r.logger.Error("Failed to get sectors", zap.Error(err))
+		return nil, fmt.Errorf("failed to get sectors: %w", err)
+	}
+	defer rows.Close()
+
+	sectors := make(map[string]string)
+	for rows.Next() {
+		var symbol, sector string
+		if err := rows.Scan(&symbol, &sector); err != nil {
+			return nil, fmt.Errorf("failed to scan sector: %w", err)
+		}
+		sectors[symbol] = sector
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sectors: %w", err)
+	}
+
+	return sectors, nil
+}
+
+// GetActiveRiskLimits retrieves every active risk limit, keyed by user ID.
+// Unset limits are zero.
+func (r *RiskRepository) GetActiveRiskLimits(ctx context.Context) (map[int][]models.RiskLimit, error) {
+	return r.getActiveRiskLimits(ctx, 0)
+}
+
+// GetUserRiskLimits retrieves a user's active risk limits. Unset limits are
+// zero.
+func (r *RiskRepository) GetUserRiskLimits(ctx context.Context, userID int) ([]models.RiskLimit, error) {
+	limits, err := r.getActiveRiskLimits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return limits[userID], nil
+}
+
+// getActiveRiskLimits retrieves the active risk limits of the user with
+// userID, or of every user when it is zero
+func (r *RiskRepository) getActiveRiskLimits(ctx context.Context, userID int) (map[int][]models.RiskLimit, error) {
+	query := `
+		SELECT id, user_id, COALESCE(symbol, ''), COALESCE(max_position_size, 0),
+		       COALESCE(max_daily_loss, 0), COALESCE(max_portfolio_risk, 0),
+		       COALESCE(max_leverage, 0), COALESCE(max_concentration, 0),
+		       COALESCE(stop_loss_percentage, 0), is_active, created_at, updated_at
+		FROM risk_limits
+		WHERE is_active = true AND ($1 = 0 OR user_id = $1)
+		ORDER BY user_id, id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("Failed to get risk limits", zap.Error(err))
+		return nil, fmt.Errorf("failed to get risk limits: %w", err)
+	}
+	defer rows.Close()
+
+	limits := make(map[int][]models.RiskLimit)
+	for rows.Next() {
+		limit := models.RiskLimit{}
+		err := rows.Scan(
+			&limit.ID,
+			&limit.UserID,
+			&limit.Symbol,
+			&limit.MaxPositionSize,
+			&limit.MaxDailyLoss,
+			&limit.MaxPortfolioRisk,
+			&limit.MaxLeverage,
+			&limit.MaxConcentration,
+			&limit.StopLossPercentage,
+			&limit.IsActive,
+			&limit.CreatedAt,
+			&limit.UpdatedAt,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan risk limit", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan risk limit: %w", err)
+		}
+		limits[limit.UserID] = append(limits[limit.UserID], limit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating risk limits: %w", err)
+	}
+
+	return limits, nil
+}
+
+// CreateRiskAlert records a risk alert
+func (r *RiskRepository) CreateRiskAlert(ctx context.Context, alert *models.RiskAlert) error {
+	query := `
+		INSERT INTO risk_alerts (user_id, alert_type, severity, symbol, message, current_value, threshold_value)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		alert.UserID,
+		alert.AlertType,
+		alert.Severity,
+		alert.Symbol,
+		alert.Message,
+		alert.CurrentValue,
+		alert.ThresholdValue,
+	).Scan(&alert.ID, &alert.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create risk alert", zap.Error(err), zap.Int("user_id", alert.UserID))
+		return fmt.Errorf("failed to create risk alert: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	backtestrepo "hedge-fund/internal/backtest/repository"
+	"hedge-fund/internal/risk/domain"
+	"hedge-fund/internal/risk/repository"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/redis"
+)
+
+const (
+	correlationKeyPrefix = "risk:correlations:"
+	correlationTTL       = 24 * time.Hour
+	tradingDaysPerYear   = 252
+)
+
+// PortfolioRiskService reports a portfolio's risk from the correlation of
+// its holdings' daily returns. Correlations of a set of symbols are cached
+// for the UTC day they were computed on.
+type PortfolioRiskService struct {
+	repo      *repository.RiskRepository
+	bars      *backtestrepo.BarRepository
+	redis     *redis.Client
+	days      int
+	threshold float64
+	logger    *zap.Logger
+}
+
+func NewPortfolioRiskService(repo *repository.RiskRepository, bars *backtestrepo.BarRepository, redisClient *redis.Client, days int, threshold float64, logger *zap.Logger) *PortfolioRiskService {
+	return &PortfolioRiskService{
+		repo:      repo,
+		bars:      bars,
+		redis:     redisClient,
+		days:      days,
+		threshold: threshold,
+		logger:    logger,
+	}
+}
+
+// GetPortfolioRisk returns a portfolio's correlation matrix, its highly
+// correlated pairs of holdings, and the volatility and VaR of its holdings
+// alone and together. Beta, Sharpe ratio and margin utilization are not
+// computed.
+func (s *PortfolioRiskService) GetPortfolioRisk(ctx context.Context, portfolioID int) (*models.PortfolioRisk, error) {
+	book, err := s.repo.GetOpenBook(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Signed market value per symbol; shorts are negative
+	values := make(map[string]float64)
+	equity, gross, largest := book.Cash, 0.0, 0.0
+	for _, holding := range book.Holdings {
+		value := holding.Value()
+		if holding.Short {
+			value = -value
+		}
+		values[holding.Symbol] += value
+		equity += value
+		gross += math.Abs(value)
+	}
+	symbols := make([]string, 0, len(values))
+	for symbol, value := range values {
+		symbols = append(symbols, symbol)
+		largest = math.Max(largest, math.Abs(value))
+	}
+	sort.Strings(symbols)
+
+	correlations, err := s.correlations(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	z95, z99 := domain.ZScore(0.95), domain.ZScore(0.99)
+	risk := &models.PortfolioRisk{
+		PortfolioID:         portfolioID,
+		UserID:              book.UserID,
+		PositionRisks:       make(map[string]models.RiskMetrics, len(symbols)),
+		Symbols:             symbols,
+		CorrelationMatrix:   correlations.Matrix,
+		CorrelationWarnings: correlations.CorrelatedPairs(s.threshold),
+		CalculatedAt:        now,
+	}
+	if risk.CorrelationWarnings == nil {
+		risk.CorrelationWarnings = []models.CorrelationWarning{}
+	}
+
+	signed := make([]float64, len(symbols))
+	for i, symbol := range symbols {
+		signed[i] = values[symbol]
+		volatility := correlations.Volatilities[i]
+		risk.PositionRisks[symbol] = models.RiskMetrics{
+			Symbol:       symbol,
+			Volatility:   volatility * math.Sqrt(tradingDaysPerYear),
+			VaR95:        z95 * volatility * math.Abs(signed[i]),
+			VaR99:        z99 * volatility * math.Abs(signed[i]),
+			CalculatedAt: now,
+		}
+	}
+
+	// One-day VaR of the book, diversified across its holdings
+	volatility := correlations.PortfolioVolatility(signed)
+	risk.TotalVaR95 = z95 * volatility
+	risk.TotalVaR99 = z99 * volatility
+	if equity > 0 {
+		risk.PortfolioVolatility = volatility / equity * math.Sqrt(tradingDaysPerYear)
+		risk.ConcentrationRisk = largest / equity
+		risk.LeverageRatio = gross / equity
+	}
+	return risk, nil
+}
+
+// correlations returns the correlations of symbols, from the cache when
+// they were computed earlier in the UTC day
+func (s *PortfolioRiskService) correlations(ctx context.Context, symbols []string) (*domain.Correlations, error) {
+	now := time.Now().UTC()
+	sum := sha1.Sum([]byte(strings.Join(symbols, ",")))
+	key := fmt.Sprintf("%s%s:%s", correlationKeyPrefix, now.Format("2006-01-02"), hex.EncodeToString(sum[:]))
+
+	var cached domain.Correlations
+	if err := s.redis.GetCache(ctx, key, &cached); err == nil {
+		return &cached, nil
+	}
+
+	bars := make(map[string][]models.Price)
+	if len(symbols) > 0 && s.days > 0 {
+		// Calendar days comfortably covering the trading days needed
+		start := now.AddDate(0, 0, -2*s.days-7)
+		var err error
+		bars, err = s.bars.GetBars(ctx, symbols, start, now)
+		if err != nil {
+			return nil, err
+		}
+		for symbol, series := range bars {
+			if len(series) > s.days+1 {
+				bars[symbol] = series[len(series)-s.days-1:]
+			}
+		}
+	}
+
+	correlations := domain.ComputeCorrelations(symbols, bars)
+	if err := s.redis.SetCache(ctx, key, correlations, correlationTTL); err != nil {
+		s.logger.Warn("Failed to cache correlations", zap.Error(err), zap.Int("symbols", len(symbols)))
+	}
+	return correlations, nil
+}
@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	backtestrepo "hedge-fund/internal/backtest/repository"
+	"hedge-fund/internal/risk/domain"
+	"hedge-fund/internal/risk/repository"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/redis"
+)
+
+const exposureTTL = time.Hour
+
+// RiskMonitor keeps every active portfolio's exposure, VaR approximation
+// and limit utilization up to date from price updates, and raises risk
+// alerts as limits are approached and breached. A portfolio is evaluated
+// once a holding's price has moved materially since its last evaluation,
+// debounced so a burst of updates is evaluated once. Positions, limits and
+// volatilities are reloaded periodically.
+type RiskMonitor struct {
+	repo           *repository.RiskRepository
+	bars           *backtestrepo.BarRepository
+	redis          *redis.Client
+	notifications  *queue.Manager
+	confidence     float64
+	z              float64
+	volatilityDays int
+	warningLevel   float64
+	minMove        float64
+	debounce       time.Duration
+	logger         *zap.Logger
+
+	mu       sync.Mutex
+	books    map[int]*domain.Book
+	bySymbol map[string][]*domain.Book
+	pending  map[int]time.Time // When portfolios with material moves are due for evaluation
+}
+
+// NewRiskMonitor creates a risk monitor. Price moves smaller than minMove, a
+// fraction of the price at the last evaluation, don't trigger one; a
+// portfolio is evaluated debounce after the first move that does.
+func NewRiskMonitor(repo *repository.RiskRepository, bars *backtestrepo.BarRepository, redisClient *redis.Client, queueManager *queue.Manager, confidence float64, volatilityDays int, warningLevel, minMove float64, debounce time.Duration, logger *zap.Logger) *RiskMonitor {
+	return &RiskMonitor{
+		repo:           repo,
+		bars:           bars,
+		redis:          redisClient,
+		notifications:  queueManager,
+		confidence:     confidence,
+		z:              domain.ZScore(confidence),
+		volatilityDays: volatilityDays,
+		warningLevel:   warningLevel,
+		minMove:        minMove,
+		debounce:       debounce,
+		logger:         logger,
+		books:          make(map[int]*domain.Book),
+		bySymbol:       make(map[string][]*domain.Book),
+		pending:        make(map[int]time.Time),
+	}
+}
+
+// Reload rebuilds every active portfolio's book from its open positions and
+// its user's risk limits, keeping the day's PnL and alerted breach levels
+func (m *RiskMonitor) Reload(ctx context.Context) error {
+	openBooks, err := m.repo.GetOpenBooks(ctx)
+	if err != nil {
+		return err
+	}
+	limits, err := m.repo.GetActiveRiskLimits(ctx)
+	if err != nil {
+		return err
+	}
+
+	held := make(map[string]bool)
+	var symbols []string
+	for _, open := range openBooks {
+		for _, holding := range open.Holdings {
+			if !held[holding.Symbol] {
+				held[holding.Symbol] = true
+				symbols = append(symbols, holding.Symbol)
+			}
+		}
+	}
+	volatilities, err := m.volatilities(ctx, symbols)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	books := make(map[int]*domain.Book, len(openBooks))
+	bySymbol := make(map[string][]*domain.Book, len(symbols))
+	for _, open := range openBooks {
+		for i := range open.Holdings {
+			open.Holdings[i].Volatility = volatilities[open.Holdings[i].Symbol]
+		}
+		book := domain.NewBook(open.PortfolioID, open.UserID, open.Cash, open.Holdings, domain.LimitsFor(limits[open.UserID]), now)
+		books[book.PortfolioID] = book
+		for _, symbol := range book.Symbols() {
+			bySymbol[symbol] = append(bySymbol[symbol], book)
+		}
+	}
+
+	m.mu.Lock()
+	for id, book := range books {
+		book.Carry(m.books[id])
+	}
+	m.books = books
+	m.bySymbol = bySymbol
+	m.pending = make(map[int]time.Time)
+	exposures := make([]models.RiskExposure, 0, len(books))
+	for _, book := range books {
+		exposures = append(exposures, book.Evaluate(m.z, m.confidence, m.warningLevel))
+	}
+	m.mu.Unlock()
+
+	for i := range exposures {
+		m.cache(ctx, &exposures[i])
+	}
+	return nil
+}
+
+// volatilities estimates each symbol's daily volatility from its most
+// recent stored daily bars. Symbols without enough history get zero.
+func (m *RiskMonitor) volatilities(ctx context.Context, symbols []string) (map[string]float64, error) {
+	volatilities := make(map[string]float64, len(symbols))
+	if len(symbols) == 0 || m.volatilityDays <= 0 {
+		return volatilities, nil
+	}
+
+	// Calendar days comfortably covering the trading days needed
+	end := time.Now()
+	start := end.AddDate(0, 0, -2*m.volatilityDays-7)
+	bars, err := m.bars.GetBars(ctx, symbols, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	for symbol, series := range bars {
+		if len(series) > m.volatilityDays+1 {
+			series = series[len(series)-m.volatilityDays-1:]
+		}
+		closes := make([]float64, len(series))
+		for i, bar := range series {
+			closes[i] = bar.Close
+		}
+		volatilities[symbol] = domain.DailyVolatility(closes)
+	}
+	return volatilities, nil
+}
+
+// OnPrice reprices symbol in every book holding it, and schedules the books
+// it moved materially for evaluation. Without a debounce they are evaluated
+// at once.
+func (m *RiskMonitor) OnPrice(ctx context.Context, symbol string, price float64) {
+	m.mu.Lock()
+	now := time.Now()
+	for _, book := range m.bySymbol[symbol] {
+		if !book.Apply(symbol, price, now) || !book.Moved(symbol, m.minMove) {
+			continue
+		}
+		if _, ok := m.pending[book.PortfolioID]; !ok {
+			m.pending[book.PortfolioID] = now.Add(m.debounce)
+		}
+	}
+	m.mu.Unlock()
+
+	if m.debounce <= 0 {
+		m.flush(ctx, now)
+	}
+}
+
+// flush evaluates the books due for evaluation at now, publishing their
+// exposures and alerting the limits they breach
+func (m *RiskMonitor) flush(ctx context.Context, now time.Time) {
+	type breach struct {
+		exposure models.RiskExposure
+		limits   []models.LimitUtilization
+	}
+
+	m.mu.Lock()
+	var updates []breach
+	for portfolioID, due := range m.pending {
+		if due.After(now) {
+			continue
+		}
+		delete(m.pending, portfolioID)
+		book, ok := m.books[portfolioID]
+		if !ok {
+			continue
+		}
+		exposure := book.Evaluate(m.z, m.confidence, m.warningLevel)
+		updates = append(updates, breach{exposure: exposure, limits: book.Breaches(exposure)})
+	}
+	m.mu.Unlock()
+
+	for i := range updates {
+		m.cache(ctx, &updates[i].exposure)
+		for _, limit := range updates[i].limits {
+			m.alert(ctx, &updates[i].exposure, limit)
+		}
+	}
+}
+
+// GetExposure returns a portfolio's latest exposure as published by
+// whichever instance runs the monitor. Trades invalidate it, so there is
+// none until the monitor has reloaded the portfolio's positions.
+func (m *RiskMonitor) GetExposure(ctx context.Context, portfolioID int) (*models.RiskExposure, error) {
+	var exposure models.RiskExposure
+	if err := m.redis.GetCache(ctx, exposureKey(portfolioID), &exposure); err != nil {
+		return nil, fmt.Errorf("exposure not found for portfolio %d", portfolioID)
+	}
+	return &exposure, nil
+}
+
+// exposureKey is where a portfolio's exposure is cached
+func exposureKey(portfolioID int) string {
+	return redis.PortfolioKey(portfolioID, "risk", "exposure")
+}
+
+func (m *RiskMonitor) cache(ctx context.Context, exposure *models.RiskExposure) {
+	err := m.redis.SetCacheTagged(ctx, exposureKey(exposure.PortfolioID), exposure, exposureTTL,
+		redis.PortfolioTag(exposure.PortfolioID), redis.UserTag(exposure.UserID))
+	if err != nil {
+		m.logger.Warn("Failed to cache risk exposure", zap.Error(err), zap.Int("portfolio_id", exposure.PortfolioID))
+	}
+}
+
+// alert records a breached limit as a risk alert, publishes it and
+// notifies the portfolio's owner. Failures are logged.
+func (m *RiskMonitor) alert(ctx context.Context, exposure *models.RiskExposure, limit models.LimitUtilization) {
+	subject := fmt.Sprintf("portfolio %d", exposure.PortfolioID)
+	if limit.Symbol != "" {
+		subject = limit.Symbol
+	}
+	alert := &models.RiskAlert{
+		UserID:         exposure.UserID,
+		AlertType:      limit.Limit,
+		Severity:       limit.Level,
+		Symbol:         limit.Symbol,
+		Message:        fmt.Sprintf("Portfolio %d: %s on %s at %.0f%% of its threshold", exposure.PortfolioID, limit.Limit, subject, limit.Utilization*100),
+		CurrentValue:   limit.Value,
+		ThresholdValue: limit.Threshold,
+	}
+
+	m.logger.Warn("Risk limit breached",
+		zap.Int("portfolio_id", exposure.PortfolioID),
+		zap.String("limit", limit.Limit),
+		zap.String("symbol", limit.Symbol),
+		zap.String("severity", limit.Level),
+		zap.Float64("utilization", limit.Utilization))
+
+	if err := m.repo.CreateRiskAlert(ctx, alert); err != nil {
+		m.logger.Warn("Failed to record risk alert", zap.Error(err))
+	}
+
+	event := models.RiskAlertEvent{
+		Event: models.Event{
+			Type:   "risk_alert",
+			Source: "risk-service",
+			Data: map[string]interface{}{
+				"portfolio_id":  exposure.PortfolioID,
+				"utilization":   limit.Utilization,
+				"var":           exposure.VaR,
+				"concentration": exposure.Concentration,
+				"change":        exposure.Change,
+			},
+			Timestamp: time.Now(),
+		},
+		AlertID:   alert.ID,
+		UserID:    alert.UserID,
+		AlertType: alert.AlertType,
+		Severity:  alert.Severity,
+		Symbol:    alert.Symbol,
+		Message:   alert.Message,
+		Value:     alert.CurrentValue,
+		Threshold: alert.ThresholdValue,
+	}
+	if err := m.redis.PublishEvent(ctx, models.ChannelRiskAlerts, event); err != nil {
+		m.logger.Warn("Failed to publish risk alert", zap.Error(err))
+	}
+
+	if m.notifications == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"severity":   alert.Severity,
+		"alert_type": alert.AlertType,
+		"symbol":     subject,
+		"message":    alert.Message,
+		"value":      alert.CurrentValue,
+		"threshold":  alert.ThresholdValue,
+	}
+	if _, err := m.notifications.EnqueueNotification(alert.UserID, "risk_alert", "", "", data, nil); err != nil {
+		m.logger.Warn("Failed to enqueue risk alert notification", zap.Error(err), zap.Int("user_id", alert.UserID))
+	}
+}
+
+// Run applies each price snapshot published on the conflated price channel,
+// evaluates portfolios as their debounce elapses and reloads positions and
+// limits every reload interval until ctx is cancelled
+func (m *RiskMonitor) Run(ctx context.Context, reload time.Duration) {
+	if err := m.Reload(ctx); err != nil {
+		m.logger.Error("Failed to load risk books", zap.Error(err))
+	}
+
+	pubsub := m.redis.SubscribeToEvents(ctx, models.ChannelPriceSnapshots)
+	defer pubsub.Close()
+
+	ticker := time.NewTicker(reload)
+	defer ticker.Stop()
+
+	// Due portfolios are checked a few times per debounce, so none waits
+	// much longer than it
+	interval := m.debounce / 4
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	flushes := time.NewTicker(interval)
+	defer flushes.Stop()
+
+	updates := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-flushes.C:
+			m.flush(ctx, now)
+		case <-ticker.C:
+			if err := m.Reload(ctx); err != nil {
+				m.logger.Error("Failed to reload risk books", zap.Error(err))
+			}
+		case message, ok := <-updates:
+			if !ok {
+				return
+			}
+
+			var update models.PriceUpdateEvent
+			if err := json.Unmarshal([]byte(message.Payload), &update); err != nil {
+				m.logger.Warn("Failed to decode price update", zap.Error(err))
+				continue
+			}
+			m.OnPrice(ctx, update.Symbol, update.Price)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/risk/domain"
+	"hedge-fund/internal/risk/repository"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// ScenarioService stress tests portfolios by projecting their PnL under
+// historical and custom scenarios, from their open positions at their last
+// known prices
+type ScenarioService struct {
+	repo   *repository.RiskRepository
+	logger *zap.Logger
+}
+
+func NewScenarioService(repo *repository.RiskRepository, logger *zap.Logger) *ScenarioService {
+	return &ScenarioService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Run projects a portfolio's PnL under each scenario
+func (s *ScenarioService) Run(ctx context.Context, portfolioID int, scenarios []*domain.Scenario) (*models.ScenarioReport, error) {
+	book, err := s.repo.GetOpenBook(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Options take the sector of their underlying
+	list := make([]string, 0, len(book.Holdings))
+	for _, holding := range book.Holdings {
+		symbol := holding.Symbol
+		if contract, ok := symbols.ParseOption(symbol); ok {
+			symbol = contract.Underlying
+		}
+		list = append(list, symbol)
+	}
+	sectors, err := s.repo.GetSectors(ctx, symbols.NormalizeAll(list))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ScenarioReport{
+		PortfolioID: portfolioID,
+		Scenarios:   make([]models.ScenarioResult, 0, len(scenarios)),
+		GeneratedAt: time.Now(),
+	}
+	for _, scenario := range scenarios {
+		report.Scenarios = append(report.Scenarios, domain.RunScenario(scenario, book.Cash, book.Holdings, sectors))
+	}
+
+	s.logger.Info("Stress scenarios run",
+		zap.Int("portfolio_id", portfolioID),
+		zap.Int("scenarios", len(scenarios)),
+		zap.Int("positions", len(book.Holdings)))
+	return report, nil
+}
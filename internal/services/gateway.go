@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/dashboard"
+	"hedge-fund/pkg/shared/health"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/registry"
+	"hedge-fund/pkg/shared/server"
+	"hedge-fund/pkg/shared/status"
+)
+
+// RunGateway runs the API Gateway until ctx is cancelled. Redis is where
+// incidents are shared between gateway instances, and job progress is
+// published by every service's workers.
+func RunGateway(ctx context.Context, cfg *config.Config) error {
+	app, err := server.New(ctx, cfg, server.Options{
+		Name:       "API Gateway",
+		HealthName: "api-gateway",
+		Port:       cfg.APIGatewayPort,
+		NoDatabase: true,
+
+		// Incidents can still be reported on the status page during maintenance
+		MaintenanceExempt: []string{"/api/v1/admin/incidents"},
+	})
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	// Service registry with background resolution and health checks
+	logger.Info("Starting service registry", zap.String("service_discovery", cfg.ServiceDiscovery))
+	registryCtx, stopRegistry := context.WithCancel(context.Background())
+	defer stopRegistry()
+
+	services := registry.New(cfg)
+	services.Start(registryCtx, 15*time.Second)
+
+	statusManager := status.NewManager(app.Redis, services, cfg)
+
+	// Dashboards aggregated from the downstream services
+	dashboards := dashboard.NewAggregator(services, cfg, logger.Logger)
+
+	r := app.Router
+
+	// The health of every service
+	r.GET("/health/all", health.NewAggregator(app.Health, services, cfg, logger.Logger).GetAll)
+
+	// API version endpoint
+	r.GET("/api/v1", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Hedge Fund API Gateway v1",
+			"version": "0.1.0",
+		})
+	})
+
+	// Registered downstream services and their endpoint health
+	r.GET("/api/v1/services", func(c *gin.Context) {
+		c.JSON(http.StatusOK, services.Endpoints())
+	})
+
+	// Circuit breakers of requests to downstream services
+	r.GET("/circuit-breakers", services.HTTPClient().GetBreakerStats)
+
+	// Public status page
+	r.GET("/status", statusManager.GetStatusPage)
+	r.GET("/api/v1/status", statusManager.GetStatus)
+
+	// Dashboard sections in one round-trip
+	r.GET("/api/v1/dashboard", dashboards.GetDashboard)
+
+	// Progress of background jobs
+	r.GET("/api/v1/jobs/:id/events", app.Queue.StreamJobEvents)
+
+	// Incident administration
+	r.GET("/api/v1/admin/incidents", statusManager.ListIncidents)
+	r.POST("/api/v1/admin/incidents", statusManager.CreateIncident)
+	r.POST("/api/v1/admin/incidents/:id/updates", statusManager.AddIncidentUpdate)
+
+	return app.Run(ctx)
+}
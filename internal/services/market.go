@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"hedge-fund/internal/market/handlers"
+	"hedge-fund/internal/market/provider"
+	"hedge-fund/internal/market/repository"
+	marketrpc "hedge-fund/internal/market/rpc"
+	"hedge-fund/internal/market/service"
+	marketpb "hedge-fund/pkg/proto/market"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/server"
+)
+
+// RunMarket runs the Market Data Service until ctx is cancelled
+func RunMarket(ctx context.Context, cfg *config.Config) error {
+	app, err := server.New(ctx, cfg, server.Options{
+		Name:       "Market Data Service",
+		HealthName: "market-data-service",
+		Port:       cfg.MarketDataServicePort,
+		GRPCPort:   cfg.MarketDataGRPCPort,
+	})
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+	db, redisClient, queueManager := app.DB, app.Redis, app.Queue
+
+	// Create dependency chain
+	financialDatasets := provider.NewFinancialDatasetsClient(cfg.FinancialDatasetsAPIURL, cfg.FinancialDatasetsAPIKey)
+
+	// Instrument reference data, synced daily from the data provider by the market data update worker
+	instrumentRepo := repository.NewInstrumentRepository(db, logger.Logger)
+	instrumentService := service.NewInstrumentService(instrumentRepo, financialDatasets, queueManager, logger.Logger)
+	instrumentHandler := handlers.NewInstrumentHandler(instrumentRepo, instrumentService, logger.Logger)
+	watchlistHandler := handlers.NewWatchlistHandler(repository.NewWatchlistRepository(db, logger.Logger), logger.Logger)
+
+	// Historical bars, populated by the market data update worker
+	priceProvider := provider.NewAssetRouter(financialDatasets, provider.NewCoinbaseClient(cfg.CoinbaseAPIURL))
+	priceRepo := repository.NewPriceRepository(db, logger.Logger)
+	priceService := service.NewPriceService(priceRepo, priceProvider, queueManager, cfg.MarketDataBackfillDays, logger.Logger)
+	priceService.HandleDataType(service.DataTypeInstruments, func(ctx context.Context, _ []string) error {
+		_, err := instrumentService.Sync(ctx)
+		return err
+	})
+
+	// Fundamentals, loaded on demand and reloaded once a day old
+	fundamentalsService := service.NewFundamentalsService(repository.NewFundamentalsRepository(db, logger.Logger),
+		financialDatasets, redisClient, time.Duration(cfg.FundamentalsCacheTTL)*time.Second, logger.Logger)
+	fundamentalsHandler := handlers.NewFundamentalsHandler(fundamentalsService, logger.Logger)
+
+	// Insider trades and institutional holdings, ingested daily for tracked symbols
+	ownershipService := service.NewOwnershipService(repository.NewOwnershipRepository(db, logger.Logger),
+		financialDatasets, priceRepo, queueManager, logger.Logger)
+	priceService.HandleDataType(service.DataTypeOwnership, ownershipService.UpdateSymbols)
+	ownershipHandler := handlers.NewOwnershipHandler(ownershipService, logger.Logger)
+
+	// Market overview, rebuilt every MARKET_OVERVIEW_INTERVAL and served from Redis
+	overviewService := service.NewOverviewService(priceRepo, redisClient, queueManager, logger.Logger)
+	priceService.HandleDataType(service.DataTypeOverview, func(ctx context.Context, _ []string) error {
+		_, err := overviewService.Refresh(ctx)
+		return err
+	})
+	overviewHandler := handlers.NewOverviewHandler(overviewService, logger.Logger)
+
+	// Price updates are published raw and as per-symbol snapshots every
+	// PRICE_CONFLATION_INTERVAL, for consumers that cannot keep up with every update
+	priceUpdates := service.NewConflator(redisClient, time.Duration(cfg.PriceConflationInterval)*time.Millisecond, logger.Logger)
+	priceService.SetPriceUpdates(priceUpdates)
+	priceHandler := handlers.NewPriceHandler(priceService, service.NewPriceStream(redisClient, logger.Logger), logger.Logger)
+
+	if err := app.StartWorkers(app.Workers(models.QueueMarketData, priceService)); err != nil {
+		return fmt.Errorf("failed to start market data worker: %w", err)
+	}
+	app.Go(priceUpdates.Run)
+
+	// Only one replica enqueues the daily refresh; workers on every replica process it
+	app.Lead("market-data-scheduler", func(ctx context.Context) {
+		go instrumentService.RunDailySchedule(ctx, cfg.MarketDataUpdateHour)
+		go ownershipService.RunDailySchedule(ctx, cfg.MarketDataUpdateHour)
+		go overviewService.RunSchedule(ctx, time.Duration(cfg.MarketOverviewInterval)*time.Second)
+		priceService.RunDailySchedule(ctx, cfg.MarketDataUpdateHour)
+	})
+
+	// Treasury yields for the risk-free rate curve (updates are disabled until a FRED API key is configured)
+	var rateProvider provider.RateProvider
+	if cfg.FREDAPIKey != "" {
+		rateProvider = provider.NewFREDClient(cfg.FREDAPIURL, cfg.FREDAPIKey)
+	}
+	rateService := service.NewRateService(repository.NewRateRepository(db, logger.Logger), rateProvider,
+		cfg.MarketDataBackfillDays, logger.Logger)
+	rateHandler := handlers.NewRateHandler(rateService, cfg.RiskFreeRateTenor, logger.Logger)
+	if rateProvider != nil {
+		app.Lead("risk-free-rate-scheduler", func(ctx context.Context) {
+			rateService.RunDailySchedule(ctx, cfg.MarketDataUpdateHour)
+		})
+	} else {
+		logger.Warn("FRED_API_KEY is not set, treasury yield ingestion is disabled")
+	}
+
+	r := app.Router
+
+	// Job worker pools
+	r.GET("/workers", queueManager.GetWorkerStats)
+
+	// API v1 routes
+	v1 := r.Group("/api/v1/market")
+	{
+		v1.GET("", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Market Data Service",
+				"version": "0.1.0",
+			})
+		})
+
+		// Index levels, movers and sector performance
+		v1.GET("/overview", overviewHandler.GetOverview)
+
+		// Instrument reference data
+		v1.GET("/instruments", instrumentHandler.ListInstruments)
+		v1.GET("/instruments/:symbol", instrumentHandler.GetInstrument)
+		v1.PUT("/instruments/:symbol", instrumentHandler.UpsertInstrument)
+		v1.GET("/symbols", instrumentHandler.SearchSymbols)
+		v1.POST("/symbols/sync", instrumentHandler.SyncSymbols)
+
+		// Watchlists quoted at the latest close
+		v1.GET("/watchlists/:user_id", watchlistHandler.GetWatchlist)
+
+		// Historical OHLCV bars
+		v1.GET("/quotes", priceHandler.GetQuotes)
+		v1.POST("/quotes", priceHandler.PostQuotes)
+		v1.GET("/quotes/stream", priceHandler.StreamQuotes)
+		v1.GET("/:symbol/bars", priceHandler.GetBars)
+		v1.POST("/:symbol/bars/refresh", priceHandler.RefreshBars)
+
+		// Financial statements and ratios
+		v1.GET("/:symbol/fundamentals", fundamentalsHandler.GetFundamentals)
+
+		// Insider trades and institutional ownership
+		v1.GET("/:symbol/insider-trades", ownershipHandler.GetInsiderTrades)
+		v1.GET("/:symbol/institutional-ownership", ownershipHandler.GetInstitutionalOwnership)
+		v1.POST("/:symbol/ownership/refresh", ownershipHandler.RefreshOwnership)
+
+		// Risk-free rate curve
+		v1.GET("/risk-free-rates", rateHandler.GetRiskFreeRates)
+		v1.POST("/risk-free-rates/refresh", rateHandler.RefreshRiskFreeRates)
+	}
+
+	// gRPC server for internal service-to-service calls
+	marketpb.RegisterMarketDataServiceServer(app.GRPC, marketrpc.NewServer(instrumentRepo, logger.Logger))
+
+	return app.Run(ctx)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+// recoveryMiddleware recovers from panics and returns 500 error
+func recoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if err := recover(); err != nil {
+				logger.Error("Panic recovered",
+					zap.Any("error", err),
+					zap.String("path", c.Request.URL.Path),
+					zap.String("method", c.Request.Method),
+					zap.String("request_id", requestctx.RequestID(c.Request.Context())),
+				)
+				apierror.Abort(c, http.StatusInternalServerError, apierror.Response{Error: "Internal server error"})
+			}
+		}()
+		c.Next()
+	}
+}
+
+// errorMiddleware logs errors after handlers execute
+func errorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		// Check for errors after handlers execute
+		if len(c.Errors) > 0 {
+			err := c.Errors.Last()
+			logger.Error("Request error",
+				zap.Error(err),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("request_id", requestctx.RequestID(c.Request.Context())),
+			)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hedge-fund/internal/notifications/channels"
+	"hedge-fund/internal/notifications/handlers"
+	"hedge-fund/internal/notifications/repository"
+	"hedge-fund/internal/notifications/service"
+	"hedge-fund/pkg/shared/auth"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/server"
+)
+
+// RunNotifications runs the Notifications Service until ctx is cancelled
+func RunNotifications(ctx context.Context, cfg *config.Config) error {
+	app, err := server.New(ctx, cfg, server.Options{
+		Name:       "Notifications Service",
+		HealthName: "notifications-service",
+		Port:       cfg.NotificationsServicePort,
+	})
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	if cfg.SMTPHost == "" {
+		logger.Warn("SMTP_HOST is not set, email notifications will fail")
+	}
+
+	// Channel adapters
+	senders := map[string]channels.Sender{
+		models.NotificationChannelEmail:   channels.NewEmailSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom),
+		models.NotificationChannelSlack:   channels.NewSlackSender(),
+		models.NotificationChannelWebhook: channels.NewWebhookSender(),
+	}
+
+	// Create dependency chain
+	notificationRepo := repository.NewNotificationRepository(app.DB, logger.Logger)
+	notificationService := service.NewNotificationService(notificationRepo, app.Queue, senders,
+		cfg.NotificationMaxAttempts, time.Duration(cfg.NotificationRetryBackoff)*time.Millisecond, logger.Logger)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, logger.Logger)
+
+	// Signed event webhooks, such as trade confirmations, posted to user-configured endpoints
+	webhookService := service.NewWebhookService(repository.NewWebhookRepository(app.DB, logger.Logger), channels.NewSignedWebhookSender(),
+		cfg.NotificationMaxAttempts, time.Duration(cfg.NotificationRetryBackoff)*time.Millisecond, logger.Logger)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, logger.Logger)
+
+	// Users reach only their own history, preferences and webhooks, as the
+	// user of the session or named by X-User-ID on service calls
+	authorizer := auth.NewAuthorizer(app.DB, logger.Logger)
+	authorizer.SetSessions(auth.NewSessions(app.Redis, cfg))
+	authorizer.SetServiceToken(cfg.ServiceToken)
+	self := authorizer.RequireSelf("user_id")
+
+	// Background workers delivering queued notifications and webhook events
+	if err := app.StartWorkers(app.Workers(models.QueueNotifications, notificationService)); err != nil {
+		return fmt.Errorf("failed to start notification worker: %w", err)
+	}
+	if err := app.StartWorkers(app.Workers(models.QueueWebhooks, webhookService)); err != nil {
+		return fmt.Errorf("failed to start webhook worker: %w", err)
+	}
+
+	r := app.Router
+
+	// Job worker pools
+	r.GET("/workers", app.Queue.GetWorkerStats)
+
+	// API v1 routes
+	v1 := r.Group("/api/v1")
+	{
+		v1.POST("/notifications", notificationHandler.SendNotification)
+		v1.GET("/notifications/:job_id/deliveries", notificationHandler.GetDeliveries)
+
+		users := v1.Group("/users/:user_id", authorizer.Authenticate(), self)
+
+		// Per-user history and preferences
+		users.GET("/notifications", notificationHandler.GetUserDeliveries)
+		users.GET("/notification-preferences", notificationHandler.GetPreferences)
+		users.PUT("/notification-preferences", notificationHandler.UpdatePreferences)
+
+		// Webhook endpoints and their delivery logs
+		users.GET("/webhooks", webhookHandler.GetWebhooks)
+		users.POST("/webhooks", webhookHandler.CreateWebhook)
+		users.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+		users.GET("/webhooks/:id/deliveries", webhookHandler.GetWebhookDeliveries)
+	}
+
+	return app.Run(ctx)
+}
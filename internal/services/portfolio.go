@@ -0,0 +1,432 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/internal/portfolio/domain"
+	"hedge-fund/internal/portfolio/handlers"
+	"hedge-fund/internal/portfolio/repository"
+	portfoliorpc "hedge-fund/internal/portfolio/rpc"
+	"hedge-fund/internal/portfolio/service"
+	reporthandlers "hedge-fund/internal/reports/handlers"
+	reportrepository "hedge-fund/internal/reports/repository"
+	reportservice "hedge-fund/internal/reports/service"
+	"hedge-fund/internal/reports/storage"
+	"hedge-fund/pkg/broker"
+	portfoliopb "hedge-fund/pkg/proto/portfolio"
+	"hedge-fund/pkg/shared/analytics"
+	"hedge-fund/pkg/shared/auth"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/flags"
+	"hedge-fund/pkg/shared/health"
+	"hedge-fund/pkg/shared/httpclient"
+	"hedge-fund/pkg/shared/jobledger"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/riskfree"
+	"hedge-fund/pkg/shared/server"
+)
+
+// RunPortfolio runs the Portfolio Service, which also generates reports,
+// until ctx is cancelled
+func RunPortfolio(ctx context.Context, cfg *config.Config) error {
+	app, err := server.New(ctx, cfg, server.Options{
+		Name:       "Portfolio Service",
+		HealthName: "portfolio-service",
+		Port:       cfg.PortfolioServicePort,
+		GRPCPort:   cfg.PortfolioGRPCPort,
+		Recovery:   recoveryMiddleware(),
+	})
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+	db, redisClient, queueManager := app.DB, app.Redis, app.Queue
+
+	// Create dependency chain
+	// Repository layer (database operations)
+	portfolioRepo := repository.NewPortfolioRepository(db, logger.Logger)
+
+	// Domain service (business logic)
+	domainService := domain.NewPortfolioService()
+
+	// Service layer (orchestration + transactions)
+	portfolioService := service.NewPortfolioService(portfolioRepo, domainService, logger.Logger)
+
+	// Execution venues; portfolios trade on paper unless linked to a live broker account
+	paper := broker.NewPaperBroker()
+	paper.SetLiquidity(cfg.PaperFillLiquidity)
+	venues := []broker.Broker{paper}
+	if cfg.AlpacaAPIKeyID != "" {
+		venues = append(venues, broker.NewAlpacaBroker(cfg.AlpacaAPIURL, cfg.AlpacaAPIKeyID, cfg.AlpacaAPISecretKey))
+	}
+	brokers := broker.NewRegistry(venues...)
+	portfolioService.SetBrokers(brokers)
+	brokerFees, err := domainService.ParseBrokerFeeSchedules(cfg.BrokerFeeSchedules)
+	if err != nil {
+		return fmt.Errorf("invalid BROKER_FEE_SCHEDULES: %w", err)
+	}
+	portfolioService.SetBrokerFees(brokerFees)
+
+	// Product analytics events
+	analyticsSink, err := analytics.NewSink(cfg, redisClient, logger.Logger)
+	if err != nil {
+		return fmt.Errorf("failed to create analytics sink: %w", err)
+	}
+	if analyticsSink != nil {
+		tracker := analytics.NewTracker(analyticsSink, "portfolio", cfg.AnalyticsBufferSize, logger.Logger)
+		defer tracker.Close()
+		portfolioService.SetAnalytics(tracker)
+	}
+	portfolioService.SetRiskFreeRates(riskfree.NewSource(db, cfg))
+	portfolioService.SetCache(redisClient, time.Duration(cfg.SummaryCacheTTL)*time.Second)
+	portfolioService.SetTradeConfirmations(redisClient, queueManager)
+	portfolioService.SetJobLedger(jobledger.New(db))
+
+	// Market Data Service client, or static prices with MARKET_DATA_CLIENT=mock,
+	// pricing options without a quote at their intrinsic value
+	marketHTTP := httpclient.New(httpclient.NewOptions(cfg), logger.Logger)
+	var upstreamMarket handlers.MarketDataClient = handlers.NewMockMarketDataClient()
+	marketURL := strings.TrimSpace(strings.Split(cfg.MarketDataServiceURL, ",")[0])
+	if cfg.MarketDataClient == "http" {
+		upstreamMarket = handlers.NewHTTPMarketDataClient(marketURL, marketHTTP)
+	}
+	marketClient := handlers.NewOptionPricingClient(upstreamMarket)
+
+	// The Market Data Service is optional for readiness, as prices are served
+	// from the cache while it is down
+	if cfg.MarketDataClient == "http" {
+		app.Health.Optional("market-data-service", health.HTTPCheck(http.DefaultClient, marketURL))
+	}
+
+	// Handler (HTTP layer)
+	portfolioHandler := handlers.NewPortfolioHandler(portfolioService, marketClient, logger.Logger)
+
+	// Valuation prices are cached in Redis, shared by every portfolio instance
+	priceCache := handlers.NewCachedMarketDataClient(marketClient, redisClient,
+		time.Duration(cfg.PriceCacheFreshness)*time.Second, logger.Logger)
+	portfolioHandler.SetPriceCache(priceCache)
+
+	// Roles and portfolio ownership of the acting user
+	authorizer := auth.NewAuthorizer(db, logger.Logger)
+	authorizer.SetSessions(auth.NewSessions(redisClient, cfg))
+	authorizer.SetServiceToken(cfg.ServiceToken)
+	authorizer.SetTwoFactor(auth.NewTwoFactor(db, redisClient, cfg))
+	secondFactor := authorizer.RequireSecondFactor()
+	portfolioHandler.SetSecondFactor(authorizer.CheckSecondFactor, cfg.TwoFactorTradeThreshold)
+	owner := authorizer.RequirePortfolioOwner("id")
+	self := authorizer.RequireSelf("user_id")
+	trader := authorizer.RequireRole(models.RoleTrader, models.RoleAdmin)
+	admin := authorizer.RequireRole(models.RoleAdmin)
+
+	// Shared read-only switch for maintenance windows
+	maintenanceManager := app.Maintenance
+	portfolioService.SetReadOnly(maintenanceManager.IsReadOnly) // Scheduled trades wait it out
+
+	// Feature flags gating live order routing and market order re-quoting
+	flagManager := flags.NewManager(redisClient, cfg)
+	portfolioService.SetFlags(flagManager)
+
+	// Re-quote market orders just before they fill
+	portfolioService.SetRepricing(marketClient, cfg.MaxSlippagePercent, queueManager)
+
+	// Broker reconciliation (disabled until a statement API is configured)
+	var statements broker.StatementClient
+	if cfg.BrokerAPIURL != "" {
+		statements = broker.NewHTTPStatementClient(cfg.BrokerAPIURL, cfg.BrokerAPIKey)
+	}
+	reconciliationService := service.NewReconciliationService(portfolioService, statements, queueManager, redisClient,
+		domain.ReconcileTolerance{
+			PriceRounding:    cfg.ReconciliationPriceTolerance,
+			MaxFeeAdjustment: cfg.ReconciliationMaxFeeAdjustment,
+		}, logger.Logger)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationService, logger.Logger)
+
+	if statements != nil {
+		reconciliationWorkers := app.Workers(models.QueueReconciliation, reconciliationService).
+			PauseWhen(maintenanceManager.IsReadOnly)
+		if err := app.StartWorkers(reconciliationWorkers); err != nil {
+			return fmt.Errorf("failed to start reconciliation worker: %w", err)
+		}
+
+		// Only one replica enqueues the daily runs; workers on every replica process them
+		app.Lead("reconciliation-scheduler", func(ctx context.Context) {
+			reconciliationService.RunDailySchedule(ctx, cfg.ReconciliationHour)
+		})
+	} else {
+		logger.Warn("BROKER_API_URL is not set, broker reconciliation is disabled")
+	}
+
+	// Imports of brokerage history, applied by workers on every replica
+	importService := service.NewImportService(portfolioService, queueManager, logger.Logger)
+	importHandler := handlers.NewImportHandler(importService, logger.Logger)
+	importWorkers := app.Workers(models.QueueImports, importService).
+		PauseWhen(maintenanceManager.IsReadOnly)
+	if err := app.StartWorkers(importWorkers); err != nil {
+		return fmt.Errorf("failed to start import worker: %w", err)
+	}
+
+	// Allocation models, with one replica checking drift daily
+	allocationService := service.NewAllocationService(portfolioService, marketClient, queueManager, redisClient, logger.Logger)
+	allocationHandler := handlers.NewAllocationHandler(allocationService, logger.Logger)
+	app.Lead("drift-scheduler", func(ctx context.Context) {
+		allocationService.RunDailySchedule(ctx, cfg.DriftCheckHour)
+	})
+
+	// Daily portfolio snapshots and benchmark-relative alerts
+	benchmarkService := service.NewBenchmarkService(portfolioService, marketClient, queueManager, redisClient, logger.Logger)
+	benchmarkHandler := handlers.NewBenchmarkHandler(benchmarkService, logger.Logger)
+	app.Lead("benchmark-scheduler", func(ctx context.Context) {
+		benchmarkService.RunDailySchedule(ctx, cfg.BenchmarkCheckHour)
+	})
+
+	// VaR model validation against realized PnL, after the daily snapshots
+	varBacktestService := service.NewVaRBacktestService(portfolioService, redisClient, service.VaRBacktestConfig{
+		Confidence: cfg.VaRBacktestConfidence,
+		Lookback:   cfg.VaRBacktestLookback,
+		Window:     cfg.VaRBacktestWindow,
+	}, logger.Logger)
+	varBacktestHandler := handlers.NewVaRBacktestHandler(varBacktestService, logger.Logger)
+	app.Lead("var-backtest-scheduler", func(ctx context.Context) {
+		varBacktestService.RunDailySchedule(ctx, cfg.VaRBacktestHour)
+	})
+
+	// Trading competitions, scored from the daily snapshots
+	competitionService := service.NewCompetitionService(portfolioService, logger.Logger)
+	competitionHandler := handlers.NewCompetitionHandler(competitionService, logger.Logger)
+	app.Lead("competition-scorer", func(ctx context.Context) {
+		competitionService.RunDailySchedule(ctx, cfg.CompetitionScoringHour)
+	})
+
+	// Settle option positions after their contracts expire
+	optionExpiryService := service.NewOptionExpiryService(portfolioService, marketClient, logger.Logger)
+	app.Lead("option-expiry-scheduler", func(ctx context.Context) {
+		optionExpiryService.RunDailySchedule(ctx, cfg.OptionExpiryHour)
+	})
+
+	// Roll the previous close and day open day P&L is measured from
+	dayAnchorService := service.NewDayAnchorService(portfolioService, marketClient, redisClient, logger.Logger)
+	app.Lead("day-anchor-scheduler", func(ctx context.Context) {
+		dayAnchorService.RunDailySchedule(ctx, cfg.DayAnchorHour)
+	})
+
+	// Monthly account statements, compiled from the daily snapshots
+	statementService := service.NewStatementService(portfolioService, queueManager, logger.Logger)
+	statementHandler := handlers.NewStatementHandler(statementService, logger.Logger)
+	app.Lead("statement-scheduler", func(ctx context.Context) {
+		statementService.RunMonthlySchedule(ctx, cfg.StatementHour)
+	})
+
+	// Fire position alerts and close positions whose stop-loss is hit as price
+	// updates arrive. One instance subscribes, so each is checked once per update.
+	stopLossService := service.NewStopLossService(portfolioService, redisClient, logger.Logger)
+	app.Lead("stop-loss-worker", stopLossService.Run)
+
+	// Cached summaries of portfolios holding a symbol are dropped once its price moves materially
+	summaryInvalidator := service.NewSummaryInvalidator(redisClient, cfg.SummaryCacheMinMove, logger.Logger)
+	app.Lead("summary-invalidator", summaryInvalidator.Run)
+
+	// Poll live venues for fills of routed orders
+	if len(brokers.Names()) > 1 {
+		app.Lead("order-sync", func(ctx context.Context) {
+			portfolioService.RunOrderSync(ctx, time.Duration(cfg.OrderSyncInterval)*time.Second)
+		})
+		app.Lead("day-order-expiry", func(ctx context.Context) {
+			portfolioService.RunDayOrderExpiry(ctx, cfg.DayOrderExpiryHour)
+		})
+		logger.Info("Live order routing enabled", zap.Strings("venues", brokers.Names()))
+	}
+
+	// Report generation (read-only, so it keeps running during maintenance)
+	reportStore, err := newReportStore(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize report storage: %w", err)
+	}
+	reportService := reportservice.NewReportService(reportrepository.NewReportRepository(db, logger.Logger),
+		portfolioRepo, reportStore, queueManager, logger.Logger)
+	reportService.SetSettings(portfolioService)
+	reportHandler := reporthandlers.NewReportHandler(reportService, logger.Logger)
+
+	if err := app.StartWorkers(app.Workers(models.QueueReports, reportService)); err != nil {
+		return fmt.Errorf("failed to start report worker: %w", err)
+	}
+
+	// Scheduled report templates, requested by one replica
+	app.Lead("report-scheduler", func(ctx context.Context) {
+		reportService.RunDailySchedule(ctx, cfg.ReportScheduleHour)
+	})
+
+	// Middleware after the common stack (order matters!)
+	router := app.Router
+	router.Use(errorMiddleware()) // Error handling
+
+	// Job worker pools
+	router.GET("/workers", authorizer.Authenticate(), admin, queueManager.GetWorkerStats)
+
+	// Circuit breakers of calls to the Market Data Service
+	router.GET("/circuit-breakers", marketHTTP.GetBreakerStats)
+
+	// Logging in starts a session, whose bearer token authenticates later requests
+	router.POST("/api/v1/auth/login", authorizer.Login)
+
+	// API v1 routes, as the user of the session or named by X-User-ID on service calls
+	v1 := router.Group("/api/v1", authorizer.Authenticate())
+	{
+		// Login sessions, passwords and two-factor authentication
+		v1.POST("/auth/logout", authorizer.Logout)
+		v1.GET("/users/:user_id/sessions", self, authorizer.ListSessions)
+		v1.DELETE("/users/:user_id/sessions/:session_id", self, authorizer.RevokeSession)
+		v1.PUT("/users/:user_id/password", self, authorizer.ChangePassword)
+		v1.GET("/users/:user_id/two-factor", self, authorizer.GetTwoFactor)
+		v1.POST("/users/:user_id/two-factor", self, authorizer.EnrollTwoFactor)
+		v1.POST("/users/:user_id/two-factor/verify", self, authorizer.VerifyTwoFactor)
+		v1.DELETE("/users/:user_id/two-factor", self, authorizer.DisableTwoFactor)
+
+		// Portfolio CRUD operations
+		v1.POST("/portfolios", trader, portfolioHandler.CreatePortfolio)
+		v1.GET("/portfolios/:id", owner, portfolioHandler.GetPortfolio)
+		v1.PUT("/portfolios/:id", owner, trader, portfolioHandler.UpdatePortfolio)
+		v1.PATCH("/portfolios/:id", owner, trader, portfolioHandler.PatchPortfolio)
+		v1.DELETE("/portfolios/:id", owner, trader, portfolioHandler.DeletePortfolio)
+		v1.GET("/portfolios/user/:user_id", self, portfolioHandler.ListUserPortfolios)
+
+		// Position operations
+		v1.GET("/portfolios/:id/positions", owner, portfolioHandler.GetPositions)
+		v1.GET("/portfolios/:id/positions/export", owner, portfolioHandler.ExportPositions)
+		v1.GET("/portfolios/:id/positions/:symbol", owner, portfolioHandler.GetPositionSummary)
+		v1.GET("/portfolios/:id/positions/:symbol/lots", owner, portfolioHandler.GetPositionLots)
+		v1.PUT("/portfolios/:id/positions/:symbol/stop-loss", owner, trader, portfolioHandler.SetStopLoss)
+
+		// Position alerts
+		v1.POST("/portfolios/:id/position-alerts", owner, trader, portfolioHandler.CreatePositionAlert)
+		v1.GET("/portfolios/:id/position-alerts", owner, portfolioHandler.ListPositionAlerts)
+		v1.GET("/portfolios/:id/position-alerts/:alert_id", owner, portfolioHandler.GetPositionAlert)
+		v1.PUT("/portfolios/:id/position-alerts/:alert_id", owner, trader, portfolioHandler.UpdatePositionAlert)
+		v1.DELETE("/portfolios/:id/position-alerts/:alert_id", owner, trader, portfolioHandler.DeletePositionAlert)
+
+		// Portfolio analysis
+		v1.GET("/portfolios/:id/summary", owner, portfolioHandler.GetSummary)
+		v1.GET("/users/:user_id/portfolios/summary", self, portfolioHandler.GetUserSummaries)
+		v1.GET("/users/:user_id/overview", self, portfolioHandler.GetUserOverview)
+		v1.GET("/portfolios/:id/allocation", owner, portfolioHandler.GetAllocation)
+		v1.GET("/portfolios/:id/risk", owner, portfolioHandler.GetRiskMetrics)
+		v1.GET("/portfolios/:id/risk/history", owner, portfolioHandler.GetFactorHistory)
+		v1.GET("/portfolios/:id/performance", owner, portfolioHandler.GetPerformance)
+		v1.GET("/portfolios/:id/drawdown", owner, portfolioHandler.GetDrawdown)
+
+		// Trading operations
+		v1.POST("/portfolios/:id/trades", owner, trader, portfolioHandler.ExecuteTrade)
+		v1.GET("/portfolios/:id/trades", owner, portfolioHandler.GetTradeHistory)
+		v1.GET("/portfolios/:id/trades/export", owner, portfolioHandler.ExportTrades)
+		v1.GET("/portfolios/:id/trade-events", owner, portfolioHandler.GetTradeEvents)
+		v1.GET("/portfolios/:id/trade-events/replay", owner, portfolioHandler.ReplayTradeEvents)
+
+		// Cash management
+		v1.POST("/portfolios/:id/cash-transactions", owner, trader, portfolioHandler.CreateCashTransaction)
+		v1.GET("/portfolios/:id/cash-transactions", owner, portfolioHandler.GetCashTransactions)
+
+		// Rebalancing
+		v1.POST("/portfolios/:id/rebalance", owner, portfolioHandler.GetRebalanceRecommendations)
+		v1.POST("/portfolios/:id/rebalance/execute", owner, trader, portfolioHandler.ExecuteRebalance)
+
+		// Compliance
+		v1.GET("/portfolios/:id/audit", owner, portfolioHandler.GetAuditTrail)
+
+		// Settings
+		v1.GET("/portfolios/:id/settings", owner, portfolioHandler.GetSettings)
+		v1.PATCH("/portfolios/:id/settings", owner, trader, portfolioHandler.UpdateSettings)
+		v1.PUT("/portfolios/:id/settings", owner, trader, portfolioHandler.ReplaceSettings)
+		v1.GET("/users/:user_id/portfolio-settings", self, portfolioHandler.GetUserSettings)
+		v1.PATCH("/users/:user_id/portfolio-settings", self, trader, portfolioHandler.UpdateUserSettings)
+
+		// Allocation models and drift
+		v1.POST("/portfolios/:id/allocation-models", owner, trader, allocationHandler.CreateAllocationModel)
+		v1.GET("/portfolios/:id/allocation-models", owner, allocationHandler.ListAllocationModels)
+		v1.GET("/portfolios/:id/allocation-models/:model_id", owner, allocationHandler.GetAllocationModel)
+		v1.PUT("/portfolios/:id/allocation-models/:model_id", owner, trader, allocationHandler.UpdateAllocationModel)
+		v1.DELETE("/portfolios/:id/allocation-models/:model_id", owner, trader, allocationHandler.DeleteAllocationModel)
+		v1.POST("/portfolios/:id/allocation-models/:model_id/drift", owner, allocationHandler.CheckDrift)
+		v1.GET("/portfolios/:id/allocation-models/:model_id/drift", owner, allocationHandler.ListDriftChecks)
+
+		// Benchmark-relative alerts
+		v1.POST("/portfolios/:id/benchmark-rules", owner, trader, benchmarkHandler.CreateBenchmarkRule)
+		v1.GET("/portfolios/:id/benchmark-rules", owner, benchmarkHandler.ListBenchmarkRules)
+		v1.DELETE("/portfolios/:id/benchmark-rules/:rule_id", owner, trader, benchmarkHandler.DeleteBenchmarkRule)
+		v1.GET("/portfolios/:id/benchmark-rules/:rule_id/comparison", owner, benchmarkHandler.CompareToBenchmark)
+		v1.GET("/portfolios/:id/benchmark-simulation", owner, benchmarkHandler.SimulateBenchmark)
+
+		// VaR model validation
+		v1.POST("/portfolios/:id/var-backtests", owner, varBacktestHandler.RunVaRBacktest)
+		v1.GET("/portfolios/:id/var-backtests", owner, varBacktestHandler.ListVaRBacktests)
+		v1.GET("/portfolios/:id/statements", owner, statementHandler.ListStatements)
+
+		// Trading competitions
+		v1.POST("/competitions", trader, competitionHandler.CreateCompetition)
+		v1.GET("/competitions", competitionHandler.ListCompetitions)
+		v1.GET("/competitions/:id", competitionHandler.GetCompetition)
+		v1.POST("/competitions/:id/entries", trader, competitionHandler.Enroll)
+		v1.DELETE("/competitions/:id/entries/:portfolio_id", trader, authorizer.RequirePortfolioOwner("portfolio_id"), competitionHandler.Withdraw)
+		v1.GET("/competitions/:id/leaderboard", competitionHandler.Leaderboard)
+
+		// Broker reconciliation
+		v1.PUT("/portfolios/:id/broker-account", owner, trader, secondFactor, reconciliationHandler.LinkBrokerAccount)
+		v1.POST("/portfolios/:id/reconciliations", owner, trader, reconciliationHandler.RunReconciliation)
+		v1.GET("/portfolios/:id/reconciliations", owner, reconciliationHandler.ListReconciliations)
+		v1.GET("/portfolios/:id/reconciliations/:run_id", owner, reconciliationHandler.GetReconciliationReport)
+
+		// Brokerage history imports
+		v1.POST("/portfolios/:id/import", owner, trader, importHandler.CreateImport)
+		v1.GET("/portfolios/:id/import/:import_id", owner, importHandler.GetImport)
+		v1.PUT("/portfolios/:id/import/:import_id/mapping", owner, trader, importHandler.UpdateImportMapping)
+		v1.POST("/portfolios/:id/import/:import_id/start", owner, trader, importHandler.StartImport)
+
+		// Reports
+		v1.POST("/portfolios/:id/reports", owner, reportHandler.GenerateReport)
+		v1.POST("/portfolios/:id/tax-reports", owner, reportHandler.GenerateTaxReport)
+		v1.GET("/reports/:id", reportHandler.GetReport)
+		v1.GET("/reports/:id/download", reportHandler.DownloadReport)
+		v1.GET("/users/:user_id/reports", self, reportHandler.ListUserReports)
+		v1.POST("/users/:user_id/report-templates", self, reportHandler.CreateTemplate)
+		v1.GET("/users/:user_id/report-templates", self, reportHandler.ListTemplates)
+		v1.GET("/report-templates/:id", reportHandler.GetTemplate)
+		v1.PUT("/report-templates/:id", reportHandler.UpdateTemplate)
+		v1.DELETE("/report-templates/:id", reportHandler.DeleteTemplate)
+
+		// Administration
+		v1.GET("/admin/maintenance", admin, maintenanceManager.GetStatus)
+		v1.POST("/admin/maintenance", admin, maintenanceManager.ScheduleMaintenance)
+		v1.DELETE("/admin/maintenance", admin, maintenanceManager.ClearMaintenance)
+		v1.GET("/admin/feature-flags", admin, flagManager.ListFlags)
+		v1.PUT("/admin/feature-flags/:name", admin, flagManager.SetFlag)
+		v1.DELETE("/admin/feature-flags/:name", admin, flagManager.DeleteFlag)
+		v1.GET("/admin/queues", admin, queueManager.GetQueues)
+		v1.POST("/admin/queues/:queue/pause", admin, queueManager.PauseQueue)
+		v1.POST("/admin/queues/:queue/resume", admin, queueManager.ResumeQueue)
+		v1.GET("/admin/workers", admin, queueManager.GetWorkers)
+		v1.GET("/users/:user_id/feature-flags", self, flagManager.GetUserFlags)
+		v1.POST("/admin/provision", admin, portfolioHandler.ProvisionUsers)
+	}
+
+	// gRPC server for internal service-to-service calls
+	rpcServer := portfoliorpc.NewServer(portfolioService, marketClient, authorizer, logger.Logger)
+	rpcServer.SetSecondFactor(cfg.TwoFactorTradeThreshold)
+	portfoliopb.RegisterPortfolioServiceServer(app.GRPC, rpcServer)
+
+	return app.Run(ctx)
+}
+
+// newReportStore selects where generated reports are kept
+func newReportStore(cfg *config.Config) (storage.Store, error) {
+	switch cfg.ReportStorage {
+	case "s3":
+		return storage.NewS3Store(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKeyID, cfg.S3SecretAccessKey)
+	case "local", "":
+		return storage.NewLocalStore(cfg.ReportStoragePath)
+	}
+	return nil, fmt.Errorf("unknown REPORT_STORAGE: %s", cfg.ReportStorage)
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	aihandlers "hedge-fund/internal/ai/handlers"
+	airepo "hedge-fund/internal/ai/repository"
+	"hedge-fund/internal/ai/script"
+	aiservice "hedge-fund/internal/ai/service"
+	"hedge-fund/internal/ai/workflow"
+	"hedge-fund/internal/backtest/handlers"
+	"hedge-fund/internal/backtest/repository"
+	backtestrpc "hedge-fund/internal/backtest/rpc"
+	"hedge-fund/internal/backtest/service"
+	riskhandlers "hedge-fund/internal/risk/handlers"
+	riskrepo "hedge-fund/internal/risk/repository"
+	riskservice "hedge-fund/internal/risk/service"
+	marketpb "hedge-fund/pkg/proto/market"
+	portfoliopb "hedge-fund/pkg/proto/portfolio"
+	riskpb "hedge-fund/pkg/proto/risk"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/flags"
+	"hedge-fund/pkg/shared/health"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/riskfree"
+	"hedge-fund/pkg/shared/rpc"
+	"hedge-fund/pkg/shared/server"
+)
+
+// RunRisk runs the Risk Service, which also serves backtesting and the AI
+// agents, until ctx is cancelled
+func RunRisk(ctx context.Context, cfg *config.Config) error {
+	app, err := server.New(ctx, cfg, server.Options{
+		Name:         "Risk Service",
+		HealthName:   "risk-service",
+		Port:         cfg.RiskServicePort,
+		GRPCPort:     cfg.RiskGRPCPort,
+		WriteTimeout: 60 * time.Second,
+
+		// Auto-trading can still be halted during maintenance
+		MaintenanceExempt: []string{"/api/v1/ai/auto-trade/kill-switch"},
+	})
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+	db, redisClient, queueManager := app.DB, app.Redis, app.Queue
+
+	// Create dependency chain
+	barRepo := repository.NewBarRepository(db, logger.Logger)
+	backtestService := service.NewBacktestService(barRepo, riskfree.NewSource(db, cfg), redisClient, queueManager, cfg.BacktestConcurrency, logger.Logger)
+	backtestHandler := handlers.NewBacktestHandler(backtestService, logger.Logger)
+
+	// User-defined strategy scripts, run sandboxed as custom agents and in backtests
+	agentRepo := airepo.NewAgentRepository(db, logger.Logger)
+	strategyService := aiservice.NewStrategyService(agentRepo, barRepo, redisClient, script.Limits{
+		MaxSteps:  uint64(cfg.StrategyMaxSteps),
+		Timeout:   time.Duration(cfg.StrategyTimeout) * time.Millisecond,
+		MaxMemory: uint64(cfg.StrategyMaxMemory) << 20,
+	}, cfg.StrategyMaxSize, logger.Logger)
+	strategyHandler := aihandlers.NewStrategyHandler(strategyService, logger.Logger)
+	backtestService.SetStrategies(strategyService)
+
+	// Background worker for parameter sweeps
+	if err := app.StartWorkers(app.Workers(models.QueueBacktests, backtestService)); err != nil {
+		return fmt.Errorf("failed to start backtest worker: %w", err)
+	}
+
+	// Intraday risk monitoring from price updates, evaluating portfolios on
+	// material moves. One instance subscribes, so each breach is alerted once.
+	riskRepo := riskrepo.NewRiskRepository(db, logger.Logger)
+	riskMonitor := riskservice.NewRiskMonitor(riskRepo, barRepo, redisClient, queueManager,
+		cfg.RiskMonitorConfidence, cfg.RiskMonitorVolatilityDays, cfg.RiskMonitorWarningLevel,
+		cfg.RiskMonitorMinMove, time.Duration(cfg.RiskMonitorDebounce)*time.Millisecond, logger.Logger)
+	monitorHandler := riskhandlers.NewMonitorHandler(riskMonitor, logger.Logger)
+	portfolioRiskHandler := riskhandlers.NewPortfolioRiskHandler(riskservice.NewPortfolioRiskService(riskRepo, barRepo, redisClient,
+		cfg.RiskCorrelationDays, cfg.RiskCorrelationThreshold, logger.Logger), logger.Logger)
+	scenarioHandler := riskhandlers.NewScenarioHandler(riskservice.NewScenarioService(riskRepo, logger.Logger), logger.Logger)
+
+	app.Lead("risk-monitor", func(ctx context.Context) {
+		riskMonitor.Run(ctx, time.Duration(cfg.RiskMonitorReload)*time.Second)
+	})
+
+	// Daily evaluation of AI agents' signals against subsequent price moves
+	agentService := aiservice.NewAgentService(agentRepo, barRepo, cfg.AgentPerformanceLookback, logger.Logger)
+	agentHandler := aihandlers.NewAgentHandler(agentService, logger.Logger)
+	backtestService.SetAgents(agentService)
+	app.Lead("agent-performance-scheduler", func(ctx context.Context) {
+		agentService.RunDailySchedule(ctx, cfg.AgentPerformanceHour)
+	})
+
+	// Auto-trading of linked portfolios from consensus signals, with orders
+	// placed through the portfolio service
+	portfolioConn, err := rpc.Dial(cfg.PortfolioGRPCAddr, rpc.ServiceToken(cfg.ServiceToken))
+	if err != nil {
+		return fmt.Errorf("failed to connect to portfolio service: %w", err)
+	}
+	defer portfolioConn.Close()
+	autoTradeService := aiservice.NewAutoTradeService(agentRepo, agentService, riskRepo,
+		aiservice.NewPortfolioTrader(portfoliopb.NewPortfolioServiceClient(portfolioConn)), redisClient, queueManager,
+		time.Duration(cfg.AutoTradeCooldown)*time.Minute, time.Duration(cfg.AutoTradeApprovalTTL)*time.Minute, logger.Logger)
+	autoTradeService.SetFlags(flags.NewManager(redisClient, cfg))
+	autoTradeHandler := aihandlers.NewAutoTradeHandler(autoTradeService, logger.Logger)
+	app.Lead("auto-trader", autoTradeService.Run)
+
+	// Multi-step AI analyses, run by the AI analysis workers with progress
+	// tracked in Redis
+	marketConn, err := rpc.Dial(cfg.MarketGRPCAddr, rpc.ServiceToken(cfg.ServiceToken))
+	if err != nil {
+		return fmt.Errorf("failed to connect to market data service: %w", err)
+	}
+	defer marketConn.Close()
+	workflowEngine := workflow.NewEngine(workflow.AnalysisSteps(barRepo,
+		aiservice.NewMarketInstruments(marketpb.NewMarketDataServiceClient(marketConn)), redisClient, strategyService, agentService),
+		redisClient, queueManager, cfg.WorkflowStepRetries, time.Duration(cfg.WorkflowRetryBackoff)*time.Second, logger.Logger)
+	workflowHandler := aihandlers.NewWorkflowHandler(workflowEngine, logger.Logger)
+	if err := app.StartWorkers(app.Workers(models.QueueAIAnalysis, workflowEngine)); err != nil {
+		return fmt.Errorf("failed to start AI workflow worker: %w", err)
+	}
+
+	// Upstream services, which readiness reports without depending on
+	app.Health.Optional("portfolio-service", health.GRPCCheck(portfolioConn))
+	app.Health.Optional("market-data-service", health.GRPCCheck(marketConn))
+
+	r := app.Router
+
+	// Job worker pools
+	r.GET("/workers", queueManager.GetWorkerStats)
+
+	// API v1 routes
+	v1 := r.Group("/api/v1")
+	{
+		v1.GET("/risk", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Risk Management Service",
+				"version": "0.1.0",
+			})
+		})
+
+		v1.GET("/risk/portfolios/:id", portfolioRiskHandler.GetPortfolioRisk)
+		v1.GET("/risk/portfolios/:id/exposure", monitorHandler.GetExposure)
+		v1.POST("/risk/scenarios", scenarioHandler.RunScenarios)
+
+		// AI agent performance
+		v1.GET("/ai/agents/performance", agentHandler.GetPerformance)
+		v1.GET("/ai/agents/leaderboard", agentHandler.GetLeaderboard)
+		v1.POST("/ai/analysis", agentHandler.Analyze)
+		v1.GET("/ai/signals/latest", agentHandler.GetLatestSignals)
+
+		// AI analysis workflows
+		v1.POST("/ai/workflows", workflowHandler.StartWorkflow)
+		v1.GET("/ai/workflows/:id", workflowHandler.GetWorkflowStatus)
+
+		// AI auto-trading
+		v1.PUT("/ai/auto-trade/portfolios/:id", autoTradeHandler.SaveSettings)
+		v1.GET("/ai/auto-trade/portfolios/:id", autoTradeHandler.GetSettings)
+		v1.DELETE("/ai/auto-trade/portfolios/:id", autoTradeHandler.DeleteSettings)
+		v1.GET("/ai/auto-trade/portfolios/:id/orders", autoTradeHandler.ListOrders)
+		v1.POST("/ai/auto-trade/orders/:id/approve", autoTradeHandler.ApproveOrder)
+		v1.POST("/ai/auto-trade/orders/:id/reject", autoTradeHandler.RejectOrder)
+		v1.GET("/ai/auto-trade/kill-switch", autoTradeHandler.GetKillSwitch)
+		v1.POST("/ai/auto-trade/kill-switch", autoTradeHandler.EngageKillSwitch)
+		v1.DELETE("/ai/auto-trade/kill-switch", autoTradeHandler.ReleaseKillSwitch)
+
+		// Strategy scripts
+		v1.POST("/users/:user_id/strategies", strategyHandler.CreateStrategy)
+		v1.GET("/users/:user_id/strategies", strategyHandler.ListStrategies)
+		v1.GET("/strategies/:id", strategyHandler.GetStrategy)
+		v1.PATCH("/strategies/:id", strategyHandler.UpdateStrategy)
+		v1.DELETE("/strategies/:id", strategyHandler.DeleteStrategy)
+		v1.POST("/strategies/:id/signals", strategyHandler.RunSignals)
+
+		// Backtesting
+		v1.POST("/backtests", backtestHandler.RunBacktest)
+		v1.POST("/backtests/optimizations", backtestHandler.StartOptimization)
+		v1.GET("/backtests/optimizations/:id", backtestHandler.GetOptimization)
+	}
+
+	// gRPC server for internal service-to-service calls
+	riskpb.RegisterRiskServiceServer(app.GRPC, backtestrpc.NewServer(backtestService, logger.Logger))
+
+	return app.Run(ctx)
+}
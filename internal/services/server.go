@@ -0,0 +1,80 @@
+// Package services wires the platform's services: their dependencies and
+// routes on top of the shared server bootstrap. Each service runs from its own
+// binary in production, and any of them can run together in one process for
+// local development.
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/server"
+)
+
+// Service is one of the platform's services
+type Service struct {
+	Name        string // Command name, e.g. "portfolio"
+	Description string
+
+	Run server.RunFunc
+}
+
+// The platform's services
+var (
+	Portfolio = Service{
+		Name:        "portfolio",
+		Description: "Portfolio Service: portfolios, trading, reports and their workers",
+		Run:         RunPortfolio,
+	}
+	Market = Service{
+		Name:        "market",
+		Description: "Market Data Service: prices, instruments and market data ingestion",
+		Run:         RunMarket,
+	}
+	Risk = Service{
+		Name:        "risk",
+		Description: "Risk Service: risk monitoring, backtesting and the AI agents",
+		Run:         RunRisk,
+	}
+	Notifications = Service{
+		Name:        "notifications",
+		Description: "Notifications Service: email, Slack and webhook delivery",
+		Run:         RunNotifications,
+	}
+	Gateway = Service{
+		Name:        "gateway",
+		Description: "API Gateway: service registry, status page and dashboards",
+		Run:         RunGateway,
+	}
+)
+
+// All is every service, in start order
+var All = []Service{Portfolio, Market, Risk, Notifications, Gateway}
+
+// Serve runs services in this process until SIGINT or SIGTERM, or until one
+// of them fails
+func Serve(services ...Service) error {
+	return server.Main(runFuncs(services)...)
+}
+
+// Run runs services concurrently until ctx is cancelled. A service that
+// fails stops the others, and its error is returned once all have stopped.
+func Run(ctx context.Context, cfg *config.Config, services ...Service) error {
+	return server.Group(ctx, cfg, runFuncs(services)...)
+}
+
+// runFuncs returns the services' Run functions, naming the service in their errors
+func runFuncs(services []Service) []server.RunFunc {
+	fns := make([]server.RunFunc, len(services))
+	for i, s := range services {
+		s := s
+		fns[i] = func(ctx context.Context, cfg *config.Config) error {
+			if err := s.Run(ctx, cfg); err != nil {
+				return fmt.Errorf("%s: %w", s.Name, err)
+			}
+			return nil
+		}
+	}
+	return fns
+}
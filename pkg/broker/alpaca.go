@@ -0,0 +1,196 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alpaca is the registry name of the Alpaca adapter
+const Alpaca = "alpaca"
+
+// AlpacaBroker routes orders to the Alpaca trading API. Alpaca keys belong to
+// a single account, so Order.AccountID is not sent. Use the paper-api host to
+// trade against an Alpaca paper account.
+type AlpacaBroker struct {
+	baseURL   string
+	keyID     string
+	secretKey string
+	client    *http.Client
+}
+
+func NewAlpacaBroker(baseURL, keyID, secretKey string) *AlpacaBroker {
+	return &AlpacaBroker{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		keyID:     keyID,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type alpacaOrderRequest struct {
+	Symbol        string `json:"symbol"`
+	Qty           string `json:"qty"`
+	Side          string `json:"side"`
+	Type          string `json:"type"`
+	TimeInForce   string `json:"time_in_force"`
+	LimitPrice    string `json:"limit_price,omitempty"`
+	ClientOrderID string `json:"client_order_id,omitempty"`
+}
+
+type alpacaOrder struct {
+	ID             string     `json:"id"`
+	ClientOrderID  string     `json:"client_order_id"`
+	Status         string     `json:"status"`
+	FilledQty      string     `json:"filled_qty"`
+	FilledAvgPrice *string    `json:"filled_avg_price"`
+	FilledAt       *time.Time `json:"filled_at"`
+}
+
+type alpacaError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Name implements Broker
+func (b *AlpacaBroker) Name() string {
+	return Alpaca
+}
+
+// SubmitOrder implements Broker
+func (b *AlpacaBroker) SubmitOrder(ctx context.Context, order Order) (*OrderStatus, error) {
+	req := alpacaOrderRequest{
+		Symbol:        order.Symbol,
+		Qty:           strconv.FormatFloat(order.Quantity, 'f', -1, 64),
+		Side:          order.Side,
+		Type:          order.Type,
+		TimeInForce:   order.TimeInForce,
+		ClientOrderID: order.ClientOrderID,
+	}
+	if req.TimeInForce == "" {
+		req.TimeInForce = "day"
+	}
+	if order.Type == "limit" {
+		req.LimitPrice = strconv.FormatFloat(order.LimitPrice, 'f', -1, 64)
+	}
+
+	var resp alpacaOrder
+	if err := b.do(ctx, http.MethodPost, "/v2/orders", req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to submit order: %w", err)
+	}
+	return resp.toStatus()
+}
+
+// GetOrder implements Broker
+func (b *AlpacaBroker) GetOrder(ctx context.Context, brokerOrderID string) (*OrderStatus, error) {
+	var resp alpacaOrder
+	if err := b.do(ctx, http.MethodGet, "/v2/orders/"+url.PathEscape(brokerOrderID), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	return resp.toStatus()
+}
+
+// CancelOrder implements Broker
+func (b *AlpacaBroker) CancelOrder(ctx context.Context, brokerOrderID string) error {
+	if err := b.do(ctx, http.MethodDelete, "/v2/orders/"+url.PathEscape(brokerOrderID), nil, nil); err != nil {
+		return fmt.Errorf("failed to cancel order: %w", err)
+	}
+	return nil
+}
+
+func (b *AlpacaBroker) do(ctx context.Context, method, path string, body, dest interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("APCA-API-KEY-ID", b.keyID)
+	req.Header.Set("APCA-API-SECRET-KEY", b.secretKey)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("order not found")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr alpacaError
+		json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apiErr)
+		if apiErr.Message != "" {
+			return fmt.Errorf("alpaca returned status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("alpaca returned status %d", resp.StatusCode)
+	}
+
+	if dest == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+func (o *alpacaOrder) toStatus() (*OrderStatus, error) {
+	status := &OrderStatus{
+		BrokerOrderID: o.ID,
+		ClientOrderID: o.ClientOrderID,
+		Status:        alpacaStatus(o.Status),
+		FilledAt:      o.FilledAt,
+		Fees:          new(float64), // Alpaca equity trading is commission-free
+	}
+	if status.Status == StatusCancelled || status.Status == StatusRejected {
+		status.Reason = "alpaca order " + o.Status
+	}
+
+	if o.FilledQty != "" {
+		// Alpaca reports quantities as decimal strings to support fractional shares
+		qty, err := strconv.ParseFloat(o.FilledQty, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filled_qty %q: %w", o.FilledQty, err)
+		}
+		status.FilledQuantity = qty
+	}
+	if o.FilledAvgPrice != nil && *o.FilledAvgPrice != "" {
+		price, err := strconv.ParseFloat(*o.FilledAvgPrice, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filled_avg_price %q: %w", *o.FilledAvgPrice, err)
+		}
+		status.FilledPrice = price
+	}
+	return status, nil
+}
+
+// alpacaStatus maps Alpaca's order statuses onto ours
+func alpacaStatus(status string) string {
+	switch status {
+	case "filled":
+		return StatusFilled
+	case "partially_filled":
+		return StatusPartiallyFilled
+	case "canceled", "expired", "done_for_day":
+		return StatusCancelled
+	case "rejected":
+		return StatusRejected
+	}
+	return StatusOpen // new, accepted, pending_new, held, suspended, ...
+}
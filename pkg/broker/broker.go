@@ -0,0 +1,90 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Paper is the name of the built-in simulated venue
+const Paper = "paper"
+
+// Order statuses reported by a venue
+const (
+	StatusOpen            = "open"
+	StatusPartiallyFilled = "partially_filled"
+	StatusFilled          = "filled"
+	StatusCancelled       = "cancelled"
+	StatusRejected        = "rejected"
+)
+
+// Order is an order routed to an execution venue
+type Order struct {
+	ClientOrderID  string // Our trade ID, echoed back on broker statements
+	AccountID      string // Broker account; adapters keyed per account may ignore it
+	Symbol         string
+	Side           string // "buy" or "sell"
+	Quantity       float64
+	Type           string  // "market" or "limit"
+	LimitPrice     float64 // Limit orders only
+	ReferencePrice float64 // Last market price when the order was placed
+	TimeInForce    string  // "day", "gtc", "ioc" or "fok"; "day" when empty
+}
+
+// OrderStatus is a venue's view of an order
+type OrderStatus struct {
+	BrokerOrderID  string
+	ClientOrderID  string
+	Status         string
+	FilledQuantity float64
+	FilledPrice    float64  // Average fill price
+	Fees           *float64 // Nil when the venue does not report commissions
+	FilledAt       *time.Time
+	Reason         string // Why an order was cancelled or rejected, when known
+}
+
+// Terminal reports whether the order can no longer change
+func (s *OrderStatus) Terminal() bool {
+	return s.Status == StatusFilled || s.Status == StatusCancelled || s.Status == StatusRejected
+}
+
+// Broker is an execution venue
+type Broker interface {
+	Name() string
+	SubmitOrder(ctx context.Context, order Order) (*OrderStatus, error)
+	GetOrder(ctx context.Context, brokerOrderID string) (*OrderStatus, error)
+	CancelOrder(ctx context.Context, brokerOrderID string) error
+}
+
+// Registry holds the configured execution venues by name
+type Registry struct {
+	brokers map[string]Broker
+}
+
+func NewRegistry(brokers ...Broker) *Registry {
+	r := &Registry{brokers: make(map[string]Broker)}
+	for _, b := range brokers {
+		r.brokers[b.Name()] = b
+	}
+	return r
+}
+
+// Get returns the venue registered under name
+func (r *Registry) Get(name string) (Broker, error) {
+	b, ok := r.brokers[name]
+	if !ok {
+		return nil, fmt.Errorf("execution venue not configured: %s", name)
+	}
+	return b, nil
+}
+
+// Names lists the registered venues
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.brokers))
+	for name := range r.brokers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
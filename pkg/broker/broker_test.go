@@ -0,0 +1,104 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaperBrokerFillsImmediately(t *testing.T) {
+	b := NewPaperBroker()
+
+	status, err := b.SubmitOrder(context.Background(), Order{Symbol: "AAPL", Side: "buy", Quantity: 10, Type: "market", ReferencePrice: 150})
+	require.NoError(t, err)
+	assert.True(t, status.Terminal())
+	assert.Equal(t, 10.0, status.FilledQuantity)
+	assert.Equal(t, 150.0, status.FilledPrice)
+	assert.Nil(t, status.Fees)
+
+	status, err = b.SubmitOrder(context.Background(), Order{Symbol: "AAPL", Side: "buy", Quantity: 1, Type: "limit", LimitPrice: 149, ReferencePrice: 150})
+	require.NoError(t, err)
+	assert.Equal(t, 149.0, status.FilledPrice)
+}
+
+func TestPaperBrokerTimeInForce(t *testing.T) {
+	b := NewPaperBroker()
+	b.SetLiquidity(4)
+	ctx := context.Background()
+
+	status, err := b.SubmitOrder(ctx, Order{Symbol: "AAPL", Side: "buy", Quantity: 10, Type: "market", ReferencePrice: 150, TimeInForce: "ioc"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusCancelled, status.Status)
+	assert.Equal(t, 4.0, status.FilledQuantity)
+	assert.NotNil(t, status.FilledAt)
+	assert.NotEmpty(t, status.Reason)
+
+	status, err = b.SubmitOrder(ctx, Order{Symbol: "AAPL", Side: "buy", Quantity: 10, Type: "market", ReferencePrice: 150, TimeInForce: "fok"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusCancelled, status.Status)
+	assert.Zero(t, status.FilledQuantity)
+	assert.Nil(t, status.FilledAt)
+
+	status, err = b.SubmitOrder(ctx, Order{Symbol: "AAPL", Side: "buy", Quantity: 3, Type: "market", ReferencePrice: 150, TimeInForce: "fok"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusFilled, status.Status)
+	assert.Equal(t, 3.0, status.FilledQuantity)
+
+	for _, tif := range []string{"", "day", "gtc"} {
+		status, err = b.SubmitOrder(ctx, Order{Symbol: "AAPL", Side: "buy", Quantity: 10, Type: "market", ReferencePrice: 150, TimeInForce: tif})
+		require.NoError(t, err)
+		assert.Equal(t, StatusFilled, status.Status, tif)
+		assert.Equal(t, 10.0, status.FilledQuantity, tif)
+	}
+}
+
+func TestAlpacaBrokerSubmitsAndPollsOrders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("APCA-API-KEY-ID"))
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/orders":
+			var req alpacaOrderRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "42", req.ClientOrderID)
+			assert.Equal(t, "5", req.Qty)
+			assert.Equal(t, "101.5", req.LimitPrice)
+			assert.Equal(t, "day", req.TimeInForce)
+			w.Write([]byte(`{"id":"ord-1","client_order_id":"42","status":"accepted","filled_qty":"0","filled_avg_price":null}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/orders/ord-1":
+			w.Write([]byte(`{"id":"ord-1","client_order_id":"42","status":"filled","filled_qty":"5","filled_avg_price":"101.25","filled_at":"2024-03-01T15:04:05Z"}`))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"code":42210000,"message":"insufficient buying power"}`))
+		}
+	}))
+	defer server.Close()
+
+	b := NewAlpacaBroker(server.URL, "key", "secret")
+	ctx := context.Background()
+
+	status, err := b.SubmitOrder(ctx, Order{ClientOrderID: "42", Symbol: "AAPL", Side: "buy", Quantity: 5, Type: "limit", LimitPrice: 101.5})
+	require.NoError(t, err)
+	assert.Equal(t, StatusOpen, status.Status)
+	assert.False(t, status.Terminal())
+
+	status, err = b.GetOrder(ctx, "ord-1")
+	require.NoError(t, err)
+	assert.Equal(t, StatusFilled, status.Status)
+	assert.Equal(t, 5.0, status.FilledQuantity)
+	assert.Equal(t, 101.25, status.FilledPrice)
+	require.NotNil(t, status.Fees)
+	require.NotNil(t, status.FilledAt)
+
+	_, err = b.GetOrder(ctx, "missing")
+	assert.ErrorContains(t, err, "not found")
+
+	err = b.CancelOrder(ctx, "ord-1")
+	assert.ErrorContains(t, err, "insufficient buying power")
+}
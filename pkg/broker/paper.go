@@ -0,0 +1,81 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaperBroker simulates an exchange by filling every order at once, at the
+// reference price (or the limit price for limit orders). Orders fill in full
+// unless a liquidity cap is set: IOC orders then fill up to it and cancel the
+// rest, and FOK orders larger than it are cancelled unfilled. Commissions are
+// left to the portfolio's own fee model.
+type PaperBroker struct {
+	liquidity float64 // Most quantity one order can fill; 0 is unlimited
+}
+
+func NewPaperBroker() *PaperBroker {
+	return &PaperBroker{}
+}
+
+// SetLiquidity caps the quantity one order can fill, 0 for unlimited. Only
+// IOC and FOK orders are held to it; DAY and GTC orders would rest on a real
+// venue until filled.
+func (b *PaperBroker) SetLiquidity(quantity float64) {
+	b.liquidity = max(quantity, 0)
+}
+
+// Name implements Broker
+func (b *PaperBroker) Name() string {
+	return Paper
+}
+
+// SubmitOrder implements Broker
+func (b *PaperBroker) SubmitOrder(ctx context.Context, order Order) (*OrderStatus, error) {
+	price := order.ReferencePrice
+	if order.Type == "limit" && order.LimitPrice > 0 {
+		price = order.LimitPrice
+	}
+	if price <= 0 {
+		return nil, fmt.Errorf("no price to fill %s at", order.Symbol)
+	}
+
+	status := &OrderStatus{
+		BrokerOrderID:  uuid.New().String(),
+		ClientOrderID:  order.ClientOrderID,
+		Status:         StatusFilled,
+		FilledQuantity: order.Quantity,
+		FilledPrice:    price,
+	}
+	if b.liquidity > 0 && order.Quantity > b.liquidity {
+		switch order.TimeInForce {
+		case "fok":
+			status.Status = StatusCancelled
+			status.FilledQuantity = 0
+			status.Reason = fmt.Sprintf("fill or kill: only %g of %g available", b.liquidity, order.Quantity)
+			return status, nil
+		case "ioc":
+			status.Status = StatusCancelled
+			status.FilledQuantity = b.liquidity
+			status.Reason = fmt.Sprintf("immediate or cancel: %g of %g unfilled", order.Quantity-b.liquidity, order.Quantity)
+		}
+	}
+
+	now := time.Now()
+	status.FilledAt = &now
+	return status, nil
+}
+
+// GetOrder implements Broker. Paper orders are filled on submission and not
+// kept, so there is never an open order to look up.
+func (b *PaperBroker) GetOrder(ctx context.Context, brokerOrderID string) (*OrderStatus, error) {
+	return nil, fmt.Errorf("order not found: %s", brokerOrderID)
+}
+
+// CancelOrder implements Broker
+func (b *PaperBroker) CancelOrder(ctx context.Context, brokerOrderID string) error {
+	return fmt.Errorf("order not found: %s", brokerOrderID)
+}
@@ -0,0 +1,87 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// StatementClient reads positions and executions from a broker's statements
+type StatementClient interface {
+	GetPositions(ctx context.Context, accountID string) ([]models.BrokerPosition, error)
+	GetTransactions(ctx context.Context, accountID string, start, end time.Time) ([]models.BrokerTransaction, error)
+}
+
+// HTTPStatementClient reads statements from a broker REST API exposing
+// /accounts/{id}/positions and /accounts/{id}/transactions
+type HTTPStatementClient struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func NewHTTPStatementClient(baseURL, apiKey string) *HTTPStatementClient {
+	return &HTTPStatementClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetPositions implements StatementClient
+func (c *HTTPStatementClient) GetPositions(ctx context.Context, accountID string) ([]models.BrokerPosition, error) {
+	var positions []models.BrokerPosition
+	path := fmt.Sprintf("/accounts/%s/positions", url.PathEscape(accountID))
+	if err := c.get(ctx, path, nil, &positions); err != nil {
+		return nil, fmt.Errorf("failed to get broker positions: %w", err)
+	}
+	return positions, nil
+}
+
+// GetTransactions implements StatementClient
+func (c *HTTPStatementClient) GetTransactions(ctx context.Context, accountID string, start, end time.Time) ([]models.BrokerTransaction, error) {
+	var transactions []models.BrokerTransaction
+	path := fmt.Sprintf("/accounts/%s/transactions", url.PathEscape(accountID))
+	query := url.Values{
+		"start": {start.UTC().Format(time.RFC3339)},
+		"end":   {end.UTC().Format(time.RFC3339)},
+	}
+	if err := c.get(ctx, path, query, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to get broker transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+func (c *HTTPStatementClient) get(ctx context.Context, path string, query url.Values, dest interface{}) error {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("broker returned status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
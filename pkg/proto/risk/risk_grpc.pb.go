@@ -0,0 +1,146 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: risk/risk.proto
+
+package riskpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	RiskService_RunBacktest_FullMethodName     = "/hedgefund.risk.v1.RiskService/RunBacktest"
+	RiskService_GetOptimization_FullMethodName = "/hedgefund.risk.v1.RiskService/GetOptimization"
+)
+
+// RiskServiceClient is the client API for RiskService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RiskServiceClient interface {
+	RunBacktest(ctx context.Context, in *RunBacktestRequest, opts ...grpc.CallOption) (*BacktestResult, error)
+	GetOptimization(ctx context.Context, in *GetOptimizationRequest, opts ...grpc.CallOption) (*Optimization, error)
+}
+
+type riskServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRiskServiceClient(cc grpc.ClientConnInterface) RiskServiceClient {
+	return &riskServiceClient{cc}
+}
+
+func (c *riskServiceClient) RunBacktest(ctx context.Context, in *RunBacktestRequest, opts ...grpc.CallOption) (*BacktestResult, error) {
+	out := new(BacktestResult)
+	err := c.cc.Invoke(ctx, RiskService_RunBacktest_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *riskServiceClient) GetOptimization(ctx context.Context, in *GetOptimizationRequest, opts ...grpc.CallOption) (*Optimization, error) {
+	out := new(Optimization)
+	err := c.cc.Invoke(ctx, RiskService_GetOptimization_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RiskServiceServer is the server API for RiskService service.
+// All implementations must embed UnimplementedRiskServiceServer
+// for forward compatibility
+type RiskServiceServer interface {
+	RunBacktest(context.Context, *RunBacktestRequest) (*BacktestResult, error)
+	GetOptimization(context.Context, *GetOptimizationRequest) (*Optimization, error)
+	mustEmbedUnimplementedRiskServiceServer()
+}
+
+// UnimplementedRiskServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedRiskServiceServer struct {
+}
+
+func (UnimplementedRiskServiceServer) RunBacktest(context.Context, *RunBacktestRequest) (*BacktestResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunBacktest not implemented")
+}
+func (UnimplementedRiskServiceServer) GetOptimization(context.Context, *GetOptimizationRequest) (*Optimization, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOptimization not implemented")
+}
+func (UnimplementedRiskServiceServer) mustEmbedUnimplementedRiskServiceServer() {}
+
+// UnsafeRiskServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RiskServiceServer will
+// result in compilation errors.
+type UnsafeRiskServiceServer interface {
+	mustEmbedUnimplementedRiskServiceServer()
+}
+
+func RegisterRiskServiceServer(s grpc.ServiceRegistrar, srv RiskServiceServer) {
+	s.RegisterService(&RiskService_ServiceDesc, srv)
+}
+
+func _RiskService_RunBacktest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunBacktestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RiskServiceServer).RunBacktest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RiskService_RunBacktest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RiskServiceServer).RunBacktest(ctx, req.(*RunBacktestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RiskService_GetOptimization_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOptimizationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RiskServiceServer).GetOptimization(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RiskService_GetOptimization_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RiskServiceServer).GetOptimization(ctx, req.(*GetOptimizationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RiskService_ServiceDesc is the grpc.ServiceDesc for RiskService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RiskService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "hedgefund.risk.v1.RiskService",
+	HandlerType: (*RiskServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RunBacktest",
+			Handler:    _RiskService_RunBacktest_Handler,
+		},
+		{
+			MethodName: "GetOptimization",
+			Handler:    _RiskService_GetOptimization_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "risk/risk.proto",
+}
@@ -0,0 +1,126 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+// Business events emitted for product analytics
+const (
+	EventPortfolioCreated  = "portfolio_created"
+	EventTradeExecuted     = "trade_executed"
+	EventAnalysisRequested = "analysis_requested"
+)
+
+// How long a sink may take to accept one event
+const emitTimeout = 5 * time.Second
+
+// Event is a product usage event. UserID is the owner of the affected
+// resource; Actor is whoever made the request, which differs for system jobs.
+type Event struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	UserID     int                    `json:"user_id"`
+	Actor      string                 `json:"actor"`
+	Feature    string                 `json:"feature"`
+	Service    string                 `json:"service"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+	Timestamp  time.Time              `json:"timestamp"`
+}
+
+// Sink delivers events to an analytics pipeline
+type Sink interface {
+	Emit(ctx context.Context, event Event) error
+}
+
+// Tracker emits events to a sink in the background so tracking never slows
+// down or fails the request that triggered it. When the buffer is full new
+// events are dropped. A nil Tracker discards events.
+type Tracker struct {
+	sink    Sink
+	service string
+	logger  *zap.Logger
+
+	mu     sync.Mutex
+	closed bool
+	events chan Event
+	done   chan struct{}
+}
+
+// NewTracker creates a tracker for a service and starts delivering to sink
+func NewTracker(sink Sink, service string, bufferSize int, logger *zap.Logger) *Tracker {
+	t := &Tracker{
+		sink:    sink,
+		service: service,
+		logger:  logger,
+		events:  make(chan Event, bufferSize),
+		done:    make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// Track records a business event with the request metadata carried by ctx
+func (t *Tracker) Track(ctx context.Context, name string, userID int, feature string, properties map[string]interface{}) {
+	if t == nil {
+		return
+	}
+
+	event := Event{
+		ID:         uuid.New().String(),
+		Name:       name,
+		UserID:     userID,
+		Actor:      requestctx.Actor(ctx),
+		Feature:    feature,
+		Service:    t.service,
+		RequestID:  requestctx.RequestID(ctx),
+		Properties: properties,
+		Timestamp:  time.Now().UTC(),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	select {
+	case t.events <- event:
+	default:
+		t.logger.Warn("Analytics buffer full, dropping event", zap.String("event", name))
+	}
+}
+
+// Close delivers the buffered events and stops the tracker
+func (t *Tracker) Close() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.events)
+	}
+	t.mu.Unlock()
+	<-t.done
+}
+
+func (t *Tracker) run() {
+	defer close(t.done)
+	for event := range t.events {
+		ctx, cancel := context.WithTimeout(context.Background(), emitTimeout)
+		if err := t.sink.Emit(ctx, event); err != nil {
+			t.logger.Warn("Failed to emit analytics event",
+				zap.Error(err),
+				zap.String("event", event.Name),
+				zap.String("event_id", event.ID))
+		}
+		cancel()
+	}
+}
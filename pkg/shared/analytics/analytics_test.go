@@ -0,0 +1,49 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *recordingSink) Emit(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestTrackerDeliversEventsWithRequestMetadata(t *testing.T) {
+	sink := &recordingSink{}
+	tracker := NewTracker(sink, "portfolio", 10, zap.NewNop())
+
+	ctx := requestctx.WithActor(requestctx.WithRequestID(context.Background(), "req-1"), "42")
+	tracker.Track(ctx, EventPortfolioCreated, 42, "portfolios", map[string]interface{}{"portfolio_id": 7})
+	tracker.Close()
+	tracker.Track(ctx, EventTradeExecuted, 42, "trading", nil) // Dropped after Close
+
+	require.Len(t, sink.events, 1)
+	event := sink.events[0]
+	assert.Equal(t, EventPortfolioCreated, event.Name)
+	assert.Equal(t, 42, event.UserID)
+	assert.Equal(t, "42", event.Actor)
+	assert.Equal(t, "req-1", event.RequestID)
+	assert.Equal(t, "portfolio", event.Service)
+	assert.NotEmpty(t, event.ID)
+}
+
+func TestNilTrackerDiscardsEvents(t *testing.T) {
+	var tracker *Tracker
+	tracker.Track(context.Background(), EventAnalysisRequested, 1, "risk", nil)
+	tracker.Close()
+}
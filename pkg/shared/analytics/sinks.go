@@ -0,0 +1,172 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// Sink names accepted by ANALYTICS_SINK
+const (
+	SinkLog     = "log"
+	SinkRedis   = "redis"
+	SinkSegment = "segment"
+	SinkNone    = "none"
+)
+
+// streamMaxLen caps the analytics stream; consumers are expected to keep up
+const streamMaxLen = 100000
+
+// NewSink creates the sink selected by configuration. It returns nil for
+// SinkNone, which disables tracking.
+func NewSink(cfg *config.Config, redisClient *redis.Client, logger *zap.Logger) (Sink, error) {
+	switch cfg.AnalyticsSink {
+	case SinkLog, "":
+		return NewLogSink(logger), nil
+	case SinkRedis:
+		return NewRedisStreamSink(redisClient, cfg.AnalyticsStream), nil
+	case SinkSegment:
+		if cfg.AnalyticsWriteKey == "" {
+			return nil, fmt.Errorf("ANALYTICS_WRITE_KEY is required for the segment sink")
+		}
+		return NewSegmentSink(cfg.AnalyticsSegmentURL, cfg.AnalyticsWriteKey), nil
+	case SinkNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown analytics sink: %s", cfg.AnalyticsSink)
+	}
+}
+
+// LogSink writes events to the service log
+type LogSink struct {
+	logger *zap.Logger
+}
+
+func NewLogSink(logger *zap.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+func (s *LogSink) Emit(ctx context.Context, event Event) error {
+	s.logger.Info("Analytics event",
+		zap.String("event", event.Name),
+		zap.String("event_id", event.ID),
+		zap.Int("user_id", event.UserID),
+		zap.String("actor", event.Actor),
+		zap.String("feature", event.Feature),
+		zap.String("service", event.Service),
+		zap.String("request_id", event.RequestID),
+		zap.Any("properties", event.Properties))
+	return nil
+}
+
+// RedisStreamSink appends events to a Redis stream for downstream consumers
+type RedisStreamSink struct {
+	redis  *redis.Client
+	stream string
+}
+
+func NewRedisStreamSink(redisClient *redis.Client, stream string) *RedisStreamSink {
+	return &RedisStreamSink{redis: redisClient, stream: stream}
+}
+
+func (s *RedisStreamSink) Emit(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = s.redis.XAdd(ctx, &goredis.XAddArgs{
+		Stream: s.stream,
+		MaxLen: streamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"name": event.Name, "event": data},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+	return nil
+}
+
+// SegmentSink posts events to a Segment-compatible HTTP tracking API
+type SegmentSink struct {
+	url      string
+	writeKey string
+	client   *http.Client
+}
+
+func NewSegmentSink(url, writeKey string) *SegmentSink {
+	return &SegmentSink{
+		url:      url,
+		writeKey: writeKey,
+		client:   &http.Client{Timeout: emitTimeout},
+	}
+}
+
+// segmentTrack is the body of a Segment track call
+type segmentTrack struct {
+	MessageID   string                 `json:"messageId"`
+	UserID      string                 `json:"userId,omitempty"`
+	AnonymousID string                 `json:"anonymousId,omitempty"`
+	Event       string                 `json:"event"`
+	Properties  map[string]interface{} `json:"properties"`
+	Context     map[string]interface{} `json:"context"`
+	Timestamp   time.Time              `json:"timestamp"`
+}
+
+func (s *SegmentSink) Emit(ctx context.Context, event Event) error {
+	properties := map[string]interface{}{"feature": event.Feature}
+	for key, value := range event.Properties {
+		properties[key] = value
+	}
+
+	body := segmentTrack{
+		MessageID:  event.ID,
+		Event:      event.Name,
+		Properties: properties,
+		Context: map[string]interface{}{
+			"service":    event.Service,
+			"actor":      event.Actor,
+			"request_id": event.RequestID,
+		},
+		Timestamp: event.Timestamp,
+	}
+	if event.UserID != 0 {
+		body.UserID = strconv.Itoa(event.UserID)
+	} else {
+		body.AnonymousID = event.Actor // Segment requires one of the two
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(s.writeKey, "")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
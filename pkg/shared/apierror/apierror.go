@@ -0,0 +1,115 @@
+package apierror
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+// Error codes. Clients branch on these rather than on messages, which are
+// for people and may change.
+const (
+	// Generic codes, used when an error has no more specific one
+	CodeInvalidRequest     = "INVALID_REQUEST"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeForbidden          = "FORBIDDEN"
+	CodeNotFound           = "NOT_FOUND"
+	CodeConflict           = "CONFLICT"
+	CodeUnprocessable      = "UNPROCESSABLE"
+	CodeRateLimited        = "RATE_LIMITED"
+	CodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+	CodeInternal           = "INTERNAL_ERROR"
+
+	CodeMaintenance     = "MAINTENANCE_MODE"
+	CodeFeatureDisabled = "FEATURE_DISABLED"
+
+	// Authentication
+	CodeTwoFactorRequired   = "TWO_FACTOR_REQUIRED"   // Send a code or trusted-device token
+	CodeTwoFactorEnrollment = "TWO_FACTOR_ENROLLMENT" // Enroll in two-factor authentication first
+
+	// Portfolio service
+	CodePortfolioNotFound   = "PORTFOLIO_NOT_FOUND"
+	CodePositionNotFound    = "POSITION_NOT_FOUND"
+	CodeInvalidOrder        = "INVALID_ORDER"
+	CodeInsufficientFunds   = "INSUFFICIENT_FUNDS"
+	CodeInsufficientShares  = "INSUFFICIENT_SHARES"
+	CodeInsufficientHistory = "INSUFFICIENT_HISTORY"
+	CodeSlippageExceeded    = "SLIPPAGE_EXCEEDED"
+	CodeVersionConflict     = "VERSION_CONFLICT"
+	CodeCompetitionRule     = "COMPETITION_RULE_VIOLATION"
+	CodePositionLimit       = "POSITION_LIMIT_EXCEEDED"
+	CodeOrderNotFilled      = "ORDER_NOT_FILLED"
+	CodeLiveRebalance       = "LIVE_REBALANCE_UNSUPPORTED"
+	CodeReportNotReady      = "REPORT_NOT_READY"
+
+	// AI service
+	CodeKillSwitchEngaged = "KILL_SWITCH_ENGAGED"
+	CodeOrderNotPending   = "ORDER_NOT_PENDING"
+)
+
+// errorCodeKey is the gin context key under which Respond records the code,
+// for request logging
+const errorCodeKey = "error_code"
+
+// Response is the error envelope every service returns
+type Response struct {
+	Code      string `json:"code"`                 // Machine-readable, e.g. PORTFOLIO_NOT_FOUND
+	Error     string `json:"error"`                // Human-readable summary
+	Details   string `json:"details,omitempty"`    // Underlying cause
+	RequestID string `json:"request_id,omitempty"` // For matching the response to service logs
+}
+
+// Respond writes resp with status. An empty code is filled in from the
+// status, and the request ID from the request context.
+func Respond(c *gin.Context, status int, resp Response) {
+	c.JSON(status, complete(c, status, resp))
+}
+
+// Abort writes resp like Respond and stops the handler chain
+func Abort(c *gin.Context, status int, resp Response) {
+	c.AbortWithStatusJSON(status, complete(c, status, resp))
+}
+
+func complete(c *gin.Context, status int, resp Response) Response {
+	if resp.Code == "" {
+		resp.Code = CodeForStatus(status)
+	}
+	if resp.RequestID == "" {
+		resp.RequestID = requestctx.RequestID(c.Request.Context())
+	}
+	c.Set(errorCodeKey, resp.Code)
+	return resp
+}
+
+// CodeForStatus returns the generic code for an HTTP error status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeInvalidRequest
+}
+
+// ResponseCode returns the code of the error response written for c, or ""
+// if none was
+func ResponseCode(c *gin.Context) string {
+	return c.GetString(errorCodeKey)
+}
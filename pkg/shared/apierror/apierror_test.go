@@ -0,0 +1,52 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+func TestRespondFillsCodeAndRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(requestctx.Middleware())
+	r.GET("/missing", func(c *gin.Context) {
+		Respond(c, http.StatusNotFound, Response{Error: "Portfolio not found", Details: "portfolio not found: 7"})
+	})
+	r.POST("/trades", func(c *gin.Context) {
+		Respond(c, http.StatusUnprocessableEntity, Response{Code: CodeInsufficientFunds, Error: "Failed to execute trade"})
+		assert.Equal(t, CodeInsufficientFunds, ResponseCode(c))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.Header.Set(requestctx.HeaderRequestID, "req-42")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, Response{Code: CodeNotFound, Error: "Portfolio not found", Details: "portfolio not found: 7", RequestID: "req-42"}, resp)
+	assert.Equal(t, "req-42", w.Header().Get(requestctx.HeaderRequestID))
+
+	// A specific code is kept, and a request ID is generated when none is sent
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/trades", nil))
+	resp = Response{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, CodeInsufficientFunds, resp.Code)
+	assert.NotEmpty(t, resp.RequestID)
+	assert.Equal(t, resp.RequestID, w.Header().Get(requestctx.HeaderRequestID))
+}
+
+func TestCodeForStatus(t *testing.T) {
+	assert.Equal(t, CodeInvalidRequest, CodeForStatus(http.StatusBadRequest))
+	assert.Equal(t, CodeConflict, CodeForStatus(http.StatusConflict))
+	assert.Equal(t, CodeServiceUnavailable, CodeForStatus(http.StatusServiceUnavailable))
+	assert.Equal(t, CodeInternal, CodeForStatus(http.StatusBadGateway))
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+)
+
+var (
+	// ErrUnknownUser is returned for user IDs with no user
+	ErrUnknownUser = errors.New("unknown user")
+
+	// ErrInactiveUser is returned for deactivated users
+	ErrInactiveUser = errors.New("user is inactive")
+
+	// ErrPortfolioNotFound is returned for portfolio IDs with no portfolio
+	ErrPortfolioNotFound = errors.New("portfolio not found")
+)
+
+type contextKey string
+
+const identityKey contextKey = "identity"
+
+// Identity is the user a request acts as
+type Identity struct {
+	UserID    int    `json:"user_id"`
+	Role      string `json:"role"`
+	SessionID string `json:"session_id,omitempty"` // Login session the request authenticated with, if any
+}
+
+// IsAdmin reports whether the user may access every portfolio and the admin
+// endpoints
+func (id Identity) IsAdmin() bool {
+	return id.Role == models.RoleAdmin
+}
+
+// CanTrade reports whether the user may change portfolios they can access
+func (id Identity) CanTrade() bool {
+	return id.Role == models.RoleAdmin || id.Role == models.RoleTrader
+}
+
+// CanActFor reports whether the user may access resources owned by userID
+func (id Identity) CanActFor(userID int) bool {
+	return id.IsAdmin() || id.UserID == userID
+}
+
+// WithIdentity returns a copy of ctx carrying the request's identity
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey, id)
+}
+
+// FromContext returns the identity carried by ctx. Requests that did not
+// pass through Authenticate, such as internal calls, carry none.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey).(Identity)
+	return id, ok
+}
+
+// CanAccess reports whether ctx's identity may access resources owned by
+// userID. Contexts without an identity, such as internal calls and jobs, may
+// access every user's.
+func CanAccess(ctx context.Context, userID int) bool {
+	id, ok := FromContext(ctx)
+	return !ok || id.CanActFor(userID)
+}
+
+// Credentials are what a user logs in with
+type Credentials struct {
+	UserID       int
+	PasswordHash string // bcrypt
+	Active       bool
+}
+
+// Store resolves users' roles, credentials and portfolios' owners
+type Store interface {
+	UserRole(ctx context.Context, userID int) (string, error)
+	PortfolioOwner(ctx context.Context, portfolioID int) (int, error)
+	UserCredentials(ctx context.Context, username string) (Credentials, error)
+	PasswordHash(ctx context.Context, userID int) (string, error)
+	SetPasswordHash(ctx context.Context, userID int, hash string) error
+}
+
+type dbStore struct {
+	db *database.DB
+}
+
+func (s *dbStore) UserRole(ctx context.Context, userID int) (string, error) {
+	var role string
+	var active bool
+	query := `SELECT COALESCE(role, ''), COALESCE(is_active, false) FROM users WHERE id = $1`
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&role, &active)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("%w: %d", ErrUnknownUser, userID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user role: %w", err)
+	}
+	if !active {
+		return "", fmt.Errorf("%w: %d", ErrInactiveUser, userID)
+	}
+	return role, nil
+}
+
+func (s *dbStore) PortfolioOwner(ctx context.Context, portfolioID int) (int, error) {
+	var userID int
+	query := `SELECT user_id FROM portfolios WHERE id = $1`
+	err := s.db.QueryRowContext(ctx, query, portfolioID).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%w: %d", ErrPortfolioNotFound, portfolioID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get portfolio owner: %w", err)
+	}
+	return userID, nil
+}
+
+func (s *dbStore) UserCredentials(ctx context.Context, username string) (Credentials, error) {
+	var creds Credentials
+	query := `SELECT id, password_hash, COALESCE(is_active, false) FROM users WHERE username = $1`
+	err := s.db.QueryRowContext(ctx, query, username).Scan(&creds.UserID, &creds.PasswordHash, &creds.Active)
+	if err == sql.ErrNoRows {
+		return Credentials{}, fmt.Errorf("%w: %s", ErrUnknownUser, username)
+	}
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to get user credentials: %w", err)
+	}
+	return creds, nil
+}
+
+func (s *dbStore) PasswordHash(ctx context.Context, userID int) (string, error) {
+	var hash string
+	query := `SELECT password_hash FROM users WHERE id = $1`
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("%w: %d", ErrUnknownUser, userID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get password hash: %w", err)
+	}
+	return hash, nil
+}
+
+func (s *dbStore) SetPasswordHash(ctx context.Context, userID int, hash string) error {
+	query := `UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id = $1`
+	result, err := s.db.ExecContext(ctx, query, userID, hash)
+	if err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownUser, userID)
+	}
+	return nil
+}
+
+// HeaderServiceToken carries the shared secret of service-to-service calls,
+// which may name their acting user with X-User-ID
+const HeaderServiceToken = "X-Service-Token"
+
+// Authorizer authenticates requests by their login session, or by the
+// X-User-ID header of service calls, and enforces roles and portfolio
+// ownership on routes
+type Authorizer struct {
+	store        Store
+	sessions     *Sessions
+	twoFactor    *TwoFactor
+	serviceToken string
+	logger       *zap.Logger
+}
+
+// NewAuthorizer creates an authorizer over the users and portfolios tables
+func NewAuthorizer(db *database.DB, logger *zap.Logger) *Authorizer {
+	return newAuthorizer(&dbStore{db: db}, logger)
+}
+
+func newAuthorizer(store Store, logger *zap.Logger) *Authorizer {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Authorizer{store: store, logger: logger}
+}
+
+// SetSessions enables login sessions: requests may then authenticate with a
+// session's bearer token, and the login and session endpoints are served
+func (a *Authorizer) SetSessions(sessions *Sessions) {
+	a.sessions = sessions
+}
+
+// SetServiceToken sets the shared secret that authenticates service calls.
+// Only requests carrying it in X-Service-Token may act as the user named by
+// X-User-ID; with no secret set the header is never accepted.
+func (a *Authorizer) SetServiceToken(token string) {
+	a.serviceToken = token
+}
+
+// serviceCall reports whether token, sent in X-Service-Token, is the service
+// token
+func (a *Authorizer) serviceCall(token string) bool {
+	return a.serviceToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.serviceToken)) == 1
+}
+
+// Identify resolves a user's identity, for requests and calls that name the
+// user they act as. Roles other than admin and trader, such as the former
+// analyst role, are read as viewer so unknown roles get the least access.
+func (a *Authorizer) Identify(ctx context.Context, userID int) (Identity, error) {
+	role, err := a.store.UserRole(ctx, userID)
+	if err != nil {
+		return Identity{}, err
+	}
+	if role != models.RoleAdmin && role != models.RoleTrader {
+		role = models.RoleViewer
+	}
+	return Identity{UserID: userID, Role: role}, nil
+}
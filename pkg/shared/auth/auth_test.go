@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+// stubStore has users 1 (admin), 2 (trader), 3 (viewer), 4 (legacy analyst)
+// and 5 (inactive); users 2, 3 and 4 own portfolios 20, 30 and 40
+type stubStore struct{}
+
+func (stubStore) UserRole(ctx context.Context, userID int) (string, error) {
+	switch userID {
+	case 1:
+		return models.RoleAdmin, nil
+	case 2:
+		return models.RoleTrader, nil
+	case 3:
+		return models.RoleViewer, nil
+	case 4:
+		return "analyst", nil
+	case 5:
+		return "", fmt.Errorf("%w: %d", ErrInactiveUser, userID)
+	}
+	return "", fmt.Errorf("%w: %d", ErrUnknownUser, userID)
+}
+
+func (stubStore) PortfolioOwner(ctx context.Context, portfolioID int) (int, error) {
+	switch portfolioID {
+	case 20:
+		return 2, nil
+	case 30:
+		return 3, nil
+	case 40:
+		return 4, nil
+	}
+	return 0, fmt.Errorf("%w: %d", ErrPortfolioNotFound, portfolioID)
+}
+
+// testPassword is every stub user's password
+const testPassword = "correct-horse"
+
+var testPasswordHash, _ = bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+
+// UserCredentials knows users 2 as "trader" and 5 as "inactive"
+func (stubStore) UserCredentials(ctx context.Context, username string) (Credentials, error) {
+	switch username {
+	case "trader":
+		return Credentials{UserID: 2, PasswordHash: string(testPasswordHash), Active: true}, nil
+	case "inactive":
+		return Credentials{UserID: 5, PasswordHash: string(testPasswordHash)}, nil
+	}
+	return Credentials{}, fmt.Errorf("%w: %s", ErrUnknownUser, username)
+}
+
+func (stubStore) PasswordHash(ctx context.Context, userID int) (string, error) {
+	if userID > 5 {
+		return "", fmt.Errorf("%w: %d", ErrUnknownUser, userID)
+	}
+	return string(testPasswordHash), nil
+}
+
+func (stubStore) SetPasswordHash(ctx context.Context, userID int, hash string) error {
+	if userID > 5 {
+		return fmt.Errorf("%w: %d", ErrUnknownUser, userID)
+	}
+	return nil
+}
+
+const testServiceToken = "service-secret"
+
+func testRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	a := newAuthorizer(stubStore{}, nil)
+	a.SetServiceToken(testServiceToken)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	r := gin.New()
+	v1 := r.Group("/api/v1", a.Authenticate())
+	owner := a.RequirePortfolioOwner("id")
+	trader := a.RequireRole(models.RoleTrader, models.RoleAdmin)
+	v1.GET("/portfolios/:id", owner, ok)
+	v1.POST("/portfolios/:id/trades", owner, trader, ok)
+	v1.GET("/users/:user_id/overview", a.RequireSelf("user_id"), ok)
+	v1.POST("/admin/provision", a.RequireRole(models.RoleAdmin), ok)
+	return r
+}
+
+// serve sends a service call acting as userID
+func serve(r *gin.Engine, method, path, userID string) int {
+	return serveAs(r, method, path, userID, testServiceToken)
+}
+
+func serveAs(r *gin.Engine, method, path, userID, serviceToken string) int {
+	req := httptest.NewRequest(method, path, nil)
+	if userID != "" {
+		req.Header.Set(requestctx.HeaderActor, userID)
+	}
+	if serviceToken != "" {
+		req.Header.Set(HeaderServiceToken, serviceToken)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAuthenticate(t *testing.T) {
+	r := testRouter()
+
+	assert.Equal(t, http.StatusUnauthorized, serve(r, http.MethodGet, "/api/v1/portfolios/20", ""))
+	assert.Equal(t, http.StatusUnauthorized, serve(r, http.MethodGet, "/api/v1/portfolios/20", "abc"))
+	assert.Equal(t, http.StatusUnauthorized, serve(r, http.MethodGet, "/api/v1/portfolios/20", "99"))
+	assert.Equal(t, http.StatusForbidden, serve(r, http.MethodGet, "/api/v1/portfolios/20", "5"))
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/api/v1/portfolios/20", "2"))
+
+	// X-User-ID is trusted only on service calls
+	assert.Equal(t, http.StatusUnauthorized, serveAs(r, http.MethodGet, "/api/v1/portfolios/20", "2", ""))
+	assert.Equal(t, http.StatusUnauthorized, serveAs(r, http.MethodGet, "/api/v1/portfolios/20", "2", "guess"))
+	assert.Equal(t, http.StatusUnauthorized, serveAs(r, http.MethodPost, "/api/v1/admin/provision", "1", ""))
+
+	// Without a service token set, no header is trusted
+	a := newAuthorizer(stubStore{}, nil)
+	closed := gin.New()
+	closed.GET("/api/v1/portfolios/:id", a.Authenticate(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	assert.Equal(t, http.StatusUnauthorized, serveAs(closed, http.MethodGet, "/api/v1/portfolios/20", "2", ""))
+}
+
+func TestRolesAndOwnership(t *testing.T) {
+	r := testRouter()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		user   string
+		want   int
+	}{
+		{"owner reads", http.MethodGet, "/api/v1/portfolios/30", "3", http.StatusOK},
+		{"other user's portfolio is hidden", http.MethodGet, "/api/v1/portfolios/30", "2", http.StatusNotFound},
+		{"missing portfolio", http.MethodGet, "/api/v1/portfolios/99", "2", http.StatusNotFound},
+		{"admin reads any portfolio", http.MethodGet, "/api/v1/portfolios/30", "1", http.StatusOK},
+		{"trader trades own portfolio", http.MethodPost, "/api/v1/portfolios/20/trades", "2", http.StatusOK},
+		{"trader cannot trade another's", http.MethodPost, "/api/v1/portfolios/30/trades", "2", http.StatusNotFound},
+		{"viewer cannot trade", http.MethodPost, "/api/v1/portfolios/30/trades", "3", http.StatusForbidden},
+		{"legacy role is a viewer", http.MethodPost, "/api/v1/portfolios/40/trades", "4", http.StatusForbidden},
+		{"own user resources", http.MethodGet, "/api/v1/users/3/overview", "3", http.StatusOK},
+		{"another user's resources", http.MethodGet, "/api/v1/users/2/overview", "3", http.StatusForbidden},
+		{"admin endpoint", http.MethodPost, "/api/v1/admin/provision", "1", http.StatusOK},
+		{"admin endpoint for trader", http.MethodPost, "/api/v1/admin/provision", "2", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, serve(r, tt.method, tt.path, tt.user))
+		})
+	}
+}
+
+func TestCanAccess(t *testing.T) {
+	ctx := context.Background()
+	assert.True(t, CanAccess(ctx, 7), "internal calls carry no identity")
+
+	viewer := WithIdentity(ctx, Identity{UserID: 3, Role: models.RoleViewer})
+	assert.True(t, CanAccess(viewer, 3))
+	assert.False(t, CanAccess(viewer, 2))
+
+	admin := WithIdentity(ctx, Identity{UserID: 1, Role: models.RoleAdmin})
+	assert.True(t, CanAccess(admin, 2))
+}
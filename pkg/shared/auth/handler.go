@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+// Authenticate resolves the acting user and their role: the user of the
+// login session named by a bearer token, or the user named by the X-User-ID
+// header of a service call carrying the service token. Requests with an
+// Authorization header must name a live session; the header is never
+// consulted for them. Requests without a session or service token, or not
+// of a known, active user, are rejected with 401 or 403.
+func (a *Authorizer) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		var userID int
+		var sessionID string
+		if authorization := c.GetHeader(requestctx.HeaderAuth); authorization != "" {
+			// Credentials, once sent, must hold: never fall back to the header
+			token := bearerToken(authorization)
+			if token == "" || a.sessions == nil {
+				apierror.Abort(c, http.StatusUnauthorized, apierror.Response{
+					Error:   "Authentication required",
+					Details: "invalid session token",
+				})
+				return
+			}
+			session, err := a.sessions.Resolve(ctx, token)
+			if errors.Is(err, ErrSessionNotFound) {
+				apierror.Abort(c, http.StatusUnauthorized, apierror.Response{
+					Error:   "Authentication required",
+					Details: "session expired or revoked",
+				})
+				return
+			}
+			if err != nil {
+				a.logger.Error("Failed to resolve session", zap.Error(err))
+				apierror.Abort(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to authenticate request"})
+				return
+			}
+			userID, sessionID = session.UserID, session.ID
+			ctx = requestctx.WithActor(ctx, strconv.Itoa(userID))
+		} else {
+			header := c.GetHeader(requestctx.HeaderActor)
+			if header == "" {
+				apierror.Abort(c, http.StatusUnauthorized, apierror.Response{
+					Error:   "Authentication required",
+					Details: "missing session token",
+				})
+				return
+			}
+			if !a.serviceCall(c.GetHeader(HeaderServiceToken)) {
+				a.logger.Warn("Rejected user header without service token",
+					zap.String("user_header", header), zap.String("client_ip", c.ClientIP()))
+				apierror.Abort(c, http.StatusUnauthorized, apierror.Response{
+					Error:   "Authentication required",
+					Details: fmt.Sprintf("%s is only accepted on service calls", requestctx.HeaderActor),
+				})
+				return
+			}
+			var err error
+			userID, err = strconv.Atoi(header)
+			if err != nil || userID <= 0 {
+				apierror.Abort(c, http.StatusUnauthorized, apierror.Response{
+					Error:   "Authentication required",
+					Details: fmt.Sprintf("invalid %s header", requestctx.HeaderActor),
+				})
+				return
+			}
+		}
+
+		identity, err := a.Identify(ctx, userID)
+		switch {
+		case errors.Is(err, ErrUnknownUser):
+			apierror.Abort(c, http.StatusUnauthorized, apierror.Response{Error: "Authentication required", Details: err.Error()})
+			return
+		case errors.Is(err, ErrInactiveUser):
+			apierror.Abort(c, http.StatusForbidden, apierror.Response{Error: "Forbidden", Details: err.Error()})
+			return
+		case err != nil:
+			a.logger.Error("Failed to authenticate request", zap.Error(err), zap.Int("user_id", userID))
+			apierror.Abort(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to authenticate request"})
+			return
+		}
+		identity.SessionID = sessionID
+
+		c.Request = c.Request.WithContext(WithIdentity(ctx, identity))
+		c.Next()
+	}
+}
+
+// bearerToken returns the token of an Authorization header of the Bearer
+// scheme, or ""
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// RequireRole admits only users with one of roles. It must run after
+// Authenticate.
+func (a *Authorizer) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := FromContext(c.Request.Context())
+		if !ok {
+			apierror.Abort(c, http.StatusUnauthorized, apierror.Response{Error: "Authentication required"})
+			return
+		}
+		for _, role := range roles {
+			if identity.Role == role {
+				c.Next()
+				return
+			}
+		}
+		apierror.Abort(c, http.StatusForbidden, apierror.Response{
+			Error:   "Forbidden",
+			Details: fmt.Sprintf("requires role %s", strings.Join(roles, " or ")),
+		})
+	}
+}
+
+// RequireSelf admits a user only to routes whose param names their own user
+// ID, or any user's for admins. Malformed IDs are left to the handler.
+func (a *Authorizer) RequireSelf(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := FromContext(c.Request.Context())
+		if !ok {
+			apierror.Abort(c, http.StatusUnauthorized, apierror.Response{Error: "Authentication required"})
+			return
+		}
+		userID, err := strconv.Atoi(c.Param(param))
+		if err != nil || identity.CanActFor(userID) {
+			c.Next()
+			return
+		}
+		apierror.Abort(c, http.StatusForbidden, apierror.Response{
+			Error:   "Forbidden",
+			Details: "cannot access another user's resources",
+		})
+	}
+}
+
+// RequirePortfolioOwner admits a user only to routes whose param names one
+// of their own portfolios, or any portfolio for admins. Other users'
+// portfolios are answered as not found, so portfolio IDs cannot be probed
+// for existence. Malformed IDs are left to the handler.
+func (a *Authorizer) RequirePortfolioOwner(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := FromContext(c.Request.Context())
+		if !ok {
+			apierror.Abort(c, http.StatusUnauthorized, apierror.Response{Error: "Authentication required"})
+			return
+		}
+		portfolioID, err := strconv.Atoi(c.Param(param))
+		if err != nil || identity.IsAdmin() {
+			c.Next()
+			return
+		}
+
+		owner, err := a.store.PortfolioOwner(c.Request.Context(), portfolioID)
+		if err != nil && !errors.Is(err, ErrPortfolioNotFound) {
+			a.logger.Error("Failed to authorize portfolio access", zap.Error(err), zap.Int("portfolio_id", portfolioID))
+			apierror.Abort(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to authorize request"})
+			return
+		}
+		if err != nil || owner != identity.UserID {
+			apierror.Abort(c, http.StatusNotFound, apierror.Response{
+				Code:    apierror.CodePortfolioNotFound,
+				Error:   "Portfolio not found",
+				Details: fmt.Sprintf("portfolio not found: %d", portfolioID),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// userParam parses the user ID of the request, responding with an error
+// when it is invalid
+func userParam(c *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid user ID"})
+		return 0, false
+	}
+	return userID, true
+}
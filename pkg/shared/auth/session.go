@@ -0,0 +1,321 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// Login sessions
+//
+// Logging in creates a session in Redis and returns its bearer token,
+// "<session ID>.<secret>". Only the secret's SHA-256 is stored, so sessions
+// can be listed and revoked by ID without exposing their tokens. Each
+// request extends a session by its idle TTL, up to a maximum lifetime from
+// login. A user holds a limited number of sessions: logging in past the
+// limit ends the least recently used.
+
+const (
+	// userSessionsPrefix prefixes the set of each user's session IDs
+	userSessionsPrefix = "user_sessions:"
+
+	// touchInterval is how long a session may go without its last use being
+	// recorded, so not every request writes to Redis
+	touchInterval = time.Minute
+)
+
+// ErrSessionNotFound is returned for unknown, expired or revoked sessions
+// and for tokens that do not match their session
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session is a user's login
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int       `json:"user_id"`
+	SecretHash string    `json:"secret_hash"` // Hex SHA-256 of the token's secret
+	IPAddress  string    `json:"ip_address,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// sessionStore stores sessions and indexes them by user
+type sessionStore interface {
+	// Save stores a new session until it expires
+	Save(ctx context.Context, session *Session) error
+	// Touch replaces a stored session, reporting whether it was still stored
+	Touch(ctx context.Context, session *Session) (bool, error)
+	// Get returns a session, or ErrSessionNotFound
+	Get(ctx context.Context, sessionID string) (*Session, error)
+	// Delete removes a user's session
+	Delete(ctx context.Context, userID int, sessionID string) error
+	// SessionIDs returns the IDs of a user's sessions, some of which may
+	// have expired
+	SessionIDs(ctx context.Context, userID int) ([]string, error)
+}
+
+type redisSessionStore struct {
+	client      *redis.Client
+	maxLifetime time.Duration
+}
+
+func userSessionsKey(userID int) string {
+	return userSessionsPrefix + strconv.Itoa(userID)
+}
+
+func (s *redisSessionStore) Save(ctx context.Context, session *Session) error {
+	if err := s.client.SetSession(ctx, session.ID, session, time.Until(session.ExpiresAt)); err != nil {
+		return err
+	}
+
+	// The index outlives every session in it, as each is younger than the
+	// newest login's maximum lifetime
+	key := userSessionsKey(session.UserID)
+	pipe := s.client.TxPipeline()
+	pipe.SAdd(ctx, key, session.ID)
+	pipe.Expire(ctx, key, s.maxLifetime)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to index session: %w", err)
+	}
+	return nil
+}
+
+func (s *redisSessionStore) Touch(ctx context.Context, session *Session) (bool, error) {
+	return s.client.TouchSession(ctx, session.ID, session, time.Until(session.ExpiresAt))
+}
+
+func (s *redisSessionStore) Get(ctx context.Context, sessionID string) (*Session, error) {
+	var session Session
+	err := s.client.GetSession(ctx, sessionID, &session)
+	if errors.Is(err, redis.ErrCacheMiss) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *redisSessionStore) Delete(ctx context.Context, userID int, sessionID string) error {
+	if err := s.client.DeleteSession(ctx, sessionID); err != nil {
+		return err
+	}
+	if err := s.client.SRem(ctx, userSessionsKey(userID), sessionID).Err(); err != nil {
+		return fmt.Errorf("failed to unindex session: %w", err)
+	}
+	return nil
+}
+
+func (s *redisSessionStore) SessionIDs(ctx context.Context, userID int) ([]string, error) {
+	ids, err := s.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+	return ids, nil
+}
+
+// Sessions issues, resolves and revokes login sessions
+type Sessions struct {
+	store       sessionStore
+	ttl         time.Duration
+	maxLifetime time.Duration
+	maxPerUser  int
+	now         func() time.Time
+}
+
+// NewSessions creates a session manager storing sessions in Redis, with
+// the TTL, lifetime and per-user limit of cfg
+func NewSessions(client *redis.Client, cfg *config.Config) *Sessions {
+	maxLifetime := time.Duration(cfg.SessionMaxLifetime) * time.Second
+	return newSessions(&redisSessionStore{client: client, maxLifetime: maxLifetime},
+		time.Duration(cfg.SessionTTL)*time.Second, maxLifetime, cfg.SessionMaxPerUser)
+}
+
+func newSessions(store sessionStore, ttl, maxLifetime time.Duration, maxPerUser int) *Sessions {
+	if maxLifetime < ttl {
+		maxLifetime = ttl
+	}
+	return &Sessions{store: store, ttl: ttl, maxLifetime: maxLifetime, maxPerUser: maxPerUser, now: time.Now}
+}
+
+// expiry returns when session expires if used at now: one TTL later, but
+// no later than its maximum lifetime
+func (s *Sessions) expiry(session *Session, now time.Time) time.Time {
+	expires := now.Add(s.ttl)
+	if end := session.CreatedAt.Add(s.maxLifetime); end.Before(expires) {
+		return end
+	}
+	return expires
+}
+
+// Create starts a session for a user, returning its bearer token. If the
+// user then holds more sessions than allowed, the least recently used end.
+func (s *Sessions) Create(ctx context.Context, userID int, ipAddress, userAgent string) (string, *Session, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("failed to generate session secret: %w", err)
+	}
+
+	now := s.now()
+	session := &Session{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		SecretHash: hashSecret(hex.EncodeToString(secret)),
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	session.ExpiresAt = s.expiry(session, now)
+	if err := s.store.Save(ctx, session); err != nil {
+		return "", nil, err
+	}
+
+	if err := s.enforceLimit(ctx, userID, session.ID); err != nil {
+		return "", nil, err
+	}
+	return session.ID + "." + hex.EncodeToString(secret), session, nil
+}
+
+// enforceLimit ends a user's least recently used sessions, other than
+// keep, until they hold no more than maxPerUser
+func (s *Sessions) enforceLimit(ctx context.Context, userID int, keep string) error {
+	if s.maxPerUser <= 0 {
+		return nil
+	}
+	sessions, err := s.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Newest use first, so the excess is at the end
+	for i := len(sessions) - 1; i >= 0 && len(sessions) > s.maxPerUser; i-- {
+		if sessions[i].ID == keep {
+			continue
+		}
+		if err := s.store.Delete(ctx, userID, sessions[i].ID); err != nil {
+			return err
+		}
+		sessions = append(sessions[:i], sessions[i+1:]...)
+	}
+	return nil
+}
+
+// Resolve returns the session of a bearer token, extending it as it is
+// used. It returns ErrSessionNotFound for tokens of no live session.
+func (s *Sessions) Resolve(ctx context.Context, token string) (*Session, error) {
+	sessionID, secret, ok := strings.Cut(token, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return nil, ErrSessionNotFound
+	}
+
+	session, err := s.store.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(session.SecretHash)) != 1 {
+		return nil, ErrSessionNotFound
+	}
+
+	now := s.now()
+	if !now.Before(session.ExpiresAt) {
+		return nil, ErrSessionNotFound
+	}
+	if now.Sub(session.LastSeenAt) < touchInterval {
+		return session, nil
+	}
+
+	session.LastSeenAt = now
+	session.ExpiresAt = s.expiry(session, now)
+	touched, err := s.store.Touch(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	if !touched {
+		// Revoked while this request was resolving it
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// List returns a user's live sessions, most recently used first
+func (s *Sessions) List(ctx context.Context, userID int) ([]Session, error) {
+	ids, err := s.store.SessionIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(ids))
+	for _, id := range ids {
+		session, err := s.store.Get(ctx, id)
+		if errors.Is(err, ErrSessionNotFound) {
+			// Expired; drop it from the index
+			if err := s.store.Delete(ctx, userID, id); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// Revoke ends a user's session. It returns ErrSessionNotFound if the user
+// holds no such session.
+func (s *Sessions) Revoke(ctx context.Context, userID int, sessionID string) error {
+	session, err := s.store.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return s.store.Delete(ctx, userID, sessionID)
+}
+
+// RevokeAll ends every session of a user except the one named by keep,
+// which may be empty, returning the number ended
+func (s *Sessions) RevokeAll(ctx context.Context, userID int, keep string) (int, error) {
+	sessions, err := s.List(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, session := range sessions {
+		if session.ID == keep {
+			continue
+		}
+		if err := s.store.Delete(ctx, userID, session.ID); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// hashSecret returns the hex SHA-256 of a session secret
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"hedge-fund/pkg/shared/apierror"
+)
+
+// LoginRequest is a user's credentials
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// LoginResponse is a new session and the bearer token authenticating it,
+// which is only returned here
+type LoginResponse struct {
+	Token   string          `json:"token"`
+	Session SessionResponse `json:"session"`
+}
+
+// SessionResponse is a login session, without its token
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // The session of the request
+}
+
+// ChangePasswordRequest sets a user's password. The current password is
+// required unless an admin sets another user's.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
+// ChangePasswordResponse reports the sessions ended by a password change
+type ChangePasswordResponse struct {
+	RevokedSessions int `json:"revoked_sessions"`
+}
+
+// Login godoc
+// @Summary Log in
+// @Description Start a login session with a username and password. Send the returned token as "Authorization: Bearer <token>"; each request extends the session, up to a maximum lifetime. Past the per-user session limit, the least recently used session ends.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body LoginRequest true "Credentials"
+// @Success 201 {object} LoginResponse
+// @Failure 400 {object} apierror.Response
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/auth/login [post]
+func (a *Authorizer) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	creds, err := a.store.UserCredentials(ctx, req.Username)
+	if err != nil && !errors.Is(err, ErrUnknownUser) {
+		a.logger.Error("Failed to get user credentials", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to log in"})
+		return
+	}
+	if err != nil {
+		// Spend as long as for a known user, so usernames cannot be probed
+		// by timing
+		creds.PasswordHash = unknownUserHash()
+	}
+	if bcrypt.CompareHashAndPassword([]byte(creds.PasswordHash), []byte(req.Password)) != nil || err != nil {
+		apierror.Respond(c, http.StatusUnauthorized, apierror.Response{Error: "Invalid username or password"})
+		return
+	}
+	if !creds.Active {
+		apierror.Respond(c, http.StatusForbidden, apierror.Response{Error: "Forbidden", Details: ErrInactiveUser.Error()})
+		return
+	}
+
+	token, session, err := a.sessions.Create(ctx, creds.UserID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		a.logger.Error("Failed to create session", zap.Error(err), zap.Int("user_id", creds.UserID))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to log in"})
+		return
+	}
+
+	a.logger.Info("User logged in", zap.Int("user_id", creds.UserID), zap.String("session_id", session.ID))
+	c.JSON(http.StatusCreated, LoginResponse{Token: token, Session: toSessionResponse(*session, session.ID)})
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description End the login session the request authenticated with
+// @Tags auth
+// @Success 204
+// @Failure 400 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/auth/logout [post]
+func (a *Authorizer) Logout(c *gin.Context) {
+	identity, _ := FromContext(c.Request.Context())
+	if identity.SessionID == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Request is not authenticated by a session"})
+		return
+	}
+
+	err := a.sessions.Revoke(c.Request.Context(), identity.UserID, identity.SessionID)
+	if err != nil && !errors.Is(err, ErrSessionNotFound) {
+		a.logger.Error("Failed to revoke session", zap.Error(err), zap.String("session_id", identity.SessionID))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to log out"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListSessions godoc
+// @Summary List a user's sessions
+// @Description Get a user's live login sessions, most recently used first, marking the one the request authenticated with
+// @Tags auth
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {array} SessionResponse
+// @Failure 400 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/users/{user_id}/sessions [get]
+func (a *Authorizer) ListSessions(c *gin.Context) {
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := a.sessions.List(c.Request.Context(), userID)
+	if err != nil {
+		a.logger.Error("Failed to list sessions", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to list sessions"})
+		return
+	}
+
+	identity, _ := FromContext(c.Request.Context())
+	response := make([]SessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = toSessionResponse(session, identity.SessionID)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description End one of a user's login sessions, e.g. on a lost device
+// @Tags auth
+// @Param user_id path int true "User ID"
+// @Param session_id path string true "Session ID"
+// @Success 204
+// @Failure 400 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/users/{user_id}/sessions/{session_id} [delete]
+func (a *Authorizer) RevokeSession(c *gin.Context) {
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+	sessionID := c.Param("session_id")
+
+	err := a.sessions.Revoke(c.Request.Context(), userID, sessionID)
+	if errors.Is(err, ErrSessionNotFound) {
+		apierror.Respond(c, http.StatusNotFound, apierror.Response{Error: "Session not found"})
+		return
+	}
+	if err != nil {
+		a.logger.Error("Failed to revoke session", zap.Error(err), zap.String("session_id", sessionID))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to revoke session"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ChangePassword godoc
+// @Summary Change a user's password
+// @Description Set a new password, of at least 8 characters, and end the user's other login sessions. The current password is required unless an admin resets another user's, which ends all of their sessions.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param request body ChangePasswordRequest true "Passwords"
+// @Success 200 {object} ChangePasswordResponse
+// @Failure 400 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/users/{user_id}/password [put]
+func (a *Authorizer) ChangePassword(c *gin.Context) {
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	identity, _ := FromContext(ctx)
+	reset := identity.IsAdmin() && identity.UserID != userID
+	if !reset {
+		hash, err := a.store.PasswordHash(ctx, userID)
+		if err != nil {
+			a.respondUserError(c, err, "Failed to change password")
+			return
+		}
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.CurrentPassword)) != nil {
+			apierror.Respond(c, http.StatusForbidden, apierror.Response{Error: "Forbidden", Details: "current password is incorrect"})
+			return
+		}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		a.logger.Error("Failed to hash password", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to change password"})
+		return
+	}
+	if err := a.store.SetPasswordHash(ctx, userID, string(hash)); err != nil {
+		a.respondUserError(c, err, "Failed to change password")
+		return
+	}
+
+	// Whoever held the old password loses their sessions; the user changing
+	// their own keeps the one they changed it from
+	keep := identity.SessionID
+	if reset {
+		keep = ""
+	}
+	revoked, err := a.sessions.RevokeAll(ctx, userID, keep)
+	if err != nil {
+		a.logger.Error("Failed to revoke sessions after password change", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Password changed, but failed to end sessions"})
+		return
+	}
+
+	a.logger.Info("Password changed",
+		zap.Int("user_id", userID),
+		zap.Int("changed_by", identity.UserID),
+		zap.Int("revoked_sessions", revoked))
+	c.JSON(http.StatusOK, ChangePasswordResponse{RevokedSessions: revoked})
+}
+
+func (a *Authorizer) respondUserError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, ErrUnknownUser) {
+		apierror.Respond(c, http.StatusNotFound, apierror.Response{Error: "User not found"})
+		return
+	}
+	a.logger.Error(msg, zap.Error(err))
+	apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: msg})
+}
+
+func toSessionResponse(session Session, current string) SessionResponse {
+	return SessionResponse{
+		ID:         session.ID,
+		IPAddress:  session.IPAddress,
+		UserAgent:  session.UserAgent,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.ExpiresAt,
+		Current:    session.ID == current,
+	}
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// unknownUserHash returns a bcrypt hash no password matches, compared
+// against for unknown usernames
+func unknownUserHash() string {
+	dummyHashOnce.Do(func() {
+		hash, _ := bcrypt.GenerateFromPassword([]byte(strconv.FormatInt(time.Now().UnixNano(), 36)), bcrypt.DefaultCost)
+		dummyHash = string(hash)
+	})
+	return dummyHash
+}
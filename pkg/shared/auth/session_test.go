@@ -0,0 +1,345 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+// memSessionStore keeps sessions in memory, expiring them by ExpiresAt at
+// the clock of the sessions using it
+type memSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+	now      func() time.Time
+}
+
+func newMemSessionStore(now func() time.Time) *memSessionStore {
+	return &memSessionStore{sessions: make(map[string]Session), now: now}
+}
+
+func (m *memSessionStore) Save(ctx context.Context, session *Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[session.ID] = *session
+	return nil
+}
+
+func (m *memSessionStore) Touch(ctx context.Context, session *Session) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[session.ID]; !ok {
+		return false, nil
+	}
+	m.sessions[session.ID] = *session
+	return true, nil
+}
+
+func (m *memSessionStore) Get(ctx context.Context, sessionID string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[sessionID]
+	if !ok || !m.now().Before(session.ExpiresAt) {
+		return nil, ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+func (m *memSessionStore) Delete(ctx context.Context, userID int, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+func (m *memSessionStore) SessionIDs(ctx context.Context, userID int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ids []string
+	for id, session := range m.sessions {
+		if session.UserID == userID {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// testSessions returns sessions lasting an hour idle and a day in all, at
+// most 3 per user, on a clock the test advances
+func testSessions() (*Sessions, *time.Time) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	sessions := newSessions(newMemSessionStore(clock), time.Hour, 24*time.Hour, 3)
+	sessions.now = clock
+	return sessions, &now
+}
+
+func TestSessionSlidingExpiry(t *testing.T) {
+	ctx := context.Background()
+	sessions, now := testSessions()
+
+	token, created, err := sessions.Create(ctx, 2, "10.0.0.1", "test")
+	require.NoError(t, err)
+	assert.Equal(t, created.CreatedAt.Add(time.Hour), created.ExpiresAt)
+
+	// Each use extends the session by the idle TTL
+	*now = now.Add(50 * time.Minute)
+	session, err := sessions.Resolve(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, 2, session.UserID)
+	assert.Equal(t, now.Add(time.Hour), session.ExpiresAt)
+
+	*now = now.Add(50 * time.Minute)
+	_, err = sessions.Resolve(ctx, token)
+	require.NoError(t, err, "still live after twice the TTL from login")
+
+	// Idle past the TTL
+	*now = now.Add(61 * time.Minute)
+	_, err = sessions.Resolve(ctx, token)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestSessionMaxLifetime(t *testing.T) {
+	ctx := context.Background()
+	sessions, now := testSessions()
+
+	token, created, err := sessions.Create(ctx, 2, "", "")
+	require.NoError(t, err)
+
+	for i := 0; i < 24; i++ {
+		*now = now.Add(time.Hour - time.Minute)
+		_, err := sessions.Resolve(ctx, token)
+		require.NoError(t, err)
+	}
+	session, err := sessions.Resolve(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, created.CreatedAt.Add(24*time.Hour), session.ExpiresAt, "capped at the maximum lifetime")
+
+	*now = created.CreatedAt.Add(24 * time.Hour)
+	_, err = sessions.Resolve(ctx, token)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestSessionTokens(t *testing.T) {
+	ctx := context.Background()
+	sessions, _ := testSessions()
+
+	token, session, err := sessions.Create(ctx, 2, "", "")
+	require.NoError(t, err)
+
+	for _, bad := range []string{"", session.ID, session.ID + ".", session.ID + ".wrong", "unknown.secret"} {
+		_, err := sessions.Resolve(ctx, bad)
+		assert.ErrorIs(t, err, ErrSessionNotFound, bad)
+	}
+	_, err = sessions.Resolve(ctx, token)
+	assert.NoError(t, err)
+}
+
+func TestSessionLimit(t *testing.T) {
+	ctx := context.Background()
+	sessions, now := testSessions()
+
+	var tokens []string
+	for i := 0; i < 3; i++ {
+		token, _, err := sessions.Create(ctx, 2, "", "")
+		require.NoError(t, err)
+		tokens = append(tokens, token)
+		*now = now.Add(2 * time.Minute)
+	}
+
+	// Using the oldest login makes the second the least recently used
+	_, err := sessions.Resolve(ctx, tokens[0])
+	require.NoError(t, err)
+	*now = now.Add(2 * time.Minute)
+
+	_, _, err = sessions.Create(ctx, 2, "", "")
+	require.NoError(t, err)
+	list, err := sessions.List(ctx, 2)
+	require.NoError(t, err)
+	assert.Len(t, list, 3)
+
+	_, err = sessions.Resolve(ctx, tokens[1])
+	assert.ErrorIs(t, err, ErrSessionNotFound, "least recently used session ended")
+	_, err = sessions.Resolve(ctx, tokens[0])
+	assert.NoError(t, err)
+
+	// Other users' sessions are not counted
+	_, _, err = sessions.Create(ctx, 3, "", "")
+	require.NoError(t, err)
+	list, err = sessions.List(ctx, 2)
+	require.NoError(t, err)
+	assert.Len(t, list, 3)
+}
+
+func TestSessionRevoke(t *testing.T) {
+	ctx := context.Background()
+	sessions, _ := testSessions()
+
+	token, session, err := sessions.Create(ctx, 2, "", "")
+	require.NoError(t, err)
+	other, _, err := sessions.Create(ctx, 2, "", "")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, sessions.Revoke(ctx, 3, session.ID), ErrSessionNotFound, "another user's session")
+	require.NoError(t, sessions.Revoke(ctx, 2, session.ID))
+	_, err = sessions.Resolve(ctx, token)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	revoked, err := sessions.RevokeAll(ctx, 2, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+	_, err = sessions.Resolve(ctx, other)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func sessionRouter(sessions *Sessions) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	a := newAuthorizer(stubStore{}, nil)
+	a.SetSessions(sessions)
+	a.SetServiceToken(testServiceToken)
+
+	r := gin.New()
+	r.POST("/api/v1/auth/login", a.Login)
+	v1 := r.Group("/api/v1", a.Authenticate())
+	self := a.RequireSelf("user_id")
+	v1.POST("/auth/logout", a.Logout)
+	v1.GET("/users/:user_id/sessions", self, a.ListSessions)
+	v1.PUT("/users/:user_id/password", self, a.ChangePassword)
+	v1.GET("/admin/provision", a.RequireRole(models.RoleAdmin), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func serveJSON(r *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func login(t *testing.T, r *gin.Engine) LoginResponse {
+	w := serveJSON(r, http.MethodPost, "/api/v1/auth/login", "", LoginRequest{Username: "trader", Password: testPassword})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestLogin(t *testing.T) {
+	sessions, _ := testSessions()
+	r := sessionRouter(sessions)
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		want     int
+	}{
+		{"wrong password", "trader", "guess", http.StatusUnauthorized},
+		{"unknown user", "nobody", testPassword, http.StatusUnauthorized},
+		{"inactive user", "inactive", testPassword, http.StatusForbidden},
+		{"missing password", "trader", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveJSON(r, http.MethodPost, "/api/v1/auth/login", "", LoginRequest{Username: tt.username, Password: tt.password})
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+
+	session := login(t, r)
+	assert.NotEmpty(t, session.Token)
+	assert.True(t, session.Session.Current)
+
+	w := serveJSON(r, http.MethodGet, "/api/v1/users/2/sessions", session.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list []SessionResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, session.Session.ID, list[0].ID)
+	assert.True(t, list[0].Current)
+
+	// The session acts as its user, with their role
+	assert.Equal(t, http.StatusForbidden, serveJSON(r, http.MethodGet, "/api/v1/users/3/sessions", session.Token, nil).Code)
+	assert.Equal(t, http.StatusForbidden, serveJSON(r, http.MethodGet, "/api/v1/admin/provision", session.Token, nil).Code)
+
+	assert.Equal(t, http.StatusNoContent, serveJSON(r, http.MethodPost, "/api/v1/auth/logout", session.Token, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, serveJSON(r, http.MethodGet, "/api/v1/users/2/sessions", session.Token, nil).Code)
+}
+
+func TestAuthenticateRequiresSession(t *testing.T) {
+	sessions, _ := testSessions()
+	r := sessionRouter(sessions)
+	session := login(t, r)
+
+	request := func(authorization, userID, serviceToken string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1/sessions", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		if userID != "" {
+			req.Header.Set("X-User-ID", userID)
+		}
+		if serviceToken != "" {
+			req.Header.Set(HeaderServiceToken, serviceToken)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Naming a user without a session or the service token is refused
+	assert.Equal(t, http.StatusUnauthorized, request("", "", ""))
+	assert.Equal(t, http.StatusUnauthorized, request("", "1", ""))
+
+	// Credentials that fail are never made up for by the header
+	assert.Equal(t, http.StatusUnauthorized, request("Bearer expired", "1", testServiceToken))
+	assert.Equal(t, http.StatusUnauthorized, request("Basic YWRtaW46YWRtaW4=", "1", testServiceToken))
+
+	// A session acts as its own user whatever the header claims
+	assert.Equal(t, http.StatusForbidden, request("Bearer "+session.Token, "1", ""))
+
+	// Service calls may still act as the user they name
+	assert.Equal(t, http.StatusOK, request("", "1", testServiceToken))
+}
+
+func TestChangePasswordRevokesOtherSessions(t *testing.T) {
+	sessions, _ := testSessions()
+	r := sessionRouter(sessions)
+	current, other := login(t, r), login(t, r)
+
+	w := serveJSON(r, http.MethodPut, "/api/v1/users/2/password", current.Token,
+		ChangePasswordRequest{CurrentPassword: "guess", NewPassword: "new-password"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serveJSON(r, http.MethodPut, "/api/v1/users/2/password", current.Token,
+		ChangePasswordRequest{CurrentPassword: testPassword, NewPassword: "short"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serveJSON(r, http.MethodPut, "/api/v1/users/2/password", current.Token,
+		ChangePasswordRequest{CurrentPassword: testPassword, NewPassword: "new-password"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp ChangePasswordResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 1, resp.RevokedSessions)
+
+	assert.Equal(t, http.StatusOK, serveJSON(r, http.MethodGet, "/api/v1/users/2/sessions", current.Token, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, serveJSON(r, http.MethodGet, "/api/v1/users/2/sessions", other.Token, nil).Code)
+}
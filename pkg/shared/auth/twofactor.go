@@ -0,0 +1,521 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// Two-factor authentication
+//
+// Users enroll an authenticator app with a TOTP secret and confirm it with a
+// first code. Once enabled, sensitive actions need a second factor on the
+// request: a current code in X-2FA-Code, or in X-Trusted-Device a token
+// issued when a code was verified, which stands in for codes on that device
+// for a while. Each code is accepted once. After maxCodeFailures wrong codes
+// a user is locked out of codes for a while, twice as long for each further
+// wrong code, so codes cannot be guessed.
+
+// HTTP headers carrying a second factor
+const (
+	HeaderTwoFactorCode = "X-2FA-Code"
+	HeaderTrustedDevice = "X-Trusted-Device"
+)
+
+const (
+	// usedCodePrefix prefixes the keys marking a user's codes as used
+	usedCodePrefix = "totp_used:"
+
+	// trustedDevicePrefix prefixes trusted devices, by their token's hash
+	trustedDevicePrefix = "trusted_device:"
+
+	// codeFailuresPrefix and codeLockoutPrefix prefix the counts of a
+	// user's wrong codes and their lockouts
+	codeFailuresPrefix = "totp_failures:"
+	codeLockoutPrefix  = "totp_lockout:"
+)
+
+const (
+	// maxCodeFailures wrong codes lock a user out of codes
+	maxCodeFailures = 5
+
+	// codeLockout is the first lockout; each wrong code after it doubles it
+	// up to maxCodeLockout
+	codeLockout    = time.Minute
+	maxCodeLockout = time.Hour
+
+	// codeFailureWindow is how long wrong codes are counted after the last
+	codeFailureWindow = 24 * time.Hour
+)
+
+var (
+	// ErrTwoFactorNotEnabled is returned for users without two-factor
+	// authentication enabled
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+
+	// ErrTwoFactorEnabled is returned when enrolling a user who already is
+	ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+
+	// ErrSecondFactorRequired is returned for requests without a code or
+	// trusted-device token
+	ErrSecondFactorRequired = errors.New("two-factor code or trusted-device token required")
+
+	// ErrInvalidCode is returned for wrong, expired or reused codes and
+	// unknown or expired trusted-device tokens
+	ErrInvalidCode = errors.New("invalid or expired two-factor code")
+
+	// ErrTooManyAttempts is returned, as a *LockoutError, for codes of users
+	// locked out after too many wrong ones
+	ErrTooManyAttempts = errors.New("too many wrong two-factor codes")
+)
+
+// LockoutError is returned for codes of a user locked out of them
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("%s: try again in %s", ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockoutError) Unwrap() error { return ErrTooManyAttempts }
+
+// TwoFactorStatus is whether a user has two-factor authentication enabled
+type TwoFactorStatus struct {
+	Enabled   bool       `json:"enabled"`
+	Pending   bool       `json:"pending"` // Enrolled but not yet confirmed with a code
+	EnabledAt *time.Time `json:"enabled_at,omitempty"`
+}
+
+// twoFactorRecord is a user's enrollment
+type twoFactorRecord struct {
+	Secret    string
+	Enabled   bool
+	EnabledAt *time.Time
+}
+
+// trustedDevice is a device whose token stands in for codes
+type trustedDevice struct {
+	UserID    int       `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// twoFactorStore stores enrollments, used codes and trusted devices
+type twoFactorStore interface {
+	// Get returns a user's enrollment, or nil if they have none
+	Get(ctx context.Context, userID int) (*twoFactorRecord, error)
+	// SaveSecret replaces a user's enrollment with a pending one
+	SaveSecret(ctx context.Context, userID int, secret string) error
+	// Enable enables a user's pending enrollment as of at
+	Enable(ctx context.Context, userID int, at time.Time) error
+	// Delete removes a user's enrollment
+	Delete(ctx context.Context, userID int) error
+	// UseStep marks the code of a time step used, reporting whether it was
+	// not already
+	UseStep(ctx context.Context, userID int, step int64) (bool, error)
+	// SaveDevice stores a trusted device by its token's hash until ttl
+	SaveDevice(ctx context.Context, hash string, device trustedDevice, ttl time.Duration) error
+	// Device returns a trusted device by its token's hash, or nil
+	Device(ctx context.Context, hash string) (*trustedDevice, error)
+	// AddFailure counts a wrong code of a user's, returning how many have
+	// been counted, and keeps the count for window
+	AddFailure(ctx context.Context, userID int, window time.Duration) (int, error)
+	// ClearFailures forgets a user's wrong codes and lockout
+	ClearFailures(ctx context.Context, userID int) error
+	// Lock locks a user out of codes for ttl
+	Lock(ctx context.Context, userID int, ttl time.Duration) error
+	// LockedFor returns how much longer a user is locked out, or 0
+	LockedFor(ctx context.Context, userID int) (time.Duration, error)
+}
+
+type dbTwoFactorStore struct {
+	db    *database.DB
+	redis *redis.Client
+}
+
+func (s *dbTwoFactorStore) Get(ctx context.Context, userID int) (*twoFactorRecord, error) {
+	var record twoFactorRecord
+	var enabledAt sql.NullTime
+	query := `SELECT secret, enabled, enabled_at FROM user_two_factor WHERE user_id = $1`
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&record.Secret, &record.Enabled, &enabledAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor enrollment: %w", err)
+	}
+	if enabledAt.Valid {
+		record.EnabledAt = &enabledAt.Time
+	}
+	return &record, nil
+}
+
+func (s *dbTwoFactorStore) SaveSecret(ctx context.Context, userID int, secret string) error {
+	query := `
+		INSERT INTO user_two_factor (user_id, secret, enabled, created_at, enabled_at)
+		VALUES ($1, $2, false, NOW(), NULL)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, enabled = false, created_at = NOW(), enabled_at = NULL`
+	if _, err := s.db.ExecContext(ctx, query, userID, secret); err != nil {
+		return fmt.Errorf("failed to save two-factor secret: %w", err)
+	}
+	return nil
+}
+
+func (s *dbTwoFactorStore) Enable(ctx context.Context, userID int, at time.Time) error {
+	query := `UPDATE user_two_factor SET enabled = true, enabled_at = $2 WHERE user_id = $1 AND NOT enabled`
+	if _, err := s.db.ExecContext(ctx, query, userID, at); err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+func (s *dbTwoFactorStore) Delete(ctx context.Context, userID int) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM user_two_factor WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+func (s *dbTwoFactorStore) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	key := fmt.Sprintf("%s%d:%d", usedCodePrefix, userID, step)
+	fresh, err := s.redis.SetNX(ctx, key, 1, (2*totpSkew+1)*totpPeriod).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor code: %w", err)
+	}
+	return fresh, nil
+}
+
+func (s *dbTwoFactorStore) SaveDevice(ctx context.Context, hash string, device trustedDevice, ttl time.Duration) error {
+	return s.redis.SetCache(ctx, trustedDevicePrefix+hash, device, ttl)
+}
+
+func (s *dbTwoFactorStore) Device(ctx context.Context, hash string) (*trustedDevice, error) {
+	var device trustedDevice
+	err := s.redis.GetCache(ctx, trustedDevicePrefix+hash, &device)
+	if errors.Is(err, redis.ErrCacheMiss) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (s *dbTwoFactorStore) AddFailure(ctx context.Context, userID int, window time.Duration) (int, error) {
+	key := fmt.Sprintf("%s%d", codeFailuresPrefix, userID)
+	pipe := s.redis.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to count two-factor failure: %w", err)
+	}
+	return int(incr.Val()), nil
+}
+
+func (s *dbTwoFactorStore) ClearFailures(ctx context.Context, userID int) error {
+	err := s.redis.Del(ctx, fmt.Sprintf("%s%d", codeFailuresPrefix, userID), fmt.Sprintf("%s%d", codeLockoutPrefix, userID)).Err()
+	if err != nil {
+		return fmt.Errorf("failed to clear two-factor failures: %w", err)
+	}
+	return nil
+}
+
+func (s *dbTwoFactorStore) Lock(ctx context.Context, userID int, ttl time.Duration) error {
+	if err := s.redis.Set(ctx, fmt.Sprintf("%s%d", codeLockoutPrefix, userID), 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to lock out two-factor codes: %w", err)
+	}
+	return nil
+}
+
+func (s *dbTwoFactorStore) LockedFor(ctx context.Context, userID int) (time.Duration, error) {
+	ttl, err := s.redis.PTTL(ctx, fmt.Sprintf("%s%d", codeLockoutPrefix, userID)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check two-factor lockout: %w", err)
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// TwoFactor enrolls users in two-factor authentication and checks their
+// second factors
+type TwoFactor struct {
+	store     twoFactorStore
+	issuer    string
+	required  bool
+	deviceTTL time.Duration
+	now       func() time.Time
+}
+
+// NewTwoFactor creates two-factor authentication over the user_two_factor
+// table, keeping used codes and trusted devices in Redis
+func NewTwoFactor(db *database.DB, client *redis.Client, cfg *config.Config) *TwoFactor {
+	return newTwoFactor(&dbTwoFactorStore{db: db, redis: client}, cfg.TwoFactorIssuer, cfg.TwoFactorRequired,
+		time.Duration(cfg.TwoFactorDeviceTTL)*time.Second)
+}
+
+func newTwoFactor(store twoFactorStore, issuer string, required bool, deviceTTL time.Duration) *TwoFactor {
+	return &TwoFactor{store: store, issuer: issuer, required: required, deviceTTL: deviceTTL, now: time.Now}
+}
+
+// Status returns whether a user has two-factor authentication enabled
+func (t *TwoFactor) Status(ctx context.Context, userID int) (TwoFactorStatus, error) {
+	record, err := t.store.Get(ctx, userID)
+	if err != nil || record == nil {
+		return TwoFactorStatus{}, err
+	}
+	return TwoFactorStatus{Enabled: record.Enabled, Pending: !record.Enabled, EnabledAt: record.EnabledAt}, nil
+}
+
+// Enroll gives a user a new secret, returning it and its otpauth URL. It is
+// enabled once Verify accepts a code from it. Enrolling again before then
+// replaces the secret.
+func (t *TwoFactor) Enroll(ctx context.Context, userID int) (string, string, error) {
+	record, err := t.store.Get(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	if record != nil && record.Enabled {
+		return "", "", ErrTwoFactorEnabled
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := t.store.SaveSecret(ctx, userID, secret); err != nil {
+		return "", "", err
+	}
+	return secret, totpURL(t.issuer, fmt.Sprintf("user-%d", userID), secret), nil
+}
+
+// Verify checks a code of a user's, enabling a pending enrollment it
+// confirms. It returns ErrTwoFactorNotEnabled for users not enrolled,
+// ErrInvalidCode for wrong or reused codes and a *LockoutError for users
+// locked out after too many.
+func (t *TwoFactor) Verify(ctx context.Context, userID int, code string) error {
+	record, err := t.store.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := t.verifyCode(ctx, userID, record, code); err != nil {
+		return err
+	}
+	if !record.Enabled {
+		return t.store.Enable(ctx, userID, t.now())
+	}
+	return nil
+}
+
+// verifyCode checks a code, counting wrong ones towards a lockout. Codes of
+// locked-out users are refused unchecked.
+func (t *TwoFactor) verifyCode(ctx context.Context, userID int, record *twoFactorRecord, code string) error {
+	locked, err := t.store.LockedFor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if locked > 0 {
+		return &LockoutError{RetryAfter: locked}
+	}
+
+	step, ok := verifyTOTP(record.Secret, code, t.now())
+	if !ok {
+		return t.fail(ctx, userID, ErrInvalidCode)
+	}
+	fresh, err := t.store.UseStep(ctx, userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return t.fail(ctx, userID, fmt.Errorf("%w: code already used", ErrInvalidCode))
+	}
+	return t.store.ClearFailures(ctx, userID)
+}
+
+// fail counts a wrong code, locking the user out once there have been
+// maxCodeFailures, and returns err
+func (t *TwoFactor) fail(ctx context.Context, userID int, err error) error {
+	failures, countErr := t.store.AddFailure(ctx, userID, codeFailureWindow)
+	if countErr != nil {
+		return countErr
+	}
+	if failures < maxCodeFailures {
+		return err
+	}
+
+	lockout := codeLockout
+	for i := maxCodeFailures; i < failures && lockout < maxCodeLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > maxCodeLockout {
+		lockout = maxCodeLockout
+	}
+	if lockErr := t.store.Lock(ctx, userID, lockout); lockErr != nil {
+		return lockErr
+	}
+	return err
+}
+
+// TrustDevice issues a token that stands in for a user's codes until it
+// expires, returning it and its expiry. Call it only after verifying a code.
+func (t *TwoFactor) TrustDevice(ctx context.Context, userID int) (string, time.Time, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate device token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	now := t.now()
+	device := trustedDevice{UserID: userID, CreatedAt: now}
+	if err := t.store.SaveDevice(ctx, hashSecret(token), device, t.deviceTTL); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, now.Add(t.deviceTTL), nil
+}
+
+// Check verifies the second factor of a request by a user: a code, or a
+// trusted-device token issued since two-factor authentication was last
+// enabled. It returns ErrTwoFactorNotEnabled for users not enrolled,
+// ErrSecondFactorRequired when neither is given, ErrInvalidCode when the one
+// given is not accepted and a *LockoutError for codes of users locked out.
+func (t *TwoFactor) Check(ctx context.Context, userID int, code, deviceToken string) error {
+	record, err := t.store.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if record == nil || !record.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if deviceToken != "" {
+		device, err := t.store.Device(ctx, hashSecret(deviceToken))
+		if err != nil {
+			return err
+		}
+		if device != nil && device.UserID == userID && record.EnabledAt != nil && !device.CreatedAt.Before(*record.EnabledAt) {
+			return nil
+		}
+		if code == "" {
+			return ErrInvalidCode
+		}
+	}
+	if code == "" {
+		return ErrSecondFactorRequired
+	}
+	return t.verifyCode(ctx, userID, record, code)
+}
+
+// Disable removes a user's enrollment. Their trusted devices lapse with it.
+func (t *TwoFactor) Disable(ctx context.Context, userID int) error {
+	return t.store.Delete(ctx, userID)
+}
+
+// SetTwoFactor enables two-factor authentication: RequireSecondFactor and
+// CheckSecondFactor then ask enrolled users for a second factor, and the
+// two-factor endpoints are served
+func (a *Authorizer) SetTwoFactor(twoFactor *TwoFactor) {
+	a.twoFactor = twoFactor
+}
+
+// RequireSecondFactor admits a request to a sensitive action only with a
+// valid second factor, from users with two-factor authentication enabled.
+// It must run after Authenticate.
+func (a *Authorizer) RequireSecondFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a.CheckSecondFactor(c) {
+			c.Next()
+		}
+	}
+}
+
+// VerifySecondFactor checks the second factor given for a sensitive action
+// by a user. It returns nil when two-factor authentication is off, or the
+// user is not enrolled and need not be; otherwise it returns the error of
+// TwoFactor.Check, with ErrTwoFactorNotEnabled meaning the user must enroll.
+func (a *Authorizer) VerifySecondFactor(ctx context.Context, identity Identity, code, deviceToken string) error {
+	if a.twoFactor == nil {
+		return nil
+	}
+	err := a.twoFactor.Check(ctx, identity.UserID, code, deviceToken)
+	if errors.Is(err, ErrTwoFactorNotEnabled) && (!a.twoFactor.required || !identity.CanTrade()) {
+		return nil
+	}
+	return err
+}
+
+// CheckSecondFactor reports whether a request may take a sensitive action,
+// for handlers whose actions are only sometimes sensitive. Users with
+// two-factor authentication enabled need a valid second factor; traders
+// and admins without it are refused when enrollment is required. Refused
+// requests are answered with 403.
+func (a *Authorizer) CheckSecondFactor(c *gin.Context) bool {
+	if a.twoFactor == nil {
+		return true
+	}
+	identity, ok := FromContext(c.Request.Context())
+	if !ok {
+		apierror.Abort(c, http.StatusUnauthorized, apierror.Response{Error: "Authentication required"})
+		return false
+	}
+
+	err := a.VerifySecondFactor(c.Request.Context(), identity,
+		c.GetHeader(HeaderTwoFactorCode), c.GetHeader(HeaderTrustedDevice))
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrTwoFactorNotEnabled):
+		apierror.Abort(c, http.StatusForbidden, apierror.Response{
+			Code:    apierror.CodeTwoFactorEnrollment,
+			Error:   "Two-factor authentication required",
+			Details: "enroll in two-factor authentication to take this action",
+		})
+		return false
+	case errors.Is(err, ErrSecondFactorRequired), errors.Is(err, ErrInvalidCode):
+		apierror.Abort(c, http.StatusForbidden, apierror.Response{
+			Code:    apierror.CodeTwoFactorRequired,
+			Error:   "Two-factor authentication required",
+			Details: err.Error(),
+		})
+		return false
+	case errors.Is(err, ErrTooManyAttempts):
+		abortLockedOut(c, err)
+		return false
+	default:
+		a.logger.Error("Failed to check second factor", zap.Error(err), zap.Int("user_id", identity.UserID))
+		apierror.Abort(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to authorize request"})
+		return false
+	}
+}
+
+// abortLockedOut answers 429 with a Retry-After header to codes of users
+// locked out of them
+func abortLockedOut(c *gin.Context, err error) {
+	var lockout *LockoutError
+	if errors.As(err, &lockout) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockout.RetryAfter.Seconds()))))
+	}
+	apierror.Abort(c, http.StatusTooManyRequests, apierror.Response{
+		Code:    apierror.CodeRateLimited,
+		Error:   "Too many wrong two-factor codes",
+		Details: err.Error(),
+	})
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/apierror"
+)
+
+// EnrollTwoFactorResponse is a new TOTP secret for an authenticator app,
+// only returned here
+type EnrollTwoFactorResponse struct {
+	Secret     string `json:"secret"`      // Base32, for typing into the app
+	OTPAuthURL string `json:"otpauth_url"` // For a QR code
+}
+
+// VerifyTwoFactorRequest is a code from the user's authenticator app
+type VerifyTwoFactorRequest struct {
+	Code        string `json:"code" binding:"required,len=6,numeric"`
+	TrustDevice bool   `json:"trust_device"` // Issue a token standing in for codes on this device
+}
+
+// VerifyTwoFactorResponse reports the user enabled, with a trusted-device
+// token when one was asked for
+type VerifyTwoFactorResponse struct {
+	Enabled            bool       `json:"enabled"`
+	TrustedDevice      string     `json:"trusted_device,omitempty"` // Send as X-Trusted-Device
+	TrustedDeviceUntil *time.Time `json:"trusted_device_until,omitempty"`
+}
+
+// GetTwoFactor godoc
+// @Summary Get a user's two-factor status
+// @Description Whether the user has two-factor authentication enabled, or an enrollment awaiting its first code
+// @Tags auth
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {object} TwoFactorStatus
+// @Failure 400 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/users/{user_id}/two-factor [get]
+func (a *Authorizer) GetTwoFactor(c *gin.Context) {
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	status, err := a.twoFactor.Status(c.Request.Context(), userID)
+	if err != nil {
+		a.logger.Error("Failed to get two-factor status", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to get two-factor status"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// EnrollTwoFactor godoc
+// @Summary Enroll in two-factor authentication
+// @Description Get a new TOTP secret to add to an authenticator app. Two-factor authentication is enabled once a code from it is verified; enrolling again before then replaces the secret. Once enabled, trades from the configured value, cash withdrawals, transfers and reductions, broker account links and uncapped risk tolerance need a code in X-2FA-Code or a trusted-device token in X-Trusted-Device.
+// @Tags auth
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 201 {object} EnrollTwoFactorResponse
+// @Failure 400 {object} apierror.Response
+// @Failure 409 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/users/{user_id}/two-factor [post]
+func (a *Authorizer) EnrollTwoFactor(c *gin.Context) {
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	secret, otpauthURL, err := a.twoFactor.Enroll(c.Request.Context(), userID)
+	if errors.Is(err, ErrTwoFactorEnabled) {
+		apierror.Respond(c, http.StatusConflict, apierror.Response{Error: "Two-factor authentication is already enabled"})
+		return
+	}
+	if err != nil {
+		a.logger.Error("Failed to enroll in two-factor authentication", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to enroll in two-factor authentication"})
+		return
+	}
+	c.JSON(http.StatusCreated, EnrollTwoFactorResponse{Secret: secret, OTPAuthURL: otpauthURL})
+}
+
+// VerifyTwoFactor godoc
+// @Summary Verify a two-factor code
+// @Description Check a code from the user's authenticator app, enabling two-factor authentication if it was awaiting its first code. With trust_device, also issue a token that stands in for codes on this device for a while. After five wrong codes the user's codes are refused with 429 for a minute, doubling with each further wrong one up to an hour.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param request body VerifyTwoFactorRequest true "Code"
+// @Success 200 {object} VerifyTwoFactorResponse
+// @Failure 400 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 429 {object} apierror.Response "Locked out after too many wrong codes"
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/users/{user_id}/two-factor/verify [post]
+func (a *Authorizer) VerifyTwoFactor(c *gin.Context) {
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+	var req VerifyTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := a.twoFactor.Verify(ctx, userID, req.Code)
+	switch {
+	case errors.Is(err, ErrTwoFactorNotEnabled):
+		apierror.Respond(c, http.StatusNotFound, apierror.Response{Error: "Not enrolled in two-factor authentication"})
+		return
+	case errors.Is(err, ErrInvalidCode):
+		apierror.Respond(c, http.StatusForbidden, apierror.Response{
+			Code:    apierror.CodeTwoFactorRequired,
+			Error:   "Invalid two-factor code",
+			Details: err.Error(),
+		})
+		return
+	case errors.Is(err, ErrTooManyAttempts):
+		abortLockedOut(c, err)
+		return
+	case err != nil:
+		a.logger.Error("Failed to verify two-factor code", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to verify two-factor code"})
+		return
+	}
+
+	response := VerifyTwoFactorResponse{Enabled: true}
+	if req.TrustDevice {
+		token, until, err := a.twoFactor.TrustDevice(ctx, userID)
+		if err != nil {
+			a.logger.Error("Failed to trust device", zap.Error(err), zap.Int("user_id", userID))
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to trust device"})
+			return
+		}
+		response.TrustedDevice, response.TrustedDeviceUntil = token, &until
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Remove the user's authenticator secret; their trusted devices lapse with it. Needs a second factor unless an admin disables another user's, e.g. after a lost device.
+// @Tags auth
+// @Param user_id path int true "User ID"
+// @Param X-2FA-Code header string false "Code from the authenticator app"
+// @Param X-Trusted-Device header string false "Trusted-device token"
+// @Success 204
+// @Failure 400 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/users/{user_id}/two-factor [delete]
+func (a *Authorizer) DisableTwoFactor(c *gin.Context) {
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	identity, _ := FromContext(c.Request.Context())
+	if !(identity.IsAdmin() && identity.UserID != userID) && !a.CheckSecondFactor(c) {
+		return
+	}
+
+	if err := a.twoFactor.Disable(c.Request.Context(), userID); err != nil {
+		a.logger.Error("Failed to disable two-factor authentication", zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to disable two-factor authentication"})
+		return
+	}
+
+	a.logger.Info("Two-factor authentication disabled", zap.Int("user_id", userID), zap.Int("disabled_by", identity.UserID))
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,338 @@
+package auth
+
+import (
+	"context"
+	"encoding/base32"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/models"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1, truncated to six digits
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, totpCode(key, totpStep(time.Unix(tt.unix, 0))), tt.unix)
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+
+	step, ok := verifyTOTP(secret, "050471", now)
+	assert.True(t, ok)
+	assert.Equal(t, totpStep(now), step)
+
+	_, ok = verifyTOTP(secret, "050471", now.Add(totpPeriod))
+	assert.True(t, ok, "previous step's code is accepted")
+	_, ok = verifyTOTP(secret, "050471", now.Add(3*totpPeriod))
+	assert.False(t, ok)
+	_, ok = verifyTOTP(secret, "050472", now)
+	assert.False(t, ok)
+	_, ok = verifyTOTP(secret, "50471", now)
+	assert.False(t, ok)
+}
+
+func TestTOTPURL(t *testing.T) {
+	url := totpURL("AI Hedge Fund", "user-2", "ABCDEF")
+	assert.True(t, strings.HasPrefix(url, "otpauth://totp/AI%20Hedge%20Fund:user-2?"), url)
+	assert.Contains(t, url, "secret=ABCDEF")
+	assert.Contains(t, url, "issuer=AI+Hedge+Fund")
+}
+
+// memTwoFactorStore keeps enrollments, used codes, devices and wrong codes
+// in memory, expiring lockouts on a clock the test advances
+type memTwoFactorStore struct {
+	records  map[int]*twoFactorRecord
+	used     map[[2]int64]bool
+	devices  map[string]trustedDevice
+	failures map[int]int
+	lockouts map[int]time.Time
+	now      func() time.Time
+}
+
+func newMemTwoFactorStore(now func() time.Time) *memTwoFactorStore {
+	return &memTwoFactorStore{
+		records:  make(map[int]*twoFactorRecord),
+		used:     make(map[[2]int64]bool),
+		devices:  make(map[string]trustedDevice),
+		failures: make(map[int]int),
+		lockouts: make(map[int]time.Time),
+		now:      now,
+	}
+}
+
+func (m *memTwoFactorStore) Get(ctx context.Context, userID int) (*twoFactorRecord, error) {
+	record, ok := m.records[userID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *record
+	return &copied, nil
+}
+
+func (m *memTwoFactorStore) SaveSecret(ctx context.Context, userID int, secret string) error {
+	m.records[userID] = &twoFactorRecord{Secret: secret}
+	return nil
+}
+
+func (m *memTwoFactorStore) Enable(ctx context.Context, userID int, at time.Time) error {
+	m.records[userID].Enabled = true
+	m.records[userID].EnabledAt = &at
+	return nil
+}
+
+func (m *memTwoFactorStore) Delete(ctx context.Context, userID int) error {
+	delete(m.records, userID)
+	return nil
+}
+
+func (m *memTwoFactorStore) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	key := [2]int64{int64(userID), step}
+	if m.used[key] {
+		return false, nil
+	}
+	m.used[key] = true
+	return true, nil
+}
+
+func (m *memTwoFactorStore) SaveDevice(ctx context.Context, hash string, device trustedDevice, ttl time.Duration) error {
+	m.devices[hash] = device
+	return nil
+}
+
+func (m *memTwoFactorStore) Device(ctx context.Context, hash string) (*trustedDevice, error) {
+	device, ok := m.devices[hash]
+	if !ok {
+		return nil, nil
+	}
+	return &device, nil
+}
+
+func (m *memTwoFactorStore) AddFailure(ctx context.Context, userID int, window time.Duration) (int, error) {
+	m.failures[userID]++
+	return m.failures[userID], nil
+}
+
+func (m *memTwoFactorStore) ClearFailures(ctx context.Context, userID int) error {
+	delete(m.failures, userID)
+	delete(m.lockouts, userID)
+	return nil
+}
+
+func (m *memTwoFactorStore) Lock(ctx context.Context, userID int, ttl time.Duration) error {
+	m.lockouts[userID] = m.now().Add(ttl)
+	return nil
+}
+
+func (m *memTwoFactorStore) LockedFor(ctx context.Context, userID int) (time.Duration, error) {
+	if left := m.lockouts[userID].Sub(m.now()); left > 0 {
+		return left, nil
+	}
+	return 0, nil
+}
+
+// testTwoFactor returns two-factor authentication on a clock the test
+// advances
+func testTwoFactor(required bool) (*TwoFactor, *time.Time) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	twoFactor := newTwoFactor(newMemTwoFactorStore(clock), "AI Hedge Fund", required, 30*24*time.Hour)
+	twoFactor.now = clock
+	return twoFactor, &now
+}
+
+// currentCode returns the code of a secret at now
+func currentCode(t *testing.T, secret string, now time.Time) string {
+	key, err := totpEncoding.DecodeString(secret)
+	require.NoError(t, err)
+	return totpCode(key, totpStep(now))
+}
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	ctx := context.Background()
+	twoFactor, now := testTwoFactor(false)
+
+	assert.ErrorIs(t, twoFactor.Check(ctx, 2, "", ""), ErrTwoFactorNotEnabled)
+
+	secret, _, err := twoFactor.Enroll(ctx, 2)
+	require.NoError(t, err)
+	status, err := twoFactor.Status(ctx, 2)
+	require.NoError(t, err)
+	assert.True(t, status.Pending)
+	assert.ErrorIs(t, twoFactor.Check(ctx, 2, "", ""), ErrTwoFactorNotEnabled, "not enabled until a code is verified")
+
+	assert.ErrorIs(t, twoFactor.Verify(ctx, 2, "000000"), ErrInvalidCode)
+	require.NoError(t, twoFactor.Verify(ctx, 2, currentCode(t, secret, *now)))
+	status, err = twoFactor.Status(ctx, 2)
+	require.NoError(t, err)
+	assert.True(t, status.Enabled)
+
+	_, _, err = twoFactor.Enroll(ctx, 2)
+	assert.ErrorIs(t, err, ErrTwoFactorEnabled)
+
+	// A code is accepted once
+	assert.ErrorIs(t, twoFactor.Check(ctx, 2, currentCode(t, secret, *now), ""), ErrInvalidCode)
+	*now = now.Add(totpPeriod)
+	assert.ErrorIs(t, twoFactor.Check(ctx, 2, "", ""), ErrSecondFactorRequired)
+	assert.NoError(t, twoFactor.Check(ctx, 2, currentCode(t, secret, *now), ""))
+}
+
+func TestTwoFactorTrustedDevice(t *testing.T) {
+	ctx := context.Background()
+	twoFactor, now := testTwoFactor(false)
+
+	secret, _, err := twoFactor.Enroll(ctx, 2)
+	require.NoError(t, err)
+	require.NoError(t, twoFactor.Verify(ctx, 2, currentCode(t, secret, *now)))
+	token, until, err := twoFactor.TrustDevice(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(30*24*time.Hour), until)
+
+	assert.NoError(t, twoFactor.Check(ctx, 2, "", token))
+	assert.ErrorIs(t, twoFactor.Check(ctx, 3, "", token), ErrTwoFactorNotEnabled)
+	assert.ErrorIs(t, twoFactor.Check(ctx, 2, "", "unknown"), ErrInvalidCode)
+
+	// Re-enrolling lapses devices trusted before
+	require.NoError(t, twoFactor.Disable(ctx, 2))
+	*now = now.Add(time.Minute)
+	secret, _, err = twoFactor.Enroll(ctx, 2)
+	require.NoError(t, err)
+	require.NoError(t, twoFactor.Verify(ctx, 2, currentCode(t, secret, *now)))
+	assert.ErrorIs(t, twoFactor.Check(ctx, 2, "", token), ErrInvalidCode)
+}
+
+func TestTwoFactorLockout(t *testing.T) {
+	ctx := context.Background()
+	twoFactor, now := testTwoFactor(false)
+	secret, _, err := twoFactor.Enroll(ctx, 2)
+	require.NoError(t, err)
+	require.NoError(t, twoFactor.Verify(ctx, 2, currentCode(t, secret, *now)))
+	device, _, err := twoFactor.TrustDevice(ctx, 2)
+	require.NoError(t, err)
+
+	for i := 0; i < maxCodeFailures; i++ {
+		assert.ErrorIs(t, twoFactor.Check(ctx, 2, "000000", ""), ErrInvalidCode)
+	}
+
+	// Locked out: even the right code is refused until the lockout ends
+	*now = now.Add(totpPeriod)
+	err = twoFactor.Check(ctx, 2, currentCode(t, secret, *now), "")
+	var lockout *LockoutError
+	require.ErrorAs(t, err, &lockout)
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	assert.Equal(t, codeLockout-totpPeriod, lockout.RetryAfter)
+	assert.ErrorIs(t, twoFactor.Verify(ctx, 2, currentCode(t, secret, *now)), ErrTooManyAttempts)
+	assert.NoError(t, twoFactor.Check(ctx, 2, "", device), "trusted devices are not locked out")
+
+	// Each further wrong code doubles the lockout
+	*now = now.Add(codeLockout)
+	assert.ErrorIs(t, twoFactor.Check(ctx, 2, "000000", ""), ErrInvalidCode)
+	err = twoFactor.Check(ctx, 2, "000000", "")
+	require.ErrorAs(t, err, &lockout)
+	assert.Equal(t, 2*codeLockout, lockout.RetryAfter)
+
+	// A right code after the lockout clears the count
+	*now = now.Add(2 * codeLockout)
+	require.NoError(t, twoFactor.Check(ctx, 2, currentCode(t, secret, *now), ""))
+	*now = now.Add(totpPeriod)
+	assert.ErrorIs(t, twoFactor.Check(ctx, 2, "000000", ""), ErrInvalidCode)
+	require.NoError(t, twoFactor.Check(ctx, 2, currentCode(t, secret, *now), ""))
+}
+
+func TestCheckSecondFactor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	for _, required := range []bool{false, true} {
+		twoFactor, now := testTwoFactor(required)
+		a := newAuthorizer(stubStore{}, nil)
+		a.SetTwoFactor(twoFactor)
+		a.SetServiceToken(testServiceToken)
+
+		r := gin.New()
+		v1 := r.Group("/api/v1", a.Authenticate())
+		v1.PUT("/portfolios/:id/broker-account", a.RequireSecondFactor(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		request := func(userID, code string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/portfolios/20/broker-account", nil)
+			req.Header.Set("X-User-ID", userID)
+			req.Header.Set(HeaderServiceToken, testServiceToken)
+			if code != "" {
+				req.Header.Set(HeaderTwoFactorCode, code)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w
+		}
+
+		// Not enrolled: refused to traders only when enrollment is required
+		w := request("2", "")
+		if required {
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Contains(t, w.Body.String(), apierror.CodeTwoFactorEnrollment)
+		} else {
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+		assert.Equal(t, http.StatusOK, request("3", "").Code, "viewers are never made to enroll")
+
+		secret, _, err := twoFactor.Enroll(ctx, 2)
+		require.NoError(t, err)
+		require.NoError(t, twoFactor.Verify(ctx, 2, currentCode(t, secret, *now)))
+		*now = now.Add(totpPeriod)
+
+		w = request("2", "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), apierror.CodeTwoFactorRequired)
+		assert.Equal(t, http.StatusForbidden, request("2", "123456").Code)
+		assert.Equal(t, http.StatusOK, request("2", currentCode(t, secret, *now)).Code)
+
+		// Guessing codes is locked out
+		for i := 0; i < maxCodeFailures; i++ {
+			assert.Equal(t, http.StatusForbidden, request("2", "000000").Code)
+		}
+		w = request("2", "000000")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	}
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	ctx := context.Background()
+	trader := Identity{UserID: 2, Role: models.RoleTrader}
+	viewer := Identity{UserID: 3, Role: models.RoleViewer}
+
+	a := newAuthorizer(stubStore{}, nil)
+	assert.NoError(t, a.VerifySecondFactor(ctx, trader, "", ""), "two-factor authentication off")
+
+	twoFactor, now := testTwoFactor(true)
+	a.SetTwoFactor(twoFactor)
+	assert.ErrorIs(t, a.VerifySecondFactor(ctx, trader, "", ""), ErrTwoFactorNotEnabled)
+	assert.NoError(t, a.VerifySecondFactor(ctx, viewer, "", ""), "viewers are never made to enroll")
+
+	secret, _, err := twoFactor.Enroll(ctx, 2)
+	require.NoError(t, err)
+	require.NoError(t, twoFactor.Verify(ctx, 2, currentCode(t, secret, *now)))
+	*now = now.Add(totpPeriod)
+	assert.ErrorIs(t, a.VerifySecondFactor(ctx, trader, "", ""), ErrSecondFactorRequired)
+	assert.NoError(t, a.VerifySecondFactor(ctx, trader, currentCode(t, secret, *now), ""))
+}
@@ -0,0 +1,75 @@
+// Package cashflow measures how a portfolio's investments did between its
+// snapshots net of the cash moved in and out of it, so that a deposit is not
+// counted as a gain nor a withdrawal as a loss
+package cashflow
+
+import (
+	"fmt"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// Net returns the cash a ledger entry moved into its portfolio: positive for
+// deposits and incoming transfers, negative for withdrawals and outgoing
+// transfers
+func Net(entry models.CashTransaction) float64 {
+	switch entry.Type {
+	case models.CashDeposit, models.CashTransferIn:
+		return entry.Amount
+	case models.CashWithdrawal, models.CashTransferOut:
+		return -entry.Amount
+	}
+	return 0
+}
+
+// Returns returns the return over each interval between consecutive
+// snapshots, net of the cash transactions made during it, by the Modified
+// Dietz method: a flow counts towards the capital at work for the part of
+// the interval after it was made. As in account statements, a transaction
+// falls in the interval from the snapshot taken at or before it to the next.
+// returns[i] is the return from snapshots[i] to snapshots[i+1]. Snapshots
+// and transactions must be oldest first.
+func Returns(snapshots []models.PortfolioSnapshot, cash []models.CashTransaction) ([]float64, error) {
+	if len(snapshots) < 2 {
+		return []float64{}, nil
+	}
+
+	returns := make([]float64, len(snapshots)-1)
+	next := 0
+	for i := range returns {
+		start, end := snapshots[i], snapshots[i+1]
+		span := end.CreatedAt.Sub(start.CreatedAt)
+
+		var flow, weighted float64
+		for ; next < len(cash) && cash[next].CreatedAt.Before(end.CreatedAt); next++ {
+			entry := cash[next]
+			if entry.CreatedAt.Before(start.CreatedAt) {
+				continue
+			}
+			amount := Net(entry)
+			flow += amount
+			if span > 0 {
+				weighted += amount * float64(end.CreatedAt.Sub(entry.CreatedAt)) / float64(span)
+			}
+		}
+
+		capital := start.TotalValue + weighted
+		if capital <= 0 {
+			return nil, fmt.Errorf("no value on %s", start.SnapshotDate.Format("2006-01-02"))
+		}
+		returns[i] = (end.TotalValue - start.TotalValue - flow) / capital
+	}
+	return returns, nil
+}
+
+// Index compounds returns into the growth of 1 invested before the first of
+// them: index[0] is 1 and index[i+1] is index[i] grown by returns[i]. It is
+// a value curve without the jumps cash flows put in the balance.
+func Index(returns []float64) []float64 {
+	index := make([]float64, len(returns)+1)
+	index[0] = 1
+	for i, r := range returns {
+		index[i+1] = index[i] * (1 + r)
+	}
+	return index
+}
@@ -0,0 +1,69 @@
+package cashflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+var start = time.Date(2024, 3, 1, 16, 0, 0, 0, time.UTC)
+
+func snapshots(values ...float64) []models.PortfolioSnapshot {
+	result := make([]models.PortfolioSnapshot, len(values))
+	for i, value := range values {
+		taken := start.AddDate(0, 0, i)
+		result[i] = models.PortfolioSnapshot{SnapshotDate: taken, TotalValue: value, CreatedAt: taken}
+	}
+	return result
+}
+
+func TestReturns(t *testing.T) {
+	// +10%, then a deposit of 100 halfway through a day the portfolio gains 10.5
+	cash := []models.CashTransaction{
+		{Type: models.CashDeposit, Amount: 100, CreatedAt: start.AddDate(0, 0, 1).Add(12 * time.Hour)},
+	}
+	returns, err := Returns(snapshots(100, 110, 220.5), cash)
+	require.NoError(t, err)
+	require.Len(t, returns, 2)
+	assert.InDelta(t, 0.10, returns[0], 1e-9)
+	assert.InDelta(t, 10.5/(110+50), returns[1], 1e-9) // The deposit was at work for half the day
+
+	// Entries before the first snapshot, or at the last, are outside the intervals
+	cash = []models.CashTransaction{
+		{Type: models.CashWithdrawal, Amount: 30, CreatedAt: start.Add(-time.Hour)},
+		{Type: models.CashTransferOut, Amount: 40, CreatedAt: start.AddDate(0, 0, 1)},
+	}
+	returns, err = Returns(snapshots(100, 110), cash)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.10, returns[0], 1e-9)
+
+	// A portfolio funded during the interval has capital at work from then
+	cash = []models.CashTransaction{{Type: models.CashDeposit, Amount: 100, CreatedAt: start}}
+	returns, err = Returns(snapshots(0, 105), cash)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.05, returns[0], 1e-9)
+
+	_, err = Returns(snapshots(0, 10), nil)
+	assert.Error(t, err)
+
+	returns, err = Returns(snapshots(100), nil)
+	require.NoError(t, err)
+	assert.Empty(t, returns)
+}
+
+func TestIndex(t *testing.T) {
+	index := Index([]float64{0.1, -0.5, 1})
+	require.Len(t, index, 4)
+	assert.InDelta(t, 1.0, index[0], 1e-9)
+	assert.InDelta(t, 1.1, index[1], 1e-9)
+	assert.InDelta(t, 0.55, index[2], 1e-9)
+	assert.InDelta(t, 1.1, index[3], 1e-9)
+}
+
+func TestNet(t *testing.T) {
+	assert.Equal(t, 5.0, Net(models.CashTransaction{Type: models.CashTransferIn, Amount: 5}))
+	assert.Equal(t, -5.0, Net(models.CashTransaction{Type: models.CashWithdrawal, Amount: 5}))
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTunables(t *testing.T) {
+	cfg := &Config{LogLevel: "info", RateLimit: 600, FeatureFlags: "auto_trade, options ,"}
+	tunables := cfg.Tunables()
+	assert.Equal(t, 600, tunables.RateLimit)
+	assert.True(t, tunables.Enabled("auto_trade"))
+	assert.True(t, tunables.Enabled("options"))
+	assert.False(t, tunables.Enabled(""))
+	assert.NoError(t, tunables.validate())
+
+	assert.Error(t, Tunables{LogLevel: "verbose"}.validate())
+	assert.Error(t, Tunables{LogLevel: "info", RateLimit: -1}.validate())
+}
+
+func TestLiveTunables(t *testing.T) {
+	cfg := &Config{LogLevel: "info"}
+	var reloaded []string
+	cfg.OnReload(func(t Tunables) { reloaded = append(reloaded, t.LogLevel) })
+
+	cfg.live.set(Tunables{LogLevel: "debug"})
+	assert.Equal(t, "debug", cfg.Tunables().LogLevel)
+	assert.Equal(t, []string{"debug"}, reloaded)
+
+	// The fields loaded at startup are left alone
+	assert.Equal(t, "info", cfg.LogLevel)
+}
+
+func TestFileSecrets(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "JWT_SECRET"), []byte("s3cret\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fred_api_key"), []byte("fred"), 0o600))
+
+	secrets := FileSecrets{Dir: dir}
+	value, ok, err := secrets.Secret("JWT_SECRET")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "s3cret", value)
+
+	value, ok, err = secrets.Secret("FRED_API_KEY")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "fred", value)
+
+	_, ok, err = secrets.Secret("SMTP_PASSWORD")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestVaultSecrets(t *testing.T) {
+	requests := 0
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		assert.Equal(t, "/v1/secret/data/hedge-fund", r.URL.Path)
+		w.Write([]byte(`{"data":{"data":{"OPENAI_API_KEY":"sk-test"},"metadata":{"version":3}}}`))
+	}))
+	defer vault.Close()
+
+	secrets := NewVaultSecrets(vault.URL+"/", "token", "/secret/data/hedge-fund")
+	value, ok, err := secrets.Secret("OPENAI_API_KEY")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "sk-test", value)
+
+	// The secret is fetched once
+	_, ok, err = secrets.Secret("ANTHROPIC_API_KEY")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 1, requests)
+
+	_, _, err = NewVaultSecrets(vault.URL, "wrong", "secret/data/hedge-fund").Secret("OPENAI_API_KEY")
+	assert.ErrorContains(t, err, "permission denied")
+}
+
+func TestNewSecretProvider(t *testing.T) {
+	provider, err := NewSecretProvider(&Config{SecretsProvider: "file", SecretsDir: "/run/secrets"})
+	require.NoError(t, err)
+	assert.Equal(t, FileSecrets{Dir: "/run/secrets"}, provider)
+
+	_, err = NewSecretProvider(&Config{SecretsProvider: "vault"})
+	assert.ErrorContains(t, err, "VAULT_TOKEN")
+
+	_, err = NewSecretProvider(&Config{SecretsProvider: "keychain"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// secretKeys are the settings that may come from the secrets provider
+// instead of plain environment variables
+var secretKeys = []string{
+	"DATABASE_URL",
+	"DATABASE_REPLICA_URL",
+	"REDIS_URL",
+	"OPENAI_API_KEY",
+	"FINANCIAL_DATASETS_API_KEY",
+	"ANTHROPIC_API_KEY",
+	"JWT_SECRET",
+	"SERVICE_TOKEN",
+	"SMTP_PASSWORD",
+	"BROKER_API_KEY",
+	"ALPACA_API_KEY_ID",
+	"ALPACA_API_SECRET_KEY",
+	"FRED_API_KEY",
+	"S3_ACCESS_KEY_ID",
+	"S3_SECRET_ACCESS_KEY",
+	"ANALYTICS_WRITE_KEY",
+}
+
+// SecretProvider looks up secrets by their configuration key, e.g. "JWT_SECRET"
+type SecretProvider interface {
+	// Secret returns the secret and whether the provider has one for key
+	Secret(key string) (string, bool, error)
+}
+
+// NewSecretProvider returns the provider named by SECRETS_PROVIDER
+func NewSecretProvider(cfg *Config) (SecretProvider, error) {
+	switch cfg.SecretsProvider {
+	case "env", "":
+		return EnvSecrets{}, nil
+	case "file":
+		return FileSecrets{Dir: cfg.SecretsDir}, nil
+	case "vault":
+		if cfg.VaultToken == "" {
+			return nil, errors.New("VAULT_TOKEN is required with SECRETS_PROVIDER=vault")
+		}
+		return NewVaultSecrets(cfg.VaultAddr, cfg.VaultToken, cfg.VaultSecretPath), nil
+	}
+	return nil, fmt.Errorf("unknown SECRETS_PROVIDER: %s", cfg.SecretsProvider)
+}
+
+// loadSecrets sets the secret keys the configured provider has, overriding
+// the environment and config file
+func loadSecrets() error {
+	cfg := &Config{}
+	if err := viper.Unmarshal(cfg); err != nil {
+		return err
+	}
+	provider, err := NewSecretProvider(cfg)
+	if err != nil {
+		return err
+	}
+	if _, ok := provider.(EnvSecrets); ok {
+		return nil // Already read with the rest of the environment
+	}
+
+	for _, key := range secretKeys {
+		value, ok, err := provider.Secret(key)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		if ok {
+			viper.Set(key, value)
+		}
+	}
+	return nil
+}
+
+// EnvSecrets reads secrets from environment variables
+type EnvSecrets struct{}
+
+// Secret returns the environment variable named key
+func (EnvSecrets) Secret(key string) (string, bool, error) {
+	value, ok := os.LookupEnv(key)
+	return value, ok, nil
+}
+
+// FileSecrets reads secrets from files named after their key, as Docker and
+// Kubernetes mount them. Trailing newlines are trimmed.
+type FileSecrets struct {
+	Dir string
+}
+
+// Secret returns the contents of Dir/key, or of Dir/key in lower case
+func (s FileSecrets) Secret(key string) (string, bool, error) {
+	for _, name := range []string{key, strings.ToLower(key)} {
+		data, err := os.ReadFile(filepath.Join(s.Dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", false, err
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	}
+	return "", false, nil
+}
+
+// VaultSecrets reads secrets from one key/value secret in HashiCorp Vault,
+// fetched on first use
+type VaultSecrets struct {
+	addr   string
+	token  string
+	path   string
+	client *http.Client
+
+	secrets map[string]string
+}
+
+// NewVaultSecrets reads the secret at path, e.g. "secret/data/hedge-fund"
+// for version 2 of the key/value engine, whose keys are configuration keys
+func NewVaultSecrets(addr, token, path string) *VaultSecrets {
+	return &VaultSecrets{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		path:   strings.Trim(path, "/"),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Secret returns the value of key in the Vault secret
+func (s *VaultSecrets) Secret(key string) (string, bool, error) {
+	if s.secrets == nil {
+		secrets, err := s.fetch()
+		if err != nil {
+			return "", false, err
+		}
+		s.secrets = secrets
+	}
+	value, ok := s.secrets[key]
+	return value, ok, nil
+}
+
+func (s *VaultSecrets) fetch() (map[string]string, error) {
+	req, err := http.NewRequest(http.MethodGet, s.addr+"/v1/"+s.path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", s.token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("vault returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	// Version 2 of the key/value engine nests the secret's data under data
+	var payload struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("invalid vault response: %w", err)
+	}
+	var v2 struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(payload.Data, &v2); err == nil && v2.Data != nil {
+		return v2.Data, nil
+	}
+	var v1 map[string]string
+	if err := json.Unmarshal(payload.Data, &v1); err != nil {
+		return nil, fmt.Errorf("invalid vault secret: %w", err)
+	}
+	return v1, nil
+}
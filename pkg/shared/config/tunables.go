@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Tunables are the settings that take effect without a restart when the
+// config file changes. Environment variables take precedence over the file,
+// so a tunable set in the environment stays fixed.
+type Tunables struct {
+	LogLevel  string
+	RateLimit int             // Requests per minute from each client; 0 disables
+	Features  map[string]bool // Enabled feature flags
+}
+
+// Enabled reports whether the feature flag is on
+func (t Tunables) Enabled(feature string) bool {
+	return t.Features[feature]
+}
+
+func (t Tunables) validate() error {
+	switch t.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("unknown LOG_LEVEL: %s", t.LogLevel)
+	}
+	if t.RateLimit < 0 {
+		return fmt.Errorf("RATE_LIMIT must not be negative, got %d", t.RateLimit)
+	}
+	return nil
+}
+
+// liveTunables holds the current tunables, swapped whole on reload so that
+// readers never see a partial update
+type liveTunables struct {
+	current atomic.Pointer[Tunables]
+
+	mu        sync.Mutex
+	listeners []func(Tunables)
+}
+
+func newLiveTunables(t Tunables) *liveTunables {
+	live := &liveTunables{}
+	live.current.Store(&t)
+	return live
+}
+
+// tunables returns the tunables set in c's fields
+func (c *Config) tunables() Tunables {
+	features := make(map[string]bool)
+	for _, name := range strings.Split(c.FeatureFlags, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			features[name] = true
+		}
+	}
+	return Tunables{LogLevel: c.LogLevel, RateLimit: c.RateLimit, Features: features}
+}
+
+// Tunables returns the current tunables, which Watch keeps up to date
+func (c *Config) Tunables() Tunables {
+	if c.live == nil {
+		return c.tunables()
+	}
+	return *c.live.current.Load()
+}
+
+// OnReload registers fn to be called with the new tunables after each reload
+func (c *Config) OnReload(fn func(Tunables)) {
+	if c.live == nil {
+		c.live = newLiveTunables(c.tunables())
+	}
+	c.live.mu.Lock()
+	defer c.live.mu.Unlock()
+	c.live.listeners = append(c.live.listeners, fn)
+}
+
+// Watch reloads the tunables whenever the config file changes. Changes to
+// other settings are ignored until a restart, and a change that leaves the
+// tunables invalid is rejected.
+func (c *Config) Watch() {
+	if viper.ConfigFileUsed() == "" {
+		log.Printf("No config file to watch, tunables are fixed until restart")
+		return
+	}
+	if c.live == nil {
+		c.live = newLiveTunables(c.tunables())
+	}
+	viper.OnConfigChange(func(fsnotify.Event) {
+		c.reload()
+	})
+	viper.WatchConfig()
+}
+
+func (c *Config) reload() {
+	fresh := &Config{}
+	if err := viper.Unmarshal(fresh); err != nil {
+		log.Printf("Ignoring config change: %v", err)
+		return
+	}
+	t := fresh.tunables()
+	if err := t.validate(); err != nil {
+		log.Printf("Ignoring config change: %v", err)
+		return
+	}
+	c.live.set(t)
+	log.Printf("Reloaded tunables from %s", viper.ConfigFileUsed())
+}
+
+// set stores t and notifies the listeners
+func (l *liveTunables) set(t Tunables) {
+	l.current.Store(&t)
+
+	l.mu.Lock()
+	listeners := append([]func(Tunables){}, l.listeners...)
+	l.mu.Unlock()
+	for _, fn := range listeners {
+		fn(t)
+	}
+}
@@ -0,0 +1,106 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/registry"
+)
+
+// signalLimit is how many of the latest AI signals a dashboard shows
+const signalLimit = 20
+
+// Fetcher sends a request to a downstream service and decodes its JSON
+// response; registry.Client is one
+type Fetcher interface {
+	Service() string
+	Do(ctx context.Context, method, path string, body interface{}, dest interface{}) error
+}
+
+// Section is one part of a dashboard: the downstream service's response, or
+// why it could not be loaded. A failed section leaves the others intact.
+type Section struct {
+	Data      json.RawMessage `json:"data,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	LatencyMS int64           `json:"latency_ms"`
+}
+
+// Dashboard is everything a user's dashboard shows, fetched in one request
+type Dashboard struct {
+	UserID      int       `json:"user_id"`
+	PortfolioID int       `json:"portfolio_id"`
+	Summary     Section   `json:"summary"`   // Portfolio summary from the portfolio service
+	Risk        Section   `json:"risk"`      // Portfolio risk metrics from the portfolio service
+	Watchlist   Section   `json:"watchlist"` // Watchlist quotes from the market data service
+	Signals     Section   `json:"signals"`   // Latest AI signals from the AI service
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// Aggregator assembles dashboards by fanning out to the downstream services
+// in parallel, so clients make one round-trip instead of one per section
+type Aggregator struct {
+	portfolio Fetcher
+	market    Fetcher
+	ai        Fetcher
+	timeout   time.Duration
+	logger    *zap.Logger
+}
+
+// NewAggregator creates an aggregator over the registry's services. Each
+// section may take DASHBOARD_TIMEOUT.
+func NewAggregator(services *registry.Registry, cfg *config.Config, logger *zap.Logger) *Aggregator {
+	timeout := time.Duration(cfg.DashboardTimeout) * time.Millisecond
+	return &Aggregator{
+		portfolio: services.NewClient(registry.ServicePortfolio, timeout),
+		market:    services.NewClient(registry.ServiceMarketData, timeout),
+		ai:        services.NewClient(registry.ServiceAI, timeout),
+		timeout:   timeout,
+		logger:    logger,
+	}
+}
+
+// Build fetches every section of a user's dashboard for one of their
+// portfolios at once. Sections that fail or time out carry their error.
+func (a *Aggregator) Build(ctx context.Context, userID, portfolioID int) *Dashboard {
+	d := &Dashboard{UserID: userID, PortfolioID: portfolioID}
+
+	var wg sync.WaitGroup
+	fetch := func(section *Section, fetcher Fetcher, path string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			*section = a.fetch(ctx, fetcher, path)
+		}()
+	}
+	fetch(&d.Summary, a.portfolio, fmt.Sprintf("/api/v1/portfolios/%d/summary", portfolioID))
+	fetch(&d.Risk, a.portfolio, fmt.Sprintf("/api/v1/portfolios/%d/risk", portfolioID))
+	fetch(&d.Watchlist, a.market, fmt.Sprintf("/api/v1/market/watchlists/%d", userID))
+	fetch(&d.Signals, a.ai, fmt.Sprintf("/api/v1/ai/signals/latest?limit=%d", signalLimit))
+	wg.Wait()
+
+	d.GeneratedAt = time.Now()
+	return d
+}
+
+func (a *Aggregator) fetch(ctx context.Context, fetcher Fetcher, path string) Section {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	start := time.Now()
+	var data json.RawMessage
+	err := fetcher.Do(ctx, http.MethodGet, path, nil, &data)
+	section := Section{LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		a.logger.Warn("Dashboard section failed", zap.Error(err), zap.String("service", fetcher.Service()), zap.String("path", path))
+		section.Error = err.Error()
+		return section
+	}
+	section.Data = data
+	return section
+}
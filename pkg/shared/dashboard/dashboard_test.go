@@ -0,0 +1,71 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// stubFetcher answers each path with a canned response, an error, or by
+// blocking until the request is cancelled
+type stubFetcher struct {
+	name      string
+	responses map[string]string
+	errs      map[string]error
+	block     bool
+
+	mu    sync.Mutex
+	paths []string
+}
+
+func (f *stubFetcher) Service() string {
+	return f.name
+}
+
+func (f *stubFetcher) Do(ctx context.Context, method, path string, body interface{}, dest interface{}) error {
+	f.mu.Lock()
+	f.paths = append(f.paths, path)
+	f.mu.Unlock()
+
+	if f.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if err := f.errs[path]; err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(f.responses[path]), dest)
+}
+
+func TestBuildIsolatesFailedSections(t *testing.T) {
+	portfolio := &stubFetcher{
+		name:      "portfolio-service",
+		responses: map[string]string{"/api/v1/portfolios/9/summary": `{"total_value":1000}`},
+		errs:      map[string]error{"/api/v1/portfolios/9/risk": errors.New("portfolio-service returned status 500")},
+	}
+	market := &stubFetcher{
+		name:      "market-data-service",
+		responses: map[string]string{"/api/v1/market/watchlists/4": `{"user_id":4,"quotes":[]}`},
+	}
+	ai := &stubFetcher{name: "ai-service", block: true}
+	a := &Aggregator{portfolio: portfolio, market: market, ai: ai, timeout: 50 * time.Millisecond, logger: zap.NewNop()}
+
+	d := a.Build(context.Background(), 4, 9)
+
+	assert.Equal(t, 4, d.UserID)
+	assert.Equal(t, 9, d.PortfolioID)
+	assert.JSONEq(t, `{"total_value":1000}`, string(d.Summary.Data))
+	assert.Empty(t, d.Summary.Error)
+	assert.JSONEq(t, `{"user_id":4,"quotes":[]}`, string(d.Watchlist.Data))
+	assert.Contains(t, d.Risk.Error, "status 500")
+	assert.Nil(t, d.Risk.Data)
+	assert.Equal(t, context.DeadlineExceeded.Error(), d.Signals.Error)
+	assert.ElementsMatch(t, []string{"/api/v1/portfolios/9/summary", "/api/v1/portfolios/9/risk"}, portfolio.paths)
+	assert.Equal(t, []string{"/api/v1/ai/signals/latest?limit=20"}, ai.paths)
+}
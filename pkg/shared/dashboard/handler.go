@@ -0,0 +1,34 @@
+package dashboard
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"hedge-fund/pkg/shared/apierror"
+)
+
+// GetDashboard godoc
+// @Summary Get a dashboard
+// @Description Portfolio summary, risk metrics, watchlist quotes and the latest AI signals in one response, fetched from the downstream services in parallel. A section that fails or times out carries its error and the others are still returned.
+// @Tags dashboard
+// @Produce json
+// @Param user_id query int true "User ID"
+// @Param portfolio_id query int true "Portfolio ID"
+// @Success 200 {object} Dashboard
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/dashboard [get]
+func (a *Aggregator) GetDashboard(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Query("user_id"))
+	if err != nil || userID <= 0 {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid user ID"})
+		return
+	}
+	portfolioID, err := strconv.Atoi(c.Query("portfolio_id"))
+	if err != nil || portfolioID <= 0 {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid portfolio ID"})
+		return
+	}
+
+	c.JSON(http.StatusOK, a.Build(c.Request.Context(), userID, portfolioID))
+}
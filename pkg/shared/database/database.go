@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -14,28 +15,52 @@ import (
 
 type DB struct {
 	*sql.DB
+
+	replica *sql.DB // Nil without DATABASE_REPLICA_URL
+	pool    poolConfig
+
+	down        atomic.Bool // Set by Monitor while the primary is unreachable
+	replicaDown atomic.Bool // Set by Monitor while the replica is unreachable
 }
 
-// Connect establishes a connection to the PostgreSQL database
+// Connect establishes a connection to the PostgreSQL database, and to its
+// read replica if DATABASE_REPLICA_URL is set
 func Connect(cfg *config.Config) (*DB, error) {
-	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	pool := newPoolConfig(cfg)
+	db, err := open(cfg.DatabaseURL, pool, "primary")
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
-	// Configure connection pool
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	logger.Info("Successfully connected to PostgreSQL database",
+		zap.Int("max_open_conns", pool.maxOpen),
+		zap.Int("max_idle_conns", pool.maxIdle))
+
+	result := &DB{DB: db, pool: pool}
+	if cfg.DatabaseReplicaURL == "" {
+		return result, nil
+	}
 
-	// Test the connection
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	replica, err := open(cfg.DatabaseReplicaURL, pool, "replica")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("read replica: %w", err)
 	}
+	result.replica = replica
+
+	logger.Info("Successfully connected to PostgreSQL read replica")
 
-	logger.Info("Successfully connected to PostgreSQL database")
+	return result, nil
+}
 
-	return &DB{db}, nil
+// Reader returns the pool for read-only queries: the read replica when one
+// is configured and reachable, otherwise the primary. Reads from a replica
+// may miss the latest writes by its replication lag.
+func (db *DB) Reader() *sql.DB {
+	if db.replica == nil || db.replicaDown.Load() {
+		return db.DB
+	}
+	return db.replica
 }
 
 // Health checks if the database connection is healthy
@@ -43,6 +68,11 @@ func (db *DB) Health() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	return db.HealthContext(ctx)
+}
+
+// HealthContext checks if the database connection is healthy within ctx
+func (db *DB) HealthContext(ctx context.Context) error {
 	if err := db.PingContext(ctx); err != nil {
 		return fmt.Errorf("database health check failed: %w", err)
 	}
@@ -53,6 +83,11 @@ func (db *DB) Health() error {
 // Close closes the database connection
 func (db *DB) Close() error {
 	logger.Info("Closing database connection")
+	if db.replica != nil {
+		if err := db.replica.Close(); err != nil {
+			logger.Warn("Failed to close read replica connection", zap.Error(err))
+		}
+	}
 	return db.DB.Close()
 }
 
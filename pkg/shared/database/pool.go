@@ -0,0 +1,205 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/logger"
+)
+
+// maxBackoff caps the wait between connection attempts
+const maxBackoff = 30 * time.Second
+
+// poolConfig is the connection pool and retry configuration, with defaults
+// for unset values
+type poolConfig struct {
+	maxOpen        int
+	maxIdle        int
+	maxLifetime    time.Duration
+	retries        int
+	backoff        time.Duration
+	healthInterval time.Duration
+}
+
+func newPoolConfig(cfg *config.Config) poolConfig {
+	pool := poolConfig{
+		maxOpen:        cfg.DBMaxOpenConns,
+		maxIdle:        cfg.DBMaxIdleConns,
+		maxLifetime:    time.Duration(cfg.DBConnMaxLifetime) * time.Second,
+		retries:        cfg.DBConnectRetries,
+		backoff:        time.Duration(cfg.DBConnectBackoff) * time.Millisecond,
+		healthInterval: time.Duration(cfg.DBHealthInterval) * time.Second,
+	}
+	if pool.maxOpen <= 0 {
+		pool.maxOpen = 25
+	}
+	if pool.maxIdle <= 0 {
+		pool.maxIdle = 5
+	}
+	if pool.maxIdle > pool.maxOpen {
+		pool.maxIdle = pool.maxOpen
+	}
+	if pool.maxLifetime <= 0 {
+		pool.maxLifetime = 5 * time.Minute
+	}
+	if pool.retries < 0 {
+		pool.retries = 0
+	}
+	if pool.backoff <= 0 {
+		pool.backoff = 500 * time.Millisecond
+	}
+	if pool.healthInterval <= 0 {
+		pool.healthInterval = 15 * time.Second
+	}
+	return pool
+}
+
+// backoffDelay is the wait before retry number attempt (from 0): backoff
+// doubled attempt times, up to maxBackoff
+func backoffDelay(backoff time.Duration, attempt int) time.Duration {
+	delay := backoff
+	for i := 0; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
+
+// retry calls fn until it succeeds, retrying up to retries times with
+// exponential backoff. onRetry is told of each failure before its wait. The
+// last error is returned once the retries are exhausted or ctx is done.
+func retry(ctx context.Context, retries int, backoff time.Duration, onRetry func(attempt int, err error, wait time.Duration), fn func(context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= retries {
+			return err
+		}
+
+		wait := backoffDelay(backoff, attempt)
+		if onRetry != nil {
+			onRetry(attempt+1, err, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// open opens a pool to the database at url, retrying its first connection
+// while Postgres is still starting. role names the database in logs.
+func open(url string, pool poolConfig, role string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(pool.maxOpen)
+	db.SetMaxIdleConns(pool.maxIdle)
+	db.SetConnMaxLifetime(pool.maxLifetime)
+
+	err = retry(context.Background(), pool.retries, pool.backoff, func(attempt int, err error, wait time.Duration) {
+		logger.Warn("Database not reachable, retrying",
+			zap.String("database", role),
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", wait),
+			zap.Error(err))
+	}, db.PingContext)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
+}
+
+// Up reports whether the primary was reachable at Monitor's last check.
+// Without Monitor running it is always true.
+func (db *DB) Up() bool {
+	return !db.down.Load()
+}
+
+// Monitor checks the connections every DB_HEALTH_INTERVAL until ctx is
+// done. While a database is unreachable it is checked again with
+// exponential backoff, so that the pool's broken connections are replaced
+// and recovery is logged as soon as it is back. Reads go to the primary
+// while the replica is unreachable.
+func (db *DB) Monitor(ctx context.Context) {
+	if db.pool.healthInterval <= 0 {
+		db.pool = newPoolConfig(&config.Config{})
+	}
+
+	targets := []*monitored{{role: "primary", db: db.DB, down: &db.down}}
+	if db.replica != nil {
+		targets = append(targets, &monitored{role: "replica", db: db.replica, down: &db.replicaDown})
+	}
+
+	wait := db.pool.healthInterval
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		wait = db.pool.healthInterval
+		for _, target := range targets {
+			if !target.check(ctx) {
+				return
+			}
+			if target.failures > 0 {
+				if delay := backoffDelay(db.pool.backoff, target.failures-1); delay < wait {
+					wait = delay
+				}
+			}
+		}
+	}
+}
+
+// monitored is a database Monitor checks
+type monitored struct {
+	role     string
+	db       *sql.DB
+	down     *atomic.Bool
+	failures int // Consecutive failed checks
+	lostAt   time.Time
+}
+
+// check pings the database, logging lost and restored connectivity. It
+// returns false once ctx is done.
+func (m *monitored) check(ctx context.Context) bool {
+	checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	err := m.db.PingContext(checkCtx)
+	cancel()
+	if ctx.Err() != nil {
+		return false
+	}
+
+	switch {
+	case err == nil && m.failures > 0:
+		logger.Info("Database connection restored",
+			zap.String("database", m.role),
+			zap.Duration("downtime", time.Since(m.lostAt)),
+			zap.Int("failed_checks", m.failures))
+		m.failures = 0
+		m.down.Store(false)
+	case err != nil && m.failures == 0:
+		logger.Error("Database connection lost", zap.String("database", m.role), zap.Error(err))
+		m.lostAt = time.Now()
+		m.failures++
+		m.down.Store(true)
+	case err != nil:
+		m.failures++
+		logger.Warn("Database still unreachable",
+			zap.String("database", m.role),
+			zap.Int("failed_checks", m.failures),
+			zap.Error(err))
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/config"
+)
+
+func TestNewPoolConfig(t *testing.T) {
+	pool := newPoolConfig(&config.Config{DBMaxOpenConns: 10, DBMaxIdleConns: 20, DBConnectRetries: 3, DBConnectBackoff: 250})
+	assert.Equal(t, 10, pool.maxOpen)
+	assert.Equal(t, 10, pool.maxIdle, "idle connections are capped at the open limit")
+	assert.Equal(t, 3, pool.retries)
+	assert.Equal(t, 250*time.Millisecond, pool.backoff)
+
+	// Unset values fall back to the defaults
+	pool = newPoolConfig(&config.Config{})
+	assert.Equal(t, 25, pool.maxOpen)
+	assert.Equal(t, 5, pool.maxIdle)
+	assert.Equal(t, 5*time.Minute, pool.maxLifetime)
+	assert.Equal(t, 15*time.Second, pool.healthInterval)
+}
+
+func TestBackoffDelay(t *testing.T) {
+	assert.Equal(t, 500*time.Millisecond, backoffDelay(500*time.Millisecond, 0))
+	assert.Equal(t, time.Second, backoffDelay(500*time.Millisecond, 1))
+	assert.Equal(t, 4*time.Second, backoffDelay(500*time.Millisecond, 3))
+	assert.Equal(t, maxBackoff, backoffDelay(500*time.Millisecond, 10))
+	assert.Equal(t, maxBackoff, backoffDelay(500*time.Millisecond, 1000))
+}
+
+func TestRetry(t *testing.T) {
+	errDown := errors.New("connection refused")
+
+	// Succeeds once the database is up, after reporting each failure
+	calls := 0
+	var waits []time.Duration
+	err := retry(context.Background(), 5, time.Millisecond, func(attempt int, err error, wait time.Duration) {
+		assert.Equal(t, len(waits)+1, attempt)
+		assert.ErrorIs(t, err, errDown)
+		waits = append(waits, wait)
+	}, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errDown
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond}, waits)
+
+	// Gives up with the last error after the retries
+	calls = 0
+	err = retry(context.Background(), 2, time.Millisecond, nil, func(context.Context) error {
+		calls++
+		return errDown
+	})
+	assert.ErrorIs(t, err, errDown)
+	assert.Equal(t, 3, calls)
+
+	// Stops waiting when the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	err = retry(ctx, 5, time.Hour, nil, func(context.Context) error {
+		calls++
+		return errDown
+	})
+	assert.ErrorIs(t, err, errDown)
+	assert.Equal(t, 1, calls)
+}
+
+func TestReader(t *testing.T) {
+	primary, err := sql.Open("postgres", "postgres://localhost/primary")
+	require.NoError(t, err)
+	defer primary.Close()
+	replica, err := sql.Open("postgres", "postgres://localhost/replica")
+	require.NoError(t, err)
+	defer replica.Close()
+
+	// Without a replica, reads go to the primary
+	db := &DB{DB: primary}
+	assert.Same(t, primary, db.Reader())
+
+	db.replica = replica
+	assert.Same(t, replica, db.Reader())
+
+	// And back to the primary while the replica is unreachable
+	db.replicaDown.Store(true)
+	assert.Same(t, primary, db.Reader())
+}
@@ -0,0 +1,95 @@
+package drawdown
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"hedge-fund/pkg/shared/cashflow"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Analyze walks a portfolio's snapshots, in date order, tracking the
+// running peak of its value net of the cash transactions made between them,
+// oldest first: the time-weighted index of cashflow.Index. Deposits and
+// withdrawals therefore neither hide nor open drawdowns. Each decline below
+// a peak is a drawdown period that ends when the index regains the peak; one
+// still open at the last snapshot is the current drawdown. Snapshots with no
+// value, whose next return is undefined, are an error.
+func Analyze(snapshots []models.PortfolioSnapshot, cash []models.CashTransaction) (models.DrawdownAnalysis, error) {
+	analysis := models.DrawdownAnalysis{
+		Periods:    []models.DrawdownPeriod{},
+		Underwater: make([]models.UnderwaterPoint, 0, len(snapshots)),
+	}
+	if len(snapshots) == 0 {
+		return analysis, nil
+	}
+	returns, err := cashflow.Returns(snapshots, cash)
+	if err != nil {
+		return analysis, err
+	}
+	index := cashflow.Index(returns)
+	first, last := snapshots[0], snapshots[len(snapshots)-1]
+	analysis.PortfolioID = first.PortfolioID
+	analysis.StartDate = first.SnapshotDate
+	analysis.EndDate = last.SnapshotDate
+
+	var peak, deepest float64
+	var peakDate time.Time
+	var open *models.DrawdownPeriod
+	for i, snapshot := range snapshots {
+		point := models.UnderwaterPoint{Date: snapshot.SnapshotDate, Value: snapshot.TotalValue}
+		if index[i] >= peak {
+			if open != nil {
+				recovery := snapshot.SnapshotDate
+				open.Recovery = &recovery
+				open.Days = days(open.Peak, recovery)
+				analysis.Periods = append(analysis.Periods, *open)
+				open = nil
+			}
+			peak, peakDate = index[i], snapshot.SnapshotDate
+		} else {
+			point.Drawdown = (peak - index[i]) / peak * 100
+			if open == nil {
+				open = &models.DrawdownPeriod{Peak: peakDate}
+			}
+			if point.Drawdown > open.Depth {
+				open.Depth, open.Trough = point.Drawdown, snapshot.SnapshotDate
+			}
+		}
+		deepest = math.Max(deepest, point.Drawdown)
+		point.MaxDrawdown = deepest
+		// The peak in the day's money: what the value would be at the peak's index
+		if index[i] > 0 {
+			point.Peak = snapshot.TotalValue * peak / index[i]
+		}
+		analysis.Underwater = append(analysis.Underwater, point)
+	}
+	if open != nil {
+		open.Days = days(open.Peak, last.SnapshotDate)
+		analysis.Periods = append(analysis.Periods, *open)
+		analysis.CurrentDrawdown = analysis.Underwater[len(analysis.Underwater)-1].Drawdown
+		analysis.CurrentDrawdownDays = open.Days
+	}
+
+	sort.SliceStable(analysis.Periods, func(i, j int) bool {
+		return analysis.Periods[i].Depth > analysis.Periods[j].Depth
+	})
+	for _, period := range analysis.Periods {
+		analysis.LongestDrawdownDays = max(analysis.LongestDrawdownDays, period.Days)
+	}
+	if len(analysis.Periods) > 0 {
+		worst := analysis.Periods[0]
+		analysis.MaxDrawdown = worst.Depth
+		analysis.MaxDrawdownPeak = &worst.Peak
+		analysis.MaxDrawdownTrough = &worst.Trough
+		analysis.MaxDrawdownRecovery = worst.Recovery
+		analysis.MaxDrawdownDays = worst.Days
+	}
+	return analysis, nil
+}
+
+// days returns the calendar days from start to end
+func days(start, end time.Time) int {
+	return int(math.Round(end.Sub(start).Hours() / 24))
+}
@@ -0,0 +1,93 @@
+package drawdown
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC)
+}
+
+func snapshots(values ...float64) []models.PortfolioSnapshot {
+	result := make([]models.PortfolioSnapshot, len(values))
+	for i, value := range values {
+		result[i] = models.PortfolioSnapshot{PortfolioID: 3, SnapshotDate: day(i + 1), TotalValue: value, CreatedAt: day(i + 1)}
+	}
+	return result
+}
+
+func TestAnalyze(t *testing.T) {
+	// A 10% drawdown recovered on day 4, then a 20% one still open
+	analysis, err := Analyze(snapshots(100, 90, 95, 100, 110, 88, 99), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, analysis.PortfolioID)
+	assert.Equal(t, day(1), analysis.StartDate)
+	assert.Equal(t, day(7), analysis.EndDate)
+	assert.InDelta(t, 20.0, analysis.MaxDrawdown, 1e-9)
+	assert.Equal(t, day(5), *analysis.MaxDrawdownPeak)
+	assert.Equal(t, day(6), *analysis.MaxDrawdownTrough)
+	assert.Nil(t, analysis.MaxDrawdownRecovery)
+	assert.Equal(t, 2, analysis.MaxDrawdownDays)
+	assert.Equal(t, 3, analysis.LongestDrawdownDays)
+	assert.InDelta(t, 10.0, analysis.CurrentDrawdown, 1e-9)
+	assert.Equal(t, 2, analysis.CurrentDrawdownDays)
+
+	require.Len(t, analysis.Periods, 2)
+	recovered := analysis.Periods[1]
+	assert.Equal(t, day(1), recovered.Peak)
+	assert.Equal(t, day(2), recovered.Trough)
+	assert.Equal(t, day(4), *recovered.Recovery)
+	assert.InDelta(t, 10.0, recovered.Depth, 1e-9)
+	assert.Equal(t, 3, recovered.Days)
+
+	require.Len(t, analysis.Underwater, 7)
+	assert.InDelta(t, 5.0, analysis.Underwater[2].Drawdown, 1e-9)
+	assert.InDelta(t, 10.0, analysis.Underwater[2].MaxDrawdown, 1e-9)
+	assert.Equal(t, 0.0, analysis.Underwater[4].Drawdown)
+	assert.InDelta(t, 110.0, analysis.Underwater[6].Peak, 1e-9)
+	assert.InDelta(t, 20.0, analysis.Underwater[6].MaxDrawdown, 1e-9)
+}
+
+func TestAnalyzeWithoutDrawdown(t *testing.T) {
+	analysis, err := Analyze(snapshots(100, 100, 105), nil)
+	require.NoError(t, err)
+	assert.Zero(t, analysis.MaxDrawdown)
+	assert.Nil(t, analysis.MaxDrawdownPeak)
+	assert.Zero(t, analysis.CurrentDrawdownDays)
+	assert.Empty(t, analysis.Periods)
+	assert.Len(t, analysis.Underwater, 3)
+
+	empty, err := Analyze(nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, empty.Underwater)
+	assert.NotNil(t, empty.Periods)
+}
+
+func TestAnalyzeNetsCashFlows(t *testing.T) {
+	// A withdrawal of 50 before day 2 and a deposit of 100 before day 5; the
+	// investments fall 10% on day 3 and recover on day 4
+	cash := []models.CashTransaction{
+		{Type: models.CashWithdrawal, Amount: 50, CreatedAt: day(2).Add(-time.Minute)},
+		{Type: models.CashDeposit, Amount: 100, CreatedAt: day(5).Add(-time.Minute)},
+	}
+	analysis, err := Analyze(snapshots(100, 50, 45, 50, 150), cash)
+	require.NoError(t, err)
+
+	require.Len(t, analysis.Periods, 1)
+	period := analysis.Periods[0]
+	assert.Equal(t, day(2), period.Peak)
+	assert.Equal(t, day(3), period.Trough)
+	assert.Equal(t, day(4), *period.Recovery)
+	assert.InDelta(t, 10.0, analysis.MaxDrawdown, 0.01)
+	assert.InDelta(t, 0.0, analysis.CurrentDrawdown, 1e-9)
+	assert.InDelta(t, 50.0, analysis.Underwater[2].Peak, 0.01) // In the money left after the withdrawal
+
+	_, err = Analyze(snapshots(0, 10), nil)
+	assert.Error(t, err)
+}
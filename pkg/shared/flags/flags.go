@@ -0,0 +1,235 @@
+// Package flags gates risky subsystems behind feature flags that roll out
+// to chosen users and a percentage of the rest. Flags are stored in Redis so
+// every service instance shares them; a flag not stored there falls back to
+// the FEATURE_FLAGS tunable, which turns it on for everyone.
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// Flags gating risky subsystems
+const (
+	AutoTrade     = "auto-trade"     // Orders placed from AI consensus signals
+	LiveExecution = "live-execution" // Orders routed to live broker accounts
+	OrderEngine   = "order-engine"   // Market orders re-quoted against the slippage limit before they fill
+)
+
+const (
+	flagsKey = "system:feature_flags"
+
+	// How long flags read from Redis are reused before re-reading
+	cacheTTL = 2 * time.Second
+)
+
+// ErrDisabled is returned for operations gated behind a flag that is off
+// for the user
+var ErrDisabled = errors.New("feature is not enabled")
+
+// Flag is a feature flag's rollout. A flag that is not enabled is off for
+// everyone; an enabled one is on for its users and for Percentage percent of
+// the others.
+type Flag struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Enabled     bool      `json:"enabled"`
+	Percentage  int       `json:"percentage"`      // 0-100
+	Users       []int     `json:"users,omitempty"` // On regardless of the percentage
+	Source      string    `json:"source"`          // "redis", or "config" for FEATURE_FLAGS
+	UpdatedBy   string    `json:"updated_by,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Flag sources
+const (
+	SourceRedis  = "redis"
+	SourceConfig = "config"
+)
+
+// OnFor reports whether the flag is on for the user. Users are placed in
+// the rollout by a hash of the flag and user, so raising the percentage
+// keeps every user already in it.
+func (f Flag) OnFor(userID int) bool {
+	if !f.Enabled {
+		return false
+	}
+	for _, id := range f.Users {
+		if id == userID {
+			return true
+		}
+	}
+	return bucket(f.Name, userID) < f.Percentage
+}
+
+// bucket places a user in [0, 100) for a flag
+func bucket(name string, userID int) int {
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + strconv.Itoa(userID)))
+	return int(h.Sum32() % 100)
+}
+
+func (f Flag) validate() error {
+	if f.Name == "" {
+		return errors.New("flag name is required")
+	}
+	if f.Percentage < 0 || f.Percentage > 100 {
+		return fmt.Errorf("percentage must be between 0 and 100, got %d", f.Percentage)
+	}
+	return nil
+}
+
+// Manager evaluates feature flags, caching those read from Redis briefly
+type Manager struct {
+	redis *redis.Client
+	cfg   *config.Config
+
+	mu       sync.Mutex
+	cached   map[string]Flag
+	cachedAt time.Time
+}
+
+// NewManager creates a flag manager. Without Redis, only the FEATURE_FLAGS
+// tunable is read.
+func NewManager(redisClient *redis.Client, cfg *config.Config) *Manager {
+	return &Manager{redis: redisClient, cfg: cfg}
+}
+
+// Enabled reports whether the flag is on for the user. A flag neither stored
+// nor set in FEATURE_FLAGS is off.
+func (m *Manager) Enabled(ctx context.Context, name string, userID int) bool {
+	if flag, ok := m.stored(ctx)[name]; ok {
+		return flag.OnFor(userID)
+	}
+	return m.cfg.Tunables().Enabled(name)
+}
+
+// Require returns ErrDisabled unless the flag is on for the user
+func (m *Manager) Require(ctx context.Context, name string, userID int) error {
+	if !m.Enabled(ctx, name, userID) {
+		return fmt.Errorf("%w: %s", ErrDisabled, name)
+	}
+	return nil
+}
+
+// List returns every flag, stored or set in FEATURE_FLAGS, by name
+func (m *Manager) List(ctx context.Context) []Flag {
+	stored := m.stored(ctx)
+	all := make([]Flag, 0, len(stored))
+	for _, flag := range stored {
+		all = append(all, flag)
+	}
+	for name, on := range m.cfg.Tunables().Features {
+		if _, ok := stored[name]; !ok {
+			all = append(all, Flag{Name: name, Enabled: on, Percentage: 100, Source: SourceConfig})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// ForUser returns whether each listed flag is on for the user
+func (m *Manager) ForUser(ctx context.Context, userID int) map[string]bool {
+	result := make(map[string]bool)
+	for _, flag := range m.List(ctx) {
+		result[flag.Name] = flag.OnFor(userID)
+	}
+	return result
+}
+
+// Set stores a flag, overriding FEATURE_FLAGS for it
+func (m *Manager) Set(ctx context.Context, flag Flag) error {
+	if err := flag.validate(); err != nil {
+		return err
+	}
+	if m.redis == nil {
+		return errors.New("feature flags are read-only without Redis")
+	}
+	flag.Source = SourceRedis
+	flag.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(flag)
+	if err != nil {
+		return err
+	}
+	if err := m.redis.HSet(ctx, flagsKey, flag.Name, data).Err(); err != nil {
+		return fmt.Errorf("failed to store feature flag: %w", err)
+	}
+	m.invalidate()
+
+	logger.Info("Feature flag set",
+		zap.String("flag", flag.Name),
+		zap.Bool("enabled", flag.Enabled),
+		zap.Int("percentage", flag.Percentage),
+		zap.Int("users", len(flag.Users)))
+	return nil
+}
+
+// Delete removes a stored flag, leaving it to FEATURE_FLAGS
+func (m *Manager) Delete(ctx context.Context, name string) error {
+	if m.redis == nil {
+		return errors.New("feature flags are read-only without Redis")
+	}
+	if err := m.redis.HDel(ctx, flagsKey, name).Err(); err != nil {
+		return fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+	m.invalidate()
+
+	logger.Info("Feature flag deleted", zap.String("flag", name))
+	return nil
+}
+
+// Helper functions
+
+func (m *Manager) stored(ctx context.Context) map[string]Flag {
+	if m.redis == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cached != nil && time.Since(m.cachedAt) < cacheTTL {
+		return m.cached
+	}
+
+	raw, err := m.redis.HGetAll(ctx, flagsKey).Result()
+	if err != nil {
+		// Keep the last known flags while Redis is unavailable
+		logger.Warn("Failed to read feature flags", zap.Error(err))
+		return m.cached
+	}
+
+	flags := make(map[string]Flag, len(raw))
+	for name, data := range raw {
+		var flag Flag
+		if err := json.Unmarshal([]byte(data), &flag); err != nil {
+			logger.Warn("Failed to decode feature flag", zap.String("flag", name), zap.Error(err))
+			continue
+		}
+		flags[name] = flag
+	}
+
+	m.cached = flags
+	m.cachedAt = time.Now()
+	return m.cached
+}
+
+func (m *Manager) invalidate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cachedAt = time.Time{}
+}
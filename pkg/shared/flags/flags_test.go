@@ -0,0 +1,74 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/config"
+)
+
+func TestOnFor(t *testing.T) {
+	flag := Flag{Name: LiveExecution, Enabled: true, Users: []int{7}}
+	assert.True(t, flag.OnFor(7))
+	assert.False(t, flag.OnFor(8))
+
+	flag.Percentage = 100
+	assert.True(t, flag.OnFor(8))
+
+	// A disabled flag is off even for its users
+	flag.Enabled = false
+	assert.False(t, flag.OnFor(7))
+}
+
+func TestPercentageRollout(t *testing.T) {
+	onAt := func(percentage int) map[int]bool {
+		flag := Flag{Name: AutoTrade, Enabled: true, Percentage: percentage}
+		on := make(map[int]bool)
+		for user := 1; user <= 1000; user++ {
+			if flag.OnFor(user) {
+				on[user] = true
+			}
+		}
+		return on
+	}
+
+	quarter, half := onAt(25), onAt(50)
+	assert.InDelta(t, 250, len(quarter), 50)
+	assert.InDelta(t, 500, len(half), 50)
+
+	// Raising the percentage keeps every user already in the rollout
+	for user := range quarter {
+		assert.True(t, half[user], "user %d left the rollout", user)
+	}
+	assert.Empty(t, onAt(0))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Flag{Name: OrderEngine, Percentage: 100}.validate())
+	assert.Error(t, Flag{Percentage: 10}.validate())
+	assert.Error(t, Flag{Name: OrderEngine, Percentage: 101}.validate())
+	assert.Error(t, Flag{Name: OrderEngine, Percentage: -1}.validate())
+}
+
+func TestConfigFlags(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(nil, &config.Config{LogLevel: "info", FeatureFlags: "order-engine"})
+
+	assert.True(t, m.Enabled(ctx, OrderEngine, 1))
+	assert.False(t, m.Enabled(ctx, AutoTrade, 1))
+	assert.NoError(t, m.Require(ctx, OrderEngine, 1))
+
+	err := m.Require(ctx, AutoTrade, 1)
+	assert.True(t, errors.Is(err, ErrDisabled))
+	assert.Contains(t, err.Error(), AutoTrade)
+
+	flags := m.List(ctx)
+	require.Len(t, flags, 1)
+	assert.Equal(t, Flag{Name: OrderEngine, Enabled: true, Percentage: 100, Source: SourceConfig}, flags[0])
+	assert.Equal(t, map[string]bool{OrderEngine: true}, m.ForUser(ctx, 1))
+
+	assert.Error(t, m.Set(ctx, Flag{Name: AutoTrade, Enabled: true}))
+}
@@ -0,0 +1,109 @@
+package flags
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"hedge-fund/pkg/shared/apierror"
+)
+
+// SetFlagRequest is the admin API payload for a flag's rollout
+type SetFlagRequest struct {
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Percentage  int    `json:"percentage" binding:"min=0,max=100"`
+	Users       []int  `json:"users"`
+	UpdatedBy   string `json:"updated_by"`
+}
+
+// ListFlags godoc
+// @Summary List feature flags
+// @Description Flags stored in Redis and those only set through FEATURE_FLAGS
+// @Tags admin
+// @Produce json
+// @Success 200 {array} Flag
+// @Router /api/v1/admin/feature-flags [get]
+func (m *Manager) ListFlags(c *gin.Context) {
+	c.JSON(http.StatusOK, m.List(c.Request.Context()))
+}
+
+// SetFlag godoc
+// @Summary Set a feature flag's rollout
+// @Description Turns the flag on for the listed users and a percentage of the others, overriding FEATURE_FLAGS
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Flag name"
+// @Param request body SetFlagRequest true "Rollout"
+// @Success 200 {object} Flag
+// @Failure 400 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/admin/feature-flags/{name} [put]
+func (m *Manager) SetFlag(c *gin.Context) {
+	var req SetFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	flag := Flag{
+		Name:        c.Param("name"),
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		Percentage:  req.Percentage,
+		Users:       req.Users,
+		UpdatedBy:   req.UpdatedBy,
+	}
+	if err := flag.validate(); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid feature flag", Details: err.Error()})
+		return
+	}
+	if err := m.Set(c.Request.Context(), flag); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to set feature flag", Details: err.Error()})
+		return
+	}
+
+	for _, stored := range m.List(c.Request.Context()) {
+		if stored.Name == flag.Name {
+			flag = stored
+		}
+	}
+	c.JSON(http.StatusOK, flag)
+}
+
+// DeleteFlag godoc
+// @Summary Delete a feature flag
+// @Description The flag falls back to FEATURE_FLAGS
+// @Tags admin
+// @Param name path string true "Flag name"
+// @Success 204
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/admin/feature-flags/{name} [delete]
+func (m *Manager) DeleteFlag(c *gin.Context) {
+	if err := m.Delete(c.Request.Context(), c.Param("name")); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to delete feature flag", Details: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetUserFlags godoc
+// @Summary Feature flags of a user
+// @Description Whether each flag is on for the user, so clients can hide gated features
+// @Tags feature-flags
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {object} map[string]bool
+// @Failure 400 {object} apierror.Response
+// @Router /api/v1/users/{user_id}/feature-flags [get]
+func (m *Manager) GetUserFlags(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid user ID"})
+		return
+	}
+
+	c.JSON(http.StatusOK, m.ForUser(c.Request.Context(), userID))
+}
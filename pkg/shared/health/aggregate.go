@@ -0,0 +1,169 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/registry"
+)
+
+// Instances lists every instance of the downstream services;
+// registry.Registry is one
+type Instances interface {
+	Endpoints() map[string][]registry.Endpoint
+}
+
+// InstanceHealth is the readiness of one instance of a downstream service
+type InstanceHealth struct {
+	URL       string  `json:"url"`
+	Status    string  `json:"status"`
+	LatencyMS int64   `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`  // Why the instance could not be checked
+	Report    *Report `json:"report,omitempty"` // The instance's readiness report
+}
+
+// ServiceHealth is the readiness of every instance of a downstream service.
+// A service is down when none of its instances can serve.
+type ServiceHealth struct {
+	Service   string           `json:"service"`
+	Status    string           `json:"status"`
+	Instances []InstanceHealth `json:"instances"`
+}
+
+// Summary is the health of the gateway and every downstream service
+type Summary struct {
+	Status   string          `json:"status"`
+	Gateway  Report          `json:"gateway"`
+	Services []ServiceHealth `json:"services"`
+	Time     time.Time       `json:"time"`
+}
+
+// Aggregator collects the readiness of every downstream service instance for
+// the gateway
+type Aggregator struct {
+	gateway   *Checker
+	instances Instances
+	client    *http.Client
+	logger    *zap.Logger
+}
+
+// NewAggregator creates an aggregator over the registry's services. Each
+// instance may take HEALTH_CHECK_TIMEOUT to answer.
+func NewAggregator(gateway *Checker, services *registry.Registry, cfg *config.Config, logger *zap.Logger) *Aggregator {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Aggregator{
+		gateway:   gateway,
+		instances: services,
+		client:    &http.Client{Timeout: time.Duration(cfg.HealthCheckTimeout) * time.Millisecond},
+		logger:    logger,
+	}
+}
+
+// Aggregate checks the gateway and every downstream instance in parallel.
+// The summary is down only when the gateway is; downstream outages make it
+// degraded.
+func (a *Aggregator) Aggregate(ctx context.Context) Summary {
+	summary := Summary{Status: StatusOK}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		summary.Gateway = a.gateway.Ready(ctx)
+	}()
+
+	for service, endpoints := range a.instances.Endpoints() {
+		health := ServiceHealth{Service: service, Instances: make([]InstanceHealth, len(endpoints))}
+		for i, endpoint := range endpoints {
+			wg.Add(1)
+			go func(instance *InstanceHealth, url string) {
+				defer wg.Done()
+				*instance = a.check(ctx, url)
+			}(&health.Instances[i], endpoint.URL)
+		}
+		summary.Services = append(summary.Services, health)
+	}
+	wg.Wait()
+
+	sort.Slice(summary.Services, func(i, j int) bool {
+		return summary.Services[i].Service < summary.Services[j].Service
+	})
+	for i := range summary.Services {
+		service := &summary.Services[i]
+		service.Status = serviceStatus(service.Instances)
+		if service.Status != StatusOK {
+			summary.Status = StatusDegraded
+		}
+	}
+	if summary.Gateway.Status != StatusOK {
+		summary.Status = summary.Gateway.Status
+	}
+	summary.Time = time.Now().UTC()
+	return summary
+}
+
+func (a *Aggregator) check(ctx context.Context, url string) InstanceHealth {
+	instance := InstanceHealth{URL: url, Status: StatusDown}
+
+	start := time.Now()
+	report, err := a.fetch(ctx, url)
+	instance.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		a.logger.Warn("Downstream health check failed", zap.Error(err), zap.String("url", url))
+		instance.Error = err.Error()
+		return instance
+	}
+	instance.Status = report.Status
+	instance.Report = report
+	return instance
+}
+
+// fetch reads an instance's readiness report, which is sent with 503 when
+// the instance is down
+func (a *Aggregator) fetch(ctx context.Context, url string) (*Report, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+ReadinessPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var report Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil || report.Status == "" {
+		return nil, fmt.Errorf("%s returned status %d without a health report", url, resp.StatusCode)
+	}
+	return &report, nil
+}
+
+// serviceStatus is ok when every instance is, down when no instance can
+// serve, and degraded otherwise
+func serviceStatus(instances []InstanceHealth) string {
+	serving, ok := 0, 0
+	for _, instance := range instances {
+		if instance.Status != StatusDown {
+			serving++
+		}
+		if instance.Status == StatusOK {
+			ok++
+		}
+	}
+	switch {
+	case serving == 0:
+		return StatusDown
+	case ok == len(instances):
+		return StatusOK
+	}
+	return StatusDegraded
+}
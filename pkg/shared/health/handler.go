@@ -0,0 +1,64 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Probe paths every service serves
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// Register serves the liveness and readiness probes on r
+func (c *Checker) Register(r gin.IRoutes) {
+	r.GET(LivenessPath, c.GetLiveness)
+	r.GET(ReadinessPath, c.GetReadiness)
+}
+
+// GetLiveness godoc
+// @Summary Liveness probe
+// @Description Answers while the process serves requests, without checking dependencies
+// @Tags health
+// @Produce json
+// @Success 200 {object} Report
+// @Router /healthz [get]
+func (c *Checker) GetLiveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.Live())
+}
+
+// GetReadiness godoc
+// @Summary Readiness probe
+// @Description Checks the database, Redis and upstream services with their latencies. Down when a required dependency is; degraded, but still ready, when an upstream service is.
+// @Tags health
+// @Produce json
+// @Success 200 {object} Report
+// @Failure 503 {object} Report
+// @Router /readyz [get]
+func (c *Checker) GetReadiness(ctx *gin.Context) {
+	report := c.Ready(ctx.Request.Context())
+	status := http.StatusOK
+	if report.Status == StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, report)
+}
+
+// GetAll godoc
+// @Summary Health of every service
+// @Description Readiness of the gateway and of every instance of the downstream services, checked in parallel
+// @Tags health
+// @Produce json
+// @Success 200 {object} Summary
+// @Failure 503 {object} Summary
+// @Router /health/all [get]
+func (a *Aggregator) GetAll(c *gin.Context) {
+	summary := a.Aggregate(c.Request.Context())
+	status := http.StatusOK
+	if summary.Status == StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, summary)
+}
@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"hedge-fund/pkg/shared/config"
+)
+
+// Statuses of services and their dependencies
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // Serving, with an optional dependency down
+	StatusDown     = "down"     // Not ready to serve
+)
+
+// Check reports whether a dependency is usable, returning within ctx
+type Check func(ctx context.Context) error
+
+// Dependency is the outcome of checking one dependency
+type Dependency struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"` // Whether the service is down without it
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report is a service's health. Liveness reports carry no dependencies.
+type Report struct {
+	Service      string       `json:"service"`
+	Status       string       `json:"status"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+	Time         time.Time    `json:"time"`
+}
+
+type dependency struct {
+	name     string
+	check    Check
+	critical bool
+}
+
+// Checker answers a service's liveness and readiness probes. Liveness only
+// shows the process is serving requests; readiness checks every dependency.
+type Checker struct {
+	service      string
+	timeout      time.Duration
+	dependencies []dependency
+	logger       *zap.Logger
+}
+
+// NewChecker creates a checker for a service. Each dependency check may take
+// HEALTH_CHECK_TIMEOUT.
+func NewChecker(service string, cfg *config.Config, logger *zap.Logger) *Checker {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Checker{
+		service: service,
+		timeout: time.Duration(cfg.HealthCheckTimeout) * time.Millisecond,
+		logger:  logger,
+	}
+}
+
+// Require adds a dependency the service cannot serve without, such as its
+// database
+func (c *Checker) Require(name string, check Check) {
+	c.dependencies = append(c.dependencies, dependency{name: name, check: check, critical: true})
+}
+
+// Optional adds a dependency the service degrades without, such as an
+// upstream service. Upstream outages leave the service ready, so they do not
+// cascade.
+func (c *Checker) Optional(name string, check Check) {
+	c.dependencies = append(c.dependencies, dependency{name: name, check: check})
+}
+
+// Live returns the liveness report
+func (c *Checker) Live() Report {
+	return Report{Service: c.service, Status: StatusOK, Time: time.Now().UTC()}
+}
+
+// Ready checks every dependency in parallel. The service is down if a
+// required dependency is, and degraded if an optional one is.
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := c.Live()
+	report.Dependencies = make([]Dependency, len(c.dependencies))
+
+	var wg sync.WaitGroup
+	for i, dep := range c.dependencies {
+		wg.Add(1)
+		go func(i int, dep dependency) {
+			defer wg.Done()
+			report.Dependencies[i] = c.check(ctx, dep)
+		}(i, dep)
+	}
+	wg.Wait()
+
+	for _, dep := range report.Dependencies {
+		switch {
+		case dep.Status == StatusOK:
+		case dep.Critical:
+			report.Status = StatusDown
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (c *Checker) check(ctx context.Context, dep dependency) Dependency {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dep.check(ctx)
+	result := Dependency{Name: dep.name, Status: StatusOK, Critical: dep.critical, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		c.logger.Warn("Health check failed", zap.Error(err), zap.String("service", c.service), zap.String("dependency", dep.name))
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// HTTPCheck checks an upstream service through its liveness endpoint
+func HTTPCheck(client *http.Client, baseURL string) Check {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+LivenessPath, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s returned status %d", baseURL, resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// GRPCCheck checks an upstream service through the state of the connection
+// to it. Idle connections are fine; they connect on the next call.
+func GRPCCheck(conn *grpc.ClientConn) Check {
+	return func(ctx context.Context) error {
+		switch state := conn.GetState(); state {
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			return fmt.Errorf("connection to %s is %s", conn.Target(), state)
+		}
+		return nil
+	}
+}
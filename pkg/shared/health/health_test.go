@@ -0,0 +1,136 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/registry"
+)
+
+func testChecker(service string) *Checker {
+	return &Checker{service: service, timeout: 50 * time.Millisecond, logger: zap.NewNop()}
+}
+
+func ok(ctx context.Context) error { return nil }
+
+func failing(ctx context.Context) error { return errors.New("connection refused") }
+
+func blocking(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestReady(t *testing.T) {
+	c := testChecker("portfolio-service")
+	c.Require("database", ok)
+	c.Require("redis", ok)
+	report := c.Ready(context.Background())
+	assert.Equal(t, StatusOK, report.Status)
+	require.Len(t, report.Dependencies, 2)
+	assert.Equal(t, "database", report.Dependencies[0].Name)
+	assert.True(t, report.Dependencies[0].Critical)
+
+	// An upstream outage degrades the service without taking it down
+	c.Optional("market-data-service", blocking)
+	report = c.Ready(context.Background())
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, StatusDown, report.Dependencies[2].Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Dependencies[2].Error)
+
+	c.Require("broker", failing)
+	report = c.Ready(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, "connection refused", report.Dependencies[3].Error)
+}
+
+func TestProbeHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := testChecker("risk-service")
+	c.Require("database", failing)
+	r := gin.New()
+	c.Register(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, LivenessPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var report Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, "risk-service", report.Service)
+}
+
+type stubInstances map[string][]registry.Endpoint
+
+func (s stubInstances) Endpoints() map[string][]registry.Endpoint {
+	return s
+}
+
+// readyServer serves a service's readiness probe from a checker
+func readyServer(t *testing.T, c *Checker) string {
+	r := gin.New()
+	c.Register(r)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestAggregate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	healthy := testChecker("portfolio-service")
+	healthy.Require("database", ok)
+	down := testChecker("portfolio-service")
+	down.Require("database", failing)
+	degraded := testChecker("risk-service")
+	degraded.Optional("portfolio-service", failing)
+
+	gateway := testChecker("api-gateway")
+	gateway.Require("redis", ok)
+	a := &Aggregator{
+		gateway: gateway,
+		instances: stubInstances{
+			registry.ServicePortfolio:  {{URL: readyServer(t, healthy)}, {URL: readyServer(t, down)}},
+			registry.ServiceRisk:       {{URL: readyServer(t, degraded)}},
+			registry.ServiceMarketData: {{URL: "http://127.0.0.1:1"}},
+		},
+		client: &http.Client{Timeout: time.Second},
+		logger: zap.NewNop(),
+	}
+
+	summary := a.Aggregate(context.Background())
+
+	assert.Equal(t, StatusDegraded, summary.Status)
+	assert.Equal(t, StatusOK, summary.Gateway.Status)
+	require.Len(t, summary.Services, 3)
+
+	market, portfolio, risk := summary.Services[0], summary.Services[1], summary.Services[2]
+	assert.Equal(t, registry.ServiceMarketData, market.Service)
+	assert.Equal(t, StatusDown, market.Status)
+	assert.NotEmpty(t, market.Instances[0].Error)
+	assert.Nil(t, market.Instances[0].Report)
+
+	// One of two instances down leaves the service serving
+	assert.Equal(t, StatusDegraded, portfolio.Status)
+	assert.Equal(t, StatusOK, portfolio.Instances[0].Status)
+	assert.Equal(t, StatusDown, portfolio.Instances[1].Status)
+	require.NotNil(t, portfolio.Instances[1].Report)
+	assert.Equal(t, "connection refused", portfolio.Instances[1].Report.Dependencies[0].Error)
+
+	assert.Equal(t, StatusDegraded, risk.Status)
+
+	// The summary is down only with the gateway
+	gateway.Require("redis", failing)
+	assert.Equal(t, StatusDown, a.Aggregate(context.Background()).Status)
+}
@@ -0,0 +1,18 @@
+package httpclient
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBreakerStats godoc
+// @Summary Get circuit breaker state
+// @Description Each upstream's circuit breaker state with its request, failure, retry and rejection counts since the service started
+// @Tags services
+// @Produce json
+// @Success 200 {array} BreakerStats
+// @Router /circuit-breakers [get]
+func (hc *Client) GetBreakerStats(c *gin.Context) {
+	c.JSON(http.StatusOK, hc.Stats())
+}
@@ -0,0 +1,334 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/config"
+)
+
+// Circuit breaker states
+const (
+	StateClosed   = "closed"    // Requests flow
+	StateOpen     = "open"      // Requests are refused until the breaker cools down
+	StateHalfOpen = "half_open" // One probe request decides whether to close or reopen
+)
+
+// IdempotencyKeyHeader marks a request as safe to retry whatever its method,
+// such as a POST that only reads
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxRetryTokens caps an upstream's saved retry budget, and is what a new
+// upstream starts with so early failures can still be retried
+const maxRetryTokens = 10
+
+// ErrCircuitOpen is returned, without sending the request, while the
+// upstream's breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Options configure a Client
+type Options struct {
+	Timeout          time.Duration // Per attempt; the request context's deadline bounds all of them
+	MaxRetries       int           // Retries of an idempotent request after a transport error, 502, 503 or 504
+	RetryBackoff     time.Duration // Base delay before a retry, doubled per retry, with full jitter
+	RetryBudget      float64       // Retries each request earns for its upstream, e.g. 0.2 for one retry per five requests
+	FailureThreshold int           // Consecutive failures that open an upstream's breaker
+	OpenDuration     time.Duration // How long an open breaker refuses requests before letting a probe through
+}
+
+// NewOptions reads client options from the HTTP_CLIENT_* and HTTP_BREAKER_*
+// configuration
+func NewOptions(cfg *config.Config) Options {
+	return Options{
+		Timeout:          time.Duration(cfg.HTTPClientTimeout) * time.Millisecond,
+		MaxRetries:       cfg.HTTPClientMaxRetries,
+		RetryBackoff:     time.Duration(cfg.HTTPClientRetryBackoff) * time.Millisecond,
+		RetryBudget:      cfg.HTTPClientRetryBudget,
+		FailureThreshold: cfg.HTTPBreakerFailureThreshold,
+		OpenDuration:     time.Duration(cfg.HTTPBreakerOpenDuration) * time.Second,
+	}
+}
+
+// BreakerStats are an upstream's breaker state and counters since the
+// client was created
+type BreakerStats struct {
+	Upstream            string    `json:"upstream"` // host:port
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Requests            int64     `json:"requests"` // Attempts sent, retries included
+	Failures            int64     `json:"failures"` // Transport errors and 5xx responses
+	Retries             int64     `json:"retries"`
+	Rejected            int64     `json:"rejected"` // Refused by the open breaker
+	Opened              int64     `json:"opened"`   // Times the breaker has opened
+	RetryTokens         float64   `json:"retry_tokens"`
+	StateChangedAt      time.Time `json:"state_changed_at"`
+}
+
+type breaker struct {
+	stats    BreakerStats
+	openedAt time.Time
+	probing  bool // A half-open probe is in flight
+}
+
+// Client is an HTTP client for calls between services. Each upstream host
+// has a circuit breaker that opens after consecutive failures, so callers
+// fail fast instead of piling onto a struggling service, and retries are
+// bounded by a per-upstream budget so they cannot multiply an outage.
+type Client struct {
+	httpClient *http.Client
+	opts       Options
+	logger     *zap.Logger
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// New creates a client
+func New(opts Options, logger *zap.Logger) *Client {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Client{
+		httpClient: &http.Client{},
+		opts:       opts,
+		logger:     logger,
+		breakers:   make(map[string]*breaker),
+	}
+}
+
+// Do sends req through its upstream's breaker. Each attempt is bounded by
+// the per-attempt timeout and by req's context, so a caller's deadline
+// propagates to every attempt and retries stop once it is too close. Only
+// idempotent requests, and those with an IdempotencyKeyHeader, are retried. The response is that of the last
+// attempt; 4xx and 5xx responses are not errors.
+func (hc *Client) Do(req *http.Request) (*http.Response, error) {
+	upstream := req.URL.Host
+	retryable := (idempotent(req.Method) || req.Header.Get(IdempotencyKeyHeader) != "") &&
+		(req.Body == nil || req.GetBody != nil)
+
+	for attempt := 0; ; attempt++ {
+		if err := hc.allow(upstream, attempt == 0); err != nil {
+			return nil, err
+		}
+
+		resp, err := hc.send(req, attempt)
+		if err != nil && req.Context().Err() != nil {
+			// The caller gave up; that says nothing about the upstream
+			hc.abandon(upstream)
+			return nil, err
+		}
+		hc.record(upstream, err == nil && resp.StatusCode < http.StatusInternalServerError)
+
+		if !retryable || attempt >= hc.opts.MaxRetries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+		delay := hc.backoff(attempt)
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+		if !hc.spendRetry(upstream) {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// send makes one attempt at req under the per-attempt timeout
+func (hc *Client) send(req *http.Request, attempt int) (*http.Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if hc.opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), hc.opts.Timeout)
+	}
+
+	out := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to rewind request body: %w", err)
+		}
+		out.Body = body
+	}
+
+	resp, err := hc.httpClient.Do(out)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The attempt's context must outlive reading the body
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// allow admits an attempt to upstream, or refuses it while the breaker is
+// open. After OpenDuration one probe is let through half-open. A request's
+// first attempt adds to upstream's retry budget.
+func (hc *Client) allow(upstream string, first bool) error {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	b := hc.breaker(upstream)
+	switch b.stats.State {
+	case StateOpen:
+		if time.Since(b.openedAt) < hc.opts.OpenDuration {
+			b.stats.Rejected++
+			return fmt.Errorf("%w: %s", ErrCircuitOpen, upstream)
+		}
+		hc.transition(b, StateHalfOpen)
+		b.probing = true
+	case StateHalfOpen:
+		if b.probing {
+			b.stats.Rejected++
+			return fmt.Errorf("%w: %s", ErrCircuitOpen, upstream)
+		}
+		b.probing = true
+	}
+
+	b.stats.Requests++
+	if first {
+		b.stats.RetryTokens = math.Min(b.stats.RetryTokens+hc.opts.RetryBudget, maxRetryTokens)
+	}
+	return nil
+}
+
+// record updates upstream's breaker with an attempt's outcome
+func (hc *Client) record(upstream string, ok bool) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	b := hc.breaker(upstream)
+	b.probing = false
+	if ok {
+		b.stats.ConsecutiveFailures = 0
+		if b.stats.State != StateClosed {
+			hc.transition(b, StateClosed)
+		}
+		return
+	}
+
+	b.stats.Failures++
+	b.stats.ConsecutiveFailures++
+	if b.stats.State == StateHalfOpen || (b.stats.State == StateClosed && b.stats.ConsecutiveFailures >= hc.opts.FailureThreshold) {
+		b.openedAt = time.Now()
+		b.stats.Opened++
+		hc.transition(b, StateOpen)
+	}
+}
+
+// abandon releases a half-open probe whose caller gave up, so another
+// request can probe
+func (hc *Client) abandon(upstream string) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.breaker(upstream).probing = false
+}
+
+// spendRetry takes one retry from upstream's budget, if it has one
+func (hc *Client) spendRetry(upstream string) bool {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	b := hc.breaker(upstream)
+	if b.stats.RetryTokens < 1 {
+		return false
+	}
+	b.stats.RetryTokens--
+	b.stats.Retries++
+	return true
+}
+
+func (hc *Client) breaker(upstream string) *breaker {
+	b, ok := hc.breakers[upstream]
+	if !ok {
+		b = &breaker{stats: BreakerStats{
+			Upstream:       upstream,
+			State:          StateClosed,
+			RetryTokens:    maxRetryTokens,
+			StateChangedAt: time.Now(),
+		}}
+		hc.breakers[upstream] = b
+	}
+	return b
+}
+
+func (hc *Client) transition(b *breaker, state string) {
+	hc.logger.Info("Circuit breaker state changed",
+		zap.String("upstream", b.stats.Upstream),
+		zap.String("from", b.stats.State),
+		zap.String("to", state),
+		zap.Int("consecutive_failures", b.stats.ConsecutiveFailures),
+	)
+	b.stats.State = state
+	b.stats.StateChangedAt = time.Now()
+}
+
+// backoff returns the delay before retry attempt+1: a random duration up to
+// RetryBackoff doubled attempt times
+func (hc *Client) backoff(attempt int) time.Duration {
+	ceiling := hc.opts.RetryBackoff << attempt
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+// Stats returns every upstream's breaker state and counters, by upstream
+func (hc *Client) Stats() []BreakerStats {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	stats := make([]BreakerStats, 0, len(hc.breakers))
+	for _, b := range hc.breakers {
+		stats = append(stats, b.stats)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Upstream < stats[j].Upstream })
+	return stats
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOptions() Options {
+	return Options{
+		Timeout:          time.Second,
+		MaxRetries:       2,
+		RetryBackoff:     time.Millisecond,
+		RetryBudget:      0.2,
+		FailureThreshold: 3,
+		OpenDuration:     50 * time.Millisecond,
+	}
+}
+
+// statusServer answers each request with the next status in statuses,
+// repeating the last one
+func statusServer(t *testing.T, statuses ...int) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1)) - 1
+		if n >= len(statuses) {
+			n = len(statuses) - 1
+		}
+		w.WriteHeader(statuses[n])
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func get(t *testing.T, client *Client, url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+	}
+	return resp, err
+}
+
+func TestRetriesIdempotentRequests(t *testing.T) {
+	server, calls := statusServer(t, http.StatusServiceUnavailable, http.StatusOK)
+	client := New(testOptions(), nil)
+
+	resp, err := get(t, client, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 2, atomic.LoadInt32(calls))
+
+	stats := client.Stats()
+	require.Len(t, stats, 1)
+	assert.EqualValues(t, 1, stats[0].Retries)
+	assert.EqualValues(t, 2, stats[0].Requests)
+
+	// A POST is not retried
+	unavailable, posts := statusServer(t, http.StatusServiceUnavailable)
+	req, _ := http.NewRequest(http.MethodPost, unavailable.URL, strings.NewReader("{}"))
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.EqualValues(t, 1, atomic.LoadInt32(posts))
+
+	// Unless it carries an idempotency key
+	recovering, keyed := statusServer(t, http.StatusServiceUnavailable, http.StatusOK)
+	req, _ = http.NewRequest(http.MethodPost, recovering.URL, strings.NewReader("{}"))
+	req.Header.Set(IdempotencyKeyHeader, "quotes-1")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 2, atomic.LoadInt32(keyed))
+}
+
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	server, calls := statusServer(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK)
+	opts := testOptions()
+	opts.MaxRetries = 0
+	client := New(opts, nil)
+
+	for i := 0; i < 3; i++ {
+		resp, err := get(t, client, server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	}
+	assert.Equal(t, StateOpen, client.Stats()[0].State)
+
+	// Refused without reaching the upstream while open
+	_, err := get(t, client, server.URL)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.EqualValues(t, 3, atomic.LoadInt32(calls))
+
+	// A successful probe after cooling down closes it
+	time.Sleep(opts.OpenDuration)
+	resp, err := get(t, client, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	stats := client.Stats()[0]
+	assert.Equal(t, StateClosed, stats.State)
+	assert.EqualValues(t, 1, stats.Opened)
+	assert.EqualValues(t, 1, stats.Rejected)
+	assert.Zero(t, stats.ConsecutiveFailures)
+}
+
+func TestRetryBudgetBoundsRetries(t *testing.T) {
+	server, calls := statusServer(t, http.StatusBadGateway)
+	opts := testOptions()
+	opts.FailureThreshold = 1000
+	client := New(opts, nil)
+
+	// The initial budget is spent after maxRetryTokens retries; then each
+	// request earns a fifth of one
+	for i := 0; i < 10; i++ {
+		_, err := get(t, client, server.URL)
+		require.NoError(t, err)
+	}
+	stats := client.Stats()[0]
+	assert.Less(t, stats.Retries, int64(20))
+	assert.EqualValues(t, 10+stats.Retries, atomic.LoadInt32(calls))
+	assert.Less(t, stats.RetryTokens, 1.0)
+}
+
+func TestDeadlinePropagatesToAttempts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	client := New(testOptions(), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	start := time.Now()
+	_, err := client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// The caller's deadline is not held against the upstream
+	stats := client.Stats()[0]
+	assert.Zero(t, stats.Failures)
+	assert.Equal(t, StateClosed, stats.State)
+}
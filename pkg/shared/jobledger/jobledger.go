@@ -0,0 +1,169 @@
+// Package jobledger makes the side effects of queue jobs idempotent. Jobs
+// are delivered at least once: a retry, a redelivery after a crash or a job
+// reaped as stuck runs its handler again. Each side effect is recorded in
+// the processed_jobs ledger, by the job's idempotency key and a step name,
+// in the same transaction as the effect, so it commits once however often
+// the job runs.
+package jobledger
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+)
+
+// ErrProcessed is returned for a step a job already committed
+var ErrProcessed = errors.New("job step already processed")
+
+// uniqueViolation is the PostgreSQL error code of a duplicate key
+const uniqueViolation = "23505"
+
+// Entry is a committed step of a job
+type Entry struct {
+	Key         string          `json:"key"`
+	JobID       string          `json:"job_id"`
+	JobType     string          `json:"job_type"`
+	Result      json.RawMessage `json:"result,omitempty"` // What the step recorded, e.g. the trade it made
+	ProcessedAt time.Time       `json:"processed_at"`
+}
+
+// Key returns the ledger key of a step of job: its idempotency key, or ID
+// for jobs enqueued without one, and the step. Steps name each side effect
+// of the job, e.g. "trade:42:AAPL:buy".
+func Key(job *models.Job, step string) string {
+	key := job.IdempotencyKey
+	if key == "" {
+		key = job.ID
+	}
+	return key + ":" + step
+}
+
+// WithKey returns a copy of ctx carrying key as the idempotency key of work
+// that does not run as a queue job, such as a scheduled task or an RPC sent
+// with a key, so the steps it makes are recorded like a job's. source names
+// the kind of work in the ledger. Keys must fit processed_jobs.job_id.
+func WithKey(ctx context.Context, key, source string) context.Context {
+	return queue.WithJob(ctx, &models.Job{ID: key, Type: source, IdempotencyKey: key})
+}
+
+// Ledger records the steps jobs committed
+type Ledger struct {
+	db *database.DB
+}
+
+// New creates a ledger of db's processed_jobs table
+func New(db *database.DB) *Ledger {
+	return &Ledger{db: db}
+}
+
+// Get returns a committed step by key, or nil if it was not committed
+func (l *Ledger) Get(ctx context.Context, key string) (*Entry, error) {
+	query := `
+		SELECT idempotency_key, job_id, job_type, result, processed_at
+		FROM processed_jobs
+		WHERE idempotency_key = $1`
+
+	var entry Entry
+	var result []byte
+	err := l.db.QueryRowContext(ctx, query, key).Scan(&entry.Key, &entry.JobID, &entry.JobType, &result, &entry.ProcessedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processed job: %w", err)
+	}
+	entry.Result = result
+	return &entry, nil
+}
+
+// RecordTx records a step of job with its result in tx, the transaction
+// making the step's side effects. It returns ErrProcessed if the step was
+// already committed, and tx must then be rolled back.
+func (l *Ledger) RecordTx(ctx context.Context, tx *sql.Tx, job *models.Job, step string, result interface{}) error {
+	var data []byte
+	if result != nil {
+		var err error
+		if data, err = json.Marshal(result); err != nil {
+			return fmt.Errorf("failed to marshal job result: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO processed_jobs (idempotency_key, job_id, job_type, result)
+		VALUES ($1, $2, $3, $4)`
+	if _, err := tx.ExecContext(ctx, query, Key(job, step), job.ID, job.Type, data); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return fmt.Errorf("%w: %s", ErrProcessed, step)
+		}
+		return fmt.Errorf("failed to record processed job: %w", err)
+	}
+	return nil
+}
+
+// Once runs a step of job once: fn makes the step's side effects in tx and
+// returns its result, which is recorded with them. A step already committed
+// is not run again; its entry is returned with ErrProcessed.
+func (l *Ledger) Once(ctx context.Context, job *models.Job, step string, fn func(tx *sql.Tx) (interface{}, error)) (*Entry, error) {
+	entry, err := l.Get(ctx, Key(job, step))
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		return entry, fmt.Errorf("%w: %s", ErrProcessed, step)
+	}
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := fn(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.RecordTx(ctx, tx, job, step, result); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &Entry{Key: Key(job, step), JobID: job.ID, JobType: job.Type, ProcessedAt: time.Now()}, nil
+}
+
+// Step prepares a step of the job carried by ctx, for code a job calls
+// into. It returns the job, to record the step with in RecordTx, or
+// ErrProcessed if the job already committed the step. Outside a job, or
+// with a nil ledger, it returns nil and the step is not recorded.
+func (l *Ledger) Step(ctx context.Context, step string) (*models.Job, error) {
+	job := queue.JobFromContext(ctx)
+	if l == nil || job == nil {
+		return nil, nil
+	}
+	entry, err := l.Get(ctx, Key(job, step))
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		return nil, fmt.Errorf("%w: %s", ErrProcessed, step)
+	}
+	return job, nil
+}
+
+// Settled returns nil for a step that was already processed, so a handler
+// redelivered after committing succeeds, and err otherwise
+func Settled(err error) error {
+	if errors.Is(err, ErrProcessed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package jobledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/queue"
+)
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "order-7:trade:42:AAPL:buy", Key(&models.Job{ID: "job-1", IdempotencyKey: "order-7"}, "trade:42:AAPL:buy"))
+
+	// Jobs enqueued before keys existed are keyed by ID, which retries keep
+	assert.Equal(t, "job-1:rebalance:42", Key(&models.Job{ID: "job-1"}, "rebalance:42"))
+}
+
+func TestSettled(t *testing.T) {
+	assert.NoError(t, Settled(fmt.Errorf("%w: trade:42:AAPL:buy", ErrProcessed)))
+	assert.NoError(t, Settled(nil))
+
+	err := errors.New("insufficient cash")
+	assert.Equal(t, err, Settled(err))
+}
+
+func TestStepOutsideJob(t *testing.T) {
+	// Steps are only recorded for jobs with a ledger
+	var ledger *Ledger
+	job, err := ledger.Step(queue.WithJob(context.Background(), &models.Job{ID: "job-1"}), "rebalance:42")
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	ledger = &Ledger{}
+	job, err = ledger.Step(context.Background(), "rebalance:42")
+	require.NoError(t, err)
+	assert.Nil(t, job)
+}
+
+func TestWithKey(t *testing.T) {
+	// Keyed work outside the queue is recorded under its key
+	job := queue.JobFromContext(WithKey(context.Background(), "auto_trade_order:7", "auto_trade"))
+	require.NotNil(t, job)
+	assert.Equal(t, "auto_trade", job.Type)
+	assert.Equal(t, "auto_trade_order:7:trade:42:AAPL:buy", Key(job, "trade:42:AAPL:buy"))
+}
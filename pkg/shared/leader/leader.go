@@ -0,0 +1,211 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/redis"
+)
+
+const (
+	keyPrefix = "leader:"
+
+	// DefaultTTL is the lease of an elector created without a usable TTL
+	DefaultTTL = 15 * time.Second
+
+	// minTTL is the shortest lease renewed every ttl/3
+	minTTL = 3 * time.Millisecond
+
+	// Events published on models.ChannelSystemEvents
+	EventLeaderElected  = "leader_elected"
+	EventLeaderResigned = "leader_resigned"
+)
+
+// Only extend or release the lease if this instance still holds it
+var (
+	renewScript = goredis.NewScript(`
+		if redis.call("GET", KEYS[1]) == ARGV[1] then
+			return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+		end
+		return 0`)
+	releaseScript = goredis.NewScript(`
+		if redis.call("GET", KEYS[1]) == ARGV[1] then
+			return redis.call("DEL", KEYS[1])
+		end
+		return 0`)
+)
+
+// Elector elects a single leader for a named role across all replicas using a
+// Redis lease. The leader renews the lease while it runs; if it dies or loses
+// Redis the lease expires and another replica takes over within one TTL.
+type Elector struct {
+	redis    *redis.Client
+	name     string
+	id       string
+	ttl      time.Duration
+	interval time.Duration
+	leading  atomic.Bool
+}
+
+// NewElector creates an elector for role name. ttl bounds how long a dead
+// leader can hold the role; the lease is renewed every ttl/3. A ttl too short
+// to renew, such as an unset LEADER_LEASE_TTL, is replaced by DefaultTTL.
+func NewElector(redisClient *redis.Client, name string, ttl time.Duration) *Elector {
+	if ttl < minTTL {
+		logger.Warn("Leader lease TTL too short, using the default",
+			zap.String("role", name), zap.Duration("ttl", ttl), zap.Duration("default", DefaultTTL))
+		ttl = DefaultTTL
+	}
+	hostname, _ := os.Hostname()
+	return &Elector{
+		redis:    redisClient,
+		name:     name,
+		id:       fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8]),
+		ttl:      ttl,
+		interval: ttl / 3,
+	}
+}
+
+// ID identifies this instance as a candidate
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// IsLeader reports whether this instance currently holds the role
+func (e *Elector) IsLeader() bool {
+	return e.leading.Load()
+}
+
+// Leader returns the ID of the current leader, or "" if the role is vacant
+func (e *Elector) Leader(ctx context.Context) (string, error) {
+	id, err := e.redis.Get(ctx, e.key()).Result()
+	if err == goredis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get leader: %w", err)
+	}
+	return id, nil
+}
+
+// Run campaigns for the role until ctx is cancelled. Each time this instance
+// is elected, fn is started with a context that is cancelled when leadership
+// is lost; fn must return promptly once its context is done. If fn returns
+// first, the lease is released and this instance campaigns again. The lease
+// is released on shutdown so a standby can take over immediately.
+func (e *Elector) Run(ctx context.Context, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		if e.acquire(ctx) {
+			e.lead(ctx, fn)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) acquire(ctx context.Context) bool {
+	acquired, err := e.redis.SetNX(ctx, e.key(), e.id, e.ttl).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Warn("Leader election failed", zap.String("role", e.name), zap.Error(err))
+		}
+		return false
+	}
+	return acquired
+}
+
+// lead runs fn while renewing the lease, returning once leadership is lost,
+// fn returns or ctx is done
+func (e *Elector) lead(ctx context.Context, fn func(ctx context.Context)) {
+	e.leading.Store(true)
+	logger.Info("Elected leader", zap.String("role", e.name), zap.String("id", e.id))
+	e.publish(ctx, EventLeaderElected)
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer cancel() // Stops renewing once fn is done
+		fn(leaderCtx)
+	}()
+
+	e.renew(leaderCtx)
+
+	cancel()
+	wg.Wait()
+	e.leading.Store(false)
+
+	// Release with a fresh context since ctx may already be cancelled
+	releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer releaseCancel()
+	if err := releaseScript.Run(releaseCtx, e.redis, []string{e.key()}, e.id).Err(); err != nil {
+		logger.Warn("Failed to release leadership", zap.String("role", e.name), zap.Error(err))
+	}
+	logger.Info("Resigned leadership", zap.String("role", e.name), zap.String("id", e.id))
+	e.publish(releaseCtx, EventLeaderResigned)
+}
+
+// renew extends the lease until ctx is done or the lease is lost. Redis errors
+// are retried until the lease may have expired, at which point another
+// replica could have been elected and this one must stop.
+func (e *Elector) renew(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	lastRenewed := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		renewed, err := renewScript.Run(ctx, e.redis, []string{e.key()}, e.id, e.ttl.Milliseconds()).Int()
+		switch {
+		case err == nil && renewed == 1:
+			lastRenewed = time.Now()
+		case err == nil:
+			logger.Warn("Leadership lost to another instance", zap.String("role", e.name), zap.String("id", e.id))
+			return
+		case time.Since(lastRenewed) >= e.ttl-e.interval:
+			logger.Warn("Leadership lease could not be renewed", zap.String("role", e.name), zap.Error(err))
+			return
+		}
+	}
+}
+
+func (e *Elector) publish(ctx context.Context, eventType string) {
+	event := models.Event{
+		Type:      eventType,
+		Source:    "leader_elector",
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"role": e.name,
+			"id":   e.id,
+		},
+	}
+	if err := e.redis.PublishEvent(ctx, models.ChannelSystemEvents, event); err != nil {
+		logger.Warn("Failed to publish leadership event", zap.Error(err))
+	}
+}
+
+func (e *Elector) key() string {
+	return keyPrefix + e.name
+}
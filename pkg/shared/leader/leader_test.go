@@ -0,0 +1,223 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// errAnswered stops a command the fake answered from reaching the network
+var errAnswered = errors.New("answered")
+
+// leaseRedis answers the elector's commands from one in-memory lease, which
+// never expires; tests hand it to another instance by setting its holder
+type leaseRedis struct {
+	mu        sync.Mutex
+	holder    string
+	renewals  int
+	releases  int
+	unhealthy bool // Scripts fail as if Redis were unreachable
+}
+
+func (r *leaseRedis) BeforeProcess(ctx context.Context, cmd goredis.Cmder) (context.Context, error) {
+	if err := ctx.Err(); err != nil {
+		return ctx, err // As a real connection would be
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	args := cmd.Args()
+	switch c := cmd.(type) {
+	case *goredis.BoolCmd: // SET NX
+		acquired := r.holder == ""
+		if acquired {
+			r.holder = fmt.Sprint(args[2])
+		}
+		c.SetVal(acquired)
+	case *goredis.StringCmd: // GET
+		if r.holder == "" {
+			return ctx, goredis.Nil
+		}
+		c.SetVal(r.holder)
+	case *goredis.Cmd: // EVALSHA sha 1 key id ...
+		if r.unhealthy {
+			return ctx, errors.New("connection refused")
+		}
+		held := r.holder == fmt.Sprint(args[4])
+		switch args[1] {
+		case renewScript.Hash():
+			r.renewals++
+		case releaseScript.Hash():
+			if held {
+				r.holder = ""
+				r.releases++
+			}
+		}
+		if held {
+			c.SetVal(int64(1))
+		} else {
+			c.SetVal(int64(0))
+		}
+	case *goredis.IntCmd: // PUBLISH
+		c.SetVal(0)
+	}
+	return ctx, errAnswered
+}
+
+func (r *leaseRedis) AfterProcess(_ context.Context, cmd goredis.Cmder) error {
+	if errors.Is(cmd.Err(), errAnswered) {
+		cmd.SetErr(nil)
+	}
+	return nil
+}
+
+func (r *leaseRedis) BeforeProcessPipeline(ctx context.Context, _ []goredis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (r *leaseRedis) AfterProcessPipeline(context.Context, []goredis.Cmder) error { return nil }
+
+func (r *leaseRedis) state() (holder string, renewals, releases int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.holder, r.renewals, r.releases
+}
+
+func (r *leaseRedis) set(fn func(r *leaseRedis)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(r)
+}
+
+const testTTL = 60 * time.Millisecond
+
+func newTestElector(t *testing.T) (*Elector, *leaseRedis) {
+	require.NoError(t, logger.Init("error", "test"))
+	store := &leaseRedis{}
+	client := goredis.NewClient(&goredis.Options{Addr: "127.0.0.1:0"})
+	client.AddHook(store)
+	t.Cleanup(func() { client.Close() })
+
+	return NewElector(&redis.Client{Client: client}, "test", testTTL), store
+}
+
+// run campaigns in the background until the test ends, returning a channel
+// closed once Run has returned
+func run(t *testing.T, e *Elector, fn func(ctx context.Context)) (context.CancelFunc, <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.Run(ctx, fn)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return cancel, done
+}
+
+func TestElectorAcquiresRenewsAndReleases(t *testing.T) {
+	e, store := newTestElector(t)
+	var stopped atomic.Bool
+	cancel, done := run(t, e, func(ctx context.Context) {
+		<-ctx.Done()
+		stopped.Store(true)
+	})
+
+	require.Eventually(t, e.IsLeader, time.Second, time.Millisecond)
+	leader, err := e.Leader(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, e.ID(), leader)
+
+	// The lease is kept while the leader runs
+	require.Eventually(t, func() bool {
+		_, renewals, _ := store.state()
+		return renewals >= 3
+	}, time.Second, time.Millisecond)
+	assert.True(t, e.IsLeader())
+	assert.False(t, stopped.Load())
+
+	cancel()
+	<-done
+	assert.True(t, stopped.Load())
+	assert.False(t, e.IsLeader())
+	holder, _, releases := store.state()
+	assert.Empty(t, holder) // Released for a standby
+	assert.Equal(t, 1, releases)
+}
+
+func TestElectorStepsDownWhenLeaseIsLost(t *testing.T) {
+	e, store := newTestElector(t)
+	lost := make(chan struct{})
+	run(t, e, func(ctx context.Context) {
+		<-ctx.Done()
+		close(lost)
+	})
+	require.Eventually(t, e.IsLeader, time.Second, time.Millisecond)
+
+	store.set(func(r *leaseRedis) { r.holder = "other" })
+
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("leader kept running after losing its lease")
+	}
+	require.Eventually(t, func() bool { return !e.IsLeader() }, time.Second, time.Millisecond)
+	holder, _, releases := store.state()
+	assert.Equal(t, "other", holder) // Another instance's lease is left alone
+	assert.Zero(t, releases)
+}
+
+func TestElectorStepsDownWhenRenewalsFail(t *testing.T) {
+	e, store := newTestElector(t)
+	lost := make(chan struct{})
+	run(t, e, func(ctx context.Context) {
+		<-ctx.Done()
+		close(lost)
+	})
+	require.Eventually(t, e.IsLeader, time.Second, time.Millisecond)
+
+	store.set(func(r *leaseRedis) { r.unhealthy = true })
+
+	// The lease may have expired by then, so another instance could lead
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("leader kept running without renewing its lease")
+	}
+}
+
+func TestElectorReleasesWhenFnReturns(t *testing.T) {
+	e, store := newTestElector(t)
+	var runs atomic.Int32
+	run(t, e, func(ctx context.Context) {
+		runs.Add(1)
+	})
+
+	// The lease is released rather than renewed forever, and won again
+	require.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond)
+	_, _, releases := store.state()
+	assert.GreaterOrEqual(t, releases, 1)
+}
+
+func TestNewElectorDefaultsTTL(t *testing.T) {
+	require.NoError(t, logger.Init("error", "test"))
+
+	e := NewElector(nil, "test", 0)
+	assert.Equal(t, DefaultTTL, e.ttl)
+	assert.Equal(t, DefaultTTL/3, e.interval)
+
+	e = NewElector(nil, "test", testTTL)
+	assert.Equal(t, testTTL/3, e.interval)
+}
@@ -7,7 +7,10 @@ import (
 
 var Logger *zap.Logger
 
-func Init(level string, env string) error {
+// level is Logger's level, which SetLevel changes while it is in use
+var level = zap.NewAtomicLevel()
+
+func Init(logLevel string, env string) error {
 	var config zap.Config
 
 	if env == "production" {
@@ -19,19 +22,8 @@ func Init(level string, env string) error {
 		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	}
 
-	// Parse log level
-	switch level {
-	case "debug":
-		config.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
-	case "info":
-		config.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
-	case "warn":
-		config.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
-	case "error":
-		config.Level = zap.NewAtomicLevelAt(zap.ErrorLevel)
-	default:
-		config.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
-	}
+	SetLevel(logLevel)
+	config.Level = level
 
 	var err error
 	Logger, err = config.Build(zap.AddCallerSkip(1))
@@ -42,6 +34,20 @@ func Init(level string, env string) error {
 	return nil
 }
 
+// SetLevel changes the level of the logger; unknown levels are info
+func SetLevel(logLevel string) {
+	switch logLevel {
+	case "debug":
+		level.SetLevel(zap.DebugLevel)
+	case "warn":
+		level.SetLevel(zap.WarnLevel)
+	case "error":
+		level.SetLevel(zap.ErrorLevel)
+	default:
+		level.SetLevel(zap.InfoLevel)
+	}
+}
+
 func Info(msg string, fields ...zap.Field) {
 	Logger.Info(msg, fields...)
 }
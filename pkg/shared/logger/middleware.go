@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+// Middleware logs every HTTP request with its request ID, so a request can
+// be followed across services, and the code of any error response
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		query := c.Request.URL.RawQuery
+
+		c.Next()
+
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", path),
+			zap.String("query", query),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+			zap.Int("response_size", c.Writer.Size()),
+			zap.String("request_id", requestctx.RequestID(c.Request.Context())),
+		}
+		if code := apierror.ResponseCode(c); code != "" {
+			fields = append(fields, zap.String("error_code", code))
+		}
+		Info("Request completed", fields...)
+	}
+}
@@ -0,0 +1,115 @@
+package maintenance
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"hedge-fund/pkg/shared/apierror"
+)
+
+// ScheduleRequest is the admin API payload for enabling maintenance
+type ScheduleRequest struct {
+	Reason    string     `json:"reason" binding:"required"`
+	StartsAt  *time.Time `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at"`
+	CreatedBy string     `json:"created_by"`
+}
+
+// ReadOnlyMiddleware rejects mutating requests with 503 and a Retry-After
+// header while maintenance is active. Paths under exemptPrefixes (e.g. the
+// admin API used to turn maintenance off) are always allowed.
+func (m *Manager) ReadOnlyMiddleware(exemptPrefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		for _, prefix := range exemptPrefixes {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		status := m.Status(c.Request.Context())
+		if !status.ReadOnly {
+			c.Next()
+			return
+		}
+
+		reason := "System is in read-only maintenance mode"
+		if status.Window != nil && status.Window.Reason != "" {
+			reason = status.Window.Reason
+		}
+
+		c.Header("Retry-After", strconv.Itoa(status.RetryAfter))
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.Response{
+			Code:    apierror.CodeMaintenance,
+			Error:   "Service in maintenance mode",
+			Details: reason,
+		})
+	}
+}
+
+// GetStatus godoc
+// @Summary Get maintenance status
+// @Tags admin
+// @Produce json
+// @Success 200 {object} Status
+// @Router /api/v1/admin/maintenance [get]
+func (m *Manager) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, m.Status(c.Request.Context()))
+}
+
+// ScheduleMaintenance godoc
+// @Summary Enable or schedule maintenance
+// @Description Put the system in read-only mode now or during a future window
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body ScheduleRequest true "Maintenance window"
+// @Success 200 {object} Status
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/maintenance [post]
+func (m *Manager) ScheduleMaintenance(c *gin.Context) {
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	window := Window{
+		Reason:    req.Reason,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		CreatedBy: req.CreatedBy,
+	}
+
+	if err := m.Schedule(c.Request.Context(), window); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Failed to schedule maintenance", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, m.Status(c.Request.Context()))
+}
+
+// ClearMaintenance godoc
+// @Summary Disable maintenance
+// @Tags admin
+// @Success 204
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/maintenance [delete]
+func (m *Manager) ClearMaintenance(c *gin.Context) {
+	if err := m.Clear(c.Request.Context()); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to clear maintenance", Details: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
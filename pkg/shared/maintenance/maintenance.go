@@ -0,0 +1,197 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/redis"
+)
+
+const (
+	stateKey = "system:maintenance"
+
+	// Events published on models.ChannelSystemEvents
+	EventMaintenanceScheduled = "maintenance_scheduled"
+	EventMaintenanceStarted   = "maintenance_started"
+	EventMaintenanceEnded     = "maintenance_ended"
+
+	// How long a state read from Redis is reused before re-reading
+	cacheTTL = 2 * time.Second
+)
+
+// Window describes an active or scheduled maintenance window. A window with
+// no StartsAt starts immediately; one with no EndsAt lasts until disabled.
+type Window struct {
+	Reason    string     `json:"reason"`
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Status is the current read-only state of the system
+type Status struct {
+	ReadOnly   bool    `json:"read_only"`
+	Forced     bool    `json:"forced"` // Enabled through MAINTENANCE_MODE config
+	Window     *Window `json:"window,omitempty"`
+	RetryAfter int     `json:"retry_after,omitempty"` // Seconds
+}
+
+// Manager stores the maintenance window in Redis so every service instance
+// shares a single read-only switch
+type Manager struct {
+	redis      *redis.Client
+	forced     bool
+	retryAfter time.Duration
+
+	mu       sync.Mutex
+	cached   *Window
+	cachedAt time.Time
+}
+
+// NewManager creates a maintenance manager
+func NewManager(redisClient *redis.Client, cfg *config.Config) *Manager {
+	return &Manager{
+		redis:      redisClient,
+		forced:     cfg.MaintenanceMode,
+		retryAfter: time.Duration(cfg.MaintenanceRetryAfter) * time.Second,
+	}
+}
+
+// Schedule stores a maintenance window and announces it on the system events channel
+func (m *Manager) Schedule(ctx context.Context, window Window) error {
+	if window.StartsAt != nil && window.EndsAt != nil && !window.EndsAt.After(*window.StartsAt) {
+		return fmt.Errorf("maintenance window must end after it starts")
+	}
+	window.CreatedAt = time.Now()
+
+	if err := m.redis.SetCache(ctx, stateKey, window, 0); err != nil {
+		return fmt.Errorf("failed to store maintenance window: %w", err)
+	}
+	m.setCached(&window)
+
+	eventType := EventMaintenanceStarted
+	if window.StartsAt != nil && window.StartsAt.After(time.Now()) {
+		eventType = EventMaintenanceScheduled
+	}
+	m.publish(ctx, eventType, &window)
+
+	logger.Warn("Maintenance window set",
+		zap.String("reason", window.Reason),
+		zap.String("event", eventType))
+	return nil
+}
+
+// Clear removes the maintenance window, returning the system to read-write
+func (m *Manager) Clear(ctx context.Context) error {
+	if err := m.redis.DeleteCache(ctx, stateKey); err != nil {
+		return fmt.Errorf("failed to clear maintenance window: %w", err)
+	}
+	m.setCached(nil)
+
+	m.publish(ctx, EventMaintenanceEnded, nil)
+	logger.Info("Maintenance window cleared")
+	return nil
+}
+
+// Status returns the current read-only state
+func (m *Manager) Status(ctx context.Context) Status {
+	window := m.window(ctx)
+	now := time.Now()
+
+	status := Status{Forced: m.forced, Window: window}
+	status.ReadOnly = m.forced || (window != nil && window.activeAt(now))
+
+	if status.ReadOnly {
+		status.RetryAfter = int(m.retryAfter.Seconds())
+		if window != nil && window.EndsAt != nil && window.EndsAt.After(now) {
+			status.RetryAfter = int(window.EndsAt.Sub(now).Seconds()) + 1
+		}
+	}
+
+	return status
+}
+
+// IsReadOnly reports whether mutating operations should be rejected
+func (m *Manager) IsReadOnly(ctx context.Context) bool {
+	return m.Status(ctx).ReadOnly
+}
+
+// Helper functions
+
+func (w *Window) activeAt(now time.Time) bool {
+	if w.StartsAt != nil && now.Before(*w.StartsAt) {
+		return false
+	}
+	if w.EndsAt != nil && !now.Before(*w.EndsAt) {
+		return false
+	}
+	return true
+}
+
+func (m *Manager) window(ctx context.Context) *Window {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if time.Since(m.cachedAt) < cacheTTL {
+		return m.cached
+	}
+
+	data, err := m.redis.Get(ctx, stateKey).Bytes()
+	if err == goredis.Nil {
+		m.cached = nil
+		m.cachedAt = time.Now()
+		return nil
+	}
+	if err != nil {
+		// Keep the last known state while Redis is unavailable
+		logger.Warn("Failed to read maintenance state", zap.Error(err))
+		return m.cached
+	}
+
+	var window Window
+	if err := json.Unmarshal(data, &window); err != nil {
+		logger.Warn("Failed to decode maintenance state", zap.Error(err))
+		return m.cached
+	}
+
+	m.cached = &window
+	m.cachedAt = time.Now()
+	return m.cached
+}
+
+func (m *Manager) setCached(window *Window) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cached = window
+	m.cachedAt = time.Now()
+}
+
+func (m *Manager) publish(ctx context.Context, eventType string, window *Window) {
+	data := map[string]interface{}{}
+	if window != nil {
+		data["reason"] = window.Reason
+		data["starts_at"] = window.StartsAt
+		data["ends_at"] = window.EndsAt
+	}
+
+	event := models.Event{
+		Type:      eventType,
+		Source:    "maintenance_manager",
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+
+	if err := m.redis.PublishEvent(ctx, models.ChannelSystemEvents, event); err != nil {
+		logger.Warn("Failed to publish maintenance event", zap.Error(err))
+	}
+}
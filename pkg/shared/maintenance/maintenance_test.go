@@ -0,0 +1,113 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// emptyRedis answers every command as if no window were stored, counting
+// the reads
+type emptyRedis struct {
+	reads int
+}
+
+func (r *emptyRedis) BeforeProcess(ctx context.Context, cmd goredis.Cmder) (context.Context, error) {
+	r.reads++
+	return ctx, goredis.Nil
+}
+
+func (r *emptyRedis) AfterProcess(context.Context, goredis.Cmder) error { return nil }
+
+func (r *emptyRedis) BeforeProcessPipeline(ctx context.Context, _ []goredis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (r *emptyRedis) AfterProcessPipeline(context.Context, []goredis.Cmder) error { return nil }
+
+func newTestManager(t *testing.T, forced bool) (*Manager, *emptyRedis) {
+	require.NoError(t, logger.Init("error", "test"))
+	store := &emptyRedis{}
+	client := goredis.NewClient(&goredis.Options{Addr: "127.0.0.1:0"})
+	client.AddHook(store)
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{MaintenanceMode: forced, MaintenanceRetryAfter: 30}
+	return NewManager(&redis.Client{Client: client}, cfg), store
+}
+
+func serve(m *Manager, method, path string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(m.ReadOnlyMiddleware("/api/v1/admin/maintenance"))
+	r.Handle(method, path, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestReadOnlyMiddleware(t *testing.T) {
+	m, _ := newTestManager(t, false)
+	endsAt := time.Now().Add(90 * time.Second)
+	m.setCached(&Window{Reason: "Database upgrade", EndsAt: &endsAt})
+
+	w := serve(m, http.MethodPost, "/api/v1/portfolios/1/trades")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After")) // Until the window ends
+	var resp apierror.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, apierror.CodeMaintenance, resp.Code)
+	assert.Equal(t, "Database upgrade", resp.Details)
+
+	// Reads and exempt paths are still served
+	assert.Equal(t, http.StatusOK, serve(m, http.MethodGet, "/api/v1/portfolios/1").Code)
+	assert.Equal(t, http.StatusOK, serve(m, http.MethodDelete, "/api/v1/admin/maintenance").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serve(m, http.MethodPost, "/api/v1/admin/provision").Code)
+
+	m.setCached(nil)
+	assert.Equal(t, http.StatusOK, serve(m, http.MethodPost, "/api/v1/portfolios/1/trades").Code)
+}
+
+func TestForcedMaintenance(t *testing.T) {
+	m, _ := newTestManager(t, true)
+
+	w := serve(m, http.MethodPut, "/api/v1/portfolios/1")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After")) // No window end, so the configured delay
+}
+
+func TestStatusCachesWindow(t *testing.T) {
+	m, store := newTestManager(t, false)
+	ctx := context.Background()
+
+	// A window set on this instance is used without reading Redis
+	m.setCached(&Window{Reason: "Upgrade"})
+	assert.True(t, m.IsReadOnly(ctx))
+	assert.True(t, m.IsReadOnly(ctx))
+	assert.Zero(t, store.reads)
+
+	// Once stale, the state is read again: another instance cleared it
+	m.cachedAt = time.Now().Add(-cacheTTL)
+	assert.False(t, m.IsReadOnly(ctx))
+	assert.Equal(t, 1, store.reads)
+	assert.False(t, m.IsReadOnly(ctx))
+	assert.Equal(t, 1, store.reads)
+
+	// Scheduled windows aren't read-only until they start
+	startsAt := time.Now().Add(time.Hour)
+	m.setCached(&Window{Reason: "Upgrade", StartsAt: &startsAt})
+	assert.False(t, m.IsReadOnly(ctx))
+}
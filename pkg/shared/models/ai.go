@@ -21,6 +21,8 @@ type AIAnalysisRequest struct {
 	StartDate *time.Time        `json:"start_date,omitempty"`
 	EndDate   *time.Time        `json:"end_date,omitempty"`
 	Options   map[string]interface{} `json:"options,omitempty"` // Additional options
+	Consensus string            `json:"consensus,omitempty"` // "majority", "confidence_weighted" or "performance_weighted"
+	PerformancePeriod string    `json:"performance_period,omitempty"` // Agent performance period weighting "performance_weighted", e.g. "1m"
 }
 
 // AIAnalysisResponse represents the response from AI analysis
@@ -30,6 +32,8 @@ type AIAnalysisResponse struct {
 	Signals        []AISignal        `json:"signals"`
 	ConsensusSignal string           `json:"consensus_signal"` // Overall consensus
 	ConsensusConfidence float64      `json:"consensus_confidence"`
+	ConsensusStrategy string         `json:"consensus_strategy"`
+	Instrument     *Instrument       `json:"instrument,omitempty"`
 	MarketData     *MarketData       `json:"market_data,omitempty"`
 	RiskMetrics    *RiskMetrics      `json:"risk_metrics,omitempty"`
 	ProcessingTime float64           `json:"processing_time_ms"`
@@ -71,14 +75,26 @@ type WorkflowStatus struct {
 	Status          string                 `json:"status"`          // "pending", "running", "completed", "failed"
 	CurrentStep     string                 `json:"current_step"`
 	CompletedSteps  []string               `json:"completed_steps"`
+	Steps           []WorkflowStep         `json:"steps"`           // In execution order
 	Progress        float64                `json:"progress"`        // 0-100
 	ErrorMessage    string                 `json:"error_message,omitempty"`
+	Request         AIAnalysisRequest      `json:"request"`
 	Result          *AIAnalysisResponse    `json:"result,omitempty"`
 	Metadata        map[string]interface{} `json:"metadata,omitempty"`
 	StartedAt       time.Time              `json:"started_at"`
 	CompletedAt     *time.Time             `json:"completed_at,omitempty"`
 }
 
+// WorkflowStep is the progress of one step of an AI workflow
+type WorkflowStep struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`   // "pending", "running", "retrying", "completed", "failed"
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error,omitempty"` // Of the latest failed attempt
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
 // AIAgentMetrics represents performance metrics for an AI agent
 type AIAgentMetrics struct {
 	AgentName       string    `json:"agent_name"`
@@ -89,4 +105,77 @@ type AIAgentMetrics struct {
 	AvgConfidence   float64   `json:"avg_confidence"`
 	LastRequest     time.Time `json:"last_request"`
 	LastSuccess     time.Time `json:"last_success"`
-}
\ No newline at end of file
+}
+// StrategyScript is a user-defined signal strategy written in Starlark. It
+// runs as a custom AI agent: its signals are recorded under AgentName.
+type StrategyScript struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	AgentName string    `json:"agent_name" db:"agent_name"`
+	Source    string    `json:"source" db:"source"`
+	Lookback  int       `json:"lookback" db:"lookback"` // Bars of history passed to the script with the current one
+	IsActive  bool      `json:"is_active" db:"is_active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AutoTradeSettings let high-confidence consensus signals trade a linked
+// portfolio, within per-symbol position caps and the owner's risk limits
+type AutoTradeSettings struct {
+	ID               int                `json:"id" db:"id"`
+	UserID           int                `json:"user_id" db:"user_id"`
+	PortfolioID      int                `json:"portfolio_id" db:"portfolio_id"`
+	Mode             string             `json:"mode" db:"mode"` // "auto" executes orders, "manual" queues them for approval
+	Consensus        string             `json:"consensus" db:"consensus"`
+	MinConfidence    float64            `json:"min_confidence" db:"min_confidence"`
+	OrderValue       float64            `json:"order_value" db:"order_value"`               // Market value bought per buy signal
+	MaxPositionValue float64            `json:"max_position_value" db:"max_position_value"` // Per-symbol position cap
+	SymbolCaps       map[string]float64 `json:"symbol_caps" db:"symbol_caps"`               // Per-symbol overrides of MaxPositionValue
+	IsEnabled        bool               `json:"is_enabled" db:"is_enabled"`
+	CreatedAt        time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// Auto-trade modes
+const (
+	AutoTradeAuto   = "auto"
+	AutoTradeManual = "manual"
+)
+
+// AutoTradeOrder is an order raised by a consensus signal
+type AutoTradeOrder struct {
+	ID              int        `json:"id" db:"id"`
+	SettingsID      int        `json:"settings_id" db:"settings_id"`
+	UserID          int        `json:"user_id" db:"user_id"`
+	PortfolioID     int        `json:"portfolio_id" db:"portfolio_id"`
+	Symbol          string     `json:"symbol" db:"symbol"`
+	Side            string     `json:"side" db:"side"`
+	Quantity        float64    `json:"quantity" db:"quantity"` // Whole shares, or fractions of crypto
+	Price           float64    `json:"price" db:"price"`       // When the signal arrived; orders execute at market
+	ConsensusSignal string     `json:"consensus_signal" db:"consensus_signal"`
+	Confidence      float64    `json:"confidence" db:"confidence"`
+	Status          string     `json:"status" db:"status"`
+	Reason          string     `json:"reason,omitempty" db:"reason"`
+	TradeID         *int       `json:"trade_id,omitempty" db:"trade_id"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty" db:"decided_at"`
+}
+
+// Auto-trade order statuses
+const (
+	AutoTradePendingApproval = "pending_approval"
+	AutoTradeApproved        = "approved" // Being placed
+	AutoTradeExecuted        = "executed"
+	AutoTradeRejected        = "rejected"
+	AutoTradeBlocked         = "blocked" // Stopped by a guardrail
+	AutoTradeFailed          = "failed"
+	AutoTradeExpired         = "expired" // Not approved in time
+)
+
+// KillSwitch halts all auto-trading while engaged
+type KillSwitch struct {
+	Engaged   bool       `json:"engaged"`
+	Reason    string     `json:"reason,omitempty"`
+	EngagedAt *time.Time `json:"engaged_at,omitempty"`
+}
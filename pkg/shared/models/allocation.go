@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// AllocationModel is a named target allocation a portfolio is monitored against
+type AllocationModel struct {
+	ID             int                `json:"id" db:"id"`
+	PortfolioID    int                `json:"portfolio_id" db:"portfolio_id"`
+	Name           string             `json:"name" db:"name"`
+	Basis          string             `json:"basis" db:"basis"`                     // "symbol" or "sector"
+	Targets        map[string]float64 `json:"targets" db:"targets"`                 // % of total value per symbol or sector; CASH targets cash
+	DriftThreshold float64            `json:"drift_threshold" db:"drift_threshold"` // Percentage points of drift that raise an alert
+	IsActive       bool               `json:"is_active" db:"is_active"`
+	CreatedAt      time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// Allocation model bases
+const (
+	AllocationBasisSymbol = "symbol"
+	AllocationBasisSector = "sector"
+)
+
+// AllocationDrift is how far one symbol or sector is from its target
+type AllocationDrift struct {
+	Key    string  `json:"key"`
+	Target float64 `json:"target"`
+	Actual float64 `json:"actual"`
+	Drift  float64 `json:"drift"` // Actual minus target, in percentage points
+}
+
+// DriftCheck is one comparison of a portfolio against an allocation model
+type DriftCheck struct {
+	ID          int64             `json:"id" db:"id"`
+	ModelID     int               `json:"model_id" db:"model_id"`
+	PortfolioID int               `json:"portfolio_id" db:"portfolio_id"`
+	MaxDrift    float64           `json:"max_drift" db:"max_drift"`
+	Threshold   float64           `json:"threshold" db:"threshold"`
+	Breached    bool              `json:"breached" db:"breached"`
+	Drifts      []AllocationDrift `json:"drifts" db:"drifts"` // Largest drift first
+	CheckedAt   time.Time         `json:"checked_at" db:"checked_at"`
+}
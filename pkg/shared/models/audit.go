@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent records a single mutation of a portfolio, position or trade
+type AuditEvent struct {
+	ID          int64           `json:"id" db:"id"`
+	PortfolioID int             `json:"portfolio_id" db:"portfolio_id"`
+	EntityType  string          `json:"entity_type" db:"entity_type"` // "portfolio", "position", "trade"
+	EntityID    int             `json:"entity_id" db:"entity_id"`
+	Action      string          `json:"action" db:"action"` // "create", "update", "delete"
+	Actor       string          `json:"actor" db:"actor"`
+	RequestID   string          `json:"request_id" db:"request_id"`
+	Before      json.RawMessage `json:"before,omitempty" db:"before_state"`
+	After       json.RawMessage `json:"after,omitempty" db:"after_state"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+}
+
+// Audit entity types and actions
+const (
+	AuditEntityPortfolio = "portfolio"
+	AuditEntityPosition  = "position"
+	AuditEntityTrade     = "trade"
+	AuditEntitySettings  = "settings"
+
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// PortfolioSnapshot is a portfolio's value at the end of a day
+type PortfolioSnapshot struct {
+	ID           int64     `json:"id" db:"id"`
+	PortfolioID  int       `json:"portfolio_id" db:"portfolio_id"`
+	SnapshotDate time.Time `json:"snapshot_date" db:"snapshot_date"`
+	TotalValue   float64   `json:"total_value" db:"total_value"`
+	Cash         float64   `json:"cash" db:"cash"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// BenchmarkRule alerts when a portfolio's return over WindowDays trails its
+// benchmark's by more than Threshold percentage points
+type BenchmarkRule struct {
+	ID              int        `json:"id" db:"id"`
+	PortfolioID     int        `json:"portfolio_id" db:"portfolio_id"`
+	Benchmark       string     `json:"benchmark" db:"benchmark"` // Benchmark symbol, e.g. SPY
+	WindowDays      int        `json:"window_days" db:"window_days"`
+	Threshold       float64    `json:"threshold" db:"threshold"`
+	IsActive        bool       `json:"is_active" db:"is_active"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty" db:"last_triggered_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// BenchmarkComparison is a portfolio's return against its benchmark's over
+// a rule's window. Returns are in percent.
+type BenchmarkComparison struct {
+	RuleID          int       `json:"rule_id"`
+	PortfolioID     int       `json:"portfolio_id"`
+	Benchmark       string    `json:"benchmark"`
+	StartDate       time.Time `json:"start_date"`
+	EndDate         time.Time `json:"end_date"`
+	PortfolioReturn float64   `json:"portfolio_return"`
+	BenchmarkReturn float64   `json:"benchmark_return"`
+	RelativeReturn  float64   `json:"relative_return"` // Portfolio minus benchmark, in percentage points
+	Threshold       float64   `json:"threshold"`
+	Lagging         bool      `json:"lagging"`
+}
+
+// BenchmarkSimulation is a portfolio's value over its daily snapshots
+// alongside a counterfactual that put the same money into its benchmark at
+// the same times: cash moves as it did in the portfolio, but buys and sales
+// trade the benchmark instead.
+type BenchmarkSimulation struct {
+	PortfolioID    int                        `json:"portfolio_id"`
+	Benchmark      string                     `json:"benchmark"`
+	StartDate      time.Time                  `json:"start_date"`
+	EndDate        time.Time                  `json:"end_date"`
+	Contributions  float64                    `json:"contributions"` // Deposits and incoming transfers over the period
+	Withdrawals    float64                    `json:"withdrawals"`   // Withdrawals and outgoing transfers over the period
+	PortfolioValue float64                    `json:"portfolio_value"`
+	BenchmarkValue float64                    `json:"benchmark_value"` // Of the counterfactual
+	Difference     float64                    `json:"difference"`      // Portfolio minus counterfactual value
+	Points         []BenchmarkSimulationPoint `json:"points"`          // One per snapshot, oldest first
+}
+
+// BenchmarkSimulationPoint is the real and counterfactual value on a day
+type BenchmarkSimulationPoint struct {
+	Date           time.Time `json:"date"`
+	PortfolioValue float64   `json:"portfolio_value"`
+	BenchmarkValue float64   `json:"benchmark_value"`
+	BenchmarkClose float64   `json:"benchmark_close"` // The counterfactual was valued at
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// CashTransaction is an entry in a portfolio's cash ledger. A transfer is
+// recorded on both portfolios, as transfer_out and transfer_in entries
+// sharing a transfer ID.
+type CashTransaction struct {
+	ID                      int64     `json:"id" db:"id"`
+	PortfolioID             int       `json:"portfolio_id" db:"portfolio_id"`
+	Type                    string    `json:"type" db:"type"`
+	Amount                  float64   `json:"amount" db:"amount"`               // Always positive; the type gives the direction
+	BalanceAfter            float64   `json:"balance_after" db:"balance_after"` // Cash once the entry was applied
+	CounterpartyPortfolioID *int      `json:"counterparty_portfolio_id,omitempty" db:"counterparty_portfolio_id"`
+	TransferID              string    `json:"transfer_id,omitempty" db:"transfer_id"`
+	Note                    string    `json:"note,omitempty" db:"note"`
+	RequestID               string    `json:"request_id,omitempty" db:"request_id"`
+	CreatedAt               time.Time `json:"created_at" db:"created_at"`
+}
+
+// Cash transaction types
+const (
+	CashDeposit     = "deposit"
+	CashWithdrawal  = "withdrawal"
+	CashTransferIn  = "transfer_in"
+	CashTransferOut = "transfer_out"
+)
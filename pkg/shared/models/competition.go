@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Competition ranks enrolled paper portfolios by their return between
+// StartDate and EndDate (inclusive, UTC dates)
+type Competition struct {
+	ID           int              `json:"id" db:"id"`
+	Name         string           `json:"name" db:"name"`
+	Description  string           `json:"description,omitempty" db:"description"`
+	Universe     []string         `json:"universe" db:"universe"` // Tradable symbols; empty allows any
+	StartDate    time.Time        `json:"start_date" db:"start_date"`
+	EndDate      time.Time        `json:"end_date" db:"end_date"`
+	StartingCash float64          `json:"starting_cash" db:"starting_cash"`
+	Rules        CompetitionRules `json:"rules" db:"rules"`
+	Status       string           `json:"status" db:"-"`
+	Entries      int              `json:"entries" db:"-"`
+	CreatedAt    time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// CompetitionRules restrict the trades of enrolled portfolios while the
+// competition runs. Zero values leave a rule off.
+type CompetitionRules struct {
+	MaxPositionPercent float64 `json:"max_position_percent,omitempty"` // Largest position after a buy, in percent of portfolio value
+	MaxTradesPerDay    int     `json:"max_trades_per_day,omitempty"`
+}
+
+// Competition statuses
+const (
+	CompetitionUpcoming = "upcoming"
+	CompetitionActive   = "active"
+	CompetitionFinished = "finished"
+)
+
+// CompetitionEntry is a portfolio enrolled in a competition
+type CompetitionEntry struct {
+	ID            int       `json:"id" db:"id"`
+	CompetitionID int       `json:"competition_id" db:"competition_id"`
+	PortfolioID   int       `json:"portfolio_id" db:"portfolio_id"`
+	UserID        int       `json:"user_id" db:"user_id"`
+	JoinedAt      time.Time `json:"joined_at" db:"joined_at"`
+}
+
+// CompetitionStanding is an entry's rank on a day of a competition
+type CompetitionStanding struct {
+	CompetitionID int       `json:"competition_id" db:"competition_id"`
+	PortfolioID   int       `json:"portfolio_id" db:"portfolio_id"`
+	Username      string    `json:"username" db:"username"`
+	AsOf          time.Time `json:"as_of" db:"as_of"`
+	TotalValue    float64   `json:"total_value" db:"total_value"`
+	ReturnPercent float64   `json:"return_percent" db:"return_percent"`
+	Rank          int       `json:"rank" db:"rank"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// DrawdownAnalysis describes a portfolio's declines from its running peak
+// value over a period of daily snapshots, net of the cash moved in and out.
+// Drawdowns are percentages below the peak and durations are calendar days.
+type DrawdownAnalysis struct {
+	PortfolioID         int               `json:"portfolio_id"`
+	StartDate           time.Time         `json:"start_date"`
+	EndDate             time.Time         `json:"end_date"`
+	MaxDrawdown         float64           `json:"max_drawdown"`
+	MaxDrawdownPeak     *time.Time        `json:"max_drawdown_peak,omitempty"`
+	MaxDrawdownTrough   *time.Time        `json:"max_drawdown_trough,omitempty"`
+	MaxDrawdownRecovery *time.Time        `json:"max_drawdown_recovery,omitempty"` // Nil while not recovered
+	MaxDrawdownDays     int               `json:"max_drawdown_days"`               // Peak to recovery, or to EndDate
+	LongestDrawdownDays int               `json:"longest_drawdown_days"`
+	CurrentDrawdown     float64           `json:"current_drawdown"`
+	CurrentDrawdownDays int               `json:"current_drawdown_days"`
+	Periods             []DrawdownPeriod  `json:"periods"`    // Deepest first
+	Underwater          []UnderwaterPoint `json:"underwater"` // One point per snapshot, oldest first
+}
+
+// DrawdownPeriod is one decline from a peak until the value regains it
+type DrawdownPeriod struct {
+	Peak     time.Time  `json:"peak"`
+	Trough   time.Time  `json:"trough"`
+	Recovery *time.Time `json:"recovery,omitempty"` // Nil while not recovered
+	Depth    float64    `json:"depth"`
+	Days     int        `json:"days"` // Peak to recovery, or to the last snapshot
+}
+
+// UnderwaterPoint is a day on the underwater curve: how far the value was
+// below its running peak, and the deepest drawdown up to that day. Peak is in
+// the day's money, scaled by the cash moved since the peak.
+type UnderwaterPoint struct {
+	Date        time.Time `json:"date"`
+	Value       float64   `json:"value"`
+	Peak        float64   `json:"peak"`
+	Drawdown    float64   `json:"drawdown"`
+	MaxDrawdown float64   `json:"max_drawdown"`
+}
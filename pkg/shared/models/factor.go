@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// FactorExposure is a portfolio's beta to its benchmark and its exposure to
+// simple price-based style factors on a day. Factor exposures are the
+// value-weighted average of its positions', each measured relative to the
+// benchmark, so a portfolio that tracks the benchmark is near zero.
+type FactorExposure struct {
+	ID           int64                    `json:"id" db:"id"`
+	PortfolioID  int                      `json:"portfolio_id" db:"portfolio_id"`
+	Benchmark    string                   `json:"benchmark" db:"benchmark"`
+	ExposureDate time.Time                `json:"exposure_date" db:"exposure_date"`
+	Beta         float64                  `json:"beta" db:"beta"`
+	Size         float64                  `json:"size" db:"size"`         // Log of average dollar volume relative to the benchmark's
+	Momentum     float64                  `json:"momentum" db:"momentum"` // 12-1 month return less the benchmark's
+	Value        float64                  `json:"value" db:"value"`       // Discount to the 52-week high less the benchmark's
+	Positions    []PositionFactorExposure `json:"positions,omitempty"`    // Not stored
+	CreatedAt    time.Time                `json:"created_at" db:"created_at"`
+}
+
+// PositionFactorExposure is one position's beta and factor exposures.
+// Weight is its signed share of the portfolio's value; shorts are negative.
+type PositionFactorExposure struct {
+	Symbol   string  `json:"symbol"`
+	Weight   float64 `json:"weight"`
+	Beta     float64 `json:"beta"`
+	Size     float64 `json:"size"`
+	Momentum float64 `json:"momentum"`
+	Value    float64 `json:"value"`
+}
@@ -12,6 +12,7 @@ type Job struct {
 	Retries   int                    `json:"retries"`
 	CreatedAt time.Time              `json:"created_at"`
 	ScheduledAt *time.Time           `json:"scheduled_at,omitempty"` // For delayed jobs
+	IdempotencyKey string            `json:"idempotency_key,omitempty"` // Side effects of jobs sharing it commit once; the job ID when unset
 }
 
 // AIAnalysisJob represents a job for AI analysis
@@ -45,19 +46,28 @@ type RiskCalculationJob struct {
 type NotificationJob struct {
 	Job
 	UserID   int                    `json:"user_id"`
-	Type     string                 `json:"notification_type"` // "email", "slack", "webhook"
-	Subject  string                 `json:"subject"`
-	Message  string                 `json:"message"`
+	Type     string                 `json:"notification_type"` // Template name, e.g. "trade_executed", "risk_alert"
+	Subject  string                 `json:"subject"`           // Overrides the template subject
+	Message  string                 `json:"message"`           // Overrides the template body
 	Data     map[string]interface{} `json:"data"`
-	Channels []string               `json:"channels"`
+	Channels []string               `json:"channels"` // "email", "slack", "webhook"; empty means all enabled
+}
+
+// WebhookJob is an event to post to a user's webhook endpoints
+type WebhookJob struct {
+	Job
+	UserID    int                    `json:"user_id"`
+	EventType string                 `json:"event_type"`
+	Data      map[string]interface{} `json:"data"`
 }
 
 // ReportGenerationJob represents a job for generating reports
 type ReportGenerationJob struct {
 	Job
+	ReportID    string    `json:"report_id"`
 	UserID      int       `json:"user_id"`
 	PortfolioID int       `json:"portfolio_id"`
-	ReportType  string    `json:"report_type"` // "performance", "risk", "positions"
+	ReportType  string    `json:"report_type"` // "performance", "positions", "trades"
 	StartDate   time.Time `json:"start_date"`
 	EndDate     time.Time `json:"end_date"`
 	Format      string    `json:"format"` // "pdf", "csv", "json"
@@ -83,10 +93,14 @@ const (
 	QueueAIAnalysis   = "queue:ai_analysis"
 	QueueRiskCalc     = "queue:risk_calculation"
 	QueueNotifications = "queue:notifications"
+	QueueWebhooks     = "queue:webhooks"
 
 	// Medium priority queues
 	QueueMarketData   = "queue:market_data"
 	QueueReports      = "queue:reports"
+	QueueBacktests    = "queue:backtests"
+	QueueReconciliation = "queue:reconciliation"
+	QueueImports      = "queue:imports"
 
 	// Low priority queues
 	QueueCleanup      = "queue:cleanup"
@@ -99,6 +113,10 @@ const (
 	JobTypeNotification    = "notification"
 	JobTypeReportGeneration = "report_generation"
 	JobTypeCleanup         = "cleanup"
+	JobTypeBacktestOptimization = "backtest_optimization"
+	JobTypeReconciliation  = "reconciliation"
+	JobTypePortfolioImport = "portfolio_import"
+	JobTypeWebhook         = "webhook"
 
 	// Job statuses
 	JobStatusPending   = "pending"
@@ -125,13 +143,24 @@ type PriceUpdateEvent struct {
 	Volume int64   `json:"volume"`
 }
 
+// PriceSnapshotEvent is a symbol's price updates over a conflation interval
+// coalesced into one. It decodes as a PriceUpdateEvent carrying the latest
+// price, the change and volume over the interval.
+type PriceSnapshotEvent struct {
+	PriceUpdateEvent
+	Open    float64 `json:"open"` // Price of the first update in the interval
+	High    float64 `json:"high"`
+	Low     float64 `json:"low"`
+	Updates int     `json:"updates"` // Raw updates coalesced
+}
+
 // TradeExecutedEvent represents a trade execution
 type TradeExecutedEvent struct {
 	Event
 	TradeID  int     `json:"trade_id"`
 	UserID   int     `json:"user_id"`
 	Symbol   string  `json:"symbol"`
-	Quantity int64   `json:"quantity"`
+	Quantity float64 `json:"quantity"`
 	Price    float64 `json:"price"`
 	Side     string  `json:"side"`
 }
@@ -160,11 +189,19 @@ type AISignalEvent struct {
 	Price      float64 `json:"price"`
 }
 
+// WorkflowEvent carries an AI workflow's status after a change
+type WorkflowEvent struct {
+	Event
+	Workflow WorkflowStatus `json:"workflow"`
+}
+
 // Event channels for pub/sub
 const (
-	ChannelPriceUpdates = "events:price_updates"
-	ChannelTradeEvents  = "events:trades"
-	ChannelRiskAlerts   = "events:risk_alerts"
-	ChannelAISignals    = "events:ai_signals"
-	ChannelSystemEvents = "events:system"
+	ChannelPriceUpdates   = "events:price_updates"   // Every raw price update
+	ChannelPriceSnapshots = "events:price_snapshots" // Price updates conflated per symbol and interval
+	ChannelTradeEvents    = "events:trades"
+	ChannelRiskAlerts     = "events:risk_alerts"
+	ChannelAISignals      = "events:ai_signals"
+	ChannelAIWorkflows    = "events:ai_workflows"
+	ChannelSystemEvents   = "events:system"
 )
\ No newline at end of file
@@ -5,6 +5,7 @@ import "time"
 // Price represents market price data
 type Price struct {
 	Symbol    string    `json:"symbol" db:"symbol"`
+	Interval  string    `json:"interval" db:"bar_interval"` // Bar width, e.g. "1d"
 	Open      float64   `json:"open" db:"open"`
 	High      float64   `json:"high" db:"high"`
 	Low       float64   `json:"low" db:"low"`
@@ -14,6 +15,88 @@ type Price struct {
 	Source    string    `json:"source" db:"source"` // API source identifier
 }
 
+// RiskFreeRate is one day's point of the risk-free rate curve for a tenor
+type RiskFreeRate struct {
+	Tenor  string    `json:"tenor" db:"tenor"` // e.g. "3m", "1y"
+	Date   time.Time `json:"date" db:"rate_date"`
+	Rate   float64   `json:"rate" db:"rate"` // Annual, e.g. 0.04
+	Source string    `json:"source" db:"source"`
+}
+
+// Fundamentals are a company's reported financials and ratios for one fiscal
+// period. Values the provider does not report are nil.
+type Fundamentals struct {
+	Symbol       string    `json:"symbol" db:"symbol"`
+	Period       string    `json:"period" db:"period"`               // "annual", "quarterly" or "ttm"
+	ReportPeriod time.Time `json:"report_period" db:"report_period"` // End of the fiscal period
+	Currency     string    `json:"currency" db:"currency"`
+
+	// Income statement
+	Revenue   *float64 `json:"revenue" db:"revenue"`
+	NetIncome *float64 `json:"net_income" db:"net_income"`
+	EPS       *float64 `json:"eps" db:"eps"` // Diluted
+
+	// Balance sheet
+	TotalAssets        *float64 `json:"total_assets" db:"total_assets"`
+	TotalLiabilities   *float64 `json:"total_liabilities" db:"total_liabilities"`
+	ShareholdersEquity *float64 `json:"shareholders_equity" db:"shareholders_equity"`
+	TotalDebt          *float64 `json:"total_debt" db:"total_debt"`
+	CurrentAssets      *float64 `json:"current_assets" db:"current_assets"`
+	CurrentLiabilities *float64 `json:"current_liabilities" db:"current_liabilities"`
+
+	// Cash flow statement
+	OperatingCashFlow  *float64 `json:"operating_cash_flow" db:"operating_cash_flow"`
+	CapitalExpenditure *float64 `json:"capital_expenditure" db:"capital_expenditure"` // Negative, as reported
+	FreeCashFlow       *float64 `json:"free_cash_flow" db:"free_cash_flow"`
+
+	// Ratios, as fractions
+	GrossMargin     *float64 `json:"gross_margin" db:"gross_margin"`
+	OperatingMargin *float64 `json:"operating_margin" db:"operating_margin"`
+	NetMargin       *float64 `json:"net_margin" db:"net_margin"`
+	ReturnOnEquity  *float64 `json:"return_on_equity" db:"return_on_equity"`
+	ReturnOnAssets  *float64 `json:"return_on_assets" db:"return_on_assets"`
+	DebtToEquity    *float64 `json:"debt_to_equity" db:"debt_to_equity"`
+	CurrentRatio    *float64 `json:"current_ratio" db:"current_ratio"`
+
+	// Valuation multiples at the end of the period
+	MarketCap         *float64 `json:"market_cap" db:"market_cap"`
+	PriceToEarnings   *float64 `json:"price_to_earnings" db:"price_to_earnings"`
+	PriceToBook       *float64 `json:"price_to_book" db:"price_to_book"`
+	PriceToSales      *float64 `json:"price_to_sales" db:"price_to_sales"`
+	EVToEBITDA        *float64 `json:"ev_to_ebitda" db:"ev_to_ebitda"`
+	FreeCashFlowYield *float64 `json:"free_cash_flow_yield" db:"free_cash_flow_yield"`
+
+	Source    string    `json:"source" db:"source"`
+	FetchedAt time.Time `json:"fetched_at" db:"fetched_at"`
+}
+
+// InsiderTrade is an insider's transaction in a company's securities
+type InsiderTrade struct {
+	Symbol           string     `json:"symbol" db:"symbol"`
+	InsiderName      string     `json:"insider_name" db:"insider_name"`
+	Title            string     `json:"title" db:"title"`
+	IsDirector       bool       `json:"is_director" db:"is_director"`
+	SecurityTitle    string     `json:"security_title" db:"security_title"`
+	TransactionDate  *time.Time `json:"transaction_date" db:"transaction_date"`
+	FilingDate       time.Time  `json:"filing_date" db:"filing_date"`
+	Shares           *float64   `json:"shares" db:"shares"` // Negative for sales
+	Price            *float64   `json:"price" db:"price"`
+	Value            *float64   `json:"value" db:"value"`
+	SharesOwnedAfter *float64   `json:"shares_owned_after" db:"shares_owned_after"`
+	Source           string     `json:"source" db:"source"`
+}
+
+// InstitutionalHolding is an institution's position in a company at the end
+// of a quarter
+type InstitutionalHolding struct {
+	Symbol       string    `json:"symbol" db:"symbol"`
+	Investor     string    `json:"investor" db:"investor"`
+	ReportPeriod time.Time `json:"report_period" db:"report_period"`
+	Shares       float64   `json:"shares" db:"shares"`
+	MarketValue  *float64  `json:"market_value" db:"market_value"`
+	Source       string    `json:"source" db:"source"`
+}
+
 // Quote represents real-time quote data
 type Quote struct {
 	Symbol    string    `json:"symbol"`
@@ -102,4 +185,49 @@ type MarketIndex struct {
 	Change        float64   `json:"change"`
 	ChangePercent float64   `json:"change_percent"`
 	LastUpdated   time.Time `json:"last_updated"`
-}
\ No newline at end of file
+}
+
+// Mover is a symbol's move over its latest trading day
+type Mover struct {
+	Symbol        string  `json:"symbol"`
+	Name          string  `json:"name"`
+	Price         float64 `json:"price"`
+	Change        float64 `json:"change"`
+	ChangePercent float64 `json:"change_percent"`
+	Volume        int64   `json:"volume"`
+}
+
+// SectorPerformance is the equal-weighted move of a sector's symbols over
+// their latest trading day
+type SectorPerformance struct {
+	Sector        string  `json:"sector"`
+	ChangePercent float64 `json:"change_percent"`
+	Symbols       int     `json:"symbols"`
+	Advancers     int     `json:"advancers"`
+	Decliners     int     `json:"decliners"`
+}
+
+// MarketOverview summarizes the latest trading day: index levels, the
+// biggest movers and how each sector did
+type MarketOverview struct {
+	AsOf        time.Time           `json:"as_of"` // Trading day covered
+	Indices     []MarketIndex       `json:"indices"`
+	Gainers     []Mover             `json:"gainers"`
+	Losers      []Mover             `json:"losers"`
+	Sectors     []SectorPerformance `json:"sectors"` // Best first
+	GeneratedAt time.Time           `json:"generated_at"`
+}
+// Instrument represents reference metadata for a tradable symbol
+type Instrument struct {
+	Symbol     string          `json:"symbol" db:"symbol"`
+	Name       string          `json:"name" db:"name"`
+	AssetClass string          `json:"asset_class" db:"asset_class"` // "equity", "etf", "option", "crypto"
+	Sector     string          `json:"sector" db:"sector"`
+	Industry   string          `json:"industry" db:"industry"`
+	Exchange   string          `json:"exchange" db:"exchange"`
+	Currency   string          `json:"currency" db:"currency"`
+	Option     *OptionContract `json:"option,omitempty"` // Contract terms of an option; nil for other asset classes
+	Tradable   bool            `json:"tradable" db:"tradable"` // False once the data provider stops listing the symbol
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// DataMigrationRun is one applied run of a data migration, kept so it can be rolled back
+type DataMigrationRun struct {
+	ID           int        `json:"id" db:"id"`
+	Name         string     `json:"name" db:"name"`
+	Status       string     `json:"status" db:"status"` // "applied", "rolled_back"
+	Changed      int        `json:"changed" db:"changed"`
+	Unresolved   int        `json:"unresolved" db:"unresolved"`
+	AppliedBy    string     `json:"applied_by" db:"applied_by"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	RolledBackAt *time.Time `json:"rolled_back_at,omitempty" db:"rolled_back_at"`
+}
+
+// Data migration run statuses
+const (
+	MigrationApplied    = "applied"
+	MigrationRolledBack = "rolled_back"
+)
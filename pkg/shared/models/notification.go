@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// NotificationPreferences controls which channels a user is notified on
+type NotificationPreferences struct {
+	UserID          int       `json:"user_id" db:"user_id"`
+	EmailEnabled    bool      `json:"email_enabled" db:"email_enabled"`
+	SlackEnabled    bool      `json:"slack_enabled" db:"slack_enabled"`
+	WebhookEnabled  bool      `json:"webhook_enabled" db:"webhook_enabled"`
+	EmailAddress    string    `json:"email_address" db:"email_address"` // Defaults to the account email
+	SlackWebhookURL string    `json:"slack_webhook_url" db:"slack_webhook_url"`
+	WebhookURL      string    `json:"webhook_url" db:"webhook_url"`
+	MutedTypes      []string  `json:"muted_types" db:"muted_types"` // Notification types never delivered
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationDelivery records the outcome of sending a notification on one channel
+type NotificationDelivery struct {
+	ID               int64      `json:"id" db:"id"`
+	JobID            string     `json:"job_id" db:"job_id"`
+	UserID           int        `json:"user_id" db:"user_id"`
+	Channel          string     `json:"channel" db:"channel"`
+	NotificationType string     `json:"notification_type" db:"notification_type"`
+	Recipient        string     `json:"recipient" db:"recipient"`
+	Subject          string     `json:"subject" db:"subject"`
+	Status           string     `json:"status" db:"status"`
+	Attempts         int        `json:"attempts" db:"attempts"`
+	LastError        string     `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt      *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// WebhookEndpoint is a user-configured URL signed event webhooks are posted to
+type WebhookEndpoint struct {
+	ID         int       `json:"id" db:"id"`
+	UserID     int       `json:"user_id" db:"user_id"`
+	URL        string    `json:"url" db:"url"`
+	Secret     string    `json:"-" db:"secret"`                // Key of the HMAC-SHA256 signature
+	EventTypes []string  `json:"event_types" db:"event_types"` // Events posted; empty means every event
+	Active     bool      `json:"active" db:"active"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// WebhookDelivery records the outcome of posting an event to one endpoint
+type WebhookDelivery struct {
+	ID             int64      `json:"id" db:"id"`
+	EventID        string     `json:"event_id" db:"event_id"`
+	EndpointID     int        `json:"endpoint_id" db:"endpoint_id"`
+	EventType      string     `json:"event_type" db:"event_type"`
+	Status         string     `json:"status" db:"status"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty" db:"response_status"` // HTTP status of the last attempt
+	LastError      string     `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// Webhook event types
+const (
+	WebhookEventTradeExecuted = "trade_executed"
+)
+
+// Notification channels
+const (
+	NotificationChannelEmail   = "email"
+	NotificationChannelSlack   = "slack"
+	NotificationChannelWebhook = "webhook"
+)
+
+// Notification delivery statuses
+const (
+	DeliveryStatusSent    = "sent"
+	DeliveryStatusFailed  = "failed"
+	DeliveryStatusSkipped = "skipped"
+)
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Option types
+const (
+	OptionCall = "call"
+	OptionPut  = "put"
+)
+
+// DefaultOptionMultiplier is the shares of the underlying a standard equity
+// option contract covers
+const DefaultOptionMultiplier = 100
+
+// OptionContract is the terms of an equity option
+type OptionContract struct {
+	Underlying string    `json:"underlying" db:"underlying"`
+	Type       string    `json:"option_type" db:"option_type"` // "call" or "put"
+	Strike     float64   `json:"strike" db:"strike"`
+	Expiry     time.Time `json:"expiry" db:"expiry"`         // Last trading day, as a UTC date
+	Multiplier float64   `json:"multiplier" db:"multiplier"` // Shares of the underlying per contract
+}
+
+// IntrinsicValue is what one share's worth of the contract is worth
+// exercised at underlyingPrice: the amount a call's strike is below it or a
+// put's above it, and zero out of the money
+func (o *OptionContract) IntrinsicValue(underlyingPrice float64) float64 {
+	if o.Type == OptionPut {
+		return math.Max(o.Strike-underlyingPrice, 0)
+	}
+	return math.Max(underlyingPrice-o.Strike, 0)
+}
+
+// Expired reports whether the contract's last trading day is over at now
+func (o *OptionContract) Expired(now time.Time) bool {
+	return !now.UTC().Before(o.Expiry.AddDate(0, 0, 1))
+}
@@ -6,52 +6,145 @@ import (
 
 // Position represents a trading position
 type Position struct {
-	ID               int       `json:"id" db:"id"`
-	UserID           int       `json:"user_id" db:"user_id"`
-	PortfolioID      int       `json:"portfolio_id" db:"portfolio_id"`
-	Symbol           string    `json:"symbol" db:"symbol"`
-	Quantity         int64     `json:"quantity" db:"quantity"`
-	Side             string    `json:"side" db:"side"` // "long" or "short"
-	EntryPrice       float64   `json:"entry_price" db:"entry_price"`
-	CurrentPrice     float64   `json:"current_price" db:"current_price"`
-	UnrealizedPnL    float64   `json:"unrealized_pnl" db:"unrealized_pnl"`
-	RealizedPnL      float64   `json:"realized_pnl" db:"realized_pnl"`
-	CreatedAt        time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+	ID              int        `json:"id" db:"id"`
+	UserID          int        `json:"user_id" db:"user_id"`
+	PortfolioID     int        `json:"portfolio_id" db:"portfolio_id"`
+	Symbol          string     `json:"symbol" db:"symbol"`
+	AssetType       string     `json:"asset_type" db:"asset_type"` // "equity", "crypto" or "option"
+	Quantity        float64    `json:"quantity" db:"quantity"`     // Whole shares for equities, fractional for crypto, contracts for options
+	Multiplier      float64    `json:"multiplier" db:"multiplier"` // Shares of the underlying per option contract; 1 for other assets
+	Side            string     `json:"side" db:"side"`             // "long" or "short"
+	EntryPrice      float64    `json:"entry_price" db:"entry_price"`
+	CurrentPrice    float64    `json:"current_price" db:"current_price"`
+	UnrealizedPnL   float64    `json:"unrealized_pnl" db:"unrealized_pnl"`
+	RealizedPnL     float64    `json:"realized_pnl" db:"realized_pnl"`
+	StopLossPercent *float64   `json:"stop_loss_percent,omitempty" db:"stop_loss_percent"` // Below entry price
+	StopLossPrice   *float64   `json:"stop_loss_price,omitempty" db:"stop_loss_price"`
+	PreviousClose   *float64   `json:"previous_close,omitempty" db:"previous_close"` // Official close of the last session; day P&L is measured from it
+	DayOpenPrice    *float64   `json:"day_open_price,omitempty" db:"day_open_price"`
+	AnchoredAt      *time.Time `json:"anchored_at,omitempty" db:"anchored_at"` // When the day anchors were last rolled
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// StopLossTrigger returns the price at or below which the position's
+// stop-loss is hit, and false when it has none. A percentage stop follows
+// the entry price as the position is added to.
+func (p *Position) StopLossTrigger() (float64, bool) {
+	switch {
+	case p.StopLossPrice != nil:
+		return *p.StopLossPrice, true
+	case p.StopLossPercent != nil:
+		return p.EntryPrice * (1 - *p.StopLossPercent/100), true
+	default:
+		return 0, false
+	}
+}
+
+// Value is the market value of the position at price. Prices of options
+// are per share of the underlying, so are scaled by the multiplier; an unset
+// multiplier counts as 1.
+func (p *Position) Value(price float64) float64 {
+	return p.Quantity * price * multiplier(p.Multiplier)
 }
 
 // Portfolio represents a user's portfolio
 type Portfolio struct {
-	ID               int        `json:"id" db:"id"`
-	UserID           int        `json:"user_id" db:"user_id"`
-	Name             string     `json:"name" db:"name"`
-	Cash             float64    `json:"cash" db:"cash"`
-	MarginUsed       float64    `json:"margin_used" db:"margin_used"`
-	MarginAvailable  float64    `json:"margin_available" db:"margin_available"`
-	TotalValue       float64    `json:"total_value" db:"total_value"`
-	UnrealizedPnL    float64    `json:"unrealized_pnl" db:"unrealized_pnl"`
-	RealizedPnL      float64    `json:"realized_pnl" db:"realized_pnl"`
-	DayPnL           float64    `json:"day_pnl" db:"day_pnl"`
-	Positions        []Position `json:"positions"`
-	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+	ID              int          `json:"id" db:"id"`
+	UserID          int          `json:"user_id" db:"user_id"`
+	Name            string       `json:"name" db:"name"`
+	Description     string       `json:"description" db:"description"`
+	StrategyTags    []string     `json:"strategy_tags" db:"strategy_tags"`
+	Cash            float64      `json:"cash" db:"cash"`
+	MarginUsed      float64      `json:"margin_used" db:"margin_used"`
+	MarginAvailable float64      `json:"margin_available" db:"margin_available"`
+	TotalValue      float64      `json:"total_value" db:"total_value"`
+	UnrealizedPnL   float64      `json:"unrealized_pnl" db:"unrealized_pnl"`
+	RealizedPnL     float64      `json:"realized_pnl" db:"realized_pnl"`
+	DayPnL          float64      `json:"day_pnl" db:"day_pnl"`
+	Positions       []Position   `json:"positions"`
+	Fees            *FeeSchedule `json:"-"` // From the portfolio's settings; nil charges the default schedule
+	CryptoFees      *FeeSchedule `json:"-"` // Charged on crypto trades instead of Fees
+	MaxPosition     float64      `json:"-"` // Percent of the portfolio one position may reach, from its risk tolerance; zero is uncapped
+	Version         int          `json:"version" db:"version"` // Advanced by every update, for optimistic concurrency
+	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at" db:"updated_at"`
 }
 
 // Trade represents a trade transaction
 type Trade struct {
-	ID          int       `json:"id" db:"id"`
-	UserID      int       `json:"user_id" db:"user_id"`
-	PortfolioID int       `json:"portfolio_id" db:"portfolio_id"`
-	PositionID  int       `json:"position_id" db:"position_id"`
-	Symbol      string    `json:"symbol" db:"symbol"`
-	Quantity    int64     `json:"quantity" db:"quantity"`
-	Price       float64   `json:"price" db:"price"`
-	Side        string    `json:"side" db:"side"` // "buy" or "sell"
-	Type        string    `json:"type" db:"type"` // "market", "limit", etc.
-	Status      string    `json:"status" db:"status"` // "pending", "filled", "cancelled"
-	Fees        float64   `json:"fees" db:"fees"`
-	ExecutedAt  *time.Time `json:"executed_at" db:"executed_at"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	ID            int        `json:"id" db:"id"`
+	UserID        int        `json:"user_id" db:"user_id"`
+	PortfolioID   int        `json:"portfolio_id" db:"portfolio_id"`
+	PositionID    int        `json:"position_id" db:"position_id"`
+	Symbol        string     `json:"symbol" db:"symbol"`
+	AssetType     string     `json:"asset_type" db:"asset_type"` // "equity", "crypto" or "option"; inferred from the symbol when empty
+	Quantity      float64    `json:"quantity" db:"quantity"`
+	Multiplier    float64    `json:"multiplier" db:"multiplier"` // Shares of the underlying per option contract; 1 for other assets
+	Price         float64    `json:"price" db:"price"`
+	Side          string     `json:"side" db:"side"`     // "buy" or "sell"
+	Type          string     `json:"type" db:"type"`     // "market", "limit", etc.
+	Status        string     `json:"status" db:"status"` // "pending", "filled", "cancelled"
+	TimeInForce   string     `json:"time_in_force" db:"time_in_force"` // How long the order stays open; "day" when empty
+	Fees          float64    `json:"fees" db:"fees"`
+	FeeItems      []FeeItem  `json:"fee_items,omitempty" db:"fee_items"`             // Lines of the fees; nil for trades recorded before they were itemized
+	Broker        string     `json:"broker,omitempty" db:"broker"`                   // Execution venue of a live order
+	BrokerOrderID string     `json:"broker_order_id,omitempty" db:"broker_order_id"` // Venue's order ID of a live order
+	TriggerReason string     `json:"trigger_reason,omitempty" db:"trigger_reason"`   // Why an automated trade was placed, e.g. "stop_loss"
+	ExecutedAt    *time.Time `json:"executed_at" db:"executed_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Value is the value of the trade's quantity at price, scaled by its
+// multiplier like Position.Value
+func (t *Trade) Value(price float64) float64 {
+	return t.Quantity * price * multiplier(t.Multiplier)
+}
+
+func multiplier(m float64) float64 {
+	if m == 0 {
+		return 1
+	}
+	return m
+}
+
+// Time in force, how long an order stays open before it is cancelled
+const (
+	TimeInForceDay = "day" // Until the market closes
+	TimeInForceGTC = "gtc" // Until filled or cancelled
+	TimeInForceIOC = "ioc" // Filled at once as far as possible, the rest cancelled
+	TimeInForceFOK = "fok" // Filled at once in full, or cancelled
+)
+
+// Asset types. Equities trade in whole shares; crypto in fractional
+// quantities, around the clock; options in whole contracts.
+const (
+	AssetTypeEquity = "equity"
+	AssetTypeCrypto = "crypto"
+	AssetTypeOption = "option"
+)
+
+// TradeTriggerStopLoss is the trigger reason of trades that close a position
+// whose stop-loss was hit
+const TradeTriggerStopLoss = "stop_loss"
+
+// TradeTriggerImport is the trigger reason of trades imported from an
+// external brokerage's history
+const TradeTriggerImport = "import"
+
+// Trigger reasons of the trades that settle option positions at expiry
+const (
+	TradeTriggerOptionExercise   = "option_exercise"   // Long contract in the money
+	TradeTriggerOptionAssignment = "option_assignment" // Short contract in the money
+	TradeTriggerOptionExpiry     = "option_expiry"     // Out of the money, expired worthless
+)
+
+// DayAnchor is a symbol's prices day P&L is measured from, rolled onto the
+// positions in it at market open
+type DayAnchor struct {
+	Symbol        string
+	PreviousClose float64 // Official close of the last session
+	DayOpen       float64 // First price of the session
 }
 
 // PortfolioSummary provides a high-level view of portfolio performance
@@ -69,13 +162,50 @@ type PortfolioSummary struct {
 
 // PositionSummary provides aggregated position information
 type PositionSummary struct {
-	Symbol           string  `json:"symbol"`
-	NetQuantity      int64   `json:"net_quantity"`
-	LongQuantity     int64   `json:"long_quantity"`
-	ShortQuantity    int64   `json:"short_quantity"`
-	AveragePrice     float64 `json:"average_price"`
-	CurrentPrice     float64 `json:"current_price"`
-	MarketValue      float64 `json:"market_value"`
-	UnrealizedPnL    float64 `json:"unrealized_pnl"`
-	UnrealizedReturn float64 `json:"unrealized_return"`
+	Symbol           string        `json:"symbol"`
+	NetQuantity      float64       `json:"net_quantity"`
+	LongQuantity     float64       `json:"long_quantity"`
+	ShortQuantity    float64       `json:"short_quantity"`
+	AveragePrice     float64       `json:"average_price"`
+	CurrentPrice     float64       `json:"current_price"`
+	MarketValue      float64       `json:"market_value"`
+	UnrealizedPnL    float64       `json:"unrealized_pnl"`
+	UnrealizedReturn float64       `json:"unrealized_return"`
+	EntryDate        time.Time     `json:"entry_date"`           // Quantity-weighted average acquisition date of open lots
+	DaysHeld         int           `json:"days_held"`            // Days since the oldest open lot was acquired
+	AvgHoldingDays   float64       `json:"average_holding_days"` // Quantity-weighted average age of open lots
+	Lots             []PositionLot `json:"lots,omitempty"`
+}
+
+// PositionLot is the quantity acquired by a single buy. Sells reduce
+// RemainingQuantity of open lots in the portfolio's cost basis order.
+type PositionLot struct {
+	ID                int        `json:"id" db:"id"`
+	PortfolioID       int        `json:"portfolio_id" db:"portfolio_id"`
+	Symbol            string     `json:"symbol" db:"symbol"`
+	TradeID           int        `json:"trade_id" db:"trade_id"`
+	Quantity          float64    `json:"quantity" db:"quantity"`
+	RemainingQuantity float64    `json:"remaining_quantity" db:"remaining_quantity"`
+	Price             float64    `json:"price" db:"price"`
+	AcquiredAt        time.Time  `json:"acquired_at" db:"acquired_at"`
+	ClosedAt          *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+}
+
+// LotBreakdown shows how a position's average entry price was formed from the
+// lots of the buys averaged into it
+type LotBreakdown struct {
+	Symbol            string       `json:"symbol"`
+	Quantity          float64      `json:"quantity"`
+	AveragePrice      float64      `json:"average_price"`
+	LotQuantity       float64      `json:"lot_quantity"`       // Remaining in open lots
+	LotAveragePrice   float64      `json:"lot_average_price"`  // Of the quantity remaining in open lots
+	UntrackedQuantity float64      `json:"untracked_quantity"` // Held without lots, e.g. bought before lot tracking
+	CostBasisMethod   string       `json:"cost_basis_method"`
+	Lots              []LotHistory `json:"lots"`
+}
+
+// LotHistory is a lot that contributed to a position, open or since closed
+type LotHistory struct {
+	PositionLot
+	Weight float64 `json:"weight"` // Percent of the open lots' cost; 0 once closed
 }
\ No newline at end of file
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// PortfolioImport is an upload of positions or trade history exported by an
+// external brokerage. It is previewed, remapped as needed, then applied to
+// the portfolio by a job.
+type PortfolioImport struct {
+	ID            int               `json:"id" db:"id"`
+	PortfolioID   int               `json:"portfolio_id" db:"portfolio_id"`
+	Kind          string            `json:"kind" db:"kind"`     // "trades" or "positions"
+	Format        string            `json:"format" db:"format"` // "csv"
+	Filename      string            `json:"filename,omitempty" db:"filename"`
+	Columns       []string          `json:"columns" db:"columns"`         // Header of the file
+	Mapping       map[string]string `json:"mapping" db:"mapping"`         // Column of each import field
+	AdjustCash    bool              `json:"adjust_cash" db:"adjust_cash"` // Whether imported trades move cash; positions never do
+	Content       string            `json:"-" db:"content"`
+	Status        string            `json:"status" db:"status"`
+	JobID         string            `json:"job_id,omitempty" db:"job_id"`
+	TotalRows     int               `json:"total_rows" db:"total_rows"`
+	ProcessedRows int               `json:"processed_rows" db:"processed_rows"`
+	ImportedRows  int               `json:"imported_rows" db:"imported_rows"`
+	DuplicateRows int               `json:"duplicate_rows" db:"duplicate_rows"`
+	FailedRows    int               `json:"failed_rows" db:"failed_rows"`
+	Errors        []ImportError     `json:"errors" db:"errors"`         // Of failed rows, the first MaxImportErrors
+	Error         string            `json:"error,omitempty" db:"error"` // Why the import as a whole failed
+	CreatedBy     string            `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+	StartedAt     *time.Time        `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt   *time.Time        `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// ImportError is why a row of an import was not imported
+type ImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// MaxImportErrors is how many row errors an import keeps
+const MaxImportErrors = 100
+
+// Import kinds
+const (
+	ImportKindTrades    = "trades"
+	ImportKindPositions = "positions"
+)
+
+// Import file formats
+const (
+	ImportFormatCSV = "csv"
+	ImportFormatOFX = "ofx"
+)
+
+// Import statuses
+const (
+	ImportPreviewed = "previewed" // Awaiting confirmation of its mapping
+	ImportQueued    = "queued"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
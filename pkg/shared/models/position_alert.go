@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Position alert directions
+const (
+	PositionAlertUp   = "up"
+	PositionAlertDown = "down"
+)
+
+// PositionAlert notifies a position's owner once its symbol's price moves
+// UpPercent above or DownPercent below the position's entry price. It
+// fires once, then stays inactive until it is updated.
+type PositionAlert struct {
+	ID             int        `json:"id" db:"id"`
+	PortfolioID    int        `json:"portfolio_id" db:"portfolio_id"`
+	PositionID     int        `json:"position_id" db:"position_id"`
+	UserID         int        `json:"user_id" db:"user_id"`
+	Symbol         string     `json:"symbol" db:"symbol"`
+	UpPercent      *float64   `json:"up_percent,omitempty" db:"up_percent"`
+	DownPercent    *float64   `json:"down_percent,omitempty" db:"down_percent"`
+	Note           string     `json:"note" db:"note"`
+	IsActive       bool       `json:"is_active" db:"is_active"`
+	TriggeredAt    *time.Time `json:"triggered_at,omitempty" db:"triggered_at"`
+	TriggeredPrice *float64   `json:"triggered_price,omitempty" db:"triggered_price"`
+	EntryPrice     float64    `json:"entry_price" db:"-"` // The position's, so levels follow it as the position is added to
+	Quantity       float64    `json:"quantity" db:"-"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Levels returns the prices at or above which, and at or below which, the
+// alert fires, zero for a direction it doesn't watch
+func (a *PositionAlert) Levels() (up, down float64) {
+	if a.UpPercent != nil {
+		up = a.EntryPrice * (1 + *a.UpPercent/100)
+	}
+	if a.DownPercent != nil {
+		down = a.EntryPrice * (1 - *a.DownPercent/100)
+	}
+	return up, down
+}
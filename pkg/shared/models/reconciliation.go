@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// BrokerAccount links a portfolio to an account held at an external broker
+type BrokerAccount struct {
+	PortfolioID int       `json:"portfolio_id" db:"portfolio_id"`
+	Broker      string    `json:"broker" db:"broker"`
+	AccountID   string    `json:"account_id" db:"account_id"`
+	TradingMode string    `json:"trading_mode" db:"trading_mode"` // "paper" fills in-house, "live" routes orders to the broker
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Trading modes
+const (
+	TradingModePaper = "paper"
+	TradingModeLive  = "live"
+)
+
+// BrokerPosition is a holding as reported on a broker statement. Short
+// positions have a negative quantity.
+type BrokerPosition struct {
+	Symbol       string  `json:"symbol"`
+	Quantity     float64 `json:"quantity"`
+	AveragePrice float64 `json:"average_price"`
+}
+
+// BrokerTransaction is an execution as reported on a broker statement
+type BrokerTransaction struct {
+	ID            string    `json:"id"`
+	ClientOrderID string    `json:"client_order_id"` // Our trade ID when the order originated here
+	Symbol        string    `json:"symbol"`
+	Side          string    `json:"side"` // "buy" or "sell"
+	Quantity      float64   `json:"quantity"`
+	Price         float64   `json:"price"`
+	Fees          float64   `json:"fees"`
+	ExecutedAt    time.Time `json:"executed_at"`
+}
+
+// ReconciliationRun is one comparison of a portfolio against a broker statement
+type ReconciliationRun struct {
+	ID               int                   `json:"id" db:"id"`
+	PortfolioID      int                   `json:"portfolio_id" db:"portfolio_id"`
+	StatementDate    time.Time             `json:"statement_date" db:"statement_date"`
+	Status           string                `json:"status" db:"status"` // "running", "completed", "failed"
+	PositionsChecked int                   `json:"positions_checked" db:"positions_checked"`
+	TradesChecked    int                   `json:"trades_checked" db:"trades_checked"`
+	AutoFixed        int                   `json:"auto_fixed" db:"auto_fixed"`
+	OpenBreaks       int                   `json:"open_breaks" db:"open_breaks"`
+	Error            string                `json:"error,omitempty" db:"error"`
+	StartedAt        time.Time             `json:"started_at" db:"started_at"`
+	CompletedAt      *time.Time            `json:"completed_at,omitempty" db:"completed_at"`
+	Breaks           []ReconciliationBreak `json:"breaks,omitempty"`
+}
+
+// ReconciliationBreak is a difference between internal records and the broker
+type ReconciliationBreak struct {
+	ID            int64     `json:"id" db:"id"`
+	RunID         int       `json:"run_id" db:"run_id"`
+	PortfolioID   int       `json:"portfolio_id" db:"portfolio_id"`
+	BreakType     string    `json:"break_type" db:"break_type"`
+	Symbol        string    `json:"symbol" db:"symbol"`
+	TradeID       int       `json:"trade_id,omitempty" db:"trade_id"`
+	BrokerRef     string    `json:"broker_ref,omitempty" db:"broker_ref"`
+	InternalValue float64   `json:"internal_value" db:"internal_value"`
+	BrokerValue   float64   `json:"broker_value" db:"broker_value"`
+	Resolution    string    `json:"resolution" db:"resolution"` // "auto_fixed" or "open"
+	Message       string    `json:"message" db:"message"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// Reconciliation break types
+const (
+	BreakFeeMismatch          = "fee_mismatch"
+	BreakPriceRounding        = "price_rounding"
+	BreakPriceMismatch        = "price_mismatch"
+	BreakQuantityMismatch     = "quantity_mismatch"
+	BreakMissingInternalTrade = "missing_internal_trade"
+	BreakMissingBrokerTrade   = "missing_broker_trade"
+	BreakPositionMismatch     = "position_mismatch"
+)
+
+// Reconciliation break resolutions
+const (
+	ResolutionAutoFixed = "auto_fixed"
+	ResolutionOpen      = "open"
+)
+
+// Reconciliation run statuses
+const (
+	ReconciliationRunning   = "running"
+	ReconciliationCompleted = "completed"
+	ReconciliationFailed    = "failed"
+)
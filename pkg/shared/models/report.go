@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Report is a generated report file and where it is stored
+type Report struct {
+	ID          string     `json:"id" db:"id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	PortfolioID int        `json:"portfolio_id" db:"portfolio_id"`
+	ReportType  string     `json:"report_type" db:"report_type"`           // "performance", "positions", "trades", "custom"
+	TemplateID  *int       `json:"template_id,omitempty" db:"template_id"` // Layout of a "custom" report
+	Format      string     `json:"format" db:"format"`                     // "pdf", "html", "csv", "json"
+	StartDate   time.Time  `json:"start_date" db:"start_date"`
+	EndDate     time.Time  `json:"end_date" db:"end_date"` // Exclusive
+	Status      string     `json:"status" db:"status"`     // "pending", "completed", "failed"
+	JobID       string     `json:"job_id,omitempty" db:"job_id"`
+	StorageKey  string     `json:"-" db:"storage_key"`
+	ContentType string     `json:"content_type,omitempty" db:"content_type"`
+	SizeBytes   int64      `json:"size_bytes" db:"size_bytes"`
+	Error       string     `json:"error,omitempty" db:"error"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// Report statuses
+const (
+	ReportPending   = "pending"
+	ReportCompleted = "completed"
+	ReportFailed    = "failed"
+)
+
+// ReportTemplate is a user-defined report layout. A template with a Schedule
+// is generated for its PortfolioID each period.
+type ReportTemplate struct {
+	ID          int               `json:"id" db:"id"`
+	UserID      int               `json:"user_id" db:"user_id"`
+	Name        string            `json:"name" db:"name"`
+	Sections    []TemplateSection `json:"sections" db:"sections"`
+	Format      string            `json:"format" db:"format"`
+	Schedule    string            `json:"schedule,omitempty" db:"schedule"` // "daily", "weekly", "monthly" or empty
+	PortfolioID *int              `json:"portfolio_id,omitempty" db:"portfolio_id"`
+	LastRunAt   *time.Time        `json:"last_run_at,omitempty" db:"last_run_at"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// TemplateSection is one section of a report template
+type TemplateSection struct {
+	Type    string   `json:"type"`              // "summary", "allocation", "top_trades", "risk", "metrics"
+	Title   string   `json:"title,omitempty"`   // Defaults to the section type's title
+	Limit   int      `json:"limit,omitempty"`   // Rows of a top_trades section
+	Metrics []string `json:"metrics,omitempty"` // Metrics of a metrics section
+}
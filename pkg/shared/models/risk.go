@@ -19,18 +19,29 @@ type RiskMetrics struct {
 
 // PortfolioRisk represents portfolio-level risk metrics
 type PortfolioRisk struct {
-	UserID               int                     `json:"user_id"`
-	TotalVaR95           float64                 `json:"total_var_95"`
-	TotalVaR99           float64                 `json:"total_var_99"`
-	PortfolioVolatility  float64                 `json:"portfolio_volatility"`
-	PortfolioBeta        float64                 `json:"portfolio_beta"`
-	PortfolioSharpe      float64                 `json:"portfolio_sharpe"`
-	ConcentrationRisk    float64                 `json:"concentration_risk"`    // Largest position as % of portfolio
-	LeverageRatio        float64                 `json:"leverage_ratio"`        // Total exposure / equity
-	MarginUtilization    float64                 `json:"margin_utilization"`    // Used margin / available margin
-	PositionRisks        map[string]RiskMetrics  `json:"position_risks"`
-	CorrelationMatrix    [][]float64             `json:"correlation_matrix"`
-	CalculatedAt         time.Time               `json:"calculated_at"`
+	PortfolioID         int                    `json:"portfolio_id"`
+	UserID              int                    `json:"user_id"`
+	TotalVaR95          float64                `json:"total_var_95"`
+	TotalVaR99          float64                `json:"total_var_99"`
+	PortfolioVolatility float64                `json:"portfolio_volatility"`
+	PortfolioBeta       float64                `json:"portfolio_beta"`
+	PortfolioSharpe     float64                `json:"portfolio_sharpe"`
+	ConcentrationRisk   float64                `json:"concentration_risk"` // Largest position as % of portfolio
+	LeverageRatio       float64                `json:"leverage_ratio"`     // Total exposure / equity
+	MarginUtilization   float64                `json:"margin_utilization"` // Used margin / available margin
+	PositionRisks       map[string]RiskMetrics `json:"position_risks"`
+	Symbols             []string               `json:"symbols"` // Order of the rows and columns of CorrelationMatrix
+	CorrelationMatrix   [][]float64            `json:"correlation_matrix"`
+	CorrelationWarnings []CorrelationWarning   `json:"correlation_warnings"` // Highly correlated pairs of holdings
+	CalculatedAt        time.Time              `json:"calculated_at"`
+}
+
+// CorrelationWarning flags a pair of holdings whose daily returns are
+// highly correlated, so they diversify less than their count suggests
+type CorrelationWarning struct {
+	SymbolA     string  `json:"symbol_a"`
+	SymbolB     string  `json:"symbol_b"`
+	Correlation float64 `json:"correlation"`
 }
 
 // RiskLimit represents risk limits for trading
@@ -73,4 +84,132 @@ type VolatilityData struct {
 	MonthlyVolatility float64  `json:"monthly_volatility"`
 	AnnualizedVolatility float64 `json:"annualized_volatility"`
 	CalculatedAt     time.Time `json:"calculated_at"`
-}
\ No newline at end of file
+}
+
+// VaRBacktest validates a portfolio's historical-simulation VaR model by
+// comparing each day's forecast with the realized PnL. Each forecast uses
+// only the LookbackDays returns before it. Exceptions are days whose loss
+// exceeded the forecast.
+type VaRBacktest struct {
+	ID                        int64       `json:"id" db:"id"`
+	PortfolioID               int         `json:"portfolio_id" db:"portfolio_id"`
+	Confidence                float64     `json:"confidence" db:"confidence"` // e.g. 0.99
+	LookbackDays              int         `json:"lookback_days" db:"lookback_days"`
+	StartDate                 time.Time   `json:"start_date" db:"start_date"`
+	EndDate                   time.Time   `json:"end_date" db:"end_date"`
+	Observations              int         `json:"observations" db:"observations"`
+	Exceptions                int         `json:"exceptions" db:"exceptions"`
+	ExpectedExceptions        float64     `json:"expected_exceptions" db:"expected_exceptions"`
+	KupiecLR                  float64     `json:"kupiec_lr" db:"kupiec_lr"` // Proportion of failures
+	KupiecPValue              float64     `json:"kupiec_p_value" db:"kupiec_p_value"`
+	IndependenceLR            float64     `json:"independence_lr" db:"independence_lr"` // Christoffersen
+	IndependencePValue        float64     `json:"independence_p_value" db:"independence_p_value"`
+	ConditionalCoverageLR     float64     `json:"conditional_coverage_lr" db:"conditional_coverage_lr"`
+	ConditionalCoveragePValue float64     `json:"conditional_coverage_p_value" db:"conditional_coverage_p_value"`
+	Adequate                  bool        `json:"adequate" db:"adequate"` // Neither test rejects the model
+	ExceptionDates            []time.Time `json:"exception_dates" db:"exception_dates"`
+	CreatedAt                 time.Time   `json:"created_at" db:"created_at"`
+}
+
+// PortfolioPerformance is a portfolio's risk-adjusted return over a period,
+// from its daily snapshots. Returns, volatility and drawdown are percentages;
+// RiskFreeRate is the annual rate excess returns are taken over.
+type PortfolioPerformance struct {
+	PortfolioID      int       `json:"portfolio_id"`
+	StartDate        time.Time `json:"start_date"`
+	EndDate          time.Time `json:"end_date"`
+	Observations     int       `json:"observations"` // Daily returns
+	TotalReturn      float64   `json:"total_return"`
+	AnnualizedReturn float64   `json:"annualized_return"`
+	Volatility       float64   `json:"volatility"` // Annualized
+	RiskFreeRate     float64   `json:"risk_free_rate"`
+	SharpeRatio      float64   `json:"sharpe_ratio"`
+	SortinoRatio     float64   `json:"sortino_ratio"`
+	MaxDrawdown      float64   `json:"max_drawdown"`
+}
+
+// Risk alert types, one per limit the intraday risk monitor checks
+const (
+	RiskAlertPositionLimit = "position_limit"
+	RiskAlertDailyLoss     = "daily_loss"
+	RiskAlertVaRBreach     = "var_breach"
+	RiskAlertLeverage      = "leverage_limit"
+	RiskAlertConcentration = "concentration_limit"
+)
+
+// Risk alert severities
+const (
+	RiskSeverityWarning  = "warning"
+	RiskSeverityCritical = "critical"
+)
+
+// RiskExposure is a portfolio's intraday exposure, VaR approximation and
+// limit utilization, as kept up to date by the risk monitor from price
+// updates
+type RiskExposure struct {
+	PortfolioID   int                `json:"portfolio_id"`
+	UserID        int                `json:"user_id"`
+	Equity        float64            `json:"equity"`
+	LongExposure  float64            `json:"long_exposure"`
+	ShortExposure float64            `json:"short_exposure"`
+	GrossExposure float64            `json:"gross_exposure"`
+	NetExposure   float64            `json:"net_exposure"`
+	DailyPnL      float64            `json:"daily_pnl"` // Since the first update of the UTC day
+	VaR           float64            `json:"var"`       // One-day, undiversified
+	Confidence    float64            `json:"confidence"`
+	Concentration float64            `json:"concentration"` // Largest position / equity
+	Limits        []LimitUtilization `json:"limits"`
+	Change        *ExposureChange    `json:"change,omitempty"` // Since the previous evaluation
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+// ExposureChange is how much a portfolio's exposure moved between two
+// evaluations by the risk monitor
+type ExposureChange struct {
+	Equity        float64   `json:"equity"`
+	GrossExposure float64   `json:"gross_exposure"`
+	NetExposure   float64   `json:"net_exposure"`
+	VaR           float64   `json:"var"`
+	Concentration float64   `json:"concentration"`
+	Since         time.Time `json:"since"`
+}
+
+// LimitUtilization is how much of a risk limit a portfolio uses. Level is
+// empty below the warning level.
+type LimitUtilization struct {
+	Limit       string  `json:"limit"` // Risk alert type
+	Symbol      string  `json:"symbol,omitempty"`
+	Value       float64 `json:"value"`
+	Threshold   float64 `json:"threshold"`
+	Utilization float64 `json:"utilization"` // Value / Threshold
+	Level       string  `json:"level,omitempty"`
+}
+
+// ScenarioResult is a portfolio's projected PnL under a stress scenario
+type ScenarioResult struct {
+	Scenario        string                   `json:"scenario"`
+	Description     string                   `json:"description"`
+	Equity          float64                  `json:"equity"`
+	ProjectedPnL    float64                  `json:"projected_pnl"`
+	ProjectedReturn float64                  `json:"projected_return"` // ProjectedPnL / Equity
+	Positions       []ScenarioPositionImpact `json:"positions"`        // Largest losses first
+}
+
+// ScenarioPositionImpact is one position's projected PnL under a stress
+// scenario. Short positions have a negative market value.
+type ScenarioPositionImpact struct {
+	Symbol       string  `json:"symbol"`
+	Sector       string  `json:"sector,omitempty"`
+	Side         string  `json:"side"` // "long" or "short"
+	MarketValue  float64 `json:"market_value"`
+	ShockPercent float64 `json:"shock_percent"`
+	ProjectedPnL float64 `json:"projected_pnl"`
+}
+
+// ScenarioReport is a portfolio's projected PnL under each of a set of
+// stress scenarios
+type ScenarioReport struct {
+	PortfolioID int              `json:"portfolio_id"`
+	Scenarios   []ScenarioResult `json:"scenarios"`
+	GeneratedAt time.Time        `json:"generated_at"`
+}
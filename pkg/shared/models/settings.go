@@ -0,0 +1,153 @@
+package models
+
+import "time"
+
+// Strategies a portfolio can be traded with
+const (
+	StrategyManual     = "manual"     // Only trades placed through the API
+	StrategyMomentum   = "momentum"   // Signals from the momentum strategy
+	StrategyAllocation = "allocation" // Rebalanced towards its allocation models
+)
+
+// Cost basis methods, the order a sale consumes a position's lots in
+const (
+	CostBasisFIFO = "fifo" // Oldest lot first
+	CostBasisLIFO = "lifo" // Newest lot first
+	CostBasisHIFO = "hifo" // Highest-priced lot first
+)
+
+// Risk tolerances, each capping a position's share of the portfolio
+const (
+	RiskConservative = "conservative"
+	RiskModerate     = "moderate"
+	RiskAggressive   = "aggressive" // Uncapped
+)
+
+// Levels a portfolio setting is resolved from, most specific first
+const (
+	SettingsLevelPortfolio = "portfolio"
+	SettingsLevelUser      = "user"
+	SettingsLevelDefault   = "default"
+)
+
+// SettingsOverrides are the portfolio options set at one level. Nil fields
+// are not set at that level and are inherited from the next one.
+type SettingsOverrides struct {
+	CommissionRate    *float64        `json:"commission_rate,omitempty"` // Fraction of trade value
+	MinCommission     *float64        `json:"min_commission,omitempty"`
+	Benchmark         *string         `json:"benchmark,omitempty"`
+	BaseCurrency      *string         `json:"base_currency,omitempty"`
+	DRIPEnabled       *bool           `json:"drip_enabled,omitempty"`
+	AutoTradeEnabled  *bool           `json:"auto_trade_enabled,omitempty"`
+	Strategy          *string         `json:"strategy,omitempty"`
+	FeeSchedule       *FeeSchedule    `json:"fee_schedule,omitempty"`        // Replaces commission_rate and min_commission set at the same or a less specific level
+	CryptoFeeSchedule *FeeSchedule    `json:"crypto_fee_schedule,omitempty"` // Charged on crypto trades instead of the fee options above
+	CostBasisMethod   *string         `json:"cost_basis_method,omitempty"`
+	DefaultOrderType  *string         `json:"default_order_type,omitempty"` // Of trades placed without an order type
+	RiskTolerance     *string         `json:"risk_tolerance,omitempty"`
+	AutoRebalance     *bool           `json:"auto_rebalance,omitempty"` // Rebalance when an allocation model drifts past its threshold
+	Notifications     map[string]bool `json:"notifications,omitempty"`  // Whether each notification type is sent; types left out are inherited
+	UpdatedAt         *time.Time      `json:"updated_at,omitempty"`
+}
+
+// PortfolioSettings are a portfolio's effective options after inheritance
+type PortfolioSettings struct {
+	PortfolioID       int               `json:"portfolio_id"`
+	CommissionRate    float64           `json:"commission_rate"`
+	MinCommission     float64           `json:"min_commission"`
+	Benchmark         string            `json:"benchmark"`
+	BaseCurrency      string            `json:"base_currency"`
+	DRIPEnabled       bool              `json:"drip_enabled"`
+	AutoTradeEnabled  bool              `json:"auto_trade_enabled"`
+	Strategy          string            `json:"strategy"`
+	FeeSchedule       *FeeSchedule      `json:"fee_schedule,omitempty"`
+	CryptoFeeSchedule FeeSchedule       `json:"crypto_fee_schedule"`
+	CostBasisMethod   string            `json:"cost_basis_method"`
+	DefaultOrderType  string            `json:"default_order_type"`
+	RiskTolerance     string            `json:"risk_tolerance"`
+	AutoRebalance     bool              `json:"auto_rebalance"`
+	Notifications     map[string]bool   `json:"notifications"`
+	Sources           map[string]string `json:"sources"` // Level each option was resolved from, by option name
+}
+
+// settingsLevelRank orders the settings levels, most specific highest
+var settingsLevelRank = map[string]int{SettingsLevelDefault: 0, SettingsLevelUser: 1, SettingsLevelPortfolio: 2}
+
+// Fees returns the settings' fee schedule: the fee_schedule option when it
+// is set at least as specifically as the commission options, otherwise a
+// percentage commission
+func (s *PortfolioSettings) Fees() FeeSchedule {
+	if s.FeeSchedule != nil {
+		level := settingsLevelRank[s.Sources["fee_schedule"]]
+		if level >= settingsLevelRank[s.Sources["commission_rate"]] && level >= settingsLevelRank[s.Sources["min_commission"]] {
+			return *s.FeeSchedule
+		}
+	}
+	return FeeSchedule{Rate: s.CommissionRate, Minimum: s.MinCommission}
+}
+
+// Notifies reports whether the owner is sent notifications of a type. Types
+// without a preference are sent.
+func (s *PortfolioSettings) Notifies(notificationType string) bool {
+	on, ok := s.Notifications[notificationType]
+	return on || !ok
+}
+
+// SetsFees reports whether a portfolio or its owner sets any fee option,
+// rather than all of them taking their defaults
+func (s *PortfolioSettings) SetsFees() bool {
+	for _, name := range []string{"commission_rate", "min_commission", "fee_schedule"} {
+		if level, ok := s.Sources[name]; ok && level != SettingsLevelDefault {
+			return true
+		}
+	}
+	return false
+}
+
+// Fee models, how a fee schedule's commission is computed
+const (
+	FeeModelPercentage = "percentage" // Rate of trade value
+	FeeModelFlat       = "flat"       // Flat amount per trade
+	FeeModelPerShare   = "per_share"  // PerShare for every share traded
+	FeeModelTiered     = "tiered"     // Each slice of trade value at its tier's rate
+)
+
+// FeeSchedule is the fees charged on a trade: a commission computed by its
+// model, kept between Minimum and Maximum, and exchange or regulatory levies
+// on top
+type FeeSchedule struct {
+	Model    string    `json:"model,omitempty"` // Empty is percentage
+	Rate     float64   `json:"rate"`            // Fraction of trade value, for percentage
+	Minimum  float64   `json:"minimum"`
+	Maximum  float64   `json:"maximum,omitempty"` // Zero is uncapped
+	Flat     float64   `json:"flat,omitempty"`
+	PerShare float64   `json:"per_share,omitempty"`
+	Tiers    []FeeTier `json:"tiers,omitempty"`
+	Levies   []FeeLevy `json:"levies,omitempty"`
+}
+
+// FeeTier charges Rate on the slice of trade value up to UpTo, above the
+// previous tier's. The last tier has no UpTo and covers the rest.
+type FeeTier struct {
+	UpTo float64 `json:"up_to,omitempty"`
+	Rate float64 `json:"rate"`
+}
+
+// FeeLevy is an exchange or regulatory fee: Rate of trade value plus
+// PerShare, capped at Maximum when set, charged on Side only when set
+type FeeLevy struct {
+	Name     string  `json:"name"`
+	Rate     float64 `json:"rate,omitempty"`
+	PerShare float64 `json:"per_share,omitempty"`
+	Maximum  float64 `json:"maximum,omitempty"`
+	Side     string  `json:"side,omitempty"` // "buy" or "sell"; empty charges both
+}
+
+// FeeItem is a line of the fees charged on a trade
+type FeeItem struct {
+	Name   string  `json:"name"` // "commission" or a levy's name
+	Amount float64 `json:"amount"`
+}
+
+// FeeCommission names the commission line of a trade's fees
+const FeeCommission = "commission"
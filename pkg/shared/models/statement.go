@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// AccountStatement summarizes a portfolio's month, from the last snapshot
+// before it (or the first in it, for portfolios opened that month) to the
+// last in it. Activity is counted between the two snapshots, so
+// StartingValue + Contributions - Withdrawals + RealizedPnL - Fees +
+// UnrealizedPnL = EndingValue.
+type AccountStatement struct {
+	ID            int64     `json:"id" db:"id"`
+	PortfolioID   int       `json:"portfolio_id" db:"portfolio_id"`
+	UserID        int       `json:"user_id" db:"user_id"`
+	Month         time.Time `json:"month" db:"month"`           // First day of the month
+	StartDate     time.Time `json:"start_date" db:"start_date"` // Of the opening snapshot
+	EndDate       time.Time `json:"end_date" db:"end_date"`     // Of the closing snapshot
+	StartingValue float64   `json:"starting_value" db:"starting_value"`
+	EndingValue   float64   `json:"ending_value" db:"ending_value"`
+	Contributions float64   `json:"contributions" db:"contributions"`   // Deposits and incoming transfers
+	Withdrawals   float64   `json:"withdrawals" db:"withdrawals"`       // Withdrawals and outgoing transfers
+	RealizedPnL   float64   `json:"realized_pnl" db:"realized_pnl"`     // Of sales at weighted-average cost, before fees
+	UnrealizedPnL float64   `json:"unrealized_pnl" db:"unrealized_pnl"` // Change over the month in the gains of open positions
+	Fees          float64   `json:"fees" db:"fees"`
+	TradeCount    int       `json:"trade_count" db:"trade_count"` // Filled trades
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
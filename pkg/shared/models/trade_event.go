@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// TradeEvent is one step in the execution lifecycle of a trade order
+type TradeEvent struct {
+	ID          int64     `json:"id" db:"id"`
+	PortfolioID int       `json:"portfolio_id" db:"portfolio_id"`
+	UserID      int       `json:"user_id" db:"user_id"`
+	TradeID     int       `json:"trade_id,omitempty" db:"trade_id"` // Set once the trade is filled
+	Symbol      string    `json:"symbol" db:"symbol"`
+	EventType   string    `json:"event_type" db:"event_type"`
+	Side        string    `json:"side" db:"side"`
+	Quantity    float64   `json:"quantity" db:"quantity"`
+	Price       float64   `json:"price" db:"price"`
+	Fees        float64   `json:"fees" db:"fees"`
+	Reason      string    `json:"reason,omitempty" db:"reason"` // Why an order was rejected or cancelled
+	RequestID   string    `json:"request_id,omitempty" db:"request_id"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Trade event types
+const (
+	TradeEventValidated = "validated" // Order passed validation
+	TradeEventFilled    = "filled"    // Order executed and persisted
+	TradeEventRejected  = "rejected"  // Order failed validation
+	TradeEventCancelled = "cancelled" // Order was validated but execution was aborted
+)
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// User is an account that owns portfolios
+type User struct {
+	ID           int       `json:"id" db:"id"`
+	Username     string    `json:"username" db:"username"`
+	Email        string    `json:"email" db:"email"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	FullName     string    `json:"full_name,omitempty" db:"full_name"`
+	Role         string    `json:"role" db:"role"`
+	IsActive     bool      `json:"is_active" db:"is_active"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// User roles
+const (
+	RoleAdmin  = "admin"  // Every portfolio, and the admin and job queue endpoints
+	RoleTrader = "trader" // Reads and trades their own portfolios
+	RoleViewer = "viewer" // Reads their own portfolios without changing them
+)
@@ -0,0 +1,187 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MaxLimit caps the page size a client can request
+const MaxLimit = 200
+
+var (
+	// ErrInvalidCursor is returned for cursors that do not decode or were
+	// issued for a different sort
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidSort is returned for sort fields outside a list's whitelist
+	// and orders other than asc and desc
+	ErrInvalidSort = errors.New("invalid sort")
+	// ErrInvalidLimit is returned for limits that are not positive integers
+	ErrInvalidLimit = errors.New("invalid limit")
+)
+
+// Field is a column a list can be sorted by. Type is the SQL type cursor
+// values are cast back to. Columns must not be nullable.
+type Field struct {
+	Column string
+	Type   string
+}
+
+// Sorts whitelists the fields a list can be sorted by. ID is a unique column
+// that breaks ties between rows with equal sort values, so every row has a
+// stable position.
+type Sorts struct {
+	ID      Field
+	Fields  map[string]Field
+	Default string
+	Desc    bool
+}
+
+// Request is a validated page request
+type Request struct {
+	Limit int
+	Sort  string
+	Desc  bool
+
+	field Field
+	id    Field
+	after *cursor
+}
+
+// cursor is the position after the last row of a page. It is encoded as
+// base64 JSON so clients treat it as opaque.
+type cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    string `json:"i"`
+}
+
+// Parse reads the limit, cursor, sort and order query parameters. A cursor
+// carries its sort, so sort and order may be omitted when one is given.
+func Parse(query url.Values, sorts Sorts, defaultLimit int) (Request, error) {
+	req := Request{Limit: defaultLimit, Sort: sorts.Default, Desc: sorts.Desc, id: sorts.ID}
+
+	if l := query.Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit < 1 {
+			return Request{}, fmt.Errorf("%w: %q", ErrInvalidLimit, l)
+		}
+		req.Limit = limit
+	}
+	if req.Limit > MaxLimit {
+		req.Limit = MaxLimit
+	}
+
+	if c := query.Get("cursor"); c != "" {
+		after, err := decode(c)
+		if err != nil {
+			return Request{}, err
+		}
+		req.after = after
+		req.Sort, req.Desc = after.Sort, after.Desc
+	}
+
+	if s := query.Get("sort"); s != "" {
+		if req.after != nil && s != req.Sort {
+			return Request{}, fmt.Errorf("%w: issued for sort %q", ErrInvalidCursor, req.Sort)
+		}
+		req.Sort = s
+	}
+	switch o := strings.ToLower(query.Get("order")); o {
+	case "":
+	case "asc", "desc":
+		if req.after != nil && (o == "desc") != req.Desc {
+			return Request{}, fmt.Errorf("%w: issued for a different order", ErrInvalidCursor)
+		}
+		req.Desc = o == "desc"
+	default:
+		return Request{}, fmt.Errorf("%w: order must be asc or desc", ErrInvalidSort)
+	}
+
+	field, ok := sorts.Fields[req.Sort]
+	if !ok {
+		return Request{}, fmt.Errorf("%w: cannot sort by %q", ErrInvalidSort, req.Sort)
+	}
+	req.field = field
+
+	return req, nil
+}
+
+// Key is the select expression for a row's sort value, scanned as a string
+// and passed to Cursor
+func (r Request) Key() string {
+	return "(" + r.field.Column + ")::text"
+}
+
+// After returns the condition selecting rows after the cursor, joined with
+// AND, and its arguments numbered from $n. It is empty on the first page.
+func (r Request) After(n int) (string, []interface{}) {
+	if r.after == nil {
+		return "", nil
+	}
+	op := ">"
+	if r.Desc {
+		op = "<"
+	}
+	clause := fmt.Sprintf(" AND (%s, %s) %s ($%d::%s, $%d::%s)",
+		r.field.Column, r.id.Column, op, n, r.field.Type, n+1, r.id.Type)
+	return clause, []interface{}{r.after.Value, r.after.ID}
+}
+
+// OrderBy returns the ORDER BY and LIMIT clauses. One row more than the
+// limit is fetched so More can tell whether another page follows.
+func (r Request) OrderBy() string {
+	direction := "ASC"
+	if r.Desc {
+		direction = "DESC"
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, %s %s LIMIT %d",
+		r.field.Column, direction, r.id.Column, direction, r.Limit+1)
+}
+
+// More reports whether a query fetched more rows than the page holds
+func (r Request) More(fetched int) bool {
+	return fetched > r.Limit
+}
+
+// Cursor encodes the position after a row from its sort key and ID
+func (r Request) Cursor(key string, id interface{}) string {
+	data, _ := json.Marshal(cursor{Sort: r.Sort, Desc: r.Desc, Value: key, ID: fmt.Sprint(id)})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort == "" || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Result describes a page a repository returned
+type Result struct {
+	NextCursor string
+	Total      int
+}
+
+// Page is the envelope list endpoints respond with. NextCursor is empty on
+// the last page.
+type Page struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor"`
+	Total      int         `json:"total"`
+}
+
+// Page wraps a page of response items in the list envelope
+func (r Result) Page(data interface{}) Page {
+	return Page{Data: data, NextCursor: r.NextCursor, Total: r.Total}
+}
@@ -0,0 +1,94 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSorts = Sorts{
+	ID: Field{Column: "id", Type: "integer"},
+	Fields: map[string]Field{
+		"created_at": {Column: "created_at", Type: "timestamptz"},
+		"name":       {Column: "name", Type: "text"},
+	},
+	Default: "created_at",
+	Desc:    true,
+}
+
+func TestParseDefaults(t *testing.T) {
+	req, err := Parse(url.Values{}, testSorts, 50)
+	require.NoError(t, err)
+	assert.Equal(t, 50, req.Limit)
+	assert.Equal(t, "created_at", req.Sort)
+	assert.True(t, req.Desc)
+
+	where, args := req.After(2)
+	assert.Empty(t, where)
+	assert.Nil(t, args)
+	assert.Equal(t, " ORDER BY created_at DESC, id DESC LIMIT 51", req.OrderBy())
+	assert.Equal(t, "(created_at)::text", req.Key())
+}
+
+func TestParseRejectsInvalidParameters(t *testing.T) {
+	_, err := Parse(url.Values{"limit": {"0"}}, testSorts, 50)
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+
+	_, err = Parse(url.Values{"limit": {"ten"}}, testSorts, 50)
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+
+	_, err = Parse(url.Values{"sort": {"password"}}, testSorts, 50)
+	assert.ErrorIs(t, err, ErrInvalidSort)
+
+	_, err = Parse(url.Values{"order": {"sideways"}}, testSorts, 50)
+	assert.ErrorIs(t, err, ErrInvalidSort)
+
+	_, err = Parse(url.Values{"cursor": {"not-a-cursor"}}, testSorts, 50)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestParseCapsLimit(t *testing.T) {
+	req, err := Parse(url.Values{"limit": {"5000"}}, testSorts, 50)
+	require.NoError(t, err)
+	assert.Equal(t, MaxLimit, req.Limit)
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	first, err := Parse(url.Values{"sort": {"name"}, "order": {"asc"}, "limit": {"10"}}, testSorts, 50)
+	require.NoError(t, err)
+	assert.True(t, first.More(11))
+	assert.False(t, first.More(10))
+
+	next, err := Parse(url.Values{"cursor": {first.Cursor("Growth", 42)}, "limit": {"10"}}, testSorts, 50)
+	require.NoError(t, err)
+	assert.Equal(t, "name", next.Sort)
+	assert.False(t, next.Desc)
+
+	where, args := next.After(2)
+	assert.Equal(t, " AND (name, id) > ($2::text, $3::integer)", where)
+	assert.Equal(t, []interface{}{"Growth", "42"}, args)
+}
+
+func TestCursorIsBoundToItsSort(t *testing.T) {
+	first, err := Parse(url.Values{}, testSorts, 50)
+	require.NoError(t, err)
+	c := first.Cursor("2024-01-02 00:00:00+00", 7)
+
+	_, err = Parse(url.Values{"cursor": {c}, "sort": {"name"}}, testSorts, 50)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = Parse(url.Values{"cursor": {c}, "order": {"asc"}}, testSorts, 50)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = Parse(url.Values{"cursor": {c}, "sort": {"created_at"}, "order": {"desc"}}, testSorts, 50)
+	assert.NoError(t, err)
+}
+
+func TestResultPage(t *testing.T) {
+	page := Result{NextCursor: "abc", Total: 3}.Page([]int{1, 2})
+	assert.Equal(t, []int{1, 2}, page.Data)
+	assert.Equal(t, "abc", page.NextCursor)
+	assert.Equal(t, 3, page.Total)
+}
@@ -0,0 +1,533 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Queue administration
+//
+// Workers of every service report a heartbeat to Redis, and pauses set
+// through any service are read by all of them, so one admin API observes and
+// controls the whole job system.
+
+const (
+	// workersKey is the hash of worker heartbeats, by consumer
+	workersKey = "queue:workers"
+
+	// pausedKey is the hash of paused queues' pauses, by queue
+	pausedKey = "queue:paused"
+
+	// throughputPrefix starts the per-minute hashes counting settled jobs
+	throughputPrefix = "queue:throughput:"
+
+	// heartbeatInterval is how often a running worker reports its state
+	heartbeatInterval = 10 * time.Second
+
+	// heartbeatExpiry is how long after its last heartbeat a worker is
+	// considered gone, e.g. its service crashed without removing it
+	heartbeatExpiry = 6 * heartbeatInterval
+
+	// pauseCacheTTL is how long workers reuse the paused queues read from
+	// Redis, so a pause takes effect within it and a worker's wait
+	pauseCacheTTL = 5 * time.Second
+
+	// throughputRetention bounds how long per-minute counts are kept
+	throughputRetention = 2 * time.Hour
+)
+
+// Worker states
+const (
+	WorkerIdle   = "idle"
+	WorkerBusy   = "busy"
+	WorkerPaused = "paused"
+	WorkerStuck  = "stuck" // Its job overran its timeout and is left to the reaper
+)
+
+// ErrUnknownQueue is returned for a queue that is not one of Queues
+var ErrUnknownQueue = errors.New("unknown queue")
+
+// Queues lists every job queue
+var Queues = []string{
+	models.QueueAIAnalysis,
+	models.QueueRiskCalc,
+	models.QueueNotifications,
+	models.QueueWebhooks,
+	models.QueueMarketData,
+	models.QueueReports,
+	models.QueueBacktests,
+	models.QueueReconciliation,
+	models.QueueImports,
+	models.QueueCleanup,
+	models.QueueMaintenance,
+}
+
+// QueueName returns the queue named name, with or without its "queue:"
+// prefix
+func QueueName(name string) (string, error) {
+	queue := "queue:" + strings.TrimPrefix(name, "queue:")
+	for _, known := range Queues {
+		if queue == known {
+			return queue, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownQueue, name)
+}
+
+// QueueStats reports a queue's backlog and recent throughput
+type QueueStats struct {
+	Queue            string           `json:"queue"`
+	Length           int64            `json:"length"`             // Unacknowledged jobs across priority bands
+	Bands            map[string]int64 `json:"bands"`              // Length by band: high, normal and low
+	Pending          int64            `json:"pending"`            // Delivered to a worker and not yet acknowledged
+	DeadLetters      int64            `json:"dead_letters"`       // Jobs in the dead letter stream
+	OldestJobAgeSecs float64          `json:"oldest_job_age_sec"` // 0 for an empty queue
+	Running          int64            `json:"running"`            // Jobs being processed
+	Stuck            int64            `json:"stuck"`              // Running jobs whose heartbeat went stale, until reaped
+	Throughput       Throughput       `json:"throughput"`
+	Paused           *Pause           `json:"paused,omitempty"`
+}
+
+// Throughput counts the attempts at a queue's jobs settled by its workers
+type Throughput struct {
+	Last5m    JobCounts `json:"last_5m"`
+	LastHour  JobCounts `json:"last_hour"`
+	PerMinute float64   `json:"per_minute"` // Attempts a minute over the last five
+}
+
+// JobCounts counts job attempts by outcome
+type JobCounts struct {
+	Completed int64 `json:"completed"`
+	Failed    int64 `json:"failed"` // Including those retried
+	Reaped    int64 `json:"reaped"` // Stuck jobs taken from their worker, not counted as failed
+}
+
+// Pause records why and by whom a queue's workers were paused
+type Pause struct {
+	Reason   string    `json:"reason,omitempty"`
+	PausedBy string    `json:"paused_by,omitempty"`
+	PausedAt time.Time `json:"paused_at"`
+}
+
+// Heartbeat is a worker's last reported state
+type Heartbeat struct {
+	Consumer      string      `json:"consumer"`
+	Queue         string      `json:"queue"`
+	Host          string      `json:"host"`
+	PID           int         `json:"pid"`
+	State         string      `json:"state"`
+	CurrentJob    *CurrentJob `json:"current_job,omitempty"`
+	StartedAt     time.Time   `json:"started_at"`
+	LastHeartbeat time.Time   `json:"last_heartbeat"`
+}
+
+// CurrentJob is the job a busy worker is processing
+type CurrentJob struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// expired reports whether the worker has not reported since heartbeatExpiry
+// before now
+func (h Heartbeat) expired(now time.Time) bool {
+	return now.Sub(h.LastHeartbeat) > heartbeatExpiry
+}
+
+// WorkerList reports the running workers of every service
+type WorkerList struct {
+	Total   int         `json:"total"`
+	Busy    int         `json:"busy"`
+	Paused  int         `json:"paused"`
+	Workers []Heartbeat `json:"workers"` // By queue, then consumer
+}
+
+// QueueStats reports every queue's backlog, throughput and pause. A queue
+// whose stats cannot be read is left out.
+func (m *Manager) QueueStats(ctx context.Context) ([]QueueStats, error) {
+	paused, err := m.readPauses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	throughput, err := m.throughput(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	running, err := m.runningJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]QueueStats, 0, len(Queues))
+	for _, queue := range Queues {
+		queueStats, err := m.queueStats(ctx, queue)
+		if err != nil {
+			logger.Warn("Failed to get queue stats", zap.String("queue", queue), zap.Error(err))
+			continue
+		}
+		queueStats.Throughput = throughput[queue]
+		for _, job := range running {
+			if job.Queue != queue {
+				continue
+			}
+			queueStats.Running++
+			if job.stale(now) {
+				queueStats.Stuck++
+			}
+		}
+		if pause, ok := paused[queue]; ok {
+			queueStats.Paused = &pause
+		}
+		stats = append(stats, queueStats)
+	}
+	return stats, nil
+}
+
+func (m *Manager) queueStats(ctx context.Context, queue string) (QueueStats, error) {
+	stats := QueueStats{Queue: queue, Bands: make(map[string]int64, 3)}
+
+	var oldest time.Time
+	for i, stream := range priorityStreams(queue) {
+		length, err := m.redis.QueueLength(ctx, stream)
+		if err != nil {
+			return stats, err
+		}
+		pending, err := m.redis.PendingJobs(ctx, stream, consumerGroup)
+		if err != nil {
+			return stats, err
+		}
+		enqueuedAt, ok, err := m.redis.OldestJob(ctx, stream)
+		if err != nil {
+			return stats, err
+		}
+
+		stats.Bands[bandNames[i]] = length
+		stats.Length += length
+		stats.Pending += pending
+		if ok && (oldest.IsZero() || enqueuedAt.Before(oldest)) {
+			oldest = enqueuedAt
+		}
+	}
+	if !oldest.IsZero() {
+		stats.OldestJobAgeSecs = time.Since(oldest).Seconds()
+	}
+
+	deadLetters, err := m.redis.QueueLength(ctx, DeadLetterQueue(queue))
+	if err != nil {
+		return stats, err
+	}
+	stats.DeadLetters = deadLetters
+	return stats, nil
+}
+
+// bandNames names the streams of priorityStreams
+var bandNames = []string{"high", "normal", "low"}
+
+// Workers reports the workers of every service that reported a heartbeat
+// recently. Workers that stopped reporting are removed.
+func (m *Manager) Workers(ctx context.Context) (WorkerList, error) {
+	raw, err := m.redis.HGetAll(ctx, workersKey).Result()
+	if err != nil {
+		return WorkerList{}, fmt.Errorf("failed to get workers: %w", err)
+	}
+
+	now := time.Now()
+	list := WorkerList{Workers: make([]Heartbeat, 0, len(raw))}
+	var expired []string
+	for consumer, data := range raw {
+		var heartbeat Heartbeat
+		if err := json.Unmarshal([]byte(data), &heartbeat); err != nil || heartbeat.expired(now) {
+			expired = append(expired, consumer)
+			continue
+		}
+
+		switch heartbeat.State {
+		case WorkerBusy:
+			list.Busy++
+		case WorkerPaused:
+			list.Paused++
+		}
+		list.Workers = append(list.Workers, heartbeat)
+	}
+	list.Total = len(list.Workers)
+
+	if len(expired) > 0 {
+		if err := m.redis.HDel(ctx, workersKey, expired...).Err(); err != nil {
+			logger.Warn("Failed to remove expired workers", zap.Error(err))
+		}
+	}
+
+	sort.Slice(list.Workers, func(i, j int) bool {
+		a, b := list.Workers[i], list.Workers[j]
+		if a.Queue != b.Queue {
+			return a.Queue < b.Queue
+		}
+		return a.Consumer < b.Consumer
+	})
+	return list, nil
+}
+
+// Pause makes every worker of a queue stop taking jobs, including abandoned
+// ones, until it is resumed, setting when it was paused. Jobs being
+// processed finish and queued jobs stay in place.
+func (m *Manager) Pause(ctx context.Context, queue string, pause *Pause) error {
+	pause.PausedAt = time.Now()
+	data, err := json.Marshal(pause)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pause: %w", err)
+	}
+	if err := m.redis.HSet(ctx, pausedKey, queue, data).Err(); err != nil {
+		return fmt.Errorf("failed to pause queue: %w", err)
+	}
+
+	m.pauses.invalidate()
+	logger.Info("Queue paused", zap.String("queue", queue), zap.String("reason", pause.Reason), zap.String("paused_by", pause.PausedBy))
+	return nil
+}
+
+// Resume lets a paused queue's workers take jobs again
+func (m *Manager) Resume(ctx context.Context, queue string) error {
+	if err := m.redis.HDel(ctx, pausedKey, queue).Err(); err != nil {
+		return fmt.Errorf("failed to resume queue: %w", err)
+	}
+
+	m.pauses.invalidate()
+	logger.Info("Queue resumed", zap.String("queue", queue))
+	return nil
+}
+
+// queuePaused reports whether a queue was paused through the admin API
+func (m *Manager) queuePaused(ctx context.Context, queue string) bool {
+	_, ok := m.pauses.get(ctx, m)[queue]
+	return ok
+}
+
+func (m *Manager) readPauses(ctx context.Context) (map[string]Pause, error) {
+	raw, err := m.redis.HGetAll(ctx, pausedKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paused queues: %w", err)
+	}
+
+	paused := make(map[string]Pause, len(raw))
+	for queue, data := range raw {
+		var pause Pause
+		if err := json.Unmarshal([]byte(data), &pause); err != nil {
+			logger.Warn("Failed to decode queue pause", zap.String("queue", queue), zap.Error(err))
+		}
+		paused[queue] = pause
+	}
+	return paused, nil
+}
+
+// pauseCache holds the paused queues for pauseCacheTTL, so idle workers do
+// not read them from Redis on every poll
+type pauseCache struct {
+	mu       sync.Mutex
+	paused   map[string]Pause
+	cachedAt time.Time
+}
+
+func (c *pauseCache) get(ctx context.Context, m *Manager) map[string]Pause {
+	if m.redis == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.cachedAt) < pauseCacheTTL {
+		return c.paused
+	}
+
+	paused, err := m.readPauses(ctx)
+	if err != nil {
+		// Keep the last known pauses while Redis is unavailable
+		logger.Warn("Failed to read paused queues", zap.Error(err))
+		return c.paused
+	}
+	c.paused = paused
+	c.cachedAt = time.Now()
+	return c.paused
+}
+
+func (c *pauseCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cachedAt = time.Time{}
+}
+
+// Throughput
+
+// throughputKey is the hash counting a queue's jobs settled in the minute of at
+func throughputKey(queue string, at time.Time) string {
+	return fmt.Sprintf("%s%s:%d", throughputPrefix, queue, at.Unix()/60)
+}
+
+// Outcomes of job attempts counted by throughput
+const (
+	outcomeCompleted = "completed"
+	outcomeFailed    = "failed"
+	outcomeReaped    = "reaped"
+)
+
+// recordOutcome counts a settled attempt at a job of queue
+func (m *Manager) recordOutcome(queue, outcome string) {
+	if m.redis == nil {
+		return
+	}
+
+	key := throughputKey(queue, time.Now())
+	_, err := m.redis.Pipelined(m.ctx, func(pipe goredis.Pipeliner) error {
+		pipe.HIncrBy(m.ctx, key, outcome, 1)
+		pipe.Expire(m.ctx, key, throughputRetention)
+		return nil
+	})
+	if err != nil {
+		logger.Warn("Failed to record job throughput", zap.String("queue", queue), zap.Error(err))
+	}
+}
+
+// throughput sums every queue's settled attempts over the hour before now
+func (m *Manager) throughput(ctx context.Context, now time.Time) (map[string]Throughput, error) {
+	cmds := make(map[string][]*goredis.StringStringMapCmd, len(Queues))
+	_, err := m.redis.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for _, queue := range Queues {
+			for minute := 0; minute < 60; minute++ {
+				cmds[queue] = append(cmds[queue], pipe.HGetAll(ctx, throughputKey(queue, now.Add(-time.Duration(minute)*time.Minute))))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job throughput: %w", err)
+	}
+
+	throughput := make(map[string]Throughput, len(Queues))
+	for queue, minutes := range cmds {
+		counts := make([]JobCounts, len(minutes))
+		for i, cmd := range minutes {
+			counts[i] = JobCounts{
+				Completed: parseCount(cmd.Val()[outcomeCompleted]),
+				Failed:    parseCount(cmd.Val()[outcomeFailed]),
+				Reaped:    parseCount(cmd.Val()[outcomeReaped]),
+			}
+		}
+		throughput[queue] = sumThroughput(counts)
+	}
+	return throughput, nil
+}
+
+// sumThroughput sums per-minute counts, the current minute first
+func sumThroughput(minutes []JobCounts) Throughput {
+	var t Throughput
+	for i, counts := range minutes {
+		if i < 5 {
+			t.Last5m.Completed += counts.Completed
+			t.Last5m.Failed += counts.Failed
+			t.Last5m.Reaped += counts.Reaped
+		}
+		t.LastHour.Completed += counts.Completed
+		t.LastHour.Failed += counts.Failed
+		t.LastHour.Reaped += counts.Reaped
+	}
+	t.PerMinute = float64(t.Last5m.Completed+t.Last5m.Failed) / 5
+	return t
+}
+
+func parseCount(raw string) int64 {
+	n, _ := strconv.ParseInt(raw, 10, 64)
+	return n
+}
+
+// Heartbeats
+
+// heartbeat reports the worker's state until its loop exits, then removes it
+func (w *Worker) heartbeat() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			// The manager's context may already be cancelled on shutdown
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			err := w.manager.redis.HDel(ctx, workersKey, w.consumer).Err()
+			cancel()
+			if err != nil {
+				logger.Warn("Failed to remove worker heartbeat", zap.String("consumer", w.consumer), zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+			w.beat()
+		}
+	}
+}
+
+// beat reports the worker's current state, and refreshes the heartbeat of
+// its job unless the job overran its timeout
+func (w *Worker) beat() {
+	if w.manager.redis == nil {
+		return
+	}
+
+	now := time.Now()
+	w.mu.Lock()
+	heartbeat := Heartbeat{
+		Consumer:      w.consumer,
+		Queue:         w.queue,
+		Host:          hostname(),
+		PID:           os.Getpid(),
+		State:         WorkerIdle,
+		StartedAt:     w.startedAt,
+		LastHeartbeat: now,
+	}
+	running := w.running
+	overdue := running != nil && now.After(w.deadline)
+	switch {
+	case overdue:
+		heartbeat.State = WorkerStuck
+	case running != nil:
+		heartbeat.State = WorkerBusy
+	case w.isPaused:
+		heartbeat.State = WorkerPaused
+	}
+	if running != nil {
+		heartbeat.CurrentJob = &CurrentJob{ID: running.Job.ID, Type: running.Job.Type, StartedAt: running.StartedAt}
+	}
+	w.mu.Unlock()
+
+	data, err := json.Marshal(heartbeat)
+	if err != nil {
+		return
+	}
+	if err := w.manager.redis.HSet(w.manager.ctx, workersKey, w.consumer, data).Err(); err != nil {
+		logger.Warn("Failed to report worker heartbeat", zap.String("consumer", w.consumer), zap.Error(err))
+	}
+
+	if running != nil && !overdue {
+		w.manager.refreshJob(*running, now)
+	}
+}
+
+func hostname() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "worker"
+	}
+	return host
+}
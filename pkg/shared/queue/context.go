@@ -0,0 +1,22 @@
+package queue
+
+import (
+	"context"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+type contextKey struct{}
+
+// WithJob returns a copy of ctx carrying the job being processed. Workers
+// hand it to their handler, so code a job calls into can make its side
+// effects idempotent.
+func WithJob(ctx context.Context, job *models.Job) context.Context {
+	return context.WithValue(ctx, contextKey{}, job)
+}
+
+// JobFromContext returns the job carried by ctx, or nil outside a job
+func JobFromContext(ctx context.Context) *models.Job {
+	job, _ := ctx.Value(contextKey{}).(*models.Job)
+	return job
+}
@@ -0,0 +1,173 @@
+package queue
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/models"
+)
+
+// StreamJobEvents godoc
+// @Summary Stream a job's progress
+// @Description Server-sent "status" events with the job's status, progress and message: the current status first, then every update until the job completes or fails. AI workflows report each step on their job.
+// @Tags jobs
+// @Produce text/event-stream
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.JobStatus
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/jobs/{id}/events [get]
+func (m *Manager) StreamJobEvents(c *gin.Context) {
+	jobID := c.Param("id")
+
+	// The stream outlives the server's write timeout. Headers are sent with
+	// the first event, so an unknown job can still be reported as such.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn("Failed to lift write deadline for job stream", zap.Error(err))
+	}
+	started := false
+	err := m.WatchJob(c.Request.Context(), jobID, func(status *models.JobStatus) bool {
+		if !started {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			started = true
+		}
+		c.SSEvent("status", status)
+		c.Writer.Flush()
+		return true
+	})
+	switch {
+	case err == nil:
+	case started:
+		logger.Warn("Job stream ended", zap.String("job_id", jobID), zap.Error(err))
+	case strings.Contains(err.Error(), "not found"):
+		apierror.Respond(c, http.StatusNotFound, apierror.Response{Error: "Job not found", Details: err.Error()})
+	default:
+		logger.Error("Failed to stream job", zap.String("job_id", jobID), zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to stream job", Details: err.Error()})
+	}
+}
+
+// GetWorkerStats godoc
+// @Summary Get the service's job worker pools
+// @Description Each worker pool's queue, workers, those processing a job and those holding a job past its timeout, and per job type the attempts processed, failed and timed out with their average and maximum durations since the service started
+// @Tags jobs
+// @Produce json
+// @Success 200 {array} PoolStats
+// @Router /workers [get]
+func (m *Manager) GetWorkerStats(c *gin.Context) {
+	c.JSON(http.StatusOK, m.WorkerStats())
+}
+
+// PauseRequest is the admin API payload for pausing a queue
+type PauseRequest struct {
+	Reason   string `json:"reason"`
+	PausedBy string `json:"paused_by"`
+}
+
+// GetQueues godoc
+// @Summary Get the job queues
+// @Description Each queue's unacknowledged jobs by priority band, those delivered to a worker, its dead letters, the age of its oldest job, the attempts its workers completed and failed over the last five minutes and hour, the jobs being processed and those whose heartbeat went stale, and its pause
+// @Tags admin
+// @Produce json
+// @Success 200 {array} QueueStats
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/queues [get]
+func (m *Manager) GetQueues(c *gin.Context) {
+	stats, err := m.QueueStats(c.Request.Context())
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to get queues", Details: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetWorkers godoc
+// @Summary Get the job workers
+// @Description The workers of every service that reported a heartbeat in the last minute, with their state, the job they are processing and when they last reported
+// @Tags admin
+// @Produce json
+// @Success 200 {object} WorkerList
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/workers [get]
+func (m *Manager) GetWorkers(c *gin.Context) {
+	workers, err := m.Workers(c.Request.Context())
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to get workers", Details: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, workers)
+}
+
+// PauseQueue godoc
+// @Summary Pause a queue
+// @Description Stop every worker of the queue taking jobs until it is resumed. Jobs being processed finish and queued jobs stay in place; workers pick the pause up within seconds.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param queue path string true "Queue, e.g. ai_analysis"
+// @Param request body PauseRequest false "Reason"
+// @Success 200 {object} Pause
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/queues/{queue}/pause [post]
+func (m *Manager) PauseQueue(c *gin.Context) {
+	queue, ok := queueParam(c)
+	if !ok {
+		return
+	}
+
+	var req PauseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid request", Details: err.Error()})
+			return
+		}
+	}
+
+	pause := Pause{Reason: req.Reason, PausedBy: req.PausedBy}
+	if err := m.Pause(c.Request.Context(), queue, &pause); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to pause queue", Details: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, pause)
+}
+
+// ResumeQueue godoc
+// @Summary Resume a paused queue
+// @Tags admin
+// @Param queue path string true "Queue, e.g. ai_analysis"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/queues/{queue}/resume [post]
+func (m *Manager) ResumeQueue(c *gin.Context) {
+	queue, ok := queueParam(c)
+	if !ok {
+		return
+	}
+
+	if err := m.Resume(c.Request.Context(), queue); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to resume queue", Details: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// queueParam resolves the queue named in the path, responding with 404 for
+// an unknown one
+func queueParam(c *gin.Context) (string, bool) {
+	queue, err := QueueName(c.Param("queue"))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.Response{Error: "Queue not found", Details: err.Error()})
+		return "", false
+	}
+	return queue, true
+}
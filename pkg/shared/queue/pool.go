@@ -0,0 +1,288 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/logger"
+)
+
+// PoolConfig sizes worker pools and bounds their jobs
+type PoolConfig struct {
+	Concurrency  map[string]int           // Workers by queue; one when unset
+	JobTimeouts  map[string]time.Duration // By job type; jobTimeout when unset
+	DrainTimeout time.Duration            // How long Stop waits for in-flight jobs
+}
+
+// NewPoolConfig reads the worker pool settings from cfg
+func NewPoolConfig(cfg *config.Config) (PoolConfig, error) {
+	poolConfig := PoolConfig{
+		Concurrency:  make(map[string]int),
+		JobTimeouts:  make(map[string]time.Duration),
+		DrainTimeout: time.Duration(cfg.WorkerDrainTimeout) * time.Second,
+	}
+
+	concurrency, err := parseSettings(cfg.WorkerConcurrency)
+	if err != nil {
+		return PoolConfig{}, fmt.Errorf("invalid WORKER_CONCURRENCY: %w", err)
+	}
+	for queue, workers := range concurrency {
+		poolConfig.Concurrency["queue:"+strings.TrimPrefix(queue, "queue:")] = workers
+	}
+
+	timeouts, err := parseSettings(cfg.JobTimeouts)
+	if err != nil {
+		return PoolConfig{}, fmt.Errorf("invalid JOB_TIMEOUTS: %w", err)
+	}
+	for jobType, seconds := range timeouts {
+		poolConfig.JobTimeouts[jobType] = time.Duration(seconds) * time.Second
+	}
+	return poolConfig, nil
+}
+
+// parseSettings parses comma-separated name=value pairs of positive integers
+func parseSettings(raw string) (map[string]int, error) {
+	settings := make(map[string]int)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not name=value", pair)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%q must be a positive integer", pair)
+		}
+		settings[strings.TrimSpace(name)] = n
+	}
+	return settings, nil
+}
+
+// Pool runs several workers of one queue, each processing a job at a time
+type Pool struct {
+	queue        string
+	workers      []*Worker
+	metrics      *poolMetrics
+	drainTimeout time.Duration
+}
+
+// PoolStats reports a pool's workers and the jobs they processed since the
+// service started
+type PoolStats struct {
+	Queue         string              `json:"queue"`
+	Workers       int                 `json:"workers"`
+	ActiveWorkers int                 `json:"active_workers"` // Processing a job
+	StuckWorkers  int                 `json:"stuck_workers"`  // Holding a job past its timeout
+	Jobs          map[string]JobStats `json:"jobs"`           // By job type
+}
+
+// JobStats summarizes the attempts at jobs of a type
+type JobStats struct {
+	Processed     int64   `json:"processed"`
+	Failed        int64   `json:"failed"`
+	TimedOut      int64   `json:"timed_out"` // Included in failed
+	AvgDurationMs float64 `json:"avg_duration_ms"`
+	MaxDurationMs float64 `json:"max_duration_ms"`
+}
+
+// NewPool creates a pool of cfg.Concurrency[queue] workers of queue. The
+// pool is listed in the manager's worker stats.
+func (m *Manager) NewPool(queue string, handler JobHandler, cfg PoolConfig) *Pool {
+	size := cfg.Concurrency[queue]
+	if size < 1 {
+		size = 1
+	}
+
+	pool := &Pool{
+		queue:        queue,
+		metrics:      &poolMetrics{},
+		drainTimeout: cfg.DrainTimeout,
+	}
+	for i := 0; i < size; i++ {
+		pool.workers = append(pool.workers, m.newWorker(queue, handler, cfg.JobTimeouts, pool.metrics))
+	}
+
+	m.poolsMu.Lock()
+	m.pools = append(m.pools, pool)
+	m.poolsMu.Unlock()
+	return pool
+}
+
+// PauseWhen makes the pool's workers stop dequeuing jobs while fn returns
+// true, as Worker.PauseWhen
+func (p *Pool) PauseWhen(fn func(ctx context.Context) bool) *Pool {
+	for _, w := range p.workers {
+		w.PauseWhen(fn)
+	}
+	return p
+}
+
+// Start starts the pool's workers
+func (p *Pool) Start() error {
+	for _, w := range p.workers {
+		if err := w.Start(); err != nil {
+			p.Stop()
+			return err
+		}
+	}
+	logger.Info("Started worker pool", zap.String("queue", p.queue), zap.Int("workers", len(p.workers)))
+	return nil
+}
+
+// Stop stops the pool, waiting up to its drain timeout for in-flight jobs
+func (p *Pool) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.drainTimeout)
+	defer cancel()
+	p.Shutdown(ctx)
+}
+
+// Shutdown stops the pool's workers taking jobs and waits for their
+// in-flight jobs to settle. Jobs still running when ctx is done are
+// abandoned, to be redelivered, and ctx's error returned.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(p.workers))
+	for i, w := range p.workers {
+		wg.Add(1)
+		go func(i int, w *Worker) {
+			defer wg.Done()
+			errs[i] = w.Shutdown(ctx)
+		}(i, w)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			logger.Warn("Worker pool stopped before its jobs settled", zap.String("queue", p.queue), zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats reports the pool's workers and job metrics
+func (p *Pool) Stats() PoolStats {
+	stats := p.metrics.snapshot()
+	stats.Queue = p.queue
+	stats.Workers = len(p.workers)
+	now := time.Now()
+	for _, w := range p.workers {
+		if w.stuck(now) {
+			stats.StuckWorkers++
+		}
+	}
+	return stats
+}
+
+// Shutdown drains every pool created by the manager at once, as
+// Pool.Shutdown
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.poolsMu.Lock()
+	pools := append([]*Pool(nil), m.pools...)
+	m.poolsMu.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(pools))
+	for i, pool := range pools {
+		wg.Add(1)
+		go func(i int, pool *Pool) {
+			defer wg.Done()
+			errs[i] = pool.Shutdown(ctx)
+		}(i, pool)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WorkerStats reports every pool created by the manager
+func (m *Manager) WorkerStats() []PoolStats {
+	m.poolsMu.Lock()
+	defer m.poolsMu.Unlock()
+
+	stats := make([]PoolStats, 0, len(m.pools))
+	for _, pool := range m.pools {
+		stats = append(stats, pool.Stats())
+	}
+	return stats
+}
+
+// poolMetrics counts a pool's active workers and the durations of its jobs
+type poolMetrics struct {
+	mu     sync.Mutex
+	active int
+	jobs   map[string]*jobMetrics
+}
+
+type jobMetrics struct {
+	processed, failed, timedOut int64
+	total, max                  time.Duration
+}
+
+// begin records a worker taking a job, returning when it started
+func (pm *poolMetrics) begin() time.Time {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.active++
+	return time.Now()
+}
+
+// end records the attempt at a job of jobType started at started
+func (pm *poolMetrics) end(jobType string, started time.Time, err error, timedOut bool) {
+	elapsed := time.Since(started)
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.active--
+	if pm.jobs == nil {
+		pm.jobs = make(map[string]*jobMetrics)
+	}
+	job, ok := pm.jobs[jobType]
+	if !ok {
+		job = &jobMetrics{}
+		pm.jobs[jobType] = job
+	}
+
+	job.processed++
+	if err != nil {
+		job.failed++
+		if timedOut {
+			job.timedOut++
+		}
+	}
+	job.total += elapsed
+	if elapsed > job.max {
+		job.max = elapsed
+	}
+}
+
+func (pm *poolMetrics) snapshot() PoolStats {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	stats := PoolStats{ActiveWorkers: pm.active, Jobs: make(map[string]JobStats, len(pm.jobs))}
+	for jobType, job := range pm.jobs {
+		stats.Jobs[jobType] = JobStats{
+			Processed:     job.processed,
+			Failed:        job.failed,
+			TimedOut:      job.timedOut,
+			AvgDurationMs: float64(job.total.Microseconds()) / 1000 / float64(job.processed),
+			MaxDurationMs: float64(job.max.Microseconds()) / 1000,
+		}
+	}
+	return stats
+}
@@ -2,7 +2,11 @@ package queue
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,9 +17,13 @@ import (
 )
 
 type Manager struct {
-	redis  *redis.Client
-	ctx    context.Context
-	cancel context.CancelFunc
+	redis   *redis.Client
+	ctx     context.Context
+	cancel  context.CancelFunc
+	poolsMu sync.Mutex
+	pools   []*Pool
+	pauses  pauseCache
+	reaped  reapCounts
 }
 
 // NewManager creates a new queue manager
@@ -34,21 +42,32 @@ func (m *Manager) EnqueueJob(job *models.Job) error {
 	if job.ID == "" {
 		job.ID = uuid.New().String()
 	}
+	// Retries and redeliveries keep the key, so their side effects commit once
+	if job.IdempotencyKey == "" {
+		job.IdempotencyKey = job.ID
+	}
 
 	// Set created time
 	job.CreatedAt = time.Now()
 
-	// Determine queue based on job type
+	// Determine queue based on job type, and the queue's stream by priority
 	queue := m.getQueueForJobType(job.Type)
+	stream := priorityStream(queue, job.Priority)
 
-	if err := m.redis.EnqueueJob(m.ctx, queue, job); err != nil {
+	if err := m.redis.EnqueueJob(m.ctx, stream, job); err != nil {
 		return fmt.Errorf("failed to enqueue job: %w", err)
 	}
 
 	logger.Info("Job enqueued successfully",
 		zap.String("job_id", job.ID),
 		zap.String("job_type", job.Type),
-		zap.String("queue", queue))
+		zap.String("queue", stream),
+		zap.Int("priority", job.Priority))
+
+	// Recorded so the job can be watched before a worker takes it
+	if err := m.SetJobStatus(job.ID, models.JobStatusPending, "Job queued", 0); err != nil {
+		logger.Warn("Failed to record queued job status", zap.String("job_id", job.ID), zap.Error(err))
+	}
 
 	return nil
 }
@@ -139,21 +158,103 @@ func (m *Manager) EnqueueRiskCalculation(userID, portfolioID int, symbols []stri
 	return job.ID, nil
 }
 
-// DequeueJob gets the next job from a specific queue
-func (m *Manager) DequeueJob(queue string, timeout time.Duration) (*models.Job, error) {
-	var job models.Job
-	if err := m.redis.DequeueJob(m.ctx, queue, timeout, &job); err != nil {
-		return nil, err
+// EnqueueNotification enqueues a notification job
+func (m *Manager) EnqueueNotification(userID int, notificationType, subject, message string, data map[string]interface{}, channels []string) (string, error) {
+	job := &models.NotificationJob{
+		Job: models.Job{
+			ID:         uuid.New().String(),
+			Type:       models.JobTypeNotification,
+			Priority:   6,
+			MaxRetries: 3,
+			Payload: map[string]interface{}{
+				"user_id":           userID,
+				"notification_type": notificationType,
+				"subject":           subject,
+				"message":           message,
+				"data":              data,
+				"channels":          channels,
+			},
+		},
+		UserID:   userID,
+		Type:     notificationType,
+		Subject:  subject,
+		Message:  message,
+		Data:     data,
+		Channels: channels,
 	}
 
-	logger.Info("Job dequeued successfully",
-		zap.String("job_id", job.ID),
-		zap.String("job_type", job.Type),
-		zap.String("queue", queue))
+	if err := m.EnqueueJob(&job.Job); err != nil {
+		return "", err
+	}
+
+	return job.ID, nil
+}
+
+// EnqueueWebhook enqueues an event to post to a user's webhook endpoints,
+// returning the job ID, which is also the event's ID
+func (m *Manager) EnqueueWebhook(userID int, eventType string, data map[string]interface{}) (string, error) {
+	job := &models.WebhookJob{
+		Job: models.Job{
+			ID:         uuid.New().String(),
+			Type:       models.JobTypeWebhook,
+			Priority:   6,
+			MaxRetries: 5,
+			Payload: map[string]interface{}{
+				"user_id":    userID,
+				"event_type": eventType,
+				"data":       data,
+			},
+		},
+		UserID:    userID,
+		EventType: eventType,
+		Data:      data,
+	}
+
+	if err := m.EnqueueJob(&job.Job); err != nil {
+		return "", err
+	}
 
-	return &job, nil
+	return job.ID, nil
 }
 
+// EnqueueReportGeneration enqueues a job to build a stored report for [startDate, endDate)
+func (m *Manager) EnqueueReportGeneration(reportID string, userID, portfolioID int, reportType, format string, startDate, endDate time.Time) (string, error) {
+	job := &models.ReportGenerationJob{
+		Job: models.Job{
+			ID:         uuid.New().String(),
+			Type:       models.JobTypeReportGeneration,
+			Priority:   4,
+			MaxRetries: 3,
+			Payload: map[string]interface{}{
+				"report_id":    reportID,
+				"user_id":      userID,
+				"portfolio_id": portfolioID,
+				"report_type":  reportType,
+				"format":       format,
+				"start_date":   startDate.Format(time.RFC3339),
+				"end_date":     endDate.Format(time.RFC3339),
+			},
+		},
+		ReportID:    reportID,
+		UserID:      userID,
+		PortfolioID: portfolioID,
+		ReportType:  reportType,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Format:      format,
+	}
+
+	if err := m.EnqueueJob(&job.Job); err != nil {
+		return "", err
+	}
+
+	return job.ID, nil
+}
+
+// EventJobStatusUpdated is published on models.ChannelSystemEvents whenever
+// a job's status is set
+const EventJobStatusUpdated = "job_status_updated"
+
 // SetJobStatus updates the status of a job
 func (m *Manager) SetJobStatus(jobID, status string, message string, progress float64) error {
 	statusKey := fmt.Sprintf("job_status:%s", jobID)
@@ -179,7 +280,7 @@ func (m *Manager) SetJobStatus(jobID, status string, message string, progress fl
 
 	// Publish status update event
 	event := models.Event{
-		Type:      "job_status_updated",
+		Type:      EventJobStatusUpdated,
 		Source:    "queue_manager",
 		Timestamp: time.Now(),
 		Data: map[string]interface{}{
@@ -209,25 +310,74 @@ func (m *Manager) GetJobStatus(jobID string) (*models.JobStatus, error) {
 	return &status, nil
 }
 
-// GetQueueLength returns the number of jobs in a queue
+// WatchJob calls fn with a job's current status and then with each update
+// to it, until the job completes or fails, fn returns false or ctx is done
+func (m *Manager) WatchJob(ctx context.Context, jobID string, fn func(*models.JobStatus) bool) error {
+	// Subscribed before reading the current status, so no update is missed
+	pubsub := m.redis.SubscribeToEvents(ctx, models.ChannelSystemEvents)
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to job status: %w", err)
+	}
+
+	status, err := m.GetJobStatus(jobID)
+	if err != nil {
+		return err
+	}
+	if !fn(status) || jobSettled(status.Status) {
+		return nil
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var event models.Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				continue
+			}
+			if event.Type != EventJobStatusUpdated || event.Data["job_id"] != jobID {
+				continue
+			}
+
+			// The stored status carries the start and completion times
+			status, err := m.GetJobStatus(jobID)
+			if err != nil {
+				return err
+			}
+			if !fn(status) || jobSettled(status.Status) {
+				return nil
+			}
+		}
+	}
+}
+
+func jobSettled(status string) bool {
+	return status == models.JobStatusCompleted || status == models.JobStatusFailed
+}
+
+// GetQueueLength returns the number of jobs in a queue across its priority bands
 func (m *Manager) GetQueueLength(queue string) (int64, error) {
-	return m.redis.QueueLength(m.ctx, queue)
+	var total int64
+	for _, stream := range priorityStreams(queue) {
+		length, err := m.redis.QueueLength(m.ctx, stream)
+		if err != nil {
+			return 0, err
+		}
+		total += length
+	}
+	return total, nil
 }
 
 // GetAllQueueLengths returns the length of all queues
 func (m *Manager) GetAllQueueLengths() (map[string]int64, error) {
-	queues := []string{
-		models.QueueAIAnalysis,
-		models.QueueRiskCalc,
-		models.QueueNotifications,
-		models.QueueMarketData,
-		models.QueueReports,
-		models.QueueCleanup,
-		models.QueueMaintenance,
-	}
-
 	lengths := make(map[string]int64)
-	for _, queue := range queues {
+	for _, queue := range Queues {
 		length, err := m.GetQueueLength(queue)
 		if err != nil {
 			logger.Warn("Failed to get queue length",
@@ -241,14 +391,87 @@ func (m *Manager) GetAllQueueLengths() (map[string]int64, error) {
 	return lengths, nil
 }
 
-// Worker represents a job worker
+// Stream delivery settings
+const (
+	// consumerGroup is the group every worker of a queue reads through, so
+	// each job is delivered to one of them
+	consumerGroup = "workers"
+
+	// jobTimeout bounds a single attempt at a job of a type without its
+	// own timeout
+	jobTimeout = 10 * time.Minute
+
+	// claimMargin is how much longer than its longest job timeout a
+	// delivered job may go unacknowledged before it is considered abandoned
+	// by a crashed worker and claimed by another, so running jobs are not
+	// claimed
+	claimMargin = 5 * time.Minute
+
+	// claimInterval is how often a worker looks for abandoned jobs
+	claimInterval = time.Minute
+
+	// maxDeliveries bounds how often a job may be claimed after crashing
+	// its worker before it is moved to the dead letter stream
+	maxDeliveries = 5
+)
+
+// Priority bands. Each band of a queue is its own stream; workers always
+// take a job from a higher band first and jobs within a band are FIFO.
+const (
+	HighPriority = 7 // Jobs at or above this priority preempt normal work
+	LowPriority  = 3 // Jobs at or below this priority wait for normal work
+)
+
+// priorityStreams returns the streams backing a queue, highest band first.
+// The normal band keeps the queue's own name.
+func priorityStreams(queue string) []string {
+	return []string{queue + ":high", queue, queue + ":low"}
+}
+
+// priorityStream returns the stream of queue holding jobs of priority
+func priorityStream(queue string, priority int) string {
+	streams := priorityStreams(queue)
+	switch {
+	case priority >= HighPriority:
+		return streams[0]
+	case priority <= LowPriority:
+		return streams[2]
+	}
+	return streams[1]
+}
+
+// DeadLetterQueue is the stream holding jobs of queue that repeatedly crashed their worker
+func DeadLetterQueue(queue string) string {
+	return queue + ":dead"
+}
+
+// Worker represents a job worker processing one job at a time. Jobs are
+// delivered at least once: a job is acknowledged only after it completes,
+// fails for good, or is re-enqueued for a retry, and jobs left
+// unacknowledged by a crashed worker are claimed by another worker of the
+// queue.
 type Worker struct {
-	manager   *Manager
-	queue     string
-	handler   JobHandler
-	ctx       context.Context
-	cancel    context.CancelFunc
-	isRunning bool
+	manager     *Manager
+	queue       string
+	consumer    string
+	handler     JobHandler
+	ctx         context.Context // Jobs' parent, cancelled to abandon them
+	cancel      context.CancelFunc
+	reading     context.Context // Cancelled to stop taking jobs
+	stopReading context.CancelFunc
+	done        chan struct{} // Closed once the worker loop exits
+	isRunning   bool
+	pauseWhen   func(ctx context.Context) bool
+	mu          sync.Mutex // Guards the state reported by heartbeats
+	isPaused    bool
+	running     *RunningJob // Job being processed
+	deadline    time.Time   // Of the running job
+	tracked     bool        // Whether the running job was recorded for the reaper
+	startedAt   time.Time
+	lastClaim   time.Time
+	timeouts    map[string]time.Duration
+	claimIdle   time.Duration
+	metrics     *poolMetrics
 }
 
 // JobHandler defines the interface for handling jobs
@@ -257,16 +480,59 @@ type JobHandler interface {
 	CanHandle(jobType string) bool
 }
 
-// NewWorker creates a new job worker
+// NewWorker creates a new job worker with the default job timeout
 func (m *Manager) NewWorker(queue string, handler JobHandler) *Worker {
+	return m.newWorker(queue, handler, nil, &poolMetrics{})
+}
+
+// newWorker creates a worker bounding jobs by timeouts, by job type, and
+// recording them in metrics
+func (m *Manager) newWorker(queue string, handler JobHandler, timeouts map[string]time.Duration, metrics *poolMetrics) *Worker {
 	ctx, cancel := context.WithCancel(m.ctx)
-	return &Worker{
-		manager: m,
-		queue:   queue,
-		handler: handler,
-		ctx:     ctx,
-		cancel:  cancel,
+	reading, stopReading := context.WithCancel(ctx)
+	w := &Worker{
+		manager:     m,
+		queue:       queue,
+		consumer:    consumerName(),
+		handler:     handler,
+		ctx:         ctx,
+		cancel:      cancel,
+		reading:     reading,
+		stopReading: stopReading,
+		done:        make(chan struct{}),
+		timeouts:    timeouts,
+		metrics:     metrics,
+	}
+
+	longest := jobTimeout
+	for _, timeout := range timeouts {
+		if timeout > longest {
+			longest = timeout
+		}
 	}
+	w.claimIdle = longest + claimMargin
+	return w
+}
+
+// jobTimeout returns the bound of a single attempt at a job of jobType
+func (w *Worker) jobTimeout(jobType string) time.Duration {
+	if timeout, ok := w.timeouts[jobType]; ok {
+		return timeout
+	}
+	return jobTimeout
+}
+
+// consumerName identifies this worker within the consumer group
+func consumerName() string {
+	return fmt.Sprintf("%s-%d-%s", hostname(), os.Getpid(), uuid.New().String()[:8])
+}
+
+// PauseWhen makes the worker stop dequeuing jobs while fn returns true, e.g.
+// a state-mutating worker during a maintenance window. Queued jobs are left
+// in place and picked up once fn returns false.
+func (w *Worker) PauseWhen(fn func(ctx context.Context) bool) *Worker {
+	w.pauseWhen = fn
+	return w
 }
 
 // Start starts the worker
@@ -275,14 +541,31 @@ func (w *Worker) Start() error {
 		return fmt.Errorf("worker is already running")
 	}
 
+	if moved, err := w.manager.redis.MigrateListQueue(w.ctx, w.queue); err != nil {
+		return fmt.Errorf("failed to migrate queue %s: %w", w.queue, err)
+	} else if moved > 0 {
+		logger.Info("Migrated queued jobs to stream", zap.String("queue", w.queue), zap.Int("jobs", moved))
+	}
+
+	for _, stream := range priorityStreams(w.queue) {
+		if err := w.manager.redis.EnsureConsumerGroup(w.ctx, stream, consumerGroup); err != nil {
+			return fmt.Errorf("failed to start worker for %s: %w", stream, err)
+		}
+	}
+
 	w.isRunning = true
-	logger.Info("Starting job worker", zap.String("queue", w.queue))
+	w.startedAt = time.Now()
+	logger.Info("Starting job worker", zap.String("queue", w.queue), zap.String("consumer", w.consumer))
 
+	w.beat()
+	go w.heartbeat()
 	go w.run()
 	return nil
 }
 
-// Stop stops the worker
+// Stop stops the worker, abandoning the job it is processing. Jobs it has
+// not acknowledged are redelivered to another worker once they have been
+// idle for claimIdle.
 func (w *Worker) Stop() {
 	if !w.isRunning {
 		return
@@ -293,41 +576,153 @@ func (w *Worker) Stop() {
 	w.isRunning = false
 }
 
+// Shutdown stops the worker taking jobs and waits for the job it is
+// processing to settle. If ctx is done first the job is abandoned as by
+// Stop and ctx's error returned.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	if !w.isRunning {
+		return nil
+	}
+	defer w.cancel()
+
+	w.stopReading()
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		logger.Warn("Abandoning in-flight job", zap.String("queue", w.queue), zap.String("consumer", w.consumer))
+		w.cancel()
+		<-w.done
+		return ctx.Err()
+	}
+}
+
 // run is the main worker loop
 func (w *Worker) run() {
 	defer func() {
 		w.isRunning = false
+		close(w.done)
 		logger.Info("Job worker stopped", zap.String("queue", w.queue))
 	}()
 
 	for {
 		select {
-		case <-w.ctx.Done():
+		case <-w.reading.Done():
 			return
 		default:
-			// Try to get a job with a timeout
-			job, err := w.manager.DequeueJob(w.queue, 5*time.Second)
-			if err != nil {
-				// Timeout is expected, continue
+			if w.paused() {
+				w.wait(5 * time.Second)
 				continue
 			}
 
-			// Check if handler can process this job type
-			if !w.handler.CanHandle(job.Type) {
-				logger.Warn("Handler cannot process job type",
-					zap.String("job_type", job.Type),
-					zap.String("job_id", job.ID))
+			if time.Since(w.lastClaim) >= claimInterval {
+				w.claimStaleJobs()
+				w.lastClaim = time.Now()
+			}
+
+			// Take the highest priority job, waiting with a timeout if there is none
+			entries, err := w.manager.redis.ReadJobs(w.reading, priorityStreams(w.queue), consumerGroup, w.consumer, 5*time.Second)
+			if err != nil {
+				if w.reading.Err() == nil {
+					logger.Warn("Failed to read job", zap.String("queue", w.queue), zap.Error(err))
+					w.wait(time.Second)
+				}
 				continue
 			}
 
-			// Process the job
-			w.processJob(job)
+			// Usually one job; several only when bands filled up while blocked
+			for _, entry := range entries {
+				w.handleEntry(entry)
+			}
 		}
 	}
 }
 
-// processJob processes a single job
-func (w *Worker) processJob(job *models.Job) {
+// paused reports whether the queue was paused through the admin API or the
+// pause condition holds, logging and reporting transitions
+func (w *Worker) paused() bool {
+	paused := w.manager.queuePaused(w.reading, w.queue) || (w.pauseWhen != nil && w.pauseWhen(w.reading))
+	if paused != w.isPaused {
+		if paused {
+			logger.Info("Job worker paused", zap.String("queue", w.queue))
+		} else {
+			logger.Info("Job worker resumed", zap.String("queue", w.queue))
+		}
+		w.mu.Lock()
+		w.isPaused = paused
+		w.mu.Unlock()
+		w.beat()
+	}
+	return paused
+}
+
+// wait sleeps for d or until the worker stops taking jobs
+func (w *Worker) wait(d time.Duration) {
+	select {
+	case <-w.reading.Done():
+	case <-time.After(d):
+	}
+}
+
+// claimStaleJobs takes over jobs abandoned by crashed workers
+func (w *Worker) claimStaleJobs() {
+	for _, stream := range priorityStreams(w.queue) {
+		entries, err := w.manager.redis.ClaimStaleJobs(w.reading, stream, consumerGroup, w.consumer, w.claimIdle, 10)
+		if err != nil {
+			if w.reading.Err() == nil {
+				logger.Warn("Failed to claim abandoned jobs", zap.String("queue", stream), zap.Error(err))
+			}
+			return
+		}
+
+		for _, entry := range entries {
+			logger.Warn("Claimed job abandoned by another worker",
+				zap.String("queue", stream),
+				zap.String("entry_id", entry.ID),
+				zap.Int64("deliveries", entry.Deliveries))
+			w.handleEntry(entry)
+		}
+	}
+}
+
+// handleEntry decodes a delivered job and processes it
+func (w *Worker) handleEntry(entry redis.StreamEntry) {
+	var job models.Job
+	if err := json.Unmarshal(entry.Data, &job); err != nil {
+		logger.Error("Dropping malformed job",
+			zap.String("queue", w.queue),
+			zap.String("entry_id", entry.ID),
+			zap.Error(err))
+		w.ack(entry)
+		return
+	}
+
+	// A job that keeps crashing its worker would otherwise be claimed forever
+	if entry.Deliveries > maxDeliveries {
+		w.deadLetter(entry, &job)
+		return
+	}
+
+	// Check if handler can process this job type
+	if !w.handler.CanHandle(job.Type) {
+		logger.Warn("Handler cannot process job type",
+			zap.String("job_type", job.Type),
+			zap.String("job_id", job.ID))
+		w.ack(entry)
+		return
+	}
+
+	logger.Info("Job dequeued successfully",
+		zap.String("job_id", job.ID),
+		zap.String("job_type", job.Type),
+		zap.String("queue", w.queue))
+
+	// Process the job
+	w.processJob(entry, &job)
+}
+
+// processJob processes a single job and acknowledges it once it is settled
+func (w *Worker) processJob(entry redis.StreamEntry, job *models.Job) {
 	logger.Info("Processing job",
 		zap.String("job_id", job.ID),
 		zap.String("job_type", job.Type))
@@ -335,12 +730,29 @@ func (w *Worker) processJob(job *models.Job) {
 	// Update status to running
 	w.manager.SetJobStatus(job.ID, models.JobStatusRunning, "Processing job", 0)
 
-	// Create job context with timeout
-	ctx, cancel := context.WithTimeout(w.ctx, 10*time.Minute)
+	// Create job context with timeout, carrying the job for idempotent side effects
+	ctx, cancel := context.WithTimeout(WithJob(w.ctx, job), w.jobTimeout(job.Type))
 	defer cancel()
 
 	// Handle the job
+	started := w.metrics.begin()
+	deadline, _ := ctx.Deadline()
+	w.startJob(entry, job, started, deadline)
 	err := w.handler.Handle(ctx, job)
+	w.metrics.end(job.Type, started, err, errors.Is(ctx.Err(), context.DeadlineExceeded))
+	if !w.finishJob() {
+		// Retried or failed by the reaper, which acknowledged the entry
+		logger.Warn("Job settled after it was reaped as stuck",
+			zap.String("job_id", job.ID),
+			zap.String("job_type", job.Type),
+			zap.Error(err))
+		return
+	}
+	outcome := outcomeCompleted
+	if err != nil {
+		outcome = outcomeFailed
+	}
+	w.manager.recordOutcome(w.queue, outcome)
 	if err != nil {
 		logger.Error("Job processing failed",
 			zap.String("job_id", job.ID),
@@ -352,24 +764,63 @@ func (w *Worker) processJob(job *models.Job) {
 			w.manager.SetJobStatus(job.ID, models.JobStatusRetrying,
 				fmt.Sprintf("Retrying job (attempt %d/%d)", job.Retries, job.MaxRetries), 0)
 
-			// Re-enqueue with exponential backoff
+			// Re-enqueue with backoff. The original entry is acknowledged only
+			// once the retry is queued; if the worker stops first it is
+			// redelivered instead.
 			go func() {
 				backoff := time.Duration(job.Retries) * time.Minute
-				time.Sleep(backoff)
-				w.manager.EnqueueJob(job)
+				select {
+				case <-w.ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+				if err := w.manager.EnqueueJob(job); err != nil {
+					logger.Error("Failed to re-enqueue job", zap.String("job_id", job.ID), zap.Error(err))
+					return
+				}
+				w.ack(entry)
 			}()
 		} else {
 			w.manager.SetJobStatus(job.ID, models.JobStatusFailed,
 				fmt.Sprintf("Job failed after %d retries: %v", job.MaxRetries, err), 100)
+			w.ack(entry)
 		}
 		return
 	}
 
 	// Mark as completed
 	w.manager.SetJobStatus(job.ID, models.JobStatusCompleted, "Job completed successfully", 100)
+	w.ack(entry)
 	logger.Info("Job completed successfully", zap.String("job_id", job.ID))
 }
 
+// deadLetter parks a job that was abandoned too often for manual inspection
+func (w *Worker) deadLetter(entry redis.StreamEntry, job *models.Job) {
+	logger.Error("Moving job to dead letter queue",
+		zap.String("queue", w.queue),
+		zap.String("job_id", job.ID),
+		zap.Int64("deliveries", entry.Deliveries))
+
+	if err := w.manager.redis.EnqueueJob(w.manager.ctx, DeadLetterQueue(w.queue), job); err != nil {
+		logger.Error("Failed to dead-letter job", zap.String("job_id", job.ID), zap.Error(err))
+		return
+	}
+	w.manager.SetJobStatus(job.ID, models.JobStatusFailed,
+		fmt.Sprintf("Job abandoned after %d deliveries", entry.Deliveries), 100)
+	w.ack(entry)
+}
+
+// ack acknowledges a settled job. A failed acknowledgement only means the job
+// may be delivered again.
+func (w *Worker) ack(entry redis.StreamEntry) {
+	if err := w.manager.redis.AckJob(w.manager.ctx, entry.Stream, consumerGroup, entry.ID); err != nil {
+		logger.Warn("Failed to acknowledge job",
+			zap.String("queue", entry.Stream),
+			zap.String("entry_id", entry.ID),
+			zap.Error(err))
+	}
+}
+
 // getQueueForJobType returns the appropriate queue for a job type
 func (m *Manager) getQueueForJobType(jobType string) string {
 	switch jobType {
@@ -379,12 +830,20 @@ func (m *Manager) getQueueForJobType(jobType string) string {
 		return models.QueueRiskCalc
 	case models.JobTypeNotification:
 		return models.QueueNotifications
+	case models.JobTypeWebhook:
+		return models.QueueWebhooks
 	case models.JobTypeMarketDataUpdate:
 		return models.QueueMarketData
 	case models.JobTypeReportGeneration:
 		return models.QueueReports
 	case models.JobTypeCleanup:
 		return models.QueueCleanup
+	case models.JobTypeBacktestOptimization:
+		return models.QueueBacktests
+	case models.JobTypeReconciliation:
+		return models.QueueReconciliation
+	case models.JobTypePortfolioImport:
+		return models.QueueImports
 	default:
 		return models.QueueMaintenance
 	}
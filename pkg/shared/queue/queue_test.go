@@ -0,0 +1,180 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/redis"
+)
+
+func TestPriorityStream(t *testing.T) {
+	queue := models.QueueMarketData
+
+	assert.Equal(t, queue+":high", priorityStream(queue, 8))
+	assert.Equal(t, queue+":high", priorityStream(queue, HighPriority))
+	assert.Equal(t, queue, priorityStream(queue, 5))
+	assert.Equal(t, queue+":low", priorityStream(queue, LowPriority))
+	assert.Equal(t, queue+":low", priorityStream(queue, 0))
+
+	// Workers read bands in this order
+	assert.Equal(t, []string{queue + ":high", queue, queue + ":low"}, priorityStreams(queue))
+}
+
+func TestNewPoolConfig(t *testing.T) {
+	cfg, err := NewPoolConfig(&config.Config{
+		WorkerConcurrency:  "ai_analysis=4, queue:backtests=2",
+		JobTimeouts:        "backtest_optimization=3600",
+		WorkerDrainTimeout: 20,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{models.QueueAIAnalysis: 4, models.QueueBacktests: 2}, cfg.Concurrency)
+	assert.Equal(t, time.Hour, cfg.JobTimeouts[models.JobTypeBacktestOptimization])
+	assert.Equal(t, 20*time.Second, cfg.DrainTimeout)
+
+	_, err = NewPoolConfig(&config.Config{WorkerConcurrency: "ai_analysis"})
+	assert.Error(t, err)
+	_, err = NewPoolConfig(&config.Config{JobTimeouts: "notification=0"})
+	assert.Error(t, err)
+}
+
+func TestWorkerJobTimeouts(t *testing.T) {
+	m := &Manager{}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	defer m.cancel()
+
+	w := m.newWorker(models.QueueBacktests, nil, map[string]time.Duration{models.JobTypeBacktestOptimization: time.Hour}, &poolMetrics{})
+	assert.Equal(t, time.Hour, w.jobTimeout(models.JobTypeBacktestOptimization))
+	assert.Equal(t, jobTimeout, w.jobTimeout(models.JobTypeCleanup))
+
+	// Running jobs are never claimed by another worker
+	assert.Equal(t, time.Hour+claimMargin, w.claimIdle)
+	assert.Equal(t, jobTimeout+claimMargin, m.NewWorker(models.QueueBacktests, nil).claimIdle)
+}
+
+func TestPoolMetrics(t *testing.T) {
+	metrics := &poolMetrics{}
+
+	first := metrics.begin()
+	second := metrics.begin()
+	assert.Equal(t, 2, metrics.snapshot().ActiveWorkers)
+
+	metrics.end(models.JobTypeAIAnalysis, first.Add(-2*time.Second), nil, false)
+	metrics.end(models.JobTypeAIAnalysis, second, errors.New("deadline exceeded"), true)
+
+	stats := metrics.snapshot()
+	assert.Equal(t, 0, stats.ActiveWorkers)
+	job := stats.Jobs[models.JobTypeAIAnalysis]
+	assert.Equal(t, int64(2), job.Processed)
+	assert.Equal(t, int64(1), job.Failed)
+	assert.Equal(t, int64(1), job.TimedOut)
+	assert.GreaterOrEqual(t, job.MaxDurationMs, 2000.0)
+	assert.GreaterOrEqual(t, job.AvgDurationMs, 1000.0)
+}
+
+func TestWorkerShutdownDrainsInFlightJob(t *testing.T) {
+	require.NoError(t, logger.Init("error", "test"))
+	m := &Manager{}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	defer m.cancel()
+
+	// A worker loop busy with a job once it stops taking jobs, reporting
+	// whether the job finished or was abandoned
+	start := func(w *Worker, busy time.Duration) <-chan bool {
+		finished := make(chan bool, 1)
+		w.isRunning = true
+		go func() {
+			defer close(w.done)
+			<-w.reading.Done()
+			select {
+			case <-time.After(busy):
+				finished <- true
+			case <-w.ctx.Done():
+				finished <- false
+			}
+		}()
+		return finished
+	}
+
+	drained := m.NewWorker(models.QueueReports, nil)
+	finished := start(drained, 10*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, drained.Shutdown(ctx))
+	assert.True(t, <-finished)
+
+	abandoned := m.NewWorker(models.QueueReports, nil)
+	finished = start(abandoned, time.Minute)
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, abandoned.Shutdown(ctx), context.DeadlineExceeded)
+	assert.False(t, <-finished)
+}
+
+func TestQueueName(t *testing.T) {
+	queue, err := QueueName("ai_analysis")
+	require.NoError(t, err)
+	assert.Equal(t, models.QueueAIAnalysis, queue)
+
+	queue, err = QueueName(models.QueueImports)
+	require.NoError(t, err)
+	assert.Equal(t, models.QueueImports, queue)
+
+	_, err = QueueName("queue:unknown")
+	assert.ErrorIs(t, err, ErrUnknownQueue)
+}
+
+func TestSumThroughput(t *testing.T) {
+	minutes := make([]JobCounts, 60)
+	minutes[0] = JobCounts{Completed: 6, Failed: 1}
+	minutes[4] = JobCounts{Completed: 3}
+	minutes[5] = JobCounts{Completed: 20, Failed: 2}
+	minutes[59] = JobCounts{Completed: 1}
+
+	throughput := sumThroughput(minutes)
+	assert.Equal(t, JobCounts{Completed: 9, Failed: 1}, throughput.Last5m)
+	assert.Equal(t, JobCounts{Completed: 30, Failed: 3}, throughput.LastHour)
+	assert.Equal(t, 2.0, throughput.PerMinute)
+}
+
+func TestHeartbeatExpired(t *testing.T) {
+	now := time.Now()
+	assert.False(t, Heartbeat{LastHeartbeat: now.Add(-heartbeatInterval)}.expired(now))
+	assert.True(t, Heartbeat{LastHeartbeat: now.Add(-heartbeatExpiry - time.Second)}.expired(now))
+}
+
+func TestWorkerStuckJob(t *testing.T) {
+	m := &Manager{}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	defer m.cancel()
+
+	pool := m.NewPool(models.QueueAIAnalysis, nil, PoolConfig{Concurrency: map[string]int{models.QueueAIAnalysis: 2}})
+	w := pool.workers[0]
+	job := &models.Job{ID: "job-1", Type: models.JobTypeAIAnalysis}
+	now := time.Now()
+
+	w.startJob(redis.StreamEntry{Stream: models.QueueAIAnalysis, ID: "1-0"}, job, now, now.Add(time.Minute))
+	assert.False(t, w.stuck(now))
+	assert.Equal(t, 0, pool.Stats().StuckWorkers)
+
+	// Past its timeout the job's heartbeat is no longer refreshed
+	w.deadline = now.Add(-time.Second)
+	assert.True(t, w.stuck(now))
+	assert.Equal(t, 1, pool.Stats().StuckWorkers)
+
+	// A job that could not be recorded cannot be reaped, so stays owned
+	assert.True(t, w.finishJob())
+	assert.False(t, w.stuck(now))
+}
+
+func TestRunningJobStale(t *testing.T) {
+	now := time.Now()
+	assert.False(t, RunningJob{LastHeartbeat: now.Add(-heartbeatInterval)}.stale(now))
+	assert.True(t, RunningJob{LastHeartbeat: now.Add(-heartbeatExpiry - time.Second)}.stale(now))
+}
@@ -0,0 +1,279 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/redis"
+)
+
+// Stuck jobs
+//
+// A worker records each job it takes in a shared hash and refreshes the
+// job's heartbeat with its own until the job settles or overruns its
+// timeout. The heartbeat of a job whose handler ignores cancellation, or
+// whose service died, goes stale; the reaper, run by one replica, then takes
+// the job from its worker and retries or fails it, so it does not hold a
+// "running" status forever.
+
+const (
+	// runningKey is the hash of running jobs, by job ID
+	runningKey = "queue:running"
+
+	// reapInterval is how often the reaper looks for stuck jobs
+	reapInterval = 30 * time.Second
+)
+
+// refreshScript refreshes a job's heartbeat only while it is recorded, so a
+// reaped job is not recorded again by its worker
+var refreshScript = goredis.NewScript(`
+	if redis.call("HEXISTS", KEYS[1], ARGV[1]) == 1 then
+		redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+		return 1
+	end
+	return 0`)
+
+// RunningJob is a job being processed, as recorded for the reaper
+type RunningJob struct {
+	Job           models.Job `json:"job"` // As taken from the queue
+	Queue         string     `json:"queue"`
+	Stream        string     `json:"stream"`
+	EntryID       string     `json:"entry_id"`
+	Consumer      string     `json:"consumer"`
+	StartedAt     time.Time  `json:"started_at"`
+	LastHeartbeat time.Time  `json:"last_heartbeat"`
+}
+
+// stale reports whether the job's heartbeat is older than heartbeatExpiry
+// at now
+func (r RunningJob) stale(now time.Time) bool {
+	return now.Sub(r.LastHeartbeat) > heartbeatExpiry
+}
+
+// startJob records the job the worker took from entry, which should settle
+// by deadline
+func (w *Worker) startJob(entry redis.StreamEntry, job *models.Job, started, deadline time.Time) {
+	running := &RunningJob{
+		Job:           *job,
+		Queue:         w.queue,
+		Stream:        entry.Stream,
+		EntryID:       entry.ID,
+		Consumer:      w.consumer,
+		StartedAt:     started,
+		LastHeartbeat: started,
+	}
+	tracked := w.manager.recordJob(*running)
+
+	w.mu.Lock()
+	w.running = running
+	w.deadline = deadline
+	w.tracked = tracked
+	w.mu.Unlock()
+	w.beat()
+}
+
+// finishJob clears the worker's job, reporting whether the worker still
+// owns it, i.e. the reaper did not take it
+func (w *Worker) finishJob() bool {
+	w.mu.Lock()
+	running, tracked := w.running, w.tracked
+	w.running = nil
+	w.mu.Unlock()
+
+	owned := true
+	if running != nil && tracked {
+		owned = w.manager.releaseJob(running.Job.ID)
+	}
+	w.beat()
+	return owned
+}
+
+// stuck reports whether the worker holds a job past its timeout
+func (w *Worker) stuck(now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.running != nil && now.After(w.deadline)
+}
+
+// recordJob records a running job, reporting whether it was recorded
+func (m *Manager) recordJob(job RunningJob) bool {
+	if m.redis == nil {
+		return false
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return false
+	}
+	if err := m.redis.HSet(m.ctx, runningKey, job.Job.ID, data).Err(); err != nil {
+		logger.Warn("Failed to record running job", zap.String("job_id", job.Job.ID), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// refreshJob sets a running job's heartbeat to now
+func (m *Manager) refreshJob(job RunningJob, now time.Time) {
+	job.LastHeartbeat = now
+	data, err := json.Marshal(job)
+	if err != nil {
+		return
+	}
+	if err := refreshScript.Run(m.ctx, m.redis, []string{runningKey}, job.Job.ID, data).Err(); err != nil {
+		logger.Warn("Failed to refresh job heartbeat", zap.String("job_id", job.Job.ID), zap.Error(err))
+	}
+}
+
+// releaseJob removes a settled job from the running jobs, reporting whether
+// it was still there. A job that cannot be removed is assumed to be.
+func (m *Manager) releaseJob(jobID string) bool {
+	removed, err := m.redis.HDel(m.ctx, runningKey, jobID).Result()
+	if err != nil {
+		logger.Warn("Failed to release running job", zap.String("job_id", jobID), zap.Error(err))
+		return true
+	}
+	return removed > 0
+}
+
+// runningJobs returns the jobs being processed by the workers of every service
+func (m *Manager) runningJobs(ctx context.Context) ([]RunningJob, error) {
+	raw, err := m.redis.HGetAll(ctx, runningKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get running jobs: %w", err)
+	}
+
+	jobs := make([]RunningJob, 0, len(raw))
+	for jobID, data := range raw {
+		var job RunningJob
+		if err := json.Unmarshal([]byte(data), &job); err != nil {
+			logger.Warn("Failed to decode running job", zap.String("job_id", jobID), zap.Error(err))
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// RunReaper reaps stuck jobs every reapInterval until ctx is done. One
+// replica should run it, e.g. through leader election.
+func (m *Manager) RunReaper(ctx context.Context) {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.ReapStuckJobs(ctx); err != nil && ctx.Err() == nil {
+			logger.Warn("Failed to reap stuck jobs", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReapStuckJobs takes every running job whose heartbeat went stale from its
+// worker. The job is retried as after a failed attempt, or failed once out
+// of retries, and its stream entry acknowledged so it is not also claimed
+// by another worker. It returns the number of jobs reaped.
+func (m *Manager) ReapStuckJobs(ctx context.Context) (int, error) {
+	running, err := m.runningJobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	reaped := 0
+	for _, job := range running {
+		if !job.stale(now) {
+			continue
+		}
+
+		// Removing the job takes it: its worker settling it meanwhile, or
+		// another reaper, finds it gone
+		removed, err := m.redis.HDel(ctx, runningKey, job.Job.ID).Result()
+		if err != nil {
+			return reaped, fmt.Errorf("failed to take stuck job: %w", err)
+		}
+		if removed == 0 {
+			continue
+		}
+
+		m.reap(ctx, job, now)
+		reaped++
+	}
+	return reaped, nil
+}
+
+func (m *Manager) reap(ctx context.Context, running RunningJob, now time.Time) {
+	job := running.Job
+	silent := now.Sub(running.LastHeartbeat).Round(time.Second)
+	logger.Warn("Reaping stuck job",
+		zap.String("job_id", job.ID),
+		zap.String("job_type", job.Type),
+		zap.String("queue", running.Queue),
+		zap.String("consumer", running.Consumer),
+		zap.Duration("since_heartbeat", silent))
+
+	if job.Retries < job.MaxRetries {
+		job.Retries++
+		m.SetJobStatus(job.ID, models.JobStatusRetrying,
+			fmt.Sprintf("Job stalled without a heartbeat for %s, retrying (attempt %d/%d)", silent, job.Retries, job.MaxRetries), 0)
+		if err := m.EnqueueJob(&job); err != nil {
+			// The entry stays pending, to be claimed once idle for long enough
+			logger.Error("Failed to re-enqueue stuck job", zap.String("job_id", job.ID), zap.Error(err))
+			return
+		}
+	} else {
+		m.SetJobStatus(job.ID, models.JobStatusFailed,
+			fmt.Sprintf("Job stalled without a heartbeat after %d retries", job.MaxRetries), 100)
+	}
+
+	if err := m.redis.AckJob(ctx, running.Stream, consumerGroup, running.EntryID); err != nil {
+		logger.Warn("Failed to acknowledge stuck job",
+			zap.String("queue", running.Stream),
+			zap.String("entry_id", running.EntryID),
+			zap.Error(err))
+	}
+	m.recordOutcome(running.Queue, outcomeReaped)
+	m.reaped.add(running.Queue)
+}
+
+// ReapedJobs returns the number of stuck jobs this instance reaped since
+// it started, by queue
+func (m *Manager) ReapedJobs() map[string]int64 {
+	return m.reaped.snapshot()
+}
+
+// reapCounts counts reaped jobs by queue
+type reapCounts struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (c *reapCounts) add(queue string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int64)
+	}
+	c.counts[queue]++
+}
+
+func (c *reapCounts) snapshot() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[string]int64, len(c.counts))
+	for queue, n := range c.counts {
+		counts[queue] = n
+	}
+	return counts
+}
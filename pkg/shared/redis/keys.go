@@ -0,0 +1,135 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/symbols"
+)
+
+// Cache keys are namespaced by what they belong to, so a user's, a
+// portfolio's or a symbol's caches can be told apart and invalidated
+// together. Tags group keys across namespaces: a key cached with a tag is
+// deleted when the tag is invalidated.
+
+const tagPrefix = "tag:"
+
+// Key joins key parts with the ":" separator
+func Key(parts ...string) string {
+	return strings.Join(parts, ":")
+}
+
+// UserKey builds a key in a user's namespace: user:{id}:{parts}
+func UserKey(userID int, parts ...string) string {
+	return Key(append([]string{"user", strconv.Itoa(userID)}, parts...)...)
+}
+
+// PortfolioKey builds a key in a portfolio's namespace: portfolio:{id}:{parts}
+func PortfolioKey(portfolioID int, parts ...string) string {
+	return Key(append([]string{"portfolio", strconv.Itoa(portfolioID)}, parts...)...)
+}
+
+// SymbolKey builds a key in a symbol's namespace, by its normalized form:
+// symbol:{symbol}:{parts}
+func SymbolKey(symbol string, parts ...string) string {
+	return Key(append([]string{"symbol", symbols.Normalize(symbol)}, parts...)...)
+}
+
+// UserTag tags the caches derived from a user's data. Parts narrow the tag
+// to one kind of cache, invalidated separately from the user's others.
+func UserTag(userID int, parts ...string) string {
+	return tagPrefix + UserKey(userID, parts...)
+}
+
+// PortfolioTag tags the caches derived from a portfolio's data, narrowed by
+// parts like UserTag
+func PortfolioTag(portfolioID int, parts ...string) string {
+	return tagPrefix + PortfolioKey(portfolioID, parts...)
+}
+
+// SymbolTag tags the caches derived from a symbol's data, narrowed by parts
+// like UserTag
+func SymbolTag(symbol string, parts ...string) string {
+	return tagPrefix + SymbolKey(symbol, parts...)
+}
+
+// setTaggedScript sets KEYS[1] and adds it to the tag sets KEYS[2:]. A tag
+// set lives as long as its longest-lived key: it never expires once a key
+// without expiration is tagged.
+var setTaggedScript = redis.NewScript(`
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+for i = 2, #KEYS do
+	local existed = redis.call('EXISTS', KEYS[i])
+	local current = redis.call('PTTL', KEYS[i])
+	redis.call('SADD', KEYS[i], KEYS[1])
+	if ttl == 0 then
+		redis.call('PERSIST', KEYS[i])
+	elseif existed == 0 or (current >= 0 and current < ttl) then
+		redis.call('PEXPIRE', KEYS[i], ttl)
+	end
+end
+return 1
+`)
+
+// invalidateScript deletes the keys in the tag sets KEYS and the tag sets
+// themselves, returning how many cached keys were deleted
+var invalidateScript = redis.NewScript(`
+local deleted = 0
+for i = 1, #KEYS do
+	local members = redis.call('SMEMBERS', KEYS[i])
+	for j = 1, #members do
+		deleted = deleted + redis.call('DEL', members[j])
+	end
+	redis.call('DEL', KEYS[i])
+end
+return deleted
+`)
+
+// SetCacheTagged stores a value in cache with expiration, like SetCache, and
+// tags it so InvalidateTags deletes it
+func (c *Client) SetCacheTagged(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error {
+	if len(tags) == 0 {
+		return c.SetCache(ctx, key, value, expiration)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache value: %w", err)
+	}
+
+	keys := append([]string{key}, tags...)
+	if err := setTaggedScript.Run(ctx, c.Client, keys, data, expiration.Milliseconds()).Err(); err != nil {
+		return fmt.Errorf("failed to set tagged cache: %w", err)
+	}
+
+	logger.Debug("Tagged cache set successfully", zap.String("key", key), zap.Strings("tags", tags))
+	return nil
+}
+
+// InvalidateTags deletes every cached key tagged with any of the tags,
+// returning how many were deleted
+func (c *Client) InvalidateTags(ctx context.Context, tags ...string) (int64, error) {
+	if len(tags) == 0 {
+		return 0, nil
+	}
+
+	deleted, err := invalidateScript.Run(ctx, c.Client, tags).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to invalidate cache tags: %w", err)
+	}
+
+	logger.Debug("Cache tags invalidated", zap.Strings("tags", tags), zap.Int64("deleted", deleted))
+	return deleted, nil
+}
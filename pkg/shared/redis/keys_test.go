@@ -0,0 +1,21 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyBuilders(t *testing.T) {
+	assert.Equal(t, "market:overview", Key("market", "overview"))
+	assert.Equal(t, "user:7:alerts", UserKey(7, "alerts"))
+	assert.Equal(t, "portfolio:42:risk:exposure", PortfolioKey(42, "risk", "exposure"))
+	assert.Equal(t, "symbol:BRK.B:fundamentals:annual:4", SymbolKey(" brk.b ", "fundamentals", "annual", "4"))
+}
+
+func TestTags(t *testing.T) {
+	assert.Equal(t, "tag:user:7", UserTag(7))
+	assert.Equal(t, "tag:portfolio:42", PortfolioTag(42))
+	assert.Equal(t, "tag:symbol:AAPL", SymbolTag("aapl"))
+	assert.Equal(t, "tag:symbol:AAPL:summary", SymbolTag("aapl", "summary"))
+}
@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,8 +11,12 @@ import (
 	"go.uber.org/zap"
 	"hedge-fund/pkg/shared/config"
 	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/symbols"
 )
 
+// ErrCacheMiss is returned for cache keys that are not set or have expired
+var ErrCacheMiss = errors.New("cache key not found")
+
 type Client struct {
 	*redis.Client
 }
@@ -43,6 +48,11 @@ func (c *Client) Health() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	return c.HealthContext(ctx)
+}
+
+// HealthContext checks if the Redis connection is healthy within ctx
+func (c *Client) HealthContext(ctx context.Context) error {
 	if err := c.Ping(ctx).Err(); err != nil {
 		return fmt.Errorf("Redis health check failed: %w", err)
 	}
@@ -72,7 +82,7 @@ func (c *Client) GetCache(ctx context.Context, key string, dest interface{}) err
 	data, err := c.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return fmt.Errorf("cache key not found: %s", key)
+			return fmt.Errorf("%w: %s", ErrCacheMiss, key)
 		}
 		return fmt.Errorf("failed to get cache: %w", err)
 	}
@@ -105,57 +115,6 @@ func (c *Client) CacheExists(ctx context.Context, key string) (bool, error) {
 	return count > 0, nil
 }
 
-// Job Queue operations
-
-// EnqueueJob adds a job to a queue
-func (c *Client) EnqueueJob(ctx context.Context, queue string, job interface{}) error {
-	data, err := json.Marshal(job)
-	if err != nil {
-		return fmt.Errorf("failed to marshal job: %w", err)
-	}
-
-	if err := c.LPush(ctx, queue, data).Err(); err != nil {
-		return fmt.Errorf("failed to enqueue job: %w", err)
-	}
-
-	logger.Debug("Job enqueued successfully",
-		zap.String("queue", queue),
-		zap.Any("job", job))
-	return nil
-}
-
-// DequeueJob removes and returns a job from a queue (blocking)
-func (c *Client) DequeueJob(ctx context.Context, queue string, timeout time.Duration, dest interface{}) error {
-	result, err := c.BRPop(ctx, timeout, queue).Result()
-	if err != nil {
-		if err == redis.Nil {
-			return fmt.Errorf("no job available in queue: %s", queue)
-		}
-		return fmt.Errorf("failed to dequeue job: %w", err)
-	}
-
-	if len(result) < 2 {
-		return fmt.Errorf("invalid job result from queue")
-	}
-
-	if err := json.Unmarshal([]byte(result[1]), dest); err != nil {
-		return fmt.Errorf("failed to unmarshal job: %w", err)
-	}
-
-	logger.Debug("Job dequeued successfully", zap.String("queue", queue))
-	return nil
-}
-
-// QueueLength returns the number of jobs in a queue
-func (c *Client) QueueLength(ctx context.Context, queue string) (int64, error) {
-	length, err := c.LLen(ctx, queue).Result()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get queue length: %w", err)
-	}
-
-	return length, nil
-}
-
 // Session storage operations
 
 // SetSession stores session data
@@ -170,6 +129,22 @@ func (c *Client) GetSession(ctx context.Context, sessionID string, dest interfac
 	return c.GetCache(ctx, key, dest)
 }
 
+// TouchSession replaces session data and its expiration only while the
+// session exists, reporting whether it did, so a session deleted meanwhile
+// is not restored
+func (c *Client) TouchSession(ctx context.Context, sessionID string, data interface{}, expiration time.Duration) (bool, error) {
+	value, err := json.Marshal(data)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal session: %w", err)
+	}
+	key := fmt.Sprintf("session:%s", sessionID)
+	ok, err := c.SetXX(ctx, key, value, expiration).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to touch session: %w", err)
+	}
+	return ok, nil
+}
+
 // DeleteSession removes session data
 func (c *Client) DeleteSession(ctx context.Context, sessionID string) error {
 	key := fmt.Sprintf("session:%s", sessionID)
@@ -180,19 +155,20 @@ func (c *Client) DeleteSession(ctx context.Context, sessionID string) error {
 
 // SetMarketData caches market data with appropriate TTL
 func (c *Client) SetMarketData(ctx context.Context, symbol string, data interface{}) error {
-	key := fmt.Sprintf("market:%s", symbol)
+	key := fmt.Sprintf("market:%s", symbols.Normalize(symbol))
 	// Market data expires after 1 minute for real-time updates
 	return c.SetCache(ctx, key, data, time.Minute)
 }
 
 // GetMarketData retrieves cached market data
 func (c *Client) GetMarketData(ctx context.Context, symbol string, dest interface{}) error {
-	key := fmt.Sprintf("market:%s", symbol)
+	key := fmt.Sprintf("market:%s", symbols.Normalize(symbol))
 	return c.GetCache(ctx, key, dest)
 }
 
 // SetPriceAlert sets a price alert for a symbol
 func (c *Client) SetPriceAlert(ctx context.Context, userID int, symbol string, price float64) error {
+	symbol = symbols.Normalize(symbol)
 	key := fmt.Sprintf("alert:%d:%s", userID, symbol)
 	alertData := map[string]interface{}{
 		"user_id": userID,
@@ -250,16 +226,6 @@ func (c *Client) SubscribeToEvents(ctx context.Context, channel string) *redis.P
 
 // Utility functions
 
-// FlushCache clears all cache data (use with caution)
-func (c *Client) FlushCache(ctx context.Context) error {
-	if err := c.FlushDB(ctx).Err(); err != nil {
-		return fmt.Errorf("failed to flush cache: %w", err)
-	}
-
-	logger.Warn("Cache flushed - all data cleared")
-	return nil
-}
-
 // Close closes the Redis connection
 func (c *Client) Close() error {
 	logger.Info("Closing Redis connection")
@@ -0,0 +1,278 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/logger"
+)
+
+// Job Queue operations
+//
+// Each queue is a Redis stream read through a consumer group. A job stays in
+// the group's pending entries list from delivery until it is acknowledged, so
+// jobs held by a crashed worker can be claimed by another one.
+
+// streamJobField is the stream entry field holding the JSON-encoded job
+const streamJobField = "job"
+
+// StreamEntry is a job delivered from a queue stream. It must be acknowledged
+// with AckJob once it has been processed.
+type StreamEntry struct {
+	Stream     string
+	ID         string
+	Data       []byte
+	Deliveries int64 // Times the entry has been delivered, set for claimed entries
+}
+
+// EnqueueJob appends a job to a queue stream
+func (c *Client) EnqueueJob(ctx context.Context, queue string, job interface{}) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	err = c.XAdd(ctx, &redis.XAddArgs{
+		Stream: queue,
+		Values: map[string]interface{}{streamJobField: data},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	logger.Debug("Job enqueued successfully",
+		zap.String("queue", queue),
+		zap.Any("job", job))
+	return nil
+}
+
+// EnsureConsumerGroup creates the consumer group of a queue stream if it does
+// not exist. A new group starts at the beginning of the stream so jobs
+// enqueued before any worker started are delivered.
+func (c *Client) EnsureConsumerGroup(ctx context.Context, queue, group string) error {
+	err := c.XGroupCreateMkStream(ctx, queue, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+	return nil
+}
+
+// ReadJobs returns the next job not yet delivered to the group from the
+// first of streams that has one, so streams are listed in order of
+// preference. When all are empty it blocks for up to timeout, and may then
+// return one job from each stream that received one. It returns nil when none
+// arrived.
+func (c *Client) ReadJobs(ctx context.Context, streams []string, group, consumer string, timeout time.Duration) ([]StreamEntry, error) {
+	for _, stream := range streams {
+		entries, err := c.readGroup(ctx, []string{stream}, group, consumer, -1)
+		if err != nil || len(entries) > 0 {
+			return entries, err
+		}
+	}
+	return c.readGroup(ctx, streams, group, consumer, timeout)
+}
+
+// readGroup reads at most one new entry per stream; a negative block does not wait
+func (c *Client) readGroup(ctx context.Context, streams []string, group, consumer string, block time.Duration) ([]StreamEntry, error) {
+	args := make([]string, 0, 2*len(streams))
+	args = append(args, streams...)
+	for range streams {
+		args = append(args, ">")
+	}
+
+	result, err := c.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  args,
+		Count:    1,
+		Block:    block,
+	}).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read job: %w", err)
+	}
+
+	// Keep the caller's stream order regardless of the order Redis replied in
+	byStream := make(map[string][]redis.XMessage, len(result))
+	for _, stream := range result {
+		byStream[stream.Stream] = stream.Messages
+	}
+
+	var entries []StreamEntry
+	for _, stream := range streams {
+		for _, message := range byStream[stream] {
+			entry := toStreamEntry(stream, message)
+			entry.Deliveries = 1
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// AckJob acknowledges a processed job and removes it from the stream
+func (c *Client) AckJob(ctx context.Context, queue, group, id string) error {
+	_, err := c.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, queue, group, id)
+		pipe.XDel(ctx, queue, id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge job: %w", err)
+	}
+	return nil
+}
+
+// ClaimStaleJobs transfers up to count jobs that have been pending for longer
+// than minIdle, i.e. whose worker died or hung, to consumer
+func (c *Client) ClaimStaleJobs(ctx context.Context, queue, group, consumer string, minIdle time.Duration, count int64) ([]StreamEntry, error) {
+	pending, err := c.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: queue,
+		Group:  group,
+		Idle:   minIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  count,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending jobs: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(pending))
+	deliveries := make(map[string]int64, len(pending))
+	for i, p := range pending {
+		ids[i] = p.ID
+		deliveries[p.ID] = p.RetryCount + 1 // Claiming is another delivery
+	}
+
+	// MinIdle is checked again, so an entry acknowledged or claimed by
+	// another worker in the meantime is skipped
+	messages, err := c.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   queue,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pending jobs: %w", err)
+	}
+
+	entries := make([]StreamEntry, 0, len(messages))
+	for _, message := range messages {
+		entry := toStreamEntry(queue, message)
+		entry.Deliveries = deliveries[message.ID]
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// QueueLength returns the number of unacknowledged jobs in a queue, including
+// jobs currently being processed
+func (c *Client) QueueLength(ctx context.Context, queue string) (int64, error) {
+	length, err := c.XLen(ctx, queue).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queue length: %w", err)
+	}
+
+	return length, nil
+}
+
+// PendingJobs returns the number of jobs in a queue delivered to a worker of
+// the group and not yet acknowledged. A queue no worker has read yet has none.
+func (c *Client) PendingJobs(ctx context.Context, queue, group string) (int64, error) {
+	pending, err := c.XPending(ctx, queue, group).Result()
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "NOGROUP") {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get pending jobs: %w", err)
+	}
+	return pending.Count, nil
+}
+
+// OldestJob returns when the oldest unacknowledged job of a queue was
+// enqueued, from its entry ID. ok is false when the queue is empty.
+func (c *Client) OldestJob(ctx context.Context, queue string) (enqueuedAt time.Time, ok bool, err error) {
+	messages, err := c.XRangeN(ctx, queue, "-", "+", 1).Result()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get oldest job: %w", err)
+	}
+	if len(messages) == 0 {
+		return time.Time{}, false, nil
+	}
+
+	enqueuedAt, err = streamIDTime(messages[0].ID)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return enqueuedAt, true, nil
+}
+
+// streamIDTime returns the time a stream entry was added, the milliseconds
+// part of its ID
+func streamIDTime(id string) (time.Time, error) {
+	ms, _, _ := strings.Cut(id, "-")
+	millis, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid stream entry ID %q", id)
+	}
+	return time.UnixMilli(millis), nil
+}
+
+// MigrateListQueue moves jobs left in a queue from before queues were
+// streams (a list at the same key) onto the stream, oldest first
+func (c *Client) MigrateListQueue(ctx context.Context, queue string) (int, error) {
+	legacy := queue + ":legacy"
+
+	keyType, err := c.Type(ctx, queue).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check queue type: %w", err)
+	}
+	if keyType == "list" {
+		// Another worker may have renamed it first
+		if err := c.Rename(ctx, queue, legacy).Err(); err != nil && !strings.Contains(err.Error(), "no such key") {
+			return 0, fmt.Errorf("failed to move legacy queue: %w", err)
+		}
+	}
+
+	moved := 0
+	for {
+		// Jobs were pushed on the left and popped from the right
+		data, err := c.RPop(ctx, legacy).Bytes()
+		if err == redis.Nil {
+			return moved, nil
+		}
+		if err != nil {
+			return moved, fmt.Errorf("failed to read legacy job: %w", err)
+		}
+
+		err = c.XAdd(ctx, &redis.XAddArgs{
+			Stream: queue,
+			Values: map[string]interface{}{streamJobField: data},
+		}).Err()
+		if err != nil {
+			c.RPush(ctx, legacy, data) // Put it back for the next attempt
+			return moved, fmt.Errorf("failed to migrate legacy job: %w", err)
+		}
+		moved++
+	}
+}
+
+func toStreamEntry(stream string, message redis.XMessage) StreamEntry {
+	entry := StreamEntry{Stream: stream, ID: message.ID}
+	if data, ok := message.Values[streamJobField].(string); ok {
+		entry.Data = []byte(data)
+	}
+	return entry
+}
@@ -0,0 +1,18 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamIDTime(t *testing.T) {
+	at, err := streamIDTime("1710500000123-4")
+	require.NoError(t, err)
+	assert.Equal(t, time.UnixMilli(1710500000123), at)
+
+	_, err = streamIDTime("not-an-id")
+	assert.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"hedge-fund/pkg/shared/httpclient"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+// Client is an HTTP client bound to a single downstream service. Each request
+// is sent to an endpoint selected by the registry through the registry's
+// resilient HTTP client; transport errors and 5xx responses mark the
+// endpoint as failed.
+type Client struct {
+	service  string
+	registry *Registry
+	timeout  time.Duration
+}
+
+// NewClient creates a client for a registered service. timeout bounds each
+// request, retries included.
+func (r *Registry) NewClient(service string, timeout time.Duration) *Client {
+	return &Client{
+		service:  service,
+		registry: r,
+		timeout:  timeout,
+	}
+}
+
+// Service returns the name of the service this client talks to
+func (c *Client) Service() string {
+	return c.service
+}
+
+// Do sends a JSON request and decodes the JSON response into dest (if non-nil)
+func (c *Client) Do(ctx context.Context, method, path string, body interface{}, dest interface{}) error {
+	baseURL, err := c.registry.Endpoint(c.service)
+	if err != nil {
+		return err
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	requestctx.SetHeaders(ctx, req.Header)
+
+	resp, err := c.registry.http.Do(req)
+	if err != nil {
+		if !errors.Is(err, httpclient.ErrCircuitOpen) {
+			c.registry.MarkFailure(c.service, baseURL)
+		}
+		return fmt.Errorf("request to %s failed: %w", c.service, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		c.registry.MarkFailure(c.service, baseURL)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned status %d: %s", c.service, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	if dest != nil {
+		if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+			return fmt.Errorf("failed to decode response from %s: %w", c.service, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,287 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/httpclient"
+	"hedge-fund/pkg/shared/logger"
+)
+
+// Service names
+const (
+	ServicePortfolio  = "portfolio-service"
+	ServiceRisk       = "risk-service"
+	ServiceMarketData = "market-data-service"
+	ServiceAI         = "ai-service"
+)
+
+// ProbeWindow is how many recent health probes per service availability is
+// measured over
+const ProbeWindow = 240
+
+// Endpoint is a single instance of a service
+type Endpoint struct {
+	URL         string    `json:"url"`
+	Healthy     bool      `json:"healthy"`
+	Failures    int       `json:"failures"`
+	LastChecked time.Time `json:"last_checked"`
+}
+
+// Registry tracks the endpoints of downstream services and selects healthy
+// instances for clients. Endpoints come from static configuration and are
+// optionally refreshed from DNS or Consul.
+type Registry struct {
+	static     StaticResolver
+	resolver   Resolver
+	httpClient *http.Client
+	http       *httpclient.Client // Requests of service clients
+	healthPath string
+
+	mu        sync.RWMutex
+	endpoints map[string][]*Endpoint
+	next      map[string]int
+	probes    map[string][]bool // Recent probe outcomes per service, oldest first
+}
+
+// New creates a registry from configuration, seeded with the static endpoints
+func New(cfg *config.Config) *Registry {
+	static := StaticResolver{
+		ServicePortfolio:  splitURLs(cfg.PortfolioServiceURL),
+		ServiceRisk:       splitURLs(cfg.RiskServiceURL),
+		ServiceMarketData: splitURLs(cfg.MarketDataServiceURL),
+		ServiceAI:         splitURLs(cfg.AIServiceURL),
+	}
+
+	var resolver Resolver
+	switch cfg.ServiceDiscovery {
+	case "dns":
+		resolver = NewDNSResolver(cfg.DNSDomain)
+	case "consul":
+		resolver = NewConsulResolver(cfg.ConsulAddr)
+	}
+
+	r := &Registry{
+		static:     static,
+		resolver:   resolver,
+		httpClient: &http.Client{Timeout: 3 * time.Second},
+		http:       httpclient.New(httpclient.NewOptions(cfg), logger.Logger),
+		healthPath: "/readyz",
+		endpoints:  make(map[string][]*Endpoint),
+		next:       make(map[string]int),
+		probes:     make(map[string][]bool),
+	}
+
+	for service, urls := range static {
+		r.Register(service, urls...)
+	}
+
+	return r
+}
+
+// HTTPClient returns the resilient client service clients send requests
+// through, whose circuit breakers are per endpoint
+func (r *Registry) HTTPClient() *httpclient.Client {
+	return r.http
+}
+
+// Register replaces the known endpoints for a service. Health state is kept
+// for URLs that were already registered.
+func (r *Registry) Register(service string, urls ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing := make(map[string]*Endpoint)
+	for _, endpoint := range r.endpoints[service] {
+		existing[endpoint.URL] = endpoint
+	}
+
+	endpoints := make([]*Endpoint, 0, len(urls))
+	for _, url := range urls {
+		url = strings.TrimSuffix(url, "/")
+		if endpoint, ok := existing[url]; ok {
+			endpoints = append(endpoints, endpoint)
+			continue
+		}
+		endpoints = append(endpoints, &Endpoint{URL: url, Healthy: true})
+	}
+
+	r.endpoints[service] = endpoints
+}
+
+// Endpoint selects the next healthy endpoint for a service (round-robin)
+func (r *Registry) Endpoint(service string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	endpoints := r.endpoints[service]
+	if len(endpoints) == 0 {
+		return "", fmt.Errorf("unknown service: %s", service)
+	}
+
+	for i := 0; i < len(endpoints); i++ {
+		idx := (r.next[service] + i) % len(endpoints)
+		if endpoints[idx].Healthy {
+			r.next[service] = idx + 1
+			return endpoints[idx].URL, nil
+		}
+	}
+
+	return "", fmt.Errorf("no healthy endpoint for service: %s", service)
+}
+
+// Endpoints returns a snapshot of all endpoints for every known service
+func (r *Registry) Endpoints() map[string][]Endpoint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot := make(map[string][]Endpoint, len(r.endpoints))
+	for service, endpoints := range r.endpoints {
+		for _, endpoint := range endpoints {
+			snapshot[service] = append(snapshot[service], *endpoint)
+		}
+	}
+	return snapshot
+}
+
+// MarkFailure records a failed call so the endpoint is skipped until the next
+// successful health check
+func (r *Registry) MarkFailure(service, url string) {
+	r.setHealth(service, url, false)
+}
+
+// Refresh re-resolves all known services through the configured resolver.
+// Static endpoints are kept when dynamic resolution fails or returns nothing.
+func (r *Registry) Refresh(ctx context.Context) {
+	if r.resolver == nil {
+		return
+	}
+
+	for service := range r.static {
+		urls, err := r.resolver.Resolve(ctx, service)
+		if err != nil || len(urls) == 0 {
+			logger.Warn("Service resolution failed, keeping current endpoints",
+				zap.String("service", service),
+				zap.Error(err))
+			continue
+		}
+		r.Register(service, urls...)
+	}
+}
+
+// CheckHealth probes the health endpoint of every registered instance
+func (r *Registry) CheckHealth(ctx context.Context) {
+	for service, endpoints := range r.Endpoints() {
+		for _, endpoint := range endpoints {
+			healthy := r.probe(ctx, endpoint.URL)
+			r.setHealth(service, endpoint.URL, healthy)
+			r.recordProbe(service, healthy)
+		}
+	}
+}
+
+// Availability returns the fraction of a service's last ProbeWindow health
+// probes that succeeded, and how many probes that covers. A service that has
+// not been probed yet is reported fully available.
+func (r *Registry) Availability(service string) (float64, int) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	probes := r.probes[service]
+	if len(probes) == 0 {
+		return 1, 0
+	}
+	succeeded := 0
+	for _, healthy := range probes {
+		if healthy {
+			succeeded++
+		}
+	}
+	return float64(succeeded) / float64(len(probes)), len(probes)
+}
+
+// Start refreshes and health-checks endpoints on an interval until ctx is done
+func (r *Registry) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			r.Refresh(ctx)
+			r.CheckHealth(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Helper functions
+
+func (r *Registry) probe(ctx context.Context, url string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+r.healthPath, nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK
+}
+
+func (r *Registry) setHealth(service, url string, healthy bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, endpoint := range r.endpoints[service] {
+		if endpoint.URL != url {
+			continue
+		}
+		if !healthy {
+			endpoint.Failures++
+			if endpoint.Healthy {
+				logger.Warn("Service endpoint marked unhealthy",
+					zap.String("service", service),
+					zap.String("url", url))
+			}
+		} else {
+			endpoint.Failures = 0
+		}
+		endpoint.Healthy = healthy
+		endpoint.LastChecked = time.Now()
+	}
+}
+
+func (r *Registry) recordProbe(service string, healthy bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	probes := append(r.probes[service], healthy)
+	if len(probes) > ProbeWindow {
+		probes = probes[len(probes)-ProbeWindow:]
+	}
+	r.probes[service] = probes
+}
+
+func splitURLs(raw string) []string {
+	var urls []string
+	for _, url := range strings.Split(raw, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
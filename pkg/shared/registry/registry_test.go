@@ -0,0 +1,92 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/logger"
+)
+
+func newTestRegistry() *Registry {
+	return &Registry{
+		endpoints: make(map[string][]*Endpoint),
+		next:      make(map[string]int),
+		probes:    make(map[string][]bool),
+	}
+}
+
+func TestEndpointRoundRobinSkipsUnhealthy(t *testing.T) {
+	require.NoError(t, logger.Init("error", "test"))
+
+	r := newTestRegistry()
+	r.Register(ServiceRisk, "http://a:8082", "http://b:8082/", "http://c:8082")
+
+	first, err := r.Endpoint(ServiceRisk)
+	require.NoError(t, err)
+	assert.Equal(t, "http://a:8082", first)
+
+	r.MarkFailure(ServiceRisk, "http://b:8082")
+
+	second, err := r.Endpoint(ServiceRisk)
+	require.NoError(t, err)
+	assert.Equal(t, "http://c:8082", second)
+
+	third, err := r.Endpoint(ServiceRisk)
+	require.NoError(t, err)
+	assert.Equal(t, "http://a:8082", third)
+}
+
+func TestEndpointNoHealthyInstances(t *testing.T) {
+	require.NoError(t, logger.Init("error", "test"))
+
+	r := newTestRegistry()
+	r.Register(ServiceAI, "http://ai:8084")
+	r.MarkFailure(ServiceAI, "http://ai:8084")
+
+	_, err := r.Endpoint(ServiceAI)
+	assert.Error(t, err)
+
+	_, err = r.Endpoint("unknown-service")
+	assert.Error(t, err)
+}
+
+func TestRegisterKeepsHealthState(t *testing.T) {
+	require.NoError(t, logger.Init("error", "test"))
+
+	r := newTestRegistry()
+	r.Register(ServiceMarketData, "http://m1:8083", "http://m2:8083")
+	r.MarkFailure(ServiceMarketData, "http://m1:8083")
+
+	r.Register(ServiceMarketData, "http://m1:8083", "http://m3:8083")
+
+	endpoints := r.Endpoints()[ServiceMarketData]
+	require.Len(t, endpoints, 2)
+	assert.False(t, endpoints[0].Healthy)
+	assert.Equal(t, 1, endpoints[0].Failures)
+	assert.True(t, endpoints[1].Healthy)
+}
+
+func TestAvailabilityOverProbeWindow(t *testing.T) {
+	r := newTestRegistry()
+
+	availability, samples := r.Availability(ServiceRisk)
+	assert.Equal(t, 1.0, availability)
+	assert.Zero(t, samples)
+
+	r.recordProbe(ServiceRisk, false)
+	for i := 0; i < 3; i++ {
+		r.recordProbe(ServiceRisk, true)
+	}
+	availability, samples = r.Availability(ServiceRisk)
+	assert.Equal(t, 0.75, availability)
+	assert.Equal(t, 4, samples)
+
+	// The failure ages out of the window
+	for i := 0; i < ProbeWindow; i++ {
+		r.recordProbe(ServiceRisk, true)
+	}
+	availability, samples = r.Availability(ServiceRisk)
+	assert.Equal(t, 1.0, availability)
+	assert.Equal(t, ProbeWindow, samples)
+}
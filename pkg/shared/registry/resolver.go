@@ -0,0 +1,123 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Resolver looks up the base URLs of all instances of a service
+type Resolver interface {
+	Resolve(ctx context.Context, service string) ([]string, error)
+}
+
+// StaticResolver resolves services from a fixed map of base URLs
+type StaticResolver map[string][]string
+
+// Resolve returns the configured URLs for a service
+func (s StaticResolver) Resolve(ctx context.Context, service string) ([]string, error) {
+	urls, ok := s[service]
+	if !ok || len(urls) == 0 {
+		return nil, fmt.Errorf("no static endpoints configured for service: %s", service)
+	}
+	return urls, nil
+}
+
+// DNSResolver resolves services through SRV records (_http._tcp.<service>.<domain>),
+// which is how headless Kubernetes services expose their pods
+type DNSResolver struct {
+	Domain   string
+	resolver *net.Resolver
+}
+
+func NewDNSResolver(domain string) *DNSResolver {
+	return &DNSResolver{
+		Domain:   domain,
+		resolver: net.DefaultResolver,
+	}
+}
+
+// Resolve returns one URL per SRV target
+func (d *DNSResolver) Resolve(ctx context.Context, service string) ([]string, error) {
+	name := service
+	if d.Domain != "" {
+		name = service + "." + d.Domain
+	}
+
+	_, records, err := d.resolver.LookupSRV(ctx, "http", "tcp", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve SRV records for %s: %w", name, err)
+	}
+
+	urls := make([]string, 0, len(records))
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		urls = append(urls, fmt.Sprintf("http://%s:%d", host, record.Port))
+	}
+
+	return urls, nil
+}
+
+// ConsulResolver resolves services through the Consul health API, returning
+// only instances whose checks are passing
+type ConsulResolver struct {
+	Addr       string
+	httpClient *http.Client
+}
+
+func NewConsulResolver(addr string) *ConsulResolver {
+	return &ConsulResolver{
+		Addr:       strings.TrimSuffix(addr, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type consulServiceEntry struct {
+	Node struct {
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		Address string `json:"Address"`
+		Port    int    `json:"Port"`
+	} `json:"Service"`
+}
+
+// Resolve returns one URL per passing Consul service instance
+func (c *ConsulResolver) Resolve(ctx context.Context, service string) ([]string, error) {
+	url := fmt.Sprintf("%s/v1/health/service/%s?passing=true", c.Addr, service)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consul request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query consul: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("consul returned status %d for service %s", resp.StatusCode, service)
+	}
+
+	var entries []consulServiceEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to decode consul response: %w", err)
+	}
+
+	urls := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		// Service address falls back to the node address when not set
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+		urls = append(urls, fmt.Sprintf("http://%s:%d", host, entry.Service.Port))
+	}
+
+	return urls, nil
+}
@@ -0,0 +1,32 @@
+package requestctx
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Middleware stores the request ID, the caller's credentials and the acting
+// user it claims on the request context.
+// A request ID sent by the caller, such as the gateway, is kept so one ID
+// follows a request across services; otherwise one is generated. It is
+// echoed in the X-Request-ID response header.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(HeaderRequestID)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		c.Writer.Header().Set(HeaderRequestID, requestID)
+
+		ctx := WithRequestID(c.Request.Context(), requestID)
+		if authorization := c.GetHeader(HeaderAuth); authorization != "" {
+			ctx = WithAuthorization(ctx, authorization)
+		}
+		if actor := c.GetHeader(HeaderActor); actor != "" {
+			ctx = WithActor(ctx, actor)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
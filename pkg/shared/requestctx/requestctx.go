@@ -0,0 +1,75 @@
+package requestctx
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "request_id"
+	actorKey     contextKey = "actor"
+	authKey      contextKey = "authorization"
+
+	// HTTP headers used to propagate request metadata between services
+	HeaderRequestID = "X-Request-ID"
+	HeaderActor     = "X-User-ID"
+	HeaderAuth      = "Authorization"
+
+	// SystemActor is used for mutations not triggered by a user request
+	SystemActor = "system"
+)
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if none
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithActor returns a copy of ctx carrying the acting user
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// Actor returns the acting user carried by ctx, or SystemActor if none
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
+
+// WithAuthorization returns a copy of ctx carrying the caller's
+// Authorization header
+func WithAuthorization(ctx context.Context, authorization string) context.Context {
+	return context.WithValue(ctx, authKey, authorization)
+}
+
+// Authorization returns the caller's Authorization header carried by ctx, or
+// "" if none
+func Authorization(ctx context.Context) string {
+	authorization, _ := ctx.Value(authKey).(string)
+	return authorization
+}
+
+// SetHeaders copies the request ID, the caller's credentials and the acting
+// user carried by ctx onto an outgoing request to another service. Services
+// authenticate the forwarded credentials; the acting user alone is trusted
+// only on calls authenticated as a service.
+func SetHeaders(ctx context.Context, header http.Header) {
+	if requestID := RequestID(ctx); requestID != "" {
+		header.Set(HeaderRequestID, requestID)
+	}
+	if authorization := Authorization(ctx); authorization != "" {
+		header.Set(HeaderAuth, authorization)
+	}
+	if actor := Actor(ctx); actor != SystemActor {
+		header.Set(HeaderActor, actor)
+	}
+}
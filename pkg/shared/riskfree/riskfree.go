@@ -0,0 +1,105 @@
+package riskfree
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/models"
+)
+
+// Sources of the risk-free rate
+const (
+	SourceConstant = "constant" // RISK_FREE_RATE for every period
+	SourceTreasury = "treasury" // Treasury yields stored by the market data service
+)
+
+// Source resolves the annual risk-free rate for an analysis period, from the
+// stored yield curve or a configured constant. Excess returns in Sharpe-style
+// metrics are taken over it.
+type Source struct {
+	db       *database.DB
+	curve    bool
+	tenor    string
+	constant float64
+}
+
+// NewSource creates a risk-free rate source from RISK_FREE_RATE_SOURCE,
+// RISK_FREE_RATE and RISK_FREE_RATE_TENOR. db may be nil for a constant
+// source.
+func NewSource(db *database.DB, cfg *config.Config) *Source {
+	return &Source{
+		db:       db,
+		curve:    cfg.RiskFreeRateSource == SourceTreasury && db != nil,
+		tenor:    cfg.RiskFreeRateTenor,
+		constant: cfg.RiskFreeRate,
+	}
+}
+
+// Rate returns the annual risk-free rate for the period from start to end:
+// the average of the curve's daily rates over it. Before any rates are
+// stored it is the configured constant.
+func (s *Source) Rate(ctx context.Context, start, end time.Time) (float64, error) {
+	if !s.curve {
+		return s.constant, nil
+	}
+
+	// Rates in the period, and the last one before it for periods that
+	// start and end between observations (weekends, holidays)
+	query := `
+		SELECT tenor, rate_date, rate, source
+		FROM risk_free_rates
+		WHERE tenor = $1 AND rate_date <= $3 AND rate_date >= COALESCE(
+			(SELECT MAX(rate_date) FROM risk_free_rates WHERE tenor = $1 AND rate_date <= $2), $2)
+		ORDER BY rate_date`
+
+	rows, err := s.db.QueryContext(ctx, query, s.tenor, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get risk-free rates: %w", err)
+	}
+	defer rows.Close()
+
+	var curve []models.RiskFreeRate
+	for rows.Next() {
+		var rate models.RiskFreeRate
+		if err := rows.Scan(&rate.Tenor, &rate.Date, &rate.Rate, &rate.Source); err != nil {
+			return 0, fmt.Errorf("failed to scan risk-free rate: %w", err)
+		}
+		curve = append(curve, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating risk-free rates: %w", err)
+	}
+
+	if rate, ok := PeriodRate(curve, start, end); ok {
+		return rate, nil
+	}
+	return s.constant, nil
+}
+
+// PeriodRate averages the rates of a curve, in date order, dated from start
+// to end. A period without any uses the last rate before it. It reports false
+// when the curve has neither.
+func PeriodRate(curve []models.RiskFreeRate, start, end time.Time) (float64, bool) {
+	sum, count := 0.0, 0
+	var before *models.RiskFreeRate
+	for i := range curve {
+		switch {
+		case curve[i].Date.Before(start):
+			before = &curve[i]
+		case !curve[i].Date.After(end):
+			sum += curve[i].Rate
+			count++
+		}
+	}
+
+	if count > 0 {
+		return sum / float64(count), true
+	}
+	if before != nil {
+		return before.Rate, true
+	}
+	return 0, false
+}
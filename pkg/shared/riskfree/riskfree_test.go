@@ -0,0 +1,44 @@
+package riskfree
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/models"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPeriodRateAveragesRatesInPeriod(t *testing.T) {
+	curve := []models.RiskFreeRate{
+		{Date: day(1), Rate: 0.050},
+		{Date: day(4), Rate: 0.052},
+		{Date: day(5), Rate: 0.054},
+		{Date: day(8), Rate: 0.060},
+	}
+
+	rate, ok := PeriodRate(curve, day(2), day(6))
+	assert.True(t, ok)
+	assert.InDelta(t, 0.053, rate, 1e-9)
+
+	// A weekend between observations uses the last rate before it
+	rate, ok = PeriodRate(curve, day(6), day(7))
+	assert.True(t, ok)
+	assert.Equal(t, 0.054, rate)
+
+	_, ok = PeriodRate(nil, day(1), day(2))
+	assert.False(t, ok)
+}
+
+func TestConstantSource(t *testing.T) {
+	source := NewSource(nil, &config.Config{RiskFreeRateSource: SourceTreasury, RiskFreeRate: 0.04})
+
+	rate, err := source.Rate(context.Background(), day(1), day(31))
+	assert.NoError(t, err)
+	assert.Equal(t, 0.04, rate)
+}
@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/requestctx"
+)
+
+const (
+	testToken       = "service-secret"
+	executeTrade    = "/hedgefund.portfolio.v1.PortfolioService/ExecuteTrade"
+	getPortfolio    = "/hedgefund.portfolio.v1.PortfolioService/GetPortfolio"
+	listTradeEvents = "/hedgefund.portfolio.v1.PortfolioService/ListTradeEvents"
+)
+
+// call runs interceptor on a call to method with metadata pairs, returning
+// the actor the handler saw
+func call(interceptor grpc.UnaryServerInterceptor, method string, pairs ...string) (string, error) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+	var actor string
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		actor = requestctx.Actor(ctx)
+		return nil, nil
+	})
+	return actor, err
+}
+
+func TestAuthenticate(t *testing.T) {
+	require.NoError(t, logger.Init("error", "test"))
+	interceptor := Authenticate(testToken)
+
+	actor, err := call(interceptor, executeTrade, serviceTokenKey, testToken, actorKey, "7")
+	require.NoError(t, err)
+	assert.Equal(t, "7", actor)
+
+	// Calls naming no user act as the calling service
+	actor, err = call(interceptor, executeTrade, serviceTokenKey, testToken)
+	require.NoError(t, err)
+	assert.Equal(t, requestctx.SystemActor, actor)
+
+	for name, pairs := range map[string][]string{
+		"missing token": {actorKey, "7"},
+		"wrong token":   {serviceTokenKey, "guess", actorKey, "7"},
+	} {
+		_, err := call(interceptor, executeTrade, pairs...)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), name)
+	}
+
+	// With no token set, no call is trusted
+	_, err = call(Authenticate(""), getPortfolio, serviceTokenKey, "")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestReadOnly(t *testing.T) {
+	readOnly := true
+	interceptor := ReadOnly(func(context.Context) bool { return readOnly })
+
+	_, err := call(interceptor, executeTrade)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	for _, method := range []string{getPortfolio, listTradeEvents} {
+		_, err := call(interceptor, method)
+		assert.NoError(t, err, method)
+	}
+
+	readOnly = false
+	_, err = call(interceptor, executeTrade)
+	assert.NoError(t, err)
+}
+
+func TestServiceTokenCredentials(t *testing.T) {
+	md, err := serviceToken(testToken).GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{serviceTokenKey: testToken}, md)
+}
+
+func TestSecondFactorMetadata(t *testing.T) {
+	ctx := WithSecondFactor(context.Background(), "123456", "")
+	md, _ := metadata.FromOutgoingContext(ctx)
+	code, device := SecondFactor(metadata.NewIncomingContext(context.Background(), md))
+	assert.Equal(t, "123456", code)
+	assert.Empty(t, device)
+}
@@ -0,0 +1,88 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"hedge-fund/pkg/shared/config"
+)
+
+func testRouter(cfg *config.Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Headers(cfg), CORS(cfg))
+	r.GET("/api/v1/portfolios", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func request(r *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/v1/portfolios", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSListedOrigins(t *testing.T) {
+	r := testRouter(&config.Config{
+		CORSAllowedOrigins:   "https://app.example.com, https://admin.example.com",
+		CORSAllowedMethods:   "GET,POST",
+		CORSAllowedHeaders:   "Content-Type,X-User-ID",
+		CORSAllowCredentials: true,
+		CORSMaxAge:           600,
+	})
+
+	w := request(r, http.MethodOptions, "https://app.example.com")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, X-User-ID", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+
+	w = request(r, http.MethodGet, "https://admin.example.com")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://admin.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "X-Request-ID")
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+
+	// Unlisted origins get no CORS headers, and their preflights are refused
+	w = request(r, http.MethodGet, "https://evil.example.com")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, http.StatusForbidden, request(r, http.MethodOptions, "https://evil.example.com").Code)
+
+	// Same-origin and non-browser requests are untouched
+	w = request(r, http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Vary"))
+}
+
+func TestCORSAnyOriginNeverAllowsCredentials(t *testing.T) {
+	r := testRouter(&config.Config{CORSAllowedOrigins: "*", CORSAllowCredentials: true})
+
+	w := request(r, http.MethodOptions, "https://app.example.com")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Empty(t, w.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestHeaders(t *testing.T) {
+	w := request(testRouter(&config.Config{HSTSMaxAge: 31536000}), http.MethodGet, "")
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+	assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+
+	w = request(testRouter(&config.Config{}), http.MethodGet, "")
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+}
@@ -0,0 +1,289 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/database"
+	"hedge-fund/pkg/shared/health"
+	"hedge-fund/pkg/shared/leader"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/maintenance"
+	"hedge-fund/pkg/shared/queue"
+	"hedge-fund/pkg/shared/redis"
+	"hedge-fund/pkg/shared/requestctx"
+	"hedge-fund/pkg/shared/rpc"
+	"hedge-fund/pkg/shared/security"
+)
+
+const (
+	// shutdownTimeout bounds how long in-flight HTTP requests get to finish
+	shutdownTimeout = 10 * time.Second
+
+	defaultWriteTimeout = 15 * time.Second
+)
+
+// Options describe a service to New
+type Options struct {
+	Name         string        // For logs, e.g. "Portfolio Service"
+	HealthName   string        // Reported by the health probes, e.g. "portfolio-service"
+	Port         string        // HTTP port
+	GRPCPort     string        // gRPC port; internal calls are not served when empty
+	WriteTimeout time.Duration // Of HTTP responses; 15s when zero
+	NoDatabase   bool          // Skip the database connection (the gateway only uses Redis)
+
+	// MaintenanceExempt lists path prefixes that still take writes during
+	// maintenance, besides the maintenance, queue pause and resume, and auth
+	// APIs
+	MaintenanceExempt []string
+
+	// Recovery handles panics in handlers; gin.Recovery() when nil
+	Recovery gin.HandlerFunc
+}
+
+// App is a service being bootstrapped. New connects its backends and sets up
+// the router with the common middleware, health probes and metrics; the
+// service then registers its routes, workers and scheduled work, and Run
+// serves until shutdown.
+type App struct {
+	Config *config.Config
+	DB     *database.DB // nil with Options.NoDatabase
+	Redis  *redis.Client
+	Queue  *queue.Manager
+	Router *gin.Engine
+	Health *health.Checker
+	GRPC   *grpc.Server // nil without Options.GRPCPort
+
+	// Maintenance is the shared read-only switch of maintenance windows
+	Maintenance *maintenance.Manager
+
+	opts         Options
+	poolConfig   queue.PoolConfig
+	metrics      *Metrics
+	scheduleCtx  context.Context
+	stopSchedule context.CancelFunc
+	closers      []func()
+}
+
+// New connects the service's backends and creates its router. ctx bounds
+// the service's scheduled work. Close releases what New acquired.
+func New(ctx context.Context, cfg *config.Config, opts Options) (*App, error) {
+	if opts.WriteTimeout == 0 {
+		opts.WriteTimeout = defaultWriteTimeout
+	}
+	if opts.Recovery == nil {
+		opts.Recovery = gin.Recovery()
+	}
+
+	logger.Info("Starting "+opts.Name,
+		zap.String("env", cfg.Env),
+		zap.String("port", opts.Port),
+	)
+
+	a := &App{Config: cfg, opts: opts}
+	if err := a.connect(); err != nil {
+		a.Close()
+		return nil, err
+	}
+	a.scheduleCtx, a.stopSchedule = context.WithCancel(ctx)
+	a.Maintenance = maintenance.NewManager(a.Redis, cfg)
+
+	// Stuck jobs of every service, reaped by one replica of any
+	a.Lead("job-reaper", a.Queue.RunReaper)
+
+	// Common middleware (order matters!)
+	if cfg.Env == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	a.metrics = NewMetrics(opts.HealthName, a.Queue.WorkerStats)
+	a.metrics.reaped = a.Queue.ReapedJobs
+	if a.DB != nil {
+		a.metrics.database = a.DB
+		a.Go(a.DB.Monitor) // Logs lost and restored connectivity, and keeps db_up current
+	}
+	a.Router = gin.New()
+	a.Router.Use(security.Headers(cfg))
+	a.Router.Use(security.CORS(cfg))
+	a.Router.Use(requestctx.Middleware()) // Request ID and actor for logs, errors and downstream calls
+	a.Router.Use(logger.Middleware())
+	a.Router.Use(a.metrics.Middleware())
+	a.Router.Use(opts.Recovery)
+
+	// Liveness and readiness probes, and request and worker metrics
+	a.Health = health.NewChecker(opts.HealthName, cfg, logger.Logger)
+	if a.DB != nil {
+		a.Health.Require("database", a.DB.HealthContext)
+	}
+	a.Health.Require("redis", a.Redis.HealthContext)
+	a.Health.Register(a.Router)
+	a.Router.GET("/metrics", a.metrics.GetMetrics)
+
+	// Routes the service registers are rate limited; the probes and metrics
+	// above are not
+	a.Router.Use(newRateLimiter(func() int { return cfg.Tunables().RateLimit }).Middleware())
+
+	// Writes are refused during maintenance, except to the admin APIs that
+	// end it and pause or resume queues, and to sign-in
+	exempt := append([]string{"/api/v1/admin/maintenance", "/api/v1/admin/queues/", "/api/v1/auth/"}, opts.MaintenanceExempt...)
+	a.Router.Use(a.Maintenance.ReadOnlyMiddleware(exempt...))
+
+	// Internal calls must carry the service token, and may not write during
+	// maintenance
+	if opts.GRPCPort != "" {
+		a.GRPC = rpc.NewServer(grpc.ChainUnaryInterceptor(
+			rpc.Authenticate(cfg.ServiceToken),
+			rpc.ReadOnly(a.Maintenance.IsReadOnly),
+		))
+	}
+	return a, nil
+}
+
+// connect opens the database, Redis and job queue connections
+func (a *App) connect() error {
+	if !a.opts.NoDatabase {
+		db, err := database.Connect(a.Config)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		a.DB = db
+		if err := db.Health(); err != nil {
+			return fmt.Errorf("database health check failed: %w", err)
+		}
+	}
+
+	redisClient, err := redis.Connect(a.Config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	a.Redis = redisClient
+	if err := redisClient.Health(); err != nil {
+		return fmt.Errorf("redis health check failed: %w", err)
+	}
+
+	poolConfig, err := queue.NewPoolConfig(a.Config)
+	if err != nil {
+		return fmt.Errorf("invalid worker pool configuration: %w", err)
+	}
+	a.poolConfig = poolConfig
+	a.Queue = queue.NewManager(redisClient)
+	return nil
+}
+
+// Workers creates a pool of workers of queueName sized by the worker pool
+// configuration. StartWorkers starts it.
+func (a *App) Workers(queueName string, handler queue.JobHandler) *queue.Pool {
+	return a.Queue.NewPool(queueName, handler, a.poolConfig)
+}
+
+// StartWorkers starts pool, which is stopped by Close
+func (a *App) StartWorkers(pool *queue.Pool) error {
+	if err := pool.Start(); err != nil {
+		return err
+	}
+	a.OnClose(pool.Stop)
+	return nil
+}
+
+// Go runs fn in the background until the service shuts down
+func (a *App) Go(fn func(ctx context.Context)) {
+	go fn(a.scheduleCtx)
+}
+
+// Lead runs fn on whichever replica holds the named leadership, until the
+// service shuts down and hands leadership to another replica
+func (a *App) Lead(name string, fn func(ctx context.Context)) {
+	elector := leader.NewElector(a.Redis, name, time.Duration(a.Config.LeaderLeaseTTL)*time.Second)
+	go elector.Run(a.scheduleCtx, fn)
+}
+
+// OnClose registers fn to run on Close, before the connections are closed.
+// Functions run in the reverse order of registration.
+func (a *App) OnClose(fn func()) {
+	a.closers = append(a.closers, fn)
+}
+
+// Run serves HTTP, and gRPC when configured, until ctx is cancelled. It then
+// stops the scheduled work, lets in-flight calls and jobs settle and shuts
+// the HTTP server down gracefully.
+func (a *App) Run(ctx context.Context) error {
+	if a.GRPC != nil {
+		if err := rpc.Serve(a.GRPC, a.opts.GRPCPort); err != nil {
+			a.drain()
+			return fmt.Errorf("failed to start gRPC server: %w", err)
+		}
+	}
+
+	srv := &http.Server{
+		Addr:         ":" + a.opts.Port,
+		Handler:      a.Router,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: a.opts.WriteTimeout,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	failed := make(chan error, 1)
+	go func() {
+		logger.Info(a.opts.Name+" listening", zap.String("port", a.opts.Port))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			failed <- err
+		}
+	}()
+
+	select {
+	case err := <-failed:
+		a.drain()
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutting down " + a.opts.Name + "...")
+	a.drain()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("server forced to shutdown: %w", err)
+	}
+
+	logger.Info(a.opts.Name + " stopped")
+	return nil
+}
+
+// drain stops the service's background work ahead of the HTTP server
+func (a *App) drain() {
+	a.stopSchedule() // Hand singleton leadership to another replica
+	if a.GRPC != nil {
+		a.GRPC.GracefulStop()
+	}
+
+	// Let in-flight jobs settle; jobs still running are redelivered
+	ctx, cancel := context.WithTimeout(context.Background(), a.poolConfig.DrainTimeout)
+	defer cancel()
+	a.Queue.Shutdown(ctx)
+}
+
+// Close stops the service's workers and closes its connections
+func (a *App) Close() {
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		a.closers[i]()
+	}
+	if a.stopSchedule != nil {
+		a.stopSchedule()
+	}
+	if a.Queue != nil {
+		a.Queue.Close()
+	}
+	if a.Redis != nil {
+		a.Redis.Close()
+	}
+	if a.DB != nil {
+		a.DB.Close()
+	}
+}
@@ -0,0 +1,282 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"hedge-fund/pkg/shared/queue"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request latency
+// histogram
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics counts a service's HTTP requests and their latency, and exposes
+// them with its worker and database pool stats in the Prometheus text format
+type Metrics struct {
+	service  string
+	workers  func() []queue.PoolStats
+	reaped   func() map[string]int64 // Stuck jobs reaped by queue; nil when not reaping
+	database databasePool            // Nil for services without a database
+	started  time.Time
+
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	latencies map[routeKey]*histogram
+}
+
+// databasePool reports a database connection pool, as *database.DB does
+type databasePool interface {
+	Stats() sql.DBStats
+	Up() bool
+}
+
+type routeKey struct {
+	method string
+	route  string
+}
+
+type requestKey struct {
+	routeKey
+	status int
+}
+
+type histogram struct {
+	buckets []uint64 // Cumulative counts by latencyBuckets
+	count   uint64
+	sum     float64
+}
+
+// NewMetrics creates metrics of service. workers reports its worker pools,
+// and may be nil.
+func NewMetrics(service string, workers func() []queue.PoolStats) *Metrics {
+	return &Metrics{
+		service:   service,
+		workers:   workers,
+		started:   time.Now(),
+		requests:  make(map[requestKey]uint64),
+		latencies: make(map[routeKey]*histogram),
+	}
+}
+
+// Middleware records each request by method, route template and status.
+// Requests matching no route are recorded under "unmatched", so that
+// arbitrary paths do not grow the metrics.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.observe(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+func (m *Metrics) observe(method, route string, status int, elapsed time.Duration) {
+	key := routeKey{method: method, route: route}
+	seconds := elapsed.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{routeKey: key, status: status}]++
+	h, ok := m.latencies[key]
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(latencyBuckets))}
+		m.latencies[key] = h
+	}
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// GetMetrics godoc
+// @Summary Service metrics
+// @Description Request counts and latencies, worker and database pool stats and process stats in the Prometheus text format
+// @Tags metrics
+// @Produce plain
+// @Success 200 {string} string
+// @Router /metrics [get]
+func (m *Metrics) GetMetrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	m.Write(c.Writer)
+}
+
+// Write writes the metrics to w in the Prometheus text format
+func (m *Metrics) Write(w io.Writer) {
+	service := labels("service", m.service)
+
+	m.mu.Lock()
+	requests := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		requests = append(requests, key)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].routeKey != requests[j].routeKey {
+			return requests[i].routeKey.less(requests[j].routeKey)
+		}
+		return requests[i].status < requests[j].status
+	})
+	writeHeader(w, "http_requests_total", "counter", "HTTP requests handled, by method, route and status.")
+	for _, key := range requests {
+		fmt.Fprintf(w, "http_requests_total{%s,%s} %d\n", service,
+			labels("method", key.method, "route", key.route, "status", strconv.Itoa(key.status)), m.requests[key])
+	}
+
+	routes := make([]routeKey, 0, len(m.latencies))
+	for key := range m.latencies {
+		routes = append(routes, key)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].less(routes[j]) })
+	writeHeader(w, "http_request_duration_seconds", "histogram", "Latency of HTTP requests, by method and route.")
+	for _, key := range routes {
+		h := m.latencies[key]
+		route := labels("method", key.method, "route", key.route)
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(w, "http_request_duration_seconds_bucket{%s,%s,le=%q} %d\n", service, route,
+				strconv.FormatFloat(bound, 'g', -1, 64), h.buckets[i])
+		}
+		fmt.Fprintf(w, "http_request_duration_seconds_bucket{%s,%s,le=\"+Inf\"} %d\n", service, route, h.count)
+		fmt.Fprintf(w, "http_request_duration_seconds_sum{%s,%s} %g\n", service, route, h.sum)
+		fmt.Fprintf(w, "http_request_duration_seconds_count{%s,%s} %d\n", service, route, h.count)
+	}
+	m.mu.Unlock()
+
+	if m.workers != nil {
+		m.writeWorkers(w, service, m.workers())
+	}
+	if m.reaped != nil {
+		m.writeReaped(w, service, m.reaped())
+	}
+	if m.database != nil {
+		m.writeDatabase(w, service, m.database)
+	}
+
+	writeHeader(w, "process_uptime_seconds", "gauge", "Time since the service started.")
+	fmt.Fprintf(w, "process_uptime_seconds{%s} %g\n", service, time.Since(m.started).Seconds())
+	writeHeader(w, "go_goroutines", "gauge", "Number of goroutines.")
+	fmt.Fprintf(w, "go_goroutines{%s} %d\n", service, runtime.NumGoroutine())
+}
+
+func (m *Metrics) writeWorkers(w io.Writer, service string, pools []queue.PoolStats) {
+	writeHeader(w, "worker_pool_workers", "gauge", "Workers in each job queue's pool.")
+	for _, p := range pools {
+		fmt.Fprintf(w, "worker_pool_workers{%s,%s} %d\n", service, labels("queue", p.Queue), p.Workers)
+	}
+	writeHeader(w, "worker_pool_active_workers", "gauge", "Workers processing a job.")
+	for _, p := range pools {
+		fmt.Fprintf(w, "worker_pool_active_workers{%s,%s} %d\n", service, labels("queue", p.Queue), p.ActiveWorkers)
+	}
+	writeHeader(w, "worker_pool_stuck_workers", "gauge", "Workers holding a job past its timeout.")
+	for _, p := range pools {
+		fmt.Fprintf(w, "worker_pool_stuck_workers{%s,%s} %d\n", service, labels("queue", p.Queue), p.StuckWorkers)
+	}
+
+	writeHeader(w, "worker_jobs_processed_total", "counter", "Jobs attempted, by queue and job type.")
+	for _, p := range pools {
+		for _, jobType := range sortedJobTypes(p.Jobs) {
+			fmt.Fprintf(w, "worker_jobs_processed_total{%s,%s} %d\n", service,
+				labels("queue", p.Queue, "job_type", jobType), p.Jobs[jobType].Processed)
+		}
+	}
+	writeHeader(w, "worker_jobs_failed_total", "counter", "Job attempts that failed, including timeouts, by queue and job type.")
+	for _, p := range pools {
+		for _, jobType := range sortedJobTypes(p.Jobs) {
+			fmt.Fprintf(w, "worker_jobs_failed_total{%s,%s} %d\n", service,
+				labels("queue", p.Queue, "job_type", jobType), p.Jobs[jobType].Failed)
+		}
+	}
+}
+
+func (m *Metrics) writeReaped(w io.Writer, service string, reaped map[string]int64) {
+	queues := make([]string, 0, len(reaped))
+	for queue := range reaped {
+		queues = append(queues, queue)
+	}
+	sort.Strings(queues)
+
+	writeHeader(w, "worker_jobs_reaped_total", "counter", "Stuck jobs whose heartbeat went stale, retried or failed by the reaper, by queue.")
+	for _, queue := range queues {
+		fmt.Fprintf(w, "worker_jobs_reaped_total{%s,%s} %d\n", service, labels("queue", queue), reaped[queue])
+	}
+}
+
+func (m *Metrics) writeDatabase(w io.Writer, service string, db databasePool) {
+	stats := db.Stats()
+	up := 0
+	if db.Up() {
+		up = 1
+	}
+
+	writeHeader(w, "db_up", "gauge", "Whether the database was reachable at the last connectivity check.")
+	fmt.Fprintf(w, "db_up{%s} %d\n", service, up)
+	writeHeader(w, "db_pool_max_open_connections", "gauge", "Maximum open database connections.")
+	fmt.Fprintf(w, "db_pool_max_open_connections{%s} %d\n", service, stats.MaxOpenConnections)
+	writeHeader(w, "db_pool_open_connections", "gauge", "Open database connections, in use or idle.")
+	fmt.Fprintf(w, "db_pool_open_connections{%s} %d\n", service, stats.OpenConnections)
+	writeHeader(w, "db_pool_in_use_connections", "gauge", "Database connections in use.")
+	fmt.Fprintf(w, "db_pool_in_use_connections{%s} %d\n", service, stats.InUse)
+	writeHeader(w, "db_pool_idle_connections", "gauge", "Idle database connections.")
+	fmt.Fprintf(w, "db_pool_idle_connections{%s} %d\n", service, stats.Idle)
+	writeHeader(w, "db_pool_wait_count_total", "counter", "Waits for a free database connection.")
+	fmt.Fprintf(w, "db_pool_wait_count_total{%s} %d\n", service, stats.WaitCount)
+	writeHeader(w, "db_pool_wait_duration_seconds_total", "counter", "Time spent waiting for a free database connection.")
+	fmt.Fprintf(w, "db_pool_wait_duration_seconds_total{%s} %g\n", service, stats.WaitDuration.Seconds())
+	writeHeader(w, "db_pool_closed_connections_total", "counter", "Database connections closed by the pool, by reason.")
+	fmt.Fprintf(w, "db_pool_closed_connections_total{%s,%s} %d\n", service, labels("reason", "max_idle"), stats.MaxIdleClosed)
+	fmt.Fprintf(w, "db_pool_closed_connections_total{%s,%s} %d\n", service, labels("reason", "max_idle_time"), stats.MaxIdleTimeClosed)
+	fmt.Fprintf(w, "db_pool_closed_connections_total{%s,%s} %d\n", service, labels("reason", "max_lifetime"), stats.MaxLifetimeClosed)
+}
+
+func (k routeKey) less(other routeKey) bool {
+	if k.route != other.route {
+		return k.route < other.route
+	}
+	return k.method < other.method
+}
+
+func sortedJobTypes(jobs map[string]queue.JobStats) []string {
+	types := make([]string, 0, len(jobs))
+	for jobType := range jobs {
+		types = append(types, jobType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// labelEscaper escapes label values as the text format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats name/value pairs as comma-separated labels
+func labels(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"hedge-fund/pkg/shared/apierror"
+)
+
+// rateLimitWindow is the fixed window requests are counted in
+const rateLimitWindow = time.Minute
+
+// rateLimiter limits the requests of each client IP in fixed one-minute
+// windows. The limit is read on every request, so it follows config reloads.
+type rateLimiter struct {
+	limit func() int // Requests per window; 0 disables
+	now   func() time.Time
+
+	mu     sync.Mutex
+	window time.Time
+	counts map[string]int // In the current window, by client
+}
+
+func newRateLimiter(limit func() int) *rateLimiter {
+	return &rateLimiter{limit: limit, now: time.Now, counts: make(map[string]int)}
+}
+
+// Middleware answers 429 Too Many Requests, with the seconds until the next
+// window in Retry-After, to clients over the limit
+func (l *rateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := l.limit()
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		if retryAfter, ok := l.allow(c.ClientIP(), limit); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			apierror.Abort(c, http.StatusTooManyRequests, apierror.Response{
+				Error:   "Rate limit exceeded",
+				Details: strconv.Itoa(limit) + " requests per minute",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// allow counts a request from client, returning whether it is within limit
+// and otherwise the time until the window ends
+func (l *rateLimiter) allow(client string, limit int) (time.Duration, bool) {
+	now := l.now()
+	window := now.Truncate(rateLimitWindow)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !window.Equal(l.window) {
+		l.window = window
+		l.counts = make(map[string]int)
+	}
+	if l.counts[client] >= limit {
+		return window.Add(rateLimitWindow).Sub(now), false
+	}
+	l.counts[client]++
+	return 0, true
+}
@@ -0,0 +1,68 @@
+// Package server bootstraps a service: configuration, logging, connections
+// to the database, Redis and the job queue, the middleware stack, health
+// probes, metrics and graceful shutdown. A service only registers its
+// dependencies and routes on the App it is given.
+package server
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/logger"
+)
+
+// RunFunc serves until ctx is cancelled, then shuts down gracefully
+type RunFunc func(ctx context.Context, cfg *config.Config) error
+
+// Main loads the configuration, initializes the logger and runs fns until
+// SIGINT or SIGTERM, or until one of them fails
+func Main(fns ...RunFunc) error {
+	cfg := config.Load()
+	if err := logger.Init(cfg.LogLevel, cfg.Env); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	defer logger.Sync()
+
+	// Tunables follow the config file without a restart
+	cfg.OnReload(func(t config.Tunables) {
+		logger.SetLevel(t.LogLevel)
+	})
+	cfg.Watch()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := Group(ctx, cfg, fns...); err != nil {
+		logger.Error("Service failed", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Group runs fns concurrently until ctx is cancelled. One that fails stops
+// the others, and its error is returned once all have stopped.
+func Group(ctx context.Context, cfg *config.Config, fns ...RunFunc) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(fns))
+	for _, fn := range fns {
+		go func(fn RunFunc) {
+			err := fn(ctx, cfg)
+			cancel()
+			errs <- err
+		}(fn)
+	}
+
+	var first error
+	for range fns {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
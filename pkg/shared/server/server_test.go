@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/queue"
+)
+
+func TestGroup(t *testing.T) {
+	// A failure stops the other services, and is what Group returns
+	stopped := make(chan struct{})
+	err := Group(context.Background(), &config.Config{},
+		func(ctx context.Context, cfg *config.Config) error {
+			<-ctx.Done()
+			close(stopped)
+			return nil
+		},
+		func(ctx context.Context, cfg *config.Config) error {
+			return errors.New("port in use")
+		},
+	)
+	assert.EqualError(t, err, "port in use")
+	_, running := <-stopped
+	assert.False(t, running)
+
+	// Services stop cleanly once ctx is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Group(ctx, &config.Config{}, func(ctx context.Context, cfg *config.Config) error {
+		<-ctx.Done()
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewMetrics("market-data-service", func() []queue.PoolStats {
+		return []queue.PoolStats{{
+			Queue:         "market_data",
+			Workers:       4,
+			ActiveWorkers: 1,
+			StuckWorkers:  1,
+			Jobs:          map[string]queue.JobStats{"refresh_bars": {Processed: 12, Failed: 2}},
+		}}
+	})
+	m.reaped = func() map[string]int64 { return map[string]int64{"market_data": 3} }
+	r := gin.New()
+	r.Use(m.Middleware())
+	r.GET("/api/v1/market/:symbol/bars", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/metrics", m.GetMetrics)
+
+	for _, path := range []string{"/api/v1/market/AAPL/bars", "/api/v1/market/MSFT/bars", "/unknown"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	body := w.Body.String()
+
+	// Requests are counted by route template, not path
+	assert.Contains(t, body, "# TYPE http_requests_total counter\n")
+	assert.Contains(t, body, `http_requests_total{service="market-data-service",method="GET",route="/api/v1/market/:symbol/bars",status="200"} 2`)
+	assert.Contains(t, body, `http_requests_total{service="market-data-service",method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_bucket{service="market-data-service",method="GET",route="/api/v1/market/:symbol/bars",le="+Inf"} 2`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{service="market-data-service",method="GET",route="/api/v1/market/:symbol/bars"} 2`)
+
+	assert.Contains(t, body, `worker_pool_workers{service="market-data-service",queue="market_data"} 4`)
+	assert.Contains(t, body, `worker_pool_active_workers{service="market-data-service",queue="market_data"} 1`)
+	assert.Contains(t, body, `worker_pool_stuck_workers{service="market-data-service",queue="market_data"} 1`)
+	assert.Contains(t, body, `worker_jobs_reaped_total{service="market-data-service",queue="market_data"} 3`)
+	assert.Contains(t, body, `worker_jobs_processed_total{service="market-data-service",queue="market_data",job_type="refresh_bars"} 12`)
+	assert.Contains(t, body, `worker_jobs_failed_total{service="market-data-service",queue="market_data",job_type="refresh_bars"} 2`)
+	assert.Contains(t, body, "# TYPE go_goroutines gauge\n")
+	assert.NotContains(t, body, "db_pool_")
+}
+
+type fakePool struct {
+	stats sql.DBStats
+	up    bool
+}
+
+func (p fakePool) Stats() sql.DBStats { return p.stats }
+func (p fakePool) Up() bool           { return p.up }
+
+func TestMetricsDatabase(t *testing.T) {
+	m := NewMetrics("portfolio-service", nil)
+	m.database = fakePool{stats: sql.DBStats{
+		MaxOpenConnections: 25,
+		OpenConnections:    7,
+		InUse:              5,
+		Idle:               2,
+		WaitCount:          3,
+		WaitDuration:       1500 * time.Millisecond,
+		MaxLifetimeClosed:  4,
+	}}
+
+	var b strings.Builder
+	m.Write(&b)
+	body := b.String()
+
+	assert.Contains(t, body, `db_up{service="portfolio-service"} 0`)
+	assert.Contains(t, body, `db_pool_max_open_connections{service="portfolio-service"} 25`)
+	assert.Contains(t, body, `db_pool_open_connections{service="portfolio-service"} 7`)
+	assert.Contains(t, body, `db_pool_in_use_connections{service="portfolio-service"} 5`)
+	assert.Contains(t, body, `db_pool_idle_connections{service="portfolio-service"} 2`)
+	assert.Contains(t, body, "# TYPE db_pool_wait_count_total counter\n")
+	assert.Contains(t, body, `db_pool_wait_count_total{service="portfolio-service"} 3`)
+	assert.Contains(t, body, `db_pool_wait_duration_seconds_total{service="portfolio-service"} 1.5`)
+	assert.Contains(t, body, `db_pool_closed_connections_total{service="portfolio-service",reason="max_lifetime"} 4`)
+}
+
+func TestLabels(t *testing.T) {
+	assert.Equal(t, `route="/a",note="say \"hi\"\\n"`, labels("route", "/a", "note", `say "hi"`+`\n`))
+	assert.Equal(t, `note="line\nbreak"`, labels("note", "line\nbreak"))
+}
+
+func TestRateLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limit := 2
+	limiter := newRateLimiter(func() int { return limit })
+	now := time.Date(2024, 3, 1, 14, 30, 15, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	r := gin.New()
+	r.Use(limiter.Middleware())
+	r.GET("/api/v1/quotes", func(c *gin.Context) { c.Status(http.StatusOK) })
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/quotes", nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, get().Code)
+	assert.Equal(t, http.StatusOK, get().Code)
+	w := get()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "45", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), `"code":"RATE_LIMITED"`)
+
+	// A new window starts the count again
+	now = now.Add(time.Minute)
+	assert.Equal(t, http.StatusOK, get().Code)
+
+	// Disabling the limit by reload takes effect on the next request
+	limit = 0
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, get().Code)
+	}
+}
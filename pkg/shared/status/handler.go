@@ -0,0 +1,189 @@
+package status
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/apierror"
+	"hedge-fund/pkg/shared/logger"
+)
+
+// OpenIncidentRequest is the admin API payload for declaring an incident
+type OpenIncidentRequest struct {
+	Title      string   `json:"title" binding:"required"`
+	Impact     Level    `json:"impact" binding:"required,oneof=degraded down"`
+	Components []string `json:"components" binding:"required,min=1"`
+	Message    string   `json:"message" binding:"required"`
+	CreatedBy  string   `json:"created_by"`
+}
+
+// IncidentUpdateRequest is the admin API payload for adding to an incident's
+// timeline. A status of resolved closes the incident.
+type IncidentUpdateRequest struct {
+	Status    string `json:"status" binding:"required,oneof=investigating identified monitoring resolved"`
+	Message   string `json:"message" binding:"required"`
+	CreatedBy string `json:"created_by"`
+}
+
+// GetStatus godoc
+// @Summary Get system status
+// @Description Public status of every component, derived from health checks and SLO burn, with open and recently resolved incidents
+// @Tags status
+// @Produce json
+// @Success 200 {object} Page
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/status [get]
+func (m *Manager) GetStatus(c *gin.Context) {
+	page, err := m.Page(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to build status page", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to get status", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetStatusPage godoc
+// @Summary Status page
+// @Description Human-readable version of /api/v1/status
+// @Tags status
+// @Produce html
+// @Success 200 {string} string
+// @Router /status [get]
+func (m *Manager) GetStatusPage(c *gin.Context) {
+	page, err := m.Page(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to build status page", zap.Error(err))
+		c.String(http.StatusInternalServerError, "Status is temporarily unavailable")
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := pageTemplate.Execute(c.Writer, page); err != nil {
+		logger.Warn("Failed to render status page", zap.Error(err))
+	}
+}
+
+// ListIncidents godoc
+// @Summary List incidents
+// @Description Every incident, open and resolved, newest first
+// @Tags admin
+// @Produce json
+// @Success 200 {array} Incident
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/incidents [get]
+func (m *Manager) ListIncidents(c *gin.Context) {
+	incidents, err := m.Incidents(c.Request.Context())
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to list incidents", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, incidents)
+}
+
+// CreateIncident godoc
+// @Summary Open an incident
+// @Description Declare an incident; affected components show at least its impact until it is resolved
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body OpenIncidentRequest true "Incident"
+// @Success 201 {object} Incident
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/incidents [post]
+func (m *Manager) CreateIncident(c *gin.Context) {
+	var req OpenIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	incident, err := m.OpenIncident(c.Request.Context(), req.Title, req.Impact, req.Components, req.Message, req.CreatedBy)
+	if err != nil {
+		writeIncidentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, incident)
+}
+
+// AddIncidentUpdate godoc
+// @Summary Update an incident
+// @Description Add a timeline entry to an open incident; a status of resolved closes it
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Incident ID"
+// @Param request body IncidentUpdateRequest true "Timeline entry"
+// @Success 200 {object} Incident
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/incidents/{id}/updates [post]
+func (m *Manager) AddIncidentUpdate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid incident ID"})
+		return
+	}
+
+	var req IncidentUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid request", Details: err.Error()})
+		return
+	}
+
+	incident, err := m.UpdateIncident(c.Request.Context(), id, req.Status, req.Message, req.CreatedBy)
+	if err != nil {
+		writeIncidentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, incident)
+}
+
+func writeIncidentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidIncident):
+		apierror.Respond(c, http.StatusBadRequest, apierror.Response{Error: "Invalid incident", Details: err.Error()})
+	case errors.Is(err, ErrIncidentNotFound):
+		apierror.Respond(c, http.StatusNotFound, apierror.Response{Error: "Incident not found"})
+	default:
+		logger.Error("Failed to save incident", zap.Error(err))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Response{Error: "Failed to save incident", Details: err.Error()})
+	}
+}
+
+var pageTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>System Status</title>
+<style>
+body { font-family: sans-serif; max-width: 720px; margin: 2em auto; color: #222; }
+.operational { color: #1a7f37; } .degraded { color: #9a6700; } .down { color: #cf222e; }
+table { width: 100%; border-collapse: collapse; } td { padding: 0.4em 0; border-bottom: 1px solid #eee; }
+.update { margin-left: 1em; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1 class="{{.Status}}">System {{.Status}}</h1>
+<table>
+{{range .Components}}<tr><td>{{.Name}}</td><td class="{{.Status}}">{{.Status}}</td></tr>
+{{end}}</table>
+<h2>Incidents</h2>
+{{range .Incidents}}<h3>{{.Title}} <span class="{{.Impact}}">({{.Status}})</span></h3>
+{{range .Updates}}<p class="update"><strong>{{.Status}}</strong> {{.CreatedAt.Format "2006-01-02 15:04 MST"}}: {{.Message}}</p>
+{{end}}{{else}}<p>No incidents in the last 7 days.</p>
+{{end}}<p><small>Updated {{.UpdatedAt.Format "2006-01-02 15:04:05 MST"}}</small></p>
+</body>
+</html>
+`))
@@ -0,0 +1,380 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"hedge-fund/pkg/shared/config"
+	"hedge-fund/pkg/shared/logger"
+	"hedge-fund/pkg/shared/models"
+	"hedge-fund/pkg/shared/redis"
+	"hedge-fund/pkg/shared/registry"
+)
+
+// Level is the status of a component or of the whole system
+type Level string
+
+const (
+	Operational Level = "operational"
+	Degraded    Level = "degraded"
+	Down        Level = "down"
+)
+
+// Incident statuses, in the order an incident usually moves through them
+const (
+	IncidentInvestigating = "investigating"
+	IncidentIdentified    = "identified"
+	IncidentMonitoring    = "monitoring"
+	IncidentResolved      = "resolved"
+)
+
+const (
+	incidentsKey   = "status:incidents"
+	incidentSeqKey = "status:incident_seq"
+
+	// Events published on models.ChannelSystemEvents
+	EventIncidentOpened   = "incident_opened"
+	EventIncidentUpdated  = "incident_updated"
+	EventIncidentResolved = "incident_resolved"
+
+	// Resolved incidents stay on the status page this long
+	resolvedRetention = 7 * 24 * time.Hour
+
+	// GatewayComponent is the gateway itself, operational while it serves the page
+	GatewayComponent = "api-gateway"
+)
+
+var (
+	ErrIncidentNotFound = errors.New("incident not found")
+	ErrInvalidIncident  = errors.New("invalid incident")
+)
+
+// Component is the status of one service
+type Component struct {
+	Name             string  `json:"name"`
+	Status           Level   `json:"status"`
+	HealthyEndpoints int     `json:"healthy_endpoints"`
+	TotalEndpoints   int     `json:"total_endpoints"`
+	Availability     float64 `json:"availability"` // Over the last registry.ProbeWindow health probes
+	BurnRate         float64 `json:"burn_rate"`    // Error budget consumption; 1 spends it exactly over the window
+}
+
+// IncidentUpdate is an entry in an incident's timeline
+type IncidentUpdate struct {
+	Status    string    `json:"status"`
+	Message   string    `json:"message"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Incident is an outage or degradation declared through the admin API.
+// While open it raises its components to at least its impact.
+type Incident struct {
+	ID         int              `json:"id"`
+	Title      string           `json:"title"`
+	Impact     Level            `json:"impact"`
+	Status     string           `json:"status"`
+	Components []string         `json:"components"`
+	Updates    []IncidentUpdate `json:"updates"` // Oldest first
+	CreatedAt  time.Time        `json:"created_at"`
+	ResolvedAt *time.Time       `json:"resolved_at,omitempty"`
+}
+
+// Open reports whether the incident has not been resolved
+func (i *Incident) Open() bool {
+	return i.Status != IncidentResolved
+}
+
+// Page is the public status of the system
+type Page struct {
+	Status     Level       `json:"status"`
+	Components []Component `json:"components"`
+	Incidents  []Incident  `json:"incidents"` // Open and recently resolved, newest first
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// Manager derives component status from the service registry's health
+// checks and stores incidents in Redis so every gateway instance serves the
+// same page
+type Manager struct {
+	redis       *redis.Client
+	services    *registry.Registry
+	sloTarget   float64
+	maxBurnRate float64
+}
+
+// NewManager creates a status manager
+func NewManager(redisClient *redis.Client, services *registry.Registry, cfg *config.Config) *Manager {
+	return &Manager{
+		redis:       redisClient,
+		services:    services,
+		sloTarget:   cfg.StatusSLOTarget / 100,
+		maxBurnRate: cfg.StatusMaxBurnRate,
+	}
+}
+
+// Page returns the current status of every component with open and recently
+// resolved incidents
+func (m *Manager) Page(ctx context.Context) (*Page, error) {
+	incidents, err := m.Incidents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	page := &Page{Incidents: []Incident{}, UpdatedAt: now}
+	for _, incident := range incidents {
+		if incident.Open() || (incident.ResolvedAt != nil && now.Sub(*incident.ResolvedAt) < resolvedRetention) {
+			page.Incidents = append(page.Incidents, incident)
+		}
+	}
+
+	page.Components = append(page.Components, Component{Name: GatewayComponent, Status: Operational, HealthyEndpoints: 1, TotalEndpoints: 1, Availability: 1})
+	for service, endpoints := range m.services.Endpoints() {
+		if len(endpoints) == 0 {
+			continue // Not deployed
+		}
+		healthy := 0
+		for _, endpoint := range endpoints {
+			if endpoint.Healthy {
+				healthy++
+			}
+		}
+		availability, _ := m.services.Availability(service)
+		burnRate := BurnRate(availability, m.sloTarget)
+		page.Components = append(page.Components, Component{
+			Name:             service,
+			Status:           Derive(healthy, len(endpoints), burnRate, m.maxBurnRate),
+			HealthyEndpoints: healthy,
+			TotalEndpoints:   len(endpoints),
+			Availability:     availability,
+			BurnRate:         burnRate,
+		})
+	}
+	sort.Slice(page.Components, func(i, j int) bool { return page.Components[i].Name < page.Components[j].Name })
+
+	ApplyIncidents(page.Components, incidents)
+	page.Status = Overall(page.Components)
+	return page, nil
+}
+
+// Incidents returns every stored incident, newest first
+func (m *Manager) Incidents(ctx context.Context) ([]Incident, error) {
+	fields, err := m.redis.HGetAll(ctx, incidentsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read incidents: %w", err)
+	}
+
+	incidents := make([]Incident, 0, len(fields))
+	for id, data := range fields {
+		var incident Incident
+		if err := json.Unmarshal([]byte(data), &incident); err != nil {
+			logger.Warn("Failed to decode incident", zap.String("incident_id", id), zap.Error(err))
+			continue
+		}
+		incidents = append(incidents, incident)
+	}
+	sort.Slice(incidents, func(i, j int) bool { return incidents[i].CreatedAt.After(incidents[j].CreatedAt) })
+	return incidents, nil
+}
+
+// Incident returns a stored incident
+func (m *Manager) Incident(ctx context.Context, id int) (*Incident, error) {
+	data, err := m.redis.HGet(ctx, incidentsKey, strconv.Itoa(id)).Bytes()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return nil, fmt.Errorf("%w: %d", ErrIncidentNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read incident: %w", err)
+	}
+
+	var incident Incident
+	if err := json.Unmarshal(data, &incident); err != nil {
+		return nil, fmt.Errorf("failed to decode incident %d: %w", id, err)
+	}
+	return &incident, nil
+}
+
+// OpenIncident declares an incident affecting components, with its first
+// timeline entry
+func (m *Manager) OpenIncident(ctx context.Context, title string, impact Level, components []string, message, createdBy string) (*Incident, error) {
+	if title == "" {
+		return nil, fmt.Errorf("%w: title is required", ErrInvalidIncident)
+	}
+	if impact != Degraded && impact != Down {
+		return nil, fmt.Errorf("%w: impact must be %s or %s", ErrInvalidIncident, Degraded, Down)
+	}
+	if len(components) == 0 {
+		return nil, fmt.Errorf("%w: at least one component is required", ErrInvalidIncident)
+	}
+	known := m.services.Endpoints()
+	for _, name := range components {
+		if _, ok := known[name]; !ok && name != GatewayComponent {
+			return nil, fmt.Errorf("%w: unknown component %q", ErrInvalidIncident, name)
+		}
+	}
+
+	id, err := m.redis.Incr(ctx, incidentSeqKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate incident ID: %w", err)
+	}
+
+	now := time.Now()
+	incident := &Incident{
+		ID:         int(id),
+		Title:      title,
+		Impact:     impact,
+		Status:     IncidentInvestigating,
+		Components: components,
+		Updates:    []IncidentUpdate{{Status: IncidentInvestigating, Message: message, CreatedBy: createdBy, CreatedAt: now}},
+		CreatedAt:  now,
+	}
+	if err := m.save(ctx, incident); err != nil {
+		return nil, err
+	}
+
+	m.publish(ctx, EventIncidentOpened, incident)
+	logger.Warn("Incident opened",
+		zap.Int("incident_id", incident.ID),
+		zap.String("title", title),
+		zap.String("impact", string(impact)),
+		zap.Strings("components", components))
+	return incident, nil
+}
+
+// UpdateIncident adds a timeline entry and moves the incident to status.
+// Moving it to IncidentResolved closes it; resolved incidents cannot be
+// updated.
+func (m *Manager) UpdateIncident(ctx context.Context, id int, status, message, createdBy string) (*Incident, error) {
+	switch status {
+	case IncidentInvestigating, IncidentIdentified, IncidentMonitoring, IncidentResolved:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidIncident, status)
+	}
+	if message == "" {
+		return nil, fmt.Errorf("%w: message is required", ErrInvalidIncident)
+	}
+
+	incident, err := m.Incident(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !incident.Open() {
+		return nil, fmt.Errorf("%w: incident %d is resolved", ErrInvalidIncident, id)
+	}
+
+	now := time.Now()
+	incident.Status = status
+	incident.Updates = append(incident.Updates, IncidentUpdate{Status: status, Message: message, CreatedBy: createdBy, CreatedAt: now})
+	if status == IncidentResolved {
+		incident.ResolvedAt = &now
+	}
+	if err := m.save(ctx, incident); err != nil {
+		return nil, err
+	}
+
+	eventType := EventIncidentUpdated
+	if status == IncidentResolved {
+		eventType = EventIncidentResolved
+	}
+	m.publish(ctx, eventType, incident)
+	logger.Info("Incident updated", zap.Int("incident_id", id), zap.String("status", status))
+	return incident, nil
+}
+
+// BurnRate is how fast a service spends its error budget: the observed
+// failure rate divided by the failure rate the SLO target allows
+func BurnRate(availability, target float64) float64 {
+	budget := 1 - target
+	if budget <= 0 {
+		return 0
+	}
+	return (1 - availability) / budget
+}
+
+// Derive returns a component's status from its endpoint health and SLO burn
+// rate. A component with no healthy endpoint is down; one with some unhealthy
+// endpoints or burning its error budget faster than maxBurnRate is degraded.
+func Derive(healthy, total int, burnRate, maxBurnRate float64) Level {
+	switch {
+	case healthy == 0:
+		return Down
+	case healthy < total, burnRate > maxBurnRate:
+		return Degraded
+	}
+	return Operational
+}
+
+// ApplyIncidents raises each component affected by an open incident to at
+// least the incident's impact
+func ApplyIncidents(components []Component, incidents []Incident) {
+	for _, incident := range incidents {
+		if !incident.Open() {
+			continue
+		}
+		for _, name := range incident.Components {
+			for i := range components {
+				if components[i].Name == name {
+					components[i].Status = worst(components[i].Status, incident.Impact)
+				}
+			}
+		}
+	}
+}
+
+// Overall returns the worst status of any component
+func Overall(components []Component) Level {
+	level := Operational
+	for _, component := range components {
+		level = worst(level, component.Status)
+	}
+	return level
+}
+
+// Helper functions
+
+var severity = map[Level]int{Operational: 0, Degraded: 1, Down: 2}
+
+func worst(a, b Level) Level {
+	if severity[b] > severity[a] {
+		return b
+	}
+	return a
+}
+
+func (m *Manager) save(ctx context.Context, incident *Incident) error {
+	data, err := json.Marshal(incident)
+	if err != nil {
+		return fmt.Errorf("failed to encode incident: %w", err)
+	}
+	if err := m.redis.HSet(ctx, incidentsKey, strconv.Itoa(incident.ID), data).Err(); err != nil {
+		return fmt.Errorf("failed to store incident: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) publish(ctx context.Context, eventType string, incident *Incident) {
+	event := models.Event{
+		Type:      eventType,
+		Source:    "status_manager",
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"incident_id": incident.ID,
+			"title":       incident.Title,
+			"impact":      incident.Impact,
+			"status":      incident.Status,
+			"components":  incident.Components,
+		},
+	}
+
+	if err := m.redis.PublishEvent(ctx, models.ChannelSystemEvents, event); err != nil {
+		logger.Warn("Failed to publish incident event", zap.Error(err))
+	}
+}
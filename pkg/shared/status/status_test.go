@@ -0,0 +1,47 @@
+package status
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBurnRate(t *testing.T) {
+	assert.Equal(t, 0.0, BurnRate(1, 0.995))
+	assert.InDelta(t, 1.0, BurnRate(0.995, 0.995), 1e-9)
+	assert.InDelta(t, 10.0, BurnRate(0.95, 0.995), 1e-9)
+	assert.Equal(t, 0.0, BurnRate(0.5, 1)) // No error budget to burn
+}
+
+func TestDerive(t *testing.T) {
+	assert.Equal(t, Operational, Derive(2, 2, 0.5, 2))
+	assert.Equal(t, Degraded, Derive(1, 2, 0, 2))
+	assert.Equal(t, Degraded, Derive(2, 2, 3, 2))
+	assert.Equal(t, Down, Derive(0, 2, 0, 2))
+}
+
+func TestApplyIncidentsRaisesAffectedComponents(t *testing.T) {
+	components := []Component{
+		{Name: "portfolio-service", Status: Operational},
+		{Name: "risk-service", Status: Down},
+		{Name: "market-data-service", Status: Operational},
+	}
+	resolvedAt := time.Now()
+	incidents := []Incident{
+		{Impact: Degraded, Status: IncidentInvestigating, Components: []string{"portfolio-service", "risk-service"}},
+		{Impact: Down, Status: IncidentResolved, Components: []string{"market-data-service"}, ResolvedAt: &resolvedAt},
+	}
+
+	ApplyIncidents(components, incidents)
+
+	assert.Equal(t, Degraded, components[0].Status)
+	assert.Equal(t, Down, components[1].Status) // Never lowered by an incident
+	assert.Equal(t, Operational, components[2].Status)
+	assert.Equal(t, Down, Overall(components))
+}
+
+func TestOverallOfHealthyComponents(t *testing.T) {
+	assert.Equal(t, Operational, Overall(nil))
+	assert.Equal(t, Degraded, Overall([]Component{{Status: Operational}, {Status: Degraded}}))
+}
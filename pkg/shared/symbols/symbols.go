@@ -0,0 +1,117 @@
+package symbols
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+)
+
+// MaxLength is the longest symbol the database columns hold
+const MaxLength = 20
+
+// ErrInvalid is returned for symbols that are empty, too long or contain
+// characters no listed instrument uses
+var ErrInvalid = errors.New("invalid symbol")
+
+var (
+	// Share classes are written with a dot: BRK-B and BRK/B become BRK.B
+	shareClass = regexp.MustCompile(`^([A-Z]+)[-/]([A-Z])$`)
+	valid      = regexp.MustCompile(`^\^?[A-Z0-9][A-Z0-9.\-=/]*$`) // ^ prefixes indexes
+)
+
+// Normalize returns the canonical form of a symbol: trimmed, upper case and
+// with share classes separated by a dot. Every symbol should pass through it
+// before being stored, compared or used in a cache key.
+func Normalize(symbol string) string {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	return shareClass.ReplaceAllString(symbol, "$1.$2")
+}
+
+// Validate checks that a normalized symbol is well formed
+func Validate(symbol string) error {
+	if symbol == "" || len(symbol) > MaxLength || !valid.MatchString(symbol) {
+		return fmt.Errorf("%w: %q", ErrInvalid, symbol)
+	}
+	return nil
+}
+
+// NormalizeAll normalizes a list of symbols, dropping empty entries and
+// duplicates while keeping the first occurrence's position
+func NormalizeAll(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	normalized := make([]string, 0, len(list))
+	for _, symbol := range list {
+		symbol = Normalize(symbol)
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		normalized = append(normalized, symbol)
+	}
+	return normalized
+}
+
+// cryptoPair matches crypto pairs quoted in a fiat currency or stablecoin,
+// the way crypto venues write them: BTC-USD, ETH-USDT
+var cryptoPair = regexp.MustCompile(`^[A-Z0-9]{2,10}-(USD|USDT|USDC|EUR|GBP)$`)
+
+// IsCrypto reports whether a normalized symbol is a crypto pair rather than
+// a listed instrument
+func IsCrypto(symbol string) bool {
+	return cryptoPair.MatchString(symbol)
+}
+
+// optionSymbol matches equity options in the compact OCC form: the root,
+// the expiry as YYMMDD, C or P, and the strike in thousandths padded to
+// eight digits, e.g. AAPL240119C00150000
+var optionSymbol = regexp.MustCompile(`^([A-Z][A-Z0-9.]{0,4})(\d{6})([CP])(\d{8})$`)
+
+// IsOption reports whether a normalized symbol is an OCC option symbol
+func IsOption(symbol string) bool {
+	_, ok := ParseOption(symbol)
+	return ok
+}
+
+// ParseOption reads the terms of the contract an OCC option symbol names,
+// with the standard multiplier. It reports false for other symbols.
+func ParseOption(symbol string) (*models.OptionContract, bool) {
+	match := optionSymbol.FindStringSubmatch(symbol)
+	if match == nil {
+		return nil, false
+	}
+	expiry, err := time.Parse("060102", match[2])
+	if err != nil {
+		return nil, false
+	}
+	strike, err := strconv.ParseInt(match[4], 10, 64)
+	if err != nil || strike == 0 {
+		return nil, false
+	}
+
+	optionType := models.OptionCall
+	if match[3] == "P" {
+		optionType = models.OptionPut
+	}
+	return &models.OptionContract{
+		Underlying: match[1],
+		Type:       optionType,
+		Strike:     float64(strike) / 1000,
+		Expiry:     expiry,
+		Multiplier: models.DefaultOptionMultiplier,
+	}, true
+}
+
+// OptionSymbol returns the OCC symbol of a contract
+func OptionSymbol(contract *models.OptionContract) string {
+	right := "C"
+	if contract.Type == models.OptionPut {
+		right = "P"
+	}
+	return fmt.Sprintf("%s%s%s%08d", contract.Underlying, contract.Expiry.Format("060102"), right, int64(math.Round(contract.Strike*1000)))
+}
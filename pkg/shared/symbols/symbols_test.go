@@ -0,0 +1,69 @@
+package symbols
+
+import (
+	"testing"
+	"time"
+
+	"hedge-fund/pkg/shared/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"aapl":     "AAPL",
+		" AAPL ":   "AAPL",
+		"brk-b":    "BRK.B",
+		"BRK/B":    "BRK.B",
+		"BRK.B":    "BRK.B",
+		"BTC-USD":  "BTC-USD", // Not a share class
+		"\tmsft\n": "MSFT",
+	}
+	for input, want := range cases {
+		assert.Equal(t, want, Normalize(input), input)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("BRK.B"))
+	assert.NoError(t, Validate("^GSPC"))
+	assert.ErrorIs(t, Validate(""), ErrInvalid)
+	assert.ErrorIs(t, Validate("AAPL;DROP"), ErrInvalid)
+	assert.ErrorIs(t, Validate("A B"), ErrInvalid)
+	assert.ErrorIs(t, Validate("ABCDEFGHIJKLMNOPQRSTU"), ErrInvalid)
+}
+
+func TestNormalizeAll(t *testing.T) {
+	assert.Equal(t, []string{"AAPL", "BRK.B"}, NormalizeAll([]string{"aapl", " ", "BRK-B", "AAPL", "brk.b"}))
+}
+
+func TestIsCrypto(t *testing.T) {
+	assert.True(t, IsCrypto("BTC-USD"))
+	assert.True(t, IsCrypto("ETH-USDT"))
+	assert.False(t, IsCrypto("AAPL"))
+	assert.False(t, IsCrypto("BRK.B"))
+	assert.False(t, IsCrypto("BAC-PL"))
+}
+
+func TestParseOption(t *testing.T) {
+	contract, ok := ParseOption("AAPL240119C00150000")
+	assert.True(t, ok)
+	assert.Equal(t, "AAPL", contract.Underlying)
+	assert.Equal(t, models.OptionCall, contract.Type)
+	assert.Equal(t, 150.0, contract.Strike)
+	assert.Equal(t, time.Date(2024, 1, 19, 0, 0, 0, 0, time.UTC), contract.Expiry)
+	assert.Equal(t, float64(models.DefaultOptionMultiplier), contract.Multiplier)
+	assert.Equal(t, "AAPL240119C00150000", OptionSymbol(contract))
+
+	put, ok := ParseOption("SPY241220P00432500")
+	assert.True(t, ok)
+	assert.Equal(t, models.OptionPut, put.Type)
+	assert.Equal(t, 432.5, put.Strike)
+	assert.NoError(t, Validate("SPY241220P00432500"))
+
+	for _, symbol := range []string{"AAPL", "BTC-USD", "AAPL241340C00150000", "AAPL240119X00150000", "AAPL240119C00000000"} {
+		_, ok := ParseOption(symbol)
+		assert.False(t, ok, symbol)
+		assert.False(t, IsOption(symbol), symbol)
+	}
+}
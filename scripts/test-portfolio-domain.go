@@ -83,8 +83,8 @@ func main() {
 
 	// Test 3: Position Summary
 	fmt.Println("\n📈 Test 3: Position Summary Calculation")
-	positionSummary := ps.CalculatePositionSummary(&portfolio.Positions[0], 155.0)
-	fmt.Printf("✅ AAPL Position Summary: Symbol=%s, Quantity=%d, MarketValue=$%.2f, UnrealizedPnL=$%.2f\n",
+	positionSummary := ps.CalculatePositionSummary(&portfolio.Positions[0], nil, 155.0)
+	fmt.Printf("✅ AAPL Position Summary: Symbol=%s, Quantity=%v, MarketValue=$%.2f, UnrealizedPnL=$%.2f\n",
 		positionSummary.Symbol, positionSummary.NetQuantity, positionSummary.MarketValue, positionSummary.UnrealizedPnL)
 
 	// Test 4: Portfolio Allocation
@@ -161,7 +161,7 @@ func main() {
 
 	// Test 9: Risk Metrics
 	fmt.Println("\n⚠️ Test 9: Risk Metrics")
-	riskMetrics := ps.CalculateRiskMetrics(portfolio, currentPrices)
+	riskMetrics := ps.CalculateRiskMetrics(portfolio, currentPrices, nil)
 	fmt.Printf("✅ Risk Metrics:\n")
 	for key, value := range riskMetrics {
 		switch v := value.(type) {
@@ -182,7 +182,7 @@ func main() {
 	position, err := ps.ExecuteTradeOrder(buyTrade, portfolio, 142.0)
 	if err == nil && position != nil {
 		fmt.Printf("✅ Trade execution - PASSED\n")
-		fmt.Printf("   New position created: %s, Quantity: %d, Entry Price: $%.2f\n",
+		fmt.Printf("   New position created: %s, Quantity: %v, Entry Price: $%.2f\n",
 			position.Symbol, position.Quantity, position.EntryPrice)
 		fmt.Printf("   Cash before: $%.2f, Cash after: $%.2f\n", originalCash, portfolio.Cash)
 		fmt.Printf("   Positions before: %d, Positions after: %d\n", originalPositionCount, len(portfolio.Positions))
@@ -4,7 +4,7 @@ echo "=== Testing Portfolio Service API ==="
 echo ""
 
 echo "1. Health Check"
-curl -s http://localhost:8081/health | jq
+curl -s http://localhost:8081/readyz | jq
 echo ""
 
 echo "2. Create Portfolio"